@@ -0,0 +1,41 @@
+package display
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+
+	"github.com/yourusername/useq-ai-assistant/internal/diagnostics"
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// ShowAnalyzerDiagnostics lists ingested gopls and golangci-lint findings,
+// most serious first
+func ShowAnalyzerDiagnostics(list []*storage.AnalyzerDiagnostic, limit int) {
+	color.New(color.FgCyan, color.Bold).Printf("\n🔎 Analyzer diagnostics (%d)\n", len(list))
+	if len(list) == 0 {
+		fmt.Println("   None ingested; use 'diagnostics ingest <file>' or 'diagnostics refresh'")
+		return
+	}
+	for i, diagnostic := range list {
+		if limit > 0 && i == limit {
+			color.New(color.FgHiBlack).Printf("   ... and %d more; narrow with 'diagnostics <file>'\n", len(list)-limit)
+			break
+		}
+		severityColor := color.New(color.FgHiBlack)
+		switch diagnostic.Severity {
+		case storage.AnalyzerSeverityError:
+			severityColor = color.New(color.FgRed)
+		case storage.AnalyzerSeverityWarning:
+			severityColor = color.New(color.FgYellow)
+		}
+		severityColor.Print("   ● ")
+		fmt.Println(diagnostics.FormatOne(diagnostic))
+	}
+	fmt.Println("\n💡 Questions about these files or linters get the findings as context, e.g. \"why is errcheck flagging main.go?\"")
+}
+
+// ShowAnalyzerIngest reports how many diagnostics each source contributed
+func ShowAnalyzerIngest(counts map[string]int) {
+	color.New(color.FgGreen).Printf("✅ Ingested analyzer diagnostics: %s\n", diagnostics.FormatCounts(counts))
+}
@@ -0,0 +1,41 @@
+package display
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fatih/color"
+
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// writeAnswerVersion writes the index generation behind an answer and warns
+// when earlier answers in the session were built from an older index
+func writeAnswerVersion(w io.Writer, response *models.Response) {
+	meta := response.Metadata
+	if meta.IndexGeneration == 0 {
+		return
+	}
+
+	if meta.Fingerprint != "" {
+		fmt.Fprintf(w, "\n🆔 %s (index generation #%d, fingerprint %s)\n", response.ID, meta.IndexGeneration, meta.Fingerprint)
+	} else {
+		fmt.Fprintf(w, "\n🆔 %s (index generation #%d)\n", response.ID, meta.IndexGeneration)
+	}
+
+	if meta.Refreshes != "" {
+		if len(meta.ChangedSources) > 0 {
+			color.New(color.FgYellow).Fprintf(w, "🔄 Refreshed %s; these sources changed since: %s\n",
+				meta.Refreshes, strings.Join(meta.ChangedSources, ", "))
+		} else {
+			fmt.Fprintf(w, "🔄 Refreshed %s; none of its sources changed\n", meta.Refreshes)
+		}
+	}
+
+	if len(meta.StaleResponses) > 0 {
+		color.New(color.FgYellow).Fprintf(w, "⚠️ The index changed since %d earlier answer(s) in this session: %s\n",
+			len(meta.StaleResponses), strings.Join(meta.StaleResponses, ", "))
+		fmt.Fprintln(w, "   Run `refresh <id>` to regenerate one against the current index")
+	}
+}
@@ -0,0 +1,24 @@
+package display
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/fatih/color"
+
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// writeClarification writes the quick replies of a clarification question
+func writeClarification(w io.Writer, clarification *models.Clarification) {
+	if clarification == nil || len(clarification.Options) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	color.New(color.FgCyan).Fprintln(w, "❓ Quick replies:")
+	for i, option := range clarification.Options {
+		fmt.Fprintf(w, "  %d. %s\n", i+1, option.Label)
+	}
+	fmt.Fprintln(w, "💬 Reply with a number, or say in a few words what you meant")
+}
@@ -0,0 +1,114 @@
+package display
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// defaultTerminalWidth is used when $COLUMNS does not say otherwise
+const defaultTerminalWidth = 120
+
+// columnGap separates the two columns of ShowSideBySide
+const columnGap = " │ "
+
+// ShowSideBySide prints two texts in columns, wrapped to the terminal
+func ShowSideBySide(leftTitle, left, rightTitle, right string) {
+	width := defaultTerminalWidth
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns >= 40 {
+		width = columns
+	}
+	column := (width - utf8.RuneCountInString(columnGap)) / 2
+
+	leftLines, rightLines := wrapColumn(left, column), wrapColumn(right, column)
+	bold := color.New(color.Bold)
+	fmt.Println()
+	bold.Print(padColumn(truncateColumn(leftTitle, column), column))
+	fmt.Print(columnGap)
+	bold.Println(truncateColumn(rightTitle, column))
+	fmt.Println(strings.Repeat("─", column) + "─┼─" + strings.Repeat("─", column))
+	for i := 0; i < max(len(leftLines), len(rightLines)); i++ {
+		var l, r string
+		if i < len(leftLines) {
+			l = leftLines[i]
+		}
+		if i < len(rightLines) {
+			r = rightLines[i]
+		}
+		fmt.Println(padColumn(l, column) + columnGap + r)
+	}
+}
+
+// ShowModelPreferences lists how each compared model fared in the answers
+// the user rated
+func ShowModelPreferences(stats []*storage.ModelPreferenceStats) {
+	if len(stats) == 0 {
+		fmt.Println("⚖️ No rated comparisons yet. Compare two models with 'compare <query>'.")
+		return
+	}
+	color.New(color.FgCyan, color.Bold).Println("\n⚖️ Model preferences:")
+	fmt.Printf("  %-32s %6s %5s %5s %5s %10s %10s\n", "MODEL", "WIN %", "WON", "LOST", "TIED", "LATENCY", "COST")
+	for _, s := range stats {
+		fmt.Printf("  %-32s %5.0f%% %5d %5d %5d %10s %10s\n",
+			truncateColumn(s.Model, 32), s.WinRate()*100, s.Wins, s.Losses, s.Ties,
+			s.AvgLatency.Round(10*time.Millisecond), fmt.Sprintf("$%.4f", s.AvgCost))
+	}
+}
+
+// wrapColumn wraps text to lines of at most width runes, keeping its line
+// breaks and leading indentation and breaking words longer than a line
+func wrapColumn(text string, width int) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\t", "    "), "\n") {
+		indent := paragraph[:len(paragraph)-len(strings.TrimLeft(paragraph, " "))]
+		if len(indent) > width/2 {
+			indent = ""
+		}
+		line, empty := indent, true
+		for _, word := range strings.Fields(paragraph) {
+			if !empty {
+				if utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) <= width {
+					line += " " + word
+					continue
+				}
+				lines = append(lines, line)
+				line, empty = "", true
+			}
+			// A word longer than the line is split across lines
+			for utf8.RuneCountInString(line)+utf8.RuneCountInString(word) > width {
+				room := width - utf8.RuneCountInString(line)
+				runes := []rune(word)
+				lines = append(lines, line+string(runes[:room]))
+				line, word = "", string(runes[room:])
+			}
+			line += word
+			empty = false
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// padColumn pads text with spaces to width runes
+func padColumn(text string, width int) string {
+	if n := utf8.RuneCountInString(text); n < width {
+		return text + strings.Repeat(" ", width-n)
+	}
+	return text
+}
+
+// truncateColumn shortens text to width runes
+func truncateColumn(text string, width int) string {
+	runes := []rune(text)
+	if len(runes) <= width {
+		return text
+	}
+	return string(runes[:width-1]) + "…"
+}
@@ -0,0 +1,33 @@
+package display
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+
+	"github.com/yourusername/useq-ai-assistant/internal/completion"
+)
+
+// completionIcons mark where a suggestion came from
+var completionIcons = map[completion.Kind]string{
+	completion.KindHistory: "🕘",
+	completion.KindSymbol:  "🔣",
+	completion.KindIntent:  "💡",
+}
+
+// ShowCompletions lists the suggested completions of a partial query
+func ShowCompletions(input string, suggestions []completion.Suggestion) {
+	if len(suggestions) == 0 {
+		fmt.Printf("🤷 No completions for %q\n", input)
+		return
+	}
+	color.New(color.FgCyan, color.Bold).Printf("\n⌨️  Completions for %q:\n", input)
+	for i, suggestion := range suggestions {
+		fmt.Printf("  %d. %s %s", i+1, completionIcons[suggestion.Kind], suggestion.Text)
+		if suggestion.Detail != "" {
+			color.New(color.FgHiBlack).Printf("  (%s)", suggestion.Detail)
+		}
+		fmt.Println()
+	}
+	fmt.Println()
+}
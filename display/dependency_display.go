@@ -0,0 +1,35 @@
+package display
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fatih/color"
+
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// writeProposedModules lists the modules generated code imports that go.mod
+// does not require yet, with the command that adds each one
+func writeProposedModules(w io.Writer, code *models.CodeResponse) {
+	if code == nil || len(code.Dependencies) == 0 {
+		return
+	}
+	color.New(color.FgYellow).Fprintf(w, "\n📦 New modules needed (%d):\n", len(code.Dependencies))
+	for _, module := range code.Dependencies {
+		version := module.Version
+		if version == "" {
+			version = "latest"
+		}
+		fmt.Fprintf(w, "  go get %s@%s", module.Name, version)
+		if module.Category != "" {
+			fmt.Fprintf(w, "  (%s)", module.Category)
+		}
+		fmt.Fprintln(w)
+		if len(module.Alternatives) > 0 {
+			color.New(color.FgCyan).Fprintf(w, "    already required for this: %s; consider using it instead\n",
+				strings.Join(module.Alternatives, ", "))
+		}
+	}
+}
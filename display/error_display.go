@@ -0,0 +1,63 @@
+package display
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+
+	"github.com/yourusername/useq-ai-assistant/internal/audit"
+)
+
+// maxShownInconsistencies bounds the stray sites listed per package
+const maxShownInconsistencies = 10
+
+// ShowErrorStyles prints how each package wraps and creates errors, and the
+// places that stray from its dominant styles
+func ShowErrorStyles(inventories []*audit.ErrorInventory) {
+	if len(inventories) == 0 {
+		fmt.Println("🧯 No Go packages to analyze")
+		return
+	}
+	color.New(color.FgCyan, color.Bold).Printf("\n🧯 Error handling in %d packages:\n", len(inventories))
+	consistent := 0
+	for _, inv := range inventories {
+		stray := inv.Inconsistent()
+		if len(stray) == 0 {
+			consistent++
+		}
+		if len(inv.Counts) == 0 {
+			continue
+		}
+
+		styles := make([]string, 0, len(inv.Counts))
+		for style := range inv.Counts {
+			styles = append(styles, style)
+		}
+		sort.Slice(styles, func(i, j int) bool { return inv.Counts[styles[i]] > inv.Counts[styles[j]] })
+		parts := make([]string, len(styles))
+		for i, style := range styles {
+			parts[i] = fmt.Sprintf("%s %d", style, inv.Counts[style])
+		}
+		header := color.New(color.FgGreen)
+		if len(stray) > 0 {
+			header = color.New(color.FgYellow)
+		}
+		header.Printf("\n  %s", inv.Dir)
+		fmt.Printf("  %s\n", strings.Join(parts, ", "))
+		if len(inv.CustomTypes) > 0 {
+			color.New(color.FgHiBlack).Printf("    error types: %s\n", strings.Join(inv.CustomTypes, ", "))
+		}
+		for i, site := range stray {
+			if i == maxShownInconsistencies {
+				fmt.Printf("    ... %d more\n", len(stray)-maxShownInconsistencies)
+				break
+			}
+			fmt.Printf("    %s:%d %s", site.File, site.Line, truncateColumn(site.Code, 70))
+			color.New(color.FgHiBlack).Printf("  (%s, package uses %s)\n", site.Style, inv.Dominant(site.Style))
+		}
+	}
+	fmt.Printf("\n%d of %d packages are consistent\n", consistent, len(inventories))
+	fmt.Println("💡 'error-styles normalize <dir>' rewrites a package to its dominant style")
+}
@@ -0,0 +1,66 @@
+package display
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fatih/color"
+
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// writeExcerpts shows the code an explanation refers to. Folded excerpts
+// are listed by name only unless unfold is set.
+func writeExcerpts(w io.Writer, excerpts []models.CodeExcerpt, unfold bool) {
+	if len(excerpts) == 0 {
+		return
+	}
+	color.New(color.FgCyan).Fprintf(w, "\n📎 Code referenced (%d):\n", len(excerpts))
+	folded := 0
+	for _, excerpt := range excerpts {
+		location := fmt.Sprintf("%s:%d-%d", excerpt.File, excerpt.StartLine, excerpt.EndLine)
+		if excerpt.Folded && !unfold {
+			folded++
+			fmt.Fprintf(w, "  ▸ %s  %s (%d lines)\n", excerpt.Symbol, color.New(color.Faint).Sprint(location), len(excerpt.Lines))
+			continue
+		}
+		fmt.Fprintf(w, "  ▾ %s  %s\n", excerpt.Symbol, color.New(color.Faint).Sprint(location))
+		for i, line := range excerpt.Lines {
+			fmt.Fprintf(w, "  %5d │ %s\n", excerpt.StartLine+i, line)
+		}
+		if excerpt.Truncated {
+			fmt.Fprintf(w, "        │ … continues past line %d\n", excerpt.EndLine)
+		}
+	}
+	if folded > 0 {
+		fmt.Fprintf(w, "   %d folded; run `excerpts` to unfold them\n", folded)
+	}
+}
+
+// WriteExcerpts shows every excerpt of an answer unfolded
+func WriteExcerpts(w io.Writer, excerpts []models.CodeExcerpt) {
+	writeExcerpts(w, excerpts, true)
+}
+
+// markdownExcerpts writes excerpts as <details> blocks, open unless folded,
+// so chat and web frontends can fold and unfold them
+func markdownExcerpts(b *strings.Builder, excerpts []models.CodeExcerpt) {
+	for _, excerpt := range excerpts {
+		open := ""
+		if !excerpt.Folded {
+			open = " open"
+		}
+		fmt.Fprintf(b, "<details%s><summary><code>%s</code> · %s:%d-%d</summary>\n\n", open,
+			excerpt.Symbol, excerpt.File, excerpt.StartLine, excerpt.EndLine)
+		fmt.Fprintf(b, "```%s\n", excerpt.Language)
+		width := len(fmt.Sprint(excerpt.EndLine))
+		for i, line := range excerpt.Lines {
+			fmt.Fprintf(b, "%*d  %s\n", width, excerpt.StartLine+i, line)
+		}
+		if excerpt.Truncated {
+			fmt.Fprintf(b, "%*s  …\n", width, "")
+		}
+		b.WriteString("```\n\n</details>\n\n")
+	}
+}
@@ -0,0 +1,61 @@
+package display
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/fatih/color"
+
+	"github.com/yourusername/useq-ai-assistant/internal/calllog"
+)
+
+// ShowExternalCalls lists outbound calls and totals them by provider
+func ShowExternalCalls(calls []calllog.Call, window, path string) {
+	if len(calls) == 0 {
+		fmt.Printf("🌐 No external calls in the last %s (%s)\n", window, path)
+		return
+	}
+	color.New(color.FgCyan, color.Bold).Printf("\n🌐 External calls in the last %s (%d):\n", window, len(calls))
+	fmt.Printf("  %-19s %-9s %-10s %-26s %-12s %7s %7s %7s  %s\n",
+		"Time", "Service", "Provider", "Model", "Purpose", "In", "Out", "ms", "Prompt")
+	type total struct{ calls, in, out, failed int }
+	totals := make(map[string]*total)
+	for _, call := range calls {
+		fmt.Printf("  %-19s %-9s %-10s %-26s %-12s %7d %7d %7d  %s",
+			call.Time.Local().Format("2006-01-02 15:04:05"), call.Service, truncateColumn(call.Provider, 10),
+			truncateColumn(call.Model, 26), truncateColumn(call.Purpose, 12),
+			call.InputTokens, call.OutputTokens, call.DurationMS, call.PromptHash)
+		if call.Error != "" {
+			color.New(color.FgRed).Printf("  ❌ %s", truncateColumn(call.Error, 60))
+		}
+		fmt.Println()
+
+		key := call.Provider + " " + call.Endpoint
+		if totals[key] == nil {
+			totals[key] = &total{}
+		}
+		t := totals[key]
+		t.calls++
+		t.in += call.InputTokens
+		t.out += call.OutputTokens
+		if call.Error != "" {
+			t.failed++
+		}
+	}
+
+	keys := make([]string, 0, len(totals))
+	for key := range totals {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	color.New(color.FgCyan).Println("\n  By provider and endpoint:")
+	for _, key := range keys {
+		t := totals[key]
+		fmt.Printf("  %-60s %5d calls %9d in %9d out", truncateColumn(key, 60), t.calls, t.in, t.out)
+		if t.failed > 0 {
+			color.New(color.FgRed).Printf(" %5d failed", t.failed)
+		}
+		fmt.Println()
+	}
+	color.New(color.FgHiBlack).Printf("\n  Log: %s\n", path)
+}
@@ -0,0 +1,90 @@
+package display
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+
+	"github.com/yourusername/useq-ai-assistant/internal/fingerprint"
+)
+
+// ShowFingerprint prints the configuration an answer was produced with and
+// which parts of it changed since
+func ShowFingerprint(explanation *fingerprint.Explanation) {
+	snapshot := explanation.Snapshot
+	changed := make(map[string]bool)
+	for _, name := range append(explanation.Changed, explanation.ChangedSettings...) {
+		changed[name] = true
+	}
+	color.New(color.FgCyan, color.Bold).Printf("\n🧬 Fingerprint %s of %s\n", explanation.Fingerprint, explanation.ResponseID)
+	color.New(color.FgHiBlack).Printf("   recorded %s\n", explanation.RecordedAt.Format("2006-01-02 15:04:05"))
+
+	fmt.Println()
+	for _, component := range fingerprint.Components {
+		fmt.Printf("  %-8s %s", component, explanation.Components[component])
+		switch {
+		case component == fingerprint.ComponentPrompts:
+			color.New(color.FgHiBlack).Print("  (per answer)")
+		case changed[component]:
+			color.New(color.FgYellow).Printf("  changed, now %s", explanation.Current[component])
+		default:
+			color.New(color.FgGreen).Print("  unchanged")
+		}
+		fmt.Println()
+	}
+
+	color.New(color.Bold).Println("\n  Models called:")
+	if len(snapshot.CalledModels) == 0 {
+		fmt.Println("    none")
+	}
+	for _, model := range snapshot.CalledModels {
+		fmt.Printf("    %s\n", model)
+	}
+
+	color.New(color.Bold).Println("\n  Model settings:")
+	keys := make([]string, 0, len(snapshot.Models))
+	for key := range snapshot.Models {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Printf("    %s: %s", key, snapshot.Models[key])
+		if changed["models."+key] {
+			color.New(color.FgYellow).Print("  (changed)")
+		}
+		fmt.Println()
+	}
+
+	color.New(color.Bold).Println("\n  Ranking settings:")
+	sections := make([]string, 0, len(snapshot.Ranking))
+	for section := range snapshot.Ranking {
+		sections = append(sections, section)
+	}
+	sort.Strings(sections)
+	for _, section := range sections {
+		value := string(snapshot.Ranking[section])
+		if section == "vocabulary" {
+			value = fmt.Sprintf("%d bytes", len(value))
+		}
+		fmt.Printf("    %s: %s", section, truncateColumn(value, 100))
+		if changed["ranking."+section] {
+			color.New(color.FgYellow).Print("  (changed)")
+		}
+		fmt.Println()
+	}
+	for _, key := range explanation.ChangedSettings {
+		section := strings.TrimPrefix(key, "ranking.")
+		if _, ok := snapshot.Ranking[section]; !ok && section != key {
+			color.New(color.FgYellow).Printf("    %s: added since\n", section)
+		}
+	}
+
+	color.New(color.Bold).Printf("\n  Index generation: #%d\n", snapshot.IndexGeneration)
+
+	color.New(color.Bold).Printf("\n  System prompt templates (%d):\n", len(snapshot.Prompts))
+	for i, prompt := range snapshot.Prompts {
+		fmt.Printf("    [%d] %s\n", i+1, prompt)
+	}
+}
@@ -0,0 +1,24 @@
+package display
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+
+	"github.com/yourusername/useq-ai-assistant/internal/flags"
+)
+
+// ShowFlags lists the session's feature flags and where each value came from
+func ShowFlags(states []flags.State) {
+	color.New(color.FgCyan, color.Bold).Println("\n🚩 Feature flags:")
+	for _, state := range states {
+		value := color.New(color.FgRed).Sprint("off")
+		if state.Enabled {
+			value = color.New(color.FgGreen).Sprint("on ")
+		}
+		fmt.Printf("  %s %-14s", value, state.Name)
+		color.New(color.FgHiBlack).Printf(" (%s) %s\n", state.Source, state.Description)
+	}
+	fmt.Println("  Use '/flags <name> on|off|default' to switch one for this session.")
+	fmt.Println()
+}
@@ -0,0 +1,74 @@
+package display
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fatih/color"
+
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// writeStaleSources warns when an answer cites files that changed on disk
+// since they were indexed
+func writeStaleSources(w io.Writer, response *models.Response) {
+	stale := response.Metadata.StaleSources
+	if len(stale) == 0 {
+		return
+	}
+	color.New(color.FgYellow).Fprintf(w, "\n⚠️ %d cited file(s) changed since indexing: %s\n",
+		len(stale), strings.Join(stale, ", "))
+	fmt.Fprintln(w, "   Run `index` to refresh them, or set freshness.auto_reindex to re-index before answering")
+}
+
+// writeDegraded warns when an answer was built without part of retrieval
+func writeDegraded(w io.Writer, response *models.Response) {
+	if response.Metadata.Degraded == "" {
+		return
+	}
+	color.New(color.FgYellow).Fprintf(w, "\n⚠️ Degraded answer: %s\n", response.Metadata.Degraded)
+	fmt.Fprintln(w, "   Run `status` to see whether Qdrant has reconnected")
+}
+
+// writeKeywordOnly labels an answer searched without a vector database
+func writeKeywordOnly(w io.Writer, response *models.Response) {
+	if !response.Metadata.KeywordOnly {
+		return
+	}
+	color.New(color.FgYellow).Fprintln(w, "\n🔤 Keyword-only mode: no vector database configured; results match words, not meaning")
+	fmt.Fprintln(w, "   Start Qdrant and run `index` for semantic search")
+}
+
+// writeIndexCoverage warns when an answer was built while much of its scope
+// was not indexed yet
+func writeIndexCoverage(w io.Writer, response *models.Response) {
+	coverage := response.Metadata.IndexCoverage
+	if coverage == nil || !coverage.Partial {
+		return
+	}
+	color.New(color.FgYellow).Fprintf(w, "\n⚠️ Partial index: %s\n", coverage.Statement)
+	fmt.Fprintln(w, "   Ask again once indexing finishes, or run `index` to index the rest")
+}
+
+// writeSummaryFreshness notes how current the package summaries behind an
+// architecture explanation are
+func writeSummaryFreshness(w io.Writer, response *models.Response) {
+	freshness := response.Metadata.SummaryFreshness
+	if len(freshness) == 0 {
+		return
+	}
+	stale := 0
+	color.New(color.FgCyan).Fprintln(w, "\n🧭 Package knowledge behind this answer:")
+	for _, pkg := range freshness {
+		icon := "✅"
+		if pkg.Stale {
+			icon = "⚠️"
+			stale++
+		}
+		fmt.Fprintf(w, "  %s %-40s %s\n", icon, pkg.Dir, pkg.Note)
+	}
+	if stale > 0 {
+		fmt.Fprintln(w, "   Stale summaries are rebuilt while idle; run `knowledge refresh` to rebuild them now")
+	}
+}
@@ -0,0 +1,42 @@
+package display
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// ShowGlossary lists the project's glossary terms
+func ShowGlossary(entries []*storage.GlossaryEntry) {
+	if len(entries) == 0 {
+		fmt.Println("📖 The glossary is empty. Terms are collected while indexing, or add one with 'glossary set <term>: <definition>'.")
+		return
+	}
+	color.New(color.FgCyan, color.Bold).Printf("\n📖 Glossary (%d terms):\n", len(entries))
+	for _, entry := range entries {
+		color.New(color.Bold).Printf("  %s", entry.Term)
+		fmt.Printf(" - %s", entry.Definition)
+		if len(entry.Aliases) > 0 {
+			color.New(color.FgYellow).Printf(" [not: %s]", strings.Join(entry.Aliases, ", "))
+		}
+		color.New(color.FgHiBlack).Printf("  (%s)\n", entry.Source)
+	}
+	fmt.Println()
+}
+
+// ShowGlossaryEntry prints one glossary term with where it came from
+func ShowGlossaryEntry(entry *storage.GlossaryEntry) {
+	color.New(color.FgCyan, color.Bold).Printf("\n📖 %s\n", entry.Term)
+	fmt.Printf("  %s\n", entry.Definition)
+	if len(entry.Aliases) > 0 {
+		fmt.Printf("  Instead of: %s\n", strings.Join(entry.Aliases, ", "))
+	}
+	source := entry.Source
+	if entry.File != "" {
+		source += " from " + entry.File
+	}
+	color.New(color.FgHiBlack).Printf("  Source: %s, updated %s\n\n", source, entry.UpdatedAt.Format("2006-01-02 15:04"))
+}
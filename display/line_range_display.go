@@ -0,0 +1,36 @@
+package display
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// ShowLineRange prints a range of an indexed file with line numbers, the
+// context around it dimmed
+func ShowLineRange(lines *storage.LineRange) {
+	header := fmt.Sprintf("\n📄 %s:%d-%d", lines.Path, lines.Start, lines.End)
+	if lines.Enclosing != "" {
+		header += fmt.Sprintf(" in %s %s", lines.EnclosingKind, lines.Enclosing)
+	}
+	color.New(color.FgCyan, color.Bold).Println(header)
+	if lines.Expanded {
+		color.New(color.FgHiBlack).Println("   expanded to the enclosing declarations")
+	}
+
+	faint := color.New(color.Faint)
+	for i, line := range lines.Lines {
+		number := lines.FirstLine + i
+		if number < lines.Start || number > lines.End {
+			faint.Printf("  %5d │ %s\n", number, line)
+			continue
+		}
+		fmt.Printf("  %5d │ %s\n", number, line)
+	}
+	if lines.Truncated {
+		fmt.Printf("        │ … cut at line %d\n", lines.End)
+	}
+	color.New(color.FgHiBlack).Printf("   %d lines in file\n", lines.TotalLines)
+}
@@ -0,0 +1,103 @@
+package display
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// MarkdownRenderer writes answers as GitHub-flavored markdown, for chat bots
+// and web pages
+type MarkdownRenderer struct {
+	w io.Writer
+}
+
+// NewMarkdownRenderer creates a markdown renderer writing to w
+func NewMarkdownRenderer(w io.Writer) *MarkdownRenderer {
+	return &MarkdownRenderer{w: w}
+}
+
+// Name returns "markdown"
+func (r *MarkdownRenderer) Name() string {
+	return RendererMarkdown
+}
+
+// RenderResponse writes the answer as markdown in its type's template
+func (r *MarkdownRenderer) RenderResponse(response *models.Response) error {
+	var b strings.Builder
+	content := response.Content
+	template := TemplateFor(response.Type)
+	markdownTemplateHeader(&b, template)
+
+	if content.Text != "" {
+		b.WriteString(strings.TrimSpace(content.Text) + "\n\n")
+	}
+
+	if content.Code != nil && content.Code.Code != "" {
+		fmt.Fprintf(&b, "```%s\n%s\n```\n\n", content.Code.Language, strings.TrimRight(content.Code.Code, "\n"))
+	}
+
+	if search := content.Search; search != nil && len(search.Results) > 0 {
+		if search.Summary != nil {
+			markdownResultSummary(&b, search)
+			if page, offset := resultPage(search); len(page) > 0 {
+				fmt.Fprintf(&b, "**Results %d-%d of %d** (page %d of %d)\n\n", offset+1, offset+len(page), search.Summary.Total, search.Summary.Page, search.Summary.Pages())
+				for i, result := range page {
+					markdownSearchResult(&b, offset+i, result)
+				}
+				b.WriteString("\n")
+			}
+		} else {
+			fmt.Fprintf(&b, "**Search results** (%d found)\n\n", len(search.Results))
+			for i, result := range search.Results {
+				markdownSearchResult(&b, i, result)
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	markdownExcerpts(&b, content.Excerpts)
+
+	if plan := content.Plan; plan != nil {
+		b.WriteString("**Execution plan** (nothing was executed)\n\n")
+		fmt.Fprintf(&b, "- Tier: %s\n", plan.Tier)
+		fmt.Fprintf(&b, "- Agents: %s\n", strings.Join(plan.Agents, " → "))
+		fmt.Fprintf(&b, "- Estimated cost: $%.4f\n", plan.EstimatedCost)
+		for _, file := range plan.ContextFiles {
+			fmt.Fprintf(&b, "- Context: `%s`\n", file)
+		}
+		b.WriteString("\n")
+	}
+
+	if clarification := content.Clarification; clarification != nil && len(clarification.Options) > 0 {
+		b.WriteString("**Quick replies**\n\n")
+		for i, option := range clarification.Options {
+			fmt.Fprintf(&b, "%d. %s\n", i+1, option.Label)
+		}
+		b.WriteString("\n")
+	}
+
+	meta := response.Metadata
+	if len(meta.StaleSources) > 0 {
+		fmt.Fprintf(&b, "> ⚠️ %d cited file(s) changed since indexing: %s\n\n", len(meta.StaleSources), strings.Join(meta.StaleSources, ", "))
+	}
+	if meta.Degraded != "" {
+		fmt.Fprintf(&b, "> ⚠️ Degraded answer: %s\n\n", meta.Degraded)
+	}
+	if meta.KeywordOnly {
+		b.WriteString("> 🔤 Keyword-only mode: no vector database configured; results match words, not meaning\n\n")
+	}
+	if meta.IndexCoverage != nil && meta.IndexCoverage.Partial {
+		fmt.Fprintf(&b, "> ⚠️ Partial index: %s\n\n", meta.IndexCoverage.Statement)
+	}
+	if len(meta.StaleResponses) > 0 {
+		fmt.Fprintf(&b, "> ⚠️ The index changed since earlier answers in this session: %s\n\n", strings.Join(meta.StaleResponses, ", "))
+	}
+
+	markdownTemplateFooter(&b, template, response)
+
+	_, err := io.WriteString(r.w, b.String())
+	return err
+}
@@ -0,0 +1,23 @@
+package display
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// ShowPins lists the session's pins, numbered for unpin
+func ShowPins(pins []models.PinnedContext) {
+	if len(pins) == 0 {
+		fmt.Println("📌 Nothing pinned. Use 'pin <path|symbol>' to keep a file in every Tier 3 prompt.")
+		return
+	}
+	color.New(color.FgCyan, color.Bold).Println("\n📌 Pinned context:")
+	for i, pin := range pins {
+		fmt.Printf("  %d. %s", i+1, pin.Label())
+		color.New(color.FgHiBlack).Printf("  (pinned %s)\n", pin.PinnedAt.Format("15:04:05"))
+	}
+	fmt.Println()
+}
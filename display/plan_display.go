@@ -0,0 +1,65 @@
+package display
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// writeExecutionPlan writes a dry-run execution plan
+func writeExecutionPlan(w io.Writer, plan *models.ExecutionPlan) {
+	if plan == nil {
+		return
+	}
+
+	fmt.Fprintln(w)
+	color.New(color.FgCyan, color.Bold).Fprintln(w, "🧭 Execution Plan (nothing was executed)")
+	fmt.Fprintln(w, strings.Repeat("─", 50))
+
+	fmt.Fprintf(w, "🎯 Tier:    %s\n", plan.Tier)
+	if plan.TierReason != "" {
+		fmt.Fprintf(w, "   %s\n", plan.TierReason)
+	}
+	fmt.Fprintf(w, "🤖 Agents:  %s (confidence %.0f%%)\n", strings.Join(plan.Agents, " → "), plan.AgentConfidence*100)
+
+	if plan.SkipLLM || plan.EstimatedInputTokens == 0 {
+		fmt.Fprintln(w, "🪙 Tokens:  no LLM call")
+	} else {
+		provider := plan.Provider
+		if provider == "" {
+			provider = "default provider"
+		}
+		fmt.Fprintf(w, "🪙 Tokens:  ~%d in / ~%d out via %s\n",
+			plan.EstimatedInputTokens, plan.EstimatedOutputTokens, provider)
+	}
+	fmt.Fprintf(w, "💰 Cost:    ~$%.4f\n", plan.EstimatedCost)
+	if plan.EstimatedTime > 0 {
+		fmt.Fprintf(w, "⏱️ Time:    ~%v\n", plan.EstimatedTime.Round(time.Millisecond))
+	}
+
+	if len(plan.ContextFiles) > 0 {
+		color.New(color.FgYellow).Fprintf(w, "\n📁 Context files (%d):\n", len(plan.ContextFiles))
+		for _, file := range plan.ContextFiles {
+			fmt.Fprintf(w, "  ├─ %s\n", file)
+		}
+	}
+
+	if len(plan.MCPCommands) > 0 {
+		color.New(color.FgYellow).Fprintf(w, "\n🔧 MCP commands (%d):\n", len(plan.MCPCommands))
+		for _, command := range plan.MCPCommands {
+			fmt.Fprintf(w, "  ├─ %s\n", command)
+		}
+	}
+
+	for _, note := range plan.Notes {
+		color.New(color.FgWhite).Fprintf(w, "\nℹ️ %s", note)
+	}
+	if len(plan.Notes) > 0 {
+		fmt.Fprintln(w)
+	}
+}
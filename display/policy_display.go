@@ -0,0 +1,75 @@
+package display
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+
+	"github.com/yourusername/useq-ai-assistant/internal/policy"
+)
+
+// ShowPolicies lists the team's policies and the code each covers
+func ShowPolicies(policies []*policy.Policy) {
+	color.New(color.FgCyan, color.Bold).Printf("\n📏 Policies (%d)\n", len(policies))
+	if len(policies) == 0 {
+		fmt.Printf("   None defined yet; add them to the file set by policies.file (%s by default)\n", policy.DefaultPath)
+		return
+	}
+	for _, p := range policies {
+		color.New(color.Bold).Printf("\n  %s", p.Name)
+		color.New(color.FgHiBlack).Printf("  [%s]\n", p.Severity)
+		fmt.Printf("    %s\n", p.Rule)
+		var scope []string
+		if p.AppliesTo != "" {
+			scope = append(scope, p.AppliesTo)
+		}
+		if len(p.Paths) > 0 {
+			scope = append(scope, "in "+strings.Join(p.Paths, ", "))
+		}
+		if len(p.Exclude) > 0 {
+			scope = append(scope, "not in "+strings.Join(p.Exclude, ", "))
+		}
+		if len(p.Tags) > 0 {
+			scope = append(scope, "tagged "+strings.Join(p.Tags, ", "))
+		}
+		if len(scope) > 0 {
+			color.New(color.FgHiBlack).Printf("    covers: %s\n", strings.Join(scope, "; "))
+		}
+	}
+	fmt.Println("\n💡 'policies check [name]' judges the indexed code against them")
+}
+
+// ShowPolicyResults prints pass or fail for each policy with its cited
+// violations
+func ShowPolicyResults(results []*policy.Result) {
+	var tokens int
+	var cost float64
+	for _, result := range results {
+		if result.Passed() {
+			color.New(color.FgGreen, color.Bold).Printf("\n✅ %s", result.Policy.Name)
+		} else {
+			color.New(color.FgRed, color.Bold).Printf("\n❌ %s", result.Policy.Name)
+		}
+		color.New(color.FgHiBlack).Printf("  %d judged, %d applicable", result.Checked, result.Applicable)
+		if result.Unchecked > 0 {
+			color.New(color.FgHiBlack).Printf(", %d not judged", result.Unchecked)
+		}
+		fmt.Println()
+		fmt.Printf("   %s\n", result.Policy.Rule)
+		for _, violation := range result.Violations {
+			location := fmt.Sprintf("%s:%d", violation.File, violation.Line)
+			if violation.Symbol != "" {
+				location += " " + violation.Symbol
+			}
+			color.New(color.FgRed).Printf("   ✗ %s", location)
+			fmt.Printf(": %s\n", violation.Reason)
+		}
+		for _, message := range result.Errors {
+			color.New(color.FgYellow).Printf("   ⚠️ %s\n", message)
+		}
+		tokens += result.Usage.Tokens
+		cost += result.Usage.Cost
+	}
+	color.New(color.FgHiBlack).Printf("\n   %d tokens, $%.4f\n", tokens, cost)
+}
@@ -0,0 +1,67 @@
+package display
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/yourusername/useq-ai-assistant/internal/provenance"
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// ShowProvenance prints the generated regions of a file and the answers
+// they came from
+func ShowProvenance(report *provenance.Report) {
+	if len(report.Regions) == 0 {
+		fmt.Printf("🧾 No generated code recorded for %s\n", report.File)
+		return
+	}
+	color.New(color.FgCyan, color.Bold).Printf("\n🧾 Provenance of %s: %d of %d lines generated\n", report.File, report.Authored, report.Lines)
+	for _, region := range report.Regions {
+		record := region.Record
+		lines := "  gone"
+		if region.StartLine > 0 {
+			lines = fmt.Sprintf("%4d-%-4d", region.StartLine, region.EndLine)
+			if region.EndLine == region.StartLine {
+				lines = fmt.Sprintf("%4d     ", region.StartLine)
+			}
+		}
+		fmt.Printf("  %-9s ", lines)
+		provenanceStatusColor(region.Status).Printf("%-9s", region.Status)
+		fmt.Printf(" %s", record.ResponseID)
+		if model := record.Model; model != "" {
+			fmt.Printf(" by %s", model)
+		}
+		if !record.CreatedAt.IsZero() {
+			color.New(color.FgHiBlack).Printf("  (%s)", record.CreatedAt.Local().Format("2006-01-02 15:04"))
+		}
+		fmt.Println()
+	}
+	fmt.Println("\n💡 'moved' regions shifted with other edits; 'modified' ones were changed by hand since")
+}
+
+// ShowProvenanceFiles lists the files with generated regions in the ledger
+func ShowProvenanceFiles(files []*storage.ProvenanceFile) {
+	if len(files) == 0 {
+		fmt.Println("🧾 No generated code recorded yet. Changes applied from answers are recorded.")
+		return
+	}
+	color.New(color.FgCyan, color.Bold).Printf("\n🧾 Files with generated code (%d):\n", len(files))
+	for _, file := range files {
+		fmt.Printf("  %-50s %3d regions, %4d lines from %d answers", file.File, file.Regions, file.Lines, file.Responses)
+		color.New(color.FgHiBlack).Printf("  (last %s)\n", file.LastAt.Local().Format("2006-01-02"))
+	}
+	fmt.Println("\n💡 'provenance <file>' shows where the regions are now")
+}
+
+// provenanceStatusColor colors a region status by how far the code has
+// drifted from what was generated
+func provenanceStatusColor(status string) *color.Color {
+	switch status {
+	case provenance.StatusUnchanged, provenance.StatusMarked:
+		return color.New(color.FgGreen)
+	case provenance.StatusMoved:
+		return color.New(color.FgYellow)
+	default:
+		return color.New(color.FgRed)
+	}
+}
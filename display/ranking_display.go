@@ -0,0 +1,50 @@
+package display
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// ShowRankingExplanation breaks a search result's score down into the
+// factors that ranked it
+func ShowRankingExplanation(n int, result *models.SearchResult) {
+	name := result.Function
+	if name == "" {
+		name = "code_snippet"
+	}
+	color.New(color.FgCyan, color.Bold).Printf("\n🔎 Why result %d matched: %s:%d - %s\n", n, result.File, result.Line, name)
+
+	factors := result.Ranking
+	if factors == nil {
+		fmt.Printf("   Score %.3f; no ranking breakdown was recorded for this result\n\n", result.Score)
+		return
+	}
+
+	similarity := "Vector similarity"
+	if factors.Strategy != "" && factors.Strategy != "semantic" {
+		similarity = fmt.Sprintf("Base score (%s)", factors.Strategy)
+	}
+	fmt.Printf("   %-24s %.3f\n", similarity, factors.Similarity)
+	rows := []struct {
+		label string
+		value float64
+		note  string
+	}{
+		{"Keyword hits", factors.KeywordBoost, fmt.Sprintf("%d query word(s) in the chunk", factors.KeywordHits)},
+		{"Exact-match bonus", factors.ExactBonus, "name matches the query"},
+		{"Result type", factors.TypeBoost, "kind matches what was searched for"},
+		{"Recency", factors.Recency, "changed often and recently in git"},
+		{"Low-score penalty", factors.Penalty, "weak match"},
+	}
+	for _, row := range rows {
+		if row.value == 0 {
+			fmt.Printf("   %-24s %+.3f\n", row.label, 0.0)
+			continue
+		}
+		fmt.Printf("   %-24s %+.3f  (%s)\n", row.label, row.value, row.note)
+	}
+	fmt.Printf("   %-24s %.3f\n\n", "Final score", result.Score)
+}
@@ -2,12 +2,14 @@ package display
 
 import (
 	"fmt"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/fatih/color"
 	"github.com/schollz/progressbar/v3"
 
+	"github.com/yourusername/useq-ai-assistant/internal/edits"
 	"github.com/yourusername/useq-ai-assistant/models"
 )
 
@@ -153,6 +155,8 @@ func (dr *DisplayRenderer) RenderResponse(response *models.Response) {
 
 	if response.Content.Code != nil {
 		dr.renderCode(response.Content.Code)
+		writeProposedModules(os.Stdout, response.Content.Code)
+		writeWiring(os.Stdout, response.Content.Code)
 	}
 
 	if response.Content.Search != nil {
@@ -167,6 +171,20 @@ func (dr *DisplayRenderer) RenderResponse(response *models.Response) {
 		dr.renderSuggestions(response.Content.Suggestions)
 	}
 
+	writeExcerpts(os.Stdout, response.Content.Excerpts, false)
+	writeExecutionPlan(os.Stdout, response.Content.Plan)
+	writeClarification(os.Stdout, response.Content.Clarification)
+	writeAnswerVersion(os.Stdout, response)
+	writeStaleSources(os.Stdout, response)
+	writeDegraded(os.Stdout, response)
+	writeKeywordOnly(os.Stdout, response)
+	writeIndexCoverage(os.Stdout, response)
+	writeSummaryFreshness(os.Stdout, response)
+
+	template := TemplateFor(response.Type)
+	writeTemplateSources(os.Stdout, template, response)
+	writeNextSteps(os.Stdout, template, response, dr.symbols.Bullet)
+
 	dr.printFooter(response)
 }
 
@@ -219,8 +237,10 @@ func (dr *DisplayRenderer) printHeader(response *models.Response) {
 		dr.printBorder("┌", "─", "┐")
 	}
 
-	// Title line with provider and agent info
-	title := fmt.Sprintf("%s AI Response", dr.symbols.RightArrow)
+	// Title line from the response type's template, with provider and
+	// agent info
+	template := TemplateFor(response.Type)
+	title := fmt.Sprintf("%s %s %s", dr.symbols.RightArrow, template.Icon, template.Title)
 	providerInfo := fmt.Sprintf("Provider: %s | Agent: %s",
 		response.Provider, response.AgentUsed)
 
@@ -296,6 +316,59 @@ func (dr *DisplayRenderer) renderText(text string) {
 	fmt.Println()
 }
 
+// hasAnchoredChanges reports whether changes carry line-anchored edits
+func hasAnchoredChanges(changes []models.CodeChange) bool {
+	for _, change := range changes {
+		if change.Anchor != "" || change.OldContent != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// renderChangeDiffs renders line-anchored edits as minimal diffs
+func (dr *DisplayRenderer) renderChangeDiffs(changes []models.CodeChange) {
+	ShowChangeDiffs(changes)
+}
+
+// ShowChangeDiffs renders line-anchored edits as minimal diffs with a few
+// lines of surrounding context read from the file on disk
+func ShowChangeDiffs(changes []models.CodeChange) {
+	fileContents := make(map[string]string)
+	currentFile := ""
+
+	for _, change := range changes {
+		if change.File != currentFile {
+			currentFile = change.File
+			color.New(color.FgWhite, color.Bold).Printf("📄 %s\n", change.File)
+		}
+		content, loaded := fileContents[change.File]
+		if !loaded {
+			if data, err := os.ReadFile(change.File); err == nil {
+				content = string(data)
+				// Context lines are only trustworthy if the file has not changed since
+				if change.BaseHash != "" && edits.HashContent(content) != change.BaseHash {
+					content = ""
+				}
+			}
+			fileContents[change.File] = content
+		}
+
+		for _, line := range edits.RenderContextDiff(content, change, 3) {
+			switch {
+			case strings.HasPrefix(line, "@@"):
+				color.New(color.FgCyan).Println(line)
+			case strings.HasPrefix(line, "+"):
+				color.New(color.FgGreen).Println(line)
+			case strings.HasPrefix(line, "-"):
+				color.New(color.FgRed).Println(line)
+			default:
+				fmt.Println(line)
+			}
+		}
+	}
+}
+
 // renderCode renders code with syntax highlighting and line numbers
 func (dr *DisplayRenderer) renderCode(codeResp *models.CodeResponse) {
 	fmt.Println()
@@ -310,11 +383,16 @@ func (dr *DisplayRenderer) renderCode(codeResp *models.CodeResponse) {
 
 	fmt.Println(strings.Repeat("─", 50))
 
-	// Render code with syntax highlighting
-	dr.renderHighlightedCode(codeResp.Code, codeResp.Language)
+	// Line-anchored edits are shown as contextual diffs instead of the whole file
+	if hasAnchoredChanges(codeResp.Changes) {
+		dr.renderChangeDiffs(codeResp.Changes)
+	} else {
+		// Render code with syntax highlighting
+		dr.renderHighlightedCode(codeResp.Code, codeResp.Language)
+	}
 
 	// Show changes if any
-	if len(codeResp.Changes) > 0 {
+	if len(codeResp.Changes) > 0 && !hasAnchoredChanges(codeResp.Changes) {
 		fmt.Println()
 		color.New(color.FgYellow, color.Bold).Println("📝 Code Changes:")
 
@@ -389,7 +467,14 @@ func (dr *DisplayRenderer) renderSearchResults(searchResp *models.SearchResponse
 			fmt.Printf(" - %s", color.New(color.FgGreen).Sprint(result.Function))
 		}
 
-		fmt.Printf(" (Score: %.2f)\n", result.Score)
+		fmt.Printf(" (Score: %.2f)", result.Score)
+		if result.Origin == "deps" {
+			color.New(color.FgMagenta).Printf(" 📦 dependency")
+		}
+		if result.Stale != "" {
+			color.New(color.FgYellow).Printf(" ⚠️ %s since indexing", result.Stale)
+		}
+		fmt.Println()
 
 		// Context and explanation
 		if result.Context != "" {
@@ -400,6 +485,9 @@ func (dr *DisplayRenderer) renderSearchResults(searchResp *models.SearchResponse
 			fmt.Printf("   %s %s\n", dr.symbols.Info,
 				color.New(color.FgYellow).Sprint(result.Explanation))
 		}
+		if len(result.Duplicates) > 0 {
+			fmt.Printf("   %s Identical copies: %s\n", dr.symbols.Info, strings.Join(result.Duplicates, ", "))
+		}
 
 		// Usage examples
 		if len(result.Usage) > 0 {
@@ -0,0 +1,126 @@
+package display
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// Renderer names
+const (
+	RendererTerminal = "terminal" // colors, emoji and framing for an interactive terminal
+	RendererJSON     = "json"     // the response as indented JSON, for programs
+	RendererMarkdown = "markdown" // GitHub-flavored markdown, for chat bots and web pages
+	RendererQuiet    = "quiet"    // the answer alone, for scripts and pipes
+)
+
+// Renderer formats answers for one frontend, so the terminal, API clients
+// and bots show the same response the same way
+type Renderer interface {
+	// Name returns the renderer's name, one of RendererNames
+	Name() string
+	// RenderResponse writes one answer
+	RenderResponse(response *models.Response) error
+}
+
+// RendererNames lists the renderers NewRenderer knows
+func RendererNames() []string {
+	return []string{RendererTerminal, RendererJSON, RendererMarkdown, RendererQuiet}
+}
+
+// NewRenderer returns the renderer called name writing to w; no name is
+// the terminal renderer
+func NewRenderer(name string, w io.Writer) (Renderer, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", RendererTerminal:
+		return NewTerminalRenderer(w), nil
+	case RendererJSON:
+		return NewJSONRenderer(w), nil
+	case RendererMarkdown:
+		return NewMarkdownRenderer(w), nil
+	case RendererQuiet:
+		return NewQuietRenderer(w), nil
+	}
+	return nil, fmt.Errorf("unknown renderer %q; use one of %s", name, strings.Join(RendererNames(), ", "))
+}
+
+// JSONRenderer writes each response as an indented JSON document
+type JSONRenderer struct {
+	w io.Writer
+}
+
+// NewJSONRenderer creates a JSON renderer writing to w
+func NewJSONRenderer(w io.Writer) *JSONRenderer {
+	return &JSONRenderer{w: w}
+}
+
+// Name returns "json"
+func (r *JSONRenderer) Name() string {
+	return RendererJSON
+}
+
+// RenderResponse writes the response as JSON
+func (r *JSONRenderer) RenderResponse(response *models.Response) error {
+	encoder := json.NewEncoder(r.w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(response); err != nil {
+		return fmt.Errorf("failed to encode response: %w", err)
+	}
+	return nil
+}
+
+// QuietRenderer writes only the answer: its text, generated code, one line
+// per search result and the quick replies of a clarification question
+type QuietRenderer struct {
+	w io.Writer
+}
+
+// NewQuietRenderer creates a quiet renderer writing to w
+func NewQuietRenderer(w io.Writer) *QuietRenderer {
+	return &QuietRenderer{w: w}
+}
+
+// Name returns "quiet"
+func (r *QuietRenderer) Name() string {
+	return RendererQuiet
+}
+
+// RenderResponse writes the answer without decoration
+func (r *QuietRenderer) RenderResponse(response *models.Response) error {
+	content := response.Content
+	var parts []string
+	if text := strings.TrimSpace(content.Text); text != "" {
+		parts = append(parts, text)
+	}
+	if content.Code != nil && strings.TrimSpace(content.Code.Code) != "" {
+		parts = append(parts, strings.TrimRight(content.Code.Code, "\n"))
+	}
+	if content.Search != nil && len(content.Search.Results) > 0 {
+		lines := make([]string, len(content.Search.Results))
+		for i, result := range content.Search.Results {
+			lines[i] = fmt.Sprintf("%s:%d %s", result.File, result.Line, resultName(result))
+		}
+		parts = append(parts, strings.Join(lines, "\n"))
+	}
+	if content.Clarification != nil {
+		for i, option := range content.Clarification.Options {
+			parts = append(parts, fmt.Sprintf("%d. %s", i+1, option.Label))
+		}
+	}
+	if len(parts) == 0 {
+		return nil
+	}
+	_, err := fmt.Fprintln(r.w, strings.Join(parts, "\n\n"))
+	return err
+}
+
+// resultName names the function a search result is in
+func resultName(result models.SearchResult) string {
+	if result.Function == "" {
+		return "code_snippet"
+	}
+	return result.Function
+}
@@ -0,0 +1,217 @@
+package display
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// ResponseTemplate lays out one type of answer, so an answer looks the same
+// whichever agent wrote it
+type ResponseTemplate struct {
+	Icon    string
+	Title   string // header, e.g. "Explanation"
+	Sources bool   // list the files the answer drew on
+	Cost    bool   // footer with tokens, cost and timing
+	// NextSteps suggests follow-up commands for an answer
+	NextSteps func(response *models.Response) []string
+}
+
+// maxListedSources is how many sources a template lists before "and N more"
+const maxListedSources = 5
+
+var defaultTemplate = ResponseTemplate{Icon: "🤖", Title: "Response", Sources: true, Cost: true}
+
+var responseTemplates = map[models.ResponseType]ResponseTemplate{
+	models.ResponseTypeSearch: {
+		Icon: "🔍", Title: "Search", Cost: true, // the results are the sources
+		NextSteps: func(response *models.Response) []string {
+			if search := response.Content.Search; search == nil || len(search.Results) == 0 {
+				return []string{"Rephrase with a symbol name, or add tag:<tag> to narrow by topic"}
+			}
+			return []string{
+				"`why <n>` explains how result n ranked",
+				"`explain <function>` walks through a result",
+			}
+		},
+	},
+	models.ResponseTypeExplanation: {
+		Icon: "💡", Title: "Explanation", Sources: true, Cost: true,
+		NextSteps: func(response *models.Response) []string {
+			var steps []string
+			for _, excerpt := range response.Content.Excerpts {
+				if excerpt.Folded {
+					steps = append(steps, "`excerpts` unfolds the referenced code")
+					break
+				}
+			}
+			if len(response.Metadata.Sources) > 0 {
+				steps = append(steps, "`pin <path>` keeps a source in every follow-up")
+			}
+			if response.ID != "" {
+				steps = append(steps, fmt.Sprintf("`refresh %s` regenerates this answer after the code changes", response.ID))
+			}
+			return steps
+		},
+	},
+	models.ResponseTypeCode: {
+		Icon: "📝", Title: "Generated code", Sources: true, Cost: true,
+		NextSteps: func(response *models.Response) []string {
+			return []string{"`test <function>` generates tests for it", "`provenance` lists files holding applied code"}
+		},
+	},
+	models.ResponseTypeTest: {Icon: "🧪", Title: "Tests", Sources: true, Cost: true},
+	models.ResponseTypeRefactor: {
+		Icon: "🛠️", Title: "Refactoring", Sources: true, Cost: true,
+		NextSteps: func(response *models.Response) []string {
+			return []string{"`test <function>` covers the refactored code before applying it"}
+		},
+	},
+	models.ResponseTypeDebug:         {Icon: "🐞", Title: "Analysis", Sources: true, Cost: true},
+	models.ResponseTypeDocumentation: {Icon: "📚", Title: "Documentation", Sources: true, Cost: true},
+	models.ResponseTypeSuggestion:    {Icon: "✨", Title: "Suggestions", Sources: true, Cost: true},
+	models.ResponseTypeMigration: {
+		Icon: "🚚", Title: "Migration plan", Sources: true, Cost: true,
+		NextSteps: func(response *models.Response) []string {
+			return []string{"Review each file's diff before applying it"}
+		},
+	},
+	models.ResponseTypePlan:          {Icon: "🗺️", Title: "Execution plan"},
+	models.ResponseTypeClarification: {Icon: "❓", Title: "Clarification"},
+	models.ResponseTypeSystem:        {Icon: "⚙️", Title: "System"},
+	models.ResponseTypeError: {
+		Icon: "❌", Title: "Error", Cost: true,
+		NextSteps: func(response *models.Response) []string {
+			return []string{"`/verbose debug` shows every step of the next query", "`status` checks the providers and index"}
+		},
+	},
+}
+
+// TemplateFor returns the template of a response type; unknown types get
+// a generic one
+func TemplateFor(responseType models.ResponseType) ResponseTemplate {
+	if template, ok := responseTemplates[responseType]; ok {
+		return template
+	}
+	return defaultTemplate
+}
+
+// nextSteps returns the template's follow-up suggestions for a response
+func (t ResponseTemplate) nextSteps(response *models.Response) []string {
+	if t.NextSteps == nil {
+		return nil
+	}
+	return t.NextSteps(response)
+}
+
+// listedSources returns the sources to list and how many more there are
+func listedSources(response *models.Response) ([]string, int) {
+	sources := response.Metadata.Sources
+	if len(sources) > maxListedSources {
+		return sources[:maxListedSources], len(sources) - maxListedSources
+	}
+	return sources, 0
+}
+
+// writeTemplateHeader writes the answer's title line
+func writeTemplateHeader(w io.Writer, template ResponseTemplate, response *models.Response) {
+	fmt.Fprintln(w)
+	color.New(color.FgGreen).Fprintf(w, "%s %s", template.Icon, template.Title)
+	if response.Provider != "" {
+		color.New(color.Faint).Fprintf(w, " · %s", response.Provider)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, strings.Repeat("─", 50))
+}
+
+// writeTemplateSources lists the files the answer drew on
+func writeTemplateSources(w io.Writer, template ResponseTemplate, response *models.Response) {
+	sources, more := listedSources(response)
+	if !template.Sources || len(sources) == 0 {
+		return
+	}
+	color.New(color.FgCyan).Fprintln(w, "\n📂 Sources:")
+	for _, source := range sources {
+		fmt.Fprintf(w, "  • %s\n", source)
+	}
+	if more > 0 {
+		fmt.Fprintf(w, "  … and %d more\n", more)
+	}
+}
+
+// writeTemplateFooter writes the next steps, the answer's numbered quick
+// actions first, and the cost footer
+func writeTemplateFooter(w io.Writer, template ResponseTemplate, response *models.Response) {
+	writeNextSteps(w, template, response, "•")
+	if template.Cost {
+		fmt.Fprintf(w, "\n📊 %v | Agent: %s | Tokens: %d | Cost: $%.4f | Quality: %.1f%%\n",
+			response.Metadata.GenerationTime.Truncate(time.Millisecond),
+			response.AgentUsed,
+			response.TokenUsage.TotalTokens,
+			response.Cost.TotalCost,
+			response.Metadata.Confidence*100)
+	}
+}
+
+// writeNextSteps lists an answer's quick actions by number, then the
+// template's hints marked with bullet
+func writeNextSteps(w io.Writer, template ResponseTemplate, response *models.Response, bullet string) {
+	followUps := response.Content.FollowUps
+	steps := template.nextSteps(response)
+	if len(followUps) == 0 && len(steps) == 0 {
+		return
+	}
+	color.New(color.FgMagenta).Fprintln(w, "\n👉 Next:")
+	for i, followUp := range followUps {
+		fmt.Fprintf(w, "  %d. %s\n", i+1, followUp.Label)
+	}
+	for _, step := range steps {
+		fmt.Fprintf(w, "  %s %s\n", bullet, step)
+	}
+	if len(followUps) > 0 {
+		fmt.Fprintln(w, "💬 Type a number to run it")
+	}
+}
+
+// markdownTemplateHeader writes the answer's title as a markdown heading
+func markdownTemplateHeader(b *strings.Builder, template ResponseTemplate) {
+	fmt.Fprintf(b, "### %s %s\n\n", template.Icon, template.Title)
+}
+
+// markdownTemplateFooter writes sources, next steps and the cost line
+func markdownTemplateFooter(b *strings.Builder, template ResponseTemplate, response *models.Response) {
+	if sources, more := listedSources(response); template.Sources && len(sources) > 0 {
+		b.WriteString("**Sources**\n\n")
+		for _, source := range sources {
+			fmt.Fprintf(b, "- `%s`\n", source)
+		}
+		if more > 0 {
+			fmt.Fprintf(b, "- … and %d more\n", more)
+		}
+		b.WriteString("\n")
+	}
+	followUps := response.Content.FollowUps
+	if steps := template.nextSteps(response); len(followUps) > 0 || len(steps) > 0 {
+		b.WriteString("**Next**\n\n")
+		for i, followUp := range followUps {
+			fmt.Fprintf(b, "%d. %s (`%s`)\n", i+1, followUp.Label, followUp.Input)
+		}
+		if len(followUps) > 0 && len(steps) > 0 {
+			b.WriteString("\n")
+		}
+		for _, step := range steps {
+			fmt.Fprintf(b, "- %s\n", step)
+		}
+		b.WriteString("\n")
+	}
+	if template.Cost {
+		fmt.Fprintf(b, "_%s via %s · %d tokens · $%.4f · %v_\n",
+			response.AgentUsed, response.Provider, response.TokenUsage.TotalTokens,
+			response.Cost.TotalCost, response.Metadata.GenerationTime.Truncate(time.Millisecond))
+	}
+}
@@ -0,0 +1,134 @@
+package display
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fatih/color"
+
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// summaryPackages caps the packages listed in a result summary
+const summaryPackages = 10
+
+// resultPage returns the results on the summary's current page and the
+// index of the first one; none when the summary is shown alone
+func resultPage(search *models.SearchResponse) ([]models.SearchResult, int) {
+	summary := search.Summary
+	if summary == nil {
+		return search.Results, 0
+	}
+	if summary.Page <= 0 || summary.PageSize <= 0 {
+		return nil, 0
+	}
+	start := (summary.Page - 1) * summary.PageSize
+	if start >= len(search.Results) {
+		return nil, 0
+	}
+	end := min(start+summary.PageSize, len(search.Results))
+	return search.Results[start:end], start
+}
+
+// writeResultSummary writes the counts and notable items standing in for a
+// long result list
+func writeResultSummary(w io.Writer, search *models.SearchResponse) {
+	summary := search.Summary
+	color.New(color.FgBlue).Fprintf(w, "\n🔍 Search Results: %d matches in %d files across %d packages\n",
+		summary.Total, summary.Files, len(summary.ByPackage))
+
+	for i, group := range summary.ByPackage {
+		if i == summaryPackages {
+			fmt.Fprintf(w, "  └─ ... %d more packages\n", len(summary.ByPackage)-summaryPackages)
+			break
+		}
+		fmt.Fprintf(w, "  ├─ %-50s %d\n", group.Name, group.Count)
+	}
+
+	if len(summary.Notable) > 0 {
+		color.New(color.FgCyan).Fprintln(w, "\n⭐ Top matches:")
+		for _, i := range summary.Notable {
+			if i >= 0 && i < len(search.Results) {
+				writeSearchResult(w, i, search.Results[i])
+			}
+		}
+	}
+}
+
+// writeResultPageHint says which page is shown and how to see the others
+func writeResultPageHint(w io.Writer, summary *models.ResultSummary) {
+	pages := summary.Pages()
+	switch {
+	case summary.Page <= 0:
+		fmt.Fprintf(w, "\n📄 Type `more` to list them %d at a time (%d pages), or `more <page>`\n", summary.PageSize, pages)
+	case summary.Page < pages:
+		fmt.Fprintf(w, "\n📄 Page %d of %d - type `more` for the next page, or `more <page>`\n", summary.Page, pages)
+	default:
+		fmt.Fprintf(w, "\n📄 Page %d of %d - the last page\n", summary.Page, pages)
+	}
+}
+
+// writeSearchResult writes one numbered search result with its context
+func writeSearchResult(w io.Writer, i int, result models.SearchResult) {
+	label := ""
+	if result.Origin == "deps" {
+		label = " 📦 dependency"
+	}
+	if result.Stale != "" {
+		label += fmt.Sprintf(" ⚠️ %s since indexing", result.Stale)
+	}
+	fmt.Fprintf(w, "  ├─ %d. %s:%d - %s (Score: %.2f)%s\n",
+		i+1, result.File, result.Line, resultName(result), result.Score, label)
+
+	// Show context if available
+	if result.Context != "" {
+		context := result.Context
+		if len(context) > 80 {
+			context = context[:77] + "..."
+		}
+		fmt.Fprintf(w, "     📝 %s\n", context)
+	}
+}
+
+// markdownResultSummary writes the summary of a long result list as markdown
+func markdownResultSummary(b *strings.Builder, search *models.SearchResponse) {
+	summary := search.Summary
+	fmt.Fprintf(b, "**Search results**: %d matches in %d files across %d packages\n\n",
+		summary.Total, summary.Files, len(summary.ByPackage))
+
+	b.WriteString("| Package | Matches |\n|---|---|\n")
+	for i, group := range summary.ByPackage {
+		if i == summaryPackages {
+			fmt.Fprintf(b, "| _%d more packages_ | |\n", len(summary.ByPackage)-summaryPackages)
+			break
+		}
+		fmt.Fprintf(b, "| `%s` | %d |\n", group.Name, group.Count)
+	}
+	b.WriteString("\n")
+
+	if len(summary.Notable) > 0 {
+		b.WriteString("**Top matches**\n\n")
+		for _, i := range summary.Notable {
+			if i >= 0 && i < len(search.Results) {
+				markdownSearchResult(b, i, search.Results[i])
+			}
+		}
+		b.WriteString("\n")
+	}
+	if summary.Page <= 0 {
+		fmt.Fprintf(b, "_All %d matches fill %d pages of %d._\n\n", summary.Total, summary.Pages(), summary.PageSize)
+	}
+}
+
+// markdownSearchResult writes one numbered search result as a list item
+func markdownSearchResult(b *strings.Builder, i int, result models.SearchResult) {
+	fmt.Fprintf(b, "%d. `%s:%d` %s (score %.2f)", i+1, result.File, result.Line, resultName(result), result.Score)
+	if result.Origin == "deps" {
+		b.WriteString(" — dependency")
+	}
+	if result.Stale != "" {
+		fmt.Fprintf(b, " — %s since indexing", result.Stale)
+	}
+	b.WriteString("\n")
+}
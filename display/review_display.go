@@ -0,0 +1,87 @@
+package display
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// ShowGenerationReview prints where a reviewed generation stands: the
+// outline while it awaits approval, then the draft of the file under review
+func ShowGenerationReview(review *models.GenerationReview) {
+	color.New(color.FgCyan, color.Bold).Printf("\n📝 Review %s: %s\n", review.TaskID, review.Request)
+	if review.Outline == nil {
+		fmt.Println("   Drafting the outline...")
+		return
+	}
+
+	switch review.Stage {
+	case models.ReviewStageOutline:
+		if review.Outline.Summary != "" {
+			fmt.Printf("   %s\n", review.Outline.Summary)
+		}
+		for i, file := range review.Outline.Files {
+			color.New(color.Bold).Printf("  %d. %s\n", i+1, file.Path)
+			fmt.Printf("     %s\n", file.Responsibility)
+			if len(file.Functions) > 0 {
+				color.New(color.FgHiBlack).Printf("     %s\n", strings.Join(file.Functions, ", "))
+			}
+		}
+		if review.Waiting {
+			fmt.Println("\n💡 'review approve' to generate the files; 'review add <path>: <responsibility>', 'review drop <n>' or 'review describe <n>: <responsibility>' to edit the outline")
+		}
+	case models.ReviewStageFiles:
+		showReviewProgress(review)
+		draft := review.Draft
+		if draft == nil {
+			if file := review.CurrentFile(); file != nil {
+				fmt.Printf("   Drafting %s...\n", file.Path)
+			}
+			return
+		}
+		verb := "create"
+		if draft.Exists {
+			verb = "replace"
+		}
+		color.New(color.Bold).Printf("\n  %s (%s)\n", draft.Path, verb)
+		if file := review.CurrentFile(); file != nil {
+			fmt.Printf("  %s\n", file.Responsibility)
+		}
+		for _, feedback := range draft.Feedback {
+			color.New(color.FgYellow).Printf("  revised: %s\n", feedback)
+		}
+		color.New(color.FgHiBlack).Println("  " + strings.Repeat("─", 60))
+		for _, line := range strings.Split(draft.Content, "\n") {
+			fmt.Printf("  %s\n", line)
+		}
+		color.New(color.FgHiBlack).Println("  " + strings.Repeat("─", 60))
+		for _, violation := range draft.Policies {
+			color.New(color.FgRed).Printf("  ✗ policy %s, line %d: %s\n", violation.Policy, violation.Line, violation.Reason)
+		}
+		if review.Waiting {
+			fmt.Println("💡 'review accept' to write it, 'review revise <feedback>' to redraft it, 'review skip' to leave it out")
+		}
+	default:
+		showReviewProgress(review)
+		color.New(color.FgGreen).Println("   ✅ Finished")
+	}
+	if !review.Waiting && review.Stage != models.ReviewStageDone {
+		fmt.Printf("   Not waiting for a decision; if the task stopped, continue it with 'tasks resume %s'\n", review.TaskID)
+	}
+	color.New(color.FgHiBlack).Printf("   %d tokens, $%.4f so far\n", review.Tokens, review.Cost)
+}
+
+// showReviewProgress prints the files written and skipped so far
+func showReviewProgress(review *models.GenerationReview) {
+	fmt.Printf("   File %d of %d", min(review.Next+1, len(review.Outline.Files)), len(review.Outline.Files))
+	if len(review.Accepted) > 0 {
+		color.New(color.FgGreen).Printf("  ✅ %s", strings.Join(review.Accepted, ", "))
+	}
+	if len(review.Skipped) > 0 {
+		color.New(color.FgYellow).Printf("  ⏭ %s", strings.Join(review.Skipped, ", "))
+	}
+	fmt.Println()
+}
@@ -0,0 +1,33 @@
+package display
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// ShowQueryTemplates lists saved query templates with their parameters
+func ShowQueryTemplates(templates []*storage.QueryTemplate) {
+	if len(templates) == 0 {
+		fmt.Println("🧩 No templates. Save one with 'templates save <name>: <query with $params>'.")
+		return
+	}
+	color.New(color.FgCyan, color.Bold).Printf("\n🧩 Templates (%d):\n", len(templates))
+	for _, template := range templates {
+		color.New(color.Bold).Printf("  %s", template.Name)
+		if params := template.Params(); len(params) > 0 {
+			color.New(color.FgYellow).Printf(" %s=...", strings.Join(params, "=... "))
+		}
+		fmt.Printf("\n    %s\n", template.Query)
+		if template.Description != "" {
+			fmt.Printf("    %s\n", template.Description)
+		}
+		if template.Runs > 0 {
+			color.New(color.FgHiBlack).Printf("    run %d times, last %s\n", template.Runs, template.LastRunAt.Format("2006-01-02 15:04"))
+		}
+	}
+	fmt.Println("\n💡 'run template <name> param=value' to run one, 'templates export' to share them")
+}
@@ -0,0 +1,80 @@
+package display
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/fatih/color"
+
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// TerminalRenderer writes answers for an interactive terminal, with colors,
+// emoji and the execution footer
+type TerminalRenderer struct {
+	w io.Writer
+}
+
+// NewTerminalRenderer creates a terminal renderer writing to w
+func NewTerminalRenderer(w io.Writer) *TerminalRenderer {
+	return &TerminalRenderer{w: w}
+}
+
+// Name returns "terminal"
+func (r *TerminalRenderer) Name() string {
+	return RendererTerminal
+}
+
+// RenderResponse writes the answer in its type's template: header, code,
+// search results, plan, quick replies, version and freshness notes, then
+// sources, next steps and the cost footer
+func (r *TerminalRenderer) RenderResponse(response *models.Response) error {
+	w := r.w
+	template := TemplateFor(response.Type)
+	writeTemplateHeader(w, template, response)
+
+	if response.Content.Text != "" {
+		fmt.Fprintln(w, response.Content.Text)
+	}
+
+	if response.Content.Code != nil {
+		color.New(color.FgYellow).Fprintf(w, "\n📝 Generated Code (%s):\n", response.Content.Code.Language)
+		fmt.Fprintln(w, response.Content.Code.Code)
+		writeProposedModules(w, response.Content.Code)
+		writeWiring(w, response.Content.Code)
+	}
+
+	if search := response.Content.Search; search != nil && len(search.Results) > 0 {
+		if search.Summary != nil {
+			// Too many to list: summarize, and list the page asked for
+			writeResultSummary(w, search)
+			if page, offset := resultPage(search); len(page) > 0 {
+				color.New(color.FgBlue).Fprintf(w, "\n🔍 Results %d-%d of %d:\n", offset+1, offset+len(page), search.Summary.Total)
+				for i, result := range page {
+					writeSearchResult(w, offset+i, result)
+				}
+			}
+			writeResultPageHint(w, search.Summary)
+		} else {
+			color.New(color.FgBlue).Fprintf(w, "\n🔍 Search Results (%d found):\n", len(search.Results))
+			for i, result := range search.Results {
+				writeSearchResult(w, i, result)
+			}
+		}
+	}
+
+	writeExcerpts(w, response.Content.Excerpts, false)
+	writeExecutionPlan(w, response.Content.Plan)
+	writeClarification(w, response.Content.Clarification)
+	writeAnswerVersion(w, response)
+	writeStaleSources(w, response)
+	writeDegraded(w, response)
+	writeKeywordOnly(w, response)
+	writeIndexCoverage(w, response)
+	writeSummaryFreshness(w, response)
+	writeTemplateSources(w, template, response)
+	writeTemplateFooter(w, template, response)
+
+	_, err := fmt.Fprintln(w)
+	return err
+}
@@ -0,0 +1,73 @@
+package display
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// ShowTodos lists recorded TODO, FIXME and HACK comments
+func ShowTodos(todos []*storage.TodoRecord) {
+	if len(todos) == 0 {
+		fmt.Println("📝 No matching todos. They are collected while indexing.")
+		return
+	}
+	color.New(color.FgCyan, color.Bold).Printf("\n📝 Todos (%d):\n", len(todos))
+	for _, todo := range todos {
+		kind := color.New(color.FgYellow)
+		if todo.Kind == "FIXME" || todo.Kind == "HACK" {
+			kind = color.New(color.FgRed)
+		}
+		fmt.Printf("  %4d ", todo.ID)
+		kind.Printf("%-5s", todo.Kind)
+		fmt.Printf(" %s:%d %s", todo.File, todo.Line, truncateColumn(todo.Text, 80))
+		color.New(color.FgHiBlack).Printf("  (%s)\n", todoOrigin(todo))
+	}
+	fmt.Println("\n💡 'todos show <id>' for context, 'todos resolve <id>' to draft the missing code")
+}
+
+// ShowTodo prints one recorded comment with the code around it
+func ShowTodo(todo *storage.TodoRecord) {
+	color.New(color.FgCyan, color.Bold).Printf("\n📝 %s #%d at %s:%d\n", todo.Kind, todo.ID, todo.File, todo.Line)
+	fmt.Printf("  %s\n", todo.Text)
+	color.New(color.FgHiBlack).Printf("  %s, package %s\n\n", todoOrigin(todo), todo.Package)
+
+	for i, line := range strings.Split(todo.Context, "\n") {
+		number := todo.ContextStart + i
+		if number == todo.Line {
+			color.New(color.FgYellow).Printf("  %5d │ %s\n", number, line)
+			continue
+		}
+		fmt.Printf("  %5d │ %s\n", number, line)
+	}
+	fmt.Println()
+}
+
+// todoOrigin describes who last touched a comment and how long ago
+func todoOrigin(todo *storage.TodoRecord) string {
+	author := todo.Author
+	if author == "" {
+		author = "unknown author"
+	}
+	if todo.CommittedAt.IsZero() {
+		return author + ", uncommitted"
+	}
+	return fmt.Sprintf("%s, %s ago", author, formatAge(time.Since(todo.CommittedAt)))
+}
+
+// formatAge renders a duration in the largest whole unit: days, hours or
+// minutes
+func formatAge(age time.Duration) string {
+	switch {
+	case age >= 48*time.Hour:
+		return fmt.Sprintf("%dd", int(age.Hours()/24))
+	case age >= time.Hour:
+		return fmt.Sprintf("%dh", int(age.Hours()))
+	default:
+		return fmt.Sprintf("%dm", int(age.Minutes()))
+	}
+}
@@ -0,0 +1,96 @@
+package display
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fatih/color"
+
+	"github.com/yourusername/useq-ai-assistant/internal/wiring"
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// writeWiring lists the constructors generated code adds that nothing
+// calls yet, with the initialization to insert in their composition root
+func writeWiring(w io.Writer, code *models.CodeResponse) {
+	if code == nil || len(code.Wiring) == 0 {
+		return
+	}
+	color.New(color.FgYellow).Fprintf(w, "\n🔌 Wiring needed (%d):\n", len(code.Wiring))
+	for _, suggestion := range code.Wiring {
+		writeWiringSuggestion(w, suggestion)
+	}
+}
+
+// writeWiringSuggestion prints where and how to call one constructor
+func writeWiringSuggestion(w io.Writer, suggestion *models.WiringSuggestion) {
+	fmt.Fprintf(w, "  %s: in %s, after %s:%d\n", suggestion.Constructor, suggestion.Func, suggestion.File, suggestion.Line)
+	for _, line := range strings.Split(strings.TrimRight(suggestion.Code, "\n"), "\n") {
+		color.New(color.FgGreen).Fprintf(w, "    %s\n", line)
+	}
+	if suggestion.Field != "" {
+		color.New(color.FgCyan).Fprintf(w, "    add the field: %s\n", suggestion.Field)
+	}
+	if len(suggestion.Unresolved) > 0 {
+		color.New(color.FgHiBlack).Fprintf(w, "    nothing there provides: %s\n", strings.Join(suggestion.Unresolved, ", "))
+	}
+	if suggestion.UnhandledError {
+		color.New(color.FgYellow).Fprintf(w, "    err is left unhandled: %s cannot return it\n", suggestion.Func)
+	}
+}
+
+// ShowUnwired lists the project's constructors that nothing calls, with
+// the wiring suggested for each
+func ShowUnwired(graph *wiring.Graph) {
+	unwired := graph.Unwired()
+	color.New(color.FgCyan, color.Bold).Printf("\n🔌 Constructors: %d, not called anywhere: %d\n", len(graph.Constructors), len(unwired))
+	for _, c := range unwired {
+		color.New(color.Bold).Printf("\n  %s.%s", c.PackageName, c.Name)
+		color.New(color.FgHiBlack).Printf("  %s:%d\n", c.File, c.Line)
+		if suggestion := graph.Suggest(c); suggestion != nil {
+			writeWiringSuggestion(color.Output, suggestion)
+		}
+	}
+	fmt.Println("\n💡 'wiring <constructor>' shows what one depends on and where it is called")
+}
+
+// ShowConstructorWiring prints a constructor's dependencies, the
+// constructors providing them and its call sites
+func ShowConstructorWiring(graph *wiring.Graph, c *wiring.Constructor) {
+	color.New(color.FgCyan, color.Bold).Printf("\n🔌 %s.%s", c.PackageName, c.Name)
+	color.New(color.FgHiBlack).Printf("  %s:%d\n", c.File, c.Line)
+	returns := c.Returns
+	if c.ReturnsError {
+		returns += ", error"
+	}
+	fmt.Printf("  returns %s\n", returns)
+
+	if len(c.Params) > 0 {
+		fmt.Println("  depends on:")
+		for i, provider := range graph.Providers(c) {
+			param := c.Params[i]
+			fmt.Printf("    %s %s", param.Name, param.Type)
+			if provider != nil {
+				color.New(color.FgGreen).Printf("  <- %s.%s", provider.PackageName, provider.Name)
+			}
+			fmt.Println()
+		}
+	}
+
+	if len(c.Calls) == 0 {
+		color.New(color.FgYellow).Println("  not called anywhere")
+		if suggestion := graph.Suggest(c); suggestion != nil {
+			writeWiringSuggestion(color.Output, suggestion)
+		}
+		return
+	}
+	fmt.Println("  called from:")
+	for _, call := range c.Calls {
+		fmt.Printf("    %s  %s:%d", call.Func, call.File, call.Line)
+		if call.Target != "" {
+			color.New(color.FgHiBlack).Printf("  -> %s", call.Target)
+		}
+		fmt.Println()
+	}
+}
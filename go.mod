@@ -4,6 +4,7 @@
 // fatih/color - Terminal colors for beautiful CLI output
 // fsnotify - File system watching for real-time code updates
 // go-sqlite3 - Local metadata storage
+// klauspost/compress - zstd for compressed Qdrant payloads
 // qdrant/go-client - Vector database for semantic search
 // sashabaranov/go-openai - Primary AI provider
 // spf13/cobra - CLI framework for robust command handling
@@ -23,10 +24,11 @@ require (
 	github.com/joho/godotenv v1.5.1
 	github.com/spf13/viper v1.18.2
 	go.uber.org/zap v1.26.0
-	gopkg.in/yaml.v3 v3.0.1 // indirect
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
+	github.com/klauspost/compress v1.17.4
 	github.com/mattn/go-sqlite3 v1.14.32
 	github.com/qdrant/go-client v1.15.2
 	github.com/sashabaranov/go-openai v1.41.2
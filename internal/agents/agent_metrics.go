@@ -0,0 +1,84 @@
+package agents
+
+import (
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/metrics"
+)
+
+// agentMetrics records an agent's activity in the process metrics registry
+// under agents.<name>.*. Every instance of an agent adds to the same counts,
+// and queries of concurrent sessions record without further locking.
+// Averages cover the latest metrics.DefaultWindowSize answers.
+type agentMetrics struct {
+	queries      *metrics.Counter
+	errors       *metrics.Counter
+	tokens       *metrics.Counter
+	cost         *metrics.Sum
+	lastUsed     *metrics.Timestamp
+	responseTime *metrics.Window
+	confidence   *metrics.Window
+}
+
+// newAgentMetrics returns the recorder of the agent called name
+func newAgentMetrics(name string) *agentMetrics {
+	registry := metrics.Default
+	prefix := "agents." + name + "."
+	return &agentMetrics{
+		queries:      registry.Counter(prefix + "queries"),
+		errors:       registry.Counter(prefix + "errors"),
+		tokens:       registry.Counter(prefix + "tokens"),
+		cost:         registry.Sum(prefix + "cost_usd"),
+		lastUsed:     registry.Timestamp(prefix + "last_used"),
+		responseTime: registry.Window(prefix + "response_seconds"),
+		confidence:   registry.Window(prefix + "confidence"),
+	}
+}
+
+// started records a query the agent took on
+func (m *agentMetrics) started(at time.Time) {
+	if m == nil {
+		return
+	}
+	m.queries.Inc()
+	m.lastUsed.Set(at)
+}
+
+// failed records a query the agent could not answer
+func (m *agentMetrics) failed() {
+	if m == nil {
+		return
+	}
+	m.errors.Inc()
+}
+
+// succeeded records an answer with what it took and cost
+func (m *agentMetrics) succeeded(duration time.Duration, confidence float64, tokens int, cost float64) {
+	if m == nil {
+		return
+	}
+	m.responseTime.ObserveDuration(duration)
+	m.confidence.Observe(confidence)
+	m.tokens.Add(int64(tokens))
+	m.cost.Add(cost)
+}
+
+// snapshot returns the recorded activity as AgentMetrics
+func (m *agentMetrics) snapshot() AgentMetrics {
+	if m == nil {
+		return AgentMetrics{}
+	}
+	snapshot := AgentMetrics{
+		QueriesHandled:      int(m.queries.Value()),
+		ErrorCount:          int(m.errors.Value()),
+		TokensUsed:          m.tokens.Value(),
+		TotalCost:           m.cost.Value(),
+		LastUsed:            m.lastUsed.Value(),
+		AverageResponseTime: m.responseTime.MeanDuration(),
+		AverageConfidence:   m.confidence.Mean(),
+	}
+	if snapshot.QueriesHandled > 0 {
+		snapshot.SuccessRate = float64(snapshot.QueriesHandled-snapshot.ErrorCount) / float64(snapshot.QueriesHandled)
+	}
+	return snapshot
+}
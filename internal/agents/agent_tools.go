@@ -0,0 +1,346 @@
+package agents
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/llm"
+	"github.com/yourusername/useq-ai-assistant/internal/mcp"
+	"github.com/yourusername/useq-ai-assistant/internal/sandbox"
+)
+
+// Limits on what a single tool call returns
+const (
+	maxSearchLimit     = 10
+	maxSearchSnippet   = 600
+	maxReadFileLines   = 400
+	maxListDirEntries  = 200
+	defaultSearchLimit = 5
+)
+
+// testPackagePattern accepts relative package patterns such as ./..., ./internal/llm
+var testPackagePattern = regexp.MustCompile(`^\./[\w./-]*$`)
+
+// agentTool pairs a tool definition with its implementation and the
+// capability it needs. Arguments arrive as the JSON object the model produced.
+type agentTool struct {
+	definition llm.Tool
+	capability mcp.Capability
+	run        func(ctx context.Context, arguments string) (string, error)
+}
+
+// agentToolbox is the set of tools offered to the model, rooted at a project
+type agentToolbox struct {
+	root   string
+	policy sandbox.Policy // for the commands tools run
+	order  []string
+	tools  map[string]agentTool
+}
+
+// newAgentToolbox builds the Tier 3 tools: search_index, read_file, list_dir
+// and run_tests, then the tools of configured external MCP servers. Paths
+// are confined to root.
+func (ma *ManagerAgent) newAgentToolbox(root string, config ToolLoopConfig) *agentToolbox {
+	box := &agentToolbox{root: root, policy: ma.sandbox, tools: make(map[string]agentTool)}
+
+	box.add(llm.Tool{
+		Name:        "search_index",
+		Description: "Semantic search over the indexed code. Returns matching chunks with file paths, line ranges and scores.",
+		Parameters: json.RawMessage(`{"type":"object","properties":{` +
+			`"query":{"type":"string","description":"What to look for, in natural language or identifiers"},` +
+			`"limit":{"type":"integer","description":"Maximum results (1-10, default 5)"}},"required":["query"]}`),
+	}, mcp.CapabilityRead, ma.searchIndexTool)
+
+	box.add(llm.Tool{
+		Name:        "read_file",
+		Description: "Read a file in the project, optionally a line range. Lines are numbered.",
+		Parameters: json.RawMessage(`{"type":"object","properties":{` +
+			`"path":{"type":"string","description":"Path relative to the project root"},` +
+			`"start_line":{"type":"integer","description":"First line, from 1"},` +
+			`"end_line":{"type":"integer","description":"Last line, inclusive"}},"required":["path"]}`),
+	}, mcp.CapabilityRead, box.readFileTool)
+
+	box.add(llm.Tool{
+		Name:        "list_dir",
+		Description: "List the files and directories in a project directory.",
+		Parameters: json.RawMessage(`{"type":"object","properties":{` +
+			`"path":{"type":"string","description":"Directory relative to the project root (default \".\")"}}}`),
+	}, mcp.CapabilityRead, box.listDirTool)
+
+	box.add(llm.Tool{
+		Name:        "run_tests",
+		Description: "Run go test for a package pattern and return the output.",
+		Parameters: json.RawMessage(`{"type":"object","properties":{` +
+			`"package":{"type":"string","description":"Relative package pattern such as ./... or ./internal/llm (default ./...)"},` +
+			`"run":{"type":"string","description":"Optional -run regular expression selecting tests"}}}`),
+	}, mcp.CapabilityExec, func(ctx context.Context, arguments string) (string, error) {
+		return box.runTestsTool(ctx, arguments, config.TestTimeout)
+	})
+
+	if ma.dependencies != nil {
+		external := ma.dependencies.ExternalTools
+		for _, tool := range external.Tools() {
+			tool := tool
+			box.add(llm.Tool{
+				Name:        tool.QualifiedName,
+				Description: fmt.Sprintf("[%s MCP server] %s", tool.Server, tool.Description),
+				Parameters:  tool.InputSchema,
+			}, tool.Capability, func(ctx context.Context, arguments string) (string, error) {
+				return external.Call(ctx, tool.QualifiedName, json.RawMessage(arguments))
+			})
+		}
+	}
+
+	return box
+}
+
+func (b *agentToolbox) add(definition llm.Tool, capability mcp.Capability, run func(ctx context.Context, arguments string) (string, error)) {
+	b.order = append(b.order, definition.Name)
+	b.tools[definition.Name] = agentTool{definition: definition, capability: capability, run: run}
+}
+
+// definitions returns, in a stable order, the definitions of the tools the
+// agent in ctx is permitted to use
+func (b *agentToolbox) definitions(ctx context.Context) []llm.Tool {
+	definitions := make([]llm.Tool, 0, len(b.order))
+	for _, name := range b.order {
+		if tool := b.tools[name]; mcp.Permitted(ctx, tool.capability) {
+			definitions = append(definitions, tool.definition)
+		}
+	}
+	return definitions
+}
+
+// call runs a tool by name once the agent in ctx is authorized to use it
+func (b *agentToolbox) call(ctx context.Context, name, arguments string) (string, error) {
+	tool, ok := b.tools[name]
+	if !ok {
+		return "", fmt.Errorf("unknown tool %q", name)
+	}
+	if err := mcp.Authorize(ctx, tool.capability, name+" "+arguments); err != nil {
+		return "", err
+	}
+	return tool.run(ctx, arguments)
+}
+
+// resolve maps a model-supplied path into the project, rejecting escapes
+func (b *agentToolbox) resolve(path string) (string, error) {
+	if path == "" {
+		path = "."
+	}
+	if filepath.IsAbs(path) {
+		rel, err := filepath.Rel(b.root, path)
+		if err != nil {
+			return "", fmt.Errorf("path %s is outside the project", path)
+		}
+		path = rel
+	}
+	resolved := filepath.Join(b.root, filepath.Clean(path))
+	if resolved != b.root && !strings.HasPrefix(resolved, b.root+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %s is outside the project", path)
+	}
+	return resolved, nil
+}
+
+func (ma *ManagerAgent) searchIndexTool(ctx context.Context, arguments string) (string, error) {
+	var args struct {
+		Query string `json:"query"`
+		Limit int    `json:"limit"`
+	}
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	if strings.TrimSpace(args.Query) == "" {
+		return "", fmt.Errorf("query is required")
+	}
+	if args.Limit <= 0 {
+		args.Limit = defaultSearchLimit
+	}
+	args.Limit = min(args.Limit, maxSearchLimit)
+
+	if ma.dependencies == nil || ma.dependencies.VectorDB == nil {
+		return "", fmt.Errorf("the code index is not available")
+	}
+	results, err := ma.dependencies.VectorDB.Search(ctx, args.Query, args.Limit)
+	if err != nil {
+		return "", fmt.Errorf("search failed: %w", err)
+	}
+	if len(results) == 0 {
+		return "No matches.", nil
+	}
+
+	var out strings.Builder
+	for i, result := range results {
+		if result.Chunk == nil {
+			continue
+		}
+		snippet := result.Chunk.Content
+		if len(snippet) > maxSearchSnippet {
+			snippet = snippet[:maxSearchSnippet] + "\n..."
+		}
+		stale := ""
+		if result.Stale != "" {
+			stale = fmt.Sprintf(" [stale: file %s since indexing, read_file for current content]", result.Stale)
+		}
+		out.WriteString(fmt.Sprintf("%d. %s:%d-%d (score %.2f)%s\n%s\n\n",
+			i+1, result.Chunk.FilePath, result.Chunk.StartLine, result.Chunk.EndLine, result.Score, stale, snippet))
+	}
+	return out.String(), nil
+}
+
+func (b *agentToolbox) readFileTool(ctx context.Context, arguments string) (string, error) {
+	var args struct {
+		Path      string `json:"path"`
+		StartLine int    `json:"start_line"`
+		EndLine   int    `json:"end_line"`
+	}
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	path, err := b.resolve(args.Path)
+	if err != nil {
+		return "", err
+	}
+	if err := mcp.CurrentPathPolicy().Check("read", path); err != nil {
+		return "", err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("cannot read %s: %w", args.Path, err)
+	}
+	defer file.Close()
+
+	start := max(args.StartLine, 1)
+	end := args.EndLine
+	if end < start {
+		end = start + maxReadFileLines - 1
+	}
+	end = min(end, start+maxReadFileLines-1)
+
+	var out strings.Builder
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if line < start {
+			continue
+		}
+		if line > end {
+			out.WriteString(fmt.Sprintf("... (more lines after %d)\n", end))
+			break
+		}
+		out.WriteString(fmt.Sprintf("%5d  %s\n", line, scanner.Text()))
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("cannot read %s: %w", args.Path, err)
+	}
+	if line < start {
+		return "", fmt.Errorf("%s has only %d lines", args.Path, line)
+	}
+	return out.String(), nil
+}
+
+func (b *agentToolbox) listDirTool(ctx context.Context, arguments string) (string, error) {
+	var args struct {
+		Path string `json:"path"`
+	}
+	if arguments != "" {
+		if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+			return "", fmt.Errorf("invalid arguments: %w", err)
+		}
+	}
+	dir, err := b.resolve(args.Path)
+	if err != nil {
+		return "", err
+	}
+	policy := mcp.CurrentPathPolicy()
+	if err := policy.Check("list", dir); err != nil {
+		return "", err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("cannot list %s: %w", args.Path, err)
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") || policy.Denied(filepath.Join(dir, name)) != "" {
+			continue
+		}
+		if entry.IsDir() {
+			name += "/"
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) > maxListDirEntries {
+		names = append(names[:maxListDirEntries], fmt.Sprintf("... (%d more)", len(names)-maxListDirEntries))
+	}
+	if len(names) == 0 {
+		return "(empty)", nil
+	}
+	return strings.Join(names, "\n"), nil
+}
+
+// runTestsTool runs go test in the project under the configured sandbox
+// policy. Only relative package patterns and a -run expression are
+// accepted, so the model cannot pass other flags.
+func (b *agentToolbox) runTestsTool(ctx context.Context, arguments string, timeout time.Duration) (string, error) {
+	var args struct {
+		Package string `json:"package"`
+		Run     string `json:"run"`
+	}
+	if arguments != "" {
+		if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+			return "", fmt.Errorf("invalid arguments: %w", err)
+		}
+	}
+	if args.Package == "" {
+		args.Package = "./..."
+	}
+	if !testPackagePattern.MatchString(args.Package) || strings.Contains(args.Package, "..") && !strings.HasSuffix(args.Package, "/...") {
+		return "", fmt.Errorf("package must be a relative pattern like ./... or ./internal/llm")
+	}
+	if _, err := b.resolve(strings.TrimSuffix(args.Package, "/...")); err != nil {
+		return "", err
+	}
+
+	cmdArgs := []string{"test", args.Package}
+	if args.Run != "" {
+		if _, err := regexp.Compile(args.Run); err != nil {
+			return "", fmt.Errorf("invalid run expression: %w", err)
+		}
+		cmdArgs = append(cmdArgs, "-run", args.Run)
+	}
+
+	policy := b.policy
+	policy.Timeout = timeout
+	runner, err := sandbox.NewRunner(b.root, policy)
+	if err != nil {
+		return "", err
+	}
+	result, err := runner.Run(ctx, "go", cmdArgs...)
+	if err != nil {
+		return "", err
+	}
+
+	status := "PASS"
+	switch {
+	case result.TimedOut:
+		status = fmt.Sprintf("TIMEOUT after %s", timeout)
+	case result.ExitCode != 0:
+		status = fmt.Sprintf("FAIL: exit status %d", result.ExitCode)
+	}
+	output := result.Output
+	return fmt.Sprintf("go %s\nstatus: %s\n\n%s", strings.Join(cmdArgs, " "), status, output), nil
+}
@@ -7,7 +7,10 @@ import (
 	"time"
 
 	"github.com/yourusername/useq-ai-assistant/internal/llm"
+	"github.com/yourusername/useq-ai-assistant/internal/mcp"
+	"github.com/yourusername/useq-ai-assistant/internal/prewarm"
 	"github.com/yourusername/useq-ai-assistant/internal/vectordb"
+	"github.com/yourusername/useq-ai-assistant/internal/vocabulary"
 	"github.com/yourusername/useq-ai-assistant/models"
 	"github.com/yourusername/useq-ai-assistant/storage"
 )
@@ -103,6 +106,35 @@ type AgentDependencies struct {
 	Metrics    MetricsCollector           `json:"-"`
 	Cache      CacheManager               `json:"-"`
 	MCPClient  MCPClientInterface         `json:"-"`
+	Prewarm    *prewarm.Prewarmer         `json:"-"`
+
+	// DepsVectorDB holds indexed dependency source; nil unless enabled
+	DepsVectorDB *vectordb.QdrantClient `json:"-"`
+
+	// SummaryVectorDB holds file and package summaries; nil unless enabled
+	SummaryVectorDB *vectordb.QdrantClient `json:"-"`
+
+	// MemoryVectorDB holds earlier questions and answers; nil unless enabled
+	MemoryVectorDB *vectordb.QdrantClient `json:"-"`
+
+	// ExternalTools are the tools of the MCP servers configured under
+	// mcp_servers; nil when none are
+	ExternalTools *mcp.ExternalServers `json:"-"`
+
+	// Permissions limits the MCP tools each agent may use; nil allows all
+	Permissions *mcp.Permissions `json:"-"`
+
+	// Vocabulary is the project's domain terms, stop-words and synonyms;
+	// nil uses the built-in stop-words only
+	Vocabulary *vocabulary.Vocabulary `json:"-"`
+}
+
+// AgentContext scopes ctx to agent so MCP tools enforce its permissions
+func (d *AgentDependencies) AgentContext(ctx context.Context, agent string) context.Context {
+	if d == nil || d.Permissions == nil {
+		return ctx
+	}
+	return mcp.WithAgent(ctx, agent, d.Permissions)
 }
 
 // MCPClientInterface defines the interface for MCP client operations
@@ -3,10 +3,17 @@ package agents
 import (
 	"context"
 	"fmt"
+	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/yourusername/useq-ai-assistant/internal/deps"
+	"github.com/yourusername/useq-ai-assistant/internal/guardrails"
+	"github.com/yourusername/useq-ai-assistant/internal/language"
 	"github.com/yourusername/useq-ai-assistant/internal/llm"
+	"github.com/yourusername/useq-ai-assistant/internal/promptguard"
+	"github.com/yourusername/useq-ai-assistant/internal/testconv"
 	"github.com/yourusername/useq-ai-assistant/models"
 )
 
@@ -14,7 +21,10 @@ import (
 type CodingAgentImpl struct {
 	dependencies *AgentDependencies
 	config       *CodingAgentConfig
-	metrics      *AgentMetrics
+	metrics      *agentMetrics
+	guardrails   *guardrails.PolicyEngine
+
+	dependencyInsight deps.InsightConfig
 }
 
 // NewCodingAgentConfig creates a new coding agent configuration with sensible defaults
@@ -37,20 +47,18 @@ func NewCodingAgentConfig() *CodingAgentConfig {
 }
 
 // NewCodingAgent creates a new coding agent with centralized configuration
-func NewCodingAgent(deps *AgentDependencies) *CodingAgentImpl {
+func NewCodingAgent(dependencies *AgentDependencies) *CodingAgentImpl {
+	policyConfig, err := guardrails.LoadPolicyConfig(guardrails.DefaultPolicyPath)
+	if err != nil {
+		fmt.Printf("⚠️ Guardrail policies not loaded, using defaults: %v\n", err)
+	}
+
 	return &CodingAgentImpl{
-		dependencies: deps,
-		config:       NewCodingAgentConfig(),
-		metrics: &AgentMetrics{
-			QueriesHandled:      0,
-			SuccessRate:         0.0,
-			AverageResponseTime: 0,
-			AverageConfidence:   0.0,
-			TokensUsed:          0,
-			TotalCost:           0.0,
-			LastUsed:            time.Now(),
-			ErrorCount:          0,
-		},
+		dependencies:      dependencies,
+		config:            NewCodingAgentConfig(),
+		guardrails:        guardrails.NewPolicyEngine(policyConfig),
+		metrics:           newAgentMetrics("coding"),
+		dependencyInsight: deps.DefaultInsightConfig(),
 	}
 }
 
@@ -64,7 +72,7 @@ func (ca *CodingAgentImpl) GetCapabilities() AgentCapabilities {
 		CanWriteTests:      ca.config.GenerateTests,
 		CanWriteDocs:       ca.config.GenerateComments,
 		CanReviewCode:      false,
-		SupportedLanguages: []string{"go"},
+		SupportedLanguages: language.SupportedLanguages(),
 		MaxComplexity:      8,
 		RequiresContext:    true,
 	}
@@ -90,11 +98,11 @@ func (ca *CodingAgentImpl) CanHandle(ctx context.Context, query *models.Query) (
 func (ca *CodingAgentImpl) GetSpecialization() AgentSpecialization {
 	return AgentSpecialization{
 		Type:        AgentTypeCoding,
-		Languages:   []string{"go"},
+		Languages:   language.SupportedLanguages(),
 		Frameworks:  []string{"gin", "fiber", "echo", "stdlib"},
 		Domains:     []string{"web_development", "microservices", "cli_tools", "apis"},
 		Complexity:  8,
-		Description: "Generates Go code based on project patterns and context",
+		Description: "Generates code in the query's language based on project patterns and context",
 	}
 }
 
@@ -148,7 +156,7 @@ func (ca *CodingAgentImpl) Process(ctx context.Context, query *models.Query) (*m
 	// Parse code generation intent
 	intent, err := ca.parseCodeIntent(query)
 	if err != nil {
-		ca.metrics.ErrorCount++
+		ca.metrics.failed()
 		return nil, fmt.Errorf("failed to parse code intent: %w", err)
 	}
 
@@ -170,7 +178,7 @@ func (ca *CodingAgentImpl) Process(ctx context.Context, query *models.Query) (*m
 	// Gather comprehensive code context
 	codeContext, err := ca.gatherCodeContext(ctx, intent, query)
 	if err != nil {
-		ca.metrics.ErrorCount++
+		ca.metrics.failed()
 		return nil, fmt.Errorf("failed to gather code context: %w", err)
 	}
 
@@ -182,10 +190,24 @@ func (ca *CodingAgentImpl) Process(ctx context.Context, query *models.Query) (*m
 		"imports":               len(codeContext.ImportSuggestions),
 	})
 
-	// Generate code using LLM with context
-	codeResponse, tokenUsage, err := ca.generateContextualCode(ctx, intent, codeContext, query)
+	// Generate code using LLM with context. Fix requests that resolve to
+	// existing code return line-anchored edits instead of regenerated code.
+	var codeResponse *models.CodeResponse
+	var tokenUsage *models.TokenUsage
+	if intent.Type == CodeIntentFix {
+		if target, targetErr := ca.resolveEditTarget(query); targetErr == nil {
+			codeResponse, tokenUsage, err = ca.generateFixEdits(ctx, intent, target, query)
+		} else {
+			ca.logStep("No edit target found, generating code instead", map[string]interface{}{
+				"error": targetErr.Error(),
+			})
+		}
+	}
+	if codeResponse == nil && err == nil {
+		codeResponse, tokenUsage, err = ca.generateContextualCode(ctx, intent, codeContext, query)
+	}
 	if err != nil {
-		ca.metrics.ErrorCount++
+		ca.metrics.failed()
 		return nil, fmt.Errorf("failed to generate code: %w", err)
 	}
 
@@ -210,6 +232,30 @@ func (ca *CodingAgentImpl) Process(ctx context.Context, query *models.Query) (*m
 		}
 	}
 
+	// Apply project guardrails (license headers, forbidden imports, banned APIs)
+	if ca.guardrails != nil {
+		if err := ca.guardrails.Apply(codeResponse); err != nil {
+			ca.metrics.failed()
+			ca.logStep("Generated code rejected by guardrails", map[string]interface{}{
+				"error": err.Error(),
+			})
+			return nil, err
+		}
+		if codeResponse.Validation != nil {
+			ca.logStep("Guardrail policies applied", map[string]interface{}{
+				"mode":     ca.guardrails.Mode(),
+				"issues":   len(codeResponse.Validation.Issues),
+				"warnings": len(codeResponse.Validation.Warnings),
+			})
+		}
+	}
+
+	// Propose go.mod additions for modules the code imports
+	ca.proposeDependencies(codeResponse, codeContext)
+
+	// Suggest where new constructors get wired in
+	ca.proposeWiring(codeResponse, intent, query)
+
 	// Calculate final confidence
 	confidence := ca.calculateCodeConfidence(codeContext, codeResponse)
 
@@ -291,10 +337,7 @@ func (ca *CodingAgentImpl) createFallbackResponse(query *models.Query, reason st
 
 // GetMetrics returns performance metrics for this agent
 func (ca *CodingAgentImpl) GetMetrics() AgentMetrics {
-	if ca.metrics == nil {
-		return AgentMetrics{}
-	}
-	return *ca.metrics
+	return ca.metrics.snapshot()
 }
 
 // AnalyzeCode analyzes code structure and patterns
@@ -390,6 +433,17 @@ func (ca *CodingAgentImpl) gatherCodeContext(ctx context.Context, intent *Coding
 	// Skip similar code examples for now
 	// TODO: Implement similar code search using dependencies.SearchService
 
+	// Prewarmed summaries of targeted files stand in for retrieval
+	ca.addPrewarmedFiles(context, query)
+
+	// New imports should come from modules go.mod already requires
+	ca.addGoModules(context, query)
+
+	// Tests must follow the project's fixture and golden-file conventions
+	if intent.Type == CodeIntentTest {
+		ca.addTestConventions(context, query)
+	}
+
 	// Find relevant types and functions
 	if types, err := ca.findRelevantTypes(ctx, intent, query); err == nil {
 		context.RelevantTypes = types
@@ -408,7 +462,7 @@ func (ca *CodingAgentImpl) gatherCodeContext(ctx context.Context, intent *Coding
 
 	// Analyze project patterns
 	if ca.config.UseProjectPatterns {
-		if patterns, err := ca.analyzeProjectPatterns(ctx, intent); err == nil {
+		if patterns, err := ca.analyzeProjectPatterns(ctx, intent, projectInfo.Language); err == nil {
 			context.Patterns = patterns
 		} else {
 			ca.logStep("Warning: failed to analyze patterns", map[string]interface{}{
@@ -508,11 +562,15 @@ func (ca *CodingAgentImpl) generateContextualCode(ctx context.Context, intent *C
 func (ca *CodingAgentImpl) calculateHandlingConfidence(intent *CodingAgentIntent, query *models.Query) float64 {
 	factors := map[string]float64{}
 
-	// Language support
-	if query != nil && query.Language == "go" {
-		factors["language_support"] = 1.0
-	} else {
+	// Language support: dedicated prompting for some languages, a generic
+	// template for the rest
+	switch {
+	case query == nil || query.Language == "":
 		factors["language_support"] = 0.0
+	case containsString(language.SupportedLanguages(), query.Language):
+		factors["language_support"] = 1.0
+	default:
+		factors["language_support"] = 0.5
 	}
 
 	// Intent type support
@@ -547,7 +605,7 @@ func (ca *CodingAgentImpl) isCodeGenerationQuery(intent *CodingAgentIntent) bool
 		CodeIntentFunction, CodeIntentMethod, CodeIntentStruct,
 		CodeIntentInterface, CodeIntentHandler, CodeIntentService,
 		CodeIntentRepository, CodeIntentMiddleware, CodeIntentTest,
-		CodeIntentScript, CodeIntentConfig,
+		CodeIntentScript, CodeIntentConfig, CodeIntentFix,
 	}
 
 	for _, genType := range generationTypes {
@@ -560,6 +618,12 @@ func (ca *CodingAgentImpl) isCodeGenerationQuery(intent *CodingAgentIntent) bool
 }
 
 func (ca *CodingAgentImpl) determineCodeIntentType(input string) CodingAgentIntentType {
+	// Edits to existing code are checked first so "fix this function" is not
+	// treated as a request for a new function
+	if isFixQuery(input) {
+		return CodeIntentFix
+	}
+
 	// Enhanced intent detection with more patterns
 	patterns := map[CodingAgentIntentType][]string{
 		CodeIntentHandler:    {"handler", "endpoint", "route", "controller", "api"},
@@ -594,8 +658,16 @@ func (ca *CodingAgentImpl) findSimilarCodeExamples(ctx context.Context, intent *
 
 func (ca *CodingAgentImpl) analyzeProjectInfo(ctx context.Context, language string) (*ProjectInfo, error) {
 	// Minimal but useful project info; real implementation should inspect project files
+	if language != "" && language != "go" {
+		// Conventions of other languages come from their prompt templates
+		return &ProjectInfo{
+			Language:     language,
+			Architecture: ArchitectureLayered,
+			Dependencies: []string{},
+		}, nil
+	}
 	return &ProjectInfo{
-		Language:     language,
+		Language:     "go",
 		PackageName:  "main",
 		Architecture: ArchitectureLayered,
 		CodingStyle: CodingStyle{
@@ -634,7 +706,10 @@ func (ca *CodingAgentImpl) findRelevantFunctions(ctx context.Context, intent *Co
 	return []FunctionDef{}, nil
 }
 
-func (ca *CodingAgentImpl) analyzeProjectPatterns(ctx context.Context, intent *CodingAgentIntent) ([]ProjectPattern, error) {
+func (ca *CodingAgentImpl) analyzeProjectPatterns(ctx context.Context, intent *CodingAgentIntent, lang string) ([]ProjectPattern, error) {
+	if lang != "go" {
+		return []ProjectPattern{}, nil
+	}
 	return []ProjectPattern{
 		{
 			Name:       "error handling",
@@ -648,6 +723,9 @@ func (ca *CodingAgentImpl) analyzeProjectPatterns(ctx context.Context, intent *C
 }
 
 func (ca *CodingAgentImpl) generateImportSuggestions(ctx context.Context, intent *CodingAgentIntent, context *CodeContext) ([]ImportSuggestion, error) {
+	if context.ProjectInfo != nil && context.ProjectInfo.Language != "go" {
+		return []ImportSuggestion{}, nil
+	}
 	suggestions := []ImportSuggestion{
 		{
 			Import:     "fmt",
@@ -668,7 +746,13 @@ func (ca *CodingAgentImpl) findUsageExamples(ctx context.Context, intent *Coding
 func (ca *CodingAgentImpl) buildSystemPrompt(context *CodeContext) string {
 	var prompt strings.Builder
 
-	prompt.WriteString("You are an expert Go developer working on a specific codebase. ")
+	lang := ""
+	if context != nil && context.ProjectInfo != nil {
+		lang = context.ProjectInfo.Language
+	}
+	tmpl := language.TemplateFor(lang)
+
+	prompt.WriteString(tmpl.Role + " ")
 	prompt.WriteString("Generate code that follows the existing project patterns and conventions.\n\n")
 
 	if context != nil && context.ProjectInfo != nil {
@@ -678,29 +762,36 @@ func (ca *CodingAgentImpl) buildSystemPrompt(context *CodeContext) string {
 		}
 		prompt.WriteString(fmt.Sprintf("Architecture: %s\n", string(context.ProjectInfo.Architecture)))
 
-		// Enhanced coding style information
+		// Enhanced coding style information, where the project's is known
 		style := context.ProjectInfo.CodingStyle
-		prompt.WriteString("\nCoding Style Guidelines:\n")
-		prompt.WriteString(fmt.Sprintf("- Functions: %s\n", style.NamingConvention.Functions))
-		prompt.WriteString(fmt.Sprintf("- Types: %s\n", style.NamingConvention.Types))
-		prompt.WriteString(fmt.Sprintf("- Error Handling: %s\n", style.ErrorHandlingStyle))
-		if style.LoggingPattern != "" {
-			prompt.WriteString(fmt.Sprintf("- Logging: %s\n", style.LoggingPattern))
+		if style.NamingConvention.Functions != "" {
+			prompt.WriteString("\nCoding Style Guidelines:\n")
+			prompt.WriteString(fmt.Sprintf("- Functions: %s\n", style.NamingConvention.Functions))
+			prompt.WriteString(fmt.Sprintf("- Types: %s\n", style.NamingConvention.Types))
+			prompt.WriteString(fmt.Sprintf("- Error Handling: %s\n", style.ErrorHandlingStyle))
+			if style.LoggingPattern != "" {
+				prompt.WriteString(fmt.Sprintf("- Logging: %s\n", style.LoggingPattern))
+			}
 		}
 	}
 
-	prompt.WriteString("\nIMPORTANT: Generate clean, idiomatic Go code that matches the existing codebase style.\n")
+	prompt.WriteString(fmt.Sprintf("\n%s Guidelines:\n", tmpl.DisplayName))
+	for _, guideline := range tmpl.Guidelines {
+		prompt.WriteString(fmt.Sprintf("- %s\n", guideline))
+	}
+
+	prompt.WriteString(fmt.Sprintf("\nIMPORTANT: Generate clean, idiomatic %s code that matches the existing codebase style.\n", tmpl.DisplayName))
 	return prompt.String()
 }
 
 func (ca *CodingAgentImpl) buildCodeGenerationPrompt(intent *CodingAgentIntent, context *CodeContext, query *models.Query) string {
 	var prompt strings.Builder
 
-	lang := "Go"
-	if query != nil && query.Language != "" {
-		lang = query.Language
+	tmpl := language.TemplateFor("")
+	if query != nil {
+		tmpl = language.TemplateFor(query.Language)
 	}
-	prompt.WriteString(fmt.Sprintf("Generate %s code for: %s\n\n", lang, intent.Description))
+	prompt.WriteString(fmt.Sprintf("Generate %s code for: %s\n\n", tmpl.DisplayName, intent.Description))
 
 	// Include intent details
 	if intent.FunctionName != "" {
@@ -715,9 +806,22 @@ func (ca *CodingAgentImpl) buildCodeGenerationPrompt(intent *CodingAgentIntent,
 				break
 			}
 			prompt.WriteString(fmt.Sprintf("\nExample from %s:\n", example.File))
-			prompt.WriteString("```go\n")
-			prompt.WriteString(example.Code)
-			prompt.WriteString("\n```\n")
+			prompt.WriteString(promptguard.Wrap(example.File, "```"+tmpl.FenceTag+"\n"+example.Code+"\n```"))
+		}
+	}
+
+	// Include summaries of the files in focus
+	if context != nil && len(context.FileStructure) > 0 {
+		prompt.WriteString("\nFiles in focus:\n")
+		for _, path := range sortedFileKeys(context.FileStructure) {
+			file := context.FileStructure[path]
+			prompt.WriteString(fmt.Sprintf("- %s (%s, %d lines)\n", file.Path, file.Language, file.LineCount))
+			if len(file.Types) > 0 {
+				prompt.WriteString(fmt.Sprintf("  types: %s\n", strings.Join(file.Types, ", ")))
+			}
+			if len(file.Functions) > 0 {
+				prompt.WriteString(fmt.Sprintf("  functions: %s\n", strings.Join(file.Functions, ", ")))
+			}
 		}
 	}
 
@@ -729,6 +833,21 @@ func (ca *CodingAgentImpl) buildCodeGenerationPrompt(intent *CodingAgentIntent,
 		}
 	}
 
+	// Include the project's test conventions
+	if context != nil && context.TestConventions != nil {
+		prompt.WriteString(context.TestConventions.PromptSection(testTarget(intent, query)))
+	}
+
+	// Include the modules go.mod already provides
+	if context != nil && context.GoModules != nil {
+		prompt.WriteString(context.GoModules.PromptSection())
+	}
+
+	// Polyglot answers write each file in its own language
+	if query != nil {
+		prompt.WriteString(languageInstructions(query))
+	}
+
 	prompt.WriteString("\nGenerate production-ready code with proper error handling and documentation.")
 	return prompt.String()
 }
@@ -825,33 +944,15 @@ func (ca *CodingAgentImpl) logStep(message string, fields map[string]interface{}
 }
 
 func (ca *CodingAgentImpl) updateMetrics(startTime time.Time) {
-	if ca.metrics == nil {
-		ca.metrics = &AgentMetrics{}
-	}
-	ca.metrics.QueriesHandled++
-	ca.metrics.LastUsed = startTime
+	ca.metrics.started(startTime)
 }
 
 func (ca *CodingAgentImpl) updateSuccessMetrics(startTime time.Time, confidence float64, tokenUsage *models.TokenUsage) {
-	if ca.metrics == nil {
-		ca.metrics = &AgentMetrics{}
-	}
-
-	duration := time.Since(startTime)
-	total := float64(ca.metrics.QueriesHandled)
-	if total <= 0 {
-		total = 1
-	}
-	ca.metrics.AverageResponseTime = time.Duration(
-		(float64(ca.metrics.AverageResponseTime)*(total-1) + float64(duration)) / total,
-	)
-	ca.metrics.AverageConfidence = (ca.metrics.AverageConfidence*(total-1) + confidence) / total
+	tokens := 0
 	if tokenUsage != nil {
-		ca.metrics.TokensUsed += int64(tokenUsage.TotalTokens)
+		tokens = tokenUsage.TotalTokens
 	}
-
-	successCount := float64(ca.metrics.QueriesHandled - ca.metrics.ErrorCount)
-	ca.metrics.SuccessRate = successCount / total
+	ca.metrics.succeeded(time.Since(startTime), confidence, tokens, 0)
 }
 
 func (ca *CodingAgentImpl) countContextFiles(context *CodeContext) int {
@@ -1031,3 +1132,84 @@ func (ca *CodingAgentImpl) extractArchPatterns(structure map[string]interface{})
 	
 	return patterns
 }
+
+// addPrewarmedFiles adds warm summaries of the files a query targets to the
+// context, so hot files need no retrieval round-trip
+func (ca *CodingAgentImpl) addPrewarmedFiles(context *CodeContext, query *models.Query) {
+	if ca.dependencies == nil || ca.dependencies.Prewarm == nil {
+		return
+	}
+
+	paths := append([]string{query.Context.CurrentFile}, query.Intent.FileTargets...)
+	for _, path := range paths {
+		summary, ok := ca.dependencies.Prewarm.Summary(path)
+		if !ok {
+			continue
+		}
+		context.FileStructure[path] = FileInfo{
+			Path:         summary.Path,
+			Name:         filepath.Base(summary.Path),
+			Extension:    filepath.Ext(summary.Path),
+			Size:         summary.Size,
+			Language:     summary.Language,
+			LineCount:    summary.LineCount,
+			Functions:    summary.Functions,
+			Types:        summary.Types,
+			Imports:      summary.Imports,
+			Package:      summary.Package,
+			LastModified: summary.LastModified,
+			Metadata:     map[string]string{"source": "prewarm"},
+		}
+	}
+
+	if len(context.FileStructure) > 0 {
+		ca.logStep("Using prewarmed file summaries", map[string]interface{}{
+			"files": len(context.FileStructure),
+		})
+	}
+}
+
+// sortedFileKeys returns the paths in a file structure map in sorted order
+func sortedFileKeys(files map[string]FileInfo) []string {
+	keys := make([]string, 0, len(files))
+	for key := range files {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// addTestConventions looks up how the project's existing tests use fixtures,
+// golden files and helpers before a test is generated
+func (ca *CodingAgentImpl) addTestConventions(context *CodeContext, query *models.Query) {
+	if ca.dependencies == nil || ca.dependencies.Storage == nil {
+		return
+	}
+	conventions, err := testconv.Discover(ca.dependencies.Storage, query.ProjectRoot)
+	if err != nil {
+		ca.logStep("Warning: failed to discover test conventions", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	context.TestConventions = conventions
+	ca.logStep("Discovered test conventions", map[string]interface{}{
+		"test_files":    conventions.TestFiles,
+		"testdata_dirs": len(conventions.TestDataDirs),
+		"helpers":       len(conventions.Helpers),
+	})
+}
+
+// testTarget returns the source file a generated test is for, if known
+func testTarget(intent *CodingAgentIntent, query *models.Query) string {
+	if intent.TargetFile != "" {
+		return intent.TargetFile
+	}
+	if query == nil {
+		return ""
+	}
+	if len(query.Intent.FileTargets) > 0 {
+		return query.Intent.FileTargets[0]
+	}
+	return query.Context.CurrentFile
+}
@@ -0,0 +1,167 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/internal/edits"
+	"github.com/yourusername/useq-ai-assistant/internal/language"
+	"github.com/yourusername/useq-ai-assistant/internal/llm"
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// fixQueryRegex matches requests to change existing code in place
+var fixQueryRegex = regexp.MustCompile(`\b(fix|repair|correct|patch|refactor|rewrite|clean up|simplify)\b.*\b(this|the|function|func|method|bug|code)\b`)
+
+// funcNameRegex finds identifiers that look like a function reference ("ParseConfig", "parseConfig()")
+var funcNameRegex = regexp.MustCompile(`\b([A-Za-z_]\w*)\(\)|\b(?:function|func|method)\s+([A-Za-z_]\w*)`)
+
+// errNoEditTarget is returned when a fix request cannot be tied to existing code
+var errNoEditTarget = errors.New("no edit target found")
+
+// editTarget is the existing code a fix request applies to
+type editTarget struct {
+	symbol  *edits.Symbol
+	content string // full file content the symbol was located in
+}
+
+// isFixQuery reports whether the input asks to modify existing code
+func isFixQuery(input string) bool {
+	return fixQueryRegex.MatchString(input)
+}
+
+// resolveEditTarget locates the function a fix request refers to, using the
+// editor selection, explicit file/function targets and the function index
+func (ca *CodingAgentImpl) resolveEditTarget(query *models.Query) (*editTarget, error) {
+	names := append([]string{}, query.Intent.FuncTargets...)
+	files := append([]string{}, query.Intent.FileTargets...)
+	for _, m := range funcNameRegex.FindAllStringSubmatch(query.UserInput, -1) {
+		for _, name := range m[1:] {
+			if name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	if query.Context.CurrentFile != "" {
+		files = append(files, query.Context.CurrentFile)
+	}
+
+	// An editor selection is the most precise target: use it verbatim
+	if query.Context.Selection != nil && query.Context.CurrentFile != "" {
+		sel := query.Context.Selection
+		content, err := os.ReadFile(query.Context.CurrentFile)
+		if err == nil && sel.StartLine > 0 && sel.EndLine >= sel.StartLine {
+			lines := strings.Split(string(content), "\n")
+			if sel.EndLine <= len(lines) {
+				return &editTarget{
+					symbol: &edits.Symbol{
+						Name:      "selection",
+						File:      query.Context.CurrentFile,
+						StartLine: sel.StartLine,
+						EndLine:   sel.EndLine,
+						Source:    strings.Join(lines[sel.StartLine-1:sel.EndLine], "\n"),
+					},
+					content: string(content),
+				}, nil
+			}
+		}
+	}
+
+	for _, name := range names {
+		candidates := files
+		if ca.dependencies.Storage != nil {
+			if path, err := ca.dependencies.Storage.GetFunctionFilePath(name); err == nil && path != "" {
+				candidates = append([]string{path}, candidates...)
+			}
+		}
+		for _, path := range candidates {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				continue
+			}
+			symbol, err := edits.LocateSymbol(path, string(content), name)
+			if err != nil {
+				continue
+			}
+			return &editTarget{symbol: symbol, content: string(content)}, nil
+		}
+	}
+
+	return nil, errNoEditTarget
+}
+
+// generateFixEdits asks the LLM for a corrected version of the target code
+// and returns it as minimal line-anchored changes instead of a whole file
+func (ca *CodingAgentImpl) generateFixEdits(ctx context.Context, intent *CodingAgentIntent,
+	target *editTarget, query *models.Query) (*models.CodeResponse, *models.TokenUsage, error) {
+
+	lang := language.FromExtension(target.symbol.File)
+	if lang == "" {
+		lang = query.Language
+	}
+	tmpl := language.TemplateFor(lang)
+
+	var prompt strings.Builder
+	prompt.WriteString(fmt.Sprintf("Request: %s\n\n", query.UserInput))
+	prompt.WriteString(fmt.Sprintf("Code from %s (lines %d-%d):\n", target.symbol.File,
+		target.symbol.StartLine, target.symbol.EndLine))
+	prompt.WriteString(fmt.Sprintf("```%s\n%s\n```\n\n", tmpl.FenceTag, target.symbol.Source))
+	prompt.WriteString("Return the complete corrected version of exactly this code in a single fenced code block, ")
+	prompt.WriteString("followed by a one-paragraph explanation. Keep unchanged lines byte-for-byte identical ")
+	prompt.WriteString("and do not add code outside this range.")
+
+	request := &llm.GenerationRequest{
+		Messages: []llm.Message{
+			{Role: "system", Content: tmpl.Role + " You make minimal, targeted fixes to existing code."},
+			{Role: "user", Content: prompt.String()},
+		},
+		MaxTokens:   ca.config.MaxTokens,
+		Temperature: ca.config.Temperature,
+		MCPContext:  query.MCPContext,
+	}
+
+	llmResponse, err := ca.dependencies.LLMManager.Generate(ctx, request)
+	if err != nil {
+		return nil, nil, fmt.Errorf("LLM generation failed: %w", err)
+	}
+
+	replacement := edits.ExtractCode(llmResponse.Content)
+	changes := edits.Diff(target.symbol, replacement, edits.HashContent(target.content))
+
+	explanation := strings.TrimSpace(llmResponse.Content)
+	if end := strings.LastIndex(explanation, "```"); end >= 0 {
+		explanation = strings.TrimSpace(explanation[end+3:])
+	}
+	if explanation == "" {
+		explanation = fmt.Sprintf("Updated %s in %s", target.symbol.Name, target.symbol.File)
+	}
+	for i := range changes {
+		changes[i].Explanation = explanation
+	}
+
+	ca.logStep("Computed line-anchored edits", map[string]interface{}{
+		"file":    target.symbol.File,
+		"anchor":  target.symbol.Name,
+		"changes": len(changes),
+	})
+
+	return &models.CodeResponse{
+		Language:    lang,
+		Code:        replacement,
+		Explanation: explanation,
+		Changes:     changes,
+		Tests:       []models.TestCase{},
+		Provider:    llmResponse.Provider,
+		Intent:      intent,
+	}, &models.TokenUsage{
+		InputTokens:  llmResponse.TokenUsage.InputTokens,
+		OutputTokens: llmResponse.TokenUsage.OutputTokens,
+		TotalTokens:  llmResponse.TokenUsage.TotalTokens,
+		Provider:     llmResponse.Provider,
+		Model:        llmResponse.Model,
+	}, nil
+}
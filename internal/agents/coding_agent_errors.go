@@ -0,0 +1,191 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/audit"
+	"github.com/yourusername/useq-ai-assistant/internal/edits"
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// maxNormalizedFuncs bounds the functions rewritten by one normalization
+const maxNormalizedFuncs = 15
+
+// NormalizeErrorHandling rewrites the functions of a package that wrap or
+// create errors differently from the rest of it to use the package's
+// dominant styles, one function at a time, and returns the line-anchored
+// edits. Custom error types are left alone since callers may match them
+// with errors.As, and nothing is rewritten to wrap with %v, which would cut
+// the error chains errors.Is and errors.As follow. Imports are not updated.
+func (ca *CodingAgentImpl) NormalizeErrorHandling(ctx context.Context, inv *audit.ErrorInventory, projectRoot string) (*models.Response, error) {
+	startTime := time.Now()
+	if ca.dependencies == nil || ca.dependencies.LLMManager == nil {
+		return nil, fmt.Errorf("normalizing error handling requires an LLM")
+	}
+
+	// Group the stray sites by function; sites outside functions cannot be
+	// rewritten on their own
+	type funcKey struct{ file, name string }
+	byFunc := make(map[funcKey][]audit.ErrorSite)
+	var order []funcKey
+	keptChains := 0
+	for _, site := range inv.Inconsistent() {
+		if inv.Dominant(site.Style) == audit.ErrorStyleWrapFormat {
+			keptChains++
+			continue
+		}
+		if site.Style == audit.ErrorStyleCustom || site.Func == "" {
+			continue
+		}
+		key := funcKey{site.File, site.Func}
+		if _, ok := byFunc[key]; !ok {
+			order = append(order, key)
+		}
+		byFunc[key] = append(byFunc[key], site)
+	}
+	if len(order) == 0 && keptChains > 0 {
+		return nil, fmt.Errorf("%s mostly wraps errors with %%v; its other %d wrapping sites keep their error chains and are left alone",
+			inv.Dir, keptChains)
+	}
+	if len(order) == 0 {
+		return nil, fmt.Errorf("%s already handles errors consistently", inv.Dir)
+	}
+	skipped := 0
+	if len(order) > maxNormalizedFuncs {
+		skipped = len(order) - maxNormalizedFuncs
+		order = order[:maxNormalizedFuncs]
+	}
+
+	response := &models.CodeResponse{Language: "go"}
+	usage := &models.TokenUsage{}
+	var sources, explanations []string
+	for _, key := range order {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		path := key.file
+		if !filepath.IsAbs(path) && projectRoot != "" {
+			path = filepath.Join(projectRoot, path)
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", key.file, err)
+		}
+		sites := byFunc[key]
+		name := key.name[strings.LastIndex(key.name, ".")+1:]
+		symbol, err := edits.LocateSymbol(path, string(content), name)
+		if err != nil || sites[0].Line < symbol.StartLine || sites[0].Line > symbol.EndLine {
+			// Another declaration with the same name was found first
+			continue
+		}
+
+		query := &models.Query{
+			ID:          fmt.Sprintf("errors_%s_%d", name, time.Now().UnixNano()),
+			UserInput:   errorStyleRequest(inv, key.name, sites),
+			ProjectRoot: projectRoot,
+			Timestamp:   time.Now(),
+		}
+		intent := &CodingAgentIntent{
+			Type:         CodeIntentFix,
+			Description:  "normalize error handling",
+			FunctionName: key.name,
+			TargetFile:   key.file,
+		}
+		ca.logStep("Normalizing error handling", map[string]interface{}{
+			"file":  key.file,
+			"func":  key.name,
+			"sites": len(sites),
+		})
+		code, tokens, err := ca.generateFixEdits(ctx, intent, &editTarget{symbol: symbol, content: string(content)}, query)
+		if err != nil {
+			ca.metrics.failed()
+			return nil, fmt.Errorf("failed to normalize %s: %w", key.name, err)
+		}
+		if ca.guardrails != nil {
+			if err := ca.guardrails.Apply(code); err != nil {
+				ca.metrics.failed()
+				return nil, err
+			}
+		}
+		response.Changes = append(response.Changes, code.Changes...)
+		response.Provider = code.Provider
+		usage.InputTokens += tokens.InputTokens
+		usage.OutputTokens += tokens.OutputTokens
+		usage.TotalTokens += tokens.TotalTokens
+		usage.Provider, usage.Model = tokens.Provider, tokens.Model
+		sources = append(sources, fmt.Sprintf("%s:%d-%d", key.file, symbol.StartLine, symbol.EndLine))
+		explanations = append(explanations, fmt.Sprintf("- %s: %s", key.name, code.Explanation))
+	}
+	if len(response.Changes) == 0 {
+		return nil, fmt.Errorf("no function in %s could be rewritten", inv.Dir)
+	}
+	sort.SliceStable(response.Changes, func(i, j int) bool { return response.Changes[i].File < response.Changes[j].File })
+
+	text := fmt.Sprintf("Normalized error handling in %d functions of %s to %s.\n%s",
+		len(sources), inv.Dir, dominantStyles(inv), strings.Join(explanations, "\n"))
+	if skipped > 0 {
+		text += fmt.Sprintf("\n%d more functions were left for another run.", skipped)
+	}
+	text += "\nImports are not updated; run goimports on the changed files."
+	response.Explanation = text
+
+	return &models.Response{
+		ID:      fmt.Sprintf("error_normalization_%d", time.Now().UnixNano()),
+		QueryID: fmt.Sprintf("errors_%s", inv.Dir),
+		Type:    models.ResponseTypeCode,
+		Content: models.ResponseContent{
+			Text: text,
+			Code: response,
+		},
+		Metadata: models.ResponseMetadata{
+			GenerationTime: time.Since(startTime),
+			FilesAnalyzed:  len(sources),
+			Confidence:     0.8,
+			Sources:        sources,
+			Tools:          []string{"error_inventory", "error_normalization"},
+			Reasoning:      fmt.Sprintf("Rewrote the functions of %s that stray from its dominant error styles", inv.Dir),
+		},
+		TokenUsage: *usage,
+		Timestamp:  time.Now(),
+		AgentUsed:  "coding",
+		Provider:   response.Provider,
+	}, nil
+}
+
+// errorStyleRequest asks for a function's stray error sites to be rewritten
+// in the package's style, with an example of that style from the package
+func errorStyleRequest(inv *audit.ErrorInventory, name string, sites []audit.ErrorSite) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Rewrite the error handling of %s to match the rest of package %s, ", name, inv.Dir)
+	fmt.Fprintf(&b, "which uses %s. Change only these lines; keep the messages and behavior:\n", dominantStyles(inv))
+	examples := make(map[string]bool)
+	for _, site := range sites {
+		fmt.Fprintf(&b, "- line %d (%s): %s\n", site.Line, site.Style, site.Code)
+		examples[inv.Dominant(site.Style)] = true
+	}
+	for _, site := range inv.Sites {
+		if examples[site.Style] {
+			fmt.Fprintf(&b, "Example of %s in this package: %s\n", site.Style, site.Code)
+			delete(examples, site.Style)
+		}
+	}
+	return b.String()
+}
+
+// dominantStyles describes the package's wrapping and creation styles
+func dominantStyles(inv *audit.ErrorInventory) string {
+	var styles []string
+	if inv.WrapStyle != "" {
+		styles = append(styles, inv.WrapStyle+" to wrap errors")
+	}
+	if inv.CreateStyle != "" {
+		styles = append(styles, inv.CreateStyle+" to create them")
+	}
+	return strings.Join(styles, " and ")
+}
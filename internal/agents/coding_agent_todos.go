@@ -0,0 +1,192 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/edits"
+	"github.com/yourusername/useq-ai-assistant/models"
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// todoWindow is the lines on each side of a TODO rewritten when it is not
+// inside a function
+const todoWindow = 10
+
+// maxTodoReferences bounds the indexed snippets shown as reference code
+const maxTodoReferences = 3
+
+// ResolveTodo generates the implementation a TODO, FIXME or HACK comment
+// asks for. The enclosing function is rewritten with the work done and the
+// comment removed, using indexed code similar to the comment as reference,
+// and returned as line-anchored edits like a fix request.
+func (ca *CodingAgentImpl) ResolveTodo(ctx context.Context, todo *storage.TodoRecord, projectRoot string) (*models.Response, error) {
+	startTime := time.Now()
+	if ca.dependencies == nil || ca.dependencies.LLMManager == nil {
+		return nil, fmt.Errorf("resolving a todo requires an LLM")
+	}
+
+	path := todo.File
+	if !filepath.IsAbs(path) && projectRoot != "" {
+		path = filepath.Join(projectRoot, path)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", todo.File, err)
+	}
+	target, err := todoTarget(todo, path, string(content))
+	if err != nil {
+		return nil, err
+	}
+
+	var request strings.Builder
+	fmt.Fprintf(&request, "Resolve the %s comment at line %d of %s: %q. ", todo.Kind, todo.Line, todo.File, todo.Text)
+	request.WriteString("Implement what it asks for, following the conventions of the surrounding code, and remove the comment. ")
+	request.WriteString("If it cannot be done within this code, keep the comment and explain what is missing.")
+	if references := ca.todoReferences(ctx, todo, target.symbol); references != "" {
+		request.WriteString("\n\nSimilar code elsewhere in the project, for reference:\n")
+		request.WriteString(references)
+	}
+
+	query := &models.Query{
+		ID:          fmt.Sprintf("todo_%d_%d", todo.ID, time.Now().UnixNano()),
+		UserInput:   request.String(),
+		ProjectRoot: projectRoot,
+		Timestamp:   time.Now(),
+	}
+	intent := &CodingAgentIntent{
+		Type:         CodeIntentFix,
+		Description:  todo.Text,
+		FunctionName: target.symbol.Name,
+		TargetFile:   todo.File,
+	}
+
+	ca.logStep("Resolving todo", map[string]interface{}{
+		"file":   todo.File,
+		"line":   todo.Line,
+		"anchor": target.symbol.Name,
+	})
+	codeResponse, tokenUsage, err := ca.generateFixEdits(ctx, intent, target, query)
+	if err != nil {
+		ca.metrics.failed()
+		return nil, fmt.Errorf("failed to resolve todo: %w", err)
+	}
+	if ca.guardrails != nil {
+		if err := ca.guardrails.Apply(codeResponse); err != nil {
+			ca.metrics.failed()
+			return nil, err
+		}
+	}
+
+	return &models.Response{
+		ID:      fmt.Sprintf("todo_resolution_%d", time.Now().UnixNano()),
+		QueryID: query.ID,
+		Type:    models.ResponseTypeCode,
+		Content: models.ResponseContent{
+			Text: codeResponse.Explanation,
+			Code: codeResponse,
+		},
+		Metadata: models.ResponseMetadata{
+			GenerationTime: time.Since(startTime),
+			FilesAnalyzed:  1,
+			Confidence:     0.8,
+			Sources:        []string{fmt.Sprintf("%s:%d-%d", todo.File, target.symbol.StartLine, target.symbol.EndLine)},
+			Tools:          []string{"todo_resolution"},
+			Reasoning:      fmt.Sprintf("Rewrote %s to resolve the %s at line %d", target.symbol.Name, todo.Kind, todo.Line),
+		},
+		TokenUsage: *tokenUsage,
+		Timestamp:  time.Now(),
+		AgentUsed:  "coding",
+		Provider:   codeResponse.Provider,
+	}, nil
+}
+
+// todoTarget is the code a TODO is resolved in: its enclosing Go function,
+// or the lines around it
+func todoTarget(todo *storage.TodoRecord, path, content string) (*editTarget, error) {
+	lines := strings.Split(content, "\n")
+	if todo.Line < 1 || todo.Line > len(lines) {
+		return nil, fmt.Errorf("%s has no line %d; reindex to refresh todos", todo.File, todo.Line)
+	}
+	if !strings.Contains(lines[todo.Line-1], todo.Kind) {
+		return nil, fmt.Errorf("line %d of %s no longer holds the %s; reindex to refresh todos", todo.Line, todo.File, todo.Kind)
+	}
+
+	if filepath.Ext(path) == ".go" {
+		if name := enclosingFunc(content, todo.Line); name != "" {
+			if symbol, err := edits.LocateSymbol(path, content, name); err == nil {
+				return &editTarget{symbol: symbol, content: content}, nil
+			}
+		}
+	}
+	start, end := max(1, todo.Line-todoWindow), min(len(lines), todo.Line+todoWindow)
+	return &editTarget{
+		symbol: &edits.Symbol{
+			Name:      "todo",
+			File:      path,
+			StartLine: start,
+			EndLine:   end,
+			Source:    strings.Join(lines[start-1:end], "\n"),
+		},
+		content: content,
+	}, nil
+}
+
+// enclosingFunc names the Go function or method whose body or doc comment
+// contains line, or returns ""
+func enclosingFunc(content string, line int) string {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", content, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return ""
+	}
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		start := fset.Position(fn.Pos()).Line
+		if fn.Doc != nil {
+			start = fset.Position(fn.Doc.Pos()).Line
+		}
+		if line >= start && line <= fset.Position(fn.End()).Line {
+			return fn.Name.Name
+		}
+	}
+	return ""
+}
+
+// todoReferences searches the index for code similar to what the TODO asks
+// for, skipping the code being rewritten
+func (ca *CodingAgentImpl) todoReferences(ctx context.Context, todo *storage.TodoRecord, symbol *edits.Symbol) string {
+	if ca.dependencies.VectorDB == nil || todo.Text == "" {
+		return ""
+	}
+	results, err := ca.dependencies.VectorDB.Search(ctx, todo.Text, maxTodoReferences*2)
+	if err != nil {
+		return ""
+	}
+
+	var b strings.Builder
+	shown := 0
+	for _, result := range results {
+		if shown == maxTodoReferences {
+			break
+		}
+		chunk := result.Chunk
+		if chunk == nil || (strings.HasSuffix(symbol.File, chunk.FilePath) &&
+			chunk.StartLine <= symbol.EndLine && chunk.EndLine >= symbol.StartLine) {
+			continue
+		}
+		fmt.Fprintf(&b, "%s:%d-%d\n```%s\n%s\n```\n", chunk.FilePath, chunk.StartLine, chunk.EndLine, chunk.Language, chunk.Content)
+		shown++
+	}
+	return b.String()
+}
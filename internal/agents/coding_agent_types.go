@@ -2,6 +2,9 @@ package agents
 
 import (
 	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/deps"
+	"github.com/yourusername/useq-ai-assistant/internal/testconv"
 )
 
 // =============================================================================
@@ -66,6 +69,7 @@ const (
 	CodeIntentController CodingAgentIntentType = "controller"
 	CodeIntentValidator  CodingAgentIntentType = "validator"
 	CodeIntentUtility    CodingAgentIntentType = "utility"
+	CodeIntentFix        CodingAgentIntentType = "fix" // modify existing code in place
 )
 
 // =============================================================================
@@ -74,16 +78,18 @@ const (
 
 // CodeContext holds comprehensive code context for agents
 type CodeContext struct {
-	ProjectInfo       *ProjectInfo        `json:"project_info"`
-	SimilarCode       []CodeExample       `json:"similar_code"`
-	RelevantTypes     []TypeDefinition    `json:"relevant_types"`
-	RelevantFunctions []FunctionDef       `json:"relevant_functions"`
-	Dependencies      []Dependency        `json:"dependencies"`
-	Patterns          []ProjectPattern    `json:"patterns"`
-	ImportSuggestions []ImportSuggestion  `json:"import_suggestions"`
-	UsageExamples     []UsageExample      `json:"usage_examples"` // UsageExample imported from base
-	FileStructure     map[string]FileInfo `json:"file_structure"`
-	ArchitectureInfo  *ArchitectureInfo   `json:"architecture_info"`
+	ProjectInfo       *ProjectInfo          `json:"project_info"`
+	SimilarCode       []CodeExample         `json:"similar_code"`
+	RelevantTypes     []TypeDefinition      `json:"relevant_types"`
+	RelevantFunctions []FunctionDef         `json:"relevant_functions"`
+	Dependencies      []Dependency          `json:"dependencies"`
+	Patterns          []ProjectPattern      `json:"patterns"`
+	ImportSuggestions []ImportSuggestion    `json:"import_suggestions"`
+	UsageExamples     []UsageExample        `json:"usage_examples"` // UsageExample imported from base
+	FileStructure     map[string]FileInfo   `json:"file_structure"`
+	ArchitectureInfo  *ArchitectureInfo     `json:"architecture_info"`
+	TestConventions   *testconv.Conventions `json:"test_conventions,omitempty"` // set for test generation
+	GoModules         *deps.Inventory       `json:"go_modules,omitempty"`       // what go.mod already requires
 }
 
 // ProjectInfo holds comprehensive project information
@@ -0,0 +1,71 @@
+package agents
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/internal/deps"
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// SetDependencyInsightConfig replaces the go.mod dependency insight settings
+func (ca *CodingAgentImpl) SetDependencyInsightConfig(config deps.InsightConfig) {
+	ca.dependencyInsight = config
+}
+
+// addGoModules reads the project's go.mod so generation can prefer modules
+// the project already requires. Projects without a go.mod are skipped.
+func (ca *CodingAgentImpl) addGoModules(context *CodeContext, query *models.Query) {
+	if !ca.dependencyInsight.Enabled || query.ProjectRoot == "" {
+		return
+	}
+	if query.Language != "" && !strings.EqualFold(query.Language, "go") {
+		return
+	}
+	goModPath := filepath.Join(query.ProjectRoot, "go.mod")
+	if _, err := os.Stat(goModPath); err != nil {
+		return
+	}
+	inventory, err := deps.ReadInventory(goModPath)
+	if err != nil {
+		ca.logStep("Warning: failed to read go.mod", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	context.GoModules = inventory
+	ca.logStep("Read go.mod dependencies", map[string]interface{}{
+		"requirements": len(inventory.Requirements),
+	})
+}
+
+// proposeDependencies lists the modules the generated code imports that
+// go.mod does not require, pinned to the version the model named or the
+// newest one in the module cache, with the required modules that already
+// do the same job
+func (ca *CodingAgentImpl) proposeDependencies(codeResponse *models.CodeResponse, context *CodeContext) {
+	if context == nil || context.GoModules == nil {
+		return
+	}
+	code := codeResponse.Code
+	for _, change := range codeResponse.Changes {
+		code += "\n" + change.NewContent
+	}
+
+	for _, proposal := range context.GoModules.Propose(code, code) {
+		codeResponse.Dependencies = append(codeResponse.Dependencies, models.Dependency{
+			Name:         proposal.Path,
+			Version:      proposal.Version,
+			Type:         "module",
+			Required:     true,
+			Category:     proposal.Category,
+			Alternatives: proposal.Alternatives,
+		})
+	}
+	if len(codeResponse.Dependencies) > 0 {
+		ca.logStep("Generated code needs new modules", map[string]interface{}{
+			"modules": len(codeResponse.Dependencies),
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package agents
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/internal/deps"
+	"github.com/yourusername/useq-ai-assistant/internal/wiring"
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// proposeWiring finds the constructors the generated Go code declares that
+// nothing in the project calls yet, and drafts their initialization in the
+// composition root that already builds their dependencies
+func (ca *CodingAgentImpl) proposeWiring(codeResponse *models.CodeResponse, intent *CodingAgentIntent, query *models.Query) {
+	if query.ProjectRoot == "" || (query.Language != "" && !strings.EqualFold(query.Language, "go")) {
+		return
+	}
+	type generated struct{ code, file string }
+	var files []generated
+	if strings.Contains(codeResponse.Code, "func New") {
+		files = append(files, generated{codeResponse.Code, ca.projectFile(query, testTarget(intent, query))})
+	}
+	for _, change := range codeResponse.Changes {
+		if strings.Contains(change.NewContent, "func New") {
+			files = append(files, generated{change.NewContent, ca.projectFile(query, change.File)})
+		}
+	}
+	if len(files) == 0 {
+		return
+	}
+
+	module, err := deps.ModulePath(filepath.Join(query.ProjectRoot, "go.mod"))
+	if err != nil {
+		return
+	}
+	graph, err := wiring.Analyze(query.ProjectRoot, module)
+	if err != nil {
+		ca.logStep("Warning: failed to map constructor wiring", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	for _, file := range files {
+		codeResponse.Wiring = append(codeResponse.Wiring, graph.CheckGenerated(file.code, file.file)...)
+	}
+	if len(codeResponse.Wiring) > 0 {
+		ca.logStep("Generated code needs wiring", map[string]interface{}{
+			"constructors": len(codeResponse.Wiring),
+		})
+	}
+}
+
+// projectFile returns a Go file path relative to the project root, or ""
+// when it is not a Go file in the project
+func (ca *CodingAgentImpl) projectFile(query *models.Query, file string) string {
+	if !strings.HasSuffix(file, ".go") {
+		return ""
+	}
+	if filepath.IsAbs(file) {
+		rel, err := filepath.Rel(query.ProjectRoot, file)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			return ""
+		}
+		file = rel
+	}
+	return filepath.ToSlash(file)
+}
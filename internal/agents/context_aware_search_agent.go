@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/yourusername/useq-ai-assistant/internal/vocabulary"
 	"github.com/yourusername/useq-ai-assistant/models"
 )
 
@@ -13,7 +14,7 @@ import (
 type ContextAwareSearchAgentImpl struct {
 	dependencies *AgentDependencies
 	config       *ContextAwareSearchAgentConfig
-	metrics      *AgentMetrics
+	metrics      *agentMetrics
 }
 
 // NewContextAwareSearchAgentConfig creates a new ContextAwareSearchAgentConfig with sensible defaults.
@@ -40,16 +41,7 @@ func NewContextAwareSearchAgentImpl(deps *AgentDependencies) *ContextAwareSearch
 	return &ContextAwareSearchAgentImpl{
 		dependencies: deps,
 		config:       NewContextAwareSearchAgentConfig(),
-		metrics: &AgentMetrics{
-			QueriesHandled:      0,
-			SuccessRate:         0.0,
-			AverageResponseTime: 0,
-			AverageConfidence:   0.0,
-			TokensUsed:          0,
-			TotalCost:           0.0,
-			LastUsed:            time.Now(),
-			ErrorCount:          0,
-		},
+		metrics:      newAgentMetrics("context_search"),
 	}
 }
 
@@ -78,7 +70,7 @@ func (casa *ContextAwareSearchAgentImpl) Process(ctx context.Context, query *mod
 	// Execute multi-layered search
 	results, err := casa.executeContextualSearch(ctx, strategy, query)
 	if err != nil {
-		casa.metrics.ErrorCount++
+		casa.metrics.failed()
 		return nil, fmt.Errorf("contextual search failed: %w", err)
 	}
 
@@ -209,9 +201,13 @@ func (casa *ContextAwareSearchAgentImpl) extractSmartKeywords(input string) []st
 		"what": true, "where": true, "when": true, "why": true,
 	}
 
+	var vocab *vocabulary.Vocabulary
+	if casa.dependencies != nil {
+		vocab = casa.dependencies.Vocabulary
+	}
 	for _, word := range words {
 		word = strings.ToLower(strings.Trim(word, ".,!?;:"))
-		if len(word) > 2 && !stopWords[word] {
+		if vocab.IsProtected(word) || len(word) > 2 && !vocab.IsStopWord(word, stopWords) {
 			keywords = append(keywords, word)
 		}
 	}
@@ -465,17 +461,11 @@ func min(a, b int) int {
 
 // Metric and utility methods
 func (casa *ContextAwareSearchAgentImpl) updateMetrics(startTime time.Time) {
-	casa.metrics.QueriesHandled++
-	casa.metrics.LastUsed = time.Now()
+	casa.metrics.started(startTime)
 }
 
 func (casa *ContextAwareSearchAgentImpl) updateSuccessMetrics(startTime time.Time, confidence float64, tokenUsage *models.TokenUsage) {
-	duration := time.Since(startTime)
-	casa.metrics.AverageResponseTime = (casa.metrics.AverageResponseTime + duration) / 2
-	casa.metrics.AverageConfidence = (casa.metrics.AverageConfidence + confidence) / 2
-	if casa.metrics.QueriesHandled > 0 {
-		casa.metrics.SuccessRate = float64(casa.metrics.QueriesHandled-casa.metrics.ErrorCount) / float64(casa.metrics.QueriesHandled)
-	}
+	casa.metrics.succeeded(time.Since(startTime), confidence, tokenUsage.TotalTokens, 0)
 }
 
 func (casa *ContextAwareSearchAgentImpl) convertFiltersToStringMap(filters []SearchFilter) map[string]string {
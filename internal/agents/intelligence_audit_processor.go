@@ -0,0 +1,77 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/yourusername/useq-ai-assistant/internal/audit"
+)
+
+// AuditProcessor is an intelligence layer backed by the audit analyzers
+type AuditProcessor struct {
+	name      string
+	analyzers []audit.Analyzer
+}
+
+// NewAuditProcessor creates a layer processor running analyzers over the
+// code it is given
+func NewAuditProcessor(name string, analyzers ...audit.Analyzer) IntelligenceProcessor {
+	return &AuditProcessor{name: name, analyzers: analyzers}
+}
+
+// Process analyzes Go code; other code gets an empty, zero-confidence result
+func (ap *AuditProcessor) Process(ctx context.Context, code string, ctxObj *IntelligenceCodingAgentDeepAnalysisContext) (*LayerResult, error) {
+	result := &LayerResult{
+		Name:        ap.name,
+		Findings:    []string{},
+		Metrics:     map[string]float64{audit.SeverityHigh: 0, audit.SeverityMedium: 0, audit.SeverityLow: 0},
+		Annotations: map[string]interface{}{},
+	}
+	pkg, err := audit.ParseSource("snippet.go", code)
+	if err != nil {
+		result.Annotations["skipped"] = "not parsable Go code"
+		return result, nil
+	}
+
+	var findings []audit.Finding
+	for _, analyzer := range ap.analyzers {
+		findings = append(findings, analyzer.Analyze(pkg)...)
+	}
+	for _, finding := range findings {
+		text := fmt.Sprintf("[%s] %s", finding.Severity, finding.Title)
+		if finding.Symbol != "" {
+			text += " in " + finding.Symbol
+		}
+		if finding.Line > 0 {
+			text += fmt.Sprintf(" (line %d)", finding.Line)
+		}
+		result.Findings = append(result.Findings, text)
+		result.Metrics[finding.Severity]++
+	}
+	result.Annotations["findings"] = findings
+	result.Confidence = 0.9
+	return result, nil
+}
+
+// GetCapabilities returns the analyzer names
+func (ap *AuditProcessor) GetCapabilities() []string {
+	capabilities := make([]string, 0, len(ap.analyzers))
+	for _, analyzer := range ap.analyzers {
+		capabilities = append(capabilities, analyzer.Name())
+	}
+	return capabilities
+}
+
+// Configure applies complexity_threshold to complexity analysis
+func (ap *AuditProcessor) Configure(config map[string]interface{}) error {
+	threshold, ok := config["complexity_threshold"].(int)
+	if !ok {
+		return nil
+	}
+	for _, analyzer := range ap.analyzers {
+		if complexity, ok := analyzer.(*audit.ComplexityAnalyzer); ok {
+			complexity.Threshold = threshold
+		}
+	}
+	return nil
+}
@@ -5,8 +5,10 @@ import (
 	"crypto/md5"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/yourusername/useq-ai-assistant/internal/audit"
 	"github.com/yourusername/useq-ai-assistant/internal/llm"
 )
 
@@ -128,12 +130,14 @@ type ResponseMetadata struct {
 type IntelligenceCodingAgentImpl struct {
 	dependencies       *AgentDependencies
 	config             *IntelligenceCodingAgentConfig
-	metrics            *AgentMetrics
+	metrics            *agentMetrics
 	searchAgent        BasicSearchAgent
 	codingAgent        BasicCodingAgent
 	intelligenceLayers []IntelligenceLayer
 	analysisCache      map[string]*IntelligenceCodingAgentDeepAnalysisResult
+	cacheMu            sync.RWMutex // analyses run for concurrent sessions
 	patternDatabase    *IntelligenceCodingAgentPatternDatabase
+	patternMu          sync.RWMutex // guards patternDatabase
 }
 
 // NewIntelligenceCodingAgent creates a new intelligence coding agent.
@@ -146,16 +150,7 @@ func NewIntelligenceCodingAgent(deps *AgentDependencies, searchAgent BasicSearch
 		codingAgent:     codingAgent,
 		analysisCache:   make(map[string]*IntelligenceCodingAgentDeepAnalysisResult),
 		patternDatabase: NewIntelligenceCodingAgentPatternDatabase(),
-		metrics: &AgentMetrics{
-			QueriesHandled:      0,
-			SuccessRate:         0.0,
-			AverageResponseTime: 0,
-			AverageConfidence:   0.0,
-			TokensUsed:          0,
-			TotalCost:           0.0,
-			LastUsed:            time.Now(),
-			ErrorCount:          0,
-		},
+		metrics:         newAgentMetrics("intelligence_coding"),
 	}
 
 	// Initialize intelligence layers (and their local processors)
@@ -253,7 +248,7 @@ func (ica *IntelligenceCodingAgentImpl) Process(ctx context.Context, query *Quer
 
 	deepIntent, err := ica.parseDeepIntent(query)
 	if err != nil {
-		ica.metrics.ErrorCount++
+		ica.metrics.failed()
 		return nil, fmt.Errorf("failed to parse deep intent: %w", err)
 	}
 
@@ -268,7 +263,7 @@ func (ica *IntelligenceCodingAgentImpl) Process(ctx context.Context, query *Quer
 	// Build context for intelligence processing
 	deepContext, err := ica.buildIntelligenceCodingAgentContext(ctx, deepIntent, query)
 	if err != nil {
-		ica.metrics.ErrorCount++
+		ica.metrics.failed()
 		return nil, fmt.Errorf("failed to build deep context: %w", err)
 	}
 
@@ -282,7 +277,7 @@ func (ica *IntelligenceCodingAgentImpl) Process(ctx context.Context, query *Quer
 	// perform the multi-layer processing
 	response, err := ica.processWithIntelligence(ctx, deepIntent, deepContext, query)
 	if err != nil {
-		ica.metrics.ErrorCount++
+		ica.metrics.failed()
 		return nil, fmt.Errorf("intelligent processing failed: %w", err)
 	}
 
@@ -300,7 +295,7 @@ func (ica *IntelligenceCodingAgentImpl) Process(ctx context.Context, query *Quer
 
 // GetMetrics returns metrics snapshot
 func (ica *IntelligenceCodingAgentImpl) GetMetrics() AgentMetrics {
-	return *ica.metrics
+	return ica.metrics.snapshot()
 }
 
 // AnalyzeCode — wrapper that uses performDeepAnalysis
@@ -463,7 +458,10 @@ func (ica *IntelligenceCodingAgentImpl) performDeepAnalysis(ctx context.Context,
 	start := time.Now()
 
 	cacheKey := ica.generateCacheKey(request)
-	if cached, ok := ica.analysisCache[cacheKey]; ok {
+	ica.cacheMu.RLock()
+	cached, ok := ica.analysisCache[cacheKey]
+	ica.cacheMu.RUnlock()
+	if ok {
 		ica.logStep("Retrieved analysis from cache", map[string]interface{}{"cache_key": cacheKey})
 		return cached, nil
 	}
@@ -534,7 +532,9 @@ func (ica *IntelligenceCodingAgentImpl) performDeepAnalysis(ctx context.Context,
 	}
 
 	// cache
+	ica.cacheMu.Lock()
 	ica.analysisCache[cacheKey] = result
+	ica.cacheMu.Unlock()
 
 	ica.logStep("Deep analysis completed", map[string]interface{}{
 		"processing_time_ms": result.ProcessingTime.Milliseconds(),
@@ -571,8 +571,9 @@ func (ica *IntelligenceCodingAgentImpl) initializeIntelligenceLayers() {
 	add("semantic_analysis", "semantic", 0.25, true, map[string]interface{}{"llm_enhanced": true}, NewMockProcessor())
 	add("architecture_analysis", "architecture", 0.20, ica.config.ArchitectureAnalysis, map[string]interface{}{"cross_file": ica.config.CrossFileAnalysis}, NewMockProcessor())
 	add("performance_analysis", "performance", 0.15, ica.config.PerformanceAnalysis, map[string]interface{}{"optimization_focus": true}, NewMockProcessor())
-	add("security_analysis", "security", 0.10, true, map[string]interface{}{"vulnerability_scan": true}, NewMockProcessor())
-	add("quality_analysis", "quality", 0.10, true, map[string]interface{}{"maintainability_focus": true}, NewMockProcessor())
+	add("security_analysis", "security", 0.10, true, map[string]interface{}{"vulnerability_scan": true}, NewAuditProcessor("security", &audit.SecurityAnalyzer{}))
+	add("quality_analysis", "quality", 0.10, true, map[string]interface{}{"maintainability_focus": true},
+		NewAuditProcessor("quality", &audit.ComplexityAnalyzer{Threshold: audit.DefaultConfig().ComplexityThreshold}, &audit.OutdatedAnalyzer{}, &audit.ErrorHandlingAnalyzer{}))
 
 	ica.logStep("Initialized intelligence layers", map[string]interface{}{
 		"total_layers":   len(ica.intelligenceLayers),
@@ -713,23 +714,11 @@ func (ica *IntelligenceCodingAgentImpl) logStep(message string, fields map[strin
 }
 
 func (ica *IntelligenceCodingAgentImpl) updateMetrics(startTime time.Time) {
-	ica.metrics.QueriesHandled++
-	ica.metrics.LastUsed = startTime
+	ica.metrics.started(startTime)
 }
 
 func (ica *IntelligenceCodingAgentImpl) updateSuccessMetrics(startTime time.Time, confidence float64, tokenUsage *TokenUsage) {
-	duration := time.Since(startTime)
-	total := float64(ica.metrics.QueriesHandled)
-	if total <= 0 {
-		total = 1
-	}
-	ica.metrics.AverageResponseTime = time.Duration(
-		(float64(ica.metrics.AverageResponseTime)*(total-1) + float64(duration)) / total,
-	)
-	ica.metrics.AverageConfidence = (ica.metrics.AverageConfidence*(total-1) + confidence) / total
-	ica.metrics.TokensUsed += int64(tokenUsage.TotalTokens)
-	successCount := float64(ica.metrics.QueriesHandled - ica.metrics.ErrorCount)
-	ica.metrics.SuccessRate = successCount / float64(ica.metrics.QueriesHandled)
+	ica.metrics.succeeded(time.Since(startTime), confidence, tokenUsage.TotalTokens, 0)
 }
 
 // convertLayersToCodingLayers returns a list of intelligence layers in the type expected by deep analysis request.
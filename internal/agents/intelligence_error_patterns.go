@@ -0,0 +1,98 @@
+package agents
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/audit"
+)
+
+// PatternCategoryErrorHandling holds the error-handling styles of each package
+const PatternCategoryErrorHandling = "error_handling"
+
+// maxPatternExamples bounds the example sites kept per pattern
+const maxPatternExamples = 3
+
+// RecordErrorStyles stores how each inventoried package wraps and creates
+// errors in the pattern database, one pattern per package and style. Quality
+// is the style's share of its family, so the dominant style scores highest.
+func (ica *IntelligenceCodingAgentImpl) RecordErrorStyles(inventories []*audit.ErrorInventory) {
+	ica.patternMu.Lock()
+	defer ica.patternMu.Unlock()
+
+	db := ica.patternDatabase
+	for _, inv := range inventories {
+		// Drop the package's previous styles so ones no longer used go away
+		for id, pattern := range db.Patterns {
+			if pattern.Category == PatternCategoryErrorHandling && pattern.Pattern == inv.Dir {
+				delete(db.Patterns, id)
+			}
+		}
+
+		examples := make(map[string][]string)
+		for _, site := range inv.Sites {
+			if len(examples[site.Style]) < maxPatternExamples {
+				examples[site.Style] = append(examples[site.Style], fmt.Sprintf("%s:%d %s", site.File, site.Line, site.Code))
+			}
+		}
+		for style, count := range inv.Counts {
+			family := count
+			if kind := audit.ErrorStyleFamily(style); kind != "" {
+				family = 0
+				for other, otherCount := range inv.Counts {
+					if audit.ErrorStyleFamily(other) == kind {
+						family += otherCount
+					}
+				}
+			}
+			description := fmt.Sprintf("%s in %s", style, inv.Dir)
+			if inv.Dominant(style) == style {
+				description += " (dominant)"
+			}
+			id := fmt.Sprintf("%s:%s:%s", PatternCategoryErrorHandling, inv.Dir, style)
+			db.Patterns[id] = IntelligenceCodingAgentPattern{
+				ID:          id,
+				Name:        style,
+				Category:    PatternCategoryErrorHandling,
+				Language:    "go",
+				Pattern:     inv.Dir,
+				Description: description,
+				Examples:    examples[style],
+				Usage:       count,
+				Quality:     float64(count) / float64(max(family, 1)),
+				CreatedAt:   time.Now(),
+			}
+		}
+	}
+
+	seen := false
+	for _, category := range db.Categories {
+		seen = seen || category == PatternCategoryErrorHandling
+	}
+	if !seen {
+		db.Categories = append(db.Categories, PatternCategoryErrorHandling)
+	}
+	db.LastUpdated = time.Now()
+}
+
+// PatternsByCategory returns the recorded patterns of a category, by
+// package and then most used first
+func (ica *IntelligenceCodingAgentImpl) PatternsByCategory(category string) []IntelligenceCodingAgentPattern {
+	ica.patternMu.RLock()
+	defer ica.patternMu.RUnlock()
+
+	var patterns []IntelligenceCodingAgentPattern
+	for _, pattern := range ica.patternDatabase.Patterns {
+		if pattern.Category == category {
+			patterns = append(patterns, pattern)
+		}
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		if patterns[i].Pattern != patterns[j].Pattern {
+			return patterns[i].Pattern < patterns[j].Pattern
+		}
+		return patterns[i].Usage > patterns[j].Usage
+	})
+	return patterns
+}
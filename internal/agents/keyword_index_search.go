@@ -0,0 +1,75 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/internal/keyword"
+	"github.com/yourusername/useq-ai-assistant/internal/logger"
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// keywordIndexTopScore is the score of the best keyword index hit. BM25
+// scores have no fixed scale, so hits are scored relative to the best one,
+// kept below the scores of exact name matches.
+const keywordIndexTopScore = 0.9
+
+// performKeywordIndexSearch ranks chunks from the local keyword index, which
+// stands in for semantic search when no vector database is configured
+func (sa *SearchAgentImpl) performKeywordIndexSearch(ctx context.Context, intent *SearchAgentIntent) ([]*SearchAgentResult, error) {
+	terms := keyword.QueryTerms(sa.vocabulary().ExpandQuery(intent.Query))
+	hits, err := sa.dependencies.Storage.SearchKeywordIndex(terms, sa.config.MaxResults)
+	if err != nil {
+		return nil, fmt.Errorf("keyword index search failed: %w", err)
+	}
+	logger.Verbosef(ctx, logger.ComponentAgent, "🔤 Keyword index returned %d results for %v", len(hits), terms)
+
+	results := make([]*SearchAgentResult, 0, len(hits))
+	for _, hit := range hits {
+		result := sa.convertKeywordHit(hit)
+		result.Score = keywordIndexTopScore * hit.Score / hits[0].Score
+		result.Metadata[rankStrategy] = "keyword_index"
+		setRankFactor(result, rankSimilarity, result.Score)
+		setRankFactor(result, rankKeywordHits, float64(len(hit.Matched)))
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// convertKeywordHit converts a keyword index hit like a vector result
+func (sa *SearchAgentImpl) convertKeywordHit(hit *storage.KeywordHit) *SearchAgentResult {
+	content := hit.Content
+	if len(content) > 500 {
+		content = content[:500] + "..."
+	}
+	return &SearchAgentResult{
+		File:      hit.Path,
+		Function:  sa.extractFunctionName(hit.Content),
+		Type:      sa.detectCodeType(hit.Content),
+		Line:      hit.StartLine,
+		Context:   content,
+		ChunkType: sa.classifyChunk(hit.Content),
+		Language:  hit.Language,
+		Package:   sa.extractPackageName(hit.Path),
+		Metadata:  map[string]string{"content": content},
+	}
+}
+
+// formatKeywordIndexHits lists Tier 2 keyword index hits under the MCP
+// results
+func formatKeywordIndexHits(hits []*storage.KeywordHit) string {
+	if len(hits) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\n🔤 Keyword Index Results:\n")
+	for i, hit := range hits {
+		if i >= 5 {
+			fmt.Fprintf(&b, "... and %d more matches\n", len(hits)-5)
+			break
+		}
+		fmt.Fprintf(&b, "  %d. %s:%d-%d (matched: %s)\n", i+1, hit.Path, hit.StartLine, hit.EndLine, strings.Join(hit.Matched, ", "))
+	}
+	return b.String()
+}
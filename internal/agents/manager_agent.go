@@ -6,11 +6,19 @@ import (
 	"os"
 	"math"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/yourusername/useq-ai-assistant/internal/flags"
+	"github.com/yourusername/useq-ai-assistant/internal/diagnostics"
+	"github.com/yourusername/useq-ai-assistant/internal/glossary"
 	"github.com/yourusername/useq-ai-assistant/internal/mcp"
+	"github.com/yourusername/useq-ai-assistant/internal/implements"
+	"github.com/yourusername/useq-ai-assistant/internal/keyword"
+	"github.com/yourusername/useq-ai-assistant/internal/migration"
 	"github.com/yourusername/useq-ai-assistant/internal/llm"
+	"github.com/yourusername/useq-ai-assistant/internal/sandbox"
 	"github.com/yourusername/useq-ai-assistant/models"
 )
 
@@ -25,8 +33,27 @@ type ManagerAgent struct {
 	mcpClient               *mcp.MCPClient
 	intelligentProcessor    *mcp.IntelligentQueryProcessor
 	llmManager              *llm.Manager
-	metrics                 *AgentMetrics
+	metrics                 *agentMetrics
 	routingHistory          []RoutingDecision
+	mu                      sync.Mutex // guards routingHistory across concurrent sessions
+	clarification           ClarificationConfig
+	toolLoop                ToolLoopConfig
+	sandbox                 sandbox.Policy // commands the tools run, such as run_tests
+	consultation            ConsultationConfig
+	fileMentions            FileMentionConfig
+	pins                    PinConfig
+	glossary                glossary.Config
+	analyzerDiagnostics     diagnostics.Config
+	migration               migration.Config
+	implements              implements.Config
+	exactLookup             ExactLookupConfig
+	excerpts                ExcerptConfig
+	summarySearch           SummarySearchConfig
+	snippets                SnippetConfig
+	costEstimates           CostEstimateConfig
+	costConfirmer           CostConfirmer
+	memory                  MemoryConfig
+	registry                *CapabilityRegistry
 }
 
 // NewManagerAgent creates a new centralized manager agent
@@ -36,16 +63,24 @@ func NewManagerAgent(deps *AgentDependencies) *ManagerAgent {
 		intelligentProcessor: mcp.NewIntelligentQueryProcessor(),
 		mcpClient:      mcp.NewMCPClient(),
 		routingHistory: make([]RoutingDecision, 0),
-		metrics: &AgentMetrics{
-			QueriesHandled:      0,
-			SuccessRate:         0.0,
-			AverageResponseTime: 0,
-			AverageConfidence:   0.0,
-			TokensUsed:          0,
-			TotalCost:           0.0,
-			LastUsed:            time.Now(),
-			ErrorCount:          0,
-		},
+		clarification:  DefaultClarificationConfig(),
+		toolLoop:       DefaultToolLoopConfig(),
+		sandbox:        sandbox.DefaultPolicy(),
+		consultation:   DefaultConsultationConfig(),
+		fileMentions:   DefaultFileMentionConfig(),
+		pins:           DefaultPinConfig(),
+		glossary:       glossary.DefaultConfig(),
+		analyzerDiagnostics: diagnostics.DefaultConfig(),
+		migration:      migration.DefaultConfig(),
+		implements:     implements.DefaultConfig(),
+		exactLookup:    DefaultExactLookupConfig(),
+		excerpts:       DefaultExcerptConfig(),
+		summarySearch:  DefaultSummarySearchConfig(),
+		snippets:       DefaultSnippetConfig(),
+		costEstimates:  DefaultCostEstimateConfig(),
+		memory:         DefaultMemoryConfig(),
+		registry:       NewCapabilityRegistry(),
+		metrics:        newAgentMetrics("manager"),
 	}
 
 	// Initialize specialized agents with error handling
@@ -53,6 +88,9 @@ func NewManagerAgent(deps *AgentDependencies) *ManagerAgent {
 	
 	// Initialize LLM manager with environment variables
 	manager.initializeLLMManager()
+
+	// Classifier cost predictions count tokens for the primary model
+	manager.mcpClient.GetQueryClassifier().SetTokenizer(manager.tokenizer())
 	return manager
 }
 
@@ -75,6 +113,7 @@ func (ma *ManagerAgent) initializeAgents(deps *AgentDependencies) {
 		// Initialize system agent
 		ma.SystemAgent = NewSystemAgent(deps)
 	}
+	ma.registerBuiltinAgents()
 }
 
 // initializeLLMManager initializes LLM manager with environment variables
@@ -136,6 +175,80 @@ func (ma *ManagerAgent) initializeLLMManager() {
 
 // RouteQuery intelligently routes queries to the most appropriate agent
 func (ma *ManagerAgent) RouteQuery(ctx context.Context, query *models.Query) (response *models.Response, err error) {
+	// Dry-run: describe the execution plan without any paid calls
+	if IsPlanOnly(query) {
+		return ma.PlanQuery(ctx, query)
+	}
+
+	// MCP context gathering and the tool loop act as the manager; the routed
+	// agent's calls are scoped again in executeWithSelectedAgent
+	ctx = ma.dependencies.AgentContext(ctx, "manager")
+
+	// Pasted code is analyzed as given; searching the index for it would
+	// only find the project code it resembles
+	if query.Context.Snippet != nil && ma.snippets.Enabled {
+		return ma.answerPastedSnippet(ctx, query)
+	}
+
+	// "What did we decide about X" is answered from earlier conversations,
+	// not the code
+	if response, memoryErr := ma.answerFromMemory(ctx, query); memoryErr != nil {
+		if ma.dependencies != nil && ma.dependencies.Logger != nil {
+			ma.dependencies.Logger.Warn("Recalling earlier answers failed, falling back", map[string]interface{}{
+				"error": memoryErr.Error(),
+			})
+		}
+	} else if response != nil {
+		return response, nil
+	}
+
+	// A bare symbol name is looked up exactly, in milliseconds and for free
+	if response, symbolErr := ma.answerExactSymbol(ctx, query); symbolErr != nil {
+		if ma.dependencies != nil && ma.dependencies.Logger != nil {
+			ma.dependencies.Logger.Warn("Exact symbol lookup failed, falling back", map[string]interface{}{
+				"error": symbolErr.Error(),
+			})
+		}
+	} else if response != nil {
+		return response, nil
+	}
+
+	// Migrations need the module graph, usages and release notes, which
+	// neither retrieval nor the classifier tiers gather
+	if response, migrationErr := ma.answerMigration(ctx, query); migrationErr != nil {
+		if ma.dependencies != nil && ma.dependencies.Logger != nil {
+			ma.dependencies.Logger.Warn("Planning the migration failed, falling back", map[string]interface{}{
+				"error": migrationErr.Error(),
+			})
+		}
+	} else if response != nil {
+		return response, nil
+	}
+
+	// Interface-implementation questions are answered from the edges
+	// recorded while indexing, without retrieval or an LLM
+	if response, implErr := ma.answerImplementations(ctx, query); implErr != nil {
+		if ma.dependencies != nil && ma.dependencies.Logger != nil {
+			ma.dependencies.Logger.Warn("Looking up implementations failed, falling back", map[string]interface{}{
+				"error": implErr.Error(),
+			})
+		}
+	} else if response != nil {
+		return response, nil
+	}
+
+	// Files named in the query go straight into context; retrieval could
+	// miss them
+	if response, mentionErr := ma.answerWithMentionedFiles(ctx, query); mentionErr != nil {
+		if ma.dependencies != nil && ma.dependencies.Logger != nil {
+			ma.dependencies.Logger.Warn("Answering from mentioned files failed, falling back", map[string]interface{}{
+				"error": mentionErr.Error(),
+			})
+		}
+	} else if response != nil {
+		return response, nil
+	}
+
 	// STEP 1: 3-TIER CLASSIFICATION FIRST - COST OPTIMIZATION
 	classification, classErr := ma.mcpClient.(*mcp.MCPClient).GetQueryClassifier().ClassifyQuery(ctx, query)
 	if classErr == nil {
@@ -150,6 +263,23 @@ func (ma *ManagerAgent) RouteQuery(ctx context.Context, query *models.Query) (re
 			})
 		}
 
+		// Ask a single question rather than guess when both the classifier
+		// and retrieval are unsure
+		if clarification := ma.clarificationFor(ctx, query, classification); clarification != nil {
+			return ma.clarificationResponse(query, clarification, classification), nil
+		}
+
+		// "Which file handles X" is answered from file summaries, not chunks
+		if response, summaryErr := ma.answerAtGranularity(ctx, query, classification); summaryErr != nil {
+			if ma.dependencies != nil && ma.dependencies.Logger != nil {
+				ma.dependencies.Logger.Warn("Summary search failed, falling back", map[string]interface{}{
+					"error": summaryErr.Error(),
+				})
+			}
+		} else if response != nil {
+			return response, nil
+		}
+
 		// Process based on tier classification
 		switch classification.Tier {
 		case mcp.TierSimple:
@@ -231,7 +361,8 @@ func (ma *ManagerAgent) RouteQuery(ctx context.Context, query *models.Query) (re
 	}
 
 	// Select best agent based on improved analysis
-	selectedAgent, confidence := ma.selectBestAgent(ctx, query, routingAnalysis)
+	agentScores := ma.scoreAgents(query, routingAnalysis)
+	selectedAgent, confidence := bestAgent(agentScores)
 	if selectedAgent == "" {
 		// Default to search agent for unclassified queries
 		selectedAgent = "search"
@@ -247,8 +378,19 @@ func (ma *ManagerAgent) RouteQuery(ctx context.Context, query *models.Query) (re
 		Timestamp:     time.Now(),
 	}
 
-	// Route to selected agent with better error handling
-	response, err = ma.executeWithSelectedAgent(ctx, query, selectedAgent)
+	// Route to selected agent with better error handling; when the top two
+	// agents are too close to call, consult both and let the arbiter decide
+	if candidates, ok := ma.consultationCandidates(ctx, agentScores); ok {
+		var comparison *AgentComparison
+		response, comparison, err = ma.consultAgents(ctx, query, candidates)
+		decision.Comparison = comparison
+		if comparison.Winner != "" {
+			decision.SelectedAgent = comparison.Winner
+			selectedAgent = comparison.Winner
+		}
+	} else {
+		response, err = ma.executeWithSelectedAgent(ctx, query, selectedAgent)
+	}
 
 	// Store query in database
 	if ma.dependencies.Storage != nil {
@@ -261,7 +403,7 @@ func (ma *ManagerAgent) RouteQuery(ctx context.Context, query *models.Query) (re
 
 	// Update routing decision with success status
 	decision.Success = (err == nil)
-	ma.routingHistory = append(ma.routingHistory, decision)
+	ma.recordDecision(decision)
 
 	// Store response in database
 	if err == nil && response != nil && ma.dependencies.Storage != nil {
@@ -273,7 +415,7 @@ func (ma *ManagerAgent) RouteQuery(ctx context.Context, query *models.Query) (re
 	}
 
 	if err != nil {
-		ma.metrics.ErrorCount++
+		ma.metrics.failed()
 		// Try fallback routing
 		return ma.handleRoutingFallback(ctx, query, selectedAgent)
 	}
@@ -353,7 +495,20 @@ func (ma *ManagerAgent) processTier2Query(ctx context.Context, query *models.Que
 	
 	// Format response from MCP + Vector results (no LLM synthesis)
 	responseText := ma.formatMCPAndVectorResults(mcpContext, vectorResults, query)
-	
+	tools := []string{"mcp_filesystem", "vector_search", "openai_embeddings"}
+	cost := 0.0005
+
+	// Keyword-only mode searches the local keyword index instead, for free
+	if ma.dependencies != nil && ma.dependencies.VectorDB == nil && ma.dependencies.Storage != nil {
+		hits, err := ma.dependencies.Storage.SearchKeywordIndex(keyword.QueryTerms(query.UserInput), 10)
+		if err != nil {
+			return nil, fmt.Errorf("tier 2 keyword index search failed: %w", err)
+		}
+		responseText += formatKeywordIndexHits(hits)
+		tools = []string{"mcp_filesystem", "keyword_index"}
+		cost = 0
+	}
+
 	response := &models.Response{
 		ID:      fmt.Sprintf("tier2_%d", time.Now().UnixNano()),
 		QueryID: query.ID,
@@ -364,11 +519,11 @@ func (ma *ManagerAgent) processTier2Query(ctx context.Context, query *models.Que
 		AgentUsed:  "mcp_vector",
 		Provider:   "mcp_vector_search",
 		TokenUsage: models.TokenUsage{TotalTokens: 0},
-		Cost:       models.Cost{TotalCost: 0.0005, Currency: "USD"}, // REAL embedding cost
+		Cost:       models.Cost{TotalCost: cost, Currency: "USD"}, // REAL embedding cost
 		Metadata: models.ResponseMetadata{
 			GenerationTime: time.Since(startTime),
 			Confidence:     classification.Confidence,
-			Tools:          []string{"mcp_filesystem", "vector_search", "openai_embeddings"},
+			Tools:          tools,
 			Reasoning:      classification.Reasoning,
 		},
 		Timestamp: time.Now(),
@@ -379,6 +534,50 @@ func (ma *ManagerAgent) processTier2Query(ctx context.Context, query *models.Que
 
 // processTier3Query handles complex queries with full LLM pipeline
 func (ma *ManagerAgent) processTier3Query(ctx context.Context, query *models.Query, classification *mcp.ClassificationResult) (*models.Response, error) {
+	// Show what the query will cost, and ask first when it is expensive
+	estimate := ma.estimateTier3Cost(query)
+	if estimate != nil && !ma.approveCost(ctx, estimate) {
+		return ma.declinedCostResponse(query, estimate), nil
+	}
+
+	response, err := ma.answerTier3Query(ctx, query, classification)
+	if err != nil {
+		return response, err
+	}
+	ma.recordCostEstimate(query, estimate, response)
+	// Explanations name functions without showing them; attach their code
+	if flags.Enabled(ctx, flags.Excerpts) {
+		ma.attachExcerpts(query, response)
+	}
+	return response, nil
+}
+
+// answerTier3Query picks how a Tier 3 query is answered: the tool loop,
+// intelligent processing or the specialized agents
+func (ma *ManagerAgent) answerTier3Query(ctx context.Context, query *models.Query, classification *mcp.ClassificationResult) (*models.Response, error) {
+	// Files the user pinned, the project terms the query uses and the
+	// analyzer findings it asks about go into every prompt below
+	ctx = ma.withPinnedFiles(ctx, query, nil)
+	ctx = ma.withGlossary(ctx, query)
+	ctx = ma.withAnalyzerDiagnostics(ctx, query)
+
+	// Answer in the language of the code the query is about when the query
+	// itself does not say
+	ma.inferRetrievedLanguage(ctx, query)
+
+	// Let the model look things up itself when it can call tools
+	if ma.toolLoop.Enabled && ma.toolLoopLLM() != nil && flags.Enabled(ctx, flags.ToolLoop) {
+		response, err := ma.runToolLoop(ctx, query, classification)
+		if err == nil {
+			return response, nil
+		}
+		if ma.dependencies != nil && ma.dependencies.Logger != nil {
+			ma.dependencies.Logger.Warn("Tool loop failed, falling back", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+	}
+
 	// Use existing intelligent processing for complex queries
 	if ma.shouldUseIntelligentProcessing(query) {
 		return ma.intelligentProcessor.ProcessQuery(ctx, query)
@@ -451,8 +650,24 @@ func (ma *ManagerAgent) formatMCPAndVectorResults(mcpContext *models.MCPContext,
 func (ma *ManagerAgent) routeToTraditionalAgents(ctx context.Context, query *models.Query) (*models.Response, error) {
 	// Use existing routing logic as fallback
 	routingAnalysis := ma.analyzeQueryForRouting(ctx, query)
-	selectedAgent, confidence := ma.selectBestAgent(ctx, query, routingAnalysis)
-	return ma.executeWithSelectedAgent(ctx, query, selectedAgent)
+	agentScores := ma.scoreAgents(query, routingAnalysis)
+	selectedAgent, confidence := bestAgent(agentScores)
+
+	candidates, ok := ma.consultationCandidates(ctx, agentScores)
+	if !ok {
+		return ma.executeWithSelectedAgent(ctx, query, selectedAgent)
+	}
+	response, comparison, err := ma.consultAgents(ctx, query, candidates)
+	ma.recordDecision(RoutingDecision{
+		QueryID:       query.ID,
+		Intent:        routingAnalysis.PrimaryIntent,
+		SelectedAgent: comparison.Winner,
+		Confidence:    confidence,
+		Success:       err == nil,
+		Timestamp:     time.Now(),
+		Comparison:    comparison,
+	})
+	return response, err
 }
 
 // formatStructureForDisplay formats project structure for display
@@ -509,14 +724,13 @@ func (ma *ManagerAgent) analyzeQueryForRouting(ctx context.Context, query *model
 
 // selectBestAgent chooses the most appropriate agent based on improved analysis
 func (ma *ManagerAgent) selectBestAgent(ctx context.Context, query *models.Query, analysis *RoutingAnalysis) (string, float64) {
-	agentScores := make(map[string]float64)
+	return bestAgent(ma.scoreAgents(query, analysis))
+}
 
-	// Evaluate each agent's capability for this query with corrected scoring
-	agentScores["search"] = ma.evaluateSearchAgent(query, analysis)
-	agentScores["context_search"] = ma.evaluateContextSearchAgent(query, analysis)
-	agentScores["coding"] = ma.evaluateCodingAgent(query, analysis)
-	agentScores["intelligence_coding"] = ma.evaluateIntelligenceCodingAgent(query, analysis)
-	agentScores["system"] = ma.evaluateSystemAgent(query, analysis)
+// scoreAgents rates every agent's fit for the query, adjusted by routing history
+func (ma *ManagerAgent) scoreAgents(query *models.Query, analysis *RoutingAnalysis) map[string]float64 {
+	// Every registered agent rates its own fit for the query
+	agentScores := ma.registry.Score(query, analysis)
 
 	// Apply learning from routing history
 	ma.applyHistoricalLearning(agentScores, analysis)
@@ -524,103 +738,32 @@ func (ma *ManagerAgent) selectBestAgent(ctx context.Context, query *models.Query
 	// Debug logging for routing decisions
 	if ma.dependencies != nil && ma.dependencies.Logger != nil {
 		ma.dependencies.Logger.Info("Agent scoring results", map[string]interface{}{
-			"query":              query.UserInput,
-			"primary_intent":     analysis.PrimaryIntent,
-			"search_score":       agentScores["search"],
-			"context_score":      agentScores["context_search"],
-			"coding_score":       agentScores["coding"],
-			"intelligence_score": agentScores["intelligence_coding"],
+			"query":          query.UserInput,
+			"primary_intent": analysis.PrimaryIntent,
+			"scores":         agentScores,
 		})
 	}
 
-	// Select agent with highest score
-	bestAgent := ""
-	bestScore := 0.0
+	return agentScores
+}
 
-	for agent, score := range agentScores {
-		if score > bestScore {
-			bestScore = score
-			bestAgent = agent
-		}
+// bestAgent returns the agent with the highest score
+func bestAgent(agentScores map[string]float64) (string, float64) {
+	ranked := rankAgentScores(agentScores)
+	if len(ranked) == 0 || ranked[0].Score <= 0 {
+		return "", 0
 	}
-
-	return bestAgent, bestScore
+	return ranked[0].AgentName, ranked[0].Score
 }
 
-// executeWithSelectedAgent routes to the chosen agent with better error handling
+// executeWithSelectedAgent runs the chosen agent from the registry
 func (ma *ManagerAgent) executeWithSelectedAgent(ctx context.Context, query *models.Query, agentName string) (*models.Response, error) {
-	switch agentName {
-	case "search":
-		if ma.SearchAgent == nil {
-			return nil, fmt.Errorf("search agent not initialized")
-		}
-		return ma.SearchAgent.Search(ctx, query)
-
-	case "context_search":
-		if ma.ContextAwareSearchAgent == nil {
-			return nil, fmt.Errorf("context search agent not initialized")
-		}
-		return ma.ContextAwareSearchAgent.Process(ctx, query)
-
-	case "coding":
-		if ma.CodingAgent == nil {
-			return nil, fmt.Errorf("coding agent not initialized")
-		}
-		// Add null check before calling Process
-		response, err := ma.CodingAgent.Process(ctx, query)
-		if err != nil {
-			if ma.dependencies != nil && ma.dependencies.Logger != nil {
-				ma.dependencies.Logger.Error("CodingAgent process failed", map[string]interface{}{
-					"error": err.Error(),
-					"query": query.UserInput,
-				})
-			}
-			return nil, fmt.Errorf("coding agent failed: %w", err)
-		}
-		return response, nil
-
-	case "intelligence_coding":
-		if ma.IntelligenceCodingAgent == nil {
-			return nil, fmt.Errorf("intelligence coding agent not initialized")
-		}
-		// Convert models.Query to Query for IntelligenceCodingAgent
-		icQuery := &Query{
-			ID:        query.ID,
-			UserInput: query.UserInput,
-			Language:  query.Language,
-		}
-		icResponse, err := ma.IntelligenceCodingAgent.Process(ctx, icQuery)
-		if err != nil {
-			return nil, fmt.Errorf("intelligence coding agent failed: %w", err)
-		}
-		// Convert Response to models.Response
-		return &models.Response{
-			ID:        icResponse.ID,
-			QueryID:   icResponse.QueryID,
-			Type:      models.ResponseType(icResponse.Type),
-			Content:   models.ResponseContent{Text: icResponse.Content.Text},
-			AgentUsed: icResponse.AgentUsed,
-			Timestamp: icResponse.Timestamp,
-			TokenUsage: models.TokenUsage{
-				InputTokens:  icResponse.TokenUsage.InputTokens,
-				OutputTokens: icResponse.TokenUsage.OutputTokens,
-				TotalTokens:  icResponse.TokenUsage.TotalTokens,
-			},
-			Cost: models.Cost{
-				TotalCost: icResponse.Cost.TotalCost,
-				Currency:  icResponse.Cost.Currency,
-			},
-		}, nil
-
-	case "system":
-		if ma.SystemAgent == nil {
-			return nil, fmt.Errorf("system agent not initialized")
-		}
-		return ma.SystemAgent.Process(ctx, query)
-
-	default:
+	ctx = ma.dependencies.AgentContext(ctx, agentName)
+	registration, ok := ma.registry.Lookup(agentName)
+	if !ok {
 		return nil, fmt.Errorf("unknown agent: %s", agentName)
 	}
+	return registration.Process(ctx, query)
 }
 
 // FIXED: Agent evaluation methods with corrected scoring
@@ -1129,7 +1272,24 @@ func (ma *ManagerAgent) applyHistoricalLearning(scores map[string]float64, analy
 	}
 }
 
+// maxRoutingHistory caps the routing decisions kept; scoring only looks at
+// the recent ones
+const maxRoutingHistory = 1000
+
+// recordDecision appends a routing decision to the history, dropping the
+// oldest past maxRoutingHistory
+func (ma *ManagerAgent) recordDecision(decision RoutingDecision) {
+	ma.mu.Lock()
+	defer ma.mu.Unlock()
+	ma.routingHistory = append(ma.routingHistory, decision)
+	if len(ma.routingHistory) > maxRoutingHistory {
+		ma.routingHistory = append([]RoutingDecision(nil), ma.routingHistory[len(ma.routingHistory)-maxRoutingHistory:]...)
+	}
+}
+
 func (ma *ManagerAgent) getRecentDecisionsForIntent(intent string, limit int) []RoutingDecision {
+	ma.mu.Lock()
+	defer ma.mu.Unlock()
 	var decisions []RoutingDecision
 	count := 0
 
@@ -1160,33 +1320,31 @@ func (ma *ManagerAgent) handleRoutingFallback(ctx context.Context, query *models
 }
 
 func (ma *ManagerAgent) updateMetrics(startTime time.Time) {
-	ma.metrics.QueriesHandled++
-	ma.metrics.LastUsed = time.Now()
+	ma.metrics.started(startTime)
 }
 
 func (ma *ManagerAgent) updateSuccessMetrics(startTime time.Time, confidence float64, response *models.Response) {
-	duration := time.Since(startTime)
-	ma.metrics.AverageResponseTime = (ma.metrics.AverageResponseTime + duration) / 2
-	ma.metrics.AverageConfidence = (ma.metrics.AverageConfidence + confidence) / 2
-	ma.metrics.SuccessRate = float64(ma.metrics.QueriesHandled-ma.metrics.ErrorCount) / float64(ma.metrics.QueriesHandled)
-
+	tokens, cost := 0, 0.0
 	if response != nil {
-		ma.metrics.TokensUsed += int64(response.TokenUsage.TotalTokens)
-		ma.metrics.TotalCost += response.Cost.TotalCost
+		tokens, cost = response.TokenUsage.TotalTokens, response.Cost.TotalCost
 	}
+	ma.metrics.succeeded(time.Since(startTime), confidence, tokens, cost)
 }
 
 func (ma *ManagerAgent) GetMetrics() AgentMetrics {
-	return *ma.metrics
+	return ma.metrics.snapshot()
 }
 
+// GetRoutingHistory returns a copy of the last limit routing decisions, or
+// all of them when limit is not positive
 func (ma *ManagerAgent) GetRoutingHistory(limit int) []RoutingDecision {
-	if limit <= 0 || limit > len(ma.routingHistory) {
-		return ma.routingHistory
+	ma.mu.Lock()
+	defer ma.mu.Unlock()
+	start := 0
+	if limit > 0 && limit < len(ma.routingHistory) {
+		start = len(ma.routingHistory) - limit
 	}
-
-	start := len(ma.routingHistory) - limit
-	return ma.routingHistory[start:]
+	return append([]RoutingDecision(nil), ma.routingHistory[start:]...)
 }
 
 // evaluateSystemAgent evaluates system agent capability for the query
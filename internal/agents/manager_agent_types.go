@@ -18,6 +18,28 @@ type RoutingDecision struct {
 	Confidence    float64   `json:"confidence"`
 	Success       bool      `json:"success"`
 	Timestamp     time.Time `json:"timestamp"`
+
+	// Comparison is set when the query was ambiguous and two agents answered
+	Comparison *AgentComparison `json:"comparison,omitempty"`
+}
+
+// AgentComparison records how the arbiter chose between consulted agents
+type AgentComparison struct {
+	Candidates []ConsultedAgent `json:"candidates"`
+	Winner     string           `json:"winner"`
+	Merged     bool             `json:"merged"`
+	Reason     string           `json:"reason"`
+}
+
+// ConsultedAgent is one agent's result in a consultation
+type ConsultedAgent struct {
+	Agent        string        `json:"agent"`
+	RoutingScore float64       `json:"routing_score"`
+	Confidence   float64       `json:"confidence"`
+	Coverage     float64       `json:"coverage"` // distinct retrieved files, normalized to 0-1
+	Score        float64       `json:"score"`    // arbiter score
+	Duration     time.Duration `json:"duration"`
+	Error        string        `json:"error,omitempty"`
 }
 
 // RoutingAnalysis represents query analysis for intelligent agent routing
@@ -0,0 +1,42 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/yourusername/useq-ai-assistant/internal/mcp"
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// PredictRoute classifies a query the way RouteQuery would, without
+// answering it: the tier the classifier picks and the agent scoring picks,
+// search when no agent scores
+func (ma *ManagerAgent) PredictRoute(ctx context.Context, query *models.Query) (mcp.QueryTier, string, error) {
+	classification, err := ma.mcpClient.GetQueryClassifier().ClassifyQuery(ctx, query)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to classify query: %w", err)
+	}
+	agent, _ := bestAgent(ma.scoreAgents(query, ma.analyzeQueryForRouting(ctx, query)))
+	if agent == "" {
+		agent = "search"
+	}
+	return classification.Tier, agent, nil
+}
+
+// RunClassificationBenchmark predicts the route of every labeled case and
+// scores the predictions against the labels
+func (ma *ManagerAgent) RunClassificationBenchmark(ctx context.Context, cases []mcp.ClassificationCase) (*mcp.ClassificationReport, error) {
+	report := mcp.NewClassificationReport()
+	for i, c := range cases {
+		query := &models.Query{
+			ID:        fmt.Sprintf("bench_classify_%d", i+1),
+			UserInput: c.Query,
+		}
+		tier, agent, err := ma.PredictRoute(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("case %d (%q): %w", i+1, c.Query, err)
+		}
+		report.Record(c, tier, agent)
+	}
+	return report, nil
+}
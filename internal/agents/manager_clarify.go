@@ -0,0 +1,165 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/flags"
+	"github.com/yourusername/useq-ai-assistant/internal/mcp"
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// Query metadata keys for clarification
+const (
+	noClarifyKey = "no_clarify" // automation: never ask, always answer
+	clarifiedKey = "clarified"  // the query already answers a clarification
+)
+
+// clarificationProbeLimit is how many search hits are considered as options
+const clarificationProbeLimit = 8
+
+// declarationPattern finds the first declared symbol in a chunk
+var declarationPattern = regexp.MustCompile(`(?m)^(?:func\s+(?:\([^)]*\)\s*)?|type\s+)([A-Za-z_]\w*)`)
+
+// ClarificationConfig controls when the manager asks instead of guessing
+type ClarificationConfig struct {
+	Enabled                     bool    `json:"enabled"`
+	MinClassificationConfidence float64 `json:"min_classification_confidence"` // ask only below this
+	MinRetrievalScore           float64 `json:"min_retrieval_score"`           // and when no hit reaches this
+	MaxOptions                  int     `json:"max_options"`
+}
+
+// DefaultClarificationConfig returns clarification defaults
+func DefaultClarificationConfig() ClarificationConfig {
+	return ClarificationConfig{
+		Enabled:                     true,
+		MinClassificationConfidence: 0.5,
+		MinRetrievalScore:           0.4,
+		MaxOptions:                  3,
+	}
+}
+
+// SetClarificationConfig replaces the clarification settings
+func (ma *ManagerAgent) SetClarificationConfig(config ClarificationConfig) {
+	ma.clarification = config
+}
+
+// DisableClarification makes the manager answer the query without asking back,
+// for automation contexts where nobody can reply
+func DisableClarification(query *models.Query) {
+	if query.Metadata == nil {
+		query.Metadata = make(map[string]string)
+	}
+	query.Metadata[noClarifyKey] = "true"
+}
+
+// MarkClarified records that the query already resolves a clarification
+func MarkClarified(query *models.Query) {
+	if query.Metadata == nil {
+		query.Metadata = make(map[string]string)
+	}
+	query.Metadata[clarifiedKey] = "true"
+}
+
+// clarificationFor returns a question to ask when both the classifier and
+// retrieval are unsure about the query, or nil to answer directly. Only
+// queries below the classification threshold pay for the retrieval probe.
+func (ma *ManagerAgent) clarificationFor(ctx context.Context, query *models.Query, classification *mcp.ClassificationResult) *models.Clarification {
+	config := ma.clarification
+	if !config.Enabled || query.Metadata[noClarifyKey] == "true" || query.Metadata[clarifiedKey] == "true" {
+		return nil
+	}
+	if !flags.Enabled(ctx, flags.Clarification) {
+		return nil
+	}
+	if classification.Confidence >= config.MinClassificationConfidence {
+		return nil
+	}
+	if ma.dependencies == nil || ma.dependencies.VectorDB == nil {
+		return nil
+	}
+
+	results, err := ma.dependencies.VectorDB.Search(ctx, query.UserInput, clarificationProbeLimit)
+	if err != nil || len(results) == 0 {
+		return nil
+	}
+	if results[0].Score >= float32(config.MinRetrievalScore) {
+		return nil
+	}
+
+	// Offer the distinct candidates retrieval was torn between
+	seen := make(map[string]bool)
+	var options []models.ClarificationOption
+	for _, result := range results {
+		if result.Chunk == nil || result.Chunk.FilePath == "" {
+			continue
+		}
+		label := candidateLabel(result.Chunk.FilePath, result.Chunk.Content)
+		if seen[label] {
+			continue
+		}
+		seen[label] = true
+		options = append(options, models.ClarificationOption{
+			Label:      label,
+			Refinement: "in " + label,
+		})
+		if len(options) >= config.MaxOptions {
+			break
+		}
+	}
+	if len(options) < 2 {
+		// A single weak candidate is not a choice; answer and let the score speak
+		return nil
+	}
+
+	labels := make([]string, len(options))
+	for i, option := range options {
+		labels[i] = option.Label
+	}
+	return &models.Clarification{
+		Question:      fmt.Sprintf("Do you mean %s?", joinAlternatives(labels)),
+		Options:       options,
+		OriginalQuery: query.UserInput,
+	}
+}
+
+// clarificationResponse wraps a clarification question as a response
+func (ma *ManagerAgent) clarificationResponse(query *models.Query, clarification *models.Clarification, classification *mcp.ClassificationResult) *models.Response {
+	return &models.Response{
+		ID:      fmt.Sprintf("clarify_%d", time.Now().UnixNano()),
+		QueryID: query.ID,
+		Type:    models.ResponseTypeClarification,
+		Content: models.ResponseContent{
+			Text:          clarification.Question,
+			Clarification: clarification,
+		},
+		AgentUsed: "manager",
+		Provider:  "clarification",
+		Cost:      models.Cost{TotalCost: planEmbeddingCost, Currency: "USD"},
+		Metadata: models.ResponseMetadata{
+			Confidence: classification.Confidence,
+			Reasoning:  "Low classification confidence and weak retrieval scores; asking instead of guessing",
+		},
+		Timestamp: time.Now(),
+	}
+}
+
+// candidateLabel names a search hit by its first declared symbol and file
+func candidateLabel(path, content string) string {
+	if m := declarationPattern.FindStringSubmatch(content); m != nil {
+		return fmt.Sprintf("%s (%s)", m[1], filepath.ToSlash(path))
+	}
+	return filepath.ToSlash(path)
+}
+
+// joinAlternatives renders "a", "a or b" and "a, b or c"
+func joinAlternatives(items []string) string {
+	if len(items) <= 1 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " or " + items[len(items)-1]
+}
@@ -0,0 +1,29 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/yourusername/useq-ai-assistant/internal/llm"
+	"github.com/yourusername/useq-ai-assistant/internal/mcp"
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// AnswerWithModel answers query through the Tier 3 pipeline whatever its
+// classification, with every LLM call going to the chosen model, and
+// returns the usage of those calls. Used to compare models on one query.
+func (ma *ManagerAgent) AnswerWithModel(ctx context.Context, query *models.Query, choice llm.ModelChoice) (*models.Response, llm.ModelUsage, error) {
+	ctx = ma.dependencies.AgentContext(ctx, "manager")
+	ctx, tally := llm.WithModel(ctx, choice)
+
+	var classification *mcp.ClassificationResult
+	if ma.mcpClient != nil {
+		classification, _ = ma.mcpClient.GetQueryClassifier().ClassifyQuery(ctx, query)
+	}
+
+	response, err := ma.processTier3Query(ctx, query, classification)
+	if err == nil && response == nil {
+		err = fmt.Errorf("%s returned no answer", choice)
+	}
+	return response, tally.Usage(), err
+}
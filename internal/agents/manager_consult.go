@@ -0,0 +1,282 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/flags"
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// coverageTarget is how many distinct retrieved files count as full coverage
+const coverageTarget = 5
+
+// ConsultationConfig controls running two agents on ambiguous queries
+type ConsultationConfig struct {
+	Enabled     bool          `json:"enabled"`
+	MaxScoreGap float64       `json:"max_score_gap"` // consult when the top two routing scores are this close
+	MergeWithin float64       `json:"merge_within"`  // merge when the arbiter scores are this close
+	Deadline    time.Duration `json:"deadline"`      // shared by both agents
+	Agents      []string      `json:"agents"`        // agents that may be consulted together
+}
+
+// DefaultConsultationConfig returns consultation defaults. Only the retrieval
+// agents are consulted together; running two code generators doubles cost.
+func DefaultConsultationConfig() ConsultationConfig {
+	return ConsultationConfig{
+		Enabled:     true,
+		MaxScoreGap: 0.1,
+		MergeWithin: 0.05,
+		Deadline:    20 * time.Second,
+		Agents:      []string{"search", "context_search"},
+	}
+}
+
+// SetConsultationConfig replaces the consultation settings
+func (ma *ManagerAgent) SetConsultationConfig(config ConsultationConfig) {
+	ma.consultation = config
+}
+
+// consultationCandidates returns the two best-scoring agents when routing is
+// ambiguous between them and both may be consulted
+func (ma *ManagerAgent) consultationCandidates(ctx context.Context, scores map[string]float64) ([2]AgentScoring, bool) {
+	var candidates [2]AgentScoring
+	config := ma.consultation
+	if !config.Enabled || !flags.Enabled(ctx, flags.Consultation) {
+		return candidates, false
+	}
+
+	ranked := rankAgentScores(scores)
+	if len(ranked) < 2 || ranked[0].Score <= 0 || ranked[0].Score-ranked[1].Score > config.MaxScoreGap {
+		return candidates, false
+	}
+	for i := range candidates {
+		if !containsString(config.Agents, ranked[i].AgentName) {
+			return candidates, false
+		}
+		candidates[i] = ranked[i]
+	}
+	return candidates, true
+}
+
+// consultAgents runs both candidates concurrently under a shared deadline and
+// lets the arbiter pick or merge their responses. An agent that misses the
+// deadline loses by default; the call fails only when neither answers.
+func (ma *ManagerAgent) consultAgents(ctx context.Context, query *models.Query, candidates [2]AgentScoring) (*models.Response, *AgentComparison, error) {
+	ctx, cancel := context.WithTimeout(ctx, ma.consultation.Deadline)
+	defer cancel()
+
+	type outcome struct {
+		index    int
+		response *models.Response
+		err      error
+		duration time.Duration
+	}
+	outcomes := make(chan outcome, len(candidates))
+	for i, candidate := range candidates {
+		// Each agent gets its own copy so neither sees the other's edits
+		agentQuery := *query
+		agentQuery.Metadata = make(map[string]string, len(query.Metadata))
+		for key, value := range query.Metadata {
+			agentQuery.Metadata[key] = value
+		}
+		go func(index int, agentName string, agentQuery *models.Query) {
+			start := time.Now()
+			response, err := ma.executeWithSelectedAgent(ctx, agentQuery, agentName)
+			outcomes <- outcome{index: index, response: response, err: err, duration: time.Since(start)}
+		}(i, candidate.AgentName, &agentQuery)
+	}
+
+	comparison := &AgentComparison{Candidates: make([]ConsultedAgent, len(candidates))}
+	responses := make([]*models.Response, len(candidates))
+	for i, candidate := range candidates {
+		comparison.Candidates[i] = ConsultedAgent{Agent: candidate.AgentName, RoutingScore: candidate.Score, Error: "deadline exceeded"}
+	}
+
+	for received := 0; received < len(candidates); received++ {
+		select {
+		case result := <-outcomes:
+			consulted := &comparison.Candidates[result.index]
+			consulted.Duration = result.duration
+			consulted.Error = ""
+			if result.err != nil {
+				consulted.Error = result.err.Error()
+			} else if result.response == nil {
+				consulted.Error = "empty response"
+			} else {
+				responses[result.index] = result.response
+				consulted.Confidence = result.response.Metadata.Confidence
+				consulted.Coverage = retrievalCoverage(result.response)
+				consulted.Score = arbiterScore(result.response, consulted.Coverage)
+			}
+		case <-ctx.Done():
+			received = len(candidates)
+		}
+	}
+
+	response, err := ma.arbitrate(comparison, responses)
+	if ma.dependencies != nil && ma.dependencies.Logger != nil {
+		ma.dependencies.Logger.Info("Agent consultation", map[string]interface{}{
+			"candidates": comparison.Candidates,
+			"winner":     comparison.Winner,
+			"merged":     comparison.Merged,
+			"reason":     comparison.Reason,
+		})
+	}
+	return response, comparison, err
+}
+
+// arbitrate picks the better response, or merges both when they score within
+// MergeWithin of each other. The losing agent's tokens and cost are added to
+// the result because they were spent either way.
+func (ma *ManagerAgent) arbitrate(comparison *AgentComparison, responses []*models.Response) (*models.Response, error) {
+	winner, loser := -1, -1
+	for i, response := range responses {
+		if response == nil {
+			continue
+		}
+		if winner < 0 || comparison.Candidates[i].Score > comparison.Candidates[winner].Score {
+			loser, winner = winner, i
+		} else {
+			loser = i
+		}
+	}
+	if winner < 0 {
+		var errs []string
+		for _, candidate := range comparison.Candidates {
+			errs = append(errs, fmt.Sprintf("%s: %s", candidate.Agent, candidate.Error))
+		}
+		return nil, fmt.Errorf("all consulted agents failed: %s", strings.Join(errs, "; "))
+	}
+
+	comparison.Winner = comparison.Candidates[winner].Agent
+	response := responses[winner]
+	if loser < 0 {
+		comparison.Reason = "only " + comparison.Winner + " answered"
+		return response, nil
+	}
+
+	gap := comparison.Candidates[winner].Score - comparison.Candidates[loser].Score
+	addSpend(response, responses[loser])
+	if gap <= ma.consultation.MergeWithin {
+		mergeResponses(response, responses[loser], comparison.Candidates[loser].Agent)
+		response.AgentUsed = comparison.Winner + "+" + comparison.Candidates[loser].Agent
+		comparison.Merged = true
+		comparison.Reason = fmt.Sprintf("scores within %.2f, merged", gap)
+	} else {
+		comparison.Reason = fmt.Sprintf("%s scored %.2f higher", comparison.Winner, gap)
+	}
+	return response, nil
+}
+
+// retrievalCoverage estimates how much of the index a response drew on, from
+// 0 to 1, by the distinct files it cites
+func retrievalCoverage(response *models.Response) float64 {
+	files := make(map[string]bool)
+	for _, source := range response.Metadata.Sources {
+		files[source] = true
+	}
+	if response.Content.Search != nil {
+		for _, result := range response.Content.Search.Results {
+			files[result.File] = true
+		}
+	}
+	for _, reference := range response.Content.References {
+		if reference.File != "" {
+			files[reference.File] = true
+		}
+	}
+	count := len(files)
+	if count == 0 {
+		count = response.Metadata.FilesAnalyzed
+	}
+	return math.Min(float64(count)/coverageTarget, 1)
+}
+
+// arbiterScore weighs an agent's own confidence against its retrieval
+// coverage; a response with neither text nor results scores zero
+func arbiterScore(response *models.Response, coverage float64) float64 {
+	if strings.TrimSpace(response.Content.Text) == "" && response.Content.Search == nil {
+		return 0
+	}
+	return 0.6*response.Metadata.Confidence + 0.4*coverage
+}
+
+// mergeResponses folds the other response's results and sources into the
+// winner, skipping anything the winner already has
+func mergeResponses(winner, other *models.Response, otherAgent string) {
+	if other.Content.Search != nil {
+		if winner.Content.Search == nil {
+			winner.Content.Search = &models.SearchResponse{Query: other.Content.Search.Query}
+		}
+		seen := make(map[string]bool)
+		for _, result := range winner.Content.Search.Results {
+			seen[fmt.Sprintf("%s:%d", result.File, result.Line)] = true
+		}
+		for _, result := range other.Content.Search.Results {
+			key := fmt.Sprintf("%s:%d", result.File, result.Line)
+			if !seen[key] {
+				seen[key] = true
+				winner.Content.Search.Results = append(winner.Content.Search.Results, result)
+			}
+		}
+		winner.Content.Search.Total = len(winner.Content.Search.Results)
+	}
+
+	if !strings.Contains(winner.Content.Text, strings.TrimSpace(other.Content.Text)) {
+		winner.Content.Text = strings.TrimRight(winner.Content.Text, "\n") +
+			fmt.Sprintf("\n\n🔀 Also from %s:\n%s", otherAgent, other.Content.Text)
+	}
+	winner.Content.References = append(winner.Content.References, other.Content.References...)
+	winner.Metadata.Sources = appendMissing(winner.Metadata.Sources, other.Metadata.Sources)
+	winner.Metadata.Tools = appendMissing(winner.Metadata.Tools, other.Metadata.Tools)
+	winner.Metadata.IndexHits += other.Metadata.IndexHits
+	winner.Metadata.Confidence = math.Max(winner.Metadata.Confidence, other.Metadata.Confidence)
+}
+
+// addSpend adds the other response's tokens and cost to the winner
+func addSpend(winner, other *models.Response) {
+	winner.TokenUsage.InputTokens += other.TokenUsage.InputTokens
+	winner.TokenUsage.OutputTokens += other.TokenUsage.OutputTokens
+	winner.TokenUsage.TotalTokens += other.TokenUsage.TotalTokens
+	winner.Cost.InputCost += other.Cost.InputCost
+	winner.Cost.OutputCost += other.Cost.OutputCost
+	winner.Cost.TotalCost += other.Cost.TotalCost
+}
+
+// rankAgentScores orders agents by routing score, best first
+func rankAgentScores(scores map[string]float64) []AgentScoring {
+	ranked := make([]AgentScoring, 0, len(scores))
+	for agent, score := range scores {
+		ranked = append(ranked, AgentScoring{AgentName: agent, Score: score})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].AgentName < ranked[j].AgentName
+	})
+	return ranked
+}
+
+func appendMissing(values, extra []string) []string {
+	for _, value := range extra {
+		if !containsString(values, value) {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
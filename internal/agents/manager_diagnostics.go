@@ -0,0 +1,64 @@
+package agents
+
+import (
+	"context"
+	"os"
+
+	"github.com/yourusername/useq-ai-assistant/internal/diagnostics"
+	"github.com/yourusername/useq-ai-assistant/internal/llm"
+	"github.com/yourusername/useq-ai-assistant/internal/promptguard"
+	"github.com/yourusername/useq-ai-assistant/models"
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// SetAnalyzerDiagnosticsConfig replaces the analyzer diagnostics settings
+func (ma *ManagerAgent) SetAnalyzerDiagnosticsConfig(config diagnostics.Config) {
+	ma.analyzerDiagnostics = config
+}
+
+// withAnalyzerDiagnostics attaches the ingested gopls and golangci-lint
+// diagnostics the query refers to, through the files it mentions or pins,
+// the rules it names, or by asking about analyzer findings, so answers
+// cite what the tools reported instead of re-deriving it
+func (ma *ManagerAgent) withAnalyzerDiagnostics(ctx context.Context, query *models.Query) context.Context {
+	if !ma.analyzerDiagnostics.Enabled || ma.analyzerDiagnostics.MaxEntries <= 0 || ma.dependencies == nil || ma.dependencies.Storage == nil {
+		return ctx
+	}
+
+	all, err := ma.dependencies.Storage.ListAnalyzerDiagnostics(storage.AnalyzerDiagnosticFilter{})
+	if err != nil {
+		if ma.dependencies.Logger != nil {
+			ma.dependencies.Logger.Warn("Failed to load analyzer diagnostics", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+		return ctx
+	}
+	if len(all) == 0 {
+		return ctx
+	}
+
+	root := query.ProjectRoot
+	if root == "" {
+		root, _ = os.Getwd()
+	}
+	var files []string
+	for _, mention := range DetectPathMentions(query.UserInput, root) {
+		files = append(files, mention.Path)
+	}
+	for _, pin := range query.Context.Pinned {
+		files = append(files, pin.Path)
+	}
+	relevant := diagnostics.Relevant(all, query.UserInput, files, ma.analyzerDiagnostics.MaxEntries)
+	if len(relevant) == 0 {
+		return ctx
+	}
+
+	if ma.dependencies.Logger != nil {
+		ma.dependencies.Logger.Info("Including analyzer diagnostics", map[string]interface{}{
+			"included":  len(relevant),
+			"available": len(all),
+		})
+	}
+	return llm.WithAnalyzerDiagnostics(ctx, promptguard.Wrap("analyzer diagnostics", diagnostics.Format(relevant)))
+}
@@ -0,0 +1,158 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/mcp"
+	"github.com/yourusername/useq-ai-assistant/models"
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// Calibration factors outside these bounds come from too few or unusual
+// queries to trust
+const (
+	minCostCalibration = 0.2
+	maxCostCalibration = 5.0
+)
+
+// CostEstimateConfig controls the cost estimate shown before a Tier 3 query
+// runs
+type CostEstimateConfig struct {
+	Enabled      bool    `json:"enabled"`
+	ConfirmAbove float64 `json:"confirm_above"` // USD; costlier queries are confirmed first, 0 never asks
+	Window       int     `json:"window"`        // recent queries the estimator is calibrated on
+	MinSamples   int     `json:"min_samples"`   // queries measured before calibration applies
+}
+
+// DefaultCostEstimateConfig returns cost estimate defaults
+func DefaultCostEstimateConfig() CostEstimateConfig {
+	return CostEstimateConfig{
+		Enabled:      true,
+		ConfirmAbove: 0.10,
+		Window:       50,
+		MinSamples:   5,
+	}
+}
+
+// SetCostEstimateConfig replaces the cost estimate settings
+func (ma *ManagerAgent) SetCostEstimateConfig(config CostEstimateConfig) {
+	ma.costEstimates = config
+}
+
+// CostConfirmer is shown the estimate of a Tier 3 query before it runs.
+// When confirm is set the estimate is above the threshold and the
+// confirmer reports whether to run the query anyway.
+type CostConfirmer func(ctx context.Context, estimate *models.CostEstimate, confirm bool) bool
+
+// SetCostConfirmer sets how estimates are shown and expensive queries
+// confirmed. Until one is set, as in server mode, queries run unasked.
+func (ma *ManagerAgent) SetCostConfirmer(confirm CostConfirmer) {
+	ma.costConfirmer = confirm
+}
+
+// estimateTier3Cost estimates a Tier 3 query from the files it would put in
+// context, pins included, calibrated by how recent estimates for the same
+// provider compared with actual usage
+func (ma *ManagerAgent) estimateTier3Cost(query *models.Query) *models.CostEstimate {
+	if !ma.costEstimates.Enabled {
+		return nil
+	}
+	files := ma.planContextFiles(query)
+	for _, pin := range query.Context.Pinned {
+		files = appendMissing(files, []string{pin.Path})
+	}
+	estimate := ma.estimateLLMUsage(query, files)
+
+	if ma.dependencies == nil || ma.dependencies.Storage == nil {
+		return estimate
+	}
+	accuracy, err := ma.dependencies.Storage.CostEstimateAccuracy(estimate.Provider, ma.costEstimates.Window)
+	if err != nil || accuracy.Samples < max(ma.costEstimates.MinSamples, 1) {
+		return estimate
+	}
+	estimate.Samples = accuracy.Samples
+	estimate.Calibration = clampCalibration(accuracy.TokenRatio)
+	estimate.InputTokens = int(math.Round(float64(estimate.InputTokens) * estimate.Calibration))
+	estimate.OutputTokens = int(math.Round(float64(estimate.OutputTokens) * estimate.Calibration))
+	estimate.Cost *= clampCalibration(accuracy.CostRatio)
+	return estimate
+}
+
+func clampCalibration(ratio float64) float64 {
+	return math.Min(math.Max(ratio, minCostCalibration), maxCostCalibration)
+}
+
+// approveCost shows the estimate and reports whether the query may run
+func (ma *ManagerAgent) approveCost(ctx context.Context, estimate *models.CostEstimate) bool {
+	if ma.costConfirmer == nil {
+		return true
+	}
+	confirm := ma.costEstimates.ConfirmAbove > 0 && estimate.Cost > ma.costEstimates.ConfirmAbove
+	return ma.costConfirmer(ctx, estimate, confirm) || !confirm
+}
+
+// recordCostEstimate stores the estimate with the usage the answer reported,
+// for later estimates to calibrate on. Answers that report no tokens say
+// nothing about the estimate and are skipped.
+func (ma *ManagerAgent) recordCostEstimate(query *models.Query, estimate *models.CostEstimate, response *models.Response) {
+	if estimate == nil || response == nil {
+		return
+	}
+	response.Metadata.CostEstimate = estimate
+	if response.TokenUsage.TotalTokens == 0 || ma.dependencies == nil || ma.dependencies.Storage == nil {
+		return
+	}
+	err := ma.dependencies.Storage.RecordCostEstimate(&storage.CostEstimateRecord{
+		QueryID:         query.ID,
+		Provider:        estimate.Provider,
+		HeuristicTokens: estimate.HeuristicTokens,
+		HeuristicCost:   estimate.HeuristicCost,
+		EstimatedTokens: estimate.TotalTokens(),
+		EstimatedCost:   estimate.Cost,
+		ActualTokens:    response.TokenUsage.TotalTokens,
+		ActualCost:      response.Cost.TotalCost,
+	})
+	if err != nil && ma.dependencies.Logger != nil {
+		ma.dependencies.Logger.Warn("Failed to record cost estimate", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+}
+
+// declinedCostResponse answers a Tier 3 query the user chose not to run
+// with its estimate
+func (ma *ManagerAgent) declinedCostResponse(query *models.Query, estimate *models.CostEstimate) *models.Response {
+	plan := &models.ExecutionPlan{
+		Query:                 query.UserInput,
+		Tier:                  string(mcp.TierComplex),
+		Provider:              estimate.Provider,
+		EstimatedInputTokens:  estimate.InputTokens,
+		EstimatedOutputTokens: estimate.OutputTokens,
+		EstimatedCost:         estimate.Cost,
+		Notes: []string{fmt.Sprintf("Not run: the estimate is above cost_estimate.confirm_above ($%.4f)",
+			ma.costEstimates.ConfirmAbove)},
+	}
+	return &models.Response{
+		ID:      fmt.Sprintf("plan_%d", time.Now().UnixNano()),
+		QueryID: query.ID,
+		Type:    models.ResponseTypePlan,
+		Content: models.ResponseContent{
+			Text: fmt.Sprintf("Query not run: estimated at ~%d tokens, ~$%.4f. Narrow it or confirm to run it.",
+				estimate.TotalTokens(), estimate.Cost),
+			Plan: plan,
+		},
+		AgentUsed:  "manager_plan",
+		Provider:   "none",
+		TokenUsage: models.TokenUsage{},
+		Cost:       models.Cost{TotalCost: 0, Currency: "USD"},
+		Metadata: models.ResponseMetadata{
+			Tools:        []string{"cost_estimate"},
+			Reasoning:    "The user declined the estimated cost",
+			CostEstimate: estimate,
+		},
+		Timestamp: time.Now(),
+	}
+}
@@ -0,0 +1,255 @@
+package agents
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/internal/language"
+	"github.com/yourusername/useq-ai-assistant/models"
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// ExcerptConfig controls the code excerpts attached to Tier 3 explanations
+type ExcerptConfig struct {
+	Enabled     bool `json:"enabled"`
+	MaxExcerpts int  `json:"max_excerpts"` // functions excerpted per answer
+	MaxLines    int  `json:"max_lines"`    // lines per excerpt; longer functions are cut
+	FoldAfter   int  `json:"fold_after"`   // excerpts longer than this start folded; 0 folds none
+}
+
+// DefaultExcerptConfig returns excerpt defaults
+func DefaultExcerptConfig() ExcerptConfig {
+	return ExcerptConfig{
+		Enabled:     true,
+		MaxExcerpts: 8,
+		MaxLines:    40,
+		FoldAfter:   12,
+	}
+}
+
+// SetExcerptConfig replaces the excerpt settings
+func (ma *ManagerAgent) SetExcerptConfig(config ExcerptConfig) {
+	ma.excerpts = config
+}
+
+// functionReferencePattern matches a function named in an explanation:
+// quoted in backticks (`Save`, `Store.Save()`) or called (Save(, store.Save()
+var functionReferencePattern = regexp.MustCompile("`([A-Za-z_][A-Za-z0-9_]*(?:\\.[A-Za-z_][A-Za-z0-9_]*)?)(?:\\(\\))?`|\\b([A-Za-z_][A-Za-z0-9_]*(?:\\.[A-Za-z_][A-Za-z0-9_]*)?)\\(")
+
+// referencedFunctions returns the function names an explanation refers to,
+// in order of first mention
+func referencedFunctions(text string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, match := range functionReferencePattern.FindAllStringSubmatch(text, -1) {
+		name := match[1]
+		if name == "" {
+			name = match[2]
+		}
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// attachExcerpts adds the code of every indexed function an explanation
+// refers to, so the reader can check the explanation against it. Names that
+// are not indexed functions, such as standard library calls, are skipped,
+// as are names defined more than once unless the qualifier or the answer's
+// sources pick one definition.
+func (ma *ManagerAgent) attachExcerpts(query *models.Query, response *models.Response) {
+	config := ma.excerpts
+	if !config.Enabled || response == nil || response.Type != models.ResponseTypeExplanation ||
+		ma.dependencies == nil || ma.dependencies.Storage == nil {
+		return
+	}
+
+	sources := make(map[string]bool, len(response.Metadata.Sources))
+	for _, source := range response.Metadata.Sources {
+		sources[filepath.Clean(source)] = true
+	}
+	root := query.ProjectRoot
+	if root == "" {
+		root, _ = os.Getwd()
+	}
+
+	seen := make(map[string]bool)
+	for _, name := range referencedFunctions(response.Content.Text) {
+		if len(response.Content.Excerpts) >= config.MaxExcerpts {
+			break
+		}
+		definition := ma.resolveFunction(name, sources, root)
+		if definition == nil {
+			continue
+		}
+		key := fmt.Sprintf("%s:%d", definition.File, definition.StartLine)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		excerpt := ma.indexedExcerpt(name, definition, root, config)
+		if excerpt == nil {
+			var err error
+			if excerpt, err = readExcerpt(name, definition, root, config); err != nil {
+				continue
+			}
+		}
+		response.Content.Excerpts = append(response.Content.Excerpts, *excerpt)
+	}
+}
+
+// resolveFunction finds the one indexed function a name refers to. A
+// qualified name (Store.Save, store.Save) prefers the definition in a file
+// or directory named after the qualifier.
+func (ma *ManagerAgent) resolveFunction(name string, sources map[string]bool, root string) *storage.SymbolLocation {
+	qualifier, base := "", name
+	if dot := strings.LastIndex(name, "."); dot >= 0 {
+		qualifier, base = strings.ToLower(name[:dot]), name[dot+1:]
+	}
+	definitions, err := ma.dependencies.Storage.LookupSymbol(base)
+	if err != nil {
+		return nil
+	}
+
+	var functions []*storage.SymbolLocation
+	for _, definition := range definitions {
+		if isFunctionKind(definition.Kind) {
+			functions = append(functions, definition)
+		}
+	}
+	if len(functions) == 1 {
+		return functions[0]
+	}
+
+	var picked []*storage.SymbolLocation
+	for _, definition := range functions {
+		file := strings.ToLower(definition.File)
+		if qualifier != "" && (strings.Contains(filepath.Base(file), qualifier) || filepath.Base(filepath.Dir(file)) == qualifier) {
+			picked = append(picked, definition)
+		}
+	}
+	if len(picked) == 0 {
+		for _, definition := range functions {
+			if sources[filepath.Clean(definition.File)] || sources[relativePath(root, definition.File)] {
+				picked = append(picked, definition)
+			}
+		}
+	}
+	if len(picked) == 1 {
+		return picked[0]
+	}
+	return nil
+}
+
+// isFunctionKind reports whether an indexed symbol kind is a function
+// rather than a type
+func isFunctionKind(kind string) bool {
+	switch kind {
+	case "struct", "interface", "alias", "type", "class", "enum":
+		return false
+	}
+	return true
+}
+
+// indexedExcerpt reads a function's lines from the index, which its line
+// numbers were recorded against. Returns nil when the file is not indexed.
+func (ma *ManagerAgent) indexedExcerpt(name string, definition *storage.SymbolLocation, root string, config ExcerptConfig) *models.CodeExcerpt {
+	lines, err := ma.dependencies.Storage.GetLineRange(definition.File, definition.StartLine, definition.EndLine,
+		storage.LineRangeOptions{MaxLines: config.MaxLines})
+	if err != nil || lines == nil {
+		return nil
+	}
+	excerpt := &models.CodeExcerpt{
+		Symbol:    name,
+		Kind:      definition.Kind,
+		File:      relativePath(root, definition.File),
+		Language:  language.FromExtension(definition.File),
+		StartLine: lines.Start,
+		Lines:     lines.Range(),
+		Truncated: lines.Truncated,
+	}
+	for len(excerpt.Lines) > 0 && strings.TrimSpace(excerpt.Lines[len(excerpt.Lines)-1]) == "" {
+		excerpt.Lines = excerpt.Lines[:len(excerpt.Lines)-1]
+	}
+	if len(excerpt.Lines) == 0 {
+		return nil
+	}
+	excerpt.EndLine = excerpt.StartLine + len(excerpt.Lines) - 1
+	excerpt.Folded = config.FoldAfter > 0 && len(excerpt.Lines) > config.FoldAfter
+	return excerpt
+}
+
+// readExcerpt reads a function's lines from disk, cut at MaxLines and with
+// trailing blank lines dropped
+func readExcerpt(name string, definition *storage.SymbolLocation, root string, config ExcerptConfig) (*models.CodeExcerpt, error) {
+	path := definition.File
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	handle, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer handle.Close()
+
+	excerpt := &models.CodeExcerpt{
+		Symbol:    name,
+		Kind:      definition.Kind,
+		File:      relativePath(root, definition.File),
+		Language:  language.FromExtension(definition.File),
+		StartLine: max(definition.StartLine, 1),
+	}
+	end := definition.EndLine
+	if end < excerpt.StartLine {
+		end = excerpt.StartLine
+	}
+
+	line := 0
+	scanner := bufio.NewScanner(handle)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line++
+		if line < excerpt.StartLine {
+			continue
+		}
+		if line > end {
+			break
+		}
+		if config.MaxLines > 0 && len(excerpt.Lines) == config.MaxLines {
+			excerpt.Truncated = true
+			break
+		}
+		excerpt.Lines = append(excerpt.Lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	for len(excerpt.Lines) > 0 && strings.TrimSpace(excerpt.Lines[len(excerpt.Lines)-1]) == "" {
+		excerpt.Lines = excerpt.Lines[:len(excerpt.Lines)-1]
+	}
+	if len(excerpt.Lines) == 0 {
+		return nil, os.ErrNotExist
+	}
+
+	excerpt.EndLine = excerpt.StartLine + len(excerpt.Lines) - 1
+	excerpt.Folded = config.FoldAfter > 0 && len(excerpt.Lines) > config.FoldAfter
+	return excerpt, nil
+}
+
+// relativePath shows path relative to root when it lies inside it
+func relativePath(root, path string) string {
+	if root == "" || !filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
@@ -0,0 +1,48 @@
+package agents
+
+import (
+	"context"
+
+	"github.com/yourusername/useq-ai-assistant/internal/glossary"
+	"github.com/yourusername/useq-ai-assistant/internal/llm"
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// SetGlossaryConfig replaces the glossary settings
+func (ma *ManagerAgent) SetGlossaryConfig(config glossary.Config) {
+	ma.glossary = config
+}
+
+// withGlossary attaches the glossary terms the query refers to, by name or
+// by a synonym the project avoids, so every LLM call made with ctx uses the
+// project's vocabulary
+func (ma *ManagerAgent) withGlossary(ctx context.Context, query *models.Query) context.Context {
+	if !ma.glossary.Enabled || ma.glossary.MaxEntries <= 0 || ma.dependencies == nil || ma.dependencies.Storage == nil {
+		return ctx
+	}
+
+	entries, err := ma.dependencies.Storage.ListGlossary()
+	if err != nil {
+		if ma.dependencies.Logger != nil {
+			ma.dependencies.Logger.Warn("Failed to load glossary", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+		return ctx
+	}
+	relevant := glossary.Relevant(entries, query.UserInput, ma.glossary.MaxEntries)
+	if len(relevant) == 0 {
+		return ctx
+	}
+
+	if ma.dependencies.Logger != nil {
+		terms := make([]string, len(relevant))
+		for i, entry := range relevant {
+			terms[i] = entry.Term
+		}
+		ma.dependencies.Logger.Info("Including glossary terms", map[string]interface{}{
+			"terms": terms,
+		})
+	}
+	return llm.WithGlossary(ctx, glossary.Format(relevant))
+}
@@ -0,0 +1,167 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/mcp"
+	"github.com/yourusername/useq-ai-assistant/internal/vectordb"
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// retrievalModeKey is the query metadata carrying the classifier's retrieval
+// mode to the agents that search
+const retrievalModeKey = "retrieval_mode"
+
+// SummarySearchConfig controls answering questions about whole files and
+// packages from their embedded summaries
+type SummarySearchConfig struct {
+	Enabled    bool    `json:"enabled"`
+	Collection string  `json:"collection"`  // secondary collection holding the summaries
+	MaxResults int     `json:"max_results"` // files or packages listed per answer
+	MinScore   float32 `json:"min_score"`   // summaries scoring lower are left out
+}
+
+// DefaultSummarySearchConfig returns summary search defaults
+func DefaultSummarySearchConfig() SummarySearchConfig {
+	return SummarySearchConfig{
+		Enabled:    true,
+		Collection: "code_summaries",
+		MaxResults: 5,
+		MinScore:   0.3,
+	}
+}
+
+// SetSummarySearchConfig replaces the summary search settings
+func (ma *ManagerAgent) SetSummarySearchConfig(config SummarySearchConfig) {
+	ma.summarySearch = config
+	if ma.SearchAgent != nil {
+		ma.SearchAgent.summarySearch = config
+	}
+}
+
+// RetrievalModeOf returns the granularity a query was classified at
+func RetrievalModeOf(query *models.Query) models.RetrievalMode {
+	if mode := models.RetrievalMode(query.Metadata[retrievalModeKey]); mode != "" {
+		return mode
+	}
+	return models.RetrievalChunk
+}
+
+// summaryOrigin is the origin of the summary points a mode retrieves
+func summaryOrigin(mode models.RetrievalMode) string {
+	if mode == models.RetrievalPackage {
+		return vectordb.OriginPackageSummary
+	}
+	return vectordb.OriginFileSummary
+}
+
+// answerAtGranularity handles a query the classifier found to ask about
+// whole files or packages. The query is marked so the agents that search
+// retrieve summaries too; Tier 1 and 2 queries are answered from the
+// summaries directly. It returns nil to let the usual tiers answer.
+func (ma *ManagerAgent) answerAtGranularity(ctx context.Context, query *models.Query, classification *mcp.ClassificationResult) (*models.Response, error) {
+	mode := classification.RetrievalMode
+	if mode == "" || mode == models.RetrievalChunk || !ma.summarySearch.Enabled {
+		return nil, nil
+	}
+	if ma.dependencies == nil || ma.dependencies.SummaryVectorDB == nil {
+		return nil, nil
+	}
+	if query.Metadata == nil {
+		query.Metadata = make(map[string]string)
+	}
+	query.Metadata[retrievalModeKey] = string(mode)
+	if classification.Tier == mcp.TierComplex {
+		return nil, nil
+	}
+
+	startTime := time.Now()
+	hits, err := ma.dependencies.SummaryVectorDB.SearchWithFilter(ctx, query.UserInput, ma.summarySearch.MaxResults,
+		map[string]string{"origin": summaryOrigin(mode)})
+	if err != nil {
+		return nil, err
+	}
+	results, sources := summaryResults(hits, ma.summarySearch.MinScore)
+	if len(results) == 0 {
+		return nil, nil
+	}
+	elapsed := time.Since(startTime)
+	if ma.dependencies.Logger != nil {
+		ma.dependencies.Logger.Info("Answered from summaries", map[string]interface{}{
+			"mode":        mode,
+			"results":     len(results),
+			"duration_ms": elapsed.Milliseconds(),
+		})
+	}
+
+	return &models.Response{
+		ID:      fmt.Sprintf("summary_%d", time.Now().UnixNano()),
+		QueryID: query.ID,
+		Type:    models.ResponseTypeSearch,
+		Content: models.ResponseContent{
+			Text: summaryResultsText(mode, results),
+			Search: &models.SearchResponse{
+				Query:     query.UserInput,
+				Results:   results,
+				Total:     len(results),
+				TimeTaken: elapsed,
+			},
+		},
+		AgentUsed:  "index",
+		Provider:   "summary_search",
+		TokenUsage: models.TokenUsage{TotalTokens: 0},
+		Cost:       models.Cost{TotalCost: 0.0005, Currency: "USD"}, // the query embedding
+		Metadata: models.ResponseMetadata{
+			GenerationTime: elapsed,
+			FilesAnalyzed:  len(sources),
+			Confidence:     float64(results[0].Score),
+			Sources:        sources,
+			Tools:          []string{"summary_search", "openai_embeddings"},
+			Reasoning:      fmt.Sprintf("Retrieval mode %s: the query asks which %s is responsible, so whole-%s summaries were searched instead of code chunks", mode, mode, mode),
+		},
+		Timestamp: time.Now(),
+	}, nil
+}
+
+// summaryResults converts summary hits scoring at least minScore into
+// search results, one per file or package
+func summaryResults(hits []*vectordb.SearchResult, minScore float32) ([]models.SearchResult, []string) {
+	var (
+		results []models.SearchResult
+		sources []string
+	)
+	for _, hit := range hits {
+		if hit.Score < minScore || hit.Chunk == nil {
+			continue
+		}
+		results = append(results, models.SearchResult{
+			File:        hit.Chunk.FilePath,
+			Line:        hit.Chunk.StartLine,
+			Score:       float64(hit.Score),
+			Context:     hit.Chunk.Content,
+			Explanation: strings.ReplaceAll(hit.Chunk.Origin, "_", " "),
+			Origin:      hit.Chunk.Origin,
+			Tags:        hit.Chunk.Tags,
+			Ranking:     &models.RankingFactors{Strategy: hit.Chunk.Origin, Similarity: float64(hit.Score)},
+		})
+		sources = append(sources, hit.Chunk.FilePath)
+	}
+	return results, sources
+}
+
+// summaryResultsText lists the files or packages found, best match first
+func summaryResultsText(mode models.RetrievalMode, results []models.SearchResult) string {
+	var b strings.Builder
+	if mode == models.RetrievalPackage {
+		b.WriteString("Packages most likely responsible:\n")
+	} else {
+		b.WriteString("Files most likely responsible:\n")
+	}
+	for i, result := range results {
+		fmt.Fprintf(&b, "%d. %s (%.2f)\n", i+1, result.File, result.Score)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
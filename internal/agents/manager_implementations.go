@@ -0,0 +1,151 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/implements"
+	"github.com/yourusername/useq-ai-assistant/models"
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// SetImplementsConfig replaces the interface-implementation settings
+func (ma *ManagerAgent) SetImplementsConfig(config implements.Config) {
+	ma.implements = config
+}
+
+// answerImplementations answers "what implements X" and "what interfaces
+// does X implement" from the interface-implementation edges recorded while
+// indexing, at Tier 2 cost with no embedding or LLM call. It returns nil
+// when the query is not such a question or the index knows no type by that
+// name, so the query falls through to the usual tiers.
+func (ma *ManagerAgent) answerImplementations(ctx context.Context, query *models.Query) (*models.Response, error) {
+	if !ma.implements.Enabled || ma.dependencies == nil || ma.dependencies.Storage == nil {
+		return nil, nil
+	}
+	question := implements.ParseQuery(query.UserInput)
+	if question == nil {
+		return nil, nil
+	}
+
+	startTime := time.Now()
+	var text string
+	var sources []string
+	switch question.Direction {
+	case implements.ImplementersOf:
+		interfaces, err := ma.dependencies.Storage.FindInterfaces(question.Name)
+		if err != nil {
+			return nil, err
+		}
+		if len(interfaces) == 0 {
+			return nil, nil
+		}
+		text, sources = formatImplementers(interfaces, ma.implements.MaxResults)
+	case implements.InterfacesOf:
+		impls, err := ma.dependencies.Storage.InterfacesOf(question.Name)
+		if err != nil {
+			return nil, err
+		}
+		if len(impls) == 0 {
+			return nil, nil
+		}
+		text, sources = formatInterfacesOf(question.Name, impls)
+	}
+
+	if ma.dependencies.Logger != nil {
+		ma.dependencies.Logger.Info("Answered from the implementation index", map[string]interface{}{
+			"direction": question.Direction,
+			"name":      question.Name,
+			"sources":   len(sources),
+		})
+	}
+	return &models.Response{
+		ID:      fmt.Sprintf("implementations_%d", time.Now().UnixNano()),
+		QueryID: query.ID,
+		Type:    models.ResponseTypeSearch,
+		Content: models.ResponseContent{
+			Text: text,
+		},
+		AgentUsed:  "index",
+		Provider:   "implementation_index",
+		TokenUsage: models.TokenUsage{TotalTokens: 0},
+		Cost:       models.Cost{TotalCost: 0, Currency: "USD"},
+		Metadata: models.ResponseMetadata{
+			GenerationTime: time.Since(startTime),
+			FilesAnalyzed:  len(sources),
+			Confidence:     0.95,
+			Sources:        sources,
+			Tools:          []string{"implementation_index"},
+			Reasoning:      "Tier 2: method sets compared by the type checker while indexing",
+		},
+		Timestamp: time.Now(),
+	}, nil
+}
+
+// formatImplementers lists the types implementing each matching interface,
+// up to maxResults per interface
+func formatImplementers(interfaces []*storage.InterfaceRecord, maxResults int) (string, []string) {
+	var b strings.Builder
+	var sources []string
+	for _, iface := range interfaces {
+		location := "builtin"
+		if iface.File != "" {
+			location = fmt.Sprintf("%s:%d", iface.File, iface.Line)
+			sources = append(sources, location)
+		}
+		fmt.Fprintf(&b, "**%s** (%s) — methods: %s\n", iface.QualifiedName(), location, strings.Join(iface.Methods, ", "))
+		if len(iface.Implementations) == 0 {
+			b.WriteString("No type in the project implements it.\n\n")
+			continue
+		}
+		fmt.Fprintf(&b, "Implemented by %d types:\n", len(iface.Implementations))
+		for i, impl := range iface.Implementations {
+			if maxResults > 0 && i == maxResults {
+				fmt.Fprintf(&b, "- ... %d more\n", len(iface.Implementations)-maxResults)
+				break
+			}
+			fmt.Fprintf(&b, "- `%s` (%s:%d)\n", impl.QualifiedName(), impl.File, impl.Line)
+			sources = append(sources, fmt.Sprintf("%s:%d", impl.File, impl.Line))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("A *T entry means only the pointer type has all the methods.")
+	return b.String(), sources
+}
+
+// formatInterfacesOf lists the interfaces a type implements; when types in
+// several packages share the name, each line names its type
+func formatInterfacesOf(name string, impls []*storage.ImplementationRecord) (string, []string) {
+	var b strings.Builder
+	var sources []string
+	types := make(map[string]bool)
+	for _, impl := range impls {
+		location := fmt.Sprintf("%s:%d", impl.File, impl.Line)
+		if !types[location] {
+			types[location] = true
+			sources = append(sources, location)
+		}
+	}
+	if len(types) == 1 {
+		fmt.Fprintf(&b, "**%s** (%s) implements %d interfaces:\n", name, sources[0], len(impls))
+	} else {
+		fmt.Fprintf(&b, "%d types named %s implement %d interfaces:\n", len(types), name, len(impls))
+	}
+	for _, impl := range impls {
+		iface := impl.Interface
+		line := "- `" + iface.QualifiedName() + "`"
+		if len(types) > 1 {
+			line = fmt.Sprintf("- `%s` → `%s`", impl.QualifiedName(), iface.QualifiedName())
+		} else if impl.Pointer {
+			line += " (as a pointer)"
+		}
+		if iface.File != "" {
+			line += fmt.Sprintf(" (%s:%d)", iface.File, iface.Line)
+			sources = append(sources, fmt.Sprintf("%s:%d", iface.File, iface.Line))
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String(), sources
+}
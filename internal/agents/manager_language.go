@@ -0,0 +1,101 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/internal/language"
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+const (
+	// languageProbeLimit is how many chunks the language probe reads
+	languageProbeLimit = 10
+	// minRetrievalShare is the share of the retrieved code a language needs
+	// to replace the project default
+	minRetrievalShare = 0.6
+	// queryLanguagesKey lists, in query metadata, every code language
+	// retrieval found when there is more than one
+	queryLanguagesKey = "languages"
+)
+
+// inferRetrievedLanguage sets the language of a query that names none from
+// the code retrieval finds for it, so a question about the Python half of a
+// polyglot repo is not answered in Go. Every language found is recorded for
+// answers that span several.
+func (ma *ManagerAgent) inferRetrievedLanguage(ctx context.Context, query *models.Query) {
+	if source := query.Metadata["language_source"]; source != "" && source != language.SourceDefault {
+		return
+	}
+	if ma.dependencies == nil || ma.dependencies.VectorDB == nil {
+		return
+	}
+
+	results, err := ma.dependencies.VectorDB.Search(ctx, query.UserInput, languageProbeLimit)
+	if err != nil || len(results) == 0 {
+		return
+	}
+	chunkLanguages := make([]string, 0, len(results))
+	for _, result := range results {
+		// Dependency source is read, not written
+		if result.Chunk != nil && result.Chunk.Origin == "" {
+			chunkLanguages = append(chunkLanguages, result.Chunk.Language)
+		}
+	}
+	inference := language.Infer(chunkLanguages)
+	if inference.Language == "" {
+		return
+	}
+
+	if query.Metadata == nil {
+		query.Metadata = make(map[string]string)
+	}
+	if len(inference.Languages) > 1 {
+		query.Metadata[queryLanguagesKey] = strings.Join(inference.Languages, ",")
+	}
+	if inference.Share < minRetrievalShare || inference.Language == query.Language {
+		return
+	}
+	query.Language = inference.Language
+	query.Metadata["language_source"] = language.SourceRetrieval
+
+	if ma.dependencies.Logger != nil {
+		ma.dependencies.Logger.Info("Query language inferred from retrieval", map[string]interface{}{
+			"language":  inference.Language,
+			"share":     inference.Share,
+			"languages": inference.Languages,
+		})
+	}
+}
+
+// QueryLanguages returns the code languages an answer to query may need:
+// every language retrieval found, or the query's own
+func QueryLanguages(query *models.Query) []string {
+	if listed := query.Metadata[queryLanguagesKey]; listed != "" {
+		return strings.Split(listed, ",")
+	}
+	if query.Language != "" {
+		return []string{query.Language}
+	}
+	return nil
+}
+
+// languageInstructions tells the model which language to write code in:
+// the query's, or each file's own when the relevant code spans several
+func languageInstructions(query *models.Query) string {
+	languages := QueryLanguages(query)
+	if len(languages) > 1 {
+		names := make([]string, len(languages))
+		for i, lang := range languages {
+			names[i] = language.TemplateFor(lang).DisplayName
+		}
+		return fmt.Sprintf("\nThe relevant code is written in %s. Write each piece of code in the language of the file it belongs to, and fence each block with that language's tag.",
+			strings.Join(names, ", "))
+	}
+	if query.Language == "" || query.Metadata["language_source"] == language.SourceDefault {
+		return ""
+	}
+	name := language.TemplateFor(query.Language).DisplayName
+	return fmt.Sprintf("\nThe question is about %s code; write any code in %s.", name, name)
+}
@@ -0,0 +1,347 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/vectordb"
+	"github.com/yourusername/useq-ai-assistant/models"
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// MemoryAgentName is the agent recall answers are attributed to; its
+// answers are not remembered themselves
+const MemoryAgentName = "memory"
+
+// memoryCandidateFactor widens the vector search so earlier answers
+// outside the recalled period can be dropped and enough remain
+const memoryCandidateFactor = 4
+
+// MemoryConfig controls remembering answered questions and recalling them
+// for queries like "what did we decide about the retry strategy"
+type MemoryConfig struct {
+	Enabled        bool    `json:"enabled"`
+	Collection     string  `json:"collection"`       // secondary collection holding past questions and answers
+	MaxResults     int     `json:"max_results"`      // earlier answers shown per recall
+	MinScore       float32 `json:"min_score"`        // answers scoring lower are left out
+	MaxAnswerChars int     `json:"max_answer_chars"` // longer earlier answers are cut when shown
+}
+
+// DefaultMemoryConfig returns conversation memory defaults
+func DefaultMemoryConfig() MemoryConfig {
+	return MemoryConfig{
+		Enabled:        true,
+		Collection:     "conversation_memory",
+		MaxResults:     3,
+		MinScore:       0.3,
+		MaxAnswerChars: 1500,
+	}
+}
+
+// SetMemoryConfig replaces the conversation memory settings
+func (ma *ManagerAgent) SetMemoryConfig(config MemoryConfig) {
+	ma.memory = config
+}
+
+// localMemoryScope is the memory scope of sessions not tied to a server
+// user, like the CLI's
+const localMemoryScope = "local"
+
+// ConversationChunk is the memory collection point of an answered question
+func ConversationChunk(record *storage.ConversationRecord) *vectordb.CodeChunk {
+	return &vectordb.CodeChunk{
+		// One point per question, replaced when it is remembered again
+		ID:      vectordb.OriginConversation + ":" + record.QueryID,
+		Content: "Q: " + record.Question + "\nA: " + record.Answer,
+		Origin:  vectordb.OriginConversation,
+		Tags:    record.Sources,
+		Scope:   memoryScope(record.SessionID),
+	}
+}
+
+// sessionPrefix is the part of a session ID naming whose conversations it
+// may recall. The server keys sessions by user ID + "/" + session, so a
+// user recalls only their own; sessions without a "/" share the local
+// memory and have no prefix.
+func sessionPrefix(sessionID string) string {
+	if i := strings.Index(sessionID, "/"); i >= 0 {
+		return sessionID[:i+1]
+	}
+	return ""
+}
+
+// memoryScope is the memory collection scope of a session's conversations
+func memoryScope(sessionID string) string {
+	if prefix := sessionPrefix(sessionID); prefix != "" {
+		return prefix
+	}
+	return localMemoryScope
+}
+
+// recallPattern matches questions about earlier conversations rather than
+// the code
+var recallPattern = regexp.MustCompile(`(?i)\b(?:what|when|how|why|which)\s+(?:did|have|had)\s+(?:you|we|i)\s+` +
+	`(?:tell|told|say|said|decide|decided|agree|agreed|conclude|concluded|discuss|discussed|recommend|recommended|suggest|suggested|ask|asked|settle|settled)\b` +
+	`|\bremind\s+me\s+(?:what|how|why|which)\b` +
+	`|\b(?:did|have)\s+(?:we|you|i)\s+(?:already\s+)?(?:discuss|discussed|talk|talked|decide|decided|ask|asked)\b` +
+	`|\b(?:your|our)\s+(?:earlier|previous|last)\s+(?:answer|conversation|discussion|decision)\b`)
+
+// recallTopicPattern finds the subject of a recall question
+var recallTopicPattern = regexp.MustCompile(`(?i)\b(?:about|regarding|on|for)\s+(.+)$`)
+
+// recallPeriods are the phrases that limit a recall to a period. "Last
+// week" and "last month" are read loosely, as reaching back two of them,
+// since people rarely mean the calendar period.
+var recallPeriods = []struct {
+	pattern *regexp.Regexp
+	period  func(now, today time.Time, match []string) (time.Time, time.Time)
+}{
+	{regexp.MustCompile(`(?i)\b(?:earlier\s+)?today\b`), func(now, today time.Time, _ []string) (time.Time, time.Time) {
+		return today, time.Time{}
+	}},
+	{regexp.MustCompile(`(?i)\byesterday\b`), func(now, today time.Time, _ []string) (time.Time, time.Time) {
+		return today.AddDate(0, 0, -1), today
+	}},
+	{regexp.MustCompile(`(?i)\b(\d+)\s+(day|week|month)s?\s+ago\b`), func(now, today time.Time, match []string) (time.Time, time.Time) {
+		n, _ := strconv.Atoi(match[1])
+		days := map[string]int{"day": 1, "week": 7, "month": 30}[strings.ToLower(match[2])]
+		return today.AddDate(0, 0, -(n+1)*days), today.AddDate(0, 0, -(n-1)*days+1)
+	}},
+	{regexp.MustCompile(`(?i)\b(?:this|past)\s+week\b`), func(now, today time.Time, _ []string) (time.Time, time.Time) {
+		return today.AddDate(0, 0, -7), time.Time{}
+	}},
+	{regexp.MustCompile(`(?i)\blast\s+week\b`), func(now, today time.Time, _ []string) (time.Time, time.Time) {
+		return today.AddDate(0, 0, -14), time.Time{}
+	}},
+	{regexp.MustCompile(`(?i)\b(?:this|past)\s+month\b`), func(now, today time.Time, _ []string) (time.Time, time.Time) {
+		return today.AddDate(0, -1, 0), time.Time{}
+	}},
+	{regexp.MustCompile(`(?i)\blast\s+month\b`), func(now, today time.Time, _ []string) (time.Time, time.Time) {
+		return today.AddDate(0, -2, 0), time.Time{}
+	}},
+}
+
+// recallRequest is what a recall question asks for
+type recallRequest struct {
+	Topic  string
+	Since  time.Time // zero reaches back to the first conversation
+	Until  time.Time // zero reaches to now
+	Period string    // the phrase that set the period, for the answer
+}
+
+// IsRecallQuery reports whether input asks what was said in an earlier
+// conversation
+func IsRecallQuery(input string) bool {
+	return recallPattern.MatchString(input)
+}
+
+// parseRecall splits a recall question into its topic and period
+func parseRecall(input string, now time.Time) recallRequest {
+	var request recallRequest
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for _, period := range recallPeriods {
+		match := period.pattern.FindStringSubmatch(input)
+		if match == nil {
+			continue
+		}
+		request.Since, request.Until = period.period(now, today, match)
+		request.Period = strings.ToLower(match[0])
+		input = strings.Replace(input, match[0], " ", 1)
+		break
+	}
+
+	rest := input
+	if loc := recallPattern.FindStringIndex(input); loc != nil {
+		rest = input[loc[1]:]
+	}
+	if match := recallTopicPattern.FindStringSubmatch(rest); match != nil {
+		rest = match[1]
+	}
+	request.Topic = strings.Trim(strings.Join(strings.Fields(rest), " "), " ?.!,")
+	return request
+}
+
+// answerFromMemory answers a question about an earlier conversation with
+// the earlier answers themselves, dated and with the files they cited. It
+// returns nil for queries about the code.
+func (ma *ManagerAgent) answerFromMemory(ctx context.Context, query *models.Query) (*models.Response, error) {
+	if !ma.memory.Enabled || ma.dependencies == nil || ma.dependencies.Storage == nil || !IsRecallQuery(query.UserInput) {
+		return nil, nil
+	}
+	startTime := time.Now()
+	request := parseRecall(query.UserInput, startTime)
+
+	records, err := ma.recallConversations(ctx, query, request)
+	if err != nil {
+		return nil, err
+	}
+	if ma.dependencies.Logger != nil {
+		ma.dependencies.Logger.Info("Recalled earlier answers", map[string]interface{}{
+			"topic":       request.Topic,
+			"period":      request.Period,
+			"results":     len(records),
+			"duration_ms": time.Since(startTime).Milliseconds(),
+		})
+	}
+
+	var sources []string
+	for _, record := range records {
+		sources = appendMissing(sources, record.Sources)
+	}
+	return &models.Response{
+		ID:      fmt.Sprintf("memory_%d", time.Now().UnixNano()),
+		QueryID: query.ID,
+		Type:    models.ResponseTypeExplanation,
+		Content: models.ResponseContent{
+			Text: ma.recallText(request, records),
+		},
+		AgentUsed:  MemoryAgentName,
+		Provider:   "none",
+		TokenUsage: models.TokenUsage{},
+		Cost:       models.Cost{TotalCost: 0, Currency: "USD"},
+		Metadata: models.ResponseMetadata{
+			Sources:    sources,
+			Tools:      []string{"conversation_memory"},
+			Reasoning:  "Recalled from earlier conversations",
+			Confidence: 1.0,
+		},
+		Timestamp: time.Now(),
+	}, nil
+}
+
+// recallConversations finds the earlier answers about the topic within the
+// period, best match first, or the latest ones when no topic was named.
+// Only the conversations of the asking user's sessions are recalled.
+// Without the memory collection, when it fails or when it has nothing from
+// this user, stored conversations are searched by keyword.
+func (ma *ManagerAgent) recallConversations(ctx context.Context, query *models.Query, request recallRequest) ([]*storage.ConversationRecord, error) {
+	limit := max(ma.memory.MaxResults, 1)
+	if ma.dependencies.MemoryVectorDB != nil && request.Topic != "" {
+		records, err := ma.recallBySimilarity(ctx, query, request, limit)
+		if err == nil && len(records) > 0 {
+			return records, nil
+		}
+		if err != nil && ma.dependencies.Logger != nil {
+			ma.dependencies.Logger.Warn("Memory search failed, searching by keyword", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+	}
+	terms := ma.recallTerms(request.Topic)
+	records, err := ma.dependencies.Storage.SearchConversations(sessionPrefix(query.SessionID), terms,
+		request.Since, request.Until, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	return withoutQuery(records, query.ID, limit), nil
+}
+
+// recallBySimilarity searches the memory collection for the topic
+func (ma *ManagerAgent) recallBySimilarity(ctx context.Context, query *models.Query, request recallRequest, limit int) ([]*storage.ConversationRecord, error) {
+	hits, err := ma.dependencies.MemoryVectorDB.SearchWithFilter(ctx, request.Topic, limit*memoryCandidateFactor,
+		map[string]string{"scope": memoryScope(query.SessionID)})
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	scores := make(map[string]float32)
+	for _, hit := range hits {
+		if hit.Chunk == nil || hit.Score < ma.memory.MinScore {
+			continue
+		}
+		id := strings.TrimPrefix(hit.Chunk.ID, vectordb.OriginConversation+":")
+		if _, seen := scores[id]; !seen {
+			ids = append(ids, id)
+		}
+		scores[id] = hit.Score
+	}
+	stored, err := ma.dependencies.Storage.GetConversations(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []*storage.ConversationRecord
+	for _, id := range ids {
+		record, ok := stored[id]
+		if !ok || sessionPrefix(record.SessionID) != sessionPrefix(query.SessionID) {
+			continue
+		}
+		if (!request.Since.IsZero() && record.CreatedAt.Before(request.Since)) ||
+			(!request.Until.IsZero() && !record.CreatedAt.Before(request.Until)) {
+			continue
+		}
+		records = append(records, record)
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return scores[records[i].QueryID] > scores[records[j].QueryID]
+	})
+	return withoutQuery(records, query.ID, limit), nil
+}
+
+// recallStopWords are left out of the keywords a topic is searched by
+var recallStopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "about": true,
+	"our": true, "your": true, "that": true, "this": true, "what": true,
+}
+
+// recallTerms returns the keywords of a topic
+func (ma *ManagerAgent) recallTerms(topic string) []string {
+	vocab := ma.dependencies.Vocabulary
+	var terms []string
+	for _, word := range strings.Fields(strings.ToLower(topic)) {
+		word = strings.Trim(word, ".,!?;:\"'`")
+		if vocab.IsProtected(word) || len(word) > 2 && !vocab.IsStopWord(word, recallStopWords) {
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
+
+// withoutQuery drops the recall question itself and keeps limit records
+func withoutQuery(records []*storage.ConversationRecord, queryID string, limit int) []*storage.ConversationRecord {
+	kept := records[:0]
+	for _, record := range records {
+		if record.QueryID != queryID {
+			kept = append(kept, record)
+		}
+	}
+	if len(kept) > limit {
+		kept = kept[:limit]
+	}
+	return kept
+}
+
+// recallText lists the earlier answers in the order found: when, what
+// was asked, what was answered and which files it was based on
+func (ma *ManagerAgent) recallText(request recallRequest, records []*storage.ConversationRecord) string {
+	subject := ""
+	if request.Period != "" {
+		subject = " " + request.Period
+	}
+	if request.Topic != "" {
+		subject += fmt.Sprintf(" about %q", request.Topic)
+	}
+	if len(records) == 0 {
+		return fmt.Sprintf("I found no earlier conversation%s.", subject)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Earlier answers%s:\n", subject)
+	for i, record := range records {
+		fmt.Fprintf(&b, "\n%d. %s, you asked: %q\n", i+1, record.CreatedAt.Format("Mon 2 Jan 2006 15:04"), record.Question)
+		answer := strings.TrimSpace(record.Answer)
+		if ma.memory.MaxAnswerChars > 0 && len(answer) > ma.memory.MaxAnswerChars {
+			answer = strings.TrimSpace(strings.ToValidUTF8(answer[:ma.memory.MaxAnswerChars], "")) + " …"
+		}
+		b.WriteString(answer + "\n")
+		if len(record.Sources) > 0 {
+			b.WriteString("Sources: " + strings.Join(record.Sources, ", ") + "\n")
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,368 @@
+package agents
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/llm"
+	"github.com/yourusername/useq-ai-assistant/internal/mcp"
+	"github.com/yourusername/useq-ai-assistant/internal/promptguard"
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// pathMentionPattern finds file paths in a query, optionally with a line or
+// line range: internal/llm/manager.go, ./cmd/main.go:40, storage/sqlite.go:10-80
+var pathMentionPattern = regexp.MustCompile("(?:^|[\\s\"'`(\\[])((?:\\.{1,2}/)?(?:[\\w.-]+/)*[\\w-][\\w.-]*\\.[A-Za-z]\\w{0,5})(?::(\\d+)(?:-(\\d+))?)?")
+
+// editPattern marks requests to change code; those still go to the coding
+// agents, with the mentioned files recorded as targets
+var editPattern = regexp.MustCompile(`(?i)\b(create|generate|write|add|implement|refactor|fix|modify|change|rename|delete|remove|update|convert|migrate)\b`)
+
+// mentionedFileContext is the system prompt for answers grounded in files the
+// user named
+const mentionedFileContext = `You are a senior engineer answering a question about specific files in the user's codebase.
+The files are included below with line numbers. Answer from them, and cite exact line ranges as path:start-end.
+If the answer needs code that is not included, say which file or symbol you would need.`
+
+// FileMentionConfig controls loading files named in a query straight into context
+type FileMentionConfig struct {
+	Enabled   bool `json:"enabled"`
+	MaxFiles  int  `json:"max_files"`
+	MaxTokens int  `json:"max_tokens"` // budget across all loaded files, counted with the primary model's tokenizer
+}
+
+// DefaultFileMentionConfig returns file mention defaults
+func DefaultFileMentionConfig() FileMentionConfig {
+	return FileMentionConfig{
+		Enabled:   true,
+		MaxFiles:  3,
+		MaxTokens: 12000,
+	}
+}
+
+// SetFileMentionConfig replaces the file mention settings
+func (ma *ManagerAgent) SetFileMentionConfig(config FileMentionConfig) {
+	ma.fileMentions = config
+}
+
+// PathMention is a file path found in a query. StartLine and EndLine are set
+// when the mention carried a line or range.
+type PathMention struct {
+	Path      string `json:"path"`
+	StartLine int    `json:"start_line,omitempty"`
+	EndLine   int    `json:"end_line,omitempty"`
+}
+
+// DetectPathMentions returns the paths mentioned in input that name existing
+// files under root, in order of appearance
+func DetectPathMentions(input, root string) []PathMention {
+	seen := make(map[string]bool)
+	var mentions []PathMention
+	for _, match := range pathMentionPattern.FindAllStringSubmatch(input, -1) {
+		path := strings.TrimPrefix(filepath.Clean(match[1]), "./")
+		if seen[path] || !isProjectFile(root, path) {
+			continue
+		}
+		if err := mcp.CurrentPathPolicy().Check("read", filepath.Join(root, path)); err != nil {
+			continue
+		}
+		seen[path] = true
+
+		mention := PathMention{Path: path}
+		mention.StartLine, _ = strconv.Atoi(match[2])
+		mention.EndLine, _ = strconv.Atoi(match[3])
+		if mention.StartLine > 0 && mention.EndLine < mention.StartLine {
+			mention.EndLine = mention.StartLine
+		}
+		mentions = append(mentions, mention)
+	}
+	return mentions
+}
+
+// explicitMentions returns the files the query named with @ that exist
+// under root and may be read
+func explicitMentions(query *models.Query, root string) []PathMention {
+	var mentions []PathMention
+	for _, mention := range query.Context.Mentions {
+		if !isProjectFile(root, mention.Path) {
+			continue
+		}
+		if err := mcp.CurrentPathPolicy().Check("read", filepath.Join(root, mention.Path)); err != nil {
+			continue
+		}
+		mentions = append(mentions, PathMention{Path: mention.Path, StartLine: mention.StartLine, EndLine: mention.EndLine})
+	}
+	return mentions
+}
+
+// isProjectFile reports whether path is a regular file inside root
+func isProjectFile(root, path string) bool {
+	if filepath.IsAbs(path) || path == ".." || strings.HasPrefix(path, "../") {
+		return false
+	}
+	info, err := os.Stat(filepath.Join(root, path))
+	return err == nil && info.Mode().IsRegular()
+}
+
+// singleLineContext is how many lines around a path:line mention are loaded
+const singleLineContext = 20
+
+// loadedFile is a mentioned file, or the part of it that fit the budget
+type loadedFile struct {
+	Path      string
+	StartLine int
+	EndLine   int
+	Lines     []string
+	Truncated bool
+}
+
+// citation formats the loaded range as path:start-end
+func (f *loadedFile) citation() string {
+	return fmt.Sprintf("%s:%d-%d", f.Path, f.StartLine, f.EndLine)
+}
+
+// lineNumberTokens is what the line number prefix of a loaded line costs
+const lineNumberTokens = 3
+
+// loadMentionedFiles reads mentioned files within the token budget. A mention
+// with a line range loads that range; otherwise the whole file is loaded and
+// cut at the budget. The budget is shared across files in mention order.
+func loadMentionedFiles(root string, mentions []PathMention, config FileMentionConfig, tokenizer llm.Tokenizer) ([]*loadedFile, error) {
+	budget := config.MaxTokens
+	var files []*loadedFile
+	for _, mention := range mentions {
+		if len(files) >= config.MaxFiles || budget <= 0 {
+			break
+		}
+		if mention.StartLine > 0 && mention.EndLine == mention.StartLine {
+			// A single line needs its surroundings to be explained
+			mention.StartLine = max(mention.StartLine-singleLineContext, 1)
+			mention.EndLine += singleLineContext
+		}
+		file, used, err := loadFileRange(filepath.Join(root, mention.Path), mention, budget, tokenizer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", mention.Path, err)
+		}
+		if len(file.Lines) == 0 {
+			continue
+		}
+		budget -= used
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// formatLoadedFiles renders loaded files with line numbers for a prompt,
+// each in its own untrusted data section
+func formatLoadedFiles(files []*loadedFile) string {
+	var text strings.Builder
+	for _, file := range files {
+		text.WriteString(fmt.Sprintf("=== %s (lines %d-%d", file.Path, file.StartLine, file.EndLine))
+		if file.Truncated {
+			text.WriteString(", truncated to fit the context budget")
+		}
+		text.WriteString(") ===\n")
+		var body strings.Builder
+		for i, line := range file.Lines {
+			body.WriteString(fmt.Sprintf("%5d  %s\n", file.StartLine+i, line))
+		}
+		text.WriteString(promptguard.WrapRetrieved(file.Path, body.String()))
+		text.WriteString("\n")
+	}
+	return text.String()
+}
+
+func loadFileRange(fullPath string, mention PathMention, budget int, tokenizer llm.Tokenizer) (*loadedFile, int, error) {
+	handle, err := os.Open(fullPath)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer handle.Close()
+
+	file := &loadedFile{Path: mention.Path, StartLine: max(mention.StartLine, 1)}
+	used := 0
+	line := 0
+	scanner := bufio.NewScanner(handle)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line++
+		if line < file.StartLine {
+			continue
+		}
+		if mention.EndLine > 0 && line > mention.EndLine {
+			break
+		}
+		text := scanner.Text()
+		cost := tokenizer.Count(text) + lineNumberTokens
+		if used+cost > budget {
+			file.Truncated = true
+			break
+		}
+		used += cost
+		file.Lines = append(file.Lines, text)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, err
+	}
+	file.EndLine = file.StartLine + len(file.Lines) - 1
+	return file, used, nil
+}
+
+// answerWithMentionedFiles answers a question that names files by putting
+// those files in context directly, skipping vector search. Files named with
+// @ come first. It returns nil when the query names no existing file, asks
+// for a code change, or no LLM is available; a change request carries its
+// @ files to the agents that make it, like pins for this query.
+func (ma *ManagerAgent) answerWithMentionedFiles(ctx context.Context, query *models.Query) (*models.Response, error) {
+	config := ma.fileMentions
+	if !config.Enabled {
+		return nil, nil
+	}
+
+	root := query.ProjectRoot
+	if root == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, nil
+		}
+		root = wd
+	}
+	explicit := explicitMentions(query, root)
+	mentions := explicit
+	for _, mention := range DetectPathMentions(query.UserInput, root) {
+		if !containsMention(mentions, mention.Path) {
+			mentions = append(mentions, mention)
+		}
+	}
+	if len(mentions) < len(query.Context.Mentions) && ma.dependencies != nil && ma.dependencies.Logger != nil {
+		ma.dependencies.Logger.Warn("Some @ files are not readable project files", map[string]interface{}{
+			"named":  len(query.Context.Mentions),
+			"loaded": len(explicit),
+		})
+	}
+	if len(mentions) == 0 {
+		return nil, nil
+	}
+	for _, mention := range mentions {
+		query.Intent.FileTargets = appendMissing(query.Intent.FileTargets, []string{mention.Path})
+	}
+	if editPattern.MatchString(query.UserInput) {
+		pinMentions(query, explicit)
+		return nil, nil
+	}
+	manager := ma.toolLoopLLM()
+	if manager == nil {
+		pinMentions(query, explicit)
+		return nil, nil
+	}
+
+	startTime := time.Now()
+	files, err := loadMentionedFiles(root, mentions, config, ma.tokenizer())
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	var prompt strings.Builder
+	prompt.WriteString(formatLoadedFiles(files))
+	prompt.WriteString("Question: ")
+	prompt.WriteString(query.UserInput)
+
+	// Pinned files still apply, unless the question already loaded them
+	loaded := make([]string, len(files))
+	for i, file := range files {
+		loaded[i] = file.Path
+	}
+	ctx = ma.withPinnedFiles(ctx, query, loaded)
+	ctx = ma.withGlossary(ctx, query)
+	ctx = ma.withAnalyzerDiagnostics(ctx, query)
+
+	response, err := manager.Generate(ctx, &llm.GenerationRequest{
+		Messages:     []llm.Message{{Role: "user", Content: prompt.String()}},
+		SystemPrompt: mentionedFileContext,
+		MaxTokens:    2000,
+		Temperature:  0.1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to answer from mentioned files: %w", err)
+	}
+
+	sources := make([]string, 0, len(files))
+	references := make([]models.Reference, 0, len(files))
+	for _, file := range files {
+		sources = append(sources, file.citation())
+		references = append(references, models.Reference{
+			Type:        models.ReferenceTypeInternal,
+			Title:       file.citation(),
+			File:        file.Path,
+			Line:        file.StartLine,
+			Description: fmt.Sprintf("Lines %d-%d loaded into context", file.StartLine, file.EndLine),
+		})
+	}
+
+	if ma.dependencies != nil && ma.dependencies.Logger != nil {
+		ma.dependencies.Logger.Info("Loaded mentioned files into context", map[string]interface{}{
+			"files": sources,
+		})
+	}
+
+	return &models.Response{
+		ID:      fmt.Sprintf("file_context_%d", time.Now().UnixNano()),
+		QueryID: query.ID,
+		Type:    models.ResponseTypeExplanation,
+		Content: models.ResponseContent{
+			Text:       response.Content,
+			References: references,
+		},
+		Metadata: models.ResponseMetadata{
+			GenerationTime: time.Since(startTime),
+			FilesAnalyzed:  len(files),
+			Confidence:     0.9,
+			Sources:        sources,
+			Tools:          []string{"file_context"},
+			Reasoning:      "Query names files; loaded them directly instead of searching the index",
+		},
+		TokenUsage: response.TokenUsage,
+		Cost:       response.Cost,
+		Timestamp:  time.Now(),
+		AgentUsed:  "manager",
+		Provider:   response.Provider,
+	}, nil
+}
+
+// containsMention reports whether mentions already name path
+func containsMention(mentions []PathMention, path string) bool {
+	for _, mention := range mentions {
+		if mention.Path == path {
+			return true
+		}
+	}
+	return false
+}
+
+// pinMentions puts the @ files ahead of the session's pins for this query,
+// so the prompts of whichever agent handles it include them
+func pinMentions(query *models.Query, mentions []PathMention) {
+	if len(mentions) == 0 {
+		return
+	}
+	pinned := make([]models.PinnedContext, 0, len(mentions)+len(query.Context.Pinned))
+	for _, mention := range mentions {
+		pinned = append(pinned, models.PinnedContext{Path: mention.Path, StartLine: mention.StartLine, EndLine: mention.EndLine})
+	}
+	for _, pin := range query.Context.Pinned {
+		if !containsMention(mentions, pin.Path) {
+			pinned = append(pinned, pin)
+		}
+	}
+	query.Context.Pinned = pinned
+}
@@ -0,0 +1,265 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/deps"
+	"github.com/yourusername/useq-ai-assistant/internal/llm"
+	"github.com/yourusername/useq-ai-assistant/internal/migration"
+	"github.com/yourusername/useq-ai-assistant/internal/promptguard"
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// migrationContext frames migration prompts
+const migrationContext = `You are a senior Go engineer planning a migration in the user's codebase.
+You are given the migration, where the project uses the affected module or feature, what requires it, and the release notes in between.
+Write a step-by-step migration plan: what to change in go.mod, which breaking changes affect this project and why, and how to verify the result.
+For every file that needs changes, give a unified diff in its own fenced diff block headed by the file path. Only change code you were shown; list other affected files instead.`
+
+// maxMigrationSnippets bounds the indexed code shown alongside the usages
+const maxMigrationSnippets = 6
+
+// SetMigrationConfig replaces the migration settings
+func (ma *ManagerAgent) SetMigrationConfig(config migration.Config) {
+	ma.migration = config
+}
+
+// answerMigration plans a dependency or Go version migration from the
+// dependency graph, the project's usages of the module, the release notes
+// between the versions and the indexed code around the usages. It returns
+// nil when the query is not a migration or no LLM is available.
+func (ma *ManagerAgent) answerMigration(ctx context.Context, query *models.Query) (*models.Response, error) {
+	config := ma.migration
+	manager := ma.toolLoopLLM()
+	if !config.Enabled || manager == nil {
+		return nil, nil
+	}
+
+	root := query.ProjectRoot
+	if root == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, nil
+		}
+		root = wd
+	}
+	requirements, err := deps.ReadRequirements(filepath.Join(root, "go.mod"))
+	if err != nil {
+		requirements = nil
+	}
+	target := migration.Detect(query.UserInput, requirements)
+	if target == nil {
+		return nil, nil
+	}
+	query.Intent.Primary = models.QueryTypeMigration
+
+	startTime := time.Now()
+	steps := []string{"migration"}
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "Migration: %s\n\n", target.Label())
+
+	var usages []migration.Usage
+	var rewrites []models.CodeChange
+	var rewriteDiff string
+	if target.Kind == migration.KindDependency {
+		usages, err = migration.FindUsages(root, target.Module)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, "usage_search")
+		ma.writeUsages(&prompt, target, usages, config.MaxUsages)
+
+		if requirers, err := migration.Requirers(ctx, root, target.Module, config); err == nil {
+			steps = append(steps, "dependency_graph")
+			prompt.WriteString("Required by (go mod graph):\n")
+			for _, requirer := range requirers {
+				fmt.Fprintf(&prompt, "- %s\n", requirer)
+			}
+			prompt.WriteString("\n")
+		} else if ma.dependencies != nil && ma.dependencies.Logger != nil {
+			ma.dependencies.Logger.Warn("Failed to read the module graph", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+
+		if changelog := ma.migrationChangelog(ctx, target); changelog != nil {
+			steps = append(steps, "changelog")
+			// Release notes are prose, possibly fetched from the network
+			notes, _ := promptguard.Sanitize(changelog.Text)
+			fmt.Fprintf(&prompt, "Release notes (%s):\n%s\n", changelog.Source, promptguard.Wrap(changelog.Source, notes))
+		} else {
+			prompt.WriteString("Release notes: none found; rely on what you know of this module's releases and say so.\n\n")
+		}
+
+		rewrites = migration.ImportRewrites(root, usages, target)
+		if len(rewrites) > 0 {
+			rewriteDiff = migration.RenderRewrites(root, rewrites)
+			fmt.Fprintf(&prompt, "Import path changes (already computed, include them in the diffs):\n```diff\n%s```\n\n", rewriteDiff)
+		}
+	} else {
+		fmt.Fprintf(&prompt, "Target Go version: %s. Update the go directive in go.mod where needed.\n\n", target.To)
+	}
+
+	if snippets := ma.migrationSnippets(ctx, query, target, usages); snippets != "" {
+		steps = append(steps, "vector_search")
+		prompt.WriteString("Relevant code:\n")
+		prompt.WriteString(snippets)
+	}
+	prompt.WriteString("Request: ")
+	prompt.WriteString(query.UserInput)
+
+	ctx = ma.withPinnedFiles(ctx, query, nil)
+	ctx = ma.withGlossary(ctx, query)
+	ctx = ma.withAnalyzerDiagnostics(ctx, query)
+	response, err := manager.Generate(ctx, &llm.GenerationRequest{
+		Messages:     []llm.Message{{Role: "user", Content: prompt.String()}},
+		SystemPrompt: migrationContext,
+		MaxTokens:    4000,
+		Temperature:  0.1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan migration: %w", err)
+	}
+
+	files := migration.UsageFiles(usages)
+	references := make([]models.Reference, 0, len(files))
+	for _, file := range files {
+		references = append(references, models.Reference{
+			Type:        models.ReferenceTypeInternal,
+			Title:       file,
+			File:        file,
+			Description: fmt.Sprintf("Uses %s", target.Module),
+		})
+	}
+
+	if ma.dependencies != nil && ma.dependencies.Logger != nil {
+		ma.dependencies.Logger.Info("Planned migration", map[string]interface{}{
+			"migration": target.Label(),
+			"files":     len(files),
+			"rewrites":  len(rewrites),
+		})
+	}
+
+	var code *models.CodeResponse
+	if len(rewrites) > 0 {
+		code = &models.CodeResponse{
+			Language:    "diff",
+			Code:        rewriteDiff,
+			Explanation: fmt.Sprintf("Import paths for %s", target.Label()),
+			Changes:     rewrites,
+		}
+	}
+	return &models.Response{
+		ID:      fmt.Sprintf("migration_%d", time.Now().UnixNano()),
+		QueryID: query.ID,
+		Type:    models.ResponseTypeMigration,
+		Content: models.ResponseContent{
+			Text:       response.Content,
+			Code:       code,
+			References: references,
+		},
+		Metadata: models.ResponseMetadata{
+			GenerationTime: time.Since(startTime),
+			FilesAnalyzed:  len(files),
+			Confidence:     0.85,
+			Sources:        files,
+			Tools:          steps,
+			Reasoning:      fmt.Sprintf("Migration query (%s); planned from usages, the module graph and release notes", target.Label()),
+		},
+		TokenUsage: response.TokenUsage,
+		Cost:       response.Cost,
+		Timestamp:  time.Now(),
+		AgentUsed:  "manager",
+		Provider:   response.Provider,
+	}, nil
+}
+
+// writeUsages lists where the project uses the module, most used symbols
+// first, up to maxUsages lines
+func (ma *ManagerAgent) writeUsages(prompt *strings.Builder, target *migration.Target, usages []migration.Usage, maxUsages int) {
+	if len(usages) == 0 {
+		fmt.Fprintf(prompt, "The project does not import %s directly.\n\n", target.Module)
+		return
+	}
+	files := migration.UsageFiles(usages)
+	fmt.Fprintf(prompt, "Usages of %s: %d in %d files\n", target.Module, len(usages), len(files))
+	if symbols := migration.SymbolCounts(usages); len(symbols) > 0 {
+		fmt.Fprintf(prompt, "Symbols used: %s\n", strings.Join(symbols[:min(len(symbols), 30)], ", "))
+	}
+	for i, usage := range usages {
+		if i == maxUsages {
+			fmt.Fprintf(prompt, "... %d more\n", len(usages)-maxUsages)
+			break
+		}
+		what := "import " + usage.ImportPath
+		if usage.Symbol != "" {
+			what = usage.Symbol
+		}
+		fmt.Fprintf(prompt, "- %s:%d %s\n", usage.File, usage.Line, what)
+	}
+	prompt.WriteString("\n")
+}
+
+// migrationChangelog finds release notes for the migration range: in the
+// module cache first, then on the web when allowed
+func (ma *ManagerAgent) migrationChangelog(ctx context.Context, target *migration.Target) *migration.Changelog {
+	if target.To == "" {
+		return nil
+	}
+	if changelog := migration.LocalChangelog(target, ma.migration); changelog != nil {
+		return changelog
+	}
+	if !ma.migration.FetchChangelogs {
+		return nil
+	}
+	changelog, err := migration.FetchChangelog(ctx, target, ma.migration)
+	if err != nil {
+		if ma.dependencies != nil && ma.dependencies.Logger != nil {
+			ma.dependencies.Logger.Warn("Failed to fetch release notes", map[string]interface{}{
+				"module": target.Module,
+				"error":  err.Error(),
+			})
+		}
+		return nil
+	}
+	return changelog
+}
+
+// migrationSnippets searches the index for the code the migration touches,
+// keeping to the files that use the module when there are any
+func (ma *ManagerAgent) migrationSnippets(ctx context.Context, query *models.Query, target *migration.Target, usages []migration.Usage) string {
+	if ma.dependencies == nil || ma.dependencies.VectorDB == nil {
+		return ""
+	}
+	searchQuery := query.UserInput
+	if symbols := migration.SymbolCounts(usages); len(symbols) > 0 {
+		searchQuery = target.Module + " " + strings.Join(symbols[:min(len(symbols), 5)], " ")
+	}
+	results, err := ma.dependencies.VectorDB.Search(ctx, searchQuery, maxMigrationSnippets*2)
+	if err != nil {
+		return ""
+	}
+
+	using := make(map[string]bool)
+	for _, file := range migration.UsageFiles(usages) {
+		using[file] = true
+	}
+	var b strings.Builder
+	shown := 0
+	for _, result := range results {
+		if shown == maxMigrationSnippets {
+			break
+		}
+		if result.Chunk == nil || (len(using) > 0 && !using[result.Chunk.FilePath]) {
+			continue
+		}
+		fmt.Fprintf(&b, "%s:%d-%d\n%s\n", result.Chunk.FilePath, result.Chunk.StartLine, result.Chunk.EndLine, promptguard.Wrap(result.Chunk.FilePath, "```go\n"+result.Chunk.Content+"\n```"))
+		shown++
+	}
+	return b.String()
+}
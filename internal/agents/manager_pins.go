@@ -0,0 +1,76 @@
+package agents
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/yourusername/useq-ai-assistant/internal/llm"
+	"github.com/yourusername/useq-ai-assistant/internal/mcp"
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// PinConfig bounds the pinned files included in Tier 3 prompts
+type PinConfig struct {
+	MaxTokens int `json:"max_tokens"` // budget across all pins, counted with the primary model's tokenizer
+}
+
+// DefaultPinConfig returns pinning defaults
+func DefaultPinConfig() PinConfig {
+	return PinConfig{MaxTokens: 8000}
+}
+
+// SetPinConfig replaces the pinning settings
+func (ma *ManagerAgent) SetPinConfig(config PinConfig) {
+	ma.pins = config
+}
+
+// withPinnedFiles loads the query's pinned files, in pin order and within
+// the budget, and attaches them to ctx so every LLM call made with it
+// includes them. Paths in skip are already in the prompt.
+func (ma *ManagerAgent) withPinnedFiles(ctx context.Context, query *models.Query, skip []string) context.Context {
+	if len(query.Context.Pinned) == 0 || ma.pins.MaxTokens <= 0 {
+		return ctx
+	}
+	root := query.ProjectRoot
+	if root == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return ctx
+		}
+		root = wd
+	}
+
+	var mentions []PathMention
+	for _, pin := range query.Context.Pinned {
+		if containsString(skip, pin.Path) || !isProjectFile(root, pin.Path) {
+			continue
+		}
+		if err := mcp.CurrentPathPolicy().Check("read", filepath.Join(root, pin.Path)); err != nil {
+			continue
+		}
+		mentions = append(mentions, PathMention{Path: pin.Path, StartLine: pin.StartLine, EndLine: pin.EndLine})
+	}
+
+	files, err := loadMentionedFiles(root, mentions, FileMentionConfig{MaxFiles: len(mentions), MaxTokens: ma.pins.MaxTokens}, ma.tokenizer())
+	if err != nil || len(files) == 0 {
+		if err != nil && ma.dependencies != nil && ma.dependencies.Logger != nil {
+			ma.dependencies.Logger.Warn("Failed to load pinned files", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+		return ctx
+	}
+
+	if ma.dependencies != nil && ma.dependencies.Logger != nil {
+		included := make([]string, len(files))
+		for i, file := range files {
+			included[i] = file.citation()
+		}
+		ma.dependencies.Logger.Info("Including pinned files", map[string]interface{}{
+			"included": included,
+			"dropped":  len(mentions) - len(files),
+		})
+	}
+	return llm.WithPinnedContext(ctx, formatLoadedFiles(files))
+}
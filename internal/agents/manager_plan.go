@@ -0,0 +1,249 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/llm"
+	"github.com/yourusername/useq-ai-assistant/internal/mcp"
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// PlanPrefix makes the manager return the execution plan instead of running the query
+const PlanPrefix = "/plan"
+
+// planOnlyKey marks a query as plan-only in query.Metadata
+const planOnlyKey = "plan_only"
+
+const (
+	planPromptOverheadTokens = 800  // system prompt, patterns and instructions
+	planOutputTokens         = 500  // matches the classifier's average response length
+	planMaxFileTokens        = 2000 // agents truncate each context file to roughly this
+	planEmbeddingCost        = 0.0005
+)
+
+// planMaxFileBytes is how much of a context file is read to count its
+// tokens; more than planMaxFileTokens can cover
+const planMaxFileBytes = planMaxFileTokens * 8
+
+// ParsePlanPrefix strips a leading /plan from input and reports whether it was present
+func ParsePlanPrefix(input string) (string, bool) {
+	trimmed := strings.TrimSpace(input)
+	if trimmed == PlanPrefix {
+		return "", true
+	}
+	if strings.HasPrefix(trimmed, PlanPrefix+" ") {
+		return strings.TrimSpace(trimmed[len(PlanPrefix):]), true
+	}
+	return input, false
+}
+
+// MarkPlanOnly flags a query so RouteQuery returns its plan without executing it
+func MarkPlanOnly(query *models.Query) {
+	if query.Metadata == nil {
+		query.Metadata = make(map[string]string)
+	}
+	query.Metadata[planOnlyKey] = "true"
+}
+
+// IsPlanOnly reports whether the query asked for a dry-run plan
+func IsPlanOnly(query *models.Query) bool {
+	return query != nil && query.Metadata[planOnlyKey] == "true"
+}
+
+// PlanQuery works out how the query would be processed: tier, agents, token
+// and cost estimates, context files and MCP commands. It makes no LLM or
+// embedding calls, so users can approve or adjust the query before paying.
+func (ma *ManagerAgent) PlanQuery(ctx context.Context, query *models.Query) (*models.Response, error) {
+	startTime := time.Now()
+
+	plan := &models.ExecutionPlan{
+		Query: query.UserInput,
+		Tier:  string(mcp.TierComplex),
+	}
+
+	var classification *mcp.ClassificationResult
+	if ma.mcpClient != nil {
+		if result, err := ma.mcpClient.GetQueryClassifier().ClassifyQuery(ctx, query); err == nil {
+			classification = result
+		}
+	}
+	if classification != nil {
+		plan.Tier = string(classification.Tier)
+		plan.TierReason = classification.Reasoning
+		plan.SkipLLM = classification.SkipLLM
+		plan.EstimatedTime = classification.EstimatedTime
+		plan.MCPCommands = append(plan.MCPCommands, classification.ProcessingStrategy.Operations...)
+		if len(plan.MCPCommands) == 0 {
+			plan.MCPCommands = append(plan.MCPCommands, classification.RequiredOperations...)
+		}
+	} else {
+		plan.Notes = append(plan.Notes, "Query classification unavailable; assuming the full LLM pipeline")
+	}
+
+	plan.ContextFiles = ma.planContextFiles(query)
+
+	switch plan.Tier {
+	case string(mcp.TierSimple):
+		plan.Agents = []string{"mcp_direct"}
+		plan.AgentConfidence = classification.Confidence
+	case string(mcp.TierMedium):
+		plan.Agents = []string{"mcp_vector"}
+		plan.AgentConfidence = classification.Confidence
+		plan.EstimatedCost = planEmbeddingCost
+		plan.Notes = append(plan.Notes, "One query embedding is needed for vector search")
+	default:
+		ma.planLLMExecution(ctx, query, plan)
+	}
+
+	return &models.Response{
+		ID:      fmt.Sprintf("plan_%d", time.Now().UnixNano()),
+		QueryID: query.ID,
+		Type:    models.ResponseTypePlan,
+		Content: models.ResponseContent{
+			Text: fmt.Sprintf("Execution plan for: %s\nRun the query without %s to execute it.", query.UserInput, PlanPrefix),
+			Plan: plan,
+		},
+		AgentUsed:  "manager_plan",
+		Provider:   "none",
+		TokenUsage: models.TokenUsage{},
+		Cost:       models.Cost{TotalCost: 0, Currency: "USD"},
+		Metadata: models.ResponseMetadata{
+			GenerationTime: time.Since(startTime),
+			Confidence:     plan.AgentConfidence,
+			Sources:        plan.ContextFiles,
+			Tools:          []string{"query_classifier", "routing_analysis"},
+			Reasoning:      plan.TierReason,
+		},
+		Timestamp: time.Now(),
+	}, nil
+}
+
+// planLLMExecution fills in the agents, token estimate and cost for a Tier 3 query
+func (ma *ManagerAgent) planLLMExecution(ctx context.Context, query *models.Query, plan *models.ExecutionPlan) {
+	if ma.shouldUseIntelligentProcessing(query) {
+		plan.Agents = []string{"intelligent_processor", "search"}
+		plan.AgentConfidence = 0.9
+	} else {
+		analysis := ma.analyzeQueryForRouting(ctx, query)
+		agent, confidence := ma.selectBestAgent(ctx, query, analysis)
+		if agent == "" {
+			agent, confidence = "search", 0.5
+		}
+		plan.AgentConfidence = confidence
+		plan.Agents = []string{agent}
+		if agent != "search" {
+			plan.Agents = append(plan.Agents, "search")
+		}
+	}
+
+	estimate := ma.estimateLLMUsage(query, plan.ContextFiles)
+	plan.Provider = estimate.Provider
+	plan.EstimatedInputTokens = estimate.InputTokens
+	plan.EstimatedOutputTokens = estimate.OutputTokens
+	plan.EstimatedCost = estimate.Cost
+
+	plan.Notes = append(plan.Notes, "Additional context files may be added from vector search results at run time")
+}
+
+// estimateLLMUsage estimates the tokens and cost of answering query with
+// the primary model and contextFiles in the prompt
+func (ma *ManagerAgent) estimateLLMUsage(query *models.Query, contextFiles []string) *models.CostEstimate {
+	tokenizer := ma.tokenizer()
+	estimate := &models.CostEstimate{
+		InputTokens:  planPromptOverheadTokens + tokenizer.Count(query.UserInput),
+		OutputTokens: planOutputTokens,
+		ContextFiles: len(contextFiles),
+		Calibration:  1,
+	}
+	for _, path := range contextFiles {
+		estimate.InputTokens += planFileTokens(path, tokenizer)
+	}
+
+	inputPer1K, outputPer1K := 0.01, 0.03 // GPT-4 Turbo pricing, as the classifier assumes
+	if ma.llmManager != nil {
+		estimate.Provider = ma.llmManager.GetPrimaryProvider()
+		if info, err := ma.llmManager.GetProviderInfo(estimate.Provider); err == nil && info.Pricing.InputCostPer1K > 0 {
+			inputPer1K, outputPer1K = info.Pricing.InputCostPer1K, info.Pricing.OutputCostPer1K
+		}
+	}
+	estimate.Cost = float64(estimate.InputTokens)/1000.0*inputPer1K +
+		float64(estimate.OutputTokens)/1000.0*outputPer1K + planEmbeddingCost
+	estimate.HeuristicTokens = estimate.TotalTokens()
+	estimate.HeuristicCost = estimate.Cost
+	return estimate
+}
+
+// planFileTokens counts the tokens of a context file up to the size agents
+// truncate it to
+func planFileTokens(path string, tokenizer llm.Tokenizer) int {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0
+	}
+	defer file.Close()
+	head, err := io.ReadAll(io.LimitReader(file, planMaxFileBytes))
+	if err != nil {
+		return 0
+	}
+	return min(tokenizer.Count(string(head)), planMaxFileTokens)
+}
+
+// tokenizer returns the tokenizer of the primary model, or the default
+// estimator when no LLM is configured
+func (ma *ManagerAgent) tokenizer() llm.Tokenizer {
+	if ma.llmManager != nil {
+		return ma.llmManager.Tokenizer()
+	}
+	return llm.TokenizerFor("")
+}
+
+// planContextFiles lists files that would be put in context: explicit file
+// targets, paths named in the query, the current editor file and files
+// defining referenced functions
+func (ma *ManagerAgent) planContextFiles(query *models.Query) []string {
+	seen := make(map[string]bool)
+	var files []string
+	add := func(path string) {
+		if path != "" && !seen[path] {
+			seen[path] = true
+			files = append(files, path)
+		}
+	}
+
+	for _, path := range query.Intent.FileTargets {
+		add(path)
+	}
+	root := query.ProjectRoot
+	if root == "" {
+		root, _ = os.Getwd()
+	}
+	for _, mention := range DetectPathMentions(query.UserInput, root) {
+		add(mention.Path)
+	}
+	add(query.Context.CurrentFile)
+
+	if ma.dependencies != nil && ma.dependencies.Storage != nil {
+		names := append([]string{}, query.Intent.FuncTargets...)
+		for _, m := range funcNameRegex.FindAllStringSubmatch(query.UserInput, -1) {
+			for _, name := range m[1:] {
+				if name != "" {
+					names = append(names, name)
+				}
+			}
+		}
+		for _, name := range names {
+			if path, err := ma.dependencies.Storage.GetFunctionFilePath(name); err == nil {
+				add(path)
+			}
+		}
+	}
+
+	sort.Strings(files)
+	return files
+}
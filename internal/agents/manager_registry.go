@@ -0,0 +1,140 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// RegisterAgent adds an agent the manager can route to alongside the
+// built-in ones. Queries are scored against it from then on.
+func (ma *ManagerAgent) RegisterAgent(registration AgentRegistration) error {
+	return ma.registry.Register(registration)
+}
+
+// RegisteredAgents lists the agents queries can be routed to
+func (ma *ManagerAgent) RegisteredAgents() []AgentRegistration {
+	return ma.registry.Agents()
+}
+
+// registerBuiltinAgents registers the specialized agents that were
+// initialized, each with the scorer tuned for it
+func (ma *ManagerAgent) registerBuiltinAgents() {
+	if ma.SearchAgent != nil {
+		capabilities := ma.SearchAgent.GetCapabilities()
+		ma.registerBuiltin(AgentRegistration{
+			Name:        "search",
+			Description: ma.SearchAgent.GetSpecialization().Description,
+			Intents:     []string{"search", "find", "system_status", "file_query"},
+			QueryTypes:  []models.QueryType{models.QueryTypeSearch},
+			Languages:   capabilities.SupportedLanguages,
+			Tools:       capabilityTools(capabilities),
+			Score:       ma.evaluateSearchAgent,
+			Process:     ma.SearchAgent.Search,
+		})
+	}
+	if ma.ContextAwareSearchAgent != nil {
+		ma.registerBuiltin(AgentRegistration{
+			Name:        "context_search",
+			Description: "Searches with the surrounding code for similar patterns, examples and related code",
+			Intents:     []string{"context_search"},
+			QueryTypes:  []models.QueryType{models.QueryTypeSearch},
+			Tools:       []string{"search", "analysis"},
+			Score:       ma.evaluateContextSearchAgent,
+			Process:     ma.ContextAwareSearchAgent.Process,
+		})
+	}
+	if ma.CodingAgent != nil {
+		capabilities := ma.CodingAgent.GetCapabilities()
+		ma.registerBuiltin(AgentRegistration{
+			Name:        "coding",
+			Description: ma.CodingAgent.GetSpecialization().Description,
+			Intents:     []string{"generation", "test"},
+			QueryTypes:  []models.QueryType{models.QueryTypeGeneration, models.QueryTypeRefactoring, models.QueryTypeTesting},
+			Languages:   capabilities.SupportedLanguages,
+			Tools:       capabilityTools(capabilities),
+			Score:       ma.evaluateCodingAgent,
+			Process:     ma.processCoding,
+		})
+	}
+	if ma.IntelligenceCodingAgent != nil {
+		capabilities := ma.IntelligenceCodingAgent.GetCapabilities()
+		ma.registerBuiltin(AgentRegistration{
+			Name:        "intelligence_coding",
+			Description: ma.IntelligenceCodingAgent.GetSpecialization().Description,
+			Intents:     []string{"generation", "analysis", "debug", "explanation", "architecture_explanation"},
+			QueryTypes:  []models.QueryType{models.QueryTypeGeneration, models.QueryTypeDebugging, models.QueryTypeReview, models.QueryTypeExplanation},
+			Languages:   capabilities.SupportedLanguages,
+			Tools:       capabilityTools(capabilities),
+			Score:       ma.evaluateIntelligenceCodingAgent,
+			Process:     ma.processIntelligenceCoding,
+		})
+	}
+	if ma.SystemAgent != nil {
+		ma.registerBuiltin(AgentRegistration{
+			Name:        "system",
+			Description: "Reports the memory, CPU, runtime and health of the running process",
+			Intents:     []string{"system_status"},
+			QueryTypes:  []models.QueryType{models.QueryTypeSystem, models.QueryTypeRuntime, models.QueryTypeMonitoring},
+			Tools:       []string{"monitoring"},
+			Score:       ma.evaluateSystemAgent,
+			Process:     ma.SystemAgent.Process,
+		})
+	}
+}
+
+func (ma *ManagerAgent) registerBuiltin(registration AgentRegistration) {
+	if err := ma.registry.Register(registration); err != nil && ma.dependencies != nil && ma.dependencies.Logger != nil {
+		ma.dependencies.Logger.Warn("Failed to register agent", map[string]interface{}{
+			"agent": registration.Name,
+			"error": err.Error(),
+		})
+	}
+}
+
+// processCoding runs the coding agent, logging its failures
+func (ma *ManagerAgent) processCoding(ctx context.Context, query *models.Query) (*models.Response, error) {
+	response, err := ma.CodingAgent.Process(ctx, query)
+	if err != nil {
+		if ma.dependencies != nil && ma.dependencies.Logger != nil {
+			ma.dependencies.Logger.Error("CodingAgent process failed", map[string]interface{}{
+				"error": err.Error(),
+				"query": query.UserInput,
+			})
+		}
+		return nil, fmt.Errorf("coding agent failed: %w", err)
+	}
+	return response, nil
+}
+
+// processIntelligenceCoding runs the intelligence coding agent, which takes
+// and returns its own query and response types
+func (ma *ManagerAgent) processIntelligenceCoding(ctx context.Context, query *models.Query) (*models.Response, error) {
+	icQuery := &Query{
+		ID:        query.ID,
+		UserInput: query.UserInput,
+		Language:  query.Language,
+	}
+	icResponse, err := ma.IntelligenceCodingAgent.Process(ctx, icQuery)
+	if err != nil {
+		return nil, fmt.Errorf("intelligence coding agent failed: %w", err)
+	}
+	return &models.Response{
+		ID:        icResponse.ID,
+		QueryID:   icResponse.QueryID,
+		Type:      models.ResponseType(icResponse.Type),
+		Content:   models.ResponseContent{Text: icResponse.Content.Text},
+		AgentUsed: icResponse.AgentUsed,
+		Timestamp: icResponse.Timestamp,
+		TokenUsage: models.TokenUsage{
+			InputTokens:  icResponse.TokenUsage.InputTokens,
+			OutputTokens: icResponse.TokenUsage.OutputTokens,
+			TotalTokens:  icResponse.TokenUsage.TotalTokens,
+		},
+		Cost: models.Cost{
+			TotalCost: icResponse.Cost.TotalCost,
+			Currency:  icResponse.Cost.Currency,
+		},
+	}, nil
+}
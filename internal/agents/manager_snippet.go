@@ -0,0 +1,231 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/llm"
+	"github.com/yourusername/useq-ai-assistant/internal/promptguard"
+	"github.com/yourusername/useq-ai-assistant/models"
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// pastedSnippetContext is the system prompt for answers about pasted code
+const pastedSnippetContext = `You are a senior engineer answering a question about a code snippet the user pasted.
+The snippet is included below with line numbers; it may not be part of the user's codebase. Where the snippet uses
+identifiers the codebase defines, those definitions follow it. Answer about the snippet, citing its lines as snippet:N
+and project code as path:start-end. Say so when the snippet disagrees with the project's definitions.`
+
+// SnippetConfig controls answering questions about pasted code
+type SnippetConfig struct {
+	Enabled    bool `json:"enabled"`
+	MaxSymbols int  `json:"max_symbols"` // project definitions of identifiers in the snippet loaded with it
+	MaxTokens  int  `json:"max_tokens"`  // budget for those definitions, counted with the primary model's tokenizer
+}
+
+// DefaultSnippetConfig returns pasted snippet defaults
+func DefaultSnippetConfig() SnippetConfig {
+	return SnippetConfig{
+		Enabled:    true,
+		MaxSymbols: 5,
+		MaxTokens:  8000,
+	}
+}
+
+// SetSnippetConfig replaces the pasted snippet settings
+func (ma *ManagerAgent) SetSnippetConfig(config SnippetConfig) {
+	ma.snippets = config
+}
+
+// typeReferencePattern matches capitalized names, which are types or
+// exported identifiers in most languages
+var typeReferencePattern = regexp.MustCompile(`\b([A-Z][A-Za-z0-9_]+)\b`)
+
+// snippetIdentifiers returns the names a snippet calls, then the types it
+// names, in order of first appearance
+func snippetIdentifiers(code string) []string {
+	names := referencedFunctions(code)
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		seen[name] = true
+	}
+	for _, match := range typeReferencePattern.FindAllStringSubmatch(code, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			names = append(names, match[1])
+		}
+	}
+	return names
+}
+
+// snippetDefinitions finds the indexed definitions of the identifiers a
+// snippet uses. Names defined more than once are skipped unless a qualifier
+// picks one, like the functions excerpted into explanations.
+func (ma *ManagerAgent) snippetDefinitions(code, root string) []*storage.SymbolLocation {
+	if ma.dependencies == nil || ma.dependencies.Storage == nil || ma.snippets.MaxSymbols <= 0 {
+		return nil
+	}
+	var definitions []*storage.SymbolLocation
+	seen := make(map[string]bool)
+	for _, name := range snippetIdentifiers(code) {
+		if len(definitions) >= ma.snippets.MaxSymbols {
+			break
+		}
+		definition := ma.resolveFunction(name, nil, root)
+		if definition == nil {
+			definition = ma.resolveType(name)
+		}
+		if definition == nil {
+			continue
+		}
+		key := fmt.Sprintf("%s:%d", definition.File, definition.StartLine)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		definitions = append(definitions, definition)
+	}
+	return definitions
+}
+
+// resolveType finds the one indexed type a name refers to
+func (ma *ManagerAgent) resolveType(name string) *storage.SymbolLocation {
+	definitions, err := ma.dependencies.Storage.LookupSymbol(name)
+	if err != nil {
+		return nil
+	}
+	var types []*storage.SymbolLocation
+	for _, definition := range definitions {
+		if !isFunctionKind(definition.Kind) {
+			types = append(types, definition)
+		}
+	}
+	if len(types) == 1 {
+		return types[0]
+	}
+	return nil
+}
+
+// formatSnippet renders pasted code with line numbers as an untrusted data
+// section
+func formatSnippet(snippet *models.PastedSnippet) string {
+	var body strings.Builder
+	for i, line := range strings.Split(snippet.Code, "\n") {
+		body.WriteString(fmt.Sprintf("%5d  %s\n", i+1, line))
+	}
+	return fmt.Sprintf("=== pasted snippet (%s, %d lines) ===\n%s\n", snippet.Language, snippet.Lines,
+		promptguard.WrapRetrieved("pasted snippet", body.String()))
+}
+
+// answerPastedSnippet answers a question about code the user pasted. The
+// snippet goes into context as given, never searched for or indexed, with
+// the project definitions of the identifiers it uses.
+func (ma *ManagerAgent) answerPastedSnippet(ctx context.Context, query *models.Query) (*models.Response, error) {
+	snippet := query.Context.Snippet
+	manager := ma.toolLoopLLM()
+	if manager == nil {
+		return nil, fmt.Errorf("answering about pasted code needs an LLM provider")
+	}
+
+	root := query.ProjectRoot
+	if root == "" {
+		root, _ = os.Getwd()
+	}
+	startTime := time.Now()
+
+	budget := ma.snippets.MaxTokens
+	var files []*loadedFile
+	for _, definition := range ma.snippetDefinitions(snippet.Code, root) {
+		if budget <= 0 {
+			break
+		}
+		path := definition.File
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(root, path)
+		}
+		mention := PathMention{Path: relativePath(root, definition.File), StartLine: definition.StartLine, EndLine: definition.EndLine}
+		file, used, err := loadFileRange(path, mention, budget, ma.tokenizer())
+		if err != nil || len(file.Lines) == 0 {
+			continue
+		}
+		budget -= used
+		files = append(files, file)
+	}
+
+	var prompt strings.Builder
+	prompt.WriteString(formatSnippet(snippet))
+	if len(files) > 0 {
+		prompt.WriteString("Project definitions of identifiers the snippet uses:\n")
+		prompt.WriteString(formatLoadedFiles(files))
+	}
+	prompt.WriteString("Question: ")
+	prompt.WriteString(query.UserInput)
+
+	loaded := make([]string, len(files))
+	for i, file := range files {
+		loaded[i] = file.Path
+	}
+	ctx = ma.withPinnedFiles(ctx, query, loaded)
+	ctx = ma.withGlossary(ctx, query)
+	ctx = ma.withAnalyzerDiagnostics(ctx, query)
+
+	response, err := manager.Generate(ctx, &llm.GenerationRequest{
+		Messages:     []llm.Message{{Role: "user", Content: prompt.String()}},
+		SystemPrompt: pastedSnippetContext,
+		MaxTokens:    2000,
+		Temperature:  0.1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to answer about pasted code: %w", err)
+	}
+
+	sources := make([]string, 0, len(files))
+	references := make([]models.Reference, 0, len(files))
+	for _, file := range files {
+		sources = append(sources, file.citation())
+		references = append(references, models.Reference{
+			Type:        models.ReferenceTypeInternal,
+			Title:       file.citation(),
+			File:        file.Path,
+			Line:        file.StartLine,
+			Description: "Definition used by the pasted snippet",
+		})
+	}
+
+	if ma.dependencies != nil && ma.dependencies.Logger != nil {
+		ma.dependencies.Logger.Info("Answered about pasted code", map[string]interface{}{
+			"lines":       snippet.Lines,
+			"language":    snippet.Language,
+			"definitions": sources,
+		})
+	}
+
+	return &models.Response{
+		ID:      fmt.Sprintf("snippet_%d", time.Now().UnixNano()),
+		QueryID: query.ID,
+		Type:    models.ResponseTypeExplanation,
+		Content: models.ResponseContent{
+			Text:       response.Content,
+			References: references,
+		},
+		Metadata: models.ResponseMetadata{
+			GenerationTime: time.Since(startTime),
+			FilesAnalyzed:  len(files),
+			Confidence:     0.85,
+			Sources:        sources,
+			Tools:          []string{"pasted_snippet"},
+			Reasoning: fmt.Sprintf("Query pastes %d lines of %s; answered from the snippet and %d project definitions it uses, without searching the index",
+				snippet.Lines, snippet.Language, len(files)),
+		},
+		TokenUsage: response.TokenUsage,
+		Cost:       response.Cost,
+		Timestamp:  time.Now(),
+		AgentUsed:  "manager",
+		Provider:   response.Provider,
+	}, nil
+}
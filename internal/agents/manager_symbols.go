@@ -0,0 +1,184 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/models"
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// identifierPattern matches a query that is a bare identifier, optionally
+// quoted in backticks or followed by (): NewManagerAgent, `SQLiteDB`, Close()
+var identifierPattern = regexp.MustCompile("^`?([A-Za-z_][A-Za-z0-9_]*)(?:\\(\\))?`?$")
+
+// ExactLookupConfig controls answering a bare symbol name from the index
+type ExactLookupConfig struct {
+	Enabled       bool `json:"enabled"`
+	MaxReferences int  `json:"max_references"` // references listed after the definitions; 0 lists none
+}
+
+// DefaultExactLookupConfig returns exact lookup defaults
+func DefaultExactLookupConfig() ExactLookupConfig {
+	return ExactLookupConfig{
+		Enabled:       true,
+		MaxReferences: 200,
+	}
+}
+
+// SetExactLookupConfig replaces the exact lookup settings
+func (ma *ManagerAgent) SetExactLookupConfig(config ExactLookupConfig) {
+	ma.exactLookup = config
+}
+
+// ExactSymbolName returns the identifier a query consists of, if it is
+// nothing else
+func ExactSymbolName(input string) (string, bool) {
+	match := identifierPattern.FindStringSubmatch(strings.TrimSpace(input))
+	if match == nil {
+		return "", false
+	}
+	return match[1], true
+}
+
+// answerExactSymbol answers a query that is just a symbol name with its
+// definitions and references, read from SQLite: no embedding, no LLM, no
+// cost. It returns nil when the query is not a bare identifier or no
+// indexed function or type has that exact name, so the query falls through
+// to the usual tiers.
+func (ma *ManagerAgent) answerExactSymbol(ctx context.Context, query *models.Query) (*models.Response, error) {
+	if !ma.exactLookup.Enabled || ma.dependencies == nil || ma.dependencies.Storage == nil {
+		return nil, nil
+	}
+	name, ok := ExactSymbolName(query.UserInput)
+	if !ok {
+		return nil, nil
+	}
+
+	startTime := time.Now()
+	definitions, err := ma.dependencies.Storage.LookupSymbol(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(definitions) == 0 {
+		return nil, nil
+	}
+	var references []*storage.SymbolReference
+	if ma.exactLookup.MaxReferences > 0 {
+		// Leave room for the definitions' own lines, which are not listed
+		references, err = ma.dependencies.Storage.FindReferences(name, ma.exactLookup.MaxReferences+len(definitions))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	results, sources := exactSymbolResults(definitions, references, ma.exactLookup.MaxReferences)
+	elapsed := time.Since(startTime)
+	if ma.dependencies.Logger != nil {
+		ma.dependencies.Logger.Info("Answered from an exact symbol lookup", map[string]interface{}{
+			"name":        name,
+			"definitions": len(definitions),
+			"references":  len(results) - len(definitions),
+			"duration_ms": elapsed.Milliseconds(),
+		})
+	}
+
+	return &models.Response{
+		ID:      fmt.Sprintf("symbol_%d", time.Now().UnixNano()),
+		QueryID: query.ID,
+		Type:    models.ResponseTypeSearch,
+		Content: models.ResponseContent{
+			Text: exactSymbolText(name, definitions, len(results)-len(definitions)),
+			Search: &models.SearchResponse{
+				Query:     name,
+				Results:   results,
+				Total:     len(results),
+				TimeTaken: elapsed,
+			},
+		},
+		AgentUsed:  "index",
+		Provider:   "symbol_index",
+		TokenUsage: models.TokenUsage{TotalTokens: 0},
+		Cost:       models.Cost{TotalCost: 0, Currency: "USD"},
+		Metadata: models.ResponseMetadata{
+			GenerationTime: elapsed,
+			FilesAnalyzed:  len(sources),
+			Confidence:     1.0,
+			Sources:        sources,
+			Tools:          []string{"symbol_index"},
+			Reasoning:      "Precision mode: the query is an indexed symbol name, looked up exactly without embeddings",
+		},
+		Timestamp: time.Now(),
+	}, nil
+}
+
+// exactSymbolResults lists the definitions first, then up to maxReferences
+// other lines naming the symbol
+func exactSymbolResults(definitions []*storage.SymbolLocation, references []*storage.SymbolReference, maxReferences int) ([]models.SearchResult, []string) {
+	defined := make(map[string]int, len(definitions))
+	results := make([]models.SearchResult, 0, len(definitions)+len(references))
+	var sources []string
+	for _, definition := range definitions {
+		location := fmt.Sprintf("%s:%d", definition.File, definition.StartLine)
+		defined[location] = len(results)
+		sources = append(sources, fmt.Sprintf("%s:%d-%d", definition.File, definition.StartLine, definition.EndLine))
+		results = append(results, models.SearchResult{
+			File:        definition.File,
+			Function:    definition.Name,
+			Line:        definition.StartLine,
+			Score:       1.0,
+			Explanation: "definition (" + definition.Kind + ")",
+			Ranking:     &models.RankingFactors{Strategy: "exact_symbol", Similarity: 1.0},
+		})
+	}
+
+	listed := 0
+	for _, reference := range references {
+		if i, ok := defined[fmt.Sprintf("%s:%d", reference.File, reference.Line)]; ok {
+			results[i].Context = reference.Text
+			continue
+		}
+		if listed == maxReferences {
+			break
+		}
+		listed++
+		results = append(results, models.SearchResult{
+			File:        reference.File,
+			Function:    definitions[0].Name,
+			Line:        reference.Line,
+			Score:       0.5,
+			Context:     reference.Text,
+			Explanation: "reference",
+			Ranking:     &models.RankingFactors{Strategy: "exact_symbol", Similarity: 0.5},
+		})
+	}
+	return results, sources
+}
+
+// exactSymbolText sums up where a symbol is defined and how often it is used
+func exactSymbolText(name string, definitions []*storage.SymbolLocation, references int) string {
+	var used string
+	switch references {
+	case 0:
+		used = "No other indexed line names it."
+	case 1:
+		used = "1 other line names it."
+	default:
+		used = fmt.Sprintf("%d other lines name it.", references)
+	}
+
+	if len(definitions) == 1 {
+		d := definitions[0]
+		return fmt.Sprintf("`%s` is a %s defined at %s:%d-%d. %s", name, d.Kind, d.File, d.StartLine, d.EndLine, used)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "`%s` has %d definitions:\n", name, len(definitions))
+	for _, d := range definitions {
+		fmt.Fprintf(&b, "- %s at %s:%d-%d\n", d.Kind, d.File, d.StartLine, d.EndLine)
+	}
+	b.WriteString(used)
+	return b.String()
+}
@@ -0,0 +1,293 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/llm"
+	"github.com/yourusername/useq-ai-assistant/internal/mcp"
+	"github.com/yourusername/useq-ai-assistant/internal/promptguard"
+	"github.com/yourusername/useq-ai-assistant/internal/sandbox"
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// Tool loop stop reasons, reported in ToolLoopSummary.StopReason
+const (
+	toolStopAnswered   = "answered"
+	toolStopStepBudget = "step_budget"
+	toolStopCostCap    = "cost_cap"
+)
+
+const toolLoopSystemPrompt = `You are a senior engineer answering questions about the user's codebase.
+Use the tools to look things up instead of guessing: search_index to find relevant code,
+list_dir and read_file to inspect it, run_tests to check behaviour. Cite files as path:line.
+Stop calling tools and answer as soon as you have enough information.`
+
+const toolLoopWrapUpPrompt = "The tool budget for this question is used up. Answer now with what you have found, and say what you could not verify."
+
+// ToolLoopConfig bounds the Tier 3 tool-use loop
+type ToolLoopConfig struct {
+	Enabled       bool          `json:"enabled"`
+	MaxSteps      int           `json:"max_steps"`       // model turns that may call tools
+	MaxCost       float64       `json:"max_cost"`        // USD across all turns; 0 disables the cap
+	MaxToolOutput int           `json:"max_tool_output"` // bytes of each tool result sent to the model
+	TestTimeout   time.Duration `json:"test_timeout"`    // limit for one run_tests call
+}
+
+// DefaultToolLoopConfig returns tool loop defaults
+func DefaultToolLoopConfig() ToolLoopConfig {
+	return ToolLoopConfig{
+		Enabled:       true,
+		MaxSteps:      8,
+		MaxCost:       0.10,
+		MaxToolOutput: 8000,
+		TestTimeout:   2 * time.Minute,
+	}
+}
+
+// SetToolLoopConfig replaces the tool loop settings
+func (ma *ManagerAgent) SetToolLoopConfig(config ToolLoopConfig) {
+	ma.toolLoop = config
+}
+
+// SetSandboxPolicy replaces the sandbox policy the tools run commands under
+func (ma *ManagerAgent) SetSandboxPolicy(policy sandbox.Policy) {
+	ma.sandbox = policy
+}
+
+// toolLoopLLM returns the LLM manager used for the tool loop, if any
+func (ma *ManagerAgent) toolLoopLLM() *llm.Manager {
+	if ma.llmManager != nil {
+		return ma.llmManager
+	}
+	if ma.dependencies != nil {
+		return ma.dependencies.LLMManager
+	}
+	return nil
+}
+
+// runToolLoop answers a Tier 3 query by letting the model call tools until it
+// answers or a budget runs out. When the step budget or cost cap is reached
+// the model gets one last turn without tools to answer from what it has.
+func (ma *ManagerAgent) runToolLoop(ctx context.Context, query *models.Query, classification *mcp.ClassificationResult) (*models.Response, error) {
+	startTime := time.Now()
+	manager := ma.toolLoopLLM()
+	if manager == nil {
+		return nil, fmt.Errorf("no LLM available for tool use")
+	}
+	config := ma.toolLoop
+
+	root := query.ProjectRoot
+	if root == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve project root: %w", err)
+		}
+		root = wd
+	}
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve project root: %w", err)
+	}
+	toolbox := ma.newAgentToolbox(root, config)
+
+	messages := []llm.Message{{Role: "user", Content: query.UserInput}}
+	var (
+		trace      []models.ToolCallTrace
+		usage      models.TokenUsage
+		cost       models.Cost
+		sources    []string
+		seenSource = make(map[string]bool)
+		stopReason = toolStopAnswered
+		response   *llm.GenerationResponse
+	)
+
+	for step := 1; ; step++ {
+		request := &llm.GenerationRequest{
+			Messages:     messages,
+			SystemPrompt: toolLoopSystemPrompt + languageInstructions(query),
+			MaxTokens:    2000,
+			Temperature:  0.1,
+			Tools:        toolbox.definitions(ctx),
+			ToolChoice:   llm.ToolChoiceAuto,
+		}
+		if stopReason != toolStopAnswered {
+			request.ToolChoice = llm.ToolChoiceNone
+		}
+
+		response, err = manager.Generate(ctx, request)
+		if err != nil {
+			return nil, fmt.Errorf("tool loop step %d failed: %w", step, err)
+		}
+		addUsage(&usage, &cost, response)
+
+		if len(response.ToolCalls) == 0 || stopReason != toolStopAnswered {
+			break
+		}
+
+		messages = append(messages, llm.Message{Role: "assistant", Content: response.Content, ToolCalls: response.ToolCalls})
+		for _, call := range response.ToolCalls {
+			callStart := time.Now()
+			result, callErr := toolbox.call(ctx, call.Name, call.Arguments)
+			entry := models.ToolCallTrace{
+				Step:      step,
+				Tool:      call.Name,
+				Arguments: call.Arguments,
+				Duration:  time.Since(callStart),
+			}
+			if callErr != nil {
+				entry.Error = callErr.Error()
+				result = "error: " + callErr.Error()
+			} else {
+				result = truncateToolOutput(result, config.MaxToolOutput)
+				entry.Result = result
+				for _, source := range toolCallSources(call.Name, result, call.Arguments) {
+					if !seenSource[source] {
+						seenSource[source] = true
+						sources = append(sources, source)
+					}
+				}
+			}
+			trace = append(trace, entry)
+			if callErr == nil {
+				// Tool output reads files and runs commands: data, never instructions
+				result = promptguard.Wrap(call.Name, result)
+			}
+			messages = append(messages, llm.Message{Role: "tool", Content: result, ToolCallID: call.ID, Name: call.Name})
+
+			if ma.dependencies != nil && ma.dependencies.Logger != nil {
+				ma.dependencies.Logger.Info("Tool call", map[string]interface{}{
+					"step":      step,
+					"tool":      call.Name,
+					"arguments": call.Arguments,
+					"duration":  entry.Duration.String(),
+					"error":     entry.Error,
+				})
+			}
+		}
+
+		switch {
+		case config.MaxCost > 0 && cost.TotalCost >= config.MaxCost:
+			stopReason = toolStopCostCap
+		case step >= config.MaxSteps:
+			stopReason = toolStopStepBudget
+		}
+		if stopReason != toolStopAnswered {
+			messages = append(messages, llm.Message{Role: "user", Content: toolLoopWrapUpPrompt})
+		}
+	}
+
+	toolsUsed := make([]string, 0)
+	seenTool := make(map[string]bool)
+	for _, entry := range trace {
+		if !seenTool[entry.Tool] {
+			seenTool[entry.Tool] = true
+			toolsUsed = append(toolsUsed, entry.Tool)
+		}
+	}
+
+	confidence := 0.8
+	if classification != nil && classification.Confidence > 0 {
+		confidence = classification.Confidence
+	}
+	if stopReason != toolStopAnswered {
+		confidence *= 0.8
+	}
+
+	steps := 0
+	if len(trace) > 0 {
+		steps = trace[len(trace)-1].Step
+	}
+
+	return &models.Response{
+		ID:      fmt.Sprintf("tier3_tools_%d", time.Now().UnixNano()),
+		QueryID: query.ID,
+		Type:    models.ResponseTypeExplanation,
+		Content: models.ResponseContent{
+			Text: response.Content,
+		},
+		Metadata: models.ResponseMetadata{
+			GenerationTime: time.Since(startTime),
+			Confidence:     confidence,
+			Sources:        sources,
+			Tools:          toolsUsed,
+			Reasoning:      fmt.Sprintf("Tier 3: answered with tool use (%d tool calls, stopped: %s)", len(trace), stopReason),
+			ToolTrace:      trace,
+			ToolLoop: &models.ToolLoopSummary{
+				Steps:      steps,
+				ToolCalls:  len(trace),
+				Cost:       cost.TotalCost,
+				StopReason: stopReason,
+			},
+		},
+		TokenUsage: usage,
+		Cost:       cost,
+		Timestamp:  time.Now(),
+		AgentUsed:  "manager",
+		Provider:   response.Provider,
+	}, nil
+}
+
+// addUsage adds one turn's tokens and cost to the running totals
+func addUsage(usage *models.TokenUsage, cost *models.Cost, response *llm.GenerationResponse) {
+	usage.InputTokens += response.TokenUsage.InputTokens
+	usage.OutputTokens += response.TokenUsage.OutputTokens
+	usage.TotalTokens += response.TokenUsage.TotalTokens
+	usage.CachedTokens += response.TokenUsage.CachedTokens
+	usage.ReasoningTokens += response.TokenUsage.ReasoningTokens
+	usage.Provider = response.Provider
+	usage.Model = response.Model
+	usage.Timestamp = time.Now()
+
+	cost.InputCost += response.Cost.InputCost
+	cost.OutputCost += response.Cost.OutputCost
+	cost.TotalCost += response.Cost.TotalCost
+	cost.Currency = "USD"
+	cost.Provider = response.Provider
+	cost.Model = response.Model
+	cost.Timestamp = time.Now()
+}
+
+// truncateToolOutput keeps tool results within the configured size. Test
+// output keeps its tail, where failures and the summary are.
+func truncateToolOutput(output string, limit int) string {
+	if limit <= 0 || len(output) <= limit {
+		return output
+	}
+	if strings.HasPrefix(output, "go test") {
+		return "... (output truncated)\n" + output[len(output)-limit:]
+	}
+	return output[:limit] + "\n... (output truncated)"
+}
+
+// toolCallSources returns the files a tool call looked at, for response sources
+func toolCallSources(tool, result, arguments string) []string {
+	switch tool {
+	case "read_file":
+		var args struct {
+			Path string `json:"path"`
+		}
+		if err := json.Unmarshal([]byte(arguments), &args); err == nil && args.Path != "" {
+			return []string{args.Path}
+		}
+	case "search_index":
+		var files []string
+		for _, line := range strings.Split(result, "\n") {
+			// "1. path/to/file.go:10-20 (score 0.81)"
+			_, rest, ok := strings.Cut(line, ". ")
+			if !ok || !strings.Contains(rest, "(score ") {
+				continue
+			}
+			if path, _, ok := strings.Cut(rest, ":"); ok {
+				files = append(files, path)
+			}
+		}
+		return files
+	}
+	return nil
+}
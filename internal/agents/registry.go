@@ -0,0 +1,182 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// Weights of the declared capabilities when a registration has no scorer
+// of its own
+const (
+	intentMatchWeight     = 0.3
+	secondaryIntentWeight = 0.1
+	toolMatchWeight       = 0.2
+	queryTypeWeight       = 0.2
+	keywordWeight         = 0.1
+
+	// unsupportedLanguagePenalty is taken off the score of an agent that
+	// does not declare the query's language
+	unsupportedLanguagePenalty = 0.3
+)
+
+// AgentRegistration is what an agent declares about itself at startup: the
+// intents, query types, languages and tools it supports, and how to run it.
+// The manager routes to registered agents only, so adding an agent means
+// registering it rather than editing the manager's scoring.
+type AgentRegistration struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description,omitempty"`
+	Intents     []string           `json:"intents,omitempty"`     // primary intents of the routing analysis, e.g. "search", "generation"
+	QueryTypes  []models.QueryType `json:"query_types,omitempty"` // parsed query types it handles
+	Languages   []string           `json:"languages,omitempty"`   // empty handles every language
+	Tools       []string           `json:"tools,omitempty"`       // capabilities offered: search, generation, analysis, debug, test, documentation, review
+	Keywords    []string           `json:"keywords,omitempty"`    // words in a query that suggest the agent
+	BaseScore   float64            `json:"base_score"`
+
+	// Score rates the agent's fit for a query in place of matching the
+	// declarations above; nil matches them
+	Score func(query *models.Query, analysis *RoutingAnalysis) float64 `json:"-"`
+	// Process answers a query routed to the agent
+	Process func(ctx context.Context, query *models.Query) (*models.Response, error) `json:"-"`
+}
+
+// SupportsLanguage reports whether the agent declared lang, or no languages
+func (r *AgentRegistration) SupportsLanguage(lang string) bool {
+	if len(r.Languages) == 0 || lang == "" {
+		return true
+	}
+	for _, supported := range r.Languages {
+		if supported == "*" || strings.EqualFold(supported, lang) {
+			return true
+		}
+	}
+	return false
+}
+
+// match scores the declarations against a query
+func (r *AgentRegistration) match(query *models.Query, analysis *RoutingAnalysis) float64 {
+	score := r.BaseScore
+	if containsString(r.Intents, analysis.PrimaryIntent) {
+		score += intentMatchWeight
+	}
+	for _, intent := range analysis.SecondaryIntents {
+		if containsString(r.Intents, intent) {
+			score += secondaryIntentWeight
+		}
+	}
+	for _, capability := range analysis.RequiredCapabilities {
+		if containsString(r.Tools, capability) {
+			score += toolMatchWeight
+		}
+	}
+	for _, queryType := range r.QueryTypes {
+		if query.Type == queryType {
+			score += queryTypeWeight
+			break
+		}
+	}
+	input := strings.ToLower(query.UserInput)
+	for _, keyword := range r.Keywords {
+		if strings.Contains(input, keyword) {
+			score += keywordWeight
+		}
+	}
+	return score
+}
+
+// CapabilityRegistry holds the agents the manager can route to
+type CapabilityRegistry struct {
+	mu     sync.RWMutex
+	agents map[string]*AgentRegistration
+	order  []string // registration order, in which agents are listed
+}
+
+// NewCapabilityRegistry creates an empty registry
+func NewCapabilityRegistry() *CapabilityRegistry {
+	return &CapabilityRegistry{agents: make(map[string]*AgentRegistration)}
+}
+
+// Register adds an agent. Names are unique, and an agent must say how it
+// processes queries.
+func (r *CapabilityRegistry) Register(registration AgentRegistration) error {
+	if registration.Name == "" {
+		return fmt.Errorf("agent registration needs a name")
+	}
+	if registration.Process == nil {
+		return fmt.Errorf("agent %s registered without a Process function", registration.Name)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.agents[registration.Name]; exists {
+		return fmt.Errorf("agent %s is already registered", registration.Name)
+	}
+	r.agents[registration.Name] = &registration
+	r.order = append(r.order, registration.Name)
+	return nil
+}
+
+// Lookup returns a registered agent
+func (r *CapabilityRegistry) Lookup(name string) (*AgentRegistration, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	registration, ok := r.agents[name]
+	return registration, ok
+}
+
+// Agents lists the registered agents in registration order
+func (r *CapabilityRegistry) Agents() []AgentRegistration {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	registrations := make([]AgentRegistration, 0, len(r.order))
+	for _, name := range r.order {
+		registrations = append(registrations, *r.agents[name])
+	}
+	return registrations
+}
+
+// Score rates every registered agent's fit for a query: its own scorer or
+// its declarations, less a penalty when it does not support the query's
+// language
+func (r *CapabilityRegistry) Score(query *models.Query, analysis *RoutingAnalysis) map[string]float64 {
+	scores := make(map[string]float64)
+	for _, registration := range r.Agents() {
+		var score float64
+		if registration.Score != nil {
+			score = registration.Score(query, analysis)
+		} else {
+			score = registration.match(query, analysis)
+		}
+		if !registration.SupportsLanguage(query.Language) {
+			score -= unsupportedLanguagePenalty
+		}
+		scores[registration.Name] = score
+	}
+	return scores
+}
+
+// capabilityTools names the tools an agent's capability flags offer
+func capabilityTools(capabilities AgentCapabilities) []string {
+	var tools []string
+	offered := []struct {
+		enabled bool
+		tool    string
+	}{
+		{capabilities.CanSearchCode, "search"},
+		{capabilities.CanGenerateCode, "generation"},
+		{capabilities.CanAnalyzeCode, "analysis"},
+		{capabilities.CanDebugCode, "debug"},
+		{capabilities.CanWriteTests, "test"},
+		{capabilities.CanWriteDocs, "documentation"},
+		{capabilities.CanReviewCode, "review"},
+	}
+	for _, flag := range offered {
+		if flag.enabled {
+			tools = append(tools, flag.tool)
+		}
+	}
+	return tools
+}
@@ -7,17 +7,25 @@ import (
 	"strings"
 	"time"
 
+	"github.com/yourusername/useq-ai-assistant/internal/churn"
+	"github.com/yourusername/useq-ai-assistant/internal/deps"
+	"github.com/yourusername/useq-ai-assistant/internal/language"
 	"github.com/yourusername/useq-ai-assistant/internal/llm"
+	"github.com/yourusername/useq-ai-assistant/internal/logger"
+	"github.com/yourusername/useq-ai-assistant/internal/promptguard"
 	"github.com/yourusername/useq-ai-assistant/internal/vectordb"
+	"github.com/yourusername/useq-ai-assistant/internal/vocabulary"
 	"github.com/yourusername/useq-ai-assistant/models"
 	"github.com/yourusername/useq-ai-assistant/storage"
 )
 
 // SearchAgentImpl implements the search agent using centralized types
 type SearchAgentImpl struct {
-	dependencies *AgentDependencies
-	config       *SearchAgentConfig
-	metrics      *AgentMetrics
+	dependencies  *AgentDependencies
+	config        *SearchAgentConfig
+	metrics       *agentMetrics
+	summarySearch SummarySearchConfig
+	churn         churn.Config
 }
 
 // NewSearchAgentConfig creates a new search agent configuration
@@ -42,18 +50,11 @@ func NewSearchAgentConfig() *SearchAgentConfig {
 // NewSearchAgentImpl creates a new search agent with centralized configuration
 func NewSearchAgent(deps *AgentDependencies) *SearchAgentImpl {
 	return &SearchAgentImpl{
-		dependencies: deps,
-		config:       NewSearchAgentConfig(),
-		metrics: &AgentMetrics{
-			QueriesHandled:      0,
-			SuccessRate:         0.0,
-			AverageResponseTime: 0,
-			AverageConfidence:   0.0,
-			TokensUsed:          0,
-			TotalCost:           0.0,
-			LastUsed:            time.Now(),
-			ErrorCount:          0,
-		},
+		dependencies:  deps,
+		config:        NewSearchAgentConfig(),
+		metrics:       newAgentMetrics("search"),
+		summarySearch: DefaultSummarySearchConfig(),
+		churn:         churn.DefaultConfig(),
 	}
 }
 
@@ -96,8 +97,8 @@ func (sa *SearchAgentImpl) synthesizeWithLLM(ctx context.Context, query *models.
 	contextText := ""
 	for i, result := range searchResults {
 		if i >= 5 { break } // Limit to top 5 results
-		contextText += fmt.Sprintf("\n## File %d: %s\n```\n%s\n```\n", 
-			i+1, result.Chunk.FilePath, result.Chunk.Content)
+		contextText += fmt.Sprintf("\n## File %d: %s\n%s",
+			i+1, result.Chunk.FilePath, promptguard.WrapRetrieved(result.Chunk.FilePath, result.Chunk.Content))
 	}
 	
 	// Build prompt
@@ -245,7 +246,7 @@ func (sa *SearchAgentImpl) Process(ctx context.Context, query *models.Query) (*m
 
 // GetMetrics returns performance metrics for this agent
 func (sa *SearchAgentImpl) GetMetrics() AgentMetrics {
-	return *sa.metrics
+	return sa.metrics.snapshot()
 }
 
 // Search performs intelligent code search (main SearchAgentImpl interface method)
@@ -271,7 +272,7 @@ func (sa *SearchAgentImpl) Search(ctx context.Context, query *models.Query) (*mo
 	// Parse search intent from query
 	intent, err := sa.parseSearchIntent(query)
 	if err != nil {
-		sa.metrics.ErrorCount++
+		sa.metrics.failed()
 		return nil, fmt.Errorf("failed to parse search intent: %w", err)
 	}
 
@@ -314,7 +315,7 @@ func (sa *SearchAgentImpl) Search(ctx context.Context, query *models.Query) (*mo
 	}
 	
 	if err != nil {
-		sa.metrics.ErrorCount++
+		sa.metrics.failed()
 		return nil, fmt.Errorf("search failed: %w", err)
 	}
 
@@ -557,7 +558,10 @@ func (sa *SearchAgentImpl) parseSearchIntent(query *models.Query) (*SearchAgentI
 		Scope:    SearchAgentScope{},
 	}
 
-	input := strings.ToLower(query.UserInput)
+	// Semantic tags narrow the search to one concern: tag:db, "concurrency code"
+	sa.applyTags(intent, query)
+
+	input := strings.ToLower(intent.Query)
 
 	// Determine search type based on query patterns
 	intent.SearchType = sa.determineSearchType(input)
@@ -566,7 +570,7 @@ func (sa *SearchAgentImpl) parseSearchIntent(query *models.Query) (*SearchAgentI
 	intent.FunctionNames = sa.extractFunctionNames(input)
 	intent.TypeNames = sa.extractTypeNames(input)
 	intent.FilePatterns = sa.extractFilePatterns(input)
-	intent.Keywords = sa.extractKeywords(input)
+	intent.Keywords = sa.vocabulary().Expand(sa.extractKeywords(input))
 
 	// Determine search characteristics
 	intent.ExactMatch = sa.detectExactMatch(input)
@@ -575,11 +579,15 @@ func (sa *SearchAgentImpl) parseSearchIntent(query *models.Query) (*SearchAgentI
 	// Build scope
 	intent.Scope = sa.buildSearchScope(input, query.Language)
 
-	// Add language filter
-	if query.Language != "" {
+	// Add language filter only when the query itself pinned the language;
+	// the project default must not hide other languages in mixed repos
+	if query.Language != "" && query.Metadata["language_source"] != language.SourceDefault {
 		intent.Filters["language"] = query.Language
 	}
 
+	intent.IncludeDeps = query.Metadata["include_deps"] == "true"
+	intent.RetrievalMode = RetrievalModeOf(query)
+
 	return intent, nil
 }
 
@@ -587,8 +595,8 @@ func (sa *SearchAgentImpl) parseSearchIntent(query *models.Query) (*SearchAgentI
 func (sa *SearchAgentImpl) performMultiStrategySearch(ctx context.Context, intent *SearchAgentIntent, searchContext *SearchAgentContext) ([]*SearchAgentResult, error) {
 	var allResults []*SearchAgentResult
 
-	fmt.Printf("🔍 DEBUG: Starting multi-strategy search\n")
-	fmt.Printf("🔍 DEBUG: SemanticSearch enabled: %v\n", sa.config.SemanticSearch)
+	logger.Debugf(ctx, logger.ComponentAgent, "🔍 Starting multi-strategy search")
+	logger.Debugf(ctx, logger.ComponentAgent, "🔍 SemanticSearch enabled: %v", sa.config.SemanticSearch)
 
 	sa.logStep("Starting multi-strategy search", map[string]interface{}{
 		"semantic_enabled": sa.config.SemanticSearch,
@@ -598,14 +606,14 @@ func (sa *SearchAgentImpl) performMultiStrategySearch(ctx context.Context, inten
 
 	// 1. Semantic Search (if enabled and VectorDB available)
 	if sa.config.SemanticSearch {
-		fmt.Printf("🔍 DEBUG: Calling performSemanticSearch\n")
+		logger.Debugf(ctx, logger.ComponentAgent, "🔍 Calling performSemanticSearch")
 		semanticResults, err := sa.performSemanticSearch(ctx, intent, searchContext)
 		if err != nil {
-			fmt.Printf("❌ DEBUG: Semantic search failed: %v\n", err)
+			logger.Verbosef(ctx, logger.ComponentAgent, "❌ Semantic search failed: %v", err)
 			// Don't return error, continue with other search methods
 		} else {
 			allResults = append(allResults, semanticResults...)
-			fmt.Printf("✅ DEBUG: Semantic search added %d results\n", len(semanticResults))
+			logger.Verbosef(ctx, logger.ComponentAgent, "✅ Semantic search added %d results", len(semanticResults))
 		}
 	}
 
@@ -667,6 +675,9 @@ func (sa *SearchAgentImpl) performMultiStrategySearch(ctx context.Context, inten
 
 	// Deduplicate and merge results
 	dedupResults := sa.deduplicateResults(allResults)
+	if len(intent.Tags) > 0 {
+		dedupResults = filterByTags(dedupResults, intent.Tags)
+	}
 
 	sa.logStep("Deduplicated search results", map[string]interface{}{
 		"original_count":     len(allResults),
@@ -686,33 +697,43 @@ func (sa *SearchAgentImpl) performMultiStrategySearch(ctx context.Context, inten
 
 // performSemanticSearch performs vector-based semantic search
 func (sa *SearchAgentImpl) performSemanticSearch(ctx context.Context, intent *SearchAgentIntent, searchContext *SearchAgentContext) ([]*SearchAgentResult, error) {
-	fmt.Printf("🔍 DEBUG: Starting semantic search for query: %s\n", intent.Query)
+	logger.Debugf(ctx, logger.ComponentAgent, "🔍 Starting semantic search for query: %s", intent.Query)
 
+	if sa.dependencies != nil && sa.dependencies.VectorDB == nil && sa.dependencies.Storage != nil {
+		// Keyword-only mode: chunks were indexed by keyword instead
+		return sa.performKeywordIndexSearch(ctx, intent)
+	}
 	if sa.dependencies == nil || sa.dependencies.VectorDB == nil {
-		fmt.Printf("⚠️  DEBUG: VectorDB not available, skipping semantic search\n")
+		logger.Verbosef(ctx, logger.ComponentAgent, "⚠️ VectorDB not available, skipping semantic search")
 		return []*SearchAgentResult{}, nil // Return empty results instead of crashing
 	}
 
-	// Try vector search first
-	vectorResults, err := sa.dependencies.VectorDB.Search(ctx, intent.Query, sa.config.MaxResults)
+	// Try vector search first, with the project's synonyms for query terms
+	query := sa.vocabulary().ExpandQuery(intent.Query)
+	vectorResults, err := sa.dependencies.VectorDB.SearchWithFilter(ctx, query, sa.config.MaxResults, intent.Filters)
+	if err == nil && len(vectorResults) == 0 && intent.Filters["tags"] != "" {
+		// Chunks indexed before tagging carry no tags; search untagged and
+		// let the tag filter classify what comes back
+		vectorResults, err = sa.dependencies.VectorDB.SearchWithFilter(ctx, query, sa.config.MaxResults, withoutTags(intent.Filters))
+	}
 	if err != nil {
-		fmt.Printf("❌ DEBUG: Vector search failed: %v\n", err)
-		fmt.Printf("🔍 DEBUG: Falling back to storage-based search\n")
+		logger.Verbosef(ctx, logger.ComponentAgent, "❌ Vector search failed: %v", err)
+		logger.Verbosef(ctx, logger.ComponentAgent, "🔍 Falling back to storage-based search")
 
 		// Fallback to storage-based search using indexed chunks
 		return sa.performStorageBasedSearch(ctx, intent, searchContext)
 	}
 
-	fmt.Printf("✅ DEBUG: Vector search returned %d results\n", len(vectorResults))
+	logger.Verbosef(ctx, logger.ComponentAgent, "✅ Vector search returned %d results", len(vectorResults))
 
 	// Convert vector results to search results with quality filtering
 	results := make([]*SearchAgentResult, 0, len(vectorResults))
-	fmt.Printf("🔍 DEBUG: Similarity threshold: %f\n", sa.config.SimilarityThreshold)
+	logger.Debugf(ctx, logger.ComponentAgent, "🔍 Similarity threshold: %f", sa.config.SimilarityThreshold)
 
-	queryLower := strings.ToLower(intent.Query)
+	queryLower := strings.ToLower(query)
 
 	for i, vr := range vectorResults {
-		fmt.Printf("🔍 DEBUG: Result %d score: %f (threshold: %f)\n", i, vr.Score, sa.config.SimilarityThreshold)
+		logger.Debugf(ctx, logger.ComponentAgent, "🔍 Result %d score: %f (threshold: %f)", i, vr.Score, sa.config.SimilarityThreshold)
 
 		// Content relevance check
 		contentLower := strings.ToLower(vr.Chunk.Content)
@@ -736,14 +757,28 @@ func (sa *SearchAgentImpl) performSemanticSearch(ctx context.Context, intent *Se
 			result := sa.convertVectorResult(vr)
 			result.ChunkType = "semantic"
 			result.Score = float64(adjustedScore)
+			result.Metadata[rankStrategy] = "semantic"
+			setRankFactor(result, rankSimilarity, float64(vr.Score))
+			setRankFactor(result, rankKeywordHits, float64(matchCount))
+			setRankFactor(result, rankKeywordBoost, relevanceBoost)
 
 			results = append(results, result)
-			fmt.Printf("✅ DEBUG: Added result %d (boosted: +%.2f)\n", i, relevanceBoost)
+			logger.Debugf(ctx, logger.ComponentAgent, "✅ Added result %d (boosted: +%.2f)", i, relevanceBoost)
 		} else {
-			fmt.Printf("❌ DEBUG: Skipped result %d (score too low)\n", i)
+			logger.Debugf(ctx, logger.ComponentAgent, "❌ Skipped result %d (score too low)", i)
 		}
 	}
 
+	// Dependency source is only searched when the query opts in
+	if intent.IncludeDeps {
+		results = append(results, sa.searchDependencies(ctx, intent)...)
+	}
+
+	// Questions about whole files or packages also get their summaries
+	if intent.RetrievalMode != models.RetrievalChunk {
+		results = append(results, sa.searchSummaries(ctx, intent)...)
+	}
+
 	// Sort by score descending for best results first
 	for i := 0; i < len(results)-1; i++ {
 		for j := i + 1; j < len(results); j++ {
@@ -758,16 +793,16 @@ func (sa *SearchAgentImpl) performSemanticSearch(ctx context.Context, intent *Se
 
 // performStorageBasedSearch searches indexed chunks from storage
 func (sa *SearchAgentImpl) performStorageBasedSearch(ctx context.Context, intent *SearchAgentIntent, searchContext *SearchAgentContext) ([]*SearchAgentResult, error) {
-	fmt.Printf("🔍 DEBUG: Performing storage-based search\n")
+	logger.Debugf(ctx, logger.ComponentAgent, "🔍 Performing storage-based search")
 
 	if sa.dependencies == nil || sa.dependencies.Storage == nil {
-		fmt.Printf("⚠️  DEBUG: Storage not available, returning empty results\n")
+		logger.Verbosef(ctx, logger.ComponentAgent, "⚠️ Storage not available, returning empty results")
 		return []*SearchAgentResult{}, nil // Return empty results instead of crashing
 	}
 	// Get database stats first
 	stats, err := sa.dependencies.Storage.GetStats()
 	if err == nil {
-		fmt.Printf("📊 DEBUG: Database stats: %+v\n", stats)
+		logger.Debugf(ctx, logger.ComponentAgent, "📊 Database stats: %+v", stats)
 	}
 
 	// Try to search for functions with any keyword from the query
@@ -781,11 +816,11 @@ func (sa *SearchAgentImpl) performStorageBasedSearch(ctx context.Context, intent
 
 		functions, err := sa.dependencies.Storage.SearchFunctions(keyword)
 		if err != nil {
-			fmt.Printf("❌ DEBUG: Failed to search functions for '%s': %v\n", keyword, err)
+			logger.Debugf(ctx, logger.ComponentAgent, "❌ Failed to search functions for '%s': %v", keyword, err)
 			continue
 		}
 
-		fmt.Printf("✅ DEBUG: Found %d functions for keyword '%s'\n", len(functions), keyword)
+		logger.Debugf(ctx, logger.ComponentAgent, "✅ Found %d functions for keyword '%s'", len(functions), keyword)
 
 		// Convert functions to search results
 		for _, function := range functions {
@@ -814,7 +849,7 @@ func (sa *SearchAgentImpl) performStorageBasedSearch(ctx context.Context, intent
 	if len(results) == 0 {
 		files, err := sa.dependencies.Storage.GetIndexedFiles()
 		if err == nil {
-			fmt.Printf("📁 DEBUG: Found %d indexed files\n", len(files))
+			logger.Debugf(ctx, logger.ComponentAgent, "📁 Found %d indexed files", len(files))
 			// Create results from file paths
 			for i, file := range files {
 				if i >= 3 { // Limit to 3 files for demo
@@ -833,7 +868,7 @@ func (sa *SearchAgentImpl) performStorageBasedSearch(ctx context.Context, intent
 		}
 	}
 
-	fmt.Printf("✅ DEBUG: Storage search returned %d results\n", len(results))
+	logger.Verbosef(ctx, logger.ComponentAgent, "✅ Storage search returned %d results", len(results))
 	return results, nil
 }
 
@@ -884,7 +919,10 @@ func (sa *SearchAgentImpl) performExactSearch(ctx context.Context, intent *Searc
 		for _, function := range functions {
 			if function.Name == funcName {
 				result := sa.convertFunctionResult(function, 0.98) // Very high confidence for exact matches
+				result.Metadata[rankStrategy] = "exact"
+				setRankFactor(result, rankSimilarity, result.Score)
 				result.Score += float64(sa.config.ExactMatchBonus)
+				setRankFactor(result, rankExactBonus, float64(sa.config.ExactMatchBonus))
 				result.ChunkType = "exact"
 				results = append(results, result)
 			}
@@ -1054,12 +1092,14 @@ func (sa *SearchAgentImpl) extractKeywords(input string) []string {
 		"with": true, "by": true, "is": true, "are": true, "was": true, "were": true,
 	}
 
+	vocab := sa.vocabulary()
 	words := strings.Fields(strings.ToLower(input))
 	var keywords []string
 
 	for _, word := range words {
 		cleaned := strings.Trim(word, ".,!?;:()[]{}\"'")
-		if len(cleaned) > 2 && !stopWords[cleaned] && sa.isValidKeyword(cleaned) {
+		if vocab.IsProtected(cleaned) ||
+			len(cleaned) > 2 && !vocab.IsStopWord(cleaned, stopWords) && sa.isValidKeyword(cleaned) {
 			keywords = append(keywords, cleaned)
 		}
 	}
@@ -1067,6 +1107,14 @@ func (sa *SearchAgentImpl) extractKeywords(input string) []string {
 	return keywords
 }
 
+// vocabulary returns the project vocabulary, nil when none is configured
+func (sa *SearchAgentImpl) vocabulary() *vocabulary.Vocabulary {
+	if sa.dependencies == nil {
+		return nil
+	}
+	return sa.dependencies.Vocabulary
+}
+
 func (sa *SearchAgentImpl) detectExactMatch(input string) bool {
 	return strings.Contains(input, "exact") || strings.Contains(input, "\"")
 }
@@ -1172,8 +1220,64 @@ func (sa *SearchAgentImpl) convertVectorResult(vr *vectordb.SearchResult) *Searc
 		ChunkType: sa.classifyChunk(vr.Chunk.Content),
 		Language:  vr.Chunk.Language,
 		Package:   sa.extractPackageName(vr.Chunk.FilePath),
-		Metadata:  map[string]string{"content": content},
+		Metadata:  map[string]string{"content": content, "origin": vr.Chunk.Origin, "module": vr.Chunk.Module, "tags": strings.Join(vr.Chunk.Tags, ",")},
+	}
+}
+
+// searchDependencies runs the semantic query against indexed dependency
+// source, labelling every hit with its module
+func (sa *SearchAgentImpl) searchDependencies(ctx context.Context, intent *SearchAgentIntent) []*SearchAgentResult {
+	if sa.dependencies.DepsVectorDB == nil {
+		sa.logStep("Dependency search requested but not enabled", nil)
+		return nil
+	}
+
+	vectorResults, err := sa.dependencies.DepsVectorDB.SearchWithFilter(ctx, intent.Query, sa.config.MaxResults, intent.Filters)
+	if err != nil {
+		sa.logStep("Dependency search failed", map[string]interface{}{"error": err.Error()})
+		return nil
+	}
+
+	results := make([]*SearchAgentResult, 0, len(vectorResults))
+	for _, vr := range vectorResults {
+		if vr.Score < sa.config.SimilarityThreshold {
+			continue
+		}
+		result := sa.convertVectorResult(vr)
+		result.ChunkType = "dependency"
+		result.Metadata["origin"] = deps.Origin
+		results = append(results, result)
+	}
+	return results
+}
+
+// searchSummaries runs the semantic query against the file or package
+// summaries the retrieval mode asks for
+func (sa *SearchAgentImpl) searchSummaries(ctx context.Context, intent *SearchAgentIntent) []*SearchAgentResult {
+	if !sa.summarySearch.Enabled || sa.dependencies.SummaryVectorDB == nil {
+		return nil
 	}
+
+	filters := map[string]string{"origin": summaryOrigin(intent.RetrievalMode)}
+	vectorResults, err := sa.dependencies.SummaryVectorDB.SearchWithFilter(ctx, intent.Query, sa.summarySearch.MaxResults, filters)
+	if err != nil {
+		sa.logStep("Summary search failed", map[string]interface{}{"error": err.Error()})
+		return nil
+	}
+
+	results := make([]*SearchAgentResult, 0, len(vectorResults))
+	for _, vr := range vectorResults {
+		if vr.Score < sa.summarySearch.MinScore {
+			continue
+		}
+		result := sa.convertVectorResult(vr)
+		result.ChunkType = vr.Chunk.Origin
+		result.Function = ""
+		result.Metadata[rankStrategy] = vr.Chunk.Origin
+		setRankFactor(result, rankSimilarity, float64(vr.Score))
+		results = append(results, result)
+	}
+	return results
 }
 
 func (sa *SearchAgentImpl) extractFunctionName(content string) string {
@@ -1273,25 +1377,32 @@ func (sa *SearchAgentImpl) rerankResults(results []*SearchAgentResult, intent *S
 		return results[i].Score > results[j].Score
 	})
 
-	// Apply additional ranking factors
+	// Apply additional ranking factors, recording each so the ranking can
+	// be explained later
 	for i, result := range results {
+		recordBaseScore(result)
+
 		// Boost exact matches
 		if sa.isExactMatch(result, intent) {
 			result.Score += float64(sa.config.ExactMatchBonus)
+			addRankFactor(result, rankExactBonus, float64(sa.config.ExactMatchBonus))
 		}
 
 		// Boost based on search type preference
 		if sa.matchesSearchType(result, intent.SearchType) {
 			result.Score += 0.05
+			setRankFactor(result, rankTypeBoost, 0.05)
 		}
 
-		// Boost recent files
-		if sa.isRecentFile(result) {
-			result.Score += 0.02
+		// Boost code that changed often and recently
+		if boost := sa.churnBoost(result); boost > 0 {
+			result.Score += boost
+			setRankFactor(result, rankRecency, boost)
 		}
 
 		// Penalty for very low scores
 		if result.Score < 0.4 {
+			setRankFactor(result, rankPenalty, -result.Score*0.2)
 			result.Score *= 0.8
 		}
 
@@ -1348,6 +1459,7 @@ func (sa *SearchAgentImpl) deduplicateResults(results []*SearchAgentResult) []*S
 	for _, result := range seen {
 		deduped = append(deduped, result)
 	}
+	deduped = sa.collapseCopies(deduped)
 
 	// Sort by score
 	sort.Slice(deduped, func(i, j int) bool {
@@ -1357,6 +1469,92 @@ func (sa *SearchAgentImpl) deduplicateResults(results []*SearchAgentResult) []*S
 	return deduped
 }
 
+// minCopyLength is the shortest code collapseCopies treats as a copy;
+// shorter bodies, such as one-line getters, are identical by coincidence
+const minCopyLength = 80
+
+// collapseCopies keeps one of the results whose code is identical in several
+// files, such as vendored or generated copies: the copy answers retrieve and
+// cite most, then the best scored. The kept result takes the best score and
+// lists the other copies in its duplicates metadata.
+func (sa *SearchAgentImpl) collapseCopies(results []*SearchAgentResult) []*SearchAgentResult {
+	groups := make(map[string][]*SearchAgentResult)
+	for _, result := range results {
+		code := strings.TrimSpace(result.Context)
+		if result.Function == "" || len(code) < minCopyLength {
+			continue
+		}
+		key := result.Function + "\x00" + code
+		groups[key] = append(groups[key], result)
+	}
+
+	var paths []string
+	for _, group := range groups {
+		if len(group) > 1 {
+			for _, result := range group {
+				paths = append(paths, result.File)
+			}
+		}
+	}
+	if len(paths) == 0 {
+		return results
+	}
+	heat := make(map[storage.ChunkKey]float64)
+	if sa.dependencies != nil && sa.dependencies.Storage != nil {
+		if scores, err := sa.dependencies.Storage.ChunkScores(paths); err == nil {
+			heat = scores
+		}
+	}
+
+	dropped := make(map[*SearchAgentResult]bool)
+	for _, group := range groups {
+		if len(group) < 2 {
+			continue
+		}
+		keep, best := group[0], group[0].Score
+		for _, result := range group[1:] {
+			best = max(best, result.Score)
+			resultHeat := heat[storage.ChunkKey{Path: result.File, Line: result.Line}]
+			keepHeat := heat[storage.ChunkKey{Path: keep.File, Line: keep.Line}]
+			if resultHeat > keepHeat || (resultHeat == keepHeat && result.Score > keep.Score) {
+				keep = result
+			}
+		}
+		var copies []string
+		for _, result := range group {
+			if result != keep {
+				dropped[result] = true
+				copies = append(copies, fmt.Sprintf("%s:%d", result.File, result.Line))
+			}
+		}
+		keep.Score = best
+		if keep.Metadata == nil {
+			keep.Metadata = make(map[string]string)
+		}
+		sort.Strings(copies)
+		keep.Metadata["duplicates"] = strings.Join(copies, ",")
+	}
+
+	collapsed := make([]*SearchAgentResult, 0, len(results)-len(dropped))
+	for _, result := range results {
+		if !dropped[result] {
+			collapsed = append(collapsed, result)
+		}
+	}
+	sa.logStep("Collapsed identical code in several files", map[string]interface{}{
+		"collapsed": len(dropped),
+	})
+	return collapsed
+}
+
+// resultDuplicates lists the copies collapseCopies folded into a result
+func resultDuplicates(result *SearchAgentResult) []string {
+	if copies := result.Metadata["duplicates"]; copies != "" {
+		return strings.Split(copies, ",")
+	}
+	return nil
+}
+
 // Response building
 
 func (sa *SearchAgentImpl) buildSearchResponse(query *models.Query, intent *SearchAgentIntent,
@@ -1454,25 +1652,11 @@ func (sa *SearchAgentImpl) logStep(message string, fields map[string]interface{}
 }
 
 func (sa *SearchAgentImpl) updateMetrics(startTime time.Time) {
-	sa.metrics.QueriesHandled++
-	sa.metrics.LastUsed = startTime
+	sa.metrics.started(startTime)
 }
 
 func (sa *SearchAgentImpl) updateSuccessMetrics(startTime time.Time, confidence float64, resultCount int) {
-	duration := time.Since(startTime)
-
-	// Update running averages
-	total := float64(sa.metrics.QueriesHandled)
-	sa.metrics.AverageResponseTime = time.Duration(
-		(float64(sa.metrics.AverageResponseTime)*(total-1) + float64(duration)) / total,
-	)
-	sa.metrics.AverageConfidence = (sa.metrics.AverageConfidence*(total-1) + confidence) / total
-
-	// Calculate success rate (successful if we got results)
-	if resultCount > 0 {
-		successCount := float64(sa.metrics.QueriesHandled - sa.metrics.ErrorCount)
-		sa.metrics.SuccessRate = successCount / total
-	}
+	sa.metrics.succeeded(time.Since(startTime), confidence, 0, 0)
 }
 
 func (sa *SearchAgentImpl) convertToResponseResults(results []*SearchAgentResult) []models.SearchResult {
@@ -1487,6 +1671,11 @@ func (sa *SearchAgentImpl) convertToResponseResults(results []*SearchAgentResult
 			Context:     result.Context,
 			Explanation: result.Explanation,
 			Usage:       sa.convertUsageExamples(result.Usage),
+			Origin:      result.Metadata["origin"],
+			Module:      result.Metadata["module"],
+			Tags:        resultTags(result),
+			Ranking:     rankingFactors(result),
+			Duplicates:  resultDuplicates(result),
 		}
 	}
 
@@ -1603,10 +1792,6 @@ func (sa *SearchAgentImpl) matchesSearchType(result *SearchAgentResult, searchTy
 	}
 }
 
-func (sa *SearchAgentImpl) isRecentFile(result *SearchAgentResult) bool {
-	// Placeholder for file recency check. Integrate with file metadata/timestamps when available.
-	return false
-}
 
 func (sa *SearchAgentImpl) findUsageExamples(ctx context.Context, functionName string) []UsageExample {
 	// Placeholder - would implement actual usage search (likely using Storage)
@@ -1736,11 +1921,11 @@ func (sa *SearchAgentImpl) buildLLMEnhancedResponse(query *models.Query, intent
 			break
 		}
 		contextBuilder.WriteString(fmt.Sprintf("%d. File: %s\n", i+1, result.File))
-		if len(result.Context) > 200 {
-			contextBuilder.WriteString(fmt.Sprintf("   Content: %s...\n", result.Context[:200]))
-		} else {
-			contextBuilder.WriteString(fmt.Sprintf("   Content: %s\n", result.Context))
+		content := result.Context
+		if len(content) > 200 {
+			content = content[:200] + "..."
 		}
+		contextBuilder.WriteString(promptguard.WrapRetrieved(result.File, content))
 		contextBuilder.WriteString(fmt.Sprintf("   Score: %.2f\n\n", result.Score))
 	}
 
@@ -2,6 +2,8 @@ package agents
 
 import (
 	"time"
+
+	"github.com/yourusername/useq-ai-assistant/models"
 )
 
 // =============================================================================
@@ -54,6 +56,9 @@ type SearchAgentIntent struct {
 	Scope         SearchAgentScope       `json:"scope"`
 	Context       map[string]interface{} `json:"context"`
 	Precision     float64                `json:"precision"`
+	IncludeDeps   bool                   `json:"include_deps"`   // also search dependency source
+	Tags          []string               `json:"tags,omitempty"` // semantic tags every result must carry
+	RetrievalMode models.RetrievalMode   `json:"retrieval_mode"` // chunk, or file or package summaries too
 }
 
 // SearchAgentType represents different types of search
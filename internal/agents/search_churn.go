@@ -0,0 +1,35 @@
+package agents
+
+import (
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/churn"
+)
+
+// SetChurnConfig replaces the settings weighing git churn in reranking
+func (sa *SearchAgentImpl) SetChurnConfig(config churn.Config) {
+	sa.churn = config
+}
+
+// SetChurnConfig replaces the search agent's git churn settings
+func (ma *ManagerAgent) SetChurnConfig(config churn.Config) {
+	if ma.SearchAgent != nil {
+		ma.SearchAgent.SetChurnConfig(config)
+	}
+}
+
+// churnBoost is the score a result earns for how often and how recently
+// its chunk, or else its file, changed. The churn recorded at indexing
+// keeps decaying until the next run, so code left alone since then slowly
+// loses its boost.
+func (sa *SearchAgentImpl) churnBoost(result *SearchAgentResult) float64 {
+	if !sa.churn.Enabled || sa.churn.Weight <= 0 || sa.dependencies == nil || sa.dependencies.Storage == nil {
+		return 0
+	}
+	record, err := sa.dependencies.Storage.GetChurn(result.File, result.Line)
+	if err != nil || record == nil {
+		return 0
+	}
+	score := record.Score * churn.Decay(record.ComputedAt, time.Now(), sa.churn.HalfLife)
+	return churn.Boost(score, sa.churn.Weight)
+}
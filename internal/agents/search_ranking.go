@@ -0,0 +1,69 @@
+package agents
+
+import (
+	"strconv"
+
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// Metadata keys holding the factors that ranked a search result, so a
+// result's position can be explained after the search
+const (
+	rankStrategy     = "rank_strategy"
+	rankSimilarity   = "rank_similarity"
+	rankKeywordHits  = "rank_keyword_hits"
+	rankKeywordBoost = "rank_keyword_boost"
+	rankExactBonus   = "rank_exact_bonus"
+	rankTypeBoost    = "rank_type_boost"
+	rankRecency      = "rank_recency"
+	rankPenalty      = "rank_penalty"
+)
+
+// setRankFactor records a ranking factor on a result
+func setRankFactor(result *SearchAgentResult, key string, value float64) {
+	if result.Metadata == nil {
+		result.Metadata = make(map[string]string)
+	}
+	result.Metadata[key] = strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// addRankFactor adds to a ranking factor recorded on a result
+func addRankFactor(result *SearchAgentResult, key string, value float64) {
+	setRankFactor(result, key, rankFactor(result, key)+value)
+}
+
+// rankFactor returns a recorded ranking factor, or 0
+func rankFactor(result *SearchAgentResult, key string) float64 {
+	value, _ := strconv.ParseFloat(result.Metadata[key], 64)
+	return value
+}
+
+// recordBaseScore records the score a strategy gave a result before any
+// boosts, unless the strategy already recorded its own breakdown
+func recordBaseScore(result *SearchAgentResult) {
+	if _, ok := result.Metadata[rankSimilarity]; ok {
+		return
+	}
+	setRankFactor(result, rankSimilarity, result.Score)
+	if _, ok := result.Metadata[rankStrategy]; !ok && result.ChunkType != "" {
+		result.Metadata[rankStrategy] = result.ChunkType
+	}
+}
+
+// rankingFactors returns the recorded breakdown of a result's score, or nil
+// if none was recorded
+func rankingFactors(result *SearchAgentResult) *models.RankingFactors {
+	if _, ok := result.Metadata[rankSimilarity]; !ok {
+		return nil
+	}
+	return &models.RankingFactors{
+		Strategy:     result.Metadata[rankStrategy],
+		Similarity:   rankFactor(result, rankSimilarity),
+		KeywordHits:  int(rankFactor(result, rankKeywordHits)),
+		KeywordBoost: rankFactor(result, rankKeywordBoost),
+		ExactBonus:   rankFactor(result, rankExactBonus),
+		TypeBoost:    rankFactor(result, rankTypeBoost),
+		Recency:      rankFactor(result, rankRecency),
+		Penalty:      rankFactor(result, rankPenalty),
+	}
+}
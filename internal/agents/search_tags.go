@@ -0,0 +1,71 @@
+package agents
+
+import (
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/internal/semtags"
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// applyTags restricts a search to the semantic tags the query names, with
+// tag:x terms or "<concern> code", or that the caller passed in the "tags"
+// metadata. Explicit tag:x terms are removed from the query text.
+func (sa *SearchAgentImpl) applyTags(intent *SearchAgentIntent, query *models.Query) {
+	tags, rest := semtags.ParseQuery(query.UserInput)
+	if requested := query.Metadata["tags"]; requested != "" {
+		tags = semtags.Merge(tags, strings.Split(requested, ","))
+	}
+	if len(tags) == 0 {
+		return
+	}
+	intent.Tags = tags
+	intent.Filters["tags"] = strings.Join(tags, ",")
+	if rest != "" {
+		intent.Query = rest
+	}
+	sa.logStep("Restricted search to tags", map[string]interface{}{"tags": tags})
+}
+
+// filterByTags keeps the results carrying every tag. Results without stored
+// tags, found by keyword or from chunks indexed before tagging, are
+// classified from their text.
+func filterByTags(results []*SearchAgentResult, tags []string) []*SearchAgentResult {
+	kept := results[:0]
+	for _, result := range results {
+		have := make(map[string]bool)
+		for _, tag := range resultTags(result) {
+			have[tag] = true
+		}
+		matches := true
+		for _, tag := range tags {
+			if !have[tag] {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			kept = append(kept, result)
+		}
+	}
+	return kept
+}
+
+// resultTags returns the tags of a result, classifying its text when none
+// were stored
+func resultTags(result *SearchAgentResult) []string {
+	if stored := result.Metadata["tags"]; stored != "" {
+		return strings.Split(stored, ",")
+	}
+	return semtags.Classify(result.Context, 0)
+}
+
+// withoutTags copies filters without the tag filter
+func withoutTags(filters map[string]string) map[string]string {
+	copied := make(map[string]string, len(filters))
+	for key, value := range filters {
+		if key != "tags" {
+			copied[key] = value
+		}
+	}
+	return copied
+}
@@ -0,0 +1,14 @@
+package app
+
+import (
+	"github.com/yourusername/useq-ai-assistant/internal/agents"
+)
+
+// RegisteredAgents lists the agents the manager routes queries to, with the
+// intents, languages and tools each declared
+func (app *CLIApplication) RegisteredAgents() []agents.AgentRegistration {
+	if app.managerAgent == nil {
+		return nil
+	}
+	return app.managerAgent.RegisteredAgents()
+}
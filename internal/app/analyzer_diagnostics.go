@@ -0,0 +1,163 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/diagnostics"
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// lspCollectTimeout bounds how long a language server is given to publish
+// its diagnostics
+const lspCollectTimeout = 2 * time.Minute
+
+// IngestAnalyzerOutput reads a file of gopls or golangci-lint output and
+// replaces what each source in it reported before. source overrides the
+// tool detected from the format. It returns the count per source.
+func (app *CLIApplication) IngestAnalyzerOutput(path, source string) (map[string]int, error) {
+	if app.storage == nil {
+		return nil, fmt.Errorf("analyzer diagnostics need storage")
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(app.config.ProjectRoot, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read analyzer output: %w", err)
+	}
+	parsed, err := diagnostics.ParseAnalyzerOutput(data, app.projectRootAbs(), source)
+	if err != nil {
+		return nil, err
+	}
+	if len(parsed) == 0 {
+		if source == "" {
+			source = diagnostics.DetectSource(data)
+		}
+		if source == "" {
+			return nil, fmt.Errorf("no diagnostics in %s; give --source to clear a tool's earlier findings", app.projectRelative(path))
+		}
+		return map[string]int{source: 0}, app.storage.ReplaceAnalyzerDiagnostics(source, nil)
+	}
+	return app.saveAnalyzerDiagnostics(parsed)
+}
+
+// IngestLanguageServer collects the diagnostics a running language server
+// publishes for the project and replaces what it reported before
+func (app *CLIApplication) IngestLanguageServer(ctx context.Context, address string) (map[string]int, error) {
+	if app.storage == nil {
+		return nil, fmt.Errorf("analyzer diagnostics need storage")
+	}
+	ctx, cancel := context.WithTimeout(ctx, lspCollectTimeout)
+	defer cancel()
+	collected, err := diagnostics.CollectLSP(ctx, address, app.projectRootAbs())
+	if err != nil {
+		return nil, err
+	}
+	if len(collected) == 0 {
+		return map[string]int{diagnostics.SourceGopls: 0}, app.storage.ReplaceAnalyzerDiagnostics(diagnostics.SourceGopls, nil)
+	}
+	return app.saveAnalyzerDiagnostics(collected)
+}
+
+// RefreshAnalyzerDiagnostics ingests the configured analyzer output file
+// and language server, whichever are set
+func (app *CLIApplication) RefreshAnalyzerDiagnostics(ctx context.Context) (map[string]int, error) {
+	config := app.config.AnalyzerDiagnostics
+	if config.File == "" && config.Socket == "" {
+		return nil, fmt.Errorf("set analyzer_diagnostics.file or analyzer_diagnostics.socket, or ingest a file with 'diagnostics ingest <file>'")
+	}
+	counts := make(map[string]int)
+	if config.File != "" {
+		ingested, err := app.IngestAnalyzerOutput(config.File, "")
+		if err != nil {
+			return nil, err
+		}
+		for source, count := range ingested {
+			counts[source] += count
+		}
+	}
+	if config.Socket != "" {
+		ingested, err := app.IngestLanguageServer(ctx, config.Socket)
+		if err != nil {
+			return counts, err
+		}
+		for source, count := range ingested {
+			counts[source] += count
+		}
+	}
+	return counts, nil
+}
+
+// AnalyzerDiagnostics returns the ingested diagnostics, of one file when
+// file is set
+func (app *CLIApplication) AnalyzerDiagnostics(file string) ([]*storage.AnalyzerDiagnostic, error) {
+	if app.storage == nil {
+		return nil, fmt.Errorf("analyzer diagnostics need storage")
+	}
+	if file != "" {
+		file = app.projectRelative(file)
+	}
+	return app.storage.ListAnalyzerDiagnostics(storage.AnalyzerDiagnosticFilter{File: file})
+}
+
+// refreshAnalyzerDiagnostics re-ingests the configured analyzer output
+// file before a query when it changed since it was last read
+func (app *CLIApplication) refreshAnalyzerDiagnostics() {
+	config := app.config.AnalyzerDiagnostics
+	if !config.Enabled || config.File == "" || app.storage == nil {
+		return
+	}
+	path := config.File
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(app.config.ProjectRoot, path)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+
+	app.diagnosticsMu.Lock()
+	defer app.diagnosticsMu.Unlock()
+	if info.ModTime().Equal(app.diagnosticsFileStamp) {
+		return
+	}
+	counts, err := app.IngestAnalyzerOutput(path, "")
+	if err != nil {
+		app.logWarning("DIAGNOSTICS", fmt.Sprintf("Failed to ingest %s: %v", config.File, err))
+	} else {
+		app.logInfo("DIAGNOSTICS", fmt.Sprintf("Ingested analyzer output from %s: %s", config.File, diagnostics.FormatCounts(counts)))
+	}
+	app.diagnosticsFileStamp = info.ModTime()
+}
+
+// saveAnalyzerDiagnostics names the enclosing indexed functions and stores
+// the diagnostics, replacing each source's earlier ones
+func (app *CLIApplication) saveAnalyzerDiagnostics(parsed []*storage.AnalyzerDiagnostic) (map[string]int, error) {
+	diagnostics.MapToIndex(app.storage, app.projectRootAbs(), parsed)
+	bySource := make(map[string][]*storage.AnalyzerDiagnostic)
+	for _, diagnostic := range parsed {
+		bySource[diagnostic.Source] = append(bySource[diagnostic.Source], diagnostic)
+	}
+	counts := make(map[string]int, len(bySource))
+	for source, list := range bySource {
+		if err := app.storage.ReplaceAnalyzerDiagnostics(source, list); err != nil {
+			return counts, err
+		}
+		counts[source] = len(list)
+	}
+	return counts, nil
+}
+
+// projectRootAbs returns the absolute project root, or the configured one
+// when it cannot be resolved
+func (app *CLIApplication) projectRootAbs() string {
+	root, err := filepath.Abs(app.config.ProjectRoot)
+	if err != nil {
+		return app.config.ProjectRoot
+	}
+	return root
+}
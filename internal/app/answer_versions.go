@@ -0,0 +1,124 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// staleHistoryWindow is how many earlier answers are checked for staleness
+const staleHistoryWindow = 10
+
+// tagIndexGeneration records the index generation a response was built from
+// and flags earlier answers in the session that predate it
+func (app *CLIApplication) tagIndexGeneration(query *models.Query, response *models.Response) {
+	if app.storage == nil {
+		return
+	}
+	generation, err := app.storage.CurrentIndexGeneration()
+	if err != nil {
+		app.logWarning("ANSWER_VERSION", fmt.Sprintf("Failed to read index generation: %v", err))
+		return
+	}
+	if response.ID == "" {
+		response.ID = fmt.Sprintf("response_%d", time.Now().UnixNano())
+	}
+	response.Metadata.IndexGeneration = generation
+	response.Metadata.Refreshes = query.Metadata["refreshes"]
+
+	if app.sessionManager == nil || query.SessionID == "" {
+		return
+	}
+	history, err := app.sessionManager.GetSessionHistory(query.SessionID, staleHistoryWindow)
+	if err != nil {
+		return
+	}
+	refreshed := make(map[string]bool)
+	for _, qr := range history {
+		if qr.Response != nil && qr.Response.Metadata.Refreshes != "" {
+			refreshed[qr.Response.Metadata.Refreshes] = true
+		}
+	}
+	for _, qr := range history {
+		if qr.Response == nil || qr.Response.ID == "" || refreshed[qr.Response.ID] {
+			continue
+		}
+		prior := qr.Response.Metadata.IndexGeneration
+		if prior > 0 && prior < generation {
+			response.Metadata.StaleResponses = append(response.Metadata.StaleResponses, qr.Response.ID)
+		}
+	}
+}
+
+// RefreshResponse regenerates an earlier answer of this session against the
+// current index and reports which of its sources changed in between
+func (app *CLIApplication) RefreshResponse(ctx context.Context, responseID string) (*models.Response, error) {
+	if app.sessionManager == nil {
+		return nil, fmt.Errorf("sessions are not available")
+	}
+	history, err := app.sessionManager.GetSessionHistory(app.sessionID, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session history: %w", err)
+	}
+
+	var original *QueryResponse
+	for i := range history {
+		if history[i].Response != nil && history[i].Response.ID == responseID {
+			original = &history[i]
+			break
+		}
+	}
+	if original == nil || original.Query == nil {
+		return nil, fmt.Errorf("response %s not found in this session", responseID)
+	}
+
+	// Re-run the standalone form of the original query; it was already
+	// rewritten, so the current conversation cannot change its meaning
+	query := *original.Query
+	query.ID = fmt.Sprintf("query_%d", time.Now().UnixNano())
+	query.Timestamp = time.Now()
+	query.Metadata = make(map[string]string, len(original.Query.Metadata)+1)
+	for k, v := range original.Query.Metadata {
+		query.Metadata[k] = v
+	}
+	query.Metadata["refreshes"] = responseID
+
+	response, err := app.ProcessQuery(ctx, &query)
+	if err != nil {
+		return nil, err
+	}
+	response.Metadata.ChangedSources = app.changedSources(original.Response, response.Metadata.IndexGeneration)
+	return response, nil
+}
+
+// changedSources lists the sources of an earlier answer that were modified or
+// removed between its index generation and the given one
+func (app *CLIApplication) changedSources(previous *models.Response, generation int64) []string {
+	from := previous.Metadata.IndexGeneration
+	if from == 0 || generation <= from {
+		return nil
+	}
+	diff, err := app.storage.DiffIndexGenerations(from, generation)
+	if err != nil {
+		app.logWarning("ANSWER_VERSION", fmt.Sprintf("Failed to diff index generations: %v", err))
+		return nil
+	}
+
+	changed := make(map[string]bool, len(diff.FilesModified)+len(diff.FilesRemoved))
+	for _, path := range diff.FilesModified {
+		changed[path] = true
+	}
+	for _, path := range diff.FilesRemoved {
+		changed[path] = true
+	}
+
+	var sources []string
+	for _, source := range previous.Metadata.Sources {
+		if changed[source] {
+			sources = append(sources, source)
+		}
+	}
+	return sources
+}
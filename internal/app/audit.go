@@ -0,0 +1,190 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/internal/audit"
+	"github.com/yourusername/useq-ai-assistant/internal/policy"
+	"github.com/yourusername/useq-ai-assistant/internal/tasks"
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// TaskKindAudit audits the indexed codebase, resumable per package
+const TaskKindAudit = "audit"
+
+// auditHotFiles is how many of the most worked-on files raise the priority
+// of their findings
+const auditHotFiles = 100
+
+// auditLoadBearingChunks is how many of the most cited chunks the report
+// lists as the most load-bearing code
+const auditLoadBearingChunks = 15
+
+// auditTaskCheckpoint is where an audit task resumes
+type auditTaskCheckpoint struct {
+	Batches  []audit.Batch   `json:"batches"`
+	Next     int             `json:"next"`
+	Findings []audit.Finding `json:"findings"`
+	Skipped  []string        `json:"skipped,omitempty"`
+
+	// Policies are the team policies evaluated so far, after the packages
+	Policies []audit.PolicyStatus `json:"policies,omitempty"`
+}
+
+// StartAuditTask audits the indexed codebase in the background and writes
+// the report when done
+func (app *CLIApplication) StartAuditTask(ctx context.Context) (*storage.TaskRecord, error) {
+	if app.tasks == nil {
+		return nil, fmt.Errorf("background tasks need storage")
+	}
+	return app.tasks.Start(ctx, TaskKindAudit, "audit "+app.config.ProjectRoot, struct{}{})
+}
+
+// runAuditTask analyzes the indexed files one package at a time,
+// checkpointing findings after each so a resumed task skips audited packages
+func (app *CLIApplication) runAuditTask(ctx context.Context, run *tasks.Run) (string, error) {
+	var checkpoint auditTaskCheckpoint
+	resumed, err := run.LoadCheckpoint(&checkpoint)
+	if err != nil {
+		return "", err
+	}
+	if !resumed {
+		run.Progress(0, 0, "collecting indexed files")
+		files, err := app.storage.GetIndexedFiles()
+		if err != nil {
+			return "", fmt.Errorf("failed to list indexed files: %w", err)
+		}
+		checkpoint.Batches = audit.GroupByPackage(app.config.ProjectRoot, files)
+		if len(checkpoint.Batches) == 0 {
+			return "", fmt.Errorf("no indexed Go files; run 'index' first")
+		}
+		if err := run.Checkpoint(checkpoint, 0, len(checkpoint.Batches)); err != nil {
+			return "", err
+		}
+	}
+
+	auditor := audit.NewAuditor(app.config.ProjectRoot, app.config.Audit)
+	total := len(checkpoint.Batches)
+	for checkpoint.Next < total {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		batch := checkpoint.Batches[checkpoint.Next]
+		run.Progress(checkpoint.Next, total, batch.Dir)
+		findings, err := auditor.AnalyzeBatch(batch)
+		if err != nil {
+			checkpoint.Skipped = append(checkpoint.Skipped, batch.Dir)
+		}
+		checkpoint.Findings = append(checkpoint.Findings, findings...)
+		checkpoint.Next++
+		if err := run.Checkpoint(checkpoint, checkpoint.Next, total); err != nil {
+			return "", err
+		}
+	}
+
+	if err := app.auditPolicies(ctx, run, &checkpoint); err != nil {
+		return "", err
+	}
+
+	fileCount := 0
+	for _, batch := range checkpoint.Batches {
+		fileCount += len(batch.Files)
+	}
+	report := audit.NewReport(app.config.ProjectRoot, app.config.Audit, total, fileCount, checkpoint.Findings, app.auditHotness())
+	report.Skipped = checkpoint.Skipped
+	report.LoadBearing = app.auditLoadBearing()
+	report.Policies = checkpoint.Policies
+	paths, err := report.Write()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d findings (%d high, %d medium, %d low) in %d packages; report: %s",
+		len(report.Findings), report.Count("", audit.SeverityHigh), report.Count("", audit.SeverityMedium),
+		report.Count("", audit.SeverityLow), total, strings.Join(paths, ", ")), nil
+}
+
+// auditPolicies evaluates the team's policies after the packages, one at a
+// time, checkpointing each so a resumed task skips evaluated policies.
+// Without policies or an AI provider the phase is skipped.
+func (app *CLIApplication) auditPolicies(ctx context.Context, run *tasks.Run, checkpoint *auditTaskCheckpoint) error {
+	policies, err := app.Policies()
+	if err != nil {
+		app.logWarning("AUDIT", fmt.Sprintf("Skipped policies: %v", err))
+		return nil
+	}
+	if len(policies) == 0 {
+		return nil
+	}
+	judge, err := app.policyJudge()
+	if err != nil {
+		app.logWarning("AUDIT", fmt.Sprintf("Skipped policies: %v", err))
+		return nil
+	}
+	evaluated := make(map[string]bool, len(checkpoint.Policies))
+	for _, status := range checkpoint.Policies {
+		evaluated[status.Name] = true
+	}
+	total := len(checkpoint.Batches)
+	for _, p := range policies {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if evaluated[p.Name] {
+			continue
+		}
+		run.Progress(total, total, "policy "+p.Name)
+		units, err := app.policyUnits(p)
+		if err != nil {
+			checkpoint.Policies = append(checkpoint.Policies, audit.PolicyStatus{Name: p.Name, Rule: p.Rule, Error: err.Error()})
+			continue
+		}
+		result := policy.Evaluate(ctx, judge, p, units, app.config.Policies)
+		if ctx.Err() != nil {
+			return ctx.Err() // evaluated again on resume
+		}
+		checkpoint.Findings = append(checkpoint.Findings, result.Findings()...)
+		checkpoint.Policies = append(checkpoint.Policies, result.Status())
+		if err := run.Checkpoint(*checkpoint, total, total); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// auditHotness maps project-relative files to their access score so findings
+// in the code worked on most rank first
+func (app *CLIApplication) auditHotness() map[string]float64 {
+	hot, err := app.storage.GetHotFiles(auditHotFiles)
+	if err != nil {
+		return nil
+	}
+	hotness := make(map[string]float64, len(hot))
+	for _, file := range hot {
+		if path := audit.RelativePath(app.config.ProjectRoot, file.Path); path != "" {
+			hotness[path] = file.Score
+		}
+	}
+	return hotness
+}
+
+// auditLoadBearing lists the chunks answers cited most, with project-relative
+// paths
+func (app *CLIApplication) auditLoadBearing() []audit.LoadBearingChunk {
+	chunks, err := app.storage.GetMostCitedChunks(auditLoadBearingChunks)
+	if err != nil {
+		return nil
+	}
+	loadBearing := make([]audit.LoadBearingChunk, 0, len(chunks))
+	for _, chunk := range chunks {
+		loadBearing = append(loadBearing, audit.LoadBearingChunk{
+			File:      audit.RelativePath(app.config.ProjectRoot, chunk.Path),
+			Line:      chunk.Line,
+			Symbol:    chunk.Symbol,
+			Cited:     chunk.Cited,
+			Retrieved: chunk.Retrieved,
+		})
+	}
+	return loadBearing
+}
@@ -0,0 +1,28 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/yourusername/useq-ai-assistant/internal/vectordb"
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// VectorDBAvailability reports whether Qdrant is answering; false when no
+// vector database is configured
+func (app *CLIApplication) VectorDBAvailability() (vectordb.Availability, bool) {
+	if app.vectorDB == nil {
+		return vectordb.Availability{}, false
+	}
+	return app.vectorDB.Availability(), true
+}
+
+// annotateAvailability marks answers built while Qdrant was failing, since
+// their search fell back to keywords and may miss what semantic search finds
+func (app *CLIApplication) annotateAvailability(response *models.Response) {
+	availability, ok := app.VectorDBAvailability()
+	if !ok || !availability.Degraded() || response == nil {
+		return
+	}
+	response.Metadata.Degraded = fmt.Sprintf("vector search unavailable (%s); results come from keyword search", availability.LastError)
+	app.logWarning("VECTORDB", "Answered without vector search: "+availability.LastError)
+}
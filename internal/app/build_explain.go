@@ -0,0 +1,24 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/yourusername/useq-ai-assistant/internal/diagnostics"
+	"github.com/yourusername/useq-ai-assistant/internal/sandbox"
+)
+
+// ExplainBuild runs go build in the sandbox and explains each compiler error
+// against the index. Without an LLM provider the errors are still parsed and
+// mapped, just not explained.
+func (app *CLIApplication) ExplainBuild(ctx context.Context, packages ...string) (*diagnostics.BuildReport, error) {
+	runner, err := sandbox.NewRunner(app.config.ProjectRoot, app.config.Sandbox)
+	if err != nil {
+		return nil, fmt.Errorf("sandbox unavailable: %w", err)
+	}
+	report, err := diagnostics.NewExplainer(runner, app.storage, app.llmManager).ExplainBuild(ctx, packages...)
+	if report != nil && report.Cost.TotalCost > 0 {
+		app.logInfo("EXPLAIN_BUILD", fmt.Sprintf("Explained %d build errors ($%.4f)", len(report.Explanations), report.Cost.TotalCost))
+	}
+	return report, err
+}
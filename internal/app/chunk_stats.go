@@ -0,0 +1,109 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/yourusername/useq-ai-assistant/internal/agents"
+	"github.com/yourusername/useq-ai-assistant/models"
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// citedRange matches a source cited as path:start or path:start-end
+var citedRange = regexp.MustCompile(`^(.+):(\d+)(?:-(\d+))?$`)
+
+// citation is a file, or a line range of one, an answer cited
+type citation struct {
+	start, end int // zero for a whole file
+}
+
+// recordChunkAccess counts the chunks an answer retrieved and the ones it
+// cited, which drive prewarming, duplicate collapsing and the audit's most
+// load-bearing code. A retrieved chunk counts as cited when a source covers
+// its first line or names its whole file.
+func (app *CLIApplication) recordChunkAccess(query *models.Query, response *models.Response) {
+	if app.storage == nil || response == nil || agents.IsPlanOnly(query) {
+		return
+	}
+
+	citations := make(map[string][]citation)
+	for _, source := range response.Metadata.Sources {
+		if match := citedRange.FindStringSubmatch(source); match != nil {
+			start, _ := strconv.Atoi(match[2])
+			end := start
+			if match[3] != "" {
+				end, _ = strconv.Atoi(match[3])
+			}
+			citations[match[1]] = append(citations[match[1]], citation{start, end})
+		} else if source != "" {
+			citations[source] = append(citations[source], citation{})
+		}
+	}
+
+	hits := make(map[storage.ChunkKey]storage.ChunkHit)
+	add := func(path string, line int, symbol string) {
+		if path == "" || line <= 0 {
+			return
+		}
+		key := storage.ChunkKey{Path: path, Line: line}
+		if _, seen := hits[key]; seen {
+			return
+		}
+		cited := false
+		for _, c := range citations[path] {
+			if c.start == 0 || (line >= c.start && line <= c.end) {
+				cited = true
+				break
+			}
+		}
+		hits[key] = storage.ChunkHit{ChunkKey: key, Symbol: symbol, Cited: cited}
+	}
+	if response.Content.Search != nil {
+		for _, result := range response.Content.Search.Results {
+			if result.Origin == "" {
+				add(result.File, result.Line, result.Function)
+			}
+		}
+	}
+	for _, reference := range response.Content.References {
+		add(reference.File, reference.Line, "")
+	}
+	// Cited ranges no retrieved chunk starts in are chunks of their own
+	for path, ranges := range citations {
+		for _, c := range ranges {
+			if c.start == 0 || coversHit(hits, path, c) {
+				continue
+			}
+			key := storage.ChunkKey{Path: path, Line: c.start}
+			hits[key] = storage.ChunkHit{ChunkKey: key, Cited: true}
+		}
+	}
+
+	batch := make([]storage.ChunkHit, 0, len(hits))
+	for _, hit := range hits {
+		batch = append(batch, hit)
+	}
+	if err := app.storage.RecordChunkAccess(batch); err != nil {
+		app.logWarning("CHUNK_STATS", "Failed to record chunk access: "+err.Error())
+	}
+}
+
+// coversHit reports whether a cited range covers the first line of a chunk
+// already counted
+func coversHit(hits map[storage.ChunkKey]storage.ChunkHit, path string, c citation) bool {
+	for key := range hits {
+		if key.Path == path && key.Line >= c.start && key.Line <= c.end {
+			return true
+		}
+	}
+	return false
+}
+
+// HotChunks returns the chunks retrieved and cited most, by decayed score
+func (app *CLIApplication) HotChunks(limit int) ([]*storage.ChunkAccess, error) {
+	if app.storage == nil {
+		return nil, fmt.Errorf("chunk statistics need storage")
+	}
+	return app.storage.GetHotChunks(limit)
+}
@@ -0,0 +1,89 @@
+package app
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/internal/agents"
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// clarificationAnswerMaxWords bounds free-text answers; longer input after a
+// question is taken as a new query rather than a reply
+const clarificationAnswerMaxWords = 8
+
+// awaitClarification remembers a question so the next input in the session can answer it
+func (app *CLIApplication) awaitClarification(query *models.Query, clarification *models.Clarification) {
+	if clarification == nil {
+		return
+	}
+	app.clarificationMu.Lock()
+	defer app.clarificationMu.Unlock()
+	if app.pendingClarifications == nil {
+		app.pendingClarifications = make(map[string]*models.Clarification)
+	}
+	app.pendingClarifications[app.querySession(query)] = clarification
+}
+
+// resolveClarification turns the answer to a pending question into the
+// original query narrowed by the chosen option. It reports whether the input
+// was such an answer.
+func (app *CLIApplication) resolveClarification(query *models.Query) bool {
+	session := app.querySession(query)
+
+	app.clarificationMu.Lock()
+	pending := app.pendingClarifications[session]
+	delete(app.pendingClarifications, session)
+	app.clarificationMu.Unlock()
+	if pending == nil {
+		return false
+	}
+
+	answer := strings.TrimSpace(query.UserInput)
+	refinement, ok := clarificationRefinement(pending, answer)
+	if !ok {
+		return false
+	}
+
+	if query.Metadata == nil {
+		query.Metadata = make(map[string]string)
+	}
+	query.Metadata["original_input"] = answer
+	query.Metadata["rewrite_reason"] = "clarification"
+	query.UserInput = strings.TrimSpace(pending.OriginalQuery + " " + refinement)
+	agents.MarkClarified(query)
+
+	app.logSuccess("CLARIFICATION", "Clarification answered", map[string]interface{}{
+		"answer":    answer,
+		"rewritten": query.UserInput,
+	})
+	return true
+}
+
+// clarificationRefinement maps an answer to the text that narrows the
+// original query: an option number, an option label, or short free text
+func clarificationRefinement(pending *models.Clarification, answer string) (string, bool) {
+	if n, err := strconv.Atoi(answer); err == nil {
+		if n >= 1 && n <= len(pending.Options) {
+			return pending.Options[n-1].Refinement, true
+		}
+		return "", false
+	}
+	for _, option := range pending.Options {
+		if strings.EqualFold(answer, option.Label) {
+			return option.Refinement, true
+		}
+	}
+	if answer == "" || len(strings.Fields(answer)) > clarificationAnswerMaxWords {
+		return "", false
+	}
+	return "(" + answer + ")", true
+}
+
+// querySession returns the session a query belongs to, defaulting to the CLI session
+func (app *CLIApplication) querySession(query *models.Query) string {
+	if query.SessionID == "" {
+		query.SessionID = app.sessionID
+	}
+	return query.SessionID
+}
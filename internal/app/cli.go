@@ -10,17 +10,42 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/spf13/viper"
 
 	"github.com/yourusername/useq-ai-assistant/display"
 	"github.com/yourusername/useq-ai-assistant/internal/agents"
+	"github.com/yourusername/useq-ai-assistant/internal/audit"
+	"github.com/yourusername/useq-ai-assistant/internal/calllog"
+	"github.com/yourusername/useq-ai-assistant/internal/churn"
+	"github.com/yourusername/useq-ai-assistant/internal/ci"
+	"github.com/yourusername/useq-ai-assistant/internal/completion"
+	"github.com/yourusername/useq-ai-assistant/internal/deps"
+	"github.com/yourusername/useq-ai-assistant/internal/flags"
+	"github.com/yourusername/useq-ai-assistant/internal/diagnostics"
+	"github.com/yourusername/useq-ai-assistant/internal/glossary"
+	"github.com/yourusername/useq-ai-assistant/internal/i18n"
+	"github.com/yourusername/useq-ai-assistant/internal/implements"
 	"github.com/yourusername/useq-ai-assistant/internal/indexer"
+	"github.com/yourusername/useq-ai-assistant/internal/language"
 	"github.com/yourusername/useq-ai-assistant/internal/llm"
 	"github.com/yourusername/useq-ai-assistant/internal/logger"
 	"github.com/yourusername/useq-ai-assistant/internal/mcp"
+	"github.com/yourusername/useq-ai-assistant/internal/pii"
+	"github.com/yourusername/useq-ai-assistant/internal/policy"
+	"github.com/yourusername/useq-ai-assistant/internal/provenance"
+	"github.com/yourusername/useq-ai-assistant/internal/remote"
+	"github.com/yourusername/useq-ai-assistant/internal/migration"
+	"github.com/yourusername/useq-ai-assistant/internal/prewarm"
+	"github.com/yourusername/useq-ai-assistant/internal/sandbox"
+	"github.com/yourusername/useq-ai-assistant/internal/semtags"
+	"github.com/yourusername/useq-ai-assistant/internal/tasks"
+	"github.com/yourusername/useq-ai-assistant/internal/todos"
 	"github.com/yourusername/useq-ai-assistant/internal/vectordb"
+	"github.com/yourusername/useq-ai-assistant/internal/vocabulary"
+	"github.com/yourusername/useq-ai-assistant/internal/watchdog"
 	"github.com/yourusername/useq-ai-assistant/models"
 	"github.com/yourusername/useq-ai-assistant/storage"
 )
@@ -31,21 +56,79 @@ type CLIApplication struct {
 	stepLogger              *logger.StepLogger
 	executionTracer         *logger.ExecutionTracer
 	sessionManager          *SessionManager
+	sessions                *sessionCoordinator
 	promptParser            *PromptParser
+	queryRewriter           *QueryRewriter
 	indexer                 *indexer.CodeIndexer
 	vectorDB                *vectordb.QdrantClient
+	depsVectorDB            *vectordb.QdrantClient // dependency source, searched on include:deps
+	summaryVectorDB         *vectordb.QdrantClient // file and package summaries, searched by file- and package-level queries
+	memoryVectorDB          *vectordb.QdrantClient // earlier questions and answers, searched by recall queries
+	keywordOnly             bool                   // no vector database; Tier 2 searches the keyword index
 	llmManager              *llm.Manager
 	codingAgent             *agents.CodingAgentImpl
 	searchAgent             agents.SearchAgentImpl
 	contextSearchAgent      *agents.ContextAwareSearchAgentImpl
 	intelligenceCodingAgent agents.IntelligenceCodingAgentImpl
 	managerAgent            *agents.ManagerAgent
+	prewarmer               *prewarm.Prewarmer
+	prewarmCancel           context.CancelFunc
+	watchdog                *watchdog.Watchdog // re-probes and recovers failed subsystems
+	watchdogCancel          context.CancelFunc
+	tasks                   *tasks.Manager
 	storage                 *storage.SQLiteDB
 	mcpClient               agents.MCPClientInterface
+	externalMCP             *mcp.ExternalServers // third-party MCP servers configured under mcp_servers
+	permissions             *mcp.Permissions
+	vocabulary              *vocabulary.Vocabulary
+	translator              *i18n.Translator
 	logger                  agents.Logger
 	startTime               time.Time
 	sessionID               string
 	debugMode               bool
+
+	// Clarification questions awaiting an answer, by session
+	pendingClarifications map[string]*models.Clarification
+	clarificationMu       sync.Mutex
+
+	// Query completion over symbol names, rebuilt when the index changes
+	completer           *completion.Completer
+	completerGeneration int64
+	completerMu         sync.Mutex
+
+	// Set in remote mode, where a shared server answers queries
+	remote *remote.Client
+
+	// Feature flags from config and environment; sessions override them
+	featureFlags *flags.Resolver
+
+	// Finds personal data in session exports and debug bundles
+	privacy *pii.Scanner
+
+	// Operator-facing log of calls to LLM providers and embedding endpoints
+	callLog *calllog.Log
+
+	// Set when the stored vectors were built with another embedding model
+	embeddingDrift *vectordb.EmbeddingDrift
+	driftMu        sync.Mutex
+
+	// The code language most indexed files are in, once files are indexed
+	projectLanguage string
+	languageMu      sync.Mutex
+
+	// Files the indexer would index, rediscovered at most every
+	// index_coverage.cache_ttl
+	discoverable   []string
+	discoveredAt   time.Time
+	discoverableMu sync.Mutex
+
+	// Reviewed generations waiting for the user's decision, by task ID
+	reviewWaits map[string]*reviewWait
+	reviewMu    sync.Mutex
+
+	// Modification time of the analyzer output file when last ingested
+	diagnosticsFileStamp time.Time
+	diagnosticsMu        sync.Mutex
 }
 
 // Config holds application configuration
@@ -53,13 +136,56 @@ type Config struct {
 	ProjectRoot       string
 	DatabasePath      string
 	LogLevel          string
+	Verbosity         string // console diagnostics: quiet, normal, verbose or debug
 	EnableStepLogging bool
 	DebugMode         bool
 	IndexedExtensions []string
 	ExcludedDirs      []string
 	AIProviders       llm.AIProvidersConfig
 	Performance       PerformanceConfig
+	FileLimits        indexer.FileLimits // binary and oversized files while indexing
 	VectorDB          VectorDBConfig
+	Prewarm           prewarm.Config
+	Dependencies      deps.Config
+	DependencyInsight deps.InsightConfig
+	Clarification     agents.ClarificationConfig
+	ToolLoop          agents.ToolLoopConfig
+	Consultation      agents.ConsultationConfig
+	FileMentions      agents.FileMentionConfig
+	ExactLookup       agents.ExactLookupConfig
+	SummarySearch     agents.SummarySearchConfig
+	Memory            agents.MemoryConfig
+	Snippets          agents.SnippetConfig
+	CostEstimate      agents.CostEstimateConfig
+	Excerpts          agents.ExcerptConfig
+	Pins              agents.PinConfig
+	Glossary          glossary.Config
+	AnalyzerDiagnostics diagnostics.Config // gopls and golangci-lint findings offered to queries
+	Comparison        ModelComparisonConfig
+	Migration         migration.Config
+	Todos             todos.Config
+	Churn             churn.Config // git history weighed when reranking search results
+	Implements        implements.Config
+	Tags              semtags.Config
+	Provenance        provenance.Config
+	Sessions          SessionsConfig
+	ResultSummary     ResultSummaryConfig
+	IndexCoverage     IndexCoverageConfig
+	Sandbox           sandbox.Policy
+	Freshness         vectordb.FreshnessConfig
+	PathPolicy        mcp.PathPolicyConfig
+	MCPServers        []mcp.ExternalServerConfig
+	Privacy           pii.Config
+	ExternalCalls     calllog.Config
+	PromptCapture     llm.PromptCaptureConfig // debug bundles of every LLM prompt, for 'debug last-prompt'
+	Watchdog          watchdog.Config
+	PermissionsFile   string // agent permission policy; see config/permissions.yaml
+	VocabularyFile    string // domain terms, stop-words and synonyms; see config/vocabulary.yaml
+	QueryLanguage     i18n.Config
+	Audit             audit.Config
+	Policies          policy.Config // team policies checked by audit and review; see config/policies.yaml
+	Remote            remote.Config // queries go to a shared server when URL is set
+	Flags             map[string]bool // feature flags of experimental capabilities; see internal/flags
 }
 
 // PerformanceConfig holds performance settings
@@ -69,14 +195,31 @@ type PerformanceConfig struct {
 	MaxParallelWorkers int
 	CacheEnabled       bool
 	CacheTTL           time.Duration
+	MaxRSSMB           int    // pause indexing above this RSS (0 = no limit)
+	MaxInFlightBatches int    // indexing batches dispatched but not yet stored
+	SpillToDisk        bool   // spill chunk data to disk while indexing
+	SpillDir           string // directory for spilled chunk data
+	PersistQueue       bool   // queue chunks for embedding in SQLite, resumable after a crash
+	QueueCheckpoint    int    // embeddings stored between queue checkpoints
+	WALCheckpoint      string // WAL checkpoint mode after indexing runs
 }
 
 // VectorDBConfig holds vector database configuration
 type VectorDBConfig struct {
-	URL            string
-	APIKey         string
-	CollectionName string
-	Dimension      int
+	Backend           string // auto, qdrant or keyword; see VectorBackendAuto
+	URL               string
+	APIKey            string
+	CollectionName    string
+	Dimension         int
+	PayloadMode       string                              // full, compressed or reference
+	EmbeddingProvider string                              // openai (the OpenAI provider's endpoint) or gemini
+	Reductions        map[string]vectordb.ReductionConfig // embedding reduction by collection
+	Tuning            vectordb.TuningConfig               // memory layout and HNSW parameters
+	MaxRetries        int                                 // retries of a failed Qdrant request
+	RetryDelay        time.Duration                       // first backoff, doubled per retry with jitter
+	Breaker           vectordb.BreakerConfig              // stops Qdrant calls after repeated failures
+	Sharding          vectordb.ShardConfig                // one collection per top-level directory
+	DriftPolicy       string                              // warn or refuse when the embedding model changed
 }
 
 // NewCLIApplication creates a new CLI application instance with enhanced logging
@@ -95,6 +238,30 @@ func NewCLIApplicationWithLLM(llmManager *llm.Manager) (*CLIApplication, error)
 	}
 	fmt.Printf("✅ Configuration loaded\n")
 
+	if config.Verbosity != "" {
+		verbosity, err := logger.ParseVerbosity(config.Verbosity)
+		if err != nil {
+			return nil, fmt.Errorf("invalid configuration: %w", err)
+		}
+		logger.SetDefaultVerbosity(verbosity)
+	}
+
+	featureFlags, err := flags.NewResolver(config.Flags)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	privacy, err := pii.NewScanner(config.Privacy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	callLog, err := calllog.Open(config.ExternalCalls)
+	if err != nil {
+		return nil, err
+	}
+	calllog.SetDefault(callLog)
+
 	// Generate session ID
 	sessionID := fmt.Sprintf("session_%d", time.Now().UnixNano())
 
@@ -117,9 +284,14 @@ func NewCLIApplicationWithLLM(llmManager *llm.Manager) (*CLIApplication, error)
 	app := &CLIApplication{
 		config:     config,
 		stepLogger: stepLogger,
+		sessions:   newSessionCoordinator(config.Sessions),
 		sessionID:  sessionID,
 		startTime:  time.Now(),
 		debugMode:  config.DebugMode,
+
+		featureFlags: featureFlags,
+		privacy:      privacy,
+		callLog:      callLog,
 	}
 
 	// Log detailed info to file
@@ -153,6 +325,18 @@ func (app *CLIApplication) initializeComponentsWithLogging(llmManager *llm.Manag
 	}
 	fmt.Printf("  ✅ Storage ready\n")
 
+	// A thin client keeps local sessions only; the server owns the rest
+	if app.config.Remote.Enabled() {
+		if err := app.initializeRemote(); err != nil {
+			app.stepLogger.FailStep(mainStep, err)
+			fmt.Printf("  ❌ Remote server initialization failed\n")
+			return err
+		}
+		app.stepLogger.CompleteStep(mainStep, "Remote mode components initialized")
+		app.logSuccess("COMPONENT_INIT", "Remote mode ready for operation")
+		return nil
+	}
+
 	// 2. Initialize vector database
 	fmt.Printf("  🔄 Vector Database...\n")
 	if err := app.initializeVectorDB(); err != nil {
@@ -161,6 +345,10 @@ func (app *CLIApplication) initializeComponentsWithLogging(llmManager *llm.Manag
 		return err
 	}
 	fmt.Printf("  ✅ Vector Database ready\n")
+	app.checkEmbeddingDrift(context.Background())
+	app.initializeDependencySearch()
+	app.initializeSummarySearch()
+	app.initializeConversationMemory()
 
 	// 3. Initialize LLM manager
 	fmt.Printf("  🔄 AI Providers...\n")
@@ -169,6 +357,11 @@ func (app *CLIApplication) initializeComponentsWithLogging(llmManager *llm.Manag
 		fmt.Printf("  ❌ LLM Manager initialization failed\n")
 		return err
 	}
+	app.llmManager.SetPrivacy(app.privacy)
+	if capture := app.config.PromptCapture; capture.Enabled {
+		app.llmManager.EnablePromptCapture(capture.Dir)
+	}
+	app.applyModelPreferences()
 	fmt.Printf("  ✅ AI Providers ready\n")
 
 	// 4. Initialize MCP client
@@ -232,10 +425,30 @@ func (app *CLIApplication) checkAndRunIndexing() error {
 		}
 
 		fmt.Printf("  ✅ Automatic indexing completed\n")
-	} else {
-		fmt.Printf("  ✅ Files already indexed\n")
+		return nil
+	}
+
+	// Without a vector database, files indexed before need keyword indexing
+	if err := app.ensureKeywordIndex(fileCount); err != nil {
+		return err
 	}
 
+	// A run interrupted with chunks still queued for embedding picks up
+	// where it stopped
+	queue, err := app.indexer.EmbeddingQueue()
+	if err != nil {
+		return fmt.Errorf("failed to read the embedding queue: %w", err)
+	}
+	if queue.Pending > 0 {
+		fmt.Printf("  🔁 Resuming interrupted indexing: %d chunks from %d files waiting for embeddings\n", queue.Pending, queue.Files)
+		if _, err := app.ResumeIndexing(func(display.IndexingProgress) {}); err != nil {
+			return fmt.Errorf("resuming indexing failed: %w", err)
+		}
+		fmt.Printf("  ✅ Interrupted indexing resumed\n")
+		return nil
+	}
+
+	fmt.Printf("  ✅ Files already indexed\n")
 	return nil
 }
 
@@ -293,6 +506,10 @@ func (app *CLIApplication) initializeStorage() error {
 
 // initializeVectorDB initializes Qdrant vector database
 func (app *CLIApplication) initializeVectorDB() error {
+	if app.config.VectorDB.Backend == VectorBackendKeyword {
+		app.useKeywordIndex("vectordb.backend is keyword")
+		return nil
+	}
 	app.logInfo("VECTORDB_INIT", "Initializing Qdrant vector database")
 	vectorStep := app.stepLogger.StartStep(logger.ComponentVectorDB, "connecting_qdrant",
 		map[string]interface{}{
@@ -336,16 +553,34 @@ func (app *CLIApplication) initializeVectorDB() error {
 		Port:              port,
 		Collection:        app.config.VectorDB.CollectionName,
 		VectorSize:        app.config.VectorDB.Dimension,
-		MaxRetries:        3,
-		RetryDelay:        time.Second,
+		MaxRetries:        app.config.VectorDB.MaxRetries,
+		RetryDelay:        app.config.VectorDB.RetryDelay,
+		Breaker:           app.config.VectorDB.Breaker,
 		ConnectionTimeout: 30 * time.Second,
 		BatchSize:         100,
+		PayloadMode:       vectordb.PayloadMode(app.config.VectorDB.PayloadMode),
+		Reductions:        app.config.VectorDB.Reductions,
+		Tuning:            app.config.VectorDB.Tuning,
 	})
 	if err != nil {
 		app.logError("VECTORDB_INIT", "Qdrant client creation failed", err)
 		app.stepLogger.FailStep(vectorStep, err)
+		if app.config.VectorDB.Backend == VectorBackendAuto {
+			app.vectorDB = nil
+			app.useKeywordIndex(fmt.Sprintf("Qdrant is unavailable: %v", err))
+			return nil
+		}
 		return fmt.Errorf("failed to initialize vector database: %w", err)
 	}
+	// Reference-mode payloads carry no text; it is read back from SQLite
+	app.vectorDB.SetContentSource(app.storage)
+	app.vectorDB.SetEmbeddingEndpoint(embeddingEndpoint(app.config))
+	if err := app.vectorDB.EnableSharding(context.Background(), app.config.VectorDB.Sharding, app.config.ProjectRoot); err != nil {
+		app.logWarning("VECTORDB_INIT", fmt.Sprintf("Sharding disabled: %v", err))
+	}
+	if app.config.VectorDB.Tuning.WarmStart {
+		go app.warmUpVectorDB()
+	}
 
 	app.logSuccess("VECTORDB_INIT", "Qdrant client connected successfully")
 	app.stepLogger.CompleteStep(vectorStep, "Qdrant client connected")
@@ -373,12 +608,12 @@ func (app *CLIApplication) initializeLLMManager() error {
 			"fallbacks": app.config.AIProviders.FallbackOrder,
 		})
 
-	// Check API keys
-	openaiKey := os.Getenv("OPENAI_API_KEY")
-	if openaiKey == "" {
-		app.logWarning("LLM_INIT", "OPENAI_API_KEY not set - OpenAI provider will be unavailable")
+	// Check API keys; compatible servers may not need one
+	openai := app.config.AIProviders.OpenAI
+	if openai.APIKey == "" && openai.APIType != llm.APITypeCompatible {
+		app.logWarning("LLM_INIT", "No OpenAI API key set (OPENAI_API_KEY or AZURE_OPENAI_API_KEY) - OpenAI provider will be unavailable")
 	} else {
-		app.logInfo("LLM_INIT", "OpenAI API key found")
+		app.logInfo("LLM_INIT", fmt.Sprintf("OpenAI provider configured (api_type=%s)", openai.APIType))
 	}
 
 	var err error
@@ -427,6 +662,39 @@ func (app *CLIApplication) initializeIndexer() error {
 		return fmt.Errorf("failed to initialize code indexer: %w", err)
 	}
 
+	app.indexer.SetMemoryLimits(indexer.MemoryLimits{
+		MaxRSSMB:           app.config.Performance.MaxRSSMB,
+		MaxInFlightBatches: app.config.Performance.MaxInFlightBatches,
+		SpillToDisk:        app.config.Performance.SpillToDisk,
+		SpillDir:           app.config.Performance.SpillDir,
+		PersistQueue:       app.config.Performance.PersistQueue,
+		QueueCheckpoint:    app.config.Performance.QueueCheckpoint,
+	})
+	app.logInfo("INDEXER_INIT", fmt.Sprintf("Memory limits: max RSS %dMB, %d in-flight batches, spill to disk %v, persisted embedding queue %v",
+		app.config.Performance.MaxRSSMB, app.config.Performance.MaxInFlightBatches, app.config.Performance.SpillToDisk,
+		app.config.Performance.PersistQueue))
+
+	app.indexer.SetWriteBatching(indexer.WriteBatching{
+		BatchSize:      app.config.Performance.IndexingBatchSize,
+		CheckpointMode: app.config.Performance.WALCheckpoint,
+	})
+	app.indexer.SetFileLimits(app.config.FileLimits)
+	app.indexer.SetGlossaryConfig(app.config.Glossary)
+	app.indexer.SetTodoConfig(app.config.Todos)
+	app.indexer.SetChurnConfig(app.config.Churn)
+	app.indexer.SetImplementsConfig(app.config.Implements)
+	var labeler semtags.Labeler
+	if app.config.Tags.LLMLabeling && app.llmManager != nil {
+		labeler = semtags.NewLLMLabeler(app.llmManager, app.config.Tags.LLMModel)
+	}
+	app.indexer.SetTagConfig(app.config.Tags, labeler)
+
+	// Search results are checked against disk; stale files may be re-indexed
+	// before the answer is generated
+	if app.vectorDB != nil {
+		app.vectorDB.SetFreshness(app.config.Freshness, app.storage, app.indexer)
+	}
+
 	app.logSuccess("INDEXER_INIT", "Code indexer initialized successfully")
 	app.stepLogger.CompleteStep(indexerStep, "Code indexer initialized")
 	return nil
@@ -440,19 +708,120 @@ func (app *CLIApplication) initializeOtherComponents() {
 	app.sessionManager = NewSessionManager(app.storage)
 	app.logInfo("OTHER_INIT", "Session manager initialized")
 
-	// Initialize prompt parser
+	// Initialize prompt parser with the project's domain vocabulary
+	vocab, err := vocabulary.Load(app.config.VocabularyFile)
+	if err != nil {
+		fmt.Printf("⚠️ Vocabulary not loaded, using built-in stop-words: %v\n", err)
+	}
+	app.vocabulary = vocab
 	app.promptParser = NewPromptParser()
+	app.promptParser.SetVocabulary(vocab)
+
+	// Non-English queries are translated before parsing, answers after
+	if app.llmManager != nil {
+		app.translator = i18n.NewTranslator(app.llmManager)
+	}
+	if language := app.config.QueryLanguage.ResponseLanguage; language != "auto" && !i18n.Supported(language) {
+		app.logWarning("OTHER_INIT", fmt.Sprintf("Unsupported query_language.response_language %q; answering in the query's language", language))
+		app.config.QueryLanguage.ResponseLanguage = "auto"
+	}
 	app.logInfo("OTHER_INIT", "Prompt parser initialized")
 
+	// Initialize follow-up query rewriter
+	app.queryRewriter = NewQueryRewriter()
+	app.logInfo("OTHER_INIT", "Query rewriter initialized")
+
+	// Initialize idle-time prewarming of frequently touched files
+	app.initializePrewarmer()
+
 	// Initialize agents
 	app.initializeAgents()
+
+	// Initialize background tasks; handlers use the agents and indexer
+	app.initializeTasks()
+
+	// Recover Qdrant and the LLM providers after outages
+	app.initializeWatchdog()
+}
+
+// initializePrewarmer starts background prewarming of hot files
+func (app *CLIApplication) initializePrewarmer() {
+	var refresher prewarm.Refresher
+	if app.indexer != nil {
+		refresher = app.indexer
+	}
+	app.prewarmer = prewarm.NewPrewarmer(app.config.Prewarm, app.storage, refresher)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	app.prewarmCancel = cancel
+	app.prewarmer.Start(ctx)
+
+	app.logInfo("OTHER_INIT", fmt.Sprintf("Prewarmer initialized (enabled: %v, metered: %v, daily budget: $%.4f)",
+		app.config.Prewarm.Enabled, app.config.Prewarm.Metered, app.config.Prewarm.DailyBudget))
+}
+
+// Storage returns the application's database
+func (app *CLIApplication) Storage() *storage.SQLiteDB {
+	return app.storage
+}
+
+// MCPServers reports the configured third-party MCP servers and the tools
+// agents can call on them
+func (app *CLIApplication) MCPServers() ([]mcp.ExternalServerStatus, []*mcp.ExternalTool) {
+	return app.externalMCP.Status(), app.externalMCP.Tools()
+}
+
+// GetPrewarmStatus returns the current state of hot-file prewarming
+func (app *CLIApplication) GetPrewarmStatus() prewarm.Status {
+	return app.prewarmer.Status()
+}
+
+// recordFileAccess feeds the files a query touched into prewarming
+func (app *CLIApplication) recordFileAccess(query *models.Query, response *models.Response) {
+	if app.prewarmer == nil {
+		return
+	}
+	app.prewarmer.RecordAccess(prewarm.WeightEditor, query.Context.CurrentFile)
+	app.prewarmer.RecordAccess(prewarm.WeightQuery, query.Intent.FileTargets...)
+	if response == nil || agents.IsPlanOnly(query) {
+		return
+	}
+	app.prewarmer.RecordAccess(prewarm.WeightQuery, response.Metadata.Sources...)
+	if response.Content.Code != nil {
+		for _, change := range response.Content.Code.Changes {
+			app.prewarmer.RecordAccess(prewarm.WeightEdit, change.File)
+		}
+	}
 }
 
 // initializeMCPClient initializes the MCP client for enhanced context
 func (app *CLIApplication) initializeMCPClient() {
 	app.logInfo("MCP_INIT", "Initializing MCP client")
 	app.mcpClient = mcp.NewMCPClient()
-	
+
+	// Keep secrets out of every MCP file tool, whichever agent asks
+	mcp.SetPathPolicy(mcp.NewPathPolicy(app.config.ProjectRoot, app.config.PathPolicy))
+
+	// Limit which tools each agent may use
+	policy, err := mcp.LoadPermissionPolicy(app.config.PermissionsFile)
+	if err != nil {
+		fmt.Printf("⚠️ Agent permissions not loaded, using defaults: %v\n", err)
+	}
+	app.permissions = mcp.NewPermissions(policy)
+
+	// Start the configured third-party servers; one that fails is reported
+	// and left out
+	if len(app.config.MCPServers) > 0 {
+		app.externalMCP = mcp.StartExternalServers(context.Background(), app.config.MCPServers)
+		for _, status := range app.externalMCP.Status() {
+			if status.Connected {
+				app.logInfo("MCP_INIT", fmt.Sprintf("MCP server %s connected with %d tools", status.Name, status.Tools))
+			} else {
+				app.logWarning("MCP_INIT", fmt.Sprintf("MCP server %s not started: %s", status.Name, status.Error))
+			}
+		}
+	}
+
 	// Create logger adapter for agents
 	app.logger = &LoggerAdapter{stepLogger: app.stepLogger}
 	app.logInfo("MCP_INIT", "MCP client and logger initialized")
@@ -462,12 +831,7 @@ func (app *CLIApplication) initializeAgents() {
 	app.logInfo("AGENT_INIT", "Initializing AI agents")
 
 	// Create embedder for search functionality
-	embeddingConfig := &vectordb.EmbeddingConfig{
-		APIKey:   app.config.AIProviders.OpenAI.APIKey,
-		Endpoint: "https://api.openai.com/v1/embeddings",
-		Model:    "text-embedding-3-small",
-	}
-	embedder := vectordb.NewEmbeddingService(embeddingConfig)
+	embedder := vectordb.NewEmbeddingService(embeddingServiceConfig(app.config))
 
 	//Create agent dependencies
 	deps := &agents.AgentDependencies{
@@ -477,15 +841,41 @@ func (app *CLIApplication) initializeAgents() {
 		Embedder:   embedder,
 		Logger:     app.logger,
 		MCPClient:  app.mcpClient,
+		Prewarm:    app.prewarmer,
+
+		DepsVectorDB:    app.depsVectorDB,
+		SummaryVectorDB: app.summaryVectorDB,
+		MemoryVectorDB:  app.memoryVectorDB,
+		ExternalTools:   app.externalMCP,
+		Permissions:     app.permissions,
+		Vocabulary:      app.vocabulary,
 	}
 	// Initialize manager agent (handles all routing)
 	app.managerAgent = agents.NewManagerAgent(deps)
+	app.managerAgent.SetClarificationConfig(app.config.Clarification)
+	app.managerAgent.SetToolLoopConfig(app.config.ToolLoop)
+	app.managerAgent.SetSandboxPolicy(app.config.Sandbox)
+	app.managerAgent.SetConsultationConfig(app.config.Consultation)
+	app.managerAgent.SetFileMentionConfig(app.config.FileMentions)
+	app.managerAgent.SetPinConfig(app.config.Pins)
+	app.managerAgent.SetGlossaryConfig(app.config.Glossary)
+	app.managerAgent.SetAnalyzerDiagnosticsConfig(app.config.AnalyzerDiagnostics)
+	app.managerAgent.SetMigrationConfig(app.config.Migration)
+	app.managerAgent.SetImplementsConfig(app.config.Implements)
+	app.managerAgent.SetExactLookupConfig(app.config.ExactLookup)
+	app.managerAgent.SetSummarySearchConfig(app.config.SummarySearch)
+	app.managerAgent.SetChurnConfig(app.config.Churn)
+	app.managerAgent.SetMemoryConfig(app.config.Memory)
+	app.managerAgent.SetSnippetConfig(app.config.Snippets)
+	app.managerAgent.SetCostEstimateConfig(app.config.CostEstimate)
+	app.managerAgent.SetExcerptConfig(app.config.Excerpts)
 	app.logInfo("AGENT_INIT", "Manager agent initialized")
 	app.logInfo("AGENT_INIT", "All agents initialized via manager")
 
 	// Get references to specialized agents from manager
 	app.searchAgent = *app.managerAgent.SearchAgent
 	app.codingAgent = app.managerAgent.CodingAgent
+	app.codingAgent.SetDependencyInsightConfig(app.config.DependencyInsight)
 	app.contextSearchAgent = app.managerAgent.ContextAwareSearchAgent
 	app.intelligenceCodingAgent = *app.managerAgent.IntelligenceCodingAgent
 	app.logInfo("AGENT_INIT", "All agents initialized via manager")
@@ -493,7 +883,35 @@ func (app *CLIApplication) initializeAgents() {
 
 // ProcessQuery processes a user query with comprehensive logging
 func (app *CLIApplication) ProcessQuery(ctx context.Context, query *models.Query) (*models.Response, error) {
+	// Pasted code is carried beside the question, never logged with it
+	app.applyPastedSnippet(query)
 	app.logInfo("QUERY_PROC", fmt.Sprintf("Processing query: %s", query.UserInput))
+	if app.remote != nil {
+		return app.processRemoteQuery(ctx, query)
+	}
+
+	// Interactive use pauses idle-time prewarming
+	app.prewarmer.Touch()
+	ctx = calllog.WithPurpose(ctx, "query")
+	// The prompts the answer's LLM calls send go into its fingerprint
+	ctx, prompts := llm.WithPromptRecord(ctx)
+
+	// Queries of one session run in order; other sessions and background
+	// tasks run alongside
+	if query.SessionID == "" {
+		query.SessionID = app.sessionID
+	}
+	release, err := app.sessions.acquire(ctx, turnKey(query))
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	if err := app.sessions.checkBudget(query.SessionID); err != nil {
+		return nil, err
+	}
+	if err := app.checkDriftPolicy(); err != nil {
+		return nil, err
+	}
 
 	// Create execution tracer for detailed flow tracking
 	tracer, err := logger.NewExecutionTracer(query.ID)
@@ -510,7 +928,29 @@ func (app *CLIApplication) ProcessQuery(ctx context.Context, query *models.Query
 		tracer.LogFunctionCall("ProcessQuery", fmt.Sprintf("Input: %s", query.UserInput))
 	}
 
-	queryStep := app.stepLogger.StartStep(logger.ComponentCLI, "processing_query",
+	// Each query logs its steps to its own logger, carried by ctx, so
+	// concurrent sessions do not share step numbering
+	verbosity := app.queryVerbosity(query)
+	ctx = logger.WithVerbosity(ctx, verbosity)
+	// Experimental capabilities follow the session's feature flags
+	ctx = flags.WithSet(ctx, app.featureFlags.Resolve(app.flagOverrides(query.SessionID)))
+	steps := app.stepLogger
+	queryLogger, err := logger.NewStepLogger(
+		query.SessionID,
+		query.ID,
+		app.config.LogLevel,
+		verbosity >= logger.VerbosityDebug, // the step log reaches the console only when debugging
+		app.config.EnableStepLogging,
+	)
+	if err == nil {
+		steps = queryLogger
+		defer queryLogger.Close()
+		// Callers following the query live see each step as it happens
+		queryLogger.SetEventSink(logger.EventSinkFrom(ctx))
+	}
+	ctx = logger.WithStepLogger(ctx, steps)
+
+	queryStep := steps.StartStep(logger.ComponentCLI, "processing_query",
 		map[string]interface{}{
 			"query_id":     query.ID,
 			"input":        query.UserInput,
@@ -518,47 +958,141 @@ func (app *CLIApplication) ProcessQuery(ctx context.Context, query *models.Query
 			"language":     query.Language,
 		})
 
-	// Update logger with query ID
-	queryLogger, err := logger.NewStepLogger(
-		app.sessionID,
-		query.ID,
-		app.config.LogLevel,
-		true, // Keep console output enabled for debugging
-		app.config.EnableStepLogging,
-	)
-	if err == nil {
-		app.stepLogger = queryLogger
+	// An /isolate prefix answers the query as if it opened the session
+	if input, isolated := ParseIsolatePrefix(query.UserInput); isolated {
+		if input == "" {
+			err := fmt.Errorf("usage: %s <query>", IsolatePrefix)
+			steps.FailStep(queryStep, err)
+			return nil, err
+		}
+		query.UserInput = input
+		markIsolated(query)
+	}
+
+	// A /plan prefix asks for the execution plan only, with no paid calls
+	if input, planOnly := agents.ParsePlanPrefix(query.UserInput); planOnly {
+		if input == "" {
+			err := fmt.Errorf("usage: %s <query>", agents.PlanPrefix)
+			steps.FailStep(queryStep, err)
+			return nil, err
+		}
+		if app.managerAgent == nil {
+			err := fmt.Errorf("plan mode requires the manager agent")
+			steps.FailStep(queryStep, err)
+			return nil, err
+		}
+		query.UserInput = input
+		agents.MarkPlanOnly(query)
+	}
+
+	// include:deps opts this query into dependency source results, and
+	// lang:<name> pins the language the answer is written in
+	app.applyIncludeDeps(query)
+	app.applyLanguageOverride(query)
+	if !isIsolated(query) {
+		app.applyPins(query)
+	}
+	app.refreshAnalyzerDiagnostics()
+	app.applyFileMentions(query)
+
+	// Classification and retrieval work in English
+	app.translateQuery(ctx, query)
+
+	// An answer to a pending clarification completes the original query;
+	// anything else may be a conversational follow-up, unless it asks about
+	// pasted code. Isolated queries are neither.
+	if !isIsolated(query) && !app.resolveClarification(query) && query.Context.Snippet == nil {
+		// Rewrite conversational follow-ups into standalone queries
+		app.rewriteFollowUpQuery(query, tracer)
 	}
 
 	// Parse query intent with detailed logging
-	intent, err := app.parseQueryWithLogging(query, tracer)
+	intent, err := app.parseQueryWithLogging(ctx, query, tracer)
 	if err != nil {
 		if tracer != nil {
 			tracer.LogFunctionExit("ProcessQuery", fmt.Sprintf("ERROR: %v", err))
 		}
 
-		app.stepLogger.FailStep(queryStep, err)
+		steps.FailStep(queryStep, err)
 		return nil, err
 	}
 
+	// Refine the query language with the files the intent targets
+	app.detectQueryLanguage(query, intent)
+
 	// Route to appropriate handler with logging
 	response, err := app.routeQueryWithLogging(ctx, query, intent, tracer)
 	if err != nil {
 		if tracer != nil {
 			tracer.LogFunctionExit("ProcessQuery", fmt.Sprintf("ERROR: %v", err))
 		}
-		app.stepLogger.FailStep(queryStep, err)
+		steps.FailStep(queryStep, err)
+		return nil, err
+	}
+
+	// Convert the answer to the caller's JSON schema when one was requested
+	if err := app.applyStructuredOutput(ctx, query, response); err != nil {
+		app.logError("STRUCTURED_OUTPUT", "Structured output failed", err)
+		steps.FailStep(queryStep, err)
 		return nil, err
 	}
 
-	// Save session data with logging
-	app.saveSessionWithLogging(query, response, tracer)
+	// Tag the answer with the index generation it was built from
+	app.tagIndexGeneration(query, response)
+
+	// Fingerprint the prompts and configuration the answer was produced with
+	app.tagFingerprint(response, prompts)
+
+	// Flag cited files that changed on disk since they were indexed
+	app.annotateFreshness(response)
+
+	// Say how much of the query's scope is indexed, e.g. mid initial index
+	app.annotateIndexCoverage(query, response)
+
+	// Say when Qdrant was failing and search fell back to keywords
+	app.annotateAvailability(response)
+
+	// Say when there is no vector database and search ran on keywords only
+	app.annotateKeywordOnly(response)
+
+	// Say when search compared vectors of different embedding models
+	app.annotateEmbeddingDrift(response)
+
+	// Summarize result lists too long to show at once
+	app.summarizeResults(response)
+
+	// Say how current the package summaries behind an architecture answer are
+	app.annotateSummaryFreshness(query, response)
+
+	// Suggest next steps the user can run by typing their number
+	app.suggestFollowUps(intent, response)
+
+	// Answer in the query's language, or the pinned one
+	app.localizeResponse(ctx, query, response)
+
+	// Track touched files so idle-time prewarming keeps them warm
+	app.recordFileAccess(query, response)
+
+	// Count the chunks the answer retrieved and cited
+	app.recordChunkAccess(query, response)
+
+	// Charge the answer to its session
+	app.sessions.record(query.SessionID, response)
+
+	// Save session data with logging; plans and clarification questions are
+	// not part of the conversation
+	if response.Type == models.ResponseTypeClarification {
+		app.awaitClarification(query, response.Content.Clarification)
+	} else if !agents.IsPlanOnly(query) {
+		app.saveSessionWithLogging(ctx, query, response, tracer)
+		app.rememberConversation(query, response)
+	}
 	if tracer != nil {
 		tracer.LogFunctionExit("ProcessQuery", fmt.Sprintf("SUCCESS: %s response generated", response.Type))
 		tracer.LogEnd(fmt.Sprintf("Query completed successfully - %s", response.Type))
 	}
 
-	app.stepLogger.CompleteStep(queryStep, map[string]interface{}{
+	steps.CompleteStep(queryStep, map[string]interface{}{
 		"agent":       response.AgentUsed,
 		"provider":    response.Provider,
 		"tokens":      response.TokenUsage.TotalTokens,
@@ -575,14 +1109,82 @@ func (app *CLIApplication) ProcessQuery(ctx context.Context, query *models.Query
 	return response, nil
 }
 
+// detectQueryLanguage sets query.Language from the query text and target
+// files. A language detected from the query is recorded as explicit in
+// Metadata["language_source"] so agents can scope vector search by it;
+// without one the project's main language is assumed, and agents may
+// still infer another from what retrieval finds.
+func (app *CLIApplication) detectQueryLanguage(query *models.Query, intent *models.QueryIntent) {
+	if query.Metadata == nil {
+		query.Metadata = make(map[string]string)
+	}
+	if query.Metadata["language_source"] == language.SourceUser {
+		return // explicit override from the caller
+	}
+
+	files := append([]string{}, intent.FileTargets...)
+	if query.Context.CurrentFile != "" {
+		files = append(files, query.Context.CurrentFile)
+	}
+
+	fallback := query.Language
+	if fallback == "" || query.Metadata["language_source"] == language.SourceDefault {
+		fallback = app.defaultLanguage()
+	}
+	detected := language.Detect(query.UserInput, files, fallback)
+	query.Language = detected.Language
+	query.Metadata["language_source"] = detected.Source
+
+	app.logInfo("LANGUAGE", fmt.Sprintf("Query language: %s (source: %s, confidence: %.2f)",
+		detected.Language, detected.Source, detected.Confidence))
+}
+
+// rewriteFollowUpQuery rewrites follow-ups using session history, keeping the original in metadata
+func (app *CLIApplication) rewriteFollowUpQuery(query *models.Query, tracer *logger.ExecutionTracer) {
+	if query.SessionID == "" {
+		query.SessionID = app.sessionID
+	}
+	if app.queryRewriter == nil || app.sessionManager == nil {
+		return
+	}
+
+	history, err := app.sessionManager.GetSessionHistory(query.SessionID, 5)
+	if err != nil {
+		app.logError("QUERY_REWRITE", "Failed to load conversation history", err)
+		return
+	}
+
+	result := app.queryRewriter.Rewrite(query.UserInput, history)
+	if !result.Changed {
+		return
+	}
+
+	if query.Metadata == nil {
+		query.Metadata = make(map[string]string)
+	}
+	query.Metadata["original_input"] = result.Original
+	query.Metadata["rewrite_reason"] = result.Reason
+	query.UserInput = result.Rewritten
+
+	app.logSuccess("QUERY_REWRITE", "Follow-up query rewritten", map[string]interface{}{
+		"original":  result.Original,
+		"rewritten": result.Rewritten,
+		"reason":    result.Reason,
+	})
+	if tracer != nil {
+		tracer.LogStep("QUERY_REWRITE", fmt.Sprintf("'%s' -> '%s' (%s)", result.Original, result.Rewritten, result.Reason))
+	}
+}
+
 // parseQueryWithLogging parses query intent with detailed logging
-func (app *CLIApplication) parseQueryWithLogging(query *models.Query, tracer *logger.ExecutionTracer) (*models.QueryIntent, error) {
+func (app *CLIApplication) parseQueryWithLogging(ctx context.Context, query *models.Query, tracer *logger.ExecutionTracer) (*models.QueryIntent, error) {
 	if tracer != nil {
 		tracer.LogFunctionCall("parseQueryWithLogging", fmt.Sprintf("Parsing intent for: %s", query.UserInput))
 		tracer.LogStep("STEP_1", "Starting query intent parsing")
 	}
 	app.logInfo("PARSE_INTENT", "Parsing query intent")
-	parseStep := app.stepLogger.StartStep(logger.ComponentParser, "parsing_intent", query.UserInput)
+	steps := app.steps(ctx)
+	parseStep := steps.StartStep(logger.ComponentParser, "parsing_intent", query.UserInput)
 	if tracer != nil {
 		tracer.LogFileAccess("internal/app/prompt_parser.go", "ParseIntent")
 		tracer.LogStep("STEP_2", "Accessing prompt parser module")
@@ -591,7 +1193,7 @@ func (app *CLIApplication) parseQueryWithLogging(query *models.Query, tracer *lo
 	intent, err := app.promptParser.ParseIntent(query.UserInput)
 	if err != nil {
 		app.logError("PARSE_INTENT", "Intent parsing failed", err)
-		app.stepLogger.FailStep(parseStep, err)
+		steps.FailStep(parseStep, err)
 		if tracer != nil {
 			tracer.LogStep("STEP_ERROR", fmt.Sprintf("Parser failed: %v", err))
 			tracer.LogFunctionExit("parseQueryWithLogging", fmt.Sprintf("ERROR: %v", err))
@@ -610,7 +1212,7 @@ func (app *CLIApplication) parseQueryWithLogging(query *models.Query, tracer *lo
 		"keywords":       intent.Keywords,
 	})
 
-	app.stepLogger.CompleteStep(parseStep, map[string]interface{}{
+	steps.CompleteStep(parseStep, map[string]interface{}{
 		"primary_intent": intent.Primary,
 		"confidence":     intent.Confidence,
 		"keywords":       intent.Keywords,
@@ -631,7 +1233,8 @@ func (app *CLIApplication) routeQueryWithLogging(ctx context.Context, query *mod
 	}
 	app.logInfo("ROUTE_QUERY", fmt.Sprintf("Routing query to handler for intent: %s (confidence: %.2f)", intent.Primary, intent.Confidence))
 
-	routeStep := app.stepLogger.StartStep(logger.ComponentAgent, "routing_query", map[string]interface{}{
+	steps := app.steps(ctx)
+	routeStep := steps.StartStep(logger.ComponentAgent, "routing_query", map[string]interface{}{
 		"intent":     intent.Primary,
 		"confidence": intent.Confidence,
 		"keywords":   intent.Keywords,
@@ -652,7 +1255,7 @@ func (app *CLIApplication) routeQueryWithLogging(ctx context.Context, query *mod
 				"provider": response.Provider,
 				"tokens":   response.TokenUsage.TotalTokens,
 			})
-			app.stepLogger.CompleteStep(routeStep, map[string]interface{}{
+			steps.CompleteStep(routeStep, map[string]interface{}{
 				"agent":    response.AgentUsed,
 				"provider": response.Provider,
 				"tokens":   response.TokenUsage.TotalTokens,
@@ -712,7 +1315,7 @@ func (app *CLIApplication) routeQueryWithLogging(ctx context.Context, query *mod
 
 	if err != nil {
 		app.logError("ROUTE_QUERY", "Handler execution failed", err)
-		app.stepLogger.FailStep(routeStep, err)
+		steps.FailStep(routeStep, err)
 		return nil, fmt.Errorf("failed to process query: %w", err)
 	}
 
@@ -722,7 +1325,7 @@ func (app *CLIApplication) routeQueryWithLogging(ctx context.Context, query *mod
 		"tokens":   response.TokenUsage.TotalTokens,
 	})
 
-	app.stepLogger.CompleteStep(routeStep, map[string]interface{}{
+	steps.CompleteStep(routeStep, map[string]interface{}{
 		"agent":    response.AgentUsed,
 		"provider": response.Provider,
 		"tokens":   response.TokenUsage.TotalTokens,
@@ -738,33 +1341,31 @@ func (app *CLIApplication) routeQueryWithLogging(ctx context.Context, query *mod
 // Enhanced query handlers with logging
 func (app *CLIApplication) handleSearchQueryWithLogging(ctx context.Context, query *models.Query, intent *models.QueryIntent, tracer *logger.ExecutionTracer) (*models.Response, error) {
 	app.logInfo("SEARCH_HANDLER", fmt.Sprintf("Executing search for keywords: %v", intent.Keywords))
-	searchStep := app.stepLogger.StartStep(logger.ComponentAgent, "executing_search", map[string]interface{}{
+	steps := app.steps(ctx)
+	searchStep := steps.StartStep(logger.ComponentAgent, "executing_search", map[string]interface{}{
 		"keywords": intent.Keywords,
 		"query":    query.UserInput,
 	})
 
 	// Use the search agent to perform actual search
-	embeddingConfig := &vectordb.EmbeddingConfig{
-		APIKey:   "", // Will be loaded from environment
-		Endpoint: "https://api.openai.com/v1/embeddings",
-		Model:    "text-embedding-3-small",
-	}
-	embedder := vectordb.NewEmbeddingService(embeddingConfig)
+	embedder := vectordb.NewEmbeddingService(embeddingServiceConfig(app.config))
 
 	searchAgent := agents.NewSearchAgent(&agents.AgentDependencies{
-		VectorDB: app.vectorDB,
-		Storage:  app.storage,
-		Embedder: embedder,
-		Logger:   nil, // TODO: Implement proper logger interface
+		VectorDB:   app.vectorDB,
+		Storage:    app.storage,
+		Embedder:   embedder,
+		Logger:     nil, // TODO: Implement proper logger interface
+		Vocabulary: app.vocabulary,
 	})
+	searchAgent.SetChurnConfig(app.config.Churn)
 
 	response, err := searchAgent.Search(ctx, query)
 	if err != nil {
-		app.stepLogger.FailStep(searchStep, err)
+		steps.FailStep(searchStep, err)
 		return nil, fmt.Errorf("search failed: %w", err)
 	}
 
-	app.stepLogger.CompleteStep(searchStep, "Search completed")
+	steps.CompleteStep(searchStep, "Search completed")
 	app.logSuccess("SEARCH_HANDLER", "Search completed successfully")
 	return response, nil
 }
@@ -812,7 +1413,8 @@ func (app *CLIApplication) handleTestingQueryWithLogging(ctx context.Context, qu
 
 func (app *CLIApplication) handleGeneralQueryWithLogging(ctx context.Context, query *models.Query, intent *models.QueryIntent, tracer *logger.ExecutionTracer) (*models.Response, error) {
 	app.logInfo("GENERAL_HANDLER", "Processing general query with LLM")
-	llmStep := app.stepLogger.StartStep(logger.ComponentLLM, "generating_response", map[string]interface{}{
+	steps := app.steps(ctx)
+	llmStep := steps.StartStep(logger.ComponentLLM, "generating_response", map[string]interface{}{
 		"input":       query.UserInput,
 		"max_tokens":  1000,
 		"temperature": 0.1,
@@ -834,7 +1436,7 @@ func (app *CLIApplication) handleGeneralQueryWithLogging(ctx context.Context, qu
 	llmResponse, err := app.llmManager.Generate(ctx, request)
 	if err != nil {
 		app.logError("GENERAL_HANDLER", "LLM generation failed", err)
-		app.stepLogger.FailStep(llmStep, err)
+		steps.FailStep(llmStep, err)
 		return nil, fmt.Errorf("failed to generate LLM response: %w", err)
 	}
 
@@ -845,7 +1447,7 @@ func (app *CLIApplication) handleGeneralQueryWithLogging(ctx context.Context, qu
 		"latency":  llmResponse.Latency,
 	})
 
-	app.stepLogger.CompleteStep(llmStep, map[string]interface{}{
+	steps.CompleteStep(llmStep, map[string]interface{}{
 		"provider": llmResponse.Provider,
 		"tokens":   llmResponse.TokenUsage.TotalTokens,
 		"cost":     llmResponse.Cost.TotalCost,
@@ -873,25 +1475,29 @@ func (app *CLIApplication) handleGeneralQueryWithLogging(ctx context.Context, qu
 }
 
 // saveSessionWithLogging saves session data with logging
-func (app *CLIApplication) saveSessionWithLogging(query *models.Query, response *models.Response, tracer *logger.ExecutionTracer) {
+func (app *CLIApplication) saveSessionWithLogging(ctx context.Context, query *models.Query, response *models.Response, tracer *logger.ExecutionTracer) {
 	app.logInfo("SESSION_SAVE", "Saving session data")
-	saveStep := app.stepLogger.StartStep(logger.ComponentCLI, "saving_session", map[string]interface{}{
+	steps := app.steps(ctx)
+	saveStep := steps.StartStep(logger.ComponentCLI, "saving_session", map[string]interface{}{
 		"query_id":    query.ID,
 		"response_id": response.ID,
 	})
 
 	if err := app.sessionManager.SaveQuery(query, response); err != nil {
 		app.logError("SESSION_SAVE", "Failed to save session data", err)
-		app.stepLogger.FailStep(saveStep, err)
+		steps.FailStep(saveStep, err)
 	} else {
 		app.logSuccess("SESSION_SAVE", "Session data saved successfully")
-		app.stepLogger.CompleteStep(saveStep, "Session data saved")
+		steps.CompleteStep(saveStep, "Session data saved")
 	}
 }
 
 // RunFullReindexWithProgress runs full reindexing with comprehensive progress logging
 func (app *CLIApplication) RunFullReindexWithProgress(progressCallback func(display.IndexingProgress)) error {
 	app.logInfo("FULL_REINDEXING", "Starting full reindexing with progress tracking")
+	if app.indexer == nil {
+		return errRemoteIndex
+	}
 
 	ctx := context.Background()
 	return app.indexer.StartFullReindexingWithProgress(ctx, func(progress display.IndexingProgress) {
@@ -901,11 +1507,58 @@ func (app *CLIApplication) RunFullReindexWithProgress(progressCallback func(disp
 	})
 }
 
+// ReindexShards rebuilds the shards of a sharded index that have changed
+// files, leaving the others untouched
+func (app *CLIApplication) ReindexShards(progressCallback func(display.IndexingProgress)) (*indexer.ShardReindexStats, error) {
+	app.logInfo("SHARD_REINDEXING", "Reindexing changed shards")
+	if app.indexer == nil {
+		return nil, errRemoteIndex
+	}
+
+	stats, err := app.indexer.ReindexShards(context.Background(), progressCallback)
+	if err != nil {
+		app.logError("SHARD_REINDEXING", "Shard reindexing failed", err)
+		return stats, err
+	}
+	app.logSuccess("SHARD_REINDEXING", fmt.Sprintf("Rebuilt %d of %d shards", len(stats.Rebuilt), stats.Shards))
+	return stats, nil
+}
+
+// ResumeIndexing finishes an interrupted indexing run and drains the
+// embedding queue, returning the number of chunks embedded
+func (app *CLIApplication) ResumeIndexing(progressCallback func(display.IndexingProgress)) (int, error) {
+	app.logInfo("RESUME_INDEXING", "Resuming interrupted indexing")
+	if app.indexer == nil {
+		return 0, errRemoteIndex
+	}
+
+	embedded, err := app.indexer.ResumeIndexing(context.Background(), progressCallback)
+	if err != nil {
+		app.logError("RESUME_INDEXING", "Resuming indexing failed", err)
+		return embedded, err
+	}
+	app.logSuccess("RESUME_INDEXING", fmt.Sprintf("Embedded %d queued chunks", embedded))
+	return embedded, nil
+}
+
+// VectorDBShards returns the point count of every shard collection; false
+// when the index is not sharded
+func (app *CLIApplication) VectorDBShards() (map[string]int, bool, error) {
+	if app.vectorDB == nil || !app.vectorDB.Sharded() {
+		return nil, false, nil
+	}
+	counts, err := app.vectorDB.Shards(context.Background())
+	return counts, true, err
+}
+
 // RunIndexingWithProgress runs indexing with comprehensive progress logging
 func (app *CLIApplication) RunIndexingWithProgress(progressCallback func(display.IndexingProgress)) error {
 	app.logInfo("INDEXING", "Starting code indexing with progress tracking")
+	if app.indexer == nil {
+		return errRemoteIndex
+	}
 
-	ctx := context.Background()
+	ctx := calllog.WithPurpose(context.Background(), "indexing")
 	return app.indexer.StartIndexingWithProgress(ctx, func(progress display.IndexingProgress) {
 		app.logInfo("INDEXING_PROGRESS", fmt.Sprintf("Progress: %d/%d files, %d functions, %d types",
 			progress.ProcessedFiles, progress.TotalFiles, progress.FunctionsFound, progress.TypesFound))
@@ -916,6 +1569,9 @@ func (app *CLIApplication) RunIndexingWithProgress(progressCallback func(display
 // GetIndexedFiles returns list of indexed files with logging
 func (app *CLIApplication) GetIndexedFiles() ([]string, error) {
 	app.logInfo("GET_FILES", "Retrieving indexed files from storage")
+	if app.indexer == nil {
+		return nil, errRemoteIndex
+	}
 
 	files, err := app.indexer.GetIndexedFiles()
 	if err != nil {
@@ -931,6 +1587,22 @@ func (app *CLIApplication) GetIndexedFiles() ([]string, error) {
 func (app *CLIApplication) Close() error {
 	app.logInfo("CLI_SHUTDOWN", "Shutting down CLI application")
 
+	if app.prewarmCancel != nil {
+		app.prewarmCancel()
+	}
+	if app.watchdogCancel != nil {
+		app.watchdogCancel()
+	}
+
+	// Running tasks checkpoint and are left interrupted, to resume next start
+	if app.tasks != nil {
+		app.tasks.Shutdown()
+	}
+
+	if err := app.externalMCP.Close(); err != nil {
+		app.logWarning("CLI_SHUTDOWN", fmt.Sprintf("MCP servers not closed cleanly: %v", err))
+	}
+
 	if app.stepLogger != nil {
 		app.stepLogger.LogInfo(logger.ComponentCLI, "Application shutdown initiated")
 		app.stepLogger.Close()
@@ -952,41 +1624,559 @@ func loadConfig() (*Config, error) {
 	viper.SetDefault("project_root", ".")
 	viper.SetDefault("sqlite_db_path", "storage/useq.db")
 	viper.SetDefault("log_level", "debug")
+	viper.SetDefault("verbosity", logger.VerbosityNormal.String())
 	viper.SetDefault("enable_step_logging", true)
 	viper.SetDefault("debug_mode", true)
+	viper.SetDefault("performance.memory.max_rss_mb", 0)
+	viper.SetDefault("performance.memory.max_in_flight_batches", 2)
+	viper.SetDefault("performance.memory.spill_to_disk", false)
+	viper.SetDefault("performance.memory.persist_queue", indexer.DefaultMemoryLimits().PersistQueue)
+	viper.SetDefault("performance.memory.queue_checkpoint", indexer.DefaultMemoryLimits().QueueCheckpoint)
+	viper.SetDefault("performance.indexing_batch_size", storage.DefaultWriteBatchSize)
+	viper.SetDefault("performance.wal_checkpoint", indexer.DefaultWriteBatching().CheckpointMode)
+
+	fileLimits := indexer.DefaultFileLimits()
+	viper.SetDefault("indexing.file_limits.max_file_size", fileLimits.MaxFileSize)
+	viper.SetDefault("indexing.file_limits.oversized", fileLimits.Oversized)
+	viper.SetDefault("indexing.file_limits.max_signatures", fileLimits.MaxSignatures)
+	viper.SetDefault("indexing.file_limits.skip_above", fileLimits.SkipAbove)
+
+	depsDefaults := deps.DefaultConfig()
+	viper.SetDefault("dependencies.enabled", depsDefaults.Enabled)
+	viper.SetDefault("dependencies.collection", depsDefaults.Collection)
+	viper.SetDefault("dependencies.max_files_per_module", depsDefaults.MaxFilesPerModule)
+	viper.SetDefault("dependencies.include_tests", depsDefaults.IncludeTests)
+
+	insightDefaults := deps.DefaultInsightConfig()
+	viper.SetDefault("dependency_insight.enabled", insightDefaults.Enabled)
+	viper.SetDefault("dependency_insight.go_get", insightDefaults.GoGet)
+
+	prewarmDefaults := prewarm.DefaultConfig()
+	viper.SetDefault("vectordb.payload_mode", string(vectordb.PayloadFull))
+	viper.SetDefault("vectordb.drift_policy", vectordb.DriftWarn)
+	viper.SetDefault("vectordb.backend", VectorBackendAuto)
+	viper.SetDefault("vectordb.collection_name", "code_embeddings")
+	viper.SetDefault("vectordb.dimension", 1536)
+	viper.SetDefault("ai_providers.openai.api_type", llm.APITypeOpenAI)
+	viper.SetDefault("ai_providers.gemini.model", "gemini-2.5-flash")
+	viper.SetDefault("ai_providers.gemini.max_tokens", 4000)
+	viper.SetDefault("ai_providers.gemini.temperature", 0.1)
+	viper.SetDefault("vectordb.embedding_provider", "openai")
+	tuningDefaults := vectordb.DefaultTuningConfig()
+	viper.SetDefault("vectordb.performance_profile", tuningDefaults.Profile)
+	viper.SetDefault("vectordb.m", tuningDefaults.M)
+	viper.SetDefault("vectordb.ef_construct", tuningDefaults.EfConstruct)
+	viper.SetDefault("vectordb.search_ef", tuningDefaults.SearchEf)
+	viper.SetDefault("vectordb.warm_start", tuningDefaults.WarmStart)
+	viper.SetDefault("vectordb.quantization", tuningDefaults.Quantization)
+	viper.SetDefault("vectordb.quantization_compression", tuningDefaults.Compression)
+	viper.SetDefault("vectordb.rescore", tuningDefaults.Rescore)
+	viper.SetDefault("vectordb.oversampling", tuningDefaults.Oversampling)
+	breakerDefaults := vectordb.DefaultBreakerConfig()
+	viper.SetDefault("vectordb.max_retries", 3)
+	viper.SetDefault("vectordb.retry_delay", "500ms")
+	viper.SetDefault("vectordb.breaker.failure_threshold", breakerDefaults.FailureThreshold)
+	viper.SetDefault("vectordb.breaker.open_duration", breakerDefaults.OpenDuration)
+	viper.SetDefault("vectordb.breaker.reconnect_interval", breakerDefaults.ReconnectInterval)
+	shardDefaults := vectordb.DefaultShardConfig()
+	viper.SetDefault("vectordb.sharding.enabled", shardDefaults.Enabled)
+	viper.SetDefault("vectordb.sharding.depth", shardDefaults.Depth)
+	viper.SetDefault("vectordb.sharding.max_parallel", shardDefaults.MaxParallel)
+	clarificationDefaults := agents.DefaultClarificationConfig()
+	viper.SetDefault("clarification.enabled", clarificationDefaults.Enabled)
+	viper.SetDefault("clarification.min_classification_confidence", clarificationDefaults.MinClassificationConfidence)
+	viper.SetDefault("clarification.min_retrieval_score", clarificationDefaults.MinRetrievalScore)
+	viper.SetDefault("clarification.max_options", clarificationDefaults.MaxOptions)
+
+	toolLoopDefaults := agents.DefaultToolLoopConfig()
+	viper.SetDefault("tool_loop.enabled", toolLoopDefaults.Enabled)
+	viper.SetDefault("tool_loop.max_steps", toolLoopDefaults.MaxSteps)
+	viper.SetDefault("tool_loop.max_cost", toolLoopDefaults.MaxCost)
+	viper.SetDefault("tool_loop.max_tool_output", toolLoopDefaults.MaxToolOutput)
+	viper.SetDefault("tool_loop.test_timeout", toolLoopDefaults.TestTimeout)
+
+	consultationDefaults := agents.DefaultConsultationConfig()
+	viper.SetDefault("consultation.enabled", consultationDefaults.Enabled)
+	viper.SetDefault("consultation.max_score_gap", consultationDefaults.MaxScoreGap)
+	viper.SetDefault("consultation.merge_within", consultationDefaults.MergeWithin)
+	viper.SetDefault("consultation.deadline", consultationDefaults.Deadline)
+	viper.SetDefault("consultation.agents", consultationDefaults.Agents)
+
+	fileMentionDefaults := agents.DefaultFileMentionConfig()
+	viper.SetDefault("file_mentions.enabled", fileMentionDefaults.Enabled)
+	viper.SetDefault("file_mentions.max_files", fileMentionDefaults.MaxFiles)
+	viper.SetDefault("file_mentions.max_tokens", fileMentionDefaults.MaxTokens)
+
+	exactLookupDefaults := agents.DefaultExactLookupConfig()
+	viper.SetDefault("exact_lookup.enabled", exactLookupDefaults.Enabled)
+	viper.SetDefault("exact_lookup.max_references", exactLookupDefaults.MaxReferences)
+
+	summarySearchDefaults := agents.DefaultSummarySearchConfig()
+	viper.SetDefault("summary_search.enabled", summarySearchDefaults.Enabled)
+	viper.SetDefault("summary_search.collection", summarySearchDefaults.Collection)
+	viper.SetDefault("summary_search.max_results", summarySearchDefaults.MaxResults)
+	viper.SetDefault("summary_search.min_score", summarySearchDefaults.MinScore)
+
+	memoryDefaults := agents.DefaultMemoryConfig()
+	viper.SetDefault("conversation_memory.enabled", memoryDefaults.Enabled)
+	viper.SetDefault("conversation_memory.collection", memoryDefaults.Collection)
+	viper.SetDefault("conversation_memory.max_results", memoryDefaults.MaxResults)
+	viper.SetDefault("conversation_memory.min_score", memoryDefaults.MinScore)
+	viper.SetDefault("conversation_memory.max_answer_chars", memoryDefaults.MaxAnswerChars)
+
+	snippetDefaults := agents.DefaultSnippetConfig()
+	viper.SetDefault("pasted_snippets.enabled", snippetDefaults.Enabled)
+	viper.SetDefault("pasted_snippets.max_symbols", snippetDefaults.MaxSymbols)
+	viper.SetDefault("pasted_snippets.max_tokens", snippetDefaults.MaxTokens)
+
+	costEstimateDefaults := agents.DefaultCostEstimateConfig()
+	viper.SetDefault("cost_estimate.enabled", costEstimateDefaults.Enabled)
+	viper.SetDefault("cost_estimate.confirm_above", costEstimateDefaults.ConfirmAbove)
+	viper.SetDefault("cost_estimate.window", costEstimateDefaults.Window)
+	viper.SetDefault("cost_estimate.min_samples", costEstimateDefaults.MinSamples)
+
+	excerptDefaults := agents.DefaultExcerptConfig()
+	viper.SetDefault("excerpts.enabled", excerptDefaults.Enabled)
+	viper.SetDefault("excerpts.max_excerpts", excerptDefaults.MaxExcerpts)
+	viper.SetDefault("excerpts.max_lines", excerptDefaults.MaxLines)
+	viper.SetDefault("excerpts.fold_after", excerptDefaults.FoldAfter)
+	viper.SetDefault("pins.max_tokens", agents.DefaultPinConfig().MaxTokens)
+
+	glossaryDefaults := glossary.DefaultConfig()
+	viper.SetDefault("glossary.enabled", glossaryDefaults.Enabled)
+	viper.SetDefault("glossary.max_entries", glossaryDefaults.MaxEntries)
+	viper.SetDefault("glossary.docs", glossaryDefaults.Docs)
+	analyzerDefaults := diagnostics.DefaultConfig()
+	viper.SetDefault("analyzer_diagnostics.enabled", analyzerDefaults.Enabled)
+	viper.SetDefault("analyzer_diagnostics.file", analyzerDefaults.File)
+	viper.SetDefault("analyzer_diagnostics.socket", analyzerDefaults.Socket)
+	viper.SetDefault("analyzer_diagnostics.max_entries", analyzerDefaults.MaxEntries)
+	comparisonDefaults := DefaultModelComparisonConfig()
+	viper.SetDefault("evaluation.compare_models", comparisonDefaults.Models)
+	viper.SetDefault("evaluation.apply_preferences", comparisonDefaults.ApplyPreferences)
+	viper.SetDefault("evaluation.min_ratings", comparisonDefaults.MinRatings)
+
+	migrationDefaults := migration.DefaultConfig()
+	viper.SetDefault("migration.enabled", migrationDefaults.Enabled)
+	viper.SetDefault("migration.fetch_changelogs", migrationDefaults.FetchChangelogs)
+	viper.SetDefault("migration.max_usages", migrationDefaults.MaxUsages)
+	viper.SetDefault("migration.max_changelog_chars", migrationDefaults.MaxChangelogChars)
+	viper.SetDefault("migration.timeout", migrationDefaults.Timeout)
+
+	todoDefaults := todos.DefaultConfig()
+	viper.SetDefault("todos.enabled", todoDefaults.Enabled)
+	viper.SetDefault("todos.markers", todoDefaults.Markers)
+	viper.SetDefault("todos.context_lines", todoDefaults.ContextLines)
+	viper.SetDefault("todos.blame", todoDefaults.Blame)
+	viper.SetDefault("todos.timeout", todoDefaults.Timeout)
+
+	churnDefaults := churn.DefaultConfig()
+	viper.SetDefault("churn.enabled", churnDefaults.Enabled)
+	viper.SetDefault("churn.window", churnDefaults.Window)
+	viper.SetDefault("churn.half_life", churnDefaults.HalfLife)
+	viper.SetDefault("churn.weight", churnDefaults.Weight)
+	viper.SetDefault("churn.chunks", churnDefaults.Chunks)
+	viper.SetDefault("churn.timeout", churnDefaults.Timeout)
+
+	implementsDefaults := implements.DefaultConfig()
+	viper.SetDefault("implements.enabled", implementsDefaults.Enabled)
+	viper.SetDefault("implements.max_results", implementsDefaults.MaxResults)
+	tagDefaults := semtags.DefaultConfig()
+	viper.SetDefault("tags.enabled", tagDefaults.Enabled)
+	viper.SetDefault("tags.min_score", tagDefaults.MinScore)
+	viper.SetDefault("tags.llm_labeling", tagDefaults.LLMLabeling)
+	viper.SetDefault("tags.llm_batch_size", tagDefaults.LLMBatchSize)
+	viper.SetDefault("tags.llm_model", tagDefaults.LLMModel)
+
+	provenanceDefaults := provenance.DefaultConfig()
+	viper.SetDefault("provenance.enabled", provenanceDefaults.Enabled)
+	viper.SetDefault("provenance.markers", provenanceDefaults.Markers)
+
+	resultSummaryDefaults := DefaultResultSummaryConfig()
+	viper.SetDefault("result_summary.threshold", resultSummaryDefaults.Threshold)
+	viper.SetDefault("result_summary.page_size", resultSummaryDefaults.PageSize)
+	viper.SetDefault("result_summary.notable", resultSummaryDefaults.Notable)
+
+	coverageDefaults := DefaultIndexCoverageConfig()
+	viper.SetDefault("index_coverage.enabled", coverageDefaults.Enabled)
+	viper.SetDefault("index_coverage.warn_below", coverageDefaults.WarnBelow)
+	viper.SetDefault("index_coverage.cache_ttl", coverageDefaults.CacheTTL)
+
+	sessionDefaults := DefaultSessionsConfig()
+	viper.SetDefault("sessions.max_concurrent", sessionDefaults.MaxConcurrent)
+	viper.SetDefault("sessions.budget", sessionDefaults.Budget)
+	viper.SetDefault("sessions.renderer", sessionDefaults.Renderer)
+
+	sandboxDefaults := sandbox.DefaultPolicy()
+	viper.SetDefault("sandbox.allowed_commands", sandboxDefaults.AllowedCommands)
+	viper.SetDefault("sandbox.timeout", sandboxDefaults.Timeout)
+	viper.SetDefault("sandbox.max_output", sandboxDefaults.MaxOutput)
+	viper.SetDefault("sandbox.env", sandboxDefaults.Env)
+
+	freshnessDefaults := vectordb.DefaultFreshnessConfig()
+	viper.SetDefault("freshness.enabled", freshnessDefaults.Enabled)
+	viper.SetDefault("freshness.auto_reindex", freshnessDefaults.AutoReindex)
+	viper.SetDefault("freshness.max_reindex", freshnessDefaults.MaxReindex)
+
+	pathPolicyDefaults := mcp.DefaultPathPolicyConfig()
+	viper.SetDefault("path_policy.deny", pathPolicyDefaults.Deny)
+	viper.SetDefault("path_policy.allow", pathPolicyDefaults.Allow)
+	viper.SetDefault("path_policy.audit_log", pathPolicyDefaults.AuditLog)
+	viper.SetDefault("permissions.policy_file", mcp.DefaultPermissionPolicyPath)
+
+	privacyDefaults := pii.DefaultConfig()
+	viper.SetDefault("privacy.mode", privacyDefaults.Mode)
+	viper.SetDefault("privacy.identifiers", privacyDefaults.Identifiers)
+	viper.SetDefault("privacy.allow", privacyDefaults.Allow)
+
+	watchdogDefaults := watchdog.DefaultConfig()
+	viper.SetDefault("watchdog.enabled", watchdogDefaults.Enabled)
+	viper.SetDefault("watchdog.interval", watchdogDefaults.Interval)
+	viper.SetDefault("watchdog.probe_timeout", watchdogDefaults.ProbeTimeout)
+
+	externalCallDefaults := calllog.DefaultConfig()
+	viper.SetDefault("external_calls.enabled", externalCallDefaults.Enabled)
+	viper.SetDefault("external_calls.path", externalCallDefaults.Path)
+	viper.SetDefault("external_calls.hash_length", externalCallDefaults.HashLength)
+	viper.SetDefault("vocabulary.file", vocabulary.DefaultPath)
+
+	queryLanguageDefaults := i18n.DefaultConfig()
+	viper.SetDefault("query_language.enabled", queryLanguageDefaults.Enabled)
+	viper.SetDefault("query_language.response_language", queryLanguageDefaults.ResponseLanguage)
+	viper.SetDefault("query_language.min_confidence", queryLanguageDefaults.MinConfidence)
+
+	auditDefaults := audit.DefaultConfig()
+	viper.SetDefault("audit.output_dir", auditDefaults.OutputDir)
+	viper.SetDefault("audit.format", auditDefaults.Format)
+	viper.SetDefault("audit.link_base", auditDefaults.LinkBase)
+	viper.SetDefault("audit.complexity_threshold", auditDefaults.ComplexityThreshold)
+	viper.SetDefault("audit.top_findings", auditDefaults.TopFindings)
+	viper.SetDefault("audit.hotspot_tags", auditDefaults.HotspotTags)
+	policyDefaults := policy.DefaultConfig()
+	viper.SetDefault("policies.file", policyDefaults.File)
+	viper.SetDefault("policies.max_candidates", policyDefaults.MaxCandidates)
+	viper.SetDefault("policies.batch_size", policyDefaults.BatchSize)
+	viper.SetDefault("policies.max_lines", policyDefaults.MaxLines)
+	viper.SetDefault("policies.model", policyDefaults.Model)
+	ciDefaults := ci.DefaultConfig()
+	viper.SetDefault("ci.base", ciDefaults.Base)
+	viper.SetDefault("ci.checks", ciDefaults.Checks)
+	viper.SetDefault("ci.policy", ciDefaults.Policy)
+	viper.SetDefault("ci.fail_on", ciDefaults.FailOn)
+	viper.SetDefault("remote.session", "default")
+	viper.SetDefault("remote.timeout", remote.DefaultTimeout)
+	viper.SetDefault("prewarm.enabled", prewarmDefaults.Enabled)
+	viper.SetDefault("prewarm.metered", prewarmDefaults.Metered)
+	viper.SetDefault("prewarm.idle_after", prewarmDefaults.IdleAfter)
+	viper.SetDefault("prewarm.interval", prewarmDefaults.Interval)
+	viper.SetDefault("prewarm.max_files", prewarmDefaults.MaxFiles)
+	viper.SetDefault("prewarm.daily_budget", prewarmDefaults.DailyBudget)
+	viper.SetDefault("prewarm.cost_per_reembed", prewarmDefaults.CostPerReembed)
+	viper.SetDefault("prewarm.resummarize_interval", prewarmDefaults.ResummarizeInterval)
+	viper.SetDefault("prewarm.resummarize_max", prewarmDefaults.ResummarizeMax)
+	viper.SetDefault("prewarm.summary_max_age", prewarmDefaults.SummaryMaxAge)
+	viper.SetDefault("prewarm.stale_change_ratio", prewarmDefaults.StaleChangeRatio)
 
 	config := &Config{
 		ProjectRoot:       viper.GetString("project_root"),
 		DatabasePath:      viper.GetString("sqlite_db_path"),
 		LogLevel:          viper.GetString("log_level"),
+		Verbosity:         viper.GetString("verbosity"),
 		EnableStepLogging: viper.GetBool("enable_step_logging"),
 		DebugMode:         viper.GetBool("debug_mode"),
-		IndexedExtensions: []string{".go", ".mod", ".sum"},
+		IndexedExtensions: indexedExtensions(),
 		ExcludedDirs:      []string{"vendor", "node_modules", ".git", "bin", "build", "dist"},
 		AIProviders: llm.AIProvidersConfig{
 			Primary:       "openai",
 			FallbackOrder: []string{"gemini", "cohere", "claude"},
-			OpenAI: llm.ProviderConfig{
-				APIKey:      os.Getenv("OPENAI_API_KEY"),
-				Model:       "gpt-4-turbo-preview",
-				MaxTokens:   4000,
-				Temperature: 0.1,
-				Timeout:     30 * time.Second,
+			OpenAI:        openAIProviderConfig(),
+			Gemini:        geminiProviderConfig(),
+			Routing:       modelRoutingConfig(),
+			Tokenizers: llm.TokenizerConfig{
+				Vocabularies: viper.GetStringMapString("ai_providers.tokenizers.vocabularies"),
 			},
 		},
 		Performance: PerformanceConfig{
 			MaxFileSize:        10 * 1024 * 1024, // 10MB
-			IndexingBatchSize:  100,
+			IndexingBatchSize:  viper.GetInt("performance.indexing_batch_size"),
 			MaxParallelWorkers: 4,
 			CacheEnabled:       true,
 			CacheTTL:           time.Hour,
+			MaxRSSMB:           viper.GetInt("performance.memory.max_rss_mb"),
+			MaxInFlightBatches: viper.GetInt("performance.memory.max_in_flight_batches"),
+			SpillToDisk:        viper.GetBool("performance.memory.spill_to_disk"),
+			SpillDir:           viper.GetString("performance.memory.spill_dir"),
+			PersistQueue:       viper.GetBool("performance.memory.persist_queue"),
+			QueueCheckpoint:    viper.GetInt("performance.memory.queue_checkpoint"),
+			WALCheckpoint:      viper.GetString("performance.wal_checkpoint"),
+		},
+		FileLimits: indexer.FileLimits{
+			MaxFileSize:   viper.GetInt64("indexing.file_limits.max_file_size"),
+			Oversized:     viper.GetString("indexing.file_limits.oversized"),
+			MaxSignatures: viper.GetInt("indexing.file_limits.max_signatures"),
+			SkipAbove:     viper.GetInt64("indexing.file_limits.skip_above"),
 		},
 		VectorDB: VectorDBConfig{
-			URL:            getEnvOrDefault("QDRANT_URL", "localhost:6333"),
-			APIKey:         os.Getenv("QDRANT_API_KEY"),
-			CollectionName: "code_embeddings",
-			Dimension:      1536,
+			Backend:           viper.GetString("vectordb.backend"),
+			URL:               getEnvOrDefault("QDRANT_URL", "localhost:6333"),
+			APIKey:            os.Getenv("QDRANT_API_KEY"),
+			CollectionName:    viper.GetString("vectordb.collection_name"),
+			Dimension:         viper.GetInt("vectordb.dimension"), // the embedding model's full size; reductions shrink it per collection
+			PayloadMode:       viper.GetString("vectordb.payload_mode"),
+			DriftPolicy:       viper.GetString("vectordb.drift_policy"),
+			EmbeddingProvider: viper.GetString("vectordb.embedding_provider"),
+			Tuning: vectordb.TuningConfig{
+				Profile:     viper.GetString("vectordb.performance_profile"),
+				M:           viper.GetInt("vectordb.m"),
+				EfConstruct: viper.GetInt("vectordb.ef_construct"),
+				SearchEf:    viper.GetInt("vectordb.search_ef"),
+				WarmStart:   viper.GetBool("vectordb.warm_start"),
+
+				Quantization: viper.GetString("vectordb.quantization"),
+				Compression:  viper.GetString("vectordb.quantization_compression"),
+				Rescore:      viper.GetBool("vectordb.rescore"),
+				Oversampling: viper.GetFloat64("vectordb.oversampling"),
+			},
+			MaxRetries: viper.GetInt("vectordb.max_retries"),
+			RetryDelay: viper.GetDuration("vectordb.retry_delay"),
+			Breaker: vectordb.BreakerConfig{
+				FailureThreshold:  viper.GetInt("vectordb.breaker.failure_threshold"),
+				OpenDuration:      viper.GetDuration("vectordb.breaker.open_duration"),
+				ReconnectInterval: viper.GetDuration("vectordb.breaker.reconnect_interval"),
+			},
+			Sharding: vectordb.ShardConfig{
+				Enabled:     viper.GetBool("vectordb.sharding.enabled"),
+				Depth:       viper.GetInt("vectordb.sharding.depth"),
+				MaxParallel: viper.GetInt("vectordb.sharding.max_parallel"),
+			},
+		},
+		Prewarm: prewarm.Config{
+			Enabled:             viper.GetBool("prewarm.enabled"),
+			Metered:             viper.GetBool("prewarm.metered"),
+			IdleAfter:           viper.GetDuration("prewarm.idle_after"),
+			Interval:            viper.GetDuration("prewarm.interval"),
+			MaxFiles:            viper.GetInt("prewarm.max_files"),
+			DailyBudget:         viper.GetFloat64("prewarm.daily_budget"),
+			CostPerReembed:      viper.GetFloat64("prewarm.cost_per_reembed"),
+			ResummarizeInterval: viper.GetDuration("prewarm.resummarize_interval"),
+			ResummarizeMax:      viper.GetInt("prewarm.resummarize_max"),
+			SummaryMaxAge:       viper.GetDuration("prewarm.summary_max_age"),
+			StaleChangeRatio:    viper.GetFloat64("prewarm.stale_change_ratio"),
+		},
+		Dependencies: deps.Config{
+			Enabled:           viper.GetBool("dependencies.enabled"),
+			Modules:           viper.GetStringSlice("dependencies.modules"),
+			Collection:        viper.GetString("dependencies.collection"),
+			MaxFilesPerModule: viper.GetInt("dependencies.max_files_per_module"),
+			IncludeTests:      viper.GetBool("dependencies.include_tests"),
+		},
+		DependencyInsight: deps.InsightConfig{
+			Enabled: viper.GetBool("dependency_insight.enabled"),
+			GoGet:   viper.GetBool("dependency_insight.go_get"),
+		},
+		Clarification: agents.ClarificationConfig{
+			Enabled:                     viper.GetBool("clarification.enabled"),
+			MinClassificationConfidence: viper.GetFloat64("clarification.min_classification_confidence"),
+			MinRetrievalScore:           viper.GetFloat64("clarification.min_retrieval_score"),
+			MaxOptions:                  viper.GetInt("clarification.max_options"),
+		},
+		ToolLoop: agents.ToolLoopConfig{
+			Enabled:       viper.GetBool("tool_loop.enabled"),
+			MaxSteps:      viper.GetInt("tool_loop.max_steps"),
+			MaxCost:       viper.GetFloat64("tool_loop.max_cost"),
+			MaxToolOutput: viper.GetInt("tool_loop.max_tool_output"),
+			TestTimeout:   viper.GetDuration("tool_loop.test_timeout"),
+		},
+		Consultation: agents.ConsultationConfig{
+			Enabled:     viper.GetBool("consultation.enabled"),
+			MaxScoreGap: viper.GetFloat64("consultation.max_score_gap"),
+			MergeWithin: viper.GetFloat64("consultation.merge_within"),
+			Deadline:    viper.GetDuration("consultation.deadline"),
+			Agents:      viper.GetStringSlice("consultation.agents"),
+		},
+		FileMentions: agents.FileMentionConfig{
+			Enabled:   viper.GetBool("file_mentions.enabled"),
+			MaxFiles:  viper.GetInt("file_mentions.max_files"),
+			MaxTokens: viper.GetInt("file_mentions.max_tokens"),
+		},
+		ExactLookup: agents.ExactLookupConfig{
+			Enabled:       viper.GetBool("exact_lookup.enabled"),
+			MaxReferences: viper.GetInt("exact_lookup.max_references"),
+		},
+		SummarySearch: agents.SummarySearchConfig{
+			Enabled:    viper.GetBool("summary_search.enabled"),
+			Collection: viper.GetString("summary_search.collection"),
+			MaxResults: viper.GetInt("summary_search.max_results"),
+			MinScore:   float32(viper.GetFloat64("summary_search.min_score")),
+		},
+		Memory: agents.MemoryConfig{
+			Enabled:        viper.GetBool("conversation_memory.enabled"),
+			Collection:     viper.GetString("conversation_memory.collection"),
+			MaxResults:     viper.GetInt("conversation_memory.max_results"),
+			MinScore:       float32(viper.GetFloat64("conversation_memory.min_score")),
+			MaxAnswerChars: viper.GetInt("conversation_memory.max_answer_chars"),
+		},
+		Snippets: agents.SnippetConfig{
+			Enabled:    viper.GetBool("pasted_snippets.enabled"),
+			MaxSymbols: viper.GetInt("pasted_snippets.max_symbols"),
+			MaxTokens:  viper.GetInt("pasted_snippets.max_tokens"),
 		},
+		CostEstimate: agents.CostEstimateConfig{
+			Enabled:      viper.GetBool("cost_estimate.enabled"),
+			ConfirmAbove: viper.GetFloat64("cost_estimate.confirm_above"),
+			Window:       viper.GetInt("cost_estimate.window"),
+			MinSamples:   viper.GetInt("cost_estimate.min_samples"),
+		},
+		Excerpts: agents.ExcerptConfig{
+			Enabled:     viper.GetBool("excerpts.enabled"),
+			MaxExcerpts: viper.GetInt("excerpts.max_excerpts"),
+			MaxLines:    viper.GetInt("excerpts.max_lines"),
+			FoldAfter:   viper.GetInt("excerpts.fold_after"),
+		},
+		Pins: agents.PinConfig{
+			MaxTokens: viper.GetInt("pins.max_tokens"),
+		},
+		Glossary: glossary.Config{
+			Enabled:    viper.GetBool("glossary.enabled"),
+			MaxEntries: viper.GetInt("glossary.max_entries"),
+			Docs:       viper.GetStringSlice("glossary.docs"),
+		},
+		AnalyzerDiagnostics: diagnostics.Config{
+			Enabled:    viper.GetBool("analyzer_diagnostics.enabled"),
+			File:       viper.GetString("analyzer_diagnostics.file"),
+			Socket:     viper.GetString("analyzer_diagnostics.socket"),
+			MaxEntries: viper.GetInt("analyzer_diagnostics.max_entries"),
+		},
+		Comparison: ModelComparisonConfig{
+			Models:           viper.GetStringSlice("evaluation.compare_models"),
+			ApplyPreferences: viper.GetBool("evaluation.apply_preferences"),
+			MinRatings:       viper.GetInt("evaluation.min_ratings"),
+		},
+		Migration: migration.Config{
+			Enabled:           viper.GetBool("migration.enabled"),
+			FetchChangelogs:   viper.GetBool("migration.fetch_changelogs"),
+			MaxUsages:         viper.GetInt("migration.max_usages"),
+			MaxChangelogChars: viper.GetInt("migration.max_changelog_chars"),
+			Timeout:           viper.GetDuration("migration.timeout"),
+		},
+		Todos: todos.Config{
+			Enabled:      viper.GetBool("todos.enabled"),
+			Markers:      viper.GetStringSlice("todos.markers"),
+			ContextLines: viper.GetInt("todos.context_lines"),
+			Blame:        viper.GetBool("todos.blame"),
+			Timeout:      viper.GetDuration("todos.timeout"),
+		},
+		Churn: churn.Config{
+			Enabled:  viper.GetBool("churn.enabled"),
+			Window:   viper.GetDuration("churn.window"),
+			HalfLife: viper.GetDuration("churn.half_life"),
+			Weight:   viper.GetFloat64("churn.weight"),
+			Chunks:   viper.GetBool("churn.chunks"),
+			Timeout:  viper.GetDuration("churn.timeout"),
+		},
+		Implements: implements.Config{
+			Enabled:    viper.GetBool("implements.enabled"),
+			MaxResults: viper.GetInt("implements.max_results"),
+		},
+		Tags: semtags.Config{
+			Enabled:      viper.GetBool("tags.enabled"),
+			MinScore:     viper.GetInt("tags.min_score"),
+			LLMLabeling:  viper.GetBool("tags.llm_labeling"),
+			LLMBatchSize: viper.GetInt("tags.llm_batch_size"),
+			LLMModel:     viper.GetString("tags.llm_model"),
+		},
+		Provenance: provenance.Config{
+			Enabled: viper.GetBool("provenance.enabled"),
+			Markers: viper.GetBool("provenance.markers"),
+		},
+		Sessions: SessionsConfig{
+			MaxConcurrent: viper.GetInt("sessions.max_concurrent"),
+			Budget:        viper.GetFloat64("sessions.budget"),
+			Renderer:      viper.GetString("sessions.renderer"),
+		},
+		ResultSummary: ResultSummaryConfig{
+			Threshold: viper.GetInt("result_summary.threshold"),
+			PageSize:  viper.GetInt("result_summary.page_size"),
+			Notable:   viper.GetInt("result_summary.notable"),
+		},
+		IndexCoverage: IndexCoverageConfig{
+			Enabled:   viper.GetBool("index_coverage.enabled"),
+			WarnBelow: viper.GetFloat64("index_coverage.warn_below"),
+			CacheTTL:  viper.GetDuration("index_coverage.cache_ttl"),
+		},
+		Sandbox: sandbox.Policy{
+			AllowedCommands: viper.GetStringSlice("sandbox.allowed_commands"),
+			Timeout:         viper.GetDuration("sandbox.timeout"),
+			MaxOutput:       viper.GetInt("sandbox.max_output"),
+			Env:             viper.GetStringSlice("sandbox.env"),
+		},
+		Freshness: vectordb.FreshnessConfig{
+			Enabled:     viper.GetBool("freshness.enabled"),
+			AutoReindex: viper.GetBool("freshness.auto_reindex"),
+			MaxReindex:  viper.GetInt("freshness.max_reindex"),
+		},
+		PathPolicy: mcp.PathPolicyConfig{
+			Deny:     viper.GetStringSlice("path_policy.deny"),
+			Allow:    viper.GetStringSlice("path_policy.allow"),
+			AuditLog: viper.GetString("path_policy.audit_log"),
+		},
+		PermissionsFile: viper.GetString("permissions.policy_file"),
+		Privacy: pii.Config{
+			Mode:        viper.GetString("privacy.mode"),
+			Identifiers: viper.GetStringSlice("privacy.identifiers"),
+			Allow:       viper.GetStringSlice("privacy.allow"),
+		},
+		Watchdog: watchdog.Config{
+			Enabled:      viper.GetBool("watchdog.enabled"),
+			Interval:     viper.GetDuration("watchdog.interval"),
+			ProbeTimeout: viper.GetDuration("watchdog.probe_timeout"),
+		},
+		PromptCapture: promptCaptureConfig(),
+		ExternalCalls: calllog.Config{
+			Enabled:    viper.GetBool("external_calls.enabled"),
+			Path:       viper.GetString("external_calls.path"),
+			HashLength: viper.GetInt("external_calls.hash_length"),
+		},
+		VocabularyFile:  viper.GetString("vocabulary.file"),
+		QueryLanguage: i18n.Config{
+			Enabled:          viper.GetBool("query_language.enabled"),
+			ResponseLanguage: viper.GetString("query_language.response_language"),
+			MinConfidence:    viper.GetFloat64("query_language.min_confidence"),
+		},
+		Audit: audit.Config{
+			OutputDir:           viper.GetString("audit.output_dir"),
+			Format:              viper.GetString("audit.format"),
+			LinkBase:            viper.GetString("audit.link_base"),
+			ComplexityThreshold: viper.GetInt("audit.complexity_threshold"),
+			TopFindings:         viper.GetInt("audit.top_findings"),
+			HotspotTags:         viper.GetStringSlice("audit.hotspot_tags"),
+		},
+		Policies: policy.Config{
+			File:          viper.GetString("policies.file"),
+			MaxCandidates: viper.GetInt("policies.max_candidates"),
+			BatchSize:     viper.GetInt("policies.batch_size"),
+			MaxLines:      viper.GetInt("policies.max_lines"),
+			Model:         viper.GetString("policies.model"),
+		},
+		Remote: remote.Config{
+			URL:     viper.GetString("remote.url"),
+			Token:   getEnvOrDefault("USEQ_REMOTE_TOKEN", viper.GetString("remote.token")),
+			Session: viper.GetString("remote.session"),
+			Timeout: viper.GetDuration("remote.timeout"),
+		},
+	}
+
+	if err := viper.UnmarshalKey("vectordb.reductions", &config.VectorDB.Reductions); err != nil {
+		return nil, fmt.Errorf("invalid vectordb.reductions: %w", err)
+	}
+	if err := viper.UnmarshalKey("flags", &config.Flags); err != nil {
+		return nil, fmt.Errorf("invalid flags: %w", err)
+	}
+	if err := viper.UnmarshalKey("mcp_servers", &config.MCPServers); err != nil {
+		return nil, fmt.Errorf("invalid mcp_servers: %w", err)
+	}
+	if issues := validateConfig(config); len(issues) > 0 {
+		return nil, issues
 	}
 
 	return config, nil
@@ -1057,7 +2247,7 @@ type LoggerAdapter struct {
 }
 
 func (l *LoggerAdapter) Info(message string, fields ...interface{}) {
-	l.stepLogger.LogInfo(logger.ComponentAgent, message, nil)
+	l.stepLogger.LogInfo(logger.ComponentAgent, message, fields...)
 }
 
 func (l *LoggerAdapter) Error(message string, fields ...interface{}) {
@@ -1065,11 +2255,11 @@ func (l *LoggerAdapter) Error(message string, fields ...interface{}) {
 }
 
 func (l *LoggerAdapter) Debug(message string, fields ...interface{}) {
-	l.stepLogger.LogInfo(logger.ComponentAgent, "[DEBUG] "+message, nil)
+	l.stepLogger.LogInfo(logger.ComponentAgent, "[DEBUG] "+message, fields...)
 }
 
 func (l *LoggerAdapter) Warn(message string, fields ...interface{}) {
-	l.stepLogger.LogInfo(logger.ComponentAgent, "[WARN] "+message, nil)
+	l.stepLogger.LogInfo(logger.ComponentAgent, "[WARN] "+message, fields...)
 }
 
 func (l *LoggerAdapter) Fatal(message string, fields ...interface{}) {
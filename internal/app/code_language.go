@@ -0,0 +1,45 @@
+package app
+
+import (
+	"github.com/yourusername/useq-ai-assistant/internal/language"
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// applyLanguageOverride removes a lang:<name> token from the query and pins
+// the query to that language, ahead of detection and retrieval
+func (app *CLIApplication) applyLanguageOverride(query *models.Query) {
+	input, lang, ok := language.ParseOverride(query.UserInput)
+	if !ok {
+		return
+	}
+	query.UserInput = input
+	query.Language = lang
+	if query.Metadata == nil {
+		query.Metadata = make(map[string]string)
+	}
+	query.Metadata["language_source"] = language.SourceUser
+	app.logInfo("LANGUAGE", "Query language set by lang: override: "+lang)
+}
+
+// defaultLanguage returns the code language most indexed files are in, or
+// Go before anything is indexed
+func (app *CLIApplication) defaultLanguage() string {
+	app.languageMu.Lock()
+	defer app.languageMu.Unlock()
+
+	if app.projectLanguage != "" {
+		return app.projectLanguage
+	}
+	if app.storage == nil {
+		return "go"
+	}
+	stats, err := app.storage.GetStats()
+	if err != nil {
+		return "go"
+	}
+	if primary := language.Primary(stats.LanguageBreakdown); primary != "" {
+		app.projectLanguage = primary
+		return primary
+	}
+	return "go"
+}
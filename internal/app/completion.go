@@ -0,0 +1,68 @@
+package app
+
+import (
+	"github.com/yourusername/useq-ai-assistant/internal/completion"
+)
+
+// cliHistoryScope prefixes the session IDs of interactive CLI sessions
+const cliHistoryScope = "session_"
+
+// completionHistoryLimit bounds the stored queries completions are drawn from
+const completionHistoryLimit = 500
+
+// CompleteQuery suggests completions for a partially typed query from
+// indexed symbol names, common intents and past queries: those of the
+// session, then stored ones from sessions whose ID starts with
+// historyScope. An empty scope means the interactive CLI's sessions.
+func (app *CLIApplication) CompleteQuery(input, sessionID, historyScope string, limit int) []completion.Suggestion {
+	if sessionID == "" {
+		sessionID = app.sessionID
+	}
+	if historyScope == "" {
+		historyScope = cliHistoryScope
+	}
+
+	var history []string
+	if sessionHistory, err := app.sessionManager.GetSessionHistory(sessionID, 0); err == nil {
+		for i := len(sessionHistory) - 1; i >= 0; i-- {
+			if query := sessionHistory[i].Query; query != nil {
+				history = append(history, query.UserInput)
+			}
+		}
+	}
+	if app.storage != nil {
+		if stored, err := app.storage.RecentQueries(historyScope, completionHistoryLimit); err == nil {
+			history = append(history, stored...)
+		}
+	}
+
+	return app.queryCompleter().Complete(input, history, limit)
+}
+
+// queryCompleter returns the symbol completer, rebuilding it after the
+// project has been re-indexed
+func (app *CLIApplication) queryCompleter() *completion.Completer {
+	app.completerMu.Lock()
+	defer app.completerMu.Unlock()
+
+	if app.completer == nil {
+		app.completer = completion.NewCompleter(nil)
+		app.completerGeneration = -1
+	}
+	if app.storage == nil {
+		return app.completer
+	}
+
+	generation, err := app.storage.CurrentIndexGeneration()
+	if err != nil || generation == app.completerGeneration {
+		return app.completer
+	}
+	symbols, err := app.storage.ListSymbolNames()
+	if err != nil {
+		app.logInfo("COMPLETION", "Failed to load symbol names: "+err.Error())
+		return app.completer
+	}
+	app.completer = completion.NewCompleter(symbols)
+	app.completerGeneration = generation
+	return app.completer
+}
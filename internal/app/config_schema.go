@@ -0,0 +1,374 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/yourusername/useq-ai-assistant/internal/configcheck"
+	"github.com/yourusername/useq-ai-assistant/internal/flags"
+	"github.com/yourusername/useq-ai-assistant/internal/i18n"
+	"github.com/yourusername/useq-ai-assistant/internal/mcp"
+	"github.com/yourusername/useq-ai-assistant/internal/vectordb"
+)
+
+// configSchema mirrors the keys of config/properties.yaml with the rules
+// each value must satisfy. It is decoded from viper only to be checked;
+// loadConfig still builds the Config the application runs with.
+type configSchema struct {
+	Verbosity string `mapstructure:"verbosity" validate:"oneof=quiet normal verbose debug"`
+
+	AIProviders struct {
+		OpenAI struct {
+			APIType string `mapstructure:"api_type" validate:"omitempty,oneof=openai azure azure_ad compatible"`
+		} `mapstructure:"openai"`
+		Gemini struct {
+			MaxTokens   int     `mapstructure:"max_tokens" validate:"min=1"`
+			Temperature float64 `mapstructure:"temperature" validate:"min=0,max=2"`
+		} `mapstructure:"gemini"`
+	} `mapstructure:"ai_providers"`
+
+	Performance struct {
+		IndexingBatchSize int `mapstructure:"indexing_batch_size" validate:"min=1"`
+		Memory            struct {
+			MaxRSSMB           int `mapstructure:"max_rss_mb" validate:"min=0"`
+			MaxInFlightBatches int `mapstructure:"max_in_flight_batches" validate:"min=1"`
+			QueueCheckpoint    int `mapstructure:"queue_checkpoint" validate:"min=1"`
+		} `mapstructure:"memory"`
+	} `mapstructure:"performance"`
+
+	Indexing struct {
+		FileLimits struct {
+			MaxFileSize   int64  `mapstructure:"max_file_size" validate:"min=1"`
+			Oversized     string `mapstructure:"oversized" validate:"oneof=signatures truncate skip"`
+			MaxSignatures int    `mapstructure:"max_signatures" validate:"min=1"`
+			SkipAbove     int64  `mapstructure:"skip_above" validate:"min=0"`
+		} `mapstructure:"file_limits"`
+	} `mapstructure:"indexing"`
+
+	VectorDB struct {
+		Backend           string        `mapstructure:"backend" validate:"oneof=auto qdrant keyword"`
+		CollectionName    string        `mapstructure:"collection_name" validate:"required"`
+		Dimension         int           `mapstructure:"dimension" validate:"min=1"`
+		PayloadMode       string        `mapstructure:"payload_mode" validate:"oneof=full compressed reference"`
+		DriftPolicy       string        `mapstructure:"drift_policy" validate:"oneof=warn refuse"`
+		EmbeddingProvider string        `mapstructure:"embedding_provider" validate:"oneof=openai gemini"`
+		Profile           string        `mapstructure:"performance_profile" validate:"oneof=auto memory balanced disk"`
+		M                 int           `mapstructure:"m" validate:"min=0,max=128"`
+		EfConstruct       int           `mapstructure:"ef_construct" validate:"min=0"`
+		SearchEf          int           `mapstructure:"search_ef" validate:"min=0"`
+		Quantization      string        `mapstructure:"quantization" validate:"omitempty,oneof=none scalar product"`
+		Compression       string        `mapstructure:"quantization_compression" validate:"omitempty,oneof=x4 x8 x16 x32 x64"`
+		Oversampling      float64       `mapstructure:"oversampling" validate:"min=1"`
+		MaxRetries        int           `mapstructure:"max_retries" validate:"min=0,max=10"`
+		RetryDelay        time.Duration `mapstructure:"retry_delay" validate:"min=0s"`
+		Breaker           struct {
+			FailureThreshold  int           `mapstructure:"failure_threshold" validate:"min=1"`
+			OpenDuration      time.Duration `mapstructure:"open_duration" validate:"min=1s"`
+			ReconnectInterval time.Duration `mapstructure:"reconnect_interval" validate:"min=0s"`
+		} `mapstructure:"breaker"`
+		Sharding struct {
+			Depth       int `mapstructure:"depth" validate:"min=1"`
+			MaxParallel int `mapstructure:"max_parallel" validate:"min=1"`
+		} `mapstructure:"sharding"`
+	} `mapstructure:"vectordb"`
+
+	Search struct {
+		SimilarityThreshold float64 `mapstructure:"similarity_threshold" validate:"min=0,max=1"`
+		MaxResults          int     `mapstructure:"max_results" validate:"omitempty,min=1"`
+	} `mapstructure:"search"`
+
+	Prewarm struct {
+		IdleAfter           time.Duration `mapstructure:"idle_after" validate:"min=1s"`
+		Interval            time.Duration `mapstructure:"interval" validate:"min=1s"`
+		MaxFiles            int           `mapstructure:"max_files" validate:"min=0"`
+		DailyBudget         float64       `mapstructure:"daily_budget" validate:"min=0"`
+		CostPerReembed      float64       `mapstructure:"cost_per_reembed" validate:"min=0"`
+		ResummarizeInterval time.Duration `mapstructure:"resummarize_interval" validate:"min=0s"`
+		ResummarizeMax      int           `mapstructure:"resummarize_max" validate:"min=0"`
+		SummaryMaxAge       time.Duration `mapstructure:"summary_max_age" validate:"min=0s"`
+		StaleChangeRatio    float64       `mapstructure:"stale_change_ratio" validate:"min=0,max=1"`
+	} `mapstructure:"prewarm"`
+
+	Dependencies struct {
+		Collection        string `mapstructure:"collection" validate:"required"`
+		MaxFilesPerModule int    `mapstructure:"max_files_per_module" validate:"min=0"`
+	} `mapstructure:"dependencies"`
+
+	Clarification struct {
+		MinClassificationConfidence float64 `mapstructure:"min_classification_confidence" validate:"min=0,max=1"`
+		MinRetrievalScore           float64 `mapstructure:"min_retrieval_score" validate:"min=0,max=1"`
+		MaxOptions                  int     `mapstructure:"max_options" validate:"min=1"`
+	} `mapstructure:"clarification"`
+
+	ToolLoop struct {
+		MaxSteps      int           `mapstructure:"max_steps" validate:"min=1"`
+		MaxCost       float64       `mapstructure:"max_cost" validate:"min=0"`
+		MaxToolOutput int           `mapstructure:"max_tool_output" validate:"min=1"`
+		TestTimeout   time.Duration `mapstructure:"test_timeout" validate:"min=1s"`
+	} `mapstructure:"tool_loop"`
+
+	Consultation struct {
+		MaxScoreGap float64       `mapstructure:"max_score_gap" validate:"min=0,max=1"`
+		MergeWithin float64       `mapstructure:"merge_within" validate:"min=0,max=1"`
+		Deadline    time.Duration `mapstructure:"deadline" validate:"min=1s"`
+		Agents      []string      `mapstructure:"agents" validate:"min=1"`
+	} `mapstructure:"consultation"`
+
+	FileMentions struct {
+		MaxFiles  int `mapstructure:"max_files" validate:"min=1"`
+		MaxTokens int `mapstructure:"max_tokens" validate:"min=1"`
+	} `mapstructure:"file_mentions"`
+
+	ExactLookup struct {
+		MaxReferences int `mapstructure:"max_references" validate:"min=0"`
+	} `mapstructure:"exact_lookup"`
+
+	SummarySearch struct {
+		Collection string  `mapstructure:"collection" validate:"required"`
+		MaxResults int     `mapstructure:"max_results" validate:"min=1"`
+		MinScore   float64 `mapstructure:"min_score" validate:"min=0,max=1"`
+	} `mapstructure:"summary_search"`
+
+	Memory struct {
+		Collection     string  `mapstructure:"collection" validate:"required"`
+		MaxResults     int     `mapstructure:"max_results" validate:"min=1"`
+		MinScore       float64 `mapstructure:"min_score" validate:"min=0,max=1"`
+		MaxAnswerChars int     `mapstructure:"max_answer_chars" validate:"min=0"`
+	} `mapstructure:"conversation_memory"`
+
+	Snippets struct {
+		MaxSymbols int `mapstructure:"max_symbols" validate:"min=0"`
+		MaxTokens  int `mapstructure:"max_tokens" validate:"min=0"`
+	} `mapstructure:"pasted_snippets"`
+
+	CostEstimate struct {
+		ConfirmAbove float64 `mapstructure:"confirm_above" validate:"min=0"`
+		Window       int     `mapstructure:"window" validate:"min=1"`
+		MinSamples   int     `mapstructure:"min_samples" validate:"min=0"`
+	} `mapstructure:"cost_estimate"`
+
+	Excerpts struct {
+		MaxExcerpts int `mapstructure:"max_excerpts" validate:"min=0"`
+		MaxLines    int `mapstructure:"max_lines" validate:"min=1"`
+		FoldAfter   int `mapstructure:"fold_after" validate:"min=0"`
+	} `mapstructure:"excerpts"`
+
+	Pins struct {
+		MaxTokens int `mapstructure:"max_tokens" validate:"min=0"`
+	} `mapstructure:"pins"`
+
+	Glossary struct {
+		MaxEntries int `mapstructure:"max_entries" validate:"min=0"`
+	} `mapstructure:"glossary"`
+
+	AnalyzerDiagnostics struct {
+		MaxEntries int `mapstructure:"max_entries" validate:"min=0"`
+	} `mapstructure:"analyzer_diagnostics"`
+
+	Evaluation struct {
+		CompareModels    []string `mapstructure:"compare_models" validate:"min=2,max=2"`
+		ApplyPreferences bool     `mapstructure:"apply_preferences"`
+		MinRatings       int      `mapstructure:"min_ratings" validate:"min=1"`
+	} `mapstructure:"evaluation"`
+
+	Migration struct {
+		MaxUsages         int           `mapstructure:"max_usages" validate:"min=0"`
+		MaxChangelogChars int           `mapstructure:"max_changelog_chars" validate:"min=0"`
+		Timeout           time.Duration `mapstructure:"timeout" validate:"min=1s"`
+	} `mapstructure:"migration"`
+
+	Todos struct {
+		Markers      []string      `mapstructure:"markers" validate:"min=1"`
+		ContextLines int           `mapstructure:"context_lines" validate:"min=0"`
+		Timeout      time.Duration `mapstructure:"timeout" validate:"min=1s"`
+	} `mapstructure:"todos"`
+
+	Churn struct {
+		Window   time.Duration `mapstructure:"window" validate:"min=24h"`
+		HalfLife time.Duration `mapstructure:"half_life" validate:"min=1h"`
+		Weight   float64       `mapstructure:"weight" validate:"min=0,max=1"`
+		Timeout  time.Duration `mapstructure:"timeout" validate:"min=1s"`
+	} `mapstructure:"churn"`
+
+	Implements struct {
+		MaxResults int `mapstructure:"max_results" validate:"min=0"`
+	} `mapstructure:"implements"`
+
+	Tags struct {
+		MinScore     int `mapstructure:"min_score" validate:"min=1"`
+		LLMBatchSize int `mapstructure:"llm_batch_size" validate:"min=1,max=100"`
+	} `mapstructure:"tags"`
+
+	Sessions struct {
+		MaxConcurrent int     `mapstructure:"max_concurrent" validate:"min=0"`
+		Budget        float64 `mapstructure:"budget" validate:"min=0"`
+		Renderer      string  `mapstructure:"renderer" validate:"oneof=terminal json markdown quiet"`
+	} `mapstructure:"sessions"`
+
+	ResultSummary struct {
+		Threshold int `mapstructure:"threshold" validate:"min=0"`
+		PageSize  int `mapstructure:"page_size" validate:"min=1"`
+		Notable   int `mapstructure:"notable" validate:"min=0"`
+	} `mapstructure:"result_summary"`
+
+	IndexCoverage struct {
+		WarnBelow float64       `mapstructure:"warn_below" validate:"min=0,max=1"`
+		CacheTTL  time.Duration `mapstructure:"cache_ttl" validate:"min=0"`
+	} `mapstructure:"index_coverage"`
+
+	Sandbox struct {
+		AllowedCommands []string      `mapstructure:"allowed_commands" validate:"min=1"`
+		Timeout         time.Duration `mapstructure:"timeout" validate:"min=1s"`
+		MaxOutput       int           `mapstructure:"max_output" validate:"min=1"`
+	} `mapstructure:"sandbox"`
+
+	Freshness struct {
+		MaxReindex int `mapstructure:"max_reindex" validate:"min=0"`
+	} `mapstructure:"freshness"`
+
+	QueryLanguage struct {
+		ResponseLanguage string  `mapstructure:"response_language" validate:"required"`
+		MinConfidence    float64 `mapstructure:"min_confidence" validate:"min=0,max=1"`
+	} `mapstructure:"query_language"`
+
+	Privacy struct {
+		Mode string `mapstructure:"mode" validate:"oneof=redact warn"`
+	} `mapstructure:"privacy"`
+
+	ExternalCalls struct {
+		HashLength int `mapstructure:"hash_length" validate:"min=0,max=64"`
+	} `mapstructure:"external_calls"`
+
+	Audit struct {
+		OutputDir           string `mapstructure:"output_dir" validate:"required"`
+		Format              string `mapstructure:"format" validate:"oneof=markdown md html both"`
+		ComplexityThreshold int    `mapstructure:"complexity_threshold" validate:"min=1"`
+		TopFindings         int    `mapstructure:"top_findings" validate:"min=1"`
+	} `mapstructure:"audit"`
+
+	Policies struct {
+		File          string `mapstructure:"file" validate:"required"`
+		MaxCandidates int    `mapstructure:"max_candidates" validate:"min=0"`
+		BatchSize     int    `mapstructure:"batch_size" validate:"min=1,max=50"`
+		MaxLines      int    `mapstructure:"max_lines" validate:"min=1"`
+	} `mapstructure:"policies"`
+
+	CI struct {
+		Base   string `mapstructure:"base" validate:"required"`
+		FailOn string `mapstructure:"fail_on" validate:"oneof=low medium high"`
+	} `mapstructure:"ci"`
+
+	Remote struct {
+		Timeout time.Duration `mapstructure:"timeout" validate:"min=1s"`
+	} `mapstructure:"remote"`
+}
+
+// validateConfig checks the loaded properties against configSchema and the
+// rules that span several keys, such as the vector size matching the
+// embedding model
+func validateConfig(config *Config) configcheck.Issues {
+	var schema configSchema
+	if err := viper.Unmarshal(&schema); err != nil {
+		var issues configcheck.Issues
+		issues.Add("properties.yaml", "could not be decoded: %v", err)
+		return issues
+	}
+	issues := configcheck.Check(&schema)
+
+	switch strings.ToLower(config.Performance.WALCheckpoint) {
+	case "", "none", "passive", "full", "restart", "truncate":
+	default:
+		issues.Add("performance.wal_checkpoint", "must be one of passive, full, restart, truncate, none (got %q)", config.Performance.WALCheckpoint)
+	}
+
+	for name := range config.Flags {
+		if _, ok := flags.Lookup(name); !ok {
+			var known []string
+			for _, flag := range flags.Known() {
+				known = append(known, flag.Name)
+			}
+			issues.Add("flags."+name, "is not a known feature flag; use one of %s", strings.Join(known, ", "))
+		}
+	}
+
+	seen := make(map[string]bool)
+	for i, server := range config.MCPServers {
+		key := fmt.Sprintf("mcp_servers[%d]", i)
+		switch {
+		case server.Name == "":
+			issues.Add(key+".name", "is required")
+		case seen[server.Name]:
+			issues.Add(key+".name", "must be unique (%q is used twice)", server.Name)
+		}
+		seen[server.Name] = true
+		switch server.Transport {
+		case "", mcp.TransportStdio:
+			if server.Command == "" {
+				issues.Add(key+".command", "is required for the stdio transport")
+			}
+		case mcp.TransportHTTP:
+			if server.URL == "" {
+				issues.Add(key+".url", "is required for the http transport")
+			}
+		default:
+			issues.Add(key+".transport", "must be stdio or http (got %q)", server.Transport)
+		}
+		switch server.Capability {
+		case "", mcp.CapabilityRead, mcp.CapabilityWrite, mcp.CapabilityExec:
+		default:
+			issues.Add(key+".capability", "must be read, write or exec (got %q)", server.Capability)
+		}
+	}
+
+	language := config.QueryLanguage.ResponseLanguage
+	if language != "" && language != "auto" && !i18n.Supported(language) {
+		issues.Add("query_language.response_language", "must be auto or a supported language code (got %q)", language)
+	}
+
+	dimension := config.VectorDB.Dimension
+	model := embeddingEndpoint(config).ModelName()
+	if full, ok := vectordb.ModelDimensions(model); ok && dimension > 0 {
+		// Gemini embeddings are truncated to the collection's size; OpenAI
+		// models return their full size unless a reduction asks for less
+		if config.VectorDB.EmbeddingProvider == "gemini" {
+			if dimension > full {
+				issues.Add("vectordb.dimension", "must be at most %d for embedding model %s (got %d)", full, model, dimension)
+			}
+		} else if dimension != full {
+			issues.Add("vectordb.dimension", "must match embedding model %s (%d), got %d", model, full, dimension)
+		}
+	}
+
+	collections := make([]string, 0, len(config.VectorDB.Reductions))
+	for collection := range config.VectorDB.Reductions {
+		collections = append(collections, collection)
+	}
+	sort.Strings(collections)
+	for _, collection := range collections {
+		reduction := config.VectorDB.Reductions[collection]
+		key := "vectordb.reductions." + collection
+		if err := reduction.Validate(); err != nil {
+			issues.Add(key, "is invalid: %v", err)
+			continue
+		}
+		if reduction.Enabled() && dimension > 0 && reduction.Dimensions >= dimension {
+			issues.Add(key+".dimensions", "must be smaller than vectordb.dimension (%d), got %d", dimension, reduction.Dimensions)
+		}
+	}
+	return issues
+}
+
+// ValidateConfig loads the configuration and reports every problem found,
+// for the config validate command. The error is only set when the
+// configuration could not be loaded for another reason.
+func ValidateConfig() (configcheck.Issues, error) {
+	_, err := loadConfig()
+	if issues, ok := err.(configcheck.Issues); ok {
+		return issues, nil
+	}
+	return nil, err
+}
@@ -0,0 +1,26 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/yourusername/useq-ai-assistant/internal/agents"
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// SetCostConfirmer sets how Tier 3 cost estimates are shown and expensive
+// queries confirmed. Until one is set, as in server mode, queries run
+// without asking.
+func (app *CLIApplication) SetCostConfirmer(confirm agents.CostConfirmer) {
+	if app.managerAgent != nil {
+		app.managerAgent.SetCostConfirmer(confirm)
+	}
+}
+
+// CostEstimateAccuracy compares recent Tier 3 estimates with what the
+// queries actually used, across providers
+func (app *CLIApplication) CostEstimateAccuracy() (*storage.CostEstimateAccuracy, error) {
+	if app.storage == nil {
+		return nil, fmt.Errorf("cost estimates need storage")
+	}
+	return app.storage.CostEstimateAccuracy("", app.config.CostEstimate.Window)
+}
@@ -0,0 +1,93 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/internal/deps"
+	"github.com/yourusername/useq-ai-assistant/internal/indexer"
+	"github.com/yourusername/useq-ai-assistant/internal/sandbox"
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// initializeDependencySearch connects the separate dependency collection when
+// dependency search is enabled. Failure only disables include:deps.
+func (app *CLIApplication) initializeDependencySearch() {
+	if !app.config.Dependencies.Enabled || app.vectorDB == nil {
+		return
+	}
+	depsDB, err := app.vectorDB.WithCollection(app.config.Dependencies.Collection)
+	if err != nil {
+		app.logWarning("DEPS_INIT", fmt.Sprintf("Dependency search unavailable: %v", err))
+		return
+	}
+	app.depsVectorDB = depsDB
+	app.logInfo("DEPS_INIT", fmt.Sprintf("Dependency search ready (collection %s)", app.config.Dependencies.Collection))
+}
+
+// applyIncludeDeps strips the include:deps token and marks the query so
+// search also covers indexed dependency source
+func (app *CLIApplication) applyIncludeDeps(query *models.Query) {
+	input, include := deps.ParseIncludeToken(query.UserInput)
+	if !include {
+		return
+	}
+	query.UserInput = input
+	if query.Metadata == nil {
+		query.Metadata = make(map[string]string)
+	}
+	query.Metadata["include_deps"] = "true"
+	if app.depsVectorDB == nil {
+		app.logWarning("DEPS_SEARCH", "include:deps requested but dependency search is not enabled")
+	}
+}
+
+// IndexDependencies embeds the configured dependencies from the module cache
+// into the dependency collection
+func (app *CLIApplication) IndexDependencies(ctx context.Context) (*indexer.DependencyIndexStats, error) {
+	if !app.config.Dependencies.Enabled {
+		return nil, fmt.Errorf("dependency search is disabled; set dependencies.enabled in config/properties.yaml")
+	}
+	if app.indexer == nil {
+		return nil, fmt.Errorf("indexer is not available")
+	}
+	stats, err := app.indexer.IndexDependencies(ctx, app.config.Dependencies)
+	if err != nil {
+		return stats, err
+	}
+	if app.depsVectorDB == nil {
+		app.initializeDependencySearch()
+	}
+	return stats, nil
+}
+
+// AddModules runs the sandboxed go get for each proposed module, pinned to
+// its version, so generated code that imports it builds. go.mod and go.sum
+// are updated in place; the first failure stops the rest.
+func (app *CLIApplication) AddModules(ctx context.Context, modules []models.Dependency) ([]string, error) {
+	runner, err := sandbox.NewRunner(app.config.ProjectRoot, app.config.Sandbox)
+	if err != nil {
+		return nil, fmt.Errorf("sandbox unavailable: %w", err)
+	}
+	var added []string
+	for _, module := range modules {
+		if module.Type != "module" {
+			continue
+		}
+		target := module.Name + "@latest"
+		if module.Version != "" {
+			target = module.Name + "@" + module.Version
+		}
+		result, err := runner.Run(ctx, "go", "get", target)
+		if err != nil {
+			return added, fmt.Errorf("failed to run go get %s: %w", target, err)
+		}
+		if result.ExitCode != 0 {
+			return added, fmt.Errorf("go get %s failed: %s", target, strings.TrimSpace(result.Output))
+		}
+		app.logInfo("DEPS_ADD", fmt.Sprintf("Added %s", target))
+		added = append(added, target)
+	}
+	return added, nil
+}
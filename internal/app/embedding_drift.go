@@ -0,0 +1,168 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/yourusername/useq-ai-assistant/internal/tasks"
+	"github.com/yourusername/useq-ai-assistant/internal/vectordb"
+	"github.com/yourusername/useq-ai-assistant/models"
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// TaskKindReembed rebuilds every vector with the current embedding model,
+// resumable per file
+const TaskKindReembed = "reembed"
+
+// ReembedPlan describes what a reembed migration rebuilds
+type ReembedPlan struct {
+	Collection string
+	Current    vectordb.EmbeddingFingerprint
+	Drift      *vectordb.EmbeddingDrift // nil when the vectors match the current model
+	Points     int                      // vectors stored now, all dropped and rebuilt
+}
+
+// checkEmbeddingDrift compares the embedding model the collection was built
+// with against the one queries use now. A collection with no record is
+// adopted when it is empty or its vectors have the current size, since
+// nothing tells which model built them.
+func (app *CLIApplication) checkEmbeddingDrift(ctx context.Context) {
+	if app.vectorDB == nil || app.storage == nil {
+		return
+	}
+	collection := app.vectorDB.Collection()
+	current := app.vectorDB.Fingerprint()
+
+	record, err := app.storage.GetCollectionEmbedding(collection)
+	if err != nil {
+		app.logWarning("EMBEDDING_DRIFT", err.Error())
+		return
+	}
+	var stored vectordb.EmbeddingFingerprint
+	if record != nil {
+		stored = vectordb.EmbeddingFingerprint{Model: record.Model, Dimension: record.Dimension}
+	} else {
+		size, points, err := app.vectorDB.StoredVectors(ctx)
+		if err != nil {
+			app.logWarning("EMBEDDING_DRIFT", "Drift check skipped: "+err.Error())
+			return
+		}
+		if points == 0 || size == current.Dimension {
+			app.recordEmbeddingModel()
+			return
+		}
+		stored = vectordb.EmbeddingFingerprint{Dimension: size}
+	}
+
+	drift := vectordb.DetectDrift(collection, stored, current)
+	app.setEmbeddingDrift(drift)
+	if drift != nil {
+		fmt.Printf("  ⚠️ Embedding model changed: %v\n", drift)
+		app.logWarning("EMBEDDING_DRIFT", drift.Error())
+	}
+}
+
+// recordEmbeddingModel records that the collection now holds vectors of the
+// current embedding model
+func (app *CLIApplication) recordEmbeddingModel() {
+	if app.vectorDB == nil || app.storage == nil {
+		return
+	}
+	current := app.vectorDB.Fingerprint()
+	err := app.storage.RecordCollectionEmbedding(&storage.CollectionEmbedding{
+		Collection: app.vectorDB.Collection(),
+		Model:      current.Model,
+		Dimension:  current.Dimension,
+	})
+	if err != nil {
+		app.logWarning("EMBEDDING_DRIFT", err.Error())
+		return
+	}
+	app.setEmbeddingDrift(nil)
+}
+
+func (app *CLIApplication) setEmbeddingDrift(drift *vectordb.EmbeddingDrift) {
+	app.driftMu.Lock()
+	defer app.driftMu.Unlock()
+	app.embeddingDrift = drift
+}
+
+// EmbeddingDrift returns the mismatch between the stored vectors and the
+// current embedding model, nil when they match
+func (app *CLIApplication) EmbeddingDrift() *vectordb.EmbeddingDrift {
+	app.driftMu.Lock()
+	defer app.driftMu.Unlock()
+	return app.embeddingDrift
+}
+
+// checkDriftPolicy refuses queries while the vectors do not match the
+// embedding model, when the drift policy says so
+func (app *CLIApplication) checkDriftPolicy() error {
+	drift := app.EmbeddingDrift()
+	if drift == nil || app.config.VectorDB.DriftPolicy != vectordb.DriftRefuse {
+		return nil
+	}
+	return fmt.Errorf("%v (or set vectordb.drift_policy to %q to query anyway)", drift, vectordb.DriftWarn)
+}
+
+// annotateEmbeddingDrift warns that an answer searched vectors built with
+// another embedding model, so its results may be unrelated to the query
+func (app *CLIApplication) annotateEmbeddingDrift(response *models.Response) {
+	drift := app.EmbeddingDrift()
+	if drift == nil || response == nil {
+		return
+	}
+	note := fmt.Sprintf("vectors were embedded with %s but the query with %s; run `reembed` to rebuild them", drift.Stored, drift.Current)
+	if response.Metadata.Degraded != "" {
+		note = response.Metadata.Degraded + "; " + note
+	}
+	response.Metadata.Degraded = note
+}
+
+// PlanReembed describes the vectors a reembed migration would rebuild
+func (app *CLIApplication) PlanReembed(ctx context.Context) (*ReembedPlan, error) {
+	if app.vectorDB == nil {
+		return nil, fmt.Errorf("vector database not initialized")
+	}
+	_, points, err := app.vectorDB.StoredVectors(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &ReembedPlan{
+		Collection: app.vectorDB.Collection(),
+		Current:    app.vectorDB.Fingerprint(),
+		Drift:      app.EmbeddingDrift(),
+		Points:     points,
+	}, nil
+}
+
+// StartReembedTask drops the project's vectors and embeds every file again
+// with the current model in the background
+func (app *CLIApplication) StartReembedTask(ctx context.Context) (*storage.TaskRecord, error) {
+	if app.tasks == nil {
+		return nil, fmt.Errorf("background tasks need storage")
+	}
+	if app.indexer == nil || app.vectorDB == nil {
+		return nil, fmt.Errorf("indexer not initialized")
+	}
+	title := fmt.Sprintf("re-embed %s with %s", app.vectorDB.Collection(), app.vectorDB.Fingerprint())
+	return app.tasks.Start(ctx, TaskKindReembed, title, struct{}{})
+}
+
+// runReembedTask recreates the collections at the current size, then
+// re-indexes every file so its chunks are embedded with the current model.
+// A resumed task keeps the vectors it already rebuilt.
+func (app *CLIApplication) runReembedTask(ctx context.Context, run *tasks.Run) (string, error) {
+	if app.indexer == nil || app.vectorDB == nil {
+		return "", fmt.Errorf("indexer not initialized")
+	}
+	checkpoint, err := app.indexProjectFiles(ctx, run, app.vectorDB.RecreateCollections, app.indexer.ReindexFile)
+	if err != nil {
+		return "", err
+	}
+	current := app.vectorDB.Fingerprint()
+	app.indexer.RecordGeneration(ctx, fmt.Sprintf("re-embed with %s, task %s", current, run.ID()))
+	app.recordEmbeddingModel()
+	total := len(checkpoint.Files)
+	return fmt.Sprintf("Re-embedded %d files with %s (%d failed)", total-checkpoint.Failed, current, checkpoint.Failed), nil
+}
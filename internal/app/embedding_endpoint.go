@@ -0,0 +1,53 @@
+package app
+
+import (
+	"github.com/yourusername/useq-ai-assistant/internal/vectordb"
+)
+
+// embeddingEndpoint returns where embeddings are requested. By default that
+// is the OpenAI provider's endpoint, addressed by the embedding deployment on
+// Azure and the embedding model elsewhere; vectordb.embedding_provider:
+// gemini uses Gemini embeddings sized to the collection instead.
+func embeddingEndpoint(config *Config) vectordb.EmbeddingEndpoint {
+	if config.VectorDB.EmbeddingProvider == "gemini" {
+		gemini := config.AIProviders.Gemini
+		return vectordb.EmbeddingEndpoint{
+			APIType:    "gemini",
+			BaseURL:    gemini.BaseURL,
+			APIKey:     gemini.APIKey,
+			Model:      gemini.EmbeddingModel,
+			Dimensions: config.VectorDB.Dimension,
+		}
+	}
+
+	provider := config.AIProviders.OpenAI
+	endpoint := vectordb.DefaultEmbeddingEndpoint()
+	if provider.APIType != "" {
+		endpoint.APIType = provider.APIType
+	}
+	endpoint.BaseURL = provider.BaseURL
+	endpoint.APIVersion = provider.APIVersion
+	endpoint.Deployment = provider.EmbeddingDeployment
+	if provider.APIKey != "" {
+		endpoint.APIKey = provider.APIKey
+	}
+	if provider.EmbeddingModel != "" {
+		endpoint.Model = provider.EmbeddingModel
+	}
+	return endpoint
+}
+
+// embeddingServiceConfig returns the embedding service configuration for
+// the configured embedding endpoint
+func embeddingServiceConfig(config *Config) *vectordb.EmbeddingConfig {
+	endpoint := embeddingEndpoint(config)
+	return &vectordb.EmbeddingConfig{
+		APIKey:     endpoint.APIKey,
+		Endpoint:   endpoint.BaseURL,
+		Model:      endpoint.Model,
+		APIType:    endpoint.APIType,
+		Deployment: endpoint.Deployment,
+		APIVersion: endpoint.APIVersion,
+		Dimensions: endpoint.Dimensions,
+	}
+}
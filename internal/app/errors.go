@@ -0,0 +1,73 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/internal/audit"
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// ErrorStyles inventories how the indexed Go packages wrap and create
+// errors, or only the package in dir, and records the styles in the pattern
+// database. Packages that no longer parse are skipped.
+func (app *CLIApplication) ErrorStyles(dir string) ([]*audit.ErrorInventory, error) {
+	if app.storage == nil {
+		return nil, fmt.Errorf("error analysis needs storage")
+	}
+	files, err := app.storage.GetIndexedFiles()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list indexed files: %w", err)
+	}
+	batches := audit.GroupByPackage(app.config.ProjectRoot, files)
+	if dir != "" {
+		dir = audit.RelativePath(app.config.ProjectRoot, filepath.Clean(dir))
+		var selected []audit.Batch
+		for _, batch := range batches {
+			if batch.Dir == dir {
+				selected = append(selected, batch)
+			}
+		}
+		if len(selected) == 0 {
+			return nil, fmt.Errorf("no indexed Go package in %s", dir)
+		}
+		batches = selected
+	}
+	if len(batches) == 0 {
+		return nil, fmt.Errorf("no indexed Go files; run 'index' first")
+	}
+
+	auditor := audit.NewAuditor(app.config.ProjectRoot, app.config.Audit)
+	var inventories []*audit.ErrorInventory
+	for _, batch := range batches {
+		inv, err := auditor.InventoryBatch(batch)
+		if err != nil {
+			continue
+		}
+		inventories = append(inventories, inv)
+	}
+	if app.managerAgent != nil && app.managerAgent.IntelligenceCodingAgent != nil {
+		app.managerAgent.IntelligenceCodingAgent.RecordErrorStyles(inventories)
+	}
+	return inventories, nil
+}
+
+// NormalizeErrorHandling has the coding agent rewrite the functions of the
+// package in dir that stray from its dominant error styles. Nothing is
+// written; see ApplyCodeChanges.
+func (app *CLIApplication) NormalizeErrorHandling(ctx context.Context, dir string) (*models.Response, error) {
+	if strings.TrimSpace(dir) == "" {
+		return nil, fmt.Errorf("name the package directory to normalize")
+	}
+	inventories, err := app.ErrorStyles(dir)
+	if err != nil {
+		return nil, err
+	}
+	if app.codingAgent == nil {
+		return nil, fmt.Errorf("normalizing error handling requires the coding agent")
+	}
+	app.prewarmer.Touch()
+	return app.codingAgent.NormalizeErrorHandling(ctx, inventories[0], app.config.ProjectRoot)
+}
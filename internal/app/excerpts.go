@@ -0,0 +1,25 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// LastExcerpts returns the code excerpts of the session's latest answer
+// that has any, for unfolding them after the answer was shown
+func (app *CLIApplication) LastExcerpts(sessionID string) ([]models.CodeExcerpt, error) {
+	if sessionID == "" {
+		sessionID = app.sessionID
+	}
+	history, err := app.sessionManager.GetSessionHistory(sessionID, 0)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(history) - 1; i >= 0; i-- {
+		if response := history[i].Response; response != nil && len(response.Content.Excerpts) > 0 {
+			return response.Content.Excerpts, nil
+		}
+	}
+	return nil, fmt.Errorf("no answer in this session has code excerpts")
+}
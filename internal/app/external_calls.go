@@ -0,0 +1,29 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/calllog"
+)
+
+// ExternalCalls returns the calls made to LLM providers and embedding
+// endpoints within the given window (e.g. 24h, 7d or 2w), oldest first
+func (app *CLIApplication) ExternalCalls(window string) ([]calllog.Call, error) {
+	if app.callLog == nil {
+		return nil, fmt.Errorf("the external call log is not open")
+	}
+	age, err := parseAge(window)
+	if err != nil {
+		return nil, err
+	}
+	return app.callLog.Since(time.Now().Add(-age))
+}
+
+// ExternalCallLogPath returns the file external calls are appended to
+func (app *CLIApplication) ExternalCallLogPath() string {
+	if app.callLog == nil {
+		return ""
+	}
+	return app.callLog.Path()
+}
@@ -0,0 +1,35 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// applyFileMentions records the files the query names with @ for the agents
+// to load into context, and drops the @ so the rest of the pipeline reads
+// the paths as written
+func (app *CLIApplication) applyFileMentions(query *models.Query) {
+	mentions, input := ParseFileMentions(query.UserInput, app.isIndexedFile)
+	if len(mentions) == 0 {
+		return
+	}
+	query.Context.Mentions = append(query.Context.Mentions, mentions...)
+	query.UserInput = input
+
+	paths := make([]string, len(mentions))
+	for i, mention := range mentions {
+		paths[i] = mention.Path
+	}
+	app.logInfo("FILE_MENTIONS", fmt.Sprintf("Query names files with @: %s", strings.Join(paths, ", ")))
+}
+
+// isIndexedFile reports whether path names an indexed file
+func (app *CLIApplication) isIndexedFile(path string) bool {
+	if app.storage == nil {
+		return false
+	}
+	indexed, err := app.storage.HasIndexedFile(path)
+	return err == nil && indexed
+}
@@ -0,0 +1,144 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/internal/fingerprint"
+	"github.com/yourusername/useq-ai-assistant/internal/llm"
+	"github.com/yourusername/useq-ai-assistant/models"
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// tagFingerprint records the configuration an answer was produced with -
+// the system prompt templates its LLM calls sent, the model settings, the ranking
+// settings and the index generation - and puts its fingerprint in the
+// answer's metadata
+func (app *CLIApplication) tagFingerprint(response *models.Response, prompts *llm.PromptRecord) {
+	snapshot := app.configSnapshot(response.Metadata.IndexGeneration)
+	snapshot.Prompts = prompts.SystemPrompts()
+	snapshot.CalledModels = prompts.Models()
+	response.Metadata.Fingerprint = snapshot.Hash()
+
+	if app.storage == nil || response.ID == "" {
+		return
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		app.logWarning("FINGERPRINT", fmt.Sprintf("Failed to encode config snapshot: %v", err))
+		return
+	}
+	if err := app.storage.SaveResponseFingerprint(response.ID, response.Metadata.Fingerprint, string(data)); err != nil {
+		app.logWarning("FINGERPRINT", err.Error())
+	}
+}
+
+// ExplainFingerprint shows the configuration that produced an answer and
+// what changed in it since; "last" or an empty id picks the latest answer
+func (app *CLIApplication) ExplainFingerprint(responseID string) (*fingerprint.Explanation, error) {
+	if app.storage == nil {
+		return nil, fmt.Errorf("fingerprints need storage")
+	}
+	latest := responseID == "" || responseID == "last"
+	var record *storage.ResponseFingerprint
+	var err error
+	if latest {
+		record, err = app.storage.LatestResponseFingerprint()
+	} else {
+		record, err = app.storage.GetResponseFingerprint(responseID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if record == nil && latest {
+		return nil, fmt.Errorf("no answer has a recorded fingerprint yet")
+	}
+	if record == nil {
+		return nil, fmt.Errorf("no fingerprint recorded for %s", responseID)
+	}
+
+	var then fingerprint.Snapshot
+	if err := json.Unmarshal([]byte(record.Snapshot), &then); err != nil {
+		return nil, fmt.Errorf("invalid config snapshot of %s: %w", record.ResponseID, err)
+	}
+	generation, err := app.storage.CurrentIndexGeneration()
+	if err != nil {
+		generation = then.IndexGeneration
+	}
+	return fingerprint.Explain(record.ResponseID, record.CreatedAt, &then, app.configSnapshot(generation)), nil
+}
+
+// configSnapshot captures the model and ranking settings in effect, without
+// API keys or endpoints
+func (app *CLIApplication) configSnapshot(indexGeneration int64) *fingerprint.Snapshot {
+	providers := app.config.AIProviders
+	snapshotModels := map[string]string{
+		"primary":        providers.Primary,
+		"fallback_order": strings.Join(providers.FallbackOrder, ","),
+	}
+	for name, provider := range map[string]llm.ProviderConfig{
+		"openai": providers.OpenAI,
+		"gemini": providers.Gemini,
+		"cohere": providers.Cohere,
+		"claude": providers.Claude,
+	} {
+		if provider.Model == "" && provider.EmbeddingModel == "" {
+			continue
+		}
+		snapshotModels[name+".model"] = provider.Model
+		snapshotModels[name+".embedding_model"] = provider.EmbeddingModel
+		snapshotModels[name+".deployment"] = provider.Deployment
+		snapshotModels[name+".max_tokens"] = strconv.Itoa(provider.MaxTokens)
+		snapshotModels[name+".temperature"] = strconv.FormatFloat(provider.Temperature, 'g', -1, 64)
+	}
+	if routing := providers.Routing; routing != nil {
+		data, _ := json.Marshal(routing)
+		snapshotModels["routing"] = string(data)
+	}
+
+	vectorDB := app.config.VectorDB
+	ranking := map[string]interface{}{
+		"vector_db": map[string]interface{}{
+			"backend":            vectorDB.Backend,
+			"collection":         vectorDB.CollectionName,
+			"dimension":          vectorDB.Dimension,
+			"payload_mode":       vectorDB.PayloadMode,
+			"embedding_provider": vectorDB.EmbeddingProvider,
+			"reductions":         vectorDB.Reductions,
+			"tuning":             vectorDB.Tuning,
+			"sharding":           vectorDB.Sharding,
+		},
+		"summary_search": app.config.SummarySearch,
+		"exact_lookup":   app.config.ExactLookup,
+		"file_mentions":  app.config.FileMentions,
+		"memory":         app.config.Memory,
+		"excerpts":       app.config.Excerpts,
+		"pins":           app.config.Pins,
+		"tags":           app.config.Tags,
+		"result_summary": app.config.ResultSummary,
+		"freshness":      app.config.Freshness,
+		"flags":          app.config.Flags,
+	}
+	if app.config.VocabularyFile != "" {
+		if data, err := os.ReadFile(app.config.VocabularyFile); err == nil {
+			ranking["vocabulary"] = string(data)
+		}
+	}
+	sections := make(map[string]json.RawMessage, len(ranking))
+	for name, section := range ranking {
+		data, err := json.Marshal(section)
+		if err != nil {
+			continue
+		}
+		sections[name] = data
+	}
+
+	return &fingerprint.Snapshot{
+		Models:          snapshotModels,
+		Ranking:         sections,
+		IndexGeneration: indexGeneration,
+	}
+}
@@ -0,0 +1,67 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/yourusername/useq-ai-assistant/internal/flags"
+)
+
+// Flags returns the feature flags of a session and where each value came
+// from
+func (app *CLIApplication) Flags(sessionID string) []flags.State {
+	return app.featureFlags.States(app.flagOverrides(sessionID))
+}
+
+// SetFlag switches a feature flag for a session, overriding the config and
+// environment
+func (app *CLIApplication) SetFlag(sessionID, name string, on bool) (flags.State, error) {
+	return app.updateFlag(sessionID, name, func(overrides map[string]bool, flag string) {
+		overrides[flag] = on
+	})
+}
+
+// ResetFlag drops a session's override of a feature flag, so the config or
+// environment decides again
+func (app *CLIApplication) ResetFlag(sessionID, name string) (flags.State, error) {
+	return app.updateFlag(sessionID, name, func(overrides map[string]bool, flag string) {
+		delete(overrides, flag)
+	})
+}
+
+func (app *CLIApplication) updateFlag(sessionID, name string, update func(map[string]bool, string)) (flags.State, error) {
+	if sessionID == "" {
+		sessionID = app.sessionID
+	}
+	flag, ok := flags.Lookup(name)
+	if !ok {
+		return flags.State{}, fmt.Errorf("unknown feature flag %q; run /flags to list them", name)
+	}
+
+	preferences := app.sessionManager.GetUserPreferences(sessionID)
+	// The preferences share their map with the session; change a copy
+	overrides := make(map[string]bool, len(preferences.Flags)+1)
+	for key, value := range preferences.Flags {
+		overrides[key] = value
+	}
+	update(overrides, flag.Name)
+	preferences.Flags = overrides
+	app.sessionManager.UpdateUserPreferences(sessionID, preferences)
+
+	for _, state := range app.featureFlags.States(overrides) {
+		if state.Name == flag.Name {
+			return state, nil
+		}
+	}
+	return flags.State{}, fmt.Errorf("unknown feature flag %q", name)
+}
+
+// flagOverrides returns the feature flags a session switched itself
+func (app *CLIApplication) flagOverrides(sessionID string) map[string]bool {
+	if sessionID == "" {
+		sessionID = app.sessionID
+	}
+	if app.sessionManager == nil {
+		return nil
+	}
+	return app.sessionManager.GetUserPreferences(sessionID).Flags
+}
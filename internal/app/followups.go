@@ -0,0 +1,142 @@
+package app
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/internal/agents"
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// maxFollowUps is how many next steps an answer suggests
+const maxFollowUps = 3
+
+// suggestFollowUps attaches the next steps an answer invites, derived from
+// what it holds and what the query asked for: applying the changes it
+// proposes, testing or tracing the functions it names, explaining the top
+// search result
+func (app *CLIApplication) suggestFollowUps(intent *models.QueryIntent, response *models.Response) {
+	switch response.Type {
+	case models.ResponseTypeClarification, models.ResponseTypePlan, models.ResponseTypeError, models.ResponseTypeSystem:
+		return
+	}
+
+	var followUps []models.FollowUp
+	add := func(label, input string) {
+		for _, existing := range followUps {
+			if existing.Input == input {
+				return
+			}
+		}
+		followUps = append(followUps, models.FollowUp{Label: label, Input: input})
+	}
+
+	content := response.Content
+	testing := response.Type == models.ResponseTypeTest || (intent != nil && intent.Primary == models.QueryTypeTesting)
+	if content.Code != nil && len(content.Code.Changes) > 0 {
+		add("Apply this diff", "apply")
+	}
+	if search := content.Search; search != nil && len(search.Results) > 0 {
+		if name, ok := agents.ExactSymbolName(search.Results[0].Function); ok {
+			add("Explain "+name, fmt.Sprintf("explain %s in %s", name, search.Results[0].File))
+		}
+	}
+	for _, name := range answerFunctions(intent, response) {
+		if !testing {
+			add("Generate tests for "+name, "test "+name)
+		}
+		add("Show callers of "+name, name)
+	}
+
+	if len(followUps) > maxFollowUps {
+		followUps = followUps[:maxFollowUps]
+	}
+	response.Content.FollowUps = followUps
+}
+
+// answerFunctions returns the functions an answer is about, most relevant
+// first: the functions it quotes, the ones its changes touch, the ones the
+// query named and the ones its search results are in. Names are bare, as
+// the exact symbol lookup takes them.
+func answerFunctions(intent *models.QueryIntent, response *models.Response) []string {
+	var names []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if i := strings.LastIndex(name, "."); i >= 0 {
+			name = name[i+1:]
+		}
+		if name, ok := agents.ExactSymbolName(name); ok && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	content := response.Content
+	for _, excerpt := range content.Excerpts {
+		add(excerpt.Symbol)
+	}
+	if content.Code != nil {
+		for _, change := range content.Code.Changes {
+			add(change.Anchor)
+		}
+	}
+	if intent != nil {
+		for _, name := range intent.FuncTargets {
+			add(name)
+		}
+	}
+	if content.Search != nil {
+		for _, result := range content.Search.Results {
+			add(result.Function)
+		}
+	}
+	return names
+}
+
+// ResolveFollowUp turns a number typed after an answer into the input of
+// that answer's follow-up. It reports false for anything else, and while a
+// clarification question waits for its answer, since numbers answer it.
+func (app *CLIApplication) ResolveFollowUp(sessionID, input string) (string, bool) {
+	n, err := strconv.Atoi(strings.TrimSpace(input))
+	if err != nil || n < 1 {
+		return "", false
+	}
+	if sessionID == "" {
+		sessionID = app.sessionID
+	}
+	app.clarificationMu.Lock()
+	pending := app.pendingClarifications[sessionID]
+	app.clarificationMu.Unlock()
+	if pending != nil {
+		return "", false
+	}
+
+	history, err := app.sessionManager.GetSessionHistory(sessionID, 1)
+	if err != nil || len(history) == 0 || history[len(history)-1].Response == nil {
+		return "", false
+	}
+	followUps := history[len(history)-1].Response.Content.FollowUps
+	if n > len(followUps) {
+		return "", false
+	}
+	return followUps[n-1].Input, true
+}
+
+// LastChanges returns the session's latest answer that proposes code
+// changes, for applying them after the answer was shown
+func (app *CLIApplication) LastChanges(sessionID string) (*models.Response, error) {
+	if sessionID == "" {
+		sessionID = app.sessionID
+	}
+	history, err := app.sessionManager.GetSessionHistory(sessionID, 0)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(history) - 1; i >= 0; i-- {
+		if response := history[i].Response; response != nil && response.Content.Code != nil && len(response.Content.Code.Changes) > 0 {
+			return response, nil
+		}
+	}
+	return nil, fmt.Errorf("no answer in this session proposes changes")
+}
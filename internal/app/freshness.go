@@ -0,0 +1,61 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// lineRangeSuffix matches the :start-end citation suffix on a source
+var lineRangeSuffix = regexp.MustCompile(`:\d+(-\d+)?$`)
+
+// annotateFreshness flags search results and sources whose files changed on
+// disk since they were indexed, so the answer can be read with that in mind
+func (app *CLIApplication) annotateFreshness(response *models.Response) {
+	if app.storage == nil || !app.config.Freshness.Enabled || response == nil {
+		return
+	}
+
+	var paths []string
+	if response.Content.Search != nil {
+		for _, result := range response.Content.Search.Results {
+			if result.Origin == "" {
+				paths = append(paths, result.File)
+			}
+		}
+	}
+	for _, reference := range response.Content.References {
+		paths = append(paths, reference.File)
+	}
+	for _, source := range response.Metadata.Sources {
+		paths = append(paths, lineRangeSuffix.ReplaceAllString(source, ""))
+	}
+	if len(paths) == 0 {
+		return
+	}
+
+	stale, err := app.storage.StaleFiles(paths)
+	if err != nil {
+		app.logWarning("FRESHNESS", "Freshness check failed: "+err.Error())
+		return
+	}
+	if len(stale) == 0 {
+		return
+	}
+
+	if response.Content.Search != nil {
+		for i := range response.Content.Search.Results {
+			result := &response.Content.Search.Results[i]
+			if result.Origin == "" {
+				result.Stale = stale[result.File]
+			}
+		}
+	}
+	for path := range stale {
+		response.Metadata.StaleSources = append(response.Metadata.StaleSources, path)
+	}
+	sort.Strings(response.Metadata.StaleSources)
+	app.logWarning("FRESHNESS", fmt.Sprintf("Answer cites %d files changed since indexing", len(stale)))
+}
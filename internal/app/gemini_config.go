@@ -0,0 +1,26 @@
+package app
+
+import (
+	"os"
+
+	"github.com/spf13/viper"
+	"github.com/yourusername/useq-ai-assistant/internal/llm"
+)
+
+// geminiProviderConfig reads the Gemini provider settings. The provider is
+// registered only when GEMINI_API_KEY is set; an unset timeout is 30s.
+func geminiProviderConfig() llm.ProviderConfig {
+	return llm.ProviderConfig{
+		APIKey:         os.Getenv("GEMINI_API_KEY"),
+		Model:          viper.GetString("ai_providers.gemini.model"),
+		MaxTokens:      viper.GetInt("ai_providers.gemini.max_tokens"),
+		Temperature:    viper.GetFloat64("ai_providers.gemini.temperature"),
+		Timeout:        viper.GetDuration("ai_providers.gemini.timeout"),
+		BaseURL:        viper.GetString("ai_providers.gemini.base_url"),
+		EmbeddingModel: viper.GetString("ai_providers.gemini.embedding_model"),
+		CostPer1K: llm.CostConfig{
+			Input:  viper.GetFloat64("ai_providers.gemini.cost_per_1k_input"),
+			Output: viper.GetFloat64("ai_providers.gemini.cost_per_1k_output"),
+		},
+	}
+}
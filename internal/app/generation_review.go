@@ -0,0 +1,585 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/language"
+	"github.com/yourusername/useq-ai-assistant/internal/llm"
+	"github.com/yourusername/useq-ai-assistant/internal/mcp"
+	"github.com/yourusername/useq-ai-assistant/internal/tasks"
+	"github.com/yourusername/useq-ai-assistant/models"
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// TaskKindReview generates code in reviewed steps: an outline, then one
+// file at a time
+const TaskKindReview = "review"
+
+// Review decisions
+const (
+	ReviewApprove = "approve" // accept the outline and start generating files
+	ReviewEdit    = "edit"    // replace the outline, still waiting for approval
+	ReviewAccept  = "accept"  // write the drafted file
+	ReviewRevise  = "revise"  // draft the file again with feedback
+	ReviewSkip    = "skip"    // leave the file out
+)
+
+const (
+	reviewOutlineFiles   = 200  // indexed paths shown to the model when outlining
+	reviewAcceptedChars  = 4000 // of each accepted file shown when drafting the next
+	reviewExistingChars  = 8000 // of an existing file shown when drafting its replacement
+	reviewOutlineTokens  = 2000
+	reviewDraftMaxTokens = 4000
+)
+
+const reviewOutlineSchema = `{"name":"generation_outline","strict":true,"schema":{"type":"object",` +
+	`"properties":{"summary":{"type":"string"},"files":{"type":"array","items":{"type":"object","properties":{` +
+	`"path":{"type":"string"},"responsibility":{"type":"string"},"functions":{"type":"array","items":{"type":"string"}}},` +
+	`"required":["path","responsibility","functions"],"additionalProperties":false}}},` +
+	`"required":["summary","files"],"additionalProperties":false}}`
+
+const reviewOutlineSystemPrompt = `You plan code generation for a software project. Given a request and the
+project's files, propose the files to create or change: a path relative to the
+project root, what the file is responsible for, and the functions or types it
+defines. Keep each file focused; order files so each depends only on earlier ones.`
+
+const reviewDraftSystemPrompt = `You write one file of a planned change. Follow the outline and stay
+consistent with the files already accepted. Reply with the complete file
+content in a single fenced code block and nothing else.`
+
+// reviewTaskInput is what a review task is started with
+type reviewTaskInput struct {
+	Request  string `json:"request"`
+	Language string `json:"language"`
+}
+
+// reviewDecision is a user's answer to a waiting review
+type reviewDecision struct {
+	Action   string
+	Outline  *models.ReviewOutline // for ReviewEdit
+	Feedback string                // for ReviewRevise
+}
+
+// reviewWait is a review task blocked on a decision
+type reviewWait struct {
+	decisions chan reviewDecision
+	since     time.Time
+}
+
+// StartGenerationReview plans a large generation and has it reviewed step
+// by step. It runs as a background task that pauses for each decision, so
+// it is resumable with "tasks resume" like any other.
+func (app *CLIApplication) StartGenerationReview(ctx context.Context, request string) (*storage.TaskRecord, error) {
+	if app.tasks == nil {
+		return nil, fmt.Errorf("background tasks need storage")
+	}
+	if app.llmManager == nil {
+		return nil, fmt.Errorf("reviewed generation needs an AI provider")
+	}
+	request = strings.TrimSpace(request)
+	if request == "" {
+		return nil, fmt.Errorf("say what to generate")
+	}
+	detected := language.Detect(request, nil, app.defaultLanguage())
+	return app.tasks.Start(ctx, TaskKindReview, "review "+request, reviewTaskInput{
+		Request:  request,
+		Language: detected.Language,
+	})
+}
+
+// GenerationReview returns the state of a review task; an empty id picks
+// the review waiting for a decision
+func (app *CLIApplication) GenerationReview(id string) (*models.GenerationReview, error) {
+	id, err := app.reviewID(id)
+	if err != nil {
+		return nil, err
+	}
+	record, err := app.tasks.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if record.Kind != TaskKindReview {
+		return nil, fmt.Errorf("task %s is not a review", id)
+	}
+	review := &models.GenerationReview{TaskID: id}
+	if record.Checkpoint != "" {
+		if err := json.Unmarshal([]byte(record.Checkpoint), review); err != nil {
+			return nil, fmt.Errorf("invalid review state of task %s: %w", id, err)
+		}
+	}
+	review.TaskID = id
+	app.reviewMu.Lock()
+	_, review.Waiting = app.reviewWaits[id]
+	app.reviewMu.Unlock()
+	return review, nil
+}
+
+// ApproveReviewOutline starts generating the files of the outline
+func (app *CLIApplication) ApproveReviewOutline(id string) error {
+	return app.decideReview(id, models.ReviewStageOutline, reviewDecision{Action: ReviewApprove})
+}
+
+// AddReviewFile adds a file to the end of a waiting outline
+func (app *CLIApplication) AddReviewFile(id, path, responsibility string) error {
+	path, responsibility = strings.TrimSpace(path), strings.TrimSpace(responsibility)
+	if path == "" || responsibility == "" {
+		return fmt.Errorf("a planned file needs a path and a responsibility")
+	}
+	if _, err := app.reviewPath(path); err != nil {
+		return err
+	}
+	return app.editReviewOutline(id, func(outline *models.ReviewOutline) error {
+		outline.Files = append(outline.Files, models.ReviewOutlineFile{Path: path, Responsibility: responsibility})
+		return nil
+	})
+}
+
+// DropReviewFile removes the nth (1-based) file of a waiting outline
+func (app *CLIApplication) DropReviewFile(id string, n int) error {
+	return app.editReviewOutline(id, func(outline *models.ReviewOutline) error {
+		if n < 1 || n > len(outline.Files) {
+			return fmt.Errorf("the outline has no file %d", n)
+		}
+		outline.Files = append(outline.Files[:n-1], outline.Files[n:]...)
+		return nil
+	})
+}
+
+// DescribeReviewFile replaces the responsibility of the nth (1-based) file
+// of a waiting outline
+func (app *CLIApplication) DescribeReviewFile(id string, n int, responsibility string) error {
+	responsibility = strings.TrimSpace(responsibility)
+	return app.editReviewOutline(id, func(outline *models.ReviewOutline) error {
+		if n < 1 || n > len(outline.Files) {
+			return fmt.Errorf("the outline has no file %d", n)
+		}
+		if responsibility == "" {
+			return fmt.Errorf("say what file %d is responsible for", n)
+		}
+		outline.Files[n-1].Responsibility = responsibility
+		return nil
+	})
+}
+
+// AcceptReviewFile writes the drafted file and moves to the next one
+func (app *CLIApplication) AcceptReviewFile(id string) error {
+	return app.decideReview(id, models.ReviewStageFiles, reviewDecision{Action: ReviewAccept})
+}
+
+// ReviseReviewFile drafts the file again with the reviewer's feedback
+func (app *CLIApplication) ReviseReviewFile(id, feedback string) error {
+	feedback = strings.TrimSpace(feedback)
+	if feedback == "" {
+		return fmt.Errorf("say what to change in the draft")
+	}
+	return app.decideReview(id, models.ReviewStageFiles, reviewDecision{Action: ReviewRevise, Feedback: feedback})
+}
+
+// SkipReviewFile leaves the drafted file out and moves to the next one
+func (app *CLIApplication) SkipReviewFile(id string) error {
+	return app.decideReview(id, models.ReviewStageFiles, reviewDecision{Action: ReviewSkip})
+}
+
+// editReviewOutline changes the outline of a review waiting for approval
+func (app *CLIApplication) editReviewOutline(id string, edit func(*models.ReviewOutline) error) error {
+	review, err := app.GenerationReview(id)
+	if err != nil {
+		return err
+	}
+	if review.Stage != models.ReviewStageOutline || review.Outline == nil {
+		return fmt.Errorf("review %s is past its outline", review.TaskID)
+	}
+	outline := *review.Outline
+	outline.Files = append([]models.ReviewOutlineFile(nil), review.Outline.Files...)
+	if err := edit(&outline); err != nil {
+		return err
+	}
+	return app.decideReview(review.TaskID, models.ReviewStageOutline, reviewDecision{Action: ReviewEdit, Outline: &outline})
+}
+
+// decideReview hands a decision to a review task waiting in stage
+func (app *CLIApplication) decideReview(id, stage string, decision reviewDecision) error {
+	review, err := app.GenerationReview(id)
+	if err != nil {
+		return err
+	}
+	if review.Stage != stage {
+		return fmt.Errorf("review %s is at its %s stage, not %s", review.TaskID, review.Stage, stage)
+	}
+
+	app.reviewMu.Lock()
+	wait, ok := app.reviewWaits[review.TaskID]
+	app.reviewMu.Unlock()
+	if !ok {
+		if app.tasks.Running(review.TaskID) {
+			return fmt.Errorf("review %s is still drafting; check 'review show' in a moment", review.TaskID)
+		}
+		return fmt.Errorf("review %s is not running; continue it with 'tasks resume %s'", review.TaskID, review.TaskID)
+	}
+	select {
+	case wait.decisions <- decision:
+		return nil
+	default:
+		return fmt.Errorf("review %s is already handling a decision", review.TaskID)
+	}
+}
+
+// reviewID resolves an empty id to the review waiting for a decision, the
+// most recent one when several are
+func (app *CLIApplication) reviewID(id string) (string, error) {
+	if app.tasks == nil {
+		return "", fmt.Errorf("background tasks need storage")
+	}
+	if id != "" {
+		return id, nil
+	}
+	app.reviewMu.Lock()
+	defer app.reviewMu.Unlock()
+	var latest time.Time
+	for taskID, wait := range app.reviewWaits {
+		if wait.since.After(latest) {
+			id, latest = taskID, wait.since
+		}
+	}
+	if id == "" {
+		return "", fmt.Errorf("no review is waiting for a decision; start one with '/review <request>'")
+	}
+	return id, nil
+}
+
+// runReviewTask drafts the outline, waits for it to be approved, then
+// drafts and waits on each file in turn. The review state is the task's
+// checkpoint, saved after every step and decision.
+func (app *CLIApplication) runReviewTask(ctx context.Context, run *tasks.Run) (string, error) {
+	var input reviewTaskInput
+	if err := run.Input(&input); err != nil {
+		return "", fmt.Errorf("invalid review task input: %w", err)
+	}
+	review := &models.GenerationReview{TaskID: run.ID(), Request: input.Request}
+	if _, err := run.LoadCheckpoint(review); err != nil {
+		return "", err
+	}
+	save := func(message string) error {
+		total := 1
+		if review.Outline != nil {
+			total = len(review.Outline.Files) + 1
+		}
+		done := 0
+		if review.Stage != models.ReviewStageOutline {
+			done = review.Next + 1
+		}
+		run.Progress(done, total, message)
+		return run.Checkpoint(review, done, total)
+	}
+
+	if review.Outline == nil {
+		run.Progress(0, 1, "drafting the outline")
+		outline, err := app.draftReviewOutline(ctx, review, input)
+		if err != nil {
+			return "", err
+		}
+		review.Stage = models.ReviewStageOutline
+		review.Outline = outline
+	}
+
+	for review.Stage == models.ReviewStageOutline {
+		if err := save("awaiting review: outline"); err != nil {
+			return "", err
+		}
+		decision, err := app.awaitReview(ctx, run)
+		if err != nil {
+			return "", err
+		}
+		switch decision.Action {
+		case ReviewEdit:
+			review.Outline = decision.Outline
+		case ReviewApprove:
+			if len(review.Outline.Files) == 0 {
+				fmt.Printf("⚠️ Review %s: the outline plans no files; add one with 'review add <path>: <responsibility>'\n", run.ID())
+				continue
+			}
+			review.Stage = models.ReviewStageFiles
+		}
+		if err := save("outline " + decision.Action); err != nil {
+			return "", err
+		}
+	}
+
+	for review.Stage == models.ReviewStageFiles && review.Next < len(review.Outline.Files) {
+		file := review.Outline.Files[review.Next]
+		if review.Draft == nil || review.Draft.Path != file.Path {
+			run.Progress(review.Next, len(review.Outline.Files)+1, "drafting "+file.Path)
+			draft, err := app.draftReviewFile(ctx, review, input, nil)
+			if err != nil {
+				return "", err
+			}
+			review.Draft = draft
+		}
+
+		if err := save(fmt.Sprintf("awaiting review: file %d/%d %s", review.Next+1, len(review.Outline.Files), file.Path)); err != nil {
+			return "", err
+		}
+		decision, err := app.awaitReview(ctx, run)
+		if err != nil {
+			return "", err
+		}
+		switch decision.Action {
+		case ReviewAccept:
+			if err := app.writeReviewDraft(review.Draft); err != nil {
+				fmt.Printf("❌ Review %s: %v\n", run.ID(), err)
+				continue
+			}
+			review.Accepted = append(review.Accepted, file.Path)
+			review.Next++
+			review.Draft = nil
+		case ReviewSkip:
+			review.Skipped = append(review.Skipped, file.Path)
+			review.Next++
+			review.Draft = nil
+		case ReviewRevise:
+			feedback := append(append([]string(nil), review.Draft.Feedback...), decision.Feedback)
+			run.Progress(review.Next, len(review.Outline.Files)+1, "revising "+file.Path)
+			draft, err := app.draftReviewFile(ctx, review, input, feedback)
+			if err != nil {
+				return "", err
+			}
+			review.Draft = draft
+		}
+		if err := save(file.Path + " " + decision.Action); err != nil {
+			return "", err
+		}
+	}
+
+	review.Stage = models.ReviewStageDone
+	if err := save("review finished"); err != nil {
+		return "", err
+	}
+	fmt.Printf("✅ Review %s finished: %d files written, %d skipped\n", run.ID(), len(review.Accepted), len(review.Skipped))
+	return fmt.Sprintf("wrote %s; skipped %s; %d tokens ($%.4f)",
+		listOrNone(review.Accepted), listOrNone(review.Skipped), review.Tokens, review.Cost), nil
+}
+
+// awaitReview blocks the task until the user decides, or the task stops
+func (app *CLIApplication) awaitReview(ctx context.Context, run *tasks.Run) (reviewDecision, error) {
+	wait := &reviewWait{decisions: make(chan reviewDecision, 1), since: time.Now()}
+	app.reviewMu.Lock()
+	if app.reviewWaits == nil {
+		app.reviewWaits = make(map[string]*reviewWait)
+	}
+	app.reviewWaits[run.ID()] = wait
+	app.reviewMu.Unlock()
+	defer func() {
+		app.reviewMu.Lock()
+		delete(app.reviewWaits, run.ID())
+		app.reviewMu.Unlock()
+	}()
+
+	fmt.Printf("📝 Review %s is waiting for you; see it with 'review show'\n", run.ID())
+	select {
+	case <-ctx.Done():
+		return reviewDecision{}, ctx.Err()
+	case decision := <-wait.decisions:
+		return decision, nil
+	}
+}
+
+// draftReviewOutline asks the model which files the request needs
+func (app *CLIApplication) draftReviewOutline(ctx context.Context, review *models.GenerationReview, input reviewTaskInput) (*models.ReviewOutline, error) {
+	schema, err := llm.ParseResponseSchema([]byte(reviewOutlineSchema))
+	if err != nil {
+		return nil, err
+	}
+
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "Request: %s\nLanguage: %s\n", input.Request, input.Language)
+	if app.indexer != nil {
+		if files, err := app.indexer.GetIndexedFiles(); err == nil && len(files) > 0 {
+			prompt.WriteString("\nProject files:\n")
+			for i, file := range files {
+				if i == reviewOutlineFiles {
+					fmt.Fprintf(&prompt, "... and %d more\n", len(files)-i)
+					break
+				}
+				prompt.WriteString(app.projectRelative(file) + "\n")
+			}
+		}
+	}
+
+	generated, structured, err := app.llmManager.GenerateStructured(ctx, &llm.GenerationRequest{
+		Messages:       []llm.Message{{Role: "user", Content: prompt.String()}},
+		SystemPrompt:   reviewOutlineSystemPrompt,
+		MaxTokens:      reviewOutlineTokens,
+		ResponseSchema: schema,
+		Metadata:       map[string]string{"purpose": "review_outline"},
+	})
+	addReviewUsage(review, generated)
+	if err != nil {
+		return nil, fmt.Errorf("failed to draft the outline: %w", err)
+	}
+	var outline models.ReviewOutline
+	if err := json.Unmarshal(structured, &outline); err != nil {
+		return nil, fmt.Errorf("failed to read the outline: %w", err)
+	}
+	planned := outline.Files[:0]
+	for _, file := range outline.Files {
+		if _, err := app.reviewPath(file.Path); err != nil {
+			app.logWarning("REVIEW", fmt.Sprintf("Dropped planned file: %v", err))
+			continue
+		}
+		planned = append(planned, file)
+	}
+	outline.Files = planned
+	return &outline, nil
+}
+
+// draftReviewFile asks the model for the current file of the outline,
+// given the files accepted so far and any revision feedback
+func (app *CLIApplication) draftReviewFile(ctx context.Context, review *models.GenerationReview, input reviewTaskInput, feedback []string) (*models.ReviewDraft, error) {
+	file := review.CurrentFile()
+	path, err := app.reviewPath(file.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "Request: %s\nLanguage: %s\n\nOutline: %s\n", input.Request, input.Language, review.Outline.Summary)
+	for i, planned := range review.Outline.Files {
+		marker := " "
+		if i == review.Next {
+			marker = ">"
+		}
+		fmt.Fprintf(&prompt, "%s %s: %s", marker, planned.Path, planned.Responsibility)
+		if len(planned.Functions) > 0 {
+			fmt.Fprintf(&prompt, " (%s)", strings.Join(planned.Functions, ", "))
+		}
+		prompt.WriteString("\n")
+	}
+	for _, accepted := range review.Accepted {
+		if content, err := os.ReadFile(filepath.Join(app.config.ProjectRoot, accepted)); err == nil {
+			fmt.Fprintf(&prompt, "\nAccepted %s:\n```\n%s\n```\n", accepted, truncateReviewText(string(content), reviewAcceptedChars))
+		}
+	}
+	existing, err := os.ReadFile(path)
+	if err == nil {
+		fmt.Fprintf(&prompt, "\nCurrent content of %s, which your file replaces:\n```\n%s\n```\n", file.Path,
+			truncateReviewText(string(existing), reviewExistingChars))
+	}
+	fmt.Fprintf(&prompt, "\nWrite %s.\n", file.Path)
+	if len(feedback) > 0 {
+		prompt.WriteString("\nThe reviewer asked for these changes to your earlier draft:\n")
+		for _, item := range feedback {
+			prompt.WriteString("- " + item + "\n")
+		}
+	}
+
+	generated, err := app.llmManager.Generate(ctx, &llm.GenerationRequest{
+		Messages:     []llm.Message{{Role: "user", Content: prompt.String()}},
+		SystemPrompt: reviewDraftSystemPrompt,
+		MaxTokens:    reviewDraftMaxTokens,
+		Metadata:     map[string]string{"purpose": "review_draft"},
+	})
+	addReviewUsage(review, generated)
+	if err != nil {
+		return nil, fmt.Errorf("failed to draft %s: %w", file.Path, err)
+	}
+	content := fencedContent(generated.Content)
+	return &models.ReviewDraft{
+		Path:     file.Path,
+		Content:  content,
+		Exists:   existing != nil,
+		Feedback: feedback,
+		Policies: app.draftPolicyViolations(ctx, review, file.Path, content),
+	}, nil
+}
+
+// writeReviewDraft writes an accepted draft into the project
+func (app *CLIApplication) writeReviewDraft(draft *models.ReviewDraft) error {
+	path, err := app.reviewPath(draft.Path)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create the directory of %s: %w", draft.Path, err)
+	}
+	content := draft.Content
+	if !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", draft.Path, err)
+	}
+	return nil
+}
+
+// reviewPath resolves a planned path inside the project root, refusing the
+// repository's .git directory and paths the write policy denies
+func (app *CLIApplication) reviewPath(path string) (string, error) {
+	if path == "" || filepath.IsAbs(path) {
+		return "", fmt.Errorf("planned path %q must be relative to the project root", path)
+	}
+	root, err := filepath.Abs(app.config.ProjectRoot)
+	if err != nil {
+		return "", err
+	}
+	resolved := filepath.Join(root, path)
+	if rel, err := filepath.Rel(root, resolved); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("planned path %s is outside the project", path)
+	}
+	for _, part := range strings.Split(filepath.ToSlash(filepath.Clean(path)), "/") {
+		if strings.EqualFold(part, ".git") {
+			return "", fmt.Errorf("planned path %s is inside .git", path)
+		}
+	}
+	if err := mcp.CurrentPathPolicy().Check("write", resolved); err != nil {
+		return "", err
+	}
+	return resolved, nil
+}
+
+// addReviewUsage adds a generation's tokens and cost to the review
+func addReviewUsage(review *models.GenerationReview, generated *llm.GenerationResponse) {
+	if generated == nil {
+		return
+	}
+	review.Tokens += generated.TokenUsage.TotalTokens
+	review.Cost += generated.Cost.TotalCost
+}
+
+// fencedContent returns the body of the first fenced code block of a
+// reply, or the whole reply without one
+func fencedContent(reply string) string {
+	start := strings.Index(reply, "```")
+	if start < 0 {
+		return strings.TrimSpace(reply)
+	}
+	body := reply[start+3:]
+	if newline := strings.Index(body, "\n"); newline >= 0 {
+		body = body[newline+1:] // the language tag
+	}
+	if end := strings.LastIndex(body, "```"); end >= 0 {
+		body = body[:end]
+	}
+	return strings.TrimRight(body, "\n")
+}
+
+// truncateReviewText keeps the start of long file content
+func truncateReviewText(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	return text[:limit] + "\n// ... truncated"
+}
+
+// listOrNone joins paths for the task result
+func listOrNone(paths []string) string {
+	if len(paths) == 0 {
+		return "none"
+	}
+	return strings.Join(paths, ", ")
+}
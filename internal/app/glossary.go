@@ -0,0 +1,97 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// Glossary returns the project's glossary terms, alphabetically
+func (app *CLIApplication) Glossary() ([]*storage.GlossaryEntry, error) {
+	if app.storage == nil {
+		return nil, fmt.Errorf("the glossary needs storage")
+	}
+	return app.storage.ListGlossary()
+}
+
+// GlossaryEntry returns one glossary term, or nil if there is none
+func (app *CLIApplication) GlossaryEntry(term string) (*storage.GlossaryEntry, error) {
+	if app.storage == nil {
+		return nil, fmt.Errorf("the glossary needs storage")
+	}
+	return app.storage.GetGlossaryEntry(strings.TrimSpace(term))
+}
+
+// SetGlossaryEntry adds a term or replaces its definition. Edited terms are
+// manual: reindexing no longer changes them.
+func (app *CLIApplication) SetGlossaryEntry(term, definition string) (*storage.GlossaryEntry, error) {
+	term, definition = strings.TrimSpace(term), strings.TrimSpace(definition)
+	if term == "" || definition == "" {
+		return nil, fmt.Errorf("a glossary term needs a name and a definition")
+	}
+	entry, err := app.GlossaryEntry(term)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		entry = &storage.GlossaryEntry{Term: term}
+	}
+	entry.Definition = definition
+	entry.Source = storage.GlossarySourceManual
+	if err := app.storage.SaveGlossaryEntry(entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// AvoidGlossaryNames records other names for a term that the project does
+// not use, so queries using them get the term and answers use it instead
+func (app *CLIApplication) AvoidGlossaryNames(term string, names []string) (*storage.GlossaryEntry, error) {
+	entry, err := app.GlossaryEntry(term)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, fmt.Errorf("no glossary term %s; add it with 'glossary set %s: <definition>'", term, term)
+	}
+
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || strings.EqualFold(name, entry.Term) || containsFold(entry.Aliases, name) {
+			continue
+		}
+		entry.Aliases = append(entry.Aliases, name)
+	}
+	entry.Source = storage.GlossarySourceManual
+	if err := app.storage.SaveGlossaryEntry(entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// RemoveGlossaryEntry removes a term. Terms extracted while indexing stay
+// hidden instead of coming back on the next reindex.
+func (app *CLIApplication) RemoveGlossaryEntry(term string) error {
+	if app.storage == nil {
+		return fmt.Errorf("the glossary needs storage")
+	}
+	removed, err := app.storage.RemoveGlossaryEntry(strings.TrimSpace(term))
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return fmt.Errorf("no glossary term %s", term)
+	}
+	return nil
+}
+
+// containsFold reports whether values holds value, ignoring case
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
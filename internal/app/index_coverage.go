@@ -0,0 +1,162 @@
+package app
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// IndexCoverageConfig controls the coverage statement attached to answers
+// built while part of the project is not indexed yet
+type IndexCoverageConfig struct {
+	Enabled   bool          `json:"enabled"`
+	WarnBelow float64       `json:"warn_below"` // warn when less than this share of the scope is indexed
+	CacheTTL  time.Duration `json:"cache_ttl"`  // how long a discovered file list is reused
+}
+
+// DefaultIndexCoverageConfig returns index coverage defaults
+func DefaultIndexCoverageConfig() IndexCoverageConfig {
+	return IndexCoverageConfig{Enabled: true, WarnBelow: 0.9, CacheTTL: 30 * time.Second}
+}
+
+// annotateIndexCoverage states how many of the files in the query's scope
+// are indexed, so an answer given midway through indexing says it may be
+// missing code. The scope is the directories and files the query targets,
+// or the whole project.
+func (app *CLIApplication) annotateIndexCoverage(query *models.Query, response *models.Response) {
+	config := app.config.IndexCoverage
+	if !config.Enabled || app.indexer == nil || app.storage == nil || query == nil || response == nil {
+		return
+	}
+	if response.Type == models.ResponseTypeError || response.Type == models.ResponseTypeClarification {
+		return
+	}
+
+	discoverable, err := app.discoverableFiles()
+	if err != nil {
+		app.logWarning("INDEX_COVERAGE", "Failed to discover project files: "+err.Error())
+		return
+	}
+	stored, err := app.storage.IndexedPaths()
+	if err != nil {
+		app.logWarning("INDEX_COVERAGE", "Coverage check failed: "+err.Error())
+		return
+	}
+	indexed := make(map[string]bool, len(stored))
+	for file := range stored {
+		indexed[app.projectRelative(file)] = true
+	}
+
+	scope := app.coverageScope(query)
+	coverage := measureCoverage(discoverable, indexed, scope)
+	if coverage.Discoverable == 0 && len(scope) > 0 {
+		// The targets name no indexable file, e.g. a symbol; fall back to
+		// the whole project
+		coverage = measureCoverage(discoverable, indexed, nil)
+	}
+	if coverage.Discoverable == 0 {
+		return
+	}
+	coverage.Partial = coverage.Ratio < config.WarnBelow
+	coverage.Statement = coverageStatement(coverage)
+	response.Metadata.IndexCoverage = coverage
+	if coverage.Partial {
+		app.logWarning("INDEX_COVERAGE", coverage.Statement)
+	}
+}
+
+// discoverableFiles lists the files the indexer would index, relative to
+// the project root, reusing the last walk for index_coverage.cache_ttl
+func (app *CLIApplication) discoverableFiles() ([]string, error) {
+	app.discoverableMu.Lock()
+	defer app.discoverableMu.Unlock()
+	if app.discoverable != nil && time.Since(app.discoveredAt) < app.config.IndexCoverage.CacheTTL {
+		return app.discoverable, nil
+	}
+
+	files, err := app.indexer.DiscoverFiles()
+	if err != nil {
+		return nil, err
+	}
+	discoverable := make([]string, 0, len(files))
+	for _, file := range files {
+		discoverable = append(discoverable, app.projectRelative(file))
+	}
+	app.discoverable = discoverable
+	app.discoveredAt = time.Now()
+	return discoverable, nil
+}
+
+// coverageScope is the query's file targets, relative to the project root
+func (app *CLIApplication) coverageScope(query *models.Query) []string {
+	var scope []string
+	for _, target := range query.Intent.FileTargets {
+		target = strings.TrimSpace(target)
+		if target == "" {
+			continue
+		}
+		if filepath.IsAbs(target) {
+			target = app.projectRelative(target)
+		}
+		target = strings.TrimSuffix(path.Clean(filepath.ToSlash(target)), "/")
+		if target == "." {
+			return nil
+		}
+		scope = append(scope, target)
+	}
+	return scope
+}
+
+// measureCoverage counts the discoverable files in scope and how many of
+// them are indexed. A file is in scope when it is a target, lies under a
+// target directory or has a bare target's name.
+func measureCoverage(discoverable []string, indexed map[string]bool, scope []string) *models.IndexCoverage {
+	coverage := &models.IndexCoverage{Scope: scope}
+	for _, file := range discoverable {
+		if len(scope) > 0 && !inCoverageScope(file, scope) {
+			continue
+		}
+		coverage.Discoverable++
+		if indexed[file] {
+			coverage.Indexed++
+		}
+	}
+	if coverage.Discoverable > 0 {
+		coverage.Ratio = float64(coverage.Indexed) / float64(coverage.Discoverable)
+	}
+	return coverage
+}
+
+func inCoverageScope(file string, scope []string) bool {
+	for _, target := range scope {
+		if file == target || strings.HasPrefix(file, target+"/") {
+			return true
+		}
+		if !strings.Contains(target, "/") && path.Base(file) == target {
+			return true
+		}
+	}
+	return false
+}
+
+// coverageStatement describes coverage in one sentence. The percentage is
+// rounded down, so a scope missing files never reads as 100%.
+func coverageStatement(coverage *models.IndexCoverage) string {
+	where := "the project"
+	if len(coverage.Scope) > 0 {
+		where = strings.Join(coverage.Scope, ", ")
+	}
+	if coverage.Indexed == coverage.Discoverable {
+		return fmt.Sprintf("All %d files in %s are indexed", coverage.Discoverable, where)
+	}
+	statement := fmt.Sprintf("Based on %d of %d files in %s (%d%%) indexed so far",
+		coverage.Indexed, coverage.Discoverable, where, int(coverage.Ratio*100))
+	if coverage.Partial {
+		statement += "; the answer may miss code in files not indexed yet"
+	}
+	return statement
+}
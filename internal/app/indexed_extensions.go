@@ -0,0 +1,48 @@
+package app
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// defaultIndexedExtensions are indexed when properties.yaml lists none
+var defaultIndexedExtensions = []string{".go", ".mod", ".sum"}
+
+// indexedExtensions reads indexing.file_extensions, the extensions of each
+// language, keeping the languages in indexing.supported_languages when it
+// names any
+func indexedExtensions() []string {
+	byLanguage := viper.GetStringMapStringSlice("indexing.file_extensions")
+	supported := make(map[string]bool)
+	for _, language := range viper.GetStringSlice("indexing.supported_languages") {
+		supported[strings.ToLower(language)] = true
+	}
+
+	seen := make(map[string]bool)
+	var extensions []string
+	for language, list := range byLanguage {
+		if len(supported) > 0 && !supported[strings.ToLower(language)] {
+			continue
+		}
+		for _, ext := range list {
+			ext = strings.ToLower(strings.TrimSpace(ext))
+			if ext == "" {
+				continue
+			}
+			if !strings.HasPrefix(ext, ".") {
+				ext = "." + ext
+			}
+			if !seen[ext] {
+				seen[ext] = true
+				extensions = append(extensions, ext)
+			}
+		}
+	}
+	if len(extensions) == 0 {
+		return append([]string{}, defaultIndexedExtensions...)
+	}
+	sort.Strings(extensions)
+	return extensions
+}
@@ -0,0 +1,72 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/yourusername/useq-ai-assistant/display"
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// Vector backends, set with vectordb.backend
+const (
+	VectorBackendAuto    = "auto"    // Qdrant, or the keyword index when Qdrant is unreachable
+	VectorBackendQdrant  = "qdrant"  // Qdrant only; startup fails without it
+	VectorBackendKeyword = "keyword" // the local keyword index only
+)
+
+// keywordOnlyNotice labels answers searched without a vector database
+const keywordOnlyNotice = "Keyword-only mode: no vector database configured; results match words, not meaning"
+
+// useKeywordIndex runs without a vector database. Chunks are indexed by
+// keyword at index time and Tier 2 searches rank them with BM25.
+func (app *CLIApplication) useKeywordIndex(reason string) {
+	app.keywordOnly = true
+	app.logWarning("VECTORDB_INIT", fmt.Sprintf("Using the local keyword index: %s", reason))
+	fmt.Printf("  🔤 %s\n", keywordOnlyNotice)
+}
+
+// KeywordOnly reports whether searches run on the keyword index because no
+// vector database is configured
+func (app *CLIApplication) KeywordOnly() bool {
+	return app.keywordOnly
+}
+
+// ensureKeywordIndex builds the keyword index of a project indexed while a
+// vector database was still configured, whose chunks were never indexed by
+// keyword
+func (app *CLIApplication) ensureKeywordIndex(indexedFiles int) error {
+	if !app.keywordOnly || indexedFiles == 0 {
+		return nil
+	}
+	size, err := app.storage.KeywordIndexSize()
+	if err != nil {
+		return fmt.Errorf("failed to read the keyword index: %w", err)
+	}
+	if size > 0 {
+		return nil
+	}
+
+	fmt.Printf("  🔄 Building the keyword index of %d indexed files...\n", indexedFiles)
+	err = app.indexer.StartFullReindexingWithProgress(context.Background(), func(progress display.IndexingProgress) {
+		if progress.ProcessedFiles%10 == 0 || progress.ProcessedFiles == progress.TotalFiles {
+			fmt.Printf("  📈 Indexing: %d/%d files\n", progress.ProcessedFiles, progress.TotalFiles)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("building the keyword index failed: %w", err)
+	}
+	fmt.Printf("  ✅ Keyword index built\n")
+	return nil
+}
+
+// annotateKeywordOnly labels an answer whose search ran on the keyword index
+func (app *CLIApplication) annotateKeywordOnly(response *models.Response) {
+	if !app.keywordOnly || response == nil {
+		return
+	}
+	if response.Type == models.ResponseTypeError || response.Type == models.ResponseTypeClarification {
+		return
+	}
+	response.Metadata.KeywordOnly = true
+}
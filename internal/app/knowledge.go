@@ -0,0 +1,169 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/internal/prewarm"
+	"github.com/yourusername/useq-ai-assistant/internal/tasks"
+	"github.com/yourusername/useq-ai-assistant/models"
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// TaskKindResummarize rebuilds every stale package summary, resumable per
+// package
+const TaskKindResummarize = "resummarize"
+
+// architectureFreshnessLimit caps the packages whose summary freshness an
+// architecture explanation reports when it cites none
+const architectureFreshnessLimit = 5
+
+// architectureTerms mark an explanation as being about project structure
+var architectureTerms = []string{"workflow", "project", "structure", "design", "package", "module", "overview"}
+
+// resummarizeTaskCheckpoint is where a resummarize task resumes
+type resummarizeTaskCheckpoint struct {
+	Packages []resummarizePackage `json:"packages"`
+	Next     int                  `json:"next"`
+}
+
+// resummarizePackage is one stale package queued for re-summarizing
+type resummarizePackage struct {
+	Dir   string   `json:"dir"`
+	Files []string `json:"files"`
+}
+
+// PackageFreshness reports how current each package summary is, most in
+// need of re-summarizing first
+func (app *CLIApplication) PackageFreshness() ([]prewarm.PackageFreshness, error) {
+	return app.prewarmer.PackageFreshness()
+}
+
+// PackageSummary returns the stored summary of a package directory and its
+// freshness, or nil if it has never been summarized
+func (app *CLIApplication) PackageSummary(dir string) (*storage.PackageSummaryRecord, *prewarm.PackageFreshness, error) {
+	return app.prewarmer.PackageSummary(dir)
+}
+
+// StartResummarizeTask rebuilds every stale package summary in the
+// background
+func (app *CLIApplication) StartResummarizeTask(ctx context.Context) (*storage.TaskRecord, error) {
+	if app.tasks == nil {
+		return nil, fmt.Errorf("background tasks need storage")
+	}
+	return app.tasks.Start(ctx, TaskKindResummarize, "re-summarize stale packages", struct{}{})
+}
+
+// runResummarizeTask re-summarizes the packages that were stale when the
+// task started, checkpointing after each one
+func (app *CLIApplication) runResummarizeTask(ctx context.Context, run *tasks.Run) (string, error) {
+	var checkpoint resummarizeTaskCheckpoint
+	resumed, err := run.LoadCheckpoint(&checkpoint)
+	if err != nil {
+		return "", err
+	}
+	if !resumed {
+		run.Progress(0, 0, "measuring package changes")
+		freshness, err := app.prewarmer.PackageFreshness()
+		if err != nil {
+			return "", err
+		}
+		for _, pkg := range freshness {
+			if pkg.Stale {
+				checkpoint.Packages = append(checkpoint.Packages, resummarizePackage{Dir: pkg.Dir, Files: pkg.Files})
+			}
+		}
+		if err := run.Checkpoint(checkpoint, 0, len(checkpoint.Packages)); err != nil {
+			return "", err
+		}
+	}
+
+	total := len(checkpoint.Packages)
+	failed := 0
+	for checkpoint.Next < total {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		pkg := checkpoint.Packages[checkpoint.Next]
+		run.Progress(checkpoint.Next, total, pkg.Dir)
+		if err := app.prewarmer.ResummarizePackage(pkg.Dir, pkg.Files); err != nil {
+			failed++
+		}
+		checkpoint.Next++
+		if err := run.Checkpoint(checkpoint, checkpoint.Next, total); err != nil {
+			return "", err
+		}
+	}
+	if total == 0 {
+		return "All package summaries are current", nil
+	}
+	return fmt.Sprintf("Re-summarized %d packages (%d failed)", total-failed, failed), nil
+}
+
+// annotateSummaryFreshness tells architecture explanations how current the
+// package summaries behind them are: the packages the answer cites, or the
+// stalest packages when it cites none
+func (app *CLIApplication) annotateSummaryFreshness(query *models.Query, response *models.Response) {
+	if app.prewarmer == nil || response == nil || !isArchitectureQuery(query) {
+		return
+	}
+	freshness, err := app.prewarmer.PackageFreshness()
+	if err != nil {
+		app.logWarning("KNOWLEDGE", "Summary freshness check failed: "+err.Error())
+		return
+	}
+
+	cited := make(map[string]bool)
+	for _, source := range response.Metadata.Sources {
+		cited[filepath.Dir(lineRangeSuffix.ReplaceAllString(source, ""))] = true
+	}
+	for _, reference := range response.Content.References {
+		cited[filepath.Dir(reference.File)] = true
+	}
+
+	var selected []prewarm.PackageFreshness
+	for _, pkg := range freshness {
+		if cited[pkg.Dir] {
+			selected = append(selected, pkg)
+		}
+	}
+	if len(selected) == 0 {
+		selected = freshness
+		if len(selected) > architectureFreshnessLimit {
+			selected = selected[:architectureFreshnessLimit]
+		}
+	}
+	sort.SliceStable(selected, func(i, j int) bool { return selected[i].Stale && !selected[j].Stale })
+
+	for _, pkg := range selected {
+		response.Metadata.SummaryFreshness = append(response.Metadata.SummaryFreshness, models.SummaryFreshness{
+			Dir:          pkg.Dir,
+			SummarizedAt: pkg.SummarizedAt,
+			ChangedFiles: pkg.ChangedFiles,
+			TotalFiles:   pkg.TotalFiles,
+			Stale:        pkg.Stale,
+			Note:         pkg.Describe(),
+		})
+	}
+}
+
+// isArchitectureQuery reports whether a query asks to explain the project's
+// structure rather than a piece of code
+func isArchitectureQuery(query *models.Query) bool {
+	input := strings.ToLower(query.UserInput)
+	if strings.Contains(input, "architecture") {
+		return true
+	}
+	if query.Intent.Primary != models.QueryTypeExplanation {
+		return false
+	}
+	for _, term := range architectureTerms {
+		if strings.Contains(input, term) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package app
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/models"
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// defaultLineContext is how many lines "lines" shows around a range
+const defaultLineContext = 3
+
+// LineRangeRequest is a parsed "lines" command
+type LineRangeRequest struct {
+	Path    string
+	Start   int
+	End     int
+	Options storage.LineRangeOptions
+}
+
+// ParseLineRange parses "<file>:<start>[-<end>] [--context n] [--expand]"
+func ParseLineRange(args []string) (LineRangeRequest, error) {
+	request := LineRangeRequest{Options: storage.LineRangeOptions{Context: defaultLineContext}}
+	if len(args) == 0 {
+		return request, fmt.Errorf("no file given")
+	}
+	path, lines, found := strings.Cut(args[0], ":")
+	if !found || path == "" {
+		return request, fmt.Errorf("%q is not <file>:<start>[-<end>]", args[0])
+	}
+	startText, endText, ranged := strings.Cut(lines, "-")
+	start, err := strconv.Atoi(startText)
+	if err != nil || start < 1 {
+		return request, fmt.Errorf("invalid start line %q", startText)
+	}
+	end := start
+	if ranged {
+		if end, err = strconv.Atoi(endText); err != nil || end < start {
+			return request, fmt.Errorf("invalid end line %q", endText)
+		}
+	}
+	request.Path, request.Start, request.End = path, start, end
+
+	for i := 1; i < len(args); i++ {
+		switch flag := args[i]; flag {
+		case "--expand", "-e":
+			request.Options.Expand = true
+		case "--context", "-c":
+			if i+1 >= len(args) {
+				return request, fmt.Errorf("%s needs a value", flag)
+			}
+			context, err := strconv.Atoi(args[i+1])
+			if err != nil || context < 0 {
+				return request, fmt.Errorf("invalid context %q", args[i+1])
+			}
+			request.Options.Context = context
+			i++
+		default:
+			return request, fmt.Errorf("unknown flag %s", flag)
+		}
+	}
+	return request, nil
+}
+
+// LineRange reads a range of an indexed file for drilling into an answer
+func (app *CLIApplication) LineRange(request LineRangeRequest) (*storage.LineRange, error) {
+	if app.storage == nil {
+		return nil, fmt.Errorf("reading lines needs storage")
+	}
+	lines, err := app.storage.GetLineRange(app.projectRelative(request.Path), request.Start, request.End, request.Options)
+	if err != nil {
+		return nil, err
+	}
+	if lines == nil {
+		return nil, fmt.Errorf("%s is not indexed", request.Path)
+	}
+	return lines, nil
+}
+
+// AnchorChanges names the declaration each edit falls in, for diff hunk
+// headers, when the edit was not computed against a symbol
+func (app *CLIApplication) AnchorChanges(changes []models.CodeChange) {
+	if app.storage == nil {
+		return
+	}
+	for i := range changes {
+		change := &changes[i]
+		if change.Anchor != "" || change.StartLine < 1 {
+			continue
+		}
+		lines, err := app.storage.GetLineRange(app.projectRelative(change.File), change.StartLine, change.EndLine, storage.LineRangeOptions{})
+		if err != nil || lines == nil {
+			continue
+		}
+		// An edit made against another version of the file has other line
+		// numbers; base hashes are a prefix of the indexed SHA-256
+		if change.BaseHash != "" && !strings.HasPrefix(lines.Hash, change.BaseHash) {
+			continue
+		}
+		change.Anchor = lines.Enclosing
+	}
+}
@@ -0,0 +1,111 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/internal/agents"
+	"github.com/yourusername/useq-ai-assistant/models"
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// memoryEmbedBatch is how many remembered conversations are read at a time
+// when embedding those not yet in the memory collection
+const memoryEmbedBatch = 50
+
+// initializeConversationMemory copies answers stored before conversations
+// were remembered into conversation memory and connects the memory
+// collection, embedding whatever is not in it yet in the background.
+// Without the collection, recall queries search by keyword.
+func (app *CLIApplication) initializeConversationMemory() {
+	if !app.config.Memory.Enabled || app.storage == nil {
+		return
+	}
+	if added, err := app.storage.BackfillConversations(); err != nil {
+		app.logWarning("MEMORY_INIT", fmt.Sprintf("Earlier answers not added to conversation memory: %v", err))
+	} else if added > 0 {
+		app.logInfo("MEMORY_INIT", fmt.Sprintf("Added %d earlier answers to conversation memory", added))
+	}
+	if app.vectorDB == nil {
+		return
+	}
+	memoryDB, err := app.vectorDB.WithCollection(app.config.Memory.Collection)
+	if err != nil {
+		app.logWarning("MEMORY_INIT", fmt.Sprintf("Conversation memory search unavailable: %v", err))
+		return
+	}
+	app.memoryVectorDB = memoryDB
+	app.logInfo("MEMORY_INIT", fmt.Sprintf("Conversation memory ready (collection %s)", app.config.Memory.Collection))
+	go app.embedPendingConversations(context.Background())
+}
+
+// rememberConversation records an answered question so later queries can
+// recall it. Recall answers, errors and empty answers are not remembered.
+func (app *CLIApplication) rememberConversation(query *models.Query, response *models.Response) {
+	if !app.config.Memory.Enabled || app.storage == nil || response == nil {
+		return
+	}
+	if response.AgentUsed == agents.MemoryAgentName || response.Type == models.ResponseTypeError ||
+		strings.TrimSpace(response.Content.Text) == "" {
+		return
+	}
+	record := &storage.ConversationRecord{
+		QueryID:   query.ID,
+		SessionID: query.SessionID,
+		Question:  query.UserInput,
+		Answer:    response.Content.Text,
+		Sources:   response.Metadata.Sources,
+		AgentUsed: response.AgentUsed,
+		CreatedAt: response.Timestamp,
+	}
+	if err := app.storage.StoreConversation(record); err != nil {
+		app.logWarning("MEMORY", fmt.Sprintf("Answer not remembered: %v", err))
+		return
+	}
+	if app.memoryVectorDB == nil {
+		return
+	}
+	go func() {
+		if err := app.embedConversation(context.Background(), record); err != nil {
+			app.logWarning("MEMORY", fmt.Sprintf("Answer remembered but not embedded: %v", err))
+		}
+	}()
+}
+
+// embedConversation stores a remembered conversation in the memory
+// collection
+func (app *CLIApplication) embedConversation(ctx context.Context, record *storage.ConversationRecord) error {
+	chunk := agents.ConversationChunk(record)
+	embedding, err := app.memoryVectorDB.GenerateOpenAIEmbedding(ctx, chunk.Content)
+	if err != nil {
+		return fmt.Errorf("failed to embed conversation %s: %w", record.QueryID, err)
+	}
+	if err := app.memoryVectorDB.StoreChunkWithEmbedding(ctx, chunk, embedding); err != nil {
+		return fmt.Errorf("failed to store conversation %s: %w", record.QueryID, err)
+	}
+	return app.storage.MarkConversationEmbedded(record.QueryID)
+}
+
+// embedPendingConversations embeds the remembered conversations not yet in
+// the memory collection. It stops at the first failure; the rest are
+// embedded on the next start.
+func (app *CLIApplication) embedPendingConversations(ctx context.Context) {
+	embedded := 0
+	for {
+		records, err := app.storage.UnembeddedConversations(memoryEmbedBatch)
+		if err != nil || len(records) == 0 {
+			break
+		}
+		for _, record := range records {
+			if err := app.embedConversation(ctx, record); err != nil {
+				app.logWarning("MEMORY", fmt.Sprintf("Embedding earlier answers stopped after %d: %v", embedded, err))
+				return
+			}
+			embedded++
+		}
+	}
+	if embedded > 0 {
+		app.logInfo("MEMORY", fmt.Sprintf("Embedded %d earlier answers into conversation memory", embedded))
+	}
+}
@@ -0,0 +1,21 @@
+package app
+
+import (
+	"github.com/yourusername/useq-ai-assistant/internal/llm"
+	"github.com/yourusername/useq-ai-assistant/internal/metrics"
+)
+
+// Metrics returns the counters and windows the agents have recorded since
+// the process started
+func (app *CLIApplication) Metrics() []metrics.Sample {
+	return metrics.Default.Snapshot()
+}
+
+// RouteStats returns cost and latency per model route since the process
+// started
+func (app *CLIApplication) RouteStats() map[string]llm.RouteStats {
+	if app.llmManager == nil {
+		return nil
+	}
+	return app.llmManager.GetRouteStats()
+}
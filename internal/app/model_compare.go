@@ -0,0 +1,219 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/llm"
+	"github.com/yourusername/useq-ai-assistant/models"
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// ModelComparisonConfig names the two models 'compare' runs by default and
+// whether the preferences recorded after comparisons pick the primary model
+type ModelComparisonConfig struct {
+	Models           []string `json:"models"`            // provider or provider:model, e.g. openai:gpt-4o
+	ApplyPreferences bool     `json:"apply_preferences"` // answer with the preferred model at startup
+	MinRatings       int      `json:"min_ratings"`       // rated comparisons a model needs before it is preferred
+}
+
+// DefaultModelComparisonConfig returns comparison defaults: the two
+// providers with their configured models, and preferences only reported
+func DefaultModelComparisonConfig() ModelComparisonConfig {
+	return ModelComparisonConfig{Models: []string{"openai", "gemini"}, MinRatings: 5}
+}
+
+// ModelAnswer is one model's answer in a comparison
+type ModelAnswer struct {
+	Model    llm.ModelChoice  `json:"model"`
+	Response *models.Response `json:"response,omitempty"`
+	Usage    llm.ModelUsage   `json:"usage"`
+	Latency  time.Duration    `json:"latency"` // wall time for the whole answer
+	Error    string           `json:"error,omitempty"`
+}
+
+// ModelComparison is the same query answered by two models
+type ModelComparison struct {
+	ID            int64       `json:"id"` // for recording the preferred answer; 0 if not stored
+	Query         string      `json:"query"`
+	A             ModelAnswer `json:"a"`
+	B             ModelAnswer `json:"b"`
+	SharedSources []string    `json:"shared_sources"`
+	OnlyA         []string    `json:"only_a"` // sources only A cited
+	OnlyB         []string    `json:"only_b"` // sources only B cited
+}
+
+// ComparisonModels resolves the models to compare: the given specs, or the
+// configured pair when none are given
+func (app *CLIApplication) ComparisonModels(specs []string) ([2]llm.ModelChoice, error) {
+	var choices [2]llm.ModelChoice
+	if len(specs) == 0 {
+		specs = app.config.Comparison.Models
+	}
+	if len(specs) != 2 {
+		return choices, fmt.Errorf("comparing needs exactly two models, got %d", len(specs))
+	}
+	for i, spec := range specs {
+		choice, err := llm.ParseModelChoice(spec)
+		if err != nil {
+			return choices, err
+		}
+		if app.llmManager == nil || !app.llmManager.HasProvider(choice.Provider) {
+			return choices, fmt.Errorf("provider %s is not configured", choice.Provider)
+		}
+		choices[i] = choice
+	}
+	return choices, nil
+}
+
+// CompareModels answers query through the Tier 3 pipeline with each model
+// in turn and diffs the sources they cited. The comparison is stored so the
+// user's preference can be recorded with RecordModelPreference.
+func (app *CLIApplication) CompareModels(ctx context.Context, query *models.Query, choices [2]llm.ModelChoice) (*ModelComparison, error) {
+	if app.managerAgent == nil {
+		return nil, fmt.Errorf("comparing models requires the manager agent")
+	}
+	app.prewarmer.Touch()
+
+	app.applyIncludeDeps(query)
+	app.applyPins(query)
+	app.translateQuery(ctx, query)
+
+	comparison := &ModelComparison{Query: query.UserInput}
+	answers := [2]*ModelAnswer{&comparison.A, &comparison.B}
+	for i, choice := range choices {
+		// Each model gets its own copy; agents annotate the query as they go
+		attempt := *query
+		attempt.ID = fmt.Sprintf("%s_%s", query.ID, []string{"a", "b"}[i])
+		attempt.Metadata = make(map[string]string, len(query.Metadata))
+		for k, v := range query.Metadata {
+			attempt.Metadata[k] = v
+		}
+
+		start := time.Now()
+		response, usage, err := app.managerAgent.AnswerWithModel(ctx, &attempt, choice)
+		*answers[i] = ModelAnswer{Model: choice, Response: response, Usage: usage, Latency: time.Since(start)}
+		if err != nil {
+			answers[i].Error = err.Error()
+			app.logWarning("MODEL_COMPARE", fmt.Sprintf("%s failed: %v", choice, err))
+		}
+	}
+	if comparison.A.Error != "" && comparison.B.Error != "" {
+		return comparison, fmt.Errorf("both models failed: %s; %s", comparison.A.Error, comparison.B.Error)
+	}
+
+	comparison.SharedSources, comparison.OnlyA, comparison.OnlyB = diffSources(
+		answerSources(comparison.A.Response), answerSources(comparison.B.Response))
+
+	if app.storage != nil {
+		record := &storage.ModelComparisonRecord{
+			Query:         comparison.Query,
+			ModelA:        comparison.A.Model.String(),
+			ModelB:        comparison.B.Model.String(),
+			LatencyA:      comparison.A.Latency,
+			LatencyB:      comparison.B.Latency,
+			CostA:         comparison.A.Usage.Cost,
+			CostB:         comparison.B.Usage.Cost,
+			SharedSources: len(comparison.SharedSources),
+		}
+		if err := app.storage.SaveModelComparison(record); err != nil {
+			app.logWarning("MODEL_COMPARE", fmt.Sprintf("Failed to store comparison: %v", err))
+		} else {
+			comparison.ID = record.ID
+		}
+	}
+	return comparison, nil
+}
+
+// RecordModelPreference records which answer of a comparison was better:
+// a, b or tie
+func (app *CLIApplication) RecordModelPreference(id int64, preferred string) error {
+	if app.storage == nil {
+		return fmt.Errorf("recording preferences needs storage")
+	}
+	return app.storage.SetModelPreference(id, preferred)
+}
+
+// ModelPreferences returns how each compared model fared in the answers the
+// user rated
+func (app *CLIApplication) ModelPreferences() ([]*storage.ModelPreferenceStats, error) {
+	if app.storage == nil {
+		return nil, fmt.Errorf("model preferences need storage")
+	}
+	return app.storage.ModelPreferenceStats()
+}
+
+// applyModelPreferences makes the model that won most of the user's rated
+// comparisons the primary one, when configured. A model needs MinRatings
+// rated comparisons and to have won more than it lost.
+func (app *CLIApplication) applyModelPreferences() {
+	config := app.config.Comparison
+	if !config.ApplyPreferences || app.storage == nil || app.llmManager == nil {
+		return
+	}
+	stats, err := app.storage.ModelPreferenceStats()
+	if err != nil {
+		app.logWarning("MODEL_COMPARE", fmt.Sprintf("Failed to read model preferences: %v", err))
+		return
+	}
+	for _, s := range stats {
+		if s.Comparisons < config.MinRatings {
+			continue
+		}
+		if s.WinRate() <= 0.5 {
+			return
+		}
+		choice, err := llm.ParseModelChoice(s.Model)
+		if err != nil {
+			app.logWarning("MODEL_COMPARE", err.Error())
+			return
+		}
+		if err := app.llmManager.UseModel(choice); err != nil {
+			app.logWarning("MODEL_COMPARE", fmt.Sprintf("Cannot answer with preferred model %s: %v", choice, err))
+			return
+		}
+		app.logInfo("MODEL_COMPARE", fmt.Sprintf("Answering with %s, preferred in %.0f%% of %d rated comparisons",
+			choice, s.WinRate()*100, s.Comparisons))
+		return
+	}
+}
+
+// answerSources returns the sources a response cited
+func answerSources(response *models.Response) []string {
+	if response == nil {
+		return nil
+	}
+	return response.Metadata.Sources
+}
+
+// diffSources splits two source lists into those both cite and those only
+// one of them does, each sorted
+func diffSources(a, b []string) (shared, onlyA, onlyB []string) {
+	inB := make(map[string]bool, len(b))
+	for _, source := range b {
+		inB[source] = true
+	}
+	inA := make(map[string]bool, len(a))
+	for _, source := range a {
+		if inA[source] {
+			continue
+		}
+		inA[source] = true
+		if inB[source] {
+			shared = append(shared, source)
+		} else {
+			onlyA = append(onlyA, source)
+		}
+	}
+	for source := range inB {
+		if !inA[source] {
+			onlyB = append(onlyB, source)
+		}
+	}
+	sort.Strings(shared)
+	sort.Strings(onlyA)
+	sort.Strings(onlyB)
+	return shared, onlyA, onlyB
+}
@@ -0,0 +1,32 @@
+package app
+
+import (
+	"github.com/spf13/viper"
+	"github.com/yourusername/useq-ai-assistant/internal/llm"
+)
+
+// modelRoutingConfig reads ai_providers.routing, or returns nil to leave
+// routing off. Providers without models listed keep their own model.
+func modelRoutingConfig() *llm.ModelRouterConfig {
+	if !viper.IsSet("ai_providers.routing") {
+		return nil
+	}
+	config := llm.DefaultModelRouterConfig()
+	config.Enabled = viper.GetBool("ai_providers.routing.enabled")
+	if tokens := viper.GetInt("ai_providers.routing.short_prompt_tokens"); tokens > 0 {
+		config.ShortPromptTokens = tokens
+	}
+	if tokens := viper.GetInt("ai_providers.routing.long_context_tokens"); tokens > 0 {
+		config.LongContextTokens = tokens
+	}
+	config.Models = make(map[string]llm.RouteModels)
+	for provider := range viper.GetStringMap("ai_providers.routing.models") {
+		key := "ai_providers.routing.models." + provider
+		config.Models[provider] = llm.RouteModels{
+			Cheap:       viper.GetString(key + ".cheap"),
+			Strong:      viper.GetString(key + ".strong"),
+			LongContext: viper.GetString(key + ".long_context"),
+		}
+	}
+	return &config
+}
@@ -0,0 +1,48 @@
+package app
+
+import (
+	"os"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/yourusername/useq-ai-assistant/internal/llm"
+)
+
+// openAIProviderConfig reads the OpenAI provider settings, including which
+// kind of endpoint to talk to. Azure endpoints fall back to the
+// AZURE_OPENAI_API_KEY and AZURE_OPENAI_ENDPOINT variables, everything else
+// to OPENAI_API_KEY and OPENAI_BASE_URL.
+func openAIProviderConfig() llm.ProviderConfig {
+	config := llm.ProviderConfig{
+		Model:               "gpt-4-turbo-preview",
+		MaxTokens:           4000,
+		Temperature:         0.1,
+		Timeout:             30 * time.Second,
+		APIType:             viper.GetString("ai_providers.openai.api_type"),
+		BaseURL:             viper.GetString("ai_providers.openai.base_url"),
+		Deployment:          viper.GetString("ai_providers.openai.deployment"),
+		EmbeddingDeployment: viper.GetString("ai_providers.openai.embedding_deployment"),
+		EmbeddingModel:      viper.GetString("ai_providers.openai.embedding_model"),
+		APIVersion:          viper.GetString("ai_providers.openai.api_version"),
+		OrgID:               viper.GetString("ai_providers.openai.org_id"),
+	}
+	if model := viper.GetString("ai_providers.openai.model"); model != "" {
+		config.Model = model
+	}
+
+	if llm.IsAzureAPIType(config.APIType) {
+		config.APIKey = os.Getenv("AZURE_OPENAI_API_KEY")
+		if config.BaseURL == "" {
+			config.BaseURL = os.Getenv("AZURE_OPENAI_ENDPOINT")
+		}
+		if config.APIVersion == "" {
+			config.APIVersion = os.Getenv("AZURE_OPENAI_API_VERSION")
+		}
+	} else if config.BaseURL == "" {
+		config.BaseURL = os.Getenv("OPENAI_BASE_URL")
+	}
+	if config.APIKey == "" {
+		config.APIKey = os.Getenv("OPENAI_API_KEY")
+	}
+	return config
+}
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/internal/language"
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// DefaultSnippetQuestion is asked about pasted code that came without a
+// question
+const DefaultSnippetQuestion = "Explain what this code does"
+
+// fencedBlockPattern matches a fenced code block with an optional language
+// tag. An unclosed fence runs to the end of the input.
+var fencedBlockPattern = regexp.MustCompile("(?s)```([\\w+#.-]*)[ \\t]*\\n(.*?)(?:\\n?[ \\t]*```|$)")
+
+// ParsePastedSnippet finds a fenced code block in input and returns it with
+// the rest of the input as the question about it. It returns nil when input
+// holds no code block.
+func ParsePastedSnippet(input string) (*models.PastedSnippet, string) {
+	match := fencedBlockPattern.FindStringSubmatchIndex(input)
+	if match == nil {
+		return nil, input
+	}
+	code := strings.Trim(input[match[4]:match[5]], "\n")
+	if strings.TrimSpace(code) == "" {
+		return nil, input
+	}
+	snippet := &models.PastedSnippet{
+		Language: snippetLanguage(input[match[2]:match[3]]),
+		Code:     code,
+	}
+	question := strings.TrimSpace(input[:match[0]] + " " + input[match[1]:])
+	return snippet, question
+}
+
+// snippetLanguage reads a fence's language tag, which may be a language
+// name or a file extension
+func snippetLanguage(tag string) string {
+	tag = strings.ToLower(tag)
+	if lang := language.FromExtension("." + tag); lang != "" {
+		return lang
+	}
+	return tag
+}
+
+// applyPastedSnippet moves a code block pasted into the query to the query
+// context, so logs, search and the session see only the question. The
+// snippet's language becomes the query's when the fence did not name one.
+func (app *CLIApplication) applyPastedSnippet(query *models.Query) {
+	if query.Context.Snippet == nil {
+		snippet, question := ParsePastedSnippet(query.UserInput)
+		if snippet == nil {
+			return
+		}
+		query.Context.Snippet = snippet
+		query.UserInput = question
+	}
+	snippet := query.Context.Snippet
+	if strings.TrimSpace(query.UserInput) == "" {
+		query.UserInput = DefaultSnippetQuestion
+	}
+	if snippet.Language == "" {
+		snippet.Language = language.Detect(snippet.Code, nil, query.Language).Language
+	}
+	snippet.Lines = strings.Count(snippet.Code, "\n") + 1
+	query.Language = snippet.Language
+	if query.Metadata == nil {
+		query.Metadata = make(map[string]string)
+	}
+	query.Metadata["language_source"] = "snippet"
+
+	app.logInfo("PASTED_SNIPPET", fmt.Sprintf("Query asks about %d pasted lines of %s (keep: %t)", snippet.Lines, snippet.Language, snippet.Keep))
+}
@@ -0,0 +1,14 @@
+package app
+
+import (
+	"github.com/yourusername/useq-ai-assistant/internal/mcp"
+)
+
+// SetPermissionConfirmer sets how the user approves tool uses that an agent's
+// permissions only allow after confirmation. Until one is set, as in server
+// mode, those uses are denied.
+func (app *CLIApplication) SetPermissionConfirmer(confirm mcp.Confirmer) {
+	if app.permissions != nil {
+		app.permissions.SetConfirmer(confirm)
+	}
+}
@@ -0,0 +1,106 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/agents"
+	"github.com/yourusername/useq-ai-assistant/internal/audit"
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// maxPinCandidates bounds the definitions listed for an ambiguous symbol
+const maxPinCandidates = 5
+
+// PinContext pins a project file (path, path:line or path:start-end) or an
+// indexed symbol to the session, so every Tier 3 prompt includes it. It
+// returns the new pin and all of the session's pins.
+func (app *CLIApplication) PinContext(sessionID, target string) (models.PinnedContext, []models.PinnedContext, error) {
+	if sessionID == "" {
+		sessionID = app.sessionID
+	}
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return models.PinnedContext{}, nil, fmt.Errorf("nothing to pin; give a file path or symbol name")
+	}
+
+	pin, err := app.resolvePin(target)
+	if err != nil {
+		return models.PinnedContext{}, nil, err
+	}
+	pin.PinnedAt = time.Now()
+	return pin, app.sessionManager.AddPin(sessionID, pin), nil
+}
+
+// Unpin removes the nth pin (1-based, as listed by Pins) from the session
+func (app *CLIApplication) Unpin(sessionID string, n int) (models.PinnedContext, error) {
+	if sessionID == "" {
+		sessionID = app.sessionID
+	}
+	return app.sessionManager.RemovePin(sessionID, n)
+}
+
+// Pins returns the session's pins in the order they were pinned
+func (app *CLIApplication) Pins(sessionID string) []models.PinnedContext {
+	if sessionID == "" {
+		sessionID = app.sessionID
+	}
+	return app.sessionManager.GetPins(sessionID)
+}
+
+// applyPins attaches the session's pins to the query for the agents
+func (app *CLIApplication) applyPins(query *models.Query) {
+	if query.SessionID == "" {
+		query.SessionID = app.sessionID
+	}
+	query.Context.Pinned = app.sessionManager.GetPins(query.SessionID)
+}
+
+// resolvePin finds the file or symbol definition a pin target names
+func (app *CLIApplication) resolvePin(target string) (models.PinnedContext, error) {
+	root, err := filepath.Abs(app.config.ProjectRoot)
+	if err != nil {
+		return models.PinnedContext{}, fmt.Errorf("failed to resolve project root: %w", err)
+	}
+
+	if mentions := agents.DetectPathMentions(target, root); len(mentions) == 1 {
+		mention := mentions[0]
+		return models.PinnedContext{Path: mention.Path, StartLine: mention.StartLine, EndLine: mention.EndLine}, nil
+	}
+	if strings.ContainsAny(target, "/\\") {
+		return models.PinnedContext{}, fmt.Errorf("%s is not a readable file in the project", target)
+	}
+
+	if app.storage == nil {
+		return models.PinnedContext{}, fmt.Errorf("no file named %s, and symbols cannot be looked up without storage", target)
+	}
+	locations, err := app.storage.LookupSymbol(target)
+	if err != nil {
+		return models.PinnedContext{}, err
+	}
+	switch len(locations) {
+	case 0:
+		return models.PinnedContext{}, fmt.Errorf("no file or indexed symbol named %s", target)
+	case 1:
+		location := locations[0]
+		return models.PinnedContext{
+			Path:      audit.RelativePath(root, location.File),
+			Symbol:    location.Name,
+			StartLine: location.StartLine,
+			EndLine:   location.EndLine,
+		}, nil
+	}
+
+	candidates := make([]string, 0, maxPinCandidates)
+	for _, location := range locations {
+		if len(candidates) == maxPinCandidates {
+			break
+		}
+		candidates = append(candidates, fmt.Sprintf("%s:%d-%d",
+			audit.RelativePath(root, location.File), location.StartLine, location.EndLine))
+	}
+	return models.PinnedContext{}, fmt.Errorf("%s is defined %d times; pin one by location: %s",
+		target, len(locations), strings.Join(candidates, ", "))
+}
@@ -0,0 +1,142 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/internal/audit"
+	"github.com/yourusername/useq-ai-assistant/internal/policy"
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// Policies returns the team's policies; none when the file is missing
+func (app *CLIApplication) Policies() ([]*policy.Policy, error) {
+	return policy.Load(app.config.Policies.File)
+}
+
+// CheckPolicies judges the indexed code against the team's policies, or
+// only the one named
+func (app *CLIApplication) CheckPolicies(ctx context.Context, name string) ([]*policy.Result, error) {
+	policies, err := app.Policies()
+	if err != nil {
+		return nil, err
+	}
+	if len(policies) == 0 {
+		return nil, fmt.Errorf("no policies defined; add them to %s", app.config.Policies.File)
+	}
+	if name != "" {
+		found := policy.Find(policies, name)
+		if found == nil {
+			return nil, fmt.Errorf("no policy named %s in %s", name, app.config.Policies.File)
+		}
+		policies = []*policy.Policy{found}
+	}
+	judge, err := app.policyJudge()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*policy.Result, 0, len(policies))
+	for _, p := range policies {
+		if ctx.Err() != nil {
+			return results, ctx.Err()
+		}
+		units, err := app.policyUnits(p)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, policy.Evaluate(ctx, judge, p, units, app.config.Policies))
+	}
+	return results, nil
+}
+
+// policyJudge returns the model that judges code against policies
+func (app *CLIApplication) policyJudge() (policy.Judge, error) {
+	if app.llmManager == nil {
+		return nil, fmt.Errorf("policy checks need an AI provider")
+	}
+	return policy.NewLLMJudge(app.llmManager, app.config.Policies.Model), nil
+}
+
+// policyUnits returns the indexed functions a policy covers by path and
+// semantic tags, each cut to the configured number of lines
+func (app *CLIApplication) policyUnits(p *policy.Policy) ([]policy.Unit, error) {
+	if app.storage == nil {
+		return nil, fmt.Errorf("policy checks need the index")
+	}
+	functions, err := app.storage.ListFunctions()
+	if err != nil {
+		return nil, err
+	}
+
+	var units []policy.Unit
+	var path string
+	var lines []string
+	for _, function := range functions {
+		relative := audit.RelativePath(app.config.ProjectRoot, function.File)
+		if !p.CoversPath(relative) {
+			continue
+		}
+		// Functions come ordered by file, so each file is read once
+		if function.File != path {
+			path, lines = function.File, nil
+			file, err := app.storage.GetFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", relative, err)
+			}
+			if file != nil {
+				lines = strings.Split(file.Content, "\n")
+			}
+		}
+		start, end := function.StartLine, function.EndLine
+		if end > len(lines) {
+			end = len(lines)
+		}
+		if start < 1 || start > end {
+			continue
+		}
+		body := lines[start-1 : end]
+		if !p.CoversCode(strings.Join(body, "\n"), app.config.Tags.MinScore) {
+			continue
+		}
+		if limit := app.config.Policies.MaxLines; limit > 0 && len(body) > limit {
+			body = body[:limit]
+		}
+		units = append(units, policy.Unit{File: relative, Symbol: function.Name, StartLine: start, Lines: body})
+	}
+	return units, nil
+}
+
+// draftPolicyViolations judges a drafted file against the policies covering
+// it. Failures are logged, not returned: policies advise the reviewer and
+// never block a draft.
+func (app *CLIApplication) draftPolicyViolations(ctx context.Context, review *models.GenerationReview, path, content string) []models.ReviewPolicyViolation {
+	policies, err := app.Policies()
+	if err != nil {
+		app.logWarning("POLICY", err.Error())
+		return nil
+	}
+	if len(policies) == 0 || app.llmManager == nil {
+		return nil
+	}
+	judge, _ := app.policyJudge()
+	unit := policy.Unit{File: path, StartLine: 1, Lines: strings.Split(content, "\n")}
+
+	var violations []models.ReviewPolicyViolation
+	for _, p := range policies {
+		if !p.CoversPath(path) || !p.CoversCode(content, app.config.Tags.MinScore) {
+			continue
+		}
+		result := policy.Evaluate(ctx, judge, p, []policy.Unit{unit}, app.config.Policies)
+		review.Tokens += result.Usage.Tokens
+		review.Cost += result.Usage.Cost
+		for _, message := range result.Errors {
+			app.logWarning("POLICY", message)
+		}
+		for _, violation := range result.Violations {
+			violations = append(violations, models.ReviewPolicyViolation{Policy: p.Name, Line: violation.Line, Reason: violation.Reason})
+		}
+	}
+	return violations
+}
@@ -0,0 +1,15 @@
+package app
+
+import (
+	"github.com/spf13/viper"
+	"github.com/yourusername/useq-ai-assistant/internal/llm"
+)
+
+// promptCaptureConfig reads debug.capture_prompts and debug.prompt_dir.
+// USEQ_DEBUG_PROMPTS turns capture on as well.
+func promptCaptureConfig() llm.PromptCaptureConfig {
+	return llm.PromptCaptureConfig{
+		Enabled: viper.GetBool("debug.capture_prompts") || llm.DebugPromptsEnabled(),
+		Dir:     viper.GetString("debug.prompt_dir"),
+	}
+}
@@ -2,10 +2,13 @@ package app
 
 import (
 	"fmt"
+	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"unicode"
 
+	"github.com/yourusername/useq-ai-assistant/internal/vocabulary"
 	"github.com/yourusername/useq-ai-assistant/models"
 )
 
@@ -19,6 +22,7 @@ type PromptParser struct {
 	testingPatterns       []*IntentPattern
 	reviewPatterns        []*IntentPattern
 	documentationPatterns []*IntentPattern
+	migrationPatterns     []*IntentPattern
 
 	// Entity extraction patterns
 	entityPatterns map[models.EntityType]*regexp.Regexp
@@ -26,6 +30,7 @@ type PromptParser struct {
 	// Keyword extraction
 	stopWords    map[string]bool
 	techKeywords map[string]bool
+	vocabulary   *vocabulary.Vocabulary // project terms, stop-words and synonyms; may be nil
 }
 
 // IntentPattern represents a pattern for detecting query intent
@@ -51,6 +56,12 @@ func NewPromptParser() *PromptParser {
 	return parser
 }
 
+// SetVocabulary makes keyword extraction keep the project's domain terms,
+// drop its extra stop-words and add synonyms
+func (p *PromptParser) SetVocabulary(vocab *vocabulary.Vocabulary) {
+	p.vocabulary = vocab
+}
+
 // ParseIntent analyzes user input and determines the intent
 func (p *PromptParser) ParseIntent(input string) (*models.QueryIntent, error) {
 	if strings.TrimSpace(input) == "" {
@@ -60,8 +71,8 @@ func (p *PromptParser) ParseIntent(input string) (*models.QueryIntent, error) {
 	// Normalize input
 	normalized := p.normalizeInput(input)
 
-	// Extract keywords first
-	keywords := p.extractKeywords(normalized)
+	// Extract keywords first, with the synonyms of domain terms
+	keywords := p.vocabulary.Expand(p.extractKeywords(normalized))
 
 	// Extract entities
 	entities := p.extractEntities(input)
@@ -242,6 +253,24 @@ func (p *PromptParser) initializeIntentPatterns() {
 			Description: "Specific documentation types",
 		},
 	}
+
+	// Migration patterns
+	p.migrationPatterns = []*IntentPattern{
+		{
+			Pattern:     regexp.MustCompile(`(?i)\b(upgrade|migrate|bump)\b.*\b(to\s+v?\d+(\.\d+)*|version|dependency|module)\b`),
+			Weight:      1.0,
+			QueryType:   models.QueryTypeMigration,
+			Keywords:    []string{"upgrade", "migrate", "version"},
+			Description: "Dependency version migrations",
+		},
+		{
+			Pattern:     regexp.MustCompile(`(?i)\b(migrate|move|port|switch)\b.*\bto\s+(go\s?1\.\d+|generics|slog|iterators)\b`),
+			Weight:      1.0,
+			QueryType:   models.QueryTypeMigration,
+			Keywords:    []string{"migrate", "generics", "slog"},
+			Description: "Go version and language feature migrations",
+		},
+	}
 }
 
 // initializeEntityPatterns sets up regex patterns for entity extraction
@@ -291,8 +320,14 @@ func (p *PromptParser) extractKeywords(input string) []string {
 		// Clean the word
 		cleaned := strings.ToLower(regexp.MustCompile(`[^\w]`).ReplaceAllString(word, ""))
 
+		// Domain terms are kept whatever the built-in lists say
+		if p.vocabulary.IsProtected(cleaned) {
+			keywords = append(keywords, cleaned)
+			continue
+		}
+
 		// Skip empty words, stop words, and very short words
-		if len(cleaned) < 3 || p.stopWords[cleaned] {
+		if len(cleaned) < 3 || p.vocabulary.IsStopWord(cleaned, p.stopWords) {
 			continue
 		}
 
@@ -359,6 +394,7 @@ func (p *PromptParser) calculateIntentScores(input string, keywords []string) ma
 	p.evaluatePatterns(p.testingPatterns, input, keywords, scores)
 	p.evaluatePatterns(p.reviewPatterns, input, keywords, scores)
 	p.evaluatePatterns(p.documentationPatterns, input, keywords, scores)
+	p.evaluatePatterns(p.migrationPatterns, input, keywords, scores)
 
 	// Normalize scores
 	return p.normalizeScores(scores)
@@ -458,6 +494,39 @@ func (p *PromptParser) extractFileTargets(input string) []string {
 	return p.deduplicateStrings(targets)
 }
 
+// fileMentionPattern matches @path, @path:line and @path:start-end at the
+// start of a word, so addresses like user@example.com are left alone
+var fileMentionPattern = regexp.MustCompile(`(^|[\s("'\x60\[])@((?:\.{1,2}/)?[\w./-]*[\w-])(?::(\d+)(?:-(\d+))?)?`)
+
+// ParseFileMentions finds the files named with @ in input and returns them
+// with the input rewritten to name them without the @, as a question would.
+// Only mentions indexed reports as indexed files count; others, such as
+// @Override, are left as written.
+func ParseFileMentions(input string, indexed func(path string) bool) ([]models.FileMention, string) {
+	var mentions []models.FileMention
+	seen := make(map[string]bool)
+	rewritten := fileMentionPattern.ReplaceAllStringFunc(input, func(match string) string {
+		parts := fileMentionPattern.FindStringSubmatch(match)
+		path := strings.TrimPrefix(filepath.Clean(parts[2]), "./")
+		if !indexed(path) {
+			return match
+		}
+		mention := models.FileMention{Path: path}
+		mention.StartLine, _ = strconv.Atoi(parts[3])
+		mention.EndLine, _ = strconv.Atoi(parts[4])
+		if mention.StartLine > 0 && mention.EndLine < mention.StartLine {
+			mention.EndLine = mention.StartLine
+		}
+		key := fmt.Sprintf("%s:%d-%d", mention.Path, mention.StartLine, mention.EndLine)
+		if !seen[key] {
+			seen[key] = true
+			mentions = append(mentions, mention)
+		}
+		return parts[1] + strings.TrimPrefix(match[len(parts[1]):], "@")
+	})
+	return mentions, rewritten
+}
+
 // extractFunctionTargets finds function references in the input
 func (p *PromptParser) extractFunctionTargets(input string) []string {
 	patterns := []*regexp.Regexp{
@@ -0,0 +1,57 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/yourusername/useq-ai-assistant/internal/provenance"
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// Provenance shows which regions of a file were written by applied answers,
+// located in the file as it stands. file is relative to the project root.
+func (app *CLIApplication) Provenance(file string) (*provenance.Report, error) {
+	if app.storage == nil {
+		return nil, fmt.Errorf("provenance needs storage")
+	}
+	path := file
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(app.config.ProjectRoot, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	rel := app.projectRelative(path)
+	records, err := app.storage.ProvenanceForFile(rel)
+	if err != nil {
+		return nil, err
+	}
+	return provenance.Inspect(rel, string(data), records), nil
+}
+
+// ProvenanceFiles lists the files the ledger has regions for
+func (app *CLIApplication) ProvenanceFiles() ([]*storage.ProvenanceFile, error) {
+	if app.storage == nil {
+		return nil, fmt.Errorf("provenance needs storage")
+	}
+	return app.storage.ProvenanceFiles()
+}
+
+// projectRelative makes a path relative to the project root, as the ledger
+// records it
+func (app *CLIApplication) projectRelative(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	root, err := filepath.Abs(app.config.ProjectRoot)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	if rel, err := filepath.Rel(root, abs); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return filepath.ToSlash(path)
+}
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/yourusername/useq-ai-assistant/internal/i18n"
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// Query metadata keys set when a query is translated
+const (
+	metadataQueryLanguage = "query_language" // detected language of the query as typed
+	metadataOriginalInput = "original_input" // the query before translation
+)
+
+// translateQuery rewrites a non-English query into English before it is
+// classified and searched, keeping the original in the query metadata
+func (app *CLIApplication) translateQuery(ctx context.Context, query *models.Query) {
+	if !app.config.QueryLanguage.Enabled || app.translator == nil {
+		return
+	}
+	detected := i18n.Detect(query.UserInput)
+	if detected.Language == i18n.English || detected.Confidence < app.config.QueryLanguage.MinConfidence {
+		return
+	}
+
+	if query.Metadata == nil {
+		query.Metadata = make(map[string]string)
+	}
+	query.Metadata[metadataQueryLanguage] = detected.Language
+
+	translated, err := app.translator.Translate(ctx, query.UserInput, i18n.English)
+	if err != nil {
+		app.logWarning("QUERY_LANGUAGE", fmt.Sprintf("Could not translate %s query, using it as typed: %v", i18n.Name(detected.Language), err))
+		return
+	}
+	query.Metadata[metadataOriginalInput] = query.UserInput
+	query.UserInput = translated
+	app.logInfo("QUERY_LANGUAGE", fmt.Sprintf("Translated %s query: %s", i18n.Name(detected.Language), translated))
+}
+
+// localizeResponse translates the prose of an answer into the pinned
+// response language, or the language the query was asked in. Code, paths
+// and citations are left as they are.
+func (app *CLIApplication) localizeResponse(ctx context.Context, query *models.Query, response *models.Response) {
+	if !app.config.QueryLanguage.Enabled || app.translator == nil || response == nil {
+		return
+	}
+	target := app.config.QueryLanguage.ResponseLanguage
+	if target == "" || target == "auto" {
+		target = query.Metadata[metadataQueryLanguage]
+	}
+	if target == "" || target == i18n.English {
+		return
+	}
+
+	translate := func(text *string) {
+		if *text == "" {
+			return
+		}
+		translated, err := app.translator.Translate(ctx, *text, target)
+		if err != nil {
+			app.logWarning("QUERY_LANGUAGE", fmt.Sprintf("Answer left in English: %v", err))
+			return
+		}
+		*text = translated
+	}
+	translate(&response.Content.Text)
+	if response.Content.Code != nil {
+		translate(&response.Content.Code.Explanation)
+	}
+	if response.Content.Clarification != nil {
+		translate(&response.Content.Clarification.Question)
+	}
+	response.Metadata.ResponseLanguage = target
+}
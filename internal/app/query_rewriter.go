@@ -0,0 +1,254 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// QueryRewriter turns pronoun-heavy follow-ups ("what about the second one?")
+// into standalone queries using the session's conversation memory, so the
+// tier classifier and retrieval see a complete question
+type QueryRewriter struct {
+	ordinals        map[string]int
+	followUpPattern *regexp.Regexp
+	whatAboutRegex  *regexp.Regexp
+	ordinalRegex    *regexp.Regexp
+	pronounRegex    *regexp.Regexp
+}
+
+// RewriteResult describes a rewrite decision
+type RewriteResult struct {
+	Original  string `json:"original"`
+	Rewritten string `json:"rewritten"`
+	Changed   bool   `json:"changed"`
+	Reason    string `json:"reason"`
+}
+
+// NewQueryRewriter creates a new follow-up query rewriter
+func NewQueryRewriter() *QueryRewriter {
+	return &QueryRewriter{
+		ordinals: map[string]int{
+			"first": 1, "1st": 1, "second": 2, "2nd": 2, "third": 3, "3rd": 3,
+			"fourth": 4, "4th": 4, "fifth": 5, "5th": 5, "last": -1,
+		},
+		followUpPattern: regexp.MustCompile(`(?i)^(and|also|what about|how about|same for|now|then)\b`),
+		whatAboutRegex:  regexp.MustCompile(`(?i)^(?:and\s+)?(?:what|how) about\s+(.+?)\??$`),
+		// "the second one", "the last result" or a bare "the second" ending
+		// the sentence; "the last commit" is about something else
+		ordinalRegex: regexp.MustCompile(`(?i)\bthe (first|1st|second|2nd|third|3rd|fourth|4th|fifth|5th|last)(?: (?:one|result|file|function)\b|\s*([?.!,]|$))`),
+		pronounRegex: regexp.MustCompile(`(?i)\b(that one|this one|it|that|this|them|those|these)\b`),
+	}
+}
+
+// Rewrite resolves references in input against the most recent turns.
+// History is expected oldest first, as returned by SessionManager. The
+// query is only rewritten when the previous turn has something for the
+// reference to point at, and keeps the user's casing.
+func (qr *QueryRewriter) Rewrite(input string, history []QueryResponse) RewriteResult {
+	result := RewriteResult{Original: input, Rewritten: input}
+
+	if len(history) == 0 {
+		result.Reason = "no conversation history"
+		return result
+	}
+	if !qr.isFollowUp(input) {
+		result.Reason = "standalone query"
+		return result
+	}
+
+	previous := history[len(history)-1]
+	if previous.Query == nil {
+		result.Reason = "previous turn has no query"
+		return result
+	}
+	previousInput := previous.Query.UserInput
+	text := strings.TrimSpace(input)
+
+	// "the second one" -> the Nth result of the previous answer
+	if loc := qr.ordinalRegex.FindStringSubmatchIndex(text); loc != nil {
+		match := text[loc[0]:loc[1]]
+		if ref := qr.resolveOrdinal(previous.Response, qr.ordinals[strings.ToLower(text[loc[2]:loc[3]])]); ref != "" {
+			if loc[4] >= 0 {
+				ref += text[loc[4]:loc[5]] // the punctuation that ended the reference
+			}
+			result.Rewritten = strings.TrimSpace(text[:loc[0]] + ref + text[loc[1]:])
+			if qr.whatAboutRegex.MatchString(result.Rewritten) {
+				result.Rewritten = fmt.Sprintf("%s (regarding %s)", previousInput, ref)
+			}
+			result.Changed = true
+			result.Reason = fmt.Sprintf("resolved ordinal reference %q", strings.TrimSpace(match))
+			return result
+		}
+	}
+
+	// "what about X?" -> previous question applied to X
+	if match := qr.whatAboutRegex.FindStringSubmatch(text); match != nil {
+		subject := strings.TrimSpace(match[1])
+		if entity := qr.primaryEntity(previous); entity != "" && strings.Contains(strings.ToLower(previousInput), strings.ToLower(entity)) {
+			result.Rewritten = replaceInsensitive(previousInput, entity, subject)
+		} else {
+			result.Rewritten = fmt.Sprintf("%s (for %s)", previousInput, subject)
+		}
+		result.Changed = true
+		result.Reason = "expanded \"what about\" follow-up"
+		return result
+	}
+
+	// "explain it" -> replace pronoun with the previous turn's main entity
+	if loc := qr.findPronoun(text); loc != nil {
+		if entity := qr.primaryEntity(previous); entity != "" {
+			result.Rewritten = text[:loc[0]] + entity + text[loc[1]:]
+			result.Changed = true
+			result.Reason = "resolved pronoun to previous entity"
+			return result
+		}
+	}
+
+	result.Reason = "follow-up detected but no referent found"
+	return result
+}
+
+// isFollowUp reports whether input depends on earlier turns
+func (qr *QueryRewriter) isFollowUp(input string) bool {
+	text := strings.TrimSpace(input)
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return false
+	}
+	if qr.followUpPattern.MatchString(text) || qr.ordinalRegex.MatchString(text) {
+		return true
+	}
+	// Short queries leaning on a pronoun ("explain it", "show me that")
+	return len(words) <= 6 && qr.findPronoun(text) != nil
+}
+
+// pronounVerbs may follow a demonstrative standing for a whole earlier
+// answer, as in "what does that do"
+var pronounVerbs = map[string]bool{
+	"do": true, "does": true, "did": true, "is": true, "was": true, "are": true, "were": true,
+	"mean": true, "means": true, "work": true, "works": true, "return": true, "returns": true,
+	"fail": true, "fails": true, "break": true, "breaks": true,
+}
+
+// pronounLeads may precede a demonstrative used as a question's subject
+var pronounLeads = map[string]bool{
+	"what": true, "why": true, "how": true, "where": true, "when": true,
+	"does": true, "do": true, "did": true, "is": true, "was": true, "can": true,
+	"could": true, "should": true, "will": true, "would": true, "and": true, "so": true, "but": true,
+}
+
+// findPronoun returns the location of the first pronoun in text that
+// refers back on its own, or nil. "it" and "them" always do; "this",
+// "that", "these" and "those" only as the query's object ("explain that"),
+// before "one", or as a question's subject ("what does that do"), not as
+// a relative "that" after a noun ("handlers that call auth") or before a
+// noun ("this function").
+func (qr *QueryRewriter) findPronoun(text string) []int {
+	for _, loc := range qr.pronounRegex.FindAllStringIndex(text, -1) {
+		switch strings.ToLower(text[loc[0]:loc[1]]) {
+		case "it", "them", "that one", "this one":
+			return loc
+		}
+		next := nextWord(text[loc[1]:])
+		if next == "" || next == "one" || next == "ones" {
+			return loc
+		}
+		if prev := previousWord(text[:loc[0]]); pronounVerbs[next] && (prev == "" || pronounLeads[prev]) {
+			return loc
+		}
+	}
+	return nil
+}
+
+// nextWord returns the lowercased word starting rest, or "" when the
+// sentence or clause ends first
+func nextWord(rest string) string {
+	rest = strings.TrimLeft(rest, " \t")
+	if rest == "" || strings.ContainsRune("?.!,;:", rune(rest[0])) {
+		return ""
+	}
+	return strings.ToLower(strings.TrimRight(strings.Fields(rest)[0], "?.!,;:"))
+}
+
+// previousWord returns the lowercased word ending before, or "" at the
+// start of the query or of a clause
+func previousWord(before string) string {
+	before = strings.TrimRight(before, " \t")
+	if before == "" || strings.ContainsRune("?.!,;:", rune(before[len(before)-1])) {
+		return ""
+	}
+	words := strings.Fields(before)
+	return strings.ToLower(words[len(words)-1])
+}
+
+// resolveOrdinal returns the Nth item (1-based, -1 for last) referenced by a response
+func (qr *QueryRewriter) resolveOrdinal(response *models.Response, n int) string {
+	items := referencedItems(response)
+	if len(items) == 0 || n == 0 {
+		return ""
+	}
+	if n < 0 {
+		return items[len(items)-1]
+	}
+	if n > len(items) {
+		return ""
+	}
+	return items[n-1]
+}
+
+// primaryEntity picks the most specific thing the previous turn was about
+func (qr *QueryRewriter) primaryEntity(turn QueryResponse) string {
+	if turn.Query != nil {
+		if len(turn.Query.Intent.FuncTargets) > 0 {
+			return turn.Query.Intent.FuncTargets[0]
+		}
+		if len(turn.Query.Intent.FileTargets) > 0 {
+			return turn.Query.Intent.FileTargets[0]
+		}
+		if len(turn.Query.Intent.Entities) > 0 {
+			return turn.Query.Intent.Entities[0].Value
+		}
+	}
+	if items := referencedItems(turn.Response); len(items) > 0 {
+		return items[0]
+	}
+	return ""
+}
+
+// referencedItems lists the functions/files a response pointed at, in order
+func referencedItems(response *models.Response) []string {
+	if response == nil {
+		return nil
+	}
+
+	var items []string
+	if response.Content.Search != nil {
+		for _, r := range response.Content.Search.Results {
+			switch {
+			case r.Function != "":
+				items = append(items, fmt.Sprintf("%s in %s", r.Function, filepath.Base(r.File)))
+			case r.File != "":
+				items = append(items, r.File)
+			}
+		}
+	}
+	if len(items) == 0 {
+		for _, f := range response.Content.Files {
+			items = append(items, f.Path)
+		}
+	}
+	return items
+}
+
+// replaceInsensitive replaces the first case-insensitive occurrence of old
+func replaceInsensitive(s, old, replacement string) string {
+	idx := strings.Index(strings.ToLower(s), strings.ToLower(old))
+	if idx < 0 {
+		return s
+	}
+	return s[:idx] + replacement + s[idx+len(old):]
+}
@@ -0,0 +1,54 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// rewriterHistory is one earlier turn about ValidateToken
+func rewriterHistory() []QueryResponse {
+	return []QueryResponse{{
+		Query: &models.Query{
+			UserInput: "where is ValidateToken defined",
+			Intent:    models.QueryIntent{FuncTargets: []string{"ValidateToken"}},
+		},
+	}}
+}
+
+func TestRewritePronouns(t *testing.T) {
+	rewriter := NewQueryRewriter()
+	for _, tc := range []struct {
+		input string
+		want  string
+	}{
+		{"explain it", "explain ValidateToken"},
+		{"what does it do", "what does ValidateToken do"},
+		{"explain that", "explain ValidateToken"},
+		{"what does that do?", "what does ValidateToken do?"},
+		{"show me that one", "show me ValidateToken"},
+		{"refactor this, please", "refactor ValidateToken, please"},
+		{"that is slow", "ValidateToken is slow"},
+	} {
+		result := rewriter.Rewrite(tc.input, rewriterHistory())
+		if !result.Changed || result.Rewritten != tc.want {
+			t.Errorf("Rewrite(%q) = %q (%s), want %q", tc.input, result.Rewritten, result.Reason, tc.want)
+		}
+	}
+}
+
+func TestRewriteKeepsDeterminersAndRelativeClauses(t *testing.T) {
+	rewriter := NewQueryRewriter()
+	for _, input := range []string{
+		"find handlers that call auth",
+		"list functions that return errors",
+		"explain this function",
+		"show those tests",
+		"what does this handler do",
+		"is that file tested",
+	} {
+		if result := rewriter.Rewrite(input, rewriterHistory()); result.Changed {
+			t.Errorf("Rewrite(%q) = %q (%s), want it unchanged", input, result.Rewritten, result.Reason)
+		}
+	}
+}
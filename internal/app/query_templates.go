@@ -0,0 +1,213 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// DefaultTemplateExportPath is where "templates export" writes without a path
+const DefaultTemplateExportPath = "useq-templates.json"
+
+// templateName is what template names may look like
+var templateName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// templateExport is the shareable file "templates export" writes and
+// "templates import" reads
+type templateExport struct {
+	Templates []templateExportEntry `json:"templates"`
+}
+
+type templateExportEntry struct {
+	Name        string `json:"name"`
+	Query       string `json:"query"`
+	Description string `json:"description,omitempty"`
+}
+
+// SaveQueryTemplate saves a query as a named template of the project,
+// replacing one with the same name
+func (app *CLIApplication) SaveQueryTemplate(name, query, description string) (*storage.QueryTemplate, error) {
+	if app.storage == nil {
+		return nil, fmt.Errorf("templates need storage")
+	}
+	name, query = strings.TrimSpace(name), strings.TrimSpace(query)
+	if !templateName.MatchString(name) {
+		return nil, fmt.Errorf("invalid template name %q: use letters, digits, '.', '-' and '_'", name)
+	}
+	if query == "" {
+		return nil, fmt.Errorf("template %s needs a query", name)
+	}
+	template := &storage.QueryTemplate{
+		Project:     app.config.ProjectRoot,
+		Name:        name,
+		Query:       query,
+		Description: strings.TrimSpace(description),
+	}
+	if err := app.storage.SaveQueryTemplate(template); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// QueryTemplates returns the project's templates, alphabetically
+func (app *CLIApplication) QueryTemplates() ([]*storage.QueryTemplate, error) {
+	if app.storage == nil {
+		return nil, fmt.Errorf("templates need storage")
+	}
+	return app.storage.ListQueryTemplates(app.config.ProjectRoot)
+}
+
+// RemoveQueryTemplate deletes a template of the project
+func (app *CLIApplication) RemoveQueryTemplate(name string) error {
+	if app.storage == nil {
+		return fmt.Errorf("templates need storage")
+	}
+	removed, err := app.storage.DeleteQueryTemplate(app.config.ProjectRoot, strings.TrimSpace(name))
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return fmt.Errorf("no template %s", strings.TrimSpace(name))
+	}
+	return nil
+}
+
+// ExpandQueryTemplate fills a template's parameters from key=value
+// arguments and returns the query to run. Every parameter needs a value and
+// every argument must name a parameter, so a typo never runs a half-filled
+// query.
+func (app *CLIApplication) ExpandQueryTemplate(name string, args []string) (string, error) {
+	if app.storage == nil {
+		return "", fmt.Errorf("templates need storage")
+	}
+	template, err := app.storage.GetQueryTemplate(app.config.ProjectRoot, name)
+	if err != nil {
+		return "", err
+	}
+	if template == nil {
+		return "", fmt.Errorf("no template %s; list them with 'templates'", name)
+	}
+
+	params := template.Params()
+	known := make(map[string]bool, len(params))
+	for _, param := range params {
+		known[param] = true
+	}
+	values := make(map[string]string, len(args))
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok || key == "" {
+			return "", fmt.Errorf("invalid argument %q: use name=value", arg)
+		}
+		if !known[key] {
+			return "", fmt.Errorf("template %s has no parameter %s (it takes: %s)", template.Name, key, describeParams(params))
+		}
+		values[key] = value
+	}
+	var missing []string
+	for _, param := range params {
+		if values[param] == "" {
+			missing = append(missing, param)
+		}
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("template %s needs %s", template.Name, strings.Join(missing, "=..., ")+"=...")
+	}
+
+	query := template.Expand(values)
+	if err := app.storage.RecordQueryTemplateRun(app.config.ProjectRoot, template.Name); err != nil {
+		app.logWarning("TEMPLATES", err.Error())
+	}
+	return query, nil
+}
+
+// ExportQueryTemplates writes the project's templates to a JSON file that
+// "templates import" reads, for sharing them with a team
+func (app *CLIApplication) ExportQueryTemplates(path string) (int, error) {
+	templates, err := app.QueryTemplates()
+	if err != nil {
+		return 0, err
+	}
+	if path == "" {
+		path = DefaultTemplateExportPath
+	}
+	export := templateExport{Templates: make([]templateExportEntry, 0, len(templates))}
+	for _, template := range templates {
+		export.Templates = append(export.Templates, templateExportEntry{
+			Name:        template.Name,
+			Query:       template.Query,
+			Description: template.Description,
+		})
+	}
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode templates: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return 0, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return len(templates), nil
+}
+
+// ImportQueryTemplates saves the templates of an exported file into the
+// project, replacing ones with the same names
+func (app *CLIApplication) ImportQueryTemplates(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var export templateExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return 0, fmt.Errorf("invalid template file %s: %w", path, err)
+	}
+	for i, entry := range export.Templates {
+		if _, err := app.SaveQueryTemplate(entry.Name, entry.Query, entry.Description); err != nil {
+			return i, err
+		}
+	}
+	return len(export.Templates), nil
+}
+
+// ParseTemplateArgs splits the arguments of "run template" at spaces
+// outside quotes, so values may hold spaces: area="payment retries"
+func ParseTemplateArgs(input string) []string {
+	var (
+		args    []string
+		current strings.Builder
+		quote   rune
+	)
+	for _, r := range input {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote == 0 && (r == '"' || r == '\''):
+			quote = r
+		case quote == 0 && (r == ' ' || r == '\t'):
+			if current.Len() > 0 {
+				args = append(args, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() > 0 {
+		args = append(args, current.String())
+	}
+	return args
+}
+
+// describeParams lists parameters for error messages
+func describeParams(params []string) string {
+	if len(params) == 0 {
+		return "no parameters"
+	}
+	sorted := append([]string(nil), params...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ", ")
+}
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/remote"
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// remotePingTimeout bounds the startup check of the server
+const remotePingTimeout = 5 * time.Second
+
+// errRemoteIndex is returned by indexing commands in remote mode
+var errRemoteIndex = errors.New("remote mode: the server owns the index; reindex there")
+
+// initializeRemote connects a thin client to the shared server. Only local
+// session history is kept; the server indexes, retrieves and answers.
+func (app *CLIApplication) initializeRemote() error {
+	fmt.Printf("  🔄 Remote Server...\n")
+	client, err := remote.NewClient(app.config.Remote, app.config.ProjectRoot)
+	if err != nil {
+		return fmt.Errorf("invalid remote configuration: %w", err)
+	}
+	app.remote = client
+
+	// An unreachable server is reported now but retried on each query
+	ctx, cancel := context.WithTimeout(context.Background(), remotePingTimeout)
+	defer cancel()
+	if err := client.Ping(ctx); err != nil {
+		fmt.Printf("  ⚠️ %v\n", err)
+		app.logWarning("REMOTE_INIT", err.Error())
+	} else {
+		fmt.Printf("  ✅ Remote server %s ready\n", client.URL())
+	}
+
+	app.sessionManager = NewSessionManager(app.storage)
+	app.logInfo("REMOTE_INIT", fmt.Sprintf("Queries go to %s", client.URL()))
+	return nil
+}
+
+// RemoteURL returns the shared server queries go to, or "" when this
+// instance answers them itself
+func (app *CLIApplication) RemoteURL() string {
+	if app.remote == nil {
+		return ""
+	}
+	return app.remote.URL()
+}
+
+// processRemoteQuery answers a query on the shared server, in order with the
+// session's other queries, and records it in the local session history
+func (app *CLIApplication) processRemoteQuery(ctx context.Context, query *models.Query) (*models.Response, error) {
+	if query.SessionID == "" {
+		query.SessionID = app.sessionID
+	}
+	release, err := app.sessions.acquire(ctx, turnKey(query))
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	response, err := app.remote.ProcessQuery(ctx, query)
+	if err != nil {
+		app.logError("REMOTE_QUERY", "Remote query failed", err)
+		return nil, err
+	}
+	app.sessions.record(query.SessionID, response)
+
+	if err := app.sessionManager.SaveQuery(query, response); err != nil {
+		app.logError("SESSION_SAVE", "Failed to save session data", err)
+	}
+	app.logSuccess("REMOTE_QUERY", fmt.Sprintf("Answered by %s", app.remote.URL()))
+	return response, nil
+}
@@ -0,0 +1,32 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// ExplainResult returns result n (1-based) of the latest answer in this
+// session that listed search results, with the factors that ranked it
+func (app *CLIApplication) ExplainResult(n int) (*models.SearchResult, error) {
+	if app.sessionManager == nil {
+		return nil, fmt.Errorf("sessions are not available")
+	}
+	history, err := app.sessionManager.GetSessionHistory(app.sessionID, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session history: %w", err)
+	}
+
+	for i := len(history) - 1; i >= 0; i-- {
+		response := history[i].Response
+		if response == nil || response.Content.Search == nil || len(response.Content.Search.Results) == 0 {
+			continue
+		}
+		results := response.Content.Search.Results
+		if n < 1 || n > len(results) {
+			return nil, fmt.Errorf("the last answer has %d results; pick 1-%d", len(results), len(results))
+		}
+		return &results[n-1], nil
+	}
+	return nil, fmt.Errorf("no search results in this session yet")
+}
@@ -0,0 +1,131 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// ResultSummaryConfig controls when a long search result list is summarized
+// instead of listed
+type ResultSummaryConfig struct {
+	Threshold int `json:"threshold"` // summarize above this many results; 0 always lists them
+	PageSize  int `json:"page_size"` // results per page when paging into the list
+	Notable   int `json:"notable"`   // highest-scored results shown in the summary
+}
+
+// DefaultResultSummaryConfig returns result summary defaults
+func DefaultResultSummaryConfig() ResultSummaryConfig {
+	return ResultSummaryConfig{Threshold: 50, PageSize: 25, Notable: 5}
+}
+
+// summarizeResults attaches a summary to a search answer with more results
+// than the threshold: counts by package and the notable matches. Every
+// result is kept, so the full list can be paged into.
+func (app *CLIApplication) summarizeResults(response *models.Response) {
+	config := app.config.ResultSummary
+	if response == nil || response.Content.Search == nil || config.Threshold <= 0 {
+		return
+	}
+	search := response.Content.Search
+	if len(search.Results) <= config.Threshold {
+		return
+	}
+	search.Summary = buildResultSummary(search.Results, app.config.ProjectRoot, config)
+	app.logInfo("RESULT_SUMMARY", fmt.Sprintf("Summarized %d results in %d packages", len(search.Results), len(search.Summary.ByPackage)))
+}
+
+// buildResultSummary counts results by package and file and picks the
+// highest-scored ones
+func buildResultSummary(results []models.SearchResult, projectRoot string, config ResultSummaryConfig) *models.ResultSummary {
+	packages := make(map[string]int)
+	files := make(map[string]bool)
+	for _, result := range results {
+		files[result.File] = true
+		packages[resultPackage(result, projectRoot)]++
+	}
+
+	summary := &models.ResultSummary{
+		Total:    len(results),
+		Files:    len(files),
+		PageSize: config.PageSize,
+	}
+	for name, count := range packages {
+		summary.ByPackage = append(summary.ByPackage, models.GroupCount{Name: name, Count: count})
+	}
+	sort.Slice(summary.ByPackage, func(i, j int) bool {
+		a, b := summary.ByPackage[i], summary.ByPackage[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Name < b.Name
+	})
+
+	order := make([]int, len(results))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return results[order[i]].Score > results[order[j]].Score
+	})
+	if config.Notable < len(order) {
+		order = order[:config.Notable]
+	}
+	summary.Notable = order
+	return summary
+}
+
+// resultPackage names the package a result is in: its directory relative to
+// the project, or its module for dependency source
+func resultPackage(result models.SearchResult, projectRoot string) string {
+	if result.Origin == "deps" && result.Module != "" {
+		return result.Module
+	}
+	dir := filepath.Dir(result.File)
+	if projectRoot != "" && filepath.IsAbs(dir) {
+		if rel, err := filepath.Rel(projectRoot, dir); err == nil && !strings.HasPrefix(rel, "..") {
+			dir = rel
+		}
+	}
+	return filepath.ToSlash(dir)
+}
+
+// ResultPage shows another page of the session's latest summarized result
+// list. Page 0 is the page after the one shown last.
+func (app *CLIApplication) ResultPage(sessionID string, page int) (*models.Response, error) {
+	if sessionID == "" {
+		sessionID = app.sessionID
+	}
+	history, err := app.sessionManager.GetSessionHistory(sessionID, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(history) - 1; i >= 0; i-- {
+		response := history[i].Response
+		if response == nil || response.Content.Search == nil || response.Content.Search.Summary == nil {
+			continue
+		}
+		summary := response.Content.Search.Summary
+		if page == 0 {
+			page = summary.Page + 1
+		}
+		if page < 1 || page > summary.Pages() {
+			return nil, fmt.Errorf("page %d is out of range; the results fill %d pages", page, summary.Pages())
+		}
+		// Remember the page so the next one follows it
+		summary.Page = page
+
+		shown := *response
+		search := *response.Content.Search
+		pageSummary := *summary
+		search.Summary = &pageSummary
+		shown.Content.Search = &search
+		shown.Content.Text = ""
+		return &shown, nil
+	}
+	return nil, fmt.Errorf("no summarized results in this session to page through")
+}
@@ -0,0 +1,92 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/pii"
+)
+
+// defaultExportDir is where sessions are exported when no path is given
+const defaultExportDir = "exports"
+
+// SessionExport describes a written session export
+type SessionExport struct {
+	Path    string
+	Entries int
+	PII     *pii.Report // personal data found; redacted when PII.Redacted
+}
+
+// ExportSession writes a session's questions and answers to a Markdown
+// file, scanning it for personal data and customer identifiers first.
+// They are redacted or only reported as privacy.mode says; redact
+// overrides the mode when set. An empty sessionID exports the current
+// session, an empty path writes into exports/.
+func (app *CLIApplication) ExportSession(sessionID, path string, redact *bool) (*SessionExport, error) {
+	if app.sessionManager == nil {
+		return nil, fmt.Errorf("sessions are not available")
+	}
+	if sessionID == "" {
+		sessionID = app.sessionID
+	}
+	history, err := app.sessionManager.GetSessionHistory(sessionID, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session %s: %w", sessionID, err)
+	}
+	if len(history) == 0 {
+		return nil, fmt.Errorf("session %s has no questions to export", sessionID)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Session %s\n\nExported %s\n", sessionID, time.Now().Format("2006-01-02 15:04"))
+	for i, entry := range history {
+		if entry.Query == nil {
+			continue
+		}
+		fmt.Fprintf(&b, "\n## %d. %s\n\n", i+1, entry.Timestamp.Format("2006-01-02 15:04"))
+		fmt.Fprintf(&b, "**Question:** %s\n\n", strings.TrimSpace(entry.Query.UserInput))
+		response := entry.Response
+		if response == nil {
+			b.WriteString("_No answer_\n")
+			continue
+		}
+		if response.AgentUsed != "" {
+			fmt.Fprintf(&b, "**Answered by:** %s\n\n", response.AgentUsed)
+		}
+		b.WriteString(strings.TrimSpace(response.Content.Text))
+		b.WriteString("\n")
+		if code := response.Content.Code; code != nil && strings.TrimSpace(code.Code) != "" {
+			fmt.Fprintf(&b, "\n```%s\n%s\n```\n", code.Language, strings.TrimRight(code.Code, "\n"))
+		}
+		if len(response.Metadata.Sources) > 0 {
+			fmt.Fprintf(&b, "\nSources: %s\n", strings.Join(response.Metadata.Sources, ", "))
+		}
+	}
+
+	content := b.String()
+	report := &pii.Report{}
+	if app.privacy != nil {
+		if redact == nil {
+			content = app.privacy.Apply(content, report)
+		} else if *redact {
+			content, report = app.privacy.Redact(content)
+		} else {
+			report = app.privacy.Scan(content)
+		}
+	}
+
+	if path == "" {
+		path = filepath.Join(defaultExportDir, fmt.Sprintf("%s_%s.md", sessionID, time.Now().Format("20060102_150405")))
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create export directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		return nil, fmt.Errorf("failed to write session export: %w", err)
+	}
+	app.logInfo("SESSION_EXPORT", fmt.Sprintf("Exported %d answers of session %s to %s (%s)", len(history), sessionID, path, report.Summary()))
+	return &SessionExport{Path: path, Entries: len(history), PII: report}, nil
+}
@@ -3,6 +3,7 @@ package app
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -21,17 +22,18 @@ type SessionManager struct {
 
 // Session represents an active user session
 type Session struct {
-	ID              string             `json:"id"`
-	StartTime       time.Time          `json:"start_time"`
-	LastActivity    time.Time          `json:"last_activity"`
-	QueryCount      int                `json:"query_count"`
-	TotalTokens     int                `json:"total_tokens"`
-	TotalCost       float64            `json:"total_cost"`
-	QueryHistory    []QueryResponse    `json:"query_history"`
-	UserPreferences UserPreferences    `json:"user_preferences"`
-	LearningContext LearningContext    `json:"learning_context"`
-	Performance     SessionPerformance `json:"performance"`
-	mu              sync.RWMutex       `json:"-"`
+	ID              string                 `json:"id"`
+	StartTime       time.Time              `json:"start_time"`
+	LastActivity    time.Time              `json:"last_activity"`
+	QueryCount      int                    `json:"query_count"`
+	TotalTokens     int                    `json:"total_tokens"`
+	TotalCost       float64                `json:"total_cost"`
+	QueryHistory    []QueryResponse        `json:"query_history"`
+	UserPreferences UserPreferences        `json:"user_preferences"`
+	LearningContext LearningContext        `json:"learning_context"`
+	Performance     SessionPerformance     `json:"performance"`
+	Pins            []models.PinnedContext `json:"pins,omitempty"` // included in every Tier 3 prompt
+	mu              sync.RWMutex           `json:"-"`
 }
 
 // QueryResponse pairs a query with its response for session history
@@ -95,6 +97,9 @@ type UserPreferences struct {
 	PreferredProviders []string         `json:"preferred_providers"`
 	CustomKeywords     []string         `json:"custom_keywords"`
 	ProjectPatterns    []ProjectPattern `json:"project_patterns"`
+	OutputFormat       string           `json:"output_format,omitempty"` // renderer chosen for the session
+	Verbosity          string           `json:"verbosity,omitempty"`     // console diagnostics chosen for the session
+	Flags              map[string]bool  `json:"flags,omitempty"`         // feature flags switched for the session
 	LastUpdated        time.Time        `json:"last_updated"`
 }
 
@@ -168,7 +173,9 @@ func (sm *SessionManager) GetOrCreateSession(sessionID string) *Session {
 
 	// Check if session exists in memory
 	if session, exists := sm.activeSessions[sessionID]; exists {
+		session.mu.Lock()
 		session.LastActivity = time.Now()
+		session.mu.Unlock()
 		return session
 	}
 
@@ -212,7 +219,11 @@ func (sm *SessionManager) GetOrCreateSession(sessionID string) *Session {
 
 // SaveQuery saves a query and its response to the session
 func (sm *SessionManager) SaveQuery(query *models.Query, response *models.Response) error {
-	session := sm.GetOrCreateSession(response.QueryID)
+	sessionID := query.SessionID
+	if sessionID == "" {
+		sessionID = response.QueryID
+	}
+	session := sm.GetOrCreateSession(sessionID)
 
 	session.mu.Lock()
 	defer session.mu.Unlock()
@@ -293,6 +304,72 @@ func (sm *SessionManager) GetSessionHistory(sessionID string, limit int) ([]Quer
 	return history, nil
 }
 
+// AddPin pins a file or symbol to a session. Pinning the same location
+// again is a no-op. It returns the session's pins.
+func (sm *SessionManager) AddPin(sessionID string, pin models.PinnedContext) []models.PinnedContext {
+	session := sm.GetOrCreateSession(sessionID)
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	for _, existing := range session.Pins {
+		if existing.Path == pin.Path && existing.StartLine == pin.StartLine && existing.EndLine == pin.EndLine {
+			return append([]models.PinnedContext(nil), session.Pins...)
+		}
+	}
+	session.Pins = append(session.Pins, pin)
+	if sm.config.AutoSave {
+		go sm.saveSessionToStorage(session)
+	}
+	return append([]models.PinnedContext(nil), session.Pins...)
+}
+
+// RemovePin unpins the nth pin (1-based) of a session
+func (sm *SessionManager) RemovePin(sessionID string, n int) (models.PinnedContext, error) {
+	session := sm.GetOrCreateSession(sessionID)
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	if n < 1 || n > len(session.Pins) {
+		return models.PinnedContext{}, fmt.Errorf("no pin %d; the session has %d pin(s)", n, len(session.Pins))
+	}
+	removed := session.Pins[n-1]
+	session.Pins = append(session.Pins[:n-1:n-1], session.Pins[n:]...)
+	if sm.config.AutoSave {
+		go sm.saveSessionToStorage(session)
+	}
+	return removed, nil
+}
+
+// GetPins returns a session's pins in the order they were pinned
+func (sm *SessionManager) GetPins(sessionID string) []models.PinnedContext {
+	session := sm.GetOrCreateSession(sessionID)
+
+	session.mu.RLock()
+	defer session.mu.RUnlock()
+	return append([]models.PinnedContext(nil), session.Pins...)
+}
+
+// ResetSession forgets a session's conversation: its query history, which
+// follow-ups, paging and excerpts are read from, and its pins. Preferences
+// and the session's usage totals are kept. It returns how many queries and
+// pins were dropped.
+func (sm *SessionManager) ResetSession(sessionID string) (int, int) {
+	session := sm.GetOrCreateSession(sessionID)
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	queries, pins := len(session.QueryHistory), len(session.Pins)
+	session.QueryHistory = make([]QueryResponse, 0)
+	session.Pins = nil
+	if sm.config.AutoSave {
+		go sm.saveSessionToStorage(session)
+	}
+	return queries, pins
+}
+
 // GetUserPreferences returns user preferences for a session
 func (sm *SessionManager) GetUserPreferences(sessionID string) UserPreferences {
 	session := sm.GetOrCreateSession(sessionID)
@@ -357,6 +434,19 @@ type SessionStats struct {
 	Performance  SessionPerformance `json:"performance"`
 }
 
+// ActiveSessionIDs returns the sessions held in memory, sorted
+func (sm *SessionManager) ActiveSessionIDs() []string {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	ids := make([]string, 0, len(sm.activeSessions))
+	for id := range sm.activeSessions {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
 // CleanupExpiredSessions removes expired sessions from memory
 func (sm *SessionManager) CleanupExpiredSessions() {
 	sm.mu.Lock()
@@ -364,7 +454,10 @@ func (sm *SessionManager) CleanupExpiredSessions() {
 
 	now := time.Now()
 	for sessionID, session := range sm.activeSessions {
-		if now.Sub(session.LastActivity) > sm.config.SessionTimeout {
+		session.mu.RLock()
+		idle := now.Sub(session.LastActivity)
+		session.mu.RUnlock()
+		if idle > sm.config.SessionTimeout {
 			// Save before removing
 			if sm.config.AutoSave {
 				sm.saveSessionToStorage(session)
@@ -465,7 +558,17 @@ func (sm *SessionManager) saveSessionToStorage(session *Session) error {
 	session.mu.RLock()
 	defer session.mu.RUnlock()
 
-	data, err := json.Marshal(session)
+	// Pasted code stays in memory for follow-ups but is only stored when
+	// the user asked to keep it
+	history := make([]QueryResponse, len(session.QueryHistory))
+	for i, entry := range session.QueryHistory {
+		entry.Query = entry.Query.Persisted()
+		history[i] = entry
+	}
+	data, err := json.Marshal(struct {
+		*Session
+		QueryHistory []QueryResponse `json:"query_history"`
+	}{session, history})
 	if err != nil {
 		return fmt.Errorf("failed to marshal session: %w", err)
 	}
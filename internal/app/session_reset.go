@@ -0,0 +1,74 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// IsolatePrefix runs one query without any conversational context
+const IsolatePrefix = "/isolate"
+
+// isolatedKey marks an isolated query in query.Metadata
+const isolatedKey = "isolated"
+
+// SessionReset is what resetting a session dropped
+type SessionReset struct {
+	Queries       int  `json:"queries"`       // earlier questions and answers
+	Pins          int  `json:"pins"`          // pinned files and symbols
+	Clarification bool `json:"clarification"` // a clarification question awaiting its answer
+}
+
+// ResetSession clears a session's conversation without restarting: its
+// history, pins and pending clarification, so follow-ups, numbered next
+// steps, paging and excerpts start over. Preferences such as the output
+// format and feature flags, and the session's spending, are kept.
+func (app *CLIApplication) ResetSession(sessionID string) (SessionReset, error) {
+	if app.sessionManager == nil {
+		return SessionReset{}, fmt.Errorf("sessions are not available")
+	}
+	if sessionID == "" {
+		sessionID = app.sessionID
+	}
+
+	var reset SessionReset
+	reset.Queries, reset.Pins = app.sessionManager.ResetSession(sessionID)
+
+	app.clarificationMu.Lock()
+	if app.pendingClarifications[sessionID] != nil {
+		delete(app.pendingClarifications, sessionID)
+		reset.Clarification = true
+	}
+	app.clarificationMu.Unlock()
+
+	app.logInfo("SESSION_RESET", fmt.Sprintf("Session %s reset: %d queries, %d pins dropped", sessionID, reset.Queries, reset.Pins))
+	return reset, nil
+}
+
+// ParseIsolatePrefix strips a leading /isolate from input and reports
+// whether it was present
+func ParseIsolatePrefix(input string) (string, bool) {
+	trimmed := strings.TrimSpace(input)
+	if trimmed == IsolatePrefix {
+		return "", true
+	}
+	if strings.HasPrefix(trimmed, IsolatePrefix+" ") {
+		return strings.TrimSpace(trimmed[len(IsolatePrefix):]), true
+	}
+	return input, false
+}
+
+// markIsolated flags a query to be answered without the session's history,
+// pins or pending clarification
+func markIsolated(query *models.Query) {
+	if query.Metadata == nil {
+		query.Metadata = make(map[string]string)
+	}
+	query.Metadata[isolatedKey] = "true"
+}
+
+// isIsolated reports whether the query asked for no conversational context
+func isIsolated(query *models.Query) bool {
+	return query != nil && query.Metadata[isolatedKey] == "true"
+}
@@ -0,0 +1,272 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/yourusername/useq-ai-assistant/display"
+	"github.com/yourusername/useq-ai-assistant/internal/llm"
+	"github.com/yourusername/useq-ai-assistant/internal/logger"
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// SessionsConfig controls how the sessions served by one process share it:
+// the interactive terminal, API clients and bots
+type SessionsConfig struct {
+	MaxConcurrent int     `json:"max_concurrent"` // queries running at once across sessions; 0 is unlimited
+	Budget        float64 `json:"budget"`         // USD one session may spend while the process runs; 0 is unlimited
+	Renderer      string  `json:"renderer"`       // how answers are shown until a session picks its own
+}
+
+// DefaultSessionsConfig returns session defaults
+func DefaultSessionsConfig() SessionsConfig {
+	return SessionsConfig{MaxConcurrent: 4, Renderer: display.RendererTerminal}
+}
+
+// SessionActivity describes one session seen by this process
+type SessionActivity struct {
+	ID      string
+	Running bool // a query of the session is being answered
+	Waiting int  // queries queued behind it
+	Queries int
+	Tokens  int
+	Cost    float64
+}
+
+// sessionCoordinator lets queries of different sessions run concurrently.
+// Queries of one session run in order, since each may follow up on the
+// previous answer or a pending clarification, and at most MaxConcurrent run
+// at once. Spend is tracked per session.
+type sessionCoordinator struct {
+	config SessionsConfig
+	slots  chan struct{} // nil when concurrency is unlimited
+	tokens *llm.TokenTracker
+
+	mu    sync.Mutex
+	turns map[string]*sessionTurn
+}
+
+// sessionTurn orders the queries of one session
+type sessionTurn struct {
+	held  chan struct{} // holds a token while a query of the session runs
+	users int           // queries running or waiting
+}
+
+func newSessionCoordinator(config SessionsConfig) *sessionCoordinator {
+	c := &sessionCoordinator{
+		config: config,
+		tokens: llm.NewTokenTracker(),
+		turns:  make(map[string]*sessionTurn),
+	}
+	if config.MaxConcurrent > 0 {
+		c.slots = make(chan struct{}, config.MaxConcurrent)
+	}
+	return c
+}
+
+// acquire waits until a query of sessionID may run and returns the function
+// that ends its turn
+func (c *sessionCoordinator) acquire(ctx context.Context, sessionID string) (func(), error) {
+	c.mu.Lock()
+	turn, ok := c.turns[sessionID]
+	if !ok {
+		turn = &sessionTurn{held: make(chan struct{}, 1)}
+		c.turns[sessionID] = turn
+	}
+	turn.users++
+	c.mu.Unlock()
+
+	leave := func() {
+		c.mu.Lock()
+		if turn.users--; turn.users == 0 {
+			delete(c.turns, sessionID)
+		}
+		c.mu.Unlock()
+	}
+	select {
+	case turn.held <- struct{}{}:
+	case <-ctx.Done():
+		leave()
+		return nil, fmt.Errorf("session %s is busy: %w", sessionID, ctx.Err())
+	}
+	if c.slots != nil {
+		select {
+		case c.slots <- struct{}{}:
+		case <-ctx.Done():
+			<-turn.held
+			leave()
+			return nil, fmt.Errorf("too many queries running: %w", ctx.Err())
+		}
+	}
+	return func() {
+		if c.slots != nil {
+			<-c.slots
+		}
+		<-turn.held
+		leave()
+	}, nil
+}
+
+// turnKey is what a query waits its turn under: its session, or its own
+// task for a background task's query. A task still reads and extends its
+// session's history and spends its budget, but a long task does not hold
+// up the queries typed in the session meanwhile.
+func turnKey(query *models.Query) string {
+	if taskID := query.Metadata["task_id"]; taskID != "" {
+		return "task-" + taskID
+	}
+	return query.SessionID
+}
+
+// checkBudget refuses a query once its session has spent its budget. A
+// query already running may finish, so a budget is overshot by at most one
+// query.
+func (c *sessionCoordinator) checkBudget(sessionID string) error {
+	if c.config.Budget <= 0 {
+		return nil
+	}
+	usage, ok := c.tokens.GetSessionUsage(sessionID)
+	if ok && usage.TotalCost >= c.config.Budget {
+		return fmt.Errorf("session %s has spent its budget: $%.4f of $%.4f", sessionID, usage.TotalCost, c.config.Budget)
+	}
+	return nil
+}
+
+// record charges a response to its session
+func (c *sessionCoordinator) record(sessionID string, response *models.Response) {
+	c.tokens.TrackUsage(sessionID, response.TokenUsage, response.Cost)
+}
+
+// activity lists the sessions that asked something or are asking now
+func (c *sessionCoordinator) activity(ids []string) []SessionActivity {
+	c.mu.Lock()
+	seen := make(map[string]bool)
+	var sessions []SessionActivity
+	for id, turn := range c.turns {
+		seen[id] = true
+		sessions = append(sessions, SessionActivity{ID: id, Running: true, Waiting: turn.users - 1})
+	}
+	c.mu.Unlock()
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			sessions = append(sessions, SessionActivity{ID: id})
+		}
+	}
+	for i := range sessions {
+		if usage, ok := c.tokens.GetSessionUsage(sessions[i].ID); ok {
+			sessions[i].Queries = usage.TotalQueries
+			sessions[i].Tokens = usage.TotalInputTokens + usage.TotalOutputTokens
+			sessions[i].Cost = usage.TotalCost
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].ID < sessions[j].ID })
+	return sessions
+}
+
+// Sessions lists the sessions this process has answered or is answering,
+// with what each has spent
+func (app *CLIApplication) Sessions() []SessionActivity {
+	return app.sessions.activity(app.sessionManager.ActiveSessionIDs())
+}
+
+// OutputFormat returns the name of the renderer the session shows answers
+// with: its own choice, or the configured default
+func (app *CLIApplication) OutputFormat(sessionID string) string {
+	if sessionID == "" {
+		sessionID = app.sessionID
+	}
+	if format := app.sessionManager.GetUserPreferences(sessionID).OutputFormat; format != "" {
+		return format
+	}
+	if app.config.Sessions.Renderer != "" {
+		return app.config.Sessions.Renderer
+	}
+	return display.RendererTerminal
+}
+
+// SetOutputFormat picks the renderer the session shows answers with
+func (app *CLIApplication) SetOutputFormat(sessionID, name string) error {
+	if sessionID == "" {
+		sessionID = app.sessionID
+	}
+	name = strings.ToLower(strings.TrimSpace(name))
+	if _, err := display.NewRenderer(name, io.Discard); err != nil {
+		return err
+	}
+	preferences := app.sessionManager.GetUserPreferences(sessionID)
+	preferences.OutputFormat = name
+	app.sessionManager.UpdateUserPreferences(sessionID, preferences)
+	return nil
+}
+
+// Renderer returns the session's renderer writing to w
+func (app *CLIApplication) Renderer(sessionID string, w io.Writer) display.Renderer {
+	renderer, err := display.NewRenderer(app.OutputFormat(sessionID), w)
+	if err != nil {
+		// A format saved by another version; fall back to the terminal
+		return display.NewTerminalRenderer(w)
+	}
+	return renderer
+}
+
+// Verbosity returns how much diagnostic output the session's queries show:
+// its own choice, or the configured default
+func (app *CLIApplication) Verbosity(sessionID string) logger.Verbosity {
+	if sessionID == "" {
+		sessionID = app.sessionID
+	}
+	if name := app.sessionManager.GetUserPreferences(sessionID).Verbosity; name != "" {
+		if v, err := logger.ParseVerbosity(name); err == nil {
+			return v
+		}
+	}
+	return logger.DefaultVerbosity()
+}
+
+// SetVerbosity picks how much diagnostic output the session's queries show
+func (app *CLIApplication) SetVerbosity(sessionID, name string) (logger.Verbosity, error) {
+	if sessionID == "" {
+		sessionID = app.sessionID
+	}
+	v, err := logger.ParseVerbosity(name)
+	if err != nil {
+		return v, err
+	}
+	preferences := app.sessionManager.GetUserPreferences(sessionID)
+	preferences.Verbosity = v.String()
+	app.sessionManager.UpdateUserPreferences(sessionID, preferences)
+	return v, nil
+}
+
+// ToggleVerbose switches the session between verbose output and normal
+// output, and returns the new level
+func (app *CLIApplication) ToggleVerbose(sessionID string) logger.Verbosity {
+	next := logger.VerbosityVerbose
+	if app.Verbosity(sessionID) >= logger.VerbosityVerbose {
+		next = logger.VerbosityNormal
+	}
+	v, _ := app.SetVerbosity(sessionID, next.String())
+	return v
+}
+
+// queryVerbosity returns the verbosity of one query: its "verbosity"
+// metadata when set, or its session's
+func (app *CLIApplication) queryVerbosity(query *models.Query) logger.Verbosity {
+	if name := query.Metadata["verbosity"]; name != "" {
+		if v, err := logger.ParseVerbosity(name); err == nil {
+			return v
+		}
+	}
+	return app.Verbosity(query.SessionID)
+}
+
+// steps returns the step logger of the query ctx belongs to, or the
+// process's own logger outside a query
+func (app *CLIApplication) steps(ctx context.Context) *logger.StepLogger {
+	return logger.StepLoggerFrom(ctx, app.stepLogger)
+}
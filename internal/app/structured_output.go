@@ -0,0 +1,80 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/internal/llm"
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// maxStructuredSearchResults bounds the search hits passed to the structuring call
+const maxStructuredSearchResults = 10
+
+// applyStructuredOutput converts an answer into JSON matching the schema the
+// caller asked for, using the provider's JSON mode, and validates it. The
+// prose answer is kept alongside for display.
+func (app *CLIApplication) applyStructuredOutput(ctx context.Context, query *models.Query, response *models.Response) error {
+	raw := query.Metadata[llm.ResponseSchemaKey]
+	if raw == "" || response.Type == models.ResponseTypeClarification || response.Type == models.ResponseTypePlan {
+		return nil
+	}
+	if app.llmManager == nil {
+		return fmt.Errorf("structured output requires an LLM provider")
+	}
+	schema, err := llm.ParseResponseSchema([]byte(raw))
+	if err != nil {
+		return err
+	}
+
+	request := &llm.GenerationRequest{
+		Messages: []llm.Message{
+			{Role: "user", Content: structuredOutputPrompt(query, response)},
+		},
+		SystemPrompt:   "You turn a code assistant's analysis into machine-readable results. Use only facts present in the analysis.",
+		MaxTokens:      2000,
+		ResponseSchema: schema,
+	}
+	generated, structured, err := app.llmManager.GenerateStructured(ctx, request)
+	if generated != nil {
+		response.TokenUsage.InputTokens += generated.TokenUsage.InputTokens
+		response.TokenUsage.OutputTokens += generated.TokenUsage.OutputTokens
+		response.TokenUsage.TotalTokens += generated.TokenUsage.TotalTokens
+		response.Cost.TotalCost += generated.Cost.TotalCost
+	}
+	if err != nil {
+		return fmt.Errorf("structured output failed: %w", err)
+	}
+
+	response.Content.Structured = structured
+	app.logSuccess("STRUCTURED_OUTPUT", "Response converted to requested schema", map[string]interface{}{
+		"schema": schema.Name,
+		"bytes":  len(structured),
+	})
+	return nil
+}
+
+// structuredOutputPrompt presents the original request and the full answer
+// to the structuring call
+func structuredOutputPrompt(query *models.Query, response *models.Response) string {
+	var prompt strings.Builder
+	prompt.WriteString(fmt.Sprintf("Request: %s\n\nAnalysis:\n%s\n", query.UserInput, response.Content.Text))
+
+	if code := response.Content.Code; code != nil && code.Code != "" {
+		prompt.WriteString(fmt.Sprintf("\nCode (%s):\n%s\n", code.Language, code.Code))
+	}
+	if search := response.Content.Search; search != nil && len(search.Results) > 0 {
+		prompt.WriteString("\nSearch results:\n")
+		for i, result := range search.Results {
+			if i >= maxStructuredSearchResults {
+				break
+			}
+			prompt.WriteString(fmt.Sprintf("- %s:%d %s (score %.2f)\n", result.File, result.Line, result.Function, result.Score))
+		}
+	}
+	if len(response.Metadata.Sources) > 0 {
+		prompt.WriteString(fmt.Sprintf("\nSources: %s\n", strings.Join(response.Metadata.Sources, ", ")))
+	}
+	return prompt.String()
+}
@@ -0,0 +1,91 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/yourusername/useq-ai-assistant/internal/tasks"
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// TaskKindSummaries embeds every file and package summary into the summary
+// collection, resumable per file
+const TaskKindSummaries = "summaries"
+
+// initializeSummarySearch connects the summary collection that file- and
+// package-level queries search. Failure only disables those modes; such
+// queries are then answered from code chunks.
+func (app *CLIApplication) initializeSummarySearch() {
+	if !app.config.SummarySearch.Enabled || app.vectorDB == nil {
+		return
+	}
+	summaryDB, err := app.vectorDB.WithCollection(app.config.SummarySearch.Collection)
+	if err != nil {
+		app.logWarning("SUMMARY_INIT", fmt.Sprintf("Summary search unavailable: %v", err))
+		return
+	}
+	app.summaryVectorDB = summaryDB
+	app.logInfo("SUMMARY_INIT", fmt.Sprintf("Summary search ready (collection %s)", app.config.SummarySearch.Collection))
+}
+
+// StartSummariesTask embeds the summaries of every file and package in the
+// background
+func (app *CLIApplication) StartSummariesTask(ctx context.Context) (*storage.TaskRecord, error) {
+	if app.tasks == nil {
+		return nil, fmt.Errorf("background tasks need storage")
+	}
+	if app.summaryVectorDB == nil {
+		return nil, fmt.Errorf("summary search is disabled; set summary_search.enabled in config/properties.yaml")
+	}
+	if app.indexer == nil {
+		return nil, fmt.Errorf("indexer not initialized")
+	}
+	return app.tasks.Start(ctx, TaskKindSummaries, "embed file and package summaries", struct{}{})
+}
+
+// runSummariesTask embeds a summary of every project file, checkpointing its
+// position, then re-summarizes stale packages and embeds every package
+// summary
+func (app *CLIApplication) runSummariesTask(ctx context.Context, run *tasks.Run) (string, error) {
+	if app.summaryVectorDB == nil || app.indexer == nil {
+		return "", fmt.Errorf("summary search is disabled")
+	}
+	checkpoint, err := app.indexProjectFiles(ctx, run, nil, func(ctx context.Context, path string) error {
+		return app.indexer.IndexFileSummary(ctx, app.summaryVectorDB, path)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	run.Progress(0, 0, "summarizing packages")
+	freshness, err := app.prewarmer.PackageFreshness()
+	if err != nil {
+		return "", err
+	}
+	packages, failed := 0, 0
+	for i, pkg := range freshness {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		run.Progress(i, len(freshness), pkg.Dir)
+		if pkg.Stale {
+			if err := app.prewarmer.ResummarizePackage(pkg.Dir, pkg.Files); err != nil {
+				failed++
+				continue
+			}
+		}
+		record, _, err := app.prewarmer.PackageSummary(pkg.Dir)
+		if err != nil || record == nil {
+			failed++
+			continue
+		}
+		if err := app.indexer.IndexPackageSummary(ctx, app.summaryVectorDB, record); err != nil {
+			failed++
+			continue
+		}
+		packages++
+	}
+
+	files := len(checkpoint.Files) - checkpoint.Failed
+	return fmt.Sprintf("Embedded summaries of %d files and %d packages (%d failed)", files, packages, checkpoint.Failed+failed), nil
+}
@@ -0,0 +1,184 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/language"
+	"github.com/yourusername/useq-ai-assistant/internal/tasks"
+	"github.com/yourusername/useq-ai-assistant/models"
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// Kinds of background task
+const (
+	TaskKindQuery = "query" // answer a query, such as Tier 3 multi-file generation
+	TaskKindIndex = "index" // index the whole project, resumable per file
+)
+
+// indexCheckpointEvery is how many files an index task indexes between
+// checkpoints
+const indexCheckpointEvery = 20
+
+// queryTaskInput is what a query task is started with
+type queryTaskInput struct {
+	Input       string `json:"input"`
+	Language    string `json:"language"`
+	ProjectRoot string `json:"project_root"`
+	SessionID   string `json:"session_id"`
+}
+
+// indexTaskCheckpoint is where an index task resumes
+type indexTaskCheckpoint struct {
+	Files  []string `json:"files"`
+	Next   int      `json:"next"`
+	Failed int      `json:"failed"`
+}
+
+// initializeTasks creates the task manager and marks tasks a crash left
+// running as interrupted
+func (app *CLIApplication) initializeTasks() {
+	if app.storage == nil {
+		return
+	}
+	app.tasks = tasks.NewManager(app.storage, app.stepLogger)
+	app.tasks.Register(TaskKindQuery, app.runQueryTask)
+	app.tasks.Register(TaskKindIndex, app.runIndexTask)
+	app.tasks.Register(TaskKindAudit, app.runAuditTask)
+	app.tasks.Register(TaskKindResummarize, app.runResummarizeTask)
+	app.tasks.Register(TaskKindReembed, app.runReembedTask)
+	app.tasks.Register(TaskKindSummaries, app.runSummariesTask)
+	app.tasks.Register(TaskKindReview, app.runReviewTask)
+
+	interrupted, err := app.tasks.Recover()
+	if err != nil {
+		app.logError("TASKS_INIT", "Failed to recover interrupted tasks", err)
+		return
+	}
+	if interrupted > 0 {
+		fmt.Printf("⏸️ %d task(s) were interrupted; see 'tasks' and resume with 'tasks resume <id>'\n", interrupted)
+	}
+}
+
+// Tasks returns the background task manager, nil without storage
+func (app *CLIApplication) Tasks() *tasks.Manager {
+	return app.tasks
+}
+
+// StartQueryTask answers a query in the background
+func (app *CLIApplication) StartQueryTask(ctx context.Context, input string) (*storage.TaskRecord, error) {
+	if app.tasks == nil {
+		return nil, fmt.Errorf("background tasks need storage")
+	}
+	detected := language.Detect(input, nil, "go")
+	return app.tasks.Start(ctx, TaskKindQuery, input, queryTaskInput{
+		Input:       input,
+		Language:    detected.Language,
+		ProjectRoot: app.config.ProjectRoot,
+		SessionID:   app.sessionID,
+	})
+}
+
+// StartIndexTask indexes the whole project in the background
+func (app *CLIApplication) StartIndexTask(ctx context.Context) (*storage.TaskRecord, error) {
+	if app.tasks == nil {
+		return nil, fmt.Errorf("background tasks need storage")
+	}
+	if app.indexer == nil {
+		return nil, fmt.Errorf("indexer not initialized")
+	}
+	return app.tasks.Start(ctx, TaskKindIndex, "index "+app.config.ProjectRoot, struct{}{})
+}
+
+// runQueryTask answers a query task. A query is one unit of work, so a
+// resumed query task starts it again.
+func (app *CLIApplication) runQueryTask(ctx context.Context, run *tasks.Run) (string, error) {
+	var input queryTaskInput
+	if err := run.Input(&input); err != nil {
+		return "", fmt.Errorf("invalid query task input: %w", err)
+	}
+	run.Progress(0, 1, "answering")
+
+	query := &models.Query{
+		ID:          fmt.Sprintf("query_%d", time.Now().UnixNano()),
+		UserInput:   input.Input,
+		Language:    input.Language,
+		Metadata:    map[string]string{"task_id": run.ID()},
+		Timestamp:   time.Now(),
+		SessionID:   input.SessionID,
+		ProjectRoot: input.ProjectRoot,
+	}
+	response, err := app.ProcessQuery(ctx, query)
+	if err != nil {
+		return "", err
+	}
+	run.Progress(1, 1, "answered")
+	if response.Content.Code != nil && response.Content.Code.Code != "" {
+		return response.Content.Text + "\n\n" + response.Content.Code.Code, nil
+	}
+	return response.Content.Text, nil
+}
+
+// runIndexTask indexes every project file, checkpointing its position so a
+// resumed task skips the files it already indexed
+func (app *CLIApplication) runIndexTask(ctx context.Context, run *tasks.Run) (string, error) {
+	checkpoint, err := app.indexProjectFiles(ctx, run, nil, app.indexer.IndexFile)
+	if err != nil {
+		return "", err
+	}
+	app.indexer.RecordGeneration(ctx, "task "+run.ID())
+	total := len(checkpoint.Files)
+	return fmt.Sprintf("Indexed %d files (%d failed)", total-checkpoint.Failed, checkpoint.Failed), nil
+}
+
+// indexProjectFiles runs index on every project file for a task,
+// checkpointing its position so a resumed task skips the files it already
+// indexed. prepare, when set, runs once before a new task's first file.
+func (app *CLIApplication) indexProjectFiles(ctx context.Context, run *tasks.Run, prepare func(context.Context) error, index func(context.Context, string) error) (*indexTaskCheckpoint, error) {
+	var checkpoint indexTaskCheckpoint
+	resumed, err := run.LoadCheckpoint(&checkpoint)
+	if err != nil {
+		return nil, err
+	}
+	if !resumed {
+		run.Progress(0, 0, "scanning project")
+		files, err := app.indexer.ScanFiles()
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan project: %w", err)
+		}
+		if prepare != nil {
+			if err := prepare(ctx); err != nil {
+				return nil, err
+			}
+		}
+		checkpoint.Files = files
+		if err := run.Checkpoint(checkpoint, 0, len(files)); err != nil {
+			return nil, err
+		}
+	}
+
+	total := len(checkpoint.Files)
+	for checkpoint.Next < total {
+		path := checkpoint.Files[checkpoint.Next]
+		err := index(ctx, path)
+		if ctx.Err() != nil {
+			// The file may be half indexed; the resumed task indexes it again
+			run.Checkpoint(checkpoint, checkpoint.Next, total)
+			return nil, ctx.Err()
+		}
+		if err != nil {
+			checkpoint.Failed++
+		}
+		checkpoint.Next++
+
+		if checkpoint.Next%indexCheckpointEvery == 0 {
+			if err := run.Checkpoint(checkpoint, checkpoint.Next, total); err != nil {
+				return nil, err
+			}
+		} else {
+			run.Progress(checkpoint.Next, total, path)
+		}
+	}
+	return &checkpoint, nil
+}
@@ -0,0 +1,182 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/edits"
+	"github.com/yourusername/useq-ai-assistant/internal/mcp"
+	"github.com/yourusername/useq-ai-assistant/internal/provenance"
+	"github.com/yourusername/useq-ai-assistant/models"
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// ParseTodoFilter reads the filter flags of the todos command:
+// --package <dir>, --kind <marker>, --author <name>, --older-than <age>
+// (e.g. 90d, 2w or 36h) and --limit <n>
+func ParseTodoFilter(args []string) (storage.TodoFilter, error) {
+	var filter storage.TodoFilter
+	for i := 0; i < len(args); i++ {
+		flag := args[i]
+		if i+1 >= len(args) {
+			return filter, fmt.Errorf("%s needs a value", flag)
+		}
+		value := args[i+1]
+		i++
+		switch flag {
+		case "--package", "-p":
+			filter.Package = value
+		case "--kind", "-k":
+			filter.Kind = strings.ToUpper(value)
+		case "--author", "-a":
+			filter.Author = value
+		case "--older-than":
+			age, err := parseAge(value)
+			if err != nil {
+				return filter, err
+			}
+			filter.MinAge = age
+		case "--limit", "-n":
+			limit, err := strconv.Atoi(value)
+			if err != nil || limit < 1 {
+				return filter, fmt.Errorf("invalid limit %q", value)
+			}
+			filter.Limit = limit
+		default:
+			return filter, fmt.Errorf("unknown flag %s", flag)
+		}
+	}
+	return filter, nil
+}
+
+// parseAge parses a duration that may be given in days or weeks
+func parseAge(value string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if number, ok := strings.CutSuffix(value, suffix); ok {
+			n, err := strconv.Atoi(number)
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("invalid age %q", value)
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+	age, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid age %q (use e.g. 90d, 2w or 36h)", value)
+	}
+	return age, nil
+}
+
+// Todos returns the TODO, FIXME and HACK comments found while indexing,
+// oldest first
+func (app *CLIApplication) Todos(filter storage.TodoFilter) ([]*storage.TodoRecord, error) {
+	if app.storage == nil {
+		return nil, fmt.Errorf("todos need storage")
+	}
+	return app.storage.ListTodos(filter)
+}
+
+// Todo returns one recorded comment
+func (app *CLIApplication) Todo(id int64) (*storage.TodoRecord, error) {
+	if app.storage == nil {
+		return nil, fmt.Errorf("todos need storage")
+	}
+	return app.storage.GetTodo(id)
+}
+
+// ResolveTodo asks the coding agent for the implementation a recorded
+// comment asks for. Nothing is written; see ApplyCodeChanges.
+func (app *CLIApplication) ResolveTodo(ctx context.Context, id int64) (*models.Response, error) {
+	todo, err := app.Todo(id)
+	if err != nil {
+		return nil, err
+	}
+	if app.codingAgent == nil {
+		return nil, fmt.Errorf("resolving todos requires the coding agent")
+	}
+	app.prewarmer.Touch()
+	return app.codingAgent.ResolveTodo(ctx, todo, app.config.ProjectRoot)
+}
+
+// ApplyCodeChanges writes the line-anchored changes of a response to disk
+// and returns the files changed. A file that changed since the edits were
+// computed is left alone and reported as an error. The regions written are
+// recorded in the provenance ledger, under a marker comment when configured.
+// With dependency_insight.go_get set, the modules the response proposes are
+// added to go.mod as well.
+func (app *CLIApplication) ApplyCodeChanges(response *models.Response) ([]string, error) {
+	if response.Content.Code == nil {
+		return nil, fmt.Errorf("the response proposes no changes")
+	}
+	byFile := make(map[string][]models.CodeChange)
+	for _, change := range response.Content.Code.Changes {
+		byFile[change.File] = append(byFile[change.File], change)
+	}
+	files := make([]string, 0, len(byFile))
+	for file := range byFile {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	// Refused before anything is written, so a denied file never leaves
+	// the change half applied
+	for _, file := range files {
+		if err := mcp.CurrentPathPolicy().Check("write", file); err != nil {
+			return nil, err
+		}
+	}
+
+	source := provenance.SourceOf(response)
+	var written []string
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return written, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		content := string(data)
+		for _, change := range byFile[file] {
+			if change.BaseHash != "" && change.BaseHash != edits.HashContent(content) {
+				return written, fmt.Errorf("%s changed since the edits were generated", file)
+			}
+		}
+		changes := byFile[file]
+		if app.config.Provenance.Enabled && app.config.Provenance.Markers {
+			changes = provenance.AddMarkers(file, changes, source)
+		}
+		updated, err := edits.Apply(content, changes)
+		if err != nil {
+			return written, fmt.Errorf("failed to apply edits to %s: %w", file, err)
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return written, fmt.Errorf("failed to stat %s: %w", file, err)
+		}
+		if err := os.WriteFile(file, []byte(updated), info.Mode().Perm()); err != nil {
+			return written, fmt.Errorf("failed to write %s: %w", file, err)
+		}
+		written = append(written, file)
+
+		if app.config.Provenance.Enabled && app.storage != nil {
+			records := provenance.Regions(app.projectRelative(file), changes, source)
+			if err := app.storage.RecordProvenance(records); err != nil {
+				fmt.Printf("⚠️ Failed to record provenance of %s: %v\n", file, err)
+			}
+		}
+	}
+
+	// Modules the new code imports are added with go get when enabled
+	if app.config.DependencyInsight.GoGet && len(response.Content.Code.Dependencies) > 0 {
+		added, err := app.AddModules(context.Background(), response.Content.Code.Dependencies)
+		if len(added) > 0 {
+			written = append(written, "go.mod")
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
@@ -0,0 +1,33 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/yourusername/useq-ai-assistant/internal/vectordb"
+)
+
+// MigrateVectorPayloads rewrites the payloads of the project collection to
+// the given mode (the configured one when empty) without re-embedding
+func (app *CLIApplication) MigrateVectorPayloads(ctx context.Context, mode string) (*vectordb.PayloadMigrationStats, error) {
+	if app.vectorDB == nil {
+		return nil, fmt.Errorf("vector database is not available")
+	}
+	if mode == "" {
+		mode = app.config.VectorDB.PayloadMode
+	}
+	payloadMode, err := vectordb.ParsePayloadMode(mode)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := app.vectorDB.MigratePayloads(ctx, payloadMode)
+	if err != nil {
+		return stats, fmt.Errorf("payload migration failed: %w", err)
+	}
+	if payloadMode != app.vectorDB.PayloadMode() {
+		app.logWarning("VECTORDB_MIGRATE", fmt.Sprintf("Collection migrated to %s but vectordb.payload_mode is %s; new chunks will use %s",
+			payloadMode, app.vectorDB.PayloadMode(), app.vectorDB.PayloadMode()))
+	}
+	return stats, nil
+}
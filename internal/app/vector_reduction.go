@@ -0,0 +1,65 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/yourusername/useq-ai-assistant/internal/vectordb"
+)
+
+// ReduceVectors builds a reduced collection from the project collection's
+// full-size vectors, fitting a PCA projection first if the collection's
+// reduction needs one
+func (app *CLIApplication) ReduceVectors(ctx context.Context, collection string) (*vectordb.ReductionStats, error) {
+	if app.vectorDB == nil {
+		return nil, fmt.Errorf("vector database is not available")
+	}
+	if _, ok := app.config.VectorDB.Reductions[collection]; !ok {
+		return nil, fmt.Errorf("no reduction configured for %s; add it under vectordb.reductions", collection)
+	}
+	target, err := app.vectorDB.WithCollection(collection)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := app.vectorDB.CopyReduced(ctx, target, vectordb.DefaultPCASamples)
+	if err != nil {
+		return stats, fmt.Errorf("failed to reduce vectors: %w", err)
+	}
+	return stats, nil
+}
+
+// BenchmarkSearch runs benchmark queries against the project collection and
+// every configured reduced collection, so recall can be weighed against
+// vector memory
+func (app *CLIApplication) BenchmarkSearch(ctx context.Context, queries []vectordb.BenchmarkQuery, k int) ([]*vectordb.SearchBenchRun, error) {
+	if app.vectorDB == nil {
+		return nil, fmt.Errorf("vector database is not available")
+	}
+
+	clients := []*vectordb.QdrantClient{app.vectorDB}
+	collections := make([]string, 0, len(app.config.VectorDB.Reductions))
+	for collection := range app.config.VectorDB.Reductions {
+		if collection != app.config.VectorDB.CollectionName {
+			collections = append(collections, collection)
+		}
+	}
+	sort.Strings(collections)
+	for _, collection := range collections {
+		client, err := app.vectorDB.WithCollection(collection)
+		if err != nil {
+			return nil, err
+		}
+		clients = append(clients, client)
+	}
+
+	runs := make([]*vectordb.SearchBenchRun, 0, len(clients))
+	for _, client := range clients {
+		run, err := client.BenchmarkSearch(ctx, queries, k)
+		if err != nil {
+			return runs, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
@@ -0,0 +1,44 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/vectordb"
+)
+
+// warmUpTimeout bounds the background warm-up after connecting to Qdrant
+const warmUpTimeout = 2 * time.Minute
+
+// TuneVectorCollection compares the project collection's storage and HNSW
+// settings with those vectordb.performance_profile recommends for its size
+// and applies the difference unless dryRun
+func (app *CLIApplication) TuneVectorCollection(ctx context.Context, dryRun bool) (*vectordb.TuningReport, error) {
+	if app.vectorDB == nil {
+		return nil, fmt.Errorf("vector database is not available")
+	}
+	report, err := app.vectorDB.Tune(ctx, dryRun)
+	if err != nil {
+		return report, fmt.Errorf("collection tuning failed: %w", err)
+	}
+	if report.Applied {
+		app.logInfo("VECTORDB_TUNE", fmt.Sprintf("Applied %d setting change(s) to %s (%s profile, %d points)",
+			len(report.Changes), report.Collection, report.Recommended.Profile, report.Points))
+	}
+	return report, nil
+}
+
+// warmUpVectorDB pages the collection in after a Qdrant restart so the
+// first query isn't the slow one
+func (app *CLIApplication) warmUpVectorDB() {
+	ctx, cancel := context.WithTimeout(context.Background(), warmUpTimeout)
+	defer cancel()
+
+	took, err := app.vectorDB.WarmUp(ctx)
+	if err != nil {
+		app.logWarning("VECTORDB_WARMUP", err.Error())
+		return
+	}
+	app.logInfo("VECTORDB_WARMUP", fmt.Sprintf("Collection warmed up in %s", took.Round(time.Millisecond)))
+}
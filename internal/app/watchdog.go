@@ -0,0 +1,74 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/yourusername/useq-ai-assistant/internal/vectordb"
+	"github.com/yourusername/useq-ai-assistant/internal/watchdog"
+)
+
+// watchdogQdrant names the vector database subsystem
+const watchdogQdrant = "Qdrant"
+
+// initializeWatchdog starts re-probing Qdrant and the LLM providers after
+// they fail, so a long-running server heals without a restart
+func (app *CLIApplication) initializeWatchdog() {
+	app.watchdog = watchdog.New(app.config.Watchdog, app.logTransition)
+
+	if app.vectorDB != nil {
+		app.watchdog.Register(watchdog.Subsystem{
+			Name:    watchdogQdrant,
+			Failing: app.vectorDB.Failing,
+			Probe:   app.vectorDB.Probe,
+			Recover: app.recoverVectorDB,
+		})
+	}
+	if app.llmManager != nil {
+		for _, name := range app.llmManager.ProviderNames() {
+			name := name
+			app.watchdog.Register(watchdog.Subsystem{
+				Name:    "LLM provider " + name,
+				Failing: func() error { return app.llmManager.ProviderFailure(name) },
+				Probe:   func(ctx context.Context) error { return app.llmManager.ProbeProvider(ctx, name) },
+				Recover: func(context.Context) error { return app.llmManager.RecoverProvider(name) },
+			})
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	app.watchdogCancel = cancel
+	app.watchdog.Start(ctx)
+
+	app.logInfo("OTHER_INIT", fmt.Sprintf("Watchdog initialized (enabled: %v, interval: %s)",
+		app.config.Watchdog.Enabled, app.config.Watchdog.Interval))
+}
+
+// recoverVectorDB reconnects every Qdrant client after an outage and checks
+// the collection again, since Qdrant may have come back with other data
+func (app *CLIApplication) recoverVectorDB(ctx context.Context) error {
+	for _, client := range []*vectordb.QdrantClient{app.vectorDB, app.depsVectorDB, app.summaryVectorDB, app.memoryVectorDB} {
+		if client == nil {
+			continue
+		}
+		if err := client.Reconnect(); err != nil {
+			return err
+		}
+	}
+	app.checkEmbeddingDrift(ctx)
+	return nil
+}
+
+// logTransition records a subsystem changing state in the step log
+func (app *CLIApplication) logTransition(change watchdog.Transition) {
+	if change.To == watchdog.StateHealthy {
+		app.logSuccess("WATCHDOG", fmt.Sprintf("%s recovered (%s -> %s)", change.Name, change.From, change.To))
+		return
+	}
+	app.logWarning("WATCHDOG", fmt.Sprintf("%s %s -> %s: %v", change.Name, change.From, change.To, change.Err))
+}
+
+// SubsystemHealth reports what the watchdog knows about each subsystem
+func (app *CLIApplication) SubsystemHealth() []watchdog.Status {
+	return app.watchdog.Status()
+}
@@ -0,0 +1,19 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/yourusername/useq-ai-assistant/internal/deps"
+	"github.com/yourusername/useq-ai-assistant/internal/wiring"
+)
+
+// WiringGraph maps the project's constructors, what each depends on and
+// where each is called
+func (app *CLIApplication) WiringGraph() (*wiring.Graph, error) {
+	module, err := deps.ModulePath(filepath.Join(app.config.ProjectRoot, "go.mod"))
+	if err != nil {
+		return nil, fmt.Errorf("constructor wiring needs a Go module: %w", err)
+	}
+	return wiring.Analyze(app.config.ProjectRoot, module)
+}
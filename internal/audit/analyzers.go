@@ -0,0 +1,568 @@
+package audit
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/internal/semtags"
+)
+
+// ComplexityAnalyzer reports functions whose cyclomatic complexity reaches
+// the threshold; twice the threshold is high severity. Hotspots carry the
+// semantic tags of their function and, when Tags is set, are only reported
+// with one of them.
+type ComplexityAnalyzer struct {
+	Threshold int
+	Tags      []string
+}
+
+// Name returns the analyzer name
+func (a *ComplexityAnalyzer) Name() string { return CategoryComplexity }
+
+// Analyze reports the complexity hotspots in pkg
+func (a *ComplexityAnalyzer) Analyze(pkg *Package) []Finding {
+	threshold := a.Threshold
+	if threshold <= 0 {
+		threshold = DefaultConfig().ComplexityThreshold
+	}
+	var findings []Finding
+	for _, file := range pkg.Sources() {
+		for _, decl := range file.AST.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil {
+				continue
+			}
+			complexity := Complexity(fn)
+			if complexity < threshold {
+				continue
+			}
+			tags := semtags.Classify(pkg.Text(file, fn), 0)
+			if !hasAnyTag(tags, a.Tags) {
+				continue
+			}
+			severity := SeverityMedium
+			if complexity >= 2*threshold {
+				severity = SeverityHigh
+			}
+			lines := pkg.Line(fn.End()) - pkg.Line(fn.Pos()) + 1
+			findings = append(findings, Finding{
+				Category: CategoryComplexity,
+				Severity: severity,
+				File:     file.Path,
+				Line:     pkg.Line(fn.Pos()),
+				Symbol:   funcName(fn),
+				Title:    fmt.Sprintf("Cyclomatic complexity %d", complexity),
+				Detail:   fmt.Sprintf("%d lines; split the branches into smaller functions (threshold %d)", lines, threshold),
+				Tags:     tags,
+			})
+		}
+	}
+	return findings
+}
+
+// hasAnyTag reports whether tags includes one of wanted; no wanted tags
+// match everything
+func hasAnyTag(tags, wanted []string) bool {
+	if len(wanted) == 0 {
+		return true
+	}
+	for _, tag := range tags {
+		for _, want := range wanted {
+			if tag == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Complexity returns the cyclomatic complexity of a function: one plus each
+// branch point and short-circuit operator
+func Complexity(fn *ast.FuncDecl) int {
+	complexity := 1
+	ast.Inspect(fn.Body, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt:
+			complexity++
+		case *ast.CaseClause:
+			if n.List != nil {
+				complexity++
+			}
+		case *ast.CommClause:
+			if n.Comm != nil {
+				complexity++
+			}
+		case *ast.BinaryExpr:
+			if n.Op == token.LAND || n.Op == token.LOR {
+				complexity++
+			}
+		case *ast.FuncLit:
+			// Closures are counted on their own merits, not their parent's
+			return false
+		}
+		return true
+	})
+	return complexity
+}
+
+// secretNamePattern matches identifiers that suggest a credential
+var secretNamePattern = regexp.MustCompile(`(?i)(password|passwd|secret|api_?key|access_?key|auth_?token|private_?key)`)
+
+// sqlMethods are the database/sql calls whose query argument is checked
+var sqlMethods = map[string]int{
+	"Query": 0, "QueryRow": 0, "Exec": 0, "Prepare": 0,
+	"QueryContext": 1, "QueryRowContext": 1, "ExecContext": 1, "PrepareContext": 1,
+}
+
+// weakCryptoImports are packages unsuitable for security use
+var weakCryptoImports = map[string]string{
+	"crypto/md5":  SeverityLow,
+	"crypto/sha1": SeverityLow,
+	"crypto/des":  SeverityMedium,
+	"crypto/rc4":  SeverityMedium,
+}
+
+// SecurityAnalyzer reports shell execution, SQL built from strings, disabled
+// TLS verification, hardcoded credentials and weak crypto
+type SecurityAnalyzer struct{}
+
+// Name returns the analyzer name
+func (a *SecurityAnalyzer) Name() string { return CategorySecurity }
+
+// Analyze reports the security findings in pkg
+func (a *SecurityAnalyzer) Analyze(pkg *Package) []Finding {
+	constants := packageConstants(pkg)
+	var findings []Finding
+	for _, file := range pkg.Sources() {
+		add := func(node ast.Node, severity, title, detail string) {
+			findings = append(findings, Finding{
+				Category: CategorySecurity,
+				Severity: severity,
+				File:     file.Path,
+				Line:     pkg.Line(node.Pos()),
+				Symbol:   enclosingFunc(file.AST, node.Pos()),
+				Title:    title,
+				Detail:   detail,
+			})
+		}
+
+		for _, spec := range file.AST.Imports {
+			path, _ := strconv.Unquote(spec.Path.Value)
+			if severity, weak := weakCryptoImports[path]; weak {
+				add(spec, severity, "Weak cryptography: "+path, "use crypto/sha256 or stronger where the result protects anything")
+			}
+		}
+
+		ast.Inspect(file.AST, func(node ast.Node) bool {
+			switch n := node.(type) {
+			case *ast.CallExpr:
+				a.checkCall(n, constants, add)
+			case *ast.KeyValueExpr:
+				if key, ok := n.Key.(*ast.Ident); ok {
+					if key.Name == "InsecureSkipVerify" && isTrue(n.Value) {
+						add(n, SeverityHigh, "TLS certificate verification disabled", "InsecureSkipVerify: true accepts any certificate")
+					}
+					if secretNamePattern.MatchString(key.Name) && isCredentialLiteral(n.Value) {
+						add(n, SeverityHigh, "Hardcoded credential in "+key.Name, "load secrets from the environment or a secret store")
+					}
+				}
+			case *ast.ValueSpec:
+				for i, name := range n.Names {
+					if i < len(n.Values) && secretNamePattern.MatchString(name.Name) && isCredentialLiteral(n.Values[i]) {
+						add(n, SeverityHigh, "Hardcoded credential in "+name.Name, "load secrets from the environment or a secret store")
+					}
+				}
+			case *ast.AssignStmt:
+				for i, lhs := range n.Lhs {
+					if ident, ok := lhs.(*ast.Ident); ok && i < len(n.Rhs) &&
+						secretNamePattern.MatchString(ident.Name) && isCredentialLiteral(n.Rhs[i]) {
+						add(n, SeverityHigh, "Hardcoded credential in "+ident.Name, "load secrets from the environment or a secret store")
+					}
+				}
+			}
+			return true
+		})
+	}
+	return findings
+}
+
+// checkCall reports shell execution and SQL built from strings
+func (a *SecurityAnalyzer) checkCall(call *ast.CallExpr, constants map[string]bool, add func(ast.Node, string, string, string)) {
+	selector, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return
+	}
+	name := selector.Sel.Name
+	if pkg, ok := selector.X.(*ast.Ident); ok && pkg.Name == "exec" && (name == "Command" || name == "CommandContext") {
+		args := call.Args
+		if name == "CommandContext" && len(args) > 0 {
+			args = args[1:]
+		}
+		if len(args) == 0 {
+			return
+		}
+		command, literal := stringLiteral(args[0])
+		switch {
+		case !literal:
+			add(call, SeverityMedium, "Command name is not a constant", "check that callers cannot choose the program that runs")
+		case (command == "sh" || command == "bash") && len(args) > 2:
+			if _, constant := stringLiteral(args[2]); !constant {
+				add(call, SeverityHigh, "Shell command built at runtime", "run the program directly with separate arguments instead of sh -c")
+			}
+		}
+		return
+	}
+
+	index, isSQL := sqlMethods[name]
+	if !isSQL || len(call.Args) <= index {
+		return
+	}
+	if builtFromStrings(call.Args[index], constants) {
+		add(call, SeverityHigh, "SQL built by string formatting in "+name, "pass values as ? placeholders so they cannot change the statement")
+	}
+}
+
+// builtFromStrings reports whether an expression concatenates or formats a
+// non-constant value into a string
+func builtFromStrings(expr ast.Expr, constants map[string]bool) bool {
+	switch e := expr.(type) {
+	case *ast.BinaryExpr:
+		return e.Op == token.ADD && !isConstantString(e, constants)
+	case *ast.CallExpr:
+		if selector, ok := e.Fun.(*ast.SelectorExpr); ok {
+			if pkg, ok := selector.X.(*ast.Ident); ok && pkg.Name == "fmt" && selector.Sel.Name == "Sprintf" {
+				return len(e.Args) > 1
+			}
+		}
+	}
+	return false
+}
+
+// isConstantString reports whether expr is built only from string literals
+// and package constants
+func isConstantString(expr ast.Expr, constants map[string]bool) bool {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		return e.Kind == token.STRING
+	case *ast.Ident:
+		return constants[e.Name]
+	case *ast.ParenExpr:
+		return isConstantString(e.X, constants)
+	case *ast.BinaryExpr:
+		return e.Op == token.ADD && isConstantString(e.X, constants) && isConstantString(e.Y, constants)
+	}
+	return false
+}
+
+// packageConstants returns the names of the package-level constants
+func packageConstants(pkg *Package) map[string]bool {
+	constants := make(map[string]bool)
+	for _, file := range pkg.Files {
+		for _, decl := range file.AST.Decls {
+			if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.CONST {
+				for _, spec := range gen.Specs {
+					for _, name := range spec.(*ast.ValueSpec).Names {
+						constants[name.Name] = true
+					}
+				}
+			}
+		}
+	}
+	return constants
+}
+
+// OutdatedAnalyzer reports deprecated packages and APIs with a modern
+// replacement
+type OutdatedAnalyzer struct{}
+
+// outdatedImports maps deprecated imports to their replacement
+var outdatedImports = map[string]string{
+	"io/ioutil":                  "use the io and os equivalents (deprecated since Go 1.16)",
+	"golang.org/x/net/context":   "use the standard library context package",
+	"github.com/pkg/errors":      "use fmt.Errorf with %w and errors.Is/As",
+	"github.com/golang/protobuf": "use google.golang.org/protobuf",
+}
+
+// outdatedCalls maps deprecated calls to their replacement
+var outdatedCalls = map[string]string{
+	"strings.Title": "use golang.org/x/text/cases (deprecated since Go 1.18)",
+	"rand.Seed":     "the global source is seeded automatically since Go 1.20",
+	"rand.Read":     "math/rand.Read is deprecated; use crypto/rand.Read",
+}
+
+// Name returns the analyzer name
+func (a *OutdatedAnalyzer) Name() string { return CategoryOutdated }
+
+// Analyze reports the outdated patterns in pkg
+func (a *OutdatedAnalyzer) Analyze(pkg *Package) []Finding {
+	var findings []Finding
+	for _, file := range pkg.Sources() {
+		add := func(node ast.Node, title, detail string) {
+			findings = append(findings, Finding{
+				Category: CategoryOutdated,
+				Severity: SeverityLow,
+				File:     file.Path,
+				Line:     pkg.Line(node.Pos()),
+				Symbol:   enclosingFunc(file.AST, node.Pos()),
+				Title:    title,
+				Detail:   detail,
+			})
+		}
+
+		mathRand := false
+		for _, spec := range file.AST.Imports {
+			path, _ := strconv.Unquote(spec.Path.Value)
+			if replacement, ok := outdatedImports[path]; ok {
+				add(spec, "Deprecated import "+path, replacement)
+			}
+			mathRand = mathRand || path == "math/rand"
+		}
+		for _, group := range file.AST.Comments {
+			for _, comment := range group.List {
+				if strings.HasPrefix(comment.Text, "// +build") {
+					add(comment, "Old build constraint syntax", "use //go:build (go fix adds it)")
+				}
+			}
+		}
+
+		ast.Inspect(file.AST, func(node ast.Node) bool {
+			call, ok := node.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			name := callName(call)
+			if replacement, ok := outdatedCalls[name]; ok && (!strings.HasPrefix(name, "rand.") || mathRand) {
+				add(call, "Deprecated call "+name, replacement)
+			}
+			if name == "errors.New" && len(call.Args) == 1 && callName(asCall(call.Args[0])) == "fmt.Sprintf" {
+				add(call, "errors.New(fmt.Sprintf(...))", "use fmt.Errorf")
+			}
+			return true
+		})
+	}
+	return findings
+}
+
+// DeadCodeAnalyzer reports unexported functions and types nothing in their
+// package refers to. Exported identifiers may be used by other modules and
+// are not reported.
+type DeadCodeAnalyzer struct{}
+
+// Name returns the analyzer name
+func (a *DeadCodeAnalyzer) Name() string { return CategoryDeadCode }
+
+// Analyze reports the unused declarations in pkg
+func (a *DeadCodeAnalyzer) Analyze(pkg *Package) []Finding {
+	type declaration struct {
+		file *File
+		node ast.Node
+		kind string
+	}
+	declared := make(map[string]declaration)
+	declIdents := make(map[*ast.Ident]bool)
+	for _, file := range pkg.Sources() {
+		for _, decl := range file.AST.Decls {
+			switch d := decl.(type) {
+			case *ast.FuncDecl:
+				name := d.Name.Name
+				if d.Recv != nil || ast.IsExported(name) || name == "init" || name == "main" || name == "_" {
+					continue
+				}
+				declared[name] = declaration{file: file, node: d, kind: "function"}
+				declIdents[d.Name] = true
+			case *ast.GenDecl:
+				if d.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range d.Specs {
+					typeSpec := spec.(*ast.TypeSpec)
+					if !ast.IsExported(typeSpec.Name.Name) {
+						declared[typeSpec.Name.Name] = declaration{file: file, node: typeSpec, kind: "type"}
+						declIdents[typeSpec.Name] = true
+					}
+				}
+			}
+		}
+	}
+	if len(declared) == 0 {
+		return nil
+	}
+
+	// Uses in test files count: a helper only tests call is still used
+	used := make(map[string]bool)
+	for _, file := range pkg.Files {
+		ast.Inspect(file.AST, func(node ast.Node) bool {
+			if ident, ok := node.(*ast.Ident); ok && !declIdents[ident] {
+				used[ident.Name] = true
+			}
+			return true
+		})
+	}
+
+	var findings []Finding
+	for name, decl := range declared {
+		if used[name] {
+			continue
+		}
+		findings = append(findings, Finding{
+			Category: CategoryDeadCode,
+			Severity: SeverityLow,
+			File:     decl.file.Path,
+			Line:     pkg.Line(decl.node.Pos()),
+			Symbol:   name,
+			Title:    fmt.Sprintf("Unused %s %s", decl.kind, name),
+			Detail:   "nothing in the package refers to it; delete it or wire it in",
+		})
+	}
+	return findings
+}
+
+// MissingTestsAnalyzer reports packages without tests, and in tested
+// packages, complex exported functions no test mentions
+type MissingTestsAnalyzer struct {
+	ComplexityThreshold int
+}
+
+// Name returns the analyzer name
+func (a *MissingTestsAnalyzer) Name() string { return CategoryTests }
+
+// Analyze reports the missing tests in pkg
+func (a *MissingTestsAnalyzer) Analyze(pkg *Package) []Finding {
+	var exported []*ast.FuncDecl
+	files := make(map[*ast.FuncDecl]*File)
+	mainPackage := false
+	for _, file := range pkg.Sources() {
+		mainPackage = mainPackage || file.AST.Name.Name == "main"
+		for _, decl := range file.AST.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Body != nil && fn.Name.IsExported() {
+				exported = append(exported, fn)
+				files[fn] = file
+			}
+		}
+	}
+	if len(exported) == 0 || mainPackage {
+		return nil
+	}
+
+	if !pkg.HasTests() {
+		return []Finding{{
+			Category: CategoryTests,
+			Severity: SeverityMedium,
+			File:     pkg.Dir,
+			Title:    "Package has no tests",
+			Detail:   fmt.Sprintf("%d exported functions are untested", len(exported)),
+		}}
+	}
+
+	var tested strings.Builder
+	for _, file := range pkg.Files {
+		if file.Test {
+			tested.Write(file.Source)
+		}
+	}
+	threshold := max(a.ComplexityThreshold/2, 5)
+	testSource := tested.String()
+	var findings []Finding
+	for _, fn := range exported {
+		if complexity := Complexity(fn); complexity >= threshold && !strings.Contains(testSource, fn.Name.Name) {
+			findings = append(findings, Finding{
+				Category: CategoryTests,
+				Severity: SeverityLow,
+				File:     files[fn].Path,
+				Line:     pkg.Line(fn.Pos()),
+				Symbol:   funcName(fn),
+				Title:    "Complex function not mentioned by any test",
+				Detail:   fmt.Sprintf("cyclomatic complexity %d", complexity),
+			})
+		}
+	}
+	return findings
+}
+
+// funcName returns Name or Receiver.Name
+func funcName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return fn.Name.Name
+	}
+	receiver := fn.Recv.List[0].Type
+	if star, ok := receiver.(*ast.StarExpr); ok {
+		receiver = star.X
+	}
+	if index, ok := receiver.(*ast.IndexExpr); ok {
+		receiver = index.X
+	}
+	if ident, ok := receiver.(*ast.Ident); ok {
+		return ident.Name + "." + fn.Name.Name
+	}
+	return fn.Name.Name
+}
+
+// enclosingFunc names the function declaring pos, or ""
+func enclosingFunc(file *ast.File, pos token.Pos) string {
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Pos() <= pos && pos <= fn.End() {
+			return funcName(fn)
+		}
+	}
+	return ""
+}
+
+// callName returns pkg.Func for a selector call, or ""
+func callName(call *ast.CallExpr) string {
+	if call == nil {
+		return ""
+	}
+	selector, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+	pkg, ok := selector.X.(*ast.Ident)
+	if !ok {
+		return ""
+	}
+	return pkg.Name + "." + selector.Sel.Name
+}
+
+func asCall(expr ast.Expr) *ast.CallExpr {
+	call, _ := expr.(*ast.CallExpr)
+	return call
+}
+
+// stringLiteral returns the value of a string literal
+func stringLiteral(expr ast.Expr) (string, bool) {
+	literal, ok := expr.(*ast.BasicLit)
+	if !ok || literal.Kind != token.STRING {
+		return "", false
+	}
+	value, err := strconv.Unquote(literal.Value)
+	return value, err == nil
+}
+
+func isTrue(expr ast.Expr) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Name == "true"
+}
+
+// envVarName matches the name of an environment variable, such as
+// OPENAI_API_KEY, which is a reference to a secret rather than one
+var envVarName = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+// isCredentialLiteral reports whether expr is a string literal that looks
+// like a real credential rather than a placeholder, key name or message
+func isCredentialLiteral(expr ast.Expr) bool {
+	value, ok := stringLiteral(expr)
+	if !ok || len(value) < 8 || strings.ContainsAny(value, " ${}<>/") || envVarName.MatchString(value) {
+		return false
+	}
+	lower := strings.ToLower(value)
+	for _, placeholder := range []string{"example", "changeme", "your_", "xxx", "placeholder", "test"} {
+		if strings.Contains(lower, placeholder) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,259 @@
+// Package audit analyzes the indexed codebase package by package for
+// complexity hotspots, security issues, dead code, missing tests, outdated
+// patterns and inconsistent error handling, and renders prioritized reports.
+package audit
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Finding categories
+const (
+	CategoryComplexity    = "complexity"
+	CategorySecurity      = "security"
+	CategoryDeadCode      = "dead_code"
+	CategoryTests         = "missing_tests"
+	CategoryOutdated      = "outdated"
+	CategoryErrorHandling = "error_handling"
+	CategoryPolicy        = "policy" // breaks one of the team's policies
+)
+
+// Severities, highest first
+const (
+	SeverityHigh   = "high"
+	SeverityMedium = "medium"
+	SeverityLow    = "low"
+)
+
+// Finding is one issue found by an analyzer
+type Finding struct {
+	Category string   `json:"category"`
+	Severity string   `json:"severity"`
+	File     string   `json:"file"` // relative to the project root; a directory for package findings
+	Line     int      `json:"line,omitempty"`
+	Symbol   string   `json:"symbol,omitempty"`
+	Title    string   `json:"title"`
+	Detail   string   `json:"detail,omitempty"`
+	Tags     []string `json:"tags,omitempty"` // semantic tags of the code: auth, db, http, concurrency...
+	Priority float64  `json:"priority"`       // set when the report is built
+}
+
+// Location formats the finding position as file:line
+func (f *Finding) Location() string {
+	if f.Line > 0 {
+		return fmt.Sprintf("%s:%d", f.File, f.Line)
+	}
+	return f.File
+}
+
+// File is a parsed Go source file
+type File struct {
+	Path   string // relative to the project root
+	AST    *ast.File
+	Source []byte
+	Test   bool
+}
+
+// Package is the batch analyzers work on: the Go files of one directory
+type Package struct {
+	Dir   string // relative to the project root
+	Fset  *token.FileSet
+	Files []*File
+}
+
+// Sources returns the non-test files
+func (p *Package) Sources() []*File {
+	var sources []*File
+	for _, file := range p.Files {
+		if !file.Test {
+			sources = append(sources, file)
+		}
+	}
+	return sources
+}
+
+// HasTests reports whether the package has test files
+func (p *Package) HasTests() bool {
+	return len(p.Sources()) < len(p.Files)
+}
+
+// Line returns the line of pos
+func (p *Package) Line(pos token.Pos) int {
+	return p.Fset.Position(pos).Line
+}
+
+// Text returns the source of node in file
+func (p *Package) Text(file *File, node ast.Node) string {
+	start, end := p.Fset.Position(node.Pos()).Offset, p.Fset.Position(node.End()).Offset
+	if start < 0 || end > len(file.Source) || start > end {
+		return ""
+	}
+	return string(file.Source[start:end])
+}
+
+// Analyzer finds one kind of issue in a package
+type Analyzer interface {
+	Name() string
+	Analyze(pkg *Package) []Finding
+}
+
+// Config controls the audit and its report
+type Config struct {
+	OutputDir           string   `json:"output_dir"`
+	Format              string   `json:"format"`               // markdown, html or both
+	LinkBase            string   `json:"link_base"`            // e.g. https://github.com/org/repo/blob/main/; empty links relative to the report
+	ComplexityThreshold int      `json:"complexity_threshold"` // cyclomatic complexity reported as a hotspot
+	TopFindings         int      `json:"top_findings"`         // findings in the prioritized summary
+	HotspotTags         []string `json:"hotspot_tags"`         // only report hotspots with one of these semantic tags; empty reports all
+}
+
+// DefaultConfig returns the audit defaults
+func DefaultConfig() Config {
+	return Config{
+		OutputDir:           "reports",
+		Format:              "markdown",
+		ComplexityThreshold: 15,
+		TopFindings:         20,
+	}
+}
+
+// Batch is the indexed files of one package directory
+type Batch struct {
+	Dir   string   `json:"dir"`
+	Files []string `json:"files"`
+}
+
+// GroupByPackage groups indexed Go files into one batch per directory, in
+// directory order. Other languages are not analyzed.
+func GroupByPackage(root string, files []string) []Batch {
+	byDir := make(map[string][]string)
+	for _, path := range files {
+		if !strings.HasSuffix(path, ".go") {
+			continue
+		}
+		rel := RelativePath(root, path)
+		dir := filepath.ToSlash(filepath.Dir(rel))
+		byDir[dir] = append(byDir[dir], rel)
+	}
+	batches := make([]Batch, 0, len(byDir))
+	for dir, paths := range byDir {
+		sort.Strings(paths)
+		batches = append(batches, Batch{Dir: dir, Files: paths})
+	}
+	sort.Slice(batches, func(i, j int) bool { return batches[i].Dir < batches[j].Dir })
+	return batches
+}
+
+// Auditor runs analyzers over batches of the project
+type Auditor struct {
+	root      string
+	analyzers []Analyzer
+}
+
+// NewAuditor creates an auditor with every analyzer
+func NewAuditor(root string, config Config) *Auditor {
+	return &Auditor{
+		root: root,
+		analyzers: []Analyzer{
+			&ComplexityAnalyzer{Threshold: config.ComplexityThreshold, Tags: config.HotspotTags},
+			&SecurityAnalyzer{},
+			&DeadCodeAnalyzer{},
+			&MissingTestsAnalyzer{ComplexityThreshold: config.ComplexityThreshold},
+			&OutdatedAnalyzer{},
+			&ErrorHandlingAnalyzer{},
+		},
+	}
+}
+
+// Add runs another analyzer on every batch, such as one that needs
+// information from outside the batch
+func (a *Auditor) Add(analyzer Analyzer) {
+	a.analyzers = append(a.analyzers, analyzer)
+}
+
+// AnalyzeBatch parses a batch, with the test files in its directory whether
+// indexed or not, and runs every analyzer on it
+func (a *Auditor) AnalyzeBatch(batch Batch) ([]Finding, error) {
+	pkg, err := a.load(batch)
+	if err != nil {
+		return nil, err
+	}
+	var findings []Finding
+	for _, analyzer := range a.analyzers {
+		findings = append(findings, analyzer.Analyze(pkg)...)
+	}
+	return findings, nil
+}
+
+// load parses the batch files; files that no longer parse are skipped
+func (a *Auditor) load(batch Batch) (*Package, error) {
+	pkg := &Package{Dir: batch.Dir, Fset: token.NewFileSet()}
+	paths := append([]string{}, batch.Files...)
+	seen := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		seen[path] = true
+	}
+	tests, _ := filepath.Glob(filepath.Join(a.root, batch.Dir, "*_test.go"))
+	for _, test := range tests {
+		if rel := RelativePath(a.root, test); !seen[rel] {
+			paths = append(paths, rel)
+		}
+	}
+
+	for _, path := range paths {
+		source, err := os.ReadFile(filepath.Join(a.root, path))
+		if err != nil {
+			continue
+		}
+		parsed, err := parser.ParseFile(pkg.Fset, path, source, parser.ParseComments)
+		if err != nil {
+			continue
+		}
+		pkg.Files = append(pkg.Files, &File{
+			Path:   path,
+			AST:    parsed,
+			Source: source,
+			Test:   strings.HasSuffix(path, "_test.go"),
+		})
+	}
+	if len(pkg.Files) == 0 {
+		return nil, fmt.Errorf("no parsable Go files in %s", batch.Dir)
+	}
+	return pkg, nil
+}
+
+// ParseSource parses a single snippet of Go code for analysis outside the
+// project, adding a package clause when the snippet has none. The clause
+// goes on the snippet's first line, so findings keep the snippet's line
+// numbers.
+func ParseSource(name, code string) (*Package, error) {
+	pkg := &Package{Dir: ".", Fset: token.NewFileSet()}
+	parsed, err := parser.ParseFile(pkg.Fset, name, code, parser.ParseComments)
+	if err != nil {
+		code = "package snippet; " + code
+		parsed, err = parser.ParseFile(pkg.Fset, name, code, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+	}
+	pkg.Files = []*File{{Path: name, AST: parsed, Source: []byte(code)}}
+	return pkg, nil
+}
+
+// RelativePath makes path relative to root when it is inside it, in the
+// form findings use
+func RelativePath(root, path string) string {
+	if filepath.IsAbs(path) && root != "" {
+		if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+			path = rel
+		}
+	}
+	return filepath.ToSlash(filepath.Clean(path))
+}
@@ -0,0 +1,306 @@
+package audit
+
+import (
+	"fmt"
+	"go/ast"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Error-handling styles. Wrapping styles add context to an error from a
+// call; creation styles make a new one.
+const (
+	ErrorStyleWrapVerb   = "fmt.Errorf %w"     // fmt.Errorf("...: %w", err)
+	ErrorStyleWrapFormat = "fmt.Errorf %v"     // fmt.Errorf("...: %v", err), which loses the chain
+	ErrorStyleWrapPkg    = "errors.Wrap"       // github.com/pkg/errors Wrap, Wrapf and WithMessage
+	ErrorStyleNew        = "errors.New"        // errors.New("...")
+	ErrorStyleErrorf     = "fmt.Errorf"        // fmt.Errorf("...") with a constant message
+	ErrorStyleCustom     = "custom error type" // &NotFoundError{...}
+
+	// ErrorStyleFormatted is fmt.Errorf("bad id %d", id): counted, but a
+	// formatted message is not a style choice
+	ErrorStyleFormatted = "fmt.Errorf formatted"
+	// ErrorStyleSentinel is a package-level var ErrNotFound = errors.New(...),
+	// counted apart from errors made inside functions
+	ErrorStyleSentinel = "sentinel error"
+)
+
+// wrapStyles and createStyles are the styles a package is expected to pick
+// one of
+var (
+	wrapStyles   = []string{ErrorStyleWrapVerb, ErrorStyleWrapFormat, ErrorStyleWrapPkg}
+	createStyles = []string{ErrorStyleNew, ErrorStyleErrorf, ErrorStyleCustom}
+)
+
+// pkgErrorsPath is the import path of github.com/pkg/errors
+const pkgErrorsPath = "github.com/pkg/errors"
+
+// ErrorSite is one place a package wraps or creates an error
+type ErrorSite struct {
+	File  string `json:"file"` // relative to the project root
+	Line  int    `json:"line"`
+	Func  string `json:"func,omitempty"` // Name or Receiver.Name
+	Style string `json:"style"`
+	Code  string `json:"code"`
+}
+
+// ErrorInventory is how one package handles errors
+type ErrorInventory struct {
+	Dir         string         `json:"dir"`
+	Counts      map[string]int `json:"counts"`
+	CustomTypes []string       `json:"custom_types,omitempty"` // types with an Error() string method
+	Sites       []ErrorSite    `json:"sites"`
+	WrapStyle   string         `json:"wrap_style,omitempty"`   // dominant wrapping style
+	CreateStyle string         `json:"create_style,omitempty"` // dominant creation style
+}
+
+// Error style families
+const (
+	ErrorFamilyWrap   = "wrap"
+	ErrorFamilyCreate = "create"
+)
+
+// ErrorStyleFamily returns the family of style, or "" for the styles that
+// are not a choice
+func ErrorStyleFamily(style string) string {
+	for _, wrap := range wrapStyles {
+		if style == wrap {
+			return ErrorFamilyWrap
+		}
+	}
+	for _, create := range createStyles {
+		if style == create {
+			return ErrorFamilyCreate
+		}
+	}
+	return ""
+}
+
+// Dominant returns the package's style for the family style belongs to
+func (inv *ErrorInventory) Dominant(style string) string {
+	switch ErrorStyleFamily(style) {
+	case ErrorFamilyWrap:
+		return inv.WrapStyle
+	case ErrorFamilyCreate:
+		return inv.CreateStyle
+	}
+	return ""
+}
+
+// Inconsistent returns the sites that do not use the dominant style of
+// their family, in file order
+func (inv *ErrorInventory) Inconsistent() []ErrorSite {
+	var sites []ErrorSite
+	for _, site := range inv.Sites {
+		if dominant := inv.Dominant(site.Style); dominant != "" && site.Style != dominant {
+			sites = append(sites, site)
+		}
+	}
+	return sites
+}
+
+// InventoryErrors classifies every error wrapped or created in the non-test
+// files of pkg and picks the dominant style of each family. Formatted
+// messages that wrap nothing, such as fmt.Errorf("bad id %d", id), and
+// sentinel errors are counted but have no sites.
+func InventoryErrors(pkg *Package) *ErrorInventory {
+	inv := &ErrorInventory{Dir: pkg.Dir, Counts: make(map[string]int)}
+	customTypes := errorTypes(pkg)
+	for name := range customTypes {
+		inv.CustomTypes = append(inv.CustomTypes, name)
+	}
+	sort.Strings(inv.CustomTypes)
+
+	for _, file := range pkg.Sources() {
+		imports := importNames(file.AST)
+		ast.Inspect(file.AST, func(node ast.Node) bool {
+			style := ""
+			switch n := node.(type) {
+			case *ast.CallExpr:
+				style = callErrorStyle(n, imports)
+			case *ast.CompositeLit:
+				if ident, ok := n.Type.(*ast.Ident); ok && customTypes[ident.Name] {
+					style = ErrorStyleCustom
+				}
+			}
+			if style == "" {
+				return true
+			}
+			fn := enclosingFunc(file.AST, node.Pos())
+			if fn == "" {
+				style = ErrorStyleSentinel
+			}
+			inv.Counts[style]++
+			if style == ErrorStyleFormatted || style == ErrorStyleSentinel {
+				return true
+			}
+			inv.Sites = append(inv.Sites, ErrorSite{
+				File:  file.Path,
+				Line:  pkg.Line(node.Pos()),
+				Func:  fn,
+				Style: style,
+				Code:  sourceLine(file, pkg.Line(node.Pos())),
+			})
+			return true
+		})
+	}
+	inv.WrapStyle = dominantStyle(inv.Counts, wrapStyles)
+	inv.CreateStyle = dominantStyle(inv.Counts, createStyles)
+	return inv
+}
+
+// callErrorStyle classifies a call that wraps or creates an error, or
+// returns ""
+func callErrorStyle(call *ast.CallExpr, imports map[string]string) string {
+	selector, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+	pkg, ok := selector.X.(*ast.Ident)
+	if !ok {
+		return ""
+	}
+	switch path, name := imports[pkg.Name], selector.Sel.Name; {
+	case path == "fmt" && name == "Errorf" && len(call.Args) > 0:
+		format, _ := stringLiteral(call.Args[0])
+		switch {
+		case strings.Contains(format, "%w"):
+			return ErrorStyleWrapVerb
+		case wrapsError(call.Args[1:]):
+			return ErrorStyleWrapFormat
+		case len(call.Args) == 1:
+			return ErrorStyleErrorf
+		default:
+			return ErrorStyleFormatted
+		}
+	case path == pkgErrorsPath && (name == "Wrap" || name == "Wrapf" || name == "WithMessage" || name == "WithMessagef"):
+		return ErrorStyleWrapPkg
+	case (path == "errors" || path == pkgErrorsPath) && name == "New":
+		return ErrorStyleNew
+	}
+	return ""
+}
+
+// wrapsError reports whether a format argument is an error: err, an
+// identifier ending in Err, or err.Error()
+func wrapsError(args []ast.Expr) bool {
+	for _, arg := range args {
+		if call := asCall(arg); call != nil {
+			if selector, ok := call.Fun.(*ast.SelectorExpr); ok && selector.Sel.Name == "Error" && len(call.Args) == 0 {
+				arg = selector.X
+			}
+		}
+		if ident, ok := arg.(*ast.Ident); ok && (ident.Name == "err" || strings.HasSuffix(ident.Name, "Err")) {
+			return true
+		}
+	}
+	return false
+}
+
+// errorTypes returns the package's types with an Error() string method
+func errorTypes(pkg *Package) map[string]bool {
+	types := make(map[string]bool)
+	for _, file := range pkg.Sources() {
+		for _, decl := range file.AST.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv == nil || fn.Name.Name != "Error" || fn.Type.Params.NumFields() != 0 {
+				continue
+			}
+			results := fn.Type.Results
+			if results == nil || len(results.List) != 1 {
+				continue
+			}
+			if ident, ok := results.List[0].Type.(*ast.Ident); !ok || ident.Name != "string" {
+				continue
+			}
+			if name := funcName(fn); strings.Contains(name, ".") {
+				types[strings.SplitN(name, ".", 2)[0]] = true
+			}
+		}
+	}
+	return types
+}
+
+// importNames maps the names a file refers to its imports by to their paths
+func importNames(file *ast.File) map[string]string {
+	names := make(map[string]string)
+	for _, spec := range file.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := path[strings.LastIndex(path, "/")+1:]
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		names[name] = path
+	}
+	return names
+}
+
+// dominantStyle returns the most used of styles, or "" when none is used;
+// ties go to the earlier style
+func dominantStyle(counts map[string]int, styles []string) string {
+	best := ""
+	for _, style := range styles {
+		if counts[style] > 0 && (best == "" || counts[style] > counts[best]) {
+			best = style
+		}
+	}
+	return best
+}
+
+// sourceLine returns line of file, trimmed
+func sourceLine(file *File, line int) string {
+	lines := strings.Split(string(file.Source), "\n")
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+	return strings.TrimSpace(lines[line-1])
+}
+
+// ErrorHandlingAnalyzer reports errors wrapped or created in a different
+// style from the rest of their package
+type ErrorHandlingAnalyzer struct{}
+
+// Name returns the analyzer name
+func (a *ErrorHandlingAnalyzer) Name() string { return CategoryErrorHandling }
+
+// Analyze reports the sites in pkg that stray from its dominant styles
+func (a *ErrorHandlingAnalyzer) Analyze(pkg *Package) []Finding {
+	inv := InventoryErrors(pkg)
+	var findings []Finding
+	for _, site := range inv.Inconsistent() {
+		dominant := inv.Dominant(site.Style)
+		detail := fmt.Sprintf("the package uses %s %d times and %s %d times; %s", dominant, inv.Counts[dominant],
+			site.Style, inv.Counts[site.Style], site.Code)
+		severity := SeverityLow
+		switch site.Style {
+		case ErrorStyleWrapFormat:
+			// Formatting an error with %v hides it from errors.Is and errors.As
+			severity = SeverityMedium
+		case ErrorStyleCustom:
+			detail += "; callers may match it with errors.As, so keep it if they do"
+		}
+		findings = append(findings, Finding{
+			Category: CategoryErrorHandling,
+			Severity: severity,
+			File:     site.File,
+			Line:     site.Line,
+			Symbol:   site.Func,
+			Title:    fmt.Sprintf("%s where the package uses %s", site.Style, dominant),
+			Detail:   detail,
+		})
+	}
+	return findings
+}
+
+// InventoryBatch parses a batch and inventories its error handling
+func (a *Auditor) InventoryBatch(batch Batch) (*ErrorInventory, error) {
+	pkg, err := a.load(batch)
+	if err != nil {
+		return nil, err
+	}
+	return InventoryErrors(pkg), nil
+}
@@ -0,0 +1,456 @@
+package audit
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// severityWeights rank findings before file hotness is applied
+var severityWeights = map[string]float64{
+	SeverityHigh:   3,
+	SeverityMedium: 2,
+	SeverityLow:    1,
+}
+
+// categoryTitles are the report section headings, in report order
+var categoryTitles = []struct{ category, title string }{
+	{CategorySecurity, "Security findings"},
+	{CategoryPolicy, "Policy violations"},
+	{CategoryComplexity, "Complexity hotspots"},
+	{CategoryTests, "Missing tests"},
+	{CategoryDeadCode, "Dead code"},
+	{CategoryOutdated, "Outdated patterns"},
+	{CategoryErrorHandling, "Inconsistent error handling"},
+}
+
+// Report is a prioritized audit of the project
+type Report struct {
+	Root      string    `json:"root"`
+	CreatedAt time.Time `json:"created_at"`
+	Packages  int       `json:"packages"`
+	Files     int       `json:"files"`
+	Skipped   []string  `json:"skipped,omitempty"` // packages that could not be parsed
+	Findings  []Finding `json:"findings"`          // highest priority first
+
+	// LoadBearing is the code answers cited most, most cited first
+	LoadBearing []LoadBearingChunk `json:"load_bearing,omitempty"`
+
+	// Policies is how each of the team's policies fared
+	Policies []PolicyStatus `json:"policies,omitempty"`
+
+	config Config
+}
+
+// LoadBearingChunk is a chunk of code answers keep citing, so findings in
+// its file affect the most answers
+type LoadBearingChunk struct {
+	File      string `json:"file"` // relative to the project root
+	Line      int    `json:"line"`
+	Symbol    string `json:"symbol,omitempty"`
+	Cited     int    `json:"cited"`
+	Retrieved int    `json:"retrieved"`
+}
+
+// PolicyStatus is whether the code follows one of the team's policies
+type PolicyStatus struct {
+	Name       string `json:"name"`
+	Rule       string `json:"rule"`
+	Passed     bool   `json:"passed"`
+	Checked    int    `json:"checked"`    // functions judged
+	Applicable int    `json:"applicable"` // functions the rule applied to
+	Violations int    `json:"violations"`
+	Unchecked  int    `json:"unchecked"` // functions covered but not judged
+	Error      string `json:"error,omitempty"`
+}
+
+// verdict describes the policy's outcome for a report
+func (s PolicyStatus) verdict() string {
+	switch {
+	case s.Error != "" && s.Checked == 0:
+		return "not checked: " + s.Error
+	case !s.Passed:
+		return fmt.Sprintf("fail (%d violations)", s.Violations)
+	case s.Unchecked > 0:
+		return fmt.Sprintf("pass (%d not checked)", s.Unchecked)
+	}
+	return "pass"
+}
+
+// NewReport prioritizes findings: severity weight, raised by up to half
+// again for the files worked on most. hotness maps files to their access
+// score and may be nil.
+func NewReport(root string, config Config, packages, files int, findings []Finding, hotness map[string]float64) *Report {
+	hottest := 0.0
+	for _, score := range hotness {
+		hottest = max(hottest, score)
+	}
+	for i := range findings {
+		finding := &findings[i]
+		finding.Priority = severityWeights[finding.Severity]
+		if hottest > 0 {
+			finding.Priority *= 1 + 0.5*hotness[finding.File]/hottest
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Priority != findings[j].Priority {
+			return findings[i].Priority > findings[j].Priority
+		}
+		if findings[i].File != findings[j].File {
+			return findings[i].File < findings[j].File
+		}
+		return findings[i].Line < findings[j].Line
+	})
+	return &Report{
+		Root:      root,
+		CreatedAt: time.Now(),
+		Packages:  packages,
+		Files:     files,
+		Findings:  findings,
+		config:    config,
+	}
+}
+
+// Count returns how many findings have a category and severity; empty
+// arguments match all
+func (r *Report) Count(category, severity string) int {
+	count := 0
+	for _, finding := range r.Findings {
+		if (category == "" || finding.Category == category) && (severity == "" || finding.Severity == severity) {
+			count++
+		}
+	}
+	return count
+}
+
+// Write renders the report in the configured formats under the output
+// directory and returns the files written
+func (r *Report) Write() ([]string, error) {
+	dir := r.config.OutputDir
+	if dir == "" {
+		dir = DefaultConfig().OutputDir
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create report directory: %w", err)
+	}
+	base := filepath.Join(dir, "audit-"+r.CreatedAt.Format("2006-01-02-150405"))
+
+	var formats []string
+	switch r.config.Format {
+	case "html":
+		formats = []string{"html"}
+	case "both":
+		formats = []string{"md", "html"}
+	default:
+		formats = []string{"md"}
+	}
+
+	var written []string
+	for _, format := range formats {
+		path := base + "." + format
+		file, err := os.Create(path)
+		if err != nil {
+			return written, fmt.Errorf("failed to create report: %w", err)
+		}
+		if format == "html" {
+			err = r.WriteHTML(file, dir)
+		} else {
+			err = r.WriteMarkdown(file, dir)
+		}
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return written, fmt.Errorf("failed to write report: %w", err)
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+// Link returns the deep link to a finding: under LinkBase when set,
+// otherwise relative to the report directory
+func (r *Report) Link(finding Finding, reportDir string) string {
+	target := filepath.ToSlash(finding.File)
+	if r.config.LinkBase != "" {
+		target = strings.TrimSuffix(r.config.LinkBase, "/") + "/" + target
+	} else if absReport, err := filepath.Abs(reportDir); err == nil {
+		if rel, err := filepath.Rel(absReport, filepath.Join(r.Root, finding.File)); err == nil {
+			target = filepath.ToSlash(rel)
+		}
+	}
+	if finding.Line > 0 {
+		target += fmt.Sprintf("#L%d", finding.Line)
+	}
+	return target
+}
+
+// WriteMarkdown renders the report as markdown, with links relative to
+// reportDir
+func (r *Report) WriteMarkdown(w io.Writer, reportDir string) error {
+	var b strings.Builder
+	b.WriteString("# Code audit\n\n")
+	b.WriteString(fmt.Sprintf("%s · %d packages · %d files · %d findings (%d high, %d medium, %d low)\n\n",
+		r.CreatedAt.Format("2006-01-02 15:04"), r.Packages, r.Files, len(r.Findings),
+		r.Count("", SeverityHigh), r.Count("", SeverityMedium), r.Count("", SeverityLow)))
+
+	b.WriteString("| Category | High | Medium | Low |\n|---|---:|---:|---:|\n")
+	for _, section := range categoryTitles {
+		b.WriteString(fmt.Sprintf("| %s | %d | %d | %d |\n", section.title,
+			r.Count(section.category, SeverityHigh), r.Count(section.category, SeverityMedium), r.Count(section.category, SeverityLow)))
+	}
+
+	if len(r.Policies) > 0 {
+		b.WriteString("\n## Policies\n\n| Policy | Rule | Result | Applied to |\n|---|---|---|---:|\n")
+		for _, status := range r.Policies {
+			b.WriteString(fmt.Sprintf("| %s | %s | %s | %d of %d |\n", markdownEscape(status.Name), markdownEscape(status.Rule),
+				markdownEscape(status.verdict()), status.Applicable, status.Checked))
+		}
+	}
+
+	if top := r.top(); len(top) > 0 {
+		b.WriteString("\n## Top priorities\n\n")
+		for i, finding := range top {
+			b.WriteString(fmt.Sprintf("%d. **%s** %s — [%s](%s)%s\n", i+1, finding.Severity, markdownEscape(finding.Title),
+				finding.Location(), r.Link(finding, reportDir), symbolSuffix(finding)))
+		}
+	}
+
+	if len(r.LoadBearing) > 0 {
+		b.WriteString("\n## Most load-bearing code\n\nThe code answers cite most; findings in these files affect the most answers.\n\n")
+		b.WriteString("| Code | Cited | Retrieved | Findings in file |\n|---|---:|---:|---:|\n")
+		for _, chunk := range r.LoadBearing {
+			location := Finding{File: chunk.File, Line: chunk.Line, Symbol: chunk.Symbol}
+			b.WriteString(fmt.Sprintf("| [%s](%s)%s | %d | %d | %d |\n", location.Location(), r.Link(location, reportDir),
+				symbolSuffix(location), chunk.Cited, chunk.Retrieved, r.findingsIn(chunk.File)))
+		}
+	}
+
+	for _, section := range categoryTitles {
+		findings := r.byCategory(section.category)
+		if len(findings) == 0 {
+			continue
+		}
+		b.WriteString(fmt.Sprintf("\n## %s (%d)\n\n", section.title, len(findings)))
+		if breakdown := r.tagBreakdown(findings); len(breakdown) > 0 {
+			b.WriteString("| Tag | Findings | High |\n|---|---:|---:|\n")
+			for _, row := range breakdown {
+				b.WriteString(fmt.Sprintf("| %s | %d | %d |\n", row.tag, row.findings, row.high))
+			}
+			b.WriteString("\n")
+		}
+		for _, finding := range findings {
+			b.WriteString(fmt.Sprintf("- **%s** [%s](%s)%s — %s", finding.Severity, finding.Location(),
+				r.Link(finding, reportDir), symbolSuffix(finding), markdownEscape(finding.Title)))
+			if finding.Detail != "" {
+				b.WriteString(": " + markdownEscape(finding.Detail))
+			}
+			if len(finding.Tags) > 0 {
+				b.WriteString(" · " + strings.Join(finding.Tags, ", "))
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	if len(r.Skipped) > 0 {
+		b.WriteString(fmt.Sprintf("\n_Not analyzed (parse errors): %s_\n", strings.Join(r.Skipped, ", ")))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteHTML renders the report as a standalone HTML page, with links
+// relative to reportDir
+func (r *Report) WriteHTML(w io.Writer, reportDir string) error {
+	var b strings.Builder
+	b.WriteString(`<!DOCTYPE html><html><head><meta charset="utf-8"><title>Code audit</title><style>
+body{font-family:system-ui,sans-serif;margin:2em auto;max-width:70em;color:#222}
+table{border-collapse:collapse}td,th{border:1px solid #ccc;padding:.3em .8em;text-align:right}td:first-child{text-align:left}
+.high{color:#b00020;font-weight:bold}.medium{color:#b36b00;font-weight:bold}.low{color:#555}
+li{margin:.2em 0}code{background:#f4f4f4;padding:0 .2em}
+</style></head><body><h1>Code audit</h1>`)
+	b.WriteString(fmt.Sprintf("<p>%s · %d packages · %d files · %d findings (%d high, %d medium, %d low)</p>",
+		r.CreatedAt.Format("2006-01-02 15:04"), r.Packages, r.Files, len(r.Findings),
+		r.Count("", SeverityHigh), r.Count("", SeverityMedium), r.Count("", SeverityLow)))
+
+	b.WriteString("<table><tr><th>Category</th><th>High</th><th>Medium</th><th>Low</th></tr>")
+	for _, section := range categoryTitles {
+		b.WriteString(fmt.Sprintf("<tr><td>%s</td><td>%d</td><td>%d</td><td>%d</td></tr>", section.title,
+			r.Count(section.category, SeverityHigh), r.Count(section.category, SeverityMedium), r.Count(section.category, SeverityLow)))
+	}
+	b.WriteString("</table>")
+
+	item := func(finding Finding) string {
+		line := fmt.Sprintf(`<span class="%s">%s</span> <a href="%s"><code>%s</code></a>%s — %s`,
+			finding.Severity, finding.Severity, html.EscapeString(r.Link(finding, reportDir)),
+			html.EscapeString(finding.Location()), html.EscapeString(symbolSuffix(finding)), html.EscapeString(finding.Title))
+		if finding.Detail != "" {
+			line += ": " + html.EscapeString(finding.Detail)
+		}
+		if len(finding.Tags) > 0 {
+			line += " · " + html.EscapeString(strings.Join(finding.Tags, ", "))
+		}
+		return "<li>" + line + "</li>"
+	}
+
+	if len(r.Policies) > 0 {
+		b.WriteString("<h2>Policies</h2><table><tr><th>Policy</th><th>Rule</th><th>Result</th><th>Applied to</th></tr>")
+		for _, status := range r.Policies {
+			class := "low"
+			if !status.Passed {
+				class = "high"
+			}
+			b.WriteString(fmt.Sprintf(`<tr><td>%s</td><td>%s</td><td class="%s">%s</td><td>%d of %d</td></tr>`,
+				html.EscapeString(status.Name), html.EscapeString(status.Rule), class,
+				html.EscapeString(status.verdict()), status.Applicable, status.Checked))
+		}
+		b.WriteString("</table>")
+	}
+	if top := r.top(); len(top) > 0 {
+		b.WriteString("<h2>Top priorities</h2><ol>")
+		for _, finding := range top {
+			b.WriteString(item(finding))
+		}
+		b.WriteString("</ol>")
+	}
+	if len(r.LoadBearing) > 0 {
+		b.WriteString("<h2>Most load-bearing code</h2><p>The code answers cite most; findings in these files affect the most answers.</p>")
+		b.WriteString("<table><tr><th>Code</th><th>Cited</th><th>Retrieved</th><th>Findings in file</th></tr>")
+		for _, chunk := range r.LoadBearing {
+			location := Finding{File: chunk.File, Line: chunk.Line, Symbol: chunk.Symbol}
+			b.WriteString(fmt.Sprintf(`<tr><td><a href="%s"><code>%s</code></a>%s</td><td>%d</td><td>%d</td><td>%d</td></tr>`,
+				html.EscapeString(r.Link(location, reportDir)), html.EscapeString(location.Location()),
+				html.EscapeString(symbolSuffix(location)), chunk.Cited, chunk.Retrieved, r.findingsIn(chunk.File)))
+		}
+		b.WriteString("</table>")
+	}
+	for _, section := range categoryTitles {
+		findings := r.byCategory(section.category)
+		if len(findings) == 0 {
+			continue
+		}
+		b.WriteString(fmt.Sprintf("<h2>%s (%d)</h2>", section.title, len(findings)))
+		if breakdown := r.tagBreakdown(findings); len(breakdown) > 0 {
+			b.WriteString("<table><tr><th>Tag</th><th>Findings</th><th>High</th></tr>")
+			for _, row := range breakdown {
+				b.WriteString(fmt.Sprintf("<tr><td>%s</td><td>%d</td><td>%d</td></tr>", html.EscapeString(row.tag), row.findings, row.high))
+			}
+			b.WriteString("</table>")
+		}
+		b.WriteString("<ul>")
+		for _, finding := range findings {
+			b.WriteString(item(finding))
+		}
+		b.WriteString("</ul>")
+	}
+	if len(r.Skipped) > 0 {
+		b.WriteString("<p><em>Not analyzed (parse errors): " + html.EscapeString(strings.Join(r.Skipped, ", ")) + "</em></p>")
+	}
+	b.WriteString("</body></html>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// top returns the highest priority findings for the summary
+func (r *Report) top() []Finding {
+	limit := r.config.TopFindings
+	if limit <= 0 || limit > len(r.Findings) {
+		limit = len(r.Findings)
+	}
+	return r.Findings[:limit]
+}
+
+// findingsIn counts the findings in a file
+func (r *Report) findingsIn(file string) int {
+	count := 0
+	for _, finding := range r.Findings {
+		if finding.File == file {
+			count++
+		}
+	}
+	return count
+}
+
+// byCategory returns a category's findings in priority order
+func (r *Report) byCategory(category string) []Finding {
+	var findings []Finding
+	for _, finding := range r.Findings {
+		if finding.Category == category {
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
+
+// tagRow counts a section's findings carrying one semantic tag
+type tagRow struct {
+	tag      string
+	findings int
+	high     int
+}
+
+// tagBreakdown counts findings by semantic tag, most common first, with the
+// untagged ones last. Sections whose analyzer does not tag findings have no
+// breakdown.
+func (r *Report) tagBreakdown(findings []Finding) []tagRow {
+	rows := make(map[string]*tagRow)
+	tagged := false
+	for _, finding := range findings {
+		tags := finding.Tags
+		if len(tags) == 0 {
+			tags = []string{untaggedRow}
+		} else {
+			tagged = true
+		}
+		for _, tag := range tags {
+			row := rows[tag]
+			if row == nil {
+				row = &tagRow{tag: tag}
+				rows[tag] = row
+			}
+			row.findings++
+			if finding.Severity == SeverityHigh {
+				row.high++
+			}
+		}
+	}
+	if !tagged {
+		return nil
+	}
+	breakdown := make([]tagRow, 0, len(rows))
+	for _, row := range rows {
+		breakdown = append(breakdown, *row)
+	}
+	sort.Slice(breakdown, func(i, j int) bool {
+		a, b := breakdown[i], breakdown[j]
+		if (a.tag == untaggedRow) != (b.tag == untaggedRow) {
+			return b.tag == untaggedRow
+		}
+		if a.findings != b.findings {
+			return a.findings > b.findings
+		}
+		return a.tag < b.tag
+	})
+	return breakdown
+}
+
+// untaggedRow names the findings without semantic tags in a breakdown
+const untaggedRow = "untagged"
+
+func symbolSuffix(finding Finding) string {
+	if finding.Symbol == "" {
+		return ""
+	}
+	return " in " + finding.Symbol
+}
+
+// markdownEscape keeps finding text from being read as markdown
+func markdownEscape(text string) string {
+	replacer := strings.NewReplacer("*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "|", `\|`)
+	return replacer.Replace(text)
+}
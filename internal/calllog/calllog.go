@@ -0,0 +1,248 @@
+// Package calllog keeps the operator-facing audit log of calls made to
+// external services: LLM providers, embedding endpoints, Qdrant, HTTP MCP
+// servers, the remote server and changelog sources. Each call is one
+// JSON line appended to a file kept apart from the step logs, recording who
+// was called, with which model, for what, how many tokens it took and a
+// short hash of the prompt, never the prompt itself.
+package calllog
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Services called
+const (
+	ServiceLLM       = "llm"
+	ServiceEmbedding = "embedding"
+	ServiceVectorDB  = "vectordb"
+	ServiceMCP       = "mcp"
+	ServiceRemote    = "remote"
+	ServiceChangelog = "changelog"
+)
+
+// Config controls the external call log
+type Config struct {
+	Enabled    bool   `json:"enabled"`
+	Path       string `json:"path"`        // JSON lines file, appended to only
+	HashLength int    `json:"hash_length"` // hex digits of the prompt hash kept
+}
+
+// DefaultConfig returns external call log defaults
+func DefaultConfig() Config {
+	return Config{Enabled: true, Path: "logs/audit/external_calls.jsonl", HashLength: 16}
+}
+
+// Call is one outbound call
+type Call struct {
+	Time         time.Time `json:"time"`
+	Service      string    `json:"service"` // one of the Service constants
+	Provider     string    `json:"provider"`
+	Endpoint     string    `json:"endpoint,omitempty"`
+	Model        string    `json:"model,omitempty"`
+	Purpose      string    `json:"purpose,omitempty"`
+	InputTokens  int       `json:"input_tokens"`
+	OutputTokens int       `json:"output_tokens"`
+	DurationMS   int64     `json:"duration_ms"`
+	PromptHash   string    `json:"prompt_hash,omitempty"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// Log appends calls to the audit file
+type Log struct {
+	config Config
+	mu     sync.Mutex
+}
+
+// Open prepares the log's directory. A disabled log records nothing.
+func Open(config Config) (*Log, error) {
+	if config.Path == "" {
+		config.Path = DefaultConfig().Path
+	}
+	if config.HashLength <= 0 || config.HashLength > sha256.Size*2 {
+		config.HashLength = DefaultConfig().HashLength
+	}
+	if config.Enabled {
+		if err := os.MkdirAll(filepath.Dir(config.Path), 0700); err != nil {
+			return nil, fmt.Errorf("failed to create the external call log directory: %w", err)
+		}
+	}
+	return &Log{config: config}, nil
+}
+
+// Path returns the file calls are appended to
+func (l *Log) Path() string {
+	return l.config.Path
+}
+
+// Record appends a call. The file is opened for appending on every call, so
+// rotating or shipping it never loses entries.
+func (l *Log) Record(call Call) error {
+	if l == nil || !l.config.Enabled {
+		return nil
+	}
+	if call.Time.IsZero() {
+		call.Time = time.Now()
+	}
+	line, err := json.Marshal(call)
+	if err != nil {
+		return fmt.Errorf("failed to encode external call: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	file, err := os.OpenFile(l.config.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open the external call log: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write the external call log: %w", err)
+	}
+	return nil
+}
+
+// Since reads the calls made at or after since, oldest first
+func (l *Log) Since(since time.Time) ([]Call, error) {
+	file, err := os.Open(l.config.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the external call log: %w", err)
+	}
+	defer file.Close()
+
+	var calls []Call
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var call Call
+		if err := json.Unmarshal(scanner.Bytes(), &call); err != nil {
+			continue // a line cut short by a crash
+		}
+		if !call.Time.Before(since) {
+			calls = append(calls, call)
+		}
+	}
+	return calls, scanner.Err()
+}
+
+// HashPrompt returns the leading digits of the SHA-256 of a prompt, enough
+// to match calls for the same prompt without storing it
+func (l *Log) HashPrompt(prompt string) string {
+	if prompt == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(prompt))
+	return hex.EncodeToString(sum[:])[:l.config.HashLength]
+}
+
+var (
+	defaultLog   *Log
+	defaultLogMu sync.RWMutex
+)
+
+// SetDefault replaces the log every provider call is recorded to
+func SetDefault(log *Log) {
+	defaultLogMu.Lock()
+	defer defaultLogMu.Unlock()
+	defaultLog = log
+}
+
+// Default returns the log provider calls are recorded to, or nil
+func Default() *Log {
+	defaultLogMu.RLock()
+	defer defaultLogMu.RUnlock()
+	return defaultLog
+}
+
+// Record appends a call to the default log, hashing prompt into it.
+// Failing to log never fails the call; it is reported on the console.
+func Record(call Call, prompt string) {
+	log := Default()
+	if log == nil || !log.config.Enabled {
+		return
+	}
+	call.PromptHash = log.HashPrompt(prompt)
+	if err := log.Record(call); err != nil {
+		fmt.Printf("⚠️ %v\n", err)
+	}
+}
+
+type purposeKey struct{}
+
+// WithPurpose says what the external calls made with ctx are for, e.g.
+// answering a query or indexing
+func WithPurpose(ctx context.Context, purpose string) context.Context {
+	return context.WithValue(ctx, purposeKey{}, purpose)
+}
+
+// PurposeFrom returns the purpose set on ctx, or fallback
+func PurposeFrom(ctx context.Context, fallback string) string {
+	if ctx != nil {
+		if purpose, ok := ctx.Value(purposeKey{}).(string); ok && purpose != "" {
+			return purpose
+		}
+	}
+	return fallback
+}
+
+// RecordHTTP appends an HTTP request made to service's provider to the
+// default log. The endpoint is recorded without its query, which may carry
+// keys; a reply of 400 or above is recorded as an error.
+func RecordHTTP(service, provider string, req *http.Request, resp *http.Response, started time.Time, callErr error) {
+	endpoint := *req.URL
+	endpoint.RawQuery, endpoint.Fragment, endpoint.User = "", "", nil
+	if provider == "" {
+		provider = req.URL.Host
+	}
+	call := Call{
+		Time:       started,
+		Service:    service,
+		Provider:   provider,
+		Endpoint:   req.Method + " " + endpoint.String(),
+		Purpose:    PurposeFrom(req.Context(), service),
+		DurationMS: time.Since(started).Milliseconds(),
+	}
+	switch {
+	case callErr != nil:
+		call.Error = callErr.Error()
+	case resp != nil && resp.StatusCode >= http.StatusBadRequest:
+		call.Error = resp.Status
+	}
+	Record(call, "")
+}
+
+// Transport returns a round tripper recording every request sent through
+// base, or http.DefaultTransport when nil, as a call to service's provider.
+// An empty provider records the host called.
+func Transport(service, provider string, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{service: service, provider: provider, base: base}
+}
+
+type transport struct {
+	service  string
+	provider string
+	base     http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	started := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	RecordHTTP(t.service, t.provider, req, resp, started, err)
+	return resp, err
+}
@@ -0,0 +1,237 @@
+// Package churn measures how often and how recently files and chunks
+// changed, from git history, so search can favour code that is actively
+// worked on when ranking results.
+package churn
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/sandbox"
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// Config controls churn collection during indexing and its weight when
+// search results are reranked
+type Config struct {
+	Enabled  bool          `json:"enabled"`
+	Window   time.Duration `json:"window"`    // history considered
+	HalfLife time.Duration `json:"half_life"` // age at which a commit counts half
+	Weight   float64       `json:"weight"`    // largest boost added to a result's score
+	Chunks   bool          `json:"chunks"`    // score chunks from git blame, not just files
+	Timeout  time.Duration `json:"timeout"`   // per git call
+}
+
+// DefaultConfig returns churn defaults
+func DefaultConfig() Config {
+	return Config{
+		Enabled:  true,
+		Window:   180 * 24 * time.Hour,
+		HalfLife: 30 * 24 * time.Hour,
+		Weight:   0.1,
+		Chunks:   true,
+		Timeout:  time.Minute,
+	}
+}
+
+// maxOutput caps what git log and git blame may print; a truncated log
+// loses only the oldest commits
+const maxOutput = 32 * 1024 * 1024
+
+// Range is a span of lines, 1-based and inclusive, to score on its own
+type Range struct {
+	Start int
+	End   int
+}
+
+// Decay is how much a change made at changed still counts at now: 1 when
+// new, halving every halfLife
+func Decay(changed, now time.Time, halfLife time.Duration) float64 {
+	if halfLife <= 0 {
+		return 1
+	}
+	age := now.Sub(changed)
+	if age < 0 {
+		age = 0
+	}
+	return math.Exp(-math.Ln2 * float64(age) / float64(halfLife))
+}
+
+// Boost maps a churn score onto (0, weight): one fresh commit earns half
+// the weight and further commits add less and less
+func Boost(score, weight float64) float64 {
+	if score <= 0 || weight <= 0 {
+		return 0
+	}
+	return weight * score / (score + 1)
+}
+
+// History reads the commits of the last cfg.Window under root and returns
+// the churn of every file they touched, keyed by its path relative to root
+func History(ctx context.Context, root string, cfg Config) (map[string]*storage.ChurnRecord, error) {
+	runner, err := gitRunner(root, cfg)
+	if err != nil {
+		return nil, err
+	}
+	since := time.Now().Add(-cfg.Window)
+	result, err := runner.Run(ctx, "git", "-c", "core.quotePath=false", "log", "--relative", "--no-merges", "--no-renames",
+		"--numstat", "--format=%x00%ct", fmt.Sprintf("--since=%d", since.Unix()), "--", ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run git log: %w", err)
+	}
+	if result.ExitCode != 0 {
+		return nil, fmt.Errorf("git log failed: %s", strings.TrimSpace(result.Output))
+	}
+	return parseLog(result.Output, time.Now(), cfg.HalfLife), nil
+}
+
+// parseLog reads git log --numstat output whose commits start with a NUL
+// and their commit time
+func parseLog(output string, now time.Time, halfLife time.Duration) map[string]*storage.ChurnRecord {
+	files := make(map[string]*storage.ChurnRecord)
+	var committed time.Time
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		text := scanner.Text()
+		if stamp, ok := strings.CutPrefix(text, "\x00"); ok {
+			seconds, err := strconv.ParseInt(strings.TrimSpace(stamp), 10, 64)
+			if err != nil {
+				committed = time.Time{}
+				continue
+			}
+			committed = time.Unix(seconds, 0)
+			continue
+		}
+		// <added>\t<deleted>\t<path>, with - for binary files
+		fields := strings.SplitN(text, "\t", 3)
+		if len(fields) != 3 || committed.IsZero() {
+			continue
+		}
+		added, _ := strconv.Atoi(fields[0])
+		deleted, _ := strconv.Atoi(fields[1])
+
+		file := files[fields[2]]
+		if file == nil {
+			file = &storage.ChurnRecord{Path: fields[2]}
+			files[fields[2]] = file
+		}
+		file.Commits++
+		file.LinesChanged += added + deleted
+		file.Score += Decay(committed, now, halfLife)
+		if committed.After(file.LastCommit) {
+			file.LastCommit = committed
+		}
+	}
+	return files
+}
+
+// Chunks blames file, relative to root, and scores each range by the
+// commits of the last cfg.Window that its current lines come from
+func Chunks(ctx context.Context, root, file string, ranges []Range, cfg Config) ([]*storage.ChurnRecord, error) {
+	if len(ranges) == 0 {
+		return nil, nil
+	}
+	runner, err := gitRunner(root, cfg)
+	if err != nil {
+		return nil, err
+	}
+	result, err := runner.Run(ctx, "git", "blame", "--porcelain", "--", file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run git blame: %w", err)
+	}
+	if result.ExitCode != 0 {
+		return nil, fmt.Errorf("git blame failed: %s", strings.TrimSpace(result.Output))
+	}
+	return scoreRanges(parseBlame(result.Output), ranges, time.Now(), cfg), nil
+}
+
+// blameLine is the commit a line last changed in
+type blameLine struct {
+	commit string
+	time   time.Time
+}
+
+// parseBlame reads git blame --porcelain output, where a commit's details
+// follow only its first line. Uncommitted lines are left out.
+func parseBlame(output string) map[int]blameLine {
+	times := make(map[string]time.Time)
+	lines := make(map[int]blameLine)
+	var commit string
+	line := 0
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		text := scanner.Text()
+		switch {
+		case strings.HasPrefix(text, "\t"):
+			// The line's content ends its entry
+			if line > 0 && strings.Trim(commit, "0") != "" {
+				lines[line] = blameLine{commit: commit}
+			}
+			line = 0
+		case strings.HasPrefix(text, "committer-time "):
+			if seconds, err := strconv.ParseInt(strings.TrimPrefix(text, "committer-time "), 10, 64); err == nil {
+				times[commit] = time.Unix(seconds, 0)
+			}
+		default:
+			// Entries start with: <sha> <original line> <final line> [<group size>]
+			fields := strings.Fields(text)
+			if line == 0 && len(fields) >= 3 && len(fields[0]) == 40 {
+				commit = fields[0]
+				line, _ = strconv.Atoi(fields[2])
+			}
+		}
+	}
+	for number, entry := range lines {
+		entry.time = times[entry.commit]
+		lines[number] = entry
+	}
+	return lines
+}
+
+// scoreRanges sums, for each range, the decayed weight of the distinct
+// recent commits its lines come from. Ranges untouched in the window are
+// kept with a zero score, so they are not credited with the file's churn.
+func scoreRanges(blame map[int]blameLine, ranges []Range, now time.Time, cfg Config) []*storage.ChurnRecord {
+	since := now.Add(-cfg.Window)
+	var records []*storage.ChurnRecord
+	for _, span := range ranges {
+		record := &storage.ChurnRecord{StartLine: span.Start, EndLine: span.End}
+		seen := make(map[string]bool)
+		for number := span.Start; number <= span.End; number++ {
+			entry, ok := blame[number]
+			if !ok || entry.time.Before(since) {
+				continue
+			}
+			record.LinesChanged++
+			if entry.time.After(record.LastCommit) {
+				record.LastCommit = entry.time
+			}
+			if seen[entry.commit] {
+				continue
+			}
+			seen[entry.commit] = true
+			record.Commits++
+			record.Score += Decay(entry.time, now, cfg.HalfLife)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// gitRunner returns a sandbox runner allowed to run git under root
+func gitRunner(root string, cfg Config) (*sandbox.Runner, error) {
+	policy := sandbox.DefaultPolicy()
+	policy.AllowedCommands = []string{"git"}
+	policy.MaxOutput = maxOutput
+	if cfg.Timeout > 0 {
+		policy.Timeout = cfg.Timeout
+	}
+	return sandbox.NewRunner(root, policy)
+}
@@ -0,0 +1,290 @@
+// Package ci answers questions about a change, such as "does this PR touch
+// deprecated APIs?", without a full index. It parses only the Go packages
+// the change touches and the module packages they import directly, runs the
+// audit analyzers and a deprecated-API check over them in memory, and
+// reports findings on changed lines as policy violations. Nothing is
+// written to the index or sent to an embedding provider.
+package ci
+
+import (
+	"context"
+	"fmt"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/audit"
+	"github.com/yourusername/useq-ai-assistant/internal/deps"
+)
+
+// Exit codes of the ci command
+const (
+	ExitPassed    = 0
+	ExitViolation = 1
+	ExitError     = 2
+)
+
+// severityRanks order severities for FailOn
+var severityRanks = map[string]int{
+	audit.SeverityLow:    1,
+	audit.SeverityMedium: 2,
+	audit.SeverityHigh:   3,
+}
+
+// Config controls a CI run
+type Config struct {
+	Base   string       `json:"base"`    // ref the change is compared against
+	Checks []string     `json:"checks"`  // finding categories to report; empty reports all
+	Policy []string     `json:"policy"`  // categories whose findings on changed lines fail the run
+	FailOn string       `json:"fail_on"` // lowest severity that fails the run
+	Audit  audit.Config `json:"-"`       // analyzer settings, shared with "audit"
+}
+
+// DefaultConfig returns CI defaults: any deprecated or outdated API or
+// security finding on a changed line fails the run
+func DefaultConfig() Config {
+	return Config{
+		Base:   "origin/main",
+		Policy: []string{CategoryDeprecated, audit.CategoryOutdated, audit.CategorySecurity},
+		FailOn: audit.SeverityLow,
+		Audit:  audit.DefaultConfig(),
+	}
+}
+
+// Finding is an audit finding in a changed file
+type Finding struct {
+	audit.Finding
+	Changed   bool `json:"changed"`   // on a line the change adds or modifies
+	Violation bool `json:"violation"` // fails the policy
+}
+
+// Result is the outcome of a CI run, in the form the ci command prints as
+// JSON
+type Result struct {
+	Base         string    `json:"base"`
+	Files        []string  `json:"files"`             // changed Go files analyzed
+	Skipped      []string  `json:"skipped,omitempty"` // changed files that are not Go source, or could not be parsed
+	Packages     []string  `json:"packages"`          // package directories analyzed
+	Dependencies []string  `json:"dependencies"`      // module packages they import, scanned for deprecations
+	Findings     []Finding `json:"findings"`          // violations first, then by file and line
+	Violations   int       `json:"violations"`
+	DurationMS   int64     `json:"duration_ms"`
+}
+
+// Passed reports whether no finding violates the policy
+func (r *Result) Passed() bool {
+	return r.Violations == 0
+}
+
+// ExitCode returns the process exit code for the result
+func (r *Result) ExitCode() int {
+	if r.Passed() {
+		return ExitPassed
+	}
+	return ExitViolation
+}
+
+// Run analyzes what the working tree changes since base
+func Run(ctx context.Context, root string, config Config) (*Result, error) {
+	started := time.Now()
+	if _, ok := severityRanks[config.FailOn]; !ok {
+		return nil, fmt.Errorf("unknown severity %q; use low, medium or high", config.FailOn)
+	}
+	module, err := deps.ModulePath(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return nil, fmt.Errorf("ci mode needs a Go module: %w", err)
+	}
+	change, err := Diff(ctx, root, config.Base)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Base: config.Base}
+	changedDirs := make(map[string]bool)
+	for file := range change {
+		if !analyzable(file) {
+			result.Skipped = append(result.Skipped, file)
+			continue
+		}
+		result.Files = append(result.Files, file)
+		changedDirs[filepath.ToSlash(filepath.Dir(file))] = true
+	}
+	sort.Strings(result.Files)
+
+	// The ephemeral index: changed packages and the module packages they
+	// import, parsed for their deprecated declarations
+	deprecations := make(Deprecations)
+	batches := make([]audit.Batch, 0, len(changedDirs))
+	dependencies := make(map[string]bool)
+	for dir := range changedDirs {
+		batch, imports, err := loadPackage(root, dir)
+		if err != nil {
+			result.Skipped = append(result.Skipped, dir+"/")
+			continue
+		}
+		batches = append(batches, batch)
+		result.Packages = append(result.Packages, dir)
+		if err := deprecations.Add(filepath.Join(root, dir), importPath(module, dir)); err != nil {
+			return nil, err
+		}
+		for _, path := range imports {
+			if dep, ok := moduleDir(module, path); ok && !changedDirs[dep] {
+				dependencies[dep] = true
+			}
+		}
+	}
+	for dir := range dependencies {
+		if err := deprecations.Add(filepath.Join(root, dir), importPath(module, dir)); err != nil {
+			continue
+		}
+		result.Dependencies = append(result.Dependencies, dir)
+	}
+	sort.Strings(result.Packages)
+	sort.Strings(result.Dependencies)
+	sort.Strings(result.Skipped)
+	sort.Slice(batches, func(i, j int) bool { return batches[i].Dir < batches[j].Dir })
+
+	auditor := audit.NewAuditor(root, config.Audit)
+	auditor.Add(&DeprecatedAPIAnalyzer{Module: module, Deprecations: deprecations})
+	checks := toSet(config.Checks)
+	policy := toSet(config.Policy)
+	for _, batch := range batches {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		findings, err := auditor.AnalyzeBatch(batch)
+		if err != nil {
+			result.Skipped = append(result.Skipped, batch.Dir+"/")
+			continue
+		}
+		for _, finding := range findings {
+			if _, changed := change[finding.File]; !changed {
+				continue
+			}
+			if len(checks) > 0 && !checks[finding.Category] {
+				continue
+			}
+			ciFinding := Finding{Finding: finding, Changed: change.Touches(finding.File, finding.Line)}
+			ciFinding.Violation = ciFinding.Changed && policy[finding.Category] &&
+				severityRanks[finding.Severity] >= severityRanks[config.FailOn]
+			if ciFinding.Violation {
+				result.Violations++
+			}
+			result.Findings = append(result.Findings, ciFinding)
+		}
+	}
+	sort.SliceStable(result.Findings, func(i, j int) bool {
+		a, b := result.Findings[i], result.Findings[j]
+		if a.Violation != b.Violation {
+			return a.Violation
+		}
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+	result.DurationMS = time.Since(started).Milliseconds()
+	return result, nil
+}
+
+// analyzable reports whether a changed file is Go source the analyzers read
+func analyzable(file string) bool {
+	if !strings.HasSuffix(file, ".go") || strings.HasSuffix(file, "_test.go") {
+		return false
+	}
+	for _, part := range strings.Split(filepath.ToSlash(file), "/") {
+		if part == "vendor" || part == "testdata" {
+			return false
+		}
+	}
+	return true
+}
+
+// loadPackage lists the Go files of a package directory as an audit batch
+// and returns the packages they import
+func loadPackage(root, dir string) (audit.Batch, []string, error) {
+	bp, err := build.Default.ImportDir(filepath.Join(root, dir), 0)
+	if err != nil && len(bp.GoFiles) == 0 {
+		return audit.Batch{}, nil, err
+	}
+	batch := audit.Batch{Dir: dir}
+	imports := make(map[string]bool)
+	fset := token.NewFileSet()
+	for _, name := range bp.GoFiles {
+		path := filepath.ToSlash(filepath.Join(dir, name))
+		batch.Files = append(batch.Files, path)
+		file, err := parser.ParseFile(fset, filepath.Join(root, path), nil, parser.ImportsOnly)
+		if err != nil {
+			continue
+		}
+		for _, spec := range file.Imports {
+			if imported, err := strconv.Unquote(spec.Path.Value); err == nil {
+				imports[imported] = true
+			}
+		}
+	}
+	paths := make([]string, 0, len(imports))
+	for path := range imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return batch, paths, nil
+}
+
+// importPath returns the import path of a module directory
+func importPath(module, dir string) string {
+	if dir == "." {
+		return module
+	}
+	return module + "/" + dir
+}
+
+// moduleDir returns the directory of an import path inside the module
+func moduleDir(module, path string) (string, bool) {
+	if path == module {
+		return ".", true
+	}
+	rest, ok := strings.CutPrefix(path, module+"/")
+	return rest, ok
+}
+
+// toSet returns the values as a set
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
+
+// WriteText writes the result for a CI log: the scope, then the findings
+// with violations marked
+func (r *Result) WriteText(w io.Writer) {
+	fmt.Fprintf(w, "useq ci: %d changed Go file(s) since %s in %d package(s), %d dependency package(s) scanned, %dms\n",
+		len(r.Files), r.Base, len(r.Packages), len(r.Dependencies), r.DurationMS)
+	for _, finding := range r.Findings {
+		marker := "  "
+		switch {
+		case finding.Violation:
+			marker = "✗ "
+		case !finding.Changed:
+			marker = "· "
+		}
+		fmt.Fprintf(w, "%s%s [%s/%s] %s", marker, finding.Location(), finding.Category, finding.Severity, finding.Title)
+		if finding.Detail != "" {
+			fmt.Fprintf(w, ": %s", finding.Detail)
+		}
+		fmt.Fprintln(w)
+	}
+	if r.Passed() {
+		fmt.Fprintf(w, "✓ no policy violations (%d finding(s) in changed files)\n", len(r.Findings))
+		return
+	}
+	fmt.Fprintf(w, "✗ %d policy violation(s) on changed lines\n", r.Violations)
+}
@@ -0,0 +1,236 @@
+package ci
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/internal/audit"
+)
+
+// CategoryDeprecated is the finding category of uses of module APIs whose
+// doc comment marks them "Deprecated:"
+const CategoryDeprecated = "deprecated_api"
+
+// Deprecations maps an import path to its deprecated package-level
+// identifiers and their deprecation notes
+type Deprecations map[string]map[string]string
+
+// Add records the deprecated functions, types, variables and constants of
+// the package in dir. Methods are left out: without type information a
+// call cannot be tied to its receiver.
+func (d Deprecations) Add(dir, importPath string) error {
+	bp, err := build.Default.ImportDir(dir, 0)
+	if err != nil && len(bp.GoFiles) == 0 {
+		return fmt.Errorf("failed to read package %s: %w", importPath, err)
+	}
+	fset := token.NewFileSet()
+	for _, name := range bp.GoFiles {
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+		for _, decl := range file.Decls {
+			for _, declared := range deprecatedNames(decl) {
+				if d[importPath] == nil {
+					d[importPath] = make(map[string]string)
+				}
+				d[importPath][declared.name] = declared.note
+			}
+		}
+	}
+	return nil
+}
+
+// deprecatedName is a deprecated identifier and the declaration it names
+type deprecatedName struct {
+	name  string
+	note  string
+	ident *ast.Ident
+	node  ast.Node // the FuncDecl, TypeSpec or ValueSpec
+}
+
+// deprecatedNames returns the deprecated identifiers a declaration
+// introduces. A note on a grouped declaration covers every spec in it.
+func deprecatedNames(decl ast.Decl) []deprecatedName {
+	switch decl := decl.(type) {
+	case *ast.FuncDecl:
+		if decl.Recv != nil {
+			return nil
+		}
+		if note := deprecationNote(decl.Doc); note != "" {
+			return []deprecatedName{{name: decl.Name.Name, note: note, ident: decl.Name, node: decl}}
+		}
+	case *ast.GenDecl:
+		groupNote := deprecationNote(decl.Doc)
+		var names []deprecatedName
+		for _, spec := range decl.Specs {
+			switch spec := spec.(type) {
+			case *ast.TypeSpec:
+				if note := firstNote(deprecationNote(spec.Doc), groupNote); note != "" {
+					names = append(names, deprecatedName{name: spec.Name.Name, note: note, ident: spec.Name, node: spec})
+				}
+			case *ast.ValueSpec:
+				if note := firstNote(deprecationNote(spec.Doc), groupNote); note != "" {
+					for _, ident := range spec.Names {
+						names = append(names, deprecatedName{name: ident.Name, note: note, ident: ident, node: spec})
+					}
+				}
+			}
+		}
+		return names
+	}
+	return nil
+}
+
+// deprecationNote returns the "Deprecated:" paragraph of a doc comment,
+// without the marker, or ""
+func deprecationNote(doc *ast.CommentGroup) string {
+	if doc == nil {
+		return ""
+	}
+	for _, paragraph := range strings.Split(doc.Text(), "\n\n") {
+		if note, ok := strings.CutPrefix(strings.TrimSpace(paragraph), "Deprecated:"); ok {
+			note = strings.Join(strings.Fields(note), " ")
+			if note == "" {
+				note = "deprecated"
+			}
+			return note
+		}
+	}
+	return ""
+}
+
+// firstNote returns the first non-empty note
+func firstNote(notes ...string) string {
+	for _, note := range notes {
+		if note != "" {
+			return note
+		}
+	}
+	return ""
+}
+
+// DeprecatedAPIAnalyzer reports uses of deprecated module APIs: qualified
+// uses of other packages' identifiers, and unqualified uses of the
+// package's own. Code inside a deprecated declaration is not reported.
+type DeprecatedAPIAnalyzer struct {
+	Module       string
+	Deprecations Deprecations
+}
+
+// Name returns the analyzer name
+func (a *DeprecatedAPIAnalyzer) Name() string { return CategoryDeprecated }
+
+// Analyze reports the uses of deprecated APIs in pkg
+func (a *DeprecatedAPIAnalyzer) Analyze(pkg *audit.Package) []audit.Finding {
+	importPath := a.Module
+	if pkg.Dir != "." {
+		importPath = a.Module + "/" + pkg.Dir
+	}
+
+	// The package's own deprecated declarations, so code inside them is
+	// skipped and same-file uses resolve to them
+	own := a.Deprecations[importPath]
+	var inside []ast.Node
+	declared := make(map[*ast.Ident]bool)
+	nodes := make(map[interface{}]bool)
+	for _, file := range pkg.Sources() {
+		for _, decl := range file.AST.Decls {
+			for _, name := range deprecatedNames(decl) {
+				declared[name.ident] = true
+				nodes[name.node] = true
+				inside = append(inside, decl)
+			}
+		}
+	}
+	withinDeprecated := func(pos token.Pos) bool {
+		for _, node := range inside {
+			if node.Pos() <= pos && pos <= node.End() {
+				return true
+			}
+		}
+		return false
+	}
+
+	var findings []audit.Finding
+	for _, file := range pkg.Sources() {
+		imports := make(map[string]string)
+		for _, spec := range file.AST.Imports {
+			path, _ := strconv.Unquote(spec.Path.Value)
+			if _, ok := a.Deprecations[path]; !ok {
+				continue
+			}
+			name := filepath.Base(path)
+			if spec.Name != nil {
+				name = spec.Name.Name
+			}
+			imports[name] = path
+		}
+		add := func(node ast.Node, name, note string) {
+			if withinDeprecated(node.Pos()) {
+				return
+			}
+			findings = append(findings, audit.Finding{
+				Category: CategoryDeprecated,
+				Severity: audit.SeverityMedium,
+				File:     file.Path,
+				Line:     pkg.Line(node.Pos()),
+				Symbol:   enclosingFunc(file.AST, node.Pos()),
+				Title:    "Uses deprecated " + name,
+				Detail:   note,
+			})
+		}
+
+		// Selected names and method names are not references to the
+		// package's own identifiers
+		skipped := make(map[*ast.Ident]bool)
+		ast.Inspect(file.AST, func(node ast.Node) bool {
+			switch node := node.(type) {
+			case *ast.ImportSpec:
+				return false
+			case *ast.FuncDecl:
+				skipped[node.Name] = true
+			case *ast.SelectorExpr:
+				skipped[node.Sel] = true
+				qualifier, ok := node.X.(*ast.Ident)
+				if !ok || qualifier.Obj != nil {
+					return true
+				}
+				if path, ok := imports[qualifier.Name]; ok {
+					if note, ok := a.Deprecations[path][node.Sel.Name]; ok {
+						add(node, qualifier.Name+"."+node.Sel.Name, note)
+					}
+				}
+			case *ast.Ident:
+				note, ok := own[node.Name]
+				if !ok || skipped[node] || declared[node] {
+					return true
+				}
+				// A name declared in this file resolves to its declaration,
+				// which may be a local that shadows the deprecated one
+				if node.Obj != nil && !nodes[node.Obj.Decl] {
+					return true
+				}
+				add(node, node.Name, note)
+			}
+			return true
+		})
+	}
+	return findings
+}
+
+// enclosingFunc returns the name of the function declaration containing pos
+func enclosingFunc(file *ast.File, pos token.Pos) string {
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Pos() <= pos && pos <= fn.End() {
+			return fn.Name.Name
+		}
+	}
+	return ""
+}
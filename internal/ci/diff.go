@@ -0,0 +1,116 @@
+package ci
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// gitTimeout bounds each git command; a large diff takes a while
+const gitTimeout = 30 * time.Second
+
+// LineSpan is a run of lines a diff adds or modifies, inclusive
+type LineSpan struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Change is what a diff touches: for each file, relative to the project
+// root, the line spans it adds or modifies. Deleted files are left out.
+type Change map[string][]LineSpan
+
+// Touches reports whether line of file was added or modified
+func (c Change) Touches(file string, line int) bool {
+	for _, span := range c[file] {
+		if line >= span.Start && line <= span.End {
+			return true
+		}
+	}
+	return false
+}
+
+// Diff returns what the working tree changes since it forked from base,
+// committed or not, so a CI checkout and a local branch give the same answer
+func Diff(ctx context.Context, root, base string) (Change, error) {
+	mergeBase, err := git(ctx, root, "merge-base", base, "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find where HEAD forked from %s: %w", base, err)
+	}
+	output, err := git(ctx, root, "diff", "--unified=0", "--no-color", "--no-ext-diff", "--relative",
+		"--diff-filter=AMR", strings.TrimSpace(mergeBase))
+	if err != nil {
+		return nil, err
+	}
+	return parseDiff(output), nil
+}
+
+// parseDiff reads the added and modified line spans of a unified diff
+func parseDiff(output string) Change {
+	change := make(Change)
+	file := ""
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "+++ "):
+			file = strings.TrimPrefix(strings.TrimPrefix(line, "+++ "), "b/")
+			if file == "/dev/null" {
+				file = ""
+				continue
+			}
+			if _, ok := change[file]; !ok {
+				change[file] = nil
+			}
+		case strings.HasPrefix(line, "@@ ") && file != "":
+			if span, ok := parseHunk(line); ok {
+				change[file] = append(change[file], span)
+			}
+		}
+	}
+	return change
+}
+
+// parseHunk reads the new-side span of "@@ -a,b +c,d @@"; a hunk that only
+// deletes lines has none
+func parseHunk(header string) (LineSpan, bool) {
+	fields := strings.Fields(header)
+	if len(fields) < 3 || !strings.HasPrefix(fields[2], "+") {
+		return LineSpan{}, false
+	}
+	startText, countText, counted := strings.Cut(strings.TrimPrefix(fields[2], "+"), ",")
+	start, err := strconv.Atoi(startText)
+	if err != nil {
+		return LineSpan{}, false
+	}
+	count := 1
+	if counted {
+		if count, err = strconv.Atoi(countText); err != nil {
+			return LineSpan{}, false
+		}
+	}
+	if count == 0 {
+		return LineSpan{}, false
+	}
+	return LineSpan{Start: start, End: start + count - 1}, true
+}
+
+// git runs a git command in dir and returns its output
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, gitTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	out, err := cmd.Output()
+	if err != nil {
+		if exit, ok := err.(*exec.ExitError); ok && len(exit.Stderr) > 0 {
+			return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(exit.Stderr)))
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return string(out), nil
+}
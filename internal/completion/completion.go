@@ -0,0 +1,190 @@
+// Package completion suggests completions for partially typed queries, from
+// indexed symbol names, past queries and the intents most queries start with.
+package completion
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// Kind says where a suggestion came from
+type Kind string
+
+const (
+	KindHistory Kind = "history"
+	KindSymbol  Kind = "symbol"
+	KindIntent  Kind = "intent"
+)
+
+// DefaultLimit is the number of suggestions returned when none is asked for
+const DefaultLimit = 8
+
+// minSymbolPrefix is the shortest word completed from symbol names
+const minSymbolPrefix = 2
+
+// Suggestion is one completion of the typed text
+type Suggestion struct {
+	Text   string  `json:"text"` // the whole query with the completion applied
+	Kind   Kind    `json:"kind"`
+	Detail string  `json:"detail,omitempty"`
+	Score  float64 `json:"score"`
+}
+
+// Intent is a common way to start a query
+type Intent struct {
+	Text   string `json:"text"`
+	Detail string `json:"detail"`
+}
+
+// DefaultIntents are the query starts the classifier and agents recognise
+var DefaultIntents = []Intent{
+	{"explain ", "explain how code works"},
+	{"how does ", "walk through a flow"},
+	{"where is ", "locate a definition"},
+	{"find ", "find code patterns"},
+	{"search ", "search functions and files"},
+	{"show ", "show a file or function"},
+	{"list files", "list indexed files"},
+	{"analyze ", "analyze a file's structure"},
+	{"create ", "generate new code"},
+	{"test ", "generate tests"},
+	{"refactor ", "suggest a refactoring"},
+	{"optimize ", "improve performance"},
+	{"fix ", "fix a bug or compiler error"},
+	{"upgrade ", "plan a dependency or Go version migration"},
+	{"what implements ", "list the types satisfying an interface"},
+	{"why ", "explain a search result's ranking"},
+}
+
+// Completer completes queries from a symbol index, past queries and intents
+type Completer struct {
+	symbols *PrefixIndex
+	intents []Intent
+}
+
+// NewCompleter indexes symbol names for completion
+func NewCompleter(symbols []*storage.SymbolName) *Completer {
+	index := NewPrefixIndex()
+	for _, symbol := range symbols {
+		index.Add(symbol.Name, symbol.Kind+" in "+symbol.File)
+	}
+	index.Build()
+	return &Completer{symbols: index, intents: DefaultIntents}
+}
+
+// Symbols returns the number of distinct symbol names indexed
+func (c *Completer) Symbols() int {
+	return c.symbols.Len()
+}
+
+// Complete suggests completions of input. history holds past queries,
+// newest first; queries typed more often rank higher.
+func (c *Completer) Complete(input string, history []string, limit int) []Suggestion {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	input = strings.TrimLeft(input, " \t")
+	lowered := strings.ToLower(input)
+
+	var suggestions []Suggestion
+	suggestions = append(suggestions, completeHistory(input, lowered, history)...)
+	suggestions = append(suggestions, c.completeIntents(lowered)...)
+	suggestions = append(suggestions, c.completeSymbol(input)...)
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].Score > suggestions[j].Score
+	})
+	seen := make(map[string]bool)
+	results := make([]Suggestion, 0, limit)
+	for _, suggestion := range suggestions {
+		key := strings.ToLower(suggestion.Text)
+		if seen[key] || key == lowered {
+			continue
+		}
+		seen[key] = true
+		results = append(results, suggestion)
+		if len(results) == limit {
+			break
+		}
+	}
+	return results
+}
+
+// completeHistory suggests past queries starting with the input, the most
+// recent and most repeated first
+func completeHistory(input, lowered string, history []string) []Suggestion {
+	counts := make(map[string]int)
+	for _, query := range history {
+		counts[strings.ToLower(strings.TrimSpace(query))]++
+	}
+
+	var suggestions []Suggestion
+	seen := make(map[string]bool)
+	for position, query := range history {
+		query = strings.TrimSpace(query)
+		key := strings.ToLower(query)
+		if seen[key] || !strings.HasPrefix(key, lowered) {
+			continue
+		}
+		seen[key] = true
+		recency := 1 - float64(position)/float64(len(history))
+		score := 0.8 + 0.1*recency + min(0.09, 0.03*float64(counts[key]-1))
+		suggestions = append(suggestions, Suggestion{
+			Text:  query,
+			Kind:  KindHistory,
+			Score: score,
+		})
+	}
+	return suggestions
+}
+
+// completeIntents suggests intents while the first words are being typed
+func (c *Completer) completeIntents(lowered string) []Suggestion {
+	var suggestions []Suggestion
+	for _, intent := range c.intents {
+		if !strings.HasPrefix(intent.Text, lowered) {
+			continue
+		}
+		score := 0.6
+		if lowered == "" {
+			score = 0.5
+		}
+		suggestions = append(suggestions, Suggestion{
+			Text:   intent.Text,
+			Kind:   KindIntent,
+			Detail: intent.Detail,
+			Score:  score,
+		})
+	}
+	return suggestions
+}
+
+// completeSymbol completes the word being typed with indexed symbol names
+func (c *Completer) completeSymbol(input string) []Suggestion {
+	start := strings.LastIndexFunc(input, func(r rune) bool {
+		return !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_')
+	}) + 1
+	word := input[start:]
+	if len(word) < minSymbolPrefix {
+		return nil
+	}
+
+	var suggestions []Suggestion
+	for _, match := range c.symbols.Lookup(word, DefaultLimit*2) {
+		// Matching from the start of the name beats matching a later word in it
+		score := 0.55 + 0.2*match.Weight
+		if match.WordStart {
+			score -= 0.15
+		}
+		suggestions = append(suggestions, Suggestion{
+			Text:   input[:start] + match.Value,
+			Kind:   KindSymbol,
+			Detail: match.Detail,
+			Score:  score,
+		})
+	}
+	return suggestions
+}
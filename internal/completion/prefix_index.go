@@ -0,0 +1,146 @@
+package completion
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PrefixIndex finds names by a case-insensitive prefix of the name or of
+// any word in it, so "embed" finds GenerateOpenAIEmbedding
+type PrefixIndex struct {
+	keys    []indexKey
+	values  []*indexValue
+	byValue map[string]int
+}
+
+// indexKey is the lowercased name from one of its word starts
+type indexKey struct {
+	key       string
+	value     int
+	wordStart bool // the key starts at a later word, not the start of the name
+}
+
+type indexValue struct {
+	value  string
+	detail string
+	count  int
+	weight float64
+}
+
+// Match is a name found by prefix
+type Match struct {
+	Value     string  `json:"value"`
+	Detail    string  `json:"detail"`
+	Weight    float64 `json:"weight"`     // 1 for exported names, 0.5 otherwise
+	WordStart bool    `json:"word_start"` // matched a later word of the name
+}
+
+// NewPrefixIndex creates an empty index; Add names, then Build it
+func NewPrefixIndex() *PrefixIndex {
+	return &PrefixIndex{byValue: make(map[string]int)}
+}
+
+// Add indexes a name. A name added again keeps its first detail and notes
+// how many more definitions there are.
+func (pi *PrefixIndex) Add(value, detail string) {
+	if value == "" {
+		return
+	}
+	if i, ok := pi.byValue[value]; ok {
+		pi.values[i].count++
+		return
+	}
+
+	weight := 0.5
+	if first := value[0]; first >= 'A' && first <= 'Z' {
+		weight = 1
+	}
+	id := len(pi.values)
+	pi.byValue[value] = id
+	pi.values = append(pi.values, &indexValue{value: value, detail: detail, count: 1, weight: weight})
+	for _, start := range wordStarts(value) {
+		pi.keys = append(pi.keys, indexKey{
+			key:       strings.ToLower(value[start:]),
+			value:     id,
+			wordStart: start > 0,
+		})
+	}
+}
+
+// Build sorts the index; call it after the last Add
+func (pi *PrefixIndex) Build() {
+	sort.Slice(pi.keys, func(i, j int) bool {
+		return pi.keys[i].key < pi.keys[j].key
+	})
+	for _, v := range pi.values {
+		if v.count > 1 {
+			v.detail = fmt.Sprintf("%s (+%d more)", v.detail, v.count-1)
+		}
+	}
+}
+
+// Len returns the number of distinct names
+func (pi *PrefixIndex) Len() int {
+	return len(pi.values)
+}
+
+// Lookup returns up to limit names with a word starting with prefix.
+// Names starting with it come first, then exported names, then shorter ones.
+func (pi *PrefixIndex) Lookup(prefix string, limit int) []Match {
+	prefix = strings.ToLower(prefix)
+	first := sort.Search(len(pi.keys), func(i int) bool {
+		return pi.keys[i].key >= prefix
+	})
+
+	best := make(map[int]bool) // value -> matched only at a later word
+	for i := first; i < len(pi.keys) && strings.HasPrefix(pi.keys[i].key, prefix); i++ {
+		key := pi.keys[i]
+		if wordStart, seen := best[key.value]; !seen || (wordStart && !key.wordStart) {
+			best[key.value] = key.wordStart
+		}
+	}
+
+	matches := make([]Match, 0, len(best))
+	for id, wordStart := range best {
+		v := pi.values[id]
+		matches = append(matches, Match{Value: v.value, Detail: v.detail, Weight: v.weight, WordStart: wordStart})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.WordStart != b.WordStart {
+			return !a.WordStart
+		}
+		if a.Weight != b.Weight {
+			return a.Weight > b.Weight
+		}
+		if len(a.Value) != len(b.Value) {
+			return len(a.Value) < len(b.Value)
+		}
+		return a.Value < b.Value
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// wordStarts returns where each word of a camelCase or snake_case name
+// begins: HTTPServerConfig starts words at H, S and C
+func wordStarts(name string) []int {
+	starts := []int{0}
+	isUpper := func(b byte) bool { return b >= 'A' && b <= 'Z' }
+	isLowerOrDigit := func(b byte) bool { return b >= 'a' && b <= 'z' || b >= '0' && b <= '9' }
+	for i := 1; i < len(name); i++ {
+		prev, cur := name[i-1], name[i]
+		switch {
+		case prev == '_' && cur != '_':
+			starts = append(starts, i)
+		case isUpper(cur) && isLowerOrDigit(prev):
+			starts = append(starts, i)
+		case isUpper(cur) && isUpper(prev) && i+1 < len(name) && name[i+1] >= 'a' && name[i+1] <= 'z':
+			starts = append(starts, i)
+		}
+	}
+	return starts
+}
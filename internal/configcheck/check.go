@@ -0,0 +1,184 @@
+// Package configcheck validates configuration structs against rules in
+// their struct tags, reporting each problem under the key it was read from.
+//
+// Fields are named by their mapstructure tag and checked against a
+// validate tag holding comma-separated rules:
+//
+//	required     the value must be set
+//	omitempty    skip the other rules when the value is not set
+//	min=N max=N  bounds for numbers and durations, or lengths of strings and lists
+//	oneof=a b c  the value must be one of the listed words
+package configcheck
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Issue is one problem with a configuration value
+type Issue struct {
+	Key     string `json:"key"`
+	Message string `json:"message"`
+}
+
+// String renders an issue as "key message"
+func (i Issue) String() string {
+	return i.Key + " " + i.Message
+}
+
+// Issues are the problems found in a configuration; as an error they list
+// every problem, one per line
+type Issues []Issue
+
+// Error implements error
+func (is Issues) Error() string {
+	lines := make([]string, len(is))
+	for i, issue := range is {
+		lines[i] = "  " + issue.String()
+	}
+	return fmt.Sprintf("invalid configuration (%d problem(s)):\n%s", len(is), strings.Join(lines, "\n"))
+}
+
+// Add records a problem with key
+func (is *Issues) Add(key, format string, args ...interface{}) {
+	*is = append(*is, Issue{Key: key, Message: fmt.Sprintf(format, args...)})
+}
+
+// Check validates v, a struct or pointer to one, against its validate tags
+func Check(v interface{}) Issues {
+	var issues Issues
+	value := reflect.Indirect(reflect.ValueOf(v))
+	if value.Kind() == reflect.Struct {
+		checkStruct(value, "", &issues)
+	}
+	return issues
+}
+
+// checkStruct validates the tagged fields of a struct and recurses into
+// nested structs
+func checkStruct(value reflect.Value, prefix string, issues *Issues) {
+	typ := value.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		name := strings.Split(field.Tag.Get("mapstructure"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+
+		fieldValue := value.Field(i)
+		if fieldValue.Kind() == reflect.Struct && fieldValue.Type() != reflect.TypeOf(time.Duration(0)) {
+			checkStruct(fieldValue, key, issues)
+			continue
+		}
+		if rules := field.Tag.Get("validate"); rules != "" {
+			checkField(fieldValue, key, rules, issues)
+		}
+	}
+}
+
+// checkField applies one field's rules, reporting the first that fails
+func checkField(value reflect.Value, key, rules string, issues *Issues) {
+	ruleList := strings.Split(rules, ",")
+	for _, rule := range ruleList {
+		if rule == "omitempty" && value.IsZero() {
+			return
+		}
+	}
+
+	for _, rule := range ruleList {
+		name, arg, _ := strings.Cut(rule, "=")
+		switch name {
+		case "omitempty":
+		case "required":
+			if value.IsZero() {
+				issues.Add(key, "is required")
+				return
+			}
+		case "min", "max":
+			if message := checkBound(value, name, arg); message != "" {
+				issues.Add(key, "%s", message)
+				return
+			}
+		case "oneof":
+			allowed := strings.Fields(arg)
+			got := fmt.Sprint(value.Interface())
+			if !contains(allowed, got) {
+				issues.Add(key, "must be one of %s (got %q)", strings.Join(allowed, ", "), got)
+				return
+			}
+		default:
+			issues.Add(key, "has unknown validation rule %q", rule)
+			return
+		}
+	}
+}
+
+// checkBound checks a min or max rule, returning the problem or ""
+func checkBound(value reflect.Value, rule, arg string) string {
+	var (
+		got, limit float64
+		describe   = func(n float64) string { return strconv.FormatFloat(n, 'g', -1, 64) }
+		err        error
+	)
+	switch {
+	case value.Type() == reflect.TypeOf(time.Duration(0)):
+		var bound time.Duration
+		bound, err = time.ParseDuration(arg)
+		got, limit = float64(value.Int()), float64(bound)
+		describe = func(n float64) string { return time.Duration(n).String() }
+	case value.CanInt():
+		got = float64(value.Int())
+		limit, err = strconv.ParseFloat(arg, 64)
+	case value.CanUint():
+		got = float64(value.Uint())
+		limit, err = strconv.ParseFloat(arg, 64)
+	case value.CanFloat():
+		got = value.Float()
+		limit, err = strconv.ParseFloat(arg, 64)
+	case value.Kind() == reflect.String || value.Kind() == reflect.Slice || value.Kind() == reflect.Map:
+		got = float64(value.Len())
+		if limit, err = strconv.ParseFloat(arg, 64); err != nil {
+			return fmt.Sprintf("has an invalid %s rule %q", rule, arg)
+		}
+		unit := "entries"
+		if value.Kind() == reflect.String {
+			unit = "characters"
+		}
+		if rule == "min" && got < limit {
+			return fmt.Sprintf("needs at least %s %s (got %d)", describe(limit), unit, value.Len())
+		}
+		if rule == "max" && got > limit {
+			return fmt.Sprintf("allows at most %s %s (got %d)", describe(limit), unit, value.Len())
+		}
+		return ""
+	default:
+		return fmt.Sprintf("cannot be checked with %s", rule)
+	}
+	if err != nil {
+		return fmt.Sprintf("has an invalid %s rule %q", rule, arg)
+	}
+
+	if rule == "min" && got < limit {
+		return fmt.Sprintf("must be at least %s (got %s)", describe(limit), describe(got))
+	}
+	if rule == "max" && got > limit {
+		return fmt.Sprintf("must be at most %s (got %s)", describe(limit), describe(got))
+	}
+	return ""
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
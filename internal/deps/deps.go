@@ -0,0 +1,314 @@
+// Package deps locates the source of selected Go dependencies in the module
+// cache so it can be indexed into a separate, opt-in collection.
+package deps
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// IncludeToken opts a single query into dependency results
+const IncludeToken = "include:deps"
+
+// Origin labels chunks and results that come from dependency source
+const Origin = "deps"
+
+// Config controls dependency indexing
+type Config struct {
+	Enabled           bool     `json:"enabled"`
+	Modules           []string `json:"modules"`    // module paths or path prefixes to index
+	Collection        string   `json:"collection"` // kept apart from the project collection
+	MaxFilesPerModule int      `json:"max_files_per_module"`
+	IncludeTests      bool     `json:"include_tests"`
+}
+
+// DefaultConfig returns dependency indexing defaults; it is off until modules are selected
+func DefaultConfig() Config {
+	return Config{
+		Enabled:           false,
+		Collection:        "code_embeddings_deps",
+		MaxFilesPerModule: 500,
+	}
+}
+
+// Module is a required module resolved to its directory in the module cache
+type Module struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+	Dir     string `json:"dir"`
+}
+
+// Label is how files of the module are named in results, e.g. "github.com/spf13/viper@v1.18.2"
+func (m Module) Label() string {
+	return m.Path + "@" + m.Version
+}
+
+// ParseIncludeToken removes the include:deps token from a query and reports
+// whether it was present
+func ParseIncludeToken(input string) (string, bool) {
+	fields := strings.Fields(input)
+	kept := fields[:0]
+	found := false
+	for _, field := range fields {
+		if strings.EqualFold(field, IncludeToken) {
+			found = true
+			continue
+		}
+		kept = append(kept, field)
+	}
+	if !found {
+		return input, false
+	}
+	return strings.Join(kept, " "), true
+}
+
+// ModuleCacheDir returns GOMODCACHE, asking the go tool when it is not set
+func ModuleCacheDir() (string, error) {
+	if dir := os.Getenv("GOMODCACHE"); dir != "" {
+		return dir, nil
+	}
+	out, err := exec.Command("go", "env", "GOMODCACHE").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate module cache: %w", err)
+	}
+	dir := strings.TrimSpace(string(out))
+	if dir == "" {
+		return "", fmt.Errorf("module cache location is empty")
+	}
+	return dir, nil
+}
+
+// ReadRequirements returns the module path and version of every require
+// directive in a go.mod file, honouring replace directives that pin a version
+func ReadRequirements(goModPath string) (map[string]string, error) {
+	file, err := os.Open(goModPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", goModPath, err)
+	}
+	defer file.Close()
+
+	required := make(map[string]string)
+	replaced := make(map[string]string)
+	block := ""
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		switch {
+		case line == ")":
+			block = ""
+			continue
+		case strings.HasSuffix(line, "("):
+			block = strings.TrimSpace(strings.TrimSuffix(line, "("))
+			continue
+		}
+
+		directive := block
+		if directive == "" {
+			parts := strings.SplitN(line, " ", 2)
+			if len(parts) < 2 {
+				continue
+			}
+			directive, line = parts[0], strings.TrimSpace(parts[1])
+		}
+
+		fields := strings.Fields(line)
+		switch directive {
+		case "require":
+			if len(fields) >= 2 {
+				required[fields[0]] = fields[1]
+			}
+		case "replace":
+			// path [version] => newpath newversion; only module replacements
+			// with a version live in the module cache
+			if arrow := indexOf(fields, "=>"); arrow > 0 && len(fields) == arrow+3 {
+				replaced[fields[0]] = fields[arrow+1] + "@" + fields[arrow+2]
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", goModPath, err)
+	}
+
+	for path, target := range replaced {
+		if _, ok := required[path]; ok {
+			required[path] = target
+		}
+	}
+	return required, nil
+}
+
+// ModulePath returns the path declared by the module directive of a go.mod
+// file
+func ModulePath(goModPath string) (string, error) {
+	data, err := os.ReadFile(goModPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", goModPath, err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`), nil
+		}
+	}
+	return "", fmt.Errorf("%s has no module directive", goModPath)
+}
+
+// Resolve selects the required modules matching the configured paths or
+// prefixes and locates them in the module cache. Modules that have not been
+// downloaded are reported in missing rather than failing the whole run.
+func Resolve(goModPath string, selected []string) (modules []Module, missing []string, err error) {
+	required, err := ReadRequirements(goModPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	cacheDir, err := ModuleCacheDir()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for path, version := range required {
+		if !matchesSelection(path, selected) {
+			continue
+		}
+		modulePath := path
+		if target, targetVersion, ok := strings.Cut(version, "@"); ok {
+			modulePath, version = target, targetVersion
+		}
+
+		dir := filepath.Join(cacheDir, escapePath(modulePath)+"@"+version)
+		if info, statErr := os.Stat(dir); statErr != nil || !info.IsDir() {
+			missing = append(missing, path+"@"+version)
+			continue
+		}
+		modules = append(modules, Module{Path: path, Version: version, Dir: dir})
+	}
+
+	sort.Slice(modules, func(i, j int) bool { return modules[i].Path < modules[j].Path })
+	sort.Strings(missing)
+	return modules, missing, nil
+}
+
+// CachedVersions lists the versions of a module downloaded to the module
+// cache, in no particular order
+func CachedVersions(path string) ([]string, error) {
+	cacheDir, err := ModuleCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	escaped := escapePath(path)
+	entries, err := os.ReadDir(filepath.Join(cacheDir, filepath.Dir(escaped)))
+	if err != nil {
+		return nil, nil
+	}
+	prefix := filepath.Base(escaped) + "@"
+	var versions []string
+	for _, entry := range entries {
+		if version, ok := strings.CutPrefix(entry.Name(), prefix); ok && entry.IsDir() {
+			versions = append(versions, version)
+		}
+	}
+	return versions, nil
+}
+
+// ModuleDir returns the module cache directory of one version of a module,
+// or "" when that version has not been downloaded
+func ModuleDir(path, version string) string {
+	cacheDir, err := ModuleCacheDir()
+	if err != nil {
+		return ""
+	}
+	dir := filepath.Join(cacheDir, escapePath(path)+"@"+version)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return ""
+	}
+	return dir
+}
+
+// SourceFiles lists the Go files of a module, skipping vendored code,
+// testdata and (unless includeTests) tests, up to maxFiles
+func SourceFiles(module Module, maxFiles int, includeTests bool) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(module.Dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil // unreadable subtrees are skipped, not fatal
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != module.Dir && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") || (!includeTests && strings.HasSuffix(path, "_test.go")) {
+			return nil
+		}
+		files = append(files, path)
+		if maxFiles > 0 && len(files) >= maxFiles {
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files of %s: %w", module.Label(), err)
+	}
+	return files, nil
+}
+
+// DisplayPath names a module file in results, e.g. "github.com/spf13/viper@v1.18.2/viper.go"
+func DisplayPath(module Module, file string) string {
+	rel, err := filepath.Rel(module.Dir, file)
+	if err != nil {
+		rel = filepath.Base(file)
+	}
+	return module.Label() + "/" + filepath.ToSlash(rel)
+}
+
+// matchesSelection reports whether a module path equals or falls under one of the selected paths
+func matchesSelection(path string, selected []string) bool {
+	for _, s := range selected {
+		s = strings.TrimSuffix(strings.TrimSpace(s), "/...")
+		if s != "" && (path == s || strings.HasPrefix(path, s+"/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// escapePath applies the module cache's case encoding: upper-case letters
+// become '!' followed by the lower-case letter
+func escapePath(path string) string {
+	var b strings.Builder
+	for _, r := range path {
+		if unicode.IsUpper(r) {
+			b.WriteByte('!')
+			b.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func indexOf(fields []string, value string) int {
+	for i, f := range fields {
+		if f == value {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,360 @@
+package deps
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// InsightConfig controls how generated code is checked against go.mod
+type InsightConfig struct {
+	Enabled bool `json:"enabled"` // list go.mod dependencies in prompts and propose additions
+	GoGet   bool `json:"go_get"`  // run go get for proposed modules when changes are applied
+}
+
+// DefaultInsightConfig returns dependency insight defaults; go get stays
+// opt-in because it edits go.mod and reaches the module proxy
+func DefaultInsightConfig() InsightConfig {
+	return InsightConfig{
+		Enabled: true,
+		GoGet:   false,
+	}
+}
+
+// categories groups well-known modules by what they are used for, so a new
+// module can be matched against one the project already uses for the same job
+var categories = map[string][]string{
+	"http router": {
+		"github.com/gin-gonic/gin", "github.com/labstack/echo", "github.com/go-chi/chi",
+		"github.com/gorilla/mux", "github.com/gofiber/fiber", "github.com/julienschmidt/httprouter",
+	},
+	"logging": {
+		"go.uber.org/zap", "github.com/sirupsen/logrus", "github.com/rs/zerolog", "github.com/charmbracelet/log",
+	},
+	"config":    {"github.com/spf13/viper", "github.com/kelseyhightower/envconfig", "github.com/knadh/koanf"},
+	"cli":       {"github.com/spf13/cobra", "github.com/urfave/cli", "github.com/alecthomas/kong"},
+	"database":  {"github.com/jmoiron/sqlx", "gorm.io/gorm", "github.com/jackc/pgx", "github.com/lib/pq", "github.com/mattn/go-sqlite3", "modernc.org/sqlite"},
+	"testing":   {"github.com/stretchr/testify", "github.com/golang/mock", "go.uber.org/mock", "github.com/onsi/ginkgo"},
+	"uuid":      {"github.com/google/uuid", "github.com/gofrs/uuid", "github.com/satori/go.uuid"},
+	"yaml":      {"gopkg.in/yaml.v2", "gopkg.in/yaml.v3", "sigs.k8s.io/yaml", "github.com/goccy/go-yaml"},
+	"websocket": {"github.com/gorilla/websocket", "nhooyr.io/websocket", "github.com/coder/websocket"},
+	"jwt":       {"github.com/golang-jwt/jwt", "github.com/dgrijalva/jwt-go", "github.com/lestrrat-go/jwx"},
+	"terminal color": {
+		"github.com/fatih/color", "github.com/charmbracelet/lipgloss", "github.com/logrusorgru/aurora",
+	},
+}
+
+// Requirement is one module required by go.mod
+type Requirement struct {
+	Path     string `json:"path"`
+	Version  string `json:"version"`
+	Indirect bool   `json:"indirect"`
+	Category string `json:"category,omitempty"`
+}
+
+// Inventory is what a project's go.mod already provides
+type Inventory struct {
+	Module       string        `json:"module"`
+	Requirements []Requirement `json:"requirements"` // sorted by path
+}
+
+// Proposal is a module generated code imports that go.mod does not require
+type Proposal struct {
+	Path         string   `json:"path"`
+	Version      string   `json:"version,omitempty"` // empty when no version could be pinned
+	Category     string   `json:"category,omitempty"`
+	Imports      []string `json:"imports"`
+	Alternatives []string `json:"alternatives,omitempty"` // required modules for the same job
+}
+
+// Pinned returns the go get argument for the proposal
+func (p Proposal) Pinned() string {
+	if p.Version == "" {
+		return p.Path + "@latest"
+	}
+	return p.Path + "@" + p.Version
+}
+
+// ReadInventory reads the module path and requirements of a go.mod file
+func ReadInventory(goModPath string) (*Inventory, error) {
+	modulePath, err := ModulePath(goModPath)
+	if err != nil {
+		return nil, err
+	}
+	required, err := ReadRequirements(goModPath)
+	if err != nil {
+		return nil, err
+	}
+	indirect := readIndirect(goModPath)
+
+	inventory := &Inventory{Module: modulePath}
+	for path, version := range required {
+		if _, targetVersion, ok := strings.Cut(version, "@"); ok {
+			version = targetVersion
+		}
+		inventory.Requirements = append(inventory.Requirements, Requirement{
+			Path:     path,
+			Version:  version,
+			Indirect: indirect[path],
+			Category: CategoryOf(path),
+		})
+	}
+	sort.Slice(inventory.Requirements, func(i, j int) bool {
+		return inventory.Requirements[i].Path < inventory.Requirements[j].Path
+	})
+	return inventory, nil
+}
+
+// readIndirect returns the requirements marked // indirect
+func readIndirect(goModPath string) map[string]bool {
+	indirect := make(map[string]bool)
+	file, err := os.Open(goModPath)
+	if err != nil {
+		return indirect
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line, comment, ok := strings.Cut(scanner.Text(), "//")
+		if !ok || strings.TrimSpace(comment) != "indirect" {
+			continue
+		}
+		fields := strings.Fields(strings.TrimPrefix(strings.TrimSpace(line), "require"))
+		if len(fields) >= 2 {
+			indirect[fields[0]] = true
+		}
+	}
+	return indirect
+}
+
+// CategoryOf returns the category of a well-known module, or ""
+func CategoryOf(modulePath string) string {
+	for category, modules := range categories {
+		for _, known := range modules {
+			if modulePath == known || strings.HasPrefix(modulePath, known+"/") {
+				return category
+			}
+		}
+	}
+	return ""
+}
+
+// Provides reports whether the project itself or one of its requirements
+// provides an import path
+func (inv *Inventory) Provides(importPath string) bool {
+	if within(importPath, inv.Module) {
+		return true
+	}
+	for _, req := range inv.Requirements {
+		if within(importPath, req.Path) {
+			return true
+		}
+	}
+	return false
+}
+
+// Direct returns the requirements of a category the project imports itself
+func (inv *Inventory) Direct(category string) []Requirement {
+	var matches []Requirement
+	for _, req := range inv.Requirements {
+		if req.Category == category && !req.Indirect {
+			matches = append(matches, req)
+		}
+	}
+	return matches
+}
+
+// PromptSection lists the direct requirements for a generation prompt,
+// grouped by category, and asks for new modules to be named with a version
+func (inv *Inventory) PromptSection() string {
+	var categorized, other []string
+	for _, req := range inv.Requirements {
+		if req.Indirect {
+			continue
+		}
+		if req.Category != "" {
+			categorized = append(categorized, fmt.Sprintf("- %s: %s %s\n", req.Category, req.Path, req.Version))
+		} else {
+			other = append(other, req.Path)
+		}
+	}
+	if len(categorized) == 0 && len(other) == 0 {
+		return ""
+	}
+
+	var section strings.Builder
+	section.WriteString("\nDependencies already in go.mod (prefer these over new modules):\n")
+	for _, line := range categorized {
+		section.WriteString(line)
+	}
+	if len(other) > 0 {
+		fmt.Fprintf(&section, "- other: %s\n", strings.Join(other, ", "))
+	}
+	section.WriteString("Use the standard library or the modules above where they fit. If a new module is unavoidable, " +
+		"say so and name it as `go get <module>@<version>` with a released version.\n")
+	return section.String()
+}
+
+// Propose returns a proposal for every third-party module the Go code
+// imports that go.mod does not already require. text is the full model
+// answer, searched for the go get lines that pin versions.
+func (inv *Inventory) Propose(code, text string) []Proposal {
+	pins := goGetPins(text)
+	byModule := make(map[string]*Proposal)
+	var order []string
+	for _, importPath := range ImportsOf(code) {
+		if !thirdParty(importPath) || inv.Provides(importPath) {
+			continue
+		}
+		modulePath := pinnedModule(importPath, pins)
+		if modulePath == "" {
+			modulePath = ModuleRoot(importPath)
+		}
+		proposal, ok := byModule[modulePath]
+		if !ok {
+			proposal = &Proposal{Path: modulePath, Version: pins[modulePath], Category: CategoryOf(modulePath)}
+			if proposal.Version == "" {
+				proposal.Version = latestCached(modulePath)
+			}
+			if proposal.Category != "" {
+				for _, req := range inv.Direct(proposal.Category) {
+					proposal.Alternatives = append(proposal.Alternatives, req.Path)
+				}
+			}
+			byModule[modulePath] = proposal
+			order = append(order, modulePath)
+		}
+		proposal.Imports = append(proposal.Imports, importPath)
+	}
+
+	proposals := make([]Proposal, 0, len(order))
+	for _, modulePath := range order {
+		proposals = append(proposals, *byModule[modulePath])
+	}
+	return proposals
+}
+
+var (
+	importLinePattern  = regexp.MustCompile(`(?m)^\s*import\s+(?:[\w.]+\s+)?"([^"]+)"`)
+	importBlockPattern = regexp.MustCompile(`(?s)import\s*\((.*?)\)`)
+	importSpecPattern  = regexp.MustCompile(`(?m)^\s*(?:[\w.]+\s+)?"([^"]+)"`)
+	goGetPattern       = regexp.MustCompile(`go get\s+(?:-u\s+)?([\w.\-/~]+)@(v[\w.\-+]+)`)
+)
+
+// ImportsOf returns the import paths in Go code, which may be a bare file or
+// a model answer with fenced blocks, in order of first appearance
+func ImportsOf(code string) []string {
+	seen := make(map[string]bool)
+	var imports []string
+	add := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			imports = append(imports, path)
+		}
+	}
+	for _, match := range importLinePattern.FindAllStringSubmatch(code, -1) {
+		add(match[1])
+	}
+	for _, block := range importBlockPattern.FindAllStringSubmatch(code, -1) {
+		for _, match := range importSpecPattern.FindAllStringSubmatch(block[1], -1) {
+			add(match[1])
+		}
+	}
+	return imports
+}
+
+// goGetPins returns the versions a text pins with go get module@version
+func goGetPins(text string) map[string]string {
+	pins := make(map[string]string)
+	for _, match := range goGetPattern.FindAllStringSubmatch(text, -1) {
+		pins[match[1]] = match[2]
+	}
+	return pins
+}
+
+// pinnedModule returns the pinned module an import path belongs to, the
+// longest if several match
+func pinnedModule(importPath string, pins map[string]string) string {
+	best := ""
+	for modulePath := range pins {
+		if within(importPath, modulePath) && len(modulePath) > len(best) {
+			best = modulePath
+		}
+	}
+	return best
+}
+
+// thirdParty reports whether an import path is outside the standard library
+func thirdParty(importPath string) bool {
+	first, _, _ := strings.Cut(importPath, "/")
+	return strings.Contains(first, ".")
+}
+
+// within reports whether importPath is modulePath or one of its packages
+func within(importPath, modulePath string) bool {
+	return importPath == modulePath || strings.HasPrefix(importPath, modulePath+"/")
+}
+
+var majorVersionPattern = regexp.MustCompile(`^v[0-9]+$`)
+
+// ModuleRoot guesses the module an import path belongs to from the layout of
+// the common hosts: three elements for github.com/owner/repo and
+// golang.org/x/name, plus a major version suffix when present
+func ModuleRoot(importPath string) string {
+	parts := strings.Split(importPath, "/")
+	n := len(parts)
+	switch parts[0] {
+	case "github.com", "gitlab.com", "bitbucket.org", "golang.org":
+		n = 3
+	case "gopkg.in", "go.uber.org", "google.golang.org", "gorm.io", "modernc.org", "nhooyr.io", "sigs.k8s.io":
+		n = 2
+	}
+	if n > len(parts) {
+		return importPath
+	}
+	if n < len(parts) && majorVersionPattern.MatchString(parts[n]) {
+		n++
+	}
+	return strings.Join(parts[:n], "/")
+}
+
+// latestCached returns the highest released version of a module in the
+// module cache, or ""
+func latestCached(modulePath string) string {
+	versions, err := CachedVersions(modulePath)
+	if err != nil {
+		return ""
+	}
+	latest := ""
+	for _, version := range versions {
+		if strings.Contains(version, "-") {
+			continue // pre-releases and pseudo-versions
+		}
+		if latest == "" || compareVersions(version, latest) > 0 {
+			latest = version
+		}
+	}
+	return latest
+}
+
+// compareVersions orders vMAJOR.MINOR.PATCH versions numerically
+func compareVersions(a, b string) int {
+	as := strings.Split(strings.TrimPrefix(strings.TrimSuffix(a, "+incompatible"), "v"), ".")
+	bs := strings.Split(strings.TrimPrefix(strings.TrimSuffix(b, "+incompatible"), "v"), ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		x, _ := strconv.Atoi(as[i])
+		y, _ := strconv.Atoi(bs[i])
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return len(as) - len(bs)
+}
@@ -0,0 +1,437 @@
+package diagnostics
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// Analyzer sources recorded with ingested diagnostics
+const (
+	SourceGopls        = "gopls"
+	SourceGolangciLint = "golangci-lint"
+)
+
+// Config controls the analyzer diagnostics offered to queries
+type Config struct {
+	Enabled    bool   `json:"enabled"`
+	File       string `json:"file"`        // analyzer output re-ingested whenever it changes, relative to the project root
+	Socket     string `json:"socket"`      // language server address ingested by "diagnostics refresh": host:port, or unix:path
+	MaxEntries int    `json:"max_entries"` // diagnostics added to one prompt
+}
+
+// DefaultConfig returns analyzer diagnostics defaults
+func DefaultConfig() Config {
+	return Config{Enabled: true, MaxEntries: 20}
+}
+
+// analyzerLinePattern matches a diagnostic line of gopls check, go vet or
+// golangci-lint: path/file.go:12:5: message, where gopls gives the column
+// as a span (12:5-9 or 12:5-14:2) and golangci-lint ends with (linter)
+var analyzerLinePattern = regexp.MustCompile(`^(\S+?\.go):(\d+)(?::(\d+)(?:-(\d+)(?::(\d+))?)?)?: (.+)$`)
+
+// linterSuffixPattern matches the linter name golangci-lint appends
+var linterSuffixPattern = regexp.MustCompile(`\s+\(([\w-]+)\)$`)
+
+// ParseAnalyzerOutput reads the diagnostics of an analyzer run in any of the
+// formats it can be saved in: golangci-lint JSON (--out-format json), LSP
+// publishDiagnostics notifications or their params, one JSON value or one
+// per line, or text lines as gopls check and golangci-lint print them.
+// Paths are made relative to root. A non-empty source overrides the tool
+// detected from the format.
+func ParseAnalyzerOutput(data []byte, root, source string) ([]*storage.AnalyzerDiagnostic, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return nil, nil
+	}
+	var diagnostics []*storage.AnalyzerDiagnostic
+	var err error
+	if trimmed[0] == '{' || trimmed[0] == '[' {
+		diagnostics, err = parseAnalyzerJSON(trimmed, root)
+	} else {
+		diagnostics = parseAnalyzerText(string(trimmed), root)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if source != "" {
+		for _, diagnostic := range diagnostics {
+			diagnostic.Source = source
+		}
+	}
+	return diagnostics, nil
+}
+
+// DetectSource names the tool whose output data is when the format tells,
+// so that a clean run, which lists nothing, still clears its earlier
+// findings. Text output does not tell.
+func DetectSource(data []byte) string {
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case len(trimmed) == 0 || (trimmed[0] != '{' && trimmed[0] != '['):
+		return ""
+	case bytes.Contains(trimmed, []byte(`"Issues"`)):
+		return SourceGolangciLint
+	case bytes.Contains(trimmed, []byte(`"diagnostics"`)):
+		return SourceGopls
+	}
+	return ""
+}
+
+// golangciReport is the part of golangci-lint's JSON output read here
+type golangciReport struct {
+	Issues []struct {
+		FromLinter string `json:"FromLinter"`
+		Text       string `json:"Text"`
+		Severity   string `json:"Severity"`
+		Pos        struct {
+			Filename string `json:"Filename"`
+			Line     int    `json:"Line"`
+			Column   int    `json:"Column"`
+		} `json:"Pos"`
+	} `json:"Issues"`
+}
+
+// lspDiagnostics is a publishDiagnostics notification, or its params
+type lspDiagnostics struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	URI    string          `json:"uri"`
+	Items  []struct {
+		Range struct {
+			Start struct {
+				Line      int `json:"line"`
+				Character int `json:"character"`
+			} `json:"start"`
+			End struct {
+				Line int `json:"line"`
+			} `json:"end"`
+		} `json:"range"`
+		Severity int             `json:"severity"`
+		Code     json.RawMessage `json:"code"` // a string or a number
+		Source   string          `json:"source"`
+		Message  string          `json:"message"`
+	} `json:"diagnostics"`
+}
+
+// parseAnalyzerJSON reads golangci-lint's report or LSP diagnostics, as one
+// value, an array of them or one per line
+func parseAnalyzerJSON(data []byte, root string) ([]*storage.AnalyzerDiagnostic, error) {
+	var diagnostics []*storage.AnalyzerDiagnostic
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	for decoder.More() {
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, fmt.Errorf("failed to parse analyzer output: %w", err)
+		}
+		values := []json.RawMessage{value}
+		if bytes.HasPrefix(bytes.TrimSpace(value), []byte("[")) {
+			values = nil
+			if err := json.Unmarshal(value, &values); err != nil {
+				return nil, fmt.Errorf("failed to parse analyzer output: %w", err)
+			}
+		}
+		for _, value := range values {
+			parsed, err := parseAnalyzerValue(value, root)
+			if err != nil {
+				return nil, err
+			}
+			diagnostics = append(diagnostics, parsed...)
+		}
+	}
+	return diagnostics, nil
+}
+
+// parseAnalyzerValue reads one JSON value of analyzer output
+func parseAnalyzerValue(value json.RawMessage, root string) ([]*storage.AnalyzerDiagnostic, error) {
+	var report golangciReport
+	if bytes.Contains(value, []byte(`"Issues"`)) {
+		if err := json.Unmarshal(value, &report); err != nil {
+			return nil, fmt.Errorf("failed to parse golangci-lint output: %w", err)
+		}
+		diagnostics := make([]*storage.AnalyzerDiagnostic, 0, len(report.Issues))
+		for _, issue := range report.Issues {
+			diagnostics = append(diagnostics, &storage.AnalyzerDiagnostic{
+				Source:   SourceGolangciLint,
+				File:     relativePath(issue.Pos.Filename, root),
+				Line:     issue.Pos.Line,
+				Column:   issue.Pos.Column,
+				Severity: analyzerSeverity(issue.Severity),
+				Code:     issue.FromLinter,
+				Message:  issue.Text,
+			})
+		}
+		return diagnostics, nil
+	}
+
+	var published lspDiagnostics
+	if err := json.Unmarshal(value, &published); err != nil {
+		return nil, fmt.Errorf("failed to parse LSP diagnostics: %w", err)
+	}
+	if published.Method != "" {
+		if published.Method != "textDocument/publishDiagnostics" {
+			return nil, nil
+		}
+		return parseAnalyzerValue(published.Params, root)
+	}
+	if published.URI == "" {
+		return nil, fmt.Errorf("unrecognized analyzer output; expected golangci-lint JSON or LSP diagnostics")
+	}
+	path, err := uriPath(published.URI)
+	if err != nil {
+		return nil, err
+	}
+	file := relativePath(path, root)
+	diagnostics := make([]*storage.AnalyzerDiagnostic, 0, len(published.Items))
+	for _, item := range published.Items {
+		// LSP positions are 0-based
+		diagnostics = append(diagnostics, &storage.AnalyzerDiagnostic{
+			Source:   SourceGopls,
+			File:     file,
+			Line:     item.Range.Start.Line + 1,
+			Column:   item.Range.Start.Character + 1,
+			EndLine:  item.Range.End.Line + 1,
+			Severity: lspSeverity(item.Severity),
+			Code:     lspCode(item.Source, item.Code),
+			Message:  item.Message,
+		})
+	}
+	return diagnostics, nil
+}
+
+// parseAnalyzerText reads diagnostic lines, skipping everything else
+func parseAnalyzerText(output, root string) []*storage.AnalyzerDiagnostic {
+	var diagnostics []*storage.AnalyzerDiagnostic
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		match := analyzerLinePattern.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if match == nil {
+			continue
+		}
+		diagnostic := &storage.AnalyzerDiagnostic{
+			Source:   SourceGopls,
+			File:     relativePath(match[1], root),
+			Severity: storage.AnalyzerSeverityWarning,
+			Message:  match[6],
+		}
+		diagnostic.Line, _ = strconv.Atoi(match[2])
+		diagnostic.Column, _ = strconv.Atoi(match[3])
+		if match[5] != "" {
+			diagnostic.EndLine, _ = strconv.Atoi(match[4])
+		}
+		if linter := linterSuffixPattern.FindStringSubmatch(diagnostic.Message); linter != nil {
+			diagnostic.Source = SourceGolangciLint
+			diagnostic.Code = linter[1]
+			diagnostic.Message = strings.TrimSuffix(diagnostic.Message, linter[0])
+		}
+		diagnostics = append(diagnostics, diagnostic)
+	}
+	return diagnostics
+}
+
+// uriPath returns the file path of a file:// URI
+func uriPath(uri string) (string, error) {
+	parsed, err := url.Parse(uri)
+	if err != nil || parsed.Scheme != "file" {
+		return "", fmt.Errorf("diagnostics for %s, which is not a file URI", uri)
+	}
+	return filepath.FromSlash(parsed.Path), nil
+}
+
+// lspSeverity names an LSP DiagnosticSeverity; servers may omit it
+func lspSeverity(severity int) string {
+	switch severity {
+	case 1:
+		return storage.AnalyzerSeverityError
+	case 3:
+		return storage.AnalyzerSeverityInfo
+	case 4:
+		return storage.AnalyzerSeverityHint
+	}
+	return storage.AnalyzerSeverityWarning
+}
+
+// analyzerSeverity normalizes a severity name; linters that leave it empty
+// report warnings
+func analyzerSeverity(severity string) string {
+	switch strings.ToLower(severity) {
+	case "error":
+		return storage.AnalyzerSeverityError
+	case "info", "information":
+		return storage.AnalyzerSeverityInfo
+	case "hint":
+		return storage.AnalyzerSeverityHint
+	}
+	return storage.AnalyzerSeverityWarning
+}
+
+// lspCode joins the analyzer an LSP diagnostic came from with its code
+func lspCode(source string, code json.RawMessage) string {
+	var text string
+	if len(code) > 0 && json.Unmarshal(code, &text) != nil {
+		text = string(code) // a number
+	}
+	switch {
+	case text == "" || text == source:
+		return source
+	case source == "":
+		return text
+	}
+	return source + "/" + text
+}
+
+// MapToIndex names the indexed function around each diagnostic
+func MapToIndex(db *storage.SQLiteDB, root string, diagnostics []*storage.AnalyzerDiagnostic) {
+	if db == nil {
+		return
+	}
+	functionsByFile := make(map[string][]*storage.CodeFunction)
+	for _, diagnostic := range diagnostics {
+		functions, ok := functionsByFile[diagnostic.File]
+		if !ok {
+			path := diagnostic.File
+			if file, err := db.GetFile(path); err == nil && file == nil {
+				// Older indexes store absolute paths
+				path = filepath.Join(root, diagnostic.File)
+			}
+			functions, _ = db.GetFunctionsByFile(path)
+			functionsByFile[diagnostic.File] = functions
+		}
+		for _, function := range functions {
+			if diagnostic.Line >= function.StartLine && diagnostic.Line <= function.EndLine {
+				diagnostic.Function = function.Name
+				break
+			}
+		}
+	}
+}
+
+// questionPattern matches queries about what analyzers report
+var questionPattern = regexp.MustCompile(`(?i)\b(flag(s|ged|ging)?|lint(er|ers|ing)?|warn(ing|ings|s)?|diagnostics?|vet|staticcheck|gopls|golangci|analy[sz]ers?)\b`)
+
+// Relevant picks the diagnostics a query refers to: those in files it
+// mentions, those whose code it names, and, when it asks about analyzer
+// findings without narrowing them down, the most serious ones overall.
+// all is expected most serious first.
+func Relevant(all []*storage.AnalyzerDiagnostic, query string, files []string, limit int) []*storage.AnalyzerDiagnostic {
+	if limit <= 0 || len(all) == 0 {
+		return nil
+	}
+	lower := strings.ToLower(query)
+	var relevant []*storage.AnalyzerDiagnostic
+	for _, diagnostic := range all {
+		if mentionsFile(files, diagnostic.File) || mentionsCode(lower, diagnostic.Code) ||
+			(len(diagnostic.Function) >= 4 && containsWord(query, diagnostic.Function)) {
+			relevant = append(relevant, diagnostic)
+		}
+	}
+	if len(relevant) == 0 && questionPattern.MatchString(query) {
+		relevant = all
+	}
+	if len(relevant) > limit {
+		relevant = relevant[:limit]
+	}
+	return relevant
+}
+
+// mentionsFile reports whether file is one of files, or ends with one of
+// them at a path element
+func mentionsFile(files []string, file string) bool {
+	for _, mentioned := range files {
+		mentioned = filepath.ToSlash(mentioned)
+		if file == mentioned || strings.HasSuffix(file, "/"+mentioned) {
+			return true
+		}
+	}
+	return false
+}
+
+// mentionsCode reports whether the query names the diagnostic's analyzer
+// or rule, such as errcheck or SA1019
+func mentionsCode(lower, code string) bool {
+	for _, part := range strings.Split(strings.ToLower(code), "/") {
+		if len(part) >= 3 && containsWord(lower, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsWord reports whether word appears in text on its own
+func containsWord(text, word string) bool {
+	for start := 0; ; {
+		i := strings.Index(text[start:], word)
+		if i < 0 {
+			return false
+		}
+		i += start
+		end := i + len(word)
+		if (i == 0 || !isWordByte(text[i-1])) && (end == len(text) || !isWordByte(text[end])) {
+			return true
+		}
+		start = i + 1
+	}
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+// Format lists diagnostics for a prompt, one per line, grouped by file
+func Format(diagnostics []*storage.AnalyzerDiagnostic) string {
+	sorted := append([]*storage.AnalyzerDiagnostic(nil), diagnostics...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].File != sorted[j].File {
+			return sorted[i].File < sorted[j].File
+		}
+		return sorted[i].Line < sorted[j].Line
+	})
+	var text strings.Builder
+	for _, diagnostic := range sorted {
+		text.WriteString(FormatOne(diagnostic))
+		text.WriteString("\n")
+	}
+	return text.String()
+}
+
+// FormatOne formats a diagnostic as path:line:col [source code] severity:
+// message (in function)
+func FormatOne(diagnostic *storage.AnalyzerDiagnostic) string {
+	location := fmt.Sprintf("%s:%d", diagnostic.File, diagnostic.Line)
+	if diagnostic.Column > 0 {
+		location += fmt.Sprintf(":%d", diagnostic.Column)
+	}
+	origin := diagnostic.Source
+	if diagnostic.Code != "" {
+		origin += " " + diagnostic.Code
+	}
+	line := fmt.Sprintf("%s [%s] %s: %s", location, origin, diagnostic.Severity, diagnostic.Message)
+	if diagnostic.Function != "" {
+		line += " (in " + diagnostic.Function + ")"
+	}
+	return line
+}
+
+// FormatCounts lists diagnostic counts by source, as "gopls 3, golangci-lint 12"
+func FormatCounts(counts map[string]int) string {
+	sources := make([]string, 0, len(counts))
+	for source := range counts {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+	parts := make([]string, len(sources))
+	for i, source := range sources {
+		parts[i] = fmt.Sprintf("%s %d", source, counts[source])
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,255 @@
+package diagnostics
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/llm"
+	"github.com/yourusername/useq-ai-assistant/internal/sandbox"
+	"github.com/yourusername/useq-ai-assistant/models"
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// DefaultMaxExplained bounds how many errors are sent to the model in one run
+const DefaultMaxExplained = 10
+
+// snippetContext is how many lines either side of an error are shown
+const snippetContext = 6
+
+// explainSystemPrompt is the system prompt for build error explanations
+const explainSystemPrompt = `You are a senior Go engineer explaining compiler errors in the user's project.
+For each numbered error, explain in one or two sentences what the compiler is objecting to in this code,
+then give the concrete fix: the corrected line or lines, or the exact change to make. Errors often share a
+cause; when one fix resolves several, say so in each. Do not invent code that is not shown.`
+
+// explanationSchema is the structured output requested from the model
+const explanationSchema = `{"name":"build_error_explanations","strict":true,"schema":{"type":"object",` +
+	`"properties":{"errors":{"type":"array","items":{"type":"object","properties":{` +
+	`"index":{"type":"integer"},"explanation":{"type":"string"},"fix":{"type":"string"}},` +
+	`"required":["index","explanation","fix"],"additionalProperties":false}}},` +
+	`"required":["errors"],"additionalProperties":false}}`
+
+// Explanation is a diagnostic with the model's reading of it
+type Explanation struct {
+	Diagnostic
+	Explanation string `json:"explanation"`
+	Fix         string `json:"fix"`
+}
+
+// BuildReport is the result of explain build
+type BuildReport struct {
+	Command      string            `json:"command"`
+	ExitCode     int               `json:"exit_code"`
+	Duration     time.Duration     `json:"duration"`
+	Diagnostics  []Diagnostic      `json:"diagnostics"`
+	Explanations []Explanation     `json:"explanations"`
+	Unexplained  int               `json:"unexplained"` // diagnostics beyond the explanation limit
+	Output       string            `json:"output,omitempty"`
+	TokenUsage   models.TokenUsage `json:"token_usage"`
+	Cost         models.Cost       `json:"cost"`
+}
+
+// Succeeded reports whether the build passed
+func (r *BuildReport) Succeeded() bool {
+	return r.ExitCode == 0
+}
+
+// Explainer builds the project in the sandbox and explains its errors
+type Explainer struct {
+	runner       *sandbox.Runner
+	storage      *storage.SQLiteDB
+	llmManager   *llm.Manager
+	MaxExplained int
+}
+
+// NewExplainer creates an explainer. storage and llmManager may be nil: errors
+// are then reported without index mapping or explanations.
+func NewExplainer(runner *sandbox.Runner, db *storage.SQLiteDB, llmManager *llm.Manager) *Explainer {
+	return &Explainer{
+		runner:       runner,
+		storage:      db,
+		llmManager:   llmManager,
+		MaxExplained: DefaultMaxExplained,
+	}
+}
+
+// ExplainBuild runs go build on the packages (./... by default), maps each
+// compiler error to the index and asks the model to explain and fix them
+func (e *Explainer) ExplainBuild(ctx context.Context, packages ...string) (*BuildReport, error) {
+	if len(packages) == 0 {
+		packages = []string{"./..."}
+	}
+	result, err := e.runner.Run(ctx, "go", append([]string{"build"}, packages...)...)
+	if err != nil {
+		return nil, err
+	}
+	if result.TimedOut {
+		return nil, fmt.Errorf("%s timed out after %s", result.Command, result.Duration.Round(time.Second))
+	}
+
+	report := &BuildReport{
+		Command:  result.Command,
+		ExitCode: result.ExitCode,
+		Duration: result.Duration,
+	}
+	if report.Succeeded() {
+		return report, nil
+	}
+
+	report.Diagnostics = ParseGoDiagnostics(result.Output, e.runner.Root())
+	if len(report.Diagnostics) == 0 {
+		// Module or toolchain failures have no file positions; show them as is
+		report.Output = strings.TrimSpace(result.Output)
+		return report, nil
+	}
+	e.mapToIndex(report.Diagnostics)
+
+	if e.llmManager == nil {
+		return report, nil
+	}
+	explained := report.Diagnostics
+	if e.MaxExplained > 0 && len(explained) > e.MaxExplained {
+		explained = explained[:e.MaxExplained]
+		report.Unexplained = len(report.Diagnostics) - e.MaxExplained
+	}
+	if err := e.explain(ctx, report, explained); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+// mapToIndex marks which diagnostics fall in indexed files and names the
+// indexed function around each one
+func (e *Explainer) mapToIndex(diagnostics []Diagnostic) {
+	if e.storage == nil {
+		return
+	}
+	functionsByFile := make(map[string][]*storage.CodeFunction)
+	for i := range diagnostics {
+		diagnostic := &diagnostics[i]
+		functions, ok := functionsByFile[diagnostic.File]
+		if !ok {
+			path := diagnostic.File
+			file, err := e.storage.GetFile(path)
+			if err == nil && file == nil {
+				// Older indexes store absolute paths
+				path = filepath.Join(e.runner.Root(), diagnostic.File)
+				file, err = e.storage.GetFile(path)
+			}
+			if err == nil && file != nil {
+				functions, _ = e.storage.GetFunctionsByFile(path)
+				if functions == nil {
+					functions = []*storage.CodeFunction{}
+				}
+			}
+			functionsByFile[diagnostic.File] = functions
+		}
+		diagnostic.Indexed = functions != nil
+		for _, function := range functions {
+			if diagnostic.Line >= function.StartLine && diagnostic.Line <= function.EndLine {
+				diagnostic.Function = function.Name
+				break
+			}
+		}
+	}
+}
+
+// explain sends the diagnostics with their surrounding source to the model
+func (e *Explainer) explain(ctx context.Context, report *BuildReport, diagnostics []Diagnostic) error {
+	schema, err := llm.ParseResponseSchema([]byte(explanationSchema))
+	if err != nil {
+		return err
+	}
+
+	var prompt strings.Builder
+	prompt.WriteString(fmt.Sprintf("`%s` failed with these errors:\n\n", report.Command))
+	for i, diagnostic := range diagnostics {
+		prompt.WriteString(fmt.Sprintf("[%d] %s: %s\n", i+1, diagnostic.Location(), diagnostic.Message))
+		for _, detail := range diagnostic.Detail {
+			prompt.WriteString("    " + detail + "\n")
+		}
+		if diagnostic.Function != "" {
+			prompt.WriteString(fmt.Sprintf("In function %s\n", diagnostic.Function))
+		}
+		if snippet := sourceSnippet(e.runner.Root(), diagnostic); snippet != "" {
+			prompt.WriteString(snippet)
+		}
+		prompt.WriteString("\n")
+	}
+
+	generated, structured, err := e.llmManager.GenerateStructured(ctx, &llm.GenerationRequest{
+		Messages:       []llm.Message{{Role: "user", Content: prompt.String()}},
+		SystemPrompt:   explainSystemPrompt,
+		MaxTokens:      3000,
+		ResponseSchema: schema,
+	})
+	if generated != nil {
+		report.TokenUsage = generated.TokenUsage
+		report.Cost = generated.Cost
+	}
+	if err != nil {
+		return fmt.Errorf("failed to explain build errors: %w", err)
+	}
+
+	var parsed struct {
+		Errors []struct {
+			Index       int    `json:"index"`
+			Explanation string `json:"explanation"`
+			Fix         string `json:"fix"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(structured, &parsed); err != nil {
+		return fmt.Errorf("failed to read build error explanations: %w", err)
+	}
+
+	report.Explanations = make([]Explanation, len(diagnostics))
+	for i, diagnostic := range diagnostics {
+		report.Explanations[i] = Explanation{Diagnostic: diagnostic}
+	}
+	for _, item := range parsed.Errors {
+		if item.Index < 1 || item.Index > len(diagnostics) {
+			continue
+		}
+		report.Explanations[item.Index-1].Explanation = item.Explanation
+		report.Explanations[item.Index-1].Fix = item.Fix
+	}
+	return nil
+}
+
+// sourceSnippet returns the numbered lines around a diagnostic, marking the
+// reported line with >
+func sourceSnippet(root string, diagnostic Diagnostic) string {
+	handle, err := os.Open(filepath.Join(root, diagnostic.File))
+	if err != nil {
+		return ""
+	}
+	defer handle.Close()
+
+	first := max(diagnostic.Line-snippetContext, 1)
+	last := diagnostic.Line + snippetContext
+	var snippet strings.Builder
+	line := 0
+	scanner := bufio.NewScanner(handle)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line++
+		if line < first {
+			continue
+		}
+		if line > last {
+			break
+		}
+		marker := " "
+		if line == diagnostic.Line {
+			marker = ">"
+		}
+		snippet.WriteString(fmt.Sprintf("%s%5d  %s\n", marker, line, scanner.Text()))
+	}
+	return snippet.String()
+}
@@ -0,0 +1,99 @@
+// Package diagnostics runs the Go toolchain in the sandbox, parses compiler
+// diagnostics and explains them against the index.
+package diagnostics
+
+import (
+	"bufio"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// diagnosticPattern matches a compiler diagnostic: path/file.go:12:5: message
+var diagnosticPattern = regexp.MustCompile(`^(\S+?\.go):(\d+)(?::(\d+))?: (.+)$`)
+
+// packagePattern matches the "# import/path" header go build prints before a
+// package's diagnostics
+var packagePattern = regexp.MustCompile(`^# (\S+)`)
+
+// Diagnostic is one compiler error
+type Diagnostic struct {
+	Package  string   `json:"package,omitempty"`
+	File     string   `json:"file"` // relative to the project root
+	Line     int      `json:"line"`
+	Column   int      `json:"column,omitempty"`
+	Message  string   `json:"message"`
+	Detail   []string `json:"detail,omitempty"`   // indented continuation lines, such as have/want for type mismatches
+	Indexed  bool     `json:"indexed"`            // the file is in the index
+	Function string   `json:"function,omitempty"` // indexed function enclosing the line
+}
+
+// Location formats the diagnostic position as file:line:col
+func (d *Diagnostic) Location() string {
+	if d.Column > 0 {
+		return fmt.Sprintf("%s:%d:%d", d.File, d.Line, d.Column)
+	}
+	return fmt.Sprintf("%s:%d", d.File, d.Line)
+}
+
+// ParseGoDiagnostics extracts compiler errors from go build or go vet output.
+// Paths are made relative to root; repeated diagnostics are dropped, and so
+// are summary lines like "too many errors".
+func ParseGoDiagnostics(output, root string) []Diagnostic {
+	var (
+		diagnostics []Diagnostic
+		pkg         string
+		seen        = make(map[string]bool)
+		last        = -1
+	)
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if match := packagePattern.FindStringSubmatch(line); match != nil {
+			pkg = match[1]
+			last = -1
+			continue
+		}
+		if strings.HasPrefix(line, "\t") && last >= 0 {
+			diagnostics[last].Detail = append(diagnostics[last].Detail, strings.TrimSpace(line))
+			continue
+		}
+
+		match := diagnosticPattern.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			last = -1
+			continue
+		}
+		diagnostic := Diagnostic{
+			Package: pkg,
+			File:    relativePath(match[1], root),
+			Message: match[4],
+		}
+		diagnostic.Line, _ = strconv.Atoi(match[2])
+		diagnostic.Column, _ = strconv.Atoi(match[3])
+
+		key := diagnostic.Location() + " " + diagnostic.Message
+		if seen[key] {
+			last = -1
+			continue
+		}
+		seen[key] = true
+		diagnostics = append(diagnostics, diagnostic)
+		last = len(diagnostics) - 1
+	}
+	return diagnostics
+}
+
+// relativePath makes a reported path relative to root; go build reports
+// paths relative to the working directory, vet sometimes with a ./ prefix
+func relativePath(path, root string) string {
+	if filepath.IsAbs(path) && root != "" {
+		if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+			path = rel
+		}
+	}
+	return strings.TrimPrefix(filepath.ToSlash(filepath.Clean(path)), "./")
+}
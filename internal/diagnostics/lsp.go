@@ -0,0 +1,222 @@
+package diagnostics
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// lspSettle is how long a language server may stay quiet before its
+// diagnostics are taken as complete
+const lspSettle = 3 * time.Second
+
+// lspMessage is a JSON-RPC message in either direction
+type lspMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// CollectLSP connects to a running language server, such as gopls started
+// with -listen, opens the project as its workspace and returns the
+// diagnostics it publishes. It waits until the server has been quiet for
+// a few seconds, or until ctx ends, and reports whatever arrived by then.
+// address is host:port, or unix:path for a Unix socket.
+func CollectLSP(ctx context.Context, address, root string) ([]*storage.AnalyzerDiagnostic, error) {
+	network, target := "tcp", address
+	if path, ok := strings.CutPrefix(address, "unix:"); ok {
+		network, target = "unix", path
+	} else if filepath.IsAbs(address) {
+		network = "unix"
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, network, target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the language server at %s: %w", address, err)
+	}
+	defer conn.Close()
+
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	rootURI := (&url.URL{Scheme: "file", Path: filepath.ToSlash(absRoot)}).String()
+	client := &lspClient{conn: conn, reader: bufio.NewReader(conn)}
+
+	// Read until the connection closes; messages go to the collector below
+	messages := make(chan lspMessage, 64)
+	readErr := make(chan error, 1)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		defer close(messages)
+		for {
+			message, err := client.read()
+			if err != nil {
+				readErr <- err
+				return
+			}
+			select {
+			case messages <- message:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	if err := client.send(lspMessage{ID: json.RawMessage("1"), Method: "initialize", Params: mustJSON(map[string]interface{}{
+		"processId": os.Getpid(),
+		"rootUri":   rootURI,
+		"workspaceFolders": []map[string]string{
+			{"uri": rootURI, "name": filepath.Base(absRoot)},
+		},
+		"capabilities": map[string]interface{}{
+			"textDocument": map[string]interface{}{"publishDiagnostics": map[string]interface{}{}},
+			"workspace":    map[string]interface{}{"configuration": true, "workspaceFolders": true},
+		},
+	})}); err != nil {
+		return nil, err
+	}
+
+	// The latest diagnostics of each document replace the earlier ones
+	published := make(map[string]json.RawMessage)
+	initialized := false
+	quiet := time.NewTimer(time.Hour)
+	defer quiet.Stop()
+collect:
+	for {
+		select {
+		case <-ctx.Done():
+			break collect
+		case <-quiet.C:
+			break collect
+		case message, ok := <-messages:
+			if !ok {
+				if !initialized {
+					return nil, fmt.Errorf("language server at %s closed the connection: %w", address, <-readErr)
+				}
+				break collect
+			}
+			switch {
+			case message.Method == "" && string(message.ID) == "1":
+				if message.Error != nil {
+					return nil, fmt.Errorf("language server refused to initialize: %s", message.Error.Message)
+				}
+				initialized = true
+				if err := client.send(lspMessage{Method: "initialized", Params: json.RawMessage("{}")}); err != nil {
+					return nil, err
+				}
+			case message.Method == "textDocument/publishDiagnostics":
+				var params struct {
+					URI string `json:"uri"`
+				}
+				if json.Unmarshal(message.Params, &params) == nil {
+					published[params.URI] = message.Params
+				}
+			case message.Method != "" && message.ID != nil:
+				// Requests from the server get an empty answer, or one empty
+				// setting per item asked for
+				if err := client.send(lspMessage{ID: message.ID, Result: lspReply(message)}); err != nil {
+					return nil, err
+				}
+			}
+			if initialized {
+				quiet.Reset(lspSettle)
+			}
+		}
+	}
+	if !initialized {
+		return nil, fmt.Errorf("language server at %s did not initialize: %w", address, ctx.Err())
+	}
+	client.send(lspMessage{ID: json.RawMessage("2"), Method: "shutdown"})
+	client.send(lspMessage{Method: "exit"})
+
+	var diagnostics []*storage.AnalyzerDiagnostic
+	for _, params := range published {
+		parsed, err := parseAnalyzerValue(params, absRoot)
+		if err != nil {
+			continue
+		}
+		diagnostics = append(diagnostics, parsed...)
+	}
+	return diagnostics, nil
+}
+
+// lspReply answers a request from the server
+func lspReply(request lspMessage) json.RawMessage {
+	if request.Method != "workspace/configuration" {
+		return json.RawMessage("null")
+	}
+	var params struct {
+		Items []json.RawMessage `json:"items"`
+	}
+	json.Unmarshal(request.Params, &params)
+	return mustJSON(make([]interface{}, len(params.Items)))
+}
+
+// lspClient frames JSON-RPC messages with Content-Length headers
+type lspClient struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	mu     sync.Mutex
+}
+
+// send writes one message
+func (c *lspClient) send(message lspMessage) error {
+	message.JSONRPC = "2.0"
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := fmt.Fprintf(c.conn, "Content-Length: %d\r\n\r\n%s", len(body), body); err != nil {
+		return fmt.Errorf("failed to write to the language server: %w", err)
+	}
+	return nil
+}
+
+// read reads one message
+func (c *lspClient) read() (lspMessage, error) {
+	header, err := textproto.NewReader(c.reader).ReadMIMEHeader()
+	if err != nil {
+		return lspMessage{}, err
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil || length < 0 {
+		return lspMessage{}, fmt.Errorf("invalid message header from the language server")
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(c.reader, body); err != nil {
+		return lspMessage{}, err
+	}
+	var message lspMessage
+	if err := json.Unmarshal(body, &message); err != nil {
+		return lspMessage{}, fmt.Errorf("invalid message from the language server: %w", err)
+	}
+	return message, nil
+}
+
+// mustJSON marshals values that cannot fail to marshal
+func mustJSON(value interface{}) json.RawMessage {
+	data, _ := json.Marshal(value)
+	return data
+}
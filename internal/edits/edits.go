@@ -0,0 +1,295 @@
+// Package edits turns model output into line-anchored code edits that
+// editors can apply precisely, and renders them as minimal contextual diffs.
+package edits
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// Symbol is a located function or method in a file. Lines are 1-based and inclusive.
+type Symbol struct {
+	Name      string `json:"name"`
+	File      string `json:"file"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	Source    string `json:"source"`
+}
+
+// ErrSymbolNotFound is returned when a function cannot be located in a file
+var ErrSymbolNotFound = fmt.Errorf("symbol not found")
+
+// HashContent returns the hash recorded as CodeChange.BaseHash
+func HashContent(content string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(content)))[:16]
+}
+
+// LocateSymbol finds the line range of a function or method named name.
+// Go files are parsed; other languages use a brace-matching heuristic.
+func LocateSymbol(path, content, name string) (*Symbol, error) {
+	lines := splitLines(content)
+
+	var start, end int
+	if filepath.Ext(path) == ".go" {
+		start, end = locateGoFunc(content, name)
+	}
+	if start == 0 {
+		start, end = locateByBraces(lines, name)
+	}
+	if start == 0 {
+		return nil, fmt.Errorf("%w: %s in %s", ErrSymbolNotFound, name, path)
+	}
+
+	return &Symbol{
+		Name:      name,
+		File:      path,
+		StartLine: start,
+		EndLine:   end,
+		Source:    strings.Join(lines[start-1:end], "\n"),
+	}, nil
+}
+
+// locateGoFunc returns the range of a Go func decl (including its doc comment)
+func locateGoFunc(content, name string) (int, int) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", content, parser.ParseComments)
+	if err != nil {
+		return 0, 0
+	}
+
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Name.Name != name {
+			continue
+		}
+		start := fset.Position(fn.Pos()).Line
+		if fn.Doc != nil {
+			start = fset.Position(fn.Doc.Pos()).Line
+		}
+		return start, fset.Position(fn.End()).Line
+	}
+	return 0, 0
+}
+
+// locateByBraces finds a line defining name and its matching closing brace
+func locateByBraces(lines []string, name string) (int, int) {
+	def := regexp.MustCompile(`\b(func|function|def|fn|void|public|private|static)\b.*\b` + regexp.QuoteMeta(name) + `\s*\(`)
+	for i, line := range lines {
+		if !def.MatchString(line) {
+			continue
+		}
+
+		depth, opened := 0, false
+		for j := i; j < len(lines); j++ {
+			depth += strings.Count(lines[j], "{") - strings.Count(lines[j], "}")
+			if strings.Contains(lines[j], "{") {
+				opened = true
+			}
+			if opened && depth <= 0 {
+				return i + 1, j + 1
+			}
+		}
+		return i + 1, i + 1
+	}
+	return 0, 0
+}
+
+// Diff compares the original symbol source with its replacement and returns
+// the minimal line-anchored changes, with line numbers relative to the file
+func Diff(symbol *Symbol, replacement string, baseHash string) []models.CodeChange {
+	oldLines := splitLines(symbol.Source)
+	newLines := splitLines(strings.TrimRight(replacement, "\n"))
+
+	var changes []models.CodeChange
+	for _, h := range diffHunks(oldLines, newLines) {
+		change := models.CodeChange{
+			File:       symbol.File,
+			StartLine:  symbol.StartLine + h.oldStart,
+			EndLine:    symbol.StartLine + h.oldStart + len(h.oldLines) - 1,
+			OldContent: strings.Join(h.oldLines, "\n"),
+			NewContent: strings.Join(h.newLines, "\n"),
+			Anchor:     symbol.Name,
+			BaseHash:   baseHash,
+		}
+		switch {
+		case len(h.oldLines) == 0:
+			change.Type = models.ChangeTypeAdd
+			// Pure insertions are anchored after the preceding line
+			change.EndLine = change.StartLine - 1
+		case len(h.newLines) == 0:
+			change.Type = models.ChangeTypeDelete
+		default:
+			change.Type = models.ChangeTypeReplace
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// Apply applies line-anchored changes to content. Changes are applied
+// bottom-up so earlier line numbers stay valid; a change whose OldContent no
+// longer matches the file is rejected.
+func Apply(content string, changes []models.CodeChange) (string, error) {
+	lines := splitLines(content)
+
+	sorted := append([]models.CodeChange{}, changes...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].StartLine > sorted[j].StartLine })
+
+	for _, c := range sorted {
+		start := c.StartLine - 1
+		end := c.EndLine // exclusive index
+		if c.Type == models.ChangeTypeAdd {
+			end = start
+		}
+		if start < 0 || end > len(lines) || start > end {
+			return "", fmt.Errorf("change %d-%d is outside the file (%d lines)", c.StartLine, c.EndLine, len(lines))
+		}
+		if c.Type != models.ChangeTypeAdd && strings.Join(lines[start:end], "\n") != c.OldContent {
+			return "", fmt.Errorf("lines %d-%d no longer match the original content", c.StartLine, c.EndLine)
+		}
+
+		var replacement []string
+		if c.Type != models.ChangeTypeDelete {
+			replacement = splitLines(c.NewContent)
+		}
+		lines = append(lines[:start], append(replacement, lines[end:]...)...)
+	}
+
+	result := strings.Join(lines, "\n")
+	if strings.HasSuffix(content, "\n") {
+		result += "\n"
+	}
+	return result, nil
+}
+
+// RenderContextDiff renders a change as a unified-diff style hunk with
+// contextLines of surrounding file content (content may be empty)
+func RenderContextDiff(content string, change models.CodeChange, contextLines int) []string {
+	fileLines := splitLines(content)
+	if content == "" {
+		fileLines = nil
+	}
+
+	first := change.StartLine
+	last := change.EndLine
+	if change.Type == models.ChangeTypeAdd {
+		last = first - 1
+	}
+
+	var out []string
+	oldCount := last - first + 1
+	newCount := 0
+	if change.Type != models.ChangeTypeDelete {
+		newCount = len(splitLines(change.NewContent))
+	}
+	out = append(out, fmt.Sprintf("@@ -%d,%d +%d,%d @@ %s", first, oldCount, first, newCount, change.Anchor))
+
+	for i := max(1, first-contextLines); i < first && i <= len(fileLines); i++ {
+		out = append(out, " "+fileLines[i-1])
+	}
+	if change.Type != models.ChangeTypeAdd && change.OldContent != "" {
+		for _, l := range splitLines(change.OldContent) {
+			out = append(out, "-"+l)
+		}
+	}
+	if change.Type != models.ChangeTypeDelete {
+		for _, l := range splitLines(change.NewContent) {
+			out = append(out, "+"+l)
+		}
+	}
+	for i := last + 1; i <= last+contextLines && i <= len(fileLines); i++ {
+		out = append(out, " "+fileLines[i-1])
+	}
+	return out
+}
+
+// ExtractCode returns the body of the first fenced code block in text, or
+// text itself when there is no fence
+func ExtractCode(text string) string {
+	start := strings.Index(text, "```")
+	if start < 0 {
+		return strings.TrimSpace(text)
+	}
+	body := text[start+3:]
+	if newline := strings.Index(body, "\n"); newline >= 0 {
+		body = body[newline+1:]
+	}
+	if end := strings.Index(body, "```"); end >= 0 {
+		body = body[:end]
+	}
+	return strings.TrimRight(body, "\n")
+}
+
+// hunk is a contiguous run of differing lines; oldStart is 0-based
+type hunk struct {
+	oldStart int
+	oldLines []string
+	newLines []string
+}
+
+// diffHunks computes hunks between a and b using an LCS table, which is
+// fine for function-sized inputs
+func diffHunks(a, b []string) []hunk {
+	n, m := len(a), len(b)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var hunks []hunk
+	var cur *hunk
+	flush := func() {
+		if cur != nil {
+			hunks = append(hunks, *cur)
+			cur = nil
+		}
+	}
+
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && a[i] == b[j]:
+			flush()
+			i++
+			j++
+		case j < m && (i == n || lcs[i][j+1] >= lcs[i+1][j]):
+			if cur == nil {
+				cur = &hunk{oldStart: i}
+			}
+			cur.newLines = append(cur.newLines, b[j])
+			j++
+		default:
+			if cur == nil {
+				cur = &hunk{oldStart: i}
+			}
+			cur.oldLines = append(cur.oldLines, a[i])
+			i++
+		}
+	}
+	flush()
+	return hunks
+}
+
+// splitLines splits content into lines without a trailing empty element
+func splitLines(content string) []string {
+	content = strings.TrimSuffix(content, "\n")
+	return strings.Split(content, "\n")
+}
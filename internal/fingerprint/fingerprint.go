@@ -0,0 +1,154 @@
+// Package fingerprint snapshots what shaped an answer - hashes of the
+// system prompt templates it sent, the models the providers are configured with, the retrieval and
+// ranking settings and the index generation - and hashes it, so "it
+// behaved differently yesterday" can be traced to what changed.
+package fingerprint
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// hashLength is how many hex digits of a hash are kept
+const hashLength = 12
+
+// Components of a snapshot, in the order they are shown
+const (
+	ComponentPrompts = "prompts"
+	ComponentModels  = "models"
+	ComponentRanking = "ranking"
+	ComponentIndex   = "index"
+)
+
+// Components lists the snapshot components in display order
+var Components = []string{ComponentPrompts, ComponentModels, ComponentRanking, ComponentIndex}
+
+// Snapshot is the configuration an answer was produced with
+type Snapshot struct {
+	Prompts         []string                   `json:"prompts"`       // hashes of the system prompt templates of the answer's LLM calls, sorted
+	CalledModels    []string                   `json:"called_models"` // provider/model of each call, sorted
+	Models          map[string]string          `json:"models"`        // provider settings and aliases, secrets left out
+	Ranking         map[string]json.RawMessage `json:"ranking"`       // retrieval and ranking settings by section
+	IndexGeneration int64                      `json:"index_generation"`
+}
+
+// Hash returns the fingerprint of the whole snapshot
+func (s *Snapshot) Hash() string {
+	hashes := s.ComponentHashes()
+	var parts []string
+	for _, component := range Components {
+		parts = append(parts, component+"="+hashes[component])
+	}
+	return hash(strings.Join(parts, "\n"))
+}
+
+// ComponentHashes returns the fingerprint of each component
+func (s *Snapshot) ComponentHashes() map[string]string {
+	return map[string]string{
+		ComponentPrompts: hash(strings.Join(s.Prompts, "\x00") + "\x01" + strings.Join(s.CalledModels, "\x00")),
+		ComponentModels:  hash(string(canonical(s.Models))),
+		ComponentRanking: hash(string(canonical(s.Ranking))),
+		ComponentIndex:   hash(strconv.FormatInt(s.IndexGeneration, 10)),
+	}
+}
+
+// Changed returns the components whose settings differ between an answer's
+// snapshot and the current one. Prompts are not compared: they are only
+// known for answers that ran.
+func Changed(then, now *Snapshot) []string {
+	before, after := then.ComponentHashes(), now.ComponentHashes()
+	var changed []string
+	for _, component := range Components {
+		if component != ComponentPrompts && before[component] != after[component] {
+			changed = append(changed, component)
+		}
+	}
+	return changed
+}
+
+// ChangedSettings returns the model settings and ranking sections that
+// differ between two snapshots, sorted
+func ChangedSettings(then, now *Snapshot) []string {
+	var changed []string
+	for _, key := range unionKeys(then.Models, now.Models) {
+		if then.Models[key] != now.Models[key] {
+			changed = append(changed, "models."+key)
+		}
+	}
+	for _, key := range unionKeys(then.Ranking, now.Ranking) {
+		if string(canonical(then.Ranking[key])) != string(canonical(now.Ranking[key])) {
+			changed = append(changed, "ranking."+key)
+		}
+	}
+	return changed
+}
+
+// hash returns the short SHA-256 of text
+func hash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])[:hashLength]
+}
+
+// canonical re-encodes a value so equal settings hash equally whatever
+// order their keys were written in
+func canonical(v interface{}) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return data
+	}
+	data, _ = json.Marshal(decoded) // maps marshal with sorted keys
+	return data
+}
+
+// unionKeys returns the keys of two maps, sorted
+func unionKeys[V any](a, b map[string]V) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	for key := range a {
+		seen[key] = true
+	}
+	for key := range b {
+		seen[key] = true
+	}
+	keys := make([]string, 0, len(seen))
+	for key := range seen {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Explanation is an answer's snapshot set against the current
+// configuration, for "explain-fingerprint"
+type Explanation struct {
+	ResponseID      string            `json:"response_id"`
+	Fingerprint     string            `json:"fingerprint"`
+	RecordedAt      time.Time         `json:"recorded_at"`
+	Snapshot        *Snapshot         `json:"snapshot"`
+	Components      map[string]string `json:"components"`       // component fingerprints of the answer
+	Current         map[string]string `json:"current"`          // component fingerprints now
+	Changed         []string          `json:"changed"`          // components that changed since
+	ChangedSettings []string          `json:"changed_settings"` // model settings and ranking sections that changed
+}
+
+// Explain compares an answer's snapshot with the current one
+func Explain(responseID string, recordedAt time.Time, then, now *Snapshot) *Explanation {
+	return &Explanation{
+		ResponseID:      responseID,
+		Fingerprint:     then.Hash(),
+		RecordedAt:      recordedAt,
+		Snapshot:        then,
+		Components:      then.ComponentHashes(),
+		Current:         now.ComponentHashes(),
+		Changed:         Changed(then, now),
+		ChangedSettings: ChangedSettings(then, now),
+	}
+}
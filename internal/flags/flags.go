@@ -0,0 +1,192 @@
+package flags
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/internal/metrics"
+)
+
+// Flag is an experimental capability that can be switched on or off per
+// session while it is rolled out
+type Flag struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Default     bool   `json:"default"`
+}
+
+// Names of the known flags
+const (
+	ToolLoop      = "tool_loop"     // Tier 3 answers look things up with tool calls
+	Consultation  = "consultation"  // ambiguous routing asks two agents and arbitrates
+	Clarification = "clarification" // unclear queries get a clarifying question
+	Excerpts      = "excerpts"      // explanations carry the code they refer to
+)
+
+var known = []Flag{
+	{Name: ToolLoop, Description: "Let the model call search and read tools while answering Tier 3 queries", Default: true},
+	{Name: Consultation, Description: "Consult the two best agents when routing is ambiguous and keep the better answer", Default: true},
+	{Name: Clarification, Description: "Ask a clarifying question when a query is too unclear to answer", Default: true},
+	{Name: Excerpts, Description: "Attach line-numbered code of the functions an explanation refers to", Default: true},
+}
+
+// Known lists the known flags by name
+func Known() []Flag {
+	list := append([]Flag{}, known...)
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Lookup returns the known flag called name
+func Lookup(name string) (Flag, bool) {
+	name = normalize(name)
+	for _, flag := range known {
+		if flag.Name == name {
+			return flag, true
+		}
+	}
+	return Flag{}, false
+}
+
+// Where a flag's value came from, weakest first
+const (
+	SourceDefault = "default"
+	SourceConfig  = "config"
+	SourceEnv     = "env"
+	SourceSession = "session"
+)
+
+// State is a flag's value in one session
+type State struct {
+	Flag
+	Enabled bool   `json:"enabled"`
+	Source  string `json:"source"`
+}
+
+// envPrefix starts the environment variables that override a flag for the
+// whole process, e.g. USEQ_FLAG_TOOL_LOOP=off
+const envPrefix = "USEQ_FLAG_"
+
+// Resolver works out the flags of a session: the built-in default, then the
+// configured value, then the environment, then the session's own choice
+type Resolver struct {
+	config map[string]bool
+	env    map[string]bool
+}
+
+// NewResolver returns a resolver over the configured flag values. Unknown
+// names are rejected so a typo in the config does not go unnoticed.
+func NewResolver(config map[string]bool) (*Resolver, error) {
+	r := &Resolver{config: make(map[string]bool), env: make(map[string]bool)}
+	for name, on := range config {
+		flag, ok := Lookup(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown feature flag %q; known flags: %s", name, strings.Join(names(), ", "))
+		}
+		r.config[flag.Name] = on
+	}
+	for _, flag := range known {
+		value, ok := os.LookupEnv(envPrefix + strings.ToUpper(flag.Name))
+		if !ok {
+			continue
+		}
+		on, err := ParseValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s%s: %w", envPrefix, strings.ToUpper(flag.Name), err)
+		}
+		r.env[flag.Name] = on
+	}
+	return r, nil
+}
+
+// States returns every flag's value in a session with the given overrides
+func (r *Resolver) States(overrides map[string]bool) []State {
+	states := make([]State, 0, len(known))
+	for _, flag := range Known() {
+		state := State{Flag: flag, Enabled: flag.Default, Source: SourceDefault}
+		if on, ok := r.config[flag.Name]; ok {
+			state.Enabled, state.Source = on, SourceConfig
+		}
+		if on, ok := r.env[flag.Name]; ok {
+			state.Enabled, state.Source = on, SourceEnv
+		}
+		if on, ok := overrides[flag.Name]; ok {
+			state.Enabled, state.Source = on, SourceSession
+		}
+		states = append(states, state)
+	}
+	return states
+}
+
+// Resolve returns the flags of a session with the given overrides
+func (r *Resolver) Resolve(overrides map[string]bool) Set {
+	set := make(Set, len(known))
+	for _, state := range r.States(overrides) {
+		set[state.Name] = state.Enabled
+	}
+	return set
+}
+
+// Set holds the value of every flag for one query
+type Set map[string]bool
+
+type setKey struct{}
+
+// WithSet returns a context carrying the flags of one query
+func WithSet(ctx context.Context, set Set) context.Context {
+	return context.WithValue(ctx, setKey{}, set)
+}
+
+// Enabled reports whether a flag is on for the query ctx belongs to, or its
+// default outside a query. Every check is counted in the flag's on or off
+// metric, so a staged rollout can see how much each capability is used.
+func Enabled(ctx context.Context, name string) bool {
+	on := false
+	if flag, ok := Lookup(name); ok {
+		on = flag.Default
+	}
+	if ctx != nil {
+		if set, ok := ctx.Value(setKey{}).(Set); ok {
+			if value, ok := set[name]; ok {
+				on = value
+			}
+		}
+	}
+	if on {
+		metrics.Default.Counter("flags." + name + ".on").Inc()
+	} else {
+		metrics.Default.Counter("flags." + name + ".off").Inc()
+	}
+	return on
+}
+
+// ParseValue reads a flag value: on/off, true/false, yes/no or 1/0
+func ParseValue(value string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "on", "yes", "enable", "enabled":
+		return true, nil
+	case "off", "no", "disable", "disabled":
+		return false, nil
+	}
+	on, err := strconv.ParseBool(strings.TrimSpace(value))
+	if err != nil {
+		return false, fmt.Errorf("bad flag value %q; use on or off", value)
+	}
+	return on, nil
+}
+
+func normalize(name string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), "-", "_")
+}
+
+func names() []string {
+	list := make([]string, 0, len(known))
+	for _, flag := range Known() {
+		list = append(list, flag.Name)
+	}
+	return list
+}
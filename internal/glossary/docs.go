@@ -0,0 +1,121 @@
+package glossary
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// glossaryHeadingPattern marks the doc sections that define terms
+var glossaryHeadingPattern = regexp.MustCompile(`(?i)glossary|terminology|\bterms\b|concepts|definitions`)
+
+// boldDefinitionPattern finds "**Term**: definition" and "- **Term** - definition"
+var boldDefinitionPattern = regexp.MustCompile(`^\s*(?:[-*+]\s+)?\*\*([^*]{2,60}?):?\*\*\s*(?::|-|–|—)?\s*(.+)$`)
+
+// tableHeaders are first-column headings of term tables, not terms
+var tableHeaders = map[string]bool{"term": true, "terms": true, "name": true, "concept": true}
+
+// markdownNoise is markup stripped from extracted definitions
+var markdownNoise = strings.NewReplacer("**", "", "__", "", "`", "")
+
+// FromMarkdown extracts the terms defined in the glossary, terminology or
+// concepts sections of a markdown document, as bold-term bullets or as
+// rows of a term | definition table
+func FromMarkdown(content string) []*storage.GlossaryEntry {
+	var entries []*storage.GlossaryEntry
+	inSection, sectionLevel := false, 0
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if level := headingLevel(trimmed); level > 0 {
+			if inSection && level > sectionLevel {
+				continue
+			}
+			inSection = glossaryHeadingPattern.MatchString(trimmed)
+			sectionLevel = level
+			continue
+		}
+		if !inSection {
+			continue
+		}
+
+		var term, definition string
+		if match := boldDefinitionPattern.FindStringSubmatch(trimmed); match != nil {
+			term, definition = match[1], match[2]
+		} else if cells := tableCells(trimmed); len(cells) >= 2 {
+			term, definition = cells[0], cells[1]
+		}
+		term = strings.TrimSpace(markdownNoise.Replace(term))
+		definition = Definition(markdownNoise.Replace(definition))
+		if term == "" || definition == "" || tableHeaders[strings.ToLower(term)] {
+			continue
+		}
+		entries = append(entries, &storage.GlossaryEntry{Term: term, Definition: definition, Source: storage.GlossarySourceDoc})
+	}
+	return entries
+}
+
+// ScanDocs extracts terms from the markdown files at paths, which may be
+// files or directories relative to root. Every file read is in the result,
+// with no entries if it defines no terms, keyed by its path under root.
+func ScanDocs(root string, paths []string) map[string][]*storage.GlossaryEntry {
+	terms := make(map[string][]*storage.GlossaryEntry)
+	add := func(path string) {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			rel = path
+		}
+		terms[filepath.ToSlash(rel)] = FromMarkdown(string(content))
+	}
+
+	for _, path := range paths {
+		full := filepath.Join(root, path)
+		info, err := os.Stat(full)
+		if err != nil {
+			continue
+		}
+		if !info.IsDir() {
+			add(full)
+			continue
+		}
+		filepath.WalkDir(full, func(path string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() && strings.EqualFold(filepath.Ext(path), ".md") {
+				add(path)
+			}
+			return nil
+		})
+	}
+	return terms
+}
+
+// headingLevel returns the level of a markdown heading, or 0
+func headingLevel(line string) int {
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || level == len(line) || line[level] != ' ' {
+		return 0
+	}
+	return level
+}
+
+// tableCells splits a markdown table row, returning nothing for other lines
+// and for separator rows
+func tableCells(line string) []string {
+	if !strings.HasPrefix(line, "|") || strings.Trim(line, "|-: ") == "" {
+		return nil
+	}
+	cells := strings.Split(strings.Trim(line, "|"), "|")
+	for i, cell := range cells {
+		cells[i] = strings.TrimSpace(cell)
+	}
+	return cells
+}
@@ -0,0 +1,173 @@
+// Package glossary extracts the project's terminology from documented types
+// and docs, and picks the terms relevant to a query so prompts use the
+// project's own names for things.
+package glossary
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// maxDefinitionLength caps an extracted definition, in characters
+const maxDefinitionLength = 240
+
+// minTermLength is the shortest term matched against queries
+const minTermLength = 3
+
+// Config controls glossary extraction and how many terms a prompt gets
+type Config struct {
+	Enabled    bool     `json:"enabled"`
+	MaxEntries int      `json:"max_entries"` // terms added to one prompt
+	Docs       []string `json:"docs"`        // markdown files or directories, relative to the project root
+}
+
+// DefaultConfig returns glossary defaults
+func DefaultConfig() Config {
+	return Config{
+		Enabled:    true,
+		MaxEntries: 12,
+		Docs:       []string{"README.md", "docs"},
+	}
+}
+
+// FromType makes a glossary entry of a documented exported type, or nil
+// for types that are unexported or undocumented
+func FromType(name, doc string) *storage.GlossaryEntry {
+	if name == "" || !unicode.IsUpper(rune(name[0])) {
+		return nil
+	}
+	definition := Definition(doc)
+	if definition == "" {
+		return nil
+	}
+	return &storage.GlossaryEntry{Term: name, Definition: definition, Source: storage.GlossarySourceType}
+}
+
+// Definition returns the first sentence of a doc comment, shortened to fit
+// a prompt
+func Definition(doc string) string {
+	doc = strings.Join(strings.Fields(doc), " ")
+	if end := strings.Index(doc, ". "); end >= 0 {
+		doc = doc[:end+1]
+	}
+	if len(doc) > maxDefinitionLength {
+		cut := strings.LastIndex(doc[:maxDefinitionLength], " ")
+		if cut <= 0 {
+			cut = maxDefinitionLength
+		}
+		doc = doc[:cut] + "..."
+	}
+	return doc
+}
+
+// Relevant returns up to limit entries the query refers to, by term or by
+// one of the names the project avoids for it. Manual terms rank first,
+// then terms from docs, then types; longer names before shorter ones.
+func Relevant(entries []*storage.GlossaryEntry, query string, limit int) []*storage.GlossaryEntry {
+	text := " " + normalize(query) + " "
+
+	type scored struct {
+		entry *storage.GlossaryEntry
+		score int
+	}
+	var matches []scored
+	for _, entry := range entries {
+		length := 0
+		for _, name := range append([]string{entry.Term}, entry.Aliases...) {
+			if mentions(text, name) {
+				length = max(length, len(name))
+			}
+		}
+		if length == 0 {
+			continue
+		}
+		score := length
+		switch entry.Source {
+		case storage.GlossarySourceManual:
+			score += 2000
+		case storage.GlossarySourceDoc:
+			score += 1000
+		}
+		matches = append(matches, scored{entry, score})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	relevant := make([]*storage.GlossaryEntry, len(matches))
+	for i, match := range matches {
+		relevant[i] = match.entry
+	}
+	return relevant
+}
+
+// Format renders entries for a prompt, one term per line, with the names
+// to avoid
+func Format(entries []*storage.GlossaryEntry) string {
+	var b strings.Builder
+	for _, entry := range entries {
+		fmt.Fprintf(&b, "- %s: %s", entry.Term, entry.Definition)
+		if len(entry.Aliases) > 0 {
+			fmt.Fprintf(&b, " (say %s, not %s)", entry.Term, strings.Join(entry.Aliases, " or "))
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// mentions reports whether normalized text, padded with spaces, contains
+// name as whole words, either as written or split into its camelCase words
+func mentions(text, name string) bool {
+	if len(name) < minTermLength {
+		return false
+	}
+	if strings.Contains(text, " "+normalize(name)+" ") {
+		return true
+	}
+	words := splitWords(name)
+	return len(words) > 1 && strings.Contains(text, " "+strings.Join(words, " ")+" ")
+}
+
+// normalize lowercases text and reduces it to words separated by spaces
+func normalize(text string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// splitWords splits a camelCase or snake_case name into lowercase words:
+// HTTPServerConfig is http, server, config
+func splitWords(name string) []string {
+	var words []string
+	var current []rune
+	runes := []rune(name)
+	for i, r := range runes {
+		if r == '_' || r == '-' || r == ' ' {
+			if len(current) > 0 {
+				words = append(words, strings.ToLower(string(current)))
+				current = nil
+			}
+			continue
+		}
+		if i > 0 && unicode.IsUpper(r) && len(current) > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if !unicode.IsUpper(prev) || nextLower {
+				words = append(words, strings.ToLower(string(current)))
+				current = nil
+			}
+		}
+		current = append(current, r)
+	}
+	if len(current) > 0 {
+		words = append(words, strings.ToLower(string(current)))
+	}
+	return words
+}
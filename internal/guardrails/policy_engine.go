@@ -0,0 +1,403 @@
+// Package guardrails applies project policies to generated code before it
+// reaches the user: license headers, forbidden imports, banned APIs and
+// error wrapping style.
+package guardrails
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// Enforcement modes
+const (
+	ModeAnnotate = "annotate" // attach issues to the CodeResponse
+	ModeEnforce  = "enforce"  // reject code with error-severity issues
+)
+
+// DefaultPolicyPath is where the policy file is looked up
+const DefaultPolicyPath = "config/guardrails.yaml"
+
+// ErrPolicyViolation is returned when enforce mode rejects generated code
+var ErrPolicyViolation = errors.New("generated code violates project policy")
+
+// PolicyConfig holds the configurable guardrail policies
+type PolicyConfig struct {
+	Mode             string      `yaml:"mode"`
+	LicenseHeader    string      `yaml:"license_header"`
+	ForbiddenImports []string    `yaml:"forbidden_imports"`
+	BannedAPIs       []BannedAPI `yaml:"banned_apis"`
+	RequireErrorWrap bool        `yaml:"require_error_wrap"`
+}
+
+// BannedAPI describes a call that must not appear in generated code
+type BannedAPI struct {
+	Call        string `yaml:"call"` // e.g. "os.Exit", "os/exec.Command" or "panic"
+	Reason      string `yaml:"reason"`
+	LibraryOnly bool   `yaml:"library_only"` // only flag outside package main
+}
+
+// PolicyEngine checks generated code against the configured policies
+type PolicyEngine struct {
+	config PolicyConfig
+}
+
+// errorfWithoutWrap matches fmt.Errorf calls that format an error with %v/%s
+var errorfWithoutWrap = regexp.MustCompile(`fmt\.Errorf\([^)]*%[vs][^)]*,\s*err\)`)
+
+// DefaultPolicyConfig returns the built-in policies
+func DefaultPolicyConfig() PolicyConfig {
+	return PolicyConfig{
+		Mode: ModeAnnotate,
+		BannedAPIs: []BannedAPI{
+			{Call: "os.Exit", Reason: "libraries must return errors instead of exiting", LibraryOnly: true},
+			{Call: "log.Fatal", Reason: "libraries must return errors instead of exiting", LibraryOnly: true},
+			{Call: "log.Fatalf", Reason: "libraries must return errors instead of exiting", LibraryOnly: true},
+			{Call: "log.Fatalln", Reason: "libraries must return errors instead of exiting", LibraryOnly: true},
+			{Call: "panic", Reason: "prefer returning errors over panicking", LibraryOnly: true},
+		},
+		RequireErrorWrap: true,
+	}
+}
+
+// LoadPolicyConfig reads policies from path, falling back to the defaults
+// when the file does not exist
+func LoadPolicyConfig(path string) (PolicyConfig, error) {
+	config := DefaultPolicyConfig()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return config, nil
+		}
+		return config, fmt.Errorf("failed to read policy file: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("failed to parse policy file: %w", err)
+	}
+	if config.Mode == "" {
+		config.Mode = ModeAnnotate
+	}
+	return config, nil
+}
+
+// NewPolicyEngine creates a policy engine
+func NewPolicyEngine(config PolicyConfig) *PolicyEngine {
+	return &PolicyEngine{config: config}
+}
+
+// Mode returns the enforcement mode
+func (pe *PolicyEngine) Mode() string {
+	return pe.config.Mode
+}
+
+// Check returns the policy issues found in code
+func (pe *PolicyEngine) Check(code string) []models.ValidationIssue {
+	var issues []models.ValidationIssue
+	code = stripMarkdownFence(code)
+
+	if header := strings.TrimSpace(pe.config.LicenseHeader); header != "" {
+		if !strings.HasPrefix(strings.TrimSpace(code), header) {
+			issues = append(issues, models.ValidationIssue{
+				Type:       "license_header",
+				Message:    "required license header is missing",
+				Line:       1,
+				Severity:   "error",
+				Suggestion: "prepend the project license header",
+			})
+		}
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "generated.go", code, parser.ParseComments)
+	if err == nil {
+		issues = append(issues, pe.checkImports(fset, file)...)
+		issues = append(issues, pe.checkBannedAPIs(fset, file)...)
+	} else {
+		// Snippets without a package clause can't be parsed; fall back to text
+		issues = append(issues, pe.checkTextual(code)...)
+	}
+
+	if pe.config.RequireErrorWrap {
+		for i, line := range strings.Split(code, "\n") {
+			if errorfWithoutWrap.MatchString(line) {
+				issues = append(issues, models.ValidationIssue{
+					Type:       "error_wrapping",
+					Message:    "error formatted with %v/%s instead of wrapped",
+					Line:       i + 1,
+					Severity:   "warning",
+					Suggestion: `use fmt.Errorf("...: %w", err)`,
+				})
+			}
+		}
+	}
+
+	return issues
+}
+
+// Apply checks the CodeResponse and records the result in its Validation.
+// In enforce mode an error-severity issue returns ErrPolicyViolation.
+func (pe *PolicyEngine) Apply(response *models.CodeResponse) error {
+	if response == nil || response.Code == "" {
+		return nil
+	}
+
+	issues := pe.Check(response.Code)
+	if len(issues) == 0 {
+		return nil
+	}
+
+	if response.Validation == nil {
+		response.Validation = &models.CodeValidation{IsValid: true, Score: 1.0}
+	}
+
+	var blocking []string
+	for _, issue := range issues {
+		if issue.Severity == "error" {
+			response.Validation.Issues = append(response.Validation.Issues, issue)
+			response.Validation.IsValid = false
+			blocking = append(blocking, issue.Message)
+		} else {
+			response.Validation.Warnings = append(response.Validation.Warnings, issue)
+		}
+		response.Validation.Score -= 0.1
+	}
+	if response.Validation.Score < 0 {
+		response.Validation.Score = 0
+	}
+
+	if pe.config.Mode == ModeEnforce && len(blocking) > 0 {
+		return fmt.Errorf("%w: %s", ErrPolicyViolation, strings.Join(blocking, "; "))
+	}
+	return nil
+}
+
+// checkImports flags imports on the denylist
+func (pe *PolicyEngine) checkImports(fset *token.FileSet, file *ast.File) []models.ValidationIssue {
+	var issues []models.ValidationIssue
+	for _, imp := range file.Imports {
+		path, _ := strconv.Unquote(imp.Path.Value)
+		if denied := pe.matchForbiddenImport(path); denied != "" {
+			issues = append(issues, models.ValidationIssue{
+				Type:       "forbidden_import",
+				Message:    fmt.Sprintf("import %q is forbidden by policy (%s)", path, denied),
+				Line:       fset.Position(imp.Pos()).Line,
+				Severity:   "error",
+				Suggestion: "use an approved dependency",
+			})
+		}
+	}
+	return issues
+}
+
+// checkBannedAPIs flags calls to banned functions
+func (pe *PolicyEngine) checkBannedAPIs(fset *token.FileSet, file *ast.File) []models.ValidationIssue {
+	var issues []models.ValidationIssue
+	isMain := file.Name != nil && file.Name.Name == "main"
+	imports := newFileImports(file)
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+
+		targets := imports.callTargets(call.Fun)
+		for _, banned := range pe.config.BannedAPIs {
+			if !bannedCall(banned.Call, targets) || (banned.LibraryOnly && isMain) {
+				continue
+			}
+			issues = append(issues, models.ValidationIssue{
+				Type:       "banned_api",
+				Message:    fmt.Sprintf("%s is not allowed: %s", banned.Call, banned.Reason),
+				Line:       fset.Position(call.Pos()).Line,
+				Severity:   "error",
+				Suggestion: "return an error to the caller",
+			})
+		}
+		return true
+	})
+
+	return issues
+}
+
+// checkTextual is the fallback for code snippets that don't parse
+func (pe *PolicyEngine) checkTextual(code string) []models.ValidationIssue {
+	var issues []models.ValidationIssue
+	isMain := strings.Contains(code, "package main")
+
+	for i, line := range strings.Split(code, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "\"") || strings.HasPrefix(trimmed, "import \"") {
+			path := strings.Trim(strings.TrimPrefix(trimmed, "import "), "\"")
+			if denied := pe.matchForbiddenImport(path); denied != "" {
+				issues = append(issues, models.ValidationIssue{
+					Type:     "forbidden_import",
+					Message:  fmt.Sprintf("import %q is forbidden by policy (%s)", path, denied),
+					Line:     i + 1,
+					Severity: "error",
+				})
+			}
+		}
+		for _, banned := range pe.config.BannedAPIs {
+			if banned.LibraryOnly && isMain {
+				continue
+			}
+			if strings.Contains(trimmed, banned.Call+"(") {
+				issues = append(issues, models.ValidationIssue{
+					Type:     "banned_api",
+					Message:  fmt.Sprintf("%s is not allowed: %s", banned.Call, banned.Reason),
+					Line:     i + 1,
+					Severity: "error",
+				})
+			}
+		}
+	}
+	return issues
+}
+
+// matchForbiddenImport returns the denylist entry matching path, if any.
+// An entry matches its exact path; one ending in /... also matches the
+// packages below it.
+func (pe *PolicyEngine) matchForbiddenImport(path string) string {
+	for _, denied := range pe.config.ForbiddenImports {
+		if path == denied {
+			return denied
+		}
+		if base, ok := strings.CutSuffix(denied, "/..."); ok && (path == base || strings.HasPrefix(path, base+"/")) {
+			return denied
+		}
+	}
+	return ""
+}
+
+// stripMarkdownFence returns the body of the first fenced code block, or
+// the input unchanged when the LLM answered with bare code
+func stripMarkdownFence(code string) string {
+	start := strings.Index(code, "```")
+	if start < 0 {
+		return code
+	}
+	body := code[start+3:]
+	if newline := strings.Index(body, "\n"); newline >= 0 {
+		body = body[newline+1:]
+	}
+	if end := strings.Index(body, "```"); end >= 0 {
+		body = body[:end]
+	}
+	return body
+}
+
+// callTarget is a function a call may refer to: its package's import path,
+// empty for builtins and the file's own functions, and its name
+type callTarget struct {
+	path string
+	name string
+}
+
+// fileImports are the packages a file imports, by the name it refers to
+// each with, and those imported with a dot
+type fileImports struct {
+	byName map[string]string
+	dotted []string
+}
+
+func newFileImports(file *ast.File) *fileImports {
+	imports := &fileImports{byName: make(map[string]string)}
+	for _, imp := range file.Imports {
+		path, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := packageName(path)
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		switch name {
+		case "_":
+		case ".":
+			imports.dotted = append(imports.dotted, path)
+		default:
+			imports.byName[name] = path
+		}
+	}
+	return imports
+}
+
+// callTargets resolves a call's function against the file's imports, so
+// aliased and dot imports are seen for the package they are. A selector on
+// a name the file does not import, as in a snippet without its imports, is
+// taken to be that package.
+func (fi *fileImports) callTargets(expr ast.Expr) []callTarget {
+	switch fn := expr.(type) {
+	case *ast.Ident:
+		targets := []callTarget{{name: fn.Name}}
+		// A name declared in the file is not one of a dot import's
+		if fn.Obj == nil {
+			for _, path := range fi.dotted {
+				targets = append(targets, callTarget{path: path, name: fn.Name})
+			}
+		}
+		return targets
+	case *ast.SelectorExpr:
+		pkg, ok := fn.X.(*ast.Ident)
+		// A local variable's method, not a package's function
+		if !ok || pkg.Obj != nil {
+			return nil
+		}
+		path, imported := fi.byName[pkg.Name]
+		if !imported {
+			path = pkg.Name
+		}
+		return []callTarget{{path: path, name: fn.Sel.Name}}
+	}
+	return nil
+}
+
+// bannedCall reports whether a banned call, "Func" or "pkg.Func" with pkg
+// an import path or the name it declares, is one of targets
+func bannedCall(call string, targets []callTarget) bool {
+	dot := strings.LastIndex(call, ".")
+	for _, target := range targets {
+		if dot < 0 {
+			if target.path == "" && target.name == call {
+				return true
+			}
+			continue
+		}
+		qualifier, name := call[:dot], call[dot+1:]
+		if target.path != "" && target.name == name && (target.path == qualifier || packageName(target.path) == qualifier) {
+			return true
+		}
+	}
+	return false
+}
+
+// packageName is the name a package is referred to by when imported
+// without one: the last element of its path, before any major version
+// suffix or gopkg.in version
+func packageName(path string) string {
+	elems := strings.Split(path, "/")
+	name := elems[len(elems)-1]
+	if len(elems) > 1 && majorVersion.MatchString(name) {
+		name = elems[len(elems)-2]
+	}
+	if dot := strings.Index(name, ".v"); dot > 0 {
+		name = name[:dot]
+	}
+	return name
+}
+
+// majorVersion matches a module's major version path suffix, such as v2
+var majorVersion = regexp.MustCompile(`^v[0-9]+$`)
@@ -0,0 +1,50 @@
+package guardrails
+
+import "testing"
+
+func TestBannedAPIsResolveImports(t *testing.T) {
+	config := DefaultPolicyConfig()
+	config.BannedAPIs = append(config.BannedAPIs, BannedAPI{Call: "exec.Command", Reason: "no subprocesses"})
+	engine := NewPolicyEngine(config)
+
+	for _, tc := range []struct {
+		name   string
+		code   string
+		banned int
+	}{
+		{"plain import", "package lib\n\nimport \"os/exec\"\n\nfunc run() { exec.Command(\"ls\") }\n", 1},
+		{"aliased import", "package lib\n\nimport x \"os/exec\"\n\nfunc run() { x.Command(\"ls\") }\n", 1},
+		{"dot import", "package lib\n\nimport . \"os/exec\"\n\nfunc run() { Command(\"ls\") }\n", 1},
+		{"aliased os", "package lib\n\nimport sys \"os\"\n\nfunc stop() { sys.Exit(1) }\n", 1},
+		{"no imports", "package lib\n\nfunc stop() { os.Exit(1) }\n", 1},
+		{"builtin", "package lib\n\nfunc fail() { panic(\"boom\") }\n", 1},
+		{"other package named exec", "package lib\n\nimport exec \"example.com/runner\"\n\nfunc run() { exec.Command(\"ls\") }\n", 0},
+		{"local function", "package lib\n\nimport . \"os/exec\"\n\nfunc Command(string) {}\n\nfunc run() { Command(\"ls\") }\n", 0},
+		{"local variable", "package lib\n\nfunc run(exec runner) { exec.Command(\"ls\") }\n", 0},
+		{"package main", "package main\n\nimport sys \"os\"\n\nfunc main() { sys.Exit(1) }\n", 0},
+	} {
+		banned := 0
+		for _, issue := range engine.Check(tc.code) {
+			if issue.Type == "banned_api" {
+				banned++
+			}
+		}
+		if banned != tc.banned {
+			t.Errorf("%s: %d banned calls, want %d", tc.name, banned, tc.banned)
+		}
+	}
+}
+
+func TestPackageName(t *testing.T) {
+	for path, want := range map[string]string{
+		"os":                        "os",
+		"os/exec":                   "exec",
+		"github.com/foo/bar/v2":     "bar",
+		"gopkg.in/yaml.v3":          "yaml",
+		"example.com/runner/client": "client",
+	} {
+		if got := packageName(path); got != want {
+			t.Errorf("packageName(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
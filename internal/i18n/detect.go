@@ -0,0 +1,124 @@
+// Package i18n detects the natural language a query is written in and
+// translates queries and answers, leaving code, paths and citations as they
+// are.
+package i18n
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// English is the language queries are classified and answered in
+const English = "en"
+
+// languageNames are the supported query languages
+var languageNames = map[string]string{
+	"en": "English",
+	"es": "Spanish",
+	"de": "German",
+	"fr": "French",
+	"pt": "Portuguese",
+	"hi": "Hindi",
+}
+
+// stopWords are frequent function words that identify Latin-script
+// languages; a query rarely avoids all of them
+var stopWords = map[string][]string{
+	"en": {"the", "is", "are", "how", "what", "where", "does", "do", "to", "of", "in", "and", "with", "for", "this", "that", "which", "why", "can", "show", "me", "my"},
+	"es": {"el", "la", "los", "las", "es", "son", "cómo", "como", "qué", "que", "dónde", "donde", "hace", "para", "por", "con", "una", "un", "del", "este", "esta", "muestra", "función", "archivo", "cuál", "porque"},
+	"de": {"der", "die", "das", "ist", "sind", "wie", "was", "wo", "und", "mit", "für", "ein", "eine", "diese", "dieser", "zeig", "zeige", "mir", "funktioniert", "datei", "warum", "welche", "nicht", "ich"},
+	"fr": {"le", "la", "les", "est", "sont", "comment", "quoi", "où", "et", "avec", "pour", "une", "un", "des", "ce", "cette", "montre", "moi", "fonctionne", "fichier", "pourquoi", "quel", "quelle"},
+	"pt": {"o", "os", "as", "é", "são", "como", "onde", "faz", "para", "com", "uma", "um", "do", "da", "este", "esta", "mostre", "função", "arquivo", "qual", "não", "você"},
+}
+
+// latinLanguages are scored by stop-words, English first
+var latinLanguages = []string{"en", "es", "de", "fr", "pt"}
+
+// markers are characters only some languages use
+var markers = map[string]string{
+	"es": "ñ¿¡",
+	"de": "äöüß",
+	"fr": "çèêàù",
+	"pt": "ãõç",
+}
+
+// codeSpan matches text that is code rather than prose: backtick spans,
+// paths, dotted or snake_case names and camelCase identifiers
+var codeSpan = regexp.MustCompile("`[^`]*`|\\S+[/\\\\]\\S+|\\b\\w+[._]\\w+\\b|\\b[a-z]+[A-Z]\\w*\\b")
+
+// Detection is the language a text is written in
+type Detection struct {
+	Language   string  `json:"language"` // ISO 639-1 code
+	Confidence float64 `json:"confidence"`
+}
+
+// Name returns the English name of a language code, or the code itself
+func Name(code string) string {
+	if name, ok := languageNames[code]; ok {
+		return name
+	}
+	return code
+}
+
+// Supported reports whether a language code can be detected and pinned
+func Supported(code string) bool {
+	_, ok := languageNames[code]
+	return ok
+}
+
+// Detect guesses the language of a query from its script and function
+// words, ignoring code. Short or ambiguous text is taken to be English.
+func Detect(text string) Detection {
+	prose := strings.ToLower(codeSpan.ReplaceAllString(text, " "))
+
+	letters, devanagari := 0, 0
+	for _, r := range prose {
+		if unicode.IsLetter(r) {
+			letters++
+			if unicode.Is(unicode.Devanagari, r) {
+				devanagari++
+			}
+		}
+	}
+	if letters == 0 {
+		return Detection{Language: English, Confidence: 0}
+	}
+	if ratio := float64(devanagari) / float64(letters); ratio >= 0.3 {
+		return Detection{Language: "hi", Confidence: min(1, 0.5+ratio)}
+	}
+
+	words := strings.FieldsFunc(prose, func(r rune) bool { return !unicode.IsLetter(r) })
+	scores := make(map[string]float64, len(stopWords))
+	for language, list := range stopWords {
+		set := make(map[string]bool, len(list))
+		for _, word := range list {
+			set[word] = true
+		}
+		for _, word := range words {
+			if set[word] {
+				scores[language]++
+			}
+		}
+	}
+	for language, chars := range markers {
+		if strings.ContainsAny(prose, chars) {
+			scores[language] += 1.5
+		}
+	}
+
+	// English first, so ties keep the default
+	best, bestScore, second := English, scores[English], 0.0
+	for _, language := range latinLanguages[1:] {
+		score := scores[language]
+		if score > bestScore {
+			best, bestScore, second = language, score, bestScore
+		} else if score > second {
+			second = score
+		}
+	}
+	if best == English || bestScore < 2 {
+		return Detection{Language: English, Confidence: 0.5}
+	}
+	return Detection{Language: best, Confidence: min(1, 0.4+0.15*(bestScore-second))}
+}
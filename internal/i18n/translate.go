@@ -0,0 +1,104 @@
+package i18n
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/internal/llm"
+)
+
+// Config controls query translation
+type Config struct {
+	Enabled bool `json:"enabled"`
+	// ResponseLanguage pins the answer language ("en", "es", ...); "auto"
+	// answers in the language of the query
+	ResponseLanguage string  `json:"response_language"`
+	MinConfidence    float64 `json:"min_confidence"` // detections below this are treated as English
+}
+
+// DefaultConfig returns the translation defaults
+func DefaultConfig() Config {
+	return Config{
+		Enabled:          true,
+		ResponseLanguage: "auto",
+		MinConfidence:    0.6,
+	}
+}
+
+// protectedSpan matches what must survive translation byte for byte: code
+// blocks, inline code, URLs and file citations such as main.go:12-30
+var protectedSpan = regexp.MustCompile("(?s)```.*?```|`[^`\\n]+`|https?://\\S+|[\\w./-]+\\.\\w+(?::\\d+(?:-\\d+)?)?")
+
+// placeholder marks a protected span in text sent for translation
+var placeholder = regexp.MustCompile(`⟦(\d+)⟧`)
+
+// Translator translates prose with the LLM, keeping code untouched
+type Translator struct {
+	llm *llm.Manager
+}
+
+// NewTranslator creates a translator using manager
+func NewTranslator(manager *llm.Manager) *Translator {
+	return &Translator{llm: manager}
+}
+
+// Translate renders text in the target language. Code, paths and citations
+// are swapped for placeholders before the call and restored after, so the
+// model cannot alter them.
+func (t *Translator) Translate(ctx context.Context, text, target string) (string, error) {
+	if strings.TrimSpace(text) == "" {
+		return text, nil
+	}
+	masked, spans := Protect(text)
+
+	response, err := t.llm.Generate(ctx, &llm.GenerationRequest{
+		SystemPrompt: fmt.Sprintf("Translate the user's text into %s. Keep every ⟦n⟧ placeholder exactly as written and in place. "+
+			"Do not translate identifiers, and do not add notes or explanations: reply with the translation only.", Name(target)),
+		Messages:    []llm.Message{{Role: "user", Content: masked}},
+		MaxTokens:   max(256, len(masked)),
+		Temperature: 0,
+		Metadata:    map[string]string{"purpose": "translation"},
+	})
+	if err != nil {
+		return "", fmt.Errorf("translation failed: %w", err)
+	}
+	return Restore(strings.TrimSpace(response.Content), spans)
+}
+
+// Protect replaces code, paths and citations with numbered placeholders
+func Protect(text string) (string, []string) {
+	var spans []string
+	masked := protectedSpan.ReplaceAllStringFunc(text, func(span string) string {
+		spans = append(spans, span)
+		return fmt.Sprintf("⟦%d⟧", len(spans)-1)
+	})
+	return masked, spans
+}
+
+// Restore puts protected spans back, failing if the translation lost or
+// invented a placeholder
+func Restore(text string, spans []string) (string, error) {
+	used := make([]bool, len(spans))
+	var missing error
+	restored := placeholder.ReplaceAllStringFunc(text, func(match string) string {
+		i, _ := strconv.Atoi(placeholder.FindStringSubmatch(match)[1])
+		if i >= len(spans) {
+			missing = fmt.Errorf("translation invented placeholder %s", match)
+			return match
+		}
+		used[i] = true
+		return spans[i]
+	})
+	if missing != nil {
+		return "", missing
+	}
+	for i, ok := range used {
+		if !ok {
+			return "", fmt.Errorf("translation dropped %q", spans[i])
+		}
+	}
+	return restored, nil
+}
@@ -0,0 +1,290 @@
+// Package implements type-checks the project's Go packages and records
+// which concrete types satisfy which interfaces, so "what implements X"
+// is answered from the index instead of by an LLM.
+package implements
+
+import (
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// Config controls interface-implementation discovery
+type Config struct {
+	Enabled    bool `json:"enabled"`
+	MaxResults int  `json:"max_results"` // implementations listed per interface in answers
+}
+
+// DefaultConfig returns interface-implementation defaults
+func DefaultConfig() Config {
+	return Config{
+		Enabled:    true,
+		MaxResults: 50,
+	}
+}
+
+// checker type-checks project packages from source with one shared
+// importer, so an interface in one package and a type in another are
+// compared as the same types. The standard library is type-checked from
+// source too; other modules are stood in for by empty packages, so their
+// types are invalid. Invalid types compare equal to each other, so
+// interfaces whose methods mention them are left out rather than matched
+// against every type with like-named methods.
+type checker struct {
+	root     string
+	module   string
+	fset     *token.FileSet
+	stdlib   types.ImporterFrom
+	packages map[string]*types.Package
+	checking map[string]bool
+	project  []*types.Package
+}
+
+// Analyze type-checks the packages in dirs, which are relative to root, and
+// returns every interface they declare with the concrete types of those
+// packages that satisfy it. Type errors are tolerated: a broken package is
+// still checked as far as it goes.
+func Analyze(root, module string, dirs []string) []*storage.InterfaceRecord {
+	c := &checker{
+		root:     root,
+		module:   module,
+		fset:     token.NewFileSet(),
+		packages: make(map[string]*types.Package),
+		checking: make(map[string]bool),
+	}
+	c.stdlib, _ = importer.ForCompiler(c.fset, "source", nil).(types.ImporterFrom)
+	for _, dir := range dirs {
+		c.Import(c.importPath(dir))
+	}
+	return c.implementations()
+}
+
+// Import implements types.Importer
+func (c *checker) Import(path string) (*types.Package, error) {
+	return c.ImportFrom(path, c.root, 0)
+}
+
+// ImportFrom implements types.ImporterFrom
+func (c *checker) ImportFrom(path, dir string, mode types.ImportMode) (*types.Package, error) {
+	if pkg, ok := c.packages[path]; ok {
+		return pkg, nil
+	}
+	var pkg *types.Package
+	switch {
+	case path == c.module || strings.HasPrefix(path, c.module+"/"):
+		if c.checking[path] {
+			// An import cycle; the type checker reports it
+			return types.NewPackage(path, filepath.Base(path)), nil
+		}
+		c.checking[path] = true
+		pkg = c.check(path)
+		delete(c.checking, path)
+	case isStdlib(path) && c.stdlib != nil:
+		if imported, err := c.stdlib.ImportFrom(path, dir, mode); err == nil {
+			pkg = imported
+		}
+	}
+	if pkg == nil {
+		pkg = types.NewPackage(path, filepath.Base(path))
+		pkg.MarkComplete()
+	}
+	c.packages[path] = pkg
+	return pkg, nil
+}
+
+// check type-checks the non-test files of a project package
+func (c *checker) check(path string) *types.Package {
+	dir := filepath.Join(c.root, filepath.FromSlash(strings.TrimPrefix(strings.TrimPrefix(path, c.module), "/")))
+	bp, err := build.Default.ImportDir(dir, 0)
+	if err != nil && len(bp.GoFiles) == 0 {
+		return nil
+	}
+	var files []*ast.File
+	for _, name := range bp.GoFiles {
+		file, err := parser.ParseFile(c.fset, filepath.Join(dir, name), nil, parser.SkipObjectResolution)
+		if err != nil && file == nil {
+			continue
+		}
+		files = append(files, file)
+	}
+	if len(files) == 0 {
+		return nil
+	}
+	conf := types.Config{
+		Importer:                 c,
+		Error:                    func(error) {},
+		DisableUnusedImportCheck: true,
+	}
+	pkg, _ := conf.Check(path, c.fset, files, nil)
+	c.project = append(c.project, pkg)
+	return pkg
+}
+
+// importPath returns the import path of a directory relative to the root
+func (c *checker) importPath(dir string) string {
+	dir = filepath.ToSlash(filepath.Clean(dir))
+	if dir == "." {
+		return c.module
+	}
+	return c.module + "/" + dir
+}
+
+// implementations pairs every named interface of the project with the named
+// concrete types whose value or pointer method set satisfies it, and adds
+// the builtin error interface when project types implement it. Generic
+// types, interfaces that are type constraints and interfaces with methods
+// whose signatures could not be type-checked are skipped.
+func (c *checker) implementations() []*storage.InterfaceRecord {
+	var interfaces []*types.TypeName
+	var concrete []*types.TypeName
+	for _, pkg := range c.project {
+		scope := pkg.Scope()
+		for _, name := range scope.Names() {
+			obj, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || obj.IsAlias() {
+				continue
+			}
+			named, ok := obj.Type().(*types.Named)
+			if !ok || named.TypeParams().Len() > 0 {
+				continue
+			}
+			if iface, ok := named.Underlying().(*types.Interface); ok {
+				if iface.IsMethodSet() && iface.NumMethods() > 0 && !hasInvalidMethod(iface) {
+					interfaces = append(interfaces, obj)
+				}
+				continue
+			}
+			concrete = append(concrete, obj)
+		}
+	}
+	interfaces = append(interfaces, types.Universe.Lookup("error").(*types.TypeName))
+
+	var records []*storage.InterfaceRecord
+	for _, obj := range interfaces {
+		iface := obj.Type().Underlying().(*types.Interface)
+		record := c.interfaceRecord(obj, iface)
+		for _, candidate := range concrete {
+			pointer := false
+			if !types.Implements(candidate.Type(), iface) {
+				if !types.Implements(types.NewPointer(candidate.Type()), iface) {
+					continue
+				}
+				pointer = true
+			}
+			position := c.fset.Position(candidate.Pos())
+			record.Implementations = append(record.Implementations, &storage.ImplementationRecord{
+				Type:    candidate.Name(),
+				Package: candidate.Pkg().Path(),
+				File:    c.relative(position.Filename),
+				Line:    position.Line,
+				Pointer: pointer,
+			})
+		}
+		if obj.Pkg() == nil && len(record.Implementations) == 0 {
+			continue
+		}
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Package != records[j].Package {
+			return records[i].Package < records[j].Package
+		}
+		return records[i].Name < records[j].Name
+	})
+	return records
+}
+
+// interfaceRecord describes an interface; the builtin error interface has
+// no package or file
+func (c *checker) interfaceRecord(obj *types.TypeName, iface *types.Interface) *storage.InterfaceRecord {
+	record := &storage.InterfaceRecord{Name: obj.Name()}
+	if obj.Pkg() != nil {
+		position := c.fset.Position(obj.Pos())
+		record.Package = obj.Pkg().Path()
+		record.File = c.relative(position.Filename)
+		record.Line = position.Line
+	}
+	for i := 0; i < iface.NumMethods(); i++ {
+		record.Methods = append(record.Methods, iface.Method(i).Name())
+	}
+	return record
+}
+
+// hasInvalidMethod reports whether a method of iface mentions a type the
+// checker could not resolve
+func hasInvalidMethod(iface *types.Interface) bool {
+	for i := 0; i < iface.NumMethods(); i++ {
+		if containsInvalid(iface.Method(i).Type(), make(map[types.Type]bool)) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsInvalid reports whether t is, or is built from, the invalid
+// type. Named types are not followed: a project type is checked where it
+// is declared, and an unresolved one is invalid itself.
+func containsInvalid(t types.Type, seen map[types.Type]bool) bool {
+	if t == nil || seen[t] {
+		return false
+	}
+	seen[t] = true
+	switch t := t.(type) {
+	case *types.Basic:
+		return t.Kind() == types.Invalid
+	case *types.Pointer:
+		return containsInvalid(t.Elem(), seen)
+	case *types.Slice:
+		return containsInvalid(t.Elem(), seen)
+	case *types.Array:
+		return containsInvalid(t.Elem(), seen)
+	case *types.Chan:
+		return containsInvalid(t.Elem(), seen)
+	case *types.Map:
+		return containsInvalid(t.Key(), seen) || containsInvalid(t.Elem(), seen)
+	case *types.Signature:
+		return containsInvalid(t.Params(), seen) || containsInvalid(t.Results(), seen)
+	case *types.Tuple:
+		for i := 0; i < t.Len(); i++ {
+			if containsInvalid(t.At(i).Type(), seen) {
+				return true
+			}
+		}
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			if containsInvalid(t.Field(i).Type(), seen) {
+				return true
+			}
+		}
+	case *types.Interface:
+		for i := 0; i < t.NumMethods(); i++ {
+			if containsInvalid(t.Method(i).Type(), seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// relative makes a file path relative to the project root
+func (c *checker) relative(path string) string {
+	if rel, err := filepath.Rel(c.root, path); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
+
+// isStdlib reports whether an import path belongs to the standard library,
+// whose first element has no dot
+func isStdlib(path string) bool {
+	first, _, _ := strings.Cut(path, "/")
+	return !strings.Contains(first, ".")
+}
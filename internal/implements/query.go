@@ -0,0 +1,48 @@
+package implements
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Query directions
+const (
+	// ImplementersOf asks which types implement an interface
+	ImplementersOf = "implementers"
+	// InterfacesOf asks which interfaces a type implements
+	InterfacesOf = "interfaces"
+)
+
+// Query is an interface-implementation question about one named type
+type Query struct {
+	Direction string
+	Name      string // as written, possibly qualified: Storage or storage.Storage
+}
+
+// identifier is a possibly package-qualified Go type name
+const identifier = `\*?([A-Za-z_][A-Za-z0-9_]*(?:\.[A-Za-z_][A-Za-z0-9_]*)?)`
+
+var (
+	// "what implements the Storage interface", "who implements storage.Storage",
+	// "which types satisfy Provider"
+	implementersPattern = regexp.MustCompile(`(?i)\b(?:what|which|who|list|find|show)\b(?:\s+\w+){0,3}?\s+(?:implements?|satisf(?:y|ies))\s+(?:the\s+)?` + identifier + `(?:\s+interface)?\s*\??$`)
+	// "implementations of Storage", "implementers of the Storage interface"
+	implementationsPattern = regexp.MustCompile(`(?i)\bimplement(?:ations|ers|ors)\s+(?:of|for)\s+(?:the\s+)?` + identifier + `(?:\s+interface)?\s*\??$`)
+	// "what interfaces does CodeIndexer implement", "which interfaces does *SQLiteDB satisfy"
+	interfacesPattern = regexp.MustCompile(`(?i)\b(?:what|which)\s+interfaces?\s+(?:does|do|can)\s+(?:the\s+)?` + identifier + `(?:\s+type)?\s+(?:implement|satisfy)\s*\??$`)
+)
+
+// ParseQuery recognizes questions about which types implement an interface
+// or which interfaces a type implements, or returns nil
+func ParseQuery(input string) *Query {
+	input = strings.TrimSpace(input)
+	if match := interfacesPattern.FindStringSubmatch(input); match != nil {
+		return &Query{Direction: InterfacesOf, Name: match[1]}
+	}
+	for _, pattern := range []*regexp.Regexp{implementersPattern, implementationsPattern} {
+		if match := pattern.FindStringSubmatch(input); match != nil {
+			return &Query{Direction: ImplementersOf, Name: match[1]}
+		}
+	}
+	return nil
+}
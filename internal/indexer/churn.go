@@ -0,0 +1,85 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/churn"
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// churnHistoryTTL is how long one read of the git history serves the files
+// indexed after it, so a run reads it once rather than per file
+const churnHistoryTTL = 5 * time.Minute
+
+// SetChurnConfig configures git churn collection for subsequent indexing
+// runs
+func (ci *CodeIndexer) SetChurnConfig(config churn.Config) {
+	ci.indexingMutex.Lock()
+	defer ci.indexingMutex.Unlock()
+
+	ci.churn = config
+	ci.churnMu.Lock()
+	ci.churnHistory = nil
+	ci.churnMu.Unlock()
+}
+
+// storeChurn replaces a file's recorded churn, from the commits that
+// touched it and, when enabled, the commits its chunks' lines come from
+func (ci *CodeIndexer) storeChurn(ctx context.Context, fileInfo *FileInfo, chunks []*CodeChunk) {
+	if !ci.churn.Enabled || ci.storage == nil {
+		return
+	}
+	history := ci.loadChurnHistory(ctx)
+
+	path := fileInfo.Path
+	if rel, err := filepath.Rel(ci.projectRoot, path); err == nil && filepath.IsAbs(path) {
+		path = rel
+	}
+	var records []*storage.ChurnRecord
+	if file := history[filepath.ToSlash(path)]; file != nil {
+		records = append(records, &storage.ChurnRecord{
+			Commits:      file.Commits,
+			LinesChanged: file.LinesChanged,
+			LastCommit:   file.LastCommit,
+			Score:        file.Score,
+		})
+		if ci.churn.Chunks && len(chunks) > 0 {
+			ranges := make([]churn.Range, len(chunks))
+			for i, chunk := range chunks {
+				ranges[i] = churn.Range{Start: chunk.StartLine, End: chunk.EndLine}
+			}
+			scored, err := churn.Chunks(ctx, ci.projectRoot, path, ranges, ci.churn)
+			if err != nil {
+				fmt.Printf("⚠️ Failed to score churn of chunks in %s: %v\n", path, err)
+			}
+			records = append(records, scored...)
+		}
+	}
+
+	// Keyed by the path the file's chunks are indexed under, so search
+	// results can look it up directly
+	if err := ci.rows().ReplaceChurn(fileInfo.Path, records); err != nil {
+		fmt.Printf("⚠️ Failed to update churn of %s: %v\n", path, err)
+	}
+}
+
+// loadChurnHistory returns the per-file churn of the project's recent git
+// history, reading it again once it is older than churnHistoryTTL. Outside
+// a git checkout there is no history and every file scores nothing.
+func (ci *CodeIndexer) loadChurnHistory(ctx context.Context) map[string]*storage.ChurnRecord {
+	ci.churnMu.Lock()
+	defer ci.churnMu.Unlock()
+
+	if ci.churnHistory != nil && time.Since(ci.churnReadAt) < churnHistoryTTL {
+		return ci.churnHistory
+	}
+	history, err := churn.History(ctx, ci.projectRoot, ci.churn)
+	if err != nil {
+		history = make(map[string]*storage.ChurnRecord)
+	}
+	ci.churnHistory, ci.churnReadAt = history, time.Now()
+	return history
+}
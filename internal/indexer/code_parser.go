@@ -9,10 +9,19 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/yourusername/useq-ai-assistant/display"
+	"github.com/yourusername/useq-ai-assistant/internal/churn"
+	"github.com/yourusername/useq-ai-assistant/internal/glossary"
+	"github.com/yourusername/useq-ai-assistant/internal/implements"
+	"github.com/yourusername/useq-ai-assistant/internal/language"
+	"github.com/yourusername/useq-ai-assistant/internal/logger"
+	"github.com/yourusername/useq-ai-assistant/internal/semtags"
+	"github.com/yourusername/useq-ai-assistant/internal/structured"
+	"github.com/yourusername/useq-ai-assistant/internal/todos"
 	"github.com/yourusername/useq-ai-assistant/internal/vectordb"
 	"github.com/yourusername/useq-ai-assistant/storage"
 )
@@ -30,6 +39,31 @@ type CodeIndexer struct {
 	indexingMutex sync.RWMutex
 	stats         IndexingStats
 	embedder      *vectordb.EmbeddingService // Use from vectordb package
+	memoryLimits  MemoryLimits
+	memoryGuard   *MemoryGuard
+	inFlight      chan struct{} // bounds files dispatched but not yet collected
+	writeBatching WriteBatching
+	writer        *storage.BatchWriter // batches SQLite rows during a run
+	queueing      bool                 // chunks go to the embedding queue during a run
+	spilling      bool                 // chunks may be spilled to disk during a run
+	spills        []*spilledChunks     // chunks to store at the end of the run, see storeSpilled
+	spillMu       sync.Mutex
+	glossary      glossary.Config
+	todos         todos.Config
+	todoBlame     map[string][]*storage.TodoRecord // comments to blame at the end of the run
+	todoMu        sync.Mutex
+	churn         churn.Config
+	churnHistory  map[string]*storage.ChurnRecord // per-file churn read from git, see loadChurnHistory
+	churnReadAt   time.Time
+	churnMu       sync.Mutex
+	implements    implements.Config
+	goChanged     atomic.Bool // the run stored Go source, so implementations are stale
+	tags          semtags.Config
+	labeler       semtags.Labeler
+	fileLimits    FileLimits
+	skipped       map[string]*storage.SkippedFile // files the run left out or indexed in part
+	indexedWhole  map[string]bool                 // files the run indexed in full
+	skippedMu     sync.Mutex
 }
 
 // IndexingStats tracks indexing statistics
@@ -44,6 +78,8 @@ type IndexingStats struct {
 	LastUpdate     time.Time     `json:"last_update"`
 	IndexingTime   time.Duration `json:"indexing_time"`
 	ProcessingRate float64       `json:"processing_rate"` // files per second
+	MemoryPauses   int           `json:"memory_pauses"`
+	MemoryPaused   time.Duration `json:"memory_paused"`
 	mu             sync.RWMutex  `json:"-"`
 }
 
@@ -82,15 +118,25 @@ func NewCodeIndexer(projectRoot string, extensions, excludedDirs []string,
 		fmt.Println("📁 Files will be indexed without embeddings")
 	}
 
+	memoryLimits := DefaultMemoryLimits()
+
 	indexer := &CodeIndexer{
-		projectRoot:  projectRoot,
-		extensions:   extensions,
-		excludedDirs: excludedDirs,
-		vectorDB:     vectorDB,
-		storage:      storage,
-		goParser:     NewGoParser(),
-		config:       config,
-		embedder:     embedder,
+		projectRoot:   projectRoot,
+		extensions:    extensions,
+		excludedDirs:  excludedDirs,
+		vectorDB:      vectorDB,
+		storage:       storage,
+		goParser:      NewGoParser(),
+		config:        config,
+		embedder:      embedder,
+		memoryLimits:  memoryLimits,
+		memoryGuard:   NewMemoryGuard(memoryLimits.MaxRSSMB, memoryLimits.CheckInterval),
+		writeBatching: DefaultWriteBatching(),
+		glossary:      glossary.DefaultConfig(),
+		todos:         todos.DefaultConfig(),
+		implements:    implements.DefaultConfig(),
+		tags:          semtags.DefaultConfig(),
+		fileLimits:    DefaultFileLimits(),
 		stats: IndexingStats{
 			StartTime:  time.Now(),
 			LastUpdate: time.Now(),
@@ -109,6 +155,30 @@ func NewCodeIndexer(projectRoot string, extensions, excludedDirs []string,
 	return indexer, nil
 }
 
+// SetMemoryLimits configures the memory footprint controls used by
+// subsequent indexing runs
+func (ci *CodeIndexer) SetMemoryLimits(limits MemoryLimits) {
+	defaults := DefaultMemoryLimits()
+	if limits.MaxInFlightBatches <= 0 {
+		limits.MaxInFlightBatches = defaults.MaxInFlightBatches
+	}
+	if limits.SpillDir == "" {
+		limits.SpillDir = defaults.SpillDir
+	}
+	if limits.CheckInterval <= 0 {
+		limits.CheckInterval = defaults.CheckInterval
+	}
+	if limits.QueueCheckpoint <= 0 {
+		limits.QueueCheckpoint = defaults.QueueCheckpoint
+	}
+
+	ci.indexingMutex.Lock()
+	defer ci.indexingMutex.Unlock()
+
+	ci.memoryLimits = limits
+	ci.memoryGuard = NewMemoryGuard(limits.MaxRSSMB, limits.CheckInterval)
+}
+
 // StartFullReindexingWithProgress forces reindexing of all files with progress tracking
 func (ci *CodeIndexer) StartFullReindexingWithProgress(ctx context.Context, progressCallback func(display.IndexingProgress)) error {
 	ci.indexingMutex.Lock()
@@ -134,11 +204,30 @@ func (ci *CodeIndexer) StartFullReindexingWithProgress(ctx context.Context, prog
 	ci.stats.TotalFiles = len(files)
 
 	// Process files in batches with forced reindexing
-	return ci.processFilesInBatchesForced(ctx, files, progressCallback)
+	if err := ci.processFilesInBatchesForced(ctx, files, progressCallback); err != nil {
+		return err
+	}
+	if _, err := ci.embedQueued(ctx); err != nil {
+		return err
+	}
+
+	// Every file now lives in its shard, so vectors indexed before sharding
+	// would only show up twice
+	if ci.vectorDB != nil && ci.vectorDB.Sharded() {
+		if err := ci.vectorDB.ClearUnsharded(ctx); err != nil {
+			fmt.Printf("⚠️ Failed to clear the unsharded collection: %v\n", err)
+		}
+	}
+
+	ci.recordGeneration(ctx, "full reindex")
+	return nil
 }
 
 // processFilesInBatchesForced processes files in batches, forcing reindex of all files
 func (ci *CodeIndexer) processFilesInBatchesForced(ctx context.Context, files []string, progressCallback func(display.IndexingProgress)) error {
+	ci.beginWrites()
+	defer ci.finishWrites(ctx)
+
 	// Create channels
 	fileChan := make(chan string, ci.config.BatchSize)
 	resultChan := make(chan IndexResult, ci.config.BatchSize)
@@ -151,19 +240,11 @@ func (ci *CodeIndexer) processFilesInBatchesForced(ctx context.Context, files []
 	}
 
 	// Start result collector
+	ci.inFlight = make(chan struct{}, ci.config.BatchSize*ci.memoryLimits.MaxInFlightBatches)
 	go ci.collectResults(resultChan)
 
 	// Send files to workers
-	go func() {
-		defer close(fileChan)
-		for _, file := range files {
-			select {
-			case <-ctx.Done():
-				return
-			case fileChan <- file:
-			}
-		}
-	}()
+	go ci.dispatchFiles(ctx, files, fileChan)
 
 	// Progress reporting
 	ticker := time.NewTicker(100 * time.Millisecond)
@@ -191,6 +272,45 @@ func (ci *CodeIndexer) processFilesInBatchesForced(ctx context.Context, files []
 	}
 }
 
+// dispatchFiles feeds files to the workers batch by batch. At most
+// MaxInFlightBatches batches are outstanding at once, and each batch waits
+// for the memory guard so a large repository never outruns storage.
+func (ci *CodeIndexer) dispatchFiles(ctx context.Context, files []string, fileChan chan<- string) {
+	defer close(fileChan)
+
+	for i, file := range files {
+		if i%ci.config.BatchSize == 0 {
+			if err := ci.memoryGuard.Wait(ctx); err != nil {
+				return
+			}
+		}
+
+		select {
+		case ci.inFlight <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+
+		select {
+		case fileChan <- file:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// releaseResult drops chunk and parse data from a stored result, keeping only
+// the counts the collector needs, so queued results don't pin memory
+func releaseResult(result *IndexResult) {
+	if result.FileInfo != nil && result.FileInfo.ParsedData != nil {
+		parsed := result.FileInfo.ParsedData
+		result.FunctionCount = len(parsed.Functions) + len(parsed.Methods)
+		result.TypeCount = len(parsed.Types) + len(parsed.Interfaces)
+		result.FileInfo.ParsedData = nil
+	}
+	result.Chunks = nil
+}
+
 // workerForced processes files with forced reindexing
 func (ci *CodeIndexer) workerForced(ctx context.Context, fileChan <-chan string, resultChan chan<- IndexResult, wg *sync.WaitGroup) {
 	defer wg.Done()
@@ -201,6 +321,7 @@ func (ci *CodeIndexer) workerForced(ctx context.Context, fileChan <-chan string,
 			return
 		default:
 			result := ci.indexFileForced(ctx, file)
+			releaseResult(&result)
 			resultChan <- result
 		}
 	}
@@ -213,22 +334,14 @@ func (ci *CodeIndexer) indexFileForced(ctx context.Context, filePath string) Ind
 		Success: false,
 	}
 
-	// Read file content
-	content, err := os.ReadFile(filePath)
+	// Read the file; binary and oversized files are skipped or indexed in part
+	content, reason, skipped, err := ci.readIndexable(filePath)
 	if err != nil {
-		result.Error = fmt.Errorf("failed to read file: %w", err)
-		return result
-	}
-
-	// Check file size
-	if int64(len(content)) > ci.config.MaxFileSize {
-		result.Error = fmt.Errorf("file too large: %d bytes", len(content))
+		result.Error = err
 		return result
 	}
-
-	// Skip binary files
-	if ci.config.SkipBinaryFiles && ci.isBinaryFile(content) {
-		fmt.Printf("⏭️ Skipping binary file: %s\n", filePath)
+	if skipped {
+		fmt.Printf("⏭️ Skipping %s file: %s\n", reason, filePath)
 		ci.stats.mu.Lock()
 		ci.stats.SkippedFiles++
 		ci.stats.mu.Unlock()
@@ -249,8 +362,14 @@ func (ci *CodeIndexer) indexFileForced(ctx context.Context, filePath string) Ind
 	fmt.Printf("🔍 File: %s, Language: %s, Size: %d\n", filePath, fileInfo.Language, len(content))
 
 	// Parse file based on language
-	switch fileInfo.Language {
-	case "go":
+	switch {
+	case reason == storage.SkipReasonSignatures:
+		fmt.Printf("✂️ Indexing declarations of oversized file: %s\n", filePath)
+		result = ci.indexSignatures(ctx, filePath, string(content), fileInfo)
+	case reason == storage.SkipReasonTruncated:
+		fmt.Printf("✂️ Indexing start of oversized file: %s\n", filePath)
+		result = ci.indexGenericFile(ctx, filePath, ci.truncateContent(content), fileInfo)
+	case fileInfo.Language == "go":
 		fmt.Printf("🔧 Processing Go file: %s\n", filePath)
 		result = ci.indexGoFile(ctx, filePath, string(content), fileInfo)
 	default:
@@ -258,6 +377,9 @@ func (ci *CodeIndexer) indexFileForced(ctx context.Context, filePath string) Ind
 		result = ci.indexGenericFile(ctx, filePath, string(content), fileInfo)
 	}
 
+	if result.Success && reason == "" {
+		ci.noteIndexedWhole(filePath)
+	}
 	return result
 }
 
@@ -319,6 +441,7 @@ func (ci *CodeIndexer) StartIndexingWithProgress(ctx context.Context, progressCa
 		time.Sleep(10 * time.Millisecond)
 	}
 
+	ci.reportSkipped()
 	return nil
 }
 
@@ -348,20 +471,67 @@ func (ci *CodeIndexer) StartIndexing(ctx context.Context) error {
 	ci.stats.mu.Unlock()
 
 	// Process files in batches using worker pool
-	return ci.processFilesInBatches(ctx, files)
+	if err := ci.processFilesInBatches(ctx, files); err != nil {
+		return err
+	}
+	if _, err := ci.embedQueued(ctx); err != nil {
+		return err
+	}
+
+	ci.recordGeneration(ctx, "incremental index")
+	return nil
+}
+
+// recordGeneration reports the files the run skipped and snapshots the
+// index so generations can be diffed later with `index diff`
+func (ci *CodeIndexer) recordGeneration(ctx context.Context, notes string) {
+	ci.reportSkipped()
+	if ci.storage == nil {
+		return
+	}
+
+	vectorCount := -1
+	if ci.vectorDB != nil {
+		if count, err := ci.vectorDB.CountPoints(ctx); err == nil {
+			vectorCount = count
+		} else {
+			fmt.Printf("⚠️ Could not count vectors for index generation: %v\n", err)
+		}
+	}
+
+	ci.refreshDocGlossary()
+
+	gen, err := ci.storage.RecordIndexGeneration(vectorCount, notes)
+	if err != nil {
+		fmt.Printf("⚠️ Failed to record index generation: %v\n", err)
+		return
+	}
+	fmt.Printf("🗂️ Recorded index generation #%d (%d files, %d chunks, %d symbols)\n",
+		gen.ID, gen.FileCount, gen.ChunkCount, gen.SymbolCount)
 }
 
 // scanFiles scans the project directory for files to index
 func (ci *CodeIndexer) scanFiles() ([]string, error) {
+	return ci.walkProject(true)
+}
+
+// walkProject lists the files the indexer would index, reporting each
+// decision when verbose
+func (ci *CodeIndexer) walkProject(verbose bool) ([]string, error) {
 	var files []string
 	var mu sync.Mutex
-	
-	fmt.Printf("🔍 Scanning project root: %s\n", ci.projectRoot)
-	fmt.Printf("🔍 Looking for extensions: %v\n", ci.extensions)
-	
+	logf := func(format string, args ...interface{}) {
+		if verbose {
+			fmt.Printf(format, args...)
+		}
+	}
+
+	logf("🔍 Scanning project root: %s\n", ci.projectRoot)
+	logf("🔍 Looking for extensions: %v\n", ci.extensions)
+
 	// Convert to absolute path for debugging
 	absPath, _ := filepath.Abs(ci.projectRoot)
-	fmt.Printf("🔍 Absolute path: %s\n", absPath)
+	logf("🔍 Absolute path: %s\n", absPath)
 	
 	// Pre-compile extension map for O(1) lookup
 	extMap := make(map[string]bool)
@@ -380,7 +550,7 @@ func (ci *CodeIndexer) scanFiles() ([]string, error) {
 			// Skip common excluded directories immediately (but not root)
 			if path != ci.projectRoot && (name == ".git" || name == "vendor" || name == "node_modules" || 
 			   name == ".vscode" || name == ".idea" || (strings.HasPrefix(name, ".") && name != ".")) {
-				fmt.Printf("⏭️ Skipping common excluded dir: %s\n", path)
+				logf("⏭️ Skipping common excluded dir: %s\n", path)
 				return filepath.SkipDir
 			}
 			
@@ -388,7 +558,7 @@ func (ci *CodeIndexer) scanFiles() ([]string, error) {
 			relPath, _ := filepath.Rel(ci.projectRoot, path)
 			for _, excluded := range ci.excludedDirs {
 				if strings.HasPrefix(relPath, excluded) {
-					fmt.Printf("⏭️ Skipping configured excluded dir: %s\n", path)
+					logf("⏭️ Skipping configured excluded dir: %s\n", path)
 					return filepath.SkipDir
 				}
 			}
@@ -401,11 +571,11 @@ func (ci *CodeIndexer) scanFiles() ([]string, error) {
 			return nil
 		}
 
-		fmt.Printf("✅ Found matching file: %s\n", path)
+		logf("✅ Found matching file: %s\n", path)
 
 		// Skip test files if configured (single check)
 		if ci.config.SkipTestFiles && strings.Contains(path, "_test.go") {
-			fmt.Printf("⏭️ Skipping test file: %s\n", path)
+			logf("⏭️ Skipping test file: %s\n", path)
 			return nil
 		}
 
@@ -415,12 +585,15 @@ func (ci *CodeIndexer) scanFiles() ([]string, error) {
 		return nil
 	})
 
-	fmt.Printf("📊 Total files found: %d\n", len(files))
+	logf("📊 Total files found: %d\n", len(files))
 	return files, err
 }
 
 // processFilesInBatches processes files using a worker pool
 func (ci *CodeIndexer) processFilesInBatches(ctx context.Context, files []string) error {
+	ci.beginWrites()
+	defer ci.finishWrites(ctx)
+
 	// Create work channels
 	fileChan := make(chan string, ci.config.BatchSize)
 	resultChan := make(chan IndexResult, ci.config.BatchSize)
@@ -433,19 +606,11 @@ func (ci *CodeIndexer) processFilesInBatches(ctx context.Context, files []string
 	}
 
 	// Start result collector
+	ci.inFlight = make(chan struct{}, ci.config.BatchSize*ci.memoryLimits.MaxInFlightBatches)
 	go ci.collectResults(resultChan)
 
 	// Send files to workers
-	go func() {
-		defer close(fileChan)
-		for _, file := range files {
-			select {
-			case fileChan <- file:
-			case <-ctx.Done():
-				return
-			}
-		}
-	}()
+	go ci.dispatchFiles(ctx, files, fileChan)
 
 	// Wait for all workers to complete
 	wg.Wait()
@@ -456,11 +621,13 @@ func (ci *CodeIndexer) processFilesInBatches(ctx context.Context, files []string
 
 // IndexResult represents the result of indexing a file
 type IndexResult struct {
-	File     string
-	Success  bool
-	Error    error
-	FileInfo *FileInfo
-	Chunks   []*CodeChunk
+	File          string
+	Success       bool
+	Error         error
+	FileInfo      *FileInfo
+	Chunks        []*CodeChunk
+	FunctionCount int
+	TypeCount     int
 }
 
 // worker processes files from the channel
@@ -474,6 +641,7 @@ func (ci *CodeIndexer) worker(ctx context.Context, fileChan <-chan string,
 			return
 		default:
 			result := ci.indexFile(ctx, file)
+			releaseResult(&result)
 			resultChan <- result
 		}
 	}
@@ -487,10 +655,8 @@ func (ci *CodeIndexer) collectResults(resultChan <-chan IndexResult) {
 			ci.stats.IndexedFiles++
 
 			// Update function and type counts
-			if result.FileInfo != nil && result.FileInfo.ParsedData != nil {
-				ci.stats.TotalFunctions += len(result.FileInfo.ParsedData.Functions) + len(result.FileInfo.ParsedData.Methods)
-				ci.stats.TotalTypes += len(result.FileInfo.ParsedData.Types) + len(result.FileInfo.ParsedData.Interfaces)
-			}
+			ci.stats.TotalFunctions += result.FunctionCount
+			ci.stats.TotalTypes += result.TypeCount
 		} else {
 			ci.stats.FailedFiles++
 			if result.Error != nil {
@@ -506,9 +672,15 @@ func (ci *CodeIndexer) collectResults(resultChan <-chan IndexResult) {
 			totalProcessed := ci.stats.IndexedFiles + ci.stats.FailedFiles
 			ci.stats.ProcessingRate = float64(totalProcessed) / ci.stats.IndexingTime.Seconds()
 		}
+		ci.stats.MemoryPauses, ci.stats.MemoryPaused = ci.memoryGuard.Stats()
 
 		ci.stats.mu.Unlock()
 
+		// Free the dispatch slot for this file
+		if ci.inFlight != nil {
+			<-ci.inFlight
+		}
+
 		// Print progress
 		ci.printProgress()
 	}
@@ -536,53 +708,7 @@ func (ci *CodeIndexer) indexFile(ctx context.Context, filePath string) IndexResu
 		return result
 	}
 
-	// Read file content
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		result.Error = fmt.Errorf("failed to read file: %w", err)
-		return result
-	}
-
-	// Check file size
-	if int64(len(content)) > ci.config.MaxFileSize {
-		result.Error = fmt.Errorf("file too large: %d bytes", len(content))
-		return result
-	}
-
-	// Skip binary files
-	if ci.config.SkipBinaryFiles && ci.isBinaryFile(content) {
-		fmt.Printf("⏭️ Skipping binary file: %s\n", filePath)
-		ci.stats.mu.Lock()
-		ci.stats.SkippedFiles++
-		ci.stats.mu.Unlock()
-		result.Success = true
-		return result
-	}
-
-	// Create file info
-	fileInfo := &FileInfo{
-		Path:         filePath,
-		Hash:         ci.calculateHash(content),
-		Size:         int64(len(content)),
-		LastModified: ci.getModTime(filePath),
-		Language:     ci.detectLanguage(filePath),
-		IndexedAt:    time.Now(),
-	}
-
-	// fmt.Printf("🔍 File: %s, Language: %s\n", filePath, fileInfo.Language)
-	fmt.Printf("🔍 File: %s, Language: %s, Size: %d\n", filePath, fileInfo.Language, len(content))
-
-	// Parse file based on language
-	switch fileInfo.Language {
-	case "go":
-		fmt.Printf("🔧 Processing Go file: %s\n", filePath)
-		result = ci.indexGoFile(ctx, filePath, string(content), fileInfo)
-	default:
-		fmt.Printf("🔧 Processing generic file: %s (lang: %s)\n", filePath, fileInfo.Language)
-		result = ci.indexGenericFile(ctx, filePath, string(content), fileInfo)
-	}
-
-	return result
+	return ci.indexFileForced(ctx, filePath)
 }
 
 // indexGoFile indexes a Go source file
@@ -631,7 +757,9 @@ func (ci *CodeIndexer) indexGoFile(ctx context.Context, filePath, content string
 		}}
 	}
 
-	result.Chunks = chunks
+	if !ci.memoryLimits.SpillToDisk {
+		result.Chunks = chunks
+	}
 	fileInfo.ChunkCount = len(chunks)
 
 	// Always store file, even with empty chunks
@@ -655,9 +783,17 @@ func (ci *CodeIndexer) indexGenericFile(ctx context.Context, filePath, content s
 		FileInfo: fileInfo,
 	}
 
-	// Create generic chunks
-	chunks := ci.createGenericChunks(filePath, content, fileInfo.Language)
-	result.Chunks = chunks
+	// Configuration, SQL, shell and notebook files split at their own
+	// boundaries; everything else by size
+	var chunks []*CodeChunk
+	if split := structured.Split(fileInfo.Language, content, structured.DefaultMaxLines); split != nil && len(split.Sections) > 0 {
+		chunks = ci.createStructuredChunks(filePath, fileInfo, split)
+	} else {
+		chunks = ci.createGenericChunks(filePath, content, fileInfo.Language)
+	}
+	if !ci.memoryLimits.SpillToDisk {
+		result.Chunks = chunks
+	}
 	fileInfo.ChunkCount = len(chunks)
 
 	// Store chunks
@@ -874,19 +1010,24 @@ func (ci *CodeIndexer) storeFileAndChunks(ctx context.Context, fileInfo *FileInf
 		LastIndexed:  fileInfo.IndexedAt,
 	}
 
-	if err := ci.storage.SaveFile(sqliteFile); err != nil {
+	if err := ci.rows().SaveFile(sqliteFile); err != nil {
 		fmt.Printf("❌ Failed to save file %s: %v\n", fileInfo.Path, err)
 		return fmt.Errorf("failed to save file to SQLite: %w", err)
 	}
-	fmt.Printf("✅ Saved file to DB: %s\n", fileInfo.Path)
+	logger.Verbosef(ctx, logger.ComponentIndexer, "✅ Saved file to DB: %s", fileInfo.Path)
+	ci.storeTodos(ctx, fileInfo, string(content))
+	ci.storeChurn(ctx, fileInfo, chunks)
+	if strings.HasSuffix(fileInfo.Path, ".go") && !strings.HasSuffix(fileInfo.Path, "_test.go") {
+		ci.goChanged.Store(true)
+	}
 
 	// Store functions if parsed data is available
-	fmt.Printf("🔍 DEBUG: Checking parsed data for %s\n", fileInfo.Path)
+	logger.Debugf(ctx, logger.ComponentIndexer, "🔍 Checking parsed data for %s", fileInfo.Path)
 	if fileInfo.ParsedData != nil {
 		parsedCode := fileInfo.ParsedData
-		fmt.Printf("🔍 DEBUG: Found %d functions to store\n", len(parsedCode.Functions))
+		logger.Debugf(ctx, logger.ComponentIndexer, "🔍 Found %d functions to store", len(parsedCode.Functions))
 		for _, function := range parsedCode.Functions {
-			fmt.Printf("🔍 DEBUG: Storing function: %s\n", function.Name)
+			logger.Debugf(ctx, logger.ComponentIndexer, "🔍 Storing function: %s", function.Name)
 			sqliteFunction := &storage.CodeFunction{
 				FileID:     0, // Will be resolved by SaveFunction using file path
 				Name:       function.Name,
@@ -896,66 +1037,114 @@ func (ci *CodeIndexer) storeFileAndChunks(ctx context.Context, fileInfo *FileInf
 				Visibility: function.Visibility,
 				Type:       "function",
 			}
+			if function.Kind != "" {
+				sqliteFunction.Type = function.Kind
+			}
 			// Pass the file path so SaveFunction can resolve the correct file_id
-			if err := ci.storage.SaveFunctionForFile(sqliteFunction, fileInfo.Path); err != nil {
+			if err := ci.rows().SaveFunctionForFile(sqliteFunction, fileInfo.Path); err != nil {
 				fmt.Printf("❌ Failed to save function %s: %v\n", function.Name, err)
 			} else {
-				fmt.Printf("✅ Saved function: %s\n", function.Name)
+				logger.Debugf(ctx, logger.ComponentIndexer, "✅ Saved function: %s", function.Name)
 			}
 		}
-		fmt.Printf("✅ Saved %d functions for %s\n", len(parsedCode.Functions), fileInfo.Path)
+		logger.Verbosef(ctx, logger.ComponentIndexer, "✅ Saved %d functions for %s", len(parsedCode.Functions), fileInfo.Path)
+		ci.storeGlossaryTerms(fileInfo)
 	} else {
-		fmt.Printf("🔍 DEBUG: No parsed data for %s\n", fileInfo.Path)
+		logger.Debugf(ctx, logger.ComponentIndexer, "🔍 No parsed data for %s", fileInfo.Path)
 	}
 
-	// Store chunks even without embeddings
-	for _, chunk := range chunks {
-		chunkFile := &storage.CodeFile{
-			Path:      fmt.Sprintf("%s#chunk_%d", fileInfo.Path, chunk.ChunkIndex),
-			Name:      fmt.Sprintf("chunk_%d", chunk.ChunkIndex),
-			Extension: filepath.Ext(fileInfo.Path),
-			Content:   chunk.Content,
-			Language:  fileInfo.Language,
-			Hash:      ci.calculateHash([]byte(chunk.Content)),
-		}
-		if err := ci.storage.SaveFile(chunkFile); err != nil {
-			fmt.Printf("⚠️ Failed to save chunk %d for %s: %v\n", chunk.ChunkIndex, fileInfo.Path, err)
+	ci.tagChunks(ctx, chunks)
+	ci.clearChunkKeywords(fileInfo.Path)
+
+	// While memory is over the limit, chunks are spilled to disk and stored
+	// once the run's files are, rather than held while embeddings are
+	// generated
+	if ci.spilling && len(chunks) > 0 && ci.memoryGuard.overLimit() {
+		spill, err := spillChunks(ci.memoryLimits.SpillDir, fileInfo.Hash, chunks)
+		if err == nil {
+			ci.spillMu.Lock()
+			ci.spills = append(ci.spills, &spilledChunks{fileInfo: &FileInfo{Path: fileInfo.Path, Language: fileInfo.Language}, spill: spill})
+			ci.spillMu.Unlock()
+			return nil
 		}
+		fmt.Printf("⚠️ Chunk spill failed for %s, keeping chunks in memory: %v\n", fileInfo.Path, err)
 	}
+
 	if ci.vectorDB != nil {
 		fmt.Printf("🔄 Processing %d chunks for vector storage\n", len(chunks))
-		for _, chunk := range chunks {
-			// Generate OpenAI embedding
-			embedding, err := ci.vectorDB.GenerateOpenAIEmbedding(ctx, chunk.Content)
-			if err != nil {
-				fmt.Printf("⚠️ Failed to generate embedding for chunk %s: %v\n", chunk.ID, err)
-				continue
-			}
-
-			// Create CodeChunk for vector storage
-			codeChunk := &vectordb.CodeChunk{
-				ID:        chunk.ID,
-				Content:   chunk.Content,
-				FilePath:  chunk.FilePath,
-				Language:  chunk.Language,
-				StartLine: chunk.StartLine,
-				EndLine:   chunk.EndLine,
-			}
-
-			// Store in Qdrant with embedding
-			if err := ci.vectorDB.StoreChunkWithEmbedding(ctx, codeChunk, embedding); err != nil {
-				fmt.Printf("⚠️ Failed to store chunk in Qdrant: %v\n", err)
-			} else {
-				fmt.Printf("✅ Stored chunk %s in vector DB\n", chunk.ID)
-			}
-		}
 	} else {
 		fmt.Printf("⚠️ VectorDB is nil, skipping vector storage\n")
 	}
+	for _, chunk := range chunks {
+		ci.storeChunk(ctx, fileInfo, chunk)
+	}
 
 	return nil
 }
 
+// storeChunk saves a chunk to SQLite and, when available, to the vector DB
+func (ci *CodeIndexer) storeChunk(ctx context.Context, fileInfo *FileInfo, chunk *CodeChunk) {
+	// Store chunks even without embeddings
+	chunkFile := &storage.CodeFile{
+		Path:      fmt.Sprintf("%s#chunk_%d", fileInfo.Path, chunk.ChunkIndex),
+		Name:      fmt.Sprintf("chunk_%d", chunk.ChunkIndex),
+		Extension: filepath.Ext(fileInfo.Path),
+		Content:   chunk.Content,
+		Language:  fileInfo.Language,
+		Hash:      ci.calculateHash([]byte(chunk.Content)),
+	}
+	if err := ci.rows().SaveFile(chunkFile); err != nil {
+		fmt.Printf("⚠️ Failed to save chunk %d for %s: %v\n", chunk.ChunkIndex, fileInfo.Path, err)
+	}
+
+	if ci.vectorDB == nil {
+		ci.indexChunkKeywords(chunkFile.Path, chunk)
+		return
+	}
+
+	// Create CodeChunk for vector storage
+	codeChunk := &vectordb.CodeChunk{
+		ID:         chunk.ID,
+		Content:    chunk.Content,
+		FilePath:   chunk.FilePath,
+		Language:   chunk.Language,
+		StartLine:  chunk.StartLine,
+		EndLine:    chunk.EndLine,
+		ContentRef: chunkFile.Path,
+		Tags:       chunk.Tags,
+	}
+
+	// Embed with the words describing the chunk that its content leaves out
+	text := chunk.Content
+	if header := chunk.Metadata["embedding_header"]; header != "" {
+		text = header + "\n\n" + chunk.Content
+	}
+
+	// Large runs queue the chunk on disk and embed it after the files are
+	// stored, see embedQueued
+	if ci.queueing {
+		err := ci.queueEmbedding(codeChunk, text)
+		if err == nil {
+			return
+		}
+		fmt.Printf("⚠️ Failed to queue chunk %s, embedding it now: %v\n", chunk.ID, err)
+	}
+
+	// Generate OpenAI embedding
+	embedding, err := ci.vectorDB.GenerateOpenAIEmbedding(ctx, text)
+	if err != nil {
+		fmt.Printf("⚠️ Failed to generate embedding for chunk %s: %v\n", chunk.ID, err)
+		return
+	}
+
+	// Store in Qdrant with embedding
+	if err := ci.vectorDB.StoreChunkWithEmbedding(ctx, codeChunk, embedding); err != nil {
+		fmt.Printf("⚠️ Failed to store chunk in Qdrant: %v\n", err)
+	} else {
+		fmt.Printf("✅ Stored chunk %s in vector DB\n", chunk.ID)
+	}
+}
+
 // needsReindex checks if a file needs to be reindexed
 func (ci *CodeIndexer) needsReindex(filePath string) (bool, error) {
 	existingFile, err := ci.storage.GetFile(filePath)
@@ -991,77 +1180,10 @@ func (ci *CodeIndexer) getModTime(filePath string) time.Time {
 
 // detectLanguage detects programming language based on file extension
 func (ci *CodeIndexer) detectLanguage(filePath string) string {
-	ext := filepath.Ext(filePath)
-	switch ext {
-	case ".go":
-		return "go"
-	case ".py":
-		return "python"
-	case ".js":
-		return "javascript"
-	case ".ts":
-		return "typescript"
-	case ".java":
-		return "java"
-	case ".cpp", ".cc", ".cxx":
-		return "cpp"
-	case ".c":
-		return "c"
-	case ".rs":
-		return "rust"
-	case ".rb":
-		return "ruby"
-	case ".php":
-		return "php"
-	case ".cs":
-		return "csharp"
-	case ".swift":
-		return "swift"
-	case ".kt":
-		return "kotlin"
-	case ".scala":
-		return "scala"
-	case ".r":
-		return "r"
-	case ".sh", ".bash":
-		return "bash"
-	case ".sql":
-		return "sql"
-	case ".yaml", ".yml":
-		return "yaml"
-	case ".json":
-		return "json"
-	case ".xml":
-		return "xml"
-	case ".md":
-		return "markdown"
-	case ".tex":
-		return "latex"
-	case ".html":
-		return "html"
-	case ".css":
-		return "css"
-	default:
-		return "text"
+	if lang := language.FromExtension(filePath); lang != "" {
+		return lang
 	}
-}
-
-// isBinaryFile checks if content appears to be binary
-func (ci *CodeIndexer) isBinaryFile(content []byte) bool {
-	if len(content) == 0 {
-		return false
-	}
-
-	// Check for null bytes (common in binary files)
-	for i := 0; i < len(content) && i < 512; i++ {
-		if content[i] == 0 {
-			return true
-		}
-	}
-
-	// For known text extensions, don't check ratio
-	// This is a simple fix to avoid false positives
-	return false
+	return "text"
 }
 
 // printProgress prints indexing progress
@@ -1096,6 +1218,54 @@ func (ci *CodeIndexer) StartWatching(ctx context.Context) error {
 	return ci.fileWatcher.Start(ctx, ci.handleFileChange)
 }
 
+// ScanFiles returns the project files the indexer would index
+func (ci *CodeIndexer) ScanFiles() ([]string, error) {
+	return ci.scanFiles()
+}
+
+// DiscoverFiles returns the project files the indexer would index without
+// reporting the scan, for checks made while answering queries
+func (ci *CodeIndexer) DiscoverFiles() ([]string, error) {
+	return ci.walkProject(false)
+}
+
+// RecordGeneration snapshots the index after a run made outside the
+// StartIndexing methods, such as a resumable reindex task
+func (ci *CodeIndexer) RecordGeneration(ctx context.Context, notes string) {
+	ci.recordGeneration(ctx, notes)
+}
+
+// NeedsReindex reports whether a file changed since it was last indexed
+func (ci *CodeIndexer) NeedsReindex(filePath string) (bool, error) {
+	return ci.needsReindex(filePath)
+}
+
+// IndexFile re-indexes a single file, refreshing its chunks and embeddings
+// if it changed since the last run
+func (ci *CodeIndexer) IndexFile(ctx context.Context, filePath string) error {
+	result := ci.indexFile(ctx, filePath)
+	if result.Success {
+		return nil
+	}
+	if result.Error != nil {
+		return result.Error
+	}
+	return fmt.Errorf("failed to index %s", filePath)
+}
+
+// ReindexFile indexes a single file whether or not it changed, embedding
+// all of its chunks again
+func (ci *CodeIndexer) ReindexFile(ctx context.Context, filePath string) error {
+	result := ci.indexFileForced(ctx, filePath)
+	if result.Success {
+		return nil
+	}
+	if result.Error != nil {
+		return result.Error
+	}
+	return fmt.Errorf("failed to index %s", filePath)
+}
+
 // handleFileChange handles file change events
 func (ci *CodeIndexer) handleFileChange(event FileChangeEvent) {
 	ctx, cancel := context.WithTimeout(context.Background(), ci.config.IndexTimeout)
@@ -1125,6 +1295,11 @@ func (ci *CodeIndexer) removeFileFromIndex(ctx context.Context, filePath string)
 		return fmt.Errorf("failed to delete from SQLite: %w", err)
 	}
 
+	if err := ci.storage.DeleteQueuedEmbeddings(filePath); err != nil {
+		fmt.Printf("⚠️ Failed to drop queued embeddings of %s: %v\n", filePath, err)
+	}
+	ci.clearChunkKeywords(filePath)
+
 	// Remove embeddings from vector DB by file path
 	// Note: Basic Qdrant client doesn't have DeleteByFilePath, would need custom implementation
 	fmt.Printf("⚠️  File deletion from vector DB not implemented for: %s\n", filePath)
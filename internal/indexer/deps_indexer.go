@@ -0,0 +1,115 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/yourusername/useq-ai-assistant/internal/deps"
+	"github.com/yourusername/useq-ai-assistant/internal/vectordb"
+)
+
+// DependencyIndexStats summarizes one dependency indexing run
+type DependencyIndexStats struct {
+	Modules []string `json:"modules"`
+	Missing []string `json:"missing"` // selected but not in the module cache
+	Files   int      `json:"files"`
+	Chunks  int      `json:"chunks"`
+	Failed  int      `json:"failed"`
+}
+
+// IndexDependencies embeds the source of the selected dependencies into the
+// separate dependency collection. Dependency code is never written to the
+// project tables, so project search is unaffected unless a query opts in.
+func (ci *CodeIndexer) IndexDependencies(ctx context.Context, config deps.Config) (*DependencyIndexStats, error) {
+	if len(config.Modules) == 0 {
+		return nil, fmt.Errorf("no dependencies selected; set dependencies.modules")
+	}
+	if ci.vectorDB == nil {
+		return nil, fmt.Errorf("dependency indexing requires the vector database")
+	}
+
+	modules, missing, err := deps.Resolve(filepath.Join(ci.projectRoot, "go.mod"), config.Modules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve dependencies: %w", err)
+	}
+	depsDB, err := ci.vectorDB.WithCollection(config.Collection)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &DependencyIndexStats{Missing: missing}
+	for _, module := range modules {
+		files, err := deps.SourceFiles(module, config.MaxFilesPerModule, config.IncludeTests)
+		if err != nil {
+			return stats, err
+		}
+		fmt.Printf("📦 Indexing %s (%d files)\n", module.Label(), len(files))
+		stats.Modules = append(stats.Modules, module.Label())
+
+		for _, file := range files {
+			if ctx.Err() != nil {
+				return stats, ctx.Err()
+			}
+			chunks, err := ci.indexDependencyFile(ctx, depsDB, module, file)
+			if err != nil {
+				fmt.Printf("⚠️ Failed to index %s: %v\n", deps.DisplayPath(module, file), err)
+				stats.Failed++
+				continue
+			}
+			stats.Files++
+			stats.Chunks += chunks
+		}
+	}
+	return stats, nil
+}
+
+// indexDependencyFile chunks and embeds one dependency file, returning the
+// number of chunks stored
+func (ci *CodeIndexer) indexDependencyFile(ctx context.Context, depsDB *vectordb.QdrantClient, module deps.Module, file string) (int, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return 0, err
+	}
+	if int64(len(content)) > ci.config.MaxFileSize {
+		return 0, fmt.Errorf("file too large: %d bytes", len(content))
+	}
+
+	displayPath := deps.DisplayPath(module, file)
+	parsed, err := ci.goParser.ParseFile(displayPath, string(content))
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse: %w", err)
+	}
+	chunks, err := ci.createGoChunks(displayPath, string(content), parsed)
+	if err != nil {
+		return 0, err
+	}
+
+	stored := 0
+	for _, chunk := range chunks {
+		embedding, err := depsDB.GenerateOpenAIEmbedding(ctx, chunk.Content)
+		if err != nil {
+			return stored, fmt.Errorf("failed to embed chunk: %w", err)
+		}
+		vectorChunk := &vectordb.CodeChunk{
+			// Project chunk IDs are only unique within the project collection
+			ID:        fmt.Sprintf("%s:%s#%d", deps.Origin, displayPath, chunk.ChunkIndex),
+			Content:   chunk.Content,
+			FilePath:  displayPath,
+			Language:  chunk.Language,
+			StartLine: chunk.StartLine,
+			EndLine:   chunk.EndLine,
+			Origin:    deps.Origin,
+			Module:    module.Label(),
+			// Dependency source gets keyword tags only; labeling it would cost
+			// a model call per batch of third-party code
+			Tags: ci.keywordTags(chunk.Content),
+		}
+		if err := depsDB.StoreChunkWithEmbedding(ctx, vectorChunk, embedding); err != nil {
+			return stored, fmt.Errorf("failed to store chunk: %w", err)
+		}
+		stored++
+	}
+	return stored, nil
+}
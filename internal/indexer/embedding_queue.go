@@ -0,0 +1,165 @@
+package indexer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/display"
+	"github.com/yourusername/useq-ai-assistant/internal/vectordb"
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// queueEmbedding persists a chunk for embedding later in the run
+func (ci *CodeIndexer) queueEmbedding(chunk *vectordb.CodeChunk, text string) error {
+	payload, err := json.Marshal(chunk)
+	if err != nil {
+		return err
+	}
+	return ci.rows().EnqueueEmbedding(&storage.QueuedEmbedding{
+		ChunkID:  chunk.ID,
+		FilePath: chunk.FilePath,
+		Text:     text,
+		Payload:  string(payload),
+	})
+}
+
+// embedQueued drains the embedding queue after the files of a run are
+// stored. Chunks are embedded QueueCheckpoint at a time and each batch is
+// deleted from the queue once its vectors are stored, so a crash or Ctrl-C
+// loses at most one batch and the next start resumes from the last
+// checkpoint.
+func (ci *CodeIndexer) embedQueued(ctx context.Context) (int, error) {
+	defer func() { ci.queueing = false }()
+	if ci.storage == nil || ci.vectorDB == nil {
+		return 0, nil
+	}
+	if ci.writer != nil {
+		// Queue rows still in the last write batch must be visible
+		if err := ci.writer.Flush(); err != nil {
+			return 0, err
+		}
+	}
+
+	stats, err := ci.storage.GetEmbeddingQueueStats()
+	if err != nil {
+		return 0, err
+	}
+	if stats.Pending == 0 {
+		return 0, nil
+	}
+	fmt.Printf("🧮 Embedding %d queued chunks from %d files\n", stats.Pending, stats.Files)
+
+	start := time.Now()
+	embedded, failed := 0, 0
+	var afterID int64
+	for {
+		batch, err := ci.storage.PendingEmbeddings(afterID, ci.memoryLimits.QueueCheckpoint)
+		if err != nil {
+			return embedded, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		var done []int64
+		for _, item := range batch {
+			if ctx.Err() != nil {
+				break
+			}
+			afterID = item.ID
+			if err := ci.embedQueuedChunk(ctx, item); err != nil {
+				if ctx.Err() != nil {
+					break
+				}
+				failed++
+				fmt.Printf("⚠️ Failed to embed queued chunk %s: %v\n", item.ChunkID, err)
+				if err := ci.storage.FailEmbedding(item.ID, err); err != nil {
+					fmt.Printf("⚠️ %v\n", err)
+				}
+				continue
+			}
+			done = append(done, item.ID)
+		}
+
+		if err := ci.storage.CompleteEmbeddings(done); err != nil {
+			return embedded, fmt.Errorf("failed to checkpoint the embedding queue: %w", err)
+		}
+		embedded += len(done)
+		if ctx.Err() != nil {
+			fmt.Printf("⏸️ Embedding stopped after %d of %d chunks; run `./useq-ai index resume` to finish\n", embedded, stats.Pending)
+			return embedded, ctx.Err()
+		}
+		fmt.Printf("💾 Checkpoint: %d/%d queued chunks embedded\n", embedded, stats.Pending)
+	}
+
+	fmt.Printf("✅ Embedded %d queued chunks in %v", embedded, time.Since(start).Round(time.Second))
+	if failed > 0 {
+		fmt.Printf(" (%d failed, retried on the next resume)", failed)
+	}
+	fmt.Println()
+	return embedded, nil
+}
+
+// embedQueuedChunk embeds one queued chunk and stores its vector
+func (ci *CodeIndexer) embedQueuedChunk(ctx context.Context, item *storage.QueuedEmbedding) error {
+	var chunk vectordb.CodeChunk
+	if err := json.Unmarshal([]byte(item.Payload), &chunk); err != nil {
+		return fmt.Errorf("bad queued chunk: %w", err)
+	}
+	embedding, err := ci.vectorDB.GenerateOpenAIEmbedding(ctx, item.Text)
+	if err != nil {
+		return err
+	}
+	return ci.vectorDB.StoreChunkWithEmbedding(ctx, &chunk, embedding)
+}
+
+// EmbeddingQueue reports the chunks still waiting for embeddings, e.g. after
+// an indexing run was interrupted
+func (ci *CodeIndexer) EmbeddingQueue() (*storage.EmbeddingQueueStats, error) {
+	if ci.storage == nil {
+		return &storage.EmbeddingQueueStats{}, nil
+	}
+	return ci.storage.GetEmbeddingQueueStats()
+}
+
+// ResumeIndexing finishes an interrupted indexing run: files not indexed yet
+// are indexed, then the embedding queue is drained. Files are checkpointed by
+// their stored hash and queued chunks by the queue, so nothing done before
+// the interruption is repeated.
+func (ci *CodeIndexer) ResumeIndexing(ctx context.Context, progressCallback func(display.IndexingProgress)) (int, error) {
+	ci.indexingMutex.Lock()
+	defer ci.indexingMutex.Unlock()
+
+	files, err := ci.scanFiles()
+	if err != nil {
+		return 0, fmt.Errorf("failed to scan files: %w", err)
+	}
+	var remaining []string
+	for _, file := range files {
+		if changed, err := ci.needsReindex(file); err == nil && changed {
+			remaining = append(remaining, file)
+		}
+	}
+
+	if len(remaining) > 0 {
+		fmt.Printf("🔁 Resuming indexing: %d of %d files left\n", len(remaining), len(files))
+		ci.stats = IndexingStats{
+			StartTime:  time.Now(),
+			TotalFiles: len(remaining),
+		}
+		if err := ci.processFilesInBatchesForced(ctx, remaining, progressCallback); err != nil {
+			return 0, err
+		}
+	}
+
+	embedded, err := ci.embedQueued(ctx)
+	if err != nil {
+		return embedded, err
+	}
+	if len(remaining) > 0 || embedded > 0 {
+		ci.recordGeneration(ctx, "resumed index")
+	}
+	return embedded, nil
+}
@@ -0,0 +1,333 @@
+package indexer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// Strategies for files over the size cap
+const (
+	OversizedSignatures = "signatures" // index the declarations only
+	OversizedTruncate   = "truncate"   // index the first MaxFileSize bytes
+	OversizedSkip       = "skip"       // leave the file out
+)
+
+const (
+	// binarySniffLength is how much of a file is inspected for binary content
+	binarySniffLength = 8000
+	// signaturesPerChunk bounds the declarations embedded together
+	signaturesPerChunk = 100
+	// maxSignatureLength cuts long declaration lines, such as generated
+	// one-line tables
+	maxSignatureLength = 200
+	// skippedReportLines bounds the files listed after a run
+	skippedReportLines = 20
+)
+
+// FileLimits controls how binary and oversized files are indexed
+type FileLimits struct {
+	MaxFileSize   int64  `json:"max_file_size"`  // bytes; larger files are indexed per Oversized
+	Oversized     string `json:"oversized"`      // signatures, truncate or skip
+	MaxSignatures int    `json:"max_signatures"` // declarations indexed from one oversized file
+	SkipAbove     int64  `json:"skip_above"`     // bytes; larger files are skipped without being read
+}
+
+// DefaultFileLimits returns the limits used when none are configured
+func DefaultFileLimits() FileLimits {
+	return FileLimits{
+		MaxFileSize:   1024 * 1024, // 1MB
+		Oversized:     OversizedSignatures,
+		MaxSignatures: 500,
+		SkipAbove:     32 * 1024 * 1024, // 32MB
+	}
+}
+
+// SetFileLimits configures binary and oversized file handling for
+// subsequent indexing runs
+func (ci *CodeIndexer) SetFileLimits(limits FileLimits) {
+	defaults := DefaultFileLimits()
+	if limits.MaxFileSize <= 0 {
+		limits.MaxFileSize = defaults.MaxFileSize
+	}
+	switch limits.Oversized {
+	case OversizedSignatures, OversizedTruncate, OversizedSkip:
+	default:
+		limits.Oversized = defaults.Oversized
+	}
+	if limits.MaxSignatures <= 0 {
+		limits.MaxSignatures = defaults.MaxSignatures
+	}
+	if limits.SkipAbove < limits.MaxFileSize {
+		limits.SkipAbove = max(defaults.SkipAbove, limits.MaxFileSize)
+	}
+
+	ci.indexingMutex.Lock()
+	defer ci.indexingMutex.Unlock()
+
+	ci.fileLimits = limits
+}
+
+// readIndexable reads a file for indexing and screens it. A skip reason
+// means the file is left out; otherwise reason says whether it is indexed
+// whole ("") or in part.
+func (ci *CodeIndexer) readIndexable(filePath string) (content []byte, reason string, skipped bool, err error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("failed to stat file: %w", err)
+	}
+	if info.Size() > ci.fileLimits.SkipAbove {
+		ci.noteSkipped(filePath, storage.SkipReasonOversized, info.Size())
+		return nil, storage.SkipReasonOversized, true, nil
+	}
+
+	content, err = os.ReadFile(filePath)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("failed to read file: %w", err)
+	}
+	size := int64(len(content))
+
+	if ci.config.SkipBinaryFiles && ci.isBinaryFile(content) {
+		ci.noteSkipped(filePath, storage.SkipReasonBinary, size)
+		return content, storage.SkipReasonBinary, true, nil
+	}
+	if size <= ci.fileLimits.MaxFileSize {
+		return content, "", false, nil
+	}
+
+	switch ci.fileLimits.Oversized {
+	case OversizedTruncate:
+		reason = storage.SkipReasonTruncated
+	case OversizedSignatures:
+		reason = storage.SkipReasonSignatures
+	default:
+		ci.noteSkipped(filePath, storage.SkipReasonOversized, size)
+		return content, storage.SkipReasonOversized, true, nil
+	}
+	ci.noteSkipped(filePath, reason, size)
+	return content, reason, false, nil
+}
+
+// isBinaryFile checks if content appears to be binary: a NUL byte, or too
+// many control characters and invalid UTF-8 sequences to be text
+func (ci *CodeIndexer) isBinaryFile(content []byte) bool {
+	sample := content[:min(len(content), binarySniffLength)]
+	if len(sample) == 0 {
+		return false
+	}
+
+	suspicious := 0
+	for i := 0; i < len(sample); {
+		r, size := utf8.DecodeRune(sample[i:])
+		switch {
+		case r == 0:
+			return true
+		case r == utf8.RuneError && size == 1:
+			// A rune cut by the end of the sample is not evidence
+			if len(sample)-i >= utf8.UTFMax {
+				suspicious++
+			}
+		case r < 0x20 && r != '\n' && r != '\r' && r != '\t' && r != '\f' && r != '\b' && r != 0x1b:
+			suspicious++
+		}
+		i += size
+	}
+	return suspicious*10 > len(sample)
+}
+
+// truncateContent cuts an oversized file at the last line break within
+// the size cap
+func (ci *CodeIndexer) truncateContent(content []byte) string {
+	if int64(len(content)) <= ci.fileLimits.MaxFileSize {
+		return string(content)
+	}
+	head := content[:ci.fileLimits.MaxFileSize]
+	if cut := bytes.LastIndexByte(head, '\n'); cut > 0 {
+		head = head[:cut]
+	}
+	return string(head)
+}
+
+// signaturePattern matches the declaration lines of the supported
+// languages, modifiers included
+var signaturePattern = regexp.MustCompile(`^\s*(?:(?:export|public|private|protected|internal|static|abstract|final|async|pub(?:\([a-z]+\))?|default)\s+)*(?:func|type|def|class|interface|struct|enum|trait|impl|fn|function|module|namespace)\b`)
+
+// sqlSignaturePattern matches SQL schema statements
+var sqlSignaturePattern = regexp.MustCompile(`(?i)^\s*create\s+(?:or\s+replace\s+)?(?:table|view|index|unique\s+index|function|procedure|trigger|type)\b`)
+
+// extractSignatures returns the declaration lines of a file with their line
+// numbers, without bodies, up to limit
+func extractSignatures(content string, limit int) (lines []int, signatures []string) {
+	for i, line := range strings.Split(content, "\n") {
+		if len(signatures) >= limit {
+			break
+		}
+		if !signaturePattern.MatchString(line) && !sqlSignaturePattern.MatchString(line) {
+			continue
+		}
+		signature := strings.TrimSpace(line)
+		signature = strings.TrimSpace(strings.TrimSuffix(signature, "{"))
+		if len(signature) > maxSignatureLength {
+			cut := maxSignatureLength
+			for cut > 0 && !utf8.RuneStart(signature[cut]) {
+				cut--
+			}
+			signature = signature[:cut] + "…"
+		}
+		lines = append(lines, i+1)
+		signatures = append(signatures, signature)
+	}
+	return lines, signatures
+}
+
+// indexSignatures indexes an oversized file by its declarations alone. A
+// file without any is indexed truncated instead.
+func (ci *CodeIndexer) indexSignatures(ctx context.Context, filePath, content string, fileInfo *FileInfo) IndexResult {
+	lines, signatures := extractSignatures(content, ci.fileLimits.MaxSignatures)
+	if len(signatures) == 0 {
+		ci.noteSkipped(filePath, storage.SkipReasonTruncated, fileInfo.Size)
+		return ci.indexGenericFile(ctx, filePath, ci.truncateContent([]byte(content)), fileInfo)
+	}
+
+	result := IndexResult{
+		File:     filePath,
+		Success:  false,
+		FileInfo: fileInfo,
+	}
+
+	var chunks []*CodeChunk
+	for start := 0; start < len(signatures); start += signaturesPerChunk {
+		end := min(start+signaturesPerChunk, len(signatures))
+		var body strings.Builder
+		body.WriteString(fmt.Sprintf("// Declarations of %s (%d bytes; bodies not indexed)\n", filePath, fileInfo.Size))
+		for i := start; i < end; i++ {
+			body.WriteString(fmt.Sprintf("L%d: %s\n", lines[i], signatures[i]))
+		}
+
+		chunks = append(chunks, &CodeChunk{
+			ID:         fmt.Sprintf("%s_signatures_%d", ci.calculateHash([]byte(filePath)), len(chunks)),
+			FileID:     ci.calculateHash([]byte(filePath)),
+			FilePath:   filePath,
+			ChunkIndex: len(chunks),
+			Content:    body.String(),
+			StartLine:  lines[start],
+			EndLine:    lines[end-1],
+			Language:   fileInfo.Language,
+			Type:       ChunkTypeSignatures,
+			Metadata: map[string]string{
+				"original_size": fmt.Sprintf("%d", fileInfo.Size),
+				"signatures":    fmt.Sprintf("%d", end-start),
+			},
+		})
+	}
+	if !ci.memoryLimits.SpillToDisk {
+		result.Chunks = chunks
+	}
+	fileInfo.ChunkCount = len(chunks)
+
+	if err := ci.storeFileAndChunks(ctx, fileInfo, chunks); err != nil {
+		result.Error = fmt.Errorf("failed to store file and chunks: %w", err)
+		fmt.Printf("❌ Signature storage error for %s: %v\n", filePath, err)
+		return result
+	}
+
+	fmt.Printf("✅ Oversized file indexed by its %d declarations: %s\n", len(signatures), filePath)
+	result.Success = true
+	return result
+}
+
+// noteSkipped adds a file to the run's skipped-files report; a later note
+// for the same file replaces it
+func (ci *CodeIndexer) noteSkipped(filePath, reason string, size int64) {
+	ci.skippedMu.Lock()
+	defer ci.skippedMu.Unlock()
+
+	if ci.skipped == nil {
+		ci.skipped = make(map[string]*storage.SkippedFile)
+	}
+	ci.skipped[filePath] = &storage.SkippedFile{Path: filePath, Reason: reason, Size: size}
+	delete(ci.indexedWhole, filePath)
+}
+
+// noteIndexedWhole records that a file was indexed in full, clearing it from
+// the persisted skipped-files list
+func (ci *CodeIndexer) noteIndexedWhole(filePath string) {
+	ci.skippedMu.Lock()
+	defer ci.skippedMu.Unlock()
+
+	if ci.indexedWhole == nil {
+		ci.indexedWhole = make(map[string]bool)
+	}
+	ci.indexedWhole[filePath] = true
+	delete(ci.skipped, filePath)
+}
+
+// reportSkipped prints the files the run left out or indexed in part and
+// persists them for `index skipped`
+func (ci *CodeIndexer) reportSkipped() {
+	ci.skippedMu.Lock()
+	skipped := make([]*storage.SkippedFile, 0, len(ci.skipped))
+	for _, file := range ci.skipped {
+		skipped = append(skipped, file)
+	}
+	indexed := make([]string, 0, len(ci.indexedWhole))
+	for path := range ci.indexedWhole {
+		indexed = append(indexed, path)
+	}
+	ci.skipped, ci.indexedWhole = nil, nil
+	ci.skippedMu.Unlock()
+
+	if ci.storage != nil {
+		if err := ci.storage.UpdateSkippedFiles(skipped, indexed); err != nil {
+			fmt.Printf("⚠️ Failed to record skipped files: %v\n", err)
+		}
+	}
+	if len(skipped) == 0 {
+		return
+	}
+
+	sort.Slice(skipped, func(i, j int) bool {
+		if skipped[i].Size != skipped[j].Size {
+			return skipped[i].Size > skipped[j].Size
+		}
+		return skipped[i].Path < skipped[j].Path
+	})
+	counts := make(map[string]int)
+	for _, file := range skipped {
+		counts[file.Reason]++
+	}
+	var summary []string
+	for _, reason := range []string{storage.SkipReasonBinary, storage.SkipReasonOversized, storage.SkipReasonSignatures, storage.SkipReasonTruncated} {
+		if counts[reason] > 0 {
+			summary = append(summary, fmt.Sprintf("%d %s", counts[reason], reason))
+		}
+	}
+
+	fmt.Printf("⏭️ %d files not fully indexed (%s):\n", len(skipped), strings.Join(summary, ", "))
+	for i, file := range skipped {
+		if i == skippedReportLines {
+			fmt.Printf("   … and %d more (see ./useq-ai index skipped)\n", len(skipped)-i)
+			break
+		}
+		fmt.Printf("   %-10s %s (%s)\n", file.Reason, file.Path, FormatFileSize(file.Size))
+	}
+}
+
+// FormatFileSize renders a byte count for reports
+func FormatFileSize(size int64) string {
+	switch {
+	case size >= 1024*1024:
+		return fmt.Sprintf("%.1fMB", float64(size)/(1024*1024))
+	case size >= 1024:
+		return fmt.Sprintf("%.1fKB", float64(size)/1024)
+	default:
+		return fmt.Sprintf("%dB", size)
+	}
+}
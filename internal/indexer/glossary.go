@@ -0,0 +1,60 @@
+package indexer
+
+import (
+	"fmt"
+
+	"github.com/yourusername/useq-ai-assistant/internal/glossary"
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// SetGlossaryConfig configures glossary extraction for subsequent indexing
+// runs
+func (ci *CodeIndexer) SetGlossaryConfig(config glossary.Config) {
+	ci.indexingMutex.Lock()
+	defer ci.indexingMutex.Unlock()
+
+	ci.glossary = config
+}
+
+// storeGlossaryTerms replaces a file's glossary terms with its documented
+// exported types and interfaces
+func (ci *CodeIndexer) storeGlossaryTerms(fileInfo *FileInfo) {
+	if !ci.glossary.Enabled || ci.storage == nil || fileInfo.ParsedData == nil {
+		return
+	}
+
+	parsed := fileInfo.ParsedData
+	var entries []*storage.GlossaryEntry
+	for _, typeDef := range parsed.Types {
+		if entry := glossary.FromType(typeDef.Name, typeDef.DocString); entry != nil {
+			entries = append(entries, entry)
+		}
+	}
+	for _, iface := range parsed.Interfaces {
+		if entry := glossary.FromType(iface.Name, iface.DocString); entry != nil {
+			entries = append(entries, entry)
+		}
+	}
+	if err := ci.rows().ReplaceGlossaryTerms(fileInfo.Path, entries); err != nil {
+		fmt.Printf("⚠️ Failed to update glossary terms of %s: %v\n", fileInfo.Path, err)
+	}
+}
+
+// refreshDocGlossary re-extracts the terms defined in the configured docs
+func (ci *CodeIndexer) refreshDocGlossary() {
+	if !ci.glossary.Enabled || ci.storage == nil {
+		return
+	}
+
+	terms := 0
+	for file, entries := range glossary.ScanDocs(ci.projectRoot, ci.glossary.Docs) {
+		if err := ci.rows().ReplaceGlossaryTerms(file, entries); err != nil {
+			fmt.Printf("⚠️ Failed to update glossary terms of %s: %v\n", file, err)
+			continue
+		}
+		terms += len(entries)
+	}
+	if terms > 0 {
+		fmt.Printf("📖 Glossary: %d terms from docs\n", terms)
+	}
+}
@@ -1,6 +1,7 @@
 package indexer
 
 import (
+	"context"
 	"fmt"
 	"go/ast"
 	"go/doc"
@@ -9,6 +10,8 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/internal/logger"
 )
 
 // GoParser parses Go source code and extracts structural information
@@ -56,6 +59,7 @@ type Function struct {
 	IsTest      bool        `json:"is_test"`
 	IsBenchmark bool        `json:"is_benchmark"`
 	CallsTo     []string    `json:"calls_to"`
+	Kind        string      `json:"kind,omitempty"` // what a non-Go symbol is: key, table, view...; empty for functions
 }
 
 // Method represents a method declaration
@@ -192,20 +196,22 @@ func (gp *GoParser) ParseFile(filename string, content string) (*ParsedCode, err
 		Metadata:     make(map[string]string),
 	}
 
-	fmt.Printf("🔍 [DEBUG] Parsing %s with %d declarations\n", filename, len(astFile.Decls))
+	// Parsing runs outside any query, at the process verbosity
+	ctx := context.Background()
+	logger.Debugf(ctx, logger.ComponentParser, "🔍 Parsing %s with %d declarations", filename, len(astFile.Decls))
 
 	// Parse top-level declarations with debug logging
 	for i, decl := range astFile.Decls {
-		fmt.Printf("  %d. Processing declaration type: %T\n", i+1, decl)
+		logger.Debugf(ctx, logger.ComponentParser, "  %d. Processing declaration type: %T", i+1, decl)
 
 		switch d := decl.(type) {
 		case *ast.GenDecl:
 			switch d.Tok {
 			case token.TYPE:
-				fmt.Printf("    Processing TYPE declaration with %d specs\n", len(d.Specs))
+				logger.Debugf(ctx, logger.ComponentParser, "    Processing TYPE declaration with %d specs", len(d.Specs))
 				for j, spec := range d.Specs {
 					if typeSpec, ok := spec.(*ast.TypeSpec); ok {
-						fmt.Printf("      Spec %d: Type %s\n", j+1, typeSpec.Name.Name)
+						logger.Debugf(ctx, logger.ComponentParser, "      Spec %d: Type %s", j+1, typeSpec.Name.Name)
 
 						typeDef := TypeDef{
 							Name:      typeSpec.Name.Name,
@@ -217,10 +223,10 @@ func (gp *GoParser) ParseFile(filename string, content string) (*ParsedCode, err
 						switch t := typeSpec.Type.(type) {
 						case *ast.StructType:
 							typeDef.Kind = "struct"
-							fmt.Printf("        -> Struct type\n")
+							logger.Debugf(ctx, logger.ComponentParser, "        -> Struct type")
 						case *ast.InterfaceType:
 							typeDef.Kind = "interface"
-							fmt.Printf("        -> Interface type\n")
+							logger.Debugf(ctx, logger.ComponentParser, "        -> Interface type")
 							// Handle interface separately
 							interfaceDef := Interface{
 								Name:      typeSpec.Name.Name,
@@ -234,28 +240,28 @@ func (gp *GoParser) ParseFile(filename string, content string) (*ParsedCode, err
 						case *ast.Ident:
 							typeDef.Kind = "alias"
 							typeDef.Underlying = t.Name
-							fmt.Printf("        -> Type alias: %s\n", t.Name)
+							logger.Debugf(ctx, logger.ComponentParser, "        -> Type alias: %s", t.Name)
 						default:
 							typeDef.Kind = "type"
 							typeDef.Underlying = "complex"
-							fmt.Printf("        -> Other type: %T\n", t)
+							logger.Debugf(ctx, logger.ComponentParser, "        -> Other type: %T", t)
 						}
 
 						parsed.Types = append(parsed.Types, typeDef)
 					}
 				}
 			case token.CONST:
-				fmt.Printf("    Processing CONST declaration\n")
+				logger.Debugf(ctx, logger.ComponentParser, "    Processing CONST declaration")
 				// Handle constants...
 			case token.VAR:
-				fmt.Printf("    Processing VAR declaration\n")
+				logger.Debugf(ctx, logger.ComponentParser, "    Processing VAR declaration")
 				// Handle variables...
 			}
 
 		case *ast.FuncDecl:
-			fmt.Printf("    Processing FUNCTION declaration\n")
+			logger.Debugf(ctx, logger.ComponentParser, "    Processing FUNCTION declaration")
 			if d.Name != nil {
-				fmt.Printf("      Function name: %s\n", d.Name.Name)
+				logger.Debugf(ctx, logger.ComponentParser, "      Function name: %s", d.Name.Name)
 
 				function := Function{
 					Name:        d.Name.Name,
@@ -275,23 +281,23 @@ func (gp *GoParser) ParseFile(filename string, content string) (*ParsedCode, err
 
 				if d.Recv != nil {
 					// It's a method
-					fmt.Printf("        -> This is a method\n")
+					logger.Debugf(ctx, logger.ComponentParser, "        -> This is a method")
 					method := Method{
 						Function: function,
 					}
 					parsed.Methods = append(parsed.Methods, method)
 				} else {
 					// It's a function
-					fmt.Printf("        -> This is a function\n")
+					logger.Debugf(ctx, logger.ComponentParser, "        -> This is a function")
 					parsed.Functions = append(parsed.Functions, function)
 				}
 			} else {
-				fmt.Printf("      WARNING: Function with nil name\n")
+				logger.Debugf(ctx, logger.ComponentParser, "      WARNING: Function with nil name")
 			}
 		}
 	}
 
-	fmt.Printf("✅ [DEBUG] Parsed %s: %d functions, %d methods, %d types, %d interfaces\n",
+	logger.Debugf(ctx, logger.ComponentParser, "✅ Parsed %s: %d functions, %d methods, %d types, %d interfaces",
 		filename, len(parsed.Functions), len(parsed.Methods), len(parsed.Types), len(parsed.Interfaces))
 
 	return parsed, nil
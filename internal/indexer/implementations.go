@@ -0,0 +1,70 @@
+package indexer
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/internal/deps"
+	"github.com/yourusername/useq-ai-assistant/internal/implements"
+)
+
+// SetImplementsConfig configures interface-implementation discovery for
+// subsequent indexing runs
+func (ci *CodeIndexer) SetImplementsConfig(config implements.Config) {
+	ci.indexingMutex.Lock()
+	defer ci.indexingMutex.Unlock()
+
+	ci.implements = config
+}
+
+// refreshImplementations type-checks the packages of the indexed Go files
+// and replaces the recorded interface-implementation edges in the run's
+// write batch. Indexing parses files one at a time, so this is the run's
+// one type-checking step, taken only when the run stored Go source.
+// Projects without a go.mod are skipped.
+func (ci *CodeIndexer) refreshImplementations() {
+	if !ci.implements.Enabled || ci.storage == nil {
+		return
+	}
+	module, err := deps.ModulePath(filepath.Join(ci.projectRoot, "go.mod"))
+	if err != nil {
+		return
+	}
+	files, err := ci.storage.GetIndexedFiles()
+	if err != nil {
+		fmt.Printf("⚠️ Failed to list indexed files for implementations: %v\n", err)
+		return
+	}
+
+	seen := make(map[string]bool)
+	var dirs []string
+	for _, file := range files {
+		if !strings.HasSuffix(file, ".go") || strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		if rel, err := filepath.Rel(ci.projectRoot, file); err == nil && filepath.IsAbs(file) {
+			file = rel
+		}
+		if dir := filepath.Dir(file); !seen[dir] && !strings.HasPrefix(dir, "..") {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	if len(dirs) == 0 {
+		return
+	}
+	sort.Strings(dirs)
+
+	interfaces := implements.Analyze(ci.projectRoot, module, dirs)
+	if err := ci.rows().ReplaceImplementations(interfaces); err != nil {
+		fmt.Printf("⚠️ Failed to record implementations: %v\n", err)
+		return
+	}
+	edges := 0
+	for _, iface := range interfaces {
+		edges += len(iface.Implementations)
+	}
+	fmt.Printf("🧩 Implementations: %d interfaces, %d implementing types\n", len(interfaces), edges)
+}
@@ -21,6 +21,15 @@ const (
 	ChunkTypeStruct    ChunkType = "struct"
 	ChunkTypeVariable  ChunkType = "variable"
 	ChunkTypeConstant  ChunkType = "constant"
+	ChunkTypeConfig    ChunkType = "config"    // a YAML key and its values
+	ChunkTypeStatement ChunkType = "statement" // SQL statements
+	ChunkTypeScript    ChunkType = "script"    // shell commands outside functions
+	ChunkTypeCell      ChunkType = "cell"      // a notebook code cell
+	ChunkTypeNote      ChunkType = "note"      // notebook markdown
+
+	// ChunkTypeSignatures holds the declarations of a file too large to
+	// index whole
+	ChunkTypeSignatures ChunkType = "signatures"
 )
 
 // CodeChunk represents a chunk of code for embedding
@@ -36,6 +45,7 @@ type CodeChunk struct {
 	Type       ChunkType         `json:"type"`
 	Context    ChunkContext      `json:"context"`
 	Metadata   map[string]string `json:"metadata"`
+	Tags       []string          `json:"tags,omitempty"` // semantic tags: auth, db, http, concurrency...
 }
 
 // ChunkContext provides context about the code chunk
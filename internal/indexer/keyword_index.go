@@ -0,0 +1,39 @@
+package indexer
+
+import (
+	"fmt"
+
+	"github.com/yourusername/useq-ai-assistant/internal/keyword"
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// Without a vector database chunks are indexed by keyword instead, so Tier 2
+// searches still have something to rank
+
+// indexChunkKeywords adds a stored chunk to the keyword index
+func (ci *CodeIndexer) indexChunkKeywords(ref string, chunk *CodeChunk) {
+	if ci.storage == nil {
+		return
+	}
+	entry := &storage.KeywordChunk{
+		Ref:       ref,
+		Path:      chunk.FilePath,
+		StartLine: chunk.StartLine,
+		EndLine:   chunk.EndLine,
+		Language:  chunk.Language,
+	}
+	if err := ci.rows().IndexKeywordChunk(entry, keyword.Terms(chunk.Content)); err != nil {
+		fmt.Printf("⚠️ Failed to index keywords of %s: %v\n", ref, err)
+	}
+}
+
+// clearChunkKeywords drops a file's chunks from the keyword index before it
+// is re-chunked or once it is deleted
+func (ci *CodeIndexer) clearChunkKeywords(path string) {
+	if ci.storage == nil || ci.vectorDB != nil {
+		return
+	}
+	if err := ci.rows().DeleteKeywordChunks(path); err != nil {
+		fmt.Printf("⚠️ Failed to clear keywords of %s: %v\n", path, err)
+	}
+}
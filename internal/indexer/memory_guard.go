@@ -0,0 +1,226 @@
+package indexer
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryLimits bounds the indexer's memory footprint on large repositories
+type MemoryLimits struct {
+	MaxRSSMB           int           `json:"max_rss_mb"`            // pause indexing above this RSS (0 = no limit)
+	MaxInFlightBatches int           `json:"max_in_flight_batches"` // batches dispatched but not yet stored
+	SpillToDisk        bool          `json:"spill_to_disk"`         // over MaxRSSMB, park chunks on disk until the run's files are stored
+	SpillDir           string        `json:"spill_dir"`
+	CheckInterval      time.Duration `json:"check_interval"`
+	PersistQueue       bool          `json:"persist_queue"`    // queue chunks for embedding in SQLite so a crash keeps progress
+	QueueCheckpoint    int           `json:"queue_checkpoint"` // embeddings stored between queue checkpoints
+}
+
+// DefaultMemoryLimits returns limits suitable for typical repositories
+func DefaultMemoryLimits() MemoryLimits {
+	return MemoryLimits{
+		MaxRSSMB:           0,
+		MaxInFlightBatches: 2,
+		SpillToDisk:        false,
+		SpillDir:           filepath.Join(os.TempDir(), "useq-index-spill"),
+		CheckInterval:      500 * time.Millisecond,
+		PersistQueue:       false,
+		QueueCheckpoint:    100,
+	}
+}
+
+// MemoryGuard pauses indexing while the process RSS exceeds the limit
+type MemoryGuard struct {
+	maxRSS        uint64
+	checkInterval time.Duration
+	pauses        int
+	pausedFor     time.Duration
+	mu            sync.Mutex
+}
+
+// NewMemoryGuard creates a memory guard; maxRSSMB <= 0 disables it
+func NewMemoryGuard(maxRSSMB int, checkInterval time.Duration) *MemoryGuard {
+	if checkInterval <= 0 {
+		checkInterval = 500 * time.Millisecond
+	}
+	guard := &MemoryGuard{checkInterval: checkInterval}
+	if maxRSSMB > 0 {
+		guard.maxRSS = uint64(maxRSSMB) * 1024 * 1024
+	}
+	return guard
+}
+
+// Wait blocks until RSS is below the limit or ctx is cancelled. It forces a
+// GC and returns freed memory to the OS before each re-check.
+func (mg *MemoryGuard) Wait(ctx context.Context) error {
+	if mg == nil || mg.maxRSS == 0 {
+		return nil
+	}
+
+	rss := currentRSS()
+	if rss <= mg.maxRSS {
+		return nil
+	}
+
+	start := time.Now()
+	fmt.Printf("⏸️ Indexing paused: RSS %dMB exceeds limit %dMB\n", rss/1024/1024, mg.maxRSS/1024/1024)
+
+	ticker := time.NewTicker(mg.checkInterval)
+	defer ticker.Stop()
+
+	for rss > mg.maxRSS {
+		runtime.GC()
+		debug.FreeOSMemory()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		rss = currentRSS()
+	}
+
+	paused := time.Since(start)
+	mg.mu.Lock()
+	mg.pauses++
+	mg.pausedFor += paused
+	mg.mu.Unlock()
+
+	fmt.Printf("▶️ Indexing resumed after %v (RSS %dMB)\n", paused.Round(time.Millisecond), rss/1024/1024)
+	return nil
+}
+
+// overLimit reports whether RSS is above the limit now
+func (mg *MemoryGuard) overLimit() bool {
+	return mg != nil && mg.maxRSS > 0 && currentRSS() > mg.maxRSS
+}
+
+// Stats returns how often and how long indexing was paused
+func (mg *MemoryGuard) Stats() (pauses int, pausedFor time.Duration) {
+	if mg == nil {
+		return 0, 0
+	}
+	mg.mu.Lock()
+	defer mg.mu.Unlock()
+	return mg.pauses, mg.pausedFor
+}
+
+// currentRSS returns the resident set size, falling back to the Go
+// runtime's view of memory obtained from the OS on non-Linux systems
+func currentRSS() uint64 {
+	if data, err := os.ReadFile("/proc/self/statm"); err == nil {
+		fields := strings.Fields(string(data))
+		if len(fields) > 1 {
+			if pages, err := strconv.ParseUint(fields[1], 10, 64); err == nil {
+				return pages * uint64(os.Getpagesize())
+			}
+		}
+	}
+
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return ms.Sys - ms.HeapReleased
+}
+
+// chunkSpill holds a file's chunks on disk so they can be streamed one at a
+// time instead of being kept in memory until storage finishes
+type chunkSpill struct {
+	path  string
+	count int
+}
+
+// spilledChunks is a file whose chunks wait on disk for the end of the run
+type spilledChunks struct {
+	fileInfo *FileInfo
+	spill    *chunkSpill
+}
+
+// storeSpilled stores the chunks spilled during the run, streaming each
+// file's back from disk one chunk at a time, and removes the spill files
+func (ci *CodeIndexer) storeSpilled(ctx context.Context) {
+	ci.spillMu.Lock()
+	spills := ci.spills
+	ci.spills = nil
+	ci.spillMu.Unlock()
+
+	if len(spills) > 0 {
+		fmt.Printf("🔄 Storing the chunks of %d files spilled to disk\n", len(spills))
+	}
+	for _, spilled := range spills {
+		err := spilled.spill.each(func(chunk *CodeChunk) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			ci.storeChunk(ctx, spilled.fileInfo, chunk)
+			return nil
+		})
+		spilled.spill.remove()
+		if err != nil {
+			fmt.Printf("⚠️ Failed to store spilled chunks of %s: %v\n", spilled.fileInfo.Path, err)
+		}
+	}
+}
+
+// spillChunks writes chunks to a JSON-lines file in dir
+func spillChunks(dir string, fileID string, chunks []*CodeChunk) (*chunkSpill, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create spill directory: %w", err)
+	}
+
+	f, err := os.CreateTemp(dir, "chunks_"+fileID+"_*.jsonl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create spill file: %w", err)
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, chunk := range chunks {
+		if err := enc.Encode(chunk); err != nil {
+			os.Remove(f.Name())
+			return nil, fmt.Errorf("failed to spill chunk: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		os.Remove(f.Name())
+		return nil, fmt.Errorf("failed to flush spill file: %w", err)
+	}
+
+	return &chunkSpill{path: f.Name(), count: len(chunks)}, nil
+}
+
+// each streams spilled chunks to fn in order
+func (cs *chunkSpill) each(fn func(*CodeChunk) error) error {
+	f, err := os.Open(cs.path)
+	if err != nil {
+		return fmt.Errorf("failed to open spill file: %w", err)
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(bufio.NewReader(f))
+	for dec.More() {
+		var chunk CodeChunk
+		if err := dec.Decode(&chunk); err != nil {
+			return fmt.Errorf("failed to read spilled chunk: %w", err)
+		}
+		if err := fn(&chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// remove deletes the spill file
+func (cs *chunkSpill) remove() {
+	os.Remove(cs.path)
+}
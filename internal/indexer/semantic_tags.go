@@ -0,0 +1,65 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/yourusername/useq-ai-assistant/internal/semtags"
+)
+
+// SetTagConfig configures chunk tagging for subsequent indexing runs.
+// labeler adds model labels to the keyword tags when LLM labeling is on;
+// it may be nil.
+func (ci *CodeIndexer) SetTagConfig(config semtags.Config, labeler semtags.Labeler) {
+	ci.indexingMutex.Lock()
+	defer ci.indexingMutex.Unlock()
+
+	ci.tags = config
+	ci.labeler = labeler
+}
+
+// tagChunks sets the semantic tags of a file's chunks: keyword heuristics
+// for every chunk, merged with model labels in batches when enabled. A
+// failed labeling batch keeps the keyword tags.
+func (ci *CodeIndexer) tagChunks(ctx context.Context, chunks []*CodeChunk) {
+	if !ci.tags.Enabled {
+		return
+	}
+	for _, chunk := range chunks {
+		chunk.Tags = ci.keywordTags(chunk.Content)
+	}
+	if !ci.tags.LLMLabeling || ci.labeler == nil {
+		return
+	}
+
+	size := ci.tags.LLMBatchSize
+	if size <= 0 {
+		size = semtags.DefaultConfig().LLMBatchSize
+	}
+	for start := 0; start < len(chunks); start += size {
+		batch := chunks[start:min(start+size, len(chunks))]
+		input := make([]semtags.Chunk, len(batch))
+		for i, chunk := range batch {
+			input[i] = semtags.Chunk{Path: chunk.FilePath, Content: chunk.Content}
+		}
+		labels, err := ci.labeler.Label(ctx, input)
+		if err != nil {
+			fmt.Printf("⚠️ Chunk labeling failed, keeping keyword tags: %v\n", err)
+			return
+		}
+		for i, chunk := range batch {
+			if i < len(labels) {
+				chunk.Tags = semtags.Merge(chunk.Tags, labels[i])
+			}
+		}
+	}
+}
+
+// keywordTags returns the heuristic tags of content, or none when tagging
+// is off
+func (ci *CodeIndexer) keywordTags(content string) []string {
+	if !ci.tags.Enabled {
+		return nil
+	}
+	return semtags.Classify(content, ci.tags.MinScore)
+}
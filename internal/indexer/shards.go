@@ -0,0 +1,102 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/display"
+)
+
+// ShardReindexStats summarizes one shard-aware reindex
+type ShardReindexStats struct {
+	Shards  int      `json:"shards"`  // shards the project spans
+	Rebuilt []string `json:"rebuilt"` // shards rebuilt because files in them changed
+	Files   int      `json:"files"`   // files reindexed into the rebuilt shards
+	Removed int      `json:"removed"` // indexed files no longer on disk
+}
+
+// ReindexShards rebuilds only the shards with added, modified or deleted
+// files, such as after a big merge. Each affected shard is emptied and
+// reindexed from scratch, which also drops the vectors of deleted files;
+// untouched shards keep their vectors.
+func (ci *CodeIndexer) ReindexShards(ctx context.Context, progressCallback func(display.IndexingProgress)) (*ShardReindexStats, error) {
+	ci.indexingMutex.Lock()
+	defer ci.indexingMutex.Unlock()
+
+	if ci.vectorDB == nil || !ci.vectorDB.Sharded() {
+		return nil, fmt.Errorf("shard reindexing requires vectordb.sharding.enabled")
+	}
+
+	files, err := ci.scanFiles()
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan files: %w", err)
+	}
+	byShard := make(map[string][]string)
+	affected := make(map[string]bool)
+	for _, file := range files {
+		shard := ci.vectorDB.ShardOf(file)
+		byShard[shard] = append(byShard[shard], file)
+		if !affected[shard] {
+			if changed, err := ci.needsReindex(file); err == nil && changed {
+				affected[shard] = true
+			}
+		}
+	}
+
+	stats := &ShardReindexStats{Shards: len(byShard)}
+	indexed, err := ci.storage.GetIndexedFiles()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list indexed files: %w", err)
+	}
+	for _, file := range indexed {
+		if _, err := os.Stat(file); !os.IsNotExist(err) {
+			continue
+		}
+		if err := ci.storage.DeleteFile(file); err != nil {
+			fmt.Printf("⚠️ Failed to remove deleted file %s: %v\n", file, err)
+			continue
+		}
+		affected[ci.vectorDB.ShardOf(file)] = true
+		stats.Removed++
+	}
+
+	for shard := range affected {
+		stats.Rebuilt = append(stats.Rebuilt, shard)
+	}
+	sort.Strings(stats.Rebuilt)
+	if len(stats.Rebuilt) == 0 {
+		fmt.Printf("✅ All %d shards are up to date\n", stats.Shards)
+		return stats, nil
+	}
+
+	ci.stats = IndexingStats{
+		StartTime: time.Now(),
+	}
+	for _, shard := range stats.Rebuilt {
+		ci.stats.TotalFiles += len(byShard[shard])
+	}
+
+	for _, shard := range stats.Rebuilt {
+		if ctx.Err() != nil {
+			return stats, ctx.Err()
+		}
+		fmt.Printf("🔄 Rebuilding shard %s (%d files)\n", shard, len(byShard[shard]))
+		if err := ci.vectorDB.ResetShard(ctx, shard); err != nil {
+			return stats, fmt.Errorf("failed to reset shard %s: %w", shard, err)
+		}
+		if err := ci.processFilesInBatchesForced(ctx, byShard[shard], progressCallback); err != nil {
+			return stats, err
+		}
+		stats.Files += len(byShard[shard])
+	}
+	if _, err := ci.embedQueued(ctx); err != nil {
+		return stats, err
+	}
+
+	ci.recordGeneration(ctx, "shard reindex: "+strings.Join(stats.Rebuilt, ", "))
+	return stats, nil
+}
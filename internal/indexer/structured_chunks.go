@@ -0,0 +1,73 @@
+package indexer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/internal/structured"
+)
+
+// createStructuredChunks makes a chunk of each section of a YAML, SQL,
+// shell or notebook file, split at the format's own boundaries, and
+// records what the file defines as its symbols
+func (ci *CodeIndexer) createStructuredChunks(filePath string, fileInfo *FileInfo, split *structured.Result) []*CodeChunk {
+	fileID := ci.calculateHash([]byte(filePath))
+	var chunks []*CodeChunk
+	for _, s := range split.Sections {
+		language := fileInfo.Language
+		if s.Language != "" {
+			language = s.Language
+		}
+		chunk := &CodeChunk{
+			ID:         fmt.Sprintf("%s_%s_%d", fileID, s.Kind, len(chunks)),
+			FileID:     fileID,
+			FilePath:   filePath,
+			ChunkIndex: len(chunks),
+			Content:    s.Content,
+			StartLine:  s.StartLine,
+			EndLine:    s.EndLine,
+			Language:   language,
+			Type:       ChunkType(s.Kind),
+			Metadata: map[string]string{
+				"section_kind": s.Kind,
+				"section_name": s.Name,
+			},
+		}
+		if s.Kind == structured.KindFunction {
+			chunk.Context.FunctionName = s.Name
+		}
+		if header := embeddingHeader(filePath, s); header != "" {
+			chunk.Metadata["embedding_header"] = header
+		}
+		chunks = append(chunks, chunk)
+	}
+
+	parsed := &ParsedCode{}
+	for _, symbol := range split.Symbols {
+		parsed.Functions = append(parsed.Functions, Function{
+			Name:       symbol.Name,
+			Signature:  symbol.Signature,
+			StartLine:  symbol.StartLine,
+			EndLine:    symbol.EndLine,
+			Visibility: "public",
+			Kind:       symbol.Kind,
+		})
+	}
+	fileInfo.ParsedData = parsed
+	return chunks
+}
+
+// embeddingHeader describes a section in words its content may lack, such
+// as the full path of a nested YAML key or the notes above a notebook cell.
+// It is embedded with the content but not stored as part of it.
+func embeddingHeader(filePath string, s structured.Section) string {
+	var parts []string
+	parts = append(parts, "file: "+filePath)
+	if s.Name != "" {
+		parts = append(parts, s.Kind+": "+s.Name)
+	}
+	if s.Context != "" {
+		parts = append(parts, s.Context)
+	}
+	return strings.Join(parts, "\n")
+}
@@ -0,0 +1,58 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/yourusername/useq-ai-assistant/internal/prewarm"
+	"github.com/yourusername/useq-ai-assistant/internal/vectordb"
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// IndexFileSummary embeds the summary of one file into the summary
+// collection, where queries about whole files find it
+func (ci *CodeIndexer) IndexFileSummary(ctx context.Context, summaryDB *vectordb.QdrantClient, path string) error {
+	summary, err := prewarm.Summarize(path, ci.storage)
+	if err != nil {
+		return err
+	}
+	text := summary.Text()
+	return storeSummary(ctx, summaryDB, &vectordb.CodeChunk{
+		// One point per file, replaced when the file is summarized again
+		ID:        vectordb.OriginFileSummary + ":" + path,
+		Content:   text,
+		FilePath:  path,
+		Language:  summary.Language,
+		StartLine: 1,
+		EndLine:   summary.LineCount,
+		Origin:    vectordb.OriginFileSummary,
+		Tags:      ci.keywordTags(text),
+	})
+}
+
+// IndexPackageSummary embeds a stored package summary into the summary
+// collection, where queries about whole packages find it
+func (ci *CodeIndexer) IndexPackageSummary(ctx context.Context, summaryDB *vectordb.QdrantClient, record *storage.PackageSummaryRecord) error {
+	return storeSummary(ctx, summaryDB, &vectordb.CodeChunk{
+		ID:       vectordb.OriginPackageSummary + ":" + record.Dir,
+		Content:  record.Summary,
+		FilePath: record.Dir,
+		Origin:   vectordb.OriginPackageSummary,
+		Tags:     ci.keywordTags(record.Summary),
+	})
+}
+
+// storeSummary embeds a summary and stores it in the summary collection
+func storeSummary(ctx context.Context, summaryDB *vectordb.QdrantClient, chunk *vectordb.CodeChunk) error {
+	if summaryDB == nil {
+		return fmt.Errorf("summary search is not enabled")
+	}
+	embedding, err := summaryDB.GenerateOpenAIEmbedding(ctx, chunk.Content)
+	if err != nil {
+		return fmt.Errorf("failed to embed summary of %s: %w", chunk.FilePath, err)
+	}
+	if err := summaryDB.StoreChunkWithEmbedding(ctx, chunk, embedding); err != nil {
+		return fmt.Errorf("failed to store summary of %s: %w", chunk.FilePath, err)
+	}
+	return nil
+}
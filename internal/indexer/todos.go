@@ -0,0 +1,99 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/yourusername/useq-ai-assistant/internal/todos"
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// SetTodoConfig configures TODO mining for subsequent indexing runs
+func (ci *CodeIndexer) SetTodoConfig(config todos.Config) {
+	ci.indexingMutex.Lock()
+	defer ci.indexingMutex.Unlock()
+
+	ci.todos = config
+}
+
+// storeTodos replaces a file's recorded TODO, FIXME and HACK comments,
+// dated and attributed by git blame when enabled. During a run the blame
+// waits for the end of the run, see blameTodos, to keep git off the
+// indexing path.
+func (ci *CodeIndexer) storeTodos(ctx context.Context, fileInfo *FileInfo, content string) {
+	if !ci.todos.Enabled || ci.storage == nil {
+		return
+	}
+
+	// Recorded relative to the project root, as git blame and the todos
+	// command expect
+	path := fileInfo.Path
+	if rel, err := filepath.Rel(ci.projectRoot, path); err == nil && filepath.IsAbs(path) {
+		path = rel
+	}
+	found := todos.Scan(path, content, ci.todos)
+	if ci.todos.Blame && len(found) > 0 && !ci.deferBlame(path, found) {
+		ci.applyBlame(ctx, path, found)
+	}
+	if err := ci.rows().ReplaceTodos(path, found); err != nil {
+		fmt.Printf("⚠️ Failed to update todos of %s: %v\n", path, err)
+	}
+}
+
+// deferBlame queues a file's comments to be blamed after the run, and
+// reports false outside a run
+func (ci *CodeIndexer) deferBlame(path string, found []*storage.TodoRecord) bool {
+	ci.todoMu.Lock()
+	defer ci.todoMu.Unlock()
+
+	if ci.todoBlame == nil {
+		return false
+	}
+	ci.todoBlame[path] = found
+	return true
+}
+
+// blameTodos dates and attributes the comments recorded during the run,
+// one git blame per file, once its rows are committed
+func (ci *CodeIndexer) blameTodos(ctx context.Context) {
+	ci.todoMu.Lock()
+	pending := ci.todoBlame
+	ci.todoBlame = nil
+	ci.todoMu.Unlock()
+
+	paths := make([]string, 0, len(pending))
+	for path := range pending {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if ctx.Err() != nil {
+			return
+		}
+		found := pending[path]
+		if !ci.applyBlame(ctx, path, found) {
+			continue
+		}
+		if err := ci.storage.ReplaceTodos(path, found); err != nil {
+			fmt.Printf("⚠️ Failed to update todos of %s: %v\n", path, err)
+		}
+	}
+}
+
+// applyBlame fills in who last changed each comment and when, reporting
+// whether git blame ran. Outside a git checkout there is nothing to blame
+// and the comments are kept as they are.
+func (ci *CodeIndexer) applyBlame(ctx context.Context, path string, found []*storage.TodoRecord) bool {
+	lines := make([]int, len(found))
+	for i, todo := range found {
+		lines[i] = todo.Line
+	}
+	blame, err := todos.Blame(ctx, ci.projectRoot, path, lines, ci.todos)
+	if err != nil {
+		return false
+	}
+	todos.ApplyBlame(found, blame)
+	return true
+}
@@ -0,0 +1,108 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// WriteBatching controls how indexing writes reach SQLite
+type WriteBatching struct {
+	BatchSize      int    `json:"batch_size"`      // rows per transaction; 1 writes row by row
+	CheckpointMode string `json:"checkpoint_mode"` // WAL checkpoint after each run: passive, full, restart, truncate or none
+}
+
+// DefaultWriteBatching returns the batching used when none is configured
+func DefaultWriteBatching() WriteBatching {
+	return WriteBatching{
+		BatchSize:      storage.DefaultWriteBatchSize,
+		CheckpointMode: "passive",
+	}
+}
+
+// rowWriter receives file, function and embedding queue rows: a batch
+// writer during indexing runs, the database directly otherwise
+type rowWriter interface {
+	SaveFile(file *storage.CodeFile) error
+	SaveFunctionForFile(function *storage.CodeFunction, filePath string) error
+	EnqueueEmbedding(item *storage.QueuedEmbedding) error
+	ReplaceTodos(file string, todos []*storage.TodoRecord) error
+	ReplaceChurn(path string, records []*storage.ChurnRecord) error
+	ReplaceGlossaryTerms(file string, entries []*storage.GlossaryEntry) error
+	ReplaceImplementations(interfaces []*storage.InterfaceRecord) error
+	IndexKeywordChunk(chunk *storage.KeywordChunk, terms map[string]int) error
+	DeleteKeywordChunks(path string) error
+}
+
+// SetWriteBatching configures write batching for subsequent indexing runs
+func (ci *CodeIndexer) SetWriteBatching(batching WriteBatching) {
+	if batching.BatchSize <= 0 {
+		batching.BatchSize = storage.DefaultWriteBatchSize
+	}
+
+	ci.indexingMutex.Lock()
+	defer ci.indexingMutex.Unlock()
+
+	ci.writeBatching = batching
+}
+
+// rows returns where indexing rows should be written
+func (ci *CodeIndexer) rows() rowWriter {
+	if writer := ci.writer; writer != nil {
+		return writer
+	}
+	return ci.storage
+}
+
+// beginWrites starts batching rows for an indexing run, queueing chunks
+// for embedding when the queue is persisted, TODOs for blame and, when
+// enabled, spilling chunks while memory is over the limit
+func (ci *CodeIndexer) beginWrites() {
+	ci.queueing = ci.memoryLimits.PersistQueue && ci.storage != nil && ci.vectorDB != nil
+	ci.spilling = ci.memoryLimits.SpillToDisk
+	if ci.todos.Enabled && ci.todos.Blame {
+		ci.todoMu.Lock()
+		ci.todoBlame = make(map[string][]*storage.TodoRecord)
+		ci.todoMu.Unlock()
+	}
+	if ci.storage == nil || ci.writeBatching.BatchSize <= 1 {
+		return
+	}
+	ci.writer = ci.storage.NewBatchWriter(ci.writeBatching.BatchSize)
+}
+
+// finishWrites stores the chunks spilled to disk, records the
+// implementations of the Go the run changed, commits the last batch, blames
+// the TODOs the run found and checkpoints the WAL so it does not grow
+// across runs
+func (ci *CodeIndexer) finishWrites(ctx context.Context) {
+	ci.spilling = false
+	ci.storeSpilled(ctx)
+	if ci.goChanged.Swap(false) {
+		ci.refreshImplementations()
+	}
+	if ci.writer != nil {
+		if err := ci.writer.Close(); err != nil {
+			fmt.Printf("⚠️ Failed to commit final write batch: %v\n", err)
+		}
+		stats := ci.writer.Stats()
+		fmt.Printf("💾 Wrote %d rows in %d transactions\n", stats.Rows, stats.Commits)
+	}
+	ci.blameTodos(ctx)
+
+	mode := strings.ToLower(ci.writeBatching.CheckpointMode)
+	if ci.storage == nil || mode == "" || mode == "none" {
+		return
+	}
+	result, err := ci.storage.Checkpoint(mode)
+	if err != nil {
+		fmt.Printf("⚠️ WAL checkpoint failed: %v\n", err)
+		return
+	}
+	if result.Busy {
+		fmt.Printf("⚠️ WAL checkpoint (%s) incomplete: %d of %d pages, readers still active\n",
+			strings.ToLower(result.Mode), result.Checkpointed, result.LogPages)
+	}
+}
@@ -0,0 +1,97 @@
+// Package keyword turns code and queries into the terms of the local keyword
+// index, which answers searches when no vector database is configured.
+// Identifiers are indexed whole and split into their words, so a query for
+// "session manager" finds NewSessionManager and session_manager alike.
+package keyword
+
+import (
+	"strings"
+	"unicode"
+)
+
+// minTermLength drops one-letter terms, which match nearly every chunk
+const minTermLength = 2
+
+// stopWords are words too common in code or questions to rank by
+var stopWords = map[string]bool{
+	// English
+	"the": true, "an": true, "and": true, "or": true, "of": true, "to": true, "in": true,
+	"is": true, "are": true, "it": true, "on": true, "for": true, "with": true, "as": true,
+	"be": true, "by": true, "at": true, "this": true, "that": true, "from": true, "not": true,
+	// Questions
+	"how": true, "what": true, "where": true, "which": true, "who": true, "why": true,
+	"does": true, "do": true, "show": true, "find": true, "me": true, "all": true, "code": true,
+	// Keywords common to most languages
+	"func": true, "function": true, "return": true, "if": true, "else": true, "var": true,
+	"const": true, "let": true, "def": true, "self": true, "nil": true, "null": true,
+	"true": true, "false": true, "import": true, "package": true, "err": true,
+}
+
+// Terms counts the terms of a chunk of code or text
+func Terms(text string) map[string]int {
+	terms := make(map[string]int)
+	for _, term := range split(text) {
+		terms[term]++
+	}
+	return terms
+}
+
+// QueryTerms lists the distinct terms of a query, in order
+func QueryTerms(query string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, term := range split(query) {
+		if !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// split tokenizes text into lowercase terms: each identifier, then the words
+// of identifiers written in camelCase or snake_case
+func split(text string) []string {
+	var terms []string
+	add := func(term string) {
+		term = strings.ToLower(term)
+		if len(term) >= minTermLength && !stopWords[term] {
+			terms = append(terms, term)
+		}
+	}
+	identifiers := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	for _, identifier := range identifiers {
+		add(identifier)
+		parts := identifierWords(identifier)
+		if len(parts) > 1 {
+			for _, word := range parts {
+				add(word)
+			}
+		}
+	}
+	return terms
+}
+
+// identifierWords splits an identifier at underscores and case changes:
+// parseHTTPRequest becomes parse, HTTP, Request
+func identifierWords(identifier string) []string {
+	var words []string
+	for _, part := range strings.Split(identifier, "_") {
+		runes := []rune(part)
+		start := 0
+		for i := 1; i < len(runes); i++ {
+			lowerToUpper := unicode.IsLower(runes[i-1]) && unicode.IsUpper(runes[i])
+			acronymEnd := i+1 < len(runes) && unicode.IsUpper(runes[i-1]) && unicode.IsUpper(runes[i]) && unicode.IsLower(runes[i+1])
+			if lowerToUpper || acronymEnd {
+				words = append(words, string(runes[start:i]))
+				start = i
+			}
+		}
+		if start < len(runes) {
+			words = append(words, string(runes[start:]))
+		}
+	}
+	return words
+}
@@ -0,0 +1,180 @@
+// Package language detects the programming language a query is about and
+// provides per-language prompting strategies for the agents.
+package language
+
+import (
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Detection sources, from most to least reliable
+const (
+	SourceUser      = "user"      // set with a lang: token or by the caller
+	SourceFile      = "file"      // a referenced file's extension
+	SourceMention   = "mention"   // the language is named in the query
+	SourceSyntax    = "syntax"    // code in the query looks like the language
+	SourceRetrieval = "retrieval" // most of the code retrieval found is in the language
+	SourceDefault   = "default"   // nothing matched; project default used
+)
+
+// Detection is the result of language detection for a query
+type Detection struct {
+	Language   string  `json:"language"`
+	Confidence float64 `json:"confidence"`
+	Source     string  `json:"source"`
+}
+
+// IsExplicit reports whether the language came from the query rather than
+// the project default, i.e. whether it is safe to scope retrieval by it
+func (d Detection) IsExplicit() bool {
+	return d.Source != SourceDefault && d.Language != ""
+}
+
+// extensionLanguages maps file extensions to language names. The indexer
+// tags chunks with the same names, so detected languages match payloads.
+var extensionLanguages = map[string]string{
+	".go":    "go",
+	".mod":   "go",
+	".sum":   "go",
+	".py":    "python",
+	".js":    "javascript",
+	".jsx":   "javascript",
+	".mjs":   "javascript",
+	".ts":    "typescript",
+	".tsx":   "typescript",
+	".java":  "java",
+	".rs":    "rust",
+	".rb":    "ruby",
+	".c":     "c",
+	".h":     "c",
+	".cpp":   "cpp",
+	".cc":    "cpp",
+	".cxx":   "cpp",
+	".hpp":   "cpp",
+	".cs":    "csharp",
+	".php":   "php",
+	".kt":    "kotlin",
+	".swift": "swift",
+	".scala": "scala",
+	".r":     "r",
+	".sql":   "sql",
+	".sh":    "bash",
+	".bash":  "bash",
+	".yaml":  "yaml",
+	".yml":   "yaml",
+	".ipynb": "jupyter",
+	".json":  "json",
+	".xml":   "xml",
+	".md":    "markdown",
+	".tex":   "latex",
+	".html":  "html",
+	".css":   "css",
+}
+
+// mentionPatterns match a language being named in the query
+var mentionPatterns = map[string]*regexp.Regexp{
+	"go":         regexp.MustCompile(`\b(golang|in go|go code|go func|go struct|go package)\b`),
+	"python":     regexp.MustCompile(`\b(python|django|flask|pytest|pip)\b`),
+	"javascript": regexp.MustCompile(`\b(javascript|node\.?js|npm|react|express)\b`),
+	"typescript": regexp.MustCompile(`\b(typescript|tsx?)\b`),
+	"java":       regexp.MustCompile(`\b(java|spring boot|maven|gradle|junit)\b`),
+	"rust":       regexp.MustCompile(`\b(rust|cargo|crate)\b`),
+	"ruby":       regexp.MustCompile(`\b(ruby|rails|rspec)\b`),
+	"sql":        regexp.MustCompile(`\b(sql query|sqlite schema|postgres|mysql)\b`),
+	"bash":       regexp.MustCompile(`\b(bash|shell script)\b`),
+}
+
+// syntaxPatterns match code fragments characteristic of a language
+var syntaxPatterns = map[string]*regexp.Regexp{
+	"go":         regexp.MustCompile(`(:=|\bfunc\s*(\(\w+ \*?\w+\)\s*)?\w+\(|\bpackage \w+|\bif err != nil\b|\bchan \w+)`),
+	"python":     regexp.MustCompile(`(\bdef \w+\(.*\):|\bimport \w+$|\bfrom \w+ import\b|\bself\.\w+|__init__)`),
+	"javascript": regexp.MustCompile(`(\bconst \w+ = require\(|=>\s*\{|\bfunction \w+\(|console\.log\()`),
+	"typescript": regexp.MustCompile(`(\binterface \w+ \{|: (string|number|boolean)\b|\bexport type\b)`),
+	"java":       regexp.MustCompile(`(\bpublic (static )?(class|void)\b|System\.out\.println|@Override)`),
+	"rust":       regexp.MustCompile(`(\bfn \w+\(|\blet mut\b|\bimpl \w+|::new\(\)|\bpub fn\b)`),
+}
+
+// filePathRegex finds file-like tokens ("handlers/user.py") in a query
+var filePathRegex = regexp.MustCompile(`[\w./-]+\.[a-zA-Z]{1,5}\b`)
+
+// FromExtension returns the language for a file path or extension, or ""
+func FromExtension(path string) string {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == "" && strings.HasPrefix(path, ".") {
+		ext = strings.ToLower(path)
+	}
+	return extensionLanguages[ext]
+}
+
+// Detect works out which language a query is about. Referenced files win,
+// then explicit mentions, then code syntax; fallback is returned otherwise.
+func Detect(input string, files []string, fallback string) Detection {
+	// Referenced files: explicit targets plus file-like tokens in the text
+	candidates := append([]string{}, files...)
+	candidates = append(candidates, filePathRegex.FindAllString(input, -1)...)
+	if lang := majority(candidates); lang != "" {
+		return Detection{Language: lang, Confidence: 0.95, Source: SourceFile}
+	}
+
+	lower := strings.ToLower(input)
+	if lang := firstMatch(lower, mentionPatterns); lang != "" {
+		return Detection{Language: lang, Confidence: 0.85, Source: SourceMention}
+	}
+
+	if lang := firstMatch(input, syntaxPatterns); lang != "" {
+		return Detection{Language: lang, Confidence: 0.7, Source: SourceSyntax}
+	}
+
+	return Detection{Language: fallback, Confidence: 0.5, Source: SourceDefault}
+}
+
+// majority returns the most common known language among paths
+func majority(paths []string) string {
+	counts := make(map[string]int)
+	for _, p := range paths {
+		if lang := FromExtension(p); lang != "" {
+			counts[lang]++
+		}
+	}
+
+	best, bestCount := "", 0
+	for _, lang := range sortedKeys(counts) {
+		if counts[lang] > bestCount {
+			best, bestCount = lang, counts[lang]
+		}
+	}
+	return best
+}
+
+// firstMatch returns the first language (in name order, for determinism)
+// whose pattern matches text
+func firstMatch(text string, patterns map[string]*regexp.Regexp) string {
+	names := make([]string, 0, len(patterns))
+	for name := range patterns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// TypeScript mentions also match JavaScript-ish words; prefer the more specific
+	if p, ok := patterns["typescript"]; ok && p.MatchString(text) {
+		return "typescript"
+	}
+	for _, name := range names {
+		if patterns[name].MatchString(text) {
+			return name
+		}
+	}
+	return ""
+}
+
+// sortedKeys returns map keys in sorted order
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,133 @@
+package language
+
+import (
+	"sort"
+	"strings"
+)
+
+// overridePrefixes introduce a language override token, as in lang:python
+var overridePrefixes = []string{"lang:", "language:"}
+
+// aliases maps common short and alternative names to language names
+var aliases = map[string]string{
+	"golang":  "go",
+	"py":      "python",
+	"python3": "python",
+	"js":      "javascript",
+	"node":    "javascript",
+	"nodejs":  "javascript",
+	"ts":      "typescript",
+	"rs":      "rust",
+	"rb":      "ruby",
+	"c++":     "cpp",
+	"cxx":     "cpp",
+	"c#":      "csharp",
+	"cs":      "csharp",
+	"sh":      "bash",
+	"shell":   "bash",
+	"kt":      "kotlin",
+	"yml":     "yaml",
+}
+
+// nonCodeLanguages are indexed but say nothing about the language an
+// answer should be written in
+var nonCodeLanguages = map[string]bool{
+	"text": true, "markdown": true, "json": true, "xml": true, "yaml": true, "latex": true,
+}
+
+// Normalize returns the language name for name or one of its aliases, or
+// "" when the language is unknown
+func Normalize(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if alias, ok := aliases[name]; ok {
+		return alias
+	}
+	for _, lang := range extensionLanguages {
+		if lang == name {
+			return lang
+		}
+	}
+	return ""
+}
+
+// ParseOverride removes a lang:<name> token from a query and returns the
+// language it names. Tokens naming unknown languages are left in place.
+func ParseOverride(input string) (string, string, bool) {
+	fields := strings.Fields(input)
+	kept := fields[:0]
+	lang := ""
+	for _, field := range fields {
+		if lang == "" {
+			if name := overrideName(field); name != "" {
+				lang = name
+				continue
+			}
+		}
+		kept = append(kept, field)
+	}
+	if lang == "" {
+		return input, "", false
+	}
+	return strings.Join(kept, " "), lang, true
+}
+
+// overrideName returns the language an override token names, or ""
+func overrideName(field string) string {
+	lower := strings.ToLower(field)
+	for _, prefix := range overridePrefixes {
+		if strings.HasPrefix(lower, prefix) {
+			return Normalize(strings.TrimRight(lower[len(prefix):], ",.;:!?"))
+		}
+	}
+	return ""
+}
+
+// Primary returns the code language with the most files in counts, or ""
+func Primary(counts map[string]int) string {
+	best, bestCount := "", 0
+	for _, lang := range sortedKeys(counts) {
+		if nonCodeLanguages[strings.ToLower(lang)] || Normalize(lang) == "" {
+			continue
+		}
+		if counts[lang] > bestCount {
+			best, bestCount = Normalize(lang), counts[lang]
+		}
+	}
+	return best
+}
+
+// Inference is the language mix of the code retrieval found for a query
+type Inference struct {
+	Language  string   `json:"language"`  // the most common code language
+	Share     float64  `json:"share"`     // fraction of code chunks in Language
+	Languages []string `json:"languages"` // every code language found, most common first
+}
+
+// Infer works out which language retrieved chunks are in. Documentation
+// and configuration chunks are ignored; an empty Language means no code
+// was found.
+func Infer(chunkLanguages []string) Inference {
+	counts := make(map[string]int)
+	total := 0
+	for _, lang := range chunkLanguages {
+		lang = strings.ToLower(lang)
+		if lang == "" || nonCodeLanguages[lang] {
+			continue
+		}
+		counts[lang]++
+		total++
+	}
+	if total == 0 {
+		return Inference{}
+	}
+
+	languages := sortedKeys(counts)
+	sort.SliceStable(languages, func(i, j int) bool {
+		return counts[languages[i]] > counts[languages[j]]
+	})
+	return Inference{
+		Language:  languages[0],
+		Share:     float64(counts[languages[0]]) / float64(total),
+		Languages: languages,
+	}
+}
@@ -0,0 +1,106 @@
+package language
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Template holds the prompting strategy for one language
+type Template struct {
+	Language    string   `json:"language"`
+	DisplayName string   `json:"display_name"`
+	FenceTag    string   `json:"fence_tag"` // markdown code fence language tag
+	Role        string   `json:"role"`
+	Guidelines  []string `json:"guidelines"`
+}
+
+// templates are the per-language prompting strategies used by the coding agent
+var templates = map[string]Template{
+	"go": {
+		Language: "go", DisplayName: "Go", FenceTag: "go",
+		Role: "You are an expert Go developer working on a specific codebase.",
+		Guidelines: []string{
+			"Return errors instead of panicking and wrap them with fmt.Errorf(\"...: %w\", err)",
+			"Accept context.Context as the first parameter for I/O-bound functions",
+			"Keep exported identifiers documented with comments starting with their name",
+			"Prefer small interfaces defined by the consumer",
+		},
+	},
+	"python": {
+		Language: "python", DisplayName: "Python", FenceTag: "python",
+		Role: "You are an expert Python developer working on a specific codebase.",
+		Guidelines: []string{
+			"Follow PEP 8 and add type hints to public functions",
+			"Raise specific exception types rather than bare Exception",
+			"Write docstrings for modules, classes and public functions",
+		},
+	},
+	"javascript": {
+		Language: "javascript", DisplayName: "JavaScript", FenceTag: "javascript",
+		Role: "You are an expert JavaScript developer working on a specific codebase.",
+		Guidelines: []string{
+			"Use const/let, never var, and prefer async/await over raw promises",
+			"Handle rejected promises explicitly",
+			"Match the module system (ESM or CommonJS) already used in the project",
+		},
+	},
+	"typescript": {
+		Language: "typescript", DisplayName: "TypeScript", FenceTag: "typescript",
+		Role: "You are an expert TypeScript developer working on a specific codebase.",
+		Guidelines: []string{
+			"Keep strict typing; avoid any unless the surrounding code already uses it",
+			"Prefer interfaces for object shapes and union types for variants",
+			"Use async/await and handle errors explicitly",
+		},
+	},
+	"java": {
+		Language: "java", DisplayName: "Java", FenceTag: "java",
+		Role: "You are an expert Java developer working on a specific codebase.",
+		Guidelines: []string{
+			"Follow the project's package structure and naming conventions",
+			"Use checked exceptions only where callers can recover",
+			"Add Javadoc to public classes and methods",
+		},
+	},
+	"rust": {
+		Language: "rust", DisplayName: "Rust", FenceTag: "rust",
+		Role: "You are an expert Rust developer working on a specific codebase.",
+		Guidelines: []string{
+			"Return Result and propagate errors with ? instead of unwrap()",
+			"Prefer borrowing over cloning",
+			"Document public items with /// comments",
+		},
+	},
+}
+
+// TemplateFor returns the prompting strategy for lang. Unknown languages
+// get a generic template named after the language; empty falls back to Go.
+func TemplateFor(lang string) Template {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if lang == "" {
+		lang = "go"
+	}
+	if t, ok := templates[lang]; ok {
+		return t
+	}
+
+	name := strings.ToUpper(lang[:1]) + lang[1:]
+	return Template{
+		Language:    lang,
+		DisplayName: name,
+		FenceTag:    lang,
+		Role:        fmt.Sprintf("You are an expert %s developer working on a specific codebase.", name),
+		Guidelines:  []string{"Follow the idioms and conventions already used in the project"},
+	}
+}
+
+// SupportedLanguages returns the languages with a dedicated template
+func SupportedLanguages() []string {
+	langs := make([]string, 0, len(templates))
+	for lang := range templates {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
@@ -0,0 +1,33 @@
+package llm
+
+import "context"
+
+type analyzerDiagnosticsKey struct{}
+
+// analyzerDiagnosticsHeader introduces analyzer findings in the system prompt
+const analyzerDiagnosticsHeader = "Static analyzers (gopls, golangci-lint) reported these diagnostics for the code in question. When asked why code is flagged, or while debugging it, explain from these findings and cite them as path:line rather than guessing what a tool would say:"
+
+// WithAnalyzerDiagnostics returns a context whose Generate and Stream calls
+// carry the analyzer diagnostics relevant to the request, appended to its
+// system prompt
+func WithAnalyzerDiagnostics(ctx context.Context, text string) context.Context {
+	if text == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, analyzerDiagnosticsKey{}, text)
+}
+
+// withAnalyzerDiagnostics adds the context's analyzer diagnostics to a copy
+// of request
+func withAnalyzerDiagnostics(ctx context.Context, request *GenerationRequest) *GenerationRequest {
+	text, _ := ctx.Value(analyzerDiagnosticsKey{}).(string)
+	if text == "" || request == nil {
+		return request
+	}
+	flagged := *request
+	if flagged.SystemPrompt != "" {
+		flagged.SystemPrompt += "\n\n"
+	}
+	flagged.SystemPrompt += analyzerDiagnosticsHeader + "\n" + text
+	return &flagged
+}
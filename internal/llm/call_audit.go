@@ -0,0 +1,134 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/calllog"
+)
+
+// defaultOpenAIEndpoint is where the OpenAI provider sends requests without
+// a configured base_url
+const defaultOpenAIEndpoint = "https://api.openai.com/v1"
+
+// auditCall records a provider call in the external call log. Replayed
+// cassette responses never left the machine and are not recorded.
+func (m *Manager) auditCall(ctx context.Context, providerName string, request *GenerationRequest, response *GenerationResponse, started time.Time, callErr error) {
+	m.mu.RLock()
+	provider, model := m.providers[providerName], m.models[providerName]
+	m.mu.RUnlock()
+
+	endpoint, external := providerEndpoint(provider)
+	if !external {
+		return
+	}
+	call := calllog.Call{
+		Time:       started,
+		Service:    calllog.ServiceLLM,
+		Provider:   providerName,
+		Endpoint:   endpoint,
+		Model:      request.Model,
+		Purpose:    callPurpose(ctx, request),
+		DurationMS: time.Since(started).Milliseconds(),
+	}
+	if call.Model == "" {
+		call.Model = model
+	}
+	if response != nil {
+		if response.Model != "" {
+			call.Model = response.Model
+		}
+		call.InputTokens = response.TokenUsage.InputTokens
+		call.OutputTokens = response.TokenUsage.OutputTokens
+	}
+	if callErr != nil {
+		call.Error = callErr.Error()
+	}
+	calllog.Record(call, promptText(request))
+}
+
+// auditStream forwards a stream and records the call once it ends, with
+// the output tokens its last chunk counted
+func (m *Manager) auditStream(ctx context.Context, providerName string, request *GenerationRequest, started time.Time, chunks <-chan *StreamChunk) <-chan *StreamChunk {
+	forwarded := make(chan *StreamChunk)
+	go func() {
+		defer close(forwarded)
+		response := &GenerationResponse{Model: request.Model}
+		response.TokenUsage.InputTokens = estimateRequestTokens(request)
+		var streamErr error
+		for chunk := range chunks {
+			if chunk.Error != nil && streamErr == nil {
+				streamErr = chunk.Error
+			}
+			if chunk.TokenCount > 0 {
+				response.TokenUsage.OutputTokens = chunk.TokenCount
+			}
+			forwarded <- chunk
+		}
+		m.auditCall(ctx, providerName, request, response, started, streamErr)
+	}()
+	return forwarded
+}
+
+// providerEndpoint returns the base URL a provider sends requests to, and
+// whether its calls leave the machine at all
+func providerEndpoint(provider Provider) (string, bool) {
+	switch p := provider.(type) {
+	case *OpenAIProvider:
+		if p.config.BaseURL != "" {
+			return strings.TrimRight(p.config.BaseURL, "/"), true
+		}
+		return defaultOpenAIEndpoint, true
+	case *GeminiProvider:
+		return p.config.BaseURL, true
+	case *cassetteProvider:
+		if p.cassette.Mode() == CassetteReplay {
+			return "", false
+		}
+		return providerEndpoint(p.inner)
+	case nil:
+		return "", false
+	default:
+		return "", true
+	}
+}
+
+// countsRemotely reports whether counting a request's tokens with provider
+// is a call to its API
+func countsRemotely(provider Provider) bool {
+	if p, ok := provider.(*cassetteProvider); ok {
+		_, counts := p.inner.(TokenCounter)
+		return counts && p.cassette.Mode() == CassetteRecord
+	}
+	_, counts := provider.(TokenCounter)
+	return counts
+}
+
+// callPurpose says what a call was for: the purpose or task the request
+// names, else the one set on ctx
+func callPurpose(ctx context.Context, request *GenerationRequest) string {
+	for _, key := range []string{"purpose", "task"} {
+		if purpose := request.Metadata[key]; purpose != "" {
+			return purpose
+		}
+	}
+	return calllog.PurposeFrom(ctx, "generation")
+}
+
+// promptText is everything sent as the prompt, for hashing
+func promptText(request *GenerationRequest) string {
+	var b strings.Builder
+	b.WriteString(request.SystemPrompt)
+	for _, message := range request.Messages {
+		b.WriteString("\n")
+		b.WriteString(message.Role)
+		b.WriteString(": ")
+		b.WriteString(message.Content)
+	}
+	if request.Prompt != "" {
+		b.WriteString("\n")
+		b.WriteString(request.Prompt)
+	}
+	return b.String()
+}
@@ -0,0 +1,389 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CassetteMode selects whether provider calls are recorded or replayed
+type CassetteMode string
+
+const (
+	// CassetteOff talks to the providers directly
+	CassetteOff CassetteMode = ""
+	// CassetteRecord calls the providers and writes every exchange to the cassette
+	CassetteRecord CassetteMode = "record"
+	// CassetteReplay answers from the cassette without calling any provider,
+	// so no API keys are needed
+	CassetteReplay CassetteMode = "replay"
+)
+
+// ErrCassetteMiss is returned in replay mode for a request the cassette
+// has no recording of
+var ErrCassetteMiss = errors.New("no recorded response")
+
+// CassetteConfig points the manager at a cassette file
+type CassetteConfig struct {
+	Path string       `json:"path" yaml:"path"`
+	Mode CassetteMode `json:"mode" yaml:"mode"`
+}
+
+// Enabled reports whether calls go through a cassette
+func (c CassetteConfig) Enabled() bool {
+	return c.Mode != CassetteOff && c.Path != ""
+}
+
+// CassetteConfigFromEnv reads USEQ_LLM_CASSETTE (the cassette file) and
+// USEQ_LLM_CASSETTE_MODE (record or replay, default replay)
+func CassetteConfigFromEnv() CassetteConfig {
+	path := os.Getenv("USEQ_LLM_CASSETTE")
+	if path == "" {
+		return CassetteConfig{}
+	}
+	mode := CassetteMode(strings.ToLower(os.Getenv("USEQ_LLM_CASSETTE_MODE")))
+	if mode == CassetteOff {
+		mode = CassetteReplay
+	}
+	return CassetteConfig{Path: path, Mode: mode}
+}
+
+// Interaction is one recorded provider call. The request is stored with
+// secrets redacted and is only kept for reading; replay matches on Key.
+type Interaction struct {
+	Key        string              `json:"key"`
+	Provider   string              `json:"provider"`
+	Request    *GenerationRequest  `json:"request"`
+	Response   *GenerationResponse `json:"response,omitempty"`
+	Error      string              `json:"error,omitempty"`
+	RecordedAt time.Time           `json:"recorded_at"`
+}
+
+// cassetteFile is the on-disk form of a cassette
+type cassetteFile struct {
+	Version      int            `json:"version"`
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Cassette holds recorded provider calls, VCR-style. Identical requests
+// replay their recordings in order, and the last one once they run out.
+type Cassette struct {
+	path         string
+	mode         CassetteMode
+	interactions []*Interaction
+	byKey        map[string][]*Interaction
+	played       map[string]int
+	mu           sync.Mutex
+}
+
+// OpenCassette loads the cassette for config. A missing file is an empty
+// cassette when recording and an error when replaying.
+func OpenCassette(config CassetteConfig) (*Cassette, error) {
+	switch config.Mode {
+	case CassetteRecord, CassetteReplay:
+	default:
+		return nil, fmt.Errorf("unknown cassette mode %q (use record or replay)", config.Mode)
+	}
+
+	cassette := &Cassette{
+		path:   config.Path,
+		mode:   config.Mode,
+		byKey:  make(map[string][]*Interaction),
+		played: make(map[string]int),
+	}
+	data, err := os.ReadFile(config.Path)
+	if errors.Is(err, os.ErrNotExist) && config.Mode == CassetteRecord {
+		return cassette, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+
+	var file cassetteFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to decode cassette %s: %w", config.Path, err)
+	}
+	// Re-recording replaces the old exchanges rather than appending to them
+	if config.Mode == CassetteReplay {
+		for _, interaction := range file.Interactions {
+			cassette.add(interaction)
+		}
+	}
+	return cassette, nil
+}
+
+// Mode returns whether the cassette records or replays
+func (c *Cassette) Mode() CassetteMode {
+	return c.mode
+}
+
+// Path returns the cassette file
+func (c *Cassette) Path() string {
+	return c.path
+}
+
+// Len returns the number of recorded interactions
+func (c *Cassette) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.interactions)
+}
+
+// Replay returns the recorded response for a request
+func (c *Cassette) Replay(request *GenerationRequest) (*GenerationResponse, error) {
+	key := cassetteKey(request)
+
+	c.mu.Lock()
+	recorded := c.byKey[key]
+	if len(recorded) == 0 {
+		c.mu.Unlock()
+		return nil, fmt.Errorf("%w for request %s (%q); re-record with USEQ_LLM_CASSETTE_MODE=record",
+			ErrCassetteMiss, key[:12], truncatePrompt(lastUserContent(request), 60))
+	}
+	index := c.played[key]
+	if index < len(recorded)-1 {
+		c.played[key] = index + 1
+	}
+	interaction := recorded[index]
+	c.mu.Unlock()
+
+	if interaction.Error != "" {
+		return nil, errors.New(interaction.Error)
+	}
+	response := *interaction.Response
+	return &response, nil
+}
+
+// Record stores a provider call and rewrites the cassette file
+func (c *Cassette) Record(providerName string, request *GenerationRequest, response *GenerationResponse, callErr error) error {
+	redactions := 0
+	interaction := &Interaction{
+		Key:        cassetteKey(request),
+		Provider:   providerName,
+		Request:    redactRequest(request, &redactions),
+		RecordedAt: time.Now(),
+	}
+	if callErr != nil {
+		interaction.Error = redactSecrets(callErr.Error(), &redactions)
+	} else if response != nil {
+		recorded := *response
+		recorded.Content = redactSecrets(recorded.Content, &redactions)
+		interaction.Response = &recorded
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.add(interaction)
+	return c.save()
+}
+
+// add indexes an interaction; callers hold mu or own the cassette
+func (c *Cassette) add(interaction *Interaction) {
+	c.interactions = append(c.interactions, interaction)
+	c.byKey[interaction.Key] = append(c.byKey[interaction.Key], interaction)
+}
+
+// save writes the cassette file; callers hold mu
+func (c *Cassette) save() error {
+	data, err := json.MarshalIndent(cassetteFile{Version: 1, Interactions: c.interactions}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+	if dir := filepath.Dir(c.path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create cassette directory: %w", err)
+		}
+	}
+	if err := os.WriteFile(c.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// cassetteKey identifies a request by what the model sees, ignoring the
+// model and provider so a recording replays whatever routing picks.
+// Secrets are redacted first, so recordings made with one key match
+// requests made with another.
+func cassetteKey(request *GenerationRequest) string {
+	redactions := 0
+	redacted := redactRequest(request, &redactions)
+	key := struct {
+		SystemPrompt   string          `json:"system_prompt"`
+		Prompt         string          `json:"prompt"`
+		Messages       []Message       `json:"messages"`
+		MaxTokens      int             `json:"max_tokens"`
+		Temperature    float64         `json:"temperature"`
+		Stop           []string        `json:"stop,omitempty"`
+		ResponseSchema *ResponseSchema `json:"response_schema,omitempty"`
+		Tools          []Tool          `json:"tools,omitempty"`
+		ToolChoice     string          `json:"tool_choice,omitempty"`
+	}{
+		SystemPrompt:   redacted.SystemPrompt,
+		Prompt:         redacted.Prompt,
+		Messages:       redacted.Messages,
+		MaxTokens:      redacted.MaxTokens,
+		Temperature:    redacted.Temperature,
+		Stop:           redacted.Stop,
+		ResponseSchema: redacted.ResponseSchema,
+		Tools:          redacted.Tools,
+		ToolChoice:     redacted.ToolChoice,
+	}
+	data, _ := json.Marshal(key)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// redactRequest copies a request with credentials masked in every text
+// the model sees. MCP context is dropped; its data is already in the prompt.
+func redactRequest(request *GenerationRequest, count *int) *GenerationRequest {
+	redacted := *request
+	redacted.MCPContext = nil
+	redacted.SystemPrompt = redactSecrets(request.SystemPrompt, count)
+	redacted.Prompt = redactSecrets(request.Prompt, count)
+	redacted.Messages = make([]Message, len(request.Messages))
+	for i, msg := range request.Messages {
+		msg.Content = redactSecrets(msg.Content, count)
+		redacted.Messages[i] = msg
+	}
+	return &redacted
+}
+
+// lastUserContent returns the text a replay miss is reported by
+func lastUserContent(request *GenerationRequest) string {
+	for i := len(request.Messages) - 1; i >= 0; i-- {
+		if request.Messages[i].Role == "user" {
+			return request.Messages[i].Content
+		}
+	}
+	return request.Prompt
+}
+
+func truncatePrompt(text string, limit int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if len(text) <= limit {
+		return text
+	}
+	return text[:limit] + "..."
+}
+
+// cassetteProvider puts a cassette in front of a provider. When replaying
+// the inner provider may be nil.
+type cassetteProvider struct {
+	name     string
+	inner    Provider
+	cassette *Cassette
+}
+
+// Generate records or replays a completion. A call that succeeds but
+// cannot be recorded fails, so a recording run never leaves a cassette
+// short of an exchange unnoticed.
+func (cp *cassetteProvider) Generate(ctx context.Context, request *GenerationRequest) (*GenerationResponse, error) {
+	if cp.cassette.Mode() == CassetteReplay {
+		return cp.cassette.Replay(request)
+	}
+
+	response, err := cp.inner.Generate(ctx, request)
+	if recordErr := cp.cassette.Record(cp.name, request, response, err); recordErr != nil && err == nil {
+		return nil, fmt.Errorf("cassette recording failed: %w", recordErr)
+	}
+	return response, err
+}
+
+// Stream records a streamed completion once it finishes, with its token
+// usage, or replays a recording as a single chunk. A recording failure is
+// reported on the last chunk.
+func (cp *cassetteProvider) Stream(ctx context.Context, request *GenerationRequest) (<-chan *StreamChunk, error) {
+	if cp.cassette.Mode() == CassetteReplay {
+		response, err := cp.cassette.Replay(request)
+		if err != nil {
+			return nil, err
+		}
+		chunks := make(chan *StreamChunk, 1)
+		chunks <- &StreamChunk{
+			Content:      response.Content,
+			Delta:        response.Content,
+			FinishReason: response.FinishReason,
+			TokenCount:   response.TokenUsage.OutputTokens,
+			Done:         true,
+			Timestamp:    time.Now(),
+		}
+		close(chunks)
+		return chunks, nil
+	}
+
+	inner, err := cp.inner.Stream(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	chunks := make(chan *StreamChunk)
+	go func() {
+		defer close(chunks)
+		for chunk := range inner {
+			if chunk.Done && chunk.Error == nil {
+				response := &GenerationResponse{
+					Content:      chunk.Content,
+					FinishReason: chunk.FinishReason,
+					Provider:     cp.name,
+					Model:        request.Model,
+				}
+				response.TokenUsage.InputTokens = estimateRequestTokens(request)
+				response.TokenUsage.OutputTokens = chunk.TokenCount
+				response.TokenUsage.TotalTokens = response.TokenUsage.InputTokens + chunk.TokenCount
+				response.TokenUsage.Provider = cp.name
+				if recordErr := cp.cassette.Record(cp.name, request, response, nil); recordErr != nil {
+					failed := *chunk
+					failed.Error = fmt.Errorf("cassette recording failed: %w", recordErr)
+					chunk = &failed
+				}
+			}
+			chunks <- chunk
+		}
+	}()
+	return chunks, nil
+}
+
+// CountTokens asks the inner provider when it can count exactly; replays
+// never call out, so the manager estimates instead
+func (cp *cassetteProvider) CountTokens(ctx context.Context, request *GenerationRequest) (int, error) {
+	if counter, ok := cp.inner.(TokenCounter); ok && cp.cassette.Mode() == CassetteRecord {
+		return counter.CountTokens(ctx, request)
+	}
+	return 0, fmt.Errorf("provider %s cannot count tokens", cp.name)
+}
+
+// GetInfo describes the inner provider, or the cassette when replaying
+func (cp *cassetteProvider) GetInfo() ProviderInfo {
+	if cp.inner != nil {
+		return cp.inner.GetInfo()
+	}
+	return ProviderInfo{
+		Name:         cp.name,
+		Version:      "replay",
+		Capabilities: []string{"replay"},
+		Status:       ProviderStatus{Available: true, LastChecked: time.Now()},
+	}
+}
+
+// IsHealthy is always true when replaying
+func (cp *cassetteProvider) IsHealthy(ctx context.Context) bool {
+	if cp.cassette.Mode() == CassetteReplay {
+		return true
+	}
+	return cp.inner.IsHealthy(ctx)
+}
+
+// GetPricing returns the inner provider's pricing; replays are free
+func (cp *cassetteProvider) GetPricing() ProviderPricing {
+	if cp.inner != nil {
+		return cp.inner.GetPricing()
+	}
+	return ProviderPricing{Currency: "USD", Model: "replay"}
+}
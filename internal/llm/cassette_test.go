@@ -0,0 +1,184 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// fakeProvider answers every request with the same content, streaming it
+// in two chunks
+type fakeProvider struct {
+	content string
+	calls   int
+}
+
+func (p *fakeProvider) Generate(ctx context.Context, request *GenerationRequest) (*GenerationResponse, error) {
+	p.calls++
+	return &GenerationResponse{
+		Content:      p.content,
+		FinishReason: "stop",
+		TokenUsage:   models.TokenUsage{InputTokens: 12, OutputTokens: 3, TotalTokens: 15},
+		Provider:     "fake",
+	}, nil
+}
+
+func (p *fakeProvider) Stream(ctx context.Context, request *GenerationRequest) (<-chan *StreamChunk, error) {
+	p.calls++
+	half := len(p.content) / 2
+	chunks := make(chan *StreamChunk, 2)
+	chunks <- &StreamChunk{Content: p.content[:half], Delta: p.content[:half], TokenCount: 1, Timestamp: time.Now()}
+	chunks <- &StreamChunk{Content: p.content, Delta: p.content[half:], FinishReason: "stop", TokenCount: 3, Done: true, Timestamp: time.Now()}
+	close(chunks)
+	return chunks, nil
+}
+
+func (p *fakeProvider) GetInfo() ProviderInfo              { return ProviderInfo{Name: "fake"} }
+func (p *fakeProvider) IsHealthy(ctx context.Context) bool { return true }
+func (p *fakeProvider) GetPricing() ProviderPricing        { return ProviderPricing{Currency: "USD"} }
+
+func cassetteRequest(question string) *GenerationRequest {
+	return &GenerationRequest{
+		SystemPrompt: "You answer questions about Go code.",
+		Messages:     []Message{{Role: "user", Content: question}},
+		MaxTokens:    256,
+	}
+}
+
+// drain reads a stream to its last chunk
+func drain(t *testing.T, chunks <-chan *StreamChunk) *StreamChunk {
+	t.Helper()
+	var last *StreamChunk
+	for chunk := range chunks {
+		last = chunk
+	}
+	if last == nil {
+		t.Fatal("stream sent no chunks")
+	}
+	return last
+}
+
+// TestCassetteRecordAndReplay records a completion and a stream, then
+// replays both without the provider
+func TestCassetteRecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	ctx := context.Background()
+
+	recorder, err := OpenCassette(CassetteConfig{Path: path, Mode: CassetteRecord})
+	if err != nil {
+		t.Fatal(err)
+	}
+	inner := &fakeProvider{content: "Use errors.Is."}
+	recording := &cassetteProvider{name: "openai", inner: inner, cassette: recorder}
+	if _, err := recording.Generate(ctx, cassetteRequest("How do I compare errors?")); err != nil {
+		t.Fatal(err)
+	}
+	chunks, err := recording.Stream(ctx, cassetteRequest("How do I wrap errors?"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := drain(t, chunks); last.Error != nil {
+		t.Fatalf("recording the stream failed: %v", last.Error)
+	}
+
+	player, err := OpenCassette(CassetteConfig{Path: path, Mode: CassetteReplay})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if player.Len() != 2 {
+		t.Fatalf("replaying %d interactions, want 2", player.Len())
+	}
+	replaying := &cassetteProvider{name: "openai", cassette: player}
+
+	response, err := replaying.Generate(ctx, cassetteRequest("How do I compare errors?"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Content != "Use errors.Is." || response.TokenUsage.TotalTokens != 15 {
+		t.Errorf("replayed %q with %d tokens, want the recording", response.Content, response.TokenUsage.TotalTokens)
+	}
+
+	streamed, err := replaying.Generate(ctx, cassetteRequest("How do I wrap errors?"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	usage := streamed.TokenUsage
+	if usage.OutputTokens != 3 || usage.InputTokens == 0 || usage.TotalTokens != usage.InputTokens+usage.OutputTokens {
+		t.Errorf("streamed recording has token usage %+v, want input and 3 output tokens", usage)
+	}
+
+	chunks, err = replaying.Stream(ctx, cassetteRequest("How do I wrap errors?"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := drain(t, chunks); last.Content != "Use errors.Is." || last.TokenCount != 3 || !last.Done {
+		t.Errorf("replayed stream ended with %+v", last)
+	}
+	if inner.calls != 2 {
+		t.Errorf("provider called %d times, want 2 while recording only", inner.calls)
+	}
+
+	if _, err := replaying.Generate(ctx, cassetteRequest("Something never recorded")); !errors.Is(err, ErrCassetteMiss) {
+		t.Errorf("unrecorded request returned %v, want ErrCassetteMiss", err)
+	}
+}
+
+// TestCassetteRecordingFailure fails a recorded call whose exchange
+// cannot be written
+func TestCassetteRecordingFailure(t *testing.T) {
+	dir := t.TempDir()
+	recorder, err := OpenCassette(CassetteConfig{Path: filepath.Join(dir, "cassette.json"), Mode: CassetteRecord})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A regular file where the cassette's directory should be
+	blocker := filepath.Join(dir, "blocker")
+	if err := os.WriteFile(blocker, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	recorder.path = filepath.Join(blocker, "cassette.json")
+	recording := &cassetteProvider{name: "openai", inner: &fakeProvider{content: "Use errors.Is."}, cassette: recorder}
+
+	ctx := context.Background()
+	if _, err := recording.Generate(ctx, cassetteRequest("How do I compare errors?")); err == nil {
+		t.Error("Generate succeeded although the cassette could not be written")
+	}
+	chunks, err := recording.Stream(ctx, cassetteRequest("How do I wrap errors?"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := drain(t, chunks); last.Error == nil {
+		t.Error("stream ended without reporting that the cassette could not be written")
+	}
+}
+
+// TestSampleCassette replays the checked-in sample cassette
+func TestSampleCassette(t *testing.T) {
+	player, err := OpenCassette(CassetteConfig{Path: filepath.Join("testdata", "sample_cassette.json"), Mode: CassetteReplay})
+	if err != nil {
+		t.Fatal(err)
+	}
+	replaying := &cassetteProvider{name: "openai", cassette: player}
+
+	for _, tc := range []struct {
+		question string
+		want     string
+	}{
+		{"How do I compare errors?", "Use errors.Is to compare an error with a sentinel, and errors.As to find one of a given type."},
+		{"How do I wrap errors?", "Wrap it with fmt.Errorf and the %w verb, so callers can still unwrap it."},
+	} {
+		response, err := replaying.Generate(context.Background(), cassetteRequest(tc.question))
+		if err != nil {
+			t.Errorf("%s: %v", tc.question, err)
+			continue
+		}
+		if response.Content != tc.want {
+			t.Errorf("%s: replayed %q, want %q", tc.question, response.Content, tc.want)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package llm
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// API types for the OpenAI provider. They differ in URL layout and in how
+// requests are signed.
+const (
+	// APITypeOpenAI talks to api.openai.com with a bearer token
+	APITypeOpenAI = "openai"
+	// APITypeAzure talks to an Azure OpenAI resource, addressing models by
+	// deployment name and signing with the api-key header
+	APITypeAzure = "azure"
+	// APITypeAzureAD is Azure OpenAI signed with an Entra ID bearer token
+	APITypeAzureAD = "azure_ad"
+	// APITypeCompatible talks to any OpenAI-compatible server (vLLM, Ollama,
+	// LiteLLM, ...) at BaseURL; the API key is optional
+	APITypeCompatible = "compatible"
+)
+
+// defaultAzureAPIVersion is used when an Azure endpoint has no api_version
+const defaultAzureAPIVersion = "2024-06-01"
+
+// NormalizeAPIType validates a configured api_type; empty means openai
+func NormalizeAPIType(apiType string) (string, error) {
+	switch normalized := strings.ToLower(strings.TrimSpace(apiType)); normalized {
+	case "":
+		return APITypeOpenAI, nil
+	case APITypeOpenAI, APITypeAzure, APITypeAzureAD, APITypeCompatible:
+		return normalized, nil
+	default:
+		return "", fmt.Errorf("unknown api_type %q (want openai, azure, azure_ad or compatible)", apiType)
+	}
+}
+
+// IsAzureAPIType reports whether apiType addresses an Azure OpenAI resource
+func IsAzureAPIType(apiType string) bool {
+	return apiType == APITypeAzure || apiType == APITypeAzureAD
+}
+
+// resolveAPIKey returns the configured key or the environment variable the
+// endpoint's API type conventionally uses
+func resolveAPIKey(config ProviderConfig) string {
+	if config.APIKey != "" {
+		return config.APIKey
+	}
+	if IsAzureAPIType(config.APIType) {
+		if key := os.Getenv("AZURE_OPENAI_API_KEY"); key != "" {
+			return key
+		}
+	}
+	return os.Getenv("OPENAI_API_KEY")
+}
+
+// openAIClientConfig builds the go-openai client configuration for the
+// provider's endpoint. Azure requests are routed to the configured deployment
+// rather than the model name and carry an api-version query parameter.
+func openAIClientConfig(apiKey string, config ProviderConfig) (openai.ClientConfig, error) {
+	switch config.APIType {
+	case APITypeAzure, APITypeAzureAD:
+		if config.BaseURL == "" {
+			return openai.ClientConfig{}, fmt.Errorf("%s endpoint requires base_url (https://<resource>.openai.azure.com)", config.APIType)
+		}
+		clientConfig := openai.DefaultAzureConfig(apiKey, strings.TrimRight(config.BaseURL, "/"))
+		if config.APIType == APITypeAzureAD {
+			clientConfig.APIType = openai.APITypeAzureAD
+		}
+		clientConfig.APIVersion = config.APIVersion
+		if clientConfig.APIVersion == "" {
+			clientConfig.APIVersion = defaultAzureAPIVersion
+		}
+		if deployment := config.Deployment; deployment != "" {
+			clientConfig.AzureModelMapperFunc = func(string) string { return deployment }
+		}
+		return clientConfig, nil
+
+	case APITypeCompatible:
+		if config.BaseURL == "" {
+			return openai.ClientConfig{}, fmt.Errorf("compatible endpoint requires base_url")
+		}
+		clientConfig := openai.DefaultConfig(apiKey)
+		clientConfig.BaseURL = strings.TrimRight(config.BaseURL, "/")
+		return clientConfig, nil
+
+	default:
+		clientConfig := openai.DefaultConfig(apiKey)
+		if config.BaseURL != "" {
+			clientConfig.BaseURL = strings.TrimRight(config.BaseURL, "/")
+		}
+		clientConfig.OrgID = config.OrgID
+		return clientConfig, nil
+	}
+}
@@ -0,0 +1,84 @@
+package llm
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// geminiPricingUpdated is when the Gemini pricing table was last checked
+// against Google's published paid-tier rates. Override stale rates with
+// ai_providers.gemini.cost_per_1k_input/output rather than editing callers.
+var geminiPricingUpdated = time.Date(2025, time.September, 1, 0, 0, 0, 0, time.UTC)
+
+// geminiPrice holds USD rates per 1K tokens. Models with a long-context tier
+// charge the Long* rates for the whole request once the prompt exceeds
+// LongContextAbove tokens.
+type geminiPrice struct {
+	Input            float64
+	Output           float64 // includes thinking tokens
+	LongInput        float64
+	LongOutput       float64
+	LongContextAbove int
+	ContextWindow    int
+}
+
+// geminiPricing is keyed by model family; versioned and preview names match
+// by longest prefix (gemini-2.5-flash-lite-preview-06-17 -> gemini-2.5-flash-lite)
+var geminiPricing = map[string]geminiPrice{
+	"gemini-2.5-pro":        {Input: 0.00125, Output: 0.01, LongInput: 0.0025, LongOutput: 0.015, LongContextAbove: 200000, ContextWindow: 1048576},
+	"gemini-2.5-flash":      {Input: 0.0003, Output: 0.0025, ContextWindow: 1048576},
+	"gemini-2.5-flash-lite": {Input: 0.0001, Output: 0.0004, ContextWindow: 1048576},
+	"gemini-2.0-flash":      {Input: 0.0001, Output: 0.0004, ContextWindow: 1048576},
+	"gemini-2.0-flash-lite": {Input: 0.000075, Output: 0.0003, ContextWindow: 1048576},
+	"gemini-1.5-pro":        {Input: 0.00125, Output: 0.005, LongInput: 0.0025, LongOutput: 0.01, LongContextAbove: 128000, ContextWindow: 2097152},
+	"gemini-1.5-flash":      {Input: 0.000075, Output: 0.0003, LongInput: 0.00015, LongOutput: 0.0006, LongContextAbove: 128000, ContextWindow: 1048576},
+	"gemini-1.5-flash-8b":   {Input: 0.0000375, Output: 0.00015, LongInput: 0.000075, LongOutput: 0.0003, LongContextAbove: 128000, ContextWindow: 1048576},
+}
+
+// lookupGeminiPrice finds the table entry for a model by longest prefix
+func lookupGeminiPrice(model string) (geminiPrice, bool) {
+	model = strings.TrimPrefix(model, "models/")
+	best := ""
+	for family := range geminiPricing {
+		if strings.HasPrefix(model, family) && len(family) > len(best) {
+			best = family
+		}
+	}
+	if best == "" {
+		return geminiPrice{}, false
+	}
+	return geminiPricing[best], true
+}
+
+// geminiRates returns the input and output rates for a prompt of the given
+// size. Unknown models are charged at gemini-2.5-pro rates so costs are
+// overestimated rather than hidden.
+func geminiRates(model string, promptTokens int) (float64, float64) {
+	price, ok := lookupGeminiPrice(model)
+	if !ok {
+		price = geminiPricing["gemini-2.5-pro"]
+	}
+	if price.LongContextAbove > 0 && promptTokens > price.LongContextAbove {
+		return price.LongInput, price.LongOutput
+	}
+	return price.Input, price.Output
+}
+
+// geminiContextWindow returns a model's input token limit
+func geminiContextWindow(model string) int {
+	if price, ok := lookupGeminiPrice(model); ok {
+		return price.ContextWindow
+	}
+	return 1048576
+}
+
+// geminiModels lists the models in the pricing table
+func geminiModels() []string {
+	names := make([]string, 0, len(geminiPricing))
+	for name := range geminiPricing {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
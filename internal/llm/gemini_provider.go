@@ -0,0 +1,653 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// geminiAPIBase is the Gemini API (Google AI Studio) root
+const geminiAPIBase = "https://generativelanguage.googleapis.com/v1beta"
+
+// defaultGeminiModel is used when no Gemini model is configured
+const defaultGeminiModel = "gemini-2.5-flash"
+
+// GeminiProvider implements Provider for Google's Gemini API over REST
+type GeminiProvider struct {
+	httpClient *http.Client
+	apiKey     string
+	config     GeminiConfig
+	pricing    ProviderPricing
+	info       ProviderInfo
+}
+
+// GeminiConfig holds Gemini-specific configuration
+type GeminiConfig struct {
+	Model       string        `json:"model"`
+	MaxTokens   int           `json:"max_tokens"`
+	Temperature float64       `json:"temperature"`
+	TopP        float64       `json:"top_p"`
+	Timeout     time.Duration `json:"timeout"`
+	BaseURL     string        `json:"base_url,omitempty"`
+	CostPer1K   CostConfig    `json:"cost_per_1k"` // overrides the pricing table when set
+}
+
+// NewGeminiProvider creates a new Gemini provider
+func NewGeminiProvider(config ProviderConfig) (Provider, error) {
+	apiKey := config.APIKey
+	if apiKey == "" {
+		apiKey = os.Getenv("GEMINI_API_KEY")
+	}
+	if apiKey == "" {
+		return nil, fmt.Errorf("Gemini API key not provided")
+	}
+
+	// Set defaults
+	if config.Model == "" {
+		config.Model = defaultGeminiModel
+	}
+	if config.MaxTokens == 0 {
+		config.MaxTokens = 4000
+	}
+	if config.Timeout == 0 {
+		config.Timeout = 30 * time.Second
+	}
+	baseURL := strings.TrimRight(config.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = geminiAPIBase
+	}
+
+	// The timeout bounds the wait for a reply to start rather than the
+	// whole exchange, so long streams are not cut off
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = config.Timeout
+
+	provider := &GeminiProvider{
+		httpClient: &http.Client{Transport: transport},
+		apiKey:     apiKey,
+		config: GeminiConfig{
+			Model:       config.Model,
+			MaxTokens:   config.MaxTokens,
+			Temperature: config.Temperature,
+			TopP:        1.0,
+			Timeout:     config.Timeout,
+			BaseURL:     baseURL,
+			CostPer1K:   config.CostPer1K,
+		},
+	}
+	provider.pricing = provider.pricingFor(config.Model, 0)
+	provider.initProviderInfo()
+
+	return provider, nil
+}
+
+// Wire types for the generateContent family of endpoints
+
+type geminiPart struct {
+	Text             string                  `json:"text,omitempty"`
+	Thought          bool                    `json:"thought,omitempty"`
+	ThoughtSignature string                  `json:"thoughtSignature,omitempty"`
+	FunctionCall     *geminiFunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *geminiFunctionResponse `json:"functionResponse,omitempty"`
+}
+
+type geminiFunctionCall struct {
+	ID   string          `json:"id,omitempty"`
+	Name string          `json:"name"`
+	Args json.RawMessage `json:"args,omitempty"`
+}
+
+type geminiFunctionResponse struct {
+	ID       string                 `json:"id,omitempty"`
+	Name     string                 `json:"name"`
+	Response map[string]interface{} `json:"response"`
+}
+
+type geminiFunctionDeclaration struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+}
+
+type geminiTool struct {
+	FunctionDeclarations []geminiFunctionDeclaration `json:"functionDeclarations"`
+}
+
+type geminiToolConfig struct {
+	FunctionCallingConfig struct {
+		Mode string `json:"mode"`
+	} `json:"functionCallingConfig"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiGenerationConfig struct {
+	Temperature        *float64        `json:"temperature,omitempty"`
+	TopP               *float64        `json:"topP,omitempty"`
+	MaxOutputTokens    int             `json:"maxOutputTokens,omitempty"`
+	StopSequences      []string        `json:"stopSequences,omitempty"`
+	ResponseMimeType   string          `json:"responseMimeType,omitempty"`
+	ResponseJSONSchema json.RawMessage `json:"responseJsonSchema,omitempty"`
+}
+
+type geminiRequest struct {
+	Contents          []geminiContent         `json:"contents"`
+	SystemInstruction *geminiContent          `json:"systemInstruction,omitempty"`
+	GenerationConfig  *geminiGenerationConfig `json:"generationConfig,omitempty"`
+	Tools             []geminiTool            `json:"tools,omitempty"`
+	ToolConfig        *geminiToolConfig       `json:"toolConfig,omitempty"`
+}
+
+type geminiUsage struct {
+	PromptTokenCount        int `json:"promptTokenCount"`
+	CandidatesTokenCount    int `json:"candidatesTokenCount"`
+	ThoughtsTokenCount      int `json:"thoughtsTokenCount"`
+	CachedContentTokenCount int `json:"cachedContentTokenCount"`
+	TotalTokenCount         int `json:"totalTokenCount"`
+}
+
+type geminiResponse struct {
+	Candidates []struct {
+		Content      geminiContent `json:"content"`
+		FinishReason string        `json:"finishReason"`
+	} `json:"candidates"`
+	PromptFeedback *struct {
+		BlockReason string `json:"blockReason"`
+	} `json:"promptFeedback,omitempty"`
+	UsageMetadata *geminiUsage `json:"usageMetadata,omitempty"`
+	ModelVersion  string       `json:"modelVersion"`
+	ResponseID    string       `json:"responseId"`
+}
+
+// text returns the answer text of the first candidate, skipping thought summaries
+func (r *geminiResponse) text() string {
+	if len(r.Candidates) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	for _, part := range r.Candidates[0].Content.Parts {
+		if !part.Thought {
+			b.WriteString(part.Text)
+		}
+	}
+	return b.String()
+}
+
+// finishReason returns the first candidate's finish reason in lower case,
+// the way OpenAI reports it
+func (r *geminiResponse) finishReason() string {
+	if len(r.Candidates) == 0 {
+		return ""
+	}
+	if len(r.toolCalls()) > 0 {
+		return FinishReasonToolCalls
+	}
+	return strings.ToLower(r.Candidates[0].FinishReason)
+}
+
+// toolCalls returns the function calls in the first candidate. Gemini does
+// not always assign call IDs, so missing ones are numbered.
+func (r *geminiResponse) toolCalls() []ToolCall {
+	if len(r.Candidates) == 0 {
+		return nil
+	}
+	var calls []ToolCall
+	for _, part := range r.Candidates[0].Content.Parts {
+		if part.FunctionCall == nil {
+			continue
+		}
+		id := part.FunctionCall.ID
+		if id == "" {
+			id = fmt.Sprintf("call_%d", len(calls)+1)
+		}
+		args := string(part.FunctionCall.Args)
+		if args == "" {
+			args = "{}"
+		}
+		calls = append(calls, ToolCall{ID: id, Name: part.FunctionCall.Name, Arguments: args, Signature: part.ThoughtSignature})
+	}
+	return calls
+}
+
+// Generate generates text completion
+func (p *GeminiProvider) Generate(ctx context.Context, request *GenerationRequest) (*GenerationResponse, error) {
+	startTime := time.Now()
+
+	// Apply timeout, the configured one unless the request sets its own
+	timeout := request.Timeout
+	if timeout <= 0 {
+		timeout = p.config.Timeout
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	model := p.getModel(request.Model)
+	var response geminiResponse
+	if err := p.post(ctx, model, "generateContent", "", p.buildRequest(request, model), &response); err != nil {
+		return nil, fmt.Errorf("Gemini API call failed: %w", err)
+	}
+	if len(response.Candidates) == 0 {
+		if response.PromptFeedback != nil && response.PromptFeedback.BlockReason != "" {
+			return nil, fmt.Errorf("Gemini blocked the prompt: %s", response.PromptFeedback.BlockReason)
+		}
+		return nil, fmt.Errorf("no candidates returned from Gemini")
+	}
+
+	if response.ModelVersion != "" {
+		model = response.ModelVersion
+	}
+	tokenUsage := p.tokenUsage(response.UsageMetadata, model, request, response.text())
+
+	return &GenerationResponse{
+		Content:      response.text(),
+		FinishReason: response.finishReason(),
+		TokenUsage:   tokenUsage,
+		Cost:         p.calculateCost(tokenUsage),
+		Model:        model,
+		Provider:     "gemini",
+		Latency:      time.Since(startTime),
+		Timestamp:    time.Now(),
+		ToolCalls:    response.toolCalls(),
+		Metadata: map[string]interface{}{
+			"gemini_id":       response.ResponseID,
+			"thoughts_tokens": p.thoughtsTokens(response.UsageMetadata),
+		},
+	}, nil
+}
+
+// Stream generates streaming text completion over server-sent events
+func (p *GeminiProvider) Stream(ctx context.Context, request *GenerationRequest) (<-chan *StreamChunk, error) {
+	// The timeout covers the whole stream, so it is released by the reader
+	cancel := context.CancelFunc(func() {})
+	if request.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, request.Timeout)
+	}
+
+	model := p.getModel(request.Model)
+	body, err := p.open(ctx, model, "streamGenerateContent", "alt=sse", p.buildRequest(request, model))
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to create Gemini stream: %w", err)
+	}
+
+	chunks := make(chan *StreamChunk, 10)
+	go p.handleStream(ctx, cancel, body, chunks)
+	return chunks, nil
+}
+
+// handleStream reads server-sent events and forwards each text delta. The
+// final event carries usage metadata, which replaces the running estimate.
+func (p *GeminiProvider) handleStream(ctx context.Context, cancel context.CancelFunc, body io.ReadCloser, chunks chan<- *StreamChunk) {
+	defer close(chunks)
+	defer cancel()
+	defer body.Close()
+
+	var fullContent strings.Builder
+	tokenCount := 0
+	finishReason := ""
+	reader := bufio.NewReader(body)
+
+	for {
+		line, err := reader.ReadString('\n')
+		if data, ok := strings.CutPrefix(strings.TrimSpace(line), "data:"); ok {
+			var event geminiResponse
+			if jsonErr := json.Unmarshal([]byte(strings.TrimSpace(data)), &event); jsonErr != nil {
+				chunks <- &StreamChunk{Error: fmt.Errorf("stream error: %w", jsonErr), Done: true, Timestamp: time.Now()}
+				return
+			}
+
+			if delta := event.text(); delta != "" {
+				fullContent.WriteString(delta)
+				tokenCount += CountTextTokens(p.config.Model, delta)
+				chunks <- &StreamChunk{
+					Content:    fullContent.String(),
+					Delta:      delta,
+					TokenCount: tokenCount,
+					Timestamp:  time.Now(),
+				}
+			}
+			if reason := event.finishReason(); reason != "" {
+				finishReason = reason
+			}
+			if event.UsageMetadata != nil && event.UsageMetadata.CandidatesTokenCount > 0 {
+				tokenCount = event.UsageMetadata.CandidatesTokenCount + event.UsageMetadata.ThoughtsTokenCount
+			}
+		}
+
+		if err != nil {
+			if err == io.EOF {
+				chunks <- &StreamChunk{
+					Content:      fullContent.String(),
+					FinishReason: finishReason,
+					TokenCount:   tokenCount,
+					Done:         true,
+					Timestamp:    time.Now(),
+				}
+				return
+			}
+			if ctx.Err() != nil {
+				err = ctx.Err()
+			}
+			chunks <- &StreamChunk{Error: fmt.Errorf("stream error: %w", err), Done: true, Timestamp: time.Now()}
+			return
+		}
+	}
+}
+
+// CountTokens returns the exact prompt token count of a request, as the
+// model will bill it, using the countTokens endpoint
+func (p *GeminiProvider) CountTokens(ctx context.Context, request *GenerationRequest) (int, error) {
+	model := p.getModel(request.Model)
+	built := p.buildRequest(request, model)
+	body := map[string]interface{}{
+		"generateContentRequest": map[string]interface{}{
+			"model":             "models/" + model,
+			"contents":          built.Contents,
+			"systemInstruction": built.SystemInstruction,
+		},
+	}
+
+	var response struct {
+		TotalTokens int `json:"totalTokens"`
+	}
+	if err := p.post(ctx, model, "countTokens", "", body, &response); err != nil {
+		return 0, fmt.Errorf("Gemini token count failed: %w", err)
+	}
+	return response.TotalTokens, nil
+}
+
+// GetInfo returns provider information
+func (p *GeminiProvider) GetInfo() ProviderInfo {
+	return p.info
+}
+
+// IsHealthy checks if the provider is healthy
+func (p *GeminiProvider) IsHealthy(ctx context.Context) bool {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", p.config.BaseURL+"/models/"+url.PathEscape(p.config.Model), nil)
+	if err != nil {
+		return false
+	}
+	req.Header.Set("x-goog-api-key", p.apiKey)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
+
+// GetPricing returns current pricing information
+func (p *GeminiProvider) GetPricing() ProviderPricing {
+	return p.pricing
+}
+
+// Helper methods
+
+// buildRequest converts a generation request to Gemini's format. System
+// messages become the system instruction and assistant turns use the
+// "model" role.
+func (p *GeminiProvider) buildRequest(request *GenerationRequest, model string) *geminiRequest {
+	var system []string
+	if request.SystemPrompt != "" {
+		system = append(system, request.SystemPrompt)
+	}
+
+	var contents []geminiContent
+	for _, msg := range request.Messages {
+		role := strings.ToLower(msg.Role)
+		switch role {
+		case "system":
+			system = append(system, msg.Content)
+		case "tool":
+			// Answers to one turn's calls travel together in a single user turn
+			part := geminiPart{FunctionResponse: &geminiFunctionResponse{
+				Name:     msg.Name,
+				Response: map[string]interface{}{"result": msg.Content},
+			}}
+			if last := len(contents) - 1; last >= 0 && contents[last].Role == "user" && contents[last].Parts[0].FunctionResponse != nil {
+				contents[last].Parts = append(contents[last].Parts, part)
+			} else {
+				contents = append(contents, geminiContent{Role: "user", Parts: []geminiPart{part}})
+			}
+		case "assistant":
+			var parts []geminiPart
+			if msg.Content != "" {
+				parts = append(parts, geminiPart{Text: msg.Content})
+			}
+			for _, call := range msg.ToolCalls {
+				parts = append(parts, geminiPart{
+					FunctionCall:     &geminiFunctionCall{Name: call.Name, Args: json.RawMessage(call.Arguments)},
+					ThoughtSignature: call.Signature,
+				})
+			}
+			contents = append(contents, geminiContent{Role: "model", Parts: parts})
+		default:
+			contents = append(contents, geminiContent{Role: "user", Parts: []geminiPart{{Text: msg.Content}}})
+		}
+	}
+	if len(contents) == 0 && request.Prompt != "" {
+		contents = append(contents, geminiContent{Role: "user", Parts: []geminiPart{{Text: request.Prompt}}})
+	}
+
+	temperature := p.getTemperature(request.Temperature)
+	topP := p.getTopP(request.TopP)
+	built := &geminiRequest{
+		Contents: contents,
+		GenerationConfig: &geminiGenerationConfig{
+			Temperature:     &temperature,
+			TopP:            &topP,
+			MaxOutputTokens: p.getMaxTokens(request.MaxTokens),
+			StopSequences:   request.Stop,
+		},
+	}
+	if len(system) > 0 {
+		built.SystemInstruction = &geminiContent{Parts: []geminiPart{{Text: strings.Join(system, "\n\n")}}}
+	}
+
+	if len(request.Tools) > 0 {
+		declarations := make([]geminiFunctionDeclaration, len(request.Tools))
+		for i, tool := range request.Tools {
+			declarations[i] = geminiFunctionDeclaration{Name: tool.Name, Description: tool.Description, Parameters: tool.Parameters}
+		}
+		built.Tools = []geminiTool{{FunctionDeclarations: declarations}}
+		if request.ToolChoice != "" {
+			built.ToolConfig = &geminiToolConfig{}
+			built.ToolConfig.FunctionCallingConfig.Mode = strings.ToUpper(request.ToolChoice)
+		}
+	}
+
+	// JSON mode; models that accept JSON Schema are held to the schema too
+	if request.ResponseSchema != nil {
+		built.GenerationConfig.ResponseMimeType = "application/json"
+		if supportsGeminiJSONSchema(model) {
+			built.GenerationConfig.ResponseJSONSchema = request.ResponseSchema.Schema
+		}
+	}
+	return built
+}
+
+// supportsGeminiJSONSchema reports whether a model takes responseJsonSchema
+func supportsGeminiJSONSchema(model string) bool {
+	return strings.HasPrefix(model, "gemini-2.5") || strings.HasPrefix(model, "gemini-3")
+}
+
+// open sends a request to a model method and returns the response body
+func (p *GeminiProvider) open(ctx context.Context, model, method, query string, body interface{}) (io.ReadCloser, error) {
+	reqBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf("%s/models/%s:%s", p.config.BaseURL, url.PathEscape(model), method)
+	if query != "" {
+		endpoint += "?" + query
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(reqBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-goog-api-key", p.apiKey)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return resp.Body, nil
+}
+
+// post sends a request to a model method and decodes the reply into out
+func (p *GeminiProvider) post(ctx context.Context, model, method, query string, body interface{}, out interface{}) error {
+	respBody, err := p.open(ctx, model, method, query, body)
+	if err != nil {
+		return err
+	}
+	defer respBody.Close()
+	return json.NewDecoder(respBody).Decode(out)
+}
+
+// getModel returns the model to use. Model names meant for other providers,
+// such as an OpenAI model picked by the router, are ignored.
+func (p *GeminiProvider) getModel(requestModel string) string {
+	if strings.HasPrefix(requestModel, "gemini-") {
+		return requestModel
+	}
+	return p.config.Model
+}
+
+// getMaxTokens returns max tokens to use
+func (p *GeminiProvider) getMaxTokens(requestMaxTokens int) int {
+	if requestMaxTokens > 0 {
+		return requestMaxTokens
+	}
+	return p.config.MaxTokens
+}
+
+// getTemperature returns temperature to use
+func (p *GeminiProvider) getTemperature(requestTemperature float64) float64 {
+	if requestTemperature > 0 {
+		return requestTemperature
+	}
+	return p.config.Temperature
+}
+
+// getTopP returns top_p to use
+func (p *GeminiProvider) getTopP(requestTopP float64) float64 {
+	if requestTopP > 0 {
+		return requestTopP
+	}
+	return p.config.TopP
+}
+
+// tokenUsage converts Gemini usage metadata, estimating only when the API
+// returned none. Thinking tokens are billed as output.
+func (p *GeminiProvider) tokenUsage(usage *geminiUsage, model string, request *GenerationRequest, content string) models.TokenUsage {
+	tokenUsage := models.TokenUsage{
+		Provider:  "gemini",
+		Model:     model,
+		Timestamp: time.Now(),
+	}
+	if usage != nil {
+		tokenUsage.InputTokens = usage.PromptTokenCount
+		tokenUsage.OutputTokens = usage.CandidatesTokenCount + usage.ThoughtsTokenCount
+		tokenUsage.TotalTokens = usage.TotalTokenCount
+	} else {
+		tokenizer := TokenizerFor(model)
+		tokenUsage.InputTokens = countRequestTokens(tokenizer, request)
+		tokenUsage.OutputTokens = tokenizer.Count(content)
+	}
+	if tokenUsage.TotalTokens == 0 {
+		tokenUsage.TotalTokens = tokenUsage.InputTokens + tokenUsage.OutputTokens
+	}
+	return tokenUsage
+}
+
+// thoughtsTokens returns how many output tokens went to thinking
+func (p *GeminiProvider) thoughtsTokens(usage *geminiUsage) int {
+	if usage == nil {
+		return 0
+	}
+	return usage.ThoughtsTokenCount
+}
+
+// calculateCost calculates the cost of token usage at the rate for the
+// prompt's context tier
+func (p *GeminiProvider) calculateCost(usage models.TokenUsage) models.Cost {
+	pricing := p.pricingFor(usage.Model, usage.InputTokens)
+	inputCost := float64(usage.InputTokens) / 1000.0 * pricing.InputCostPer1K
+	outputCost := float64(usage.OutputTokens) / 1000.0 * pricing.OutputCostPer1K
+
+	return models.Cost{
+		InputCost:  inputCost,
+		OutputCost: outputCost,
+		TotalCost:  inputCost + outputCost,
+		Currency:   "USD",
+		Provider:   "gemini",
+		Model:      usage.Model,
+		Timestamp:  time.Now(),
+	}
+}
+
+// pricingFor returns the rates for a model and prompt size. Configured
+// cost_per_1k rates take precedence over the table.
+func (p *GeminiProvider) pricingFor(model string, promptTokens int) ProviderPricing {
+	input, output := geminiRates(model, promptTokens)
+	if p.config.CostPer1K.Input > 0 || p.config.CostPer1K.Output > 0 {
+		input, output = p.config.CostPer1K.Input, p.config.CostPer1K.Output
+	}
+	return ProviderPricing{
+		InputCostPer1K:  input,
+		OutputCostPer1K: output,
+		Currency:        "USD",
+		Model:           model,
+		LastUpdated:     geminiPricingUpdated,
+	}
+}
+
+// initProviderInfo initializes provider information
+func (p *GeminiProvider) initProviderInfo() {
+	p.info = ProviderInfo{
+		Name:      "Gemini",
+		Version:   "1.0.0",
+		Models:    geminiModels(),
+		MaxTokens: geminiContextWindow(p.config.Model),
+		Capabilities: []string{
+			"chat_completion",
+			"streaming",
+			"token_counting",
+			"json_mode",
+			"embeddings",
+		},
+		Pricing: p.pricing,
+		Status: ProviderStatus{
+			Available:   true,
+			LastChecked: time.Now(),
+			Health:      "healthy",
+		},
+	}
+}
@@ -0,0 +1,31 @@
+package llm
+
+import "context"
+
+type glossaryKey struct{}
+
+// glossaryHeader introduces the project glossary in the system prompt
+const glossaryHeader = "Project glossary. Use these terms as the project does, and prefer them over synonyms:"
+
+// WithGlossary returns a context whose Generate and Stream calls carry the
+// project terms relevant to the request, appended to its system prompt
+func WithGlossary(ctx context.Context, text string) context.Context {
+	if text == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, glossaryKey{}, text)
+}
+
+// withGlossary adds the context's glossary to a copy of request
+func withGlossary(ctx context.Context, request *GenerationRequest) *GenerationRequest {
+	text, _ := ctx.Value(glossaryKey{}).(string)
+	if text == "" || request == nil {
+		return request
+	}
+	grounded := *request
+	if grounded.SystemPrompt != "" {
+		grounded.SystemPrompt += "\n\n"
+	}
+	grounded.SystemPrompt += glossaryHeader + "\n" + text
+	return &grounded
+}
@@ -26,6 +26,11 @@ type Provider interface {
 	GetPricing() ProviderPricing
 }
 
+// TokenCounter is implemented by providers that can count prompt tokens exactly
+type TokenCounter interface {
+	CountTokens(ctx context.Context, request *GenerationRequest) (int, error)
+}
+
 // GenerationRequest represents a request for text generation
 type GenerationRequest struct {
 	Messages         []Message         `json:"messages"`
@@ -42,6 +47,9 @@ type GenerationRequest struct {
 	Metadata         map[string]string `json:"metadata,omitempty"`
 	Prompt           string            `json:"prompt,omitempty"`
 	MCPContext       *models.MCPContext `json:"mcp_context,omitempty"`
+	ResponseSchema   *ResponseSchema   `json:"response_schema,omitempty"` // constrain output to JSON matching this schema
+	Tools            []Tool            `json:"tools,omitempty"`           // functions the model may call
+	ToolChoice       string            `json:"tool_choice,omitempty"`     // "auto" (default) or "none"
 }
 
 // GenerationResponse represents a response from text generation
@@ -55,6 +63,7 @@ type GenerationResponse struct {
 	Latency      time.Duration          `json:"latency"`
 	Metadata     map[string]interface{} `json:"metadata"`
 	Timestamp    time.Time              `json:"timestamp"`
+	ToolCalls    []ToolCall             `json:"tool_calls,omitempty"` // set when the model asks for tools instead of answering
 }
 
 // StreamChunk represents a chunk of streaming response
@@ -70,8 +79,14 @@ type StreamChunk struct {
 
 // Message represents a chat message
 type Message struct {
-	Role    string `json:"role"` // "system", "user", "assistant"
+	Role    string `json:"role"` // "system", "user", "assistant", "tool"
 	Content string `json:"content"`
+
+	// Tool use: assistant messages carry the calls the model made, and each
+	// "tool" message answers one call by ID and function name
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
+	Name       string     `json:"name,omitempty"`
 }
 
 // ProviderConfig holds configuration for a provider
@@ -82,6 +97,15 @@ type ProviderConfig struct {
 	Temperature float64       `json:"temperature" yaml:"temperature"`
 	Timeout     time.Duration `json:"timeout" yaml:"timeout"`
 	CostPer1K   CostConfig    `json:"cost_per_1k" yaml:"cost_per_1k"`
+
+	// Endpoint selection for OpenAI-style providers; see APIType* in endpoint.go
+	APIType             string `json:"api_type,omitempty" yaml:"api_type"`
+	BaseURL             string `json:"base_url,omitempty" yaml:"base_url"`
+	Deployment          string `json:"deployment,omitempty" yaml:"deployment"`                     // Azure chat deployment
+	EmbeddingDeployment string `json:"embedding_deployment,omitempty" yaml:"embedding_deployment"` // Azure embedding deployment
+	EmbeddingModel      string `json:"embedding_model,omitempty" yaml:"embedding_model"`
+	APIVersion          string `json:"api_version,omitempty" yaml:"api_version"`                   // Azure api-version
+	OrgID               string `json:"org_id,omitempty" yaml:"org_id"`
 }
 
 // CostConfig holds cost information per 1K tokens
@@ -130,6 +154,12 @@ type AIProvidersConfig struct {
 	Gemini        ProviderConfig `json:"gemini" yaml:"gemini"`
 	Cohere        ProviderConfig `json:"cohere" yaml:"cohere"`
 	Claude        ProviderConfig `json:"claude" yaml:"claude"`
+	// Routing configures complexity-aware model routing; nil leaves it off
+	Routing *ModelRouterConfig `json:"routing,omitempty" yaml:"routing,omitempty"`
+	// Cassette records or replays provider calls; nil reads USEQ_LLM_CASSETTE
+	Cassette *CassetteConfig `json:"cassette,omitempty" yaml:"cassette,omitempty"`
+	// Tokenizers loads exact vocabularies for token counting
+	Tokenizers TokenizerConfig `json:"tokenizers,omitempty" yaml:"tokenizers,omitempty"`
 }
 
 // ManagerConfig holds configuration for the LLM manager
@@ -3,10 +3,13 @@ package llm
 import (
 	"context"
 	"fmt"
+	"os"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/yourusername/useq-ai-assistant/internal/logger"
+	"github.com/yourusername/useq-ai-assistant/internal/pii"
 	"github.com/yourusername/useq-ai-assistant/models"
 )
 
@@ -14,10 +17,17 @@ import (
 type Manager struct {
 	providers       map[string]Provider
 	primaryProvider string
+	primaryModel    string // model the primary answers with when neither the request nor routing names one
 	fallbackOrder   []string
+	models          map[string]string // configured model by provider, for token counting
 	config          ManagerConfig
+	providersConfig AIProvidersConfig // to rebuild providers after an outage
 	stats           map[string]*ProviderStats
 	circuitBreakers map[string]*CircuitBreaker
+	promptCapture   *PromptCapture
+	privacy         *pii.Scanner // applied to debug bundles
+	router          *ModelRouter
+	cassette        *Cassette
 	mu              sync.RWMutex
 }
 
@@ -27,8 +37,14 @@ func NewManager(config AIProvidersConfig) (*Manager, error) {
 		providers:       make(map[string]Provider),
 		primaryProvider: config.Primary,
 		fallbackOrder:   config.FallbackOrder,
+		providersConfig: config,
+		models: map[string]string{
+			"openai": config.OpenAI.Model,
+			"gemini": config.Gemini.Model,
+		},
 		stats:           make(map[string]*ProviderStats),
 		circuitBreakers: make(map[string]*CircuitBreaker),
+		promptCapture:   NewPromptCapture(DefaultPromptCaptureDir, DebugPromptsEnabled()),
 		config: ManagerConfig{
 			DefaultTimeout:          30 * time.Second,
 			RetryAttempts:           3,
@@ -38,8 +54,27 @@ func NewManager(config AIProvidersConfig) (*Manager, error) {
 		},
 	}
 
-	// Initialize OpenAI provider if configured
-	if config.OpenAI.APIKey != "" {
+	// Complexity-aware model routing is off unless configured
+	routingConfig := DefaultModelRouterConfig()
+	if config.Routing != nil {
+		routingConfig = *config.Routing
+	}
+	switch strings.ToLower(os.Getenv("USEQ_MODEL_ROUTING")) {
+	case "on":
+		routingConfig.Enabled = true
+	case "off":
+		routingConfig.Enabled = false
+	}
+	routingConfig.Models = routeModels(config, routingConfig.Models)
+	manager.router = NewModelRouter(routingConfig)
+
+	// Exact vocabularies replace the estimators of the models they cover
+	if err := ConfigureTokenizers(config.Tokenizers); err != nil {
+		return nil, fmt.Errorf("failed to load tokenizers: %w", err)
+	}
+
+	// Initialize OpenAI provider if configured; compatible servers may run without a key
+	if config.OpenAI.APIKey != "" || config.OpenAI.APIType == APITypeCompatible {
 		openaiProvider, err := NewOpenAIProvider(config.OpenAI)
 		if err != nil {
 			return nil, fmt.Errorf("failed to initialize OpenAI provider: %w", err)
@@ -49,31 +84,108 @@ func NewManager(config AIProvidersConfig) (*Manager, error) {
 		manager.initCircuitBreaker("openai")
 	}
 
-	// TODO: Initialize other providers when implemented
-	// if config.Gemini.APIKey != "" {
-	//     geminiProvider, err := providers.NewGeminiProvider(config.Gemini)
-	//     if err != nil {
-	//         return nil, fmt.Errorf("failed to initialize Gemini provider: %w", err)
-	//     }
-	//     manager.providers["gemini"] = geminiProvider
-	// }
+	// Initialize Gemini provider if configured
+	if config.Gemini.APIKey != "" {
+		geminiProvider, err := NewGeminiProvider(config.Gemini)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize Gemini provider: %w", err)
+		}
+		manager.providers["gemini"] = geminiProvider
+		manager.initProviderStats("gemini")
+		manager.initCircuitBreaker("gemini")
+	}
+
+	// Record or replay provider calls for offline and deterministic runs
+	cassetteConfig := CassetteConfigFromEnv()
+	if config.Cassette != nil {
+		cassetteConfig = *config.Cassette
+	}
+	if cassetteConfig.Enabled() {
+		if err := manager.useCassette(cassetteConfig); err != nil {
+			return nil, err
+		}
+	}
 
-	// Validate that primary provider exists
+	// Validate that primary provider exists; with only a fallback's key set,
+	// that fallback becomes the primary
 	if _, exists := manager.providers[manager.primaryProvider]; !exists {
-		return nil, fmt.Errorf("primary provider '%s' not available", manager.primaryProvider)
+		promoted := ""
+		for _, name := range manager.fallbackOrder {
+			if _, ok := manager.providers[name]; ok {
+				promoted = name
+				break
+			}
+		}
+		if promoted == "" {
+			return nil, fmt.Errorf("primary provider '%s' not available", manager.primaryProvider)
+		}
+		manager.primaryProvider = promoted
 	}
 
 	return manager, nil
 }
 
+// routeModels returns the models each provider is routed between: those
+// configured for it, or else the defaults of the public OpenAI and Gemini
+// APIs. A provider with its own model set keeps it unless routes are
+// configured for that provider.
+func routeModels(config AIProvidersConfig, configured map[string]RouteModels) map[string]RouteModels {
+	models := make(map[string]RouteModels, len(configured)+len(defaultRouteModels))
+	for name, routes := range configured {
+		models[name] = routes
+	}
+	apiType, _ := NormalizeAPIType(config.OpenAI.APIType)
+	pinned := map[string]bool{
+		"openai": config.OpenAI.Model != "" || apiType != APITypeOpenAI,
+		"gemini": config.Gemini.Model != "",
+	}
+	for name, routes := range defaultRouteModels {
+		if _, ok := models[name]; ok || pinned[name] {
+			continue
+		}
+		models[name] = routes
+	}
+	return models
+}
+
 // Generate generates text using the primary provider with fallback
 func (m *Manager) Generate(ctx context.Context, request *GenerationRequest) (*GenerationResponse, error) {
-	// Enhance prompt with MCP context if available
-	enhancedRequest := m.enhanceRequestWithMCP(request)
-	
+	recordPrompt(ctx, request)
+
+	// Files pinned to the session go into every prompt
+	request = withAnalyzerDiagnostics(ctx, withGlossary(ctx, withPinnedContext(ctx, request)))
+
+	// Enhance prompt with MCP context if available; retrieved content in
+	// the request is marked as untrusted data. Fallback providers get this
+	// guarded request too, without the primary's routed model.
+	guardedRequest := withUntrustedDataPolicy(m.enhanceRequestWithMCP(request))
+	enhancedRequest := guardedRequest
+
+	// A model override answers with that model alone, for comparisons
+	if override := modelOverrideFrom(ctx); override != nil {
+		response, err := m.generateWithProvider(ctx, override.choice.Provider, override.withModel(enhancedRequest))
+		if err != nil {
+			m.recordFailure(override.choice.Provider, err)
+			return nil, fmt.Errorf("%s failed: %w", override.choice, err)
+		}
+		override.tally.record(response)
+		return response, nil
+	}
+
+	// Pick a model for the primary provider based on complexity and length
+	decision := m.router.Route(m.primaryProvider, enhancedRequest)
+	if decision.Model != "" && decision.Model != enhancedRequest.Model {
+		routed := *enhancedRequest
+		routed.Model = decision.Model
+		enhancedRequest = &routed
+	} else {
+		enhancedRequest = m.withPrimaryModel(enhancedRequest)
+	}
+
 	// Try primary provider first
 	response, err := m.generateWithProvider(ctx, m.primaryProvider, enhancedRequest)
 	if err == nil {
+		m.router.Record(decision, response)
 		return response, nil
 	}
 
@@ -91,7 +203,7 @@ func (m *Manager) Generate(ctx context.Context, request *GenerationRequest) (*Ge
 				continue // Skip unavailable providers
 			}
 
-			response, fallbackErr := m.generateWithProvider(ctx, providerName, request)
+			response, fallbackErr := m.generateWithProvider(ctx, providerName, guardedRequest)
 			if fallbackErr == nil {
 				// Success with fallback
 				m.recordSuccess(providerName, response)
@@ -124,10 +236,20 @@ func (m *Manager) generateWithProvider(ctx context.Context, providerName string,
 		request.Timeout = m.config.DefaultTimeout
 	}
 
+	m.capturePrompt(ctx, providerName, request)
+	m.recordModel(ctx, providerName, request)
+
 	startTime := time.Now()
 
-	// Make the request
-	response, err := provider.Generate(ctx, request)
+	// Make the request, streaming it when the caller follows the output live
+	var response *GenerationResponse
+	var err error
+	if sink := tokenSinkFor(ctx, request); sink != nil {
+		response, err = generateStreamed(ctx, providerName, provider, request, sink)
+	} else {
+		response, err = provider.Generate(ctx, request)
+	}
+	m.auditCall(ctx, providerName, request, response, startTime, err)
 	if err != nil {
 		m.updateCircuitBreaker(providerName, false)
 		return nil, err
@@ -146,17 +268,78 @@ func (m *Manager) generateWithProvider(ctx context.Context, providerName string,
 
 // Stream starts streaming text generation
 func (m *Manager) Stream(ctx context.Context, request *GenerationRequest) (<-chan *StreamChunk, error) {
-	// For now, only use primary provider for streaming
-	provider, exists := m.providers[m.primaryProvider]
+	// For now, only use primary provider for streaming, unless the context
+	// overrides the model
+	providerName := m.primaryProvider
+	override := modelOverrideFrom(ctx)
+	if override != nil {
+		providerName = override.choice.Provider
+	}
+	provider, exists := m.providers[providerName]
 	if !exists {
-		return nil, fmt.Errorf("primary provider not available: %s", m.primaryProvider)
+		return nil, fmt.Errorf("provider not available: %s", providerName)
+	}
+
+	if !m.isCircuitBreakerClosed(providerName) {
+		return nil, fmt.Errorf("circuit breaker open for provider: %s", providerName)
+	}
+
+	recordPrompt(ctx, request)
+	request = withUntrustedDataPolicy(withAnalyzerDiagnostics(ctx, withGlossary(ctx, withPinnedContext(ctx, request))))
+	if override != nil {
+		request = override.withModel(request)
+	} else {
+		request = m.withPrimaryModel(request)
+	}
+	m.capturePrompt(ctx, providerName, request)
+	m.recordModel(ctx, providerName, request)
+
+	startTime := time.Now()
+	chunks, err := provider.Stream(ctx, request)
+	if err != nil {
+		m.auditCall(ctx, providerName, request, nil, startTime, err)
+		return nil, err
 	}
+	return m.auditStream(ctx, providerName, request, startTime, chunks), nil
+}
 
-	if !m.isCircuitBreakerClosed(m.primaryProvider) {
-		return nil, fmt.Errorf("circuit breaker open for provider: %s", m.primaryProvider)
+// CountTokens returns the prompt tokens of a request for the primary
+// provider. Providers that can count exactly (Gemini) are asked; otherwise
+// the tokenizer of the request's model, or the primary model, counts.
+func (m *Manager) CountTokens(ctx context.Context, request *GenerationRequest) int {
+	m.mu.RLock()
+	primary := m.primaryProvider
+	provider := m.providers[primary]
+	m.mu.RUnlock()
+
+	if counter, ok := provider.(TokenCounter); ok {
+		startTime := time.Now()
+		count, err := counter.CountTokens(ctx, request)
+		if countsRemotely(provider) {
+			counted := *request
+			counted.Metadata = map[string]string{"purpose": "token_count"}
+			m.auditCall(ctx, primary, &counted, &GenerationResponse{TokenUsage: models.TokenUsage{InputTokens: count}}, startTime, err)
+		}
+		if err == nil {
+			return count
+		}
 	}
+	if request.Model != "" {
+		return estimateRequestTokens(request)
+	}
+	return countRequestTokens(m.Tokenizer(), request)
+}
 
-	return provider.Stream(ctx, request)
+// Tokenizer returns the tokenizer of the primary provider's model, for
+// budgets and cost estimates made before a request is built
+func (m *Manager) Tokenizer() Tokenizer {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	model := m.models[m.primaryProvider]
+	if model == "" {
+		model = m.primaryProvider
+	}
+	return TokenizerFor(model)
 }
 
 // GetProviderInfo returns information about a specific provider
@@ -352,9 +535,95 @@ func (m *Manager) SetPrimaryProvider(providerName string) error {
 	}
 
 	m.primaryProvider = providerName
+	m.primaryModel = ""
 	return nil
 }
 
+// GetRouteStats returns cost and latency per model route
+func (m *Manager) GetRouteStats() map[string]RouteStats {
+	return m.router.GetStats()
+}
+
+// EnablePromptCapture turns on debug bundles for every LLM call, written into dir
+func (m *Manager) EnablePromptCapture(dir string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	capture := NewPromptCapture(dir, true)
+	capture.SetPrivacy(m.privacy)
+	m.promptCapture = capture
+}
+
+// SetPrivacy scans debug bundles for personal data before they are written
+func (m *Manager) SetPrivacy(scanner *pii.Scanner) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.privacy = scanner
+	m.promptCapture.SetPrivacy(scanner)
+}
+
+// capturePrompt records the rendered prompt when debug capture is enabled.
+// A bundle that cannot be written is noted; it never fails the call.
+func (m *Manager) capturePrompt(ctx context.Context, providerName string, request *GenerationRequest) {
+	m.mu.RLock()
+	capture := m.promptCapture
+	m.mu.RUnlock()
+
+	if err := capture.Capture(providerName, request); err != nil {
+		logger.Notef(ctx, logger.ComponentLLM, "⚠️ Prompt capture failed: %v", err)
+	}
+}
+
+// PromptCaptureDir returns where debug bundles are written
+func (m *Manager) PromptCaptureDir() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.promptCapture.Dir()
+}
+
+// useCassette puts a cassette in front of every provider. Replaying also
+// stands in for the providers that have no API key, so no keys are needed.
+func (m *Manager) useCassette(config CassetteConfig) error {
+	cassette, err := OpenCassette(config)
+	if err != nil {
+		return fmt.Errorf("failed to open LLM cassette: %w", err)
+	}
+	m.cassette = cassette
+
+	names := append([]string{m.primaryProvider}, m.fallbackOrder...)
+	for name := range m.providers {
+		names = append(names, name)
+	}
+	for _, name := range names {
+		inner, exists := m.providers[name]
+		if _, wrapped := inner.(*cassetteProvider); wrapped || (!exists && cassette.Mode() != CassetteReplay) {
+			continue
+		}
+		m.providers[name] = &cassetteProvider{name: name, inner: inner, cassette: cassette}
+		if !exists {
+			m.initProviderStats(name)
+			m.initCircuitBreaker(name)
+		}
+	}
+
+	if cassette.Mode() == CassetteReplay {
+		logger.Notef(context.Background(), logger.ComponentLLM, "📼 Replaying LLM responses from %s (%d recorded)", cassette.Path(), cassette.Len())
+	} else {
+		logger.Notef(context.Background(), logger.ComponentLLM, "📼 Recording LLM calls to %s", cassette.Path())
+	}
+	return nil
+}
+
+// Cassette returns the cassette provider calls go through, or nil
+func (m *Manager) Cassette() *Cassette {
+	return m.cassette
+}
+
+// DebugPromptsEnabled reports whether USEQ_DEBUG_PROMPTS asks for prompt capture
+func DebugPromptsEnabled() bool {
+	value := strings.ToLower(os.Getenv("USEQ_DEBUG_PROMPTS"))
+	return value == "1" || value == "true"
+}
+
 // GetPrimaryProvider returns the current primary provider name
 func (m *Manager) GetPrimaryProvider() string {
 	m.mu.RLock()
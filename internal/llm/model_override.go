@@ -0,0 +1,141 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+type modelOverrideKey struct{}
+
+// ModelChoice names a provider and, optionally, one of its models; an empty
+// model uses the provider's configured one
+type ModelChoice struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model,omitempty"`
+}
+
+// ParseModelChoice parses "provider" or "provider:model", e.g.
+// "openai:gpt-4o" or "gemini"
+func ParseModelChoice(spec string) (ModelChoice, error) {
+	provider, model, _ := strings.Cut(strings.TrimSpace(spec), ":")
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	if provider == "" {
+		return ModelChoice{}, fmt.Errorf("no provider in model %q (want provider or provider:model)", spec)
+	}
+	return ModelChoice{Provider: provider, Model: strings.TrimSpace(model)}, nil
+}
+
+// String returns the choice as ParseModelChoice reads it
+func (c ModelChoice) String() string {
+	if c.Model == "" {
+		return c.Provider
+	}
+	return c.Provider + ":" + c.Model
+}
+
+// ModelUsage sums the calls made under a model override
+type ModelUsage struct {
+	Model        string        `json:"model"` // the model that answered, as the provider reported it
+	Calls        int           `json:"calls"`
+	InputTokens  int           `json:"input_tokens"`
+	OutputTokens int           `json:"output_tokens"`
+	Cost         float64       `json:"cost"`
+	Latency      time.Duration `json:"latency"` // summed over calls
+}
+
+// ModelTally collects the usage of the calls made under a model override
+type ModelTally struct {
+	usage ModelUsage
+	mu    sync.Mutex
+}
+
+// Usage returns the usage so far
+func (t *ModelTally) Usage() ModelUsage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.usage
+}
+
+// record adds one response to the tally
+func (t *ModelTally) record(response *GenerationResponse) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.usage.Calls++
+	t.usage.InputTokens += response.TokenUsage.InputTokens
+	t.usage.OutputTokens += response.TokenUsage.OutputTokens
+	t.usage.Cost += response.Cost.TotalCost
+	t.usage.Latency += response.Latency
+	if response.Model != "" {
+		t.usage.Model = response.Model
+	}
+}
+
+// modelOverride is what WithModel stores in a context
+type modelOverride struct {
+	choice ModelChoice
+	tally  *ModelTally
+}
+
+// WithModel returns a context whose Generate and Stream calls go to the
+// chosen provider and model only, bypassing routing and fallback, and the
+// tally of the calls made with it
+func WithModel(ctx context.Context, choice ModelChoice) (context.Context, *ModelTally) {
+	tally := &ModelTally{usage: ModelUsage{Model: choice.Model}}
+	return context.WithValue(ctx, modelOverrideKey{}, &modelOverride{choice: choice, tally: tally}), tally
+}
+
+// modelOverrideFrom returns the context's model override, if any
+func modelOverrideFrom(ctx context.Context) *modelOverride {
+	override, _ := ctx.Value(modelOverrideKey{}).(*modelOverride)
+	return override
+}
+
+// withModel sets the override's model on a copy of request
+func (o *modelOverride) withModel(request *GenerationRequest) *GenerationRequest {
+	if o.choice.Model == "" || request.Model == o.choice.Model {
+		return request
+	}
+	chosen := *request
+	chosen.Model = o.choice.Model
+	return &chosen
+}
+
+// HasProvider reports whether a provider is configured
+func (m *Manager) HasProvider(providerName string) bool {
+	_, exists := m.providers[providerName]
+	return exists
+}
+
+// UseModel makes choice's provider the primary one and, when choice names a
+// model, sends it the requests that name no model of their own
+func (m *Manager) UseModel(choice ModelChoice) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.providers[choice.Provider]; !exists {
+		return fmt.Errorf("provider not found: %s", choice.Provider)
+	}
+	m.primaryProvider = choice.Provider
+	m.primaryModel = choice.Model
+	if choice.Model != "" {
+		m.models[choice.Provider] = choice.Model
+	}
+	return nil
+}
+
+// withPrimaryModel sets the primary model on a copy of a request that names
+// no model
+func (m *Manager) withPrimaryModel(request *GenerationRequest) *GenerationRequest {
+	m.mu.RLock()
+	model := m.primaryModel
+	m.mu.RUnlock()
+	if model == "" || request.Model != "" {
+		return request
+	}
+	chosen := *request
+	chosen.Model = model
+	return &chosen
+}
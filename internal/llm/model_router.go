@@ -0,0 +1,204 @@
+package llm
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Model route names
+const (
+	RouteCheap       = "cheap"
+	RouteStrong      = "strong"
+	RouteLongContext = "long_context"
+	RouteDefault     = "default"
+)
+
+// ModelRouterConfig configures complexity-aware model routing. Routing is
+// off unless enabled, and a provider is only routed between the models
+// listed for it.
+type ModelRouterConfig struct {
+	Enabled           bool                   `json:"enabled" yaml:"enabled"`
+	Models            map[string]RouteModels `json:"models,omitempty" yaml:"models,omitempty"` // by provider name
+	ShortPromptTokens int                    `json:"short_prompt_tokens" yaml:"short_prompt_tokens"`
+	LongContextTokens int                    `json:"long_context_tokens" yaml:"long_context_tokens"`
+}
+
+// RouteModels names a provider's model for each route. A route without a
+// model leaves the provider's configured model in place.
+type RouteModels struct {
+	Cheap       string `json:"cheap" yaml:"cheap"`
+	Strong      string `json:"strong" yaml:"strong"`
+	LongContext string `json:"long_context" yaml:"long_context"`
+}
+
+// RouteDecision describes which model a request was routed to and why
+type RouteDecision struct {
+	Route           string `json:"route"`
+	Model           string `json:"model"`
+	Reason          string `json:"reason"`
+	EstimatedTokens int    `json:"estimated_tokens"`
+}
+
+// RouteStats tracks cost and latency per route
+type RouteStats struct {
+	Requests       int64         `json:"requests"`
+	TotalCost      float64       `json:"total_cost"`
+	TotalLatency   time.Duration `json:"total_latency"`
+	AverageLatency time.Duration `json:"average_latency"`
+}
+
+// ModelRouter picks a model for each request based on complexity and length
+type ModelRouter struct {
+	config ModelRouterConfig
+	stats  map[string]*RouteStats
+	mu     sync.RWMutex
+}
+
+// strongRouteKeywords mark prompts that need the most capable model
+var strongRouteKeywords = []string{
+	"architecture", "refactor", "redesign", "design", "review",
+	"optimize", "security", "concurrency", "race condition", "migrate",
+}
+
+// defaultRouteModels are the routes of the public OpenAI and Gemini APIs.
+// Azure deployments and compatible servers name their own models, so they
+// have none.
+var defaultRouteModels = map[string]RouteModels{
+	"openai": {Cheap: "gpt-3.5-turbo", Strong: "gpt-4-turbo-preview", LongContext: "gpt-4-turbo-preview"},
+	"gemini": {Cheap: "gemini-2.5-flash-lite", Strong: "gemini-2.5-pro", LongContext: "gemini-2.5-flash"},
+}
+
+// DefaultModelRouterConfig returns routing defaults; routing is off
+func DefaultModelRouterConfig() ModelRouterConfig {
+	return ModelRouterConfig{
+		ShortPromptTokens: 1500,
+		LongContextTokens: 12000,
+	}
+}
+
+// NewModelRouter creates a model router
+func NewModelRouter(config ModelRouterConfig) *ModelRouter {
+	defaults := DefaultModelRouterConfig()
+	if config.ShortPromptTokens == 0 {
+		config.ShortPromptTokens = defaults.ShortPromptTokens
+	}
+	if config.LongContextTokens == 0 {
+		config.LongContextTokens = defaults.LongContextTokens
+	}
+
+	return &ModelRouter{
+		config: config,
+		stats:  make(map[string]*RouteStats),
+	}
+}
+
+// Route decides which of provider's models should serve the request. An
+// explicit request.Model always wins over routing. Only the user's message
+// is matched against the strong route's keywords; system prompts mention
+// reviews and design often enough to send everything there.
+func (r *ModelRouter) Route(provider string, request *GenerationRequest) RouteDecision {
+	tokens := estimateRequestTokens(request)
+	decision := RouteDecision{Route: RouteDefault, EstimatedTokens: tokens}
+
+	if r == nil || !r.config.Enabled {
+		decision.Reason = "routing disabled"
+		return decision
+	}
+	if request.Model != "" {
+		decision.Model = request.Model
+		decision.Reason = "model set explicitly"
+		return decision
+	}
+	models, ok := r.config.Models[provider]
+	if !ok {
+		decision.Reason = "no models to route between for " + provider
+		return decision
+	}
+
+	complexity := strings.ToLower(request.Metadata["complexity"])
+	text := strings.ToLower(lastUserContent(request))
+
+	switch {
+	case tokens >= r.config.LongContextTokens:
+		decision.Route = RouteLongContext
+		decision.Model = models.LongContext
+		decision.Reason = "long context prompt"
+	case complexity == "complex" || containsAny(text, strongRouteKeywords):
+		decision.Route = RouteStrong
+		decision.Model = models.Strong
+		decision.Reason = "architecture or refactoring request"
+	case tokens <= r.config.ShortPromptTokens:
+		decision.Route = RouteCheap
+		decision.Model = models.Cheap
+		decision.Reason = "short factual synthesis"
+	default:
+		decision.Reason = "no routing rule matched"
+	}
+
+	return decision
+}
+
+// Record accumulates cost and latency for a route and annotates the response
+func (r *ModelRouter) Record(decision RouteDecision, response *GenerationResponse) {
+	if r == nil || response == nil {
+		return
+	}
+
+	model := decision.Model
+	if model == "" {
+		model = response.Model
+	}
+	cost := float64(response.TokenUsage.InputTokens)/1000.0*getPricing(model, true) +
+		float64(response.TokenUsage.OutputTokens)/1000.0*getPricing(model, false)
+
+	r.mu.Lock()
+	stats, exists := r.stats[decision.Route]
+	if !exists {
+		stats = &RouteStats{}
+		r.stats[decision.Route] = stats
+	}
+	stats.Requests++
+	stats.TotalCost += cost
+	stats.TotalLatency += response.Latency
+	stats.AverageLatency = stats.TotalLatency / time.Duration(stats.Requests)
+	r.mu.Unlock()
+
+	if response.Metadata == nil {
+		response.Metadata = make(map[string]interface{})
+	}
+	response.Metadata["model_route"] = decision.Route
+	response.Metadata["model_route_reason"] = decision.Reason
+	response.Metadata["model_route_model"] = model
+	response.Metadata["model_route_cost"] = cost
+	response.Metadata["model_route_latency_ms"] = response.Latency.Milliseconds()
+	response.Metadata["estimated_prompt_tokens"] = decision.EstimatedTokens
+}
+
+// GetStats returns a copy of the per-route statistics
+func (r *ModelRouter) GetStats() map[string]RouteStats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stats := make(map[string]RouteStats, len(r.stats))
+	for route, s := range r.stats {
+		stats[route] = *s
+	}
+	return stats
+}
+
+// estimateRequestTokens counts prompt tokens with the tokenizer of the
+// requested model
+func estimateRequestTokens(request *GenerationRequest) int {
+	return countRequestTokens(TokenizerFor(request.Model), request)
+}
+
+// containsAny reports whether text contains any of the keywords
+func containsAny(text string, keywords []string) bool {
+	for _, keyword := range keywords {
+		if strings.Contains(text, keyword) {
+			return true
+		}
+	}
+	return false
+}
@@ -3,7 +3,6 @@ package llm
 import (
 	"context"
 	"fmt"
-	"os"
 	"strings"
 	"time"
 
@@ -31,16 +30,22 @@ type OpenAIConfig struct {
 	Timeout          time.Duration `json:"timeout"`
 	BaseURL          string        `json:"base_url,omitempty"`
 	OrgID            string        `json:"org_id,omitempty"`
+	APIType          string        `json:"api_type"`
+	Deployment       string        `json:"deployment,omitempty"`
+	APIVersion       string        `json:"api_version,omitempty"`
 }
 
 // NewOpenAIProvider creates a new OpenAI provider
 func NewOpenAIProvider(config ProviderConfig) (Provider, error) {
-	apiKey := config.APIKey
-	if apiKey == "" {
-		apiKey = os.Getenv("OPENAI_API_KEY")
+	apiType, err := NormalizeAPIType(config.APIType)
+	if err != nil {
+		return nil, err
 	}
+	config.APIType = apiType
 
-	if apiKey == "" {
+	// Self-hosted compatible servers often run without authentication
+	apiKey := resolveAPIKey(config)
+	if apiKey == "" && apiType != APITypeCompatible {
 		return nil, fmt.Errorf("OpenAI API key not provided")
 	}
 
@@ -64,19 +69,17 @@ func NewOpenAIProvider(config ProviderConfig) (Provider, error) {
 		PresencePenalty:  0.0,
 		FrequencyPenalty: 0.0,
 		Timeout:          config.Timeout,
+		BaseURL:          config.BaseURL,
+		OrgID:            config.OrgID,
+		APIType:          apiType,
+		Deployment:       config.Deployment,
+		APIVersion:       config.APIVersion,
 	}
 
-	// Create OpenAI client configuration
-	clientConfig := openai.DefaultConfig(apiKey)
-
-	// Set custom base URL if provided
-	if openaiConfig.BaseURL != "" {
-		clientConfig.BaseURL = openaiConfig.BaseURL
-	}
-
-	// Set organization ID if provided
-	if openaiConfig.OrgID != "" {
-		clientConfig.OrgID = openaiConfig.OrgID
+	// Create OpenAI client configuration for the endpoint type
+	clientConfig, err := openAIClientConfig(apiKey, config)
+	if err != nil {
+		return nil, err
 	}
 
 	client := openai.NewClientWithConfig(clientConfig)
@@ -133,6 +136,9 @@ func (p *OpenAIProvider) Generate(ctx context.Context, request *GenerationReques
 		PresencePenalty:  p.getPresencePenalty(request.PresencePenalty),
 		FrequencyPenalty: p.getFrequencyPenalty(request.FrequencyPenalty),
 		Stream:           false,
+		ResponseFormat:   responseFormat(request.ResponseSchema, p.getModel(request.Model)),
+		Tools:            openAITools(request.Tools),
+		ToolChoice:       openAIToolChoice(request),
 	}
 
 	// Call OpenAI API
@@ -172,6 +178,7 @@ func (p *OpenAIProvider) Generate(ctx context.Context, request *GenerationReques
 		Provider:     "openai",
 		Latency:      time.Since(startTime),
 		Timestamp:    time.Now(),
+		ToolCalls:    fromOpenAIToolCalls(choice.Message.ToolCalls),
 		Metadata: map[string]interface{}{
 			"openai_id":          response.ID,
 			"created":            response.Created,
@@ -182,11 +189,10 @@ func (p *OpenAIProvider) Generate(ctx context.Context, request *GenerationReques
 
 // Stream generates streaming text completion
 func (p *OpenAIProvider) Stream(ctx context.Context, request *GenerationRequest) (<-chan *StreamChunk, error) {
-	// Apply timeout
+	// The timeout covers the whole stream, so it is released by the reader
+	cancel := context.CancelFunc(func() {})
 	if request.Timeout > 0 {
-		var cancel context.CancelFunc
 		ctx, cancel = context.WithTimeout(ctx, request.Timeout)
-		defer cancel()
 	}
 
 	// Convert messages
@@ -217,6 +223,7 @@ func (p *OpenAIProvider) Stream(ctx context.Context, request *GenerationRequest)
 	// Create stream
 	stream, err := p.client.CreateChatCompletionStream(ctx, openaiRequest)
 	if err != nil {
+		cancel()
 		return nil, fmt.Errorf("failed to create OpenAI stream: %w", err)
 	}
 
@@ -224,14 +231,15 @@ func (p *OpenAIProvider) Stream(ctx context.Context, request *GenerationRequest)
 	chunks := make(chan *StreamChunk, 10)
 
 	// Start streaming goroutine
-	go p.handleStream(ctx, stream, chunks)
+	go p.handleStream(ctx, cancel, stream, chunks)
 
 	return chunks, nil
 }
 
 // handleStream handles the streaming response
-func (p *OpenAIProvider) handleStream(ctx context.Context, stream *openai.ChatCompletionStream, chunks chan<- *StreamChunk) {
+func (p *OpenAIProvider) handleStream(ctx context.Context, cancel context.CancelFunc, stream *openai.ChatCompletionStream, chunks chan<- *StreamChunk) {
 	defer close(chunks)
+	defer cancel()
 	defer stream.Close()
 
 	var fullContent strings.Builder
@@ -331,8 +339,10 @@ func (p *OpenAIProvider) convertMessages(messages []Message) []openai.ChatComple
 
 	for i, msg := range messages {
 		openaiMessages[i] = openai.ChatCompletionMessage{
-			Role:    p.convertRole(msg.Role),
-			Content: msg.Content,
+			Role:       p.convertRole(msg.Role),
+			Content:    msg.Content,
+			ToolCalls:  toOpenAIToolCalls(msg.ToolCalls),
+			ToolCallID: msg.ToolCallID,
 		}
 	}
 
@@ -348,6 +358,8 @@ func (p *OpenAIProvider) convertRole(role string) string {
 		return openai.ChatMessageRoleUser
 	case "assistant":
 		return openai.ChatMessageRoleAssistant
+	case "tool":
+		return openai.ChatMessageRoleTool
 	default:
 		return openai.ChatMessageRoleUser
 	}
@@ -489,3 +501,33 @@ func getMaxTokensForModel(model string) int {
 	}
 	return max
 }
+
+// responseFormat maps a response schema to OpenAI's JSON modes. Models
+// without structured outputs get JSON object mode, and the schema is
+// enforced by validation instead.
+func responseFormat(schema *ResponseSchema, model string) *openai.ChatCompletionResponseFormat {
+	if schema == nil {
+		return nil
+	}
+	if !supportsJSONSchema(model) {
+		return &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject}
+	}
+	return &openai.ChatCompletionResponseFormat{
+		Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
+		JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
+			Name:   schema.Name,
+			Schema: schema.Schema,
+			Strict: schema.Strict,
+		},
+	}
+}
+
+// supportsJSONSchema reports whether a model accepts json_schema response formats
+func supportsJSONSchema(model string) bool {
+	for _, prefix := range []string{"gpt-4o", "gpt-4.1", "gpt-5", "o1", "o3", "o4"} {
+		if strings.HasPrefix(model, prefix) {
+			return true
+		}
+	}
+	return false
+}
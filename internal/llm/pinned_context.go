@@ -0,0 +1,31 @@
+package llm
+
+import "context"
+
+type pinnedContextKey struct{}
+
+// pinnedContextHeader introduces pinned text in the system prompt
+const pinnedContextHeader = "The user pinned these files to the conversation; they are included in every request. Use them where relevant and cite them as path:line."
+
+// WithPinnedContext returns a context whose Generate and Stream calls carry
+// text the user pinned, appended to each request's system prompt
+func WithPinnedContext(ctx context.Context, text string) context.Context {
+	if text == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, pinnedContextKey{}, text)
+}
+
+// withPinnedContext adds the context's pinned text to a copy of request
+func withPinnedContext(ctx context.Context, request *GenerationRequest) *GenerationRequest {
+	text, _ := ctx.Value(pinnedContextKey{}).(string)
+	if text == "" || request == nil {
+		return request
+	}
+	pinned := *request
+	if pinned.SystemPrompt != "" {
+		pinned.SystemPrompt += "\n\n"
+	}
+	pinned.SystemPrompt += pinnedContextHeader + "\n\n" + text
+	return &pinned
+}
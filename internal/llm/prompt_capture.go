@@ -0,0 +1,234 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/pii"
+)
+
+// PromptCapture writes the fully rendered prompt of each LLM call to a
+// redacted debug bundle so wrong answers can be traced back to their context
+type PromptCapture struct {
+	dir     string
+	enabled bool
+	privacy *pii.Scanner // redacts or reports personal data; nil skips the scan
+	mu      sync.Mutex
+}
+
+// PromptBundle is the debug record written for a single LLM call
+type PromptBundle struct {
+	Timestamp    time.Time              `json:"timestamp"`
+	Provider     string                 `json:"provider"`
+	Model        string                 `json:"model"`
+	SystemPrompt string                 `json:"system_prompt"`
+	Messages     []Message              `json:"messages"`
+	Prompt       string                 `json:"prompt"`
+	MCPData      map[string]interface{} `json:"mcp_data,omitempty"`
+	Metadata     map[string]string      `json:"metadata,omitempty"`
+	MaxTokens    int                    `json:"max_tokens"`
+	Temperature  float64                `json:"temperature"`
+	Redactions   int                    `json:"redactions"`
+	PII          *pii.Report            `json:"pii,omitempty"` // personal data found, redacted or only reported
+}
+
+const lastPromptFile = "last_prompt.json"
+
+// DefaultPromptCaptureDir is where debug bundles are written
+const DefaultPromptCaptureDir = "logs/debug"
+
+// secretPatterns match values that must never end up in a debug bundle
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`sk-[A-Za-z0-9_\-]{16,}`),
+	regexp.MustCompile(`AIza[0-9A-Za-z_\-]{35}`),
+	regexp.MustCompile(`(?i)(api[_-]?key|secret|token|password)(\s*[:=]\s*)["']?[^\s"']{6,}`),
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+}
+
+// PromptCaptureConfig turns on debug bundles of every LLM prompt
+type PromptCaptureConfig struct {
+	Enabled bool   `json:"enabled"`
+	Dir     string `json:"dir"` // where bundles are written; DefaultPromptCaptureDir when empty
+}
+
+// NewPromptCapture creates a prompt capture writing into dir
+func NewPromptCapture(dir string, enabled bool) *PromptCapture {
+	if dir == "" {
+		dir = DefaultPromptCaptureDir
+	}
+	return &PromptCapture{dir: dir, enabled: enabled}
+}
+
+// SetPrivacy scans every bundle for personal data and customer
+// identifiers, redacting or reporting them as the scanner's mode says
+func (pc *PromptCapture) SetPrivacy(scanner *pii.Scanner) {
+	if pc == nil {
+		return
+	}
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	pc.privacy = scanner
+}
+
+// IsEnabled reports whether prompts are being captured
+func (pc *PromptCapture) IsEnabled() bool {
+	return pc != nil && pc.enabled
+}
+
+// Dir returns where bundles are written
+func (pc *PromptCapture) Dir() string {
+	if pc == nil {
+		return DefaultPromptCaptureDir
+	}
+	return pc.dir
+}
+
+// Capture writes a redacted bundle for the request about to be sent
+func (pc *PromptCapture) Capture(providerName string, request *GenerationRequest) error {
+	if !pc.IsEnabled() || request == nil {
+		return nil
+	}
+
+	bundle := &PromptBundle{
+		Timestamp:   time.Now(),
+		Provider:    providerName,
+		Model:       request.Model,
+		MaxTokens:   request.MaxTokens,
+		Temperature: request.Temperature,
+		Metadata:    request.Metadata,
+	}
+
+	pc.mu.Lock()
+	privacy := pc.privacy
+	pc.mu.Unlock()
+
+	redactions := 0
+	report := &pii.Report{}
+	clean := func(text string) string {
+		return privacy.Apply(redactSecrets(text, &redactions), report)
+	}
+	bundle.SystemPrompt = clean(request.SystemPrompt)
+	bundle.Prompt = clean(request.Prompt)
+	for _, msg := range request.Messages {
+		bundle.Messages = append(bundle.Messages, Message{
+			Role:    msg.Role,
+			Content: clean(msg.Content),
+		})
+	}
+	if request.MCPContext != nil && len(request.MCPContext.Data) > 0 {
+		bundle.MCPData = make(map[string]interface{}, len(request.MCPContext.Data))
+		for key, value := range request.MCPContext.Data {
+			bundle.MCPData[key] = redactValue(value, clean)
+		}
+	}
+	bundle.Redactions = redactions
+	if report.Total() > 0 {
+		bundle.PII = report
+	}
+
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode prompt bundle: %w", err)
+	}
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	if err := os.MkdirAll(pc.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create debug directory: %w", err)
+	}
+
+	name := fmt.Sprintf("prompt_%s.json", bundle.Timestamp.Format("20060102_150405.000000000"))
+	if err := os.WriteFile(filepath.Join(pc.dir, name), data, 0600); err != nil {
+		return fmt.Errorf("failed to write prompt bundle: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(pc.dir, lastPromptFile), data, 0600); err != nil {
+		return fmt.Errorf("failed to write last prompt: %w", err)
+	}
+
+	return nil
+}
+
+// LoadLastPrompt reads the most recent prompt bundle from dir
+func LoadLastPrompt(dir string) (*PromptBundle, error) {
+	if dir == "" {
+		dir = DefaultPromptCaptureDir
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, lastPromptFile))
+	if err != nil {
+		return nil, fmt.Errorf("no captured prompt found (enable with debug.capture_prompts or USEQ_DEBUG_PROMPTS=1): %w", err)
+	}
+
+	var bundle PromptBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return nil, fmt.Errorf("failed to decode prompt bundle: %w", err)
+	}
+	return &bundle, nil
+}
+
+// redactValue cleans every string in an MCP value: strings, byte slices,
+// and those nested in maps and slices. Other values, such as structs, are
+// cleaned in their JSON form.
+func redactValue(value interface{}, clean func(string) string) interface{} {
+	switch v := value.(type) {
+	case nil, bool, int, int64, float64:
+		return v
+	case string:
+		return clean(v)
+	case []byte:
+		return clean(string(v))
+	case map[string]interface{}:
+		redacted := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			redacted[key] = redactValue(item, clean)
+		}
+		return redacted
+	case map[string]string:
+		redacted := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			redacted[key] = clean(item)
+		}
+		return redacted
+	case []interface{}:
+		redacted := make([]interface{}, len(v))
+		for i, item := range v {
+			redacted[i] = redactValue(item, clean)
+		}
+		return redacted
+	case []string:
+		redacted := make([]interface{}, len(v))
+		for i, item := range v {
+			redacted[i] = clean(item)
+		}
+		return redacted
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "[UNENCODABLE]"
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return "[UNENCODABLE]"
+	}
+	return redactValue(decoded, clean)
+}
+
+// redactSecrets masks anything that looks like a credential
+func redactSecrets(text string, count *int) string {
+	for _, pattern := range secretPatterns {
+		text = pattern.ReplaceAllStringFunc(text, func(match string) string {
+			*count++
+			if sub := pattern.FindStringSubmatch(match); len(sub) == 3 {
+				return sub[1] + sub[2] + "[REDACTED]"
+			}
+			return "[REDACTED]"
+		})
+	}
+	return text
+}
@@ -0,0 +1,88 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"sync"
+)
+
+// promptHashLength is how many hex digits of a prompt's hash are recorded
+const promptHashLength = 12
+
+type promptRecordKey struct{}
+
+// PromptRecord collects the system prompts and models of the LLM calls made
+// while answering one query, for the answer's configuration fingerprint.
+// Prompts are recorded as hashes of the templates the caller sent, before
+// pinned files, glossary, diagnostics or MCP context are added, so the
+// fingerprint follows the prompts rather than each query's context and
+// no file contents are kept.
+type PromptRecord struct {
+	mu      sync.Mutex
+	prompts map[string]bool // hashes of system prompt templates
+	models  map[string]bool // provider/model
+}
+
+// WithPromptRecord returns a context whose LLM calls are recorded in the
+// returned record
+func WithPromptRecord(ctx context.Context) (context.Context, *PromptRecord) {
+	record := &PromptRecord{prompts: make(map[string]bool), models: make(map[string]bool)}
+	return context.WithValue(ctx, promptRecordKey{}, record), record
+}
+
+// SystemPrompts returns the hashes of the distinct system prompt templates
+// sent, sorted
+func (r *PromptRecord) SystemPrompts() []string {
+	return r.sorted(r.prompts)
+}
+
+// Models returns the distinct provider/model pairs called, sorted
+func (r *PromptRecord) Models() []string {
+	return r.sorted(r.models)
+}
+
+func (r *PromptRecord) sorted(set map[string]bool) []string {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	values := make([]string, 0, len(set))
+	for value := range set {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	return values
+}
+
+// recordPrompt adds the system prompt template of a request to the
+// context's record, if any. It is called before per-query context is added
+// to the request.
+func recordPrompt(ctx context.Context, request *GenerationRequest) {
+	record, _ := ctx.Value(promptRecordKey{}).(*PromptRecord)
+	if record == nil || request == nil || request.SystemPrompt == "" {
+		return
+	}
+	sum := sha256.Sum256([]byte(request.SystemPrompt))
+	record.mu.Lock()
+	defer record.mu.Unlock()
+	record.prompts[hex.EncodeToString(sum[:])[:promptHashLength]] = true
+}
+
+// recordModel adds the model a call is about to be made with to the
+// context's record, if any
+func (m *Manager) recordModel(ctx context.Context, providerName string, request *GenerationRequest) {
+	record, _ := ctx.Value(promptRecordKey{}).(*PromptRecord)
+	if record == nil || request == nil {
+		return
+	}
+	model := request.Model
+	if model == "" {
+		model = m.models[providerName]
+	}
+	record.mu.Lock()
+	defer record.mu.Unlock()
+	record.models[providerName+"/"+model] = true
+}
@@ -0,0 +1,78 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ProviderNames returns the configured providers, sorted
+func (m *Manager) ProviderNames() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	names := make([]string, 0, len(m.providers))
+	for name := range m.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ProviderFailure reports why a provider's circuit breaker is open, or nil
+// while its calls go through
+func (m *Manager) ProviderFailure(providerName string) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	cb, exists := m.circuitBreakers[providerName]
+	if !exists || cb.State != CircuitBreakerOpen {
+		return nil
+	}
+	return fmt.Errorf("circuit open after %d consecutive failures, last at %s",
+		cb.FailureCount, cb.LastFailureTime.Format(time.TimeOnly))
+}
+
+// ProbeProvider checks whether a provider's API answers
+func (m *Manager) ProbeProvider(ctx context.Context, providerName string) error {
+	m.mu.RLock()
+	provider, exists := m.providers[providerName]
+	m.mu.RUnlock()
+	if !exists {
+		return fmt.Errorf("provider not found: %s", providerName)
+	}
+	if !provider.IsHealthy(ctx) {
+		return fmt.Errorf("%s is not answering", providerName)
+	}
+	return nil
+}
+
+// RecoverProvider rebuilds a provider's client from its configuration and
+// closes its circuit breaker, so calls go to it again at once instead of
+// after the breaker's timeout. Connections broken by the outage are dropped
+// with the old client.
+func (m *Manager) RecoverProvider(providerName string) error {
+	var (
+		provider Provider
+		err      error
+	)
+	switch providerName {
+	case "openai":
+		provider, err = NewOpenAIProvider(m.providersConfig.OpenAI)
+	case "gemini":
+		provider, err = NewGeminiProvider(m.providersConfig.Gemini)
+	default:
+		return fmt.Errorf("provider not found: %s", providerName)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to re-initialize %s: %w", providerName, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if current, ok := m.providers[providerName].(*cassetteProvider); ok {
+		provider = &cassetteProvider{name: providerName, inner: provider, cassette: current.cassette}
+	}
+	m.providers[providerName] = provider
+	m.initCircuitBreaker(providerName)
+	return nil
+}
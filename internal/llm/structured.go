@@ -0,0 +1,277 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ResponseSchemaKey holds a caller-provided JSON schema in query metadata
+const ResponseSchemaKey = "response_schema"
+
+// defaultSchemaName is used when the caller does not name the schema
+const defaultSchemaName = "structured_result"
+
+// schemaNamePattern is what providers accept as a schema name
+var schemaNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// ResponseSchema constrains a generation to JSON matching a caller-provided schema
+type ResponseSchema struct {
+	Name   string          `json:"name"`
+	Schema json.RawMessage `json:"schema"`
+	Strict bool            `json:"strict"` // provider-enforced; needs every property required and no additional properties
+}
+
+// SchemaValidationError reports output that does not match the requested schema
+type SchemaValidationError struct {
+	Path   string
+	Reason string
+}
+
+func (e *SchemaValidationError) Error() string {
+	return fmt.Sprintf("response does not match schema at %s: %s", e.Path, e.Reason)
+}
+
+// ParseResponseSchema reads a schema as sent by a caller: either a bare JSON
+// schema or {"name": ..., "schema": {...}, "strict": ...}
+func ParseResponseSchema(raw []byte) (*ResponseSchema, error) {
+	var wrapped ResponseSchema
+	if err := json.Unmarshal(raw, &wrapped); err != nil {
+		return nil, fmt.Errorf("invalid response schema: %w", err)
+	}
+	schema := &wrapped
+	if len(wrapped.Schema) == 0 {
+		schema = &ResponseSchema{Schema: json.RawMessage(raw)}
+	}
+	if schema.Name == "" {
+		schema.Name = defaultSchemaName
+	}
+	if !schemaNamePattern.MatchString(schema.Name) {
+		return nil, fmt.Errorf("invalid response schema name %q", schema.Name)
+	}
+
+	var definition map[string]interface{}
+	if err := json.Unmarshal(schema.Schema, &definition); err != nil {
+		return nil, fmt.Errorf("response schema must be a JSON object: %w", err)
+	}
+	if _, ok := definition["type"]; !ok {
+		return nil, fmt.Errorf("response schema needs a top-level \"type\"")
+	}
+	return schema, nil
+}
+
+// Validate checks that content is JSON matching the schema. It supports the
+// subset of JSON Schema that provider JSON modes use: type, properties,
+// required, additionalProperties, items, enum, minItems and maxItems.
+func (s *ResponseSchema) Validate(content string) (json.RawMessage, error) {
+	var definition map[string]interface{}
+	if err := json.Unmarshal(s.Schema, &definition); err != nil {
+		return nil, fmt.Errorf("invalid response schema: %w", err)
+	}
+
+	raw := ExtractJSON(content)
+	var value interface{}
+	if err := json.Unmarshal([]byte(raw), &value); err != nil {
+		return nil, &SchemaValidationError{Path: "$", Reason: "not valid JSON: " + err.Error()}
+	}
+	if err := validateValue(definition, value, "$"); err != nil {
+		return nil, err
+	}
+	return json.RawMessage(raw), nil
+}
+
+// Instructions tells providers without a native JSON mode what to return
+func (s *ResponseSchema) Instructions() string {
+	return "Respond with a single JSON value that matches this JSON schema, and nothing else " +
+		"(no prose, no code fences):\n" + string(s.Schema)
+}
+
+// ExtractJSON returns the JSON in content, removing markdown code fences
+func ExtractJSON(content string) string {
+	trimmed := strings.TrimSpace(content)
+	if strings.HasPrefix(trimmed, "```") {
+		trimmed = strings.TrimPrefix(trimmed, "```json")
+		trimmed = strings.TrimPrefix(trimmed, "```")
+		trimmed = strings.TrimSuffix(strings.TrimSpace(trimmed), "```")
+	}
+	return strings.TrimSpace(trimmed)
+}
+
+// GenerateStructured generates a response constrained to request.ResponseSchema
+// and validates it. Output that does not validate is sent back once with the
+// validation error before giving up with a *SchemaValidationError.
+func (m *Manager) GenerateStructured(ctx context.Context, request *GenerationRequest) (*GenerationResponse, json.RawMessage, error) {
+	schema := request.ResponseSchema
+	if schema == nil {
+		return nil, nil, fmt.Errorf("structured generation requires a response schema")
+	}
+
+	structured := *request
+	structured.SystemPrompt = strings.TrimSpace(request.SystemPrompt + "\n\n" + schema.Instructions())
+	structured.Temperature = 0
+
+	response, err := m.Generate(ctx, &structured)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, validationErr := schema.Validate(response.Content)
+	if validationErr == nil {
+		return response, result, nil
+	}
+
+	// One repair attempt with the validation error
+	repair := structured
+	repair.Messages = append(append([]Message{}, structured.Messages...),
+		Message{Role: "assistant", Content: response.Content},
+		Message{Role: "user", Content: fmt.Sprintf("That did not match the schema (%v). Reply again with only the corrected JSON.", validationErr)},
+	)
+	retried, err := m.Generate(ctx, &repair)
+	if err != nil {
+		return nil, nil, err
+	}
+	addUsage(retried, response)
+
+	result, validationErr = schema.Validate(retried.Content)
+	if validationErr != nil {
+		return retried, nil, validationErr
+	}
+	return retried, result, nil
+}
+
+// addUsage adds the tokens and cost of an earlier attempt to a response
+func addUsage(response, earlier *GenerationResponse) {
+	response.TokenUsage.InputTokens += earlier.TokenUsage.InputTokens
+	response.TokenUsage.OutputTokens += earlier.TokenUsage.OutputTokens
+	response.TokenUsage.TotalTokens += earlier.TokenUsage.TotalTokens
+	response.Cost.InputCost += earlier.Cost.InputCost
+	response.Cost.OutputCost += earlier.Cost.OutputCost
+	response.Cost.TotalCost += earlier.Cost.TotalCost
+}
+
+func validateValue(schema map[string]interface{}, value interface{}, path string) error {
+	if enum, ok := schema["enum"].([]interface{}); ok && !containsValue(enum, value) {
+		return &SchemaValidationError{Path: path, Reason: fmt.Sprintf("value %v is not one of %v", value, enum)}
+	}
+
+	if !matchesType(schema["type"], value) {
+		return &SchemaValidationError{Path: path, Reason: fmt.Sprintf("expected %v, got %s", schema["type"], jsonTypeOf(value))}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return validateObject(schema, v, path)
+	case []interface{}:
+		if minItems, ok := schema["minItems"].(float64); ok && float64(len(v)) < minItems {
+			return &SchemaValidationError{Path: path, Reason: fmt.Sprintf("expected at least %v items", minItems)}
+		}
+		if maxItems, ok := schema["maxItems"].(float64); ok && float64(len(v)) > maxItems {
+			return &SchemaValidationError{Path: path, Reason: fmt.Sprintf("expected at most %v items", maxItems)}
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				if err := validateValue(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func validateObject(schema map[string]interface{}, object map[string]interface{}, path string) error {
+	properties, _ := schema["properties"].(map[string]interface{})
+
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, name := range required {
+			key, _ := name.(string)
+			if _, present := object[key]; !present {
+				return &SchemaValidationError{Path: path, Reason: fmt.Sprintf("missing required property %q", key)}
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		propertySchema, known := properties[key].(map[string]interface{})
+		if !known {
+			if additional, ok := schema["additionalProperties"].(bool); ok && !additional {
+				return &SchemaValidationError{Path: path, Reason: fmt.Sprintf("unexpected property %q", key)}
+			}
+			continue
+		}
+		if err := validateValue(propertySchema, object[key], path+"."+key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matchesType checks a value against a schema "type", which may be a list
+func matchesType(schemaType interface{}, value interface{}) bool {
+	switch t := schemaType.(type) {
+	case nil:
+		return true
+	case string:
+		return typeMatches(t, value)
+	case []interface{}:
+		for _, candidate := range t {
+			if name, ok := candidate.(string); ok && typeMatches(name, value) {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+func typeMatches(name string, value interface{}) bool {
+	actual := jsonTypeOf(value)
+	switch name {
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case "number":
+		return actual == "number"
+	default:
+		return actual == name
+	}
+}
+
+func jsonTypeOf(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, candidate := range values {
+		if reflect.DeepEqual(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+type tokenSinkKey struct{}
+
+// TokenSink receives generated text as it arrives
+type TokenSink func(delta string)
+
+// WithTokenSink returns a context whose Generate calls stream their output,
+// passing each piece of text to sink before the full response is returned.
+// Calls that use tools or a response schema are not streamed.
+func WithTokenSink(ctx context.Context, sink TokenSink) context.Context {
+	if sink == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, tokenSinkKey{}, sink)
+}
+
+// tokenSinkFor returns the context's token sink when request can be streamed
+func tokenSinkFor(ctx context.Context, request *GenerationRequest) TokenSink {
+	if len(request.Tools) > 0 || request.ResponseSchema != nil {
+		return nil
+	}
+	sink, _ := ctx.Value(tokenSinkKey{}).(TokenSink)
+	return sink
+}
+
+// generateStreamed answers request through the provider's stream, passing
+// each delta to sink. Streams report no usage, so tokens are estimated and
+// priced at the provider's rates.
+func generateStreamed(ctx context.Context, providerName string, provider Provider, request *GenerationRequest, sink TokenSink) (*GenerationResponse, error) {
+	chunks, err := provider.Stream(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	var last *StreamChunk
+	for chunk := range chunks {
+		if chunk.Error != nil {
+			// Drain so the provider's goroutine can exit
+			for range chunks {
+			}
+			return nil, chunk.Error
+		}
+		if chunk.Delta != "" {
+			sink(chunk.Delta)
+		}
+		last = chunk
+	}
+	if last == nil {
+		return nil, fmt.Errorf("%s stream ended without output", providerName)
+	}
+
+	pricing := provider.GetPricing()
+	model := request.Model
+	if model == "" {
+		model = pricing.Model
+	}
+	usage := models.TokenUsage{
+		InputTokens:  estimateRequestTokens(request),
+		OutputTokens: last.TokenCount,
+		Provider:     providerName,
+		Model:        model,
+		Timestamp:    time.Now(),
+	}
+	usage.TotalTokens = usage.InputTokens + usage.OutputTokens
+	inputCost := float64(usage.InputTokens) / 1000.0 * pricing.InputCostPer1K
+	outputCost := float64(usage.OutputTokens) / 1000.0 * pricing.OutputCostPer1K
+
+	return &GenerationResponse{
+		Content:      last.Content,
+		FinishReason: last.FinishReason,
+		TokenUsage:   usage,
+		Cost: models.Cost{
+			InputCost:  inputCost,
+			OutputCost: outputCost,
+			TotalCost:  inputCost + outputCost,
+			Currency:   pricing.Currency,
+			Provider:   providerName,
+			Model:      model,
+			Timestamp:  time.Now(),
+		},
+		Model:    model,
+		Provider: providerName,
+		Metadata: map[string]interface{}{"streamed": true, "usage_estimated": true},
+	}, nil
+}
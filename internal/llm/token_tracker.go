@@ -274,15 +274,6 @@ func (cc *CostCalculator) initializeDefaultPricing() {
 			LastUpdated:     time.Now(),
 			Tier:            "paid",
 		},
-		{
-			Provider:        "gemini",
-			Model:           "gemini-1.5-pro",
-			InputCostPer1K:  0.0035,
-			OutputCostPer1K: 0.0105,
-			Currency:        "USD",
-			LastUpdated:     time.Now(),
-			Tier:            "paid",
-		},
 		{
 			Provider:        "cohere",
 			Model:           "command-r-plus",
@@ -303,6 +294,19 @@ func (cc *CostCalculator) initializeDefaultPricing() {
 		},
 	}
 
+	// Gemini rates come from the provider's pricing table (base tier)
+	for model, price := range geminiPricing {
+		defaultPricing = append(defaultPricing, models.ModelPricing{
+			Provider:        "gemini",
+			Model:           model,
+			InputCostPer1K:  price.Input,
+			OutputCostPer1K: price.Output,
+			Currency:        "USD",
+			LastUpdated:     geminiPricingUpdated,
+			Tier:            "paid",
+		})
+	}
+
 	for _, pricing := range defaultPricing {
 		key := pricing.Provider + ":" + pricing.Model
 		cc.pricingCache[key] = pricing
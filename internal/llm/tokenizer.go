@@ -0,0 +1,337 @@
+package llm
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Tokenizer counts the tokens a model sees in a text
+type Tokenizer interface {
+	Name() string
+	Count(text string) int
+}
+
+// TokenizerConfig points models at exact tokenizer vocabularies. Models
+// without one are counted by their family's estimator.
+type TokenizerConfig struct {
+	// Vocabularies maps a model prefix to a tiktoken BPE rank file, e.g.
+	// "gpt-4o": "~/.cache/tiktoken/o200k_base.tiktoken"
+	Vocabularies map[string]string `json:"vocabularies" yaml:"vocabularies"`
+}
+
+// messageOverheadTokens is what wrapping a chat message in its role markers
+// costs
+const messageOverheadTokens = 4
+
+// TokenizerRegistry picks the tokenizer of a model by the longest matching
+// model prefix
+type TokenizerRegistry struct {
+	mu       sync.RWMutex
+	prefixes map[string]Tokenizer
+	fallback Tokenizer
+}
+
+// Estimators for the tokenizer families of the supported models. They
+// split text the way the family's pre-tokenizer does and charge each piece
+// by the family's typical merge lengths, which lands within a few percent
+// of the real count on code and English prose.
+var (
+	// o200k_base: gpt-4o, gpt-4.1, gpt-5 and the o-series
+	o200kEstimator = &familyEstimator{name: "o200k-estimate", wordChars: 6, digitGroup: 3, symbolGroup: 2, runesPerToken: 1.5}
+	// cl100k_base: gpt-4, gpt-3.5 and the text-embedding-3 models; Llama 3
+	// uses a tiktoken vocabulary of similar granularity
+	cl100kEstimator = &familyEstimator{name: "cl100k-estimate", wordChars: 5, digitGroup: 3, symbolGroup: 2, runesPerToken: 1}
+	// Gemini's 256k SentencePiece vocabulary: long word pieces, one token
+	// per digit
+	geminiEstimator = &familyEstimator{name: "gemini-sentencepiece-estimate", wordChars: 6, digitGroup: 1, symbolGroup: 1, runesPerToken: 1.5}
+	// Llama 2, Code Llama and Mistral's 32k SentencePiece vocabulary
+	llamaEstimator = &familyEstimator{name: "llama-sentencepiece-estimate", wordChars: 4, digitGroup: 1, symbolGroup: 1, runesPerToken: 1}
+)
+
+// NewTokenizerRegistry returns a registry with the family estimators of the
+// known models
+func NewTokenizerRegistry() *TokenizerRegistry {
+	r := &TokenizerRegistry{prefixes: make(map[string]Tokenizer), fallback: cl100kEstimator}
+	for _, prefix := range []string{"gpt-4o", "gpt-4.1", "gpt-5", "o1", "o3", "o4", "chatgpt-4o"} {
+		r.Register(prefix, o200kEstimator)
+	}
+	for _, prefix := range []string{"openai", "gpt-4", "gpt-3.5", "text-embedding", "llama-3", "llama3", "meta-llama-3", "meta-llama/meta-llama-3"} {
+		r.Register(prefix, cl100kEstimator)
+	}
+	for _, prefix := range []string{"gemini", "gemma", "models/gemini"} {
+		r.Register(prefix, geminiEstimator)
+	}
+	for _, prefix := range []string{"llama", "llama-2", "llama2", "codellama", "code-llama", "mistral", "mixtral", "meta-llama/llama-2"} {
+		r.Register(prefix, llamaEstimator)
+	}
+	return r
+}
+
+// Register makes tokenizer count for every model starting with prefix
+func (r *TokenizerRegistry) Register(prefix string, tokenizer Tokenizer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.prefixes[strings.ToLower(prefix)] = tokenizer
+}
+
+// For returns the tokenizer of a model; unknown models and "" get the
+// cl100k estimator
+func (r *TokenizerRegistry) For(model string) Tokenizer {
+	model = strings.ToLower(strings.TrimSpace(model))
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	best, bestLen := r.fallback, -1
+	for prefix, tokenizer := range r.prefixes {
+		if strings.HasPrefix(model, prefix) && len(prefix) > bestLen {
+			best, bestLen = tokenizer, len(prefix)
+		}
+	}
+	return best
+}
+
+// defaultTokenizers serves every token count in the process
+var defaultTokenizers = NewTokenizerRegistry()
+
+// TokenizerFor returns the tokenizer of a model
+func TokenizerFor(model string) Tokenizer {
+	return defaultTokenizers.For(model)
+}
+
+// RegisterTokenizer makes tokenizer count for every model starting with
+// prefix, e.g. to plug in a native tiktoken or SentencePiece binding
+func RegisterTokenizer(prefix string, tokenizer Tokenizer) {
+	defaultTokenizers.Register(prefix, tokenizer)
+}
+
+// ConfigureTokenizers loads the configured vocabularies so their models are
+// counted exactly
+func ConfigureTokenizers(config TokenizerConfig) error {
+	prefixes := make([]string, 0, len(config.Vocabularies))
+	for prefix := range config.Vocabularies {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	for _, prefix := range prefixes {
+		path := expandHome(config.Vocabularies[prefix])
+		tokenizer, err := LoadTiktokenVocabulary(path)
+		if err != nil {
+			return fmt.Errorf("tokenizer for %s: %w", prefix, err)
+		}
+		RegisterTokenizer(prefix, tokenizer)
+	}
+	return nil
+}
+
+// CountTextTokens counts the tokens of text for a model
+func CountTextTokens(model, text string) int {
+	return TokenizerFor(model).Count(text)
+}
+
+// countRequestTokens counts the prompt tokens of a request: its system
+// prompt, prompt and messages, each message with its role markers
+func countRequestTokens(tokenizer Tokenizer, request *GenerationRequest) int {
+	tokens := tokenizer.Count(request.SystemPrompt) + tokenizer.Count(request.Prompt)
+	for _, msg := range request.Messages {
+		tokens += messageOverheadTokens + tokenizer.Count(msg.Content)
+	}
+	return tokens
+}
+
+// familyEstimator approximates a tokenizer family without its vocabulary
+type familyEstimator struct {
+	name          string
+	wordChars     int     // letters a single word piece typically covers
+	digitGroup    int     // digits merged into one token
+	symbolGroup   int     // punctuation characters merged into one token
+	runesPerToken float64 // non-ASCII letters per token
+}
+
+func (e *familyEstimator) Name() string {
+	return e.name
+}
+
+// Count splits text into words, numbers, symbol runs and whitespace and
+// charges each piece by the family's merge lengths
+func (e *familyEstimator) Count(text string) int {
+	tokens := 0
+	for _, piece := range preTokenPattern.FindAllString(text, -1) {
+		tokens += e.countPiece(piece)
+	}
+	return tokens
+}
+
+func (e *familyEstimator) countPiece(piece string) int {
+	// A leading space or symbol is merged into the word that follows it
+	trimmed := strings.TrimLeftFunc(piece, func(r rune) bool {
+		return r == ' ' || (!unicode.IsLetter(r) && !unicode.IsNumber(r) && !unicode.IsSpace(r))
+	})
+	if trimmed == "" {
+		// A symbol run with its leading space
+		trimmed = strings.TrimLeft(piece, " ")
+	}
+	if trimmed == "" {
+		trimmed = piece
+	}
+	first, _ := utf8.DecodeRuneInString(trimmed)
+	switch {
+	case unicode.IsLetter(first):
+		ascii, other := 0, 0
+		for _, r := range trimmed {
+			if r < utf8.RuneSelf {
+				ascii++
+			} else {
+				other++
+			}
+		}
+		return ceilDiv(ascii, e.wordChars) + int(math.Ceil(float64(other)/e.runesPerToken))
+	case unicode.IsNumber(first):
+		return ceilDiv(utf8.RuneCountInString(trimmed), e.digitGroup)
+	case unicode.IsSpace(first):
+		// Newlines break pieces; runs of spaces (indentation) merge
+		return max(1, strings.Count(trimmed, "\n")+ceilDiv(len(strings.Trim(trimmed, "\r\n")), 8))
+	default:
+		return ceilDiv(utf8.RuneCountInString(strings.TrimRight(trimmed, "\r\n")), e.symbolGroup)
+	}
+}
+
+func ceilDiv(n, d int) int {
+	if n <= 0 {
+		return 0
+	}
+	if d <= 1 {
+		return n
+	}
+	return (n + d - 1) / d
+}
+
+// preTokenPattern splits text like cl100k_base's pre-tokenizer: contractions,
+// words with an optional leading space or symbol, numbers of up to three
+// digits, symbol runs and whitespace. RE2 has no lookahead, so a run of
+// spaces keeps its last space rather than giving it to the next word.
+var preTokenPattern = regexp.MustCompile(`(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+`)
+
+// bpeTokenizer counts exactly with a tiktoken byte-pair vocabulary
+type bpeTokenizer struct {
+	name  string
+	ranks map[string]int
+
+	mu    sync.Mutex
+	cache map[string]int // tokens per pre-token piece
+}
+
+// maxBPECacheEntries bounds the piece cache; it is cleared when full
+const maxBPECacheEntries = 50000
+
+// LoadTiktokenVocabulary reads a tiktoken rank file ("<base64 token> <rank>"
+// per line), such as cl100k_base.tiktoken or o200k_base.tiktoken
+func LoadTiktokenVocabulary(path string) (Tokenizer, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	ranks := make(map[string]int)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		token, err := base64.StdEncoding.DecodeString(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("bad token %q in %s: %w", fields[0], path, err)
+		}
+		rank, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("bad rank %q in %s: %w", fields[1], path, err)
+		}
+		ranks[string(token)] = rank
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(ranks) == 0 {
+		return nil, fmt.Errorf("%s holds no tokens", path)
+	}
+
+	name := strings.TrimSuffix(filepath.Base(path), ".tiktoken")
+	return &bpeTokenizer{name: name, ranks: ranks, cache: make(map[string]int)}, nil
+}
+
+func (t *bpeTokenizer) Name() string {
+	return t.name
+}
+
+// Count pre-tokenizes text and merges the bytes of every piece by rank
+func (t *bpeTokenizer) Count(text string) int {
+	tokens := 0
+	for _, piece := range preTokenPattern.FindAllString(text, -1) {
+		tokens += t.countPiece(piece)
+	}
+	return tokens
+}
+
+func (t *bpeTokenizer) countPiece(piece string) int {
+	if _, ok := t.ranks[piece]; ok {
+		return 1
+	}
+	t.mu.Lock()
+	count, ok := t.cache[piece]
+	t.mu.Unlock()
+	if ok {
+		return count
+	}
+
+	count = len(t.merge(piece))
+	t.mu.Lock()
+	if len(t.cache) >= maxBPECacheEntries {
+		t.cache = make(map[string]int)
+	}
+	t.cache[piece] = count
+	t.mu.Unlock()
+	return count
+}
+
+// merge applies byte-pair merges to piece, lowest rank first, and returns
+// the resulting tokens
+func (t *bpeTokenizer) merge(piece string) []string {
+	parts := make([]string, len(piece))
+	for i := range piece {
+		parts[i] = piece[i : i+1]
+	}
+	for len(parts) > 1 {
+		best, bestRank := -1, math.MaxInt
+		for i := 0; i < len(parts)-1; i++ {
+			if rank, ok := t.ranks[parts[i]+parts[i+1]]; ok && rank < bestRank {
+				best, bestRank = i, rank
+			}
+		}
+		if best < 0 {
+			break
+		}
+		parts[best] += parts[best+1]
+		parts = append(parts[:best+1], parts[best+2:]...)
+	}
+	return parts
+}
+
+func expandHome(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[2:])
+		}
+	}
+	return path
+}
@@ -0,0 +1,88 @@
+package llm
+
+import (
+	"encoding/json"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Tool choices for GenerationRequest.ToolChoice
+const (
+	ToolChoiceAuto = "auto"
+	ToolChoiceNone = "none" // tools stay declared but the model must answer
+)
+
+// FinishReasonToolCalls is reported when the model stops to call tools
+const FinishReasonToolCalls = "tool_calls"
+
+// Tool describes a function the model may call
+type Tool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Parameters  json.RawMessage `json:"parameters"` // JSON schema of the arguments object
+}
+
+// ToolCall is one function call requested by the model
+type ToolCall struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"` // JSON object
+	// Signature is opaque provider state (Gemini thought signatures) that
+	// must be sent back with the call on the next turn
+	Signature string `json:"signature,omitempty"`
+}
+
+// openAITools converts tool definitions to OpenAI function tools
+func openAITools(tools []Tool) []openai.Tool {
+	if len(tools) == 0 {
+		return nil
+	}
+	converted := make([]openai.Tool, len(tools))
+	for i, tool := range tools {
+		converted[i] = openai.Tool{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.Parameters,
+			},
+		}
+	}
+	return converted
+}
+
+// openAIToolChoice maps a tool choice; nil leaves the provider default
+func openAIToolChoice(request *GenerationRequest) any {
+	if len(request.Tools) == 0 || request.ToolChoice == "" {
+		return nil
+	}
+	return request.ToolChoice
+}
+
+// fromOpenAIToolCalls converts the tool calls in an OpenAI reply
+func fromOpenAIToolCalls(calls []openai.ToolCall) []ToolCall {
+	if len(calls) == 0 {
+		return nil
+	}
+	converted := make([]ToolCall, len(calls))
+	for i, call := range calls {
+		converted[i] = ToolCall{ID: call.ID, Name: call.Function.Name, Arguments: call.Function.Arguments}
+	}
+	return converted
+}
+
+// toOpenAIToolCalls converts recorded tool calls back for the conversation history
+func toOpenAIToolCalls(calls []ToolCall) []openai.ToolCall {
+	if len(calls) == 0 {
+		return nil
+	}
+	converted := make([]openai.ToolCall, len(calls))
+	for i, call := range calls {
+		converted[i] = openai.ToolCall{
+			ID:       call.ID,
+			Type:     openai.ToolTypeFunction,
+			Function: openai.FunctionCall{Name: call.Name, Arguments: call.Arguments},
+		}
+	}
+	return converted
+}
@@ -0,0 +1,35 @@
+package llm
+
+import (
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/internal/promptguard"
+)
+
+// withUntrustedDataPolicy tells the model to treat retrieved content as data
+// when a request carries any, wherever in the request it was placed
+func withUntrustedDataPolicy(request *GenerationRequest) *GenerationRequest {
+	if request == nil || strings.Contains(request.SystemPrompt, promptguard.Policy) || !carriesUntrustedData(request) {
+		return request
+	}
+	guarded := *request
+	if guarded.SystemPrompt != "" {
+		guarded.SystemPrompt += "\n\n"
+	}
+	guarded.SystemPrompt += promptguard.Policy
+	return &guarded
+}
+
+// carriesUntrustedData reports whether any part of request holds a data
+// section
+func carriesUntrustedData(request *GenerationRequest) bool {
+	if promptguard.Contains(request.SystemPrompt) || promptguard.Contains(request.Prompt) {
+		return true
+	}
+	for _, message := range request.Messages {
+		if promptguard.Contains(message.Content) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"context"
+	"time"
+)
+
+// EventKind says what a query event reports
+type EventKind string
+
+const (
+	EventProgress EventKind = "progress" // a diagnostic the console would show
+	EventStep     EventKind = "step"     // a step of the query started, completed or failed
+	EventToken    EventKind = "token"    // text the model generated
+)
+
+// Event is live progress of a query in flight, for callers that show it
+// somewhere other than the console
+type Event struct {
+	Kind      EventKind `json:"kind"`
+	Component string    `json:"component,omitempty"`
+	Message   string    `json:"message,omitempty"`
+	Step      *LogStep  `json:"step,omitempty"`
+	Level     string    `json:"level,omitempty"` // verbosity of a progress event
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// EventSink receives the events of one query. It is called from whichever
+// goroutine does the work, so it must be safe for concurrent use and should
+// not block.
+type EventSink func(Event)
+
+type eventSinkKey struct{}
+
+// WithEventSink returns a context whose query publishes its progress,
+// steps and generated tokens to sink
+func WithEventSink(ctx context.Context, sink EventSink) context.Context {
+	if sink == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, eventSinkKey{}, sink)
+}
+
+// EventSinkFrom returns the context's event sink, or nil
+func EventSinkFrom(ctx context.Context) EventSink {
+	if ctx == nil {
+		return nil
+	}
+	sink, _ := ctx.Value(eventSinkKey{}).(EventSink)
+	return sink
+}
+
+// publish sends an event to the context's sink, if any
+func publish(ctx context.Context, event Event) {
+	if sink := EventSinkFrom(ctx); sink != nil {
+		event.Timestamp = time.Now()
+		sink(event)
+	}
+}
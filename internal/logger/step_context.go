@@ -0,0 +1,23 @@
+package logger
+
+import "context"
+
+type stepLoggerKey struct{}
+
+// WithStepLogger returns a context carrying the step logger of one query, so
+// queries of concurrent sessions log their steps apart
+func WithStepLogger(ctx context.Context, sl *StepLogger) context.Context {
+	if sl == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, stepLoggerKey{}, sl)
+}
+
+// StepLoggerFrom returns the context's step logger, or fallback when it
+// carries none
+func StepLoggerFrom(ctx context.Context, fallback *StepLogger) *StepLogger {
+	if sl, ok := ctx.Value(stepLoggerKey{}).(*StepLogger); ok {
+		return sl
+	}
+	return fallback
+}
@@ -28,6 +28,7 @@ type StepLogger struct {
 	enableConsole bool
 	enableFile    bool
 	logLevel      zapcore.Level
+	events        EventSink
 }
 
 // LogStep represents a single step in the execution flow
@@ -69,6 +70,7 @@ const (
 	ComponentDisplay  Component = "display"
 	ComponentFeedback Component = "feedback"
 	ComponentCache    Component = "cache"
+	ComponentTasks    Component = "tasks"
 )
 
 // NewStepLogger creates a new step logger instance
@@ -138,6 +140,7 @@ func (sl *StepLogger) StartStep(component Component, action string, details inte
 	}
 
 	sl.steps = append(sl.steps, step)
+	sl.publishStep(step)
 
 	// Log to console/file
 	// JSON logs disabled for console - only file logging
@@ -166,6 +169,7 @@ func (sl *StepLogger) UpdateStep(stepNumber int, status StepStatus, details inte
 			step.Metadata[k] = v
 		}
 	}
+	sl.publishStep(*step)
 
 	sl.logger.Info("Step updated",
 		zap.String("session_id", sl.sessionID),
@@ -198,6 +202,7 @@ func (sl *StepLogger) CompleteStep(stepNumber int, result interface{}) {
 	if result != nil {
 		step.Details = result
 	}
+	sl.publishStep(*step)
 
 	sl.logger.Info("Step completed",
 		zap.String("session_id", sl.sessionID),
@@ -229,6 +234,7 @@ func (sl *StepLogger) FailStep(stepNumber int, err error) {
 	if err != nil {
 		step.Error = err.Error()
 	}
+	sl.publishStep(*step)
 
 	sl.logger.Error("Step failed",
 		zap.String("session_id", sl.sessionID),
@@ -255,6 +261,16 @@ func (sl *StepLogger) LogInfo(component Component, message string, fields ...int
 	// Info output disabled - logs go to file only
 }
 
+// LogDebug logs a diagnostic message, to the log only
+func (sl *StepLogger) LogDebug(component Component, message string, fields ...interface{}) {
+	sl.logger.Debug(message,
+		zap.String("session_id", sl.sessionID),
+		zap.String("query_id", sl.queryID),
+		zap.String("component", string(component)),
+		zap.Any("data", fields),
+	)
+}
+
 // LogError logs an error message
 func (sl *StepLogger) LogError(component Component, message string, err error, fields ...interface{}) {
 	sl.logger.Error(message,
@@ -270,6 +286,27 @@ func (sl *StepLogger) LogError(component Component, message string, err error, f
 	}
 }
 
+// SetEventSink publishes every step change to sink as it happens, so a
+// query's steps can be followed live
+func (sl *StepLogger) SetEventSink(sink EventSink) {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	sl.events = sink
+}
+
+// publishStep sends a copy of step to the event sink; callers hold sl.mu
+func (sl *StepLogger) publishStep(step LogStep) {
+	if sl.events == nil {
+		return
+	}
+	metadata := make(map[string]interface{}, len(step.Metadata))
+	for k, v := range step.Metadata {
+		metadata[k] = v
+	}
+	step.Metadata = metadata
+	sl.events(Event{Kind: EventStep, Component: step.Component, Message: step.Action, Step: &step, Timestamp: time.Now()})
+}
+
 // GetExecutionSummary returns a summary of all executed steps
 func (sl *StepLogger) GetExecutionSummary() ExecutionSummary {
 	sl.mu.RLock()
@@ -0,0 +1,115 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// Verbosity is how much diagnostic output reaches the console. Every
+// diagnostic still goes to the query's step log.
+type Verbosity int
+
+const (
+	VerbosityQuiet   Verbosity = iota // answers and errors only
+	VerbosityNormal                   // progress notes as well
+	VerbosityVerbose                  // what each search strategy and stage found
+	VerbosityDebug                    // every diagnostic, and the step log on the console
+)
+
+var verbosityNames = []string{"quiet", "normal", "verbose", "debug"}
+
+// VerbosityNames lists the verbosity levels, quietest first
+func VerbosityNames() []string {
+	return append([]string{}, verbosityNames...)
+}
+
+// String returns the level's name
+func (v Verbosity) String() string {
+	if v < VerbosityQuiet || int(v) >= len(verbosityNames) {
+		return fmt.Sprintf("verbosity(%d)", int(v))
+	}
+	return verbosityNames[v]
+}
+
+// ParseVerbosity reads a level name
+func ParseVerbosity(name string) (Verbosity, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for i, known := range verbosityNames {
+		if name == known {
+			return Verbosity(i), nil
+		}
+	}
+	return VerbosityNormal, fmt.Errorf("unknown verbosity %q; use one of %s", name, strings.Join(verbosityNames, ", "))
+}
+
+// defaultVerbosity applies outside queries and to queries that set none
+var defaultVerbosity atomic.Int32
+
+func init() {
+	defaultVerbosity.Store(int32(VerbosityNormal))
+}
+
+// SetDefaultVerbosity sets the verbosity of the process
+func SetDefaultVerbosity(v Verbosity) {
+	defaultVerbosity.Store(int32(v))
+}
+
+// DefaultVerbosity returns the verbosity of the process
+func DefaultVerbosity() Verbosity {
+	return Verbosity(defaultVerbosity.Load())
+}
+
+type verbosityKey struct{}
+
+// WithVerbosity returns a context carrying the verbosity of one query
+func WithVerbosity(ctx context.Context, v Verbosity) context.Context {
+	return context.WithValue(ctx, verbosityKey{}, v)
+}
+
+// VerbosityFrom returns the verbosity of the query ctx belongs to, or the
+// process default
+func VerbosityFrom(ctx context.Context) Verbosity {
+	if ctx != nil {
+		if v, ok := ctx.Value(verbosityKey{}).(Verbosity); ok {
+			return v
+		}
+	}
+	return DefaultVerbosity()
+}
+
+// console is where diagnostics are shown
+var console io.Writer = os.Stdout
+
+// Diagnostic records a diagnostic in the step log of the query ctx belongs
+// to and shows it on the console when the query's verbosity reaches level
+func Diagnostic(ctx context.Context, level Verbosity, component Component, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	if ctx != nil {
+		if sl := StepLoggerFrom(ctx, nil); sl != nil {
+			sl.LogDebug(component, message)
+		}
+	}
+	if VerbosityFrom(ctx) >= level {
+		fmt.Fprintln(console, message)
+		publish(ctx, Event{Kind: EventProgress, Component: string(component), Message: message, Level: level.String()})
+	}
+}
+
+// Notef shows a progress note unless the query is quiet
+func Notef(ctx context.Context, component Component, format string, args ...interface{}) {
+	Diagnostic(ctx, VerbosityNormal, component, format, args...)
+}
+
+// Verbosef shows a diagnostic at verbose and debug verbosity
+func Verbosef(ctx context.Context, component Component, format string, args ...interface{}) {
+	Diagnostic(ctx, VerbosityVerbose, component, format, args...)
+}
+
+// Debugf shows a diagnostic at debug verbosity only
+func Debugf(ctx context.Context, component Component, format string, args ...interface{}) {
+	Diagnostic(ctx, VerbosityDebug, component, format, args...)
+}
@@ -0,0 +1,182 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// DefaultClassificationBenchmarkPath is the labeled dataset bench classify
+// reads when given none
+const DefaultClassificationBenchmarkPath = "config/classification_benchmark.json"
+
+// ClassificationCase is one labeled query: the tier and the agent it should
+// be routed to. Either label may be left out to measure only the other.
+type ClassificationCase struct {
+	Query string    `json:"query"`
+	Tier  QueryTier `json:"tier,omitempty"`
+	Agent string    `json:"agent,omitempty"`
+	Note  string    `json:"note,omitempty"` // why the label is what it is
+}
+
+// LoadClassificationCases reads a labeled dataset: a JSON array of cases
+func LoadClassificationCases(path string) ([]ClassificationCase, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read classification benchmark: %w", err)
+	}
+	var cases []ClassificationCase
+	if err := json.Unmarshal(data, &cases); err != nil {
+		return nil, fmt.Errorf("failed to parse classification benchmark %s: %w", path, err)
+	}
+	for i, c := range cases {
+		if c.Query == "" {
+			return nil, fmt.Errorf("case %d of %s has no query", i+1, path)
+		}
+		if c.Tier == "" && c.Agent == "" {
+			return nil, fmt.Errorf("case %d of %s (%q) has neither a tier nor an agent label", i+1, path, c.Query)
+		}
+		switch c.Tier {
+		case "", TierSimple, TierMedium, TierComplex:
+		default:
+			return nil, fmt.Errorf("case %d of %s has unknown tier %q", i+1, path, c.Tier)
+		}
+	}
+	return cases, nil
+}
+
+// ConfusionMatrix counts expected labels against predicted ones
+type ConfusionMatrix struct {
+	Counts map[string]map[string]int `json:"counts"` // expected -> predicted -> cases
+	Total  int                       `json:"total"`
+}
+
+// NewConfusionMatrix creates an empty matrix
+func NewConfusionMatrix() *ConfusionMatrix {
+	return &ConfusionMatrix{Counts: make(map[string]map[string]int)}
+}
+
+// Add records one prediction
+func (m *ConfusionMatrix) Add(expected, predicted string) {
+	if m.Counts[expected] == nil {
+		m.Counts[expected] = make(map[string]int)
+	}
+	m.Counts[expected][predicted]++
+	m.Total++
+}
+
+// Labels lists every expected or predicted label, sorted
+func (m *ConfusionMatrix) Labels() []string {
+	seen := make(map[string]bool)
+	for expected, row := range m.Counts {
+		seen[expected] = true
+		for predicted := range row {
+			seen[predicted] = true
+		}
+	}
+	labels := make([]string, 0, len(seen))
+	for label := range seen {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	return labels
+}
+
+// Count is how many cases expected to be expected were predicted as predicted
+func (m *ConfusionMatrix) Count(expected, predicted string) int {
+	return m.Counts[expected][predicted]
+}
+
+// Accuracy is the share of cases predicted correctly
+func (m *ConfusionMatrix) Accuracy() float64 {
+	if m.Total == 0 {
+		return 0
+	}
+	correct := 0
+	for label, row := range m.Counts {
+		correct += row[label]
+	}
+	return float64(correct) / float64(m.Total)
+}
+
+// LabelScore is the precision, recall and F1 of one label
+type LabelScore struct {
+	Label     string  `json:"label"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	F1        float64 `json:"f1"`
+	Support   int     `json:"support"` // cases expected to have the label
+}
+
+// Scores reports every label's precision and recall, in label order. A
+// label never predicted has precision 0; one never expected has recall 0.
+func (m *ConfusionMatrix) Scores() []LabelScore {
+	labels := m.Labels()
+	scores := make([]LabelScore, 0, len(labels))
+	for _, label := range labels {
+		truePositives := m.Counts[label][label]
+		predicted, support := 0, 0
+		for expected, row := range m.Counts {
+			predicted += row[label]
+			if expected == label {
+				for _, count := range row {
+					support += count
+				}
+			}
+		}
+		score := LabelScore{Label: label, Support: support}
+		if predicted > 0 {
+			score.Precision = float64(truePositives) / float64(predicted)
+		}
+		if support > 0 {
+			score.Recall = float64(truePositives) / float64(support)
+		}
+		if score.Precision+score.Recall > 0 {
+			score.F1 = 2 * score.Precision * score.Recall / (score.Precision + score.Recall)
+		}
+		scores = append(scores, score)
+	}
+	return scores
+}
+
+// ClassificationMiss is a case classified differently than labeled
+type ClassificationMiss struct {
+	Query     string `json:"query"`
+	Dimension string `json:"dimension"` // tier or agent
+	Expected  string `json:"expected"`
+	Predicted string `json:"predicted"`
+	Note      string `json:"note,omitempty"`
+}
+
+// ClassificationReport is the outcome of running a classifier over a
+// labeled dataset
+type ClassificationReport struct {
+	Cases  int                  `json:"cases"`
+	Tiers  *ConfusionMatrix     `json:"tiers"`
+	Agents *ConfusionMatrix     `json:"agents"`
+	Misses []ClassificationMiss `json:"misses"`
+}
+
+// NewClassificationReport creates an empty report
+func NewClassificationReport() *ClassificationReport {
+	return &ClassificationReport{Tiers: NewConfusionMatrix(), Agents: NewConfusionMatrix()}
+}
+
+// Record adds a case's predicted tier and agent. Labels the case leaves
+// out are not scored.
+func (r *ClassificationReport) Record(c ClassificationCase, tier QueryTier, agent string) {
+	r.Cases++
+	if c.Tier != "" {
+		r.Tiers.Add(string(c.Tier), string(tier))
+		if tier != c.Tier {
+			r.Misses = append(r.Misses, ClassificationMiss{c.Query, "tier", string(c.Tier), string(tier), c.Note})
+		}
+	}
+	if c.Agent != "" {
+		r.Agents.Add(c.Agent, agent)
+		if agent != c.Agent {
+			r.Misses = append(r.Misses, ClassificationMiss{c.Query, "agent", c.Agent, agent, c.Note})
+		}
+	}
+}
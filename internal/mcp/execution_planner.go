@@ -0,0 +1,253 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultOperationTimeout bounds an operation that sets no timeout of
+	// its own
+	defaultOperationTimeout = 10 * time.Second
+
+	// maxParallelOperations bounds how many operations run at once
+	maxParallelOperations = 4
+)
+
+// Operation is one step of an MCP execution plan: a file listing, a git
+// command, a system probe
+type Operation struct {
+	Name      string
+	DependsOn []string      // operations whose results it needs; they run first
+	Timeout   time.Duration // zero uses defaultOperationTimeout
+
+	// Args are the operation's fixed arguments, which with its name key
+	// cached results
+	Args       string
+	CacheTTL   time.Duration // how long a result is reused; zero never caches
+	ReadsFiles bool          // cached results are dropped when project files change
+
+	// Run performs the operation, given the results of its dependencies
+	Run func(ctx context.Context, deps map[string]interface{}) (interface{}, error)
+}
+
+// OperationResult is the outcome of one operation
+type OperationResult struct {
+	Name     string        `json:"name"`
+	Value    interface{}   `json:"value,omitempty"`
+	Err      error         `json:"-"`
+	Duration time.Duration `json:"duration"`
+	Cached   bool          `json:"cached,omitempty"` // reused from an earlier run
+}
+
+// ExecutionPlanner runs the operations a query needs. Operations that do
+// not depend on each other run concurrently, each within its own timeout;
+// an operation starts once the ones it depends on have finished.
+type ExecutionPlanner struct {
+	mu          sync.RWMutex
+	operations  map[string]*Operation
+	maxParallel int
+	cache       *OperationCache // nil runs every operation
+}
+
+// NewExecutionPlanner creates a planner running up to maxParallel
+// operations at once
+func NewExecutionPlanner(maxParallel int) *ExecutionPlanner {
+	if maxParallel <= 0 {
+		maxParallel = maxParallelOperations
+	}
+	return &ExecutionPlanner{
+		operations:  make(map[string]*Operation),
+		maxParallel: maxParallel,
+	}
+}
+
+// SetCache makes the planner reuse recent results of operations that
+// allow caching
+func (p *ExecutionPlanner) SetCache(cache *OperationCache) {
+	p.cache = cache
+}
+
+// Register adds an operation plans can include, replacing one of the same
+// name
+func (p *ExecutionPlanner) Register(operation *Operation) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.operations[operation.Name] = operation
+}
+
+// Plan orders the named operations and everything they depend on so each
+// comes after its dependencies. Names nothing is registered for are left
+// out; an unknown dependency or a cycle is an error.
+func (p *ExecutionPlanner) Plan(names []string) ([]*Operation, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int)
+	var plan []*Operation
+	var visit func(name, dependent string) error
+	visit = func(name, dependent string) error {
+		switch state[name] {
+		case visiting:
+			return fmt.Errorf("operation %s depends on itself through %s", name, dependent)
+		case visited:
+			return nil
+		}
+		operation, ok := p.operations[name]
+		if !ok {
+			return fmt.Errorf("operation %s depends on unknown operation %s", dependent, name)
+		}
+		state[name] = visiting
+		for _, dependency := range operation.DependsOn {
+			if err := visit(dependency, name); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+		plan = append(plan, operation)
+		return nil
+	}
+	for _, name := range names {
+		if _, ok := p.operations[name]; !ok {
+			continue
+		}
+		if err := visit(name, name); err != nil {
+			return nil, err
+		}
+	}
+	return plan, nil
+}
+
+// Run plans and executes the named operations
+func (p *ExecutionPlanner) Run(ctx context.Context, names []string) (map[string]*OperationResult, error) {
+	plan, err := p.Plan(names)
+	if err != nil {
+		return nil, err
+	}
+	return p.Execute(ctx, plan), nil
+}
+
+// Execute runs a plan, each operation as soon as its dependencies finish.
+// An operation whose dependency failed is not run and fails too.
+func (p *ExecutionPlanner) Execute(ctx context.Context, plan []*Operation) map[string]*OperationResult {
+	results := make(map[string]*OperationResult, len(plan))
+	done := make(map[string]chan struct{}, len(plan))
+	for _, operation := range plan {
+		done[operation.Name] = make(chan struct{})
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, p.maxParallel)
+	for _, operation := range plan {
+		wg.Add(1)
+		go func(operation *Operation) {
+			defer wg.Done()
+			defer close(done[operation.Name])
+
+			deps := make(map[string]interface{}, len(operation.DependsOn))
+			var result *OperationResult
+			for _, dependency := range operation.DependsOn {
+				<-done[dependency]
+				mu.Lock()
+				dependencyResult := results[dependency]
+				mu.Unlock()
+				if dependencyResult.Err != nil {
+					result = &OperationResult{
+						Name: operation.Name,
+						Err:  fmt.Errorf("dependency %s failed: %w", dependency, dependencyResult.Err),
+					}
+					break
+				}
+				deps[dependency] = dependencyResult.Value
+			}
+			if result == nil {
+				result = p.cached(operation)
+			}
+			if result == nil {
+				select {
+				case slots <- struct{}{}:
+					result = runOperation(ctx, operation, deps)
+					<-slots
+				case <-ctx.Done():
+					result = &OperationResult{Name: operation.Name, Err: ctx.Err()}
+				}
+				if result.Err == nil && p.cache != nil {
+					p.cache.Set(operation, result.Value)
+				}
+			}
+
+			mu.Lock()
+			results[operation.Name] = result
+			mu.Unlock()
+		}(operation)
+	}
+	wg.Wait()
+	return results
+}
+
+// cached returns a reusable earlier result of the operation, or nil
+func (p *ExecutionPlanner) cached(operation *Operation) *OperationResult {
+	if p.cache == nil || operation.CacheTTL <= 0 {
+		return nil
+	}
+	value, ok := p.cache.Get(operation)
+	if !ok {
+		return nil
+	}
+	return &OperationResult{Name: operation.Name, Value: value, Cached: true}
+}
+
+// runOperation runs one operation within its timeout. An operation that
+// ignores its context is abandoned when the timeout passes.
+func runOperation(ctx context.Context, operation *Operation, deps map[string]interface{}) *OperationResult {
+	timeout := operation.Timeout
+	if timeout <= 0 {
+		timeout = defaultOperationTimeout
+	}
+	opCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type outcome struct {
+		value interface{}
+		err   error
+	}
+	start := time.Now()
+	finished := make(chan outcome, 1)
+	go func() {
+		value, err := operation.Run(opCtx, deps)
+		finished <- outcome{value, err}
+	}()
+
+	result := &OperationResult{Name: operation.Name}
+	select {
+	case out := <-finished:
+		result.Value, result.Err = out.value, out.err
+	case <-opCtx.Done():
+		result.Err = fmt.Errorf("operation %s timed out after %v: %w", operation.Name, timeout, opCtx.Err())
+	}
+	result.Duration = time.Since(start)
+	return result
+}
+
+// mergeOperationData merges the data maps successful operations returned,
+// in plan order so later operations win on shared keys
+func mergeOperationData(data map[string]interface{}, plan []*Operation, results map[string]*OperationResult) {
+	for _, operation := range plan {
+		result := results[operation.Name]
+		if result == nil || result.Err != nil {
+			continue
+		}
+		if values, ok := result.Value.(map[string]interface{}); ok {
+			for key, value := range values {
+				data[key] = value
+			}
+		}
+	}
+}
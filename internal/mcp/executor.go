@@ -51,8 +51,18 @@ func (e *Executor) ExecuteOperations(ctx context.Context, requirements *models.M
 func (e *Executor) searchFiles(patterns []string) ([]map[string]interface{}, error) {
 	var results []map[string]interface{}
 	
+	policy := CurrentPathPolicy()
 	err := filepath.WalkDir(".", func(path string, d fs.DirEntry, err error) error {
-		if err != nil || d.IsDir() || strings.Contains(path, "/.") {
+		if err != nil {
+			return nil
+		}
+		if policy.Denied(path) != "" {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || strings.Contains(path, "/.") {
 			return nil
 		}
 		
@@ -106,6 +116,9 @@ func (e *Executor) getFileSize(path string) int64 {
 
 // readFile reads entire file content
 func (e *Executor) ReadFile(path string) (string, error) {
+	if err := CurrentPathPolicy().Check("read", path); err != nil {
+		return "", err
+	}
 	content, err := os.ReadFile(path)
 	if err != nil {
 		return "", fmt.Errorf("failed to read file %s: %w", path, err)
@@ -113,13 +126,23 @@ func (e *Executor) ReadFile(path string) (string, error) {
 	return string(content), nil
 }
 
-// writeFile writes content to file
-func (e *Executor) WriteFile(path, content string) error {
+// writeFile writes content to file if the agent in ctx may write
+func (e *Executor) WriteFile(ctx context.Context, path, content string) error {
+	if err := Authorize(ctx, CapabilityWrite, path); err != nil {
+		return err
+	}
+	if err := CurrentPathPolicy().Check("write", path); err != nil {
+		return err
+	}
 	return os.WriteFile(path, []byte(content), 0644)
 }
 
 // listDirectory lists directory contents
 func (e *Executor) ListDirectory(path string) ([]map[string]interface{}, error) {
+	policy := CurrentPathPolicy()
+	if err := policy.Check("list", path); err != nil {
+		return nil, err
+	}
 	entries, err := os.ReadDir(path)
 	if err != nil {
 		return nil, err
@@ -127,6 +150,9 @@ func (e *Executor) ListDirectory(path string) ([]map[string]interface{}, error)
 	
 	var results []map[string]interface{}
 	for _, entry := range entries {
+		if policy.Denied(filepath.Join(path, entry.Name())) != "" {
+			continue
+		}
 		info, _ := entry.Info()
 		results = append(results, map[string]interface{}{
 			"name":  entry.Name(),
@@ -0,0 +1,340 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Transports an external MCP server can be reached over
+const (
+	TransportStdio = "stdio" // a local process speaking JSON-RPC on stdin/stdout
+	TransportHTTP  = "http"  // a streamable HTTP endpoint
+)
+
+const (
+	// mcpProtocolVersion is the protocol revision offered on initialize
+	mcpProtocolVersion = "2024-11-05"
+
+	// defaultExternalTimeout bounds startup and each tool call of a server
+	// that sets no timeout of its own
+	defaultExternalTimeout = 30 * time.Second
+
+	// externalToolSeparator joins server and tool names, so tools of
+	// different servers never collide
+	externalToolSeparator = "__"
+
+	// maxToolNameLength is the longest tool name model APIs accept
+	maxToolNameLength = 64
+)
+
+// toolNameInvalid matches what model APIs reject in tool names
+var toolNameInvalid = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// ExternalServerConfig declares a third-party MCP server in properties.yaml
+type ExternalServerConfig struct {
+	Name      string            `mapstructure:"name" yaml:"name"`
+	Transport string            `mapstructure:"transport" yaml:"transport"` // stdio (default) or http
+	Command   string            `mapstructure:"command" yaml:"command"`     // stdio: executable to start
+	Args      []string          `mapstructure:"args" yaml:"args"`
+	Env       map[string]string `mapstructure:"env" yaml:"env"` // $VARS are expanded
+	URL       string            `mapstructure:"url" yaml:"url"` // http: endpoint
+	Headers   map[string]string `mapstructure:"headers" yaml:"headers"`
+
+	// Capability is what agents need to call the server's tools: read,
+	// write or exec (default)
+	Capability Capability    `mapstructure:"capability" yaml:"capability"`
+	Timeout    time.Duration `mapstructure:"timeout" yaml:"timeout"`
+	Disabled   bool          `mapstructure:"disabled" yaml:"disabled"`
+}
+
+// ExternalTool is a tool an external server offers
+type ExternalTool struct {
+	Server        string          `json:"server"`
+	Name          string          `json:"name"`           // as the server knows it
+	QualifiedName string          `json:"qualified_name"` // as agents call it
+	Description   string          `json:"description"`
+	InputSchema   json.RawMessage `json:"input_schema"`
+	Capability    Capability      `json:"capability"`
+}
+
+// ExternalServerStatus reports how a configured server started
+type ExternalServerStatus struct {
+	Name      string `json:"name"`
+	Transport string `json:"transport"`
+	Connected bool   `json:"connected"`
+	Tools     int    `json:"tools"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ExternalServer is a connection to one external MCP server
+type ExternalServer struct {
+	config    ExternalServerConfig
+	transport mcpTransport
+	tools     []*ExternalTool
+}
+
+// startExternalServer connects to a server, completes the initialize
+// handshake and lists its tools
+func startExternalServer(ctx context.Context, config ExternalServerConfig) (*ExternalServer, error) {
+	var transport mcpTransport
+	switch config.Transport {
+	case TransportStdio:
+		stdio, err := startStdioTransport(config)
+		if err != nil {
+			return nil, err
+		}
+		transport = stdio
+	case TransportHTTP:
+		transport = newHTTPTransport(config)
+	default:
+		return nil, fmt.Errorf("unknown transport %q", config.Transport)
+	}
+	server := &ExternalServer{config: config, transport: transport}
+
+	ctx, cancel := context.WithTimeout(ctx, config.Timeout)
+	defer cancel()
+	_, err := transport.call(ctx, "initialize", map[string]interface{}{
+		"protocolVersion": mcpProtocolVersion,
+		"capabilities":    map[string]interface{}{},
+		"clientInfo":      map[string]interface{}{"name": "useq-ai-assistant", "version": "1.0.0"},
+	})
+	if err == nil {
+		err = transport.notify(ctx, "notifications/initialized", nil)
+	}
+	if err == nil {
+		err = server.listTools(ctx)
+	}
+	if err != nil {
+		transport.close()
+		return nil, err
+	}
+	return server, nil
+}
+
+// listTools reads every page of the server's tool list
+func (s *ExternalServer) listTools(ctx context.Context) error {
+	cursor := ""
+	for {
+		params := map[string]interface{}{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+		raw, err := s.transport.call(ctx, "tools/list", params)
+		if err != nil {
+			return fmt.Errorf("failed to list tools: %w", err)
+		}
+		var page struct {
+			Tools []struct {
+				Name        string          `json:"name"`
+				Description string          `json:"description"`
+				InputSchema json.RawMessage `json:"inputSchema"`
+			} `json:"tools"`
+			NextCursor string `json:"nextCursor"`
+		}
+		if err := json.Unmarshal(raw, &page); err != nil {
+			return fmt.Errorf("failed to parse tool list: %w", err)
+		}
+		for _, tool := range page.Tools {
+			schema := tool.InputSchema
+			if len(schema) == 0 {
+				schema = json.RawMessage(`{"type":"object","properties":{}}`)
+			}
+			s.tools = append(s.tools, &ExternalTool{
+				Server:        s.config.Name,
+				Name:          tool.Name,
+				QualifiedName: qualifiedToolName(s.config.Name, tool.Name),
+				Description:   tool.Description,
+				InputSchema:   schema,
+				Capability:    s.config.Capability,
+			})
+		}
+		if page.NextCursor == "" || page.NextCursor == cursor {
+			return nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// callTool runs a tool and returns the text it produced. A result the
+// server marks as an error is returned as one.
+func (s *ExternalServer) callTool(ctx context.Context, name string, arguments json.RawMessage) (string, error) {
+	if len(arguments) == 0 {
+		arguments = json.RawMessage(`{}`)
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.config.Timeout)
+	defer cancel()
+	raw, err := s.transport.call(ctx, "tools/call", map[string]interface{}{
+		"name":      name,
+		"arguments": arguments,
+	})
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", s.config.Name, err)
+	}
+	var result struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		IsError bool `json:"isError"`
+	}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return "", fmt.Errorf("failed to parse %s result: %w", name, err)
+	}
+	var parts []string
+	for _, content := range result.Content {
+		if content.Type == "text" {
+			parts = append(parts, content.Text)
+		} else {
+			parts = append(parts, fmt.Sprintf("[%s content omitted]", content.Type))
+		}
+	}
+	text := strings.Join(parts, "\n")
+	if result.IsError {
+		return "", fmt.Errorf("%s %s failed: %s", s.config.Name, name, text)
+	}
+	return text, nil
+}
+
+// qualifiedToolName names a server's tool for agents: server__tool,
+// limited to the characters and length model APIs accept
+func qualifiedToolName(server, tool string) string {
+	name := toolNameInvalid.ReplaceAllString(server+externalToolSeparator+tool, "_")
+	if len(name) > maxToolNameLength {
+		name = name[:maxToolNameLength]
+	}
+	return name
+}
+
+// ExternalServers holds the external MCP servers started from the
+// configuration. A nil *ExternalServers has no servers and no tools.
+type ExternalServers struct {
+	mu      sync.RWMutex
+	servers map[string]*ExternalServer
+	tools   map[string]*ExternalTool // by qualified name
+	status  []ExternalServerStatus
+}
+
+// StartExternalServers starts the configured servers concurrently. A
+// server that fails to start is reported in Status and left out; the
+// others remain usable.
+func StartExternalServers(ctx context.Context, configs []ExternalServerConfig) *ExternalServers {
+	es := &ExternalServers{
+		servers: make(map[string]*ExternalServer),
+		tools:   make(map[string]*ExternalTool),
+	}
+
+	var wg sync.WaitGroup
+	for _, config := range configs {
+		if config.Disabled {
+			continue
+		}
+		config = withExternalDefaults(config)
+		wg.Add(1)
+		go func(config ExternalServerConfig) {
+			defer wg.Done()
+			status := ExternalServerStatus{Name: config.Name, Transport: config.Transport}
+			server, err := startExternalServer(ctx, config)
+
+			es.mu.Lock()
+			defer es.mu.Unlock()
+			if err != nil {
+				status.Error = err.Error()
+				es.status = append(es.status, status)
+				return
+			}
+			es.servers[config.Name] = server
+			for _, tool := range server.tools {
+				es.tools[tool.QualifiedName] = tool
+			}
+			status.Connected = true
+			status.Tools = len(server.tools)
+			es.status = append(es.status, status)
+		}(config)
+	}
+	wg.Wait()
+
+	sort.Slice(es.status, func(i, j int) bool { return es.status[i].Name < es.status[j].Name })
+	return es
+}
+
+// withExternalDefaults fills in what a server configuration leaves out
+func withExternalDefaults(config ExternalServerConfig) ExternalServerConfig {
+	if config.Transport == "" {
+		config.Transport = TransportStdio
+	}
+	if config.Capability == "" {
+		config.Capability = CapabilityExec
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultExternalTimeout
+	}
+	return config
+}
+
+// Tools lists the tools of every connected server, ordered by name
+func (es *ExternalServers) Tools() []*ExternalTool {
+	if es == nil {
+		return nil
+	}
+	es.mu.RLock()
+	defer es.mu.RUnlock()
+	tools := make([]*ExternalTool, 0, len(es.tools))
+	for _, tool := range es.tools {
+		tools = append(tools, tool)
+	}
+	sort.Slice(tools, func(i, j int) bool { return tools[i].QualifiedName < tools[j].QualifiedName })
+	return tools
+}
+
+// Call runs a tool by its qualified name with the JSON arguments an agent
+// produced
+func (es *ExternalServers) Call(ctx context.Context, qualifiedName string, arguments json.RawMessage) (string, error) {
+	if es == nil {
+		return "", fmt.Errorf("unknown external tool %q", qualifiedName)
+	}
+	es.mu.RLock()
+	tool, ok := es.tools[qualifiedName]
+	var server *ExternalServer
+	if ok {
+		server = es.servers[tool.Server]
+	}
+	es.mu.RUnlock()
+	if server == nil {
+		return "", fmt.Errorf("unknown external tool %q", qualifiedName)
+	}
+	return server.callTool(ctx, tool.Name, arguments)
+}
+
+// Status reports each configured server, ordered by name
+func (es *ExternalServers) Status() []ExternalServerStatus {
+	if es == nil {
+		return nil
+	}
+	es.mu.RLock()
+	defer es.mu.RUnlock()
+	return append([]ExternalServerStatus(nil), es.status...)
+}
+
+// Close stops every server
+func (es *ExternalServers) Close() error {
+	if es == nil {
+		return nil
+	}
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	var firstErr error
+	for name, server := range es.servers {
+		if err := server.transport.close(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to close %s: %w", name, err)
+		}
+	}
+	es.servers = make(map[string]*ExternalServer)
+	es.tools = make(map[string]*ExternalTool)
+	return firstErr
+}
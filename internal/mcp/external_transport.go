@@ -0,0 +1,362 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/yourusername/useq-ai-assistant/internal/calllog"
+)
+
+// maxServerStderr bounds the stderr kept from a stdio server, quoted when
+// it exits
+const maxServerStderr = 4096
+
+// rpcMessage is a JSON-RPC 2.0 request, notification or response
+type rpcMessage struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  interface{}      `json:"params,omitempty"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *rpcError        `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// mcpTransport carries JSON-RPC messages to one external MCP server
+type mcpTransport interface {
+	call(ctx context.Context, method string, params interface{}) (json.RawMessage, error)
+	notify(ctx context.Context, method string, params interface{}) error
+	close() error
+}
+
+// stdioTransport talks to a server process over its stdin and stdout, one
+// JSON message per line. Responses are matched to requests by ID, so
+// concurrent calls share the process.
+type stdioTransport struct {
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	writeMu sync.Mutex
+	nextID  int64
+
+	mu      sync.Mutex
+	pending map[int64]chan *rpcMessage
+	done    chan struct{}
+	err     error // why the server stopped answering
+	stderr  limitedBuffer
+}
+
+// startStdioTransport starts the server process
+func startStdioTransport(config ExternalServerConfig) (*stdioTransport, error) {
+	cmd := exec.Command(config.Command, config.Args...)
+	cmd.Env = os.Environ()
+	for key, value := range config.Env {
+		cmd.Env = append(cmd.Env, key+"="+os.ExpandEnv(value))
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	t := &stdioTransport{
+		cmd:     cmd,
+		stdin:   stdin,
+		pending: make(map[int64]chan *rpcMessage),
+		done:    make(chan struct{}),
+	}
+	cmd.Stderr = &t.stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", config.Command, err)
+	}
+	go t.readLoop(stdout)
+	return t, nil
+}
+
+// readLoop delivers responses to the calls waiting for them and answers
+// the server's own requests
+func (t *stdioTransport) readLoop(stdout io.Reader) {
+	reader := bufio.NewReader(stdout)
+	var readErr error
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			t.dispatch(line)
+		}
+		if err != nil {
+			readErr = err
+			break
+		}
+	}
+
+	t.mu.Lock()
+	t.err = fmt.Errorf("server exited: %v", readErr)
+	if stderr := strings.TrimSpace(t.stderr.String()); stderr != "" {
+		t.err = fmt.Errorf("server exited: %v: %s", readErr, stderr)
+	}
+	t.mu.Unlock()
+	close(t.done)
+}
+
+func (t *stdioTransport) dispatch(line []byte) {
+	var message rpcMessage
+	if err := json.Unmarshal(line, &message); err != nil {
+		return // servers may log to stdout; only JSON-RPC matters
+	}
+	if message.Method != "" {
+		if message.ID != nil {
+			t.answerServerRequest(&message)
+		}
+		return // notifications need no answer
+	}
+	var id int64
+	if message.ID == nil || json.Unmarshal(*message.ID, &id) != nil {
+		return
+	}
+	t.mu.Lock()
+	waiting, ok := t.pending[id]
+	delete(t.pending, id)
+	t.mu.Unlock()
+	if ok {
+		waiting <- &message
+	}
+}
+
+// answerServerRequest replies to requests a server makes of its client:
+// pings are answered, anything else is declined
+func (t *stdioTransport) answerServerRequest(request *rpcMessage) {
+	reply := rpcMessage{JSONRPC: "2.0", ID: request.ID}
+	if request.Method == "ping" {
+		reply.Result = json.RawMessage(`{}`)
+	} else {
+		reply.Error = &rpcError{Code: -32601, Message: "method not supported by this client: " + request.Method}
+	}
+	t.write(&reply)
+}
+
+func (t *stdioTransport) write(message *rpcMessage) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	_, err = t.stdin.Write(append(data, '\n'))
+	return err
+}
+
+func (t *stdioTransport) call(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	id := atomic.AddInt64(&t.nextID, 1)
+	rawID := json.RawMessage(fmt.Sprintf("%d", id))
+	waiting := make(chan *rpcMessage, 1)
+	t.mu.Lock()
+	t.pending[id] = waiting
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		delete(t.pending, id)
+		t.mu.Unlock()
+	}()
+
+	if err := t.write(&rpcMessage{JSONRPC: "2.0", ID: &rawID, Method: method, Params: params}); err != nil {
+		return nil, fmt.Errorf("failed to send %s: %w", method, err)
+	}
+	select {
+	case response := <-waiting:
+		if response.Error != nil {
+			return nil, response.Error
+		}
+		return response.Result, nil
+	case <-t.done:
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		return nil, t.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (t *stdioTransport) notify(ctx context.Context, method string, params interface{}) error {
+	return t.write(&rpcMessage{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+// close stops the server process. Its exit status is not an error: the
+// process is killed.
+func (t *stdioTransport) close() error {
+	t.stdin.Close()
+	if err := t.cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return err
+	}
+	t.cmd.Wait()
+	return nil
+}
+
+// httpTransport posts each message to a server's HTTP endpoint, accepting
+// a JSON reply or an event stream carrying it
+type httpTransport struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+	nextID  int64
+
+	mu        sync.Mutex
+	sessionID string // assigned by the server on initialize
+}
+
+func newHTTPTransport(config ExternalServerConfig) *httpTransport {
+	return &httpTransport{
+		url:     config.URL,
+		headers: config.Headers,
+		client:  &http.Client{Transport: calllog.Transport(calllog.ServiceMCP, config.Name, nil)},
+	}
+}
+
+func (t *httpTransport) call(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	id := atomic.AddInt64(&t.nextID, 1)
+	rawID := json.RawMessage(fmt.Sprintf("%d", id))
+	resp, err := t.post(ctx, &rpcMessage{JSONRPC: "2.0", ID: &rawID, Method: method, Params: params})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var response *rpcMessage
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		response, err = readEventStreamResponse(resp.Body, id)
+	} else {
+		response = &rpcMessage{}
+		err = json.NewDecoder(resp.Body).Decode(response)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s response: %w", method, err)
+	}
+	if response.Error != nil {
+		return nil, response.Error
+	}
+	return response.Result, nil
+}
+
+func (t *httpTransport) notify(ctx context.Context, method string, params interface{}) error {
+	resp, err := t.post(ctx, &rpcMessage{JSONRPC: "2.0", Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (t *httpTransport) post(ctx context.Context, message *rpcMessage) (*http.Response, error) {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	for key, value := range t.headers {
+		req.Header.Set(key, os.ExpandEnv(value))
+	}
+	t.mu.Lock()
+	if t.sessionID != "" {
+		req.Header.Set("Mcp-Session-Id", t.sessionID)
+	}
+	t.mu.Unlock()
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s returned %d: %s", t.url, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if sessionID := resp.Header.Get("Mcp-Session-Id"); sessionID != "" {
+		t.mu.Lock()
+		t.sessionID = sessionID
+		t.mu.Unlock()
+	}
+	return resp, nil
+}
+
+func (t *httpTransport) close() error {
+	return nil
+}
+
+// readEventStreamResponse reads server-sent events until the response to
+// request id arrives
+func readEventStreamResponse(body io.Reader, id int64) (*rpcMessage, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "data:") {
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+			continue
+		}
+		if line != "" || data.Len() == 0 {
+			continue
+		}
+		// A blank line ends an event
+		var message rpcMessage
+		var messageID int64
+		if json.Unmarshal([]byte(data.String()), &message) == nil && message.ID != nil &&
+			json.Unmarshal(*message.ID, &messageID) == nil && messageID == id && message.Method == "" {
+			return &message, nil
+		}
+		data.Reset()
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("event stream ended without a response")
+}
+
+// limitedBuffer keeps the first maxServerStderr bytes written to it
+type limitedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if room := maxServerStderr - b.buf.Len(); room > 0 {
+		if len(p) > room {
+			b.buf.Write(p[:room])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
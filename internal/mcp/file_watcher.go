@@ -1,7 +1,9 @@
 package mcp
 
 import (
+	"io/fs"
 	"log"
+	"os"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -15,19 +17,26 @@ type FileWatcher struct {
 	cache     *MCPContextCache
 	watchDirs map[string]bool
 	mu        sync.RWMutex
+
+	// operations holds cached operation results, dropped on any project
+	// file change; trees records the roots watched recursively
+	operations *OperationCache
+	trees      map[string]bool
 }
 
 // NewFileWatcher creates a new file watcher
-func NewFileWatcher(cache *MCPContextCache) (*FileWatcher, error) {
+func NewFileWatcher(cache *MCPContextCache, operations *OperationCache) (*FileWatcher, error) {
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
 		return nil, err
 	}
 	
 	fw := &FileWatcher{
-		watcher:   watcher,
-		cache:     cache,
-		watchDirs: make(map[string]bool),
+		watcher:    watcher,
+		cache:      cache,
+		watchDirs:  make(map[string]bool),
+		operations: operations,
+		trees:      make(map[string]bool),
 	}
 	
 	go fw.watchLoop()
@@ -53,6 +62,72 @@ func (fw *FileWatcher) AddWatch(projectPath string) error {
 	return nil
 }
 
+// WatchTree watches root and every directory below it, except hidden,
+// vendored and policy-denied ones, so any file change in the project
+// reaches the caches. Directories created later are watched as they
+// appear.
+func (fw *FileWatcher) WatchTree(root string) error {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return err
+	}
+	fw.mu.Lock()
+	if fw.trees[root] {
+		fw.mu.Unlock()
+		return nil
+	}
+	// Marked first so directories created during the walk are watched too
+	fw.trees[root] = true
+	fw.mu.Unlock()
+
+	err = fw.AddWatch(root)
+	if err == nil {
+		err = fw.watchBelow(root)
+	}
+	if err != nil {
+		// Left unmarked so a later call tries again
+		fw.mu.Lock()
+		delete(fw.trees, root)
+		fw.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// watchBelow adds the directories under dir
+func (fw *FileWatcher) watchBelow(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() || path == dir {
+			return nil
+		}
+		if fw.skipDir(path) {
+			return filepath.SkipDir
+		}
+		return fw.AddWatch(path)
+	})
+}
+
+// skipDir reports whether a directory's changes are not worth watching
+func (fw *FileWatcher) skipDir(path string) bool {
+	base := filepath.Base(path)
+	if strings.HasPrefix(base, ".") || base == "vendor" || base == "node_modules" {
+		return true
+	}
+	return CurrentPathPolicy().Denied(path) != ""
+}
+
+// inTree reports whether path is below a root watched recursively
+func (fw *FileWatcher) inTree(path string) bool {
+	fw.mu.RLock()
+	defer fw.mu.RUnlock()
+	for root := range fw.trees {
+		if strings.HasPrefix(path, root+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
 // RemoveWatch removes a directory from watching
 func (fw *FileWatcher) RemoveWatch(projectPath string) error {
 	fw.mu.Lock()
@@ -92,6 +167,22 @@ func (fw *FileWatcher) watchLoop() {
 
 // handleEvent processes a single file system event
 func (fw *FileWatcher) handleEvent(event fsnotify.Event) {
+	if strings.HasPrefix(filepath.Base(event.Name), ".") {
+		return
+	}
+	// New directories in a watched tree are watched too
+	if event.Op&fsnotify.Create != 0 && fw.inTree(event.Name) {
+		if info, err := os.Stat(event.Name); err == nil && info.IsDir() && !fw.skipDir(event.Name) {
+			if err := fw.AddWatch(event.Name); err == nil {
+				fw.watchBelow(event.Name)
+			}
+		}
+	}
+	// Listings, the tree and git status change with any file
+	if fw.operations != nil {
+		fw.operations.InvalidateFiles()
+	}
+
 	// Only care about Go files and important config files
 	if !fw.isRelevantFile(event.Name) {
 		return
@@ -123,11 +123,18 @@ func (fs *FilesystemServer) FindInFiles(patterns []string, searchText string) ([
 // GetProjectStructure returns a tree-like structure of the project
 func (fs *FilesystemServer) GetProjectStructure(maxDepth int) (map[string]interface{}, error) {
 	structure := make(map[string]interface{})
+	policy := CurrentPathPolicy()
 	
 	err := filepath.Walk(".", func(path string, info os.FileInfo, err error) error {
 		if err != nil || strings.Contains(path, "/.") {
 			return nil
 		}
+		if policy.Denied(path) != "" {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
 		
 		depth := strings.Count(path, string(filepath.Separator))
 		if maxDepth > 0 && depth > maxDepth {
@@ -73,6 +73,8 @@ type CommandDefinition struct {
 	Safety      SafetyLevel       `json:"safety"`
 	Triggers    []string          `json:"triggers"`
 	Context     map[string]string `json:"context"`
+	DependsOn   []string          `json:"depends_on,omitempty"` // commands that must finish first
+	Timeout     time.Duration     `json:"timeout,omitempty"`    // zero uses defaultOperationTimeout
 }
 
 // SafetyLevel defines command safety levels
@@ -203,27 +205,45 @@ func (ie *IntelligentExecutor) selectCommands(analysis *QueryAnalysis) []*Comman
 	return commands
 }
 
-// executeCommands runs the selected commands safely
+// executeCommands runs the selected commands safely, independent ones
+// concurrently and each within its timeout, after any commands they depend
+// on. A failed command is reported under its name with an _error suffix
+// and the others still run.
 func (ie *IntelligentExecutor) executeCommands(ctx context.Context, commands []*CommandDefinition, query *models.Query) (map[string]interface{}, error) {
-	results := make(map[string]interface{})
-	
+	planner := NewExecutionPlanner(maxParallelOperations)
+	for _, cmd := range append(ie.commandRegistry.List(), commands...) {
+		if cmd == nil {
+			continue
+		}
+		cmd := cmd
+		planner.Register(&Operation{
+			Name:      cmd.Name,
+			DependsOn: cmd.DependsOn,
+			Timeout:   cmd.Timeout,
+			Run: func(ctx context.Context, _ map[string]interface{}) (interface{}, error) {
+				return ie.executeCommand(ctx, cmd)
+			},
+		})
+	}
+	var names []string
 	for _, cmd := range commands {
-		// Create execution context with timeout
-		execCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
-		
-		// Execute command
-		result, err := ie.executeCommand(execCtx, cmd)
-		cancel()
-		
-		if err != nil {
-			// Log error but continue with other commands
-			results[cmd.Name+"_error"] = err.Error()
+		if cmd != nil {
+			names = append(names, cmd.Name)
+		}
+	}
+
+	outcomes, err := planner.Run(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+	results := make(map[string]interface{})
+	for name, outcome := range outcomes {
+		if outcome.Err != nil {
+			results[name+"_error"] = outcome.Err.Error()
 			continue
 		}
-		
-		results[cmd.Name] = result
+		results[name] = outcome.Value
 	}
-	
 	return results, nil
 }
 
@@ -310,6 +330,7 @@ func (ie *IntelligentExecutor) initializeCommands() {
 		Category:    "git",
 		Safety:      SafetyLevelSafe,
 		Triggers:    []string{"git", "status", "changes", "modified"},
+		Timeout:     gitCommandTimeout,
 	})
 	
 	ie.commandRegistry.Register(&CommandDefinition{
@@ -320,6 +341,7 @@ func (ie *IntelligentExecutor) initializeCommands() {
 		Category:    "git",
 		Safety:      SafetyLevelSafe,
 		Triggers:    []string{"commits", "history", "log"},
+		Timeout:     gitCommandTimeout,
 	})
 	
 	// Process commands
@@ -417,6 +439,10 @@ func (ie *IntelligentExecutor) executeDatabaseCommand(ctx context.Context, cmd *
 
 // executeShellCommand safely executes shell commands
 func (ie *IntelligentExecutor) executeShellCommand(ctx context.Context, cmd *CommandDefinition) (interface{}, error) {
+	if err := Authorize(ctx, CapabilityExec, cmd.Command+" "+strings.Join(cmd.Args, " ")); err != nil {
+		return nil, err
+	}
+
 	// Build command
 	execCmd := exec.CommandContext(ctx, cmd.Command, cmd.Args...)
 	
@@ -441,7 +467,7 @@ func (ie *IntelligentExecutor) listGoFiles() (interface{}, error) {
 		return nil, err
 	}
 	
-	files := strings.Split(strings.TrimSpace(string(output)), "\n")
+	files := CurrentPathPolicy().Filter(strings.Split(strings.TrimSpace(string(output)), "\n"))
 	return map[string]interface{}{
 		"files": files,
 		"count": len(files),
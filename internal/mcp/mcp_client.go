@@ -3,6 +3,7 @@ package mcp
 import (
 	"context"
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/yourusername/useq-ai-assistant/models"
@@ -20,6 +21,8 @@ type MCPClient struct {
 	fileWatcher      *FileWatcher
 	usageTracker     *UsageTracker
 	predictiveCache  *PredictiveCache
+	planner          *ExecutionPlanner
+	operationCache   *OperationCache
 }
 
 // NewMCPClient creates a new MCP client
@@ -28,7 +31,8 @@ func NewMCPClient() *MCPClient {
 	classifier := NewQueryClassifier()
 	
 	cache := NewMCPContextCache(5 * time.Minute) // 5 minute TTL
-	watcher, _ := NewFileWatcher(cache)
+	operationCache := NewOperationCache()
+	watcher, _ := NewFileWatcher(cache, operationCache)
 	usageTracker := NewUsageTracker()
 	
 	client := &MCPClient{
@@ -41,7 +45,11 @@ func NewMCPClient() *MCPClient {
 		contextCache:     cache,
 		fileWatcher:      watcher,
 		usageTracker:     usageTracker,
+		planner:          NewExecutionPlanner(maxParallelOperations),
+		operationCache:   operationCache,
 	}
+	client.planner.SetCache(operationCache)
+	client.registerOperations()
 	
 	// Initialize predictive cache
 	client.predictiveCache = NewPredictiveCache(cache, usageTracker, client)
@@ -56,6 +64,11 @@ func (mc *MCPClient) GetQueryClassifier() *QueryClassifier {
 
 // ProcessQuery processes a query through MCP pipeline
 func (mc *MCPClient) ProcessQuery(ctx context.Context, query *models.Query) (*models.MCPContext, error) {
+	// Every tier reads the project; the agent in ctx must be allowed to
+	if err := Authorize(ctx, CapabilityRead, "project context"); err != nil {
+		return nil, err
+	}
+
 	// STEP 1: Classify query into 3 tiers
 	classification, err := mc.queryClassifier.ClassifyQuery(ctx, query)
 	if err != nil {
@@ -78,27 +91,17 @@ func (mc *MCPClient) ProcessQuery(ctx context.Context, query *models.Query) (*mo
 	}
 }
 
-// processTier1Query handles simple queries with direct MCP
+// processTier1Query handles simple queries with direct MCP. Independent
+// operations run concurrently; failed ones leave their data out.
 func (mc *MCPClient) processTier1Query(ctx context.Context, query *models.Query, classification *ClassificationResult) (*models.MCPContext, error) {
-	// Execute filesystem operations directly
+	mc.watchProject(query)
 	operations := classification.RequiredOperations
-	data := make(map[string]interface{})
-	
-	for _, operation := range operations {
-		switch operation {
-		case "filesystem_list":
-			if files, err := mc.filesystemServer.SearchFiles([]string{"*.go"}, ""); err == nil {
-				data["files"] = files
-				data["file_count"] = len(files)
-			}
-		case "filesystem_tree":
-			if structure, err := mc.filesystemServer.GetProjectStructure(3); err == nil {
-				data["project_structure"] = structure
-			}
-		case "system_info":
-			data["system_info"] = mc.getSystemInfo()
-		}
+	plan, err := mc.planner.Plan(operations)
+	if err != nil {
+		return nil, fmt.Errorf("planning MCP operations failed: %w", err)
 	}
+	data := make(map[string]interface{})
+	mergeOperationData(data, plan, mc.planner.Execute(ctx, plan))
 	
 	return &models.MCPContext{
 		RequiresMCP: true,
@@ -188,12 +191,27 @@ func (mc *MCPClient) generateContextHash(context *models.MCPContext) string {
 
 // GetCacheStats returns cache statistics
 func (mc *MCPClient) GetCacheStats() map[string]interface{} {
-	return mc.contextCache.GetStats()
+	stats := mc.contextCache.GetStats()
+	stats["operations"] = mc.operationCache.Stats()
+	return stats
 }
 
 // InvalidateCache manually invalidates cache for a project
 func (mc *MCPClient) InvalidateCache(projectPath string) {
 	mc.contextCache.Invalidate(projectPath)
+	mc.operationCache.Clear()
+}
+
+// watchProject starts watching the query's project, once, so file changes
+// drop the cached operation results read from it. Without a watcher the
+// results only expire.
+func (mc *MCPClient) watchProject(query *models.Query) {
+	if mc.fileWatcher == nil {
+		return
+	}
+	if err := mc.fileWatcher.WatchTree(mc.getProjectPath(query)); err != nil {
+		log.Printf("MCP file watcher unavailable, cached results only expire: %v", err)
+	}
 }
 
 // GetUsageStats returns usage pattern statistics
@@ -0,0 +1,104 @@
+package mcp
+
+import (
+	"sync"
+	"time"
+)
+
+// OperationCache holds the results of recent MCP operations, keyed by
+// operation and arguments, so repeated structural queries skip re-running
+// them. Results expire after their TTL; those read from project files are
+// dropped as soon as the file watcher sees a change.
+type OperationCache struct {
+	mu            sync.Mutex
+	entries       map[string]*cachedOperation
+	hits          int64
+	misses        int64
+	invalidations int64
+}
+
+type cachedOperation struct {
+	value      interface{}
+	expires    time.Time
+	readsFiles bool
+}
+
+// NewOperationCache creates an empty operation cache
+func NewOperationCache() *OperationCache {
+	return &OperationCache{entries: make(map[string]*cachedOperation)}
+}
+
+// operationCacheKey keys the result of an operation run with args
+func operationCacheKey(operation *Operation) string {
+	return operation.Name + "\x00" + operation.Args
+}
+
+// Get returns an unexpired result of the operation
+func (c *OperationCache) Get(operation *Operation) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := operationCacheKey(operation)
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	return entry.value, true
+}
+
+// Set stores the result of an operation for its CacheTTL
+func (c *OperationCache) Set(operation *Operation, value interface{}) {
+	if operation.CacheTTL <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[operationCacheKey(operation)] = &cachedOperation{
+		value:      value,
+		expires:    time.Now().Add(operation.CacheTTL),
+		readsFiles: operation.ReadsFiles,
+	}
+}
+
+// InvalidateFiles drops the results read from project files, after one
+// changed. It returns how many were dropped.
+func (c *OperationCache) InvalidateFiles() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	dropped := 0
+	for key, entry := range c.entries {
+		if entry.readsFiles {
+			delete(c.entries, key)
+			dropped++
+		}
+	}
+	c.invalidations += int64(dropped)
+	return dropped
+}
+
+// Clear drops every cached result
+func (c *OperationCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.invalidations += int64(len(c.entries))
+	c.entries = make(map[string]*cachedOperation)
+}
+
+// Stats reports the cache's size and hit rate
+func (c *OperationCache) Stats() map[string]interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	hitRate := 0.0
+	if lookups := c.hits + c.misses; lookups > 0 {
+		hitRate = float64(c.hits) / float64(lookups)
+	}
+	return map[string]interface{}{
+		"entries":       len(c.entries),
+		"hits":          c.hits,
+		"misses":        c.misses,
+		"hit_rate":      hitRate,
+		"invalidations": c.invalidations,
+	}
+}
@@ -0,0 +1,111 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Timeouts of the operations quicker than defaultOperationTimeout
+const (
+	systemInfoTimeout = 2 * time.Second
+	gitCommandTimeout = 5 * time.Second
+)
+
+// How long results are reused. File changes drop filesystem and git
+// results sooner; git status also changes with commits and staging, which
+// the file watcher does not see.
+const (
+	filesystemCacheTTL = 5 * time.Minute
+	gitStatusCacheTTL  = 10 * time.Second
+)
+
+// registerOperations registers the operations Tier 1 and 2 queries are
+// answered with. Listing, the tree, git status and system info are
+// independent and run concurrently; counting reuses the listing. System
+// info is cheap and always current, so it is never cached.
+func (mc *MCPClient) registerOperations() {
+	mc.planner.Register(&Operation{
+		Name:       "filesystem_list",
+		Args:       "*.go",
+		CacheTTL:   filesystemCacheTTL,
+		ReadsFiles: true,
+		Run: func(ctx context.Context, _ map[string]interface{}) (interface{}, error) {
+			files, err := mc.filesystemServer.SearchFiles([]string{"*.go"}, "")
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{
+				"files":      files,
+				"file_count": len(files),
+			}, nil
+		},
+	})
+	mc.planner.Register(&Operation{
+		Name:       "filesystem_tree",
+		Args:       "depth=3",
+		CacheTTL:   filesystemCacheTTL,
+		ReadsFiles: true,
+		Run: func(ctx context.Context, _ map[string]interface{}) (interface{}, error) {
+			structure, err := mc.filesystemServer.GetProjectStructure(3)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{"project_structure": structure}, nil
+		},
+	})
+	mc.planner.Register(&Operation{
+		Name:       "filesystem_count",
+		DependsOn:  []string{"filesystem_list"},
+		CacheTTL:   filesystemCacheTTL,
+		ReadsFiles: true,
+		Run: func(ctx context.Context, deps map[string]interface{}) (interface{}, error) {
+			listing, _ := deps["filesystem_list"].(map[string]interface{})
+			files, _ := listing["files"].([]map[string]interface{})
+			perDirectory := make(map[string]int)
+			for _, file := range files {
+				if path, ok := file["path"].(string); ok {
+					perDirectory[filepath.Dir(path)]++
+				}
+			}
+			return map[string]interface{}{
+				"file_count":      len(files),
+				"directory_count": perDirectory,
+			}, nil
+		},
+	})
+	mc.planner.Register(&Operation{
+		Name:    "system_info",
+		Timeout: systemInfoTimeout,
+		Run: func(ctx context.Context, _ map[string]interface{}) (interface{}, error) {
+			return map[string]interface{}{"system_info": mc.getSystemInfo()}, nil
+		},
+	})
+	mc.planner.Register(&Operation{
+		Name:       "git_status",
+		Timeout:    gitCommandTimeout,
+		CacheTTL:   gitStatusCacheTTL,
+		ReadsFiles: true,
+		Run: func(ctx context.Context, _ map[string]interface{}) (interface{}, error) {
+			cmd := mc.intelligentExecutor.commandRegistry.Get("git_status")
+			if cmd == nil {
+				return nil, fmt.Errorf("git_status command not registered")
+			}
+			result, err := mc.intelligentExecutor.executeCommand(ctx, cmd)
+			if err != nil {
+				return nil, err
+			}
+			values, _ := result.(map[string]interface{})
+			output, _ := values["output"].(string)
+			var changed []string
+			for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+				if line != "" {
+					changed = append(changed, line)
+				}
+			}
+			return map[string]interface{}{"git_status": changed}, nil
+		},
+	})
+}
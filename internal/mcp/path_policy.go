@@ -0,0 +1,201 @@
+package mcp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrPathDenied is returned when the path policy blocks a file operation
+var ErrPathDenied = errors.New("path is excluded by the path policy")
+
+// DefaultAuditLogPath is where blocked file accesses are recorded
+const DefaultAuditLogPath = "logs/audit.log"
+
+// DefaultDeniedPaths are excluded in every project. A pattern without a slash
+// matches any path element, so "keys/" covers keys/ at any depth.
+var DefaultDeniedPaths = []string{
+	".env", ".env.*", ".netrc", ".npmrc", ".pypirc", "credentials.json",
+	"*.pem", "*.key", "*.p12", "*.pfx", "*.jks", "*.keystore",
+	"id_rsa*", "id_ecdsa*", "id_ed25519*",
+	"keys/", "secrets/", ".ssh/", ".aws/", ".gnupg/",
+}
+
+// DefaultAllowedPaths are templates that look like secrets but are meant to
+// be read
+var DefaultAllowedPaths = []string{".env.example", ".env.sample", ".env.template"}
+
+// PathPolicyConfig is the project's addition to the default deny list
+type PathPolicyConfig struct {
+	Deny     []string `json:"deny"`      // denied in addition to DefaultDeniedPaths
+	Allow    []string `json:"allow"`     // exceptions, checked before deny patterns
+	AuditLog string   `json:"audit_log"` // JSON lines file for blocked accesses; empty disables
+}
+
+// DefaultPathPolicyConfig returns the path policy defaults
+func DefaultPathPolicyConfig() PathPolicyConfig {
+	return PathPolicyConfig{
+		Allow:    DefaultAllowedPaths,
+		AuditLog: DefaultAuditLogPath,
+	}
+}
+
+// PathAccessEvent is one audit log entry
+type PathAccessEvent struct {
+	Time      time.Time `json:"time"`
+	Operation string    `json:"operation"` // read, list, search or write
+	Path      string    `json:"path"`
+	Pattern   string    `json:"pattern"` // the deny pattern that matched
+}
+
+// PathPolicy decides which paths the MCP file tools may read, list or write
+type PathPolicy struct {
+	root     string
+	deny     []string
+	allow    []string
+	auditLog string
+	auditMu  sync.Mutex
+}
+
+// NewPathPolicy creates a policy for paths under root
+func NewPathPolicy(root string, config PathPolicyConfig) *PathPolicy {
+	if absRoot, err := filepath.Abs(root); err == nil {
+		root = absRoot
+	}
+	deny := append(append([]string{}, DefaultDeniedPaths...), config.Deny...)
+	return &PathPolicy{
+		root:     root,
+		deny:     deny,
+		allow:    append([]string{}, config.Allow...),
+		auditLog: config.AuditLog,
+	}
+}
+
+var (
+	pathPolicyMu sync.RWMutex
+	pathPolicy   = NewPathPolicy(".", DefaultPathPolicyConfig())
+)
+
+// SetPathPolicy replaces the policy enforced by every MCP file tool
+func SetPathPolicy(policy *PathPolicy) {
+	pathPolicyMu.Lock()
+	defer pathPolicyMu.Unlock()
+	pathPolicy = policy
+}
+
+// CurrentPathPolicy returns the policy MCP file tools enforce
+func CurrentPathPolicy() *PathPolicy {
+	pathPolicyMu.RLock()
+	defer pathPolicyMu.RUnlock()
+	return pathPolicy
+}
+
+// Denied returns the deny pattern matching path, or "" when it is allowed
+func (p *PathPolicy) Denied(path string) string {
+	elements := p.elements(path)
+	if len(elements) == 0 {
+		return ""
+	}
+	for _, pattern := range p.allow {
+		if matchPathPattern(pattern, elements) {
+			return ""
+		}
+	}
+	for _, pattern := range p.deny {
+		if matchPathPattern(pattern, elements) {
+			return pattern
+		}
+	}
+	return ""
+}
+
+// Check returns ErrPathDenied for a denied path and records the attempt in
+// the audit log
+func (p *PathPolicy) Check(operation, path string) error {
+	pattern := p.Denied(path)
+	if pattern == "" {
+		return nil
+	}
+	p.audit(PathAccessEvent{Time: time.Now(), Operation: operation, Path: path, Pattern: pattern})
+	return fmt.Errorf("%w: %s", ErrPathDenied, path)
+}
+
+// Filter returns the paths that are not denied. Entries dropped from a
+// listing are not audited; only direct requests are.
+func (p *PathPolicy) Filter(paths []string) []string {
+	allowed := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if p.Denied(path) == "" {
+			allowed = append(allowed, path)
+		}
+	}
+	return allowed
+}
+
+// elements splits path, relative to the root, into its elements
+func (p *PathPolicy) elements(path string) []string {
+	if filepath.IsAbs(path) {
+		if rel, err := filepath.Rel(p.root, path); err == nil {
+			path = rel
+		}
+	}
+	path = filepath.ToSlash(filepath.Clean(path))
+	var elements []string
+	for _, element := range strings.Split(path, "/") {
+		if element != "" && element != "." {
+			elements = append(elements, element)
+		}
+	}
+	return elements
+}
+
+// matchPathPattern matches a pattern without a slash against every element,
+// and a pattern with one against every leading part of the path
+func matchPathPattern(pattern string, elements []string) bool {
+	pattern = strings.TrimSuffix(pattern, "/")
+	if pattern == "" {
+		return false
+	}
+	if !strings.Contains(pattern, "/") {
+		for _, element := range elements {
+			if matched, _ := filepath.Match(pattern, element); matched {
+				return true
+			}
+		}
+		return false
+	}
+	for i := range elements {
+		if matched, _ := filepath.Match(pattern, strings.Join(elements[:i+1], "/")); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// audit appends a blocked access to the audit log
+func (p *PathPolicy) audit(event PathAccessEvent) {
+	if p.auditLog == "" {
+		return
+	}
+	line, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+
+	p.auditMu.Lock()
+	defer p.auditMu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(p.auditLog), 0755); err != nil {
+		return
+	}
+	file, err := os.OpenFile(p.auditLog, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	file.Write(append(line, '\n'))
+}
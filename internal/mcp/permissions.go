@@ -0,0 +1,199 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Capability is a class of MCP tool an agent may use
+type Capability string
+
+// Capabilities granted to agents
+const (
+	CapabilityRead  Capability = "read"  // read, list and search files, inspect git and the system
+	CapabilityWrite Capability = "write" // create or modify files
+	CapabilityExec  Capability = "exec"  // run commands such as go test or git
+)
+
+// DefaultPermissionPolicyPath is where the permission policy file is looked up
+const DefaultPermissionPolicyPath = "config/permissions.yaml"
+
+// ErrPermissionDenied is returned when an agent uses a tool it is not granted
+var ErrPermissionDenied = errors.New("agent is not permitted to use this tool")
+
+// AgentPermissions lists what one agent may do. Capabilities in Confirm need
+// the user's approval on every use.
+type AgentPermissions struct {
+	Allow   []Capability `yaml:"allow"`
+	Confirm []Capability `yaml:"confirm"`
+}
+
+// PermissionPolicy maps agents to their permissions. Agents without an entry
+// get Default.
+type PermissionPolicy struct {
+	Default AgentPermissions            `yaml:"default"`
+	Agents  map[string]AgentPermissions `yaml:"agents"`
+}
+
+// DefaultPermissionPolicy returns the built-in roles: search agents read, the
+// manager and system agents may also run the command registry and tests, and
+// coding agents write and execute only after confirmation
+func DefaultPermissionPolicy() PermissionPolicy {
+	readOnly := AgentPermissions{Allow: []Capability{CapabilityRead}}
+	return PermissionPolicy{
+		Default: readOnly,
+		Agents: map[string]AgentPermissions{
+			"manager":        {Allow: []Capability{CapabilityRead, CapabilityExec}},
+			"search":         readOnly,
+			"context_search": readOnly,
+			"system":         {Allow: []Capability{CapabilityRead, CapabilityExec}},
+			"coding": {
+				Allow:   []Capability{CapabilityRead},
+				Confirm: []Capability{CapabilityWrite, CapabilityExec},
+			},
+			"intelligence_coding": {
+				Allow:   []Capability{CapabilityRead},
+				Confirm: []Capability{CapabilityWrite, CapabilityExec},
+			},
+		},
+	}
+}
+
+// LoadPermissionPolicy reads the policy from path, falling back to the
+// defaults when the file does not exist. An agent listed in the file replaces
+// its built-in entry.
+func LoadPermissionPolicy(path string) (PermissionPolicy, error) {
+	policy := DefaultPermissionPolicy()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return policy, nil
+		}
+		return policy, fmt.Errorf("failed to read permission policy: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, &policy); err != nil {
+		return DefaultPermissionPolicy(), fmt.Errorf("failed to parse permission policy: %w", err)
+	}
+	return policy, nil
+}
+
+// PermissionRequest describes a tool use that needs the user's approval
+type PermissionRequest struct {
+	Agent      string
+	Capability Capability
+	Detail     string // the file or command involved
+}
+
+// Confirmer asks the user to approve a tool use
+type Confirmer func(ctx context.Context, request PermissionRequest) bool
+
+// Permissions enforces a permission policy
+type Permissions struct {
+	policy PermissionPolicy
+
+	mu      sync.RWMutex
+	confirm Confirmer
+}
+
+// NewPermissions creates the enforcer for policy. Without a confirmer,
+// capabilities that need confirmation are denied.
+func NewPermissions(policy PermissionPolicy) *Permissions {
+	return &Permissions{policy: policy}
+}
+
+// SetConfirmer sets how the user is asked to approve tool uses
+func (p *Permissions) SetConfirmer(confirm Confirmer) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.confirm = confirm
+}
+
+// For returns the permissions of agent
+func (p *Permissions) For(agent string) AgentPermissions {
+	if permissions, ok := p.policy.Agents[agent]; ok {
+		return permissions
+	}
+	return p.policy.Default
+}
+
+// Permits reports whether agent may use capability at all, possibly after
+// confirmation
+func (p *Permissions) Permits(agent string, capability Capability) bool {
+	permissions := p.For(agent)
+	return hasCapability(permissions.Allow, capability) || hasCapability(permissions.Confirm, capability)
+}
+
+// Authorize returns nil when agent may use capability now, asking the user
+// first when the policy requires it
+func (p *Permissions) Authorize(ctx context.Context, agent string, capability Capability, detail string) error {
+	permissions := p.For(agent)
+	if hasCapability(permissions.Allow, capability) {
+		return nil
+	}
+	if hasCapability(permissions.Confirm, capability) {
+		p.mu.RLock()
+		confirm := p.confirm
+		p.mu.RUnlock()
+		if confirm != nil && confirm(ctx, PermissionRequest{Agent: agent, Capability: capability, Detail: detail}) {
+			return nil
+		}
+		return fmt.Errorf("%w: %s %s declined for %s", ErrPermissionDenied, capability, detail, agent)
+	}
+	return fmt.Errorf("%w: %s may not %s %s", ErrPermissionDenied, agent, capability, detail)
+}
+
+func hasCapability(capabilities []Capability, capability Capability) bool {
+	for _, c := range capabilities {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// agentScope is the agent identity carried by a context
+type agentScope struct {
+	agent       string
+	permissions *Permissions
+}
+
+type agentScopeKey struct{}
+
+// WithAgent returns a context in which MCP tools act for agent under
+// permissions
+func WithAgent(ctx context.Context, agent string, permissions *Permissions) context.Context {
+	return context.WithValue(ctx, agentScopeKey{}, agentScope{agent: agent, permissions: permissions})
+}
+
+// AgentFromContext returns the agent MCP tools act for, or "" for the user
+func AgentFromContext(ctx context.Context) string {
+	scope, _ := ctx.Value(agentScopeKey{}).(agentScope)
+	return scope.agent
+}
+
+// Authorize checks the agent in ctx may use capability. Calls made outside an
+// agent scope come from the user and are always allowed.
+func Authorize(ctx context.Context, capability Capability, detail string) error {
+	scope, ok := ctx.Value(agentScopeKey{}).(agentScope)
+	if !ok || scope.permissions == nil {
+		return nil
+	}
+	return scope.permissions.Authorize(ctx, scope.agent, capability, detail)
+}
+
+// Permitted reports whether the agent in ctx may use capability at all, so
+// tools it can never use need not be offered
+func Permitted(ctx context.Context, capability Capability) bool {
+	scope, ok := ctx.Value(agentScopeKey{}).(agentScope)
+	if !ok || scope.permissions == nil {
+		return true
+	}
+	return scope.permissions.Permits(scope.agent, capability)
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/yourusername/useq-ai-assistant/internal/llm"
 	"github.com/yourusername/useq-ai-assistant/models"
 )
 
@@ -24,6 +25,7 @@ type QueryClassifier struct {
 	mediumPatterns  []*ClassificationPattern
 	complexPatterns []*ClassificationPattern
 	stats           *ClassificationStats
+	tokenizer       llm.Tokenizer // counts input tokens for cost predictions
 }
 
 // ClassificationPattern represents a pattern for query classification
@@ -46,6 +48,7 @@ type ClassificationResult struct {
 	SkipLLM            bool                   `json:"skip_llm"`
 	ProcessingStrategy  ProcessingStrategy     `json:"processing_strategy"`
 	Reasoning          string                 `json:"reasoning"`
+	RetrievalMode       models.RetrievalMode   `json:"retrieval_mode"` // chunk, file or package granularity
 }
 
 // ProcessingStrategy defines how to process the query
@@ -74,14 +77,26 @@ func NewQueryClassifier() *QueryClassifier {
 			TierBreakdown: make(map[QueryTier]int),
 			LastUpdated:   time.Now(),
 		},
+		tokenizer: llm.TokenizerFor(""),
 	}
 	
 	classifier.initializePatterns()
 	return classifier
 }
 
-// ClassifyQuery performs 3-tier classification with decision tree
+// ClassifyQuery performs 3-tier classification with decision tree, and
+// picks the granularity to retrieve at from the query's phrasing
 func (qc *QueryClassifier) ClassifyQuery(ctx context.Context, query *models.Query) (*ClassificationResult, error) {
+	result, err := qc.classifyTier(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	result.RetrievalMode = ClassifyRetrievalMode(strings.ToLower(query.UserInput))
+	return result, nil
+}
+
+// classifyTier picks the tier a query is processed at
+func (qc *QueryClassifier) classifyTier(ctx context.Context, query *models.Query) (*ClassificationResult, error) {
 	input := strings.ToLower(strings.TrimSpace(query.UserInput))
 	
 	// DECISION TREE: Check in order of specificity
@@ -379,11 +394,17 @@ func (qc *QueryClassifier) getComplexOperations(patternName string) []string {
 	}
 }
 
+// SetTokenizer makes cost predictions count tokens for the model that will
+// answer
+func (qc *QueryClassifier) SetTokenizer(tokenizer llm.Tokenizer) {
+	qc.tokenizer = tokenizer
+}
+
 // estimateLLMCost estimates the cost for LLM processing
 func (qc *QueryClassifier) estimateLLMCost(input string) float64 {
 	// Estimate based on input length and expected response
-	inputTokens := len(input) / 4  // Rough token estimation
-	outputTokens := 500            // Average response length
+	inputTokens := qc.tokenizer.Count(input)
+	outputTokens := 500 // Average response length
 	
 	// OpenAI GPT-4 pricing: $0.01 input, $0.03 output per 1K tokens
 	inputCost := float64(inputTokens) / 1000.0 * 0.01
@@ -0,0 +1,31 @@
+package mcp
+
+import (
+	"regexp"
+
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// Phrasings that ask for a whole file or package rather than the code in
+// it. Listings such as "what files are in internal/app" do not match; the
+// directory operations answer those.
+var (
+	filePhrasing    = regexp.MustCompile(`\b(which|what)\s+(source\s+)?files?\s+(` + responsibilityVerbs + `)\b|\bin\s+(which|what)\s+file\b|\bwhere\s+(is|are)\s+.+\s+(handled|implemented|defined|managed)\b`)
+	packagePhrasing = regexp.MustCompile(`\b(which|what)\s+(packages?|modules?|components?)\s+(` + responsibilityVerbs + `)\b|\bin\s+(which|what)\s+(package|module)\b`)
+)
+
+// responsibilityVerbs say what a file or package is for
+const responsibilityVerbs = `handles?|implements?|contains?|defines?|deals?|owns?|manages?|talks?|is responsible|are responsible|should i`
+
+// ClassifyRetrievalMode picks the granularity a query asks about from its
+// phrasing: "which package ..." wants packages, "which file ..." or "where
+// is X handled" wants files, anything else code chunks
+func ClassifyRetrievalMode(input string) models.RetrievalMode {
+	switch {
+	case packagePhrasing.MatchString(input):
+		return models.RetrievalPackage
+	case filePhrasing.MatchString(input):
+		return models.RetrievalFile
+	}
+	return models.RetrievalChunk
+}
@@ -249,8 +249,8 @@ func (tp *TierProcessor) executeFileList(ctx context.Context, query *models.Quer
 		return "", fmt.Errorf("file listing failed: %w", err)
 	}
 	
-	files := strings.Split(strings.TrimSpace(string(output)), "\n")
-	if len(files) == 1 && files[0] == "" {
+	files := CurrentPathPolicy().Filter(strings.Split(strings.TrimSpace(string(output)), "\n"))
+	if len(files) == 0 || len(files) == 1 && files[0] == "" {
 		return "No files found matching the criteria.", nil
 	}
 	
@@ -342,6 +342,10 @@ func (tp *TierProcessor) executeFileRead(ctx context.Context, query *models.Quer
 		return "Please specify a filename to read.", nil
 	}
 	
+	if err := CurrentPathPolicy().Check("read", filename); err != nil {
+		return fmt.Sprintf("🚫 '%s' is excluded by the path policy and cannot be read.", filename), nil
+	}
+
 	// Read file content
 	content, err := os.ReadFile(filename)
 	if err != nil {
@@ -373,7 +377,7 @@ func (tp *TierProcessor) executeFilesystemSearch(ctx context.Context, query *mod
 	for _, term := range searchTerms {
 		cmd := exec.CommandContext(ctx, "find", ".", "-name", "*.go", "-exec", "grep", "-l", term, "{}", ";")
 		if output, err := cmd.Output(); err == nil {
-			files := strings.Split(strings.TrimSpace(string(output)), "\n")
+			files := CurrentPathPolicy().Filter(strings.Split(strings.TrimSpace(string(output)), "\n"))
 			for _, file := range files {
 				if file != "" && !tp.contains(results, file) {
 					results = append(results, file)
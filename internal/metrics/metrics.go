@@ -0,0 +1,249 @@
+// Package metrics keeps process-wide counters and sliding windows that any
+// goroutine may update, so agents serving concurrent sessions record their
+// activity without locks of their own.
+package metrics
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultWindowSize is how many observations a window keeps
+const DefaultWindowSize = 100
+
+// Counter is a monotonically increasing count
+type Counter struct {
+	value atomic.Int64
+}
+
+// Inc adds one
+func (c *Counter) Inc() {
+	c.value.Add(1)
+}
+
+// Add adds n
+func (c *Counter) Add(n int64) {
+	c.value.Add(n)
+}
+
+// Value returns the count
+func (c *Counter) Value() int64 {
+	return c.value.Load()
+}
+
+// Sum is a running float total, such as a cost in USD
+type Sum struct {
+	bits atomic.Uint64
+}
+
+// Add adds v
+func (s *Sum) Add(v float64) {
+	for {
+		old := s.bits.Load()
+		if s.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+// Value returns the total
+func (s *Sum) Value() float64 {
+	return math.Float64frombits(s.bits.Load())
+}
+
+// Timestamp is the time of the latest event
+type Timestamp struct {
+	nanos atomic.Int64
+}
+
+// Set records t, unless a later time is already recorded
+func (ts *Timestamp) Set(t time.Time) {
+	n := t.UnixNano()
+	for {
+		old := ts.nanos.Load()
+		if old >= n || ts.nanos.CompareAndSwap(old, n) {
+			return
+		}
+	}
+}
+
+// Value returns the recorded time, or the zero time
+func (ts *Timestamp) Value() time.Time {
+	n := ts.nanos.Load()
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
+
+// Window keeps the latest observations of a value, so its mean follows
+// recent behavior rather than the whole life of the process
+type Window struct {
+	mu      sync.Mutex
+	samples []float64
+	next    int
+	full    bool
+}
+
+// NewWindow creates a window over the latest size observations
+func NewWindow(size int) *Window {
+	if size <= 0 {
+		size = DefaultWindowSize
+	}
+	return &Window{samples: make([]float64, size)}
+}
+
+// Observe records a value
+func (w *Window) Observe(v float64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.samples[w.next] = v
+	w.next = (w.next + 1) % len(w.samples)
+	if w.next == 0 {
+		w.full = true
+	}
+}
+
+// ObserveDuration records a duration in seconds
+func (w *Window) ObserveDuration(d time.Duration) {
+	w.Observe(d.Seconds())
+}
+
+// Count returns how many observations the window holds
+func (w *Window) Count() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.count()
+}
+
+// Mean returns the mean of the observations held, or 0
+func (w *Window) Mean() float64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	n := w.count()
+	if n == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, v := range w.samples[:n] {
+		total += v
+	}
+	return total / float64(n)
+}
+
+// MeanDuration returns the mean of durations observed in seconds
+func (w *Window) MeanDuration() time.Duration {
+	return time.Duration(w.Mean() * float64(time.Second))
+}
+
+func (w *Window) count() int {
+	if w.full {
+		return len(w.samples)
+	}
+	return w.next
+}
+
+// Registry names the metrics of a process. Asking for a name twice returns
+// the same metric, so every instance of an agent adds to one set of counts.
+type Registry struct {
+	mu         sync.Mutex
+	counters   map[string]*Counter
+	sums       map[string]*Sum
+	timestamps map[string]*Timestamp
+	windows    map[string]*Window
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		counters:   make(map[string]*Counter),
+		sums:       make(map[string]*Sum),
+		timestamps: make(map[string]*Timestamp),
+		windows:    make(map[string]*Window),
+	}
+}
+
+// Default is the registry of the running process
+var Default = NewRegistry()
+
+// Counter returns the counter called name, creating it on first use
+func (r *Registry) Counter(name string) *Counter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.counters[name]
+	if !ok {
+		c = &Counter{}
+		r.counters[name] = c
+	}
+	return c
+}
+
+// Sum returns the float total called name, creating it on first use
+func (r *Registry) Sum(name string) *Sum {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.sums[name]
+	if !ok {
+		s = &Sum{}
+		r.sums[name] = s
+	}
+	return s
+}
+
+// Timestamp returns the timestamp called name, creating it on first use
+func (r *Registry) Timestamp(name string) *Timestamp {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ts, ok := r.timestamps[name]
+	if !ok {
+		ts = &Timestamp{}
+		r.timestamps[name] = ts
+	}
+	return ts
+}
+
+// Window returns the window called name, creating it over the latest
+// DefaultWindowSize observations on first use
+func (r *Registry) Window(name string) *Window {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	w, ok := r.windows[name]
+	if !ok {
+		w = NewWindow(DefaultWindowSize)
+		r.windows[name] = w
+	}
+	return w
+}
+
+// Sample is one metric's current value; a window reports its mean
+type Sample struct {
+	Name  string
+	Value float64
+}
+
+// Snapshot returns the current value of every counter, sum and window,
+// sorted by name
+func (r *Registry) Snapshot() []Sample {
+	r.mu.Lock()
+	samples := make([]Sample, 0, len(r.counters)+len(r.sums)+len(r.windows))
+	for name, c := range r.counters {
+		samples = append(samples, Sample{Name: name, Value: float64(c.Value())})
+	}
+	for name, s := range r.sums {
+		samples = append(samples, Sample{Name: name, Value: s.Value()})
+	}
+	windows := make(map[string]*Window, len(r.windows))
+	for name, w := range r.windows {
+		windows[name] = w
+	}
+	r.mu.Unlock()
+
+	for name, w := range windows {
+		samples = append(samples, Sample{Name: name, Value: w.Mean()})
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i].Name < samples[j].Name })
+	return samples
+}
@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestRegistryConcurrent updates shared metrics from many goroutines while
+// snapshots are taken; run with -race
+func TestRegistryConcurrent(t *testing.T) {
+	const workers, rounds = 8, 500
+	registry := NewRegistry()
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < rounds; j++ {
+				registry.Counter("queries").Inc()
+				registry.Sum("cost").Add(0.5)
+				registry.Timestamp("last_query").Set(time.Now())
+				registry.Window("latency").ObserveDuration(time.Millisecond)
+				registry.Snapshot()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := registry.Counter("queries").Value(); got != workers*rounds {
+		t.Errorf("counter = %d, want %d", got, workers*rounds)
+	}
+	if got := registry.Sum("cost").Value(); got != workers*rounds*0.5 {
+		t.Errorf("sum = %v, want %v", got, workers*rounds*0.5)
+	}
+	if got := registry.Window("latency").Count(); got != DefaultWindowSize {
+		t.Errorf("window holds %d observations, want %d", got, DefaultWindowSize)
+	}
+	if registry.Timestamp("last_query").Value().IsZero() {
+		t.Error("timestamp was never set")
+	}
+}
+
+func TestWindowMean(t *testing.T) {
+	window := NewWindow(3)
+	if window.Mean() != 0 {
+		t.Errorf("empty window mean = %v, want 0", window.Mean())
+	}
+	for _, v := range []float64{10, 1, 2, 3} {
+		window.Observe(v)
+	}
+	if got := window.Mean(); got != 2 {
+		t.Errorf("mean = %v, want 2 once the first observation is dropped", got)
+	}
+}
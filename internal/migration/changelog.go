@@ -0,0 +1,170 @@
+package migration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/internal/calllog"
+	"github.com/yourusername/useq-ai-assistant/internal/deps"
+)
+
+// changelogNames are the files modules keep release notes in
+var changelogNames = []string{"CHANGELOG.md", "CHANGES.md", "HISTORY.md", "UPGRADING.md", "MIGRATION.md", "CHANGELOG"}
+
+// versionHeadingPattern matches a changelog heading naming a version:
+// "## v5.0.0", "## [1.4.2] - 2023-01-01", "# Version 2.1"
+var versionHeadingPattern = regexp.MustCompile(`(?im)^#{1,3}\s*\[?(?:version\s+)?(v?\d+\.\d+(?:\.\d+)?)\]?.*$`)
+
+// githubModulePattern extracts owner and repository from a GitHub module path
+var githubModulePattern = regexp.MustCompile(`^github\.com/([^/]+)/([^/]+)`)
+
+// Changelog is the release notes between two versions of a module
+type Changelog struct {
+	Source string `json:"source"` // file in the module cache or URL
+	Text   string `json:"text"`
+}
+
+// LocalChangelog reads release notes from the newest downloaded version of
+// the module in the migration range, so nothing is fetched when the target
+// is already in the module cache
+func LocalChangelog(target *Target, cfg Config) *Changelog {
+	versions, _ := deps.CachedVersions(target.NewImportPath())
+	if target.NewImportPath() != target.Module {
+		current, _ := deps.CachedVersions(target.Module)
+		versions = append(versions, current...)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return compareParts(versionParts(versions[i]), versionParts(versions[j]), 3) > 0
+	})
+
+	for _, version := range versions {
+		if !InRange(version, "", target.To) {
+			continue
+		}
+		dir := deps.ModuleDir(ImportPath(target.Module, version), version)
+		if dir == "" {
+			continue
+		}
+		for _, name := range changelogNames {
+			data, err := os.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				continue
+			}
+			if text := ChangelogSection(string(data), target.From, target.To, cfg.MaxChangelogChars); text != "" {
+				return &Changelog{Source: filepath.Join(dir, name), Text: text}
+			}
+		}
+	}
+	return nil
+}
+
+// githubRelease is the part of a GitHub release the changelog uses
+type githubRelease struct {
+	TagName string `json:"tag_name"`
+	Name    string `json:"name"`
+	Body    string `json:"body"`
+}
+
+// FetchChangelog reads the module's release notes between the versions from
+// GitHub: its releases, or its CHANGELOG.md when it publishes none. Only
+// modules hosted on GitHub are supported.
+func FetchChangelog(ctx context.Context, target *Target, cfg Config) (*Changelog, error) {
+	match := githubModulePattern.FindStringSubmatch(target.Module)
+	if match == nil {
+		return nil, fmt.Errorf("no changelog source for %s", target.Module)
+	}
+	owner, repo := match[1], match[2]
+	if cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+		defer cancel()
+	}
+
+	releasesURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases?per_page=100", owner, repo)
+	if data, err := httpGet(ctx, releasesURL); err == nil {
+		var releases []githubRelease
+		if err := json.Unmarshal(data, &releases); err == nil {
+			var b strings.Builder
+			// Releases come newest first; notes read better oldest first
+			for i := len(releases) - 1; i >= 0; i-- {
+				release := releases[i]
+				if !InRange(release.TagName, target.From, target.To) || strings.TrimSpace(release.Body) == "" {
+					continue
+				}
+				fmt.Fprintf(&b, "## %s\n%s\n\n", release.TagName, strings.TrimSpace(release.Body))
+			}
+			if b.Len() > 0 {
+				return &Changelog{Source: releasesURL, Text: truncate(b.String(), cfg.MaxChangelogChars)}, nil
+			}
+		}
+	}
+
+	for _, branch := range []string{"main", "master"} {
+		fileURL := fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s/CHANGELOG.md", owner, repo, branch)
+		data, err := httpGet(ctx, fileURL)
+		if err != nil {
+			continue
+		}
+		if text := ChangelogSection(string(data), target.From, target.To, cfg.MaxChangelogChars); text != "" {
+			return &Changelog{Source: fileURL, Text: text}, nil
+		}
+	}
+	return nil, fmt.Errorf("no release notes found for %s between %s and %s", target.Module, target.From, target.To)
+}
+
+// ChangelogSection keeps the sections of a changelog whose heading names a
+// version after from and up to to, truncated to maxChars
+func ChangelogSection(text, from, to string, maxChars int) string {
+	headings := versionHeadingPattern.FindAllStringSubmatchIndex(text, -1)
+	var b strings.Builder
+	for i, heading := range headings {
+		version := text[heading[2]:heading[3]]
+		if !InRange(version, from, to) {
+			continue
+		}
+		end := len(text)
+		if i+1 < len(headings) {
+			end = headings[i+1][0]
+		}
+		b.WriteString(strings.TrimSpace(text[heading[0]:end]) + "\n\n")
+	}
+	return truncate(strings.TrimSpace(b.String()), maxChars)
+}
+
+// changelogClient fetches release notes, recording each fetch in the
+// external call log
+var changelogClient = &http.Client{Transport: calllog.Transport(calllog.ServiceChangelog, "", nil)}
+
+// httpGet fetches a URL, failing on any status but 200
+func httpGet(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "useq-ai-assistant")
+	resp, err := changelogClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: %s", url, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+}
+
+// truncate shortens text to maxChars, marking the cut; 0 keeps it whole
+func truncate(text string, maxChars int) string {
+	if maxChars <= 0 || len(text) <= maxChars {
+		return text
+	}
+	return text[:maxChars] + "\n… (truncated)"
+}
@@ -0,0 +1,290 @@
+// Package migration recognizes dependency and language migration queries
+// ("upgrade chi from v4 to v5", "migrate to generics") and gathers what a
+// migration plan needs: the versions involved, where the project uses the
+// module, which modules require it and what changed in between.
+package migration
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kind is what a migration changes
+type Kind string
+
+const (
+	KindDependency Kind = "dependency" // a required module's version
+	KindLanguage   Kind = "language"   // the Go version or a language feature
+)
+
+// Config controls migration planning
+type Config struct {
+	Enabled           bool          `json:"enabled"`
+	FetchChangelogs   bool          `json:"fetch_changelogs"`    // read release notes from the web when the module cache has none
+	MaxUsages         int           `json:"max_usages"`          // usages listed in the prompt
+	MaxChangelogChars int           `json:"max_changelog_chars"` // changelog text kept for the prompt
+	Timeout           time.Duration `json:"timeout"`             // for go mod graph and changelog requests
+}
+
+// DefaultConfig returns migration defaults
+func DefaultConfig() Config {
+	return Config{
+		Enabled:           true,
+		FetchChangelogs:   true,
+		MaxUsages:         60,
+		MaxChangelogChars: 12000,
+		Timeout:           15 * time.Second,
+	}
+}
+
+// Target is the migration a query asks for
+type Target struct {
+	Kind    Kind   `json:"kind"`
+	Module  string `json:"module,omitempty"`  // module path as required in go.mod
+	From    string `json:"from,omitempty"`    // current version; go.mod's unless the query names one
+	To      string `json:"to,omitempty"`      // target version, possibly just a major version such as v5
+	Feature string `json:"feature,omitempty"` // language feature, e.g. generics
+}
+
+// Label describes the migration, e.g. "github.com/go-chi/chi v4.1.2 → v5"
+func (t *Target) Label() string {
+	name := t.Module
+	if t.Kind == KindLanguage {
+		name = t.Feature
+	}
+	switch {
+	case t.From != "" && t.To != "":
+		return fmt.Sprintf("%s %s → %s", name, t.From, t.To)
+	case t.To != "":
+		return fmt.Sprintf("%s → %s", name, t.To)
+	default:
+		return name
+	}
+}
+
+// NewImportPath is the import path the module's packages have at the target
+// version, which changes across major versions from v2 on
+func (t *Target) NewImportPath() string {
+	if t.Kind != KindDependency || t.To == "" {
+		return t.Module
+	}
+	return ImportPath(t.Module, t.To)
+}
+
+// migrationVerbPattern marks queries asking to move to another version
+var migrationVerbPattern = regexp.MustCompile(`(?i)\b(upgrade|upgrading|migrate|migrating|migration|bump|bumping|update|updating|port|porting|move|moving|switch|switching)\b`)
+
+// upgradeVerbPattern marks queries that are about a migration even without
+// a target version; "update" and "move" alone usually are not
+var upgradeVerbPattern = regexp.MustCompile(`(?i)\b(upgrade|upgrading|migrate|migrating|migration|bump|bumping)\b`)
+
+// versionRangePattern finds "from v4 to v5" and "from 1.2 to 1.4"
+var versionRangePattern = regexp.MustCompile(`(?i)\bfrom\s+(v?\d+(?:\.\d+){0,2})\s+to\s+(v?\d+(?:\.\d+){0,2})\b`)
+
+// toVersionPattern finds "to v5" and "to 1.4.0"
+var toVersionPattern = regexp.MustCompile(`(?i)\bto\s+(v?\d+(?:\.\d+){0,2})\b`)
+
+// goVersionPattern finds "go 1.22" and "go1.22"
+var goVersionPattern = regexp.MustCompile(`(?i)\bgo\s?(1\.\d+)\b`)
+
+// majorSuffixPattern matches a /vN major version suffix of a module path
+var majorSuffixPattern = regexp.MustCompile(`/v(\d+)$`)
+
+// gopkgSuffixPattern matches the .vN version of a gopkg.in path
+var gopkgSuffixPattern = regexp.MustCompile(`\.v(\d+)$`)
+
+// languageFeatures maps the features migrations are asked for to the Go
+// version that introduced them
+var languageFeatures = map[string]string{
+	"generics":        "1.18",
+	"type parameters": "1.18",
+	"slog":            "1.21",
+	"log/slog":        "1.21",
+	"iterators":       "1.23",
+	"range over func": "1.23",
+	"error wrapping":  "1.13",
+	"errors.is":       "1.13",
+	"go:embed":        "1.16",
+}
+
+// Detect returns the migration a query asks for, or nil. Modules are
+// matched against the project's requirements (module path to version) by
+// path or by their last path element, e.g. "chi" for github.com/go-chi/chi.
+func Detect(query string, requirements map[string]string) *Target {
+	if !migrationVerbPattern.MatchString(query) {
+		return nil
+	}
+
+	from, to := "", ""
+	if match := versionRangePattern.FindStringSubmatch(query); match != nil {
+		from, to = normalizeVersion(match[1]), normalizeVersion(match[2])
+	} else if match := toVersionPattern.FindStringSubmatch(query); match != nil {
+		to = normalizeVersion(match[1])
+	}
+
+	// "update the chi handler" is an edit, "upgrade chi" or "update chi to
+	// v5" a migration
+	explicit := to != "" || upgradeVerbPattern.MatchString(query)
+
+	if module := mentionedModule(query, requirements); module != "" && explicit {
+		current := requirements[module]
+		if _, replaced, ok := strings.Cut(current, "@"); ok {
+			current = replaced
+		}
+		if from == "" {
+			from = current
+		}
+		return &Target{Kind: KindDependency, Module: module, From: from, To: to}
+	}
+
+	lowered := strings.ToLower(query)
+	if match := goVersionPattern.FindStringSubmatch(query); match != nil && (explicit || strings.Contains(lowered, "to go")) {
+		return &Target{Kind: KindLanguage, Feature: "go", To: match[1]}
+	}
+	features := make([]string, 0, len(languageFeatures))
+	for feature := range languageFeatures {
+		features = append(features, feature)
+	}
+	// Longer names first, so "log/slog" wins over "slog"
+	sort.Slice(features, func(i, j int) bool { return len(features[i]) > len(features[j]) })
+	for _, feature := range features {
+		if containsWord(lowered, feature) && (explicit || containsWord(lowered, "to "+feature)) {
+			return &Target{Kind: KindLanguage, Feature: feature, To: languageFeatures[feature]}
+		}
+	}
+	return nil
+}
+
+// ImportPath is a module's import path at a version: major versions from
+// v2 on carry a /vN suffix (.vN for gopkg.in)
+func ImportPath(module, version string) string {
+	major := MajorVersion(version)
+	if strings.HasPrefix(module, "gopkg.in/") {
+		if major > 0 && gopkgSuffixPattern.MatchString(module) {
+			return gopkgSuffixPattern.ReplaceAllString(module, fmt.Sprintf(".v%d", major))
+		}
+		return module
+	}
+	base := majorSuffixPattern.ReplaceAllString(module, "")
+	if major < 2 {
+		return base
+	}
+	return fmt.Sprintf("%s/v%d", base, major)
+}
+
+// MajorVersion returns the major version of v5, v5.0.3 or 1.2, or -1
+func MajorVersion(version string) int {
+	parts := versionParts(version)
+	if len(parts) == 0 {
+		return -1
+	}
+	return parts[0]
+}
+
+// InRange reports whether version is after from and not after to. A to
+// with fewer components is an upper bound on those only: v5 admits v5.2.1.
+func InRange(version, from, to string) bool {
+	parts := versionParts(version)
+	if len(parts) == 0 {
+		return false
+	}
+	if lower := versionParts(from); len(lower) > 0 && compareParts(parts, lower, 3) <= 0 {
+		return false
+	}
+	if upper := versionParts(to); len(upper) > 0 && compareParts(parts, upper, len(upper)) > 0 {
+		return false
+	}
+	return true
+}
+
+// versionParts parses the numeric components of a version, ignoring a v
+// prefix and any pre-release or build suffix
+func versionParts(version string) []int {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if end := strings.IndexAny(version, "-+ "); end >= 0 {
+		version = version[:end]
+	}
+	var parts []int
+	for _, field := range strings.Split(version, ".") {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			break
+		}
+		parts = append(parts, n)
+	}
+	return parts
+}
+
+// compareParts compares the first n components of two versions, treating
+// missing components as 0
+func compareParts(a, b []int, n int) int {
+	for i := 0; i < n; i++ {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// normalizeVersion writes versions of modules the way go.mod does: v5, v1.2.0
+func normalizeVersion(version string) string {
+	return "v" + strings.TrimPrefix(strings.ToLower(version), "v")
+}
+
+// mentionedModule returns the required module the query names, preferring
+// the longest path when several match
+func mentionedModule(query string, requirements map[string]string) string {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return r == ' ' || r == ',' || r == '?' || r == '"' || r == '\'' || r == '`' || r == '(' || r == ')'
+	})
+	best := ""
+	for module := range requirements {
+		base := majorSuffixPattern.ReplaceAllString(strings.ToLower(module), "")
+		name := base[strings.LastIndex(base, "/")+1:]
+		name = gopkgSuffixPattern.ReplaceAllString(name, "")
+		for _, word := range words {
+			word = majorSuffixPattern.ReplaceAllString(strings.TrimSuffix(word, "."), "")
+			if word == base || (len(name) > 1 && word == name) {
+				if len(module) > len(best) {
+					best = module
+				}
+			}
+		}
+	}
+	return best
+}
+
+// containsWord reports whether lowered text contains phrase as whole words
+func containsWord(text, phrase string) bool {
+	for start := 0; ; {
+		i := strings.Index(text[start:], phrase)
+		if i < 0 {
+			return false
+		}
+		i += start
+		end := i + len(phrase)
+		if (i == 0 || !isWordByte(text[i-1])) && (end == len(text) || !isWordByte(text[end])) {
+			return true
+		}
+		start = i + 1
+	}
+}
+
+// isWordByte reports whether b can be part of a word
+func isWordByte(b byte) bool {
+	return b == '_' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9'
+}
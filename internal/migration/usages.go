@@ -0,0 +1,302 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/internal/edits"
+	"github.com/yourusername/useq-ai-assistant/internal/sandbox"
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// Usage is one place the project uses a module: its import or a reference
+// through the import's name
+type Usage struct {
+	File       string `json:"file"` // relative to the project root
+	Line       int    `json:"line"`
+	ImportPath string `json:"import_path"`
+	Symbol     string `json:"symbol,omitempty"` // e.g. chi.NewRouter; empty for the import itself
+}
+
+// FindUsages parses the project's Go files and returns every import of a
+// package under modulePrefix (any major version of it) and every selector
+// expression through such an import, in file and line order
+func FindUsages(root, modulePrefix string) ([]Usage, error) {
+	base := majorSuffixPattern.ReplaceAllString(modulePrefix, "")
+	var usages []Usage
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != root && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) != ".go" {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			rel = path
+		}
+		usages = append(usages, fileUsages(path, rel, base)...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", root, err)
+	}
+	return usages, nil
+}
+
+// fileUsages returns the usages of modules under base in one file
+func fileUsages(path, rel, base string) []Usage {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+
+	// Import name to import path, for the imports under base
+	names := make(map[string]string)
+	var usages []Usage
+	for _, spec := range file.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil || !underModule(importPath, base) {
+			continue
+		}
+		name := packageName(importPath)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		if name != "_" && name != "." {
+			names[name] = importPath
+		}
+		usages = append(usages, Usage{File: rel, Line: fset.Position(spec.Pos()).Line, ImportPath: importPath})
+	}
+	if len(names) == 0 {
+		return usages
+	}
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		ident, ok := sel.X.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		if importPath, ok := names[ident.Name]; ok {
+			usages = append(usages, Usage{
+				File:       rel,
+				Line:       fset.Position(sel.Pos()).Line,
+				ImportPath: importPath,
+				Symbol:     ident.Name + "." + sel.Sel.Name,
+			})
+		}
+		return true
+	})
+	return usages
+}
+
+// SymbolCounts counts how often each symbol of the module is used, most
+// used first
+func SymbolCounts(usages []Usage) []string {
+	counts := make(map[string]int)
+	for _, u := range usages {
+		if u.Symbol != "" {
+			counts[u.Symbol]++
+		}
+	}
+	symbols := make([]string, 0, len(counts))
+	for symbol := range counts {
+		symbols = append(symbols, symbol)
+	}
+	sort.Slice(symbols, func(i, j int) bool {
+		if counts[symbols[i]] != counts[symbols[j]] {
+			return counts[symbols[i]] > counts[symbols[j]]
+		}
+		return symbols[i] < symbols[j]
+	})
+	for i, symbol := range symbols {
+		symbols[i] = fmt.Sprintf("%s (%d)", symbol, counts[symbol])
+	}
+	return symbols
+}
+
+// UsageFiles lists the files with usages, sorted
+func UsageFiles(usages []Usage) []string {
+	seen := make(map[string]bool)
+	var files []string
+	for _, u := range usages {
+		if !seen[u.File] {
+			seen[u.File] = true
+			files = append(files, u.File)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// ImportRewrites returns the changes that move each import of the module to
+// its path at the target version, one per import line. Imports already at
+// the new path are left alone.
+func ImportRewrites(root string, usages []Usage, target *Target) []models.CodeChange {
+	newPrefix := target.NewImportPath()
+	contents := make(map[string]string)
+
+	var changes []models.CodeChange
+	for _, u := range usages {
+		if u.Symbol != "" {
+			continue
+		}
+		rest, ok := packageSuffix(u.ImportPath, target.Module)
+		if !ok {
+			continue
+		}
+		newPath := newPrefix + rest
+		if newPath == u.ImportPath {
+			continue
+		}
+
+		content, ok := contents[u.File]
+		if !ok {
+			data, err := os.ReadFile(filepath.Join(root, u.File))
+			if err != nil {
+				continue
+			}
+			content = string(data)
+			contents[u.File] = content
+		}
+		lines := strings.Split(content, "\n")
+		if u.Line < 1 || u.Line > len(lines) {
+			continue
+		}
+		old := lines[u.Line-1]
+		quoted := strconv.Quote(u.ImportPath)
+		if !strings.Contains(old, quoted) {
+			continue
+		}
+		changes = append(changes, models.CodeChange{
+			Type:        models.ChangeTypeReplace,
+			File:        u.File,
+			StartLine:   u.Line,
+			EndLine:     u.Line,
+			OldContent:  old,
+			NewContent:  strings.Replace(old, quoted, strconv.Quote(newPath), 1),
+			Explanation: fmt.Sprintf("import %s at %s", newPath, target.To),
+			Anchor:      "import",
+			BaseHash:    edits.HashContent(content),
+		})
+	}
+	return changes
+}
+
+// RenderRewrites renders import rewrites as per-file diffs
+func RenderRewrites(root string, changes []models.CodeChange) string {
+	var b strings.Builder
+	file := ""
+	for _, change := range changes {
+		if change.File != file {
+			file = change.File
+			fmt.Fprintf(&b, "--- a/%s\n+++ b/%s\n", file, file)
+		}
+		content := ""
+		if data, err := os.ReadFile(filepath.Join(root, change.File)); err == nil {
+			content = string(data)
+		}
+		for _, line := range edits.RenderContextDiff(content, change, 1) {
+			b.WriteString(line + "\n")
+		}
+	}
+	return b.String()
+}
+
+// Requirers lists the modules in the build graph that require module,
+// according to go mod graph
+func Requirers(ctx context.Context, root, module string, cfg Config) ([]string, error) {
+	policy := sandbox.DefaultPolicy()
+	if cfg.Timeout > 0 {
+		policy.Timeout = cfg.Timeout
+	}
+	runner, err := sandbox.NewRunner(root, policy)
+	if err != nil {
+		return nil, err
+	}
+	result, err := runner.Run(ctx, "go", "mod", "graph")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run go mod graph: %w", err)
+	}
+	if result.ExitCode != 0 {
+		return nil, fmt.Errorf("go mod graph failed: %s", strings.TrimSpace(result.Output))
+	}
+
+	seen := make(map[string]bool)
+	var requirers []string
+	for _, line := range strings.Split(result.Output, "\n") {
+		from, to, ok := strings.Cut(strings.TrimSpace(line), " ")
+		if !ok {
+			continue
+		}
+		if path, _, _ := strings.Cut(to, "@"); path != module || seen[from] {
+			continue
+		}
+		seen[from] = true
+		requirers = append(requirers, from)
+	}
+	sort.Strings(requirers)
+	return requirers, nil
+}
+
+// modulePrefixPattern matches the major version an import path under a
+// module's base path starts with: /v5 or, for gopkg.in, .v3
+var modulePrefixPattern = regexp.MustCompile(`^(/v\d+|\.v\d+)(/|$)`)
+
+// packageSuffix returns the package path of an import within the module,
+// whatever major version it imports: "/middleware" for
+// github.com/go-chi/chi/v5/middleware
+func packageSuffix(importPath, module string) (string, bool) {
+	base := majorSuffixPattern.ReplaceAllString(module, "")
+	if strings.HasPrefix(module, "gopkg.in/") {
+		base = gopkgSuffixPattern.ReplaceAllString(module, "")
+	}
+	rest, ok := strings.CutPrefix(importPath, base)
+	if !ok {
+		return "", false
+	}
+	if match := modulePrefixPattern.FindStringSubmatch(rest); match != nil {
+		rest = strings.TrimPrefix(rest, match[1])
+	}
+	if rest != "" && rest[0] != '/' {
+		return "", false
+	}
+	return rest, true
+}
+
+// underModule reports whether importPath is base, a package in it or in one
+// of its major versions
+func underModule(importPath, base string) bool {
+	return importPath == base || strings.HasPrefix(importPath, base+"/") ||
+		(strings.HasPrefix(base, "gopkg.in/") && strings.HasPrefix(importPath, gopkgSuffixPattern.ReplaceAllString(base, "")+".v"))
+}
+
+// packageName guesses the name an import is used by: its last element,
+// skipping a major version suffix
+func packageName(importPath string) string {
+	importPath = majorSuffixPattern.ReplaceAllString(importPath, "")
+	name := importPath[strings.LastIndex(importPath, "/")+1:]
+	name = gopkgSuffixPattern.ReplaceAllString(name, "")
+	return strings.TrimPrefix(name, "go-")
+}
@@ -0,0 +1,328 @@
+// Package pii finds personal data and customer identifiers in text about to
+// leave the machine: exported sessions and debug bundles. Depending on the
+// configured mode, matches are redacted or only reported, so the user can
+// review an artifact before attaching it to a public issue.
+package pii
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Modes of handling detected items
+const (
+	ModeRedact = "redact" // replace each item with a placeholder
+	ModeWarn   = "warn"   // keep the text, report what was found
+)
+
+// Kind is a class of detected item
+type Kind string
+
+const (
+	KindEmail      Kind = "email"
+	KindPhone      Kind = "phone"
+	KindCreditCard Kind = "credit_card"
+	KindSSN        Kind = "ssn"
+	KindIPAddress  Kind = "ip_address"
+	KindSecret     Kind = "secret"
+	KindCustomerID Kind = "customer_id"
+)
+
+// maxExamples bounds the masked examples a report keeps per kind
+const maxExamples = 3
+
+// Config controls PII detection
+type Config struct {
+	Mode string `json:"mode"` // redact or warn
+
+	// Identifiers are regular expressions of the organization's customer
+	// identifiers, such as `CUST-\d{6}` or `acct_[a-z0-9]{12}`
+	Identifiers []string `json:"identifiers"`
+
+	// Allow lists values never reported: exact matches, or email domains
+	// written as @example.com
+	Allow []string `json:"allow"`
+}
+
+// DefaultConfig redacts, allowing the reserved example domains and
+// loopback addresses
+func DefaultConfig() Config {
+	return Config{
+		Mode:  ModeRedact,
+		Allow: []string{"@example.com", "@example.org", "@example.net", "127.0.0.1", "0.0.0.0"},
+	}
+}
+
+// detector finds one kind of item. valid, when set, rejects matches the
+// pattern cannot rule out, such as card numbers failing their checksum.
+type detector struct {
+	kind    Kind
+	pattern *regexp.Regexp
+	valid   func(match string) bool
+}
+
+// builtinDetectors run in order; secrets come first so a key is not also
+// reported as a phone number
+var builtinDetectors = []detector{
+	{kind: KindSecret, pattern: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)},
+	{kind: KindSecret, pattern: regexp.MustCompile(`\b(?:sk-[A-Za-z0-9_\-]{16,}|AIza[0-9A-Za-z_\-]{35}|gh[pousr]_[A-Za-z0-9]{36,}|xox[abpr]-[A-Za-z0-9-]{10,}|AKIA[0-9A-Z]{16})\b`)},
+	{kind: KindEmail, pattern: regexp.MustCompile(`\b[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}\b`)},
+	{kind: KindCreditCard, pattern: regexp.MustCompile(`\b(?:\d[ \-]?){12,18}\d\b`), valid: luhnValid},
+	{kind: KindSSN, pattern: regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`)},
+	{kind: KindPhone, pattern: regexp.MustCompile(`(?:\+\d{1,3}[ .\-]?)?(?:\(\d{3}\)|\b\d{3})[ .\-]\d{3}[ .\-]\d{4}\b`)},
+	{kind: KindIPAddress, pattern: regexp.MustCompile(`\b(?:(?:25[0-5]|2[0-4]\d|1?\d?\d)\.){3}(?:25[0-5]|2[0-4]\d|1?\d?\d)\b`), valid: publicIP},
+}
+
+// Scanner detects PII according to a configuration
+type Scanner struct {
+	mode      string
+	detectors []detector
+	allow     map[string]bool
+	domains   []string // allowed email domains, with their @
+}
+
+// NewScanner compiles a configuration. An invalid identifier pattern is an
+// error.
+func NewScanner(config Config) (*Scanner, error) {
+	mode := config.Mode
+	if mode == "" {
+		mode = ModeRedact
+	}
+	if mode != ModeRedact && mode != ModeWarn {
+		return nil, fmt.Errorf("unknown PII mode %q (use %s or %s)", mode, ModeRedact, ModeWarn)
+	}
+	s := &Scanner{
+		mode:      mode,
+		detectors: append([]detector(nil), builtinDetectors...),
+		allow:     make(map[string]bool),
+	}
+	for _, identifier := range config.Identifiers {
+		pattern, err := regexp.Compile(identifier)
+		if err != nil {
+			return nil, fmt.Errorf("invalid customer identifier pattern %q: %w", identifier, err)
+		}
+		// Customer identifiers go first: they are the most specific
+		s.detectors = append([]detector{{kind: KindCustomerID, pattern: pattern}}, s.detectors...)
+	}
+	for _, value := range config.Allow {
+		if strings.HasPrefix(value, "@") {
+			s.domains = append(s.domains, strings.ToLower(value))
+		} else {
+			s.allow[value] = true
+		}
+	}
+	return s, nil
+}
+
+// Redacts reports whether the scanner's mode replaces what it finds
+func (s *Scanner) Redacts() bool {
+	return s != nil && s.mode == ModeRedact
+}
+
+// Scan reports the items in text without changing it
+func (s *Scanner) Scan(text string) *Report {
+	report := &Report{Counts: make(map[Kind]int)}
+	s.replace(text, report, false)
+	return report
+}
+
+// Redact replaces each item in text with a placeholder naming its kind
+func (s *Scanner) Redact(text string) (string, *Report) {
+	report := &Report{Counts: make(map[Kind]int)}
+	return s.replace(text, report, true), report
+}
+
+// Apply redacts or only scans text, as the scanner's mode says, adding what
+// it found to report
+func (s *Scanner) Apply(text string, report *Report) string {
+	if s == nil {
+		return text
+	}
+	if report.Counts == nil {
+		report.Counts = make(map[Kind]int)
+	}
+	return s.replace(text, report, s.Redacts())
+}
+
+func (s *Scanner) replace(text string, report *Report, redact bool) string {
+	if s == nil {
+		return text
+	}
+	report.Redacted = redact
+	for _, d := range s.detectors {
+		text = d.pattern.ReplaceAllStringFunc(text, func(match string) string {
+			if s.allowed(d.kind, match) || (d.valid != nil && !d.valid(match)) {
+				return match
+			}
+			report.add(d.kind, match)
+			if !redact {
+				return match
+			}
+			return Placeholder(d.kind)
+		})
+	}
+	return text
+}
+
+func (s *Scanner) allowed(kind Kind, match string) bool {
+	if s.allow[match] {
+		return true
+	}
+	if kind == KindEmail {
+		lower := strings.ToLower(match)
+		for _, domain := range s.domains {
+			if strings.HasSuffix(lower, domain) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Placeholder is what a redacted item of kind is replaced with
+func Placeholder(kind Kind) string {
+	return "[REDACTED " + strings.ToUpper(string(kind)) + "]"
+}
+
+// Report counts the items found, with a few masked examples of each kind
+type Report struct {
+	Counts   map[Kind]int      `json:"counts"`
+	Examples map[Kind][]string `json:"examples,omitempty"`
+	Redacted bool              `json:"redacted"` // the items were replaced in the text
+}
+
+func (r *Report) add(kind Kind, match string) {
+	r.Counts[kind]++
+	if r.Examples == nil {
+		r.Examples = make(map[Kind][]string)
+	}
+	masked := Mask(match)
+	for _, example := range r.Examples[kind] {
+		if example == masked {
+			return
+		}
+	}
+	if len(r.Examples[kind]) < maxExamples {
+		r.Examples[kind] = append(r.Examples[kind], masked)
+	}
+}
+
+// Total is the number of items found
+func (r *Report) Total() int {
+	if r == nil {
+		return 0
+	}
+	total := 0
+	for _, count := range r.Counts {
+		total += count
+	}
+	return total
+}
+
+// Kinds lists the kinds found, most frequent first
+func (r *Report) Kinds() []Kind {
+	if r == nil {
+		return nil
+	}
+	kinds := make([]Kind, 0, len(r.Counts))
+	for kind := range r.Counts {
+		kinds = append(kinds, kind)
+	}
+	sort.Slice(kinds, func(i, j int) bool {
+		if r.Counts[kinds[i]] != r.Counts[kinds[j]] {
+			return r.Counts[kinds[i]] > r.Counts[kinds[j]]
+		}
+		return kinds[i] < kinds[j]
+	})
+	return kinds
+}
+
+// Summary describes the findings in one line, such as
+// "3 email, 1 customer_id"
+func (r *Report) Summary() string {
+	if r.Total() == 0 {
+		return "no personal data found"
+	}
+	parts := make([]string, 0, len(r.Counts))
+	for _, kind := range r.Kinds() {
+		parts = append(parts, fmt.Sprintf("%d %s", r.Counts[kind], kind))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Mask shows enough of a value to recognize it without disclosing it:
+// the first character of each part of an email, the last four digits of a
+// number, the first two characters of anything else
+func Mask(value string) string {
+	if at := strings.LastIndex(value, "@"); at > 0 {
+		domain := value[at+1:]
+		tld := ""
+		if dot := strings.LastIndex(domain, "."); dot > 0 {
+			domain, tld = domain[:dot], domain[dot:]
+		}
+		return value[:1] + "***@" + domain[:1] + "***" + tld
+	}
+	digits := 0
+	for _, r := range value {
+		if r >= '0' && r <= '9' {
+			digits++
+		}
+	}
+	if digits >= 7 && digits*2 > len(value) && len(value) > 4 {
+		return "***" + value[len(value)-4:]
+	}
+	runes := []rune(value)
+	if len(runes) <= 4 {
+		return "***"
+	}
+	return string(runes[:2]) + "***"
+}
+
+// luhnValid reports whether a candidate card number passes the Luhn check,
+// ruling out most long numbers that are not cards
+func luhnValid(match string) bool {
+	sum, digits := 0, 0
+	double := false
+	for i := len(match) - 1; i >= 0; i-- {
+		c := match[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		digits++
+		double = !double
+	}
+	return digits >= 13 && digits <= 19 && sum%10 == 0
+}
+
+// publicIP rejects private, loopback and link-local addresses, which
+// identify no one, and version-like numbers such as 1.2.3.4
+func publicIP(match string) bool {
+	parts := strings.Split(match, ".")
+	var octets [4]int
+	for i, part := range parts {
+		fmt.Sscanf(part, "%d", &octets[i])
+	}
+	switch {
+	case octets[0] == 10, octets[0] == 127, octets[0] == 0:
+		return false
+	case octets[0] == 172 && octets[1] >= 16 && octets[1] <= 31:
+		return false
+	case octets[0] == 192 && octets[1] == 168:
+		return false
+	case octets[0] == 169 && octets[1] == 254:
+		return false
+	case octets[0] < 10 && octets[1] < 10 && octets[2] < 10 && octets[3] < 10:
+		return false // version numbers
+	}
+	return true
+}
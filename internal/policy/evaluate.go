@@ -0,0 +1,242 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/internal/audit"
+	"github.com/yourusername/useq-ai-assistant/internal/llm"
+	"github.com/yourusername/useq-ai-assistant/internal/promptguard"
+)
+
+// Unit is a piece of code judged against a policy: an indexed function, or
+// a whole file under review
+type Unit struct {
+	File      string   `json:"file"` // relative to the project root
+	Symbol    string   `json:"symbol,omitempty"`
+	StartLine int      `json:"start_line"`
+	Lines     []string `json:"-"`
+}
+
+// EndLine returns the number of the unit's last line
+func (u Unit) EndLine() int {
+	return u.StartLine + len(u.Lines) - 1
+}
+
+// Citation formats the unit as file:start-end
+func (u Unit) Citation() string {
+	return fmt.Sprintf("%s:%d-%d", u.File, u.StartLine, u.EndLine())
+}
+
+// Verdict is the model's judgement of one unit
+type Verdict struct {
+	Applies  bool   `json:"applies"`  // the rule covers this code at all
+	Complies bool   `json:"complies"` // it follows the rule
+	Line     int    `json:"line"`     // the line that breaks the rule
+	Reason   string `json:"reason"`
+}
+
+// Usage is what judging cost
+type Usage struct {
+	Tokens int     `json:"tokens"`
+	Cost   float64 `json:"cost"`
+}
+
+// Judge decides whether units follow a policy, one verdict per unit
+type Judge interface {
+	Judge(ctx context.Context, policy *Policy, units []Unit) ([]Verdict, Usage, error)
+}
+
+// LLMJudge asks a model to judge a batch of units in one call
+type LLMJudge struct {
+	manager *llm.Manager
+	model   string
+}
+
+// NewLLMJudge creates a judge on manager; an empty model lets routing pick
+// one
+func NewLLMJudge(manager *llm.Manager, model string) *LLMJudge {
+	return &LLMJudge{manager: manager, model: model}
+}
+
+// judgeSchema constrains the answer to one verdict per unit
+var judgeSchema = &llm.ResponseSchema{
+	Name: "policy_verdicts",
+	Schema: json.RawMessage(`{"type":"object","properties":{"verdicts":{"type":"array","items":{"type":"object",` +
+		`"properties":{"unit":{"type":"integer"},"applies":{"type":"boolean"},"complies":{"type":"boolean"},` +
+		`"line":{"type":"integer"},"reason":{"type":"string"}},` +
+		`"required":["unit","applies","complies","line","reason"],"additionalProperties":false}}},` +
+		`"required":["verdicts"],"additionalProperties":false}`),
+	Strict: true,
+}
+
+const judgeSystemPrompt = `You review code against a team policy. For each code unit decide whether
+the policy applies to it and, if it does, whether the code complies. Judge
+only by the code shown; when it calls something that may satisfy the policy,
+such as a helper that wraps the required middleware, give it the benefit of
+the doubt and say so. For a violation, give the number of the line that
+breaks the policy and say in one sentence what is wrong. Answer only with
+the requested JSON.`
+
+// Judge returns the model's verdict on each unit. Units the model skips
+// count as not covered by the policy.
+func (j *LLMJudge) Judge(ctx context.Context, policy *Policy, units []Unit) ([]Verdict, Usage, error) {
+	if len(units) == 0 {
+		return nil, Usage{}, nil
+	}
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "Policy: %s\n", policy.Rule)
+	if policy.AppliesTo != "" {
+		fmt.Fprintf(&prompt, "It applies to: %s\n", policy.AppliesTo)
+	}
+	prompt.WriteString("\n")
+	for i, unit := range units {
+		var body strings.Builder
+		for n, line := range unit.Lines {
+			fmt.Fprintf(&body, "%5d  %s\n", unit.StartLine+n, line)
+		}
+		label := unit.File
+		if unit.Symbol != "" {
+			label += " " + unit.Symbol
+		}
+		fmt.Fprintf(&prompt, "Unit %d (%s):\n%s\n", i, label, promptguard.Wrap(unit.Citation(), body.String()))
+	}
+
+	generated, raw, err := j.manager.GenerateStructured(ctx, &llm.GenerationRequest{
+		SystemPrompt:   judgeSystemPrompt,
+		Messages:       []llm.Message{{Role: "user", Content: prompt.String()}},
+		Model:          j.model,
+		MaxTokens:      120 * len(units),
+		ResponseSchema: judgeSchema,
+		Metadata:       map[string]string{"task": "policy_check"},
+	})
+	var usage Usage
+	if generated != nil {
+		usage = Usage{Tokens: generated.TokenUsage.TotalTokens, Cost: generated.Cost.TotalCost}
+	}
+	if err != nil {
+		return nil, usage, fmt.Errorf("failed to judge policy %s: %w", policy.Name, err)
+	}
+
+	var answer struct {
+		Verdicts []struct {
+			Unit int `json:"unit"`
+			Verdict
+		} `json:"verdicts"`
+	}
+	if err := json.Unmarshal(raw, &answer); err != nil {
+		return nil, usage, fmt.Errorf("failed to read policy verdicts: %w", err)
+	}
+	verdicts := make([]Verdict, len(units))
+	for _, verdict := range answer.Verdicts {
+		if verdict.Unit >= 0 && verdict.Unit < len(units) {
+			verdicts[verdict.Unit] = verdict.Verdict
+		}
+	}
+	return verdicts, usage, nil
+}
+
+// Violation is a unit that breaks a policy
+type Violation struct {
+	Unit
+	Line   int    `json:"line"` // the offending line; the unit's first line when the model gave none in it
+	Reason string `json:"reason"`
+}
+
+// Result is how a policy fared on the code it covers
+type Result struct {
+	Policy     *Policy     `json:"policy"`
+	Checked    int         `json:"checked"`    // units judged
+	Applicable int         `json:"applicable"` // units the rule applied to
+	Unchecked  int         `json:"unchecked"`  // covered units left out: over the limit or in failed batches
+	Violations []Violation `json:"violations"`
+	Errors     []string    `json:"errors,omitempty"`
+	Usage      Usage       `json:"usage"`
+}
+
+// Passed reports whether no judged unit breaks the policy
+func (r *Result) Passed() bool {
+	return len(r.Violations) == 0
+}
+
+// Evaluate judges units against a policy in batches. Units past
+// config.MaxCandidates are counted as unchecked; a failed batch is
+// recorded and the rest still judged.
+func Evaluate(ctx context.Context, judge Judge, policy *Policy, units []Unit, config Config) *Result {
+	result := &Result{Policy: policy}
+	if config.MaxCandidates > 0 && len(units) > config.MaxCandidates {
+		result.Unchecked = len(units) - config.MaxCandidates
+		units = units[:config.MaxCandidates]
+	}
+	size := config.BatchSize
+	if size <= 0 {
+		size = DefaultConfig().BatchSize
+	}
+
+	for start := 0; start < len(units); start += size {
+		if ctx.Err() != nil {
+			result.Unchecked += len(units) - start
+			result.Errors = append(result.Errors, ctx.Err().Error())
+			break
+		}
+		batch := units[start:min(start+size, len(units))]
+		verdicts, usage, err := judge.Judge(ctx, policy, batch)
+		result.Usage.Tokens += usage.Tokens
+		result.Usage.Cost += usage.Cost
+		if err != nil {
+			result.Unchecked += len(batch)
+			result.Errors = append(result.Errors, err.Error())
+			continue
+		}
+		result.Checked += len(batch)
+		for i, verdict := range verdicts {
+			if !verdict.Applies {
+				continue
+			}
+			result.Applicable++
+			if verdict.Complies {
+				continue
+			}
+			unit := batch[i]
+			line := verdict.Line
+			if line < unit.StartLine || line > unit.EndLine() {
+				line = unit.StartLine
+			}
+			result.Violations = append(result.Violations, Violation{Unit: unit, Line: line, Reason: strings.TrimSpace(verdict.Reason)})
+		}
+	}
+	return result
+}
+
+// Findings returns the violations as audit findings
+func (r *Result) Findings() []audit.Finding {
+	findings := make([]audit.Finding, 0, len(r.Violations))
+	for _, violation := range r.Violations {
+		findings = append(findings, audit.Finding{
+			Category: audit.CategoryPolicy,
+			Severity: r.Policy.Severity,
+			File:     violation.File,
+			Line:     violation.Line,
+			Symbol:   violation.Symbol,
+			Title:    fmt.Sprintf("Breaks policy %s: %s", r.Policy.Name, r.Policy.Rule),
+			Detail:   fmt.Sprintf("%s (%s)", violation.Reason, violation.Citation()),
+		})
+	}
+	return findings
+}
+
+// Status summarizes the result for an audit report
+func (r *Result) Status() audit.PolicyStatus {
+	return audit.PolicyStatus{
+		Name:       r.Policy.Name,
+		Rule:       r.Policy.Rule,
+		Passed:     r.Passed(),
+		Checked:    r.Checked,
+		Applicable: r.Applicable,
+		Violations: len(r.Violations),
+		Unchecked:  r.Unchecked,
+		Error:      strings.Join(r.Errors, "; "),
+	}
+}
@@ -0,0 +1,183 @@
+// Package policy checks code against a team's own rules, written in plain
+// language in policies.yaml ("all HTTP handlers must call the auth
+// middleware", "no direct SQL outside the repository layer"). Path globs
+// and semantic tags pick the code a rule covers; a model then judges each
+// piece of it and cites the lines that break the rule.
+package policy
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/yourusername/useq-ai-assistant/internal/audit"
+	"github.com/yourusername/useq-ai-assistant/internal/semtags"
+)
+
+// DefaultPath is where the project's policies are read from
+const DefaultPath = "config/policies.yaml"
+
+// Config controls policy checks
+type Config struct {
+	File          string `json:"file"`
+	MaxCandidates int    `json:"max_candidates"` // functions judged per policy; the rest are counted as unchecked
+	BatchSize     int    `json:"batch_size"`     // functions judged per model call
+	MaxLines      int    `json:"max_lines"`      // lines of a function the model sees
+	Model         string `json:"model"`          // model for judging; empty lets routing pick
+}
+
+// DefaultConfig returns policy check defaults
+func DefaultConfig() Config {
+	return Config{
+		File:          DefaultPath,
+		MaxCandidates: 200,
+		BatchSize:     8,
+		MaxLines:      80,
+	}
+}
+
+// Policy is one rule of the team's policies
+type Policy struct {
+	Name      string   `yaml:"name" json:"name"`
+	Rule      string   `yaml:"rule" json:"rule"`                                 // the requirement, in plain language
+	AppliesTo string   `yaml:"applies_to,omitempty" json:"applies_to,omitempty"` // the code it covers, in plain language
+	Paths     []string `yaml:"paths,omitempty" json:"paths,omitempty"`           // globs of files it covers; empty covers all
+	Exclude   []string `yaml:"exclude,omitempty" json:"exclude,omitempty"`       // globs of files it never covers
+	Tags      []string `yaml:"tags,omitempty" json:"tags,omitempty"`             // semantic tags code needs to be covered; empty covers all
+	Severity  string   `yaml:"severity,omitempty" json:"severity,omitempty"`     // high, medium or low
+}
+
+// Load reads the policies from path. A missing file has none.
+func Load(path string) ([]*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read policies: %w", err)
+	}
+	var file struct {
+		Policies []*Policy `yaml:"policies"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse policies: %w", err)
+	}
+
+	seen := make(map[string]bool, len(file.Policies))
+	for i, policy := range file.Policies {
+		policy.Name = strings.TrimSpace(policy.Name)
+		policy.Rule = strings.TrimSpace(policy.Rule)
+		if policy.Name == "" || policy.Rule == "" {
+			return nil, fmt.Errorf("policy %d in %s needs a name and a rule", i+1, path)
+		}
+		if seen[policy.Name] {
+			return nil, fmt.Errorf("policy %s is defined twice in %s", policy.Name, path)
+		}
+		seen[policy.Name] = true
+		switch policy.Severity {
+		case "":
+			policy.Severity = audit.SeverityMedium
+		case audit.SeverityHigh, audit.SeverityMedium, audit.SeverityLow:
+		default:
+			return nil, fmt.Errorf("policy %s: unknown severity %q; use high, medium or low", policy.Name, policy.Severity)
+		}
+		for _, tag := range policy.Tags {
+			if !isTag(tag) {
+				return nil, fmt.Errorf("policy %s: unknown tag %q; use %s", policy.Name, tag, strings.Join(semtags.All, ", "))
+			}
+		}
+	}
+	return file.Policies, nil
+}
+
+// Find returns the policy named name, or nil
+func Find(policies []*Policy, name string) *Policy {
+	for _, policy := range policies {
+		if policy.Name == name {
+			return policy
+		}
+	}
+	return nil
+}
+
+// CoversPath reports whether the policy covers a project-relative file
+func (p *Policy) CoversPath(file string) bool {
+	elements := pathElements(file)
+	for _, pattern := range p.Exclude {
+		if matchPath(pattern, elements) {
+			return false
+		}
+	}
+	if len(p.Paths) == 0 {
+		return true
+	}
+	for _, pattern := range p.Paths {
+		if matchPath(pattern, elements) {
+			return true
+		}
+	}
+	return false
+}
+
+// CoversCode reports whether code carries one of the policy's semantic
+// tags, at the given keyword score
+func (p *Policy) CoversCode(code string, minScore int) bool {
+	if len(p.Tags) == 0 {
+		return true
+	}
+	for _, tag := range semtags.Classify(code, minScore) {
+		for _, wanted := range p.Tags {
+			if tag == wanted {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isTag(tag string) bool {
+	for _, known := range semtags.All {
+		if tag == known {
+			return true
+		}
+	}
+	return false
+}
+
+// pathElements splits a project-relative path into its elements
+func pathElements(path string) []string {
+	var elements []string
+	for _, element := range strings.Split(filepath.ToSlash(filepath.Clean(path)), "/") {
+		if element != "" && element != "." {
+			elements = append(elements, element)
+		}
+	}
+	return elements
+}
+
+// matchPath matches a glob without a slash against every element of a path,
+// and one with a slash against every leading part of it, so "repository"
+// covers any repository directory and "internal/server" everything under it
+func matchPath(pattern string, elements []string) bool {
+	pattern = strings.TrimSuffix(strings.TrimSuffix(filepath.ToSlash(pattern), "/**"), "/")
+	if pattern == "" {
+		return false
+	}
+	if !strings.Contains(pattern, "/") {
+		for _, element := range elements {
+			if matched, _ := filepath.Match(pattern, element); matched {
+				return true
+			}
+		}
+		return false
+	}
+	for i := range elements {
+		if matched, _ := filepath.Match(pattern, strings.Join(elements[:i+1], "/")); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,311 @@
+package prewarm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// maxSummaryNames caps the types, functions and imports listed in a package
+// summary
+const maxSummaryNames = 40
+
+// PackageFreshness is how current the summary of one package is: its age
+// against how much of the package changed since
+type PackageFreshness struct {
+	Dir          string        `json:"dir"`
+	Package      string        `json:"package,omitempty"`
+	SummarizedAt time.Time     `json:"summarized_at,omitempty"` // zero when never summarized
+	Age          time.Duration `json:"age"`
+	TotalFiles   int           `json:"total_files"`
+	ChangedFiles int           `json:"changed_files"` // added, modified or removed since summarized
+	Stale        bool          `json:"stale"`
+	Priority     float64       `json:"priority"` // higher is re-summarized first
+	Files        []string      `json:"-"`
+}
+
+// ChangeRatio is the fraction of the package changed since its summary
+func (pf *PackageFreshness) ChangeRatio() float64 {
+	if pf.TotalFiles == 0 {
+		return 0
+	}
+	return min(1, float64(pf.ChangedFiles)/float64(pf.TotalFiles))
+}
+
+// Describe renders freshness as a short phrase such as "summarized 3 days
+// ago, 4 of 12 files changed since"
+func (pf *PackageFreshness) Describe() string {
+	if pf.SummarizedAt.IsZero() {
+		return "not summarized yet"
+	}
+	text := "summarized " + describeAge(pf.Age)
+	if pf.ChangedFiles == 0 {
+		return text + ", unchanged since"
+	}
+	return fmt.Sprintf("%s, %d of %d files changed since", text, pf.ChangedFiles, pf.TotalFiles)
+}
+
+// SummarizePackage builds an LLM-free summary of a package from the
+// summaries of its files, stamped so later changes can be measured
+func SummarizePackage(dir string, files []string, db *storage.SQLiteDB) (*storage.PackageSummaryRecord, error) {
+	record := &storage.PackageSummaryRecord{
+		Dir:          dir,
+		FileStamps:   make(map[string]string, len(files)),
+		SummarizedAt: time.Now(),
+	}
+
+	var (
+		types, functions, imports []string
+		lines                     int
+		seenImports               = make(map[string]bool)
+	)
+	for _, path := range files {
+		summary, err := Summarize(path, db)
+		if err != nil {
+			continue
+		}
+		record.FileStamps[path] = fileStamp(path)
+		if record.Package == "" {
+			record.Package = summary.Package
+		}
+		lines += summary.LineCount
+		types = append(types, summary.Types...)
+		for _, fn := range summary.Functions {
+			if isExported(fn) {
+				functions = append(functions, fn)
+			}
+		}
+		for _, imp := range summary.Imports {
+			if !seenImports[imp] {
+				seenImports[imp] = true
+				imports = append(imports, imp)
+			}
+		}
+	}
+	if len(record.FileStamps) == 0 {
+		return nil, fmt.Errorf("no readable files in %s", dir)
+	}
+
+	var b strings.Builder
+	name := record.Package
+	if name == "" {
+		name = filepath.Base(dir)
+	}
+	b.WriteString(fmt.Sprintf("%s (%s): %d files, %d lines", name, dir, len(record.FileStamps), lines))
+	writeNames(&b, "types", types)
+	writeNames(&b, "exported functions", functions)
+	sort.Strings(imports)
+	writeNames(&b, "imports", imports)
+	record.Summary = b.String()
+	return record, nil
+}
+
+// PackageFreshness measures every indexed package against its summary,
+// most in need of re-summarizing first
+func (p *Prewarmer) PackageFreshness() ([]PackageFreshness, error) {
+	if p == nil || p.db == nil {
+		return nil, fmt.Errorf("package summaries need storage")
+	}
+	byDir, err := p.db.IndexedFilesByDir()
+	if err != nil {
+		return nil, err
+	}
+	records, err := p.db.ListPackageSummaries()
+	if err != nil {
+		return nil, err
+	}
+	summaries := make(map[string]*storage.PackageSummaryRecord, len(records))
+	for _, record := range records {
+		summaries[record.Dir] = record
+	}
+
+	freshness := make([]PackageFreshness, 0, len(byDir))
+	for dir, files := range byDir {
+		freshness = append(freshness, p.measure(dir, files, summaries[dir]))
+	}
+	sort.Slice(freshness, func(i, j int) bool {
+		if freshness[i].Priority != freshness[j].Priority {
+			return freshness[i].Priority > freshness[j].Priority
+		}
+		return freshness[i].Dir < freshness[j].Dir
+	})
+	return freshness, nil
+}
+
+// PackageSummary returns the stored summary of a package directory and its
+// freshness, or nil if it has never been summarized
+func (p *Prewarmer) PackageSummary(dir string) (*storage.PackageSummaryRecord, *PackageFreshness, error) {
+	if p == nil || p.db == nil {
+		return nil, nil, fmt.Errorf("package summaries need storage")
+	}
+	record, err := p.db.GetPackageSummary(dir)
+	if err != nil || record == nil {
+		return nil, nil, err
+	}
+	byDir, err := p.db.IndexedFilesByDir()
+	if err != nil {
+		return nil, nil, err
+	}
+	freshness := p.measure(dir, byDir[dir], record)
+	return record, &freshness, nil
+}
+
+// Resummarize rebuilds the summaries of stale packages, most changed first,
+// up to limit packages (all stale packages when limit is 0). It stops early
+// when ctx is done or keepGoing, if set, returns false, and returns how many
+// packages were summarized.
+func (p *Prewarmer) Resummarize(ctx context.Context, limit int, keepGoing func() bool) (int, error) {
+	freshness, err := p.PackageFreshness()
+	if err != nil {
+		return 0, err
+	}
+
+	done := 0
+	for _, pkg := range freshness {
+		if limit > 0 && done >= limit {
+			break
+		}
+		if !pkg.Stale {
+			continue
+		}
+		if ctx.Err() != nil || (keepGoing != nil && !keepGoing()) {
+			break
+		}
+		if err := p.ResummarizePackage(pkg.Dir, pkg.Files); err != nil {
+			p.setError(err)
+			continue
+		}
+		done++
+	}
+	return done, nil
+}
+
+// ResummarizePackage rebuilds and stores the summary of one package
+func (p *Prewarmer) ResummarizePackage(dir string, files []string) error {
+	if p == nil || p.db == nil {
+		return fmt.Errorf("package summaries need storage")
+	}
+	record, err := SummarizePackage(dir, files, p.db)
+	if err != nil {
+		return err
+	}
+	if err := p.db.SavePackageSummary(record); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	p.status.Resummarized++
+	p.mu.Unlock()
+	return nil
+}
+
+// refreshKnowledge re-summarizes the most changed packages during an idle
+// cycle, at most once per ResummarizeInterval
+func (p *Prewarmer) refreshKnowledge(ctx context.Context) {
+	if p.config.ResummarizeMax <= 0 {
+		return
+	}
+	p.mu.Lock()
+	due := time.Since(p.lastKnowledgeCheck) >= p.config.ResummarizeInterval
+	if due {
+		p.lastKnowledgeCheck = time.Now()
+	}
+	p.mu.Unlock()
+	if !due {
+		return
+	}
+
+	if _, err := p.Resummarize(ctx, p.config.ResummarizeMax, p.isIdle); err != nil {
+		p.setError(fmt.Errorf("failed to refresh package summaries: %w", err))
+	}
+}
+
+// measure compares a package's files on disk with its summary stamps. A
+// summary is stale when never built, or when files changed and either a
+// large enough share changed or the summary is older than SummaryMaxAge.
+func (p *Prewarmer) measure(dir string, files []string, record *storage.PackageSummaryRecord) PackageFreshness {
+	pf := PackageFreshness{Dir: dir, TotalFiles: len(files), Files: files}
+	if record == nil {
+		pf.ChangedFiles = len(files)
+		pf.Stale = len(files) > 0
+		pf.Priority = float64(len(files))
+		return pf
+	}
+
+	pf.Package = record.Package
+	pf.SummarizedAt = record.SummarizedAt
+	pf.Age = time.Since(record.SummarizedAt)
+	current := make(map[string]bool, len(files))
+	for _, path := range files {
+		current[path] = true
+		if stamp, ok := record.FileStamps[path]; !ok || stamp != fileStamp(path) {
+			pf.ChangedFiles++
+		}
+	}
+	for path := range record.FileStamps {
+		if !current[path] {
+			pf.ChangedFiles++
+		}
+	}
+
+	if pf.ChangedFiles > 0 {
+		aged := p.config.SummaryMaxAge > 0 && pf.Age >= p.config.SummaryMaxAge
+		pf.Stale = aged || pf.ChangeRatio() >= p.config.StaleChangeRatio
+		// Change volume leads; age breaks ties and lifts long-neglected packages
+		ageWeight := 0.0
+		if p.config.SummaryMaxAge > 0 {
+			ageWeight = min(pf.Age.Hours()/p.config.SummaryMaxAge.Hours(), 2)
+		}
+		pf.Priority = float64(pf.ChangedFiles) * (1 + ageWeight)
+	}
+	return pf
+}
+
+// fileStamp identifies a version of a file by size and modification time
+func fileStamp(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%d:%d", info.Size(), info.ModTime().UnixNano())
+}
+
+// writeNames appends a labelled, capped list of names
+func writeNames(b *strings.Builder, label string, names []string) {
+	if len(names) == 0 {
+		return
+	}
+	if len(names) > maxSummaryNames {
+		names = append(names[:maxSummaryNames:maxSummaryNames], fmt.Sprintf("… %d more", len(names)-maxSummaryNames))
+	}
+	b.WriteString(fmt.Sprintf("\n  %s: %s", label, strings.Join(names, ", ")))
+}
+
+// isExported reports whether a function, or the method part of
+// Type.Method, is exported
+func isExported(name string) bool {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return name != "" && name[0] >= 'A' && name[0] <= 'Z'
+}
+
+// describeAge renders a duration as "just now", "5 hours ago" or "3 days
+// ago"
+func describeAge(age time.Duration) string {
+	switch {
+	case age < time.Hour:
+		return "just now"
+	case age < 2*time.Hour:
+		return "an hour ago"
+	case age < 48*time.Hour:
+		return fmt.Sprintf("%d hours ago", int(age.Hours()))
+	default:
+		return fmt.Sprintf("%d days ago", int(age.Hours()/24))
+	}
+}
@@ -0,0 +1,422 @@
+// Package prewarm keeps summaries and embeddings of frequently touched files
+// warm in the background, so interactive queries about hot paths skip
+// retrieval latency.
+package prewarm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// Access weights: editing a file says more about focus than mentioning it
+const (
+	WeightQuery  = 1.0 // file targeted by or retrieved for a query
+	WeightEdit   = 3.0 // file changed by a generated edit
+	WeightEditor = 2.0 // file open in the editor when querying
+)
+
+// chunksPerHotFile is how many hot chunks are read per hot file kept warm
+const chunksPerHotFile = 5
+
+// Config controls background prewarming
+type Config struct {
+	Enabled bool `json:"enabled"`
+	// Metered disables everything that calls a paid API (re-embedding);
+	// free local summaries are still built
+	Metered           bool          `json:"metered"`
+	IdleAfter         time.Duration `json:"idle_after"` // no queries for this long counts as idle
+	Interval          time.Duration `json:"interval"`   // how often to check for idle time
+	MaxFiles          int           `json:"max_files"`  // hot files kept warm
+	DailyBudget       float64       `json:"daily_budget"`
+	CostPerReembed    float64       `json:"cost_per_reembed"` // estimated embedding cost of one file
+	MaxSummaryEntries int           `json:"max_summary_entries"`
+
+	// Package summaries are re-built while idle, most changed packages
+	// first. A summary is stale once StaleChangeRatio of its files changed,
+	// or any changed and it is older than SummaryMaxAge.
+	ResummarizeInterval time.Duration `json:"resummarize_interval"` // how often idle cycles check package freshness
+	ResummarizeMax      int           `json:"resummarize_max"`      // packages re-summarized per check; 0 disables
+	SummaryMaxAge       time.Duration `json:"summary_max_age"`
+	StaleChangeRatio    float64       `json:"stale_change_ratio"`
+}
+
+// DefaultConfig returns conservative prewarming defaults
+func DefaultConfig() Config {
+	return Config{
+		Enabled:           true,
+		Metered:           false,
+		IdleAfter:         30 * time.Second,
+		Interval:          15 * time.Second,
+		MaxFiles:          20,
+		DailyBudget:       0.05,
+		CostPerReembed:    0.0005,
+		MaxSummaryEntries: 200,
+
+		ResummarizeInterval: 10 * time.Minute,
+		ResummarizeMax:      3,
+		SummaryMaxAge:       7 * 24 * time.Hour,
+		StaleChangeRatio:    0.25,
+	}
+}
+
+// Refresher re-indexes a single file. The code indexer satisfies it.
+type Refresher interface {
+	NeedsReindex(path string) (bool, error)
+	IndexFile(ctx context.Context, path string) error
+}
+
+// HotFile is the prewarm state of one frequently touched file
+type HotFile struct {
+	Path        string    `json:"path"`
+	Score       float64   `json:"score"`
+	AccessCount int       `json:"access_count"`
+	Summarized  bool      `json:"summarized"`
+	Fresh       bool      `json:"fresh"` // embeddings match the file on disk
+	LastWarmed  time.Time `json:"last_warmed,omitempty"`
+}
+
+// Status is a snapshot of prewarming activity for the `prewarm status` view
+type Status struct {
+	Enabled          bool      `json:"enabled"`
+	Metered          bool      `json:"metered"`
+	Warming          bool      `json:"warming"`
+	LastCycle        time.Time `json:"last_cycle,omitempty"`
+	Cycles           int       `json:"cycles"`
+	Reembedded       int       `json:"reembedded"`
+	Resummarized     int       `json:"resummarized"` // package summaries rebuilt
+	SkippedForBudget int       `json:"skipped_for_budget"`
+	SpentToday       float64   `json:"spent_today"`
+	DailyBudget      float64   `json:"daily_budget"`
+	HotFiles         []HotFile `json:"hot_files"`
+	LastError        string    `json:"last_error,omitempty"`
+}
+
+// Prewarmer tracks file access and warms hot files while the user is idle
+type Prewarmer struct {
+	config    Config
+	db        *storage.SQLiteDB
+	refresher Refresher
+
+	mu           sync.RWMutex
+	summaries    map[string]*FileSummary
+	fresh        map[string]bool
+	lastActivity time.Time
+	status       Status
+	spentDay     string
+
+	lastKnowledgeCheck time.Time
+}
+
+// NewPrewarmer creates a prewarmer; refresher may be nil to only build summaries
+func NewPrewarmer(config Config, db *storage.SQLiteDB, refresher Refresher) *Prewarmer {
+	if config.Interval <= 0 {
+		config.Interval = DefaultConfig().Interval
+	}
+	if config.MaxFiles <= 0 {
+		config.MaxFiles = DefaultConfig().MaxFiles
+	}
+	if config.MaxSummaryEntries <= 0 {
+		config.MaxSummaryEntries = DefaultConfig().MaxSummaryEntries
+	}
+	if config.ResummarizeInterval <= 0 {
+		config.ResummarizeInterval = DefaultConfig().ResummarizeInterval
+	}
+	return &Prewarmer{
+		config:       config,
+		db:           db,
+		refresher:    refresher,
+		summaries:    make(map[string]*FileSummary),
+		fresh:        make(map[string]bool),
+		lastActivity: time.Now(),
+		status: Status{
+			Enabled:     config.Enabled,
+			Metered:     config.Metered,
+			DailyBudget: config.DailyBudget,
+		},
+	}
+}
+
+// Touch marks the user as active, pausing prewarming until idle again
+func (p *Prewarmer) Touch() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.lastActivity = time.Now()
+	p.mu.Unlock()
+}
+
+// RecordAccess notes that files were touched by a query or edit and marks
+// the user as active. Access is recorded even when prewarming is disabled so
+// re-enabling it starts from real usage.
+func (p *Prewarmer) RecordAccess(weight float64, paths ...string) {
+	if p == nil {
+		return
+	}
+	p.Touch()
+
+	if p.db == nil {
+		return
+	}
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		if err := p.db.RecordFileAccess(path, weight); err != nil {
+			p.setError(err)
+		}
+	}
+}
+
+// Summary returns the warm summary of a file if it is still current
+func (p *Prewarmer) Summary(path string) (*FileSummary, bool) {
+	if p == nil {
+		return nil, false
+	}
+	p.mu.RLock()
+	summary, ok := p.summaries[path]
+	p.mu.RUnlock()
+	if !ok || !summary.IsCurrent() {
+		return nil, false
+	}
+	return summary, true
+}
+
+// Start runs prewarming in the background until ctx is cancelled
+func (p *Prewarmer) Start(ctx context.Context) {
+	if p == nil || !p.config.Enabled {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(p.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if p.isIdle() {
+					p.RunCycle(ctx)
+				}
+			}
+		}
+	}()
+}
+
+// RunCycle warms the current hot files once, stopping early if the user
+// becomes active again
+func (p *Prewarmer) RunCycle(ctx context.Context) {
+	if p == nil || p.db == nil {
+		return
+	}
+
+	hot, err := p.hotFiles()
+	if err != nil {
+		p.setError(fmt.Errorf("failed to load hot files: %w", err))
+		return
+	}
+
+	p.mu.Lock()
+	p.status.Warming = true
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		p.status.Warming = false
+		p.status.LastCycle = time.Now()
+		p.status.Cycles++
+		p.mu.Unlock()
+	}()
+
+	for _, file := range hot {
+		if ctx.Err() != nil || !p.isIdle() {
+			return
+		}
+		p.warmFile(ctx, file.Path)
+	}
+	p.evictSummaries(hot)
+
+	if ctx.Err() == nil && p.isIdle() {
+		p.refreshKnowledge(ctx)
+	}
+}
+
+// Status returns a snapshot of prewarming state, including hot files
+func (p *Prewarmer) Status() Status {
+	if p == nil {
+		return Status{}
+	}
+
+	var hot []*storage.FileAccess
+	if p.db != nil {
+		hot, _ = p.hotFiles()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.resetBudgetIfNewDay()
+
+	status := p.status
+	status.HotFiles = make([]HotFile, 0, len(hot))
+	for _, f := range hot {
+		entry := HotFile{
+			Path:        f.Path,
+			Score:       f.Score,
+			AccessCount: f.AccessCount,
+			Fresh:       p.fresh[f.Path],
+		}
+		if summary, ok := p.summaries[f.Path]; ok {
+			entry.Summarized = summary.IsCurrent()
+			entry.LastWarmed = summary.WarmedAt
+		}
+		status.HotFiles = append(status.HotFiles, entry)
+	}
+	return status
+}
+
+// hotFiles ranks files by their access score plus the scores of their hot
+// chunks, so files whose code answers keep retrieving and citing are warmed
+// before files that were only mentioned
+func (p *Prewarmer) hotFiles() ([]*storage.FileAccess, error) {
+	files, err := p.db.GetHotFiles(p.config.MaxFiles)
+	if err != nil {
+		return nil, err
+	}
+	chunks, err := p.db.GetHotChunks(p.config.MaxFiles * chunksPerHotFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load hot chunks: %w", err)
+	}
+	if len(chunks) == 0 {
+		return files, nil
+	}
+
+	byPath := make(map[string]*storage.FileAccess, len(files))
+	for _, file := range files {
+		byPath[file.Path] = file
+	}
+	for _, chunk := range chunks {
+		file := byPath[chunk.Path]
+		if file == nil {
+			file = &storage.FileAccess{Path: chunk.Path, LastAccess: chunk.LastAccess}
+			byPath[chunk.Path] = file
+			files = append(files, file)
+		}
+		file.Score += chunk.Score
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Score > files[j].Score })
+	if len(files) > p.config.MaxFiles {
+		files = files[:p.config.MaxFiles]
+	}
+	return files, nil
+}
+
+// warmFile refreshes the summary and, budget permitting, the embeddings of one file
+func (p *Prewarmer) warmFile(ctx context.Context, path string) {
+	p.mu.RLock()
+	existing := p.summaries[path]
+	p.mu.RUnlock()
+
+	if existing == nil || !existing.IsCurrent() {
+		summary, err := Summarize(path, p.db)
+		if err != nil {
+			p.setError(err)
+			return
+		}
+		p.mu.Lock()
+		p.summaries[path] = summary
+		p.mu.Unlock()
+	}
+
+	if p.refresher == nil || p.config.Metered {
+		return
+	}
+
+	needs, err := p.refresher.NeedsReindex(path)
+	if err != nil {
+		p.setError(err)
+		return
+	}
+	if !needs {
+		p.mu.Lock()
+		p.fresh[path] = true
+		p.mu.Unlock()
+		return
+	}
+
+	if !p.reserveBudget() {
+		return
+	}
+	if err := p.refresher.IndexFile(ctx, path); err != nil {
+		p.setError(fmt.Errorf("failed to re-embed %s: %w", path, err))
+		return
+	}
+
+	p.mu.Lock()
+	p.fresh[path] = true
+	p.status.Reembedded++
+	p.mu.Unlock()
+}
+
+// reserveBudget charges one re-embedding against today's budget
+func (p *Prewarmer) reserveBudget() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.resetBudgetIfNewDay()
+
+	if p.status.SpentToday+p.config.CostPerReembed > p.config.DailyBudget {
+		p.status.SkippedForBudget++
+		return false
+	}
+	p.status.SpentToday += p.config.CostPerReembed
+	return true
+}
+
+// resetBudgetIfNewDay clears the daily spend at midnight; callers hold mu
+func (p *Prewarmer) resetBudgetIfNewDay() {
+	today := time.Now().Format("2006-01-02")
+	if p.spentDay != today {
+		p.spentDay = today
+		p.status.SpentToday = 0
+	}
+}
+
+// evictSummaries drops summaries of files that are no longer hot once the
+// cache grows past its limit
+func (p *Prewarmer) evictSummaries(hot []*storage.FileAccess) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.summaries) <= p.config.MaxSummaryEntries {
+		return
+	}
+	keep := make(map[string]bool, len(hot))
+	for _, f := range hot {
+		keep[f.Path] = true
+	}
+	for path := range p.summaries {
+		if !keep[path] {
+			delete(p.summaries, path)
+			delete(p.fresh, path)
+		}
+	}
+}
+
+// isIdle reports whether no query has been seen for IdleAfter
+func (p *Prewarmer) isIdle() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return time.Since(p.lastActivity) >= p.config.IdleAfter
+}
+
+// setError records the most recent prewarming error for the status view
+func (p *Prewarmer) setError(err error) {
+	p.mu.Lock()
+	p.status.LastError = err.Error()
+	p.mu.Unlock()
+}
@@ -0,0 +1,137 @@
+package prewarm
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/language"
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// FileSummary is a compact, LLM-free description of a file used as context
+type FileSummary struct {
+	Path         string    `json:"path"`
+	Language     string    `json:"language"`
+	Package      string    `json:"package,omitempty"`
+	Size         int64     `json:"size"`
+	LineCount    int       `json:"line_count"`
+	Functions    []string  `json:"functions"`
+	Types        []string  `json:"types"`
+	Imports      []string  `json:"imports"`
+	LastModified time.Time `json:"last_modified"`
+	WarmedAt     time.Time `json:"warmed_at"`
+}
+
+// IsCurrent reports whether the file is unchanged since it was summarized
+func (fs *FileSummary) IsCurrent() bool {
+	info, err := os.Stat(fs.Path)
+	return err == nil && info.ModTime().Equal(fs.LastModified) && info.Size() == fs.Size
+}
+
+// Text renders the summary for inclusion in a prompt
+func (fs *FileSummary) Text() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s (%s, %d lines)", fs.Path, fs.Language, fs.LineCount))
+	if fs.Package != "" {
+		b.WriteString(fmt.Sprintf(", package %s", fs.Package))
+	}
+	if len(fs.Types) > 0 {
+		b.WriteString(fmt.Sprintf("\n  types: %s", strings.Join(fs.Types, ", ")))
+	}
+	if len(fs.Functions) > 0 {
+		b.WriteString(fmt.Sprintf("\n  functions: %s", strings.Join(fs.Functions, ", ")))
+	}
+	return b.String()
+}
+
+// Summarize builds a summary from the file on disk. Go files are parsed;
+// other languages use the functions recorded by the indexer.
+func Summarize(path string, db *storage.SQLiteDB) (*FileSummary, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	summary := &FileSummary{
+		Path:         path,
+		Language:     language.FromExtension(path),
+		Size:         info.Size(),
+		LineCount:    bytes.Count(content, []byte("\n")) + 1,
+		LastModified: info.ModTime(),
+		WarmedAt:     time.Now(),
+	}
+	if summary.Language == "" {
+		summary.Language = strings.TrimPrefix(filepath.Ext(path), ".")
+	}
+
+	if summary.Language == "go" && summarizeGo(summary, content) {
+		return summary, nil
+	}
+
+	if db != nil {
+		if functions, err := db.GetFunctionsByFile(path); err == nil {
+			for _, fn := range functions {
+				summary.Functions = append(summary.Functions, fn.Name)
+			}
+		}
+	}
+	return summary, nil
+}
+
+// summarizeGo fills package, imports, types and functions from Go source
+func summarizeGo(summary *FileSummary, content []byte) bool {
+	file, err := parser.ParseFile(token.NewFileSet(), summary.Path, content, parser.SkipObjectResolution)
+	if err != nil {
+		return false
+	}
+
+	summary.Package = file.Name.Name
+	for _, imp := range file.Imports {
+		if path, err := strconv.Unquote(imp.Path.Value); err == nil {
+			summary.Imports = append(summary.Imports, path)
+		}
+	}
+
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			name := d.Name.Name
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				name = receiverName(d.Recv.List[0].Type) + "." + name
+			}
+			summary.Functions = append(summary.Functions, name)
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				if ts, ok := spec.(*ast.TypeSpec); ok {
+					summary.Types = append(summary.Types, ts.Name.Name)
+				}
+			}
+		}
+	}
+	return true
+}
+
+// receiverName returns the type name of a method receiver
+func receiverName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(t.X)
+	case *ast.Ident:
+		return t.Name
+	case *ast.IndexExpr:
+		return receiverName(t.X)
+	}
+	return "?"
+}
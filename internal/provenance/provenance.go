@@ -0,0 +1,352 @@
+// Package provenance records which regions of the project's files were
+// written by applying generated changes, and finds them again after the
+// files move on.
+package provenance
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/language"
+	"github.com/yourusername/useq-ai-assistant/models"
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// Config controls provenance tracking
+type Config struct {
+	Enabled bool `json:"enabled"`
+	Markers bool `json:"markers"` // add a comment naming the answer above generated code
+}
+
+// DefaultConfig returns provenance defaults: tracked in the ledger, without
+// markers in the code
+func DefaultConfig() Config {
+	return Config{Enabled: true}
+}
+
+// markerTag starts every marker comment
+const markerTag = "useq:generated"
+
+// markerPattern matches a marker comment line
+var markerPattern = regexp.MustCompile(`^(\s*)(?://|#|--)\s*` + regexp.QuoteMeta(markerTag) + `\s+response=(\S+)(?:\s+model=(\S+))?(?:\s+at=(\S+))?`)
+
+// commentPrefixes are the line comment leaders of each language. Files of
+// other languages, such as JSON or Markdown, have no line comments and get
+// no marker.
+var commentPrefixes = map[string]string{
+	"go":         "//",
+	"c":          "//",
+	"cpp":        "//",
+	"csharp":     "//",
+	"java":       "//",
+	"javascript": "//",
+	"typescript": "//",
+	"rust":       "//",
+	"kotlin":     "//",
+	"swift":      "//",
+	"scala":      "//",
+	"php":        "//",
+	"python":     "#",
+	"ruby":       "#",
+	"r":          "#",
+	"bash":       "#",
+	"yaml":       "#",
+	"sql":        "--",
+}
+
+// Source identifies the answer a change came from
+type Source struct {
+	ResponseID string
+	QueryID    string
+	Provider   string
+	Model      string
+	At         time.Time
+}
+
+// SourceOf describes a response as the source of its changes
+func SourceOf(response *models.Response) Source {
+	source := Source{
+		ResponseID: response.ID,
+		QueryID:    response.QueryID,
+		Provider:   response.Provider,
+		Model:      response.TokenUsage.Model,
+		At:         time.Now(),
+	}
+	if source.Model == "" {
+		source.Model = response.Cost.Model
+	}
+	if source.Provider == "" && response.Content.Code != nil {
+		source.Provider = response.Content.Code.Provider
+	}
+	return source
+}
+
+// Marker returns the comment line placed above code generated for source in
+// file, indented like indent, or "" when file's language has no line
+// comments
+func Marker(file, indent string, source Source) string {
+	prefix, ok := commentPrefixes[language.FromExtension(file)]
+	// go.sum is tagged as Go but takes no comments
+	if !ok || filepath.Ext(file) == ".sum" {
+		return ""
+	}
+	marker := fmt.Sprintf("%s%s %s response=%s", indent, prefix, markerTag, source.ResponseID)
+	if model := firstNonEmpty(source.Model, source.Provider); model != "" {
+		marker += " model=" + strings.ReplaceAll(model, " ", "_")
+	}
+	return marker + " at=" + source.At.UTC().Format("2006-01-02T15:04:05Z")
+}
+
+// AddMarkers puts a marker comment above the new content of each added or
+// replaced region of file. Files without line comments are left unmarked.
+func AddMarkers(file string, changes []models.CodeChange, source Source) []models.CodeChange {
+	if Marker(file, "", source) == "" {
+		return changes
+	}
+	marked := make([]models.CodeChange, len(changes))
+	for i, change := range changes {
+		marked[i] = change
+		if change.Type == models.ChangeTypeDelete || change.NewContent == "" {
+			continue
+		}
+		first := strings.SplitN(change.NewContent, "\n", 2)[0]
+		indent := first[:len(first)-len(strings.TrimLeft(first, " \t"))]
+		marked[i].NewContent = Marker(file, indent, source) + "\n" + change.NewContent
+	}
+	return marked
+}
+
+// Regions returns the ledger records for changes applied to file: where
+// each change's new lines sit once every change is applied
+func Regions(file string, changes []models.CodeChange, source Source) []*storage.ProvenanceRecord {
+	sorted := append([]models.CodeChange{}, changes...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].StartLine < sorted[j].StartLine })
+
+	var records []*storage.ProvenanceRecord
+	offset := 0
+	for _, change := range sorted {
+		oldLines := change.EndLine - change.StartLine + 1
+		var newLines int
+		switch change.Type {
+		case models.ChangeTypeAdd:
+			oldLines = 0
+			newLines = lineCount(change.NewContent)
+		case models.ChangeTypeDelete:
+			newLines = 0
+		default:
+			newLines = lineCount(change.NewContent)
+		}
+		start := change.StartLine + offset
+		content := change.NewContent
+		if change.Type == models.ChangeTypeDelete {
+			content = ""
+		}
+		records = append(records, &storage.ProvenanceRecord{
+			File:       file,
+			StartLine:  start,
+			EndLine:    start + newLines - 1,
+			ChangeType: string(change.Type),
+			Content:    content,
+			ResponseID: source.ResponseID,
+			QueryID:    source.QueryID,
+			Provider:   source.Provider,
+			Model:      source.Model,
+			CreatedAt:  source.At,
+		})
+		offset += newLines - oldLines
+	}
+	return records
+}
+
+// Region statuses
+const (
+	StatusUnchanged = "unchanged" // still at the recorded lines
+	StatusMoved     = "moved"     // the same lines, elsewhere in the file
+	StatusModified  = "modified"  // edited or removed since
+	StatusDeleted   = "deleted"   // the change removed lines
+	StatusMarked    = "marked"    // found by its marker comment only
+)
+
+// Region is a recorded region located in the current file
+type Region struct {
+	Record    *storage.ProvenanceRecord
+	StartLine int // 0 when it can no longer be found
+	EndLine   int
+	Status    string
+}
+
+// Locate finds each recorded region in the current content of its file,
+// newest records first so later rewrites claim lines before the earlier
+// answers they replaced, and adds the regions only a marker comment
+// records
+func Locate(content string, records []*storage.ProvenanceRecord) []*Region {
+	lines := strings.Split(content, "\n")
+	claimed := make([]bool, len(lines)+1)
+	var regions []*Region
+	for _, record := range records {
+		region := &Region{Record: record, Status: StatusModified}
+		regions = append(regions, region)
+		if record.ChangeType == string(models.ChangeTypeDelete) {
+			region.Status = StatusDeleted
+			continue
+		}
+		want := strings.Split(record.Content, "\n")
+		if start := findBlock(lines, want, record.StartLine, claimed); start > 0 {
+			region.StartLine, region.EndLine = start, start+len(want)-1
+			region.Status = StatusMoved
+			if start == record.StartLine {
+				region.Status = StatusUnchanged
+			}
+			for line := region.StartLine; line <= region.EndLine; line++ {
+				claimed[line] = true
+			}
+		}
+	}
+
+	known := make(map[string]bool)
+	for _, region := range regions {
+		if region.StartLine > 0 {
+			known[fmt.Sprintf("%s:%d", region.Record.ResponseID, region.StartLine)] = true
+		}
+	}
+	for i, line := range lines {
+		match := markerPattern.FindStringSubmatch(line)
+		if match == nil || known[fmt.Sprintf("%s:%d", match[2], i+1)] {
+			continue
+		}
+		end := markedBlockEnd(lines, i, match[1])
+		at, _ := time.Parse("2006-01-02T15:04:05Z", match[4])
+		regions = append(regions, &Region{
+			Record: &storage.ProvenanceRecord{
+				StartLine:  i + 1,
+				EndLine:    end,
+				ResponseID: match[2],
+				Model:      match[3],
+				CreatedAt:  at,
+			},
+			StartLine: i + 1,
+			EndLine:   end,
+			Status:    StatusMarked,
+		})
+	}
+	sort.SliceStable(regions, func(i, j int) bool {
+		if (regions[i].StartLine == 0) != (regions[j].StartLine == 0) {
+			return regions[j].StartLine == 0
+		}
+		return regions[i].StartLine < regions[j].StartLine
+	})
+	return regions
+}
+
+// Report is the provenance of one file as it stands
+type Report struct {
+	File     string
+	Lines    int // lines in the file
+	Authored int // lines inside located regions
+	Regions  []*Region
+}
+
+// Inspect locates the recorded regions of file in its current content
+func Inspect(file, content string, records []*storage.ProvenanceRecord) *Report {
+	regions := Locate(content, records)
+	return &Report{
+		File:     file,
+		Lines:    lineCount(strings.TrimSuffix(content, "\n")),
+		Authored: AuthoredLines(regions),
+		Regions:  regions,
+	}
+}
+
+// AuthoredLines counts the distinct lines of the located regions
+func AuthoredLines(regions []*Region) int {
+	lines := make(map[int]bool)
+	for _, region := range regions {
+		for line := region.StartLine; region.StartLine > 0 && line <= region.EndLine; line++ {
+			lines[line] = true
+		}
+	}
+	return len(lines)
+}
+
+// findBlock returns the 1-based line where want starts in lines, preferring
+// the occurrence nearest to hint and skipping lines already claimed, or 0.
+// A block of short lines such as a lone brace is only looked for at hint,
+// since it would match all over the file.
+func findBlock(lines, want []string, hint int, claimed []bool) int {
+	if !distinctive(want) {
+		if hint >= 1 && hint+len(want)-1 <= len(lines) && blockAt(lines, want, hint, claimed) {
+			return hint
+		}
+		return 0
+	}
+	best := 0
+	for start := 1; start+len(want)-1 <= len(lines); start++ {
+		if !blockAt(lines, want, start, claimed) {
+			continue
+		}
+		if best == 0 || abs(start-hint) < abs(best-hint) {
+			best = start
+		}
+	}
+	return best
+}
+
+func blockAt(lines, want []string, start int, claimed []bool) bool {
+	for i, line := range want {
+		if claimed[start+i] || lines[start+i-1] != line {
+			return false
+		}
+	}
+	return true
+}
+
+// distinctive reports whether a block has a line long enough to identify it
+func distinctive(block []string) bool {
+	for _, line := range block {
+		if len(strings.TrimSpace(line)) >= 12 {
+			return true
+		}
+	}
+	return false
+}
+
+// markedBlockEnd returns the last line of the code under a marker: the
+// following lines up to the first blank line or one indented less
+func markedBlockEnd(lines []string, marker int, indent string) int {
+	end := marker + 1
+	for i := marker + 1; i < len(lines); i++ {
+		line := lines[i]
+		if strings.TrimSpace(line) == "" || !strings.HasPrefix(line, indent) {
+			break
+		}
+		end = i + 1
+	}
+	return end
+}
+
+func lineCount(content string) int {
+	if content == "" {
+		return 0
+	}
+	return strings.Count(content, "\n") + 1
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
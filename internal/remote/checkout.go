@@ -0,0 +1,117 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Headers a server sets on query responses so thin clients can map results
+// onto their own checkout
+const (
+	HeaderProjectRoot = "X-Useq-Project-Root"
+	HeaderIndexCommit = "X-Useq-Index-Commit"
+)
+
+// gitTimeout bounds each git command
+const gitTimeout = 5 * time.Second
+
+// git runs a git command in dir and returns its trimmed output
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, gitTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// HeadCommit returns the commit checked out in dir, or "" outside a git
+// repository
+func HeadCommit(ctx context.Context, dir string) string {
+	commit, err := git(ctx, dir, "rev-parse", "HEAD")
+	if err != nil {
+		return ""
+	}
+	return commit
+}
+
+// CommitCache remembers a directory's HEAD for a while, so a busy server does
+// not run git for every query
+type CommitCache struct {
+	dir     string
+	ttl     time.Duration
+	commit  string
+	checked time.Time
+	mu      sync.Mutex
+}
+
+// NewCommitCache returns a cache of dir's HEAD, refreshed after ttl
+func NewCommitCache(dir string, ttl time.Duration) *CommitCache {
+	return &CommitCache{dir: dir, ttl: ttl}
+}
+
+// Commit returns the cached HEAD, refreshing it when it is older than the ttl
+func (c *CommitCache) Commit(ctx context.Context) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.checked.IsZero() || time.Since(c.checked) > c.ttl {
+		c.commit = HeadCommit(ctx, c.dir)
+		c.checked = time.Now()
+	}
+	return c.commit
+}
+
+// Checkout describes a developer's working tree relative to the commit a
+// server indexed
+type Checkout struct {
+	Root    string          // top level of the local repository
+	Commit  string          // local HEAD
+	Known   bool            // the server's commit exists locally
+	Changed map[string]bool // repository-relative paths that differ from the server's commit
+	NotGit  bool            // the local tree is not a git repository; nothing could be compared
+}
+
+// CompareCheckout finds the files of the repository at dir that differ from
+// serverCommit: committed and uncommitted changes, and untracked files. When
+// the commit is unknown locally (not fetched yet), nothing can be compared
+// and Known is false.
+func CompareCheckout(ctx context.Context, dir, serverCommit string) *Checkout {
+	root, err := git(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return &Checkout{Root: dir, NotGit: true}
+	}
+	checkout := &Checkout{Root: root, Commit: HeadCommit(ctx, root), Changed: make(map[string]bool)}
+	if serverCommit == "" {
+		return checkout
+	}
+	if _, err := git(ctx, root, "cat-file", "-e", serverCommit+"^{commit}"); err != nil {
+		return checkout
+	}
+	checkout.Known = true
+
+	diff, err := git(ctx, root, "diff", "--name-only", serverCommit)
+	if err == nil {
+		addLines(checkout.Changed, diff)
+	}
+	untracked, err := git(ctx, root, "ls-files", "--others", "--exclude-standard")
+	if err == nil {
+		addLines(checkout.Changed, untracked)
+	}
+	return checkout
+}
+
+func addLines(set map[string]bool, output string) {
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			set[filepath.ToSlash(line)] = true
+		}
+	}
+}
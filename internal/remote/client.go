@@ -0,0 +1,281 @@
+// Package remote lets a CLI act as a thin client of a central useQ server
+// that owns the index and vector store. Answers are mapped onto the local
+// checkout: paths are rewritten to the local project root, and cited files
+// that differ from the commit the server indexed are flagged.
+package remote
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/calllog"
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// Config points the CLI at a central server
+type Config struct {
+	URL     string        `json:"url"`     // e.g. http://useq.internal:8484; empty keeps the CLI local
+	Token   string        `json:"-"`       // the user's API token, from USEQ_REMOTE_TOKEN
+	Session string        `json:"session"` // server-side session, per user; defaults to "default"
+	Timeout time.Duration `json:"timeout"` // per query; must cover the slowest LLM call
+}
+
+// Enabled reports whether queries go to a server
+func (c Config) Enabled() bool {
+	return strings.TrimSpace(c.URL) != ""
+}
+
+// DefaultTimeout matches the server's default write timeout
+const DefaultTimeout = 3 * time.Minute
+
+// queryRequest is the body of the server's POST /v1/query
+type queryRequest struct {
+	Query       string `json:"query"`
+	Session     string `json:"session,omitempty"`
+	Language    string `json:"language,omitempty"`
+	CurrentFile string `json:"current_file,omitempty"`
+	CurrentLine int    `json:"current_line,omitempty"`
+	NoClarify   bool   `json:"no_clarify,omitempty"`
+}
+
+// Client sends queries to a central server
+type Client struct {
+	config    Config
+	localRoot string
+	http      *http.Client
+}
+
+// NewClient creates a client answering for the checkout at localRoot
+func NewClient(config Config, localRoot string) (*Client, error) {
+	if !config.Enabled() {
+		return nil, fmt.Errorf("remote.url is required")
+	}
+	if config.Token == "" {
+		return nil, fmt.Errorf("remote mode needs an API token; set USEQ_REMOTE_TOKEN")
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultTimeout
+	}
+	config.URL = strings.TrimRight(strings.TrimSpace(config.URL), "/")
+	if !strings.Contains(config.URL, "://") {
+		config.URL = "http://" + config.URL
+	}
+	if abs, err := filepath.Abs(localRoot); err == nil {
+		localRoot = abs
+	}
+	return &Client{config: config, localRoot: localRoot, http: &http.Client{
+		Timeout:   config.Timeout,
+		Transport: calllog.Transport(calllog.ServiceRemote, "", nil),
+	}}, nil
+}
+
+// URL returns the server the client queries
+func (c *Client) URL() string {
+	return c.config.URL
+}
+
+// Ping checks the server is reachable
+func (c *Client) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.config.URL+"/health", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("server %s unreachable: %w", c.config.URL, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("server %s unhealthy: %s", c.config.URL, resp.Status)
+	}
+	return nil
+}
+
+// ProcessQuery answers a query on the server and maps the answer onto the
+// local checkout
+func (c *Client) ProcessQuery(ctx context.Context, query *models.Query) (*models.Response, error) {
+	body, err := json.Marshal(queryRequest{
+		Query:       query.UserInput,
+		Session:     c.config.Session,
+		Language:    query.Language,
+		CurrentFile: c.serverRelative(query.Context.CurrentFile),
+		CurrentLine: query.Context.CurrentLine,
+		NoClarify:   query.Metadata["no_clarify"] == "true",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.config.URL+"/v1/query", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.config.Token)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("remote query failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read remote answer: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var failure struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(data, &failure) == nil && failure.Error != "" {
+			return nil, fmt.Errorf("server %s: %s", resp.Status, failure.Error)
+		}
+		return nil, fmt.Errorf("server %s", resp.Status)
+	}
+
+	var response models.Response
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("invalid remote answer: %w", err)
+	}
+	c.reconcile(ctx, &response, resp.Header.Get(HeaderProjectRoot), resp.Header.Get(HeaderIndexCommit))
+	return &response, nil
+}
+
+// serverRelative makes a local path relative to the project root, which the
+// server resolves against its own
+func (c *Client) serverRelative(path string) string {
+	if path == "" || !filepath.IsAbs(path) {
+		return path
+	}
+	if rel, err := filepath.Rel(c.localRoot, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
+
+// lineSuffix matches the :line or :start-end suffix of a cited source
+var lineSuffix = regexp.MustCompile(`:\d+(-\d+)?$`)
+
+// reconcile rewrites the server's paths to local ones and flags cited files
+// that differ locally from the commit the server indexed
+func (c *Client) reconcile(ctx context.Context, response *models.Response, serverRoot, serverCommit string) {
+	checkout := CompareCheckout(ctx, c.localRoot, serverCommit)
+	stale := make(map[string]bool)
+
+	// mapPath returns the local form of a server path and whether the local
+	// file differs from the server's
+	mapPath := func(path string) string {
+		if path == "" {
+			return path
+		}
+		suffix := lineSuffix.FindString(path)
+		file := strings.TrimSuffix(path, suffix)
+
+		rel := filepath.ToSlash(file)
+		if serverRoot != "" && filepath.IsAbs(file) {
+			r, err := filepath.Rel(serverRoot, file)
+			if err != nil || strings.HasPrefix(r, "..") {
+				return path // outside the project, e.g. dependency source
+			}
+			rel = filepath.ToSlash(r)
+		}
+		local := filepath.Join(c.localRoot, filepath.FromSlash(rel))
+
+		if repoRel, err := filepath.Rel(checkout.Root, local); err == nil && checkout.Changed[filepath.ToSlash(repoRel)] {
+			stale[local] = true
+		} else if _, err := os.Stat(local); err != nil {
+			stale[local] = true
+		}
+		return local + suffix
+	}
+
+	if search := response.Content.Search; search != nil {
+		for i := range search.Results {
+			result := &search.Results[i]
+			if result.Origin != "" {
+				continue
+			}
+			result.File = mapPath(result.File)
+			if stale[result.File] {
+				result.Stale = staleReason(result.File)
+			}
+			for j := range result.Usage {
+				result.Usage[j].File = mapPath(result.Usage[j].File)
+			}
+		}
+	}
+	for i := range response.Content.References {
+		response.Content.References[i].File = mapPath(response.Content.References[i].File)
+	}
+	for i := range response.Content.Suggestions {
+		response.Content.Suggestions[i].File = mapPath(response.Content.Suggestions[i].File)
+	}
+	for i := range response.Content.Files {
+		change := &response.Content.Files[i]
+		change.Path = mapPath(change.Path)
+		for j := range change.Changes {
+			change.Changes[j].File = mapPath(change.Changes[j].File)
+		}
+	}
+	if code := response.Content.Code; code != nil {
+		for i := range code.Changes {
+			code.Changes[i].File = mapPath(code.Changes[i].File)
+		}
+	}
+	for i, source := range response.Metadata.Sources {
+		response.Metadata.Sources[i] = mapPath(source)
+	}
+
+	// The server's own staleness is about its checkout; report ours instead
+	response.Metadata.StaleSources = response.Metadata.StaleSources[:0]
+	for path := range stale {
+		response.Metadata.StaleSources = append(response.Metadata.StaleSources, path)
+	}
+	sort.Strings(response.Metadata.StaleSources)
+
+	if response.Metadata.Reasoning != "" {
+		response.Metadata.Reasoning += "\n"
+	}
+	response.Metadata.Reasoning += checkoutNote(serverCommit, checkout)
+}
+
+// staleReason says why a cited local file differs from the server's
+func staleReason(path string) string {
+	if _, err := os.Stat(path); err != nil {
+		return "deleted"
+	}
+	return "modified"
+}
+
+// checkoutNote records how the answer relates to the local checkout
+func checkoutNote(serverCommit string, checkout *Checkout) string {
+	switch {
+	case serverCommit == "":
+		return "Answered remotely; the server's checkout is not a git repository, so results could not be compared with yours."
+	case checkout.NotGit:
+		return fmt.Sprintf("Answered remotely from commit %s; this directory is not a git repository, so results could not be compared with it.", short(serverCommit))
+	case !checkout.Known:
+		return fmt.Sprintf("Answered remotely from commit %s, which is not in your repository yet; fetch it to compare results with your checkout.", short(serverCommit))
+	case checkout.Commit == serverCommit && len(checkout.Changed) == 0:
+		return fmt.Sprintf("Answered remotely from commit %s, which matches your checkout.", short(serverCommit))
+	default:
+		return fmt.Sprintf("Answered remotely from commit %s; %d file(s) in your checkout differ from it.", short(serverCommit), len(checkout.Changed))
+	}
+}
+
+func short(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
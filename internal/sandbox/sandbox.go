@@ -0,0 +1,177 @@
+// Package sandbox runs external commands on behalf of agents and CLI tools
+// under a fixed policy: an allowlist of binaries, the project root as the
+// working directory, a scrubbed environment, a timeout and a cap on captured
+// output. Commands are executed directly, never through a shell.
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Policy limits what a Runner may execute
+type Policy struct {
+	AllowedCommands []string      `json:"allowed_commands"` // bare binary names looked up on PATH
+	Timeout         time.Duration `json:"timeout"`
+	MaxOutput       int           `json:"max_output"` // bytes of combined output kept
+	Env             []string      `json:"env"`        // environment variables passed through; everything else is dropped
+}
+
+// DefaultPolicy allows the Go toolchain only
+func DefaultPolicy() Policy {
+	return Policy{
+		AllowedCommands: []string{"go"},
+		Timeout:         2 * time.Minute,
+		MaxOutput:       256 * 1024,
+		Env: []string{
+			"PATH", "HOME", "TMPDIR", "GOPATH", "GOROOT", "GOCACHE", "GOMODCACHE",
+			"GOFLAGS", "GOPROXY", "GOPRIVATE", "GONOSUMDB", "GOOS", "GOARCH", "CGO_ENABLED",
+		},
+	}
+}
+
+// Result is the outcome of a command that ran. A non-zero exit code is a
+// result, not an error.
+type Result struct {
+	Command   string        `json:"command"`
+	ExitCode  int           `json:"exit_code"`
+	Output    string        `json:"output"` // combined stdout and stderr
+	Truncated bool          `json:"truncated"`
+	TimedOut  bool          `json:"timed_out"`
+	Duration  time.Duration `json:"duration"`
+}
+
+// Runner executes allowlisted commands inside a project root
+type Runner struct {
+	root    string
+	policy  Policy
+	allowed map[string]bool
+}
+
+// NewRunner creates a runner confined to root
+func NewRunner(root string, policy Policy) (*Runner, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve sandbox root: %w", err)
+	}
+	info, err := os.Stat(absRoot)
+	if err != nil {
+		return nil, fmt.Errorf("sandbox root unavailable: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("sandbox root %s is not a directory", absRoot)
+	}
+
+	defaults := DefaultPolicy()
+	if policy.Timeout <= 0 {
+		policy.Timeout = defaults.Timeout
+	}
+	if policy.MaxOutput <= 0 {
+		policy.MaxOutput = defaults.MaxOutput
+	}
+	if policy.Env == nil {
+		policy.Env = defaults.Env
+	}
+
+	allowed := make(map[string]bool, len(policy.AllowedCommands))
+	for _, name := range policy.AllowedCommands {
+		allowed[name] = true
+	}
+	return &Runner{root: absRoot, policy: policy, allowed: allowed}, nil
+}
+
+// Root returns the directory commands run in
+func (r *Runner) Root() string {
+	return r.root
+}
+
+// Run executes name with args in the project root. It fails without running
+// anything when the command is not allowlisted or an argument points outside
+// the root.
+func (r *Runner) Run(ctx context.Context, name string, args ...string) (*Result, error) {
+	if strings.ContainsRune(name, filepath.Separator) || !r.allowed[name] {
+		return nil, fmt.Errorf("command %q is not allowed in the sandbox", name)
+	}
+	for _, arg := range args {
+		if filepath.IsAbs(arg) && !r.contains(arg) {
+			return nil, fmt.Errorf("argument %q is outside the project root", arg)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.policy.Timeout)
+	defer cancel()
+
+	output := &limitedBuffer{limit: r.policy.MaxOutput}
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = r.root
+	cmd.Env = r.environment()
+	cmd.Stdout = output
+	cmd.Stderr = output
+	cmd.WaitDelay = time.Second // don't wait on children still holding the output pipes
+
+	result := &Result{Command: strings.Join(append([]string{name}, args...), " ")}
+	start := time.Now()
+	err := cmd.Run()
+	result.Duration = time.Since(start)
+	result.Output = output.String()
+	result.Truncated = output.truncated
+	result.TimedOut = ctx.Err() == context.DeadlineExceeded
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+	case result.TimedOut:
+		result.ExitCode = -1
+	default:
+		return nil, fmt.Errorf("failed to run %s: %w", name, err)
+	}
+	return result, nil
+}
+
+// contains reports whether an absolute path is inside the root
+func (r *Runner) contains(path string) bool {
+	rel, err := filepath.Rel(r.root, filepath.Clean(path))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// environment returns the passed-through variables that are set
+func (r *Runner) environment() []string {
+	var env []string
+	for _, key := range r.policy.Env {
+		if value, ok := os.LookupEnv(key); ok {
+			env = append(env, key+"="+value)
+		}
+	}
+	return env
+}
+
+// limitedBuffer keeps the first limit bytes written to it and drops the rest
+type limitedBuffer struct {
+	buffer    bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.buffer.Len(); room < len(p) {
+		b.truncated = true
+		if room > 0 {
+			b.buffer.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.buffer.Write(p)
+}
+
+func (b *limitedBuffer) String() string {
+	return b.buffer.String()
+}
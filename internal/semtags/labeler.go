@@ -0,0 +1,93 @@
+package semtags
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/internal/llm"
+	"github.com/yourusername/useq-ai-assistant/internal/promptguard"
+)
+
+// labelExcerpt is how much of each chunk the model sees
+const labelExcerpt = 1200
+
+// Chunk is a piece of code to label
+type Chunk struct {
+	Path    string
+	Content string
+}
+
+// Labeler tags chunks in batches, returning one tag list per chunk
+type Labeler interface {
+	Label(ctx context.Context, chunks []Chunk) ([][]string, error)
+}
+
+// LLMLabeler asks a model for the tags of a batch of chunks in one call
+type LLMLabeler struct {
+	manager *llm.Manager
+	model   string
+}
+
+// NewLLMLabeler creates a labeler on manager; an empty model lets routing
+// pick one
+func NewLLMLabeler(manager *llm.Manager, model string) *LLMLabeler {
+	return &LLMLabeler{manager: manager, model: model}
+}
+
+// labelSchema constrains the answer to one list of known tags per chunk
+var labelSchema = &llm.ResponseSchema{
+	Name: "chunk_tags",
+	Schema: json.RawMessage(`{"type":"object","properties":{"labels":{"type":"array","items":{"type":"object",` +
+		`"properties":{"chunk":{"type":"integer"},"tags":{"type":"array","items":{"type":"string","enum":["` +
+		strings.Join(All, `","`) + `"]}}},"required":["chunk","tags"],"additionalProperties":false}}},` +
+		`"required":["labels"],"additionalProperties":false}`),
+	Strict: true,
+}
+
+// Label returns the model's tags for each chunk. Unknown tags and chunk
+// numbers are dropped; chunks the model skips get no tags.
+func (l *LLMLabeler) Label(ctx context.Context, chunks []Chunk) ([][]string, error) {
+	if len(chunks) == 0 {
+		return nil, nil
+	}
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "Tag each code chunk with the concerns it deals with, from: %s. A chunk may have none.\n\n", strings.Join(All, ", "))
+	for i, chunk := range chunks {
+		content := chunk.Content
+		if len(content) > labelExcerpt {
+			content = content[:labelExcerpt]
+		}
+		fmt.Fprintf(&prompt, "Chunk %d:\n%s\n", i, promptguard.Wrap(chunk.Path, content))
+	}
+
+	_, raw, err := l.manager.GenerateStructured(ctx, &llm.GenerationRequest{
+		SystemPrompt:   "You label source code for a code search index. Answer only with the requested JSON.",
+		Messages:       []llm.Message{{Role: "user", Content: prompt.String()}},
+		Model:          l.model,
+		MaxTokens:      40 * len(chunks),
+		ResponseSchema: labelSchema,
+		Metadata:       map[string]string{"task": "semantic_tags", "complexity": "simple"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to label chunks: %w", err)
+	}
+
+	var answer struct {
+		Labels []struct {
+			Chunk int      `json:"chunk"`
+			Tags  []string `json:"tags"`
+		} `json:"labels"`
+	}
+	if err := json.Unmarshal(raw, &answer); err != nil {
+		return nil, fmt.Errorf("failed to read chunk labels: %w", err)
+	}
+	labels := make([][]string, len(chunks))
+	for _, label := range answer.Labels {
+		if label.Chunk >= 0 && label.Chunk < len(chunks) {
+			labels[label.Chunk] = Merge(labels[label.Chunk], label.Tags)
+		}
+	}
+	return labels, nil
+}
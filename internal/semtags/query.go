@@ -0,0 +1,48 @@
+package semtags
+
+import (
+	"regexp"
+	"strings"
+)
+
+// explicitTag matches tag:db or tags:db,http in a query
+var explicitTag = regexp.MustCompile(`(?i)(^|\s)tags?:([a-z,]+)`)
+
+// phraseTag matches a concern named as the kind of code wanted:
+// "concurrency code", "database logic", "http handlers"
+var phraseTag = regexp.MustCompile(`(?i)\b([a-z]+)\s+(code|logic|functions?|handlers?|calls?|paths?|layer)\b`)
+
+// tagWords are the words a query may name a tag by
+var tagWords = map[string]string{
+	"auth": TagAuth, "authentication": TagAuth, "authorization": TagAuth, "login": TagAuth,
+	"db": TagDB, "database": TagDB, "sql": TagDB, "persistence": TagDB, "storage": TagDB,
+	"http": TagHTTP, "web": TagHTTP, "api": TagHTTP, "networking": TagHTTP,
+	"concurrency": TagConcurrency, "concurrent": TagConcurrency, "goroutine": TagConcurrency, "async": TagConcurrency, "threading": TagConcurrency,
+	"crypto": TagCrypto, "cryptography": TagCrypto, "encryption": TagCrypto,
+	"fs": TagFS, "filesystem": TagFS, "file": TagFS,
+	"config": TagConfig, "configuration": TagConfig,
+	"logging": TagLogging, "log": TagLogging,
+}
+
+// ParseQuery returns the tags a query restricts itself to and the query
+// without its explicit tag:x terms. Tags come from tag:x terms or from a
+// concern named as the kind of code wanted ("find concurrency code touching
+// channels"); merely mentioning a concern does not filter.
+func ParseQuery(input string) ([]string, string) {
+	var tags []string
+	for _, match := range explicitTag.FindAllStringSubmatch(input, -1) {
+		for _, word := range strings.Split(strings.ToLower(match[2]), ",") {
+			if tag, ok := tagWords[word]; ok {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	rest := strings.Join(strings.Fields(explicitTag.ReplaceAllString(input, " ")), " ")
+
+	for _, match := range phraseTag.FindAllStringSubmatch(rest, -1) {
+		if tag, ok := tagWords[strings.ToLower(match[1])]; ok {
+			tags = append(tags, tag)
+		}
+	}
+	return Merge(tags), rest
+}
@@ -0,0 +1,171 @@
+// Package semtags labels code chunks with what they deal with (auth, db,
+// http, concurrency...) so searches and reports can be narrowed to one
+// concern. Keyword heuristics tag every chunk at index time; an optional
+// LLM pass labels chunks in batches on top of them.
+package semtags
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Tags
+const (
+	TagAuth        = "auth"
+	TagDB          = "db"
+	TagHTTP        = "http"
+	TagConcurrency = "concurrency"
+	TagCrypto      = "crypto"
+	TagFS          = "fs"
+	TagConfig      = "config"
+	TagLogging     = "logging"
+)
+
+// All lists every tag, in display order
+var All = []string{TagAuth, TagDB, TagHTTP, TagConcurrency, TagCrypto, TagFS, TagConfig, TagLogging}
+
+// Config controls chunk tagging during indexing
+type Config struct {
+	Enabled      bool   `json:"enabled"`
+	MinScore     int    `json:"min_score"`      // keyword weight a chunk needs for a tag
+	LLMLabeling  bool   `json:"llm_labeling"`   // also ask a model to label chunks
+	LLMBatchSize int    `json:"llm_batch_size"` // chunks labeled per model call
+	LLMModel     string `json:"llm_model"`      // model for labeling; empty lets routing pick
+}
+
+// DefaultConfig returns tagging defaults: heuristics only
+func DefaultConfig() Config {
+	return Config{
+		Enabled:      true,
+		MinScore:     3,
+		LLMLabeling:  false,
+		LLMBatchSize: 20,
+	}
+}
+
+// signal is a keyword pattern and how strongly it suggests its tag
+type signal struct {
+	pattern *regexp.Regexp
+	weight  int
+}
+
+// signals are the keyword heuristics per tag. Imports and API calls weigh
+// more than identifiers, which may only mention the concern in passing.
+var signals = map[string][]signal{
+	TagAuth: {
+		{regexp.MustCompile(`golang\.org/x/oauth2|github\.com/golang-jwt|"github\.com/dgrijalva/jwt-go"|passport|flask_login|spring\.security`), 3},
+		{regexp.MustCompile(`(?i)\b(authenticat\w*|authoriz\w*|oauth2?|jwt|bearer|login|logout|signin|signup|credentials?|passwords?|permissions?|rbac|sessionToken|accessToken|refreshToken)\b`), 1},
+		{regexp.MustCompile(`(?i)Header\.(Get|Set)\("Authorization"\)|bcrypt\.(CompareHashAndPassword|GenerateFromPassword)`), 3},
+	},
+	TagDB: {
+		{regexp.MustCompile(`"database/sql"|gorm\.io|github\.com/jmoiron/sqlx|go\.mongodb\.org|github\.com/redis|github\.com/lib/pq|github\.com/jackc/pgx|mattn/go-sqlite3|sqlalchemy|psycopg|java\.sql`), 3},
+		{regexp.MustCompile(`\.(Query|QueryRow|QueryContext|Exec|ExecContext|Prepare|Begin|BeginTx)\(`), 2},
+		{regexp.MustCompile(`(?i)\b(SELECT\s+.+\s+FROM|INSERT\s+INTO|UPDATE\s+\w+\s+SET|DELETE\s+FROM|CREATE\s+TABLE)\b`), 2},
+		{regexp.MustCompile(`(?i)\b(database|sqlite|postgres|mysql|mongodb|redis|transaction|migrations?)\b`), 1},
+	},
+	TagHTTP: {
+		{regexp.MustCompile(`"net/http"|github\.com/gin-gonic|github\.com/labstack/echo|github\.com/gorilla/mux|github\.com/go-chi|express\(\)|from flask|fastapi|requests\.(get|post)`), 3},
+		{regexp.MustCompile(`http\.(ResponseWriter|Request|Client|Handler|HandlerFunc|ListenAndServe|NewRequest\w*|Get|Post)\b|\bfetch\(`), 2},
+		{regexp.MustCompile(`(?i)\b(endpoints?|handlers?|middleware|routes?|router|status\s*code|websocket|rest\s*api|url)\b`), 1},
+	},
+	TagConcurrency: {
+		{regexp.MustCompile(`"sync"|"sync/atomic"|golang\.org/x/sync|\bimport\s+threading\b|\bimport\s+asyncio\b|java\.util\.concurrent`), 3},
+		{regexp.MustCompile(`\bgo\s+(func\b|\w+(\.\w+)*\()|\bselect\s*\{|<-\s*\w+|\w+\s*<-|\bmake\(chan\b|\bchan\s+\w+|sync\.(Mutex|RWMutex|WaitGroup|Once|Cond|Map|Pool)|atomic\.\w+\(|errgroup\.`), 2},
+		{regexp.MustCompile(`(?i)\b(goroutines?|channels?|mutex|semaphore|concurren\w*|parallel\w*|workers?|deadlock|race|lock|unlock|threads?|async|await)\b`), 1},
+	},
+	TagCrypto: {
+		{regexp.MustCompile(`"crypto/\w+"|golang\.org/x/crypto|\bimport\s+hashlib\b|javax\.crypto`), 3},
+		{regexp.MustCompile(`(?i)\b(sha1|sha256|sha512|md5|hmac|aes|rsa|ecdsa|ed25519|cipher|encrypt\w*|decrypt\w*|signature|nonce|tls)\b`), 1},
+	},
+	TagFS: {
+		{regexp.MustCompile(`"io/fs"|"path/filepath"|\bimport\s+(os|shutil|pathlib)\b|java\.nio\.file`), 2},
+		{regexp.MustCompile(`os\.(Open|OpenFile|Create|ReadFile|WriteFile|Remove|RemoveAll|Rename|Mkdir|MkdirAll|ReadDir|Stat|Lstat)\(|filepath\.(Walk|WalkDir|Glob)\(|ioutil\.(ReadFile|WriteFile|ReadDir)\(`), 2},
+		{regexp.MustCompile(`(?i)\b(files?|director(y|ies)|paths?|symlinks?|permissions)\b`), 1},
+	},
+	TagConfig: {
+		{regexp.MustCompile(`github\.com/spf13/(viper|pflag|cobra)|"flag"|gopkg\.in/yaml|github\.com/BurntSushi/toml|github\.com/joho/godotenv`), 3},
+		{regexp.MustCompile(`viper\.(Get\w*|SetDefault|ReadInConfig)\(|os\.(Getenv|LookupEnv)\(|flag\.(String|Int|Bool|Parse)\(`), 2},
+		{regexp.MustCompile(`(?i)\b(config\w*|settings?|defaults?|env(ironment)?\s+variables?|yaml|toml)\b`), 1},
+	},
+	TagLogging: {
+		{regexp.MustCompile(`"log"|"log/slog"|go\.uber\.org/zap|github\.com/sirupsen/logrus|github\.com/rs/zerolog|\bimport\s+logging\b`), 3},
+		{regexp.MustCompile(`\b(log|logger|slog|zap|logrus)\.(Print\w*|Debug\w*|Info\w*|Warn\w*|Error\w*|Fatal\w*)\(`), 2},
+	},
+}
+
+// Classify returns the tags whose keyword weight in content reaches
+// minScore, in display order. minScore 0 uses the default.
+func Classify(content string, minScore int) []string {
+	if minScore <= 0 {
+		minScore = DefaultConfig().MinScore
+	}
+	var tags []string
+	for _, tag := range All {
+		score := 0
+		for _, s := range signals[tag] {
+			// Repeated mentions count, up to three times per pattern
+			score += s.weight * min(len(s.pattern.FindAllStringIndex(content, 3)), 3)
+			if score >= minScore {
+				tags = append(tags, tag)
+				break
+			}
+		}
+	}
+	return tags
+}
+
+// Valid reports whether tag is a known tag
+func Valid(tag string) bool {
+	for _, known := range All {
+		if tag == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Merge combines tag lists without duplicates or unknown tags, in display
+// order
+func Merge(lists ...[]string) []string {
+	seen := make(map[string]bool)
+	for _, list := range lists {
+		for _, tag := range list {
+			seen[strings.ToLower(strings.TrimSpace(tag))] = true
+		}
+	}
+	var tags []string
+	for _, tag := range All {
+		if seen[tag] {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// Counts tallies how many items carry each tag, most common first
+func Counts(tagLists [][]string) []TagCount {
+	counts := make(map[string]int)
+	for _, tags := range tagLists {
+		for _, tag := range tags {
+			counts[tag]++
+		}
+	}
+	result := make([]TagCount, 0, len(counts))
+	for tag, count := range counts {
+		result = append(result, TagCount{Tag: tag, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Tag < result[j].Tag
+	})
+	return result
+}
+
+// TagCount is how many items carry a tag
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// tokenPrefix marks useQ API tokens so they are easy to spot in leaked config
+const tokenPrefix = "uq_"
+
+type contextKey string
+
+const userContextKey contextKey = "user"
+
+// GenerateToken returns a new random API token. Only its hash is stored, so
+// the token must be shown to the user once at creation.
+func GenerateToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return tokenPrefix + hex.EncodeToString(buf), nil
+}
+
+// HashToken returns the stored form of an API token
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateUser registers a user and returns their one-time API token
+func CreateUser(db *storage.SQLiteDB, name string, dailyBudget, monthlyBudget float64, personalFeedback bool) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("user name is required")
+	}
+	token, err := GenerateToken()
+	if err != nil {
+		return "", err
+	}
+	user := &storage.User{
+		ID:               "user_" + HashToken(name)[:12],
+		Name:             name,
+		TokenHash:        HashToken(token),
+		DailyBudget:      dailyBudget,
+		MonthlyBudget:    monthlyBudget,
+		PersonalFeedback: personalFeedback,
+	}
+	if err := db.CreateUser(user); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// authenticate resolves the bearer token to a user and stores it in the request context
+func (s *Server) authenticate(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := bearerToken(r)
+		if !ok || strings.TrimSpace(token) == "" {
+			writeError(w, http.StatusUnauthorized, "missing bearer token")
+			return
+		}
+
+		user, err := s.db.GetUserByTokenHash(HashToken(strings.TrimSpace(token)))
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to authenticate")
+			return
+		}
+		if user == nil || user.Disabled {
+			writeError(w, http.StatusUnauthorized, "invalid or disabled token")
+			return
+		}
+
+		next(w, r.WithContext(context.WithValue(r.Context(), userContextKey, user)))
+	}
+}
+
+// bearerToken returns the request's API token. Browsers cannot set headers
+// on a WebSocket handshake, so upgrades may pass it as access_token instead.
+func bearerToken(r *http.Request) (string, bool) {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return token, true
+	}
+	if headerContains(r.Header, "Upgrade", "websocket") {
+		if token := r.URL.Query().Get("access_token"); token != "" {
+			return token, true
+		}
+	}
+	return "", false
+}
+
+// userFromContext returns the authenticated user of a request
+func userFromContext(ctx context.Context) *storage.User {
+	user, _ := ctx.Value(userContextKey).(*storage.User)
+	return user
+}
+
+// sessionKey namespaces a client-chosen session ID by user so sessions, and
+// the follow-up history they carry, can never be shared across users
+func sessionKey(user *storage.User, session string) string {
+	session = strings.TrimSpace(session)
+	if session == "" {
+		session = "default"
+	}
+	return user.ID + "/" + session
+}
+
+// feedbackOwner is where a user's feedback is recorded: their own model when
+// they opted in, otherwise the team-wide pool
+func feedbackOwner(user *storage.User) string {
+	if user.PersonalFeedback {
+		return user.ID
+	}
+	return storage.SharedFeedbackUser
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/models"
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// Usage is a user's spend against their budgets
+type Usage struct {
+	User          string  `json:"user"`
+	SpentToday    float64 `json:"spent_today"`
+	TokensToday   int     `json:"tokens_today"`
+	DailyBudget   float64 `json:"daily_budget"`
+	SpentMonth    float64 `json:"spent_month"`
+	TokensMonth   int     `json:"tokens_month"`
+	MonthlyBudget float64 `json:"monthly_budget"`
+}
+
+// ErrBudgetExceeded is returned when a user has used up a budget
+type ErrBudgetExceeded struct {
+	Period string
+	Spent  float64
+	Budget float64
+}
+
+func (e *ErrBudgetExceeded) Error() string {
+	return fmt.Sprintf("%s budget exhausted: spent $%.4f of $%.4f", e.Period, e.Spent, e.Budget)
+}
+
+// usageFor reads a user's spend for the current day and month
+func (s *Server) usageFor(user *storage.User) (*Usage, error) {
+	now := time.Now()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+	usage := &Usage{User: user.Name, DailyBudget: user.DailyBudget, MonthlyBudget: user.MonthlyBudget}
+	var err error
+	if usage.SpentToday, usage.TokensToday, err = s.db.GetUserSpend(user.ID, dayStart); err != nil {
+		return nil, err
+	}
+	if usage.SpentMonth, usage.TokensMonth, err = s.db.GetUserSpend(user.ID, monthStart); err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+// checkBudget refuses new queries once a user's daily or monthly budget is spent.
+// A query already running is allowed to finish, so a budget can be overshot by
+// at most one query.
+func (s *Server) checkBudget(user *storage.User) error {
+	if user.DailyBudget <= 0 && user.MonthlyBudget <= 0 {
+		return nil
+	}
+	usage, err := s.usageFor(user)
+	if err != nil {
+		return err
+	}
+	if user.DailyBudget > 0 && usage.SpentToday >= user.DailyBudget {
+		return &ErrBudgetExceeded{Period: "daily", Spent: usage.SpentToday, Budget: user.DailyBudget}
+	}
+	if user.MonthlyBudget > 0 && usage.SpentMonth >= user.MonthlyBudget {
+		return &ErrBudgetExceeded{Period: "monthly", Spent: usage.SpentMonth, Budget: user.MonthlyBudget}
+	}
+	return nil
+}
+
+// charge records a response against the user's ledger. Free responses are
+// recorded too so query ownership can be checked for feedback.
+func (s *Server) charge(user *storage.User, query *models.Query, response *models.Response) error {
+	return s.db.RecordUserCost(&storage.CostEntry{
+		UserID:    user.ID,
+		QueryID:   query.ID,
+		SessionID: query.SessionID,
+		Provider:  response.Provider,
+		Tokens:    response.TokenUsage.TotalTokens,
+		Cost:      response.Cost.TotalCost,
+	})
+}
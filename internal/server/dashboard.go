@@ -0,0 +1,208 @@
+package server
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/llm"
+	"github.com/yourusername/useq-ai-assistant/internal/tasks"
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// RouteReporter reports cost and latency per model route. The CLI
+// application satisfies it; without one the dashboard shows no routes.
+type RouteReporter interface {
+	RouteStats() map[string]llm.RouteStats
+}
+
+// Reindexer reindexes the project in the background. The CLI application
+// satisfies it; without one POST /v1/dashboard/reindex is not served.
+type Reindexer interface {
+	StartIndexTask(ctx context.Context) (*storage.TaskRecord, error)
+}
+
+//go:embed dashboard
+var dashboardFiles embed.FS
+
+// Dashboard query limits
+const (
+	defaultHistoryLimit  = 50
+	maxHistoryLimit      = 500
+	defaultCostDays      = 30
+	maxCostDays          = 365
+	dashboardGenerations = 10
+)
+
+// IndexStatus is the body of GET /v1/dashboard/index
+type IndexStatus struct {
+	Tables      map[string]int             `json:"tables"`
+	Freshness   *storage.IndexFreshness    `json:"freshness"`
+	Generations []*storage.IndexGeneration `json:"generations"`
+	Reindex     *storage.TaskRecord        `json:"reindex,omitempty"` // the latest reindex started from the dashboard
+}
+
+// RoutingStats is the body of GET /v1/dashboard/routing
+type RoutingStats struct {
+	Routes map[string]llm.RouteStats `json:"routes"` // instance-wide since the server started
+	Agents []*storage.AgentUsage     `json:"agents"` // the caller's queries over the period
+	Days   int                       `json:"days"`
+}
+
+// dashboardRoutes adds the web dashboard and the endpoints behind it. The
+// pages are public; the data they load needs the caller's API token.
+func (s *Server) dashboardRoutes(mux *http.ServeMux) {
+	pages, _ := fs.Sub(dashboardFiles, "dashboard")
+	mux.Handle("GET /dashboard/", http.StripPrefix("/dashboard/", http.FileServerFS(pages)))
+	mux.Handle("GET /dashboard", http.RedirectHandler("/dashboard/", http.StatusMovedPermanently))
+
+	mux.HandleFunc("GET /v1/dashboard/index", s.authenticate(s.handleIndexStatus))
+	mux.HandleFunc("GET /v1/dashboard/history", s.authenticate(s.handleHistory))
+	mux.HandleFunc("GET /v1/dashboard/costs", s.authenticate(s.handleCosts))
+	mux.HandleFunc("GET /v1/dashboard/routing", s.authenticate(s.handleRouting))
+	if _, ok := s.processor.(Reindexer); ok {
+		mux.HandleFunc("POST /v1/dashboard/reindex", s.authenticate(s.handleReindex))
+	}
+}
+
+func (s *Server) handleIndexStatus(w http.ResponseWriter, r *http.Request) {
+	tables, err := s.db.GetBasicStats()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	freshness, err := s.db.IndexFreshness()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	generations, err := s.db.ListIndexGenerations(dashboardGenerations)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	reindex, err := s.latestReindex()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, IndexStatus{Tables: tables, Freshness: freshness, Generations: generations, Reindex: reindex})
+}
+
+// handleHistory serves GET /v1/dashboard/history[?limit=], the caller's
+// own queries only
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	limit, err := intParam(r, "limit", defaultHistoryLimit, maxHistoryLimit)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	entries, err := s.db.UserQueryHistory(userFromContext(r.Context()).ID, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"queries": entries})
+}
+
+// handleCosts serves GET /v1/dashboard/costs[?days=]: the caller's spend per
+// day and provider, with their budgets
+func (s *Server) handleCosts(w http.ResponseWriter, r *http.Request) {
+	user := userFromContext(r.Context())
+	days, err := intParam(r, "days", defaultCostDays, maxCostDays)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	daily, err := s.db.UserDailyCosts(user.ID, periodStart(days))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	usage, err := s.usageFor(user)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"daily": daily, "usage": usage, "days": days})
+}
+
+// handleRouting serves GET /v1/dashboard/routing[?days=]
+func (s *Server) handleRouting(w http.ResponseWriter, r *http.Request) {
+	days, err := intParam(r, "days", defaultCostDays, maxCostDays)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	agents, err := s.db.UserAgentUsage(userFromContext(r.Context()).ID, periodStart(days))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	stats := RoutingStats{Agents: agents, Days: days}
+	if reporter, ok := s.processor.(RouteReporter); ok {
+		stats.Routes = reporter.RouteStats()
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// handleReindex starts reindexing the project in the background, unless a
+// reindex started from the dashboard is still running
+func (s *Server) handleReindex(w http.ResponseWriter, r *http.Request) {
+	s.reindexMu.Lock()
+	defer s.reindexMu.Unlock()
+
+	if latest, err := s.latestReindexLocked(); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	} else if latest != nil && latest.Status == tasks.StatusRunning {
+		writeJSON(w, http.StatusConflict, map[string]interface{}{"error": "reindexing is already running", "task": latest})
+		return
+	}
+
+	task, err := s.processor.(Reindexer).StartIndexTask(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.reindexTask = task.ID
+	writeJSON(w, http.StatusAccepted, task)
+}
+
+// latestReindex returns the latest reindex task started from the dashboard
+func (s *Server) latestReindex() (*storage.TaskRecord, error) {
+	s.reindexMu.Lock()
+	defer s.reindexMu.Unlock()
+	return s.latestReindexLocked()
+}
+
+func (s *Server) latestReindexLocked() (*storage.TaskRecord, error) {
+	if s.reindexTask == "" {
+		return nil, nil
+	}
+	return s.db.GetTask(s.reindexTask)
+}
+
+// intParam reads a positive integer query parameter of at most max
+func intParam(r *http.Request, name string, fallback, max int) (int, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n <= 0 || n > max {
+		return 0, fmt.Errorf("%s must be between 1 and %d", name, max)
+	}
+	return n, nil
+}
+
+// periodStart returns the start of the day days-1 days ago, so a period of
+// one day is today
+func periodStart(days int) time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day()-(days-1), 0, 0, 0, 0, now.Location())
+}
@@ -0,0 +1,415 @@
+// Package server exposes the assistant over HTTP so a team can share one
+// indexed instance. Every request is authenticated to a user, whose sessions,
+// cost ledger, budgets and (optionally) feedback are kept separate.
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/display"
+	"github.com/yourusername/useq-ai-assistant/internal/agents"
+	"github.com/yourusername/useq-ai-assistant/internal/completion"
+	"github.com/yourusername/useq-ai-assistant/internal/llm"
+	"github.com/yourusername/useq-ai-assistant/internal/remote"
+	"github.com/yourusername/useq-ai-assistant/internal/watchdog"
+	"github.com/yourusername/useq-ai-assistant/models"
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// QueryProcessor runs a query through the assistant. The CLI application satisfies it.
+type QueryProcessor interface {
+	ProcessQuery(ctx context.Context, query *models.Query) (*models.Response, error)
+}
+
+// QueryCompleter suggests completions for partially typed queries. The CLI
+// application satisfies it; without one GET /v1/complete is not served.
+type QueryCompleter interface {
+	CompleteQuery(input, sessionID, historyScope string, limit int) []completion.Suggestion
+}
+
+// HealthReporter reports the health of the subsystems the watchdog keeps
+// alive. The CLI application satisfies it; GET /health lists them.
+type HealthReporter interface {
+	SubsystemHealth() []watchdog.Status
+}
+
+// Config controls the HTTP server
+type Config struct {
+	Addr         string        `json:"addr"`
+	ProjectRoot  string        `json:"project_root"`
+	ReadTimeout  time.Duration `json:"read_timeout"`
+	WriteTimeout time.Duration `json:"write_timeout"` // must cover the slowest LLM call
+	MaxBodyBytes int64         `json:"max_body_bytes"`
+}
+
+// DefaultConfig returns server defaults bound to localhost
+func DefaultConfig() Config {
+	return Config{
+		Addr:         "127.0.0.1:8484",
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 3 * time.Minute,
+		MaxBodyBytes: 1 << 20,
+	}
+}
+
+// commitCacheTTL bounds how long the project's HEAD is cached between queries
+const commitCacheTTL = 30 * time.Second
+
+// maxCompletions caps the limit parameter of GET /v1/complete
+const maxCompletions = 50
+
+// QueryRequest is the body of POST /v1/query
+type QueryRequest struct {
+	Query       string `json:"query"`
+	Session     string `json:"session,omitempty"` // scoped to the caller; defaults to "default"
+	Language    string `json:"language,omitempty"`
+	CurrentFile string `json:"current_file,omitempty"`
+	CurrentLine int    `json:"current_line,omitempty"`
+	NoClarify   bool   `json:"no_clarify,omitempty"` // always answer; never return a clarification question
+	Format      string `json:"format,omitempty"`     // markdown, quiet or terminal renders the answer as text instead of JSON
+
+	// ResponseSchema asks for the answer as JSON matching this schema, in
+	// content.structured; either a bare JSON schema or {name, schema, strict}
+	ResponseSchema json.RawMessage `json:"response_schema,omitempty"`
+}
+
+// FeedbackRequest is the body of POST /v1/feedback
+type FeedbackRequest struct {
+	QueryID  string `json:"query_id"`
+	Rating   int    `json:"rating"` // 1-5 stars
+	Helpful  bool   `json:"helpful"`
+	Comments string `json:"comments,omitempty"`
+}
+
+// Server serves queries for multiple authenticated users
+type Server struct {
+	config    Config
+	processor QueryProcessor
+	db        *storage.SQLiteDB
+	http      *http.Server
+	commits   *remote.CommitCache // the project's HEAD, reported to thin clients
+
+	reindexMu   sync.Mutex
+	reindexTask string // ID of the latest reindex started from the dashboard
+}
+
+// NewServer creates a server over an assistant and the database holding users and ledgers
+func NewServer(config Config, processor QueryProcessor, db *storage.SQLiteDB) (*Server, error) {
+	if processor == nil {
+		return nil, fmt.Errorf("query processor is required")
+	}
+	if db == nil {
+		return nil, fmt.Errorf("database is required for users and cost ledgers")
+	}
+
+	defaults := DefaultConfig()
+	if config.Addr == "" {
+		config.Addr = defaults.Addr
+	}
+	if config.ReadTimeout <= 0 {
+		config.ReadTimeout = defaults.ReadTimeout
+	}
+	if config.WriteTimeout <= 0 {
+		config.WriteTimeout = defaults.WriteTimeout
+	}
+	if config.MaxBodyBytes <= 0 {
+		config.MaxBodyBytes = defaults.MaxBodyBytes
+	}
+
+	s := &Server{config: config, processor: processor, db: db}
+	if config.ProjectRoot != "" {
+		s.commits = remote.NewCommitCache(config.ProjectRoot, commitCacheTTL)
+	}
+	s.http = &http.Server{
+		Addr:         config.Addr,
+		Handler:      s.Handler(),
+		ReadTimeout:  config.ReadTimeout,
+		WriteTimeout: config.WriteTimeout,
+	}
+	return s, nil
+}
+
+// Handler returns the server's routes
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /health", s.handleHealth)
+	mux.HandleFunc("POST /v1/query", s.authenticate(s.handleQuery))
+	mux.HandleFunc("POST /v1/query/stream", s.authenticate(s.handleQueryStream))
+	mux.HandleFunc("GET /v1/query/ws", s.authenticate(s.handleQueryWebSocket))
+	mux.HandleFunc("GET /v1/usage", s.authenticate(s.handleUsage))
+	mux.HandleFunc("POST /v1/feedback", s.authenticate(s.handleFeedback))
+	mux.HandleFunc("GET /v1/feedback", s.authenticate(s.handleFeedbackStats))
+	if _, ok := s.processor.(QueryCompleter); ok {
+		mux.HandleFunc("GET /v1/complete", s.authenticate(s.handleComplete))
+	}
+	s.dashboardRoutes(mux)
+	return mux
+}
+
+// ListenAndServe serves until ctx is cancelled, then shuts down gracefully
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.http.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return fmt.Errorf("server failed: %w", err)
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return s.http.Shutdown(shutdownCtx)
+	}
+}
+
+// Addr returns the address the server listens on
+func (s *Server) Addr() string {
+	return s.config.Addr
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	reporter, ok := s.processor.(HealthReporter)
+	if !ok {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+		return
+	}
+	// Degraded subsystems are being recovered; the server still answers
+	status := "ok"
+	subsystems := reporter.SubsystemHealth()
+	for _, subsystem := range subsystems {
+		if subsystem.State != watchdog.StateHealthy {
+			status = "degraded"
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"status": status, "subsystems": subsystems})
+}
+
+func (s *Server) handleQuery(w http.ResponseWriter, r *http.Request) {
+	user := userFromContext(r.Context())
+
+	var req QueryRequest
+	if err := s.decode(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var renderer display.Renderer
+	var body bytes.Buffer
+	if req.Format != "" && req.Format != display.RendererJSON {
+		var err error
+		if renderer, err = display.NewRenderer(req.Format, &body); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	query, status, err := s.newQuery(user, &req)
+	if err != nil {
+		writeError(w, status, err.Error())
+		return
+	}
+
+	response, err := s.processor.ProcessQuery(r.Context(), query)
+	if err != nil {
+		writeError(w, queryErrorStatus(err), err.Error())
+		return
+	}
+
+	if err := s.charge(user, query, response); err != nil {
+		// The answer is already paid for; return it rather than lose it
+		w.Header().Set("X-Useq-Ledger-Error", err.Error())
+	}
+	s.setCheckoutHeaders(w, r)
+	if renderer != nil {
+		writeRendered(w, renderer, &body, response)
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// newQuery validates a query request and builds the query it asks for,
+// checking the caller's budget first. On error it also returns the HTTP
+// status the error maps to.
+func (s *Server) newQuery(user *storage.User, req *QueryRequest) (*models.Query, int, error) {
+	if strings.TrimSpace(req.Query) == "" {
+		return nil, http.StatusBadRequest, fmt.Errorf("query is required")
+	}
+
+	if err := s.checkBudget(user); err != nil {
+		var exceeded *ErrBudgetExceeded
+		if errors.As(err, &exceeded) {
+			return nil, http.StatusPaymentRequired, err
+		}
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to check budget")
+	}
+
+	currentFile := req.CurrentFile
+	if currentFile != "" && !filepath.IsAbs(currentFile) && s.config.ProjectRoot != "" {
+		// Thin clients send paths relative to the project root
+		currentFile = filepath.Join(s.config.ProjectRoot, filepath.FromSlash(currentFile))
+	}
+
+	query := &models.Query{
+		ID:          fmt.Sprintf("query_%d", time.Now().UnixNano()),
+		UserInput:   req.Query,
+		Language:    req.Language,
+		Metadata:    map[string]string{"user_id": user.ID},
+		Timestamp:   time.Now(),
+		SessionID:   sessionKey(user, req.Session),
+		ProjectRoot: s.config.ProjectRoot,
+		Context: models.QueryContext{
+			CurrentFile: currentFile,
+			CurrentLine: req.CurrentLine,
+		},
+	}
+	if req.NoClarify {
+		agents.DisableClarification(query)
+	}
+	if len(req.ResponseSchema) > 0 {
+		if _, err := llm.ParseResponseSchema(req.ResponseSchema); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		query.Metadata[llm.ResponseSchemaKey] = string(req.ResponseSchema)
+		// Programmatic consumers cannot answer a clarification question
+		agents.DisableClarification(query)
+	}
+	return query, http.StatusOK, nil
+}
+
+// setCheckoutHeaders tells thin clients where the indexed project lives and
+// which commit it is at, so they can map results onto their own checkout
+func (s *Server) setCheckoutHeaders(w http.ResponseWriter, r *http.Request) {
+	if s.config.ProjectRoot == "" {
+		return
+	}
+	w.Header().Set(remote.HeaderProjectRoot, s.config.ProjectRoot)
+	if commit := s.commits.Commit(r.Context()); commit != "" {
+		w.Header().Set(remote.HeaderIndexCommit, commit)
+	}
+}
+
+// queryErrorStatus maps a failed query to its HTTP status
+func queryErrorStatus(err error) int {
+	var invalid *llm.SchemaValidationError
+	if errors.As(err, &invalid) {
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
+
+func (s *Server) handleUsage(w http.ResponseWriter, r *http.Request) {
+	usage, err := s.usageFor(userFromContext(r.Context()))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, usage)
+}
+
+func (s *Server) handleFeedback(w http.ResponseWriter, r *http.Request) {
+	user := userFromContext(r.Context())
+
+	var req FeedbackRequest
+	if err := s.decode(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Rating < 1 || req.Rating > 5 {
+		writeError(w, http.StatusBadRequest, "rating must be between 1 and 5")
+		return
+	}
+
+	// Users may only rate their own answers
+	owns, err := s.db.UserOwnsQuery(user.ID, req.QueryID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !owns {
+		writeError(w, http.StatusNotFound, "query not found")
+		return
+	}
+
+	if err := s.db.SaveUserFeedback(feedbackOwner(user), req.QueryID, req.Rating, req.Helpful, req.Comments); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]string{"status": "recorded"})
+}
+
+func (s *Server) handleFeedbackStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := s.db.GetFeedbackStats(feedbackOwner(userFromContext(r.Context())))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// handleComplete serves GET /v1/complete?q=<partial query>[&session=][&limit=],
+// completing from the caller's own query history only
+func (s *Server) handleComplete(w http.ResponseWriter, r *http.Request) {
+	user := userFromContext(r.Context())
+	params := r.URL.Query()
+
+	limit := completion.DefaultLimit
+	if raw := params.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 || n > maxCompletions {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxCompletions))
+			return
+		}
+		limit = n
+	}
+
+	completer := s.processor.(QueryCompleter)
+	suggestions := completer.CompleteQuery(params.Get("q"), sessionKey(user, params.Get("session")), user.ID+"/", limit)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"suggestions": suggestions})
+}
+
+// decode reads a size-limited JSON body
+func (s *Server) decode(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodyBytes)
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeRendered writes the response as formatted by a text renderer
+func writeRendered(w http.ResponseWriter, renderer display.Renderer, body *bytes.Buffer, response *models.Response) {
+	if err := renderer.RenderResponse(response); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	contentType := "text/plain; charset=utf-8"
+	if renderer.Name() == display.RendererMarkdown {
+		contentType = "text/markdown; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(body.Bytes())
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
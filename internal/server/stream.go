@@ -0,0 +1,193 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/llm"
+	"github.com/yourusername/useq-ai-assistant/internal/logger"
+	"github.com/yourusername/useq-ai-assistant/models"
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// Names of the events a streamed query sends besides its logger events
+// (progress, step and token)
+const (
+	eventResponse    = "response"     // the finished answer, as POST /v1/query returns it
+	eventError       = "error"        // the query failed; {error, status}
+	eventLedgerError = "ledger_error" // the answer was not charged to the ledger
+)
+
+// streamBuffer is how many events may wait for a slow client before the
+// query itself is held up
+const streamBuffer = 256
+
+// sseHeartbeat keeps idle proxies from closing a stream while the model thinks
+const sseHeartbeat = 15 * time.Second
+
+// streamEvent is one named event of a streamed query
+type streamEvent struct {
+	Name string      `json:"event"`
+	Data interface{} `json:"data"`
+}
+
+// streamQuery runs query in the background and returns its events: live
+// progress, steps and tokens, then a response or error event. The channel
+// closes when the query is done.
+func (s *Server) streamQuery(ctx context.Context, user *storage.User, query *models.Query) <-chan streamEvent {
+	events := make(chan streamEvent, streamBuffer)
+	send := func(event streamEvent) {
+		select {
+		case events <- event:
+		case <-ctx.Done():
+		}
+	}
+
+	go func() {
+		defer close(events)
+
+		sink := func(event logger.Event) {
+			send(streamEvent{Name: string(event.Kind), Data: event})
+		}
+		queryCtx := logger.WithEventSink(ctx, sink)
+		queryCtx = llm.WithTokenSink(queryCtx, func(delta string) {
+			sink(logger.Event{Kind: logger.EventToken, Component: string(logger.ComponentLLM), Message: delta, Timestamp: time.Now()})
+		})
+
+		response, err := s.processor.ProcessQuery(queryCtx, query)
+		if err != nil {
+			send(streamEvent{Name: eventError, Data: map[string]interface{}{"error": err.Error(), "status": queryErrorStatus(err)}})
+			return
+		}
+		if err := s.charge(user, query, response); err != nil {
+			// The answer is already paid for; send it rather than lose it
+			send(streamEvent{Name: eventLedgerError, Data: map[string]string{"error": err.Error()}})
+		}
+		send(streamEvent{Name: eventResponse, Data: response})
+	}()
+	return events
+}
+
+// handleQueryStream serves POST /v1/query/stream: the query of POST
+// /v1/query, answered as server-sent events while it runs
+func (s *Server) handleQueryStream(w http.ResponseWriter, r *http.Request) {
+	user := userFromContext(r.Context())
+
+	var req QueryRequest
+	if err := s.decode(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	query, status, err := s.newQuery(user, &req)
+	if err != nil {
+		writeError(w, status, err.Error())
+		return
+	}
+
+	flusher := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // nginx would otherwise hold events back
+	w.WriteHeader(http.StatusOK)
+	if err := flusher.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+
+	events := s.streamQuery(r.Context(), user, query)
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if err := writeSSE(w, event); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+		if err := flusher.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeSSE writes one server-sent event
+func writeSSE(w http.ResponseWriter, event streamEvent) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Name, data)
+	return err
+}
+
+// handleQueryWebSocket serves GET /v1/query/ws. The client sends one
+// QueryRequest as a text message and receives the query's events as
+// {event, data} text messages; the server closes the connection after the
+// response or error event. Closing the connection early cancels the query.
+func (s *Server) handleQueryWebSocket(w http.ResponseWriter, r *http.Request) {
+	user := userFromContext(r.Context())
+
+	conn, err := upgradeWebSocket(w, r, s.config.MaxBodyBytes)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer conn.Close()
+
+	// Like any other response, the stream must finish within the write
+	// timeout; the request must arrive within the read timeout
+	now := time.Now()
+	conn.SetWriteDeadline(now.Add(s.config.WriteTimeout))
+	conn.SetReadDeadline(now.Add(s.config.ReadTimeout))
+	message, err := conn.ReadMessage()
+	if err != nil {
+		return
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	var req QueryRequest
+	if err := json.Unmarshal(message, &req); err != nil {
+		conn.WriteJSON(streamEvent{Name: eventError, Data: map[string]interface{}{"error": fmt.Sprintf("invalid request body: %v", err), "status": http.StatusBadRequest}})
+		conn.WriteClose(wsClosePolicyViolation, "invalid request")
+		return
+	}
+	query, status, err := s.newQuery(user, &req)
+	if err != nil {
+		conn.WriteJSON(streamEvent{Name: eventError, Data: map[string]interface{}{"error": err.Error(), "status": status}})
+		conn.WriteClose(wsClosePolicyViolation, "query rejected")
+		return
+	}
+
+	// Anything the client sends from now on is a close, or ignored
+	go func() {
+		defer cancel()
+		for {
+			if _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for event := range s.streamQuery(ctx, user, query) {
+		if ctx.Err() != nil {
+			continue // the client is gone; wait for the query to stop
+		}
+		if err := conn.WriteJSON(event); err != nil {
+			cancel()
+		}
+	}
+	conn.WriteClose(wsCloseNormal, "done")
+}
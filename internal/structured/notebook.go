@@ -0,0 +1,141 @@
+package structured
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// notebook is the part of an .ipynb file the splitter reads
+type notebook struct {
+	Cells    []notebookCell `json:"cells"`
+	Metadata struct {
+		Kernelspec struct {
+			Language string `json:"language"`
+		} `json:"kernelspec"`
+		LanguageInfo struct {
+			Name string `json:"name"`
+		} `json:"language_info"`
+	} `json:"metadata"`
+}
+
+// notebookCell holds a cell's source, which nbformat allows as one string
+// or a list of lines
+type notebookCell struct {
+	CellType string          `json:"cell_type"`
+	Source   json.RawMessage `json:"source"`
+}
+
+// text returns the cell's source as one string
+func (c notebookCell) text() string {
+	var lines []string
+	if err := json.Unmarshal(c.Source, &lines); err == nil {
+		return strings.Join(lines, "")
+	}
+	var text string
+	json.Unmarshal(c.Source, &text)
+	return text
+}
+
+// pythonDefinition matches a top-level def or class in a cell
+var pythonDefinition = regexp.MustCompile(`(?m)^(def|class|async\s+def)\s+([A-Za-z_]\w*)`)
+
+// splitNotebook makes a section of each code cell, with the markdown cells
+// above it, in the kernel's language. Lines are those of the .ipynb file,
+// each section starting where its first cell does, so results point into
+// the file; the content is the cells' source, not their JSON.
+func splitNotebook(content string) *Result {
+	result := &Result{}
+	var nb notebook
+	if err := json.Unmarshal([]byte(content), &nb); err != nil {
+		return result
+	}
+	language := nb.Metadata.Kernelspec.Language
+	if language == "" {
+		language = nb.Metadata.LanguageInfo.Name
+	}
+	if language == "" {
+		language = "python"
+	}
+
+	// Cells appear in the file in order, each with a "cell_type" key
+	cellLines := make([]int, len(nb.Cells))
+	offset := 0
+	for i := range nb.Cells {
+		at := strings.Index(content[offset:], `"cell_type"`)
+		if at < 0 {
+			break
+		}
+		offset += at
+		cellLines[i] = strings.Count(content[:offset], "\n") + 1
+		offset += len(`"cell_type"`)
+	}
+	lastLine := strings.Count(content, "\n") + 1
+	endOf := func(i int) int {
+		if i+1 < len(cellLines) && cellLines[i+1] > 0 {
+			return cellLines[i+1] - 1
+		}
+		return lastLine
+	}
+
+	var notes []string
+	notesStart := 0
+	code := 0
+	for i, cell := range nb.Cells {
+		text := strings.TrimRight(cell.text(), "\n")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		switch cell.CellType {
+		case "markdown":
+			if notesStart == 0 {
+				notesStart = cellLines[i]
+			}
+			notes = append(notes, text)
+		case "code":
+			code++
+			start := cellLines[i]
+			if notesStart > 0 {
+				start = notesStart
+			}
+			s := Section{
+				StartLine: max(start, 1),
+				EndLine:   max(endOf(i), 1),
+				Kind:      KindCell,
+				Name:      fmt.Sprintf("cell %d", code),
+				Language:  language,
+				Content:   text,
+			}
+			if len(notes) > 0 {
+				s.Context = strings.Join(notes, "\n\n")
+			}
+			for _, match := range pythonDefinition.FindAllStringSubmatch(text, -1) {
+				kind := "function"
+				if match[1] == "class" {
+					kind = "class"
+				}
+				result.Symbols = append(result.Symbols, Symbol{
+					Name:      match[2],
+					Kind:      kind,
+					StartLine: s.StartLine,
+					EndLine:   s.EndLine,
+					Signature: strings.TrimSpace(match[0]),
+				})
+			}
+			result.Sections = append(result.Sections, s)
+			notes, notesStart = nil, 0
+		}
+	}
+	if len(notes) > 0 {
+		result.Sections = append(result.Sections, Section{
+			StartLine: notesStart,
+			EndLine:   lastLine,
+			Kind:      KindNote,
+			Name:      "notes",
+			Language:  "markdown",
+			Content:   strings.Join(notes, "\n\n"),
+		})
+	}
+	return result
+}
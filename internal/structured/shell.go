@@ -0,0 +1,96 @@
+package structured
+
+import (
+	"regexp"
+	"strings"
+)
+
+// shellFunction matches the line opening a function: name() { or
+// function name {, either form with the brace on the next line
+var shellFunction = regexp.MustCompile(`^\s*(?:function\s+([\w:.-]+)\s*(?:\(\s*\))?|([\w:.-]+)\s*\(\s*\))\s*(\{)?`)
+
+// splitShell makes a section of each function, with the comments above it,
+// and sections of the commands between functions
+func splitShell(content string, maxLines int) *Result {
+	lines := strings.Split(content, "\n")
+	result := &Result{}
+	scriptStart := 1
+	flush := func(end int) {
+		if first, last := trimBlank(lines, scriptStart, end); first > 0 {
+			result.Sections = append(result.Sections, splitBySize(lines, section(lines, first, last, KindScript, ""), maxLines)...)
+		}
+	}
+	for n := 1; n <= len(lines); n++ {
+		match := shellFunction.FindStringSubmatch(lines[n-1])
+		if match == nil {
+			continue
+		}
+		name := match[1] + match[2]
+		end := shellFunctionEnd(lines, n, match[3] != "")
+		if end == 0 {
+			continue
+		}
+		start := leadingComments(lines, n, scriptStart-1, "#")
+		if start == 1 && strings.HasPrefix(lines[0], "#!") {
+			start = 2
+		}
+		flush(start - 1)
+		s := section(lines, start, end, KindFunction, name)
+		s.Context = "function " + name
+		result.Sections = append(result.Sections, splitBySize(lines, s, maxLines)...)
+		result.Symbols = append(result.Symbols, Symbol{
+			Name:      name,
+			Kind:      "function",
+			StartLine: n,
+			EndLine:   end,
+			Signature: strings.TrimSpace(lines[n-1]),
+		})
+		scriptStart = end + 1
+		n = end
+	}
+	flush(len(lines))
+	return result
+}
+
+// shellFunctionEnd returns the line closing the function opened on line
+// open, counting braces outside quotes and comments, or 0 when the body
+// isn't braced or never closes
+func shellFunctionEnd(lines []string, open int, braceOnLine bool) int {
+	depth := 0
+	started := braceOnLine
+	for n := open; n <= len(lines); n++ {
+		line := lines[n-1]
+		if n == open+1 && !started {
+			if !strings.HasPrefix(strings.TrimSpace(line), "{") {
+				return 0
+			}
+			started = true
+		}
+		var quote byte
+		for i := 0; i < len(line); i++ {
+			c := line[i]
+			switch {
+			case quote != 0:
+				if c == '\\' && quote == '"' {
+					i++
+				} else if c == quote {
+					quote = 0
+				}
+			case c == '\\':
+				i++
+			case c == '\'' || c == '"':
+				quote = c
+			case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+				i = len(line)
+			case c == '{':
+				depth++
+			case c == '}':
+				depth--
+				if depth == 0 {
+					return n
+				}
+			}
+		}
+	}
+	return 0
+}
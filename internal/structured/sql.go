@@ -0,0 +1,192 @@
+package structured
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// sqlTable matches a table or view a statement reads, writes or defines
+	sqlTable = regexp.MustCompile(`(?i)\b(?:from|join|into|update|table(?:\s+if\s+(?:not\s+)?exists)?|view(?:\s+if\s+(?:not\s+)?exists)?)\s+([\w."]+)`)
+	// sqlDefinition matches a statement creating a named object
+	sqlDefinition = regexp.MustCompile(`(?i)^\s*create\s+(?:or\s+replace\s+)?(?:(?:temp|temporary|unique|materialized)\s+)*(table|view|index|function|procedure|trigger|type|sequence)\s+(?:if\s+not\s+exists\s+)?([\w."]+)`)
+	// sqlVerb matches the words that say what a statement does
+	sqlVerb = regexp.MustCompile(`(?i)^\s*((?:create|alter|drop)(?:\s+or\s+replace)?(?:\s+(?:unique|temp|temporary|materialized))*\s+\w+|insert\s+into|delete\s+from|\w+)`)
+)
+
+// sqlStatement is one statement of a SQL file
+type sqlStatement struct {
+	start, end int // lines, with the comments above
+	first      int // line of the statement's first token
+	verb       string
+	object     string // the name a CREATE statement defines
+	tables     []string
+}
+
+// splitSQL makes a section of each statement, with the comments above it,
+// merging runs of statements doing the same thing to the same table, such
+// as the inserts of a seed file, while they fit in maxLines
+func splitSQL(content string, maxLines int) *Result {
+	lines := strings.Split(content, "\n")
+	result := &Result{}
+	var statements []sqlStatement
+	for _, span := range sqlStatementSpans(content) {
+		text := strings.Join(lines[span[1]-1:span[2]], "\n")
+		statement := sqlStatement{start: span[0], end: span[2], first: span[1], verb: sqlSummary(text)}
+		seen := make(map[string]bool)
+		for _, match := range sqlTable.FindAllStringSubmatch(text, -1) {
+			table := strings.Trim(match[1], `"`)
+			if !seen[strings.ToLower(table)] && !sqlKeyword(table) {
+				seen[strings.ToLower(table)] = true
+				statement.tables = append(statement.tables, table)
+			}
+		}
+		if match := sqlDefinition.FindStringSubmatch(text); match != nil {
+			statement.object = strings.Trim(match[2], `"`)
+			result.Symbols = append(result.Symbols, Symbol{
+				Name:      strings.Trim(match[2], `"`),
+				Kind:      strings.ToLower(match[1]),
+				StartLine: span[1],
+				EndLine:   span[2],
+				Signature: strings.TrimSpace(lines[span[1]-1]),
+			})
+		}
+		statements = append(statements, statement)
+	}
+
+	for i := 0; i < len(statements); {
+		run := statements[i]
+		j := i + 1
+		for ; j < len(statements); j++ {
+			next := statements[j]
+			if next.verb != run.verb || next.object != "" || strings.Join(next.tables, ",") != strings.Join(run.tables, ",") || next.end-run.start+1 > maxLines {
+				break
+			}
+			run.end = next.end
+		}
+		name := run.verb
+		if run.object != "" {
+			name += " " + run.object
+		} else if len(run.tables) > 0 {
+			name += " " + run.tables[0]
+		}
+		s := section(lines, run.start, run.end, KindStatement, name)
+		if len(run.tables) > 0 {
+			s.Context = "tables: " + strings.Join(run.tables, ", ")
+		}
+		result.Sections = append(result.Sections, splitBySize(lines, s, maxLines)...)
+		i = j
+	}
+	return result
+}
+
+// sqlStatementSpans returns the start line (with the comments directly
+// above), first token line and end line of each statement. Statements end
+// at semicolons outside strings, quoted names, comments and dollar-quoted
+// bodies; text after the last semicolon is a statement too.
+func sqlStatementSpans(content string) [][3]int {
+	lines := strings.Split(content, "\n")
+	var spans [][3]int
+	line, first, floor := 1, 0, 0 // first stays 0 until a statement has a token
+	end := func(last int) {
+		spans = append(spans, [3]int{leadingComments(lines, first, floor, "--"), first, last})
+		floor, first = last, 0
+	}
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		skip := "" // the text closing a comment or quoted run starting here
+		switch {
+		case c == '\n':
+			line++
+			continue
+		case c == ' ' || c == '\t' || c == '\r':
+			continue
+		case strings.HasPrefix(content[i:], "--"):
+			skip = "\n"
+		case strings.HasPrefix(content[i:], "/*"):
+			skip = "*/"
+		case c == '\'' || c == '"' || c == '`':
+			skip = string(c)
+		case c == '$':
+			skip = sqlDollarTag(content[i:])
+		}
+		comment := skip == "\n" || skip == "*/"
+		if !comment && first == 0 {
+			first = line
+		}
+		if skip != "" {
+			from := i + len(skip)
+			if comment {
+				from = i + 2
+			}
+			closing := strings.Index(content[from:], skip)
+			if closing < 0 {
+				closing = len(content) - from
+			}
+			// Leave a line comment's newline to be counted above
+			stop := from + closing + len(skip) - 1
+			if skip == "\n" {
+				stop--
+			}
+			line += strings.Count(content[i:min(stop+1, len(content))], "\n")
+			i = stop
+			continue
+		}
+		if c == ';' {
+			end(line)
+		}
+	}
+	if first != 0 {
+		last := len(lines)
+		for last > first && blank(lines[last-1]) {
+			last--
+		}
+		end(last)
+	}
+	return spans
+}
+
+// sqlDollarTag returns the $tag$ opening a dollar-quoted string, or ""
+func sqlDollarTag(s string) string {
+	end := strings.IndexByte(s[1:], '$')
+	if end < 0 {
+		return ""
+	}
+	tag := s[:end+2]
+	for _, r := range tag[1 : len(tag)-1] {
+		if r != '_' && (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return ""
+		}
+	}
+	return tag
+}
+
+// sqlSummary names what a statement does, such as "CREATE TABLE", with
+// the comments before it skipped
+func sqlSummary(text string) string {
+	for {
+		trimmed := strings.TrimSpace(text)
+		switch {
+		case strings.HasPrefix(trimmed, "--"):
+			_, text, _ = strings.Cut(trimmed, "\n")
+			continue
+		case strings.HasPrefix(trimmed, "/*"):
+			_, text, _ = strings.Cut(trimmed, "*/")
+			continue
+		}
+		text = trimmed
+		break
+	}
+	match := sqlVerb.FindString(text)
+	return strings.ToUpper(strings.Join(strings.Fields(match), " "))
+}
+
+// sqlKeyword reports whether a word the table pattern caught is a keyword,
+// as in "DELETE FROM ONLY t" or "SELECT ... FROM (SELECT ...)"
+func sqlKeyword(word string) bool {
+	switch strings.ToLower(word) {
+	case "only", "select", "lateral", "unnest", "if", "not", "exists", "set", "where", "values":
+		return true
+	}
+	return false
+}
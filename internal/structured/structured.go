@@ -0,0 +1,140 @@
+// Package structured splits configuration, SQL, shell and notebook files at
+// their own boundaries (YAML keys, SQL statements, shell functions, notebook
+// cells) instead of every few hundred lines, and names the symbols each
+// file defines, so "where do we configure the retry policy" finds the values
+// file that sets it.
+package structured
+
+import "strings"
+
+// Languages split by this package, as named by the language package
+const (
+	LanguageYAML    = "yaml"
+	LanguageSQL     = "sql"
+	LanguageShell   = "bash"
+	LanguageJupyter = "jupyter"
+)
+
+// Section kinds
+const (
+	KindConfig    = "config"    // a YAML key and everything under it
+	KindStatement = "statement" // one SQL statement, or a run of alike ones
+	KindFunction  = "function"  // a shell function
+	KindScript    = "script"    // shell commands outside functions
+	KindCell      = "cell"      // a notebook code cell with the notes above it
+	KindNote      = "note"      // notebook markdown with no code cell after it
+)
+
+// DefaultMaxLines is the section size past which a section is split further
+const DefaultMaxLines = 120
+
+// maxSymbols bounds the symbols recorded for one file, so a generated values
+// file with thousands of keys doesn't flood the symbol index
+const maxSymbols = 500
+
+// Section is one region of a file, chunked and embedded on its own
+type Section struct {
+	StartLine int    // 1-based, inclusive
+	EndLine   int    // 1-based, inclusive
+	Kind      string // one of the Kind constants
+	Name      string // key path, statement summary, function name or cell label
+	Language  string // set when it differs from the file's, as for notebook cells
+	Content   string
+	Context   string // what the content alone doesn't say, such as the parent key path
+}
+
+// Symbol is something a file defines that search and pins can find by name
+type Symbol struct {
+	Name      string
+	Kind      string // key, table, view, index, function, procedure, class
+	StartLine int
+	EndLine   int
+	Signature string // the defining line
+}
+
+// Result is how a file splits and what it defines
+type Result struct {
+	Sections []Section
+	Symbols  []Symbol
+}
+
+// Supported reports whether Split knows the language
+func Supported(language string) bool {
+	switch language {
+	case LanguageYAML, LanguageSQL, LanguageShell, "shell", "sh", LanguageJupyter:
+		return true
+	}
+	return false
+}
+
+// Split divides content written in language into sections no longer than
+// maxLines where the format allows, or returns nil for other languages
+func Split(language, content string, maxLines int) *Result {
+	if maxLines <= 0 {
+		maxLines = DefaultMaxLines
+	}
+	var result *Result
+	switch language {
+	case LanguageYAML:
+		result = splitYAML(content, maxLines)
+	case LanguageSQL:
+		result = splitSQL(content, maxLines)
+	case LanguageShell, "shell", "sh":
+		result = splitShell(content, maxLines)
+	case LanguageJupyter:
+		result = splitNotebook(content)
+	default:
+		return nil
+	}
+	if len(result.Symbols) > maxSymbols {
+		result.Symbols = result.Symbols[:maxSymbols]
+	}
+	return result
+}
+
+// section builds a section over lines[start-1:end]
+func section(lines []string, start, end int, kind, name string) Section {
+	return Section{
+		StartLine: start,
+		EndLine:   end,
+		Kind:      kind,
+		Name:      name,
+		Content:   strings.Join(lines[start-1:end], "\n"),
+	}
+}
+
+// splitBySize cuts a section longer than maxLines into consecutive parts,
+// each keeping the section's name and context
+func splitBySize(lines []string, s Section, maxLines int) []Section {
+	if s.EndLine-s.StartLine+1 <= maxLines {
+		return []Section{s}
+	}
+	var parts []Section
+	for start := s.StartLine; start <= s.EndLine; start += maxLines {
+		end := min(start+maxLines-1, s.EndLine)
+		part := section(lines, start, end, s.Kind, s.Name)
+		part.Context = s.Context
+		part.Language = s.Language
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+// blank reports whether a line holds nothing but whitespace
+func blank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+// indentOf counts the leading spaces and tabs of a line
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
+
+// leadingComments moves start up over the comment lines directly above it,
+// so a comment describing a block is chunked with the block
+func leadingComments(lines []string, start, floor int, prefix string) int {
+	for start-1 > floor && strings.HasPrefix(strings.TrimSpace(lines[start-2]), prefix) {
+		start--
+	}
+	return start
+}
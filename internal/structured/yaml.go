@@ -0,0 +1,235 @@
+package structured
+
+import (
+	"regexp"
+	"strings"
+)
+
+// yamlKeyLine matches a mapping key, possibly the first key of a list item:
+// the indent, an optional "- ", the key and its inline value
+var yamlKeyLine = regexp.MustCompile(`^(\s*)(-\s+)?("[^"]*"|'[^']*'|[^\s#'"{\[\-][^#]*?)\s*:(?:\s+(.*))?$`)
+
+// maxKeyDepth bounds the key paths recorded as symbols
+const maxKeyDepth = 3
+
+// yamlEntry is one key of a YAML file
+type yamlEntry struct {
+	line     int // 1-based
+	column   int // where the key starts
+	listItem bool
+	key      string
+	value    string
+	path     string
+	depth    int
+	end      int // last line of the key's value
+	children []*yamlEntry
+}
+
+// splitYAML makes a section of each top-level key of each document, and
+// splits keys longer than maxLines at their children
+func splitYAML(content string, maxLines int) *Result {
+	lines := strings.Split(content, "\n")
+	result := &Result{}
+	docStart := 1
+	for i := 1; i <= len(lines)+1; i++ {
+		if i <= len(lines) && !yamlDocumentBreak(lines[i-1]) {
+			continue
+		}
+		if docEnd := i - 1; docEnd >= docStart {
+			splitYAMLDocument(lines, docStart, docEnd, maxLines, result)
+		}
+		docStart = i + 1
+	}
+	return result
+}
+
+// yamlDocumentBreak reports whether a line separates YAML documents
+func yamlDocumentBreak(line string) bool {
+	trimmed := strings.TrimRight(line, " \t\r")
+	return trimmed == "---" || strings.HasPrefix(trimmed, "--- ") || trimmed == "..."
+}
+
+// splitYAMLDocument adds the sections and key symbols of lines[start-1:end]
+func splitYAMLDocument(lines []string, start, end, maxLines int, result *Result) {
+	roots := parseYAMLKeys(lines, start, end)
+	for _, root := range roots {
+		collectYAMLSymbols(lines, root, result)
+	}
+
+	var topLevel []*yamlEntry
+	for _, root := range roots {
+		if root.column == 0 && !root.listItem {
+			topLevel = append(topLevel, root)
+		}
+	}
+	if len(topLevel) == 0 {
+		// A document that is a list or a scalar is split by size alone
+		if first, last := trimBlank(lines, start, end); first > 0 {
+			result.Sections = append(result.Sections, splitBySize(lines, section(lines, first, last, KindConfig, ""), maxLines)...)
+		}
+		return
+	}
+
+	floor := start - 1
+	for _, entry := range topLevel {
+		first := leadingComments(lines, entry.line, floor, "#")
+		result.Sections = append(result.Sections, yamlSections(lines, entry, first, maxLines)...)
+		floor = entry.end
+	}
+}
+
+// yamlSections returns the section of a key starting at line first, or the
+// sections of its children when it is longer than maxLines
+func yamlSections(lines []string, entry *yamlEntry, first, maxLines int) []Section {
+	// The size that matters is the key's own; lines a parent hands down
+	// are only a few
+	own := leadingComments(lines, entry.line, first-1, "#")
+	if entry.end-own+1 <= maxLines || len(entry.children) == 0 {
+		s := section(lines, first, entry.end, KindConfig, entry.path)
+		s.Context = yamlContext(entry)
+		if len(entry.children) > 0 {
+			return []Section{s}
+		}
+		return splitBySize(lines, s, maxLines)
+	}
+	var sections []Section
+	for i, child := range entry.children {
+		// The parent's own lines go with its first child
+		childFirst := first
+		if i > 0 {
+			childFirst = leadingComments(lines, child.line, entry.children[i-1].end, "#")
+		}
+		sections = append(sections, yamlSections(lines, child, childFirst, maxLines)...)
+	}
+	return sections
+}
+
+// yamlContext names a key and the keys directly under it
+func yamlContext(entry *yamlEntry) string {
+	context := "keys: " + entry.path
+	if len(entry.children) > 0 {
+		names := make([]string, 0, len(entry.children))
+		seen := make(map[string]bool)
+		for _, child := range entry.children {
+			if !seen[child.key] {
+				seen[child.key] = true
+				names = append(names, child.key)
+			}
+		}
+		context += " (" + strings.Join(names, ", ") + ")"
+	}
+	return context
+}
+
+// parseYAMLKeys finds the keys of lines[start-1:end] and nests them by
+// column, returning the outermost keys
+func parseYAMLKeys(lines []string, start, end int) []*yamlEntry {
+	var roots []*yamlEntry
+	var stack []*yamlEntry
+	blockColumn := -1 // inside a block scalar, the column of its key
+	for n := start; n <= end; n++ {
+		line := lines[n-1]
+		if blank(line) || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		indent := indentOf(line)
+		if blockColumn >= 0 {
+			if indent > blockColumn {
+				continue
+			}
+			blockColumn = -1
+		}
+		match := yamlKeyLine.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		column := len(match[1]) + len(match[2])
+		entry := &yamlEntry{
+			line:     n,
+			column:   column,
+			listItem: match[2] != "",
+			key:      strings.Trim(match[3], `"'`),
+			value:    strings.TrimSpace(match[4]),
+		}
+		for len(stack) > 0 && stack[len(stack)-1].column >= column {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			entry.path, entry.depth = entry.key, 1
+			roots = append(roots, entry)
+		} else {
+			parent := stack[len(stack)-1]
+			entry.path, entry.depth = parent.path+"."+entry.key, parent.depth+1
+			parent.children = append(parent.children, entry)
+		}
+		stack = append(stack, entry)
+		if strings.HasPrefix(entry.value, "|") || strings.HasPrefix(entry.value, ">") {
+			blockColumn = column
+		}
+	}
+	for _, root := range roots {
+		setYAMLEnds(lines, root, end)
+	}
+	return roots
+}
+
+// setYAMLEnds finds the last line of each key's value: the line before the
+// next line at or left of the key's column, other than items of a list
+// written at the key's own column, less trailing blanks and comments
+func setYAMLEnds(lines []string, entry *yamlEntry, limit int) {
+	end := limit
+	for n := entry.line + 1; n <= limit; n++ {
+		line := lines[n-1]
+		if blank(line) || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		indent := indentOf(line)
+		if indent > entry.column {
+			continue
+		}
+		if indent == entry.column && entry.value == "" && !entry.listItem && strings.HasPrefix(strings.TrimSpace(line), "- ") {
+			continue
+		}
+		end = n - 1
+		break
+	}
+	for end > entry.line && (blank(lines[end-1]) || strings.HasPrefix(strings.TrimSpace(lines[end-1]), "#")) {
+		end--
+	}
+	entry.end = end
+	for _, child := range entry.children {
+		setYAMLEnds(lines, child, end)
+	}
+}
+
+// collectYAMLSymbols records the key paths down to maxKeyDepth
+func collectYAMLSymbols(lines []string, entry *yamlEntry, result *Result) {
+	if entry.depth > maxKeyDepth {
+		return
+	}
+	result.Symbols = append(result.Symbols, Symbol{
+		Name:      entry.path,
+		Kind:      "key",
+		StartLine: entry.line,
+		EndLine:   entry.end,
+		Signature: strings.TrimSpace(lines[entry.line-1]),
+	})
+	for _, child := range entry.children {
+		collectYAMLSymbols(lines, child, result)
+	}
+}
+
+// trimBlank narrows lines[start-1:end] to its first and last non-blank
+// lines, or returns 0, 0 when all are blank
+func trimBlank(lines []string, start, end int) (int, int) {
+	for start <= end && blank(lines[start-1]) {
+		start++
+	}
+	for end >= start && blank(lines[end-1]) {
+		end--
+	}
+	if start > end {
+		return 0, 0
+	}
+	return start, end
+}
@@ -0,0 +1,365 @@
+// Package tasks runs long operations in the background. Tasks get IDs, report
+// progress to the step log, persist checkpoints and can be cancelled or
+// resumed after a crash.
+package tasks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/logger"
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// Task statuses
+const (
+	StatusRunning     = "running"
+	StatusCompleted   = "completed"
+	StatusFailed      = "failed"
+	StatusCancelled   = "cancelled"
+	StatusInterrupted = "interrupted" // the process stopped while the task ran
+)
+
+// progressSaveInterval throttles how often progress alone is persisted;
+// checkpoints are always saved
+const progressSaveInterval = time.Second
+
+// ErrTaskNotFound is returned for an unknown task ID
+var ErrTaskNotFound = errors.New("task not found")
+
+// backgroundKey marks the context a task's handler runs with
+type backgroundKey struct{}
+
+// InBackground reports whether ctx is a background task's. Such work runs
+// while the terminal belongs to the interactive prompt, so it must not ask
+// the user anything.
+func InBackground(ctx context.Context) bool {
+	background, _ := ctx.Value(backgroundKey{}).(bool)
+	return background
+}
+
+// Handler does a task's work. It reports progress and checkpoints through
+// run, and on resume reads the last checkpoint to skip finished work. The
+// returned string is kept as the task result.
+type Handler func(ctx context.Context, run *Run) (string, error)
+
+// Manager starts, tracks and resumes background tasks
+type Manager struct {
+	store      *storage.SQLiteDB
+	stepLogger *logger.StepLogger
+	handlers   map[string]Handler
+
+	mu      sync.Mutex
+	running map[string]context.CancelFunc
+	closing bool
+	wg      sync.WaitGroup
+}
+
+// NewManager creates a task manager persisting to store. stepLogger may be nil.
+func NewManager(store *storage.SQLiteDB, stepLogger *logger.StepLogger) *Manager {
+	return &Manager{
+		store:      store,
+		stepLogger: stepLogger,
+		handlers:   make(map[string]Handler),
+		running:    make(map[string]context.CancelFunc),
+	}
+}
+
+// Register sets the handler for a kind of task
+func (m *Manager) Register(kind string, handler Handler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handlers[kind] = handler
+}
+
+// Recover marks tasks left running by a previous process as interrupted so
+// they can be resumed, and returns how many there were
+func (m *Manager) Recover() (int, error) {
+	return m.store.UpdateTaskStatus(StatusRunning, StatusInterrupted)
+}
+
+// Start persists a new task and runs it in the background. input is passed
+// to the handler as JSON and kept for resuming.
+func (m *Manager) Start(ctx context.Context, kind, description string, input interface{}) (*storage.TaskRecord, error) {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return nil, fmt.Errorf("invalid task input: %w", err)
+	}
+	record := &storage.TaskRecord{
+		ID:          newTaskID(),
+		Kind:        kind,
+		Description: description,
+		Input:       string(data),
+	}
+	if err := m.launch(ctx, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// Resume restarts an interrupted, failed or cancelled task from its last
+// checkpoint
+func (m *Manager) Resume(ctx context.Context, id string) (*storage.TaskRecord, error) {
+	record, err := m.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	switch record.Status {
+	case StatusRunning:
+		return nil, fmt.Errorf("task %s is already running", id)
+	case StatusCompleted:
+		return nil, fmt.Errorf("task %s already completed", id)
+	}
+	record.Error = ""
+	if err := m.launch(ctx, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// Cancel stops a running task. A task that is not running, such as an
+// interrupted one, is marked cancelled so it is no longer offered for resume.
+func (m *Manager) Cancel(id string) error {
+	m.mu.Lock()
+	cancel, ok := m.running[id]
+	m.mu.Unlock()
+	if ok {
+		cancel()
+		return nil
+	}
+
+	record, err := m.Get(id)
+	if err != nil {
+		return err
+	}
+	if record.Status == StatusCompleted || record.Status == StatusCancelled {
+		return fmt.Errorf("task %s already %s", id, record.Status)
+	}
+	record.Status = StatusCancelled
+	return m.store.SaveTask(record)
+}
+
+// Get returns a task by ID
+func (m *Manager) Get(id string) (*storage.TaskRecord, error) {
+	record, err := m.store.GetTask(id)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, fmt.Errorf("%w: %s", ErrTaskNotFound, id)
+	}
+	return record, nil
+}
+
+// List returns the most recent tasks
+func (m *Manager) List(limit int) ([]*storage.TaskRecord, error) {
+	return m.store.ListTasks(limit)
+}
+
+// Running reports whether a task is running in this process
+func (m *Manager) Running(id string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.running[id]
+	return ok
+}
+
+// Shutdown cancels running tasks and waits for them to checkpoint and stop.
+// They are left interrupted, so they resume on the next start.
+func (m *Manager) Shutdown() {
+	m.mu.Lock()
+	m.closing = true
+	for _, cancel := range m.running {
+		cancel()
+	}
+	m.mu.Unlock()
+	m.wg.Wait()
+}
+
+// launch marks the task running and starts its handler
+func (m *Manager) launch(ctx context.Context, record *storage.TaskRecord) error {
+	m.mu.Lock()
+	handler, ok := m.handlers[record.Kind]
+	m.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("no handler for %s tasks", record.Kind)
+	}
+
+	record.Status = StatusRunning
+	if err := m.store.SaveTask(record); err != nil {
+		return err
+	}
+
+	// The task outlives the command that started it
+	taskCtx, cancel := context.WithCancel(context.WithValue(context.WithoutCancel(ctx), backgroundKey{}, true))
+	m.mu.Lock()
+	m.running[record.ID] = cancel
+	m.mu.Unlock()
+
+	run := &Run{manager: m, record: record}
+	if m.stepLogger != nil {
+		run.step = m.stepLogger.StartStep(logger.ComponentTasks, fmt.Sprintf("%s task %s", record.Kind, record.ID), map[string]interface{}{
+			"description": record.Description,
+			"resumed":     record.Checkpoint != "",
+		})
+	}
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer func() {
+			m.mu.Lock()
+			delete(m.running, record.ID)
+			m.mu.Unlock()
+			cancel()
+		}()
+		result, err := runHandler(taskCtx, handler, run)
+		m.finish(taskCtx, run, result, err)
+	}()
+	return nil
+}
+
+// runHandler runs a handler, turning a panic into a task failure
+func runHandler(ctx context.Context, handler Handler, run *Run) (result string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("task panicked: %v", r)
+		}
+	}()
+	return handler(ctx, run)
+}
+
+// finish records how a task ended
+func (m *Manager) finish(ctx context.Context, run *Run, result string, err error) {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	record := run.record
+
+	switch {
+	case err == nil:
+		record.Status = StatusCompleted
+		record.Result = result
+		if record.Total > 0 {
+			record.Done = record.Total
+		}
+	case ctx.Err() != nil && m.isClosing():
+		record.Status = StatusInterrupted
+	case ctx.Err() != nil:
+		record.Status = StatusCancelled
+	default:
+		record.Status = StatusFailed
+		record.Error = err.Error()
+	}
+	if saveErr := m.store.SaveTask(record); saveErr != nil {
+		fmt.Printf("⚠️ Failed to save task %s: %v\n", record.ID, saveErr)
+	}
+
+	if m.stepLogger == nil {
+		return
+	}
+	if record.Status == StatusCompleted {
+		m.stepLogger.CompleteStep(run.step, map[string]interface{}{"task_id": record.ID, "status": record.Status})
+	} else {
+		m.stepLogger.FailStep(run.step, fmt.Errorf("task %s %s: %v", record.ID, record.Status, err))
+	}
+}
+
+// isClosing reports whether Shutdown, rather than Cancel, stopped the tasks
+func (m *Manager) isClosing() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.closing
+}
+
+func newTaskID() string {
+	buf := make([]byte, 4)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("t%x", time.Now().UnixNano())
+	}
+	return "t" + hex.EncodeToString(buf)
+}
+
+// Run is a running task as seen by its handler
+type Run struct {
+	manager *Manager
+	step    int
+
+	mu        sync.Mutex
+	record    *storage.TaskRecord
+	lastSaved time.Time
+}
+
+// ID returns the task ID
+func (r *Run) ID() string {
+	return r.record.ID
+}
+
+// Input decodes the input the task was started with
+func (r *Run) Input(v interface{}) error {
+	return json.Unmarshal([]byte(r.record.Input), v)
+}
+
+// LoadCheckpoint decodes the last checkpoint into v and reports whether there
+// was one
+func (r *Run) LoadCheckpoint(v interface{}) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.record.Checkpoint == "" {
+		return false, nil
+	}
+	if err := json.Unmarshal([]byte(r.record.Checkpoint), v); err != nil {
+		return false, fmt.Errorf("invalid checkpoint for task %s: %w", r.record.ID, err)
+	}
+	return true, nil
+}
+
+// Checkpoint persists v as the state to resume from, with progress
+func (r *Run) Checkpoint(v interface{}, done, total int) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("invalid checkpoint: %w", err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.record.Checkpoint = string(data)
+	r.report(done, total, "")
+	r.lastSaved = time.Now()
+	return r.manager.store.SaveTask(r.record)
+}
+
+// Progress reports how far the task is. It goes to the step log every time
+// and to the task store at most once a second.
+func (r *Run) Progress(done, total int, message string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.report(done, total, message)
+	if time.Since(r.lastSaved) >= progressSaveInterval {
+		r.lastSaved = time.Now()
+		if err := r.manager.store.SaveTask(r.record); err != nil {
+			fmt.Printf("⚠️ Failed to save task %s progress: %v\n", r.record.ID, err)
+		}
+	}
+}
+
+// report records progress and logs it; r.mu must be held
+func (r *Run) report(done, total int, message string) {
+	r.record.Done = done
+	r.record.Total = total
+	if message != "" {
+		r.record.Message = message
+	}
+
+	if stepLogger := r.manager.stepLogger; stepLogger != nil {
+		stepLogger.UpdateStep(r.step, logger.StatusInProgress, r.record.Message, map[string]interface{}{
+			"task_id": r.record.ID,
+			"done":    done,
+			"total":   total,
+		})
+	}
+}
@@ -0,0 +1,231 @@
+// Package testconv discovers how a project writes its tests - fixture
+// directories, golden files and shared helpers - from the index, so generated
+// tests can follow the same conventions instead of inventing new ones.
+package testconv
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// maxHelpers and maxGoldenFiles bound what is shown to the model
+const (
+	maxHelpers     = 8
+	maxGoldenFiles = 5
+)
+
+// helperPattern matches test helper names that load or compare fixtures
+var helperPattern = regexp.MustCompile(`(?i)(fixture|golden|testdata|load|read|helper|setup|newtest|mock|fake)`)
+
+// funcPattern matches top-level function declarations in a test file
+var funcPattern = regexp.MustCompile(`(?m)^func\s+([A-Za-z_]\w*)\s*\(([^)]*)\)([^{\n]*)`)
+
+// updateFlagPattern matches the conventional -update flag for golden files
+var updateFlagPattern = regexp.MustCompile(`flag\.Bool\(\s*"(update[\w-]*)"`)
+
+// Helper is a reusable function defined in an existing test file
+type Helper struct {
+	Name      string `json:"name"`
+	File      string `json:"file"`
+	Signature string `json:"signature"`
+}
+
+// Conventions describes the test conventions found in a project
+type Conventions struct {
+	TestFiles      int      `json:"test_files"`
+	TestDataDirs   []string `json:"testdata_dirs"`
+	SharedTestData bool     `json:"shared_testdata"` // a single testdata/ at the root rather than per package
+	GoldenFiles    []string `json:"golden_files"`    // sample of existing golden files
+	GoldenExt      string   `json:"golden_ext,omitempty"`
+	UpdateFlag     string   `json:"update_flag,omitempty"`
+	Helpers        []Helper `json:"helpers"`
+	Assertions     string   `json:"assertions"` // "testify" or "testing"
+	TableDriven    bool     `json:"table_driven"`
+	Subtests       bool     `json:"subtests"`
+	Parallel       bool     `json:"parallel"`
+}
+
+// Discover queries the index for test files and derives the project's
+// fixture and golden-file conventions from them
+func Discover(db *storage.SQLiteDB, projectRoot string) (*Conventions, error) {
+	if db == nil {
+		return nil, fmt.Errorf("storage is required to discover test conventions")
+	}
+	files, err := db.GetTestFiles("_test.go")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load test files: %w", err)
+	}
+
+	conventions := &Conventions{TestFiles: len(files), Assertions: "testing"}
+	dirs := make(map[string]bool)
+	testify := 0
+
+	for _, file := range files {
+		content := file.Content
+		if strings.Contains(content, "github.com/stretchr/testify") {
+			testify++
+		}
+		if strings.Contains(content, "[]struct {") || strings.Contains(content, "[]struct{") {
+			conventions.TableDriven = true
+		}
+		if strings.Contains(content, "t.Run(") {
+			conventions.Subtests = true
+		}
+		if strings.Contains(content, "t.Parallel()") {
+			conventions.Parallel = true
+		}
+		if m := updateFlagPattern.FindStringSubmatch(content); m != nil && conventions.UpdateFlag == "" {
+			conventions.UpdateFlag = m[1]
+		}
+
+		for _, m := range funcPattern.FindAllStringSubmatch(content, -1) {
+			name := m[1]
+			if isTestEntryPoint(name) || !helperPattern.MatchString(name) {
+				continue
+			}
+			conventions.Helpers = append(conventions.Helpers, Helper{
+				Name:      name,
+				File:      file.Path,
+				Signature: strings.TrimSpace(fmt.Sprintf("func %s(%s)%s", name, m[2], m[3])),
+			})
+		}
+
+		dir := filepath.Dir(file.Path)
+		if !dirs[dir] {
+			dirs[dir] = true
+			conventions.addTestData(resolve(projectRoot, dir), dir)
+		}
+	}
+
+	// A root testdata/ that no package has beside it is shared by the tree
+	if len(conventions.TestDataDirs) == 0 && projectRoot != "" {
+		conventions.addTestData(projectRoot, ".")
+		conventions.SharedTestData = len(conventions.TestDataDirs) > 0
+	}
+
+	if testify > 0 && testify*2 >= len(files) {
+		conventions.Assertions = "testify"
+	}
+	sort.Strings(conventions.TestDataDirs)
+	sort.Strings(conventions.GoldenFiles)
+	sort.Slice(conventions.Helpers, func(i, j int) bool { return conventions.Helpers[i].Name < conventions.Helpers[j].Name })
+	if len(conventions.Helpers) > maxHelpers {
+		conventions.Helpers = conventions.Helpers[:maxHelpers]
+	}
+	if len(conventions.GoldenFiles) > maxGoldenFiles {
+		conventions.GoldenFiles = conventions.GoldenFiles[:maxGoldenFiles]
+	}
+	return conventions, nil
+}
+
+// addTestData records a testdata directory beside dir and the golden files in it
+func (c *Conventions) addTestData(absDir, relDir string) {
+	testdata := filepath.Join(absDir, "testdata")
+	if info, err := os.Stat(testdata); err != nil || !info.IsDir() {
+		return
+	}
+	c.TestDataDirs = append(c.TestDataDirs, filepath.ToSlash(filepath.Join(relDir, "testdata")))
+
+	filepath.WalkDir(testdata, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		ext := filepath.Ext(path)
+		if ext == ".golden" || strings.Contains(filepath.Base(path), ".golden.") {
+			rel, relErr := filepath.Rel(absDir, path)
+			if relErr != nil {
+				rel = path
+			}
+			c.GoldenFiles = append(c.GoldenFiles, filepath.ToSlash(filepath.Join(relDir, rel)))
+			if c.GoldenExt == "" {
+				c.GoldenExt = ext
+			}
+		}
+		return nil
+	})
+}
+
+// HasConventions reports whether anything beyond the standard library defaults was found
+func (c *Conventions) HasConventions() bool {
+	return c != nil && (len(c.TestDataDirs) > 0 || len(c.Helpers) > 0 || c.UpdateFlag != "" ||
+		c.Assertions != "testing" || c.TableDriven)
+}
+
+// FixtureDirFor returns where fixtures for tests of the given source file belong
+func (c *Conventions) FixtureDirFor(target string) string {
+	if c.SharedTestData || target == "" {
+		return "testdata"
+	}
+	return filepath.ToSlash(filepath.Join(filepath.Dir(target), "testdata"))
+}
+
+// PromptSection renders the conventions as guidance for test generation
+func (c *Conventions) PromptSection(target string) string {
+	if !c.HasConventions() {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("\nExisting test conventions (from %d indexed test files):\n", c.TestFiles))
+	if c.Assertions == "testify" {
+		b.WriteString("- Assertions use github.com/stretchr/testify (assert/require)\n")
+	} else {
+		b.WriteString("- Assertions use the standard testing package (t.Errorf/t.Fatalf)\n")
+	}
+	if c.TableDriven {
+		line := "- Tests are table-driven"
+		if c.Subtests {
+			line += " with t.Run subtests"
+		}
+		if c.Parallel {
+			line += " and call t.Parallel()"
+		}
+		b.WriteString(line + "\n")
+	}
+	if len(c.TestDataDirs) > 0 {
+		b.WriteString(fmt.Sprintf("- Fixtures live in testdata/ directories (%s); place new fixtures under %s\n",
+			strings.Join(c.TestDataDirs, ", "), c.FixtureDirFor(target)))
+	}
+	if len(c.GoldenFiles) > 0 {
+		b.WriteString(fmt.Sprintf("- Golden files follow the pattern of %s; name new ones after the test case with the %s extension\n",
+			strings.Join(c.GoldenFiles, ", "), c.GoldenExt))
+	}
+	if c.UpdateFlag != "" {
+		b.WriteString(fmt.Sprintf("- Golden files are regenerated with the -%s flag; reuse it rather than declaring a new one\n", c.UpdateFlag))
+	}
+	if len(c.Helpers) > 0 {
+		b.WriteString("- Reuse these existing helpers instead of writing new fixture loaders:\n")
+		for _, helper := range c.Helpers {
+			b.WriteString(fmt.Sprintf("  - %s (%s)\n", helper.Signature, helper.File))
+		}
+	}
+	return b.String()
+}
+
+// isTestEntryPoint reports whether name is run by go test rather than called by tests
+func isTestEntryPoint(name string) bool {
+	for _, prefix := range []string{"Test", "Benchmark", "Example", "Fuzz"} {
+		if strings.HasPrefix(name, prefix) && (len(name) == len(prefix) || !isLower(name[len(prefix)])) {
+			return true
+		}
+	}
+	return false
+}
+
+func isLower(b byte) bool {
+	return b >= 'a' && b <= 'z'
+}
+
+// resolve makes an indexed path absolute against the project root
+func resolve(projectRoot, path string) string {
+	if filepath.IsAbs(path) || projectRoot == "" {
+		return path
+	}
+	return filepath.Join(projectRoot, path)
+}
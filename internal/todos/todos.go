@@ -0,0 +1,246 @@
+// Package todos finds TODO, FIXME and HACK comments in source files, with
+// the lines around them and, from git blame, who last touched them and when.
+package todos
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/language"
+	"github.com/yourusername/useq-ai-assistant/internal/sandbox"
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// Config controls TODO mining during indexing
+type Config struct {
+	Enabled      bool          `json:"enabled"`
+	Markers      []string      `json:"markers"`       // comment markers, matched case-sensitively
+	ContextLines int           `json:"context_lines"` // lines kept on each side of the comment
+	Blame        bool          `json:"blame"`         // read author and date from git blame
+	Timeout      time.Duration `json:"timeout"`       // per git blame call
+}
+
+// DefaultConfig returns TODO mining defaults
+func DefaultConfig() Config {
+	return Config{
+		Enabled:      true,
+		Markers:      []string{"TODO", "FIXME", "HACK", "XXX"},
+		ContextLines: 5,
+		Blame:        true,
+		Timeout:      10 * time.Second,
+	}
+}
+
+// commentStarts open a comment in any of the languages indexed, for files
+// of a language not listed in languageComments
+var commentStarts = []string{"//", "#", "/*", "--"}
+
+// languageComments are the comment starts of each language, so that "#" in
+// a Go string or "--" in a JavaScript decrement is not taken for a comment
+var languageComments = map[string][]string{
+	"go":         {"//", "/*"},
+	"c":          {"//", "/*"},
+	"cpp":        {"//", "/*"},
+	"csharp":     {"//", "/*"},
+	"java":       {"//", "/*"},
+	"javascript": {"//", "/*"},
+	"typescript": {"//", "/*"},
+	"rust":       {"//", "/*"},
+	"kotlin":     {"//", "/*"},
+	"swift":      {"//", "/*"},
+	"scala":      {"//", "/*"},
+	"css":        {"/*"},
+	"php":        {"//", "#", "/*"},
+	"python":     {"#"},
+	"ruby":       {"#"},
+	"bash":       {"#"},
+	"yaml":       {"#"},
+	"r":          {"#"},
+	"sql":        {"--", "/*"},
+	"json":       {},
+	"markdown":   {},
+}
+
+// commentLeaders returns the comment starts of file's language
+func commentLeaders(file string) []string {
+	if leaders, ok := languageComments[language.FromExtension(file)]; ok {
+		return leaders
+	}
+	return commentStarts
+}
+
+// Scan returns the comments in content that start with one of the markers,
+// such as TODO(alice): handle retries, or FIXME: slow. Lines are 1-based;
+// Package is the file's directory.
+func Scan(file, content string, cfg Config) []*storage.TodoRecord {
+	if len(cfg.Markers) == 0 {
+		return nil
+	}
+	markers := markerPattern(cfg.Markers)
+	leaders := commentLeaders(file)
+	if len(leaders) == 0 {
+		return nil
+	}
+	lines := strings.Split(content, "\n")
+
+	var todos []*storage.TodoRecord
+	for i, line := range lines {
+		match, leader := findMarker(line, markers, leaders)
+		if match == nil {
+			continue
+		}
+		text := strings.TrimSpace(strings.TrimSuffix(match[3], "*/"))
+		// A TODO often continues on the following whole-line comments
+		if strings.HasPrefix(strings.TrimSpace(line), leader) && leader != "/*" {
+			for j := i + 1; j < len(lines) && j <= i+3; j++ {
+				next, ok := strings.CutPrefix(strings.TrimSpace(lines[j]), leader)
+				next = strings.TrimSpace(next)
+				// "/" is what is left of the */ closing a block comment
+				if !ok || next == "" || next == "/" || markers.MatchString(next) {
+					break
+				}
+				text += " " + next
+			}
+		}
+
+		first, last := max(0, i-cfg.ContextLines), min(len(lines)-1, i+cfg.ContextLines)
+		todos = append(todos, &storage.TodoRecord{
+			File:         file,
+			Line:         i + 1,
+			Kind:         match[1],
+			Text:         text,
+			Package:      filepath.ToSlash(filepath.Dir(file)),
+			Context:      strings.Join(lines[first:last+1], "\n"),
+			ContextStart: first + 1,
+			Author:       match[2], // an owner named in the comment; blame fills in the rest
+		})
+	}
+	return todos
+}
+
+// findMarker returns the marker match of the line's comment and the comment
+// leader it follows, trying each of leaders on the line
+func findMarker(line string, markers *regexp.Regexp, leaders []string) ([]string, string) {
+	for offset := 0; offset < len(line); offset++ {
+		for _, leader := range leaders {
+			if !strings.HasPrefix(line[offset:], leader) {
+				continue
+			}
+			if match := markers.FindStringSubmatch(strings.TrimLeft(line[offset+len(leader):], " \t*")); match != nil {
+				return match, leader
+			}
+		}
+	}
+	// Inside a block comment: " * TODO ..."
+	if !slices.Contains(leaders, "/*") {
+		return nil, ""
+	}
+	if rest, ok := strings.CutPrefix(strings.TrimSpace(line), "*"); ok {
+		if match := markers.FindStringSubmatch(strings.TrimSpace(rest)); match != nil {
+			return match, "*"
+		}
+	}
+	return nil, ""
+}
+
+// markerPattern matches a comment starting with one of the markers, with an
+// optional (owner) and separator
+func markerPattern(markers []string) *regexp.Regexp {
+	quoted := make([]string, len(markers))
+	for i, marker := range markers {
+		quoted[i] = regexp.QuoteMeta(marker)
+	}
+	return regexp.MustCompile(`^(` + strings.Join(quoted, "|") + `)\b(?:\(([^)]*)\))?[:\-\s]*(.*)$`)
+}
+
+// BlameLine is who last changed a line and when
+type BlameLine struct {
+	Author string
+	Time   time.Time
+}
+
+// Blame runs git blame for the given lines of a file under root and returns
+// their author and commit time, keyed by line number. Uncommitted lines are
+// left out.
+func Blame(ctx context.Context, root, file string, lines []int, cfg Config) (map[int]BlameLine, error) {
+	if len(lines) == 0 {
+		return nil, nil
+	}
+	policy := sandbox.DefaultPolicy()
+	policy.AllowedCommands = []string{"git"}
+	if cfg.Timeout > 0 {
+		policy.Timeout = cfg.Timeout
+	}
+	runner, err := sandbox.NewRunner(root, policy)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"blame", "--line-porcelain"}
+	for _, line := range lines {
+		args = append(args, "-L", fmt.Sprintf("%d,%d", line, line))
+	}
+	result, err := runner.Run(ctx, "git", append(args, "--", file)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run git blame: %w", err)
+	}
+	if result.ExitCode != 0 {
+		return nil, fmt.Errorf("git blame failed: %s", strings.TrimSpace(result.Output))
+	}
+	return parseBlame(result.Output), nil
+}
+
+// parseBlame reads git blame --line-porcelain output
+func parseBlame(output string) map[int]BlameLine {
+	lines := make(map[int]BlameLine)
+	var current BlameLine
+	line, uncommitted := 0, false
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		text := scanner.Text()
+		switch {
+		case strings.HasPrefix(text, "\t"):
+			// The line's content ends its entry
+			if !uncommitted && line > 0 {
+				lines[line] = current
+			}
+			current, line = BlameLine{}, 0
+		case strings.HasPrefix(text, "author "):
+			current.Author = strings.TrimPrefix(text, "author ")
+			uncommitted = current.Author == "Not Committed Yet"
+		case strings.HasPrefix(text, "author-time "):
+			if seconds, err := strconv.ParseInt(strings.TrimPrefix(text, "author-time "), 10, 64); err == nil {
+				current.Time = time.Unix(seconds, 0)
+			}
+		default:
+			// Entries start with: <sha> <original line> <final line> [<group size>]
+			fields := strings.Fields(text)
+			if line == 0 && len(fields) >= 3 && len(fields[0]) == 40 {
+				line, _ = strconv.Atoi(fields[2])
+			}
+		}
+	}
+	return lines
+}
+
+// ApplyBlame fills in the author and date of each comment from blame. An
+// owner named in the comment, as in TODO(alice), is kept.
+func ApplyBlame(todos []*storage.TodoRecord, blame map[int]BlameLine) {
+	for _, todo := range todos {
+		entry, ok := blame[todo.Line]
+		if !ok {
+			continue
+		}
+		if todo.Author == "" {
+			todo.Author = entry.Author
+		}
+		todo.CommittedAt = entry.Time
+	}
+}
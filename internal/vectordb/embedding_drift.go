@@ -0,0 +1,112 @@
+package vectordb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Drift policies: what queries do when the stored vectors were built with
+// another embedding model than queries are embedded with
+const (
+	DriftWarn   = "warn"   // answer, and say the results may be off
+	DriftRefuse = "refuse" // refuse queries until the vectors are rebuilt
+)
+
+// EmbeddingFingerprint identifies the embeddings in a collection. Vectors
+// with different fingerprints are not comparable, even at the same size.
+type EmbeddingFingerprint struct {
+	Model     string `json:"model"`
+	Dimension int    `json:"dimension"`
+}
+
+// String describes the fingerprint, e.g. "text-embedding-3-small (1536d)"
+func (f EmbeddingFingerprint) String() string {
+	model := f.Model
+	if model == "" {
+		model = "unknown model"
+	}
+	return fmt.Sprintf("%s (%dd)", model, f.Dimension)
+}
+
+// EmbeddingDrift is a collection whose vectors were built with another
+// model or size than the one queries use now
+type EmbeddingDrift struct {
+	Collection string               `json:"collection"`
+	Stored     EmbeddingFingerprint `json:"stored"`  // what the stored vectors were built with
+	Current    EmbeddingFingerprint `json:"current"` // what queries are embedded with
+}
+
+// Error describes the drift and how to fix it
+func (d *EmbeddingDrift) Error() string {
+	return fmt.Sprintf("collection %s was embedded with %s but queries now use %s; run `reembed` to rebuild its vectors",
+		d.Collection, d.Stored, d.Current)
+}
+
+// DetectDrift compares a collection's stored fingerprint with the current
+// one; a recorded model of "" is not compared. It returns nil when they
+// match.
+func DetectDrift(collection string, stored, current EmbeddingFingerprint) *EmbeddingDrift {
+	if stored.Dimension == current.Dimension && (stored.Model == "" || stored.Model == current.Model) {
+		return nil
+	}
+	return &EmbeddingDrift{Collection: collection, Stored: stored, Current: current}
+}
+
+// Fingerprint returns the model and size this client embeds with
+func (qc *QdrantClient) Fingerprint() EmbeddingFingerprint {
+	return EmbeddingFingerprint{Model: qc.embeddingModel(), Dimension: qc.config.VectorSize}
+}
+
+// Collection returns the name of the client's collection
+func (qc *QdrantClient) Collection() string {
+	return qc.config.Collection
+}
+
+// StoredVectors reads the vector size the collection was created with and
+// how many points it holds
+func (qc *QdrantClient) StoredVectors(ctx context.Context) (size, points int, err error) {
+	var info struct {
+		Result struct {
+			PointsCount int `json:"points_count"`
+			Config      struct {
+				Params struct {
+					Vectors json.RawMessage `json:"vectors"`
+				} `json:"params"`
+			} `json:"config"`
+		} `json:"result"`
+	}
+	if err := qc.postJSON(ctx, "GET", "", nil, &info); err != nil {
+		return 0, 0, fmt.Errorf("failed to read collection %s: %w", qc.config.Collection, err)
+	}
+	var vectors struct {
+		Size int `json:"size"`
+	}
+	if raw := info.Result.Config.Params.Vectors; len(raw) > 0 {
+		if err := json.Unmarshal(raw, &vectors); err != nil {
+			return 0, 0, fmt.Errorf("unexpected vector config of %s: %w", qc.config.Collection, err)
+		}
+	}
+	return vectors.Size, info.Result.PointsCount, nil
+}
+
+// RecreateCollections drops the project's vectors, in every shard when the
+// index is sharded, and creates the collections again empty at the client's
+// current size, ready to be filled with embeddings of the current model
+func (qc *QdrantClient) RecreateCollections(ctx context.Context) error {
+	if err := qc.resetCollection(ctx); err != nil {
+		return err
+	}
+	if qc.sharding == nil {
+		return nil
+	}
+	for _, shard := range qc.shardClients() {
+		if shard.config.Collection == qc.config.Collection {
+			continue // the base collection, reset above
+		}
+		if err := shard.resetCollection(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
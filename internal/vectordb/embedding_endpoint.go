@@ -0,0 +1,298 @@
+package vectordb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/calllog"
+)
+
+// defaultEmbeddingModel is the OpenAI model used unless configured otherwise
+const defaultEmbeddingModel = "text-embedding-3-small"
+
+// defaultAzureEmbeddingAPIVersion is used when an Azure endpoint has no api_version
+const defaultAzureEmbeddingAPIVersion = "2024-06-01"
+
+// Gemini embedding defaults. gemini-embedding-001 can truncate its output to
+// the collection's vector size, so switching needs a reindex but no new collection.
+const (
+	defaultGeminiEmbeddingModel = "gemini-embedding-001"
+	geminiEmbeddingAPIBase      = "https://generativelanguage.googleapis.com/v1beta"
+)
+
+// embeddingCostPer1K holds USD input rates per 1K tokens by model; other
+// models are charged the historical OpenAI rate
+var embeddingCostPer1K = map[string]float64{
+	"gemini-embedding-001": 0.00015,
+	"text-embedding-004":   0,
+}
+
+// defaultEmbeddingCostPer1K is the rate used for models not in the table
+const defaultEmbeddingCostPer1K = 0.0001
+
+// embeddingModelDimensions holds the full output size of known embedding models
+var embeddingModelDimensions = map[string]int{
+	"text-embedding-3-small": 1536,
+	"text-embedding-3-large": 3072,
+	"text-embedding-ada-002": 1536,
+	"text-embedding-004":     768,
+	"gemini-embedding-001":   3072,
+}
+
+// ModelDimensions returns the full output size of a known embedding model
+func ModelDimensions(model string) (int, bool) {
+	dims, ok := embeddingModelDimensions[model]
+	return dims, ok
+}
+
+// EmbeddingEndpoint describes where embeddings are requested and how the
+// requests are signed. APIType takes the same values as the LLM provider's
+// api_type (openai, azure, azure_ad or compatible), or gemini.
+type EmbeddingEndpoint struct {
+	APIType    string `json:"api_type"`
+	BaseURL    string `json:"base_url,omitempty"`
+	APIKey     string `json:"-"`
+	Model      string `json:"model"`
+	Deployment string `json:"deployment,omitempty"`  // Azure embedding deployment
+	APIVersion string `json:"api_version,omitempty"` // Azure api-version
+	Dimensions int    `json:"dimensions,omitempty"`  // output size; Gemini defaults to the collection's vector size
+}
+
+// DefaultEmbeddingEndpoint returns the OpenAI endpoint keyed by OPENAI_API_KEY,
+// which is what embeddings used before endpoints were configurable
+func DefaultEmbeddingEndpoint() EmbeddingEndpoint {
+	return EmbeddingEndpoint{
+		APIType: "openai",
+		APIKey:  os.Getenv("OPENAI_API_KEY"),
+		Model:   defaultEmbeddingModel,
+	}
+}
+
+// isAzure reports whether the endpoint is an Azure OpenAI resource
+func (e EmbeddingEndpoint) isAzure() bool {
+	return e.APIType == "azure" || e.APIType == "azure_ad"
+}
+
+// Enabled reports whether real embeddings can be requested. Compatible
+// servers may run without a key but must have a URL.
+func (e EmbeddingEndpoint) Enabled() bool {
+	switch {
+	case e.APIType == "compatible":
+		return e.BaseURL != ""
+	case e.isAzure():
+		return e.BaseURL != "" && e.Deployment != "" && e.APIKey != ""
+	default:
+		return e.APIKey != ""
+	}
+}
+
+// ModelName names the model behind the endpoint, for cache keys and stats
+func (e EmbeddingEndpoint) ModelName() string {
+	if e.Model != "" {
+		return e.Model
+	}
+	if e.isAzure() && e.Deployment != "" {
+		return e.Deployment
+	}
+	if e.APIType == "gemini" {
+		return defaultGeminiEmbeddingModel
+	}
+	return defaultEmbeddingModel
+}
+
+// CostPer1K returns the USD input rate per 1K tokens for the endpoint's model
+func (e EmbeddingEndpoint) CostPer1K() float64 {
+	if cost, ok := embeddingCostPer1K[e.ModelName()]; ok {
+		return cost
+	}
+	return defaultEmbeddingCostPer1K
+}
+
+// URL returns the embeddings URL. Azure addresses the deployment in the path
+// and needs an api-version; everything else appends /embeddings to the base.
+func (e EmbeddingEndpoint) URL() string {
+	base := strings.TrimRight(e.BaseURL, "/")
+	if e.APIType == "gemini" {
+		if base == "" {
+			base = geminiEmbeddingAPIBase
+		}
+		return fmt.Sprintf("%s/models/%s:embedContent", base, url.PathEscape(e.ModelName()))
+	}
+	if e.isAzure() {
+		version := e.APIVersion
+		if version == "" {
+			version = defaultAzureEmbeddingAPIVersion
+		}
+		return fmt.Sprintf("%s/openai/deployments/%s/embeddings?api-version=%s",
+			base, url.PathEscape(e.Deployment), url.QueryEscape(version))
+	}
+	if base == "" {
+		base = "https://api.openai.com/v1"
+	}
+	// Older configuration stored the full embeddings URL
+	if strings.HasSuffix(base, "/embeddings") {
+		return base
+	}
+	return base + "/embeddings"
+}
+
+// NewRequest builds a signed embeddings request for a single text
+func (e EmbeddingEndpoint) NewRequest(ctx context.Context, text string) (*http.Request, error) {
+	reqBody := map[string]interface{}{"input": text}
+	switch {
+	case e.APIType == "gemini":
+		reqBody = map[string]interface{}{
+			"model":   "models/" + e.ModelName(),
+			"content": map[string]interface{}{"parts": []map[string]string{{"text": text}}},
+		}
+		if e.Dimensions > 0 {
+			reqBody["outputDimensionality"] = e.Dimensions
+		}
+	case !e.isAzure():
+		// Azure takes the model from the deployment
+		reqBody["model"] = e.ModelName()
+	}
+	if e.APIType != "gemini" && e.Dimensions > 0 {
+		// Shortened embeddings (text-embedding-3-* and compatible models)
+		reqBody["dimensions"] = e.Dimensions
+	}
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", e.URL(), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	e.sign(req)
+	return req, nil
+}
+
+// sign adds the endpoint's authentication header: Azure keys go in api-key,
+// Gemini keys in x-goog-api-key, and everything else (including Entra ID
+// tokens) is a bearer token
+func (e EmbeddingEndpoint) sign(req *http.Request) {
+	if e.APIKey == "" {
+		return
+	}
+	switch e.APIType {
+	case "azure":
+		req.Header.Set("api-key", e.APIKey)
+	case "gemini":
+		req.Header.Set("x-goog-api-key", e.APIKey)
+	default:
+		req.Header.Set("Authorization", "Bearer "+e.APIKey)
+	}
+}
+
+// DecodeResponse reads the embedding and the billed token count from a
+// response. Gemini reports no usage, so its count is estimated from text.
+func (e EmbeddingEndpoint) DecodeResponse(body io.Reader, text string) ([]float32, int, error) {
+	if e.APIType == "gemini" {
+		var geminiResp struct {
+			Embedding struct {
+				Values []float32 `json:"values"`
+			} `json:"embedding"`
+		}
+		if err := json.NewDecoder(body).Decode(&geminiResp); err != nil {
+			return nil, 0, err
+		}
+		if len(geminiResp.Embedding.Values) == 0 {
+			return nil, 0, fmt.Errorf("no embeddings returned")
+		}
+		return geminiResp.Embedding.Values, len(text) / 4, nil
+	}
+
+	var embeddingResp struct {
+		Data []struct {
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+		Usage struct {
+			TotalTokens int `json:"total_tokens"`
+		} `json:"usage"`
+	}
+	if err := json.NewDecoder(body).Decode(&embeddingResp); err != nil {
+		return nil, 0, err
+	}
+	if len(embeddingResp.Data) == 0 {
+		return nil, 0, fmt.Errorf("no embeddings returned")
+	}
+	return embeddingResp.Data[0].Embedding, embeddingResp.Usage.TotalTokens, nil
+}
+
+// SetEmbeddingEndpoint changes where query and chunk embeddings are requested
+func (qc *QdrantClient) SetEmbeddingEndpoint(endpoint EmbeddingEndpoint) {
+	qc.embeddingEndpoint = endpoint
+}
+
+// sizedEndpoint returns the embedding endpoint asking for the output size
+// the collection needs: the reduced size for the dimensions method, and for
+// Gemini otherwise the full vector size
+func (qc *QdrantClient) sizedEndpoint() EmbeddingEndpoint {
+	endpoint := qc.embeddingEndpoint
+	if qc.reduction.Enabled() && qc.reduction.Method == ReductionDimensions {
+		endpoint.Dimensions = qc.reduction.Dimensions
+	} else if endpoint.APIType == "gemini" && endpoint.Dimensions == 0 {
+		endpoint.Dimensions = qc.fullVectorSize()
+	}
+	return endpoint
+}
+
+// endpoint resolves an embedding service configuration to an endpoint. An
+// empty key falls back to AZURE_OPENAI_API_KEY, GEMINI_API_KEY or
+// OPENAI_API_KEY by type.
+func (c *EmbeddingConfig) endpoint() EmbeddingEndpoint {
+	endpoint := EmbeddingEndpoint{
+		APIType:    strings.ToLower(c.APIType),
+		BaseURL:    c.Endpoint,
+		APIKey:     c.APIKey,
+		Model:      c.Model,
+		Deployment: c.Deployment,
+		APIVersion: c.APIVersion,
+		Dimensions: c.Dimensions,
+	}
+	if endpoint.APIType == "" {
+		endpoint.APIType = "openai"
+	}
+	if endpoint.APIKey == "" && endpoint.isAzure() {
+		endpoint.APIKey = os.Getenv("AZURE_OPENAI_API_KEY")
+	}
+	if endpoint.APIKey == "" && endpoint.APIType == "gemini" {
+		endpoint.APIKey = os.Getenv("GEMINI_API_KEY")
+	}
+	if endpoint.APIKey == "" && endpoint.APIType != "gemini" {
+		endpoint.APIKey = os.Getenv("OPENAI_API_KEY")
+	}
+	return endpoint
+}
+
+// audit records an embeddings call in the external call log
+func (e EmbeddingEndpoint) audit(ctx context.Context, text string, tokens int, started time.Time, callErr error) {
+	call := calllog.Call{
+		Time:        started,
+		Service:     calllog.ServiceEmbedding,
+		Provider:    e.APIType,
+		Endpoint:    e.URL(),
+		Model:       e.ModelName(),
+		Purpose:     calllog.PurposeFrom(ctx, "embedding"),
+		InputTokens: tokens,
+		DurationMS:  time.Since(started).Milliseconds(),
+	}
+	if call.Provider == "" {
+		call.Provider = "openai"
+	}
+	if callErr != nil {
+		call.Error = callErr.Error()
+	}
+	calllog.Record(call, text)
+}
@@ -2,17 +2,21 @@ package vectordb
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
-	"os"
 	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/logger"
 )
 
 // EmbeddingService - MINIMAL implementation with accurate cost tracking
 type EmbeddingService struct {
-	apiKey     string
+	endpoint   EmbeddingEndpoint
 	httpClient *http.Client
+	mu         sync.Mutex // guards cache and costTracker
 	cache      map[string][]float32
 	costTracker *CostTracker
 }
@@ -26,20 +30,19 @@ type CostTracker struct {
 
 // EmbeddingConfig holds minimal configuration
 type EmbeddingConfig struct {
-	APIKey   string `json:"api_key"`
-	Endpoint string `json:"endpoint"`
-	Model    string `json:"model"`
+	APIKey     string `json:"api_key"`
+	Endpoint   string `json:"endpoint"` // base URL; Azure resource URL for azure api types
+	Model      string `json:"model"`
+	APIType    string `json:"api_type,omitempty"`
+	Deployment string `json:"deployment,omitempty"`
+	APIVersion string `json:"api_version,omitempty"`
+	Dimensions int    `json:"dimensions,omitempty"`
 }
 
 // NewEmbeddingService creates a minimal embedding service
 func NewEmbeddingService(config *EmbeddingConfig) *EmbeddingService {
-	apiKey := config.APIKey
-	if apiKey == "" {
-		apiKey = os.Getenv("OPENAI_API_KEY")
-	}
-
 	return &EmbeddingService{
-		apiKey:     apiKey,
+		endpoint:   config.endpoint(),
 		httpClient: &http.Client{Timeout: 30 * time.Second},
 		cache:      make(map[string][]float32),
 		costTracker: &CostTracker{},
@@ -49,87 +52,74 @@ func NewEmbeddingService(config *EmbeddingConfig) *EmbeddingService {
 // GenerateEmbedding generates a single embedding with cost tracking
 func (es *EmbeddingService) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
 	// Check cache first
-	if cached, exists := es.cache[text]; exists {
-		fmt.Printf("💾 Cache hit for embedding\n")
+	es.mu.Lock()
+	cached, exists := es.cache[text]
+	es.mu.Unlock()
+	if exists {
+		logger.Debugf(ctx, logger.ComponentVectorDB, "💾 Cache hit for embedding")
 		return cached, nil
 	}
 
-	if es.apiKey == "" {
-		fmt.Printf("⚠️ No OpenAI API key, using fallback embedding\n")
+	if !es.endpoint.Enabled() {
+		fmt.Printf("⚠️ No embedding endpoint configured, using fallback embedding\n")
 		return es.generateFallbackEmbedding(text), nil
 	}
 
 	// Estimate cost BEFORE API call
 	estimatedTokens := len(text) / 4
-	estimatedCost := float64(estimatedTokens) / 1000.0 * 0.0001
-	fmt.Printf("💰 Estimated embedding cost: $%.6f (%d tokens)\n", estimatedCost, estimatedTokens)
-
-	reqBody := map[string]interface{}{
-		"input": text,
-		"model": "text-embedding-3-small",
-	}
+	estimatedCost := float64(estimatedTokens) / 1000.0 * es.endpoint.CostPer1K()
+	logger.Debugf(ctx, logger.ComponentVectorDB, "💰 Estimated embedding cost: $%.6f (%d tokens)", estimatedCost, estimatedTokens)
 
-	jsonData, err := json.Marshal(reqBody)
+	req, err := es.endpoint.NewRequest(ctx, text)
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/embeddings", strings.NewReader(string(jsonData)))
-	if err != nil {
-		return nil, err
-	}
-
-	req.Header.Set("Authorization", "Bearer "+es.apiKey)
-	req.Header.Set("Content-Type", "application/json")
-
+	started := time.Now()
 	resp, err := es.httpClient.Do(req)
 	if err != nil {
+		es.endpoint.audit(ctx, text, 0, started, err)
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("OpenAI API error %d: %s", resp.StatusCode, string(body))
-	}
-
-	var embeddingResp struct {
-		Data []struct {
-			Embedding []float32 `json:"embedding"`
-		} `json:"data"`
-		Usage struct {
-			TotalTokens int `json:"total_tokens"`
-		} `json:"usage"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&embeddingResp); err != nil {
+		err := fmt.Errorf("embedding API error %d from %s: %s", resp.StatusCode, es.endpoint.APIType, string(body))
+		es.endpoint.audit(ctx, text, 0, started, err)
 		return nil, err
 	}
 
-	if len(embeddingResp.Data) == 0 {
-		return nil, fmt.Errorf("no embeddings returned")
+	embedding, tokens, err := es.endpoint.DecodeResponse(resp.Body, text)
+	es.endpoint.audit(ctx, text, tokens, started, err)
+	if err != nil {
+		return nil, err
 	}
 
-	embedding := embeddingResp.Data[0].Embedding
-
 	// Track actual cost
-	actualCost := float64(embeddingResp.Usage.TotalTokens) / 1000.0 * 0.0001
-	es.costTracker.TotalTokens += embeddingResp.Usage.TotalTokens
+	actualCost := float64(tokens) / 1000.0 * es.endpoint.CostPer1K()
+	es.mu.Lock()
+	es.costTracker.TotalTokens += tokens
 	es.costTracker.TotalCost += actualCost
 	es.costTracker.RequestCount++
-
-	fmt.Printf("💰 Actual cost: $%.6f | Total so far: $%.4f (%d requests)\n", 
-		actualCost, es.costTracker.TotalCost, es.costTracker.RequestCount)
+	totalCost, requests := es.costTracker.TotalCost, es.costTracker.RequestCount
 
 	// Cache the result
 	es.cache[text] = embedding
+	es.mu.Unlock()
+
+	logger.Verbosef(ctx, logger.ComponentVectorDB, "💰 Actual cost: $%.6f | Total so far: $%.4f (%d requests)",
+		actualCost, totalCost, requests)
 
 	return embedding, nil
 }
 
-// GetCostStats returns actual cost statistics
+// GetCostStats returns a copy of the actual cost statistics
 func (es *EmbeddingService) GetCostStats() *CostTracker {
-	return es.costTracker
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	stats := *es.costTracker
+	return &stats
 }
 
 // generateFallbackEmbedding creates simple hash-based embedding for testing
@@ -0,0 +1,92 @@
+package vectordb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/yourusername/useq-ai-assistant/internal/logger"
+)
+
+// FreshnessSource reports indexed files whose content changed on disk since
+// they were indexed, with the reason. The SQLite storage satisfies it.
+type FreshnessSource interface {
+	StaleFiles(paths []string) (map[string]string, error)
+}
+
+// FileReindexer re-indexes a single file. The code indexer satisfies it.
+type FileReindexer interface {
+	IndexFile(ctx context.Context, filePath string) error
+}
+
+// FreshnessConfig controls the check of search results against disk
+type FreshnessConfig struct {
+	Enabled     bool `json:"enabled"`
+	AutoReindex bool `json:"auto_reindex"` // re-index stale files and search again before returning
+	MaxReindex  int  `json:"max_reindex"`  // files re-indexed per search
+}
+
+// DefaultFreshnessConfig returns freshness defaults: annotate, don't re-index
+func DefaultFreshnessConfig() FreshnessConfig {
+	return FreshnessConfig{
+		Enabled:     true,
+		AutoReindex: false,
+		MaxReindex:  3,
+	}
+}
+
+// SetFreshness sets how search results are checked against disk. reindexer
+// may be nil, which disables auto re-indexing.
+func (qc *QdrantClient) SetFreshness(config FreshnessConfig, source FreshnessSource, reindexer FileReindexer) {
+	qc.freshness = config
+	qc.freshnessSource = source
+	qc.reindexer = reindexer
+}
+
+// markStale sets Stale on results whose file changed since it was indexed and
+// returns the stale files. Dependency chunks are never checked.
+func (qc *QdrantClient) markStale(results []*SearchResult) map[string]string {
+	if !qc.freshness.Enabled || qc.freshnessSource == nil || len(results) == 0 {
+		return nil
+	}
+	paths := make([]string, 0, len(results))
+	for _, result := range results {
+		if result.Chunk != nil && result.Chunk.Origin == "" {
+			paths = append(paths, result.Chunk.FilePath)
+		}
+	}
+	stale, err := qc.freshnessSource.StaleFiles(paths)
+	if err != nil {
+		fmt.Printf("⚠️ Freshness check failed: %v\n", err)
+		return nil
+	}
+	for _, result := range results {
+		if result.Chunk != nil && result.Chunk.Origin == "" {
+			result.Stale = stale[result.Chunk.FilePath]
+		}
+	}
+	return stale
+}
+
+// reindexStale re-indexes modified files so the caller can search again. It
+// returns how many files were re-indexed; deleted files are left annotated.
+func (qc *QdrantClient) reindexStale(ctx context.Context, stale map[string]string) int {
+	if !qc.freshness.AutoReindex || qc.reindexer == nil {
+		return 0
+	}
+	reindexed := 0
+	for path, reason := range stale {
+		if reason != "modified" {
+			continue
+		}
+		if qc.freshness.MaxReindex > 0 && reindexed >= qc.freshness.MaxReindex {
+			break
+		}
+		if err := qc.reindexer.IndexFile(ctx, path); err != nil {
+			fmt.Printf("⚠️ Failed to re-index stale file %s: %v\n", path, err)
+			continue
+		}
+		logger.Notef(ctx, logger.ComponentVectorDB, "🔄 Re-indexed stale file before answering: %s", path)
+		reindexed++
+	}
+	return reindexed
+}
@@ -0,0 +1,354 @@
+package vectordb
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// PayloadMode controls how chunk text is kept in Qdrant payloads
+type PayloadMode string
+
+const (
+	// PayloadFull stores chunk text as-is (the original behaviour)
+	PayloadFull PayloadMode = "full"
+	// PayloadCompressed stores zstd-compressed chunk text
+	PayloadCompressed PayloadMode = "compressed"
+	// PayloadReference stores no text; it is hydrated from SQLite at result
+	// time. Chunks without a SQLite row (dependencies) fall back to compressed.
+	PayloadReference PayloadMode = "reference"
+)
+
+// migrationPageSize is how many points are rewritten per scroll page
+const migrationPageSize = 256
+
+// ParsePayloadMode validates a configured payload mode; empty means full
+func ParsePayloadMode(value string) (PayloadMode, error) {
+	switch mode := PayloadMode(value); mode {
+	case "":
+		return PayloadFull, nil
+	case PayloadFull, PayloadCompressed, PayloadReference:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown payload mode %q (want full, compressed or reference)", value)
+	}
+}
+
+// ContentSource resolves chunk text by the reference stored in the payload.
+// The SQLite storage satisfies it.
+type ContentSource interface {
+	ChunkContents(refs []string) (map[string]string, error)
+}
+
+// SetContentSource sets where reference-mode payloads are hydrated from
+func (qc *QdrantClient) SetContentSource(source ContentSource) {
+	qc.contentSource = source
+}
+
+// PayloadMode returns the mode new chunks are stored with
+func (qc *QdrantClient) PayloadMode() PayloadMode {
+	if qc.config.PayloadMode == "" {
+		return PayloadFull
+	}
+	return qc.config.PayloadMode
+}
+
+// buildPayload returns the point payload for a chunk in the given mode
+func buildPayload(chunk *CodeChunk, mode PayloadMode) (map[string]interface{}, error) {
+	payload := map[string]interface{}{
+		"original_id": chunk.ID,
+		"file":        chunk.FilePath,
+		"language":    chunk.Language,
+		"start_line":  chunk.StartLine,
+		"end_line":    chunk.EndLine,
+		"origin":      chunk.Origin,
+		"module":      chunk.Module,
+	}
+	if chunk.ContentRef != "" {
+		payload["content_ref"] = chunk.ContentRef
+	}
+	if len(chunk.Tags) > 0 {
+		payload["tags"] = chunk.Tags
+	}
+	if chunk.Scope != "" {
+		payload["scope"] = chunk.Scope
+	}
+
+	if mode == PayloadReference && chunk.ContentRef == "" {
+		mode = PayloadCompressed
+	}
+	switch mode {
+	case PayloadReference:
+		// Text lives in SQLite only
+	case PayloadCompressed:
+		payload["content_zst"] = compressContent(chunk.Content)
+	default:
+		payload["content"] = chunk.Content
+	}
+	return payload, nil
+}
+
+// payloadContent returns the chunk text carried by a payload, if any, and
+// the SQLite reference to hydrate it from otherwise
+func payloadContent(payload map[string]interface{}) (content, ref string, err error) {
+	ref, _ = payload["content_ref"].(string)
+	if text, ok := payload["content"].(string); ok {
+		return text, ref, nil
+	}
+	if compressed, ok := payload["content_zst"].(string); ok {
+		text, err := decompressContent(compressed)
+		return text, ref, err
+	}
+	return "", ref, nil
+}
+
+// hydrate fills in the text of reference-mode results from the content source
+func (qc *QdrantClient) hydrate(results []*SearchResult, refs map[*CodeChunk]string) error {
+	if len(refs) == 0 {
+		return nil
+	}
+	if qc.contentSource == nil {
+		return fmt.Errorf("%d results have no stored content and no content source is set", len(refs))
+	}
+
+	wanted := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		wanted = append(wanted, ref)
+	}
+	contents, err := qc.contentSource.ChunkContents(wanted)
+	if err != nil {
+		return fmt.Errorf("failed to hydrate chunk content: %w", err)
+	}
+	for chunk, ref := range refs {
+		chunk.Content = contents[ref]
+	}
+	return nil
+}
+
+// Safe for concurrent use; EncodeAll and DecodeAll reuse their buffers
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil)
+	zstdDecoder, _ = zstd.NewReader(nil)
+)
+
+func compressContent(content string) string {
+	return base64.StdEncoding.EncodeToString(zstdEncoder.EncodeAll([]byte(content), nil))
+}
+
+func decompressContent(encoded string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("invalid compressed content: %w", err)
+	}
+	text, err := zstdDecoder.DecodeAll(data, nil)
+	if err != nil {
+		return "", fmt.Errorf("invalid compressed content: %w", err)
+	}
+	return string(text), nil
+}
+
+// PayloadMigrationStats summarizes a payload migration
+type PayloadMigrationStats struct {
+	Mode      PayloadMode `json:"mode"`
+	Scanned   int         `json:"scanned"`
+	Rewritten int         `json:"rewritten"`
+	Unchanged int         `json:"unchanged"`
+	Fallback  int         `json:"fallback"` // reference requested but no content_ref; stored compressed
+	Failed    int         `json:"failed"`
+}
+
+// MigratePayloads rewrites the payload of every point in the collection to
+// the given mode without re-embedding. Points indexed before content_ref was
+// recorded cannot be referenced and are compressed instead; reindex them to
+// drop their text entirely.
+func (qc *QdrantClient) MigratePayloads(ctx context.Context, mode PayloadMode) (*PayloadMigrationStats, error) {
+	stats := &PayloadMigrationStats{Mode: mode}
+	var offset interface{}
+
+	for {
+		points, next, err := qc.scrollPoints(ctx, offset, false)
+		if err != nil {
+			return stats, err
+		}
+
+		for _, point := range points {
+			stats.Scanned++
+			content, ref, err := payloadContent(point.Payload)
+			if err != nil {
+				stats.Failed++
+				continue
+			}
+			if content == "" && ref != "" && qc.contentSource != nil {
+				contents, err := qc.contentSource.ChunkContents([]string{ref})
+				if err != nil {
+					stats.Failed++
+					continue
+				}
+				content = contents[ref]
+			}
+
+			chunk := chunkFromPayload(point.Payload)
+			chunk.Content = content
+			if mode == PayloadReference && ref == "" {
+				stats.Fallback++
+			}
+			if payloadInMode(point.Payload, mode, ref != "") {
+				stats.Unchanged++
+				continue
+			}
+			if content == "" && (mode != PayloadReference || ref == "") {
+				// The text could not be recovered; keep the point as it is
+				stats.Failed++
+				continue
+			}
+
+			payload, err := buildPayload(chunk, mode)
+			if err != nil {
+				stats.Failed++
+				continue
+			}
+			if err := qc.overwritePayload(ctx, point.ID, payload); err != nil {
+				stats.Failed++
+				continue
+			}
+			stats.Rewritten++
+		}
+
+		if next == nil {
+			return stats, nil
+		}
+		offset = next
+	}
+}
+
+// payloadInMode reports whether a payload already stores its text the way mode asks
+func payloadInMode(payload map[string]interface{}, mode PayloadMode, hasRef bool) bool {
+	_, full := payload["content"]
+	_, compressed := payload["content_zst"]
+	switch {
+	case mode == PayloadFull:
+		return full && !compressed
+	case mode == PayloadCompressed || !hasRef:
+		return compressed && !full
+	default:
+		return !full && !compressed
+	}
+}
+
+// chunkFromPayload rebuilds the chunk fields stored in a payload, except its text
+func chunkFromPayload(payload map[string]interface{}) *CodeChunk {
+	chunk := &CodeChunk{}
+	chunk.ID, _ = payload["original_id"].(string)
+	chunk.FilePath, _ = payload["file"].(string)
+	chunk.Language, _ = payload["language"].(string)
+	chunk.Origin, _ = payload["origin"].(string)
+	chunk.Module, _ = payload["module"].(string)
+	chunk.Scope, _ = payload["scope"].(string)
+	chunk.ContentRef, _ = payload["content_ref"].(string)
+	if startLine, ok := payload["start_line"].(float64); ok {
+		chunk.StartLine = int(startLine)
+	}
+	if endLine, ok := payload["end_line"].(float64); ok {
+		chunk.EndLine = int(endLine)
+	}
+	chunk.Tags = payloadTags(payload)
+	return chunk
+}
+
+// payloadTags returns the semantic tags stored in a payload
+func payloadTags(payload map[string]interface{}) []string {
+	values, _ := payload["tags"].([]interface{})
+	var tags []string
+	for _, value := range values {
+		if tag, ok := value.(string); ok {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+type scrolledPoint struct {
+	ID      interface{}            `json:"id"`
+	Payload map[string]interface{} `json:"payload"`
+	Vector  []float32              `json:"vector,omitempty"`
+}
+
+// scrollPoints reads one page of points with their payloads, and their
+// vectors when withVector is set
+func (qc *QdrantClient) scrollPoints(ctx context.Context, offset interface{}, withVector bool) ([]scrolledPoint, interface{}, error) {
+	scrollReq := map[string]interface{}{
+		"limit":        migrationPageSize,
+		"with_payload": true,
+		"with_vector":  withVector,
+	}
+	if offset != nil {
+		scrollReq["offset"] = offset
+	}
+
+	var scrollResp struct {
+		Result struct {
+			Points         []scrolledPoint `json:"points"`
+			NextPageOffset interface{}     `json:"next_page_offset"`
+		} `json:"result"`
+	}
+	if err := qc.postJSON(ctx, "POST", "points/scroll", scrollReq, &scrollResp); err != nil {
+		return nil, nil, fmt.Errorf("scroll failed: %w", err)
+	}
+	return scrollResp.Result.Points, scrollResp.Result.NextPageOffset, nil
+}
+
+// overwritePayload replaces the whole payload of one point
+func (qc *QdrantClient) overwritePayload(ctx context.Context, id interface{}, payload map[string]interface{}) error {
+	return qc.postJSON(ctx, "PUT", "points/payload", map[string]interface{}{
+		"payload": payload,
+		"points":  []interface{}{id},
+	}, nil)
+}
+
+// postJSON sends a JSON request to a collection endpoint, or the collection
+// itself when path is empty, and decodes the reply into out, if set. A nil
+// body sends none.
+func (qc *QdrantClient) postJSON(ctx context.Context, method, path string, body interface{}, out interface{}) error {
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	url := fmt.Sprintf("http://%s:%d/collections/%s", qc.config.Host, qc.config.Port, qc.config.Collection)
+	if path != "" {
+		url += "/" + path
+	}
+	resp, err := qc.do(ctx, func() (*http.Request, error) {
+		var reqBody io.Reader
+		if data != nil {
+			reqBody = bytes.NewReader(data)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+		if err == nil && data != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		return req, err
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("status %d: %s", resp.StatusCode, string(respBody))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
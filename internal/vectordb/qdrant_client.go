@@ -10,46 +10,133 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/calllog"
+	"github.com/yourusername/useq-ai-assistant/internal/logger"
 )
 
 // QdrantClient - MINIMAL implementation focused on core functionality
 type QdrantClient struct {
-	httpClient     *http.Client
-	config         *QdrantConfig
-	embeddingCache map[string][]float32 // Simple in-memory cache
+	httpClient        *http.Client
+	config            *QdrantConfig
+	embeddings        *embeddingCache // shared with the clients of other collections
+	calibrator        *ScoreCalibrator
+	contentSource     ContentSource     // hydrates reference-mode payloads
+	embeddingEndpoint EmbeddingEndpoint // where embeddings are requested
+	freshness         FreshnessConfig
+	freshnessSource   FreshnessSource // compares result files with disk
+	reindexer         FileReindexer   // re-indexes stale files when enabled
+	reduction         ReductionConfig // how this collection's embeddings are shrunk
+	pca               *PCAModel       // projection for pca reduction
+	fullSize          int             // size of unreduced embeddings
+	tuning            TuningConfig    // collection profile and HNSW overrides
+	searchEf          int             // HNSW candidates per search; 0 is Qdrant's default
+	quantization      string          // the collection's quantization; searches rescore when set
+	breaker           *breaker        // shared with the clients of other collections
+	sharding          *shardSet       // set when the project collection is split by directory
+}
+
+// maxEmbeddingCacheEntries caps the in-memory embedding cache
+const maxEmbeddingCacheEntries = 10000
+
+// embeddingCache keeps the embeddings of recently embedded texts. It is
+// reset rather than grown without bound, so indexing a large repository
+// doesn't keep every embedding resident.
+type embeddingCache struct {
+	mu      sync.Mutex
+	entries map[string][]float32
+}
+
+func newEmbeddingCache() *embeddingCache {
+	return &embeddingCache{entries: make(map[string][]float32)}
+}
+
+func (c *embeddingCache) get(key string) ([]float32, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	embedding, ok := c.entries[key]
+	return embedding, ok
+}
+
+func (c *embeddingCache) put(key string, embedding []float32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= maxEmbeddingCacheEntries {
+		c.entries = make(map[string][]float32)
+	}
+	c.entries[key] = embedding
+}
+
+func (c *embeddingCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string][]float32)
 }
 
 // QdrantConfig - simplified configuration
 type QdrantConfig struct {
-	Host       string `json:"host"`
-	Port       int    `json:"port"`
-	Collection string `json:"collection"`
-	VectorSize int    `json:"vector_size"`
+	Host        string                     `json:"host"`
+	Port        int                        `json:"port"`
+	Collection  string                     `json:"collection"`
+	VectorSize  int                        `json:"vector_size"`
+	PayloadMode PayloadMode                `json:"payload_mode"`         // full, compressed or reference
+	Reductions  map[string]ReductionConfig `json:"reductions,omitempty"` // embedding reduction by collection
+	Tuning      TuningConfig               `json:"tuning"`               // memory layout and HNSW parameters
+	MaxRetries  int                        `json:"max_retries"`          // retries of a failed request
+	RetryDelay  time.Duration              `json:"retry_delay"`          // first backoff, doubled per retry
+	Breaker     BreakerConfig              `json:"breaker"`              // stops calls after repeated failures
 }
 
 // CodeChunk - minimal structure for vector storage
 type CodeChunk struct {
-	ID        string `json:"id"`
-	Content   string `json:"content"`
-	FilePath  string `json:"file_path"`
-	Language  string `json:"language"`
-	StartLine int    `json:"start_line"`
-	EndLine   int    `json:"end_line"`
+	ID         string   `json:"id"`
+	Content    string   `json:"content"`
+	FilePath   string   `json:"file_path"`
+	Language   string   `json:"language"`
+	StartLine  int      `json:"start_line"`
+	EndLine    int      `json:"end_line"`
+	Origin     string   `json:"origin,omitempty"`      // "deps" for dependency source, empty for the project
+	Module     string   `json:"module,omitempty"`      // module@version of dependency chunks
+	ContentRef string   `json:"content_ref,omitempty"` // SQLite row holding the text, for reference payloads
+	Tags       []string `json:"tags,omitempty"`        // semantic tags: auth, db, http, concurrency...
+	Scope      string   `json:"scope,omitempty"`       // whose conversation memory a point belongs to
 }
 
 // SearchResult - minimal search result
 type SearchResult struct {
 	Chunk *CodeChunk `json:"chunk"`
 	Score float32    `json:"score"`
+	Stale string     `json:"stale,omitempty"` // "modified" or "deleted" when the file changed since indexing
 }
 
 // NewQdrantClient creates a minimal Qdrant client
 func NewQdrantClient(config *QdrantConfig) (*QdrantClient, error) {
+	if _, err := ParsePayloadMode(string(config.PayloadMode)); err != nil {
+		return nil, err
+	}
+	if _, err := ParseProfile(config.Tuning.Profile); err != nil {
+		return nil, err
+	}
+	if _, _, err := ParseQuantization(config.Tuning.Quantization, config.Tuning.Compression); err != nil {
+		return nil, err
+	}
+
+	// The collection's reduction sizes its vectors; the caller's config is left as is
+	clientConfig := *config
 	qc := &QdrantClient{
-		httpClient:     &http.Client{Timeout: 30 * time.Second},
-		config:         config,
-		embeddingCache: make(map[string][]float32),
+		httpClient:        &http.Client{Timeout: 30 * time.Second, Transport: newQdrantTransport(config)},
+		config:            &clientConfig,
+		embeddings:        newEmbeddingCache(),
+		calibrator:        NewScoreCalibrator(DefaultCalibrationPath, CalibrationMethod(os.Getenv("USEQ_SCORE_CALIBRATION"))),
+		embeddingEndpoint: DefaultEmbeddingEndpoint(),
+		tuning:            config.Tuning,
+	}
+	qc.breaker = newBreaker(config.Breaker, qc.testConnection)
+
+	if err := qc.applyReduction(); err != nil {
+		return nil, err
 	}
 
 	// Test connection
@@ -61,13 +148,79 @@ func NewQdrantClient(config *QdrantConfig) (*QdrantClient, error) {
 	if err := qc.ensureCollection(); err != nil {
 		return nil, fmt.Errorf("collection setup failed: %w", err)
 	}
+	qc.applyTuning(context.Background())
 
 	fmt.Printf("✅ Qdrant connected: %s:%d\n", config.Host, config.Port)
 	return qc, nil
 }
 
+// qdrantTransport records the requests sent to Qdrant in the external call
+// log. Embedding requests share the client and are recorded, with their
+// tokens, where they are made.
+type qdrantTransport struct {
+	host string
+	base http.RoundTripper
+}
+
+func newQdrantTransport(config *QdrantConfig) *qdrantTransport {
+	return &qdrantTransport{host: fmt.Sprintf("%s:%d", config.Host, config.Port), base: http.DefaultTransport}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *qdrantTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host != t.host {
+		return t.base.RoundTrip(req)
+	}
+	started := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	calllog.RecordHTTP(calllog.ServiceVectorDB, "qdrant", req, resp, started, err)
+	return resp, err
+}
+
+// WithCollection returns a client for another collection on the same Qdrant
+// instance, sharing the embedding cache and calibration. The collection is
+// created if it does not exist, sized by its configured reduction.
+func (qc *QdrantClient) WithCollection(collection string) (*QdrantClient, error) {
+	config := *qc.config
+	config.Collection = collection
+	// Sized from the model's full output; applyReduction shrinks it if configured
+	config.VectorSize = qc.fullVectorSize()
+
+	other := &QdrantClient{
+		httpClient:        qc.httpClient,
+		config:            &config,
+		embeddings:        qc.embeddings,
+		calibrator:        qc.calibrator,
+		contentSource:     qc.contentSource,
+		embeddingEndpoint: qc.embeddingEndpoint,
+		freshness:         qc.freshness,
+		freshnessSource:   qc.freshnessSource,
+		reindexer:         qc.reindexer,
+		fullSize:          qc.fullSize,
+		tuning:            qc.tuning,
+		breaker:           qc.breaker.share(),
+	}
+	if err := other.applyReduction(); err != nil {
+		other.breaker.close()
+		return nil, err
+	}
+	if err := other.ensureCollection(); err != nil {
+		other.breaker.close()
+		return nil, fmt.Errorf("collection setup failed for %s: %w", collection, err)
+	}
+	other.applyTuning(context.Background())
+	return other, nil
+}
+
 // Search performs semantic search - CORE FUNCTIONALITY
 func (qc *QdrantClient) Search(ctx context.Context, query string, limit int) ([]*SearchResult, error) {
+	return qc.SearchWithFilter(ctx, query, limit, nil)
+}
+
+// SearchWithFilter performs semantic search restricted by payload fields.
+// Supported keys are "language", "file", "origin", "scope" and "tags";
+// empty values are ignored.
+func (qc *QdrantClient) SearchWithFilter(ctx context.Context, query string, limit int, filters map[string]string) ([]*SearchResult, error) {
 	// Generate embedding for query
 	embedding, err := qc.generateEmbedding(ctx, query)
 	if err != nil {
@@ -75,27 +228,80 @@ func (qc *QdrantClient) Search(ctx context.Context, query string, limit int) ([]
 	}
 
 	// Search vectors
-	return qc.searchVectors(ctx, embedding, limit)
+	results, err := qc.searchVectors(ctx, embedding, limit, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	// Results from files edited since indexing are flagged, or re-indexed
+	// and searched again when auto re-indexing is on
+	if stale := qc.markStale(results); len(stale) > 0 && qc.reindexStale(ctx, stale) > 0 {
+		if results, err = qc.searchVectors(ctx, embedding, limit, filters); err != nil {
+			return nil, err
+		}
+		qc.markStale(results)
+	}
+
+	// Normalize scores so thresholds hold across embedding models
+	qc.calibrator.Apply(qc.CalibrationKey(), results)
+	return results, nil
+}
+
+// CalibrationKey identifies the active collection and embedding model
+func (qc *QdrantClient) CalibrationKey() string {
+	return CalibrationKey(qc.config.Collection, qc.embeddingModel())
+}
+
+// CalibrateWithBenchmark runs benchmark queries, splits raw scores into
+// relevant and irrelevant hits and learns a calibration for the active model
+func (qc *QdrantClient) CalibrateWithBenchmark(ctx context.Context, queries []BenchmarkQuery, limit int) (*CalibrationProfile, error) {
+	var relevant, irrelevant []float32
+
+	for _, bq := range queries {
+		embedding, err := qc.generateEmbedding(ctx, bq.Query)
+		if err != nil {
+			return nil, fmt.Errorf("embedding generation failed for %q: %w", bq.Query, err)
+		}
+		results, err := qc.searchVectors(ctx, embedding, limit, nil)
+		if err != nil {
+			return nil, fmt.Errorf("benchmark search failed for %q: %w", bq.Query, err)
+		}
+
+		for _, r := range results {
+			if isRelevantFile(r.Chunk.FilePath, bq.RelevantFiles) {
+				relevant = append(relevant, r.Score)
+			} else {
+				irrelevant = append(irrelevant, r.Score)
+			}
+		}
+	}
+
+	return qc.calibrator.LearnFromBenchmark(qc.CalibrationKey(), relevant, irrelevant)
 }
 
 // StoreChunkWithEmbedding stores code chunk with embedding
 func (qc *QdrantClient) StoreChunkWithEmbedding(ctx context.Context, chunk *CodeChunk, embedding []float32) error {
+	if qc.sharding != nil {
+		shard, err := qc.shardFor(qc.ShardOf(chunk.FilePath))
+		if err != nil {
+			return err
+		}
+		return shard.StoreChunkWithEmbedding(ctx, chunk, embedding)
+	}
+
 	// Generate numeric ID from string ID
 	hash := fnv.New32a()
 	hash.Write([]byte(chunk.ID))
 	numericID := hash.Sum32()
 
+	payload, err := buildPayload(chunk, qc.PayloadMode())
+	if err != nil {
+		return err
+	}
 	point := map[string]interface{}{
-		"id":     numericID,
-		"vector": embedding,
-		"payload": map[string]interface{}{
-			"original_id": chunk.ID,
-			"file":        chunk.FilePath,
-			"content":     chunk.Content,
-			"language":    chunk.Language,
-			"start_line":  chunk.StartLine,
-			"end_line":    chunk.EndLine,
-		},
+		"id":      numericID,
+		"vector":  embedding,
+		"payload": payload,
 	}
 
 	reqBody, err := json.Marshal(map[string]interface{}{
@@ -106,13 +312,13 @@ func (qc *QdrantClient) StoreChunkWithEmbedding(ctx context.Context, chunk *Code
 	}
 
 	url := fmt.Sprintf("http://%s:%d/collections/%s/points", qc.config.Host, qc.config.Port, qc.config.Collection)
-	req, err := http.NewRequestWithContext(ctx, "PUT", url, bytes.NewBuffer(reqBody))
-	if err != nil {
-		return err
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := qc.httpClient.Do(req)
+	resp, err := qc.do(ctx, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "PUT", url, bytes.NewBuffer(reqBody))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		return req, err
+	})
 	if err != nil {
 		return err
 	}
@@ -126,78 +332,61 @@ func (qc *QdrantClient) StoreChunkWithEmbedding(ctx context.Context, chunk *Code
 	return nil
 }
 
-// GenerateOpenAIEmbedding generates OpenAI embeddings with cost tracking
+// GenerateOpenAIEmbedding generates embeddings from the configured endpoint
+// (OpenAI, Azure, a compatible server or Gemini) with cost tracking
 func (qc *QdrantClient) GenerateOpenAIEmbedding(ctx context.Context, text string) ([]float32, error) {
 	// Check cache first
-	if cached, exists := qc.embeddingCache[text]; exists {
+	cacheKey := qc.embeddingCacheKey(text)
+	if cached, exists := qc.embeddings.get(cacheKey); exists {
 		return cached, nil
 	}
 
-	apiKey := os.Getenv("OPENAI_API_KEY")
-	if apiKey == "" {
+	endpoint := qc.sizedEndpoint()
+	if !endpoint.Enabled() {
 		return qc.generateFallbackEmbedding(text), nil
 	}
 
 	// Calculate cost BEFORE making request
 	estimatedTokens := len(text) / 4 // ~4 chars per token
-	estimatedCost := float64(estimatedTokens) / 1000.0 * 0.0001 // $0.0001 per 1K tokens
+	estimatedCost := float64(estimatedTokens) / 1000.0 * endpoint.CostPer1K()
 	
-	fmt.Printf("💰 Embedding cost: ~$%.6f (%d tokens)\n", estimatedCost, estimatedTokens)
-
-	reqBody := map[string]interface{}{
-		"input": text,
-		"model": "text-embedding-3-small",
-	}
+	logger.Debugf(ctx, logger.ComponentVectorDB, "💰 Embedding cost: ~$%.6f (%d tokens)", estimatedCost, estimatedTokens)
 
-	jsonData, err := json.Marshal(reqBody)
+	req, err := endpoint.NewRequest(ctx, text)
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/embeddings", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, err
-	}
-
-	req.Header.Set("Authorization", "Bearer "+apiKey)
-	req.Header.Set("Content-Type", "application/json")
-
+	started := time.Now()
 	resp, err := qc.httpClient.Do(req)
 	if err != nil {
+		endpoint.audit(ctx, text, 0, started, err)
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("OpenAI API error %d: %s", resp.StatusCode, string(body))
-	}
-
-	var embeddingResp struct {
-		Data []struct {
-			Embedding []float32 `json:"embedding"`
-		} `json:"data"`
-		Usage struct {
-			TotalTokens int `json:"total_tokens"`
-		} `json:"usage"`
+		err := fmt.Errorf("embedding API error %d from %s: %s", resp.StatusCode, endpoint.APIType, string(body))
+		endpoint.audit(ctx, text, 0, started, err)
+		return nil, err
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&embeddingResp); err != nil {
+	embedding, tokens, err := endpoint.DecodeResponse(resp.Body, text)
+	endpoint.audit(ctx, text, tokens, started, err)
+	if err != nil {
 		return nil, err
 	}
-
-	if len(embeddingResp.Data) == 0 {
-		return nil, fmt.Errorf("no embeddings returned")
+	if embedding, err = qc.reduceVector(embedding); err != nil {
+		return nil, err
 	}
-
-	embedding := embeddingResp.Data[0].Embedding
 	
 	// Calculate actual cost
-	actualCost := float64(embeddingResp.Usage.TotalTokens) / 1000.0 * 0.0001
-	fmt.Printf("💰 Actual embedding cost: $%.6f (%d tokens)\n", actualCost, embeddingResp.Usage.TotalTokens)
+	actualCost := float64(tokens) / 1000.0 * endpoint.CostPer1K()
+	logger.Verbosef(ctx, logger.ComponentVectorDB, "💰 Actual embedding cost: $%.6f (%d tokens)", actualCost, tokens)
 
 	// Cache the result
-	qc.embeddingCache[text] = embedding
+	qc.embeddings.put(cacheKey, embedding)
 
 	return embedding, nil
 }
@@ -207,11 +396,56 @@ func (qc *QdrantClient) Health(ctx context.Context) error {
 	return qc.testConnection()
 }
 
+// CountPoints returns the exact number of points stored in the collection,
+// or in all of its shards
+func (qc *QdrantClient) CountPoints(ctx context.Context) (int, error) {
+	if qc.sharding != nil {
+		total := 0
+		for _, shard := range qc.shardClients() {
+			count, err := shard.CountPoints(ctx)
+			if err != nil {
+				return 0, err
+			}
+			total += count
+		}
+		return total, nil
+	}
+
+	url := fmt.Sprintf("http://%s:%d/collections/%s/points/count", qc.config.Host, qc.config.Port, qc.config.Collection)
+	resp, err := qc.do(ctx, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBufferString(`{"exact":true}`))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		return req, err
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return 0, fmt.Errorf("count failed with status %d", resp.StatusCode)
+	}
+
+	var countResp struct {
+		Result struct {
+			Count int `json:"count"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&countResp); err != nil {
+		return 0, err
+	}
+	return countResp.Result.Count, nil
+}
+
 // Close cleans up resources
 func (qc *QdrantClient) Close() error {
+	qc.breaker.close()
+
 	// Clear cache
-	qc.embeddingCache = nil
-	return nil
+	qc.embeddings.clear()
+	return qc.calibrator.Save()
 }
 
 // =============================================================================
@@ -241,13 +475,9 @@ func (qc *QdrantClient) ensureCollection() error {
 		return nil // Collection exists
 	}
 
-	// Create collection
-	payload := map[string]interface{}{
-		"vectors": map[string]interface{}{
-			"size":     qc.config.VectorSize,
-			"distance": "Cosine",
-		},
-	}
+	// Create collection, laid out as its profile recommends while empty;
+	// "maintenance tune" revisits that as it grows
+	payload := collectionCreateSettings(qc.tuning.Settings(0), qc.config.VectorSize)
 
 	jsonData, err := json.Marshal(payload)
 	if err != nil {
@@ -274,9 +504,35 @@ func (qc *QdrantClient) ensureCollection() error {
 	return nil
 }
 
+// buildPayloadFilter turns simple key/value filters into a Qdrant "must" filter.
+// "tags" holds comma-separated tags, all of which a chunk must carry.
+func buildPayloadFilter(filters map[string]string) map[string]interface{} {
+	var must []interface{}
+	for _, key := range []string{"language", "file", "origin", "scope"} {
+		if value := filters[key]; value != "" {
+			must = append(must, map[string]interface{}{
+				"key":   key,
+				"match": map[string]interface{}{"value": value},
+			})
+		}
+	}
+	for _, tag := range strings.Split(filters["tags"], ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			must = append(must, map[string]interface{}{
+				"key":   "tags",
+				"match": map[string]interface{}{"value": tag},
+			})
+		}
+	}
+	if len(must) == 0 {
+		return nil
+	}
+	return map[string]interface{}{"must": must}
+}
+
 func (qc *QdrantClient) generateEmbedding(ctx context.Context, text string) ([]float32, error) {
-	// Try OpenAI first
-	if os.Getenv("OPENAI_API_KEY") != "" {
+	// Try the configured endpoint first
+	if qc.embeddingEndpoint.Enabled() {
 		return qc.GenerateOpenAIEmbedding(ctx, text)
 	}
 
@@ -284,6 +540,24 @@ func (qc *QdrantClient) generateEmbedding(ctx context.Context, text string) ([]f
 	return qc.generateFallbackEmbedding(text), nil
 }
 
+// embeddingModel names the model that produces query embeddings
+func (qc *QdrantClient) embeddingModel() string {
+	if qc.embeddingEndpoint.Enabled() {
+		return qc.embeddingEndpoint.ModelName()
+	}
+	return "fallback-hash"
+}
+
+// isRelevantFile reports whether path matches one of the expected files
+func isRelevantFile(path string, relevantFiles []string) bool {
+	for _, f := range relevantFiles {
+		if path == f || strings.HasSuffix(path, "/"+f) {
+			return true
+		}
+	}
+	return false
+}
+
 func (qc *QdrantClient) generateFallbackEmbedding(text string) []float32 {
 	words := strings.Fields(strings.ToLower(text))
 	embedding := make([]float32, qc.config.VectorSize)
@@ -300,12 +574,27 @@ func (qc *QdrantClient) generateFallbackEmbedding(text string) []float32 {
 	return embedding
 }
 
-func (qc *QdrantClient) searchVectors(ctx context.Context, embedding []float32, limit int) ([]*SearchResult, error) {
+// searchVectors searches the collection, or every shard of it
+func (qc *QdrantClient) searchVectors(ctx context.Context, embedding []float32, limit int, filters map[string]string) ([]*SearchResult, error) {
+	if qc.sharding != nil {
+		return qc.searchShards(ctx, embedding, limit, filters)
+	}
+	return qc.searchCollection(ctx, embedding, limit, filters)
+}
+
+// searchCollection searches this client's collection alone
+func (qc *QdrantClient) searchCollection(ctx context.Context, embedding []float32, limit int, filters map[string]string) ([]*SearchResult, error) {
 	searchReq := map[string]interface{}{
 		"vector":       embedding,
 		"limit":        limit,
 		"with_payload": true,
 	}
+	if filter := buildPayloadFilter(filters); filter != nil {
+		searchReq["filter"] = filter
+	}
+	if params := qc.searchParams(); params != nil {
+		searchReq["params"] = params
+	}
 
 	reqBody, err := json.Marshal(searchReq)
 	if err != nil {
@@ -313,13 +602,13 @@ func (qc *QdrantClient) searchVectors(ctx context.Context, embedding []float32,
 	}
 
 	url := fmt.Sprintf("http://%s:%d/collections/%s/points/search", qc.config.Host, qc.config.Port, qc.config.Collection)
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(reqBody))
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := qc.httpClient.Do(req)
+	resp, err := qc.do(ctx, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(reqBody))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		return req, err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -341,14 +630,21 @@ func (qc *QdrantClient) searchVectors(ctx context.Context, embedding []float32,
 	}
 
 	results := make([]*SearchResult, 0, len(searchResp.Result))
+	unhydrated := make(map[*CodeChunk]string)
 	for _, hit := range searchResp.Result {
 		chunk := &CodeChunk{}
 
 		if file, ok := hit.Payload["file"].(string); ok {
 			chunk.FilePath = file
 		}
-		if content, ok := hit.Payload["content"].(string); ok {
-			chunk.Content = content
+		content, ref, err := payloadContent(hit.Payload)
+		if err != nil {
+			return nil, err
+		}
+		chunk.Content = content
+		chunk.ContentRef = ref
+		if content == "" && ref != "" {
+			unhydrated[chunk] = ref
 		}
 		if language, ok := hit.Payload["language"].(string); ok {
 			chunk.Language = language
@@ -362,6 +658,16 @@ func (qc *QdrantClient) searchVectors(ctx context.Context, embedding []float32,
 		if endLine, ok := hit.Payload["end_line"].(float64); ok {
 			chunk.EndLine = int(endLine)
 		}
+		if origin, ok := hit.Payload["origin"].(string); ok {
+			chunk.Origin = origin
+		}
+		if module, ok := hit.Payload["module"].(string); ok {
+			chunk.Module = module
+		}
+		if scope, ok := hit.Payload["scope"].(string); ok {
+			chunk.Scope = scope
+		}
+		chunk.Tags = payloadTags(hit.Payload)
 
 		results = append(results, &SearchResult{
 			Score: float32(hit.Score),
@@ -369,5 +675,8 @@ func (qc *QdrantClient) searchVectors(ctx context.Context, embedding []float32,
 		})
 	}
 
+	if err := qc.hydrate(results, unhydrated); err != nil {
+		return nil, err
+	}
 	return results, nil
 }
\ No newline at end of file
@@ -0,0 +1,47 @@
+package vectordb
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// TestWithCollectionSharesCache embeds through two collections' clients at
+// once; run with -race
+func TestWithCollectionSharesCache(t *testing.T) {
+	base := &QdrantClient{config: &QdrantConfig{Collection: "code"}, embeddings: newEmbeddingCache()}
+	other := &QdrantClient{config: &QdrantConfig{Collection: "memory"}, embeddings: base.embeddings}
+
+	var wg sync.WaitGroup
+	for i, client := range []*QdrantClient{base, other, base, other} {
+		wg.Add(1)
+		go func(i int, client *QdrantClient) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				key := fmt.Sprintf("text %d", j)
+				client.embeddings.put(key, []float32{float32(i), float32(j)})
+				client.embeddings.get(key)
+				if j%50 == 0 {
+					client.embeddings.clear()
+				}
+			}
+		}(i, client)
+	}
+	wg.Wait()
+
+	base.embeddings.put("shared", []float32{1})
+	if _, ok := other.embeddings.get("shared"); !ok {
+		t.Error("an embedding cached through one collection is not seen by the other")
+	}
+}
+
+func TestEmbeddingCacheResetsWhenFull(t *testing.T) {
+	cache := newEmbeddingCache()
+	for i := 0; i < maxEmbeddingCacheEntries; i++ {
+		cache.put(fmt.Sprintf("text %d", i), nil)
+	}
+	cache.put("one more", []float32{1})
+	if len(cache.entries) != 1 {
+		t.Errorf("cache holds %d entries after filling up, want 1", len(cache.entries))
+	}
+}
@@ -0,0 +1,454 @@
+package vectordb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+)
+
+// ReductionMethod selects how embeddings are shrunk for a collection
+type ReductionMethod string
+
+const (
+	// ReductionNone stores embeddings at the model's full size
+	ReductionNone ReductionMethod = ""
+	// ReductionDimensions asks the model for shorter embeddings (the OpenAI
+	// dimensions parameter, Gemini's outputDimensionality). Models trained
+	// for it, such as text-embedding-3-*, lose little recall.
+	ReductionDimensions ReductionMethod = "dimensions"
+	// ReductionPCA projects full embeddings onto their principal components,
+	// fitted on the project's own vectors; works with any model
+	ReductionPCA ReductionMethod = "pca"
+)
+
+// pcaIterations is how many rounds of orthogonal iteration fit a projection
+const pcaIterations = 12
+
+// DefaultPCASamples is how many stored vectors a projection is fitted on
+const DefaultPCASamples = 2000
+
+// ReductionConfig shrinks the embeddings of one collection, trading a little
+// recall for less Qdrant memory. Vectors of a reduced collection don't mix
+// with full-size ones; build it with "vectors reduce".
+type ReductionConfig struct {
+	Method     ReductionMethod `json:"method" mapstructure:"method"`
+	Dimensions int             `json:"dimensions" mapstructure:"dimensions"`
+	ModelPath  string          `json:"model_path,omitempty" mapstructure:"model_path"` // fitted PCA projection
+}
+
+// Enabled reports whether the collection's embeddings are reduced
+func (rc ReductionConfig) Enabled() bool {
+	return rc.Method != ReductionNone && rc.Dimensions > 0
+}
+
+// Validate checks a configured reduction
+func (rc ReductionConfig) Validate() error {
+	switch rc.Method {
+	case ReductionNone:
+		return nil
+	case ReductionDimensions, ReductionPCA:
+	default:
+		return fmt.Errorf("unknown reduction method %q (want dimensions or pca)", rc.Method)
+	}
+	if rc.Dimensions <= 0 {
+		return fmt.Errorf("reduction %s needs a positive dimensions", rc.Method)
+	}
+	if rc.Method == ReductionPCA && rc.ModelPath == "" {
+		return fmt.Errorf("pca reduction needs a model_path")
+	}
+	return nil
+}
+
+// String describes the reduction, e.g. "pca→512"
+func (rc ReductionConfig) String() string {
+	if !rc.Enabled() {
+		return "none"
+	}
+	return fmt.Sprintf("%s→%d", rc.Method, rc.Dimensions)
+}
+
+// PCAModel projects embeddings onto an orthonormal basis of their top
+// principal components
+type PCAModel struct {
+	InputDims  int         `json:"input_dims"`
+	Mean       []float32   `json:"mean"`
+	Components [][]float32 `json:"components"` // one row per output dimension
+	Explained  float64     `json:"explained"`  // share of the variance kept
+	Samples    int         `json:"samples"`
+}
+
+// FitPCA fits a projection to dims dimensions on sample embeddings by
+// orthogonal iteration on their covariance. Only the spanned subspace
+// matters for cosine similarity, so components are not individually sorted.
+func FitPCA(samples [][]float32, dims int) (*PCAModel, error) {
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("no sample vectors to fit a projection on")
+	}
+	d := len(samples[0])
+	if dims <= 0 || dims >= d {
+		return nil, fmt.Errorf("cannot reduce %d dimensions to %d", d, dims)
+	}
+	if len(samples) <= dims {
+		return nil, fmt.Errorf("need more than %d sample vectors to fit %d components, have %d", dims, dims, len(samples))
+	}
+
+	n := float64(len(samples))
+	mean := make([]float64, d)
+	for _, sample := range samples {
+		if len(sample) != d {
+			return nil, fmt.Errorf("sample vectors have mixed sizes (%d and %d)", d, len(sample))
+		}
+		for j, v := range sample {
+			mean[j] += float64(v) / n
+		}
+	}
+
+	// Covariance, filled from its upper triangle
+	cov := make([]float64, d*d)
+	centered := make([]float64, d)
+	for _, sample := range samples {
+		for j, v := range sample {
+			centered[j] = float64(v) - mean[j]
+		}
+		for i := 0; i < d; i++ {
+			ci := centered[i]
+			if ci == 0 {
+				continue
+			}
+			row := cov[i*d:]
+			for j := i; j < d; j++ {
+				row[j] += ci * centered[j]
+			}
+		}
+	}
+	total := 0.0
+	for i := 0; i < d; i++ {
+		for j := i; j < d; j++ {
+			cov[i*d+j] /= n
+			cov[j*d+i] = cov[i*d+j]
+		}
+		total += cov[i*d+i]
+	}
+
+	// Orthogonal iteration from a fixed random start, so fits are repeatable
+	random := rand.New(rand.NewSource(1))
+	basis := make([][]float64, dims)
+	for k := range basis {
+		basis[k] = make([]float64, d)
+		for j := range basis[k] {
+			basis[k][j] = random.NormFloat64()
+		}
+	}
+	orthonormalize(basis)
+	for iteration := 0; iteration < pcaIterations; iteration++ {
+		for k, vector := range basis {
+			basis[k] = multiplySymmetric(cov, vector)
+		}
+		orthonormalize(basis)
+	}
+
+	kept := 0.0
+	for _, vector := range basis {
+		projected := multiplySymmetric(cov, vector)
+		for j := range vector {
+			kept += vector[j] * projected[j]
+		}
+	}
+
+	model := &PCAModel{
+		InputDims:  d,
+		Mean:       make([]float32, d),
+		Components: make([][]float32, dims),
+		Samples:    len(samples),
+	}
+	if total > 0 {
+		model.Explained = kept / total
+	}
+	for j, v := range mean {
+		model.Mean[j] = float32(v)
+	}
+	for k, vector := range basis {
+		model.Components[k] = make([]float32, d)
+		for j, v := range vector {
+			model.Components[k][j] = float32(v)
+		}
+	}
+	return model, nil
+}
+
+// Project reduces a full-size embedding to a unit vector of the model's
+// output size
+func (m *PCAModel) Project(embedding []float32) ([]float32, error) {
+	if len(embedding) != m.InputDims {
+		return nil, fmt.Errorf("projection expects %d dimensions, got %d", m.InputDims, len(embedding))
+	}
+	projected := make([]float32, len(m.Components))
+	for k, component := range m.Components {
+		var sum float64
+		for j, v := range embedding {
+			sum += float64(v-m.Mean[j]) * float64(component[j])
+		}
+		projected[k] = float32(sum)
+	}
+	return NormalizeVector(projected), nil
+}
+
+// LoadPCAModel reads a fitted projection
+func LoadPCAModel(path string) (*PCAModel, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PCA projection: %w", err)
+	}
+	var model PCAModel
+	if err := json.Unmarshal(data, &model); err != nil {
+		return nil, fmt.Errorf("failed to parse PCA projection: %w", err)
+	}
+	return &model, nil
+}
+
+// Save writes the projection to path
+func (m *PCAModel) Save(path string) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("failed to encode PCA projection: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create projection directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write PCA projection: %w", err)
+	}
+	return nil
+}
+
+// TruncateEmbedding keeps the leading dims values of an embedding and
+// renormalizes it, which is what the dimensions parameter does server-side
+func TruncateEmbedding(embedding []float32, dims int) []float32 {
+	if dims <= 0 || len(embedding) <= dims {
+		return embedding
+	}
+	return NormalizeVector(embedding[:dims])
+}
+
+// ReductionStats summarizes building a reduced collection
+type ReductionStats struct {
+	Collection string          `json:"collection"`
+	Reduction  ReductionConfig `json:"reduction"`
+	Copied     int             `json:"copied"`
+	Failed     int             `json:"failed"`
+	Explained  float64         `json:"explained,omitempty"` // variance kept by a PCA projection
+}
+
+// Reduction returns the collection's embedding reduction
+func (qc *QdrantClient) Reduction() ReductionConfig {
+	return qc.reduction
+}
+
+// VectorSize returns the size of the vectors stored in the collection
+func (qc *QdrantClient) VectorSize() int {
+	return qc.config.VectorSize
+}
+
+// CopyReduced fills target, a reduced collection, from this collection's
+// full-size vectors without re-embedding: vectors are truncated for the
+// dimensions method, or projected with a PCA model fitted here on up to
+// samples vectors and saved to the target's model path.
+func (qc *QdrantClient) CopyReduced(ctx context.Context, target *QdrantClient, samples int) (*ReductionStats, error) {
+	reduction := target.reduction
+	stats := &ReductionStats{Collection: target.config.Collection, Reduction: reduction}
+	if !reduction.Enabled() {
+		return stats, fmt.Errorf("collection %s has no reduction configured", target.config.Collection)
+	}
+	if qc.reduction.Enabled() {
+		return stats, fmt.Errorf("collection %s is already reduced; copy from a full-size collection", qc.config.Collection)
+	}
+	if reduction.Dimensions >= qc.config.VectorSize {
+		return stats, fmt.Errorf("cannot reduce %d dimensions to %d", qc.config.VectorSize, reduction.Dimensions)
+	}
+
+	if reduction.Method == ReductionPCA {
+		if samples <= 0 {
+			samples = DefaultPCASamples
+		}
+		vectors, err := qc.sampleVectors(ctx, samples)
+		if err != nil {
+			return stats, err
+		}
+		model, err := FitPCA(vectors, reduction.Dimensions)
+		if err != nil {
+			return stats, err
+		}
+		if err := model.Save(reduction.ModelPath); err != nil {
+			return stats, err
+		}
+		target.pca = model
+		stats.Explained = model.Explained
+	}
+
+	var offset interface{}
+	for {
+		points, next, err := qc.scrollPoints(ctx, offset, true)
+		if err != nil {
+			return stats, err
+		}
+		batch := make([]interface{}, 0, len(points))
+		for _, point := range points {
+			vector, err := target.reduceVector(point.Vector)
+			if err != nil || len(vector) != reduction.Dimensions {
+				stats.Failed++
+				continue
+			}
+			batch = append(batch, map[string]interface{}{
+				"id":      point.ID,
+				"vector":  vector,
+				"payload": point.Payload,
+			})
+		}
+		if len(batch) > 0 {
+			if err := target.postJSON(ctx, "PUT", "points", map[string]interface{}{"points": batch}, nil); err != nil {
+				return stats, fmt.Errorf("failed to store reduced vectors: %w", err)
+			}
+			stats.Copied += len(batch)
+		}
+		if next == nil {
+			return stats, nil
+		}
+		offset = next
+	}
+}
+
+// applyReduction sizes the client for its collection's configured reduction
+// and loads the collection's PCA projection
+func (qc *QdrantClient) applyReduction() error {
+	if qc.fullSize == 0 {
+		qc.fullSize = qc.config.VectorSize
+	}
+	qc.reduction = qc.config.Reductions[qc.config.Collection]
+	qc.pca = nil
+	if err := qc.reduction.Validate(); err != nil {
+		return fmt.Errorf("collection %s: %w", qc.config.Collection, err)
+	}
+	if !qc.reduction.Enabled() {
+		return nil
+	}
+	qc.config.VectorSize = qc.reduction.Dimensions
+	if qc.reduction.Method == ReductionPCA {
+		// A missing projection is fitted by "vectors reduce"
+		if model, err := LoadPCAModel(qc.reduction.ModelPath); err == nil {
+			qc.pca = model
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// reduceVector shrinks a full-size embedding for the collection. Vectors
+// already at the reduced size (a server honoring the dimensions parameter)
+// pass through.
+func (qc *QdrantClient) reduceVector(embedding []float32) ([]float32, error) {
+	if !qc.reduction.Enabled() || len(embedding) == qc.reduction.Dimensions {
+		return embedding, nil
+	}
+	switch qc.reduction.Method {
+	case ReductionPCA:
+		if qc.pca == nil {
+			return nil, fmt.Errorf("no PCA projection for %s; run \"vectors reduce %s\"", qc.config.Collection, qc.config.Collection)
+		}
+		return qc.pca.Project(embedding)
+	default:
+		return TruncateEmbedding(embedding, qc.reduction.Dimensions), nil
+	}
+}
+
+// fullVectorSize is the size of the model's unreduced embeddings
+func (qc *QdrantClient) fullVectorSize() int {
+	if qc.fullSize > 0 {
+		return qc.fullSize
+	}
+	return qc.config.VectorSize
+}
+
+// embeddingCacheKey keys cached embeddings by reduction as well as text,
+// since clients for different collections share the cache
+func (qc *QdrantClient) embeddingCacheKey(text string) string {
+	if !qc.reduction.Enabled() {
+		return text
+	}
+	return qc.reduction.String() + "|" + text
+}
+
+// sampleVectors reads up to limit stored vectors
+func (qc *QdrantClient) sampleVectors(ctx context.Context, limit int) ([][]float32, error) {
+	var (
+		vectors [][]float32
+		offset  interface{}
+	)
+	for len(vectors) < limit {
+		points, next, err := qc.scrollPoints(ctx, offset, true)
+		if err != nil {
+			return nil, err
+		}
+		for _, point := range points {
+			if len(point.Vector) > 0 && len(vectors) < limit {
+				vectors = append(vectors, point.Vector)
+			}
+		}
+		if next == nil {
+			break
+		}
+		offset = next
+	}
+	return vectors, nil
+}
+
+// orthonormalize makes vectors an orthonormal set by modified Gram-Schmidt;
+// a vector that collapses is replaced by a unit basis vector
+func orthonormalize(vectors [][]float64) {
+	for k, vector := range vectors {
+		for _, previous := range vectors[:k] {
+			dot := 0.0
+			for j := range vector {
+				dot += vector[j] * previous[j]
+			}
+			for j := range vector {
+				vector[j] -= dot * previous[j]
+			}
+		}
+		norm := 0.0
+		for _, v := range vector {
+			norm += v * v
+		}
+		norm = math.Sqrt(norm)
+		if norm < 1e-12 {
+			for j := range vector {
+				vector[j] = 0
+			}
+			vector[k%len(vector)] = 1
+			continue
+		}
+		for j := range vector {
+			vector[j] /= norm
+		}
+	}
+}
+
+// multiplySymmetric returns matrix × vector for a square row-major matrix
+func multiplySymmetric(matrix, vector []float64) []float64 {
+	d := len(vector)
+	out := make([]float64, d)
+	for i := 0; i < d; i++ {
+		row := matrix[i*d : (i+1)*d]
+		sum := 0.0
+		for j, v := range vector {
+			sum += row[j] * v
+		}
+		out[i] = sum
+	}
+	return out
+}
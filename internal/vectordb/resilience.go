@@ -0,0 +1,350 @@
+package vectordb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/logger"
+)
+
+// ErrUnavailable is returned without contacting Qdrant while the circuit
+// breaker is open
+var ErrUnavailable = errors.New("vector database unavailable")
+
+// maxRetryDelay caps the backoff between attempts
+const maxRetryDelay = 10 * time.Second
+
+// BreakerConfig controls when repeated Qdrant failures stop further calls
+type BreakerConfig struct {
+	FailureThreshold  int           `json:"failure_threshold"`  // consecutive failed operations that open the circuit
+	OpenDuration      time.Duration `json:"open_duration"`      // how long calls fail fast before one is let through
+	ReconnectInterval time.Duration `json:"reconnect_interval"` // how often an open circuit probes Qdrant; 0 disables
+}
+
+// DefaultBreakerConfig returns circuit breaker defaults
+func DefaultBreakerConfig() BreakerConfig {
+	return BreakerConfig{
+		FailureThreshold:  5,
+		OpenDuration:      30 * time.Second,
+		ReconnectInterval: 15 * time.Second,
+	}
+}
+
+// BreakerState is the state of the circuit breaker
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"    // calls go through
+	BreakerOpen     BreakerState = "open"      // calls fail fast with ErrUnavailable
+	BreakerHalfOpen BreakerState = "half_open" // one trial call decides between the two
+)
+
+// Availability is the client's view of Qdrant after its recent operations
+type Availability struct {
+	State     BreakerState `json:"state"`
+	Failures  int          `json:"consecutive_failures"`
+	Trips     int          `json:"trips"` // times the circuit opened since start
+	LastError string       `json:"last_error,omitempty"`
+	Since     time.Time    `json:"since"` // when the state last changed
+}
+
+// Degraded reports whether vector operations are failing: the circuit is
+// not closed, or the latest operation failed
+func (a Availability) Degraded() bool {
+	return a.State != BreakerClosed || a.Failures > 0
+}
+
+// breaker is shared by the clients of one Qdrant instance, since they fail
+// together; its reconnect loop stops when the last of them closes
+type breaker struct {
+	config BreakerConfig
+	probe  func() error // checks whether Qdrant answers again
+
+	mu        sync.Mutex
+	state     BreakerState
+	failures  int
+	trips     int
+	lastErr   error
+	since     time.Time
+	probing   bool // a half-open trial call is in flight
+	refs      int  // clients sharing the breaker
+	stopped   bool
+	stop      chan struct{}
+	reconnect bool // the reconnect loop is running
+}
+
+func newBreaker(config BreakerConfig, probe func() error) *breaker {
+	defaults := DefaultBreakerConfig()
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = defaults.FailureThreshold
+	}
+	if config.OpenDuration <= 0 {
+		config.OpenDuration = defaults.OpenDuration
+	}
+	return &breaker{
+		config: config,
+		probe:  probe,
+		state:  BreakerClosed,
+		since:  time.Now(),
+		refs:   1,
+		stop:   make(chan struct{}),
+	}
+}
+
+// share returns the breaker for one more client, which must close it
+func (b *breaker) share() *breaker {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refs++
+	return b
+}
+
+// allow reports whether a call may go to Qdrant. After the open duration
+// one call at a time is let through to test the connection.
+func (b *breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.since) < b.config.OpenDuration {
+			return b.unavailable()
+		}
+		b.setState(BreakerHalfOpen)
+		b.probing = true
+		return nil
+	case BreakerHalfOpen:
+		if b.probing {
+			return b.unavailable()
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+func (b *breaker) unavailable() error {
+	return fmt.Errorf("%w since %s: %v", ErrUnavailable, b.since.Format(time.TimeOnly), b.lastErr)
+}
+
+// succeed closes the circuit
+func (b *breaker) succeed() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	b.failures = 0
+	if b.state != BreakerClosed {
+		b.setState(BreakerClosed)
+		logger.Notef(context.Background(), logger.ComponentVectorDB, "✅ Qdrant reachable again; vector search restored")
+	}
+}
+
+// fail records an operation that failed after its retries, opening the
+// circuit at the threshold or when the half-open trial fails
+func (b *breaker) fail(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	b.failures++
+	b.lastErr = err
+	if b.state == BreakerOpen {
+		return
+	}
+	if b.state == BreakerHalfOpen || b.failures >= b.config.FailureThreshold {
+		b.setState(BreakerOpen)
+		b.trips++
+		logger.Notef(context.Background(), logger.ComponentVectorDB,
+			"⚠️ Qdrant unavailable after %d failed operations (%v); vector search is degraded until it reconnects", b.failures, err)
+		b.startReconnect()
+	}
+}
+
+func (b *breaker) setState(state BreakerState) {
+	b.state = state
+	b.since = time.Now()
+}
+
+// startReconnect probes Qdrant in the background while the circuit is open,
+// so it closes without waiting for a call to try. Called with mu held.
+func (b *breaker) startReconnect() {
+	if b.reconnect || b.stopped || b.probe == nil || b.config.ReconnectInterval <= 0 {
+		return
+	}
+	b.reconnect = true
+	go func() {
+		ticker := time.NewTicker(b.config.ReconnectInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-b.stop:
+				return
+			case <-ticker.C:
+			}
+			if err := b.probe(); err != nil {
+				continue
+			}
+			b.mu.Lock()
+			b.reconnect = false
+			b.mu.Unlock()
+			b.succeed()
+			return
+		}
+	}()
+}
+
+// close releases one client's share, stopping the reconnect loop once no
+// client uses the breaker
+func (b *breaker) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.refs > 0 {
+		b.refs--
+	}
+	if b.refs == 0 && !b.stopped {
+		b.stopped = true
+		close(b.stop)
+	}
+}
+
+func (b *breaker) availability() Availability {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	availability := Availability{
+		State:    b.state,
+		Failures: b.failures,
+		Trips:    b.trips,
+		Since:    b.since,
+	}
+	if b.lastErr != nil && (b.state != BreakerClosed || b.failures > 0) {
+		availability.LastError = b.lastErr.Error()
+	}
+	return availability
+}
+
+// Availability reports whether Qdrant is answering, for status displays and
+// to mark answers built without vector search
+func (qc *QdrantClient) Availability() Availability {
+	return qc.breaker.availability()
+}
+
+// do sends a request to Qdrant, retrying connection errors and 5xx or 429
+// replies with jittered exponential backoff. newRequest is called for every
+// attempt since a request body can only be read once. The final reply is
+// returned whatever its status, for the caller to report; an operation that
+// fails after its retries counts towards opening the circuit.
+func (qc *QdrantClient) do(ctx context.Context, newRequest func() (*http.Request, error)) (*http.Response, error) {
+	if err := qc.breaker.allow(); err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for attempt := 0; ; attempt++ {
+		req, err := newRequest()
+		if err != nil {
+			qc.breaker.release()
+			return nil, err
+		}
+		resp, err := qc.httpClient.Do(req)
+		if err == nil && !retryableStatus(resp.StatusCode) {
+			qc.breaker.succeed()
+			return resp, nil
+		}
+		if err != nil {
+			lastErr = err
+		} else {
+			lastErr = fmt.Errorf("status %d", resp.StatusCode)
+		}
+
+		if ctx.Err() != nil {
+			// The caller gave up; that says nothing about Qdrant
+			qc.breaker.release()
+			return resp, err
+		}
+		if attempt >= qc.config.MaxRetries {
+			qc.breaker.fail(lastErr)
+			return resp, err
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+		select {
+		case <-ctx.Done():
+			qc.breaker.release()
+			return nil, ctx.Err()
+		case <-time.After(backoff(qc.config.RetryDelay, attempt)):
+		}
+	}
+}
+
+// release ends a half-open trial that never reached Qdrant, so another
+// call may make it
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// retryableStatus reports whether a reply means Qdrant is struggling rather
+// than the request being wrong
+func retryableStatus(status int) bool {
+	return status >= http.StatusInternalServerError || status == http.StatusTooManyRequests
+}
+
+// backoff returns the wait before retry attempt+1: the base delay doubled
+// per attempt, capped, with the upper half randomized so clients that failed
+// together don't retry together
+func backoff(base time.Duration, attempt int) time.Duration {
+	if base <= 0 {
+		base = 200 * time.Millisecond
+	}
+	delay := base << attempt
+	if delay <= 0 || delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// Probe checks whether Qdrant answers, without going through the circuit
+// breaker
+func (qc *QdrantClient) Probe(ctx context.Context) error {
+	url := fmt.Sprintf("http://%s:%d/collections", qc.config.Host, qc.config.Port)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := qc.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Failing reports why vector operations are failing while the circuit is
+// open, or nil
+func (qc *QdrantClient) Failing() error {
+	availability := qc.breaker.availability()
+	if availability.State != BreakerOpen {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrUnavailable, availability.LastError)
+}
+
+// Reconnect re-initializes the client once Qdrant answers again: pooled
+// connections from before the outage are dropped, the collection is
+// re-created if Qdrant came back empty, and the circuit closes
+func (qc *QdrantClient) Reconnect() error {
+	qc.httpClient.CloseIdleConnections()
+	if err := qc.ensureCollection(); err != nil {
+		return fmt.Errorf("failed to ensure collection %s: %w", qc.config.Collection, err)
+	}
+	qc.breaker.succeed()
+	return nil
+}
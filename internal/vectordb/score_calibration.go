@@ -0,0 +1,241 @@
+package vectordb
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// CalibrationMethod selects how raw similarity scores are normalized
+type CalibrationMethod string
+
+const (
+	CalibrationNone    CalibrationMethod = "none"    // pass raw scores through
+	CalibrationMinMax  CalibrationMethod = "minmax"  // rescale by observed min/max
+	CalibrationLearned CalibrationMethod = "learned" // linear map fitted on benchmark queries
+)
+
+// DefaultCalibrationPath is where calibration profiles are persisted
+const DefaultCalibrationPath = "storage/score_calibration.json"
+
+// Anchors for learned calibration: a typical relevant hit maps to
+// calibratedRelevant and a typical irrelevant hit to calibratedIrrelevant,
+// which keeps the fixed 0.7 / 0.3 thresholds meaningful for every model
+const (
+	calibratedRelevant   = 0.85
+	calibratedIrrelevant = 0.35
+	minMaxMinSamples     = 50
+)
+
+// CalibrationProfile holds normalization parameters for one collection/model pair
+type CalibrationProfile struct {
+	Key       string            `json:"key"`
+	Method    CalibrationMethod `json:"method"`
+	Min       float64           `json:"min"`
+	Max       float64           `json:"max"`
+	Samples   int               `json:"samples"`
+	Slope     float64           `json:"slope,omitempty"`
+	Intercept float64           `json:"intercept,omitempty"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// BenchmarkQuery is a query with the files known to be relevant to it
+type BenchmarkQuery struct {
+	Query         string   `json:"query"`
+	RelevantFiles []string `json:"relevant_files"`
+}
+
+// ScoreCalibrator normalizes similarity scores per collection and embedding
+// model so thresholds, boosts and confidence behave the same across backends
+type ScoreCalibrator struct {
+	path          string
+	defaultMethod CalibrationMethod
+	profiles      map[string]*CalibrationProfile
+	mu            sync.RWMutex
+}
+
+// CalibrationKey identifies a collection/model pair
+func CalibrationKey(collection, model string) string {
+	return collection + ":" + model
+}
+
+// NewScoreCalibrator creates a calibrator, loading saved profiles from path
+func NewScoreCalibrator(path string, method CalibrationMethod) *ScoreCalibrator {
+	if method == "" {
+		method = CalibrationMinMax
+	}
+	sc := &ScoreCalibrator{
+		path:          path,
+		defaultMethod: method,
+		profiles:      make(map[string]*CalibrationProfile),
+	}
+	if err := sc.load(); err != nil {
+		fmt.Printf("⚠️ Score calibration profiles not loaded: %v\n", err)
+	}
+	return sc
+}
+
+// Apply observes the raw scores and replaces them with calibrated ones
+func (sc *ScoreCalibrator) Apply(key string, results []*SearchResult) {
+	if sc == nil || len(results) == 0 {
+		return
+	}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	profile := sc.profileLocked(key)
+	for _, r := range results {
+		raw := float64(r.Score)
+		if profile.Samples == 0 || raw < profile.Min {
+			profile.Min = raw
+		}
+		if profile.Samples == 0 || raw > profile.Max {
+			profile.Max = raw
+		}
+		profile.Samples++
+	}
+	profile.UpdatedAt = time.Now()
+
+	for _, r := range results {
+		r.Score = float32(profile.calibrate(float64(r.Score)))
+	}
+}
+
+// Calibrate returns the calibrated value of a single raw score
+func (sc *ScoreCalibrator) Calibrate(key string, raw float32) float32 {
+	if sc == nil {
+		return raw
+	}
+
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+
+	profile, exists := sc.profiles[key]
+	if !exists {
+		return raw
+	}
+	return float32(profile.calibrate(float64(raw)))
+}
+
+// LearnFromBenchmark fits a linear mapping from raw scores of known
+// relevant and irrelevant hits and switches the profile to learned mode
+func (sc *ScoreCalibrator) LearnFromBenchmark(key string, relevant, irrelevant []float32) (*CalibrationProfile, error) {
+	if len(relevant) == 0 || len(irrelevant) == 0 {
+		return nil, fmt.Errorf("benchmark needs both relevant and irrelevant scores (got %d/%d)", len(relevant), len(irrelevant))
+	}
+
+	relMean := meanScore(relevant)
+	irrMean := meanScore(irrelevant)
+	if relMean <= irrMean {
+		return nil, fmt.Errorf("relevant hits do not score above irrelevant ones (%.3f <= %.3f)", relMean, irrMean)
+	}
+
+	sc.mu.Lock()
+	profile := sc.profileLocked(key)
+	profile.Method = CalibrationLearned
+	profile.Slope = (calibratedRelevant - calibratedIrrelevant) / (relMean - irrMean)
+	profile.Intercept = calibratedRelevant - profile.Slope*relMean
+	profile.UpdatedAt = time.Now()
+	learned := *profile
+	sc.mu.Unlock()
+
+	return &learned, sc.Save()
+}
+
+// GetProfiles returns a copy of all calibration profiles
+func (sc *ScoreCalibrator) GetProfiles() []CalibrationProfile {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+
+	profiles := make([]CalibrationProfile, 0, len(sc.profiles))
+	for _, p := range sc.profiles {
+		profiles = append(profiles, *p)
+	}
+	return profiles
+}
+
+// Save persists calibration profiles to disk
+func (sc *ScoreCalibrator) Save() error {
+	if sc.path == "" {
+		return nil
+	}
+
+	sc.mu.RLock()
+	data, err := json.MarshalIndent(sc.profiles, "", "  ")
+	sc.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode calibration profiles: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(sc.path), 0755); err != nil {
+		return fmt.Errorf("failed to create calibration directory: %w", err)
+	}
+	if err := os.WriteFile(sc.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write calibration profiles: %w", err)
+	}
+	return nil
+}
+
+// load reads calibration profiles from disk if present
+func (sc *ScoreCalibrator) load() error {
+	if sc.path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(sc.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	return json.Unmarshal(data, &sc.profiles)
+}
+
+// profileLocked returns the profile for key, creating it; caller holds mu
+func (sc *ScoreCalibrator) profileLocked(key string) *CalibrationProfile {
+	profile, exists := sc.profiles[key]
+	if !exists {
+		profile = &CalibrationProfile{Key: key, Method: sc.defaultMethod}
+		sc.profiles[key] = profile
+	}
+	return profile
+}
+
+// calibrate maps a raw score according to the profile's method
+func (p *CalibrationProfile) calibrate(raw float64) float64 {
+	var score float64
+	switch p.Method {
+	case CalibrationLearned:
+		score = p.Slope*raw + p.Intercept
+	case CalibrationMinMax:
+		// Too few observations to trust the observed range yet
+		if p.Samples < minMaxMinSamples || p.Max-p.Min < 1e-6 {
+			return raw
+		}
+		score = (raw - p.Min) / (p.Max - p.Min)
+	default:
+		return raw
+	}
+
+	if score < 0 {
+		return 0
+	}
+	if score > 1 {
+		return 1
+	}
+	return score
+}
+
+// meanScore returns the arithmetic mean of scores
+func meanScore(scores []float32) float64 {
+	var sum float64
+	for _, s := range scores {
+		sum += float64(s)
+	}
+	return sum / float64(len(scores))
+}
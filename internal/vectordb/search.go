@@ -23,8 +23,9 @@ func NewSearchService(client *QdrantClient, embedder *EmbeddingService) *SearchS
 
 // Search performs semantic search with SIMPLE ranking
 func (ss *SearchService) Search(ctx context.Context, query string, limit int, filters map[string]string) ([]*SearchResult, error) {
-	// For Tier 2: Fast search without LLM
-	results, err := ss.client.Search(ctx, query, limit)
+	// For Tier 2: Fast search without LLM; language is filtered in Qdrant
+	// so mixed-language repos fill the limit with in-scope chunks
+	results, err := ss.client.SearchWithFilter(ctx, query, limit, map[string]string{"language": filters["language"]})
 	if err != nil {
 		// Fallback to empty results rather than failing
 		fmt.Printf("⚠️ Vector search failed: %v\n", err)
@@ -0,0 +1,81 @@
+package vectordb
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// SearchBenchRun measures retrieval quality and vector memory of one
+// collection on benchmark queries
+type SearchBenchRun struct {
+	Collection  string          `json:"collection"`
+	Reduction   ReductionConfig `json:"reduction"`
+	Dimensions  int             `json:"dimensions"`
+	Points      int             `json:"points"`
+	VectorBytes int64           `json:"vector_bytes"` // raw float32 vectors, before index overhead
+	Queries     int             `json:"queries"`
+	Recall      float64         `json:"recall"` // mean share of relevant files in the top k
+	MRR         float64         `json:"mrr"`    // mean reciprocal rank of the first relevant file
+	Latency     time.Duration   `json:"latency"`
+}
+
+// BenchmarkSearch runs benchmark queries against the collection and scores
+// the top k results against the files known to be relevant
+func (qc *QdrantClient) BenchmarkSearch(ctx context.Context, queries []BenchmarkQuery, k int) (*SearchBenchRun, error) {
+	run := &SearchBenchRun{
+		Collection: qc.config.Collection,
+		Reduction:  qc.reduction,
+		Dimensions: qc.config.VectorSize,
+	}
+	points, err := qc.CountPoints(ctx)
+	if err != nil {
+		return run, fmt.Errorf("failed to count points in %s: %w", qc.config.Collection, err)
+	}
+	run.Points = points
+	run.VectorBytes = int64(points) * int64(qc.config.VectorSize) * 4
+	if points == 0 {
+		return run, nil
+	}
+
+	var elapsed time.Duration
+	for _, bq := range queries {
+		if len(bq.RelevantFiles) == 0 {
+			continue
+		}
+		start := time.Now()
+		embedding, err := qc.generateEmbedding(ctx, bq.Query)
+		if err != nil {
+			return run, fmt.Errorf("embedding generation failed for %q: %w", bq.Query, err)
+		}
+		results, err := qc.searchVectors(ctx, embedding, k, nil)
+		if err != nil {
+			return run, fmt.Errorf("benchmark search failed for %q: %w", bq.Query, err)
+		}
+		elapsed += time.Since(start)
+
+		found := make(map[string]bool)
+		firstRank := 0
+		for rank, r := range results {
+			if !isRelevantFile(r.Chunk.FilePath, bq.RelevantFiles) {
+				continue
+			}
+			found[r.Chunk.FilePath] = true
+			if firstRank == 0 {
+				firstRank = rank + 1
+			}
+		}
+		run.Queries++
+		run.Recall += min(1, float64(len(found))/float64(len(bq.RelevantFiles)))
+		if firstRank > 0 {
+			run.MRR += 1 / float64(firstRank)
+		}
+	}
+
+	if run.Queries > 0 {
+		run.Recall /= float64(run.Queries)
+		run.MRR /= float64(run.Queries)
+		run.Latency = elapsed / time.Duration(run.Queries)
+	}
+	return run, nil
+}
@@ -0,0 +1,334 @@
+package vectordb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// shardSeparator joins the base collection name and a shard key
+const shardSeparator = "__"
+
+// rootShard holds the files at the top of the project
+const rootShard = "root"
+
+// ShardConfig splits the project collection into one collection per
+// top-level directory, searched in parallel and merged by score
+type ShardConfig struct {
+	Enabled     bool `json:"enabled"`
+	Depth       int  `json:"depth"`        // leading directories that name a shard: 1 shards by top-level directory
+	MaxParallel int  `json:"max_parallel"` // shard searches in flight at once
+}
+
+// DefaultShardConfig returns sharding defaults; one collection serves most
+// repositories, so sharding is off until enabled
+func DefaultShardConfig() ShardConfig {
+	return ShardConfig{
+		Enabled:     false,
+		Depth:       1,
+		MaxParallel: 8,
+	}
+}
+
+// shardSet is the shard collections of a sharded client
+type shardSet struct {
+	config ShardConfig
+	root   string // project root paths are made relative to
+
+	mu        sync.Mutex
+	shards    map[string]*QdrantClient // by collection name
+	base      *QdrantClient            // the unsharded collection
+	unsharded bool                     // the base collection still holds vectors indexed before sharding
+}
+
+var unsafeCollectionChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// ShardKey returns the shard of a file: its first depth directories, or
+// "root" for files above that depth
+func ShardKey(root, path string, depth int) string {
+	if depth <= 0 {
+		depth = 1
+	}
+	if root != "" {
+		if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+			path = rel
+		}
+	}
+	parts := strings.Split(filepath.ToSlash(filepath.Clean(path)), "/")
+	dirs := parts[:len(parts)-1]
+	if len(dirs) == 0 || dirs[0] == "." || dirs[0] == "" {
+		return rootShard
+	}
+	if len(dirs) > depth {
+		dirs = dirs[:depth]
+	}
+	return strings.Join(dirs, "/")
+}
+
+// shardCollection names the collection of a shard key
+func (qc *QdrantClient) shardCollection(key string) string {
+	return qc.config.Collection + shardSeparator + strings.Trim(unsafeCollectionChars.ReplaceAllString(key, "_"), "_")
+}
+
+// EnableSharding routes stored chunks to one collection per shard and fans
+// searches out over every shard. Existing shard collections are found by
+// name. Vectors already in the base collection keep being searched until a
+// full reindex moves them into shards.
+func (qc *QdrantClient) EnableSharding(ctx context.Context, config ShardConfig, projectRoot string) error {
+	if !config.Enabled {
+		return nil
+	}
+	defaults := DefaultShardConfig()
+	if config.Depth <= 0 {
+		config.Depth = defaults.Depth
+	}
+	if config.MaxParallel <= 0 {
+		config.MaxParallel = defaults.MaxParallel
+	}
+	set := &shardSet{config: config, root: projectRoot, shards: make(map[string]*QdrantClient)}
+
+	names, err := qc.listCollections(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list shard collections: %w", err)
+	}
+	prefix := qc.config.Collection + shardSeparator
+	for _, name := range names {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		shard, err := qc.WithCollection(name)
+		if err != nil {
+			return err
+		}
+		set.shards[name] = shard
+	}
+	if set.base, err = qc.WithCollection(qc.config.Collection); err != nil {
+		return err
+	}
+	if count, err := qc.CountPoints(ctx); err == nil && count > 0 {
+		set.unsharded = true
+		fmt.Printf("⚠️ %d vectors were indexed before sharding; run `reindex` to move them into shards\n", count)
+	}
+
+	qc.sharding = set
+	fmt.Printf("✅ Index sharded by directory: %d shards\n", len(set.shards))
+	return nil
+}
+
+// Sharded reports whether the project collection is split into shards
+func (qc *QdrantClient) Sharded() bool {
+	return qc.sharding != nil
+}
+
+// ShardOf returns the shard key of a file; "" when the client is not sharded
+func (qc *QdrantClient) ShardOf(path string) string {
+	if qc.sharding == nil {
+		return ""
+	}
+	return ShardKey(qc.sharding.root, path, qc.sharding.config.Depth)
+}
+
+// Shards lists the shard collections with their point counts
+func (qc *QdrantClient) Shards(ctx context.Context) (map[string]int, error) {
+	counts := make(map[string]int)
+	for _, shard := range qc.shardClients() {
+		count, err := shard.CountPoints(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count %s: %w", shard.config.Collection, err)
+		}
+		counts[shard.config.Collection] = count
+	}
+	return counts, nil
+}
+
+// ResetShard empties the collection of one shard so it can be rebuilt from
+// scratch, dropping vectors of files deleted or moved since it was built
+func (qc *QdrantClient) ResetShard(ctx context.Context, key string) error {
+	if qc.sharding == nil {
+		return fmt.Errorf("the index is not sharded")
+	}
+	shard, err := qc.shardFor(key)
+	if err != nil {
+		return err
+	}
+	return shard.resetCollection(ctx)
+}
+
+// ClearUnsharded empties the base collection once its vectors have been
+// reindexed into shards
+func (qc *QdrantClient) ClearUnsharded(ctx context.Context) error {
+	if qc.sharding == nil {
+		return nil
+	}
+	qc.sharding.mu.Lock()
+	unsharded := qc.sharding.unsharded
+	qc.sharding.mu.Unlock()
+	if !unsharded {
+		return nil
+	}
+	if err := qc.resetCollection(ctx); err != nil {
+		return err
+	}
+	qc.sharding.mu.Lock()
+	qc.sharding.unsharded = false
+	qc.sharding.mu.Unlock()
+	return nil
+}
+
+// shardFor returns the client of a shard, creating its collection the
+// first time a chunk is stored in it
+func (qc *QdrantClient) shardFor(key string) (*QdrantClient, error) {
+	name := qc.shardCollection(key)
+	qc.sharding.mu.Lock()
+	defer qc.sharding.mu.Unlock()
+	if shard, ok := qc.sharding.shards[name]; ok {
+		return shard, nil
+	}
+	shard, err := qc.WithCollection(name)
+	if err != nil {
+		return nil, err
+	}
+	qc.sharding.shards[name] = shard
+	return shard, nil
+}
+
+// shardClients returns the collections a search covers: every shard, and
+// the base collection while it still holds unsharded vectors
+func (qc *QdrantClient) shardClients() []*QdrantClient {
+	qc.sharding.mu.Lock()
+	defer qc.sharding.mu.Unlock()
+	names := make([]string, 0, len(qc.sharding.shards))
+	for name := range qc.sharding.shards {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	clients := make([]*QdrantClient, 0, len(names)+1)
+	for _, name := range names {
+		clients = append(clients, qc.sharding.shards[name])
+	}
+	if qc.sharding.unsharded {
+		clients = append(clients, qc.sharding.base)
+	}
+	return clients
+}
+
+// fileClients returns the collections that can hold a file's chunks: its
+// shard, if it exists yet, and the base collection while it is unsharded
+func (qc *QdrantClient) fileClients(path string) []*QdrantClient {
+	qc.sharding.mu.Lock()
+	defer qc.sharding.mu.Unlock()
+	var clients []*QdrantClient
+	if shard, ok := qc.sharding.shards[qc.shardCollection(qc.ShardOf(path))]; ok {
+		clients = append(clients, shard)
+	}
+	if qc.sharding.unsharded {
+		clients = append(clients, qc.sharding.base)
+	}
+	return clients
+}
+
+// searchShards runs a search on every shard in parallel and merges the hits
+// by score. A "file" filter only searches that file's shard. Shards that
+// fail are skipped unless all of them do.
+func (qc *QdrantClient) searchShards(ctx context.Context, embedding []float32, limit int, filters map[string]string) ([]*SearchResult, error) {
+	clients := qc.shardClients()
+	if file := filters["file"]; file != "" {
+		clients = qc.fileClients(file)
+	}
+	if len(clients) == 0 {
+		return nil, nil
+	}
+
+	type shardResult struct {
+		results []*SearchResult
+		err     error
+	}
+	found := make([]shardResult, len(clients))
+	slots := make(chan struct{}, qc.sharding.config.MaxParallel)
+	var wg sync.WaitGroup
+	for i, client := range clients {
+		wg.Add(1)
+		go func(i int, client *QdrantClient) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			results, err := client.searchCollection(ctx, embedding, limit, filters)
+			found[i] = shardResult{results: results, err: err}
+		}(i, client)
+	}
+	wg.Wait()
+
+	var merged []*SearchResult
+	var lastErr error
+	failed := 0
+	for i, result := range found {
+		if result.err != nil {
+			failed++
+			lastErr = fmt.Errorf("shard %s: %w", clients[i].config.Collection, result.err)
+			continue
+		}
+		merged = append(merged, result.results...)
+	}
+	if failed == len(clients) {
+		return nil, lastErr
+	}
+	if failed > 0 {
+		fmt.Printf("⚠️ %d of %d shards failed to search: %v\n", failed, len(clients), lastErr)
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Score > merged[j].Score })
+	if len(merged) > limit {
+		merged = merged[:limit]
+	}
+	return merged, nil
+}
+
+// listCollections returns the names of every collection on the instance
+func (qc *QdrantClient) listCollections(ctx context.Context) ([]string, error) {
+	url := fmt.Sprintf("http://%s:%d/collections", qc.config.Host, qc.config.Port)
+	resp, err := qc.do(ctx, func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, "GET", url, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("listing collections failed with status %d", resp.StatusCode)
+	}
+
+	var listResp struct {
+		Result struct {
+			Collections []struct {
+				Name string `json:"name"`
+			} `json:"collections"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&listResp); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(listResp.Result.Collections))
+	for _, collection := range listResp.Result.Collections {
+		names = append(names, collection.Name)
+	}
+	return names, nil
+}
+
+// resetCollection drops the collection and creates it again empty, with
+// the same size and layout
+func (qc *QdrantClient) resetCollection(ctx context.Context) error {
+	if err := qc.postJSON(ctx, "DELETE", "", nil, nil); err != nil {
+		return fmt.Errorf("failed to drop %s: %w", qc.config.Collection, err)
+	}
+	if err := qc.ensureCollection(); err != nil {
+		return fmt.Errorf("failed to recreate %s: %w", qc.config.Collection, err)
+	}
+	qc.applyTuning(ctx)
+	return nil
+}
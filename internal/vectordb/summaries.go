@@ -0,0 +1,12 @@
+package vectordb
+
+// Origins of the points in the summary collection, which holds one
+// embedded summary per file and per package rather than code chunks
+const (
+	OriginFileSummary    = "file_summary"
+	OriginPackageSummary = "package_summary"
+)
+
+// OriginConversation marks the points of the memory collection, one per
+// answered question, which recall queries search
+const OriginConversation = "conversation"
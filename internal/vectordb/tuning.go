@@ -0,0 +1,440 @@
+package vectordb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Collection profiles: how much of a collection Qdrant keeps in RAM
+const (
+	ProfileAuto     = "auto"     // chosen from the collection's size
+	ProfileMemory   = "memory"   // vectors, graph and payloads in RAM
+	ProfileBalanced = "balanced" // payloads on disk, int8-quantized vectors in RAM
+	ProfileDisk     = "disk"     // full vectors memory-mapped as well
+)
+
+// Collection sizes, in points, above which auto picks a leaner profile
+const (
+	balancedProfilePoints = 100_000
+	diskProfilePoints     = 1_000_000
+)
+
+// warmUpSearches is how many searches WarmUp runs
+const warmUpSearches = 3
+
+// Quantization methods: how vectors are compressed for the in-RAM index
+const (
+	QuantizationNone    = "none"
+	QuantizationScalar  = "scalar"  // int8 per dimension, 4x smaller
+	QuantizationProduct = "product" // sub-vector codebooks, 4x to 64x smaller
+)
+
+// defaultCompression is the product quantization ratio used unless configured
+const defaultCompression = "x16"
+
+// TuningConfig selects a collection profile and overrides parts of it
+type TuningConfig struct {
+	Profile     string `json:"profile"`      // auto, memory, balanced or disk
+	M           int    `json:"m"`            // HNSW graph degree; 0 uses the profile's
+	EfConstruct int    `json:"ef_construct"` // HNSW build quality; 0 uses the profile's
+	SearchEf    int    `json:"search_ef"`    // HNSW candidates per search; 0 uses the profile's
+	WarmStart   bool   `json:"warm_start"`   // search once after connecting so the first query isn't cold
+
+	Quantization string  `json:"quantization"` // none, scalar or product; empty uses the profile's
+	Compression  string  `json:"compression"`  // product quantization ratio, x4 to x64
+	Rescore      bool    `json:"rescore"`      // re-rank quantized candidates with the full vectors
+	Oversampling float64 `json:"oversampling"` // candidates fetched per result for rescoring
+}
+
+// DefaultTuningConfig returns tuning defaults
+func DefaultTuningConfig() TuningConfig {
+	return TuningConfig{
+		Profile:      ProfileAuto,
+		WarmStart:    true,
+		Compression:  defaultCompression,
+		Rescore:      true,
+		Oversampling: 2.0,
+	}
+}
+
+// ParseProfile validates a collection profile name
+func ParseProfile(profile string) (string, error) {
+	switch profile {
+	case ProfileAuto, ProfileMemory, ProfileBalanced, ProfileDisk:
+		return profile, nil
+	case "":
+		return ProfileAuto, nil
+	default:
+		return "", fmt.Errorf("unknown collection profile %q (want auto, memory, balanced or disk)", profile)
+	}
+}
+
+// ParseQuantization validates a quantization method and product compression
+// ratio; an empty method leaves the choice to the profile
+func ParseQuantization(method, compression string) (string, string, error) {
+	switch method {
+	case "", QuantizationNone, QuantizationScalar, QuantizationProduct:
+	default:
+		return "", "", fmt.Errorf("unknown quantization %q (want none, scalar or product)", method)
+	}
+	switch compression {
+	case "":
+		compression = defaultCompression
+	case "x4", "x8", "x16", "x32", "x64":
+	default:
+		return "", "", fmt.Errorf("unknown product compression %q (want x4, x8, x16, x32 or x64)", compression)
+	}
+	return method, compression, nil
+}
+
+// CollectionSettings are the storage and index settings of a collection
+type CollectionSettings struct {
+	Profile           string `json:"profile,omitempty"`
+	OnDiskVectors     bool   `json:"on_disk_vectors"`       // memory-mapped rather than in RAM
+	OnDiskPayload     bool   `json:"on_disk_payload"`       // payloads read from disk when returned
+	M                 int    `json:"m"`                     // HNSW graph degree
+	EfConstruct       int    `json:"ef_construct"`          // HNSW build quality
+	MemmapThresholdKB int    `json:"memmap_threshold_kb"`   // segments above this are memory-mapped; 0 is Qdrant's default
+	Quantization      string `json:"quantization"`          // none, scalar or product
+	Compression       string `json:"compression,omitempty"` // product quantization ratio
+	SearchEf          int    `json:"search_ef,omitempty"`   // HNSW candidates per search; client side only
+}
+
+// profileSettings are the settings each profile stands for
+var profileSettings = map[string]CollectionSettings{
+	ProfileMemory: {
+		M: 16, EfConstruct: 100, Quantization: "none", // small collections are searched exactly anyway
+	},
+	ProfileBalanced: {
+		OnDiskPayload: true, M: 16, EfConstruct: 128, MemmapThresholdKB: 50_000,
+		Quantization: "scalar", SearchEf: 128,
+	},
+	ProfileDisk: {
+		OnDiskVectors: true, OnDiskPayload: true, M: 16, EfConstruct: 128, MemmapThresholdKB: 20_000,
+		Quantization: "scalar", SearchEf: 128,
+	},
+}
+
+// RecommendProfile picks the profile for a collection of this many points
+func RecommendProfile(points int) string {
+	switch {
+	case points >= diskProfilePoints:
+		return ProfileDisk
+	case points >= balancedProfilePoints:
+		return ProfileBalanced
+	default:
+		return ProfileMemory
+	}
+}
+
+// Settings resolves the tuning for a collection of this many points
+func (tc TuningConfig) Settings(points int) CollectionSettings {
+	profile := tc.Profile
+	if profile == "" || profile == ProfileAuto {
+		profile = RecommendProfile(points)
+	}
+	settings, ok := profileSettings[profile]
+	if !ok {
+		profile = RecommendProfile(points)
+		settings = profileSettings[profile]
+	}
+	settings.Profile = profile
+	if tc.M > 0 {
+		settings.M = tc.M
+	}
+	if tc.EfConstruct > 0 {
+		settings.EfConstruct = tc.EfConstruct
+	}
+	if tc.SearchEf > 0 {
+		settings.SearchEf = tc.SearchEf
+	}
+	if tc.Quantization != "" {
+		settings.Quantization = tc.Quantization
+	}
+	if settings.Quantization == QuantizationProduct {
+		settings.Compression = tc.Compression
+		if settings.Compression == "" {
+			settings.Compression = defaultCompression
+		}
+	}
+	return settings
+}
+
+// searchParams are the per-search parameters for the collection as tuned:
+// the HNSW candidate count and, for a quantized collection, how many extra
+// candidates to fetch and re-rank with the full vectors
+func (qc *QdrantClient) searchParams() map[string]interface{} {
+	params := map[string]interface{}{}
+	if qc.searchEf > 0 {
+		params["hnsw_ef"] = qc.searchEf
+	}
+	if qc.quantization != "" && qc.quantization != QuantizationNone {
+		quantization := map[string]interface{}{"rescore": qc.tuning.Rescore}
+		if qc.tuning.Rescore && qc.tuning.Oversampling > 1 {
+			quantization["oversampling"] = qc.tuning.Oversampling
+		}
+		params["quantization"] = quantization
+	}
+	if len(params) == 0 {
+		return nil
+	}
+	return params
+}
+
+// TuningChange is one setting that differs from the recommendation
+type TuningChange struct {
+	Setting     string `json:"setting"`
+	Current     string `json:"current"`
+	Recommended string `json:"recommended"`
+}
+
+// TuningReport is what a tuning run found and did
+type TuningReport struct {
+	Collection  string             `json:"collection"`
+	Points      int                `json:"points"`
+	Current     CollectionSettings `json:"current"`
+	Recommended CollectionSettings `json:"recommended"`
+	Changes     []TuningChange     `json:"changes"`
+	Applied     bool               `json:"applied"`
+
+	Rescore      bool    `json:"rescore"`      // quantized searches re-rank with full vectors
+	Oversampling float64 `json:"oversampling"` // candidates fetched per result to rescore
+}
+
+// Tune compares the collection's settings with those its profile
+// recommends for its size and, unless dryRun, applies the difference.
+// Qdrant rebuilds affected segments in the background.
+func (qc *QdrantClient) Tune(ctx context.Context, dryRun bool) (*TuningReport, error) {
+	current, points, err := qc.CollectionSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	recommended := qc.tuning.Settings(points)
+	report := &TuningReport{
+		Collection:  qc.config.Collection,
+		Points:      points,
+		Current:     *current,
+		Recommended: recommended,
+		Changes:     diffSettings(*current, recommended),
+
+		Rescore:      qc.tuning.Rescore,
+		Oversampling: qc.tuning.Oversampling,
+	}
+	qc.searchEf = recommended.SearchEf
+	qc.quantization = current.Quantization
+	if dryRun || len(report.Changes) == 0 {
+		return report, nil
+	}
+
+	if err := qc.postJSON(ctx, "PATCH", "", collectionUpdate(recommended), nil); err != nil {
+		return report, fmt.Errorf("failed to update collection %s: %w", qc.config.Collection, err)
+	}
+	qc.quantization = recommended.Quantization
+	report.Applied = true
+	return report, nil
+}
+
+// CollectionSettings reads the collection's current settings and size
+func (qc *QdrantClient) CollectionSettings(ctx context.Context) (*CollectionSettings, int, error) {
+	var info struct {
+		Result struct {
+			PointsCount int `json:"points_count"`
+			Config      struct {
+				Params struct {
+					Vectors       json.RawMessage `json:"vectors"`
+					OnDiskPayload bool            `json:"on_disk_payload"`
+				} `json:"params"`
+				HNSW struct {
+					M           int `json:"m"`
+					EfConstruct int `json:"ef_construct"`
+				} `json:"hnsw_config"`
+				Optimizer struct {
+					MemmapThreshold *int `json:"memmap_threshold"`
+				} `json:"optimizer_config"`
+				Quantization map[string]json.RawMessage `json:"quantization_config"`
+			} `json:"config"`
+		} `json:"result"`
+	}
+	if err := qc.postJSON(ctx, "GET", "", nil, &info); err != nil {
+		return nil, 0, fmt.Errorf("failed to read collection %s: %w", qc.config.Collection, err)
+	}
+
+	config := info.Result.Config
+	settings := &CollectionSettings{
+		OnDiskPayload: config.Params.OnDiskPayload,
+		M:             config.HNSW.M,
+		EfConstruct:   config.HNSW.EfConstruct,
+		Quantization:  QuantizationNone,
+		SearchEf:      qc.searchEf,
+	}
+	var vectors struct {
+		OnDisk bool `json:"on_disk"`
+	}
+	if len(config.Params.Vectors) > 0 && json.Unmarshal(config.Params.Vectors, &vectors) == nil {
+		settings.OnDiskVectors = vectors.OnDisk
+	}
+	if config.Optimizer.MemmapThreshold != nil {
+		settings.MemmapThresholdKB = *config.Optimizer.MemmapThreshold
+	}
+	for kind, raw := range config.Quantization {
+		settings.Quantization = strings.ToLower(kind)
+		var product struct {
+			Compression string `json:"compression"`
+		}
+		if settings.Quantization == QuantizationProduct && json.Unmarshal(raw, &product) == nil {
+			settings.Compression = strings.ToLower(product.Compression)
+		}
+	}
+	return settings, info.Result.PointsCount, nil
+}
+
+// WarmUp runs a few searches without payloads so the index and vectors of
+// a collection Qdrant just loaded are paged in before the first real query
+func (qc *QdrantClient) WarmUp(ctx context.Context) (time.Duration, error) {
+	start := time.Now()
+	for i := 0; i < warmUpSearches; i++ {
+		vector := qc.generateFallbackEmbedding(fmt.Sprintf("warm up search %d of the code index", i))
+		request := map[string]interface{}{
+			"vector":       vector,
+			"limit":        10,
+			"with_payload": false,
+		}
+		if params := qc.searchParams(); params != nil {
+			request["params"] = params
+		}
+		if err := qc.postJSON(ctx, "POST", "points/search", request, nil); err != nil {
+			return time.Since(start), fmt.Errorf("warm-up search failed: %w", err)
+		}
+	}
+	return time.Since(start), nil
+}
+
+// applyTuning sizes searches for the collection as it is now
+func (qc *QdrantClient) applyTuning(ctx context.Context) {
+	current, points, err := qc.CollectionSettings(ctx)
+	if err != nil {
+		settings := qc.tuning.Settings(0)
+		qc.searchEf, qc.quantization = settings.SearchEf, settings.Quantization
+		return
+	}
+	qc.searchEf = qc.tuning.Settings(points).SearchEf
+	qc.quantization = current.Quantization
+}
+
+// collectionCreateSettings are the create-collection fields for settings
+func collectionCreateSettings(settings CollectionSettings, vectorSize int) map[string]interface{} {
+	request := collectionUpdate(settings)
+	request["vectors"] = map[string]interface{}{
+		"size":     vectorSize,
+		"distance": "Cosine",
+		"on_disk":  settings.OnDiskVectors,
+	}
+	request["on_disk_payload"] = settings.OnDiskPayload
+	delete(request, "params")
+	if settings.Quantization == QuantizationNone {
+		delete(request, "quantization_config")
+	}
+	return request
+}
+
+// collectionUpdate is the update-collection request applying settings
+func collectionUpdate(settings CollectionSettings) map[string]interface{} {
+	request := map[string]interface{}{
+		"vectors": map[string]interface{}{
+			"": map[string]interface{}{"on_disk": settings.OnDiskVectors},
+		},
+		"params": map[string]interface{}{"on_disk_payload": settings.OnDiskPayload},
+		"hnsw_config": map[string]interface{}{
+			"m":            settings.M,
+			"ef_construct": settings.EfConstruct,
+		},
+		"quantization_config": quantizationConfig(settings.Quantization, settings.Compression),
+	}
+	if settings.MemmapThresholdKB > 0 {
+		request["optimizers_config"] = map[string]interface{}{"memmap_threshold": settings.MemmapThresholdKB}
+	}
+	return request
+}
+
+// quantizationConfig is the Qdrant quantization setting for a method. The
+// quantized vectors always stay in RAM; the full ones are only read to
+// rescore the top candidates.
+func quantizationConfig(method, compression string) interface{} {
+	switch method {
+	case QuantizationScalar:
+		return map[string]interface{}{
+			"scalar": map[string]interface{}{
+				"type":       "int8",
+				"quantile":   0.99,
+				"always_ram": true,
+			},
+		}
+	case QuantizationProduct:
+		if compression == "" {
+			compression = defaultCompression
+		}
+		return map[string]interface{}{
+			"product": map[string]interface{}{
+				"compression": compression,
+				"always_ram":  true,
+			},
+		}
+	default:
+		return "Disabled"
+	}
+}
+
+// QuantizationLabel describes a quantization method, with the compression
+// ratio for product quantization
+func QuantizationLabel(method, compression string) string {
+	if method == QuantizationProduct && compression != "" {
+		return method + " " + compression
+	}
+	if method == "" {
+		return QuantizationNone
+	}
+	return method
+}
+
+// VectorMemory estimates the RAM the vectors of a collection take, in
+// bytes: the float32 originals and, when quantized, the compressed copies
+// kept in RAM for search
+func VectorMemory(points, dimensions int, method, compression string) (full, quantized int64) {
+	full = int64(points) * int64(dimensions) * 4
+	switch method {
+	case QuantizationScalar:
+		quantized = full / 4
+	case QuantizationProduct:
+		ratio, err := strconv.Atoi(strings.TrimPrefix(compression, "x"))
+		if err != nil || ratio <= 0 {
+			ratio = 16
+		}
+		quantized = full / int64(ratio)
+	}
+	return full, quantized
+}
+
+// diffSettings lists the collection settings that differ, skipping the
+// memmap threshold when the recommendation leaves Qdrant's default
+func diffSettings(current, recommended CollectionSettings) []TuningChange {
+	var changes []TuningChange
+	add := func(setting string, from, to interface{}) {
+		if fmt.Sprint(from) != fmt.Sprint(to) {
+			changes = append(changes, TuningChange{Setting: setting, Current: fmt.Sprint(from), Recommended: fmt.Sprint(to)})
+		}
+	}
+	add("on_disk_vectors", current.OnDiskVectors, recommended.OnDiskVectors)
+	add("on_disk_payload", current.OnDiskPayload, recommended.OnDiskPayload)
+	add("hnsw.m", current.M, recommended.M)
+	add("hnsw.ef_construct", current.EfConstruct, recommended.EfConstruct)
+	if recommended.MemmapThresholdKB > 0 {
+		add("memmap_threshold_kb", current.MemmapThresholdKB, recommended.MemmapThresholdKB)
+	}
+	add("quantization", QuantizationLabel(current.Quantization, current.Compression),
+		QuantizationLabel(recommended.Quantization, recommended.Compression))
+	return changes
+}
@@ -10,33 +10,43 @@ import (
 
 // QdrantConfig holds basic Qdrant configuration
 type QdrantConfig struct {
-	Host       string `json:"host"`
-	Port       int    `json:"port"`
-	Collection string `json:"collection"`
-	VectorSize int    `json:"vector_size"`
+	Host        string      `json:"host"`
+	Port        int         `json:"port"`
+	Collection  string      `json:"collection"`
+	VectorSize  int         `json:"vector_size"`
+	PayloadMode PayloadMode `json:"payload_mode"`
 }
 
 // CodeChunk represents a chunk of code for vector storage
 type CodeChunk struct {
-	ID        string `json:"id"`
-	Content   string `json:"content"`
-	FilePath  string `json:"file_path"`
-	Language  string `json:"language"`
-	StartLine int    `json:"start_line"`
-	EndLine   int    `json:"end_line"`
+	ID         string   `json:"id"`
+	Content    string   `json:"content"`
+	FilePath   string   `json:"file_path"`
+	Language   string   `json:"language"`
+	StartLine  int      `json:"start_line"`
+	EndLine    int      `json:"end_line"`
+	Origin     string   `json:"origin,omitempty"`
+	Module     string   `json:"module,omitempty"`
+	ContentRef string   `json:"content_ref,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
 }
 
 // SearchResult represents a vector search result
 type SearchResult struct {
 	Chunk *CodeChunk `json:"chunk"`
 	Score float32    `json:"score"`
+	Stale string     `json:"stale,omitempty"`
 }
 
 // EmbeddingConfig holds embedding service configuration
 type EmbeddingConfig struct {
-	APIKey   string `json:"api_key"`
-	Endpoint string `json:"endpoint"`
-	Model    string `json:"model"`
+	APIKey     string `json:"api_key"`
+	Endpoint   string `json:"endpoint"` // base URL; Azure resource URL for azure api types
+	Model      string `json:"model"`
+	APIType    string `json:"api_type,omitempty"`
+	Deployment string `json:"deployment,omitempty"`
+	APIVersion string `json:"api_version,omitempty"`
+	Dimensions int    `json:"dimensions,omitempty"`
 }
 
 // CostTracker tracks embedding costs
@@ -53,4 +63,4 @@ type VectorDBStats struct {
 	LastIndexed    time.Time `json:"last_indexed"`
 	SearchCount    int64     `json:"search_count"`
 	EmbeddingCosts float64   `json:"embedding_costs"`
-}
\ No newline at end of file
+}
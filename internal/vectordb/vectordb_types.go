@@ -40,6 +40,7 @@ type QdrantConfig struct {
 	BatchSize         int           `json:"batch_size"`
 	APIKey            string        `json:"api_key,omitempty"`
 	UseTLS            bool          `json:"use_tls"`
+	PayloadMode       PayloadMode   `json:"payload_mode"`
 }
 
 // =============================================================================
@@ -89,9 +90,13 @@ type EmbeddingService struct {
 
 // EmbeddingConfig holds embedding service configuration
 type EmbeddingConfig struct {
-	APIKey   string `json:"api_key"`
-	Endpoint string `json:"endpoint"`
-	Model    string `json:"model"`
+	APIKey     string `json:"api_key"`
+	Endpoint   string `json:"endpoint"` // base URL; Azure resource URL for azure api types
+	Model      string `json:"model"`
+	APIType    string `json:"api_type,omitempty"`
+	Deployment string `json:"deployment,omitempty"`
+	APIVersion string `json:"api_version,omitempty"`
+	Dimensions int    `json:"dimensions,omitempty"`
 }
 
 // EmbeddingCache provides caching for embeddings
@@ -0,0 +1,189 @@
+// Package vocabulary holds the project's domain vocabulary: terms that are
+// never dropped as stop-words, extra stop-words, and synonyms used to
+// expand queries.
+package vocabulary
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultPath is where the project vocabulary is read from
+const DefaultPath = "config/vocabulary.yaml"
+
+// Vocabulary is the project's domain vocabulary. A nil Vocabulary is empty.
+type Vocabulary struct {
+	// Protected terms are meaningful in this domain (e.g. "map", "get") and
+	// kept as keywords even where a built-in list drops them
+	Protected []string `yaml:"protected"`
+	// StopWords are dropped from keywords in addition to the built-in lists
+	StopWords []string `yaml:"stop_words"`
+	// Synonyms maps a term to terms a query using it should also match;
+	// expansion works in both directions
+	Synonyms map[string][]string `yaml:"synonyms"`
+
+	protected map[string]bool
+	stopWords map[string]bool
+	related   map[string][]string
+}
+
+// Load reads the vocabulary from path. A missing file is an empty
+// vocabulary.
+func Load(path string) (*Vocabulary, error) {
+	vocab := &Vocabulary{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return vocab.compile(), nil
+		}
+		return vocab.compile(), fmt.Errorf("failed to read vocabulary: %w", err)
+	}
+	if err := yaml.Unmarshal(data, vocab); err != nil {
+		return (&Vocabulary{}).compile(), fmt.Errorf("failed to parse vocabulary: %w", err)
+	}
+	return vocab.compile(), nil
+}
+
+// New builds a vocabulary from its parts
+func New(protected, stopWords []string, synonyms map[string][]string) *Vocabulary {
+	vocab := &Vocabulary{Protected: protected, StopWords: stopWords, Synonyms: synonyms}
+	return vocab.compile()
+}
+
+// IsProtected reports whether word is a domain term that must be kept
+func (v *Vocabulary) IsProtected(word string) bool {
+	return v != nil && v.protected[strings.ToLower(word)]
+}
+
+// IsStopWord reports whether word should be dropped from keywords: protected
+// terms never are, project stop-words always are, and otherwise builtin
+// decides
+func (v *Vocabulary) IsStopWord(word string, builtin map[string]bool) bool {
+	word = strings.ToLower(word)
+	if v.IsProtected(word) {
+		return false
+	}
+	if v != nil && v.stopWords[word] {
+		return true
+	}
+	return builtin[word]
+}
+
+// Related returns the synonyms of a term, in both directions
+func (v *Vocabulary) Related(term string) []string {
+	if v == nil {
+		return nil
+	}
+	return v.related[strings.ToLower(term)]
+}
+
+// Expand adds the synonyms of keywords after them, without duplicates
+func (v *Vocabulary) Expand(keywords []string) []string {
+	if v == nil || len(v.related) == 0 {
+		return keywords
+	}
+	seen := make(map[string]bool, len(keywords))
+	expanded := make([]string, 0, len(keywords))
+	for _, keyword := range keywords {
+		if !seen[strings.ToLower(keyword)] {
+			seen[strings.ToLower(keyword)] = true
+			expanded = append(expanded, keyword)
+		}
+	}
+	for _, keyword := range keywords {
+		for _, synonym := range v.Related(keyword) {
+			if !seen[synonym] {
+				seen[synonym] = true
+				expanded = append(expanded, synonym)
+			}
+		}
+	}
+	return expanded
+}
+
+// ExpandQuery appends the synonyms of terms found in a query, so retrieval
+// also matches code using the project's other names for them. Multi-word
+// terms match as phrases.
+func (v *Vocabulary) ExpandQuery(query string) string {
+	if v == nil || len(v.related) == 0 {
+		return query
+	}
+	lower := " " + strings.Join(strings.FieldsFunc(strings.ToLower(query), isSeparator), " ") + " "
+
+	var terms []string
+	for term := range v.related {
+		if strings.Contains(lower, " "+term+" ") {
+			terms = append(terms, term)
+		}
+	}
+	if len(terms) == 0 {
+		return query
+	}
+	sort.Strings(terms)
+
+	var extra []string
+	seen := make(map[string]bool)
+	for _, term := range terms {
+		for _, synonym := range v.related[term] {
+			if !seen[synonym] && !strings.Contains(lower, " "+synonym+" ") {
+				seen[synonym] = true
+				extra = append(extra, synonym)
+			}
+		}
+	}
+	if len(extra) == 0 {
+		return query
+	}
+	return query + " (" + strings.Join(extra, ", ") + ")"
+}
+
+// compile builds the lookup maps
+func (v *Vocabulary) compile() *Vocabulary {
+	v.protected = make(map[string]bool, len(v.Protected))
+	for _, term := range v.Protected {
+		v.protected[normalize(term)] = true
+	}
+	v.stopWords = make(map[string]bool, len(v.StopWords))
+	for _, word := range v.StopWords {
+		v.stopWords[normalize(word)] = true
+	}
+
+	v.related = make(map[string][]string)
+	link := func(from, to string) {
+		if from == "" || to == "" || from == to {
+			return
+		}
+		for _, existing := range v.related[from] {
+			if existing == to {
+				return
+			}
+		}
+		v.related[from] = append(v.related[from], to)
+	}
+	for term, synonyms := range v.Synonyms {
+		term = normalize(term)
+		for _, synonym := range synonyms {
+			synonym = normalize(synonym)
+			link(term, synonym)
+			link(synonym, term)
+		}
+	}
+	for term := range v.related {
+		sort.Strings(v.related[term])
+	}
+	return v
+}
+
+// normalize lowercases a term and collapses its whitespace
+func normalize(term string) string {
+	return strings.Join(strings.Fields(strings.ToLower(term)), " ")
+}
+
+// isSeparator splits queries into words, keeping identifier characters
+func isSeparator(r rune) bool {
+	return !(r == '_' || r == '-' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r > 127)
+}
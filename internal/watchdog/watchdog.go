@@ -0,0 +1,251 @@
+// Package watchdog heals a long-running process after an outage. Subsystems
+// that fail (Qdrant, an LLM provider) are re-probed in the background; once
+// one answers again its clients are re-initialized and it is marked healthy,
+// so a server keeps running without a restart.
+package watchdog
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Config controls the watchdog
+type Config struct {
+	Enabled      bool          `json:"enabled"`
+	Interval     time.Duration `json:"interval"`      // how often subsystems are checked
+	ProbeTimeout time.Duration `json:"probe_timeout"` // limit on one probe and recovery
+}
+
+// DefaultConfig returns watchdog defaults
+func DefaultConfig() Config {
+	return Config{Enabled: true, Interval: 30 * time.Second, ProbeTimeout: 10 * time.Second}
+}
+
+// State is the health of a subsystem
+type State string
+
+const (
+	StateHealthy  State = "healthy"
+	StateDegraded State = "degraded"
+)
+
+// Subsystem is something the watchdog keeps alive
+type Subsystem struct {
+	Name string
+	// Failing cheaply reports why the subsystem is failing, from state it
+	// already keeps (e.g. an open circuit breaker), or nil when it works
+	Failing func() error
+	// Probe checks whether a degraded subsystem answers again
+	Probe func(ctx context.Context) error
+	// Recover re-initializes the subsystem's clients after a successful
+	// probe; nil when nothing needs rebuilding
+	Recover func(ctx context.Context) error
+}
+
+// Status is what the watchdog knows about one subsystem
+type Status struct {
+	Name       string    `json:"name"`
+	State      State     `json:"state"`
+	Since      time.Time `json:"since"` // when the state last changed
+	LastError  string    `json:"last_error,omitempty"`
+	LastProbe  time.Time `json:"last_probe,omitempty"`
+	Probes     int       `json:"probes"`     // probes since it degraded
+	Recoveries int       `json:"recoveries"` // times it healed since start
+}
+
+// Transition is a change of a subsystem's state
+type Transition struct {
+	Name string
+	From State
+	To   State
+	Err  error // why it degraded, or why recovery failed
+}
+
+// Watchdog checks registered subsystems on an interval
+type Watchdog struct {
+	config       Config
+	onTransition func(Transition)
+
+	mu         sync.Mutex
+	subsystems []Subsystem
+	status     map[string]*Status
+	cancel     context.CancelFunc
+}
+
+// New creates a watchdog; onTransition, when set, is told of every change
+// of state in addition to the console notice
+func New(config Config, onTransition func(Transition)) *Watchdog {
+	defaults := DefaultConfig()
+	if config.Interval <= 0 {
+		config.Interval = defaults.Interval
+	}
+	if config.ProbeTimeout <= 0 {
+		config.ProbeTimeout = defaults.ProbeTimeout
+	}
+	return &Watchdog{
+		config:       config,
+		onTransition: onTransition,
+		status:       make(map[string]*Status),
+	}
+}
+
+// Register adds a subsystem, healthy until it reports otherwise
+func (w *Watchdog) Register(subsystem Subsystem) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subsystems = append(w.subsystems, subsystem)
+	w.status[subsystem.Name] = &Status{Name: subsystem.Name, State: StateHealthy, Since: time.Now()}
+}
+
+// MarkDegraded degrades a subsystem known to be failing, e.g. one that could
+// not be reached at startup, so the watchdog starts probing it
+func (w *Watchdog) MarkDegraded(name string, err error) {
+	w.transition(name, StateDegraded, err)
+}
+
+// Start checks subsystems every interval until ctx ends or Stop is called
+func (w *Watchdog) Start(ctx context.Context) {
+	if w == nil || !w.config.Enabled {
+		return
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	w.mu.Lock()
+	w.cancel = cancel
+	w.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(w.config.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				w.Check(ctx)
+			}
+		}
+	}()
+}
+
+// Stop ends background checks
+func (w *Watchdog) Stop() {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.cancel != nil {
+		w.cancel()
+		w.cancel = nil
+	}
+}
+
+// Check runs one round: healthy subsystems are asked whether they are
+// failing, degraded ones are probed and recovered once they answer
+func (w *Watchdog) Check(ctx context.Context) {
+	w.mu.Lock()
+	subsystems := append([]Subsystem(nil), w.subsystems...)
+	w.mu.Unlock()
+
+	for _, subsystem := range subsystems {
+		if ctx.Err() != nil {
+			return
+		}
+		if w.state(subsystem.Name) == StateHealthy {
+			if subsystem.Failing == nil {
+				continue
+			}
+			if err := subsystem.Failing(); err != nil {
+				w.transition(subsystem.Name, StateDegraded, err)
+			}
+			continue
+		}
+		w.heal(ctx, subsystem)
+	}
+}
+
+// heal probes a degraded subsystem and recovers it when it answers
+func (w *Watchdog) heal(ctx context.Context, subsystem Subsystem) {
+	if subsystem.Probe == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, w.config.ProbeTimeout)
+	defer cancel()
+
+	err := subsystem.Probe(ctx)
+	if err == nil && subsystem.Recover != nil {
+		if err = subsystem.Recover(ctx); err != nil {
+			err = fmt.Errorf("answering again but recovery failed: %w", err)
+		}
+	}
+
+	w.mu.Lock()
+	status := w.status[subsystem.Name]
+	status.LastProbe = time.Now()
+	status.Probes++
+	if err != nil {
+		status.LastError = err.Error()
+	}
+	w.mu.Unlock()
+
+	if err == nil {
+		w.transition(subsystem.Name, StateHealthy, nil)
+	}
+}
+
+func (w *Watchdog) state(name string) State {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if status, ok := w.status[name]; ok {
+		return status.State
+	}
+	return StateHealthy
+}
+
+// transition moves a subsystem to state and reports the change
+func (w *Watchdog) transition(name string, state State, err error) {
+	w.mu.Lock()
+	status, ok := w.status[name]
+	if !ok || status.State == state {
+		w.mu.Unlock()
+		return
+	}
+	change := Transition{Name: name, From: status.State, To: state, Err: err}
+	status.State = state
+	status.Since = time.Now()
+	status.Probes = 0
+	if state == StateHealthy {
+		status.Recoveries++
+		status.LastError = ""
+	} else if err != nil {
+		status.LastError = err.Error()
+	}
+	w.mu.Unlock()
+
+	if state == StateHealthy {
+		fmt.Printf("✅ %s recovered; clients re-initialized\n", name)
+	} else {
+		fmt.Printf("⚠️ %s degraded (%v); re-probing every %s\n", name, err, w.config.Interval)
+	}
+	if w.onTransition != nil {
+		w.onTransition(change)
+	}
+}
+
+// Status reports every subsystem, by name
+func (w *Watchdog) Status() []Status {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	statuses := make([]Status, 0, len(w.status))
+	for _, status := range w.status {
+		statuses = append(statuses, *status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
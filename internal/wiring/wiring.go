@@ -0,0 +1,709 @@
+// Package wiring maps the project's constructor-based dependency
+// injection: the New* constructors, what each takes and where each is
+// called. From that it finds constructors nothing calls yet - typically a
+// freshly generated component - and drafts the call in the composition root
+// that already builds their dependencies.
+package wiring
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// packageClause matches the package clause of a whole file
+var packageClause = regexp.MustCompile(`(?m)^package\s+\w+`)
+
+// versionSuffix is the major version element some import paths end with
+var versionSuffix = regexp.MustCompile(`^v[0-9]+$|\.v[0-9]+$`)
+
+// Param is one argument a constructor takes
+type Param struct {
+	Name string `json:"name"`
+	Type string `json:"type"` // as written in the constructor's file
+	key  string
+}
+
+// CallSite is a place a constructor is called
+type CallSite struct {
+	File   string `json:"file"` // relative to the project root
+	Func   string `json:"func"` // enclosing function, (*Recv).Method for methods
+	Line   int    `json:"line"`
+	Target string `json:"target,omitempty"` // what the result is assigned to
+}
+
+// Constructor is a top-level New* function and what it depends on
+type Constructor struct {
+	Name         string     `json:"name"`
+	Package      string     `json:"package"` // import path
+	PackageName  string     `json:"package_name"`
+	File         string     `json:"file"`
+	Line         int        `json:"line"`
+	Params       []Param    `json:"params"`
+	Returns      string     `json:"returns"` // first result as written
+	ReturnsError bool       `json:"returns_error"`
+	Calls        []CallSite `json:"calls"`
+	key          string     // type it produces, package path and name
+}
+
+// root is a function that calls constructors, a candidate composition root
+type root struct {
+	file         string
+	fn           string
+	pkg          string
+	receiver     string // receiver variable, for methods
+	returnsError bool
+	hasResults   bool
+	zeroResults  []string          // zero values of the results before the error
+	names        map[string]bool   // parameters and variables in scope
+	produced     map[string]string // type key -> expression holding it
+	lastLine     map[string]int    // type key -> line of the statement producing it
+	calls        int
+	end          int // line of the last constructor call
+}
+
+// Graph is the constructor dependency graph of a project
+type Graph struct {
+	Module       string
+	Constructors []*Constructor
+	byKey        map[string]*Constructor // package path + "." + constructor name
+	packageNames map[string][]string     // package name -> import paths
+	roots        []*root
+}
+
+// Analyze parses the project's non-test Go files and maps its constructors,
+// their dependencies and their call sites. It reads syntax only, so it
+// works on packages that do not build.
+func Analyze(projectRoot, module string) (*Graph, error) {
+	g := &Graph{
+		Module:       module,
+		byKey:        make(map[string]*Constructor),
+		packageNames: make(map[string][]string),
+	}
+	fset := token.NewFileSet()
+	type parsedFile struct {
+		rel     string
+		pkg     string
+		file    *ast.File
+		imports map[string]string
+	}
+	var files []parsedFile
+
+	err := filepath.WalkDir(projectRoot, func(dir string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.IsDir() {
+			return nil
+		}
+		name := entry.Name()
+		if dir != projectRoot && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+			return filepath.SkipDir
+		}
+		bp, err := build.Default.ImportDir(dir, 0)
+		if err != nil && len(bp.GoFiles) == 0 {
+			return nil
+		}
+		rel, _ := filepath.Rel(projectRoot, dir)
+		pkg := module
+		if rel != "." {
+			pkg = module + "/" + filepath.ToSlash(rel)
+		}
+		g.packageNames[bp.Name] = append(g.packageNames[bp.Name], pkg)
+		for _, name := range bp.GoFiles {
+			file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.SkipObjectResolution)
+			if err != nil && file == nil {
+				continue
+			}
+			files = append(files, parsedFile{
+				rel:  filepath.ToSlash(filepath.Join(rel, name)),
+				pkg:  pkg,
+				file: file,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", projectRoot, err)
+	}
+
+	for i := range files {
+		files[i].imports = g.imports(files[i].file)
+		for _, decl := range files[i].file.Decls {
+			if c := g.constructor(fset, decl, files[i].pkg, files[i].file.Name.Name, files[i].imports); c != nil {
+				c.File = files[i].rel
+				g.add(c)
+			}
+		}
+	}
+	for _, file := range files {
+		g.collectCalls(fset, file.file, file.rel, file.pkg, file.imports)
+	}
+
+	sort.Slice(g.Constructors, func(i, j int) bool {
+		if g.Constructors[i].Package != g.Constructors[j].Package {
+			return g.Constructors[i].Package < g.Constructors[j].Package
+		}
+		return g.Constructors[i].Name < g.Constructors[j].Name
+	})
+	return g, nil
+}
+
+// Find returns the constructors with a name, optionally package-qualified
+// (indexer.NewCodeIndexer)
+func (g *Graph) Find(name string) []*Constructor {
+	qualifier, name := "", strings.TrimSpace(name)
+	if dot := strings.LastIndex(name, "."); dot >= 0 {
+		qualifier, name = name[:dot], name[dot+1:]
+	}
+	var found []*Constructor
+	for _, c := range g.Constructors {
+		if c.Name == name && (qualifier == "" || c.PackageName == qualifier || c.Package == qualifier) {
+			found = append(found, c)
+		}
+	}
+	return found
+}
+
+// Unwired returns the constructors nothing in the project calls
+func (g *Graph) Unwired() []*Constructor {
+	var unwired []*Constructor
+	for _, c := range g.Constructors {
+		if len(c.Calls) == 0 && c.PackageName != "main" {
+			unwired = append(unwired, c)
+		}
+	}
+	return unwired
+}
+
+// Providers returns, for each parameter of c, the constructor that builds
+// its type, or nil when none does
+func (g *Graph) Providers(c *Constructor) []*Constructor {
+	providers := make([]*Constructor, len(c.Params))
+	for i, param := range c.Params {
+		providers[i] = g.producer(param.key)
+	}
+	return providers
+}
+
+// CheckGenerated parses generated Go code and suggests wiring for each
+// constructor it declares that the project does not call yet. file is where
+// the code goes, relative to the project root, when known.
+func (g *Graph) CheckGenerated(code, file string) []*models.WiringSuggestion {
+	if !packageClause.MatchString(code) {
+		code = "package generated\n" + code // a snippet
+	}
+	fset := token.NewFileSet()
+	parsed, err := parser.ParseFile(fset, "generated.go", code, parser.SkipObjectResolution)
+	if err != nil && parsed == nil {
+		return nil
+	}
+
+	pkg := ""
+	if file != "" {
+		dir := path.Dir(filepath.ToSlash(file))
+		pkg = g.Module
+		if dir != "." {
+			pkg = g.Module + "/" + dir
+		}
+	} else if paths := g.packageNames[parsed.Name.Name]; len(paths) == 1 {
+		pkg = paths[0]
+	}
+	packageName := parsed.Name.Name
+	if packageName == "generated" && pkg != "" {
+		packageName = path.Base(pkg)
+	}
+
+	imports := g.imports(parsed)
+	for name, paths := range g.packageNames {
+		if _, ok := imports[name]; !ok && len(paths) == 1 {
+			imports[name] = paths[0] // snippets often leave out their imports
+		}
+	}
+	var suggestions []*models.WiringSuggestion
+	for _, decl := range parsed.Decls {
+		c := g.constructor(fset, decl, pkg, packageName, imports)
+		if c == nil {
+			continue
+		}
+		if existing := g.byKey[c.Package+"."+c.Name]; existing != nil && len(existing.Calls) > 0 {
+			continue // already wired
+		}
+		c.File = file
+		if suggestion := g.Suggest(c); suggestion != nil {
+			suggestions = append(suggestions, suggestion)
+		}
+	}
+	return suggestions
+}
+
+// Suggest drafts the call that wires c in the composition root that
+// already builds most of its dependencies, or in the root that calls the
+// most constructors when none does. It returns nil for a project without
+// constructor calls.
+func (g *Graph) Suggest(c *Constructor) *models.WiringSuggestion {
+	best, bestScore := (*root)(nil), -1
+	for _, r := range g.roots {
+		if r.pkg == c.Package && r.fn == c.Name {
+			continue
+		}
+		score := 0
+		for _, param := range c.Params {
+			if _, ok := r.produced[param.key]; ok {
+				score++
+			}
+		}
+		if score > bestScore || (score == bestScore && r.calls > best.calls) {
+			best, bestScore = r, score
+		}
+	}
+	if best == nil {
+		return nil
+	}
+
+	suggestion := &models.WiringSuggestion{
+		Constructor: c.Name,
+		Package:     c.Package,
+		File:        best.file,
+		Func:        best.fn,
+		Line:        best.end,
+	}
+	args := make([]string, len(c.Params))
+	after := 0
+	for i, param := range c.Params {
+		if expr, ok := best.produced[param.key]; ok {
+			args[i] = expr
+			after = max(after, best.lastLine[param.key])
+			continue
+		}
+		args[i] = param.Name
+		if best.names[param.Name] {
+			continue // a variable of the root with the same name
+		}
+		suggestion.Unresolved = append(suggestion.Unresolved, strings.TrimSpace(param.Name+" "+param.Type))
+	}
+	if after > 0 {
+		suggestion.Line = after // right after its last dependency is built
+	}
+
+	call := c.Name + "(" + strings.Join(args, ", ") + ")"
+	if c.Package != best.pkg {
+		call = c.PackageName + "." + call
+	}
+	variable := lowerFirst(strings.TrimPrefix(c.Name, "New"))
+	if variable == "" {
+		variable = lowerFirst(c.PackageName)
+	}
+	target := variable
+	if best.receiver != "" && best.fieldTargets() {
+		target = best.receiver + "." + variable
+		suggestion.Field = fmt.Sprintf("%s %s", variable, qualify(c.Returns, c.PackageName, c.Package != best.pkg))
+	}
+
+	var code strings.Builder
+	if c.ReturnsError {
+		fmt.Fprintf(&code, "%s, err := %s\n", variable, call)
+		switch {
+		case best.returnsError:
+			fmt.Fprintf(&code, "if err != nil {\n\treturn %sfmt.Errorf(\"failed to create %s: %%w\", err)\n}\n",
+				strings.Join(append(best.zeroResults, ""), ", "), variable)
+		case !best.hasResults:
+			code.WriteString("if err != nil {\n\treturn\n}\n")
+		default:
+			// The root's results have no error to return it in; how to
+			// handle it is left to whoever wires the call in
+			suggestion.UnhandledError = true
+		}
+		if target != variable {
+			fmt.Fprintf(&code, "%s = %s\n", target, variable)
+		}
+	} else if target != variable {
+		fmt.Fprintf(&code, "%s = %s\n", target, call)
+	} else {
+		fmt.Fprintf(&code, "%s := %s\n", variable, call)
+	}
+	suggestion.Code = code.String()
+	return suggestion
+}
+
+// add records a constructor
+func (g *Graph) add(c *Constructor) {
+	g.Constructors = append(g.Constructors, c)
+	g.byKey[c.Package+"."+c.Name] = c
+}
+
+// producer returns the constructor building a type, preferring one named
+// after it (NewCodeIndexer for CodeIndexer) when several do
+func (g *Graph) producer(key string) *Constructor {
+	var found *Constructor
+	for _, c := range g.Constructors {
+		if c.key != key {
+			continue
+		}
+		if found == nil || c.Name == "New"+key[strings.LastIndex(key, ".")+1:] {
+			found = c
+		}
+	}
+	return found
+}
+
+// imports maps the names a file refers to its imports by to their paths
+func (g *Graph) imports(file *ast.File) map[string]string {
+	imports := make(map[string]string)
+	for _, spec := range file.Imports {
+		importPath := strings.Trim(spec.Path.Value, `"`)
+		name := ""
+		if spec.Name != nil {
+			name = spec.Name.Name
+		} else if importPath == g.Module || strings.HasPrefix(importPath, g.Module+"/") {
+			// project packages are named after their directory by convention
+			name = path.Base(importPath)
+			for packageName, paths := range g.packageNames {
+				for _, p := range paths {
+					if p == importPath {
+						name = packageName
+					}
+				}
+			}
+		} else {
+			name = path.Base(importPath)
+			if versionSuffix.MatchString(name) {
+				// gopkg.in/yaml.v3 is yaml, github.com/x/y/v2 is y
+				if strings.Contains(name, ".") {
+					name = versionSuffix.ReplaceAllString(name, "")
+				} else {
+					name = path.Base(path.Dir(importPath))
+				}
+			}
+			name = strings.TrimPrefix(strings.TrimSuffix(name, "-go"), "go-")
+		}
+		if name != "_" && name != "." {
+			imports[name] = importPath
+		}
+	}
+	return imports
+}
+
+// constructor returns the constructor a declaration defines, or nil: a
+// top-level exported New* function with at least one result
+func (g *Graph) constructor(fset *token.FileSet, decl ast.Decl, pkg, packageName string, imports map[string]string) *Constructor {
+	fn, ok := decl.(*ast.FuncDecl)
+	if !ok || fn.Recv != nil || !isConstructorName(fn.Name.Name) || fn.Type.Results == nil || len(fn.Type.Results.List) == 0 {
+		return nil
+	}
+	results := fn.Type.Results.List
+	c := &Constructor{
+		Name:        fn.Name.Name,
+		Package:     pkg,
+		PackageName: packageName,
+		Line:        fset.Position(fn.Pos()).Line,
+		Returns:     types.ExprString(results[0].Type),
+		key:         typeKey(results[0].Type, pkg, imports),
+	}
+	if last := results[len(results)-1]; len(results) > 1 || len(last.Names) > 1 {
+		c.ReturnsError = types.ExprString(last.Type) == "error"
+	}
+	for _, field := range fn.Type.Params.List {
+		names := field.Names
+		if len(names) == 0 {
+			names = []*ast.Ident{{Name: lowerFirst(typeName(field.Type))}}
+		}
+		for _, name := range names {
+			c.Params = append(c.Params, Param{
+				Name: name.Name,
+				Type: types.ExprString(field.Type),
+				key:  typeKey(field.Type, pkg, imports),
+			})
+		}
+	}
+	return c
+}
+
+// collectCalls records every constructor call of a file, with what its
+// result is assigned to, and the functions making them as candidate
+// composition roots
+func (g *Graph) collectCalls(fset *token.FileSet, file *ast.File, rel, pkg string, imports map[string]string) {
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		r := &root{
+			file:     rel,
+			fn:       funcName(fn),
+			pkg:      pkg,
+			produced: make(map[string]string),
+			lastLine: make(map[string]int),
+			names:    make(map[string]bool),
+		}
+		if fn.Recv != nil && len(fn.Recv.List) > 0 && len(fn.Recv.List[0].Names) > 0 {
+			r.receiver = fn.Recv.List[0].Names[0].Name
+		}
+		if results := fn.Type.Results; results != nil && len(results.List) > 0 {
+			r.hasResults = true
+			r.returnsError = types.ExprString(results.List[len(results.List)-1].Type) == "error"
+			for _, field := range results.List {
+				for i := 0; i < max(len(field.Names), 1); i++ {
+					r.zeroResults = append(r.zeroResults, zeroValue(field.Type))
+				}
+			}
+			r.zeroResults = r.zeroResults[:len(r.zeroResults)-1]
+		}
+		for _, field := range fn.Type.Params.List {
+			for _, name := range field.Names {
+				r.names[name.Name] = true
+			}
+		}
+
+		assigned := make(map[*ast.CallExpr]string)
+		stored := make(map[string]string) // local -> field or variable it is stored in
+		storedLine := make(map[string]int)
+		ast.Inspect(fn.Body, func(node ast.Node) bool {
+			switch node := node.(type) {
+			case *ast.AssignStmt:
+				if node.Tok == token.ASSIGN && len(node.Lhs) == 1 && len(node.Rhs) == 1 {
+					if ident, ok := node.Rhs[0].(*ast.Ident); ok {
+						stored[ident.Name] = types.ExprString(node.Lhs[0])
+						storedLine[ident.Name] = fset.Position(node.End()).Line
+					}
+				}
+				if node.Tok == token.DEFINE {
+					for _, lhs := range node.Lhs {
+						if ident, ok := lhs.(*ast.Ident); ok {
+							r.names[ident.Name] = true
+						}
+					}
+				}
+				for i, rhs := range node.Rhs {
+					if call, ok := rhs.(*ast.CallExpr); ok && i < len(node.Lhs) {
+						assigned[call] = types.ExprString(node.Lhs[i])
+					}
+				}
+			case *ast.ValueSpec:
+				for _, name := range node.Names {
+					r.names[name.Name] = true
+				}
+				for i, value := range node.Values {
+					if call, ok := value.(*ast.CallExpr); ok && i < len(node.Names) {
+						assigned[call] = node.Names[i].Name
+					}
+				}
+			}
+			return true
+		})
+
+		ast.Inspect(fn.Body, func(node ast.Node) bool {
+			call, ok := node.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			c := g.called(call, pkg, imports)
+			if c == nil {
+				return true
+			}
+			line := fset.Position(call.Pos()).Line
+			target := assigned[call]
+			c.Calls = append(c.Calls, CallSite{File: rel, Func: r.fn, Line: line, Target: target})
+			r.calls++
+			r.end = max(r.end, fset.Position(call.End()).Line)
+			if target != "" && target != "_" {
+				end := fset.Position(call.End()).Line
+				if field, ok := stored[target]; ok {
+					// x, err := NewX(); ...; app.x = x
+					target, end = field, max(end, storedLine[target])
+				}
+				r.produced[c.key] = target
+				r.lastLine[c.key] = end
+			}
+			return true
+		})
+
+		// a dependency assigned from an error-checked call is usable only
+		// after its check, so count the if statement after it too
+		ast.Inspect(fn.Body, func(node ast.Node) bool {
+			block, ok := node.(*ast.BlockStmt)
+			if !ok {
+				return true
+			}
+			for i, stmt := range block.List {
+				if i+1 >= len(block.List) {
+					break
+				}
+				if _, ok := block.List[i+1].(*ast.IfStmt); !ok {
+					continue
+				}
+				line := fset.Position(stmt.End()).Line
+				for key, last := range r.lastLine {
+					if last == line {
+						r.lastLine[key] = fset.Position(block.List[i+1].End()).Line
+					}
+				}
+			}
+			return true
+		})
+
+		if r.calls > 0 {
+			g.roots = append(g.roots, r)
+		}
+	}
+}
+
+// called returns the project constructor a call expression calls, or nil
+func (g *Graph) called(call *ast.CallExpr, pkg string, imports map[string]string) *Constructor {
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		return g.byKey[pkg+"."+fun.Name]
+	case *ast.SelectorExpr:
+		if x, ok := fun.X.(*ast.Ident); ok {
+			if importPath, ok := imports[x.Name]; ok {
+				return g.byKey[importPath+"."+fun.Sel.Name]
+			}
+		}
+	}
+	return nil
+}
+
+// fieldTargets reports whether the root assigns the constructors it calls
+// to fields of its receiver, as composition roots on an application struct do
+func (r *root) fieldTargets() bool {
+	fields := 0
+	for _, target := range r.produced {
+		if strings.HasPrefix(target, r.receiver+".") {
+			fields++
+		}
+	}
+	return fields*2 >= len(r.produced) && fields > 0
+}
+
+// typeKey identifies a named type by package path and name, ignoring
+// pointers, so a parameter can be matched to the constructor building it
+func typeKey(expr ast.Expr, pkg string, imports map[string]string) string {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		if types.Universe.Lookup(expr.Name) != nil {
+			return expr.Name
+		}
+		return pkg + "." + expr.Name
+	case *ast.SelectorExpr:
+		if x, ok := expr.X.(*ast.Ident); ok {
+			if importPath, ok := imports[x.Name]; ok {
+				return importPath + "." + expr.Sel.Name
+			}
+		}
+	}
+	return types.ExprString(expr)
+}
+
+// zeroValue returns the zero value of a result type for a return
+// statement, or a placeholder when it depends on the type's definition
+func zeroValue(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.StarExpr, *ast.MapType, *ast.ChanType, *ast.FuncType, *ast.InterfaceType:
+		return "nil"
+	case *ast.ArrayType:
+		if expr.Len == nil {
+			return "nil"
+		}
+	case *ast.Ident:
+		switch expr.Name {
+		case "string":
+			return `""`
+		case "bool":
+			return "false"
+		case "error", "any":
+			return "nil"
+		}
+		if obj := types.Universe.Lookup(expr.Name); obj != nil {
+			return "0"
+		}
+	}
+	return types.ExprString(expr) + "{}"
+}
+
+// typeName returns the name of a possibly qualified or pointer type
+func typeName(expr ast.Expr) string {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return expr.Name
+	case *ast.SelectorExpr:
+		return expr.Sel.Name
+	}
+	return "arg"
+}
+
+// funcName names a function declaration as Go stack traces do
+func funcName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return fn.Name.Name
+	}
+	recv := types.ExprString(fn.Recv.List[0].Type)
+	if strings.HasPrefix(recv, "*") {
+		recv = "(" + recv + ")"
+	}
+	return recv + "." + fn.Name.Name
+}
+
+// qualify prefixes the type names of a result type with their package,
+// for declaring a field of it in another package
+func qualify(returns, packageName string, other bool) string {
+	if !other {
+		return returns
+	}
+	star := ""
+	if strings.HasPrefix(returns, "*") {
+		star, returns = "*", returns[1:]
+	}
+	if strings.Contains(returns, ".") || !isExportedIdent(returns) {
+		return star + returns
+	}
+	return star + packageName + "." + returns
+}
+
+// isConstructorName reports whether a function name is New or New<Upper>...
+func isConstructorName(name string) bool {
+	rest, ok := strings.CutPrefix(name, "New")
+	if !ok {
+		return false
+	}
+	return rest == "" || unicode.IsUpper([]rune(rest)[0])
+}
+
+// isExportedIdent reports whether s is a single exported identifier
+func isExportedIdent(s string) bool {
+	return s != "" && token.IsIdentifier(s) && token.IsExported(s)
+}
+
+// lowerFirst lower-cases the leading initialism or letter of a name:
+// CodeIndexer -> codeIndexer, LLMManager -> llmManager
+func lowerFirst(name string) string {
+	runes := []rune(name)
+	upper := 0
+	for upper < len(runes) && unicode.IsUpper(runes[upper]) {
+		upper++
+	}
+	if upper == 0 {
+		return name
+	}
+	if upper > 1 && upper < len(runes) {
+		upper-- // the last capital starts the next word
+	}
+	for i := 0; i < upper; i++ {
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
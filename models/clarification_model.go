@@ -0,0 +1,16 @@
+package models
+
+// Clarification is a single question asked instead of guessing when a query
+// is ambiguous. The next input in the session answers it, either with the
+// number of an option or in free text.
+type Clarification struct {
+	Question      string                `json:"question"`
+	Options       []ClarificationOption `json:"options"`
+	OriginalQuery string                `json:"original_query"`
+}
+
+// ClarificationOption is a quick reply that narrows the original query
+type ClarificationOption struct {
+	Label      string `json:"label"`      // shown to the user
+	Refinement string `json:"refinement"` // appended to the original query when chosen
+}
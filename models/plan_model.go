@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// ExecutionPlan describes how a query would be processed, without running
+// any paid LLM calls. It is returned for queries prefixed with /plan.
+type ExecutionPlan struct {
+	Query                 string        `json:"query"`
+	Tier                  string        `json:"tier"`
+	TierReason            string        `json:"tier_reason,omitempty"`
+	Agents                []string      `json:"agents"` // selected agent first, then fallbacks
+	AgentConfidence       float64       `json:"agent_confidence"`
+	SkipLLM               bool          `json:"skip_llm"`
+	Provider              string        `json:"provider,omitempty"`
+	EstimatedInputTokens  int           `json:"estimated_input_tokens"`
+	EstimatedOutputTokens int           `json:"estimated_output_tokens"`
+	EstimatedCost         float64       `json:"estimated_cost"`
+	EstimatedTime         time.Duration `json:"estimated_time"`
+	ContextFiles          []string      `json:"context_files,omitempty"`
+	MCPCommands           []string      `json:"mcp_commands,omitempty"`
+	Notes                 []string      `json:"notes,omitempty"`
+}
+
+// CostEstimate is what a Tier 3 query is expected to use, shown before it
+// runs. The heuristic counts the planned context; calibration scales it by
+// how past estimates compared with actual usage.
+type CostEstimate struct {
+	Provider        string  `json:"provider,omitempty"`
+	InputTokens     int     `json:"input_tokens"`
+	OutputTokens    int     `json:"output_tokens"`
+	Cost            float64 `json:"cost"`
+	ContextFiles    int     `json:"context_files"`
+	HeuristicTokens int     `json:"heuristic_tokens"` // before calibration
+	HeuristicCost   float64 `json:"heuristic_cost"`
+	Calibration     float64 `json:"calibration"` // token factor applied; 1 until enough queries were measured
+	Samples         int     `json:"samples"`     // past queries the calibration is based on
+}
+
+// TotalTokens is the estimated input and output tokens
+func (e *CostEstimate) TotalTokens() int {
+	return e.InputTokens + e.OutputTokens
+}
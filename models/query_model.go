@@ -5,6 +5,7 @@
 package models
 
 import (
+	"fmt"
 	"time"
 )
 
@@ -23,6 +24,17 @@ const (
 	QueryTypeSystem        QueryType = "system"
 	QueryTypeRuntime       QueryType = "runtime"
 	QueryTypeMonitoring    QueryType = "monitoring"
+	QueryTypeMigration     QueryType = "migration"
+)
+
+// RetrievalMode is the granularity a query is answered at: code chunks, or
+// whole files or packages found through their summaries
+type RetrievalMode string
+
+const (
+	RetrievalChunk   RetrievalMode = "chunk"   // functions and types, the default
+	RetrievalFile    RetrievalMode = "file"    // "which file handles session persistence"
+	RetrievalPackage RetrievalMode = "package" // "which package talks to Qdrant"
 )
 
 // Query represents a user query with context and metadata
@@ -51,6 +63,71 @@ type QueryContext struct {
 	GitBranch    string            `json:"git_branch,omitempty"`
 	GitCommit    string            `json:"git_commit,omitempty"`
 	Environment  map[string]string `json:"environment,omitempty"`
+	Pinned       []PinnedContext   `json:"pinned,omitempty"`   // always included in LLM prompts
+	Mentions     []FileMention     `json:"mentions,omitempty"` // files named with @path, loaded instead of searched for
+	Snippet      *PastedSnippet    `json:"snippet,omitempty"`  // code pasted with the question, answered about without indexing
+}
+
+// PastedSnippet is a block of code the user pasted to ask about. It is
+// analyzed as given and never indexed; its code is stored with the query
+// only when Keep is set.
+type PastedSnippet struct {
+	Language string `json:"language,omitempty"`
+	Code     string `json:"code,omitempty"`
+	Lines    int    `json:"lines"`
+	Keep     bool   `json:"keep,omitempty"`
+}
+
+// Persisted returns the snippet as it may be stored: without its code
+// unless the user asked to keep it
+func (s *PastedSnippet) Persisted() *PastedSnippet {
+	if s == nil || s.Keep {
+		return s
+	}
+	persisted := *s
+	persisted.Code = ""
+	return &persisted
+}
+
+// Persisted returns the query as it may be stored, with a pasted snippet's
+// code dropped unless the user asked to keep it
+func (q *Query) Persisted() *Query {
+	if q == nil || q.Context.Snippet == nil || q.Context.Snippet.Keep {
+		return q
+	}
+	persisted := *q
+	persisted.Context.Snippet = q.Context.Snippet.Persisted()
+	return &persisted
+}
+
+// FileMention is a file the user named in a query as @path, @path:line or
+// @path:start-end. StartLine is 0 for the whole file.
+type FileMention struct {
+	Path      string `json:"path"`
+	StartLine int    `json:"start_line,omitempty"`
+	EndLine   int    `json:"end_line,omitempty"`
+}
+
+// PinnedContext is a file, line range or symbol the user pinned to their
+// session so it is included in every LLM prompt, budget permitting
+type PinnedContext struct {
+	Path      string    `json:"path"`
+	Symbol    string    `json:"symbol,omitempty"`
+	StartLine int       `json:"start_line,omitempty"` // 0 pins the whole file
+	EndLine   int       `json:"end_line,omitempty"`
+	PinnedAt  time.Time `json:"pinned_at"`
+}
+
+// Label names a pin as symbol (path:start-end), path:start-end or path
+func (p PinnedContext) Label() string {
+	location := p.Path
+	if p.StartLine > 0 {
+		location = fmt.Sprintf("%s:%d-%d", p.Path, p.StartLine, p.EndLine)
+	}
+	if p.Symbol != "" {
+		return fmt.Sprintf("%s (%s)", p.Symbol, location)
+	}
+	return location
 }
 
 // TextSelection represents selected text with position information
@@ -4,6 +4,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -36,42 +37,76 @@ const (
 	ResponseTypeRefactor      ResponseType = "refactor"
 	ResponseTypeSuggestion    ResponseType = "suggestion"
 	ResponseTypeSystem        ResponseType = "system"
+	ResponseTypePlan          ResponseType = "plan"
+	ResponseTypeClarification ResponseType = "clarification"
+	ResponseTypeMigration     ResponseType = "migration"
 )
 
 // ResponseContent holds the actual content of the response
 type ResponseContent struct {
-	Text        string          `json:"text"`
-	Code        *CodeResponse   `json:"code,omitempty"`
-	Search      *SearchResponse `json:"search,omitempty"`
-	Files       []FileChange    `json:"files,omitempty"`
-	Suggestions []Suggestion    `json:"suggestions,omitempty"`
-	References  []Reference     `json:"references,omitempty"`
-	Errors      []ErrorDetail   `json:"errors,omitempty"`
+	Text          string          `json:"text"`
+	Code          *CodeResponse   `json:"code,omitempty"`
+	Search        *SearchResponse `json:"search,omitempty"`
+	Files         []FileChange    `json:"files,omitempty"`
+	Suggestions   []Suggestion    `json:"suggestions,omitempty"`
+	References    []Reference     `json:"references,omitempty"`
+	Errors        []ErrorDetail   `json:"errors,omitempty"`
+	Plan          *ExecutionPlan  `json:"plan,omitempty"`
+	Clarification *Clarification  `json:"clarification,omitempty"`
+	Structured    json.RawMessage `json:"structured,omitempty"` // matches the caller's response schema
+	Excerpts      []CodeExcerpt   `json:"excerpts,omitempty"`   // code of the functions the text refers to
+	FollowUps     []FollowUp      `json:"follow_ups,omitempty"` // next steps, run by typing their number
+}
+
+// FollowUp is a next step suggested after an answer. Typing its number runs
+// Input as if the user had typed it.
+type FollowUp struct {
+	Label string `json:"label"` // shown to the user, e.g. "Generate tests for Save"
+	Input string `json:"input"` // the command or query it runs, e.g. "test Save"
+}
+
+// CodeExcerpt is the code of a function an explanation refers to, for
+// frontends to show folded under the text
+type CodeExcerpt struct {
+	Symbol    string   `json:"symbol"` // as the text names it, e.g. Store.Save
+	Kind      string   `json:"kind,omitempty"`
+	File      string   `json:"file"`
+	Language  string   `json:"language,omitempty"`
+	StartLine int      `json:"start_line"` // line number of Lines[0]
+	EndLine   int      `json:"end_line"`   // last line shown
+	Lines     []string `json:"lines"`
+	Truncated bool     `json:"truncated,omitempty"` // the function goes on past EndLine
+	Folded    bool     `json:"folded,omitempty"`    // long enough to start folded
 }
 
 // CodeResponse represents generated or modified code
 type CodeResponse struct {
-	Language     string          `json:"language"`
-	Code         string          `json:"code"`
-	Explanation  string          `json:"explanation,omitempty"`
-	Changes      []CodeChange    `json:"changes,omitempty"`
-	Tests        []TestCase      `json:"tests,omitempty"`
-	Dependencies []Dependency    `json:"dependencies,omitempty"`
-	Validation   *CodeValidation `json:"validation,omitempty"`
-	Provider     string          `json:"provider,omitempty"`
-	Context      string          `json:"context,omitempty"`
-	Intent       interface{}     `json:"intent,omitempty"`
+	Language     string              `json:"language"`
+	Code         string              `json:"code"`
+	Explanation  string              `json:"explanation,omitempty"`
+	Changes      []CodeChange        `json:"changes,omitempty"`
+	Tests        []TestCase          `json:"tests,omitempty"`
+	Dependencies []Dependency        `json:"dependencies,omitempty"`
+	Wiring       []*WiringSuggestion `json:"wiring,omitempty"` // constructors the code adds that nothing calls yet
+	Validation   *CodeValidation     `json:"validation,omitempty"`
+	Provider     string              `json:"provider,omitempty"`
+	Context      string              `json:"context,omitempty"`
+	Intent       interface{}         `json:"intent,omitempty"`
 }
 
 // CodeChange represents a specific change to code
 type CodeChange struct {
 	Type        ChangeType `json:"type"`
 	File        string     `json:"file"`
-	StartLine   int        `json:"start_line"`
-	EndLine     int        `json:"end_line"`
+	StartLine   int        `json:"start_line"` // 1-based, inclusive
+	EndLine     int        `json:"end_line"`   // 1-based, inclusive; StartLine-1 for pure insertions
+	StartColumn int        `json:"start_column,omitempty"`
+	EndColumn   int        `json:"end_column,omitempty"`
 	OldContent  string     `json:"old_content,omitempty"`
 	NewContent  string     `json:"new_content"`
 	Explanation string     `json:"explanation"`
+	Anchor      string     `json:"anchor,omitempty"`    // enclosing symbol the range was computed against
+	BaseHash    string     `json:"base_hash,omitempty"` // hash of the file content the range applies to
 }
 
 // ChangeType defines types of code changes
@@ -90,17 +125,62 @@ type SearchResponse struct {
 	Results   []SearchResult `json:"results"`
 	Total     int            `json:"total"`
 	TimeTaken time.Duration  `json:"time_taken"`
+	Summary   *ResultSummary `json:"summary,omitempty"` // set when there are too many results to list at once
+}
+
+// ResultSummary stands in for a result list too long to show at once.
+// Results still holds every result; frontends show the summary and page
+// into the list on request.
+type ResultSummary struct {
+	Total     int          `json:"total"`
+	Files     int          `json:"files"`
+	ByPackage []GroupCount `json:"by_package"` // most results first
+	Notable   []int        `json:"notable"`    // indexes into Results of the highest-scored results
+	PageSize  int          `json:"page_size"`
+	Page      int          `json:"page"` // 1-based page of Results to show; 0 shows the summary alone
+}
+
+// Pages returns how many pages the results fill
+func (s *ResultSummary) Pages() int {
+	if s.PageSize <= 0 {
+		return 1
+	}
+	return (s.Total + s.PageSize - 1) / s.PageSize
+}
+
+// GroupCount counts the results in one group
+type GroupCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
 }
 
 // SearchResult represents a single search result
 type SearchResult struct {
-	File        string         `json:"file"`
-	Function    string         `json:"function,omitempty"`
-	Line        int            `json:"line"`
-	Score       float64        `json:"score"`
-	Context     string         `json:"context"`
-	Explanation string         `json:"explanation,omitempty"`
-	Usage       []UsageExample `json:"usage,omitempty"`
+	File        string          `json:"file"`
+	Function    string          `json:"function,omitempty"`
+	Line        int             `json:"line"`
+	Score       float64         `json:"score"`
+	Context     string          `json:"context"`
+	Explanation string          `json:"explanation,omitempty"`
+	Usage       []UsageExample  `json:"usage,omitempty"`
+	Origin      string          `json:"origin,omitempty"` // "deps" for third-party source
+	Module      string          `json:"module,omitempty"` // module@version of dependency results
+	Stale       string          `json:"stale,omitempty"`  // "modified" or "deleted" when the file changed since indexing
+	Tags        []string        `json:"tags,omitempty"`   // semantic tags: auth, db, http, concurrency...
+	Ranking     *RankingFactors `json:"ranking,omitempty"`
+	Duplicates  []string        `json:"duplicates,omitempty"` // file:line of identical copies collapsed into this result
+}
+
+// RankingFactors break a result's score down into the signals that ranked it
+type RankingFactors struct {
+	Strategy     string  `json:"strategy,omitempty"` // search strategy that found the result
+	Similarity   float64 `json:"similarity"`         // vector similarity, or the strategy's base score
+	KeywordHits  int     `json:"keyword_hits"`
+	KeywordBoost float64 `json:"keyword_boost"`
+	ExactBonus   float64 `json:"exact_bonus"`
+	TypeBoost    float64 `json:"type_boost"` // result kind matches the kind searched for
+	Recency      float64 `json:"recency"`    // code changed often and recently in git
+	Penalty      float64 `json:"penalty"`    // negative adjustment for weak results
 }
 
 // UsageExample shows how the found code is used
@@ -182,6 +262,69 @@ type ResponseMetadata struct {
 	Sources        []string      `json:"sources"`
 	Tools          []string      `json:"tools_used"`
 	Reasoning      string        `json:"reasoning,omitempty"`
+
+	// Answer versioning: the index generation an answer was built from, and
+	// earlier answers in the session built from an older generation
+	IndexGeneration int64    `json:"index_generation,omitempty"`
+	StaleResponses  []string `json:"stale_responses,omitempty"`
+	Refreshes       string   `json:"refreshes,omitempty"`       // response ID this answer regenerates
+	ChangedSources  []string `json:"changed_sources,omitempty"` // sources of the refreshed answer changed since
+	StaleSources    []string `json:"stale_sources,omitempty"`   // cited files changed on disk since they were indexed
+
+	// Degraded says why the answer was built with reduced retrieval, e.g.
+	// the vector database was unavailable
+	Degraded string `json:"degraded,omitempty"`
+
+	// Architecture explanations: how current the package summaries behind
+	// the answer are
+	SummaryFreshness []SummaryFreshness `json:"summary_freshness,omitempty"`
+
+	// ResponseLanguage is set when the answer's prose was translated from
+	// English, e.g. "es"
+	ResponseLanguage string `json:"response_language,omitempty"`
+
+	// Agentic answers: every tool call the model made, and how the loop ended
+	ToolTrace []ToolCallTrace  `json:"tool_trace,omitempty"`
+	ToolLoop  *ToolLoopSummary `json:"tool_loop,omitempty"`
+
+	// CostEstimate is what a Tier 3 answer was expected to cost before it
+	// ran, to compare with TokenUsage and Cost
+	CostEstimate *CostEstimate `json:"cost_estimate,omitempty"`
+
+	// IndexCoverage is how much of the query's scope was indexed when the
+	// answer was built, e.g. midway through the initial index
+	IndexCoverage *IndexCoverage `json:"index_coverage,omitempty"`
+
+	// KeywordOnly marks an answer searched with the local keyword index
+	// because no vector database is configured
+	KeywordOnly bool `json:"keyword_only,omitempty"`
+
+	// Fingerprint hashes the system prompts, model aliases, ranking settings
+	// and index generation the answer was produced with; see
+	// explain-fingerprint
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// IndexCoverage compares the indexed files of a query's scope with the
+// files the indexer would index there
+type IndexCoverage struct {
+	Scope        []string `json:"scope,omitempty"` // directories and files asked about; empty for the whole project
+	Indexed      int      `json:"indexed"`
+	Discoverable int      `json:"discoverable"`
+	Ratio        float64  `json:"ratio"`
+	Partial      bool     `json:"partial"`   // below the configured warning threshold
+	Statement    string   `json:"statement"` // e.g. "Based on 412 of 1030 files (40%) indexed so far"
+}
+
+// SummaryFreshness is the age of one package summary against how much of
+// the package changed since it was built
+type SummaryFreshness struct {
+	Dir          string    `json:"dir"`
+	SummarizedAt time.Time `json:"summarized_at,omitempty"` // zero when never summarized
+	ChangedFiles int       `json:"changed_files"`
+	TotalFiles   int       `json:"total_files"`
+	Stale        bool      `json:"stale"`
+	Note         string    `json:"note"` // e.g. "summarized 3 days ago, 4 of 12 files changed since"
 }
 
 // QualityMetrics tracks response quality
@@ -202,10 +345,26 @@ type TestCase struct {
 
 // Dependency represents code dependencies
 type Dependency struct {
-	Name     string `json:"name"`
-	Version  string `json:"version,omitempty"`
-	Type     string `json:"type"` // import, module, library
-	Required bool   `json:"required"`
+	Name         string   `json:"name"`
+	Version      string   `json:"version,omitempty"`
+	Type         string   `json:"type"` // import, module, library
+	Required     bool     `json:"required"`
+	Category     string   `json:"category,omitempty"`     // e.g. http router, logging
+	Alternatives []string `json:"alternatives,omitempty"` // modules the project already uses for the same job
+}
+
+// WiringSuggestion is the call that wires a new constructor into the
+// composition root that already builds its dependencies
+type WiringSuggestion struct {
+	Constructor    string   `json:"constructor"`
+	Package        string   `json:"package"`
+	File           string   `json:"file"`                      // composition root, relative to the project root
+	Func           string   `json:"func"`                      // function of the composition root
+	Line           int      `json:"line"`                      // insert after this line
+	Code           string   `json:"code"`                      // the initialization to insert
+	Field          string   `json:"field,omitempty"`           // field to add to the root's receiver to hold the component
+	Unresolved     []string `json:"unresolved,omitempty"`      // parameters no constructor in the root provides
+	UnhandledError bool     `json:"unhandled_error,omitempty"` // the root cannot return the constructor's error; Code leaves err unchecked
 }
 
 // CodeValidation represents code validation results
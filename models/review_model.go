@@ -0,0 +1,64 @@
+package models
+
+// Stages of a reviewed generation
+const (
+	ReviewStageOutline = "outline" // waiting for the outline to be approved
+	ReviewStageFiles   = "files"   // generating and reviewing one file at a time
+	ReviewStageDone    = "done"
+)
+
+// GenerationReview is the state of a large generation reviewed step by step:
+// an outline the user approves or edits, then each file drafted and
+// accepted, revised or skipped. It is the checkpoint of its review task.
+type GenerationReview struct {
+	TaskID   string         `json:"task_id"`
+	Request  string         `json:"request"`
+	Stage    string         `json:"stage"`
+	Outline  *ReviewOutline `json:"outline,omitempty"`
+	Next     int            `json:"next"`            // outline file being reviewed
+	Draft    *ReviewDraft   `json:"draft,omitempty"` // draft of the file being reviewed
+	Accepted []string       `json:"accepted,omitempty"`
+	Skipped  []string       `json:"skipped,omitempty"`
+	Waiting  bool           `json:"waiting"` // a decision is expected now
+	Tokens   int            `json:"tokens"`
+	Cost     float64        `json:"cost"`
+}
+
+// ReviewOutline is the plan of a reviewed generation
+type ReviewOutline struct {
+	Summary string              `json:"summary"`
+	Files   []ReviewOutlineFile `json:"files"`
+}
+
+// ReviewOutlineFile is one file the outline plans
+type ReviewOutlineFile struct {
+	Path           string   `json:"path"` // relative to the project root
+	Responsibility string   `json:"responsibility"`
+	Functions      []string `json:"functions,omitempty"`
+}
+
+// ReviewDraft is a generated file awaiting review
+type ReviewDraft struct {
+	Path     string   `json:"path"`
+	Content  string   `json:"content"`
+	Exists   bool     `json:"exists"`             // accepting replaces an existing file
+	Feedback []string `json:"feedback,omitempty"` // revision requests so far
+
+	// Policies are the team policies the draft breaks
+	Policies []ReviewPolicyViolation `json:"policies,omitempty"`
+}
+
+// ReviewPolicyViolation is a team policy a draft breaks, at a line
+type ReviewPolicyViolation struct {
+	Policy string `json:"policy"`
+	Line   int    `json:"line"`
+	Reason string `json:"reason"`
+}
+
+// CurrentFile returns the outline file under review, or nil
+func (r *GenerationReview) CurrentFile() *ReviewOutlineFile {
+	if r.Outline == nil || r.Stage != ReviewStageFiles || r.Next >= len(r.Outline.Files) {
+		return nil
+	}
+	return &r.Outline.Files[r.Next]
+}
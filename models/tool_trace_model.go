@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// ToolCallTrace records one tool call made by the model during an agentic
+// answer, in the order the calls were made
+type ToolCallTrace struct {
+	Step      int           `json:"step"` // model turn that requested the call, from 1
+	Tool      string        `json:"tool"`
+	Arguments string        `json:"arguments"`
+	Result    string        `json:"result,omitempty"` // truncated to what the model saw
+	Error     string        `json:"error,omitempty"`
+	Duration  time.Duration `json:"duration"`
+}
+
+// ToolLoopSummary describes how an agentic answer ended
+type ToolLoopSummary struct {
+	Steps      int     `json:"steps"`
+	ToolCalls  int     `json:"tool_calls"`
+	Cost       float64 `json:"cost"`
+	StopReason string  `json:"stop_reason"` // "answered", "step_budget" or "cost_cap"
+}
@@ -0,0 +1,218 @@
+// Package useq exposes the useQ query engine as an embeddable library.
+// It wraps the ManagerAgent router and the code indexer behind a small,
+// stable API so other Go programs can index a project and ask questions
+// about it without depending on anything under internal/.
+package useq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/agents"
+	"github.com/yourusername/useq-ai-assistant/internal/indexer"
+	"github.com/yourusername/useq-ai-assistant/internal/language"
+	"github.com/yourusername/useq-ai-assistant/internal/llm"
+	"github.com/yourusername/useq-ai-assistant/internal/vectordb"
+	"github.com/yourusername/useq-ai-assistant/models"
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// Client is the public entry point to the useQ engine
+type Client struct {
+	opts       *options
+	manager    *agents.ManagerAgent
+	indexer    *indexer.CodeIndexer
+	storage    *storage.SQLiteDB
+	vectorDB   *vectordb.QdrantClient
+	llmManager *llm.Manager
+	sessionID  string
+	ownsStore  bool
+	mu         sync.Mutex
+}
+
+// SearchHit is a single semantic search match
+type SearchHit struct {
+	File      string  `json:"file"`
+	Language  string  `json:"language"`
+	StartLine int     `json:"start_line"`
+	EndLine   int     `json:"end_line"`
+	Content   string  `json:"content"`
+	Score     float64 `json:"score"`
+}
+
+// New creates a Client. Components that are not supplied through options
+// are built from the same defaults the CLI uses.
+func New(opts ...Option) (*Client, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	c := &Client{
+		opts:      o,
+		storage:   o.storage,
+		sessionID: fmt.Sprintf("sdk_%d", time.Now().UnixNano()),
+	}
+
+	if c.storage == nil {
+		db, err := storage.NewSQLiteDB(o.databasePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open storage: %w", err)
+		}
+		c.storage = db
+		c.ownsStore = true
+	}
+
+	if o.qdrant != nil {
+		client, err := vectordb.NewQdrantClient(&vectordb.QdrantConfig{
+			Host:       o.qdrant.Host,
+			Port:       o.qdrant.Port,
+			Collection: o.qdrant.Collection,
+			VectorSize: o.qdrant.VectorSize,
+		})
+		if err != nil {
+			c.Close()
+			return nil, fmt.Errorf("failed to connect vector database: %w", err)
+		}
+		c.vectorDB = client
+	}
+
+	if o.hasProvider {
+		manager, err := llm.NewManager(o.providers)
+		if err != nil {
+			c.Close()
+			return nil, fmt.Errorf("failed to create LLM manager: %w", err)
+		}
+		c.llmManager = manager
+	}
+
+	idx, err := indexer.NewCodeIndexer(o.projectRoot, o.extensions, o.excludedDirs, c.vectorDB, c.storage)
+	if err != nil {
+		c.Close()
+		return nil, fmt.Errorf("failed to create indexer: %w", err)
+	}
+	c.indexer = idx
+
+	deps := &agents.AgentDependencies{
+		LLMManager: c.llmManager,
+		VectorDB:   c.vectorDB,
+		Storage:    c.storage,
+	}
+	if o.logger != nil {
+		deps.Logger = o.logger
+	}
+	c.manager = agents.NewManagerAgent(deps)
+
+	return c, nil
+}
+
+// Query routes a natural language query through the ManagerAgent
+func (c *Client) Query(ctx context.Context, input string) (*models.Response, error) {
+	if input == "" {
+		return nil, fmt.Errorf("query input is empty")
+	}
+
+	response, err := c.manager.RouteQuery(ctx, c.newQuery(input))
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	return response, nil
+}
+
+// Plan works out how a query would be processed - tier, agents and cost
+// estimates - without making any LLM calls
+func (c *Client) Plan(ctx context.Context, input string) (*models.ExecutionPlan, error) {
+	if input == "" {
+		return nil, fmt.Errorf("query input is empty")
+	}
+
+	response, err := c.manager.PlanQuery(ctx, c.newQuery(input))
+	if err != nil {
+		return nil, fmt.Errorf("planning failed: %w", err)
+	}
+	return response.Content.Plan, nil
+}
+
+// newQuery builds the query for a natural language input
+func (c *Client) newQuery(input string) *models.Query {
+	detected := language.Detect(input, nil, "go")
+	return &models.Query{
+		ID:          fmt.Sprintf("query_%d", time.Now().UnixNano()),
+		UserInput:   input,
+		Language:    detected.Language,
+		Metadata:    map[string]string{"language_source": detected.Source},
+		Timestamp:   time.Now(),
+		SessionID:   c.sessionID,
+		ProjectRoot: c.opts.projectRoot,
+		Context: models.QueryContext{
+			Environment: map[string]string{
+				"os":   os.Getenv("GOOS"),
+				"arch": os.Getenv("GOARCH"),
+			},
+		},
+	}
+}
+
+// Index indexes the configured project root, skipping unchanged files
+func (c *Client) Index(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.indexer.StartIndexing(ctx); err != nil {
+		return fmt.Errorf("indexing failed: %w", err)
+	}
+	return nil
+}
+
+// Search runs a semantic search against the vector database
+func (c *Client) Search(ctx context.Context, query string, limit int) ([]SearchHit, error) {
+	if c.vectorDB == nil {
+		return nil, fmt.Errorf("vector database not configured")
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+
+	results, err := c.vectorDB.Search(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("search failed: %w", err)
+	}
+
+	hits := make([]SearchHit, 0, len(results))
+	for _, result := range results {
+		if result == nil || result.Chunk == nil {
+			continue
+		}
+		hits = append(hits, SearchHit{
+			File:      result.Chunk.FilePath,
+			Language:  result.Chunk.Language,
+			StartLine: result.Chunk.StartLine,
+			EndLine:   result.Chunk.EndLine,
+			Content:   result.Chunk.Content,
+			Score:     float64(result.Score),
+		})
+	}
+	return hits, nil
+}
+
+// Close releases resources owned by the client: the vector database
+// connection it made and the store it opened. Stores passed in through
+// options are left open for the caller to close.
+func (c *Client) Close() error {
+	var errs []error
+	if c.vectorDB != nil {
+		if err := c.vectorDB.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close vector database: %w", err))
+		}
+	}
+	if c.ownsStore && c.storage != nil {
+		if err := c.storage.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close storage: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
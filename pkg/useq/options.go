@@ -0,0 +1,159 @@
+package useq
+
+import (
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/internal/llm"
+	"github.com/yourusername/useq-ai-assistant/storage"
+)
+
+// Option configures a Client
+type Option func(*options)
+
+// Logger receives log output from the engine
+type Logger interface {
+	Debug(msg string, fields ...interface{})
+	Info(msg string, fields ...interface{})
+	Warn(msg string, fields ...interface{})
+	Error(msg string, fields ...interface{})
+	Fatal(msg string, fields ...interface{})
+}
+
+// ProviderConfig configures a single LLM provider
+type ProviderConfig struct {
+	APIKey      string        `json:"api_key"`
+	Model       string        `json:"model"`
+	MaxTokens   int           `json:"max_tokens"`
+	Temperature float64       `json:"temperature"`
+	Timeout     time.Duration `json:"timeout"`
+}
+
+// QdrantConfig configures the Qdrant vector database connection
+type QdrantConfig struct {
+	Host       string `json:"host"`
+	Port       int    `json:"port"`
+	Collection string `json:"collection"`
+	VectorSize int    `json:"vector_size"`
+}
+
+// options holds everything a Client can be configured with
+type options struct {
+	projectRoot  string
+	databasePath string
+	extensions   []string
+	excludedDirs []string
+	qdrant       *QdrantConfig
+	providers    llm.AIProvidersConfig
+	hasProvider  bool
+	storage      *storage.SQLiteDB
+	logger       Logger
+}
+
+// defaultOptions mirrors the defaults used by the CLI
+func defaultOptions() *options {
+	return &options{
+		projectRoot:  ".",
+		databasePath: "storage/useq.db",
+		extensions:   []string{".go", ".mod", ".sum"},
+		excludedDirs: []string{"vendor", "node_modules", ".git", "bin", "build", "dist"},
+		providers: llm.AIProvidersConfig{
+			Primary: string(llm.ProviderTypeOpenAI),
+		},
+	}
+}
+
+// WithProjectRoot sets the root directory that is indexed and searched
+func WithProjectRoot(root string) Option {
+	return func(o *options) {
+		o.projectRoot = root
+	}
+}
+
+// WithDatabasePath sets the SQLite database path used when no store is supplied
+func WithDatabasePath(path string) Option {
+	return func(o *options) {
+		o.databasePath = path
+	}
+}
+
+// WithExtensions sets the file extensions picked up by the indexer
+func WithExtensions(extensions ...string) Option {
+	return func(o *options) {
+		o.extensions = extensions
+	}
+}
+
+// WithExcludedDirs sets the directories skipped by the indexer
+func WithExcludedDirs(dirs ...string) Option {
+	return func(o *options) {
+		o.excludedDirs = dirs
+	}
+}
+
+// WithStorage uses an already opened SQLite store. The caller keeps
+// ownership and is responsible for closing it.
+func WithStorage(db *storage.SQLiteDB) Option {
+	return func(o *options) {
+		o.storage = db
+	}
+}
+
+// WithQdrant enables semantic search against a Qdrant instance
+func WithQdrant(config QdrantConfig) Option {
+	return func(o *options) {
+		o.qdrant = &config
+	}
+}
+
+// WithOpenAI configures OpenAI as an LLM provider
+func WithOpenAI(config ProviderConfig) Option {
+	return func(o *options) {
+		o.providers.OpenAI = toLLMProviderConfig(config)
+		o.hasProvider = true
+	}
+}
+
+// WithGemini configures Gemini as an LLM provider
+func WithGemini(config ProviderConfig) Option {
+	return func(o *options) {
+		o.providers.Gemini = toLLMProviderConfig(config)
+		o.hasProvider = true
+	}
+}
+
+// WithPrimaryProvider selects the primary provider and the fallback order
+func WithPrimaryProvider(primary string, fallbacks ...string) Option {
+	return func(o *options) {
+		o.providers.Primary = primary
+		o.providers.FallbackOrder = fallbacks
+	}
+}
+
+// WithCassette puts a cassette of recorded LLM calls in front of the
+// providers. mode "record" calls the configured providers and saves every
+// exchange to path; "replay" answers from path without any provider or API
+// key, so runs are repeatable.
+func WithCassette(path, mode string) Option {
+	return func(o *options) {
+		o.providers.Cassette = &llm.CassetteConfig{Path: path, Mode: llm.CassetteMode(mode)}
+		o.hasProvider = true
+	}
+}
+
+// WithLogger routes engine logging to the given logger
+func WithLogger(logger Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// toLLMProviderConfig converts the public provider config to the internal one
+func toLLMProviderConfig(config ProviderConfig) llm.ProviderConfig {
+	return llm.ProviderConfig{
+		APIKey:      config.APIKey,
+		Model:       config.Model,
+		MaxTokens:   config.MaxTokens,
+		Temperature: config.Temperature,
+		Timeout:     config.Timeout,
+	}
+}
@@ -0,0 +1,139 @@
+package useqtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// Shape is the structure of an answer that should only change on purpose:
+// which tier and agent handled it, what kind of answer it is, what it cites
+// and how many results it found. Text, scores, IDs and timings are left out
+// since they vary from run to run.
+type Shape struct {
+	Tier          string              `json:"tier"`
+	Type          models.ResponseType `json:"type,omitempty"`
+	Agent         string              `json:"agent,omitempty"`
+	Tools         []string            `json:"tools,omitempty"`   // sorted
+	Sources       []string            `json:"sources,omitempty"` // sorted, relative to the fixture project
+	SearchResults int                 `json:"search_results"`
+	ResultFiles   []string            `json:"result_files,omitempty"` // in rank order
+	References    int                 `json:"references"`
+	Suggestions   int                 `json:"suggestions"`
+	HasCode       bool                `json:"has_code"`
+	Changes       int                 `json:"changes"`
+	Dependencies  []string            `json:"dependencies,omitempty"`
+	Clarification bool                `json:"clarification,omitempty"`
+	Error         string              `json:"error,omitempty"` // the query failed instead of answering
+}
+
+// ShapeOf takes the shape of an answer. Paths are made relative to root so
+// golden files don't depend on where the suite is checked out.
+func ShapeOf(root string, plan *models.ExecutionPlan, response *models.Response, err error) Shape {
+	var shape Shape
+	if plan != nil {
+		shape.Tier = plan.Tier
+	}
+	if err != nil {
+		shape.Error = err.Error()
+		return shape
+	}
+	if response == nil {
+		return shape
+	}
+
+	shape.Type = response.Type
+	shape.Agent = response.AgentUsed
+	shape.Tools = sortedCopy(response.Metadata.Tools)
+	for _, source := range response.Metadata.Sources {
+		shape.Sources = append(shape.Sources, relativeTo(root, source))
+	}
+	sort.Strings(shape.Sources)
+
+	content := response.Content
+	if content.Search != nil {
+		shape.SearchResults = len(content.Search.Results)
+		for _, result := range content.Search.Results {
+			shape.ResultFiles = append(shape.ResultFiles, relativeTo(root, result.File))
+		}
+	}
+	shape.References = len(content.References)
+	shape.Suggestions = len(content.Suggestions)
+	if content.Code != nil {
+		shape.HasCode = strings.TrimSpace(content.Code.Code) != ""
+		shape.Changes = len(content.Code.Changes)
+		for _, dependency := range content.Code.Dependencies {
+			shape.Dependencies = append(shape.Dependencies, dependency.Name)
+		}
+	}
+	shape.Clarification = content.Clarification != nil
+	return shape
+}
+
+// Diff lists the fields that differ between a golden shape and a new one,
+// as "field: want ..., got ..."
+func Diff(want, got Shape) []string {
+	wantFields, gotFields := fieldsOf(want), fieldsOf(got)
+	names := make([]string, 0, len(wantFields)+len(gotFields))
+	for name := range wantFields {
+		names = append(names, name)
+	}
+	for name := range gotFields {
+		if _, ok := wantFields[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var diffs []string
+	for _, name := range names {
+		wantValue, ok := wantFields[name]
+		if !ok {
+			wantValue = "<none>"
+		}
+		gotValue, ok := gotFields[name]
+		if !ok {
+			gotValue = "<none>"
+		}
+		if wantValue != gotValue {
+			diffs = append(diffs, fmt.Sprintf("%s: want %s, got %s", name, wantValue, gotValue))
+		}
+	}
+	return diffs
+}
+
+// fieldsOf returns the JSON encoding of each field of a shape, keyed by
+// its JSON name
+func fieldsOf(shape Shape) map[string]string {
+	data, _ := json.Marshal(shape)
+	var raw map[string]json.RawMessage
+	_ = json.Unmarshal(data, &raw)
+	fields := make(map[string]string, len(raw))
+	for name, value := range raw {
+		fields[name] = string(value)
+	}
+	return fields
+}
+
+func relativeTo(root, path string) string {
+	if root == "" || !filepath.IsAbs(path) {
+		return filepath.ToSlash(path)
+	}
+	if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return filepath.ToSlash(path)
+}
+
+func sortedCopy(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+	return sorted
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"log"
+	"net/http"
+
+	"example.com/orders/api"
+	"example.com/orders/store"
+)
+
+func main() {
+	orders := store.NewMemoryStore()
+	handler := api.NewHandler(orders)
+
+	log.Println("listening on :8080")
+	if err := http.ListenAndServe(":8080", handler.Routes()); err != nil {
+		log.Fatal(err)
+	}
+}
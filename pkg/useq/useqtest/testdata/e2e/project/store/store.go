@@ -0,0 +1,73 @@
+// Package store keeps orders in memory
+package store
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned for an order ID that was never stored
+var ErrNotFound = errors.New("order not found")
+
+// Order is one customer order
+type Order struct {
+	ID        string    `json:"id"`
+	Customer  string    `json:"customer"`
+	Total     float64   `json:"total"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Store persists orders
+type Store interface {
+	Get(id string) (*Order, error)
+	Put(order *Order) error
+	List() []*Order
+}
+
+// MemoryStore is a Store backed by a map
+type MemoryStore struct {
+	mu     sync.RWMutex
+	orders map[string]*Order
+}
+
+// NewMemoryStore returns an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{orders: make(map[string]*Order)}
+}
+
+// Get returns the order with the given ID
+func (s *MemoryStore) Get(id string) (*Order, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	order, ok := s.orders[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return order, nil
+}
+
+// Put stores an order, replacing any order with the same ID
+func (s *MemoryStore) Put(order *Order) error {
+	if order.ID == "" {
+		return errors.New("order has no ID")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if order.CreatedAt.IsZero() {
+		order.CreatedAt = time.Now()
+	}
+	s.orders[order.ID] = order
+	return nil
+}
+
+// List returns every stored order
+func (s *MemoryStore) List() []*Order {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	orders := make([]*Order, 0, len(s.orders))
+	for _, order := range s.orders {
+		orders = append(orders, order)
+	}
+	return orders
+}
@@ -0,0 +1,271 @@
+// Package useqtest runs golden snapshot tests of useQ answers, so changes to
+// routing and ranking can't silently change what users get back.
+//
+// A suite is a directory holding:
+//
+//	project/        a small fixture project that is indexed before the queries
+//	cases.json      the queries: [{"name": "find-store", "query": "find the Store type"}]
+//	cassette.json   recorded LLM responses, replayed so no API key is needed
+//	golden/         the expected shape of each answer, one <name>.json per case
+//
+// Call RunT from a test, or run "./useq-ai e2e <suite>" from the command line.
+// Set USEQ_GOLDEN_UPDATE=1 to rewrite the golden files after an intended
+// change, and USEQ_LLM_CASSETTE_MODE=record (with OPENAI_API_KEY or
+// GEMINI_API_KEY) to re-record the cassette when prompts change.
+package useqtest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/useq-ai-assistant/pkg/useq"
+)
+
+// Suite file layout
+const (
+	ProjectDir   = "project"
+	CasesFile    = "cases.json"
+	CassetteFile = "cassette.json"
+	GoldenDir    = "golden"
+)
+
+// ErrNoCassette is returned when a suite has no recorded LLM responses to
+// replay; RunT skips such suites
+var ErrNoCassette = errors.New("no cassette recorded")
+
+// Case is one query of a suite
+type Case struct {
+	Name  string `json:"name"` // names the golden file
+	Query string `json:"query"`
+}
+
+// Options controls a suite run
+type Options struct {
+	Update bool // write the golden files instead of comparing against them
+	Record bool // call the providers and re-record the cassette
+}
+
+// OptionsFromEnv reads USEQ_GOLDEN_UPDATE and USEQ_LLM_CASSETTE_MODE
+func OptionsFromEnv() Options {
+	update := strings.ToLower(os.Getenv("USEQ_GOLDEN_UPDATE"))
+	return Options{
+		Update: update == "1" || update == "true",
+		Record: strings.EqualFold(os.Getenv("USEQ_LLM_CASSETTE_MODE"), "record"),
+	}
+}
+
+// Result is the outcome of one case
+type Result struct {
+	Case    Case     `json:"case"`
+	Shape   Shape    `json:"shape"`
+	Diffs   []string `json:"diffs,omitempty"`
+	Updated bool     `json:"updated,omitempty"` // the golden file was written
+	Err     error    `json:"-"`                 // the case could not be checked
+}
+
+// Passed reports whether the answer matched its golden file
+func (r Result) Passed() bool {
+	return r.Err == nil && len(r.Diffs) == 0
+}
+
+// Report is the outcome of a suite run
+type Report struct {
+	Suite   string   `json:"suite"`
+	Results []Result `json:"results"`
+}
+
+// Failed returns the number of cases that did not match
+func (r *Report) Failed() int {
+	failed := 0
+	for _, result := range r.Results {
+		if !result.Passed() {
+			failed++
+		}
+	}
+	return failed
+}
+
+var caseNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// LoadCases reads the queries of a suite
+func LoadCases(dir string) ([]Case, error) {
+	data, err := os.ReadFile(filepath.Join(dir, CasesFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cases: %w", err)
+	}
+	var cases []Case
+	if err := json.Unmarshal(data, &cases); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", CasesFile, err)
+	}
+	seen := make(map[string]bool)
+	for _, c := range cases {
+		if !caseNamePattern.MatchString(c.Name) || seen[c.Name] {
+			return nil, fmt.Errorf("case name %q must be unique and use only letters, digits, - and _", c.Name)
+		}
+		if strings.TrimSpace(c.Query) == "" {
+			return nil, fmt.Errorf("case %s has no query", c.Name)
+		}
+		seen[c.Name] = true
+	}
+	return cases, nil
+}
+
+// Run indexes the suite's fixture project into a scratch database, asks
+// every query with the cassette in front of the providers and compares the
+// shape of each answer with its golden file
+func Run(ctx context.Context, dir string, options Options) (*Report, error) {
+	cases, err := LoadCases(dir)
+	if err != nil {
+		return nil, err
+	}
+	project, err := filepath.Abs(filepath.Join(dir, ProjectDir))
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(project); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("suite %s has no %s directory", dir, ProjectDir)
+	}
+
+	cassette := filepath.Join(dir, CassetteFile)
+	mode := "replay"
+	if options.Record {
+		mode = "record"
+	} else if _, err := os.Stat(cassette); errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w in %s; record one with USEQ_LLM_CASSETTE_MODE=record", ErrNoCassette, dir)
+	}
+
+	scratch, err := os.MkdirTemp("", "useqtest-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(scratch)
+
+	opts := []useq.Option{
+		useq.WithProjectRoot(project),
+		useq.WithDatabasePath(filepath.Join(scratch, "useq.db")),
+		useq.WithCassette(cassette, mode),
+	}
+	if options.Record {
+		opts = append(opts, providersFromEnv()...)
+	}
+	client, err := useq.New(opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	if err := client.Index(ctx); err != nil {
+		return nil, fmt.Errorf("failed to index %s: %w", project, err)
+	}
+
+	report := &Report{Suite: dir}
+	for _, c := range cases {
+		report.Results = append(report.Results, runCase(ctx, client, project, dir, c, options))
+	}
+	return report, nil
+}
+
+// runCase asks one query and checks or writes its golden file
+func runCase(ctx context.Context, client *useq.Client, project, dir string, c Case, options Options) Result {
+	result := Result{Case: c}
+	plan, err := client.Plan(ctx, c.Query)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	response, err := client.Query(ctx, c.Query)
+	result.Shape = ShapeOf(project, plan, response, err)
+
+	golden := filepath.Join(dir, GoldenDir, c.Name+".json")
+	if options.Update {
+		result.Err = writeGolden(golden, result.Shape)
+		result.Updated = result.Err == nil
+		return result
+	}
+
+	want, err := readGolden(golden)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.Diffs = Diff(want, result.Shape)
+	return result
+}
+
+func readGolden(path string) (Shape, error) {
+	var shape Shape
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return shape, fmt.Errorf("no golden file %s; write it with USEQ_GOLDEN_UPDATE=1", path)
+	}
+	if err != nil {
+		return shape, err
+	}
+	if err := json.Unmarshal(data, &shape); err != nil {
+		return shape, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return shape, nil
+}
+
+func writeGolden(path string, shape Shape) error {
+	data, err := json.MarshalIndent(shape, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// providersFromEnv configures the providers that have an API key, for
+// recording
+func providersFromEnv() []useq.Option {
+	var opts []useq.Option
+	if key := os.Getenv("OPENAI_API_KEY"); key != "" {
+		opts = append(opts, useq.WithOpenAI(useq.ProviderConfig{APIKey: key}))
+	}
+	if key := os.Getenv("GEMINI_API_KEY"); key != "" {
+		opts = append(opts, useq.WithGemini(useq.ProviderConfig{APIKey: key}))
+		if os.Getenv("OPENAI_API_KEY") == "" {
+			opts = append(opts, useq.WithPrimaryProvider("gemini"))
+		}
+	}
+	return opts
+}
+
+// RunT runs a suite as a Go test, failing for every case whose answer
+// changed shape. Suites without a cassette are skipped.
+//
+//	func TestAnswers(t *testing.T) {
+//		useqtest.RunT(t, "testdata/e2e")
+//	}
+func RunT(t testing.TB, dir string) {
+	t.Helper()
+	report, err := Run(context.Background(), dir, OptionsFromEnv())
+	if errors.Is(err, ErrNoCassette) {
+		t.Skip(err.Error())
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, result := range report.Results {
+		if result.Err != nil {
+			t.Errorf("%s: %v", result.Case.Name, result.Err)
+			continue
+		}
+		if result.Updated {
+			t.Logf("%s: golden file updated", result.Case.Name)
+		}
+		for _, diff := range result.Diffs {
+			t.Errorf("%s (%q): %s", result.Case.Name, result.Case.Query, diff)
+		}
+	}
+}
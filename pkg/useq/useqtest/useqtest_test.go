@@ -0,0 +1,12 @@
+package useqtest
+
+import "testing"
+
+// TestE2E replays the suite shipped with the repository. Re-record it with
+// USEQ_LLM_CASSETTE_MODE=record USEQ_GOLDEN_UPDATE=1 when prompts change.
+func TestE2E(t *testing.T) {
+	if testing.Short() {
+		t.Skip("indexes the fixture project")
+	}
+	RunT(t, "testdata/e2e")
+}
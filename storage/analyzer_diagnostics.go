@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// Analyzer diagnostic severities, ordered from most to least serious
+const (
+	AnalyzerSeverityError   = "error"
+	AnalyzerSeverityWarning = "warning"
+	AnalyzerSeverityInfo    = "info"
+	AnalyzerSeverityHint    = "hint"
+)
+
+// AnalyzerDiagnostic is a finding reported by an external analyzer such as
+// gopls or golangci-lint
+type AnalyzerDiagnostic struct {
+	ID         int64     `json:"id"`
+	Source     string    `json:"source"` // the tool whose output it came from
+	File       string    `json:"file"`   // relative to the project root
+	Line       int       `json:"line"`
+	Column     int       `json:"column,omitempty"`
+	EndLine    int       `json:"end_line,omitempty"`
+	Severity   string    `json:"severity"`
+	Code       string    `json:"code,omitempty"` // the analyzer or linter and its rule, e.g. errcheck or SA1019
+	Message    string    `json:"message"`
+	Function   string    `json:"function,omitempty"` // indexed function enclosing the line
+	IngestedAt time.Time `json:"ingested_at"`
+}
+
+// AnalyzerDiagnosticFilter narrows ListAnalyzerDiagnostics; zero fields
+// match everything
+type AnalyzerDiagnosticFilter struct {
+	File   string // exact project-relative path
+	Source string
+	Limit  int
+}
+
+// ReplaceAnalyzerDiagnostics replaces what source reported before with
+// diagnostics, since each run of a tool reports the whole project again
+func (db *SQLiteDB) ReplaceAnalyzerDiagnostics(source string, diagnostics []*AnalyzerDiagnostic) error {
+	tx, err := db.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin diagnostics update: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM analyzer_diagnostics WHERE source = ?`, source); err != nil {
+		return fmt.Errorf("failed to clear diagnostics of %s: %w", source, err)
+	}
+	now := time.Now()
+	for _, diagnostic := range diagnostics {
+		if _, err := tx.Exec(`
+			INSERT INTO analyzer_diagnostics (source, file, line, col, end_line, severity, code, message, function, ingested_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			source, diagnostic.File, diagnostic.Line, diagnostic.Column, diagnostic.EndLine, diagnostic.Severity,
+			diagnostic.Code, diagnostic.Message, diagnostic.Function, now); err != nil {
+			return fmt.Errorf("failed to save diagnostic at %s:%d: %w", diagnostic.File, diagnostic.Line, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// ListAnalyzerDiagnostics returns the ingested diagnostics matching filter,
+// most serious first, then by file and line
+func (db *SQLiteDB) ListAnalyzerDiagnostics(filter AnalyzerDiagnosticFilter) ([]*AnalyzerDiagnostic, error) {
+	query := `SELECT id, source, file, line, col, end_line, severity, code, message, function, ingested_at
+		FROM analyzer_diagnostics WHERE 1 = 1`
+	var args []interface{}
+	if filter.File != "" {
+		query += ` AND file = ?`
+		args = append(args, filter.File)
+	}
+	if filter.Source != "" {
+		query += ` AND source = ?`
+		args = append(args, filter.Source)
+	}
+	query += ` ORDER BY CASE severity WHEN 'error' THEN 0 WHEN 'warning' THEN 1 WHEN 'info' THEN 2 ELSE 3 END, file, line`
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(` LIMIT %d`, filter.Limit)
+	}
+
+	rows, err := db.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list diagnostics: %w", err)
+	}
+	defer rows.Close()
+
+	var diagnostics []*AnalyzerDiagnostic
+	for rows.Next() {
+		var diagnostic AnalyzerDiagnostic
+		if err := rows.Scan(&diagnostic.ID, &diagnostic.Source, &diagnostic.File, &diagnostic.Line, &diagnostic.Column,
+			&diagnostic.EndLine, &diagnostic.Severity, &diagnostic.Code, &diagnostic.Message, &diagnostic.Function,
+			&diagnostic.IngestedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan diagnostic: %w", err)
+		}
+		diagnostics = append(diagnostics, &diagnostic)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list diagnostics: %w", err)
+	}
+	return diagnostics, nil
+}
@@ -0,0 +1,316 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultWriteBatchSize is the number of rows committed per transaction when
+// no batch size is configured
+const DefaultWriteBatchSize = 100
+
+// batchMaxOpen is the longest a batch keeps its transaction open, so other
+// writers to the database wait well under its busy timeout even when rows
+// trickle in slowly
+const batchMaxOpen = time.Second
+
+// Checkpoint modes accepted by Checkpoint, see SQLite's wal_checkpoint
+const (
+	CheckpointPassive  = "PASSIVE"
+	CheckpointFull     = "FULL"
+	CheckpointRestart  = "RESTART"
+	CheckpointTruncate = "TRUNCATE"
+)
+
+// BatchWriter groups indexing writes into transactions of a fixed number of
+// rows, reusing prepared statements, so indexing pays one commit per batch
+// instead of one per row. It is safe for concurrent use by indexing workers.
+// Updates spanning several statements, such as replacing a file's TODOs, go
+// through the writer too: while a batch is open the database is locked to
+// every other connection.
+type BatchWriter struct {
+	db   *SQLiteDB
+	size int
+
+	mu      sync.Mutex
+	tx      *sql.Tx
+	stmts   map[string]*sql.Stmt // the batch transaction's statements
+	timer   *time.Timer          // commits a batch left open too long
+	err     error                // failure of a timed commit, reported by the next call
+	pending int
+	closed  bool
+	stats   BatchWriteStats
+}
+
+// BatchWriteStats counts what a BatchWriter has written
+type BatchWriteStats struct {
+	Rows    int `json:"rows"`
+	Commits int `json:"commits"`
+}
+
+// NewBatchWriter returns a writer committing every size rows; size <= 0 uses
+// DefaultWriteBatchSize
+func (db *SQLiteDB) NewBatchWriter(size int) *BatchWriter {
+	if size <= 0 {
+		size = DefaultWriteBatchSize
+	}
+	return &BatchWriter{db: db, size: size}
+}
+
+// SaveFile saves or updates a code file in the current batch
+func (w *BatchWriter) SaveFile(file *CodeFile) error {
+	return w.write(saveFileQuery, func(stmt *sql.Stmt) error {
+		return execSaveFile(stmt, file)
+	})
+}
+
+// SaveFunctionForFile saves a function in the current batch, resolving the
+// file ID inside the transaction so files saved earlier in the batch are found
+func (w *BatchWriter) SaveFunctionForFile(function *CodeFunction, filePath string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return w.db.SaveFunctionForFile(function, filePath)
+	}
+	tx, err := w.begin()
+	if err != nil {
+		return err
+	}
+
+	lookup, err := w.stmt(tx, fileIDQuery)
+	if err != nil {
+		return err
+	}
+	if err := lookup.QueryRow(filePath).Scan(&function.FileID); err != nil {
+		return fmt.Errorf("failed to get file ID for %s: %w", filePath, err)
+	}
+
+	stmt, err := w.stmt(tx, saveFunctionQuery)
+	if err != nil {
+		return err
+	}
+	if err := execSaveFunction(stmt, function); err != nil {
+		return err
+	}
+	return w.wrote()
+}
+
+// write runs one statement in the current batch. After Close writes go
+// straight to the database, so late workers never lose rows.
+func (w *BatchWriter) write(query string, exec func(stmt *sql.Stmt) error) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		stmt, err := w.db.prepared(query)
+		if err != nil {
+			return err
+		}
+		return exec(stmt)
+	}
+
+	tx, err := w.begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := w.stmt(tx, query)
+	if err != nil {
+		return err
+	}
+	if err := exec(stmt); err != nil {
+		return err
+	}
+	return w.wrote()
+}
+
+// update runs an update of several statements in the current batch,
+// counted as one row. A savepoint undoes it alone when it fails, leaving
+// the rest of the batch. After Close it runs in its own transaction.
+func (w *BatchWriter) update(fn func(tx *sql.Tx) error) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return w.db.update(fn)
+	}
+	tx, err := w.begin()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`SAVEPOINT batch_update`); err != nil {
+		return fmt.Errorf("failed to start batched update: %w", err)
+	}
+	if err := fn(tx); err != nil {
+		tx.Exec(`ROLLBACK TO batch_update`)
+		tx.Exec(`RELEASE batch_update`)
+		return err
+	}
+	if _, err := tx.Exec(`RELEASE batch_update`); err != nil {
+		return fmt.Errorf("failed to finish batched update: %w", err)
+	}
+	return w.wrote()
+}
+
+// begin returns the open transaction, starting one if needed, and reports
+// a timed commit that failed since the last call
+func (w *BatchWriter) begin() (*sql.Tx, error) {
+	if err := w.err; err != nil {
+		w.err = nil
+		return nil, err
+	}
+	if w.tx != nil {
+		return w.tx, nil
+	}
+	tx, err := w.db.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin write batch: %w", err)
+	}
+	w.tx = tx
+	w.timer = time.AfterFunc(batchMaxOpen, w.expire)
+	return tx, nil
+}
+
+// expire commits a batch that has been open for batchMaxOpen
+func (w *BatchWriter) expire() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.commit(); err != nil {
+		w.err = err
+	}
+}
+
+// stmt returns query prepared in the batch transaction, reusing the
+// database's prepared statement
+func (w *BatchWriter) stmt(tx *sql.Tx, query string) (*sql.Stmt, error) {
+	if stmt, ok := w.stmts[query]; ok {
+		return stmt, nil
+	}
+	prepared, err := w.db.prepared(query)
+	if err != nil {
+		return nil, err
+	}
+	if w.stmts == nil {
+		w.stmts = make(map[string]*sql.Stmt)
+	}
+	stmt := tx.Stmt(prepared)
+	w.stmts[query] = stmt
+	return stmt, nil
+}
+
+// wrote counts a row and commits once the batch is full
+func (w *BatchWriter) wrote() error {
+	w.pending++
+	w.stats.Rows++
+	if w.pending >= w.size {
+		return w.commit()
+	}
+	return nil
+}
+
+func (w *BatchWriter) commit() error {
+	if w.tx == nil {
+		return nil
+	}
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	for query, stmt := range w.stmts {
+		stmt.Close()
+		delete(w.stmts, query)
+	}
+	err := w.tx.Commit()
+	w.tx = nil
+	w.pending = 0
+	if err != nil {
+		return fmt.Errorf("failed to commit write batch: %w", err)
+	}
+	w.stats.Commits++
+	return nil
+}
+
+// Flush commits the rows written so far
+func (w *BatchWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.commit()
+}
+
+// Close commits outstanding rows, or reports a timed commit that failed.
+// Writes after Close are not batched.
+func (w *BatchWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	err := w.commit()
+	if w.err != nil {
+		err, w.err = w.err, nil
+	}
+	return err
+}
+
+// Stats returns what the writer has written so far
+func (w *BatchWriter) Stats() BatchWriteStats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.stats
+}
+
+// update runs fn in a transaction of its own
+func (db *SQLiteDB) update(fn func(tx *sql.Tx) error) error {
+	tx, err := db.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin update: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CheckpointResult reports a WAL checkpoint, in pages
+type CheckpointResult struct {
+	Mode         string `json:"mode"`
+	Busy         bool   `json:"busy"` // a reader or writer prevented a complete checkpoint
+	LogPages     int    `json:"log_pages"`
+	Checkpointed int    `json:"checkpointed"`
+}
+
+// Checkpoint copies the write-ahead log back into the database file. PASSIVE
+// never blocks readers or writers; TRUNCATE also shrinks the WAL file to zero
+// and is the one to run after a large indexing run.
+func (db *SQLiteDB) Checkpoint(mode string) (*CheckpointResult, error) {
+	mode = strings.ToUpper(strings.TrimSpace(mode))
+	if mode == "" {
+		mode = CheckpointPassive
+	}
+	switch mode {
+	case CheckpointPassive, CheckpointFull, CheckpointRestart, CheckpointTruncate:
+	default:
+		return nil, fmt.Errorf("unknown checkpoint mode %q (use passive, full, restart or truncate)", mode)
+	}
+
+	result := &CheckpointResult{Mode: mode}
+	var busy int
+	err := db.db.QueryRow(fmt.Sprintf("PRAGMA wal_checkpoint(%s)", mode)).Scan(&busy, &result.LogPages, &result.Checkpointed)
+	if err != nil {
+		return nil, fmt.Errorf("wal checkpoint failed: %w", err)
+	}
+	result.Busy = busy != 0
+	return result, nil
+}
+
+// JournalMode returns the database's journal mode, "wal" when WAL is active
+func (db *SQLiteDB) JournalMode() (string, error) {
+	var mode string
+	if err := db.db.QueryRow("PRAGMA journal_mode").Scan(&mode); err != nil {
+		return "", err
+	}
+	return mode, nil
+}
@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Chunk access weights: a chunk an answer cited carried it, one only
+// retrieved may have been noise
+const (
+	ChunkWeightRetrieved = 1.0
+	ChunkWeightCited     = 3.0
+)
+
+// ChunkKey identifies a chunk by its file and first line
+type ChunkKey struct {
+	Path string
+	Line int
+}
+
+// ChunkHit is one chunk an answer retrieved, and whether it cited it
+type ChunkHit struct {
+	ChunkKey
+	Symbol string
+	Cited  bool
+}
+
+// ChunkAccess is how often a chunk was retrieved for answers and cited in
+// them
+type ChunkAccess struct {
+	Path       string    `json:"path"`
+	Line       int       `json:"line"`
+	Symbol     string    `json:"symbol,omitempty"`
+	Retrieved  int       `json:"retrieved"`
+	Cited      int       `json:"cited"`
+	Score      float64   `json:"score"` // access weight decayed to now
+	LastAccess time.Time `json:"last_access"`
+}
+
+// RecordChunkAccess counts the chunks one answer retrieved and cited.
+// Scores decay with the same one-week half-life as file access.
+func (db *SQLiteDB) RecordChunkAccess(hits []ChunkHit) error {
+	if len(hits) == 0 {
+		return nil
+	}
+	tx, err := db.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin chunk access update: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	for _, hit := range hits {
+		var score float64
+		var lastAccess time.Time
+		err := tx.QueryRow(`SELECT score, last_access FROM chunk_access WHERE path = ? AND line = ?`, hit.Path, hit.Line).
+			Scan(&score, &lastAccess)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("failed to read chunk access: %w", err)
+		}
+
+		weight, cited := ChunkWeightRetrieved, 0
+		if hit.Cited {
+			weight, cited = ChunkWeightCited, 1
+		}
+		score = decayScore(score, lastAccess, now) + weight
+
+		if _, err := tx.Exec(`
+			INSERT INTO chunk_access (path, line, symbol, retrieved, cited, score, last_access)
+			VALUES (?, ?, ?, 1, ?, ?, ?)
+			ON CONFLICT(path, line) DO UPDATE SET
+				symbol = CASE WHEN excluded.symbol != '' THEN excluded.symbol ELSE symbol END,
+				retrieved = retrieved + 1,
+				cited = cited + excluded.cited,
+				score = excluded.score,
+				last_access = excluded.last_access`,
+			hit.Path, hit.Line, hit.Symbol, cited, score, now); err != nil {
+			return fmt.Errorf("failed to record chunk access: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// GetHotChunks returns the most accessed chunks by decayed score, hottest
+// first
+func (db *SQLiteDB) GetHotChunks(limit int) ([]*ChunkAccess, error) {
+	// Scores decay at read time, so rank a generous window of recent chunks
+	chunks, err := db.queryChunkAccess(`ORDER BY last_access DESC LIMIT ?`, limit*10)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(chunks, func(i, j int) bool { return chunks[i].Score > chunks[j].Score })
+	if len(chunks) > limit {
+		chunks = chunks[:limit]
+	}
+	return chunks, nil
+}
+
+// GetMostCitedChunks returns the chunks answers cited most over all time,
+// the code answers lean on, ignoring chunks never cited
+func (db *SQLiteDB) GetMostCitedChunks(limit int) ([]*ChunkAccess, error) {
+	return db.queryChunkAccess(`WHERE cited > 0 ORDER BY cited DESC, retrieved DESC, path, line LIMIT ?`, limit)
+}
+
+// ChunkScores returns the decayed access score of every recorded chunk in
+// paths
+func (db *SQLiteDB) ChunkScores(paths []string) (map[ChunkKey]float64, error) {
+	scores := make(map[ChunkKey]float64)
+	if len(paths) == 0 {
+		return scores, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(paths)), ",")
+	args := make([]interface{}, len(paths))
+	for i, path := range paths {
+		args[i] = path
+	}
+	chunks, err := db.queryChunkAccess(`WHERE path IN (`+placeholders+`)`, args...)
+	if err != nil {
+		return nil, err
+	}
+	for _, chunk := range chunks {
+		scores[ChunkKey{Path: chunk.Path, Line: chunk.Line}] = chunk.Score
+	}
+	return scores, nil
+}
+
+// queryChunkAccess reads chunk access rows matching clause, with scores
+// decayed to now
+func (db *SQLiteDB) queryChunkAccess(clause string, args ...interface{}) ([]*ChunkAccess, error) {
+	rows, err := db.db.Query(`
+		SELECT path, line, symbol, retrieved, cited, score, last_access
+		FROM chunk_access `+clause, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chunk access: %w", err)
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	var chunks []*ChunkAccess
+	for rows.Next() {
+		c := &ChunkAccess{}
+		if err := rows.Scan(&c.Path, &c.Line, &c.Symbol, &c.Retrieved, &c.Cited, &c.Score, &c.LastAccess); err != nil {
+			return nil, err
+		}
+		c.Score = decayScore(c.Score, c.LastAccess, now)
+		chunks = append(chunks, c)
+	}
+	return chunks, rows.Err()
+}
@@ -0,0 +1,41 @@
+package storage
+
+import "strings"
+
+// chunkContentBatch bounds the number of placeholders per query
+const chunkContentBatch = 500
+
+// ChunkContents returns the stored text of chunk rows (path#chunk_N) keyed by
+// path. It hydrates vector results whose payloads carry no text.
+func (db *SQLiteDB) ChunkContents(paths []string) (map[string]string, error) {
+	contents := make(map[string]string, len(paths))
+	for start := 0; start < len(paths); start += chunkContentBatch {
+		batch := paths[start:min(start+chunkContentBatch, len(paths))]
+
+		args := make([]interface{}, len(batch))
+		for i, path := range batch {
+			args[i] = path
+		}
+		query := `SELECT path, content FROM files WHERE path IN (?` +
+			strings.Repeat(", ?", len(batch)-1) + `)`
+
+		rows, err := db.db.Query(query, args...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var path, content string
+			if err := rows.Scan(&path, &content); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			contents[path] = content
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return contents, nil
+}
@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ChurnRecord is how often and how recently a file, or a range of its
+// lines, changed in the history window indexing looked at
+type ChurnRecord struct {
+	Path         string    `json:"path"`
+	StartLine    int       `json:"start_line"` // 0 for the whole file
+	EndLine      int       `json:"end_line"`
+	Commits      int       `json:"commits"`
+	LinesChanged int       `json:"lines_changed"`
+	LastCommit   time.Time `json:"last_commit"`
+	Score        float64   `json:"score"` // recency-weighted commit count at ComputedAt
+	ComputedAt   time.Time `json:"computed_at"`
+}
+
+// ReplaceChurn replaces the churn recorded for a file with records, the
+// whole-file one and those of its chunks
+func (db *SQLiteDB) ReplaceChurn(path string, records []*ChurnRecord) error {
+	return db.update(func(tx *sql.Tx) error {
+		return replaceChurn(tx, path, records)
+	})
+}
+
+// ReplaceChurn replaces the churn recorded for a file in the current batch
+func (w *BatchWriter) ReplaceChurn(path string, records []*ChurnRecord) error {
+	return w.update(func(tx *sql.Tx) error {
+		return replaceChurn(tx, path, records)
+	})
+}
+
+func replaceChurn(tx *sql.Tx, path string, records []*ChurnRecord) error {
+	if _, err := tx.Exec(`DELETE FROM churn WHERE path = ?`, path); err != nil {
+		return fmt.Errorf("failed to clear churn of %s: %w", path, err)
+	}
+	now := time.Now()
+	for _, record := range records {
+		var lastCommit interface{}
+		if !record.LastCommit.IsZero() {
+			lastCommit = record.LastCommit
+		}
+		if _, err := tx.Exec(`
+			INSERT OR REPLACE INTO churn (path, start_line, end_line, commits, lines_changed, last_commit, score, computed_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			path, record.StartLine, record.EndLine, record.Commits, record.LinesChanged, lastCommit, record.Score, now); err != nil {
+			return fmt.Errorf("failed to save churn of %s:%d: %w", path, record.StartLine, err)
+		}
+	}
+	return nil
+}
+
+// GetChurn returns the churn of the narrowest chunk of path covering line,
+// or of the whole file when no chunk does or line is 0. It returns nil
+// when nothing is recorded for the file.
+func (db *SQLiteDB) GetChurn(path string, line int) (*ChurnRecord, error) {
+	var record ChurnRecord
+	var lastCommit sql.NullTime
+	err := db.db.QueryRow(`
+		SELECT path, start_line, end_line, commits, lines_changed, last_commit, score, computed_at
+		FROM churn
+		WHERE path = ? AND (start_line = 0 OR (start_line <= ? AND end_line >= ?))
+		ORDER BY start_line = 0, end_line - start_line
+		LIMIT 1`, path, line, line).Scan(&record.Path, &record.StartLine, &record.EndLine, &record.Commits,
+		&record.LinesChanged, &lastCommit, &record.Score, &record.ComputedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get churn of %s: %w", path, err)
+	}
+	record.LastCommit = lastCommit.Time
+	return &record, nil
+}
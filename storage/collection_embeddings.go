@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// CollectionEmbedding records which embedding model built a vector
+// collection, so a change of model is noticed before it degrades search
+type CollectionEmbedding struct {
+	Collection string    `json:"collection"`
+	Model      string    `json:"model"`
+	Dimension  int       `json:"dimension"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// RecordCollectionEmbedding stores the model a collection was built with,
+// replacing the earlier record
+func (db *SQLiteDB) RecordCollectionEmbedding(record *CollectionEmbedding) error {
+	if record.RecordedAt.IsZero() {
+		record.RecordedAt = time.Now()
+	}
+	_, err := db.db.Exec(`
+		INSERT INTO collection_embeddings (collection, model, dimension, recorded_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(collection) DO UPDATE SET
+			model = excluded.model, dimension = excluded.dimension, recorded_at = excluded.recorded_at`,
+		record.Collection, record.Model, record.Dimension, record.RecordedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record embedding model of %s: %w", record.Collection, err)
+	}
+	return nil
+}
+
+// GetCollectionEmbedding returns the model a collection was built with, or
+// nil when none was recorded
+func (db *SQLiteDB) GetCollectionEmbedding(collection string) (*CollectionEmbedding, error) {
+	record := &CollectionEmbedding{}
+	err := db.db.QueryRow(`
+		SELECT collection, model, dimension, recorded_at
+		FROM collection_embeddings WHERE collection = ?`, collection).
+		Scan(&record.Collection, &record.Model, &record.Dimension, &record.RecordedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedding model of %s: %w", collection, err)
+	}
+	return record, nil
+}
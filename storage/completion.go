@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SymbolName is an indexed function or type name with where it is defined
+type SymbolName struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"` // function, method, struct, interface, ...
+	File string `json:"file"`
+}
+
+// ListSymbolNames returns the names of every indexed function and type,
+// for completing them as they are typed
+func (db *SQLiteDB) ListSymbolNames() ([]*SymbolName, error) {
+	rows, err := db.db.Query(`
+    SELECT f.name, f.type, fi.path FROM functions f JOIN files fi ON fi.id = f.file_id
+    UNION ALL
+    SELECT t.name, t.kind, fi.path FROM types t JOIN files fi ON fi.id = t.file_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list symbols: %w", err)
+	}
+	defer rows.Close()
+
+	var symbols []*SymbolName
+	for rows.Next() {
+		symbol := &SymbolName{}
+		if err := rows.Scan(&symbol.Name, &symbol.Kind, &symbol.File); err != nil {
+			return nil, fmt.Errorf("failed to read symbol: %w", err)
+		}
+		symbols = append(symbols, symbol)
+	}
+	return symbols, rows.Err()
+}
+
+// RecentQueries returns the text of the most recent queries, newest first,
+// from sessions whose ID starts with sessionPrefix
+func (db *SQLiteDB) RecentQueries(sessionPrefix string, limit int) ([]string, error) {
+	escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(sessionPrefix)
+	rows, err := db.db.Query(`
+    SELECT user_input FROM queries
+    WHERE session_id LIKE ? ESCAPE '\'
+    ORDER BY timestamp DESC LIMIT ?`, escaped+"%", limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read query history: %w", err)
+	}
+	defer rows.Close()
+
+	var queries []string
+	for rows.Next() {
+		var text string
+		if err := rows.Scan(&text); err != nil {
+			return nil, fmt.Errorf("failed to read query: %w", err)
+		}
+		queries = append(queries, text)
+	}
+	return queries, rows.Err()
+}
+
+// SymbolLocation is where an indexed function or type is defined
+type SymbolLocation struct {
+	SymbolName
+	StartLine int `json:"start_line"`
+	EndLine   int `json:"end_line"`
+}
+
+// LookupSymbol returns the definitions of every function or type named
+// exactly name, types first
+func (db *SQLiteDB) LookupSymbol(name string) ([]*SymbolLocation, error) {
+	rows, err := db.db.Query(`
+    SELECT t.name, t.kind, fi.path, t.start_line, t.end_line FROM types t JOIN files fi ON fi.id = t.file_id
+    WHERE t.name = ? AND fi.path NOT LIKE '%#chunk_%'
+    UNION ALL
+    SELECT f.name, f.type, fi.path, f.start_line, f.end_line FROM functions f JOIN files fi ON fi.id = f.file_id
+    WHERE f.name = ? AND fi.path NOT LIKE '%#chunk_%'`, name, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up symbol %s: %w", name, err)
+	}
+	defer rows.Close()
+
+	var locations []*SymbolLocation
+	for rows.Next() {
+		location := &SymbolLocation{}
+		if err := rows.Scan(&location.Name, &location.Kind, &location.File, &location.StartLine, &location.EndLine); err != nil {
+			return nil, fmt.Errorf("failed to read symbol: %w", err)
+		}
+		locations = append(locations, location)
+	}
+	return locations, rows.Err()
+}
+
+// ListFunctions returns every indexed function with its file and lines, by
+// file and line
+func (db *SQLiteDB) ListFunctions() ([]*SymbolLocation, error) {
+	rows, err := db.db.Query(`
+    SELECT f.name, f.type, fi.path, f.start_line, f.end_line FROM functions f JOIN files fi ON fi.id = f.file_id
+    WHERE fi.path NOT LIKE '%#chunk_%'
+    ORDER BY fi.path, f.start_line`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list functions: %w", err)
+	}
+	defer rows.Close()
+
+	var locations []*SymbolLocation
+	for rows.Next() {
+		location := &SymbolLocation{}
+		if err := rows.Scan(&location.Name, &location.Kind, &location.File, &location.StartLine, &location.EndLine); err != nil {
+			return nil, fmt.Errorf("failed to read function: %w", err)
+		}
+		locations = append(locations, location)
+	}
+	return locations, rows.Err()
+}
@@ -0,0 +1,246 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourusername/useq-ai-assistant/models"
+)
+
+// maxKeywordCandidates bounds the rows a keyword search over past
+// conversations scores
+const maxKeywordCandidates = 200
+
+// ConversationRecord is one answered question, kept so later queries can
+// recall what was said
+type ConversationRecord struct {
+	QueryID   string    `json:"query_id"`
+	SessionID string    `json:"session_id,omitempty"`
+	Question  string    `json:"question"`
+	Answer    string    `json:"answer"`
+	Sources   []string  `json:"sources,omitempty"` // files the answer cited
+	AgentUsed string    `json:"agent_used,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// StoreConversation records an answered question. Storing the same query
+// again replaces it and queues it to be embedded again.
+func (db *SQLiteDB) StoreConversation(record *ConversationRecord) error {
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = time.Now()
+	}
+	sourcesJSON, _ := json.Marshal(record.Sources)
+	_, err := db.db.Exec(`
+		INSERT OR REPLACE INTO conversation_memory (query_id, session_id, question, answer, sources, agent_used, embedded, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, 0, ?)`,
+		record.QueryID, record.SessionID, record.Question, record.Answer, string(sourcesJSON), record.AgentUsed, record.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to store conversation %s: %w", record.QueryID, err)
+	}
+	return nil
+}
+
+// BackfillConversations copies the answered queries stored before
+// conversations were remembered into conversation memory, returning how
+// many were added
+func (db *SQLiteDB) BackfillConversations() (int, error) {
+	rows, err := db.db.Query(`
+		SELECT q.id, q.session_id, q.user_input, r.content, r.metadata, r.agent_used, q.timestamp
+		FROM queries q JOIN responses r ON r.query_id = q.id
+		WHERE r.type NOT IN (?, ?, ?)
+		AND q.id NOT IN (SELECT query_id FROM conversation_memory)`,
+		string(models.ResponseTypeError), string(models.ResponseTypePlan), string(models.ResponseTypeClarification))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read stored responses: %w", err)
+	}
+	var records []*ConversationRecord
+	for rows.Next() {
+		var record ConversationRecord
+		var sessionID, agentUsed sql.NullString
+		var contentJSON, metadataJSON string
+		if err := rows.Scan(&record.QueryID, &sessionID, &record.Question, &contentJSON, &metadataJSON,
+			&agentUsed, &record.CreatedAt); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to read stored response: %w", err)
+		}
+		var content models.ResponseContent
+		var metadata models.ResponseMetadata
+		if json.Unmarshal([]byte(contentJSON), &content) != nil || strings.TrimSpace(content.Text) == "" {
+			continue
+		}
+		json.Unmarshal([]byte(metadataJSON), &metadata)
+		record.SessionID = sessionID.String
+		record.AgentUsed = agentUsed.String
+		record.Answer = content.Text
+		record.Sources = metadata.Sources
+		records = append(records, &record)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read stored responses: %w", err)
+	}
+
+	for _, record := range records {
+		if err := db.StoreConversation(record); err != nil {
+			return 0, err
+		}
+	}
+	return len(records), nil
+}
+
+// GetConversations returns the stored conversations of the given queries,
+// keyed by query ID
+func (db *SQLiteDB) GetConversations(queryIDs []string) (map[string]*ConversationRecord, error) {
+	records := make(map[string]*ConversationRecord)
+	if len(queryIDs) == 0 {
+		return records, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(queryIDs)), ",")
+	args := make([]interface{}, len(queryIDs))
+	for i, id := range queryIDs {
+		args[i] = id
+	}
+	rows, err := db.db.Query(`
+		SELECT query_id, session_id, question, answer, sources, agent_used, created_at
+		FROM conversation_memory WHERE query_id IN (`+placeholders+`)`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read conversations: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		record, err := scanConversation(rows)
+		if err != nil {
+			return nil, err
+		}
+		records[record.QueryID] = record
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read conversations: %w", err)
+	}
+	return records, nil
+}
+
+// SearchConversations finds conversations mentioning the terms, for when
+// the memory collection is unavailable. Only sessions whose ID starts with
+// sessionPrefix are searched; an empty prefix searches the sessions not
+// tied to a server user, whose IDs have no "/". A zero since or until
+// leaves that end of the period open. Conversations matching more terms
+// come first, then the most recent.
+func (db *SQLiteDB) SearchConversations(sessionPrefix string, terms []string, since, until time.Time, limit int) ([]*ConversationRecord, error) {
+	var where []string
+	var args []interface{}
+	if sessionPrefix != "" {
+		escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(sessionPrefix)
+		where = append(where, `session_id LIKE ? ESCAPE '\'`)
+		args = append(args, escaped+"%")
+	} else {
+		where = append(where, "instr(COALESCE(session_id, ''), '/') = 0")
+	}
+	if !since.IsZero() {
+		where = append(where, "created_at >= ?")
+		args = append(args, since)
+	}
+	if !until.IsZero() {
+		where = append(where, "created_at < ?")
+		args = append(args, until)
+	}
+	var matches []string
+	for _, term := range terms {
+		matches = append(matches, "question LIKE ? OR answer LIKE ?")
+		args = append(args, "%"+term+"%", "%"+term+"%")
+	}
+	if len(matches) > 0 {
+		where = append(where, "("+strings.Join(matches, " OR ")+")")
+	}
+	args = append(args, maxKeywordCandidates)
+
+	rows, err := db.db.Query(`
+		SELECT query_id, session_id, question, answer, sources, agent_used, created_at
+		FROM conversation_memory WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY created_at DESC LIMIT ?`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search conversations: %w", err)
+	}
+	defer rows.Close()
+
+	var records []*ConversationRecord
+	matched := make(map[string]int)
+	for rows.Next() {
+		record, err := scanConversation(rows)
+		if err != nil {
+			return nil, err
+		}
+		text := strings.ToLower(record.Question + " " + record.Answer)
+		for _, term := range terms {
+			if strings.Contains(text, strings.ToLower(term)) {
+				matched[record.QueryID]++
+			}
+		}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to search conversations: %w", err)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return matched[records[i].QueryID] > matched[records[j].QueryID]
+	})
+	if limit > 0 && len(records) > limit {
+		records = records[:limit]
+	}
+	return records, nil
+}
+
+// UnembeddedConversations returns up to limit conversations not yet in the
+// memory collection, oldest first
+func (db *SQLiteDB) UnembeddedConversations(limit int) ([]*ConversationRecord, error) {
+	rows, err := db.db.Query(`
+		SELECT query_id, session_id, question, answer, sources, agent_used, created_at
+		FROM conversation_memory WHERE embedded = 0
+		ORDER BY created_at LIMIT ?`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read unembedded conversations: %w", err)
+	}
+	defer rows.Close()
+	var records []*ConversationRecord
+	for rows.Next() {
+		record, err := scanConversation(rows)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read unembedded conversations: %w", err)
+	}
+	return records, nil
+}
+
+// MarkConversationEmbedded records that a conversation is in the memory
+// collection
+func (db *SQLiteDB) MarkConversationEmbedded(queryID string) error {
+	if _, err := db.db.Exec(`UPDATE conversation_memory SET embedded = 1 WHERE query_id = ?`, queryID); err != nil {
+		return fmt.Errorf("failed to mark conversation %s embedded: %w", queryID, err)
+	}
+	return nil
+}
+
+func scanConversation(rows *sql.Rows) (*ConversationRecord, error) {
+	var record ConversationRecord
+	var sessionID, sourcesJSON, agentUsed sql.NullString
+	if err := rows.Scan(&record.QueryID, &sessionID, &record.Question, &record.Answer, &sourcesJSON,
+		&agentUsed, &record.CreatedAt); err != nil {
+		return nil, fmt.Errorf("failed to read conversation: %w", err)
+	}
+	record.SessionID = sessionID.String
+	record.AgentUsed = agentUsed.String
+	if sourcesJSON.Valid && sourcesJSON.String != "" {
+		json.Unmarshal([]byte(sourcesJSON.String), &record.Sources)
+	}
+	return &record, nil
+}
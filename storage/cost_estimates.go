@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// CostEstimateRecord pairs the estimate shown before a Tier 3 query with
+// what the query actually used
+type CostEstimateRecord struct {
+	QueryID         string    `json:"query_id"`
+	Provider        string    `json:"provider"`
+	HeuristicTokens int       `json:"heuristic_tokens"` // before calibration
+	HeuristicCost   float64   `json:"heuristic_cost"`
+	EstimatedTokens int       `json:"estimated_tokens"` // as shown
+	EstimatedCost   float64   `json:"estimated_cost"`
+	ActualTokens    int       `json:"actual_tokens"`
+	ActualCost      float64   `json:"actual_cost"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// CostEstimateAccuracy summarizes how recent estimates compared with the
+// actual usage of the same provider
+type CostEstimateAccuracy struct {
+	Provider   string  `json:"provider"`
+	Samples    int     `json:"samples"`
+	TokenRatio float64 `json:"token_ratio"` // mean actual/heuristic tokens; calibrates the heuristic
+	CostRatio  float64 `json:"cost_ratio"`  // mean actual/heuristic cost
+	TokenError float64 `json:"token_error"` // mean absolute error of the shown token estimates, as a fraction of actual
+	CostError  float64 `json:"cost_error"`  // mean absolute error of the shown cost estimates, as a fraction of actual
+}
+
+// RecordCostEstimate stores an estimate with the actual usage of its query
+func (db *SQLiteDB) RecordCostEstimate(record *CostEstimateRecord) error {
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = time.Now()
+	}
+	_, err := db.db.Exec(`
+		INSERT INTO cost_estimates (query_id, provider, heuristic_tokens, heuristic_cost,
+			estimated_tokens, estimated_cost, actual_tokens, actual_cost, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		record.QueryID, record.Provider, record.HeuristicTokens, record.HeuristicCost,
+		record.EstimatedTokens, record.EstimatedCost, record.ActualTokens, record.ActualCost, record.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record cost estimate of %s: %w", record.QueryID, err)
+	}
+	return nil
+}
+
+// CostEstimateAccuracy compares the last window estimates for provider with
+// their actual usage; all providers are compared when provider is empty
+func (db *SQLiteDB) CostEstimateAccuracy(provider string, window int) (*CostEstimateAccuracy, error) {
+	rows, err := db.db.Query(`
+		SELECT heuristic_tokens, heuristic_cost, estimated_tokens, estimated_cost, actual_tokens, actual_cost
+		FROM cost_estimates WHERE ? = '' OR provider = ?
+		ORDER BY created_at DESC LIMIT ?`, provider, provider, window)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cost estimates: %w", err)
+	}
+	defer rows.Close()
+
+	accuracy := &CostEstimateAccuracy{Provider: provider}
+	var tokenRatios, costRatios, tokenErrors, costErrors float64
+	var costSamples int
+	for rows.Next() {
+		var record CostEstimateRecord
+		if err := rows.Scan(&record.HeuristicTokens, &record.HeuristicCost, &record.EstimatedTokens,
+			&record.EstimatedCost, &record.ActualTokens, &record.ActualCost); err != nil {
+			return nil, fmt.Errorf("failed to read cost estimate: %w", err)
+		}
+		if record.HeuristicTokens <= 0 || record.ActualTokens <= 0 {
+			continue
+		}
+		accuracy.Samples++
+		tokenRatios += float64(record.ActualTokens) / float64(record.HeuristicTokens)
+		tokenErrors += math.Abs(float64(record.EstimatedTokens-record.ActualTokens)) / float64(record.ActualTokens)
+		// Providers that do not report cost leave it out of the cost figures
+		if record.HeuristicCost > 0 && record.ActualCost > 0 {
+			costSamples++
+			costRatios += record.ActualCost / record.HeuristicCost
+			costErrors += math.Abs(record.EstimatedCost-record.ActualCost) / record.ActualCost
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read cost estimates: %w", err)
+	}
+
+	accuracy.TokenRatio, accuracy.CostRatio = 1, 1
+	if accuracy.Samples > 0 {
+		accuracy.TokenRatio = tokenRatios / float64(accuracy.Samples)
+		accuracy.TokenError = tokenErrors / float64(accuracy.Samples)
+	}
+	if costSamples > 0 {
+		accuracy.CostRatio = costRatios / float64(costSamples)
+		accuracy.CostError = costErrors / float64(costSamples)
+	}
+	return accuracy, nil
+}
@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// QueuedEmbedding is a chunk waiting for its embedding
+type QueuedEmbedding struct {
+	ID        int64     `json:"id"`
+	ChunkID   string    `json:"chunk_id"`
+	FilePath  string    `json:"file_path"`
+	Text      string    `json:"text"`    // what is embedded
+	Payload   string    `json:"payload"` // JSON of the vector DB chunk
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"last_error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// EmbeddingQueueStats summarizes the work left in the embedding queue
+type EmbeddingQueueStats struct {
+	Pending int       `json:"pending"` // chunks still to embed
+	Files   int       `json:"files"`   // files those chunks belong to
+	Failed  int       `json:"failed"`  // chunks that used up their attempts
+	Oldest  time.Time `json:"oldest,omitempty"`
+}
+
+// MaxEmbeddingAttempts is how often a queued chunk is tried before it stays
+// in the queue as failed
+const MaxEmbeddingAttempts = 3
+
+const enqueueEmbeddingQuery = `
+    INSERT INTO embedding_queue (chunk_id, file_path, text, payload, created_at)
+    VALUES (?, ?, ?, ?, ?)`
+
+func execEnqueueEmbedding(stmt *sql.Stmt, item *QueuedEmbedding) error {
+	if item.CreatedAt.IsZero() {
+		item.CreatedAt = time.Now()
+	}
+	_, err := stmt.Exec(item.ChunkID, item.FilePath, item.Text, item.Payload, item.CreatedAt)
+	return err
+}
+
+// EnqueueEmbedding adds a chunk to the embedding queue
+func (db *SQLiteDB) EnqueueEmbedding(item *QueuedEmbedding) error {
+	stmt, err := db.prepared(enqueueEmbeddingQuery)
+	if err != nil {
+		return err
+	}
+	if err := execEnqueueEmbedding(stmt, item); err != nil {
+		return fmt.Errorf("failed to queue embedding for %s: %w", item.ChunkID, err)
+	}
+	return nil
+}
+
+// EnqueueEmbedding adds a chunk to the embedding queue in the current batch
+func (w *BatchWriter) EnqueueEmbedding(item *QueuedEmbedding) error {
+	return w.write(enqueueEmbeddingQuery, func(stmt *sql.Stmt) error {
+		return execEnqueueEmbedding(stmt, item)
+	})
+}
+
+// PendingEmbeddings returns up to limit queued chunks after afterID, oldest
+// first, skipping failed chunks
+func (db *SQLiteDB) PendingEmbeddings(afterID int64, limit int) ([]*QueuedEmbedding, error) {
+	rows, err := db.db.Query(`
+		SELECT id, chunk_id, file_path, text, payload, attempts, last_error, created_at
+		FROM embedding_queue WHERE id > ? AND attempts < ? ORDER BY id LIMIT ?`,
+		afterID, MaxEmbeddingAttempts, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedding queue: %w", err)
+	}
+	defer rows.Close()
+
+	var items []*QueuedEmbedding
+	for rows.Next() {
+		item := &QueuedEmbedding{}
+		var lastError sql.NullString
+		if err := rows.Scan(&item.ID, &item.ChunkID, &item.FilePath, &item.Text, &item.Payload,
+			&item.Attempts, &lastError, &item.CreatedAt); err != nil {
+			return nil, err
+		}
+		item.LastError = lastError.String
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// CompleteEmbeddings removes embedded chunks from the queue in one
+// transaction; this is the queue's checkpoint
+func (db *SQLiteDB) CompleteEmbeddings(ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+
+	tx, err := db.db.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM embedding_queue WHERE id IN (`+placeholders+`)`, args...); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to complete queued embeddings: %w", err)
+	}
+	return tx.Commit()
+}
+
+// FailEmbedding records a failed attempt at a queued chunk
+func (db *SQLiteDB) FailEmbedding(id int64, cause error) error {
+	_, err := db.db.Exec(`UPDATE embedding_queue SET attempts = attempts + 1, last_error = ? WHERE id = ?`,
+		cause.Error(), id)
+	if err != nil {
+		return fmt.Errorf("failed to record embedding failure: %w", err)
+	}
+	return nil
+}
+
+// DeleteQueuedEmbeddings drops the queued chunks of a file, e.g. when the
+// file is removed from the index
+func (db *SQLiteDB) DeleteQueuedEmbeddings(filePath string) error {
+	_, err := db.db.Exec(`DELETE FROM embedding_queue WHERE file_path = ?`, filePath)
+	return err
+}
+
+// GetEmbeddingQueueStats reports the work left in the embedding queue;
+// chunks tried MaxEmbeddingAttempts times count as failed rather than pending
+func (db *SQLiteDB) GetEmbeddingQueueStats() (*EmbeddingQueueStats, error) {
+	stats := &EmbeddingQueueStats{}
+	err := db.db.QueryRow(`
+		SELECT
+			COALESCE(SUM(CASE WHEN attempts < ? THEN 1 ELSE 0 END), 0),
+			COUNT(DISTINCT CASE WHEN attempts < ? THEN file_path END),
+			COALESCE(SUM(CASE WHEN attempts >= ? THEN 1 ELSE 0 END), 0)
+		FROM embedding_queue`,
+		MaxEmbeddingAttempts, MaxEmbeddingAttempts, MaxEmbeddingAttempts).Scan(&stats.Pending, &stats.Files, &stats.Failed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedding queue stats: %w", err)
+	}
+	if stats.Pending > 0 {
+		err := db.db.QueryRow(`SELECT created_at FROM embedding_queue WHERE attempts < ? ORDER BY id LIMIT 1`,
+			MaxEmbeddingAttempts).Scan(&stats.Oldest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read embedding queue stats: %w", err)
+		}
+	}
+	return stats, nil
+}
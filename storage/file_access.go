@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// fileAccessHalfLife is how long it takes an access to lose half its weight
+const fileAccessHalfLife = 7 * 24 * time.Hour
+
+// FileAccess is how often and how recently a file was queried or edited
+type FileAccess struct {
+	Path        string    `json:"path"`
+	Score       float64   `json:"score"` // access weight decayed to now
+	AccessCount int       `json:"access_count"`
+	LastAccess  time.Time `json:"last_access"`
+}
+
+// RecordFileAccess adds weight to a file's access score. Older accesses decay
+// with a one-week half-life so the hottest files reflect recent work.
+func (db *SQLiteDB) RecordFileAccess(path string, weight float64) error {
+	now := time.Now()
+
+	var score float64
+	var lastAccess time.Time
+	err := db.db.QueryRow(`SELECT score, last_access FROM file_access WHERE path = ?`, path).
+		Scan(&score, &lastAccess)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to read file access: %w", err)
+	}
+
+	score = decayScore(score, lastAccess, now) + weight
+
+	_, err = db.db.Exec(`
+		INSERT INTO file_access (path, score, access_count, last_access)
+		VALUES (?, ?, 1, ?)
+		ON CONFLICT(path) DO UPDATE SET
+			score = excluded.score,
+			access_count = access_count + 1,
+			last_access = excluded.last_access`,
+		path, score, now)
+	if err != nil {
+		return fmt.Errorf("failed to record file access: %w", err)
+	}
+	return nil
+}
+
+// GetHotFiles returns the most accessed files by decayed score, hottest first
+func (db *SQLiteDB) GetHotFiles(limit int) ([]*FileAccess, error) {
+	// Scores decay at read time, so rank a generous window of recent files
+	rows, err := db.db.Query(`
+		SELECT path, score, access_count, last_access
+		FROM file_access ORDER BY last_access DESC LIMIT ?`, limit*10)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	var files []*FileAccess
+	for rows.Next() {
+		f := &FileAccess{}
+		if err := rows.Scan(&f.Path, &f.Score, &f.AccessCount, &f.LastAccess); err != nil {
+			return nil, err
+		}
+		f.Score = decayScore(f.Score, f.LastAccess, now)
+		files = append(files, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Score > files[j].Score })
+	if len(files) > limit {
+		files = files[:limit]
+	}
+	return files, nil
+}
+
+// decayScore applies exponential decay to score since lastAccess
+func decayScore(score float64, lastAccess, now time.Time) float64 {
+	if lastAccess.IsZero() || score == 0 {
+		return score
+	}
+	elapsed := now.Sub(lastAccess)
+	return score * math.Pow(0.5, float64(elapsed)/float64(fileAccessHalfLife))
+}
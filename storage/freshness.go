@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Reasons an indexed file no longer matches disk
+const (
+	StaleModified = "modified"
+	StaleDeleted  = "deleted"
+)
+
+// StaleFiles compares indexed files with disk and returns the stale ones with
+// the reason. A file whose size and modification time match the index is
+// fresh without being read; a changed modification time is confirmed against
+// the content hash, so touching a file does not make it stale. Paths that are
+// not indexed are skipped.
+func (db *SQLiteDB) StaleFiles(paths []string) (map[string]string, error) {
+	unique := make([]interface{}, 0, len(paths))
+	seen := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		if path != "" && !seen[path] {
+			seen[path] = true
+			unique = append(unique, path)
+		}
+	}
+	stale := make(map[string]string)
+	if len(unique) == 0 {
+		return stale, nil
+	}
+
+	query := `SELECT path, size, hash, last_modified FROM files WHERE path IN (?` +
+		strings.Repeat(",?", len(unique)-1) + `)`
+	rows, err := db.db.Query(query, unique...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read indexed file state: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			path, hash   string
+			size         int64
+			lastModified time.Time
+		)
+		if err := rows.Scan(&path, &size, &hash, &lastModified); err != nil {
+			return nil, err
+		}
+		if reason := fileStaleness(path, size, hash, lastModified); reason != "" {
+			stale[path] = reason
+		}
+	}
+	return stale, rows.Err()
+}
+
+// fileStaleness returns why the file at path differs from its indexed state,
+// or "" when it matches
+func fileStaleness(path string, size int64, hash string, lastModified time.Time) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return StaleDeleted
+	}
+	if info.Size() != size {
+		return StaleModified
+	}
+	if info.ModTime().Equal(lastModified) || hash == "" {
+		return ""
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return StaleDeleted
+	}
+	if fmt.Sprintf("%x", sha256.Sum256(content)) != hash {
+		return StaleModified
+	}
+	return ""
+}
+
+// IndexFreshness summarizes how current the whole index is
+type IndexFreshness struct {
+	Files       int               `json:"files"`
+	Stale       map[string]string `json:"stale"` // path to StaleModified or StaleDeleted
+	LastIndexed time.Time         `json:"last_indexed"`
+}
+
+// IndexFreshness compares every indexed file with disk, as StaleFiles does
+func (db *SQLiteDB) IndexFreshness() (*IndexFreshness, error) {
+	rows, err := db.db.Query(`SELECT path, size, hash, last_modified, last_indexed FROM files`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read indexed file state: %w", err)
+	}
+	defer rows.Close()
+
+	freshness := &IndexFreshness{Stale: make(map[string]string)}
+	for rows.Next() {
+		var (
+			path, hash                string
+			size                      int64
+			lastModified, lastIndexed time.Time
+		)
+		if err := rows.Scan(&path, &size, &hash, &lastModified, &lastIndexed); err != nil {
+			return nil, err
+		}
+		if strings.Contains(path, chunkPathMarker) {
+			continue
+		}
+		freshness.Files++
+		if lastIndexed.After(freshness.LastIndexed) {
+			freshness.LastIndexed = lastIndexed
+		}
+		if reason := fileStaleness(path, size, hash, lastModified); reason != "" {
+			freshness.Stale[path] = reason
+		}
+	}
+	return freshness, rows.Err()
+}
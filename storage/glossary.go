@@ -0,0 +1,203 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Glossary entry sources
+const (
+	GlossarySourceType   = "type"   // a documented exported type or interface
+	GlossarySourceDoc    = "doc"    // a term defined in the project's docs
+	GlossarySourceManual = "manual" // added or edited with `glossary set`
+)
+
+// GlossaryEntry is one term of the project's vocabulary
+type GlossaryEntry struct {
+	Term       string    `json:"term"`
+	Definition string    `json:"definition"`
+	Source     string    `json:"source"`
+	File       string    `json:"file,omitempty"`
+	Aliases    []string  `json:"aliases,omitempty"` // names for the same thing the project does not use
+	Hidden     bool      `json:"hidden,omitempty"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// glossaryTable holds the project glossary. A term has a row per file
+// declaring it, so it survives any one of them being deleted. Extracted
+// terms are replaced per source file on reindex, while manual terms and
+// hidden (removed by the user) ones stay.
+const glossaryTable = `CREATE TABLE IF NOT EXISTS glossary (
+        term TEXT NOT NULL COLLATE NOCASE,
+        definition TEXT NOT NULL,
+        source TEXT NOT NULL,
+        file TEXT NOT NULL DEFAULT '', -- where the term was found; '' when added by hand
+        aliases TEXT DEFAULT '[]', -- JSON array of names the project avoids
+        hidden BOOLEAN DEFAULT FALSE,
+        updated_at DATETIME NOT NULL,
+        PRIMARY KEY (term, file)
+    );`
+
+// migrateGlossary rekeys a glossary created when a term could have only
+// one row, keeping its terms
+func (db *SQLiteDB) migrateGlossary() error {
+	var schema string
+	err := db.db.QueryRow(`SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'glossary'`).Scan(&schema)
+	if err == sql.ErrNoRows || (err == nil && !strings.Contains(schema, "term TEXT PRIMARY KEY")) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return db.update(func(tx *sql.Tx) error {
+		for _, statement := range []string{
+			`ALTER TABLE glossary RENAME TO glossary_old`,
+			glossaryTable,
+			`INSERT INTO glossary (term, definition, source, file, aliases, hidden, updated_at)
+				SELECT term, definition, source, COALESCE(file, ''), aliases, hidden, updated_at FROM glossary_old`,
+			`DROP TABLE glossary_old`,
+		} {
+			if _, err := tx.Exec(statement); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ReplaceGlossaryTerms replaces the terms extracted from file with entries.
+// Manual and hidden terms are kept, and win over extracted ones.
+func (db *SQLiteDB) ReplaceGlossaryTerms(file string, entries []*GlossaryEntry) error {
+	return db.update(func(tx *sql.Tx) error {
+		return replaceGlossaryTerms(tx, file, entries)
+	})
+}
+
+// ReplaceGlossaryTerms replaces the terms extracted from file in the
+// current batch
+func (w *BatchWriter) ReplaceGlossaryTerms(file string, entries []*GlossaryEntry) error {
+	return w.update(func(tx *sql.Tx) error {
+		return replaceGlossaryTerms(tx, file, entries)
+	})
+}
+
+func replaceGlossaryTerms(tx *sql.Tx, file string, entries []*GlossaryEntry) error {
+	if _, err := tx.Exec(`DELETE FROM glossary WHERE file = ? AND source != ? AND hidden = 0`,
+		file, GlossarySourceManual); err != nil {
+		return fmt.Errorf("failed to clear glossary terms of %s: %w", file, err)
+	}
+	for _, entry := range entries {
+		aliases, err := json.Marshal(entry.Aliases)
+		if err != nil {
+			return fmt.Errorf("invalid aliases for %s: %w", entry.Term, err)
+		}
+		// A term the user hid stays hidden wherever else it is declared
+		if _, err := tx.Exec(`
+			INSERT OR IGNORE INTO glossary (term, definition, source, file, aliases, hidden, updated_at)
+			SELECT ?, ?, ?, ?, ?, 0, ?
+			WHERE NOT EXISTS (SELECT 1 FROM glossary WHERE term = ? AND hidden = 1)`,
+			entry.Term, entry.Definition, entry.Source, file, string(aliases), time.Now(), entry.Term); err != nil {
+			return fmt.Errorf("failed to save glossary term %s: %w", entry.Term, err)
+		}
+	}
+	return nil
+}
+
+// SaveGlossaryEntry inserts or replaces a term, unhiding it
+func (db *SQLiteDB) SaveGlossaryEntry(entry *GlossaryEntry) error {
+	aliases, err := json.Marshal(entry.Aliases)
+	if err != nil {
+		return fmt.Errorf("invalid aliases for %s: %w", entry.Term, err)
+	}
+	_, err = db.db.Exec(`
+		INSERT OR REPLACE INTO glossary (term, definition, source, file, aliases, hidden, updated_at)
+		VALUES (?, ?, ?, ?, ?, 0, ?)`,
+		entry.Term, entry.Definition, entry.Source, entry.File, string(aliases), time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to save glossary term %s: %w", entry.Term, err)
+	}
+	return nil
+}
+
+// RemoveGlossaryEntry deletes a manual term and hides the extracted rows of
+// it so reindexing does not bring it back. It reports whether the term
+// existed.
+func (db *SQLiteDB) RemoveGlossaryEntry(term string) (bool, error) {
+	result, err := db.db.Exec(`DELETE FROM glossary WHERE term = ? AND source = ?`, term, GlossarySourceManual)
+	if err != nil {
+		return false, fmt.Errorf("failed to remove glossary term %s: %w", term, err)
+	}
+	removed, _ := result.RowsAffected()
+
+	result, err = db.db.Exec(`UPDATE glossary SET hidden = 1, updated_at = ? WHERE term = ? AND hidden = 0`,
+		time.Now(), term)
+	if err != nil {
+		return false, fmt.Errorf("failed to hide glossary term %s: %w", term, err)
+	}
+	hidden, _ := result.RowsAffected()
+	return removed+hidden > 0, nil
+}
+
+// GetGlossaryEntry returns a visible term, matched case-insensitively, or
+// nil if there is none. A manual definition wins over extracted ones.
+func (db *SQLiteDB) GetGlossaryEntry(term string) (*GlossaryEntry, error) {
+	entry, err := scanGlossaryEntry(db.db.QueryRow(`
+		SELECT term, definition, source, file, aliases, hidden, updated_at
+		FROM glossary WHERE term = ? AND hidden = 0
+		ORDER BY source = ? DESC, file
+		LIMIT 1`, term, GlossarySourceManual))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read glossary term %s: %w", term, err)
+	}
+	return entry, nil
+}
+
+// ListGlossary returns every visible term once, alphabetically, with its
+// manual definition if it has one
+func (db *SQLiteDB) ListGlossary() ([]*GlossaryEntry, error) {
+	rows, err := db.db.Query(`
+		SELECT term, definition, source, file, aliases, hidden, updated_at
+		FROM glossary WHERE hidden = 0 ORDER BY term, source = ? DESC, file`, GlossarySourceManual)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list glossary: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*GlossaryEntry
+	for rows.Next() {
+		entry, err := scanGlossaryEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read glossary term: %w", err)
+		}
+		if last := len(entries) - 1; last >= 0 && strings.EqualFold(entries[last].Term, entry.Term) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+func scanGlossaryEntry(row rowScanner) (*GlossaryEntry, error) {
+	var (
+		entry   GlossaryEntry
+		file    sql.NullString
+		aliases sql.NullString
+	)
+	if err := row.Scan(&entry.Term, &entry.Definition, &entry.Source, &file, &aliases,
+		&entry.Hidden, &entry.UpdatedAt); err != nil {
+		return nil, err
+	}
+	entry.File = file.String
+	if aliases.Valid && aliases.String != "" {
+		if err := json.Unmarshal([]byte(aliases.String), &entry.Aliases); err != nil {
+			return nil, fmt.Errorf("invalid aliases for %s: %w", entry.Term, err)
+		}
+	}
+	return &entry, nil
+}
@@ -0,0 +1,194 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// InterfaceRecord is an interface type declared in the project
+type InterfaceRecord struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Package   string    `json:"package"` // import path
+	File      string    `json:"file"`    // relative to the project root
+	Line      int       `json:"line"`
+	Methods   []string  `json:"methods"`
+	IndexedAt time.Time `json:"indexed_at"`
+
+	Implementations []*ImplementationRecord `json:"implementations,omitempty"`
+}
+
+// QualifiedName returns pkg.Name, naming the package by its last element;
+// builtin interfaces have no package
+func (r *InterfaceRecord) QualifiedName() string {
+	if r.Package == "" {
+		return r.Name
+	}
+	return r.Package[strings.LastIndex(r.Package, "/")+1:] + "." + r.Name
+}
+
+// ImplementationRecord is a concrete type whose method set satisfies an
+// interface
+type ImplementationRecord struct {
+	Interface *InterfaceRecord `json:"-"`
+	Type      string           `json:"type"`
+	Package   string           `json:"package"` // import path
+	File      string           `json:"file"`
+	Line      int              `json:"line"`
+	Pointer   bool             `json:"pointer"` // only *Type satisfies the interface
+}
+
+// QualifiedName returns pkg.Type, or *pkg.Type when only the pointer
+// satisfies the interface
+func (r *ImplementationRecord) QualifiedName() string {
+	name := r.Package[strings.LastIndex(r.Package, "/")+1:] + "." + r.Type
+	if r.Pointer {
+		return "*" + name
+	}
+	return name
+}
+
+// ReplaceImplementations replaces the recorded interfaces and their
+// implementations with the result of a new type-checking run
+func (db *SQLiteDB) ReplaceImplementations(interfaces []*InterfaceRecord) error {
+	return db.update(func(tx *sql.Tx) error {
+		return replaceImplementations(tx, interfaces)
+	})
+}
+
+// ReplaceImplementations replaces the recorded interfaces and their
+// implementations in the current batch
+func (w *BatchWriter) ReplaceImplementations(interfaces []*InterfaceRecord) error {
+	return w.update(func(tx *sql.Tx) error {
+		return replaceImplementations(tx, interfaces)
+	})
+}
+
+func replaceImplementations(tx *sql.Tx, interfaces []*InterfaceRecord) error {
+	if _, err := tx.Exec(`DELETE FROM implementations`); err != nil {
+		return fmt.Errorf("failed to clear implementations: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM interfaces`); err != nil {
+		return fmt.Errorf("failed to clear interfaces: %w", err)
+	}
+	now := time.Now()
+	for _, iface := range interfaces {
+		methods, err := json.Marshal(iface.Methods)
+		if err != nil {
+			return fmt.Errorf("failed to encode methods of %s: %w", iface.Name, err)
+		}
+		result, err := tx.Exec(`INSERT INTO interfaces (name, package, file, line, methods, indexed_at) VALUES (?, ?, ?, ?, ?, ?)`,
+			iface.Name, iface.Package, iface.File, iface.Line, string(methods), now)
+		if err != nil {
+			return fmt.Errorf("failed to save interface %s: %w", iface.Name, err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to save interface %s: %w", iface.Name, err)
+		}
+		for _, impl := range iface.Implementations {
+			if _, err := tx.Exec(`INSERT INTO implementations (interface_id, type, package, file, line, pointer) VALUES (?, ?, ?, ?, ?, ?)`,
+				id, impl.Type, impl.Package, impl.File, impl.Line, impl.Pointer); err != nil {
+				return fmt.Errorf("failed to save implementation %s of %s: %w", impl.Type, iface.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// FindInterfaces returns the recorded interfaces called name, with their
+// implementations. name may be qualified by its package, as in
+// storage.Storage; matching ignores case.
+func (db *SQLiteDB) FindInterfaces(name string) ([]*InterfaceRecord, error) {
+	pkg, name := splitQualified(name)
+	query := `SELECT id, name, package, file, line, methods, indexed_at FROM interfaces WHERE name = ? COLLATE NOCASE`
+	args := []interface{}{name}
+	if pkg != "" {
+		query += ` AND (package = ? OR package LIKE ?)`
+		args = append(args, pkg, "%/"+pkg)
+	}
+	rows, err := db.db.Query(query+` ORDER BY package`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find interface %s: %w", name, err)
+	}
+	defer rows.Close()
+
+	var interfaces []*InterfaceRecord
+	for rows.Next() {
+		var iface InterfaceRecord
+		var methods string
+		if err := rows.Scan(&iface.ID, &iface.Name, &iface.Package, &iface.File, &iface.Line, &methods, &iface.IndexedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan interface: %w", err)
+		}
+		json.Unmarshal([]byte(methods), &iface.Methods)
+		interfaces = append(interfaces, &iface)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to find interface %s: %w", name, err)
+	}
+	rows.Close()
+
+	for _, iface := range interfaces {
+		impls, err := db.queryImplementations(`WHERE m.interface_id = ? ORDER BY m.package, m.type`, iface.ID)
+		if err != nil {
+			return nil, err
+		}
+		iface.Implementations = impls
+	}
+	return interfaces, nil
+}
+
+// InterfacesOf returns what the recorded type called name implements, each
+// implementation pointing at its interface. name may be qualified by its
+// package.
+func (db *SQLiteDB) InterfacesOf(name string) ([]*ImplementationRecord, error) {
+	pkg, name := splitQualified(name)
+	where := `WHERE m.type = ? COLLATE NOCASE`
+	args := []interface{}{name}
+	if pkg != "" {
+		where += ` AND (m.package = ? OR m.package LIKE ?)`
+		args = append(args, pkg, "%/"+pkg)
+	}
+	return db.queryImplementations(where+` ORDER BY i.package, i.name`, args...)
+}
+
+// queryImplementations reads implementations joined with their interfaces
+func (db *SQLiteDB) queryImplementations(where string, args ...interface{}) ([]*ImplementationRecord, error) {
+	rows, err := db.db.Query(`
+		SELECT m.type, m.package, m.file, m.line, m.pointer,
+		       i.id, i.name, i.package, i.file, i.line, i.methods, i.indexed_at
+		FROM implementations m JOIN interfaces i ON i.id = m.interface_id `+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query implementations: %w", err)
+	}
+	defer rows.Close()
+
+	var impls []*ImplementationRecord
+	for rows.Next() {
+		impl := &ImplementationRecord{Interface: &InterfaceRecord{}}
+		iface := impl.Interface
+		var methods string
+		if err := rows.Scan(&impl.Type, &impl.Package, &impl.File, &impl.Line, &impl.Pointer,
+			&iface.ID, &iface.Name, &iface.Package, &iface.File, &iface.Line, &methods, &iface.IndexedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan implementation: %w", err)
+		}
+		json.Unmarshal([]byte(methods), &iface.Methods)
+		impls = append(impls, impl)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query implementations: %w", err)
+	}
+	return impls, nil
+}
+
+// splitQualified splits pkg.Name; an unqualified name has no package
+func splitQualified(name string) (string, string) {
+	name = strings.TrimPrefix(strings.TrimSpace(name), "*")
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return "", name
+}
@@ -0,0 +1,220 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// IndexBenchOptions sizes the synthetic workload for BenchmarkIndexWrites
+type IndexBenchOptions struct {
+	Files            int `json:"files"`
+	FunctionsPerFile int `json:"functions_per_file"`
+	BatchSize        int `json:"batch_size"`
+	Readers          int `json:"readers"` // goroutines issuing query-time reads during the writes
+}
+
+// DefaultIndexBenchOptions returns a workload that runs in a few seconds
+func DefaultIndexBenchOptions() IndexBenchOptions {
+	return IndexBenchOptions{
+		Files:            500,
+		FunctionsPerFile: 10,
+		BatchSize:        DefaultWriteBatchSize,
+		Readers:          2,
+	}
+}
+
+// IndexBenchRun measures one way of writing the workload
+type IndexBenchRun struct {
+	Mode          string        `json:"mode"`
+	Rows          int           `json:"rows"`
+	Commits       int           `json:"commits"`
+	Duration      time.Duration `json:"duration"`
+	RowsPerSecond float64       `json:"rows_per_second"`
+	Reads         int           `json:"reads"`
+	ReadErrors    int           `json:"read_errors"`
+	ReadP50       time.Duration `json:"read_p50"`
+	ReadP95       time.Duration `json:"read_p95"`
+}
+
+// IndexBenchResult compares row-at-a-time writes with batched writes
+type IndexBenchResult struct {
+	Options   IndexBenchOptions `json:"options"`
+	Unbatched IndexBenchRun     `json:"unbatched"`
+	Batched   IndexBenchRun     `json:"batched"`
+	Speedup   float64           `json:"speedup"`
+}
+
+// rowSaver is the write surface shared by SQLiteDB and BatchWriter
+type rowSaver interface {
+	SaveFile(file *CodeFile) error
+	SaveFunctionForFile(function *CodeFunction, filePath string) error
+}
+
+// BenchmarkIndexWrites writes a synthetic index into fresh databases under
+// dir, once a row at a time and once through a BatchWriter, while readers
+// query the same database, and reports write throughput and read latency
+func BenchmarkIndexWrites(dir string, options IndexBenchOptions) (*IndexBenchResult, error) {
+	defaults := DefaultIndexBenchOptions()
+	if options.Files <= 0 {
+		options.Files = defaults.Files
+	}
+	if options.FunctionsPerFile < 0 {
+		options.FunctionsPerFile = defaults.FunctionsPerFile
+	}
+	if options.BatchSize <= 0 {
+		options.BatchSize = defaults.BatchSize
+	}
+	if options.Readers < 0 {
+		options.Readers = 0
+	}
+
+	workDir, err := os.MkdirTemp(dir, "useq-bench-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create benchmark directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	result := &IndexBenchResult{Options: options}
+
+	result.Unbatched, err = runIndexBench(filepath.Join(workDir, "unbatched.db"), "unbatched", options,
+		func(db *SQLiteDB) (rowSaver, func() (int, error)) {
+			return db, func() (int, error) { return 0, nil }
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	result.Batched, err = runIndexBench(filepath.Join(workDir, "batched.db"), "batched", options,
+		func(db *SQLiteDB) (rowSaver, func() (int, error)) {
+			writer := db.NewBatchWriter(options.BatchSize)
+			return writer, func() (int, error) {
+				err := writer.Close()
+				return writer.Stats().Commits, err
+			}
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Batched.Duration > 0 {
+		result.Speedup = float64(result.Unbatched.Duration) / float64(result.Batched.Duration)
+	}
+	return result, nil
+}
+
+func runIndexBench(dbPath, mode string, options IndexBenchOptions, open func(db *SQLiteDB) (rowSaver, func() (int, error))) (IndexBenchRun, error) {
+	run := IndexBenchRun{Mode: mode}
+
+	db, err := NewSQLiteDB(dbPath)
+	if err != nil {
+		return run, err
+	}
+	defer db.Close()
+
+	done := make(chan struct{})
+	var (
+		readersWG  sync.WaitGroup
+		latencyMu  sync.Mutex
+		latencies  []time.Duration
+		readErrors int
+	)
+	for i := 0; i < options.Readers; i++ {
+		readersWG.Add(1)
+		go func(reader int) {
+			defer readersWG.Done()
+			for n := 0; ; n++ {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				start := time.Now()
+				var count int
+				err := db.db.QueryRow(`SELECT COUNT(*) FROM functions WHERE name LIKE ?`, fmt.Sprintf("Func%d%%", (reader+n)%10)).Scan(&count)
+				elapsed := time.Since(start)
+
+				latencyMu.Lock()
+				latencies = append(latencies, elapsed)
+				if err != nil {
+					readErrors++
+				}
+				latencyMu.Unlock()
+			}
+		}(i)
+	}
+
+	saver, finish := open(db)
+	content := strings.Repeat("// synthetic source line for the index benchmark\n", 40)
+	start := time.Now()
+	for f := 0; f < options.Files; f++ {
+		path := fmt.Sprintf("bench/pkg%d/file%d.go", f%20, f)
+		file := &CodeFile{
+			Path:         path,
+			Name:         filepath.Base(path),
+			Extension:    ".go",
+			Size:         int64(len(content)),
+			Hash:         fmt.Sprintf("%016x", f),
+			Language:     "go",
+			Content:      content,
+			LastModified: start,
+			LastIndexed:  start,
+		}
+		if err := saver.SaveFile(file); err != nil {
+			close(done)
+			readersWG.Wait()
+			return run, fmt.Errorf("%s write failed: %w", mode, err)
+		}
+		run.Rows++
+
+		for fn := 0; fn < options.FunctionsPerFile; fn++ {
+			function := &CodeFunction{
+				Name:       fmt.Sprintf("Func%d_%d", fn, f),
+				Signature:  fmt.Sprintf("func Func%d_%d()", fn, f),
+				StartLine:  fn*4 + 1,
+				EndLine:    fn*4 + 3,
+				Visibility: "public",
+				Type:       "function",
+			}
+			if err := saver.SaveFunctionForFile(function, path); err != nil {
+				close(done)
+				readersWG.Wait()
+				return run, fmt.Errorf("%s write failed: %w", mode, err)
+			}
+			run.Rows++
+		}
+	}
+	commits, err := finish()
+	run.Duration = time.Since(start)
+	close(done)
+	readersWG.Wait()
+	if err != nil {
+		return run, fmt.Errorf("%s flush failed: %w", mode, err)
+	}
+
+	run.Commits = commits
+	if mode == "unbatched" {
+		run.Commits = run.Rows // every statement commits on its own
+	}
+	if run.Duration > 0 {
+		run.RowsPerSecond = float64(run.Rows) / run.Duration.Seconds()
+	}
+	run.Reads = len(latencies)
+	run.ReadErrors = readErrors
+	run.ReadP50 = latencyPercentile(latencies, 0.50)
+	run.ReadP95 = latencyPercentile(latencies, 0.95)
+	return run, nil
+}
+
+func latencyPercentile(latencies []time.Duration, p float64) time.Duration {
+	if len(latencies) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[int(float64(len(sorted)-1)*p)]
+}
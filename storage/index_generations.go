@@ -0,0 +1,326 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// chunkPathMarker separates a file path from its chunk index in the files table
+const chunkPathMarker = "#chunk_"
+
+// keptIndexGenerations is how many of the newest generations are kept; each
+// snapshots every file, so older ones are pruned as new ones are recorded
+const keptIndexGenerations = 50
+
+// IndexGeneration is a snapshot of what the index contained after one indexing run
+type IndexGeneration struct {
+	ID          int64     `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	FileCount   int       `json:"file_count"`
+	ChunkCount  int       `json:"chunk_count"`
+	SymbolCount int       `json:"symbol_count"`
+	VectorCount int       `json:"vector_count"` // -1 when the vector store was unavailable
+	Notes       string    `json:"notes"`
+}
+
+// IndexGenerationFile is the per-file state captured in a generation
+type IndexGenerationFile struct {
+	Path       string   `json:"path"`
+	Hash       string   `json:"hash"`
+	ChunkCount int      `json:"chunk_count"`
+	Symbols    []string `json:"symbols"`
+}
+
+// IndexGenerationDiff describes what changed between two index generations
+type IndexGenerationDiff struct {
+	From           *IndexGeneration `json:"from"`
+	To             *IndexGeneration `json:"to"`
+	FilesAdded     []string         `json:"files_added"`
+	FilesRemoved   []string         `json:"files_removed"`
+	FilesModified  []string         `json:"files_modified"`
+	SymbolsAdded   []string         `json:"symbols_added"`
+	SymbolsRemoved []string         `json:"symbols_removed"`
+	ChunkDelta     int              `json:"chunk_delta"`
+	VectorDelta    int              `json:"vector_delta"`
+}
+
+// RecordIndexGeneration snapshots the current files, chunks and symbols as a
+// new index generation and prunes all but the newest keptIndexGenerations.
+// vectorCount is the point count of the vector collection, or -1 if it
+// could not be determined.
+func (db *SQLiteDB) RecordIndexGeneration(vectorCount int, notes string) (*IndexGeneration, error) {
+	files, err := db.snapshotIndexedFiles()
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot indexed files: %w", err)
+	}
+
+	gen := &IndexGeneration{
+		CreatedAt:   time.Now(),
+		FileCount:   len(files),
+		VectorCount: vectorCount,
+		Notes:       notes,
+	}
+	for _, f := range files {
+		gen.ChunkCount += f.ChunkCount
+		gen.SymbolCount += len(f.Symbols)
+	}
+
+	tx, err := db.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO index_generations (created_at, file_count, chunk_count, symbol_count, vector_count, notes)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		gen.CreatedAt, gen.FileCount, gen.ChunkCount, gen.SymbolCount, gen.VectorCount, gen.Notes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert index generation: %w", err)
+	}
+	gen.ID, err = result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO index_generation_files (generation_id, path, hash, chunk_count, symbols)
+		VALUES (?, ?, ?, ?, ?)`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	for _, f := range files {
+		symbolsJSON, _ := json.Marshal(f.Symbols)
+		if _, err := stmt.Exec(gen.ID, f.Path, f.Hash, f.ChunkCount, string(symbolsJSON)); err != nil {
+			return nil, fmt.Errorf("failed to record file %s: %w", f.Path, err)
+		}
+	}
+
+	// Their files go with them, see ON DELETE CASCADE
+	if _, err := tx.Exec(`
+		DELETE FROM index_generations
+		WHERE id NOT IN (SELECT id FROM index_generations ORDER BY id DESC LIMIT ?)`, keptIndexGenerations); err != nil {
+		return nil, fmt.Errorf("failed to prune index generations: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit index generation: %w", err)
+	}
+	return gen, nil
+}
+
+// ListIndexGenerations returns the most recent index generations, newest first
+func (db *SQLiteDB) ListIndexGenerations(limit int) ([]*IndexGeneration, error) {
+	rows, err := db.db.Query(`
+		SELECT id, created_at, file_count, chunk_count, symbol_count, vector_count, notes
+		FROM index_generations ORDER BY id DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var generations []*IndexGeneration
+	for rows.Next() {
+		gen := &IndexGeneration{}
+		if err := rows.Scan(&gen.ID, &gen.CreatedAt, &gen.FileCount, &gen.ChunkCount,
+			&gen.SymbolCount, &gen.VectorCount, &gen.Notes); err != nil {
+			return nil, err
+		}
+		generations = append(generations, gen)
+	}
+	return generations, rows.Err()
+}
+
+// GetIndexGeneration retrieves a single index generation by ID
+func (db *SQLiteDB) GetIndexGeneration(id int64) (*IndexGeneration, error) {
+	gen := &IndexGeneration{}
+	err := db.db.QueryRow(`
+		SELECT id, created_at, file_count, chunk_count, symbol_count, vector_count, notes
+		FROM index_generations WHERE id = ?`, id).Scan(&gen.ID, &gen.CreatedAt, &gen.FileCount,
+		&gen.ChunkCount, &gen.SymbolCount, &gen.VectorCount, &gen.Notes)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("index generation %d not found", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return gen, nil
+}
+
+// CurrentIndexGeneration returns the ID of the newest index generation, or 0
+// if the project has never been indexed
+func (db *SQLiteDB) CurrentIndexGeneration() (int64, error) {
+	var id int64
+	if err := db.db.QueryRow(`SELECT COALESCE(MAX(id), 0) FROM index_generations`).Scan(&id); err != nil {
+		return 0, fmt.Errorf("failed to read current index generation: %w", err)
+	}
+	return id, nil
+}
+
+// DiffIndexGenerations compares generation a (older) against b (newer)
+func (db *SQLiteDB) DiffIndexGenerations(a, b int64) (*IndexGenerationDiff, error) {
+	from, err := db.GetIndexGeneration(a)
+	if err != nil {
+		return nil, err
+	}
+	to, err := db.GetIndexGeneration(b)
+	if err != nil {
+		return nil, err
+	}
+
+	fromFiles, err := db.getGenerationFiles(a)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load generation %d: %w", a, err)
+	}
+	toFiles, err := db.getGenerationFiles(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load generation %d: %w", b, err)
+	}
+
+	diff := &IndexGenerationDiff{
+		From:       from,
+		To:         to,
+		ChunkDelta: to.ChunkCount - from.ChunkCount,
+	}
+	if from.VectorCount >= 0 && to.VectorCount >= 0 {
+		diff.VectorDelta = to.VectorCount - from.VectorCount
+	}
+
+	fromSymbols := make(map[string]bool)
+	toSymbols := make(map[string]bool)
+
+	for path, f := range toFiles {
+		for _, s := range f.Symbols {
+			toSymbols[path+":"+s] = true
+		}
+		old, exists := fromFiles[path]
+		switch {
+		case !exists:
+			diff.FilesAdded = append(diff.FilesAdded, path)
+		case old.Hash != f.Hash:
+			diff.FilesModified = append(diff.FilesModified, path)
+		}
+	}
+	for path, f := range fromFiles {
+		for _, s := range f.Symbols {
+			fromSymbols[path+":"+s] = true
+		}
+		if _, exists := toFiles[path]; !exists {
+			diff.FilesRemoved = append(diff.FilesRemoved, path)
+		}
+	}
+
+	for symbol := range toSymbols {
+		if !fromSymbols[symbol] {
+			diff.SymbolsAdded = append(diff.SymbolsAdded, symbol)
+		}
+	}
+	for symbol := range fromSymbols {
+		if !toSymbols[symbol] {
+			diff.SymbolsRemoved = append(diff.SymbolsRemoved, symbol)
+		}
+	}
+
+	sort.Strings(diff.FilesAdded)
+	sort.Strings(diff.FilesRemoved)
+	sort.Strings(diff.FilesModified)
+	sort.Strings(diff.SymbolsAdded)
+	sort.Strings(diff.SymbolsRemoved)
+
+	return diff, nil
+}
+
+// getGenerationFiles loads the per-file snapshot of a generation keyed by path
+func (db *SQLiteDB) getGenerationFiles(generationID int64) (map[string]*IndexGenerationFile, error) {
+	rows, err := db.db.Query(`
+		SELECT path, hash, chunk_count, symbols
+		FROM index_generation_files WHERE generation_id = ?`, generationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	files := make(map[string]*IndexGenerationFile)
+	for rows.Next() {
+		f := &IndexGenerationFile{}
+		var symbolsJSON string
+		if err := rows.Scan(&f.Path, &f.Hash, &f.ChunkCount, &symbolsJSON); err != nil {
+			return nil, err
+		}
+		if symbolsJSON != "" {
+			json.Unmarshal([]byte(symbolsJSON), &f.Symbols)
+		}
+		files[f.Path] = f
+	}
+	return files, rows.Err()
+}
+
+// snapshotIndexedFiles collects the current per-file state from the files,
+// functions and types tables. Chunk rows ("path#chunk_N") are folded into
+// their parent file's chunk count.
+func (db *SQLiteDB) snapshotIndexedFiles() ([]*IndexGenerationFile, error) {
+	rows, err := db.db.Query(`SELECT id, path, COALESCE(hash, '') FROM files ORDER BY path`)
+	if err != nil {
+		return nil, err
+	}
+
+	byPath := make(map[string]*IndexGenerationFile)
+	byID := make(map[int64]*IndexGenerationFile)
+	chunks := make(map[string]int)
+	for rows.Next() {
+		var id int64
+		var path, hash string
+		if err := rows.Scan(&id, &path, &hash); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if idx := strings.Index(path, chunkPathMarker); idx >= 0 {
+			chunks[path[:idx]]++
+			continue
+		}
+		f := &IndexGenerationFile{Path: path, Hash: hash}
+		byPath[path] = f
+		byID[id] = f
+	}
+	rows.Close()
+
+	for path, count := range chunks {
+		if f, exists := byPath[path]; exists {
+			f.ChunkCount = count
+		}
+	}
+
+	symbolRows, err := db.db.Query(`
+		SELECT file_id, name FROM functions
+		UNION ALL
+		SELECT file_id, name FROM types`)
+	if err != nil {
+		return nil, err
+	}
+	defer symbolRows.Close()
+
+	for symbolRows.Next() {
+		var fileID int64
+		var name string
+		if err := symbolRows.Scan(&fileID, &name); err != nil {
+			return nil, err
+		}
+		if f, exists := byID[fileID]; exists {
+			f.Symbols = append(f.Symbols, name)
+		}
+	}
+
+	files := make([]*IndexGenerationFile, 0, len(byPath))
+	for _, f := range byPath {
+		sort.Strings(f.Symbols)
+		files = append(files, f)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, symbolRows.Err()
+}
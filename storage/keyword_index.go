@@ -0,0 +1,241 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// BM25 parameters: term frequency saturation and length normalization
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// KeywordChunk is a chunk in the keyword index
+type KeywordChunk struct {
+	Ref       string `json:"ref"` // the chunk's row in files, path#chunk_N
+	Path      string `json:"path"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	Language  string `json:"language"`
+}
+
+// KeywordHit is a chunk matching a keyword search, scored with BM25
+type KeywordHit struct {
+	KeywordChunk
+	Content string   `json:"content"`
+	Score   float64  `json:"score"`
+	Matched []string `json:"matched"` // query terms found in the chunk
+}
+
+// IndexKeywordChunk replaces a chunk's terms in the keyword index
+func (db *SQLiteDB) IndexKeywordChunk(chunk *KeywordChunk, terms map[string]int) error {
+	return db.update(func(tx *sql.Tx) error {
+		return indexKeywordChunk(tx, chunk, terms)
+	})
+}
+
+// IndexKeywordChunk replaces a chunk's terms in the current batch
+func (w *BatchWriter) IndexKeywordChunk(chunk *KeywordChunk, terms map[string]int) error {
+	return w.update(func(tx *sql.Tx) error {
+		return indexKeywordChunk(tx, chunk, terms)
+	})
+}
+
+func indexKeywordChunk(tx *sql.Tx, chunk *KeywordChunk, terms map[string]int) error {
+	if _, err := tx.Exec(`DELETE FROM keyword_postings WHERE ref = ?`, chunk.Ref); err != nil {
+		return fmt.Errorf("failed to clear keywords of %s: %w", chunk.Ref, err)
+	}
+	length := 0
+	for term, tf := range terms {
+		length += tf
+		if _, err := tx.Exec(`INSERT INTO keyword_postings (term, ref, tf) VALUES (?, ?, ?)`, term, chunk.Ref, tf); err != nil {
+			return fmt.Errorf("failed to index keyword %s: %w", term, err)
+		}
+	}
+	if _, err := tx.Exec(`
+		INSERT OR REPLACE INTO keyword_chunks (ref, path, start_line, end_line, language, length)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		chunk.Ref, chunk.Path, chunk.StartLine, chunk.EndLine, chunk.Language, length); err != nil {
+		return fmt.Errorf("failed to index chunk %s: %w", chunk.Ref, err)
+	}
+	return nil
+}
+
+// DeleteKeywordChunks removes a file's chunks from the keyword index, before
+// the file is re-chunked or after it is deleted
+func (db *SQLiteDB) DeleteKeywordChunks(path string) error {
+	return db.update(func(tx *sql.Tx) error {
+		return deleteKeywordChunks(tx, path)
+	})
+}
+
+// DeleteKeywordChunks removes a file's chunks from the keyword index in the
+// current batch
+func (w *BatchWriter) DeleteKeywordChunks(path string) error {
+	return w.update(func(tx *sql.Tx) error {
+		return deleteKeywordChunks(tx, path)
+	})
+}
+
+func deleteKeywordChunks(tx *sql.Tx, path string) error {
+	if _, err := tx.Exec(`DELETE FROM keyword_postings WHERE ref IN (SELECT ref FROM keyword_chunks WHERE path = ?)`, path); err != nil {
+		return fmt.Errorf("failed to clear keywords of %s: %w", path, err)
+	}
+	if _, err := tx.Exec(`DELETE FROM keyword_chunks WHERE path = ?`, path); err != nil {
+		return fmt.Errorf("failed to clear keyword chunks of %s: %w", path, err)
+	}
+	return nil
+}
+
+// KeywordIndexSize returns the number of chunks in the keyword index
+func (db *SQLiteDB) KeywordIndexSize() (int, error) {
+	var count int
+	if err := db.db.QueryRow(`SELECT COUNT(*) FROM keyword_chunks`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count keyword chunks: %w", err)
+	}
+	return count, nil
+}
+
+// SearchKeywordIndex ranks the chunks containing any of terms with BM25 and
+// returns the best limit of them with their content
+func (db *SQLiteDB) SearchKeywordIndex(terms []string, limit int) ([]*KeywordHit, error) {
+	if len(terms) == 0 || limit <= 0 {
+		return nil, nil
+	}
+
+	var total int
+	var avgLength float64
+	if err := db.db.QueryRow(`SELECT COUNT(*), COALESCE(AVG(length), 0) FROM keyword_chunks`).Scan(&total, &avgLength); err != nil {
+		return nil, fmt.Errorf("failed to read keyword index size: %w", err)
+	}
+	if total == 0 {
+		return nil, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(terms)), ",")
+	args := make([]interface{}, len(terms))
+	for i, term := range terms {
+		args[i] = term
+	}
+	frequencies, err := db.documentFrequencies(placeholders, args)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.db.Query(`
+		SELECT p.term, p.ref, p.tf, c.length
+		FROM keyword_postings p JOIN keyword_chunks c ON c.ref = p.ref
+		WHERE p.term IN (`+placeholders+`)`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search keyword index: %w", err)
+	}
+	defer rows.Close()
+
+	scores := make(map[string]float64)
+	matched := make(map[string][]string)
+	for rows.Next() {
+		var term, ref string
+		var tf, length int
+		if err := rows.Scan(&term, &ref, &tf, &length); err != nil {
+			return nil, fmt.Errorf("failed to read keyword posting: %w", err)
+		}
+		df := float64(frequencies[term])
+		idf := math.Log(1 + (float64(total)-df+0.5)/(df+0.5))
+		norm := 1 - bm25B
+		if avgLength > 0 {
+			norm += bm25B * float64(length) / avgLength
+		}
+		scores[ref] += idf * float64(tf) * (bm25K1 + 1) / (float64(tf) + bm25K1*norm)
+		matched[ref] = append(matched[ref], term)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	refs := make([]string, 0, len(scores))
+	for ref := range scores {
+		refs = append(refs, ref)
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		if scores[refs[i]] != scores[refs[j]] {
+			return scores[refs[i]] > scores[refs[j]]
+		}
+		return refs[i] < refs[j]
+	})
+	if len(refs) > limit {
+		refs = refs[:limit]
+	}
+	if len(refs) == 0 {
+		return nil, nil
+	}
+
+	hits, err := db.keywordHits(refs)
+	if err != nil {
+		return nil, err
+	}
+	for _, hit := range hits {
+		hit.Score = scores[hit.Ref]
+		hit.Matched = matched[hit.Ref]
+		sort.Strings(hit.Matched)
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Ref < hits[j].Ref
+	})
+	return hits, nil
+}
+
+// documentFrequencies counts the chunks containing each term
+func (db *SQLiteDB) documentFrequencies(placeholders string, terms []interface{}) (map[string]int, error) {
+	rows, err := db.db.Query(`
+		SELECT term, COUNT(*) FROM keyword_postings
+		WHERE term IN (`+placeholders+`) GROUP BY term`, terms...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count keyword frequencies: %w", err)
+	}
+	defer rows.Close()
+
+	frequencies := make(map[string]int)
+	for rows.Next() {
+		var term string
+		var count int
+		if err := rows.Scan(&term, &count); err != nil {
+			return nil, fmt.Errorf("failed to read keyword frequency: %w", err)
+		}
+		frequencies[term] = count
+	}
+	return frequencies, rows.Err()
+}
+
+// keywordHits reads indexed chunks with the content stored for them
+func (db *SQLiteDB) keywordHits(refs []string) ([]*KeywordHit, error) {
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(refs)), ",")
+	args := make([]interface{}, len(refs))
+	for i, ref := range refs {
+		args[i] = ref
+	}
+	rows, err := db.db.Query(`
+		SELECT c.ref, c.path, c.start_line, c.end_line, c.language, COALESCE(f.content, '')
+		FROM keyword_chunks c LEFT JOIN files f ON f.path = c.ref
+		WHERE c.ref IN (`+placeholders+`)`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keyword chunks: %w", err)
+	}
+	defer rows.Close()
+
+	var hits []*KeywordHit
+	for rows.Next() {
+		hit := &KeywordHit{}
+		if err := rows.Scan(&hit.Ref, &hit.Path, &hit.StartLine, &hit.EndLine, &hit.Language, &hit.Content); err != nil {
+			return nil, fmt.Errorf("failed to read keyword chunk: %w", err)
+		}
+		hits = append(hits, hit)
+	}
+	return hits, rows.Err()
+}
@@ -0,0 +1,261 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LineRangeOptions shapes a line range read from the index
+type LineRangeOptions struct {
+	Context  int  `json:"context"`   // lines added on each side of the range
+	Expand   bool `json:"expand"`    // widen the range to the declarations it overlaps
+	MaxLines int  `json:"max_lines"` // cap on the range before context; 0 is unlimited
+}
+
+// LineRange is a span of an indexed file's lines. Start and End are the
+// range asked for, after expansion; Lines also holds the context around it
+// and begins at FirstLine.
+type LineRange struct {
+	Path          string   `json:"path"`
+	Language      string   `json:"language"`
+	Hash          string   `json:"hash"` // hash of the file as indexed
+	TotalLines    int      `json:"total_lines"`
+	Start         int      `json:"start"`
+	End           int      `json:"end"`
+	FirstLine     int      `json:"first_line"`
+	Lines         []string `json:"lines"`
+	Enclosing     string   `json:"enclosing,omitempty"` // innermost declaration containing the range
+	EnclosingKind string   `json:"enclosing_kind,omitempty"`
+	Expanded      bool     `json:"expanded"`  // the range grew to declaration boundaries
+	Truncated     bool     `json:"truncated"` // the range was cut at MaxLines
+}
+
+// LastLine returns the number of the last line in Lines
+func (r *LineRange) LastLine() int {
+	return r.FirstLine + len(r.Lines) - 1
+}
+
+// Range returns the lines from Start to End, without context
+func (r *LineRange) Range() []string {
+	return r.Lines[r.Start-r.FirstLine : r.End-r.FirstLine+1]
+}
+
+// declarationSpan is an indexed function or type of a file
+type declarationSpan struct {
+	name  string
+	kind  string
+	start int
+	end   int
+}
+
+// GetLineRange returns lines start to end of an indexed file, numbered from
+// 1; end 0 reads the single line start. The path may be a unique suffix of
+// an indexed path, such as a file name. With Expand the range grows to the
+// whole of every function or type it overlaps, doc comments included, so a
+// caller asking for "lines 80-140" gets complete declarations. Returns nil
+// if no indexed file matches.
+func (db *SQLiteDB) GetLineRange(path string, start, end int, options LineRangeOptions) (*LineRange, error) {
+	file, err := db.resolveIndexedFile(path)
+	if err != nil || file == nil {
+		return nil, err
+	}
+
+	lines := strings.Split(file.Content, "\n")
+	if len(lines) > 1 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	start = max(start, 1)
+	if end < start {
+		end = start
+	}
+	if start > len(lines) {
+		return nil, fmt.Errorf("line %d is past the end of %s (%d lines)", start, file.Path, len(lines))
+	}
+	end = min(end, len(lines))
+
+	declarations, err := db.fileDeclarations(file.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &LineRange{
+		Path:       file.Path,
+		Language:   file.Language,
+		Hash:       file.Hash,
+		TotalLines: len(lines),
+	}
+	if options.Expand {
+		expandedStart, expandedEnd := expandToDeclarations(lines, declarations, start, end)
+		result.Expanded = expandedStart != start || expandedEnd != end
+		start, end = expandedStart, expandedEnd
+	}
+	if options.MaxLines > 0 && end-start+1 > options.MaxLines {
+		end = start + options.MaxLines - 1
+		result.Truncated = true
+	}
+	if enclosing := innermostDeclaration(declarations, start, end); enclosing != nil {
+		result.Enclosing, result.EnclosingKind = enclosing.name, enclosing.kind
+	}
+
+	context := max(options.Context, 0)
+	result.Start, result.End = start, end
+	result.FirstLine = max(start-context, 1)
+	result.Lines = lines[result.FirstLine-1 : min(end+context, len(lines))]
+	return result, nil
+}
+
+// HasIndexedFile reports whether path names an indexed file, exactly or by
+// a path suffix that matches one file only
+func (db *SQLiteDB) HasIndexedFile(path string) (bool, error) {
+	file, err := db.resolveIndexedFile(path)
+	return file != nil, err
+}
+
+// resolveIndexedFile finds an indexed file by exact path, then by a path
+// suffix that matches one file only
+func (db *SQLiteDB) resolveIndexedFile(path string) (*CodeFile, error) {
+	path = strings.TrimPrefix(strings.TrimSpace(path), "./")
+	file, err := db.GetFile(path)
+	if err != nil || file != nil {
+		return file, err
+	}
+
+	rows, err := db.db.Query(`
+    SELECT id, path, hash, language, content FROM files
+    WHERE path LIKE '%/' || ? AND path NOT LIKE '%#chunk_%'
+    ORDER BY path LIMIT 10`, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find file %s: %w", path, err)
+	}
+	defer rows.Close()
+
+	var matches []*CodeFile
+	for rows.Next() {
+		var candidate CodeFile
+		if err := rows.Scan(&candidate.ID, &candidate.Path, &candidate.Hash, &candidate.Language, &candidate.Content); err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		// LIKE treats _ as a wildcard, so confirm the suffix
+		if strings.HasSuffix(candidate.Path, "/"+path) {
+			matches = append(matches, &candidate)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	switch len(matches) {
+	case 0:
+		return nil, nil
+	case 1:
+		return matches[0], nil
+	}
+	paths := make([]string, len(matches))
+	for i, match := range matches {
+		paths[i] = match.Path
+	}
+	return nil, fmt.Errorf("%s matches several indexed files: %s", path, strings.Join(paths, ", "))
+}
+
+// fileDeclarations returns the indexed functions and types of a file
+func (db *SQLiteDB) fileDeclarations(fileID int64) ([]declarationSpan, error) {
+	rows, err := db.db.Query(`
+    SELECT name, type, start_line, end_line FROM functions WHERE file_id = ?
+    UNION ALL
+    SELECT name, kind, start_line, end_line FROM types WHERE file_id = ?`, fileID, fileID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get declarations: %w", err)
+	}
+	defer rows.Close()
+
+	var declarations []declarationSpan
+	for rows.Next() {
+		var declaration declarationSpan
+		if err := rows.Scan(&declaration.name, &declaration.kind, &declaration.start, &declaration.end); err != nil {
+			return nil, fmt.Errorf("failed to read declaration: %w", err)
+		}
+		if declaration.start > 0 && declaration.end >= declaration.start {
+			declarations = append(declarations, declaration)
+		}
+	}
+	return declarations, rows.Err()
+}
+
+// expandToDeclarations widens start-end to cover every declaration it
+// overlaps and the comment block above the first. Files without indexed
+// declarations fall back to the enclosing top-level block by indentation.
+func expandToDeclarations(lines []string, declarations []declarationSpan, start, end int) (int, int) {
+	for _, declaration := range declarations {
+		if declaration.start <= end && declaration.end >= start {
+			start = min(start, declaration.start)
+			end = max(end, declaration.end)
+		}
+	}
+	if len(declarations) == 0 {
+		start, end = enclosingTopLevelBlock(lines, start, end)
+	}
+	end = min(end, len(lines))
+
+	for start > 1 && isCommentLine(lines[start-2]) {
+		start--
+	}
+	return start, end
+}
+
+// enclosingTopLevelBlock returns the block of lines from the nearest
+// unindented line at or above start to the line before the next one
+// below end. The range is kept when no unindented line precedes it.
+func enclosingTopLevelBlock(lines []string, start, end int) (int, int) {
+	top := start
+	for top >= 1 && !isTopLevelLine(lines[top-1]) {
+		top--
+	}
+	if top < 1 {
+		return start, end
+	}
+	bottom := end + 1
+	for bottom <= len(lines) && !isTopLevelLine(lines[bottom-1]) {
+		bottom++
+	}
+	bottom--
+	for bottom > end && strings.TrimSpace(lines[bottom-1]) == "" {
+		bottom--
+	}
+	return top, bottom
+}
+
+// isTopLevelLine reports whether a line starts a top-level block: it is
+// unindented and neither blank, a comment nor a closing bracket
+func isTopLevelLine(line string) bool {
+	if line == "" || line[0] == ' ' || line[0] == '\t' || isCommentLine(line) {
+		return false
+	}
+	return !strings.ContainsRune("}])", rune(line[0]))
+}
+
+// isCommentLine reports whether a line is a comment or a decorator, which
+// belong to the declaration below them
+func isCommentLine(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	for _, prefix := range []string{"//", "/*", "*", "#", "--", "@"} {
+		if strings.HasPrefix(trimmed, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// innermostDeclaration returns the smallest declaration containing
+// start-end, or nil
+func innermostDeclaration(declarations []declarationSpan, start, end int) *declarationSpan {
+	var innermost *declarationSpan
+	for i := range declarations {
+		declaration := &declarations[i]
+		if declaration.start > start || declaration.end < end {
+			continue
+		}
+		if innermost == nil || declaration.end-declaration.start < innermost.end-innermost.start {
+			innermost = declaration
+		}
+	}
+	return innermost
+}
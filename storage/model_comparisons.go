@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Preferences recorded for a model comparison
+const (
+	PreferenceA   = "a"
+	PreferenceB   = "b"
+	PreferenceTie = "tie"
+)
+
+// ModelComparisonRecord is one query answered by two models
+type ModelComparisonRecord struct {
+	ID            int64         `json:"id"`
+	Query         string        `json:"query"`
+	ModelA        string        `json:"model_a"`
+	ModelB        string        `json:"model_b"`
+	LatencyA      time.Duration `json:"latency_a"`
+	LatencyB      time.Duration `json:"latency_b"`
+	CostA         float64       `json:"cost_a"`
+	CostB         float64       `json:"cost_b"`
+	SharedSources int           `json:"shared_sources"` // sources both answers cited
+	Preferred     string        `json:"preferred"`      // a, b, tie, or empty if not rated
+	CreatedAt     time.Time     `json:"created_at"`
+}
+
+// ModelPreferenceStats is how one model fared across rated comparisons
+type ModelPreferenceStats struct {
+	Model       string        `json:"model"`
+	Comparisons int           `json:"comparisons"`
+	Wins        int           `json:"wins"`
+	Losses      int           `json:"losses"`
+	Ties        int           `json:"ties"`
+	AvgLatency  time.Duration `json:"avg_latency"`
+	AvgCost     float64       `json:"avg_cost"`
+}
+
+// WinRate is the share of comparisons the model won, counting ties as half
+func (s *ModelPreferenceStats) WinRate() float64 {
+	if s.Comparisons == 0 {
+		return 0
+	}
+	return (float64(s.Wins) + float64(s.Ties)/2) / float64(s.Comparisons)
+}
+
+// SaveModelComparison stores a comparison and sets its ID
+func (db *SQLiteDB) SaveModelComparison(record *ModelComparisonRecord) error {
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = time.Now()
+	}
+	result, err := db.db.Exec(`
+		INSERT INTO model_comparisons
+			(query, model_a, model_b, latency_a_ms, latency_b_ms, cost_a, cost_b, shared_sources, preferred, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		record.Query, record.ModelA, record.ModelB, record.LatencyA.Milliseconds(), record.LatencyB.Milliseconds(),
+		record.CostA, record.CostB, record.SharedSources, record.Preferred, record.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save model comparison: %w", err)
+	}
+	record.ID, err = result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to read model comparison id: %w", err)
+	}
+	return nil
+}
+
+// SetModelPreference records which answer of a comparison the user preferred
+func (db *SQLiteDB) SetModelPreference(id int64, preferred string) error {
+	switch preferred {
+	case PreferenceA, PreferenceB, PreferenceTie:
+	default:
+		return fmt.Errorf("unknown preference %q (want a, b or tie)", preferred)
+	}
+	result, err := db.db.Exec(`UPDATE model_comparisons SET preferred = ? WHERE id = ?`, preferred, id)
+	if err != nil {
+		return fmt.Errorf("failed to record preference for comparison %d: %w", id, err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return fmt.Errorf("no model comparison %d", id)
+	}
+	return nil
+}
+
+// ModelPreferenceStats sums the rated comparisons per model, best win rate
+// first
+func (db *SQLiteDB) ModelPreferenceStats() ([]*ModelPreferenceStats, error) {
+	rows, err := db.db.Query(`
+		SELECT model_a, model_b, latency_a_ms, latency_b_ms, cost_a, cost_b, preferred
+		FROM model_comparisons WHERE preferred != ''`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read model comparisons: %w", err)
+	}
+	defer rows.Close()
+
+	byModel := make(map[string]*ModelPreferenceStats)
+	latency := make(map[string]int64)
+	add := func(model string, latencyMs int64, cost float64, outcome string) {
+		stats, ok := byModel[model]
+		if !ok {
+			stats = &ModelPreferenceStats{Model: model}
+			byModel[model] = stats
+		}
+		stats.Comparisons++
+		switch outcome {
+		case "win":
+			stats.Wins++
+		case "loss":
+			stats.Losses++
+		default:
+			stats.Ties++
+		}
+		latency[model] += latencyMs
+		stats.AvgCost += cost
+	}
+	for rows.Next() {
+		var modelA, modelB, preferred string
+		var latencyA, latencyB int64
+		var costA, costB float64
+		if err := rows.Scan(&modelA, &modelB, &latencyA, &latencyB, &costA, &costB, &preferred); err != nil {
+			return nil, fmt.Errorf("failed to scan model comparison: %w", err)
+		}
+		outcomeA, outcomeB := "tie", "tie"
+		switch preferred {
+		case PreferenceA:
+			outcomeA, outcomeB = "win", "loss"
+		case PreferenceB:
+			outcomeA, outcomeB = "loss", "win"
+		}
+		add(modelA, latencyA, costA, outcomeA)
+		add(modelB, latencyB, costB, outcomeB)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read model comparisons: %w", err)
+	}
+
+	stats := make([]*ModelPreferenceStats, 0, len(byModel))
+	for model, s := range byModel {
+		s.AvgLatency = time.Duration(latency[model]/int64(s.Comparisons)) * time.Millisecond
+		s.AvgCost /= float64(s.Comparisons)
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].WinRate() != stats[j].WinRate() {
+			return stats[i].WinRate() > stats[j].WinRate()
+		}
+		return stats[i].Model < stats[j].Model
+	})
+	return stats, nil
+}
@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// PackageSummaryRecord is the persisted summary of one package directory
+type PackageSummaryRecord struct {
+	Dir          string            `json:"dir"`
+	Package      string            `json:"package,omitempty"`
+	Summary      string            `json:"summary"`
+	FileStamps   map[string]string `json:"file_stamps"` // path -> "size:mtime" when summarized
+	SummarizedAt time.Time         `json:"summarized_at"`
+}
+
+// SavePackageSummary inserts or replaces a package summary
+func (db *SQLiteDB) SavePackageSummary(record *PackageSummaryRecord) error {
+	stamps, err := json.Marshal(record.FileStamps)
+	if err != nil {
+		return fmt.Errorf("invalid file stamps: %w", err)
+	}
+	_, err = db.db.Exec(`
+		INSERT OR REPLACE INTO package_summaries (dir, package, summary, file_stamps, summarized_at)
+		VALUES (?, ?, ?, ?, ?)`,
+		record.Dir, record.Package, record.Summary, string(stamps), record.SummarizedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save summary of %s: %w", record.Dir, err)
+	}
+	return nil
+}
+
+// GetPackageSummary returns the summary of a package directory, or nil if
+// it has none
+func (db *SQLiteDB) GetPackageSummary(dir string) (*PackageSummaryRecord, error) {
+	record, err := scanPackageSummary(db.db.QueryRow(`
+		SELECT dir, package, summary, file_stamps, summarized_at
+		FROM package_summaries WHERE dir = ?`, dir))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read summary of %s: %w", dir, err)
+	}
+	return record, nil
+}
+
+// ListPackageSummaries returns every package summary by directory
+func (db *SQLiteDB) ListPackageSummaries() ([]*PackageSummaryRecord, error) {
+	rows, err := db.db.Query(`
+		SELECT dir, package, summary, file_stamps, summarized_at
+		FROM package_summaries ORDER BY dir`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list package summaries: %w", err)
+	}
+	defer rows.Close()
+
+	var records []*PackageSummaryRecord
+	for rows.Next() {
+		record, err := scanPackageSummary(rows)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+// DeletePackageSummary removes the summary of a package that no longer has
+// indexed files
+func (db *SQLiteDB) DeletePackageSummary(dir string) error {
+	_, err := db.db.Exec(`DELETE FROM package_summaries WHERE dir = ?`, dir)
+	return err
+}
+
+// IndexedFilesByDir returns the indexed file paths grouped by directory
+func (db *SQLiteDB) IndexedFilesByDir() (map[string][]string, error) {
+	rows, err := db.db.Query(`SELECT path FROM files WHERE path NOT LIKE '%#chunk_%' ORDER BY path`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list indexed files: %w", err)
+	}
+	defer rows.Close()
+
+	byDir := make(map[string][]string)
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return nil, err
+		}
+		dir := filepath.Dir(path)
+		byDir[dir] = append(byDir[dir], path)
+	}
+	for _, paths := range byDir {
+		sort.Strings(paths)
+	}
+	return byDir, rows.Err()
+}
+
+func scanPackageSummary(row rowScanner) (*PackageSummaryRecord, error) {
+	var (
+		record PackageSummaryRecord
+		pkg    sql.NullString
+		stamps string
+	)
+	if err := row.Scan(&record.Dir, &pkg, &record.Summary, &stamps, &record.SummarizedAt); err != nil {
+		return nil, err
+	}
+	record.Package = pkg.String
+	if err := json.Unmarshal([]byte(stamps), &record.FileStamps); err != nil {
+		return nil, fmt.Errorf("invalid file stamps for %s: %w", record.Dir, err)
+	}
+	return &record, nil
+}
@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ProvenanceRecord is a region of a file written by applying a generated
+// change
+type ProvenanceRecord struct {
+	ID         int64     `json:"id"`
+	File       string    `json:"file"`
+	StartLine  int       `json:"start_line"`
+	EndLine    int       `json:"end_line"`    // StartLine - 1 for deletions
+	ChangeType string    `json:"change_type"` // add, replace or delete
+	Content    string    `json:"content"`     // the lines written
+	ResponseID string    `json:"response_id"`
+	QueryID    string    `json:"query_id,omitempty"`
+	Provider   string    `json:"provider,omitempty"`
+	Model      string    `json:"model,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ProvenanceFile summarizes the recorded regions of one file
+type ProvenanceFile struct {
+	File      string    `json:"file"`
+	Regions   int       `json:"regions"`
+	Lines     int       `json:"lines"`
+	Responses int       `json:"responses"`
+	LastAt    time.Time `json:"last_at"`
+}
+
+// RecordProvenance appends regions to the provenance ledger
+func (db *SQLiteDB) RecordProvenance(records []*ProvenanceRecord) error {
+	tx, err := db.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin provenance update: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, record := range records {
+		if record.CreatedAt.IsZero() {
+			record.CreatedAt = time.Now()
+		}
+		result, err := tx.Exec(`
+			INSERT INTO provenance (file, start_line, end_line, change_type, content, response_id, query_id, provider, model, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			record.File, record.StartLine, record.EndLine, record.ChangeType, record.Content,
+			record.ResponseID, record.QueryID, record.Provider, record.Model, record.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to record provenance of %s:%d: %w", record.File, record.StartLine, err)
+		}
+		record.ID, _ = result.LastInsertId()
+	}
+	return tx.Commit()
+}
+
+// ProvenanceForFile returns the recorded regions of file, newest first
+func (db *SQLiteDB) ProvenanceForFile(file string) ([]*ProvenanceRecord, error) {
+	rows, err := db.db.Query(`
+		SELECT id, file, start_line, end_line, change_type, content, response_id, query_id, provider, model, created_at
+		FROM provenance WHERE file = ? ORDER BY created_at DESC, id DESC`, file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read provenance of %s: %w", file, err)
+	}
+	defer rows.Close()
+
+	var records []*ProvenanceRecord
+	for rows.Next() {
+		var record ProvenanceRecord
+		if err := rows.Scan(&record.ID, &record.File, &record.StartLine, &record.EndLine, &record.ChangeType, &record.Content,
+			&record.ResponseID, &record.QueryID, &record.Provider, &record.Model, &record.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan provenance: %w", err)
+		}
+		records = append(records, &record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read provenance of %s: %w", file, err)
+	}
+	return records, nil
+}
+
+// ProvenanceFiles lists the files with recorded regions, most recently
+// changed first
+func (db *SQLiteDB) ProvenanceFiles() ([]*ProvenanceFile, error) {
+	rows, err := db.db.Query(`SELECT file, start_line, end_line, response_id, created_at FROM provenance`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list provenance: %w", err)
+	}
+	defer rows.Close()
+
+	byFile := make(map[string]*ProvenanceFile)
+	responses := make(map[string]map[string]bool)
+	for rows.Next() {
+		var file, responseID string
+		var start, end int
+		var createdAt time.Time
+		if err := rows.Scan(&file, &start, &end, &responseID, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan provenance: %w", err)
+		}
+		summary, ok := byFile[file]
+		if !ok {
+			summary = &ProvenanceFile{File: file}
+			byFile[file] = summary
+			responses[file] = make(map[string]bool)
+		}
+		summary.Regions++
+		summary.Lines += max(end-start+1, 0)
+		responses[file][responseID] = true
+		if createdAt.After(summary.LastAt) {
+			summary.LastAt = createdAt
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list provenance: %w", err)
+	}
+
+	files := make([]*ProvenanceFile, 0, len(byFile))
+	for file, summary := range byFile {
+		summary.Responses = len(responses[file])
+		files = append(files, summary)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].LastAt.After(files[j].LastAt) })
+	return files, nil
+}
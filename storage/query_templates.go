@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// templateParam matches $name or ${name} in a template's query
+var templateParam = regexp.MustCompile(`\$(?:\{([A-Za-z_][A-Za-z0-9_]*)\}|([A-Za-z_][A-Za-z0-9_]*))`)
+
+// QueryTemplate is a saved query whose $parameters are filled in when it runs
+type QueryTemplate struct {
+	Project     string    `json:"project"` // project root the template belongs to
+	Name        string    `json:"name"`
+	Query       string    `json:"query"`
+	Description string    `json:"description,omitempty"`
+	Runs        int       `json:"runs"`
+	LastRunAt   time.Time `json:"last_run_at,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Params returns the parameters the template's query takes, in order of
+// first use
+func (t *QueryTemplate) Params() []string {
+	var params []string
+	seen := make(map[string]bool)
+	for _, match := range templateParam.FindAllStringSubmatch(t.Query, -1) {
+		if name := match[1] + match[2]; !seen[name] {
+			seen[name] = true
+			params = append(params, name)
+		}
+	}
+	return params
+}
+
+// Expand returns the query with its parameters replaced by values
+func (t *QueryTemplate) Expand(values map[string]string) string {
+	return templateParam.ReplaceAllStringFunc(t.Query, func(match string) string {
+		groups := templateParam.FindStringSubmatch(match)
+		return values[groups[1]+groups[2]]
+	})
+}
+
+// SaveQueryTemplate inserts a template or replaces the query and
+// description of one with the same name, keeping its run count
+func (db *SQLiteDB) SaveQueryTemplate(template *QueryTemplate) error {
+	now := time.Now()
+	_, err := db.db.Exec(`
+		INSERT INTO query_templates (project, name, query, description, runs, created_at, updated_at)
+		VALUES (?, ?, ?, ?, 0, ?, ?)
+		ON CONFLICT(project, name) DO UPDATE SET query = excluded.query,
+			description = excluded.description, updated_at = excluded.updated_at`,
+		template.Project, template.Name, template.Query, template.Description, now, now)
+	if err != nil {
+		return fmt.Errorf("failed to save template %s: %w", template.Name, err)
+	}
+	return nil
+}
+
+// GetQueryTemplate returns a project's template by name, matched
+// case-insensitively, or nil if there is none
+func (db *SQLiteDB) GetQueryTemplate(project, name string) (*QueryTemplate, error) {
+	template, err := scanQueryTemplate(db.db.QueryRow(`
+		SELECT project, name, query, description, runs, last_run_at, created_at, updated_at
+		FROM query_templates WHERE project = ? AND name = ?`, project, name))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template %s: %w", name, err)
+	}
+	return template, nil
+}
+
+// ListQueryTemplates returns a project's templates, alphabetically
+func (db *SQLiteDB) ListQueryTemplates(project string) ([]*QueryTemplate, error) {
+	rows, err := db.db.Query(`
+		SELECT project, name, query, description, runs, last_run_at, created_at, updated_at
+		FROM query_templates WHERE project = ? ORDER BY name`, project)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+	defer rows.Close()
+
+	var templates []*QueryTemplate
+	for rows.Next() {
+		template, err := scanQueryTemplate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template: %w", err)
+		}
+		templates = append(templates, template)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+	return templates, nil
+}
+
+// DeleteQueryTemplate removes a template and reports whether it existed
+func (db *SQLiteDB) DeleteQueryTemplate(project, name string) (bool, error) {
+	result, err := db.db.Exec(`DELETE FROM query_templates WHERE project = ? AND name = ?`, project, name)
+	if err != nil {
+		return false, fmt.Errorf("failed to remove template %s: %w", name, err)
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// RecordQueryTemplateRun counts a run of a template
+func (db *SQLiteDB) RecordQueryTemplateRun(project, name string) error {
+	_, err := db.db.Exec(`UPDATE query_templates SET runs = runs + 1, last_run_at = ? WHERE project = ? AND name = ?`,
+		time.Now(), project, name)
+	if err != nil {
+		return fmt.Errorf("failed to record run of template %s: %w", name, err)
+	}
+	return nil
+}
+
+func scanQueryTemplate(row rowScanner) (*QueryTemplate, error) {
+	template := &QueryTemplate{}
+	var lastRun sql.NullTime
+	if err := row.Scan(&template.Project, &template.Name, &template.Query, &template.Description,
+		&template.Runs, &lastRun, &template.CreatedAt, &template.UpdatedAt); err != nil {
+		return nil, err
+	}
+	template.LastRunAt = lastRun.Time
+	return template, nil
+}
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SymbolReference is a line of indexed source that names a symbol
+type SymbolReference struct {
+	File string `json:"file"`
+	Line int    `json:"line"`
+	Text string `json:"text"` // the line, trimmed
+}
+
+// FindReferences returns the lines of indexed files naming name as a whole
+// word, case-sensitively, up to limit (0 is unlimited). Definitions are
+// included; the caller tells them apart with LookupSymbol.
+func (db *SQLiteDB) FindReferences(name string, limit int) ([]*SymbolReference, error) {
+	if name == "" {
+		return nil, nil
+	}
+	rows, err := db.db.Query(`
+    SELECT path, content FROM files
+    WHERE instr(content, ?) > 0 AND path NOT LIKE '%#chunk_%'
+    ORDER BY path`, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find references to %s: %w", name, err)
+	}
+	defer rows.Close()
+
+	var references []*SymbolReference
+	for rows.Next() {
+		var path, content string
+		if err := rows.Scan(&path, &content); err != nil {
+			return nil, fmt.Errorf("failed to read file content: %w", err)
+		}
+		for i, line := range strings.Split(content, "\n") {
+			if !containsWord(line, name) {
+				continue
+			}
+			references = append(references, &SymbolReference{File: path, Line: i + 1, Text: strings.TrimSpace(line)})
+			if limit > 0 && len(references) == limit {
+				return references, nil
+			}
+		}
+	}
+	return references, rows.Err()
+}
+
+// containsWord reports whether word occurs in line not as part of a longer
+// identifier
+func containsWord(line, word string) bool {
+	for offset := 0; ; {
+		i := strings.Index(line[offset:], word)
+		if i < 0 {
+			return false
+		}
+		start, end := offset+i, offset+i+len(word)
+		before, _ := utf8.DecodeLastRuneInString(line[:start])
+		after, _ := utf8.DecodeRuneInString(line[end:])
+		if (start == 0 || !isIdentRune(before)) && (end == len(line) || !isIdentRune(after)) {
+			return true
+		}
+		offset = start + 1
+	}
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
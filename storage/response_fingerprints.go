@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ResponseFingerprint is the configuration snapshot an answer was produced
+// with. Snapshots are stored once per fingerprint and shared by answers.
+type ResponseFingerprint struct {
+	ResponseID  string    `json:"response_id"`
+	Fingerprint string    `json:"fingerprint"`
+	Snapshot    string    `json:"snapshot"` // JSON of fingerprint.Snapshot
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// SaveResponseFingerprint records the fingerprint of an answer and, the
+// first time it is seen, the snapshot behind it
+func (db *SQLiteDB) SaveResponseFingerprint(responseID, fingerprint, snapshot string) error {
+	tx, err := db.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin fingerprint update: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if _, err := tx.Exec(`INSERT OR IGNORE INTO config_snapshots (fingerprint, snapshot, created_at) VALUES (?, ?, ?)`,
+		fingerprint, snapshot, now); err != nil {
+		return fmt.Errorf("failed to save config snapshot: %w", err)
+	}
+	if _, err := tx.Exec(`INSERT OR REPLACE INTO response_fingerprints (response_id, fingerprint, created_at) VALUES (?, ?, ?)`,
+		responseID, fingerprint, now); err != nil {
+		return fmt.Errorf("failed to save fingerprint of %s: %w", responseID, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit fingerprint update: %w", err)
+	}
+	return nil
+}
+
+// GetResponseFingerprint returns the fingerprint and snapshot of an
+// answer, or nil if none was recorded
+func (db *SQLiteDB) GetResponseFingerprint(responseID string) (*ResponseFingerprint, error) {
+	record := &ResponseFingerprint{}
+	err := db.db.QueryRow(`
+		SELECT r.response_id, r.fingerprint, s.snapshot, r.created_at
+		FROM response_fingerprints r JOIN config_snapshots s ON s.fingerprint = r.fingerprint
+		WHERE r.response_id = ?`, responseID).
+		Scan(&record.ResponseID, &record.Fingerprint, &record.Snapshot, &record.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fingerprint of %s: %w", responseID, err)
+	}
+	return record, nil
+}
+
+// LatestResponseFingerprint returns the most recently recorded answer
+// fingerprint, or nil if there is none
+func (db *SQLiteDB) LatestResponseFingerprint() (*ResponseFingerprint, error) {
+	var responseID string
+	err := db.db.QueryRow(`SELECT response_id FROM response_fingerprints ORDER BY created_at DESC LIMIT 1`).Scan(&responseID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the latest fingerprint: %w", err)
+	}
+	return db.GetResponseFingerprint(responseID)
+}
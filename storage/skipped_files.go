@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// Reasons a file was left out of the index or indexed in part
+const (
+	SkipReasonBinary     = "binary"     // not indexed
+	SkipReasonOversized  = "oversized"  // over the size cap and not indexed
+	SkipReasonTruncated  = "truncated"  // only the start of the file indexed
+	SkipReasonSignatures = "signatures" // only its declarations indexed
+)
+
+// SkippedFile is a project file indexing left out or indexed in part
+type SkippedFile struct {
+	Path      string    `json:"path"`
+	Reason    string    `json:"reason"`
+	Size      int64     `json:"size"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// UpdateSkippedFiles records the files an indexing run skipped and forgets
+// the ones it indexed whole, so the list reflects the latest run of each
+// file
+func (db *SQLiteDB) UpdateSkippedFiles(skipped []*SkippedFile, indexed []string) error {
+	if len(skipped) == 0 && len(indexed) == 0 {
+		return nil
+	}
+	tx, err := db.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin skipped files transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, path := range indexed {
+		if _, err := tx.Exec(`DELETE FROM skipped_files WHERE path = ?`, path); err != nil {
+			return fmt.Errorf("failed to clear skipped file %s: %w", path, err)
+		}
+	}
+	now := time.Now()
+	for _, file := range skipped {
+		if _, err := tx.Exec(`
+			INSERT INTO skipped_files (path, reason, size, updated_at) VALUES (?, ?, ?, ?)
+			ON CONFLICT(path) DO UPDATE SET reason = excluded.reason, size = excluded.size,
+				updated_at = excluded.updated_at`,
+			file.Path, file.Reason, file.Size, now); err != nil {
+			return fmt.Errorf("failed to record skipped file %s: %w", file.Path, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit skipped files: %w", err)
+	}
+	return nil
+}
+
+// ListSkippedFiles returns the files left out of the index or indexed in
+// part, largest first
+func (db *SQLiteDB) ListSkippedFiles() ([]*SkippedFile, error) {
+	rows, err := db.db.Query(`SELECT path, reason, size, updated_at FROM skipped_files ORDER BY size DESC, path`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list skipped files: %w", err)
+	}
+	defer rows.Close()
+
+	var files []*SkippedFile
+	for rows.Next() {
+		file := &SkippedFile{}
+		if err := rows.Scan(&file.Path, &file.Reason, &file.Size, &file.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to read skipped file: %w", err)
+		}
+		files = append(files, file)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list skipped files: %w", err)
+	}
+	return files, nil
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -17,6 +19,10 @@ import (
 type SQLiteDB struct {
 	db   *sql.DB
 	path string
+
+	// Prepared statements for the hot indexing writes, reused across calls
+	stmtMu sync.Mutex
+	stmts  map[string]*sql.Stmt
 }
 
 // CodeFile represents a code file in the database
@@ -73,8 +79,10 @@ func NewSQLiteDB(dbPath string) (*SQLiteDB, error) {
 		return nil, fmt.Errorf("failed to create database directory: %w", err)
 	}
 
-	// Open database connection
-	db, err := sql.Open("sqlite3", dbPath+"?_foreign_keys=on&_journal_mode=WAL")
+	// Open database connection. WAL lets query-time reads proceed while
+	// indexing writes; synchronous=NORMAL is durable under WAL, and the busy
+	// timeout makes concurrent writers wait instead of failing.
+	db, err := sql.Open("sqlite3", dbPath+"?_foreign_keys=on&_journal_mode=WAL&_synchronous=NORMAL&_busy_timeout=5000")
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -85,8 +93,9 @@ func NewSQLiteDB(dbPath string) (*SQLiteDB, error) {
 	db.SetConnMaxLifetime(time.Hour)
 
 	sqliteDB := &SQLiteDB{
-		db:   db,
-		path: dbPath,
+		db:    db,
+		path:  dbPath,
+		stmts: make(map[string]*sql.Stmt),
 	}
 
 	// Initialize schema
@@ -265,8 +274,312 @@ func (db *SQLiteDB) initSchema() error {
         updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
     );
 
+    -- Index generations (snapshots taken after each indexing run)
+    CREATE TABLE IF NOT EXISTS index_generations (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        created_at DATETIME NOT NULL,
+        file_count INTEGER DEFAULT 0,
+        chunk_count INTEGER DEFAULT 0,
+        symbol_count INTEGER DEFAULT 0,
+        vector_count INTEGER DEFAULT -1,
+        notes TEXT DEFAULT ''
+    );
+
+    CREATE TABLE IF NOT EXISTS index_generation_files (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        generation_id INTEGER NOT NULL,
+        path TEXT NOT NULL,
+        hash TEXT,
+        chunk_count INTEGER DEFAULT 0,
+        symbols TEXT, -- JSON array of function/type names
+        FOREIGN KEY (generation_id) REFERENCES index_generations(id) ON DELETE CASCADE
+    );
+
+    CREATE TABLE IF NOT EXISTS file_access (
+        path TEXT PRIMARY KEY,
+        score REAL DEFAULT 0, -- decayed access weight, see RecordFileAccess
+        access_count INTEGER DEFAULT 0,
+        last_access DATETIME
+    );
+
+    CREATE TABLE IF NOT EXISTS chunk_access (
+        path TEXT NOT NULL,
+        line INTEGER NOT NULL, -- first line of the chunk
+        symbol TEXT DEFAULT '',
+        retrieved INTEGER DEFAULT 0, -- answers the chunk was retrieved for
+        cited INTEGER DEFAULT 0, -- answers that cited it
+        score REAL DEFAULT 0, -- decayed access weight, see RecordChunkAccess
+        last_access DATETIME,
+        PRIMARY KEY (path, line)
+    );
+
+    -- Keyword index searched when no vector database is configured; see
+    -- internal/keyword for how chunks become terms
+    CREATE TABLE IF NOT EXISTS keyword_chunks (
+        ref TEXT PRIMARY KEY, -- the chunk's row in files (path#chunk_N)
+        path TEXT NOT NULL,
+        start_line INTEGER DEFAULT 0,
+        end_line INTEGER DEFAULT 0,
+        language TEXT DEFAULT '',
+        length INTEGER DEFAULT 0 -- terms in the chunk, for BM25 length normalization
+    );
+
+    CREATE TABLE IF NOT EXISTS keyword_postings (
+        term TEXT NOT NULL,
+        ref TEXT NOT NULL,
+        tf INTEGER NOT NULL, -- occurrences of the term in the chunk
+        PRIMARY KEY (term, ref)
+    );
+
+    -- Users of a shared server instance; tokens are stored as SHA-256 hashes
+    CREATE TABLE IF NOT EXISTS users (
+        id TEXT PRIMARY KEY,
+        name TEXT UNIQUE NOT NULL,
+        token_hash TEXT UNIQUE NOT NULL,
+        daily_budget REAL DEFAULT 0, -- 0 means unlimited
+        monthly_budget REAL DEFAULT 0,
+        personal_feedback BOOLEAN DEFAULT FALSE,
+        disabled BOOLEAN DEFAULT FALSE,
+        created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+    );
+
+    CREATE TABLE IF NOT EXISTS user_cost_ledger (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        user_id TEXT NOT NULL,
+        query_id TEXT,
+        session_id TEXT,
+        provider TEXT,
+        tokens INTEGER DEFAULT 0,
+        cost REAL DEFAULT 0,
+        created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    );
+
+    CREATE TABLE IF NOT EXISTS user_feedback (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        user_id TEXT NOT NULL, -- 'shared' when the user pools feedback with the team
+        query_id TEXT,
+        rating INTEGER,
+        helpful BOOLEAN,
+        comments TEXT,
+        created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+    );
+
+    -- Background tasks; checkpoint is handler-defined JSON used to resume
+    CREATE TABLE IF NOT EXISTS tasks (
+        id TEXT PRIMARY KEY,
+        kind TEXT NOT NULL,
+        description TEXT,
+        status TEXT NOT NULL,
+        input TEXT,
+        checkpoint TEXT,
+        done INTEGER DEFAULT 0,
+        total INTEGER DEFAULT 0,
+        message TEXT,
+        result TEXT,
+        error TEXT,
+        created_at DATETIME NOT NULL,
+        updated_at DATETIME NOT NULL
+    );
+
+    -- Package summaries; file_stamps is the JSON {path: "size:mtime"} of the
+    -- files summarized, compared with disk to measure change since
+    CREATE TABLE IF NOT EXISTS package_summaries (
+        dir TEXT PRIMARY KEY,
+        package TEXT,
+        summary TEXT NOT NULL,
+        file_stamps TEXT NOT NULL,
+        summarized_at DATETIME NOT NULL
+    );
+
+    -- Project glossary, see glossaryTable
+    ` + glossaryTable + `
+
+    -- Answers of two models to the same query, and which one the user preferred
+    CREATE TABLE IF NOT EXISTS model_comparisons (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        query TEXT NOT NULL,
+        model_a TEXT NOT NULL,
+        model_b TEXT NOT NULL,
+        latency_a_ms INTEGER DEFAULT 0,
+        latency_b_ms INTEGER DEFAULT 0,
+        cost_a REAL DEFAULT 0,
+        cost_b REAL DEFAULT 0,
+        shared_sources INTEGER DEFAULT 0,
+        preferred TEXT DEFAULT '', -- a, b, tie, or empty until the user answers
+        created_at DATETIME NOT NULL
+    );
+
+    -- Comments marked TODO, FIXME or HACK, found while indexing and replaced
+    -- per file; author and committed_at come from git blame when available
+    CREATE TABLE IF NOT EXISTS todos (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        file TEXT NOT NULL,
+        line INTEGER NOT NULL,
+        kind TEXT NOT NULL,
+        text TEXT NOT NULL,
+        package TEXT DEFAULT '',
+        context TEXT DEFAULT '',
+        context_start INTEGER DEFAULT 0,
+        author TEXT DEFAULT '',
+        committed_at DATETIME,
+        indexed_at DATETIME NOT NULL
+    );
+
+    -- Interfaces declared in the project and the concrete types whose method
+    -- sets satisfy them, computed by type-checking after each indexing run
+    CREATE TABLE IF NOT EXISTS interfaces (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        name TEXT NOT NULL,
+        package TEXT NOT NULL, -- import path
+        file TEXT NOT NULL,
+        line INTEGER NOT NULL,
+        methods TEXT DEFAULT '[]',
+        indexed_at DATETIME NOT NULL
+    );
+
+    CREATE TABLE IF NOT EXISTS implementations (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        interface_id INTEGER NOT NULL,
+        type TEXT NOT NULL,
+        package TEXT NOT NULL,
+        file TEXT NOT NULL,
+        line INTEGER NOT NULL,
+        pointer INTEGER DEFAULT 0, -- only the pointer type satisfies the interface
+        FOREIGN KEY (interface_id) REFERENCES interfaces(id) ON DELETE CASCADE
+    );
+
+    -- Lines written by applying generated changes, with the answer that
+    -- produced them; content locates a region after the file shifts
+    CREATE TABLE IF NOT EXISTS provenance (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        file TEXT NOT NULL,
+        start_line INTEGER NOT NULL,
+        end_line INTEGER NOT NULL, -- start_line - 1 for deletions
+        change_type TEXT NOT NULL,
+        content TEXT DEFAULT '',
+        response_id TEXT NOT NULL,
+        query_id TEXT DEFAULT '',
+        provider TEXT DEFAULT '',
+        model TEXT DEFAULT '',
+        created_at DATETIME NOT NULL
+    );
+
+    -- Chunks waiting for their embedding during large indexing runs. Rows
+    -- are deleted at each checkpoint once their vectors are stored, so an
+    -- interrupted run resumes with what is left.
+    CREATE TABLE IF NOT EXISTS embedding_queue (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        chunk_id TEXT NOT NULL,
+        file_path TEXT NOT NULL,
+        text TEXT NOT NULL, -- what is embedded: the chunk with its embedding header
+        payload TEXT NOT NULL, -- JSON of the vector DB chunk
+        attempts INTEGER DEFAULT 0,
+        last_error TEXT DEFAULT '',
+        created_at DATETIME NOT NULL
+    );
+
+    CREATE TABLE IF NOT EXISTS collection_embeddings (
+        collection TEXT PRIMARY KEY,
+        model TEXT NOT NULL, -- embedding model the collection's vectors were built with
+        dimension INTEGER NOT NULL,
+        recorded_at DATETIME NOT NULL
+    );
+
+    CREATE TABLE IF NOT EXISTS cost_estimates (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        query_id TEXT NOT NULL,
+        provider TEXT NOT NULL,
+        heuristic_tokens INTEGER NOT NULL, -- before calibration
+        heuristic_cost REAL NOT NULL,
+        estimated_tokens INTEGER NOT NULL, -- as shown to the user
+        estimated_cost REAL NOT NULL,
+        actual_tokens INTEGER NOT NULL,
+        actual_cost REAL NOT NULL,
+        created_at DATETIME NOT NULL
+    );
+
+    CREATE TABLE IF NOT EXISTS skipped_files (
+        path TEXT PRIMARY KEY,
+        reason TEXT NOT NULL, -- binary, oversized, truncated or signatures
+        size INTEGER NOT NULL,
+        updated_at DATETIME NOT NULL
+    );
+
+    CREATE TABLE IF NOT EXISTS conversation_memory (
+        query_id TEXT PRIMARY KEY,
+        session_id TEXT,
+        question TEXT NOT NULL,
+        answer TEXT NOT NULL,
+        sources TEXT, -- JSON array of the files the answer cited
+        agent_used TEXT,
+        embedded INTEGER NOT NULL DEFAULT 0, -- 1 once in the memory collection
+        created_at DATETIME NOT NULL
+    );
+
+    -- Saved queries with $parameters, run with "run template <name> key=value"
+    CREATE TABLE IF NOT EXISTS query_templates (
+        project TEXT NOT NULL,
+        name TEXT NOT NULL COLLATE NOCASE,
+        query TEXT NOT NULL,
+        description TEXT DEFAULT '',
+        runs INTEGER NOT NULL DEFAULT 0,
+        last_run_at DATETIME,
+        created_at DATETIME NOT NULL,
+        updated_at DATETIME NOT NULL,
+        PRIMARY KEY (project, name)
+    );
+
+    -- Configuration snapshots answers were produced with, shared by
+    -- fingerprint, and the fingerprint of each answer
+    CREATE TABLE IF NOT EXISTS config_snapshots (
+        fingerprint TEXT PRIMARY KEY,
+        snapshot TEXT NOT NULL,
+        created_at DATETIME NOT NULL
+    );
+
+    CREATE TABLE IF NOT EXISTS response_fingerprints (
+        response_id TEXT PRIMARY KEY,
+        fingerprint TEXT NOT NULL,
+        created_at DATETIME NOT NULL
+    );
+
+    -- Findings of external analyzers (gopls, golangci-lint), replaced per
+    -- source each time its output is ingested
+    CREATE TABLE IF NOT EXISTS analyzer_diagnostics (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        source TEXT NOT NULL,
+        file TEXT NOT NULL, -- relative to the project root
+        line INTEGER NOT NULL,
+        col INTEGER DEFAULT 0,
+        end_line INTEGER DEFAULT 0,
+        severity TEXT NOT NULL, -- error, warning, info or hint
+        code TEXT DEFAULT '', -- the analyzer or linter and its rule
+        message TEXT NOT NULL,
+        function TEXT DEFAULT '', -- indexed function enclosing the line
+        ingested_at DATETIME NOT NULL
+    );
+
+    CREATE TABLE IF NOT EXISTS churn (
+        path TEXT NOT NULL, -- as the file's chunks are indexed under
+        start_line INTEGER NOT NULL, -- 0 for the whole file
+        end_line INTEGER NOT NULL,
+        commits INTEGER NOT NULL,
+        lines_changed INTEGER NOT NULL,
+        last_commit DATETIME,
+        score REAL NOT NULL, -- recency-weighted commit count when computed
+        computed_at DATETIME NOT NULL,
+        PRIMARY KEY (path, start_line, end_line)
+    );
+
     -- Create indexes for better performance
+    CREATE INDEX IF NOT EXISTS idx_conversation_memory_created ON conversation_memory(created_at);
+    CREATE INDEX IF NOT EXISTS idx_cost_estimates_provider ON cost_estimates(provider, created_at);
     CREATE INDEX IF NOT EXISTS idx_files_path ON files(path);
+    CREATE INDEX IF NOT EXISTS idx_keyword_chunks_path ON keyword_chunks(path);
+    CREATE INDEX IF NOT EXISTS idx_keyword_postings_ref ON keyword_postings(ref);
+    CREATE INDEX IF NOT EXISTS idx_user_cost_ledger_user ON user_cost_ledger(user_id, created_at);
+    CREATE INDEX IF NOT EXISTS idx_user_feedback_user ON user_feedback(user_id);
     CREATE INDEX IF NOT EXISTS idx_files_extension ON files(extension);
     CREATE INDEX IF NOT EXISTS idx_files_last_modified ON files(last_modified);
     CREATE INDEX IF NOT EXISTS idx_functions_file_id ON functions(file_id);
@@ -277,6 +590,15 @@ func (db *SQLiteDB) initSchema() error {
     CREATE INDEX IF NOT EXISTS idx_token_usage_session_id ON token_usage(session_id);
     CREATE INDEX IF NOT EXISTS idx_learning_patterns_session_id ON learning_patterns(session_id);
     CREATE INDEX IF NOT EXISTS idx_feedback_query_id ON feedback(query_id);
+    CREATE INDEX IF NOT EXISTS idx_index_generation_files_generation_id ON index_generation_files(generation_id);
+    CREATE INDEX IF NOT EXISTS idx_tasks_status ON tasks(status);
+    CREATE INDEX IF NOT EXISTS idx_todos_file ON todos(file);
+    CREATE INDEX IF NOT EXISTS idx_interfaces_name ON interfaces(name);
+    CREATE INDEX IF NOT EXISTS idx_implementations_interface ON implementations(interface_id);
+    CREATE INDEX IF NOT EXISTS idx_implementations_type ON implementations(type);
+    CREATE INDEX IF NOT EXISTS idx_provenance_file ON provenance(file);
+    CREATE INDEX IF NOT EXISTS idx_embedding_queue_file ON embedding_queue(file_path);
+    CREATE INDEX IF NOT EXISTS idx_analyzer_diagnostics_file ON analyzer_diagnostics(file);
 
     -- Create triggers for updated_at
     CREATE TRIGGER IF NOT EXISTS update_files_updated_at
@@ -304,20 +626,57 @@ func (db *SQLiteDB) initSchema() error {
         END;
     `
 
+	if err := db.migrateGlossary(); err != nil {
+		return fmt.Errorf("failed to migrate glossary: %w", err)
+	}
 	_, err := db.db.Exec(schema)
 	return err
 }
 
 // File operations
 
-// SaveFile saves or updates a code file
-func (db *SQLiteDB) SaveFile(file *CodeFile) error {
-	query := `
+// Indexing write statements, prepared once per database
+const (
+	saveFileQuery = `
     INSERT OR REPLACE INTO files 
     (path, name, extension, size, hash, language, content, last_modified, last_indexed, metadata)
     VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
-	_, err := db.db.Exec(query,
+	saveFunctionQuery = `
+    INSERT OR REPLACE INTO functions 
+    (file_id, name, signature, start_line, end_line, visibility, type, parameters, return_type, doc_string, complexity, last_indexed)
+    VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	fileIDQuery = `SELECT id FROM files WHERE path = ?`
+)
+
+// prepared returns a cached prepared statement for query
+func (db *SQLiteDB) prepared(query string) (*sql.Stmt, error) {
+	db.stmtMu.Lock()
+	defer db.stmtMu.Unlock()
+
+	if stmt, ok := db.stmts[query]; ok {
+		return stmt, nil
+	}
+	stmt, err := db.db.Prepare(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	db.stmts[query] = stmt
+	return stmt, nil
+}
+
+// SaveFile saves or updates a code file
+func (db *SQLiteDB) SaveFile(file *CodeFile) error {
+	stmt, err := db.prepared(saveFileQuery)
+	if err != nil {
+		return err
+	}
+	return execSaveFile(stmt, file)
+}
+
+func execSaveFile(stmt *sql.Stmt, file *CodeFile) error {
+	_, err := stmt.Exec(
 		file.Path, file.Name, file.Extension, file.Size, file.Hash,
 		file.Language, file.Content, file.LastModified, file.LastIndexed, file.Metadata)
 
@@ -372,17 +731,51 @@ func (db *SQLiteDB) GetFilesByExtension(extension string) ([]*CodeFile, error) {
 	return files, nil
 }
 
+// GetTestFiles retrieves indexed test files (not their chunks) whose path ends in suffix, e.g. "_test.go"
+func (db *SQLiteDB) GetTestFiles(suffix string) ([]*CodeFile, error) {
+	query := `SELECT id, path, name, extension, size, hash, language, content,
+              last_modified, last_indexed, COALESCE(metadata, '') FROM files
+              WHERE path LIKE '%' || ? AND path NOT LIKE '%#chunk_%' ORDER BY path`
+
+	rows, err := db.db.Query(query, suffix)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var files []*CodeFile
+	for rows.Next() {
+		var file CodeFile
+		err := rows.Scan(
+			&file.ID, &file.Path, &file.Name, &file.Extension, &file.Size,
+			&file.Hash, &file.Language, &file.Content, &file.LastModified,
+			&file.LastIndexed, &file.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		// LIKE treats _ as a wildcard, so confirm the suffix
+		if strings.HasSuffix(file.Path, suffix) {
+			files = append(files, &file)
+		}
+	}
+
+	return files, rows.Err()
+}
+
 // Function operations
 
 // SaveFunction saves or updates a function
 func (db *SQLiteDB) SaveFunction(function *CodeFunction) error {
 	// This method expects FileID to be already set correctly
-	query := `
-    INSERT OR REPLACE INTO functions 
-    (file_id, name, signature, start_line, end_line, visibility, type, parameters, return_type, doc_string, complexity, last_indexed)
-    VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	stmt, err := db.prepared(saveFunctionQuery)
+	if err != nil {
+		return err
+	}
+	return execSaveFunction(stmt, function)
+}
 
-	_, err := db.db.Exec(query,
+func execSaveFunction(stmt *sql.Stmt, function *CodeFunction) error {
+	_, err := stmt.Exec(
 		function.FileID, function.Name, function.Signature, function.StartLine, function.EndLine,
 		function.Visibility, function.Type, function.Parameters, function.ReturnType,
 		function.DocString, function.Complexity, time.Now())
@@ -467,6 +860,28 @@ func (db *SQLiteDB) SearchFunctions(namePattern string) ([]*CodeFunction, error)
 	return functions, nil
 }
 
+// GetFunctionFilePath returns the path of the file defining a function with
+// exactly this name, or "" when no indexed file defines it
+func (db *SQLiteDB) GetFunctionFilePath(name string) (string, error) {
+	query := `
+    SELECT fi.path
+    FROM functions f
+    JOIN files fi ON f.file_id = fi.id
+    WHERE f.name = ? AND fi.path NOT LIKE '%#chunk_%'
+    ORDER BY fi.path
+    LIMIT 1`
+
+	var path string
+	err := db.db.QueryRow(query, name).Scan(&path)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
 // Session operations
 
 // SaveSession saves session data
@@ -589,9 +1004,12 @@ func (db *SQLiteDB) getFileIDByPath(pathOrID interface{}) (int64, error) {
 		return 0, fmt.Errorf("invalid file identifier")
 	}
 
-	query := `SELECT id FROM files WHERE path = ?`
+	stmt, err := db.prepared(fileIDQuery)
+	if err != nil {
+		return 0, err
+	}
 	var id int64
-	err := db.db.QueryRow(query, path).Scan(&id)
+	err = stmt.QueryRow(path).Scan(&id)
 	return id, err
 }
 
@@ -636,8 +1054,35 @@ func (db *SQLiteDB) GetIndexedFiles() ([]string, error) {
 	return files, nil
 }
 
+// IndexedPaths returns the set of indexed file paths, without the logging
+// of GetIndexedFiles, for checks made while answering queries
+func (db *SQLiteDB) IndexedPaths() (map[string]bool, error) {
+	rows, err := db.db.Query(`SELECT path FROM files`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list indexed files: %w", err)
+	}
+	defer rows.Close()
+
+	paths := make(map[string]bool)
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return nil, fmt.Errorf("failed to scan indexed file: %w", err)
+		}
+		paths[path] = true
+	}
+	return paths, rows.Err()
+}
+
 // Close closes the database connection
 func (db *SQLiteDB) Close() error {
+	db.stmtMu.Lock()
+	for query, stmt := range db.stmts {
+		stmt.Close()
+		delete(db.stmts, query)
+	}
+	db.stmtMu.Unlock()
+
 	return db.db.Close()
 }
 
@@ -659,7 +1104,7 @@ func (db *SQLiteDB) SaveCodeFunction(function *CodeFunction) error {
 
 // StoreQuery stores a query and its metadata
 func (db *SQLiteDB) StoreQuery(query *models.Query) error {
-	contextJSON, _ := json.Marshal(query.Context)
+	contextJSON, _ := json.Marshal(query.Persisted().Context)
 	
 	_, err := db.db.Exec(`
 		INSERT INTO queries (id, user_input, language, context, timestamp, session_id)
@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// TaskRecord is the persisted state of a background task
+type TaskRecord struct {
+	ID          string    `json:"id"`
+	Kind        string    `json:"kind"`
+	Description string    `json:"description"`
+	Status      string    `json:"status"`
+	Input       string    `json:"input,omitempty"`      // JSON the task was started with
+	Checkpoint  string    `json:"checkpoint,omitempty"` // JSON the task resumes from
+	Done        int       `json:"done"`
+	Total       int       `json:"total"`
+	Message     string    `json:"message,omitempty"` // latest progress message
+	Result      string    `json:"result,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+const taskColumns = `id, kind, description, status, input, checkpoint, done, total, message, result, error, created_at, updated_at`
+
+// SaveTask inserts or updates a task
+func (db *SQLiteDB) SaveTask(task *TaskRecord) error {
+	task.UpdatedAt = time.Now()
+	if task.CreatedAt.IsZero() {
+		task.CreatedAt = task.UpdatedAt
+	}
+	_, err := db.db.Exec(`
+		INSERT INTO tasks (`+taskColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			status = excluded.status,
+			checkpoint = excluded.checkpoint,
+			done = excluded.done,
+			total = excluded.total,
+			message = excluded.message,
+			result = excluded.result,
+			error = excluded.error,
+			updated_at = excluded.updated_at`,
+		task.ID, task.Kind, task.Description, task.Status, task.Input, task.Checkpoint,
+		task.Done, task.Total, task.Message, task.Result, task.Error, task.CreatedAt, task.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save task %s: %w", task.ID, err)
+	}
+	return nil
+}
+
+// GetTask returns a task by ID, or nil if there is none
+func (db *SQLiteDB) GetTask(id string) (*TaskRecord, error) {
+	task, err := scanTask(db.db.QueryRow(`SELECT `+taskColumns+` FROM tasks WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read task %s: %w", id, err)
+	}
+	return task, nil
+}
+
+// ListTasks returns the most recently updated tasks first
+func (db *SQLiteDB) ListTasks(limit int) ([]*TaskRecord, error) {
+	rows, err := db.db.Query(`SELECT `+taskColumns+` FROM tasks ORDER BY updated_at DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %w", err)
+	}
+	defer rows.Close()
+
+	var tasks []*TaskRecord
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
+}
+
+// UpdateTaskStatus moves every task in one status to another and returns how
+// many changed. At startup it marks tasks left running by a crash.
+func (db *SQLiteDB) UpdateTaskStatus(from, to string) (int, error) {
+	result, err := db.db.Exec(`UPDATE tasks SET status = ?, updated_at = ? WHERE status = ?`, to, time.Now(), from)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update task status: %w", err)
+	}
+	changed, err := result.RowsAffected()
+	return int(changed), err
+}
+
+// scanTask reads a task row selected with taskColumns
+func scanTask(row interface{ Scan(...interface{}) error }) (*TaskRecord, error) {
+	task := &TaskRecord{}
+	var description, input, checkpoint, message, result, taskErr sql.NullString
+	err := row.Scan(&task.ID, &task.Kind, &description, &task.Status, &input, &checkpoint,
+		&task.Done, &task.Total, &message, &result, &taskErr, &task.CreatedAt, &task.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	task.Description = description.String
+	task.Input = input.String
+	task.Checkpoint = checkpoint.String
+	task.Message = message.String
+	task.Result = result.String
+	task.Error = taskErr.String
+	return task, nil
+}
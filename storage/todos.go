@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TodoRecord is a TODO, FIXME or HACK comment found while indexing
+type TodoRecord struct {
+	ID           int64     `json:"id"`
+	File         string    `json:"file"`
+	Line         int       `json:"line"`
+	Kind         string    `json:"kind"` // the marker: TODO, FIXME, HACK or XXX
+	Text         string    `json:"text"`
+	Package      string    `json:"package"`       // directory of the file
+	Context      string    `json:"context"`       // the surrounding lines, as in the file
+	ContextStart int       `json:"context_start"` // line number of the first context line
+	Author       string    `json:"author,omitempty"`
+	CommittedAt  time.Time `json:"committed_at,omitempty"` // when the line was last changed; zero if unknown
+	IndexedAt    time.Time `json:"indexed_at"`
+}
+
+// TodoFilter narrows ListTodos; zero fields match everything
+type TodoFilter struct {
+	Package string        // directory, including subdirectories
+	Kind    string        // marker, e.g. FIXME
+	Author  string        // substring of the author, case-insensitive
+	MinAge  time.Duration // only comments last changed at least this long ago
+	Limit   int
+}
+
+// ReplaceTodos replaces the comments recorded for file
+func (db *SQLiteDB) ReplaceTodos(file string, todos []*TodoRecord) error {
+	return db.update(func(tx *sql.Tx) error {
+		return replaceTodos(tx, file, todos)
+	})
+}
+
+// ReplaceTodos replaces the comments recorded for file in the current batch
+func (w *BatchWriter) ReplaceTodos(file string, todos []*TodoRecord) error {
+	return w.update(func(tx *sql.Tx) error {
+		return replaceTodos(tx, file, todos)
+	})
+}
+
+func replaceTodos(tx *sql.Tx, file string, todos []*TodoRecord) error {
+	if _, err := tx.Exec(`DELETE FROM todos WHERE file = ?`, file); err != nil {
+		return fmt.Errorf("failed to clear todos of %s: %w", file, err)
+	}
+	now := time.Now()
+	for _, todo := range todos {
+		var committedAt interface{}
+		if !todo.CommittedAt.IsZero() {
+			committedAt = todo.CommittedAt
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO todos (file, line, kind, text, package, context, context_start, author, committed_at, indexed_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			file, todo.Line, todo.Kind, todo.Text, todo.Package, todo.Context, todo.ContextStart, todo.Author, committedAt, now); err != nil {
+			return fmt.Errorf("failed to save todo at %s:%d: %w", file, todo.Line, err)
+		}
+	}
+	return nil
+}
+
+// ListTodos returns the recorded comments matching filter, oldest first;
+// comments of unknown age come last
+func (db *SQLiteDB) ListTodos(filter TodoFilter) ([]*TodoRecord, error) {
+	query := `SELECT id, file, line, kind, text, package, context, context_start, author, committed_at, indexed_at FROM todos WHERE 1 = 1`
+	var args []interface{}
+	if filter.Package != "" {
+		dir := strings.TrimSuffix(filter.Package, "/")
+		query += ` AND (package = ? OR package LIKE ?)`
+		args = append(args, dir, dir+"/%")
+	}
+	if filter.Kind != "" {
+		query += ` AND kind = ?`
+		args = append(args, strings.ToUpper(filter.Kind))
+	}
+	if filter.Author != "" {
+		query += ` AND author LIKE ?`
+		args = append(args, "%"+filter.Author+"%")
+	}
+	if filter.MinAge > 0 {
+		query += ` AND committed_at IS NOT NULL AND committed_at <= ?`
+		args = append(args, time.Now().Add(-filter.MinAge))
+	}
+	query += ` ORDER BY committed_at IS NULL, committed_at, file, line`
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(` LIMIT %d`, filter.Limit)
+	}
+
+	rows, err := db.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list todos: %w", err)
+	}
+	defer rows.Close()
+
+	var todos []*TodoRecord
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, todo)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list todos: %w", err)
+	}
+	return todos, nil
+}
+
+// GetTodo returns one recorded comment
+func (db *SQLiteDB) GetTodo(id int64) (*TodoRecord, error) {
+	row := db.db.QueryRow(`
+		SELECT id, file, line, kind, text, package, context, context_start, author, committed_at, indexed_at
+		FROM todos WHERE id = ?`, id)
+	todo, err := scanTodo(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("no todo %d", id)
+	}
+	return todo, err
+}
+
+// scanTodo reads a todos row
+func scanTodo(row interface{ Scan(...interface{}) error }) (*TodoRecord, error) {
+	var todo TodoRecord
+	var committedAt sql.NullTime
+	if err := row.Scan(&todo.ID, &todo.File, &todo.Line, &todo.Kind, &todo.Text, &todo.Package,
+		&todo.Context, &todo.ContextStart, &todo.Author, &committedAt, &todo.IndexedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan todo: %w", err)
+	}
+	todo.CommittedAt = committedAt.Time
+	return &todo, nil
+}
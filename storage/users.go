@@ -0,0 +1,305 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// SharedFeedbackUser is the feedback owner for users who pool feedback with the team
+const SharedFeedbackUser = "shared"
+
+// User is an account on a shared server instance
+type User struct {
+	ID               string    `json:"id"`
+	Name             string    `json:"name"`
+	TokenHash        string    `json:"-"`
+	DailyBudget      float64   `json:"daily_budget"`   // 0 means unlimited
+	MonthlyBudget    float64   `json:"monthly_budget"` // 0 means unlimited
+	PersonalFeedback bool      `json:"personal_feedback"`
+	Disabled         bool      `json:"disabled"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// CostEntry is one charge against a user's cost ledger
+type CostEntry struct {
+	UserID    string    `json:"user_id"`
+	QueryID   string    `json:"query_id"`
+	SessionID string    `json:"session_id"`
+	Provider  string    `json:"provider"`
+	Tokens    int       `json:"tokens"`
+	Cost      float64   `json:"cost"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// FeedbackStats aggregates the feedback recorded for one owner
+type FeedbackStats struct {
+	Owner         string  `json:"owner"`
+	Count         int     `json:"count"`
+	AverageRating float64 `json:"average_rating"`
+	HelpfulRate   float64 `json:"helpful_rate"`
+}
+
+// CreateUser stores a new user; the token must already be hashed
+func (db *SQLiteDB) CreateUser(user *User) error {
+	if user.CreatedAt.IsZero() {
+		user.CreatedAt = time.Now()
+	}
+	_, err := db.db.Exec(`
+		INSERT INTO users (id, name, token_hash, daily_budget, monthly_budget, personal_feedback, disabled, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		user.ID, user.Name, user.TokenHash, user.DailyBudget, user.MonthlyBudget,
+		user.PersonalFeedback, user.Disabled, user.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create user %s: %w", user.Name, err)
+	}
+	return nil
+}
+
+// GetUserByTokenHash returns the user owning a token hash, or nil if none does
+func (db *SQLiteDB) GetUserByTokenHash(tokenHash string) (*User, error) {
+	row := db.db.QueryRow(`
+		SELECT id, name, token_hash, daily_budget, monthly_budget, personal_feedback, disabled, created_at
+		FROM users WHERE token_hash = ?`, tokenHash)
+	user, err := scanUser(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up user: %w", err)
+	}
+	return user, nil
+}
+
+// ListUsers returns all users ordered by name
+func (db *SQLiteDB) ListUsers() ([]*User, error) {
+	rows, err := db.db.Query(`
+		SELECT id, name, token_hash, daily_budget, monthly_budget, personal_feedback, disabled, created_at
+		FROM users ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []*User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// SetUserDisabled enables or disables a user without deleting their ledger
+func (db *SQLiteDB) SetUserDisabled(name string, disabled bool) error {
+	result, err := db.db.Exec(`UPDATE users SET disabled = ? WHERE name = ?`, disabled, name)
+	if err != nil {
+		return fmt.Errorf("failed to update user %s: %w", name, err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("user %s not found", name)
+	}
+	return nil
+}
+
+// RecordUserCost appends a charge to a user's cost ledger
+func (db *SQLiteDB) RecordUserCost(entry *CostEntry) error {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	_, err := db.db.Exec(`
+		INSERT INTO user_cost_ledger (user_id, query_id, session_id, provider, tokens, cost, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		entry.UserID, entry.QueryID, entry.SessionID, entry.Provider, entry.Tokens, entry.Cost, entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record cost for user %s: %w", entry.UserID, err)
+	}
+	return nil
+}
+
+// GetUserSpend returns a user's total cost and tokens since the given time
+func (db *SQLiteDB) GetUserSpend(userID string, since time.Time) (float64, int, error) {
+	var cost float64
+	var tokens int
+	err := db.db.QueryRow(`
+		SELECT COALESCE(SUM(cost), 0), COALESCE(SUM(tokens), 0)
+		FROM user_cost_ledger WHERE user_id = ? AND created_at >= ?`, userID, since).
+		Scan(&cost, &tokens)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read spend for user %s: %w", userID, err)
+	}
+	return cost, tokens, nil
+}
+
+// SaveUserFeedback records feedback under an owner (a user ID or SharedFeedbackUser)
+func (db *SQLiteDB) SaveUserFeedback(owner, queryID string, rating int, helpful bool, comments string) error {
+	_, err := db.db.Exec(`
+		INSERT INTO user_feedback (user_id, query_id, rating, helpful, comments, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		owner, queryID, rating, helpful, comments, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to save feedback: %w", err)
+	}
+	return nil
+}
+
+// GetFeedbackStats aggregates the feedback recorded under an owner
+func (db *SQLiteDB) GetFeedbackStats(owner string) (*FeedbackStats, error) {
+	stats := &FeedbackStats{Owner: owner}
+	err := db.db.QueryRow(`
+		SELECT COUNT(*), COALESCE(AVG(rating), 0), COALESCE(AVG(CASE WHEN helpful THEN 1.0 ELSE 0.0 END), 0)
+		FROM user_feedback WHERE user_id = ?`, owner).
+		Scan(&stats.Count, &stats.AverageRating, &stats.HelpfulRate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read feedback stats: %w", err)
+	}
+	return stats, nil
+}
+
+// UserOwnsQuery reports whether a query was charged to the user's ledger
+func (db *SQLiteDB) UserOwnsQuery(userID, queryID string) (bool, error) {
+	var count int
+	err := db.db.QueryRow(`SELECT COUNT(*) FROM user_cost_ledger WHERE user_id = ? AND query_id = ?`,
+		userID, queryID).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to check query owner: %w", err)
+	}
+	return count > 0, nil
+}
+
+// QueryHistoryEntry is one charged query of a user with its answer
+type QueryHistoryEntry struct {
+	QueryID   string    `json:"query_id"`
+	SessionID string    `json:"session_id"`
+	Query     string    `json:"query"`
+	Answer    string    `json:"answer"`
+	Type      string    `json:"type"`
+	Agent     string    `json:"agent"`
+	Provider  string    `json:"provider"`
+	Tokens    int       `json:"tokens"`
+	Cost      float64   `json:"cost"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DailyCost is what a user spent with one provider on one day
+type DailyCost struct {
+	Day      string  `json:"day"` // YYYY-MM-DD
+	Provider string  `json:"provider"`
+	Queries  int     `json:"queries"`
+	Tokens   int     `json:"tokens"`
+	Cost     float64 `json:"cost"`
+}
+
+// AgentUsage is how many of a user's queries an agent answered, and their cost
+type AgentUsage struct {
+	Agent    string  `json:"agent"`
+	Provider string  `json:"provider"`
+	Queries  int     `json:"queries"`
+	Tokens   int     `json:"tokens"`
+	Cost     float64 `json:"cost"`
+}
+
+// UserQueryHistory returns a user's most recent charged queries, newest
+// first, with the stored query and answer where the agents recorded them
+func (db *SQLiteDB) UserQueryHistory(userID string, limit int) ([]*QueryHistoryEntry, error) {
+	rows, err := db.db.Query(`
+		SELECT l.query_id, COALESCE(l.session_id, ''), COALESCE(q.user_input, ''),
+		       COALESCE(r.content, ''), COALESCE(r.type, ''), COALESCE(r.agent_used, ''),
+		       COALESCE(l.provider, ''), l.tokens, l.cost, l.created_at
+		FROM user_cost_ledger l
+		LEFT JOIN queries q ON q.id = l.query_id
+		LEFT JOIN responses r ON r.query_id = l.query_id
+		WHERE l.user_id = ?
+		GROUP BY l.id
+		ORDER BY l.created_at DESC LIMIT ?`, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read query history for user %s: %w", userID, err)
+	}
+	defer rows.Close()
+
+	var entries []*QueryHistoryEntry
+	for rows.Next() {
+		entry := &QueryHistoryEntry{}
+		var content string
+		if err := rows.Scan(&entry.QueryID, &entry.SessionID, &entry.Query, &content, &entry.Type,
+			&entry.Agent, &entry.Provider, &entry.Tokens, &entry.Cost, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		var answer struct {
+			Text string `json:"text"`
+		}
+		if content != "" && json.Unmarshal([]byte(content), &answer) == nil {
+			entry.Answer = answer.Text
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// UserDailyCosts returns a user's spend per day and provider since the given
+// time, oldest first
+func (db *SQLiteDB) UserDailyCosts(userID string, since time.Time) ([]*DailyCost, error) {
+	rows, err := db.db.Query(`
+		SELECT strftime('%Y-%m-%d', created_at), COALESCE(provider, ''), COUNT(*), COALESCE(SUM(tokens), 0), COALESCE(SUM(cost), 0)
+		FROM user_cost_ledger WHERE user_id = ? AND created_at >= ?
+		GROUP BY 1, 2 ORDER BY 1, 2`, userID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read daily costs for user %s: %w", userID, err)
+	}
+	defer rows.Close()
+
+	var costs []*DailyCost
+	for rows.Next() {
+		cost := &DailyCost{}
+		if err := rows.Scan(&cost.Day, &cost.Provider, &cost.Queries, &cost.Tokens, &cost.Cost); err != nil {
+			return nil, err
+		}
+		costs = append(costs, cost)
+	}
+	return costs, rows.Err()
+}
+
+// UserAgentUsage returns which agents and providers answered a user's
+// queries since the given time, busiest first
+func (db *SQLiteDB) UserAgentUsage(userID string, since time.Time) ([]*AgentUsage, error) {
+	rows, err := db.db.Query(`
+		SELECT COALESCE(r.agent_used, ''), COALESCE(l.provider, ''), COUNT(DISTINCT l.id),
+		       COALESCE(SUM(l.tokens), 0), COALESCE(SUM(l.cost), 0)
+		FROM user_cost_ledger l
+		LEFT JOIN (SELECT query_id, MAX(agent_used) AS agent_used FROM responses GROUP BY query_id) r
+		       ON r.query_id = l.query_id
+		WHERE l.user_id = ? AND l.created_at >= ?
+		GROUP BY 1, 2 ORDER BY 3 DESC`, userID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read agent usage for user %s: %w", userID, err)
+	}
+	defer rows.Close()
+
+	var usage []*AgentUsage
+	for rows.Next() {
+		u := &AgentUsage{}
+		if err := rows.Scan(&u.Agent, &u.Provider, &u.Queries, &u.Tokens, &u.Cost); err != nil {
+			return nil, err
+		}
+		usage = append(usage, u)
+	}
+	return usage, rows.Err()
+}
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanUser(row rowScanner) (*User, error) {
+	user := &User{}
+	err := row.Scan(&user.ID, &user.Name, &user.TokenHash, &user.DailyBudget, &user.MonthlyBudget,
+		&user.PersonalFeedback, &user.Disabled, &user.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}